@@ -1,16 +1,294 @@
 package main
 
 import (
-	"log"
+	"context"
+	"flag"
+	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	accounthandler "ecommerce/internal/account/handler"
+	"ecommerce/internal/account/provider"
+	accountrepository "ecommerce/internal/account/repository"
+	accountservice "ecommerce/internal/account/service"
+	apikeyhandler "ecommerce/internal/apikey/handler"
+	apikeyrepository "ecommerce/internal/apikey/repository"
+	apikeyservice "ecommerce/internal/apikey/service"
+	"ecommerce/internal/gateway/cache"
+	"ecommerce/internal/gateway/config"
+	"ecommerce/internal/gateway/handler"
+	"ecommerce/internal/gateway/lockout"
+	"ecommerce/internal/gateway/proxy"
+	"ecommerce/internal/gateway/ratelimit"
+	productconfig "ecommerce/internal/product/config"
+	sessionhandler "ecommerce/internal/session/handler"
+	sessionrepository "ecommerce/internal/session/repository"
+	sessionservice "ecommerce/internal/session/service"
+	twofactorhandler "ecommerce/internal/twofactor/handler"
+	twofactorrepository "ecommerce/internal/twofactor/repository"
+	twofactorservice "ecommerce/internal/twofactor/service"
+	"ecommerce/pkg/auth"
+	sharedcache "ecommerce/pkg/cache"
+	"ecommerce/pkg/database"
+	"ecommerce/pkg/debug"
+	"ecommerce/pkg/logger"
+	"ecommerce/pkg/middleware"
+	"ecommerce/pkg/redis"
+	"ecommerce/pkg/requestlog"
+	"ecommerce/pkg/response"
 )
 
 func main() {
-	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-	})
-	http.HandleFunc("/ready", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-	})
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	flag.Parse()
+
+	// Load configuration first, so a log level set in the config file
+	// applies to the logger
+	cfg, cfgErr := config.Load()
+
+	// Initialize logger
+	logger := logger.NewLogger("api-gateway")
+	if cfgErr != nil {
+		logger.WithError(cfgErr).Fatal("Failed to load configuration")
+	}
+
+	// Print or validate the configuration and exit when asked to
+	if flag.Arg(0) == "config" {
+		if err := productconfig.Command(cfg, flag.Args()[1:], os.Stdout); err != nil {
+			logger.WithError(err).Fatal("Config command failed")
+		}
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		logger.WithError(err).Fatal("Invalid configuration")
+	}
+
+	// Load the certificate for mutual TLS between the services, if enabled
+	identity, err := cfg.MTLS.Identity(logger)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to load mTLS certificate")
+	}
+
+	// Initialize token verification
+	var verifier auth.Verifier
+	if cfg.Auth.JWKSURL != "" {
+		keys := auth.NewJWKS(cfg.Auth.JWKSURL, time.Duration(cfg.Auth.JWKSCacheTTL)*time.Second, time.Duration(cfg.Auth.JWKSTimeout)*time.Second)
+		verifier = auth.NewKeySetVerifier(keys, cfg.Auth.Issuer, cfg.Auth.Audience)
+	} else {
+		verifier = auth.NewHMACVerifier(cfg.Auth.JWTSecret)
+	}
+
+	// Initialize proxy
+	gatewayProxy, err := proxy.New(proxy.Routes(cfg.Services), cfg.Services, identity.ClientConfig(), logger)
+	if err != nil {
+		logger.Fatal("Failed to configure routes", err)
+	}
+
+	// Initialize database
+	db, err := database.NewPostgresConnection(cfg.Database)
+	if err != nil {
+		logger.Fatal("Failed to connect to database", err)
+	}
+	defer func() {
+		if err := database.Close(db); err != nil {
+			logger.Error("Failed to close database", err)
+		}
+	}()
+
+	// Initialize Redis
+	redisClient, err := redis.NewRedisClient(cfg.Redis)
+	if err != nil {
+		logger.Fatal("Failed to connect to Redis", err)
+	}
+	defer func() {
+		if err := redisClient.Close(); err != nil {
+			logger.Error("Failed to close Redis client", err)
+		}
+	}()
+
+	// Initialize the cache
+	cacheCodec, err := sharedcache.CodecByName(cfg.Redis.CacheCodec)
+	if err != nil {
+		logger.Fatal("Invalid CACHE_CODEC", err)
+	}
+	store := sharedcache.New(redisClient, sharedcache.Options{Codec: cacheCodec, Jitter: cfg.Redis.CacheJitter}, logger)
+
+	// Initialize API keys
+	apiKeyRepo := apikeyrepository.NewAPIKeyRepository(db, store, time.Duration(cfg.APIKeys.CacheTTL)*time.Second, logger)
+	apiKeyService := apikeyservice.NewAPIKeyService(apiKeyRepo, logger)
+
+	// Initialize session tracking
+	var sessionService sessionservice.SessionService
+	if cfg.Sessions.Enabled {
+		sessionRepo := sessionrepository.NewSessionRepository(redisClient)
+		sessionService = sessionservice.NewSessionService(sessionRepo, cfg.Sessions, logger)
+	}
+
+	// Initialize brute-force protection
+	var guard *lockout.Guard
+	var captcha lockout.Captcha
+	if cfg.Lockout.Enabled {
+		guard = lockout.New(redisClient, lockout.Policy{
+			Threshold:    cfg.Lockout.Threshold,
+			Decay:        time.Duration(cfg.Lockout.Decay) * time.Second,
+			Duration:     time.Duration(cfg.Lockout.Duration) * time.Second,
+			MaxDuration:  time.Duration(cfg.Lockout.MaxDuration) * time.Second,
+			Reset:        time.Duration(cfg.Lockout.Reset) * time.Second,
+			CaptchaAfter: cfg.Lockout.CaptchaAfter,
+		})
+		if cfg.Lockout.CaptchaURL != "" {
+			captcha = lockout.NewSiteVerify(cfg.Lockout.CaptchaURL, cfg.Lockout.CaptchaSecret, time.Duration(cfg.Lockout.CaptchaTimeout)*time.Second)
+		}
+	}
+
+	// Initialize two-factor authentication
+	var twoFactorService twofactorservice.TwoFactorService
+	if cfg.TwoFactor.Secret != "" {
+		twoFactorRepo := twofactorrepository.NewTwoFactorRepository(db)
+		twoFactorService, err = twofactorservice.NewTwoFactorService(twoFactorRepo, sessionService, guard, captcha, cfg.TwoFactor, logger)
+		if err != nil {
+			logger.Fatal("Failed to initialize two-factor authentication", err)
+		}
+	}
+
+	// Initialize sign-in with external identity providers
+	var accountService accountservice.AccountService
+	if cfg.Login.Enabled() {
+		providers, err := provider.New(cfg.Login)
+		if err != nil {
+			logger.Fatal("Failed to configure sign-in providers", err)
+		}
+		accountRepo := accountrepository.NewAccountRepository(db, redisClient)
+		accountService = accountservice.NewAccountService(accountRepo, providers, cfg.Login, cfg.Auth, logger)
+	}
+
+	// Initialize rate limiting
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimit.Enabled {
+		fallback, err := ratelimit.ParseLimit(cfg.RateLimit.Default)
+		if err != nil {
+			logger.Fatal("Invalid RATE_LIMIT_DEFAULT", err)
+		}
+		rules, err := ratelimit.ParseRules(cfg.RateLimit.Routes)
+		if err != nil {
+			logger.Fatal("Invalid RATE_LIMIT_ROUTES", err)
+		}
+
+		limiter = ratelimit.New(redisClient, rules, fallback)
+	}
+
+	// Initialize response caching
+	var responseCache *cache.Cache
+	if cfg.Cache.Enabled {
+		responseCache = cache.New(
+			store,
+			cfg.Cache.Routes,
+			cfg.Cache.Vary,
+			time.Duration(cfg.Cache.DefaultTTL)*time.Second,
+			time.Duration(cfg.Cache.MaxTTL)*time.Second,
+			cfg.Cache.MaxBody,
+		)
+	}
+
+	// Initialize handlers
+	httpHandler := handler.NewHTTPHandler(gatewayProxy, verifier, apiKeyService, sessionService, twoFactorService, limiter, guard, responseCache, cfg.Auth.IdentitySecret, logger)
+	apiKeyHandler := apikeyhandler.NewHTTPHandler(apiKeyService, logger)
+	var sessionHandler *sessionhandler.HTTPHandler
+	if sessionService != nil {
+		sessionHandler = sessionhandler.NewHTTPHandler(sessionService, logger)
+	}
+	var twoFactorHandler *twofactorhandler.HTTPHandler
+	if twoFactorService != nil {
+		twoFactorHandler = twofactorhandler.NewHTTPHandler(twoFactorService, logger)
+	}
+	var accountHandler *accounthandler.HTTPHandler
+	if accountService != nil {
+		accountHandler = accounthandler.NewHTTPHandler(accountService, logger)
+	}
+
+	// Setup HTTP server
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(requestlog.Middleware(cfg.Logger, logger))
+	router.Use(gin.Recovery())
+	router.Use(middleware.SecurityHeaders(cfg.HTTP.Headers()))
+	router.Use(response.Format(cfg.HTTP.ErrorFormat))
+	router.Use(cfg.HTTP.BodyLimit())
+	if err := router.SetTrustedProxies(cfg.RateLimit.TrustedProxies); err != nil {
+		logger.Fatal("Invalid TRUSTED_PROXIES", err)
+	}
+
+	// Register HTTP routes
+	httpHandler.RegisterRoutes(router)
+	apiKeyHandler.RegisterRoutes(router.Group("/api/v1", httpHandler.RequireUser))
+	if sessionHandler != nil {
+		sessionHandler.RegisterRoutes(router.Group("/api/v1", httpHandler.RequireUser))
+	}
+	if twoFactorHandler != nil {
+		twoFactorHandler.RegisterRoutes(router.Group("/api/v1", httpHandler.RequireUser))
+	}
+	if accountHandler != nil {
+		accountHandler.RegisterRoutes(router.Group("/api/v1"))
+	}
+
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%s", cfg.HTTP.Port),
+		Handler: router,
+	}
+
+	// Setup internal HTTP server for events from the services
+	internalRouter := gin.New()
+	internalRouter.Use(gin.Recovery())
+	internalRouter.Use(response.Format(cfg.HTTP.ErrorFormat))
+	internalRouter.Use(cfg.HTTP.BodyLimit())
+	httpHandler.RegisterInternalRoutes(internalRouter)
+
+	internalServer := &http.Server{
+		Addr:    fmt.Sprintf(":%s", cfg.Internal.Port),
+		Handler: internalRouter,
+	}
+
+	// Start HTTP server
+	go func() {
+		logger.Info(fmt.Sprintf("HTTP server listening on port %s", cfg.HTTP.Port))
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Fatal("Failed to start HTTP server", err)
+		}
+	}()
+
+	// Start internal HTTP server
+	go func() {
+		logger.Info(fmt.Sprintf("Internal HTTP server listening on port %s", cfg.Internal.Port))
+		if err := identity.ListenAndServe(internalServer); err != nil && err != http.ErrServerClosed {
+			logger.Fatal("Failed to start internal HTTP server", err)
+		}
+	}()
+
+	// Serve runtime diagnostics on the debug port, when one is configured
+	stopDebug := debug.Serve(cfg.Debug, logger)
+
+	// Wait for interrupt signal to gracefully shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	logger.Info("Shutting down servers...")
+
+	// Graceful shutdown with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		logger.Fatal("Server forced to shutdown", err)
+	}
+	if err := internalServer.Shutdown(ctx); err != nil {
+		logger.Fatal("Internal server forced to shutdown", err)
+	}
+	stopDebug(ctx)
+
+	logger.Info("Server exited")
 }
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// apiTimeout bounds each API call, except exports, which stream the whole
+// catalog
+const apiTimeout = 30 * time.Second
+
+// envelope is the services' JSON response, as pkg/response writes it
+type envelope struct {
+	Success bool            `json:"success"`
+	Code    string          `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+	Error   interface{}     `json:"error"`
+
+	// Problem details members, for services that send RFC 7807 errors
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+}
+
+// apiClient calls the service APIs through the gateway, authenticated with
+// a bearer token or an API key
+type apiClient struct {
+	baseURL string
+	token   string
+	apiKey  string
+	client  *http.Client
+}
+
+func newAPIClient(baseURL, token, apiKey string) *apiClient {
+	return &apiClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		apiKey:  apiKey,
+		client:  &http.Client{},
+	}
+}
+
+// do sends a JSON request and decodes the response data into out
+func (c *apiClient) do(ctx context.Context, method, path string, body, out interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	resp, err := c.send(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result envelope
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 16<<20)).Decode(&result); err != nil && resp.StatusCode < 300 {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		return statusError(method, path, resp.StatusCode, result)
+	}
+
+	if out != nil && len(result.Data) > 0 {
+		if err := json.Unmarshal(result.Data, out); err != nil {
+			return fmt.Errorf("failed to decode response data: %w", err)
+		}
+	}
+	return nil
+}
+
+// stream sends a GET request and copies the response body to w
+func (c *apiClient) stream(ctx context.Context, path string, w io.Writer) (int64, error) {
+	resp, err := c.send(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var result envelope
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result)
+		return 0, statusError(http.MethodGet, path, resp.StatusCode, result)
+	}
+
+	written, err := io.Copy(w, resp.Body)
+	if err != nil {
+		return written, fmt.Errorf("failed to read response: %w", err)
+	}
+	return written, nil
+}
+
+func (c *apiClient) send(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	switch {
+	case c.token != "":
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	case c.apiKey != "":
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s failed: %w", method, path, err)
+	}
+	return resp, nil
+}
+
+// statusError describes a failed call with the message the service gave
+func statusError(method, path string, status int, result envelope) error {
+	message := result.Message
+	if detail, ok := result.Error.(string); ok && detail != "" {
+		message = detail
+	} else if result.Detail != "" {
+		message = result.Detail
+	}
+	if message == "" {
+		message = result.Title
+	}
+	if message == "" {
+		message = http.StatusText(status)
+	}
+
+	if result.Code != "" {
+		return fmt.Errorf("%s %s: status %d (%s): %s", method, path, status, result.Code, message)
+	}
+	return fmt.Errorf("%s %s: status %d: %s", method, path, status, message)
+}
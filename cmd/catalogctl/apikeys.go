@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/spf13/cobra"
+
+	"ecommerce/internal/apikey/domain"
+)
+
+func newAPIKeysCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "api-keys",
+		Short: "Manage API keys",
+	}
+	cmd.AddCommand(newAPIKeysRotateCommand(a))
+	return cmd
+}
+
+// rotateResult reports a rotated key; the new secret is only set when the
+// key was rotated
+type rotateResult struct {
+	domain.IssuedKey
+	DryRun bool `json:"dry_run"`
+}
+
+func newAPIKeysRotateCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "rotate ID",
+		Short: "Replace an API key's secret",
+		Long: `Issue a new secret for an API key; the old one stops working. The new secret
+is shown once, so store it straight away. With --dry-run, show the key that
+would be rotated.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := "/api/v1/api-keys/" + url.PathEscape(args[0])
+
+			result := rotateResult{DryRun: a.dryRun}
+			var err error
+			if a.dryRun {
+				err = a.api().do(cmd.Context(), http.MethodGet, path, nil, &result.APIKey)
+			} else {
+				err = a.api().do(cmd.Context(), http.MethodPost, path+"/rotate", nil, &result.IssuedKey)
+			}
+			if err != nil {
+				return err
+			}
+
+			return a.print(result, func(w io.Writer) {
+				if result.DryRun {
+					fmt.Fprintf(w, "Would rotate key %s (%s, prefix %s, owner %s)\n", result.ID, result.Name, result.Prefix, result.OwnerID)
+					return
+				}
+				fmt.Fprintf(w, "Rotated key %s (%s)\n", result.ID, result.Name)
+				fmt.Fprintf(w, "New key: %s\n", result.Key)
+			})
+		},
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"ecommerce/internal/product/domain"
+)
+
+func newCacheCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the product service cache",
+	}
+	cmd.AddCommand(newCachePurgeCommand(a))
+	return cmd
+}
+
+// purgeResult reports a cache purge; a dry run lists the keys instead
+type purgeResult struct {
+	Deleted   int64    `json:"deleted"`
+	Keys      []string `json:"keys,omitempty"`
+	Truncated bool     `json:"truncated,omitempty"`
+	DryRun    bool     `json:"dry_run"`
+}
+
+func newCachePurgeCommand(a *app) *cobra.Command {
+	var pattern string
+
+	cmd := &cobra.Command{
+		Use:   "purge [KEY...]",
+		Short: "Drop cached keys by name or pattern",
+		Long: `Drop cached keys from the product service's cache, given by name, by a
+Redis glob pattern or both. With --dry-run, list the keys the pattern matches
+instead.`,
+		Example: "  catalogctl cache purge --pattern 'products:list:*'",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 && pattern == "" {
+				return fmt.Errorf("give keys to purge or a --pattern")
+			}
+
+			result, err := purgeCache(cmd, a, args, pattern)
+			if err != nil {
+				return err
+			}
+			return a.print(result, func(w io.Writer) {
+				if !result.DryRun {
+					fmt.Fprintf(w, "Deleted %d keys\n", result.Deleted)
+					return
+				}
+				for _, key := range result.Keys {
+					fmt.Fprintln(w, key)
+				}
+				if result.Truncated {
+					fmt.Fprintln(w, "(more keys match)")
+				}
+				fmt.Fprintf(w, "Would delete %d keys\n", result.Deleted)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&pattern, "pattern", "", "Redis glob pattern of the keys to drop")
+	return cmd
+}
+
+// purgeCache drops the keys, or with --dry-run lists the ones that exist
+func purgeCache(cmd *cobra.Command, a *app, keys []string, pattern string) (*purgeResult, error) {
+	ctx := cmd.Context()
+	client := a.api()
+
+	if !a.dryRun {
+		var purged domain.PurgeCacheResult
+		req := domain.PurgeCacheRequest{Keys: keys, Pattern: pattern}
+		if err := client.do(ctx, http.MethodPost, "/api/v1/cache/purge", req, &purged); err != nil {
+			return nil, err
+		}
+		return &purgeResult{Deleted: purged.Deleted}, nil
+	}
+
+	result := &purgeResult{DryRun: true}
+	for _, key := range keys {
+		var list domain.CacheKeyList
+		if err := client.do(ctx, http.MethodGet, "/api/v1/cache/keys?pattern="+url.QueryEscape(key), nil, &list); err != nil {
+			return nil, err
+		}
+		result.Keys = append(result.Keys, list.Keys...)
+	}
+	if pattern != "" {
+		var list domain.CacheKeyList
+		if err := client.do(ctx, http.MethodGet, "/api/v1/cache/keys?pattern="+url.QueryEscape(pattern), nil, &list); err != nil {
+			return nil, err
+		}
+		result.Keys = append(result.Keys, list.Keys...)
+		result.Truncated = list.Truncated
+	}
+	slices.Sort(result.Keys)
+	result.Keys = slices.Compact(result.Keys)
+	result.Deleted = int64(len(result.Keys))
+	return result, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"ecommerce/internal/product/domain"
+)
+
+func newCategoriesCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "categories",
+		Short: "Manage categories",
+	}
+	cmd.AddCommand(newCategoriesRecountCommand(a))
+	return cmd
+}
+
+// recountResult reports the product counts per category after a recount
+type recountResult struct {
+	Purged     int64             `json:"purged"`
+	Categories []domain.Category `json:"categories"`
+	DryRun     bool              `json:"dry_run"`
+}
+
+func newCategoriesRecountCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "recount",
+		Short: "Recompute the product counts per category",
+		Long: `Drop the cached product counts per category and list the categories again,
+so the counts are recomputed from the database. With --dry-run, show the
+counts as they are now.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			client := a.api()
+
+			result := recountResult{DryRun: a.dryRun}
+			if !a.dryRun {
+				var purged domain.PurgeCacheResult
+				req := domain.PurgeCacheRequest{Pattern: domain.CacheNamespaceCategoryCounts + "*"}
+				if err := client.do(ctx, http.MethodPost, "/api/v1/cache/purge", req, &purged); err != nil {
+					return err
+				}
+				result.Purged = purged.Deleted
+			}
+
+			if err := client.do(ctx, http.MethodGet, "/api/v1/categories?include_descendants=true", nil, &result.Categories); err != nil {
+				return err
+			}
+
+			return a.print(result, func(w io.Writer) {
+				table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(table, "CATEGORY\tPRODUCTS\tWITH SUBCATEGORIES")
+				for _, category := range result.Categories {
+					fmt.Fprintf(table, "%s\t%d\t%d\n", category.Name, count(category.ProductCount), count(category.TotalProductCount))
+				}
+				table.Flush()
+			})
+		},
+	}
+}
+
+// count reads an optional count, which is unset when zero
+func count(n *int64) int64 {
+	if n == nil {
+		return 0
+	}
+	return *n
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"ecommerce/internal/product/seed"
+)
+
+func newGenerateCommand(a *app) *cobra.Command {
+	var (
+		opts  seed.GenerateOptions
+		clean bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate a large catalog for load testing",
+		Long: `Generate products for load testing the list and search endpoints.
+
+Products are spread over the seeded categories and brands on Zipf
+distributions, and copied straight into Postgres. Their SKUs start with the
+prefix, so a run can be removed again with --clean. No events are published,
+so rebuild any search index afterwards.`,
+		Example: "  catalogctl generate --products 1000000",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Products < 0 {
+				return fmt.Errorf("--products must not be negative")
+			}
+
+			ctx := cmd.Context()
+			catalog, err := a.openCatalog(ctx)
+			if err != nil {
+				return err
+			}
+			defer catalog.close()
+
+			generator := seed.NewGenerator(catalog.repo, catalog.db, a.logger)
+			if clean {
+				deleted, err := generator.Clean(ctx, opts.Prefix)
+				if err != nil {
+					return err
+				}
+				a.logger.WithField("products", deleted).Info("Deleted generated products")
+			}
+
+			started := time.Now()
+			result, err := generator.Run(ctx, opts)
+			if err != nil {
+				return err
+			}
+
+			elapsed := time.Since(started)
+			a.logger.WithFields(logrus.Fields{
+				"categories": result.Categories,
+				"brands":     result.Brands,
+				"products":   result.Products,
+				"elapsed":    elapsed.Round(time.Millisecond).String(),
+				"per_second": int(float64(result.Products) / max(elapsed.Seconds(), 0.001)),
+			}).Info("Catalog generated successfully")
+
+			return a.print(result, func(w io.Writer) {
+				fmt.Fprintf(w, "Generated %d products in %d categories and %d brands\n", result.Products, result.Categories, result.Brands)
+			})
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.IntVar(&opts.Products, "products", 100000, "number of products to generate")
+	flags.Uint64Var(&opts.Seed, "seed", 1, "random seed; the same seed generates the same products")
+	flags.StringVar(&opts.Prefix, "prefix", seed.DefaultGeneratePrefix, "SKU prefix marking the generated products")
+	flags.BoolVar(&clean, "clean", false, "delete the products generated earlier with the prefix first")
+	return cmd
+}
@@ -0,0 +1,182 @@
+// Command catalogctl runs operational tasks against the product catalog.
+// Most commands call the service APIs through the gateway; generating data
+// and reindexing search work on the database directly, with the product
+// service's configuration. Results go to stdout as text or, with
+// --output json, as JSON; logs go to stderr.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+
+	"ecommerce/internal/product/config"
+	"ecommerce/internal/product/repository"
+	"ecommerce/migrations"
+	"ecommerce/pkg/cache"
+	"ecommerce/pkg/database"
+	"ecommerce/pkg/logger"
+	"ecommerce/pkg/migrate"
+	"ecommerce/pkg/redis"
+)
+
+func main() {
+	if err := newRootCommand().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// Output formats
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// app holds what the commands share
+type app struct {
+	cfg    *config.Config
+	logger *logrus.Logger
+	out    io.Writer
+
+	apiURL string
+	token  string
+	apiKey string
+	output string
+	dryRun bool
+}
+
+func newRootCommand() *cobra.Command {
+	a := &app{}
+
+	root := &cobra.Command{
+		Use:          "catalogctl",
+		Short:        "Operational tasks for the product catalog",
+		SilenceUsage: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if a.output != outputText && a.output != outputJSON {
+				return fmt.Errorf("--output must be %s or %s", outputText, outputJSON)
+			}
+			a.out = cmd.OutOrStdout()
+
+			// Load configuration first, so a log level set in the config
+			// file applies to the logger
+			cfg, err := config.Load()
+			a.logger = logger.NewLogger("catalogctl")
+			a.logger.SetOutput(cmd.ErrOrStderr())
+			if err != nil {
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+			a.cfg = cfg
+			return nil
+		},
+	}
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&a.apiURL, "api-url", getEnv("CATALOGCTL_API_URL", "http://localhost:8080"), "base URL of the API gateway")
+	flags.StringVar(&a.token, "token", os.Getenv("CATALOGCTL_TOKEN"), "bearer token to call the APIs with")
+	flags.StringVar(&a.apiKey, "api-key", os.Getenv("CATALOGCTL_API_KEY"), "API key to call the APIs with, when no token is given")
+	flags.StringVarP(&a.output, "output", "o", outputText, "output format: text or json")
+	flags.BoolVar(&a.dryRun, "dry-run", false, "show what a command would change without changing it")
+
+	root.AddCommand(
+		newGenerateCommand(a),
+		newSearchCommand(a),
+		newCacheCommand(a),
+		newCategoriesCommand(a),
+		newProductsCommand(a),
+		newAPIKeysCommand(a),
+		newWebhooksCommand(a),
+	)
+	return root
+}
+
+// api returns a client for the service APIs
+func (a *app) api() *apiClient {
+	return newAPIClient(a.apiURL, a.token, a.apiKey)
+}
+
+// print writes a command's result: as indented JSON with --output json, or
+// else with text
+func (a *app) print(result interface{}, text func(w io.Writer)) error {
+	if a.output == outputJSON {
+		encoder := json.NewEncoder(a.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+	text(a.out)
+	return nil
+}
+
+// getEnv gets an environment variable with a default value
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// catalog is a connection to the catalog's database and cache
+type catalog struct {
+	db    *gorm.DB
+	repo  repository.ProductRepository
+	close func()
+}
+
+// openCatalog connects to the database and Redis the product service uses,
+// and checks that the schema is up to date
+func (a *app) openCatalog(ctx context.Context) (*catalog, error) {
+	if err := errors.Join(config.ValidateEnv(a.cfg.Env), a.cfg.Database.Validate(a.cfg.Env)); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	db, err := database.NewPostgresConnection(a.cfg.Database)
+	if err != nil {
+		return nil, err
+	}
+	closeDB := func() {
+		if err := database.Close(db); err != nil {
+			a.logger.Error("Failed to close database", err)
+		}
+	}
+
+	migrator, err := migrate.New(db, migrations.FS, a.logger)
+	if err != nil {
+		closeDB()
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	if err := migrator.Check(ctx); err != nil {
+		closeDB()
+		return nil, fmt.Errorf("database schema is out of date; run product-service -migrate up: %w", err)
+	}
+
+	redisClient, err := redis.NewRedisClient(a.cfg.Redis)
+	if err != nil {
+		closeDB()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	codec, err := cache.CodecByName(a.cfg.Redis.CacheCodec)
+	if err != nil {
+		redisClient.Close()
+		closeDB()
+		return nil, fmt.Errorf("invalid CACHE_CODEC: %w", err)
+	}
+	store := cache.New(redisClient, cache.Options{Codec: codec, Jitter: a.cfg.Redis.CacheJitter}, a.logger)
+
+	return &catalog{
+		db:   db,
+		repo: repository.NewProductRepository(db, redisClient, store, nil, a.cfg.Redis, a.logger),
+		close: func() {
+			if err := redisClient.Close(); err != nil {
+				a.logger.Error("Failed to close Redis client", err)
+			}
+			closeDB()
+		},
+	}, nil
+}
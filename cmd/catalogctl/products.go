@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func newProductsCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "products",
+		Short: "Work with products",
+	}
+	cmd.AddCommand(newProductsExportCommand(a))
+	return cmd
+}
+
+// exportResult reports an export written to a file
+type exportResult struct {
+	File   string `json:"file"`
+	Format string `json:"format"`
+	Bytes  int64  `json:"bytes"`
+}
+
+func newProductsExportCommand(a *app) *cobra.Command {
+	var (
+		format   string
+		file     string
+		category string
+		status   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the catalog as CSV or JSON lines",
+		Long: `Stream the catalog export from the product service to stdout, or to a file
+with --file. Filters narrow the export as they do the product listing.`,
+		Example: "  catalogctl products export --format jsonl --file products.jsonl",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != "csv" && format != "jsonl" {
+				return fmt.Errorf("--format must be csv or jsonl")
+			}
+
+			query := url.Values{"format": {format}}
+			if category != "" {
+				query.Set("category_id", category)
+			}
+			if status != "" {
+				query.Set("status", status)
+			}
+			path := "/api/v1/products/export?" + query.Encode()
+
+			if file == "" {
+				if a.dryRun {
+					return fmt.Errorf("--dry-run needs --file, as the export is written to stdout")
+				}
+				_, err := a.api().stream(cmd.Context(), path, a.out)
+				return err
+			}
+
+			result := exportResult{File: file, Format: format}
+			if !a.dryRun {
+				f, err := os.Create(file)
+				if err != nil {
+					return fmt.Errorf("failed to create export file: %w", err)
+				}
+				result.Bytes, err = a.api().stream(cmd.Context(), path, f)
+				if closeErr := f.Close(); err == nil && closeErr != nil {
+					err = fmt.Errorf("failed to write export file: %w", closeErr)
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+			return a.print(result, func(w io.Writer) {
+				if a.dryRun {
+					fmt.Fprintf(w, "Would export %s from %s to %s\n", format, path, file)
+					return
+				}
+				fmt.Fprintf(w, "Exported %d bytes of %s to %s\n", result.Bytes, format, file)
+			})
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&format, "format", "csv", "export format: csv or jsonl")
+	flags.StringVarP(&file, "file", "f", "", "file to write the export to, instead of stdout")
+	flags.StringVar(&category, "category", "", "only export products in this category ID")
+	flags.StringVar(&status, "status", "", "only export products with this status")
+	return cmd
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/internal/product/search"
+)
+
+func newSearchCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "search",
+		Short: "Manage the search index",
+	}
+	cmd.AddCommand(newSearchReindexCommand(a))
+	return cmd
+}
+
+// reindexResult reports a search reindex
+type reindexResult struct {
+	Products int  `json:"products"`
+	DryRun   bool `json:"dry_run"`
+}
+
+func newSearchReindexCommand(a *app) *cobra.Command {
+	var batchSize int
+
+	cmd := &cobra.Command{
+		Use:   "reindex",
+		Short: "Rebuild the Elasticsearch index from the database",
+		Long: `Index every product in the database into Elasticsearch, creating the index
+when it is missing. Run it after a bulk load that published no events, such
+as generate. With --dry-run, only count the products that would be indexed.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if a.cfg.Search.Backend != search.BackendElasticsearch {
+				return fmt.Errorf("search backend is %q; reindexing needs %s", a.cfg.Search.Backend, search.BackendElasticsearch)
+			}
+			if batchSize <= 0 {
+				return fmt.Errorf("--batch-size must be positive")
+			}
+
+			ctx := cmd.Context()
+			catalog, err := a.openCatalog(ctx)
+			if err != nil {
+				return err
+			}
+			defer catalog.close()
+
+			result := reindexResult{DryRun: a.dryRun}
+			if a.dryRun {
+				err = catalog.repo.Iterate(ctx, &domain.ProductFilters{}, batchSize, func(batch []domain.Product) error {
+					result.Products += len(batch)
+					return nil
+				})
+			} else {
+				indexer := search.NewIndexer(search.NewElasticsearchSearcher(a.cfg.Search), catalog.repo, a.logger)
+				result.Products, err = indexer.Reindex(ctx, batchSize)
+			}
+			if err != nil {
+				return err
+			}
+
+			return a.print(result, func(w io.Writer) {
+				if result.DryRun {
+					fmt.Fprintf(w, "Would index %d products\n", result.Products)
+					return
+				}
+				fmt.Fprintf(w, "Indexed %d products\n", result.Products)
+			})
+		},
+	}
+
+	cmd.Flags().IntVar(&batchSize, "batch-size", 500, "products to load and index at a time")
+	return cmd
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/spf13/cobra"
+
+	"ecommerce/internal/webhook/domain"
+)
+
+func newWebhooksCommand(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "webhooks",
+		Short: "Manage webhook deliveries",
+	}
+	cmd.AddCommand(newWebhooksReplayCommand(a))
+	return cmd
+}
+
+// replayResult reports the deliveries a replay queued, or with --dry-run
+// the deliveries that would be replayed
+type replayResult struct {
+	Deliveries []domain.Delivery `json:"deliveries"`
+	DryRun     bool              `json:"dry_run"`
+}
+
+func newWebhooksReplayCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "replay DELIVERY_ID...",
+		Short: "Send webhook deliveries again",
+		Long: `Queue each delivery to be sent again as a new delivery of the same event.
+With --dry-run, show the deliveries that would be replayed.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := a.api()
+
+			result := replayResult{DryRun: a.dryRun}
+			for _, id := range args {
+				path := "/api/v1/webhooks/deliveries/" + url.PathEscape(id)
+
+				var delivery domain.Delivery
+				var err error
+				if a.dryRun {
+					err = client.do(cmd.Context(), http.MethodGet, path, nil, &delivery)
+				} else {
+					err = client.do(cmd.Context(), http.MethodPost, path+"/replay", nil, &delivery)
+				}
+				if err != nil {
+					return err
+				}
+				result.Deliveries = append(result.Deliveries, delivery)
+			}
+
+			return a.print(result, func(w io.Writer) {
+				for n, delivery := range result.Deliveries {
+					if result.DryRun {
+						fmt.Fprintf(w, "Would replay %s: %s event %s, %s after %d attempts\n",
+							delivery.ID, delivery.EventType, delivery.EventID, delivery.Status, delivery.Attempts)
+						continue
+					}
+					fmt.Fprintf(w, "Replayed %s as %s\n", args[n], delivery.ID)
+				}
+			})
+		},
+	}
+}
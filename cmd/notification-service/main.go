@@ -1,16 +1,175 @@
 package main
 
 import (
-	"log"
+	"context"
+	"flag"
+	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ecommerce/internal/notification/config"
+	"ecommerce/internal/notification/domain"
+	"ecommerce/internal/notification/handler"
+	"ecommerce/internal/notification/repository"
+	"ecommerce/internal/notification/sender"
+	"ecommerce/internal/notification/service"
+	productconfig "ecommerce/internal/product/config"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/database"
+	"ecommerce/pkg/debug"
+	"ecommerce/pkg/jobs"
+	"ecommerce/pkg/logger"
+	"ecommerce/pkg/middleware"
+	"ecommerce/pkg/requestlog"
+	"ecommerce/pkg/resilience"
+	"ecommerce/pkg/response"
+	"ecommerce/pkg/run"
 )
 
 func main() {
-	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-	})
-	http.HandleFunc("/ready", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-	})
-	log.Fatal(http.ListenAndServe(":8084", nil))
+	flag.Parse()
+
+	// Load configuration first, so a log level set in the config file
+	// applies to the logger
+	cfg, cfgErr := config.Load()
+
+	// Initialize logger
+	logger := logger.NewLogger("notification-service")
+	if cfgErr != nil {
+		logger.WithError(cfgErr).Fatal("Failed to load configuration")
+	}
+
+	// Print or validate the configuration and exit when asked to
+	if flag.Arg(0) == "config" {
+		if err := productconfig.Command(cfg, flag.Args()[1:], os.Stdout); err != nil {
+			logger.WithError(err).Fatal("Config command failed")
+		}
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		logger.WithError(err).Fatal("Invalid configuration")
+	}
+
+	// Load the certificate for mutual TLS between the services, if enabled
+	identity, err := cfg.MTLS.Identity(logger)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to load mTLS certificate")
+	}
+
+	// Initialize database
+	db, err := database.NewPostgresConnection(cfg.Database)
+	if err != nil {
+		logger.Fatal("Failed to connect to database", err)
+	}
+	defer func() {
+		if err := database.Close(db); err != nil {
+			logger.Error("Failed to close database", err)
+		}
+	}()
+
+	// Initialize senders
+	timeout := time.Duration(cfg.Delivery.Timeout) * time.Second
+
+	var emailSender sender.Sender
+	switch cfg.Email.Provider {
+	case sender.ProviderSendGrid:
+		sendGrid, err := sender.NewSendGrid(cfg.SendGrid, cfg.Email, timeout)
+		if err != nil {
+			logger.Fatal("Failed to configure SendGrid", err)
+		}
+		emailSender = sendGrid
+	case sender.ProviderSES:
+		emailSender = sender.NewSES(cfg.SES, cfg.Email, timeout)
+	case sender.ProviderLog:
+		emailSender = sender.NewLog(domain.ChannelEmail, logger)
+	default:
+		logger.Fatal(fmt.Sprintf("Unknown email provider %q", cfg.Email.Provider))
+	}
+
+	var smsSender sender.Sender
+	switch cfg.SMS.Provider {
+	case sender.ProviderTwilio:
+		smsSender = sender.NewTwilio(cfg.Twilio, timeout)
+	case sender.ProviderLog:
+		smsSender = sender.NewLog(domain.ChannelSMS, logger)
+	default:
+		logger.Fatal(fmt.Sprintf("Unknown SMS provider %q", cfg.SMS.Provider))
+	}
+
+	// Initialize repository
+	repo := repository.NewNotificationRepository(db, logger)
+
+	// Initialize service
+	notificationService := service.NewNotificationService(repo, database.NewTxManager(db), jobs.NewQueue(db), emailSender, smsSender, cfg.Delivery, cfg.Alerts, logger)
+
+	// Send queued notifications, including retries, as background jobs
+	jobWorker := jobs.NewWorker(db, jobs.Config{
+		Workers:      cfg.Jobs.Workers,
+		PollInterval: time.Duration(cfg.Jobs.PollInterval) * time.Millisecond,
+		Timeout:      time.Duration(cfg.Jobs.Timeout) * time.Second,
+		Retention:    time.Duration(cfg.Jobs.Retention) * time.Hour,
+	}, logger)
+	notificationService.RegisterJobs(jobWorker)
+
+	workers := run.NewGroup(logger)
+	workers.Add("jobs", jobWorker)
+
+	// Initialize handlers
+	httpHandler := handler.NewHTTPHandler(notificationService, logger)
+
+	// Setup HTTP server
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(requestlog.Middleware(cfg.Logger, logger))
+	router.Use(gin.Recovery())
+	router.Use(middleware.SecurityHeaders(cfg.HTTP.Headers()))
+	router.Use(resilience.Deadline(time.Duration(cfg.HTTP.RequestTimeout) * time.Second))
+	router.Use(response.Format(cfg.HTTP.ErrorFormat))
+	router.Use(cfg.HTTP.BodyLimit())
+	router.Use(auth.Middleware(cfg.Auth.JWTSecret, cfg.Auth.IdentitySecret))
+
+	// Register HTTP routes
+	httpHandler.RegisterRoutes(router)
+
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%s", cfg.HTTP.Port),
+		Handler: router,
+	}
+
+	// Start HTTP server
+	go func() {
+		logger.Info(fmt.Sprintf("HTTP server listening on port %s", cfg.HTTP.Port))
+		if err := identity.ListenAndServe(server); err != nil && err != http.ErrServerClosed {
+			logger.Fatal("Failed to start HTTP server", err)
+		}
+	}()
+
+	// Serve runtime diagnostics on the debug port, when one is configured
+	stopDebug := debug.Serve(cfg.Debug, logger)
+
+	// Wait for interrupt signal to gracefully shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	logger.Info("Shutting down servers...")
+
+	// Graceful shutdown with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		logger.Fatal("Server forced to shutdown", err)
+	}
+	stopDebug(ctx)
+
+	// Let in-flight deliveries finish recording their outcomes
+	workers.Stop(ctx)
+
+	logger.Info("Server exited")
 }
@@ -1,16 +1,215 @@
 package main
 
 import (
-	"log"
+	"context"
+	"flag"
+	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ecommerce/internal/order/client"
+	"ecommerce/internal/order/config"
+	"ecommerce/internal/order/handler"
+	"ecommerce/internal/order/invoice"
+	"ecommerce/internal/order/repository"
+	"ecommerce/internal/order/service"
+	productconfig "ecommerce/internal/product/config"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/database"
+	"ecommerce/pkg/debug"
+	"ecommerce/pkg/events"
+	"ecommerce/pkg/logger"
+	"ecommerce/pkg/middleware"
+	"ecommerce/pkg/redis"
+	"ecommerce/pkg/requestlog"
+	"ecommerce/pkg/resilience"
+	"ecommerce/pkg/response"
+	"ecommerce/pkg/run"
+	"ecommerce/pkg/schedule"
+	"ecommerce/pkg/storage"
+	"ecommerce/pkg/tenant"
 )
 
 func main() {
-	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-	})
-	http.HandleFunc("/ready", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
+	flag.Parse()
+
+	// Load configuration first, so a log level set in the config file
+	// applies to the logger
+	cfg, cfgErr := config.Load()
+
+	// Initialize logger
+	logger := logger.NewLogger("order-service")
+	if cfgErr != nil {
+		logger.WithError(cfgErr).Fatal("Failed to load configuration")
+	}
+
+	// Print or validate the configuration and exit when asked to
+	if flag.Arg(0) == "config" {
+		if err := productconfig.Command(cfg, flag.Args()[1:], os.Stdout); err != nil {
+			logger.WithError(err).Fatal("Config command failed")
+		}
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		logger.WithError(err).Fatal("Invalid configuration")
+	}
+
+	// Load the certificate for mutual TLS between the services, if enabled
+	identity, err := cfg.MTLS.Identity(logger)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to load mTLS certificate")
+	}
+
+	// Initialize database
+	db, err := database.NewPostgresConnection(cfg.Database)
+	if err != nil {
+		logger.Fatal("Failed to connect to database", err)
+	}
+	defer func() {
+		if err := database.Close(db); err != nil {
+			logger.Error("Failed to close database", err)
+		}
+	}()
+
+	// Initialize Redis, which holds the scheduler's leader lock
+	redisClient, err := redis.NewRedisClient(cfg.Redis)
+	if err != nil {
+		logger.Fatal("Failed to connect to Redis", err)
+	}
+	defer func() {
+		if err := redisClient.Close(); err != nil {
+			logger.Error("Failed to close Redis client", err)
+		}
+	}()
+
+	// Initialize repository
+	repo := repository.NewOrderRepository(db, logger)
+
+	// Initialize clients for the services checkout coordinates. Each
+	// service gets its own breaker, so one failing does not cut off the other.
+	policy := func() resilience.Policy {
+		return resilience.Policy{
+			Timeout: time.Duration(cfg.Services.Timeout) * time.Second,
+			Retry: resilience.RetryPolicy{
+				Attempts:  cfg.Services.RetryAttempts,
+				BaseDelay: 100 * time.Millisecond,
+				MaxDelay:  2 * time.Second,
+			},
+			Breaker: resilience.NewBreaker(cfg.Services.BreakerFailures, time.Duration(cfg.Services.BreakerOpenTimeout)*time.Second),
+		}
+	}
+	httpClient := identity.HTTPClient()
+	inventory := client.NewInventoryClient(cfg.Services.ProductURL, httpClient, policy())
+	payments := client.NewPaymentClient(cfg.Services.PaymentURL, httpClient, policy())
+	taxes := client.NewTaxClient(cfg.Services.TaxURL, httpClient, policy())
+
+	// Initialize background workers, stopped together at shutdown
+	workers := run.NewGroup(logger)
+
+	// Initialize event bus
+	bus := events.NewBus(logger, cfg.Events.BufferSize)
+	workers.Add("event bus", bus)
+	for _, url := range cfg.Events.ForwardURLs {
+		events.NewForwarder(url, identity.HTTPClient(), time.Duration(cfg.Events.ForwardTimeout)*time.Second, logger).Register(bus)
+	}
+
+	// Initialize invoice storage
+	var invoiceStorage *storage.S3
+	if cfg.Invoices.Bucket != "" {
+		invoiceStorage, err = storage.NewS3(storage.Config{
+			Endpoint:  cfg.Invoices.Endpoint,
+			Region:    cfg.Invoices.Region,
+			Bucket:    cfg.Invoices.Bucket,
+			AccessKey: cfg.Invoices.AccessKey,
+			SecretKey: cfg.Invoices.SecretKey,
+			PathStyle: cfg.Invoices.PathStyle,
+			Timeout:   time.Duration(cfg.Invoices.Timeout) * time.Second,
+		})
+		if err != nil {
+			logger.Fatal("Failed to configure invoice storage", err)
+		}
+	}
+	invoices, err := invoice.NewRenderer(cfg.Invoices)
+	if err != nil {
+		logger.Fatal("Failed to load invoice template", err)
+	}
+
+	// Initialize service
+	orderService := service.NewOrderService(repo, inventory, payments, taxes, bus, invoiceStorage, invoices, cfg.Checkout, cfg.Returns, cfg.Invoices, logger)
+
+	// Settle checkouts left behind by failed compensations or crashes,
+	// releasing the stock they reserved, on one replica at a time
+	scheduler := schedule.New(redisClient, "order-service", time.Duration(cfg.Schedule.LockTTL)*time.Second, logger)
+	err = scheduler.Add("checkout recovery", cfg.Checkout.RecoverySchedule, func(ctx context.Context) error {
+		recovered, err := orderService.RecoverCheckouts(ctx)
+		if recovered > 0 {
+			logger.WithField("count", recovered).Info("Recovered abandoned checkouts")
+		}
+		return err
 	})
-	log.Fatal(http.ListenAndServe(":8085", nil))
+	if err != nil {
+		logger.Fatal("Failed to schedule checkout recovery", err)
+	}
+	workers.Add("scheduler", scheduler)
+
+	// Initialize handlers
+	httpHandler := handler.NewHTTPHandler(orderService, logger)
+
+	// Setup HTTP server
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(requestlog.Middleware(cfg.Logger, logger))
+	router.Use(gin.Recovery())
+	router.Use(middleware.SecurityHeaders(cfg.HTTP.Headers()))
+	router.Use(resilience.Deadline(time.Duration(cfg.HTTP.RequestTimeout) * time.Second))
+	router.Use(response.Format(cfg.HTTP.ErrorFormat))
+	router.Use(cfg.HTTP.BodyLimit())
+	router.Use(auth.Middleware(cfg.Auth.JWTSecret, cfg.Auth.IdentitySecret))
+	router.Use(tenant.Middleware())
+
+	// Register HTTP routes
+	httpHandler.RegisterRoutes(router)
+
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%s", cfg.HTTP.Port),
+		Handler: router,
+	}
+
+	// Start HTTP server
+	go func() {
+		logger.Info(fmt.Sprintf("HTTP server listening on port %s", cfg.HTTP.Port))
+		if err := identity.ListenAndServe(server); err != nil && err != http.ErrServerClosed {
+			logger.Fatal("Failed to start HTTP server", err)
+		}
+	}()
+
+	// Serve runtime diagnostics on the debug port, when one is configured
+	stopDebug := debug.Serve(cfg.Debug, logger)
+
+	// Wait for interrupt signal to gracefully shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	logger.Info("Shutting down servers...")
+
+	// Graceful shutdown with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		logger.Fatal("Server forced to shutdown", err)
+	}
+	stopDebug(ctx)
+
+	// Let a recovery sweep finish, and the events it publishes be
+	// dispatched, before the connections close
+	workers.Stop(ctx)
+
+	logger.Info("Server exited")
 }
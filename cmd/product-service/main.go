@@ -2,30 +2,90 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	"ecommerce/internal/product/config"
+	"ecommerce/internal/product/feed"
+	"ecommerce/internal/product/flashsale"
 	"ecommerce/internal/product/handler"
+	"ecommerce/internal/product/importer"
+	"ecommerce/internal/product/live"
 	"ecommerce/internal/product/repository"
+	"ecommerce/internal/product/search"
+	"ecommerce/internal/product/seed"
 	"ecommerce/internal/product/service"
+	"ecommerce/internal/product/sitemap"
+	"ecommerce/internal/product/suggest"
+	"ecommerce/migrations"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/cache"
 	"ecommerce/pkg/database"
+	"ecommerce/pkg/debug"
+	"ecommerce/pkg/events"
+	"ecommerce/pkg/health"
+	"ecommerce/pkg/jobs"
+	"ecommerce/pkg/localcache"
 	"ecommerce/pkg/logger"
+	"ecommerce/pkg/media"
+	"ecommerce/pkg/middleware"
+	"ecommerce/pkg/migrate"
 	"ecommerce/pkg/redis"
+	"ecommerce/pkg/requestlog"
+	"ecommerce/pkg/resilience"
+	"ecommerce/pkg/response"
+	"ecommerce/pkg/run"
+	"ecommerce/pkg/schedule"
+	"ecommerce/pkg/storage"
+	"ecommerce/pkg/tenant"
 )
 
 func main() {
+	migrateCommand := flag.String("migrate", "", "run a migration command and exit: up, down, version or force <version>")
+	flag.Parse()
+
+	// Load configuration first, so a log level set in the config file
+	// applies to the logger
+	cfg, cfgErr := config.Load()
+
 	// Initialize logger
-	logger := logger.NewLogger()
+	logger := logger.NewLogger("product-service")
+	if cfgErr != nil {
+		logger.WithError(cfgErr).Fatal("Failed to load configuration")
+	}
 
-	// Load configuration
-	cfg := config.Load()
+	// Print or validate the configuration and exit when asked to
+	if flag.Arg(0) == "config" {
+		if err := config.Command(cfg, flag.Args()[1:], os.Stdout); err != nil {
+			logger.WithError(err).Fatal("Config command failed")
+		}
+		return
+	}
+	// Migrations only need the database, and run where the other settings
+	// may not be given
+	validate := cfg.Validate
+	if *migrateCommand != "" {
+		validate = func() error {
+			return errors.Join(config.ValidateEnv(cfg.Env), cfg.Database.Validate(cfg.Env))
+		}
+	}
+	if err := validate(); err != nil {
+		logger.WithError(err).Fatal("Invalid configuration")
+	}
 
 	// Initialize database
 	db, err := database.NewPostgresConnection(cfg.Database)
@@ -38,6 +98,37 @@ func main() {
 		}
 	}()
 
+	// Limit every query of a request to the tenant it is made for
+	if err := db.Use(tenant.Scope{}); err != nil {
+		logger.Fatal("Failed to scope the database to tenants", err)
+	}
+
+	// Apply or check schema migrations
+	migrator, err := migrate.New(db, migrations.FS, logger)
+	if err != nil {
+		logger.Fatal("Failed to load migrations", err)
+	}
+	if *migrateCommand != "" {
+		if err := runMigrations(migrator, *migrateCommand, flag.Args(), logger); err != nil {
+			logger.Fatal("Migration failed", err)
+		}
+		return
+	}
+	if cfg.Database.MigrateOnStart {
+		if _, err := migrator.Up(context.Background()); err != nil {
+			logger.Fatal("Failed to apply migrations", err)
+		}
+	}
+	if err := migrator.Check(context.Background()); err != nil {
+		logger.Fatal("Database schema is out of date; run product-service -migrate up", err)
+	}
+
+	// Load the certificate for mutual TLS between the services, if enabled
+	identity, err := cfg.MTLS.Identity(logger)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to load mTLS certificate")
+	}
+
 	// Initialize Redis
 	redisClient, err := redis.NewRedisClient(cfg.Redis)
 	if err != nil {
@@ -49,36 +140,317 @@ func main() {
 		}
 	}()
 
+	// Initialize the cache
+	cacheCodec, err := cache.CodecByName(cfg.Redis.CacheCodec)
+	if err != nil {
+		logger.Fatal("Invalid CACHE_CODEC", err)
+	}
+	store := cache.New(redisClient, cache.Options{Codec: cacheCodec, Jitter: cfg.Redis.CacheJitter}, logger)
+
+	// Initialize the local cache of hot products, dropping entries other
+	// replicas change
+	localCache := localcache.New(cfg.Cache.LocalSize, time.Duration(cfg.Cache.LocalTTL)*time.Second)
+	localCache.Broadcast(redisClient, "product:invalidations", logger)
+	defer localCache.Close()
+
 	// Initialize repository
-	repo := repository.NewProductRepository(db, redisClient, logger)
+	repo := repository.NewProductRepository(db, redisClient, store, localCache, cfg.Redis, logger)
+
+	// Seed the catalog and exit when asked to
+	if flag.Arg(0) == "seed" {
+		if err := runSeed(repo, flag.Args()[1:], logger); err != nil {
+			logger.Fatal("Seeding failed", err)
+		}
+		return
+	}
+
+	// Initialize readiness checks
+	checks := health.NewRegistry(time.Duration(cfg.Health.Timeout) * time.Second)
+	checks.Register("postgres", health.Postgres(db))
+	checks.Register("redis", health.Redis(redisClient))
+
+	// Initialize background workers, stopped together at shutdown
+	workers := run.NewGroup(logger)
+
+	// Initialize event bus
+	bus := events.NewBus(logger, cfg.Events.BufferSize)
+	workers.Add("event bus", bus)
+	for _, url := range cfg.Events.ForwardURLs {
+		events.NewForwarder(url, identity.HTTPClient(), time.Duration(cfg.Events.ForwardTimeout)*time.Second, logger).Register(bus)
+	}
+
+	// Initialize search backend
+	var searcher search.Searcher
+	switch cfg.Search.Backend {
+	case search.BackendElasticsearch:
+		esSearcher := search.NewElasticsearchSearcher(cfg.Search)
+		if err := esSearcher.EnsureIndex(context.Background()); err != nil {
+			logger.Fatal("Failed to prepare search index", err)
+		}
+		search.NewIndexer(esSearcher, repo, logger).Register(bus)
+		checks.Register("elasticsearch", esSearcher.Ping)
+		searcher = esSearcher
+	default:
+		searcher = search.NewPostgresSearcher(repo)
+	}
+	logger.Info(fmt.Sprintf("Using %s search backend", cfg.Search.Backend))
+
+	// Initialize background jobs, run by the replicas from the queue they
+	// share. Handlers are registered as their components are set up, and
+	// the workers started once they all are.
+	jobQueue := jobs.NewQueue(db)
+	jobWorker := jobs.NewWorker(db, jobs.Config{
+		Workers:      cfg.Jobs.Workers,
+		PollInterval: time.Duration(cfg.Jobs.PollInterval) * time.Millisecond,
+		Timeout:      time.Duration(cfg.Jobs.Timeout) * time.Second,
+		Retention:    time.Duration(cfg.Jobs.Retention) * time.Hour,
+	}, logger)
+
+	// Initialize imports
+	importer.New(repo, bus, logger, cfg.Import.BatchSize).Register(jobWorker)
+
+	// Initialize media storage
+	var mediaStorage *storage.S3
+	if cfg.Media.Bucket != "" {
+		mediaStorage, err = storage.NewS3(storage.Config{
+			Endpoint:  cfg.Media.Endpoint,
+			Region:    cfg.Media.Region,
+			Bucket:    cfg.Media.Bucket,
+			AccessKey: cfg.Media.AccessKey,
+			SecretKey: cfg.Media.SecretKey,
+			PathStyle: cfg.Media.PathStyle,
+			Timeout:   time.Duration(cfg.Media.Timeout) * time.Second,
+		})
+		if err != nil {
+			logger.Fatal("Failed to configure media storage", err)
+		}
+		checks.Register("s3", mediaStorage.Ping)
+	}
+
+	// Initialize image URL rewriting
+	imageURLs, err := media.NewBuilder(media.Config{
+		Mode:       cfg.Images.Mode,
+		BaseURL:    cfg.Images.BaseURL,
+		Origins:    cfg.Images.Origins,
+		Key:        cfg.Images.Key,
+		Salt:       cfg.Images.Salt,
+		Format:     cfg.Images.Format,
+		ThumbSize:  cfg.Images.ThumbSize,
+		MediumSize: cfg.Images.MediumSize,
+		FullSize:   cfg.Images.FullSize,
+	})
+	if err != nil {
+		logger.Fatal("Failed to configure image URLs", err)
+	}
+
+	// Initialize the Google Merchant feed, kept up to date from product events
+	var merchantFeed *feed.Generator
+	if cfg.Store.URL != "" {
+		merchantFeed = feed.New(repo, imageURLs, mediaStorage, cfg.Store, cfg.Feed, logger)
+		merchantFeed.Register(bus)
+		merchantFeed.RegisterJobs(jobWorker)
+	}
+
+	// Initialize the sitemap, marked stale by catalog events
+	var storeSitemap *sitemap.Generator
+	if cfg.Store.URL != "" {
+		storeSitemap = sitemap.New(repo, redisClient, mediaStorage, cfg.Store, cfg.Sitemap, logger)
+		storeSitemap.Register(bus)
+	}
+
+	// Initialize search suggestions, kept up to date from catalog events
+	suggester := suggest.New(repo, redisClient, cfg.Suggest, logger)
+	suggester.Register(bus)
+
+	// Initialize the stream of stock and price changes to storefront
+	// pages, shared between the replicas over Redis
+	liveUpdates := live.New(redisClient, cfg.Live, logger)
+	liveUpdates.Register(bus)
+	liveUpdates.Start()
 
 	// Initialize service
-	productService := service.NewProductService(repo, logger)
+	productService := service.NewProductService(repo, database.NewTxManager(db), searcher, bus, jobQueue, mediaStorage, imageURLs, flashsale.New(redisClient), cfg.Stock, cfg.Sale, cfg.Publish, cfg.FlashSale, cfg.Locale, cfg.Media, logger)
+	productService.RegisterJobs(jobWorker)
+	workers.Add("jobs", jobWorker)
+
+	// Report shortages that stock changes made outside the service left
+	// unreported, and stock that drifted from its ledger
+	workers.Every("stock check", time.Duration(cfg.Stock.CheckInterval)*time.Second, func(ctx context.Context) {
+		if _, err := productService.CheckLowStock(ctx); err != nil {
+			logger.WithError(err).Error("Low stock check failed")
+		}
+		drift, err := productService.CheckStockConsistency(ctx)
+		if err != nil {
+			logger.WithError(err).Error("Stock ledger check failed")
+		}
+		for _, d := range drift {
+			logger.WithFields(logrus.Fields{
+				"product_id": d.ProductID,
+				"stock":      d.Stock,
+				"ledger":     d.Ledger,
+			}).Warn("Product stock does not match its ledger")
+		}
+	})
+
+	// Publish drafts whose scheduled publish time has passed
+	workers.Every("scheduled publishing", time.Duration(cfg.Publish.CheckInterval)*time.Second, func(ctx context.Context) {
+		if _, err := productService.PublishDue(ctx); err != nil {
+			logger.WithError(err).Error("Scheduled publishing failed")
+		}
+	})
+
+	// Apply changesets whose scheduled time has passed
+	workers.Every("scheduled changesets", time.Duration(cfg.Publish.CheckInterval)*time.Second, func(ctx context.Context) {
+		if _, err := productService.ApplyDueChangesets(ctx); err != nil {
+			logger.WithError(err).Error("Scheduled changesets failed")
+		}
+	})
+
+	// Write the purchases taken in Redis for flash sales to Postgres, and
+	// close the sales that ended. Every replica syncs: each drops from the
+	// queue only the purchases it wrote, and purchases written twice are
+	// counted once.
+	workers.Every("flash sale sync", time.Duration(cfg.FlashSale.SyncInterval)*time.Second, func(ctx context.Context) {
+		if _, err := productService.SyncFlashSales(ctx); err != nil {
+			logger.WithError(err).Error("Flash sale sync failed")
+		}
+	})
+
+	// Remove abandoned uploads, and the images of products deleted longer
+	// ago than the retention period
+	workers.Every("media purge", time.Duration(cfg.Media.PurgeInterval)*time.Second, func(ctx context.Context) {
+		if _, err := productService.PurgeMedia(ctx); err != nil {
+			logger.WithError(err).Error("Media purge failed")
+		}
+	})
+
+	// Rebuild each replica's copy of the feed in full now and then
+	if merchantFeed != nil {
+		workers.Every("feed rebuild", time.Duration(cfg.Feed.RebuildInterval)*time.Second, func(ctx context.Context) {
+			if err := merchantFeed.Rebuild(tenant.WithID(ctx, tenant.Default)); err != nil {
+				logger.WithError(err).Error("Product feed rebuild failed")
+			}
+		})
+	}
+
+	// Run the recurring catalog tasks on cron schedules, on one replica at
+	// a time
+	scheduler := schedule.New(redisClient, "product-service", time.Duration(cfg.Schedule.LockTTL)*time.Second, logger)
+	scheduleErrs := []error{
+		// Switch prices as sale windows open and close
+		scheduler.Add("sale check", cfg.Sale.CheckSchedule, func(ctx context.Context) error {
+			_, err := productService.CheckSales(ctx)
+			return err
+		}),
+		// Keep the storefront's first listing pages cached
+		scheduler.Add("cache warm-up", cfg.Cache.WarmSchedule, func(ctx context.Context) error {
+			_, err := productService.WarmCatalog(tenant.WithID(ctx, tenant.Default), cfg.Cache.WarmPages)
+			return err
+		}),
+	}
+	if merchantFeed != nil && mediaStorage != nil {
+		// Upload the feed for Merchant Center to fetch, from whichever
+		// replica's job worker picks the export up
+		scheduleErrs = append(scheduleErrs, scheduler.Add("feed export", cfg.Feed.ExportSchedule, func(ctx context.Context) error {
+			_, err := jobQueue.Enqueue(tenant.WithID(ctx, tenant.Default), feed.ExportJobType, struct{}{}, jobs.Options{MaxAttempts: 1})
+			return err
+		}))
+	}
+	if storeSitemap != nil {
+		// Rebuild the sitemap after catalog changes
+		scheduleErrs = append(scheduleErrs, scheduler.Add("sitemap refresh", cfg.Sitemap.RefreshSchedule, func(ctx context.Context) error {
+			return storeSitemap.Refresh(tenant.WithID(ctx, tenant.Default))
+		}))
+	}
+	if err := errors.Join(scheduleErrs...); err != nil {
+		logger.Fatal("Failed to schedule catalog tasks", err)
+	}
+	workers.Add("scheduler", scheduler)
+
+	// Build the suggestion index when it is missing, and rebuild it now and
+	// then to correct any drift from missed events
+	workers.Every("suggestion refresh", time.Duration(cfg.Suggest.CheckInterval)*time.Second, func(ctx context.Context) {
+		if err := suggester.Refresh(tenant.WithID(ctx, tenant.Default)); err != nil {
+			logger.WithError(err).Error("Suggestion index refresh failed")
+		}
+	})
 
 	// Initialize handlers
-	httpHandler := handler.NewHTTPHandler(productService, logger)
+	httpHandler := handler.NewHTTPHandler(productService, merchantFeed, storeSitemap, suggester, liveUpdates, cfg, checks, logger)
 
 	// Setup HTTP server
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
+	router.Use(requestlog.Middleware(cfg.Logger, logger))
 	router.Use(gin.Recovery())
+	router.Use(middleware.SecurityHeaders(cfg.HTTP.Headers()))
+	// Exports and the feed read the whole catalog, and update streams stay
+	// open, so they run unbounded
+	router.Use(resilience.Deadline(time.Duration(cfg.HTTP.RequestTimeout)*time.Second, "/api/v1/products/export", "/api/v1/feeds/google-merchant.xml", "/api/v1/products/stream"))
+	router.Use(response.Format(cfg.HTTP.ErrorFormat))
+	router.Use(cfg.HTTP.BodyLimit())
+	router.Use(auth.Middleware(cfg.Auth.JWTSecret, cfg.Auth.IdentitySecret))
+	router.Use(tenant.Middleware())
 
 	// Register HTTP routes
 	httpHandler.RegisterRoutes(router)
 
+	// Serve the API defined in proto/product.proto over gRPC to the other
+	// services, and as JSON on the paths no HTTP route above serves
+	grpcHandler := handler.NewGRPCHandler(productService, logger)
+	transcoder, err := grpcHandler.Transcoder(context.Background())
+	if err != nil {
+		logger.Fatal("Failed to set up the transcoded API", err)
+	}
+	router.NoRoute(transcoder)
+
+	// Calls are identified as HTTP requests are, by the gateway's signed
+	// identity metadata or a bearer token
+	identifyUnary, identifyStream := handler.Identity(cfg.Auth.JWTSecret, cfg.Auth.IdentitySecret)
+	grpcOptions := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(identifyUnary),
+		grpc.ChainStreamInterceptor(identifyStream),
+	}
+	if identity != nil {
+		// No health probes call over gRPC, so every client needs a
+		// certificate
+		tlsConfig := identity.ServerConfig()
+		tlsConfig.ClientAuth = tls.RequireAnyClientCert
+		grpcOptions = append(grpcOptions, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	grpcServer := grpc.NewServer(grpcOptions...)
+	grpcHandler.Register(grpcServer)
+
 	server := &http.Server{
 		Addr:    fmt.Sprintf(":%s", cfg.HTTP.Port),
 		Handler: router,
 	}
+	// Streams never finish on their own, so end them as shutdown begins
+	server.RegisterOnShutdown(liveUpdates.Close)
 
 	// Start HTTP server
 	go func() {
 		logger.Info(fmt.Sprintf("HTTP server listening on port %s", cfg.HTTP.Port))
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := identity.ListenAndServe(server); err != nil && err != http.ErrServerClosed {
 			logger.Fatal("Failed to start HTTP server", err)
 		}
 	}()
 
+	// Start gRPC server
+	grpcListener, err := net.Listen("tcp", fmt.Sprintf(":%s", cfg.GRPC.Port))
+	if err != nil {
+		logger.Fatal("Failed to listen for gRPC", err)
+	}
+	go func() {
+		logger.Info(fmt.Sprintf("gRPC server listening on port %s", cfg.GRPC.Port))
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			logger.Fatal("Failed to start gRPC server", err)
+		}
+	}()
+
+	// Serve runtime diagnostics on the debug port, when one is configured
+	stopDebug := debug.Serve(cfg.Debug, logger)
+
 	// Wait for interrupt signal to gracefully shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -94,5 +466,85 @@ func main() {
 		logger.Fatal("Server forced to shutdown", err)
 	}
 
+	// Let calls in flight finish, cutting off those still running when
+	// the timeout passes
+	grpcStopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(grpcStopped)
+	}()
+	select {
+	case <-grpcStopped:
+	case <-ctx.Done():
+		grpcServer.Stop()
+	}
+	stopDebug(ctx)
+
+	// Let checks and queued imports finish, and the events they publish be
+	// dispatched, before the connections close
+	workers.Stop(ctx)
+
 	logger.Info("Server exited")
 }
+
+// runMigrations runs a -migrate command
+func runMigrations(migrator *migrate.Migrator, command string, args []string, logger *logrus.Logger) error {
+	ctx := context.Background()
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		logger.Info(fmt.Sprintf("Applied %d migrations, schema is at version %d", applied, migrator.Latest()))
+	case "down":
+		return migrator.Down(ctx)
+	case "version":
+		version, dirty, err := migrator.Version(ctx)
+		if err != nil {
+			return err
+		}
+		logger.Info(fmt.Sprintf("Schema is at version %d (dirty: %t), latest migration is %d", version, dirty, migrator.Latest()))
+	case "force":
+		if len(args) != 1 {
+			return fmt.Errorf("force needs a version")
+		}
+		version, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[0])
+		}
+		return migrator.Force(ctx, uint(version))
+	default:
+		return fmt.Errorf("unknown migrate command %q", command)
+	}
+	return nil
+}
+
+// runSeed runs the seed subcommand
+func runSeed(repo repository.ProductRepository, args []string, logger *logrus.Logger) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	products := flags.Int("products", 3000, "number of products to generate")
+	seedValue := flags.Uint64("seed", 1, "random seed; the same seed generates the same catalog")
+	upsert := flags.Bool("upsert", false, "overwrite seeded products that already exist instead of skipping them")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	result, err := seed.New(repo, logger).Run(context.Background(), seed.Options{
+		Products: *products,
+		Seed:     *seedValue,
+		Upsert:   *upsert,
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"categories": result.Categories,
+		"brands":     result.Brands,
+		"products":   result.Products,
+		"skipped":    result.Skipped,
+	}).Info("Catalog seeded successfully")
+	return nil
+}
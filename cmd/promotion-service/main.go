@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	productconfig "ecommerce/internal/product/config"
+	"ecommerce/internal/promotion/config"
+	"ecommerce/internal/promotion/handler"
+	"ecommerce/internal/promotion/repository"
+	"ecommerce/internal/promotion/service"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/database"
+	"ecommerce/pkg/debug"
+	"ecommerce/pkg/logger"
+	"ecommerce/pkg/middleware"
+	"ecommerce/pkg/requestlog"
+	"ecommerce/pkg/resilience"
+	"ecommerce/pkg/response"
+)
+
+func main() {
+	flag.Parse()
+
+	// Load configuration first, so a log level set in the config file
+	// applies to the logger
+	cfg, cfgErr := config.Load()
+
+	// Initialize logger
+	logger := logger.NewLogger("promotion-service")
+	if cfgErr != nil {
+		logger.WithError(cfgErr).Fatal("Failed to load configuration")
+	}
+
+	// Print or validate the configuration and exit when asked to
+	if flag.Arg(0) == "config" {
+		if err := productconfig.Command(cfg, flag.Args()[1:], os.Stdout); err != nil {
+			logger.WithError(err).Fatal("Config command failed")
+		}
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		logger.WithError(err).Fatal("Invalid configuration")
+	}
+
+	// Load the certificate for mutual TLS between the services, if enabled
+	identity, err := cfg.MTLS.Identity(logger)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to load mTLS certificate")
+	}
+
+	// Initialize database
+	db, err := database.NewPostgresConnection(cfg.Database)
+	if err != nil {
+		logger.Fatal("Failed to connect to database", err)
+	}
+	defer func() {
+		if err := database.Close(db); err != nil {
+			logger.Error("Failed to close database", err)
+		}
+	}()
+
+	// Initialize repository
+	repo := repository.NewPromotionRepository(db, logger)
+
+	// Initialize service
+	promotionService := service.NewPromotionService(repo, logger)
+
+	// Initialize handlers
+	httpHandler := handler.NewHTTPHandler(promotionService, logger)
+
+	// Setup HTTP server
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(requestlog.Middleware(cfg.Logger, logger))
+	router.Use(gin.Recovery())
+	router.Use(middleware.SecurityHeaders(cfg.HTTP.Headers()))
+	router.Use(resilience.Deadline(time.Duration(cfg.HTTP.RequestTimeout) * time.Second))
+	router.Use(response.Format(cfg.HTTP.ErrorFormat))
+	router.Use(cfg.HTTP.BodyLimit())
+	router.Use(auth.Middleware(cfg.Auth.JWTSecret, cfg.Auth.IdentitySecret))
+
+	// Register HTTP routes
+	httpHandler.RegisterRoutes(router)
+
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%s", cfg.HTTP.Port),
+		Handler: router,
+	}
+
+	// Start HTTP server
+	go func() {
+		logger.Info(fmt.Sprintf("HTTP server listening on port %s", cfg.HTTP.Port))
+		if err := identity.ListenAndServe(server); err != nil && err != http.ErrServerClosed {
+			logger.Fatal("Failed to start HTTP server", err)
+		}
+	}()
+
+	// Serve runtime diagnostics on the debug port, when one is configured
+	stopDebug := debug.Serve(cfg.Debug, logger)
+
+	// Wait for interrupt signal to gracefully shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	logger.Info("Shutting down servers...")
+
+	// Graceful shutdown with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		logger.Fatal("Server forced to shutdown", err)
+	}
+	stopDebug(ctx)
+
+	logger.Info("Server exited")
+}
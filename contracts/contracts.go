@@ -0,0 +1,16 @@
+// Package contracts embeds the contracts between the services. Each file
+// is written by a consumer and names the requests it makes of a provider
+// and the parts of the responses it relies on. Consumers test their
+// clients against a mock serving the contract, and providers replay it
+// against their handlers, so either side breaking it fails the build.
+//
+// product.proto is the gRPC schema as last published; the current schema
+// in proto/ may add to it but must not break it.
+package contracts
+
+import "embed"
+
+// FS holds the contract files
+//
+//go:embed *.json *.proto
+var FS embed.FS
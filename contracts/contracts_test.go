@@ -0,0 +1,39 @@
+package contracts_test
+
+import (
+	"io/fs"
+	"os"
+	"testing"
+
+	"ecommerce/contracts"
+	"ecommerce/pkg/contract"
+)
+
+func TestContractsLoad(t *testing.T) {
+	names, err := fs.Glob(contracts.FS, "*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		if _, err := contract.Load(contracts.FS, name); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// TestProtoCompatible fails when proto/product.proto breaks the published
+// schema. Once a breaking change has been rolled out to every client,
+// copy the new schema over contracts/product.proto.
+func TestProtoCompatible(t *testing.T) {
+	published, err := fs.ReadFile(contracts.FS, "product.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := os.ReadFile("../proto/product.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := contract.CheckProto(published, current); err != nil {
+		t.Error(err)
+	}
+}
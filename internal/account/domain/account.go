@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Account is a shopper who signs in through an external identity
+// provider, such as Google or Apple. Its ID is the subject of the tokens
+// the gateway issues for it.
+type Account struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Email     string    `json:"email" gorm:"not null"` // stored lower-cased; unique
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Identity links an account to the user a provider knows it as. An
+// account has one identity per provider it has signed in with.
+type Identity struct {
+	Provider  string    `json:"provider" gorm:"primaryKey"`
+	Subject   string    `json:"-" gorm:"primaryKey"` // the provider's ID for the user
+	AccountID uuid.UUID `json:"-" gorm:"type:uuid;not null;index"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ExternalUser is the user a provider's ID token vouches for
+type ExternalUser struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+// LoginState is what a sign-in needs to be completed once the provider
+// sends the user back: the PKCE verifier and nonce the request was made
+// with, and where the user was. It is kept until then under the state
+// parameter, which can only be used once.
+type LoginState struct {
+	Provider string `json:"provider"`
+	Verifier string `json:"verifier"`
+	Nonce    string `json:"nonce"`
+	ReturnTo string `json:"return_to"`
+}
+
+// Login is the outcome of a completed sign-in
+type Login struct {
+	Account     *Account `json:"account"`
+	AccessToken string   `json:"access_token"`
+	TokenType   string   `json:"token_type"`
+	ExpiresIn   int      `json:"expires_in"` // seconds
+	Created     bool     `json:"created"`    // whether the account is new
+	ReturnTo    string   `json:"-"`
+}
+
+// TableName returns the table name for Account
+func (Account) TableName() string {
+	return "accounts"
+}
+
+// TableName returns the table name for Identity
+func (Identity) TableName() string {
+	return "account_identities"
+}
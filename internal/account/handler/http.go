@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/account/service"
+	"ecommerce/pkg/database"
+	"ecommerce/pkg/errors"
+)
+
+// stateCookie ties a sign-in to the browser that started it, so a code
+// obtained by someone else cannot be completed in the user's browser
+const stateCookie = "login_state"
+
+// HTTPHandler handles HTTP requests for signing in with external identity
+// providers
+type HTTPHandler struct {
+	service service.AccountService
+	logger  *logrus.Logger
+}
+
+// NewHTTPHandler creates a new HTTP handler
+func NewHTTPHandler(service service.AccountService, logger *logrus.Logger) *HTTPHandler {
+	return &HTTPHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers the sign-in routes under api. They are reached
+// by browsers being sent to and back from the provider, without a token.
+func (h *HTTPHandler) RegisterRoutes(api *gin.RouterGroup) {
+	login := api.Group("/auth/:provider")
+	{
+		login.GET("/login", h.BeginLogin)
+		// Apple posts the code back as a form
+		login.GET("/callback", h.CompleteLogin)
+		login.POST("/callback", h.CompleteLogin)
+	}
+}
+
+// BeginLogin handles sending the user to a provider to sign in. The
+// return_to query parameter names the storefront page to come back to.
+func (h *HTTPHandler) BeginLogin(c *gin.Context) {
+	state, authURL, err := h.service.BeginLogin(c.Request.Context(), c.Param("provider"), c.Query("return_to"))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+
+	h.setStateCookie(c, state, 0)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// CompleteLogin handles the user coming back from a provider, sending them
+// on to the storefront with their token in the URL fragment, or the code
+// of the error that stopped them signing in
+func (h *HTTPHandler) CompleteLogin(c *gin.Context) {
+	if reason := c.Request.FormValue("error"); reason != "" {
+		h.redirect(c, "/", url.Values{"error": {reason}})
+		return
+	}
+
+	state := c.Request.FormValue("state")
+	cookie, _ := c.Cookie(stateCookie)
+	h.setStateCookie(c, "", -1)
+	if cookie == "" || subtle.ConstantTimeCompare([]byte(cookie), []byte(state)) != 1 {
+		h.redirect(c, "/", url.Values{"error": {errors.CodeLoginStateInvalid}})
+		return
+	}
+
+	login, err := h.service.CompleteLogin(c.Request.Context(), c.Param("provider"), state, c.Request.FormValue("code"))
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+
+	h.redirect(c, login.ReturnTo, url.Values{
+		"access_token": {login.AccessToken},
+		"token_type":   {login.TokenType},
+		"expires_in":   {strconv.Itoa(login.ExpiresIn)},
+	})
+}
+
+// setStateCookie sets or, with a negative maxAge, clears the state cookie.
+// Apple posts back from its own site, so the cookie must be sent on
+// cross-site requests.
+func (h *HTTPHandler) setStateCookie(c *gin.Context, state string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     stateCookie,
+		Value:    state,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteNoneMode,
+	})
+}
+
+// redirect sends the user to a storefront page, with params in the
+// fragment so they are not sent to any server
+func (h *HTTPHandler) redirect(c *gin.Context, returnTo string, params url.Values) {
+	c.Redirect(http.StatusFound, h.service.CompleteURL(returnTo)+"#"+params.Encode())
+}
+
+// fail sends the user back to the storefront with the code of the error
+// that stopped them signing in
+func (h *HTTPHandler) fail(c *gin.Context, err error) {
+	err = database.TranslateError(err)
+	code := errors.Code(err)
+	if errors.IsInternal(err) || code == "" {
+		h.logger.WithError(err).Error("Sign-in failed")
+		code = "server_error"
+	}
+	h.redirect(c, "/", url.Values{"error": {code}})
+}
@@ -0,0 +1,34 @@
+package provider
+
+import (
+	"net/http"
+	"time"
+
+	"ecommerce/internal/gateway/config"
+	"ecommerce/pkg/auth"
+)
+
+// Google's OpenID Connect endpoints
+const (
+	googleAuthURL  = "https://accounts.google.com/o/oauth2/v2/auth"
+	googleTokenURL = "https://oauth2.googleapis.com/token"
+	googleKeysURL  = "https://www.googleapis.com/oauth2/v3/certs"
+)
+
+// NewGoogle creates the provider signing users in with Google
+func NewGoogle(cfg config.GoogleLoginConfig, redirectURL string, timeout time.Duration) Provider {
+	keys := auth.NewJWKS(googleKeysURL, time.Hour, timeout)
+	return &oidc{
+		name:        "google",
+		authURL:     googleAuthURL,
+		tokenURL:    googleTokenURL,
+		issuers:     []string{"https://accounts.google.com", "accounts.google.com"},
+		clientID:    cfg.ClientID,
+		secret:      func() (string, error) { return cfg.ClientSecret, nil },
+		redirectURL: redirectURL,
+		scope:       "openid email",
+		pkce:        true,
+		verifier:    auth.NewKeySetVerifier(keys, "", cfg.ClientID),
+		client:      &http.Client{Timeout: timeout},
+	}
+}
@@ -0,0 +1,200 @@
+// Package provider signs users in with external OpenID Connect identity
+// providers. Each provider sends the user back with an authorization code,
+// which is exchanged for an ID token vouching for who they are.
+package provider
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"ecommerce/internal/account/domain"
+	"ecommerce/internal/gateway/config"
+	"ecommerce/pkg/auth"
+)
+
+// ErrRejected is returned when a provider turns down a code, or sends back
+// an ID token that cannot be trusted
+var ErrRejected = errors.New("sign-in rejected")
+
+// Provider is an identity provider users sign in with
+type Provider interface {
+	// Name identifies the provider in the login routes
+	Name() string
+	// AuthURL returns where to send the user to sign in. The state comes
+	// back with the user; the nonce in the ID token, and the challenge
+	// derives from the PKCE verifier Exchange is given.
+	AuthURL(state, nonce, challenge string) string
+	// Exchange trades the code the user came back with for the user the
+	// provider vouches for
+	Exchange(ctx context.Context, code, verifier, nonce string) (*domain.ExternalUser, error)
+}
+
+// New returns the providers the configuration enables
+func New(cfg config.LoginConfig) ([]Provider, error) {
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	var providers []Provider
+	if cfg.Google.ClientID != "" {
+		providers = append(providers, NewGoogle(cfg.Google, callbackURL(cfg, "google"), timeout))
+	}
+	if cfg.Apple.ClientID != "" {
+		apple, err := NewApple(cfg.Apple, callbackURL(cfg, "apple"), timeout)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, apple)
+	}
+	return providers, nil
+}
+
+// callbackURL returns the URL a provider sends users back to
+func callbackURL(cfg config.LoginConfig, name string) string {
+	return strings.TrimSuffix(cfg.CallbackURL, "/") + "/" + name + "/callback"
+}
+
+// oidc is a provider following OpenID Connect's authorization code flow
+type oidc struct {
+	name        string
+	authURL     string
+	tokenURL    string
+	issuers     []string // Google issues tokens under two
+	clientID    string
+	secret      func() (string, error)
+	redirectURL string
+	scope       string
+	params      url.Values // added to the authorization request
+	pkce        bool
+	verifier    auth.Verifier
+	client      *http.Client
+}
+
+func (p *oidc) Name() string {
+	return p.name
+}
+
+func (p *oidc) AuthURL(state, nonce, challenge string) string {
+	params := url.Values{
+		"response_type": {"code"},
+		"client_id":     {p.clientID},
+		"redirect_uri":  {p.redirectURL},
+		"scope":         {p.scope},
+		"state":         {state},
+		"nonce":         {nonce},
+	}
+	if p.pkce {
+		params.Set("code_challenge", challenge)
+		params.Set("code_challenge_method", "S256")
+	}
+	for name, values := range p.params {
+		params[name] = values
+	}
+	return p.authURL + "?" + params.Encode()
+}
+
+func (p *oidc) Exchange(ctx context.Context, code, verifier, nonce string) (*domain.ExternalUser, error) {
+	secret, err := p.secret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to make client secret: %w", err)
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.redirectURL},
+		"client_id":     {p.clientID},
+		"client_secret": {secret},
+	}
+	if p.pkce {
+		form.Set("code_verifier", verifier)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var token struct {
+		IDToken string `json:"id_token"`
+		Error   string `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&token); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	switch {
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return nil, fmt.Errorf("token endpoint returned status %d", resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("%w: %s", ErrRejected, token.Error)
+	case token.IDToken == "":
+		return nil, fmt.Errorf("%w: no ID token", ErrRejected)
+	}
+
+	return p.user(ctx, token.IDToken, nonce)
+}
+
+// user verifies an ID token and returns the user it names
+func (p *oidc) user(ctx context.Context, idToken, nonce string) (*domain.ExternalUser, error) {
+	claims, err := p.verifier.Verify(ctx, idToken)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidToken) || errors.Is(err, auth.ErrTokenExpired) {
+			return nil, fmt.Errorf("%w: %v", ErrRejected, err)
+		}
+		return nil, err
+	}
+	if !slices.Contains(p.issuers, claims.Issuer) || claims.Subject == "" {
+		return nil, fmt.Errorf("%w: unexpected issuer or subject", ErrRejected)
+	}
+
+	// The signature is checked, so the claims the verifier does not know
+	// can be read from the payload
+	var extra struct {
+		Nonce         string    `json:"nonce"`
+		EmailVerified claimBool `json:"email_verified"`
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.Split(idToken, ".")[1])
+	if err != nil || json.Unmarshal(payload, &extra) != nil {
+		return nil, fmt.Errorf("%w: invalid ID token", ErrRejected)
+	}
+	if subtle.ConstantTimeCompare([]byte(extra.Nonce), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrRejected)
+	}
+
+	return &domain.ExternalUser{
+		Provider:      p.name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(extra.EmailVerified),
+	}, nil
+}
+
+// claimBool is a boolean claim, which Apple sends as a string
+type claimBool bool
+
+func (b *claimBool) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case bool:
+		*b = claimBool(v)
+	case string:
+		*b = claimBool(v == "true")
+	}
+	return nil
+}
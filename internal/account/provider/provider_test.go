@@ -0,0 +1,154 @@
+package provider
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ecommerce/pkg/auth"
+)
+
+const (
+	testIssuer   = "https://id.example.com"
+	testClientID = "client-1"
+	testNonce    = "nonce-1"
+)
+
+// keySet holds the one key the test provider signs with
+type keySet struct {
+	key crypto.PublicKey
+}
+
+func (k keySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	if kid != "test" {
+		return nil, auth.ErrUnknownKey
+	}
+	return k.key, nil
+}
+
+// signIDToken returns an RS256 ID token carrying claims
+func signIDToken(t *testing.T, key *rsa.PrivateKey, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "test", "typ": "JWT"})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// TestExchangeVerifiesIDToken checks that only ID tokens issued by the
+// provider for this client, in force and for this sign-in, are trusted
+func TestExchangeVerifiesIDToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss":            testIssuer,
+			"aud":            testClientID,
+			"sub":            "user-1",
+			"email":          "Shopper@example.com",
+			"email_verified": true,
+			"nonce":          testNonce,
+			"iat":            now.Unix(),
+			"exp":            now.Add(time.Hour).Unix(),
+		}
+	}
+
+	tests := []struct {
+		name     string
+		change   func(claims map[string]interface{})
+		rejected bool
+		verified bool
+	}{
+		{name: "valid", change: func(map[string]interface{}) {}, verified: true},
+		{name: "verified as a string", change: func(c map[string]interface{}) { c["email_verified"] = "true" }, verified: true},
+		{name: "unverified email", change: func(c map[string]interface{}) { c["email_verified"] = false }},
+		{name: "wrong audience", change: func(c map[string]interface{}) { c["aud"] = "other-client" }, rejected: true},
+		{name: "wrong issuer", change: func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" }, rejected: true},
+		{name: "expired", change: func(c map[string]interface{}) { c["exp"] = now.Add(-time.Hour).Unix() }, rejected: true},
+		{name: "no expiry", change: func(c map[string]interface{}) { delete(c, "exp") }, rejected: true},
+		{name: "other sign-in", change: func(c map[string]interface{}) { c["nonce"] = "nonce-2" }, rejected: true},
+		{name: "no subject", change: func(c map[string]interface{}) { delete(c, "sub") }, rejected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := valid()
+			tt.change(claims)
+			idToken := signIDToken(t, key, claims)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.FormValue("code") != "code-1" || r.FormValue("client_id") != testClientID {
+					w.WriteHeader(http.StatusBadRequest)
+					json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]string{"id_token": idToken})
+			}))
+			defer server.Close()
+
+			p := &oidc{
+				name:     "test",
+				tokenURL: server.URL,
+				issuers:  []string{testIssuer},
+				clientID: testClientID,
+				secret:   func() (string, error) { return "secret", nil },
+				verifier: auth.NewKeySetVerifier(keySet{key: &key.PublicKey}, "", testClientID),
+				client:   server.Client(),
+			}
+
+			user, err := p.Exchange(context.Background(), "code-1", "verifier", testNonce)
+			if tt.rejected {
+				if !errors.Is(err, ErrRejected) {
+					t.Fatalf("expected rejection, got %v, %+v", err, user)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Exchange: %v", err)
+			}
+			if user.Subject != "user-1" || user.Email != "Shopper@example.com" || user.EmailVerified != tt.verified {
+				t.Fatalf("unexpected user %+v", user)
+			}
+		})
+	}
+}
+
+// TestExchangeRejectedCode checks that a code the provider turns down
+// rejects the sign-in
+func TestExchangeRejectedCode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+	}))
+	defer server.Close()
+
+	p := &oidc{
+		name:     "test",
+		tokenURL: server.URL,
+		clientID: testClientID,
+		secret:   func() (string, error) { return "secret", nil },
+		client:   server.Client(),
+	}
+	if _, err := p.Exchange(context.Background(), "code-1", "verifier", testNonce); !errors.Is(err, ErrRejected) {
+		t.Fatalf("expected rejection, got %v", err)
+	}
+}
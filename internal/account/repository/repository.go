@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"ecommerce/internal/account/domain"
+)
+
+// stateKeyPrefix namespaces sign-ins in progress in Redis, each a JSON
+// string under login:state:<state>
+const stateKeyPrefix = "login:state:"
+
+// AccountRepository defines the interface for account data operations
+type AccountRepository interface {
+	// Link returns the account of an external user, linking their identity
+	// to the account with their email or creating one, and reports
+	// whether the account was created. Callers must only pass users whose
+	// email the provider verified.
+	Link(ctx context.Context, user *domain.ExternalUser) (*domain.Account, bool, error)
+
+	SaveState(ctx context.Context, state string, login *domain.LoginState, ttl time.Duration) error
+	// TakeState returns a sign-in in progress and forgets it, so its state
+	// is only used once. It returns nil when the state is unknown.
+	TakeState(ctx context.Context, state string) (*domain.LoginState, error)
+}
+
+type accountRepository struct {
+	db     *gorm.DB
+	client redis.UniversalClient
+}
+
+// NewAccountRepository creates a new account repository
+func NewAccountRepository(db *gorm.DB, client redis.UniversalClient) AccountRepository {
+	return &accountRepository{db: db, client: client}
+}
+
+func (r *accountRepository) Link(ctx context.Context, user *domain.ExternalUser) (*domain.Account, bool, error) {
+	var account domain.Account
+	var created bool
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var identity domain.Identity
+		err := tx.Where("provider = ? AND subject = ?", user.Provider, user.Subject).Limit(1).Find(&identity).Error
+		if err != nil {
+			return fmt.Errorf("failed to get identity: %w", err)
+		}
+		if identity.AccountID != uuid.Nil {
+			if err := tx.First(&account, "id = ?", identity.AccountID).Error; err != nil {
+				return fmt.Errorf("failed to get account: %w", err)
+			}
+			return nil
+		}
+
+		// Sign-ins racing to create the account or the identity both end
+		// up with the one created first
+		account = domain.Account{Email: strings.ToLower(user.Email)}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&account)
+		if result.Error != nil {
+			return fmt.Errorf("failed to create account: %w", result.Error)
+		}
+		created = result.RowsAffected > 0
+		if !created {
+			if err := tx.First(&account, "email = ?", strings.ToLower(user.Email)).Error; err != nil {
+				return fmt.Errorf("failed to get account: %w", err)
+			}
+		}
+
+		identity = domain.Identity{
+			Provider:  user.Provider,
+			Subject:   user.Subject,
+			AccountID: account.ID,
+			Email:     user.Email,
+		}
+		result = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&identity)
+		if result.Error != nil {
+			return fmt.Errorf("failed to link identity: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			if err := tx.First(&identity, "provider = ? AND subject = ?", user.Provider, user.Subject).Error; err != nil {
+				return fmt.Errorf("failed to get identity: %w", err)
+			}
+			if err := tx.First(&account, "id = ?", identity.AccountID).Error; err != nil {
+				return fmt.Errorf("failed to get account: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &account, created, nil
+}
+
+func (r *accountRepository) SaveState(ctx context.Context, state string, login *domain.LoginState, ttl time.Duration) error {
+	data, err := json.Marshal(login)
+	if err != nil {
+		return fmt.Errorf("failed to encode sign-in: %w", err)
+	}
+	if err := r.client.Set(ctx, stateKeyPrefix+state, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save sign-in: %w", err)
+	}
+	return nil
+}
+
+func (r *accountRepository) TakeState(ctx context.Context, state string) (*domain.LoginState, error) {
+	data, err := r.client.GetDel(ctx, stateKeyPrefix+state).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sign-in: %w", err)
+	}
+
+	var login domain.LoginState
+	if err := json.Unmarshal(data, &login); err != nil {
+		return nil, fmt.Errorf("failed to decode sign-in: %w", err)
+	}
+	return &login, nil
+}
@@ -0,0 +1,79 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"ecommerce/internal/account/domain"
+	"ecommerce/internal/account/repository"
+)
+
+// TestLink checks how sign-ins are linked to accounts against Postgres,
+// when TEST_DATABASE_URL and TEST_REDIS_ADDR point at instances the tests
+// may write to. The schema is expected to be migrated.
+func TestLink(t *testing.T) {
+	dsn, addr := os.Getenv("TEST_DATABASE_URL"), os.Getenv("TEST_REDIS_ADDR")
+	if dsn == "" || addr == "" {
+		t.Skip("TEST_DATABASE_URL and TEST_REDIS_ADDR are not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to connect to Postgres: %v", err)
+	}
+	redisClient := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { redisClient.Close() })
+	repo := repository.NewAccountRepository(db, redisClient)
+	ctx := context.Background()
+
+	// A fresh address per run keeps runs apart
+	email := uuid.NewString() + "@Example.com"
+	google := &domain.ExternalUser{Provider: "google", Subject: uuid.NewString(), Email: email, EmailVerified: true}
+
+	account, created, err := repo.Link(ctx, google)
+	if err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+	if !created {
+		t.Fatal("first sign-in did not create an account")
+	}
+
+	again, created, err := repo.Link(ctx, google)
+	if err != nil {
+		t.Fatalf("Link again: %v", err)
+	}
+	if created || again.ID != account.ID {
+		t.Fatalf("second sign-in reached %s (created %v), want %s", again.ID, created, account.ID)
+	}
+
+	// Another provider vouching for the same address, in another case,
+	// reaches the same account
+	apple := &domain.ExternalUser{Provider: "apple", Subject: uuid.NewString(), Email: email, EmailVerified: true}
+	linked, created, err := repo.Link(ctx, apple)
+	if err != nil {
+		t.Fatalf("Link apple: %v", err)
+	}
+	if created || linked.ID != account.ID {
+		t.Fatalf("apple sign-in reached %s (created %v), want %s", linked.ID, created, account.ID)
+	}
+
+	// The identity stays linked to its account when the provider later
+	// reports another address for it
+	apple.Email = "changed-" + email
+	relinked, created, err := repo.Link(ctx, apple)
+	if err != nil {
+		t.Fatalf("Link changed email: %v", err)
+	}
+	if created || relinked.ID != account.ID {
+		t.Fatalf("changed email reached %s (created %v), want %s", relinked.ID, created, account.ID)
+	}
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/account/domain"
+	"ecommerce/internal/account/provider"
+	"ecommerce/internal/account/repository"
+	"ecommerce/internal/gateway/config"
+	"ecommerce/pkg/auth"
+	customErrors "ecommerce/pkg/errors"
+	"ecommerce/pkg/logger"
+)
+
+// AccountService defines the interface for signing in with external
+// identity providers
+type AccountService interface {
+	// BeginLogin starts signing in with a provider and returns the state
+	// identifying the sign-in and the URL to send the user to. The user
+	// is sent back to returnTo, a path on the storefront, once signed in.
+	BeginLogin(ctx context.Context, providerName, returnTo string) (state string, authURL string, err error)
+	// CompleteLogin exchanges the code a provider sent the user back with
+	// and issues a token for their account
+	CompleteLogin(ctx context.Context, providerName, state, code string) (*domain.Login, error)
+
+	// CompleteURL returns the storefront URL a user is sent back to
+	CompleteURL(returnTo string) string
+}
+
+type accountService struct {
+	repo        repository.AccountRepository
+	providers   map[string]provider.Provider
+	secret      []byte // signs the tokens issued
+	issuer      string
+	completeURL string
+	tokenTTL    time.Duration
+	stateTTL    time.Duration
+	logger      *logrus.Logger
+}
+
+// NewAccountService creates a new account service. Tokens are issued as
+// HS256 tokens signed with the secret the gateway verifies them with.
+func NewAccountService(repo repository.AccountRepository, providers []provider.Provider, cfg config.LoginConfig, authCfg config.AuthConfig, logger *logrus.Logger) AccountService {
+	byName := make(map[string]provider.Provider, len(providers))
+	for _, p := range providers {
+		byName[p.Name()] = p
+	}
+
+	return &accountService{
+		repo:        repo,
+		providers:   byName,
+		secret:      []byte(authCfg.JWTSecret),
+		issuer:      authCfg.Issuer,
+		completeURL: strings.TrimSuffix(cfg.CompleteURL, "/"),
+		tokenTTL:    time.Duration(cfg.TokenTTL) * time.Second,
+		stateTTL:    time.Duration(cfg.StateTTL) * time.Second,
+		logger:      logger,
+	}
+}
+
+// log returns the logger of the request ctx belongs to
+func (s *accountService) log(ctx context.Context) *logrus.Entry {
+	return logger.FromContext(ctx, s.logger)
+}
+
+func (s *accountService) BeginLogin(ctx context.Context, providerName, returnTo string) (string, string, error) {
+	p, err := s.provider(providerName)
+	if err != nil {
+		return "", "", err
+	}
+	if !localPath(returnTo) {
+		returnTo = "/"
+	}
+
+	var tokens [3]string
+	for i := range tokens {
+		if tokens[i], err = randomToken(); err != nil {
+			return "", "", customErrors.NewInternalError("Failed to start sign-in", err)
+		}
+	}
+	state, nonce, verifier := tokens[0], tokens[1], tokens[2]
+	login := &domain.LoginState{
+		Provider: p.Name(),
+		Verifier: verifier,
+		Nonce:    nonce,
+		ReturnTo: returnTo,
+	}
+	if err := s.repo.SaveState(ctx, state, login, s.stateTTL); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to save sign-in")
+		return "", "", customErrors.NewUnavailableError("Failed to start sign-in", err)
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	return state, p.AuthURL(state, nonce, base64.RawURLEncoding.EncodeToString(challenge[:])), nil
+}
+
+func (s *accountService) CompleteLogin(ctx context.Context, providerName, state, code string) (*domain.Login, error) {
+	p, err := s.provider(providerName)
+	if err != nil {
+		return nil, err
+	}
+	if state == "" || code == "" {
+		return nil, customErrors.NewValidationError("Missing state or code", nil).WithCode(customErrors.CodeLoginStateInvalid)
+	}
+
+	login, err := s.repo.TakeState(ctx, state)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to get sign-in")
+		return nil, customErrors.NewUnavailableError("Failed to complete sign-in", err)
+	}
+	if login == nil || login.Provider != p.Name() {
+		return nil, customErrors.NewUnauthorizedError("Sign-in expired or already completed", nil).WithCode(customErrors.CodeLoginStateInvalid)
+	}
+
+	user, err := p.Exchange(ctx, code, login.Verifier, login.Nonce)
+	if err != nil {
+		if errors.Is(err, provider.ErrRejected) {
+			s.log(ctx).WithError(err).WithField("provider", p.Name()).Warn("Sign-in rejected")
+			return nil, customErrors.NewUnauthorizedError("Sign-in rejected by the provider", err).WithCode(customErrors.CodeLoginRejected)
+		}
+		s.log(ctx).WithError(err).WithField("provider", p.Name()).Error("Failed to exchange sign-in code")
+		return nil, customErrors.NewUnavailableError("Identity provider unavailable", err)
+	}
+	// Accounts are linked by email, so only an address the provider
+	// vouches for may claim one
+	if user.Email == "" || !user.EmailVerified {
+		return nil, customErrors.NewForbiddenError("The provider has not verified your email address", nil).WithCode(customErrors.CodeLoginEmailUnverified)
+	}
+
+	account, created, err := s.repo.Link(ctx, user)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to link account")
+		return nil, customErrors.NewInternalError("Failed to complete sign-in", err)
+	}
+
+	token, err := s.issue(account)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to issue token")
+		return nil, customErrors.NewInternalError("Failed to complete sign-in", err)
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"account_id": account.ID,
+		"provider":   p.Name(),
+		"created":    created,
+	}).Info("Signed in successfully")
+
+	return &domain.Login{
+		Account:     account,
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(s.tokenTTL.Seconds()),
+		Created:     created,
+		ReturnTo:    login.ReturnTo,
+	}, nil
+}
+
+func (s *accountService) CompleteURL(returnTo string) string {
+	if !localPath(returnTo) {
+		returnTo = "/"
+	}
+	return s.completeURL + returnTo
+}
+
+// issue signs a token for an account. Each sign-in starts a session of
+// its own, which the gateway tracks like those of an identity provider.
+func (s *accountService) issue(account *domain.Account) (string, error) {
+	session, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	return auth.SignToken(&auth.Claims{
+		Subject:   account.ID.String(),
+		Email:     account.Email,
+		SessionID: session,
+		Issuer:    s.issuer,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(s.tokenTTL).Unix(),
+	}, s.secret)
+}
+
+// provider returns the provider with a name
+func (s *accountService) provider(name string) (provider.Provider, error) {
+	p, ok := s.providers[name]
+	if !ok {
+		return nil, customErrors.NewNotFoundError("Sign-in provider not found", nil).WithCode(customErrors.CodeLoginProviderNotFound)
+	}
+	return p, nil
+}
+
+// localPath reports whether a return path stays on the storefront, so the
+// sign-in cannot be used to send users elsewhere
+func localPath(path string) bool {
+	return strings.HasPrefix(path, "/") && !strings.HasPrefix(path, "//") && !strings.ContainsAny(path, "\\\r\n#")
+}
+
+// randomToken returns 32 random bytes, URL-safe encoded
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,176 @@
+package service_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/account/domain"
+	"ecommerce/internal/account/provider"
+	"ecommerce/internal/account/service"
+	"ecommerce/internal/gateway/config"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+)
+
+const secret = "test-secret-that-is-long-enough-for-hs256"
+
+// accounts keeps accounts and sign-ins in memory, linking users by email
+// as the Postgres repository does
+type accounts struct {
+	byEmail map[string]*domain.Account
+	states  map[string]*domain.LoginState
+	links   int
+}
+
+func newAccounts() *accounts {
+	return &accounts{
+		byEmail: map[string]*domain.Account{},
+		states:  map[string]*domain.LoginState{},
+	}
+}
+
+func (r *accounts) Link(ctx context.Context, user *domain.ExternalUser) (*domain.Account, bool, error) {
+	r.links++
+	email := strings.ToLower(user.Email)
+	account, ok := r.byEmail[email]
+	if !ok {
+		account = &domain.Account{ID: uuid.New(), Email: email}
+		r.byEmail[email] = account
+	}
+	return account, !ok, nil
+}
+
+func (r *accounts) SaveState(ctx context.Context, state string, login *domain.LoginState, ttl time.Duration) error {
+	r.states[state] = login
+	return nil
+}
+
+func (r *accounts) TakeState(ctx context.Context, state string) (*domain.LoginState, error) {
+	login := r.states[state]
+	delete(r.states, state)
+	return login, nil
+}
+
+// fakeProvider vouches for whichever user it is set up with, as long as
+// the sign-in's nonce comes back
+type fakeProvider struct {
+	name  string
+	user  domain.ExternalUser
+	nonce string
+}
+
+func (p *fakeProvider) Name() string { return p.name }
+
+func (p *fakeProvider) AuthURL(state, nonce, challenge string) string {
+	p.nonce = nonce
+	return "https://id.example.com/auth?state=" + state
+}
+
+func (p *fakeProvider) Exchange(ctx context.Context, code, verifier, nonce string) (*domain.ExternalUser, error) {
+	if nonce != p.nonce {
+		return nil, provider.ErrRejected
+	}
+	user := p.user
+	user.Provider = p.name
+	return &user, nil
+}
+
+func newService(repo *accounts, providers ...provider.Provider) service.AccountService {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	login := config.LoginConfig{CompleteURL: "https://shop.example.com", TokenTTL: 3600, StateTTL: 600}
+	return service.NewAccountService(repo, providers, login, config.AuthConfig{JWTSecret: secret}, logger)
+}
+
+// signIn runs a sign-in with a provider from start to finish
+func signIn(t *testing.T, s service.AccountService, name string) (*domain.Login, error) {
+	t.Helper()
+	state, _, err := s.BeginLogin(context.Background(), name, "/cart")
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+	return s.CompleteLogin(context.Background(), name, state, "code-1")
+}
+
+// TestCompleteLoginRefusesUnverifiedEmail checks that an email the
+// provider has not verified cannot claim an account
+func TestCompleteLoginRefusesUnverifiedEmail(t *testing.T) {
+	repo := newAccounts()
+	google := &fakeProvider{name: "google", user: domain.ExternalUser{Subject: "g-1", Email: "shopper@example.com"}}
+	s := newService(repo, google)
+
+	_, err := signIn(t, s, "google")
+	if !errors.IsForbidden(err) || errors.Code(err) != errors.CodeLoginEmailUnverified {
+		t.Fatalf("expected unverified email to be refused, got %v", err)
+	}
+	if repo.links != 0 {
+		t.Fatalf("linked %d accounts for an unverified email", repo.links)
+	}
+}
+
+// TestCompleteLoginLinksAccounts checks that signing in with a second
+// provider under the same verified email reaches the same account, and
+// that the token issued names it
+func TestCompleteLoginLinksAccounts(t *testing.T) {
+	repo := newAccounts()
+	google := &fakeProvider{name: "google", user: domain.ExternalUser{Subject: "g-1", Email: "shopper@example.com", EmailVerified: true}}
+	apple := &fakeProvider{name: "apple", user: domain.ExternalUser{Subject: "a-1", Email: "Shopper@Example.com", EmailVerified: true}}
+	s := newService(repo, google, apple)
+
+	first, err := signIn(t, s, "google")
+	if err != nil {
+		t.Fatalf("sign in with google: %v", err)
+	}
+	if !first.Created || first.ReturnTo != "/cart" {
+		t.Fatalf("unexpected first sign-in %+v", first)
+	}
+	second, err := signIn(t, s, "apple")
+	if err != nil {
+		t.Fatalf("sign in with apple: %v", err)
+	}
+	if second.Created || second.Account.ID != first.Account.ID {
+		t.Fatalf("apple sign-in reached account %s (created %v), want %s", second.Account.ID, second.Created, first.Account.ID)
+	}
+
+	claims, err := auth.ParseToken(second.AccessToken, []byte(secret))
+	if err != nil {
+		t.Fatalf("ParseToken: %v", err)
+	}
+	if claims.Subject != first.Account.ID.String() || claims.Email != "shopper@example.com" || claims.IssuedAt == 0 || claims.SessionID == "" {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+}
+
+// TestCompleteLoginUsesStateOnce checks that a sign-in cannot be replayed
+// or completed with another provider
+func TestCompleteLoginUsesStateOnce(t *testing.T) {
+	repo := newAccounts()
+	google := &fakeProvider{name: "google", user: domain.ExternalUser{Subject: "g-1", Email: "shopper@example.com", EmailVerified: true}}
+	apple := &fakeProvider{name: "apple", user: domain.ExternalUser{Subject: "a-1", Email: "shopper@example.com", EmailVerified: true}}
+	s := newService(repo, google, apple)
+
+	state, _, err := s.BeginLogin(context.Background(), "google", "/")
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+	if _, err := s.CompleteLogin(context.Background(), "google", state, "code-1"); err != nil {
+		t.Fatalf("CompleteLogin: %v", err)
+	}
+	if _, err := s.CompleteLogin(context.Background(), "google", state, "code-1"); errors.Code(err) != errors.CodeLoginStateInvalid {
+		t.Fatalf("expected replay to be refused, got %v", err)
+	}
+
+	state, _, err = s.BeginLogin(context.Background(), "google", "/")
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+	if _, err := s.CompleteLogin(context.Background(), "apple", state, "code-1"); errors.Code(err) != errors.CodeLoginStateInvalid {
+		t.Fatalf("expected another provider to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// API key scopes
+const (
+	ScopeRead      = "read"       // safe methods only
+	ScopeReadWrite = "read_write" // any method
+)
+
+// APIKey is a credential for a machine client. Only a hash of the key is
+// stored; the key itself is shown once, when it is issued or rotated.
+type APIKey struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name      string     `json:"name" gorm:"not null"`
+	OwnerID   string     `json:"owner_id" gorm:"not null;index"`
+	Role      string     `json:"role,omitempty"`      // the owner's role when the key was issued
+	TenantID  string     `json:"tenant_id,omitempty"` // the owner's tenant when the key was issued
+	Scope     string     `json:"scope" gorm:"not null"`
+	Prefix    string     `json:"prefix" gorm:"not null"` // leading characters, to recognise a key
+	Hash      string     `json:"-" gorm:"not null;uniqueIndex"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// IsActive reports whether the key may be used at the given time
+func (k *APIKey) IsActive(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// Allows reports whether the key's scope permits a request method
+func (k *APIKey) Allows(method string) bool {
+	if k.Scope == ScopeReadWrite {
+		return true
+	}
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// IssuedKey is an API key together with the key itself
+type IssuedKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// CreateAPIKeyRequest represents the request to issue an API key
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,min=1,max=100"`
+	Scope     string     `json:"scope" validate:"required,oneof=read read_write"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// APIKeyFilters represents filters for API key queries
+type APIKeyFilters struct {
+	OwnerID        string `json:"owner_id,omitempty"`
+	IncludeRevoked bool   `json:"include_revoked,omitempty"`
+	Limit          int    `json:"limit,omitempty"`
+	Offset         int    `json:"offset,omitempty"`
+}
+
+// APIKeyList represents a paginated list of API keys
+type APIKeyList struct {
+	Keys    []APIKey `json:"keys"`
+	Total   int64    `json:"total"`
+	Limit   int      `json:"limit"`
+	Offset  int      `json:"offset"`
+	HasMore bool     `json:"has_more"`
+}
+
+// TableName returns the table name for APIKey
+func (APIKey) TableName() string {
+	return "api_keys"
+}
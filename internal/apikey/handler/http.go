@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/apikey/domain"
+	"ecommerce/internal/apikey/service"
+	"ecommerce/pkg/database"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/response"
+)
+
+// HTTPHandler handles HTTP requests for API key management
+type HTTPHandler struct {
+	service service.APIKeyService
+	logger  *logrus.Logger
+}
+
+// NewHTTPHandler creates a new HTTP handler
+func NewHTTPHandler(service service.APIKeyService, logger *logrus.Logger) *HTTPHandler {
+	return &HTTPHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers the API key routes under api. The caller must
+// put the routes behind middleware that authenticates the actor.
+func (h *HTTPHandler) RegisterRoutes(api *gin.RouterGroup) {
+	keys := api.Group("/api-keys")
+	{
+		keys.POST("", h.CreateAPIKey)
+		keys.GET("", h.ListAPIKeys)
+		keys.GET("/:id", h.GetAPIKey)
+		keys.POST("/:id/rotate", h.RotateAPIKey)
+		keys.DELETE("/:id", h.RevokeAPIKey)
+	}
+}
+
+// CreateAPIKey handles API key issuance
+func (h *HTTPHandler) CreateAPIKey(c *gin.Context) {
+	var req domain.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	key, err := h.service.CreateAPIKey(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "API key created successfully", key)
+}
+
+// ListAPIKeys handles API key listing
+func (h *HTTPHandler) ListAPIKeys(c *gin.Context) {
+	filters := &domain.APIKeyFilters{
+		OwnerID:        c.Query("owner_id"),
+		IncludeRevoked: c.Query("include_revoked") == "true",
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			filters.Limit = l
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if o, err := strconv.Atoi(offset); err == nil {
+			filters.Offset = o
+		}
+	}
+
+	keys, err := h.service.ListAPIKeys(c.Request.Context(), filters)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "API keys retrieved successfully", keys)
+}
+
+// GetAPIKey handles getting a single API key
+func (h *HTTPHandler) GetAPIKey(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid API key ID", err)
+		return
+	}
+
+	key, err := h.service.GetAPIKey(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "API key retrieved successfully", key)
+}
+
+// RotateAPIKey handles replacing an API key's secret
+func (h *HTTPHandler) RotateAPIKey(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid API key ID", err)
+		return
+	}
+
+	key, err := h.service.RotateAPIKey(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "API key rotated successfully", key)
+}
+
+// RevokeAPIKey handles API key revocation
+func (h *HTTPHandler) RevokeAPIKey(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid API key ID", err)
+		return
+	}
+
+	if err := h.service.RevokeAPIKey(c.Request.Context(), id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "API key revoked successfully", nil)
+}
+
+// handleError handles service errors and converts them to appropriate HTTP responses
+func (h *HTTPHandler) handleError(c *gin.Context, err error) {
+	err = database.TranslateError(err)
+	switch {
+	case errors.IsNotFound(err):
+		response.Error(c, http.StatusNotFound, "Resource not found", err)
+	case errors.IsValidation(err):
+		response.Error(c, http.StatusBadRequest, "Validation failed", err)
+	case errors.IsConflict(err):
+		response.Error(c, http.StatusConflict, "Resource conflict", err)
+	case errors.IsUnauthorized(err):
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", err)
+	case errors.IsForbidden(err):
+		response.Error(c, http.StatusForbidden, "Forbidden", err)
+	case errors.IsUnavailable(err):
+		response.Error(c, http.StatusServiceUnavailable, "Service unavailable", err)
+	default:
+		h.logger.WithError(err).Error("Internal server error")
+		response.Error(c, http.StatusInternalServerError, "Internal server error", nil)
+	}
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"ecommerce/internal/apikey/domain"
+	"ecommerce/pkg/cache"
+	"ecommerce/pkg/database"
+	customErrors "ecommerce/pkg/errors"
+)
+
+// APIKeyRepository defines the interface for API key data operations
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *domain.APIKey) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.APIKey, error)
+	GetByHash(ctx context.Context, hash string) (*domain.APIKey, error)
+	Update(ctx context.Context, key *domain.APIKey) error
+	List(ctx context.Context, filters *domain.APIKeyFilters) ([]domain.APIKey, int64, error)
+	InvalidateKeyCache(ctx context.Context, hash string) error
+}
+
+type apiKeyRepository struct {
+	db     *gorm.DB
+	keys   *cache.Namespace
+	logger *logrus.Logger
+}
+
+// NewAPIKeyRepository creates a new API key repository. Keys looked up by
+// hash are cached for cacheTTL.
+func NewAPIKeyRepository(db *gorm.DB, store *cache.Cache, cacheTTL time.Duration, logger *logrus.Logger) APIKeyRepository {
+	return &apiKeyRepository{
+		db:     db,
+		keys:   store.Namespace("apikey", cacheTTL),
+		logger: logger,
+	}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	if err := r.db.WithContext(ctx).Create(key).Error; err != nil {
+		return fmt.Errorf("failed to create API key: %w", err)
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.APIKey, error) {
+	var key domain.APIKey
+	err := r.db.WithContext(ctx).First(&key, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("API key not found", err).WithCode(customErrors.CodeAPIKeyNotFound)
+		}
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	return &key, nil
+}
+
+// GetByHash resolves a key by its hash. This runs on every request made
+// with an API key, so results are cached; updates that change whether a
+// key may be used must invalidate its cache entry.
+func (r *apiKeyRepository) GetByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	// Loads read from the primary, so a lagging replica can't cache a key
+	// again as it was before being revoked
+	key, err := cache.GetOrLoad(database.WithPrimary(ctx), r.keys, r.keys.Key(hash), func(ctx context.Context) (*domain.APIKey, error) {
+		var key domain.APIKey
+		err := r.db.WithContext(ctx).First(&key, "hash = ?", hash).Error
+
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, customErrors.NewNotFoundError("API key not found", err).WithCode(customErrors.CodeAPIKeyNotFound)
+			}
+			return nil, fmt.Errorf("failed to get API key by hash: %w", err)
+		}
+
+		return &key, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The hash isn't cached
+	key.Hash = hash
+	return key, nil
+}
+
+func (r *apiKeyRepository) Update(ctx context.Context, key *domain.APIKey) error {
+	if err := r.db.WithContext(ctx).Save(key).Error; err != nil {
+		return fmt.Errorf("failed to update API key: %w", err)
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) List(ctx context.Context, filters *domain.APIKeyFilters) ([]domain.APIKey, int64, error) {
+	query := r.db.WithContext(ctx).Model(&domain.APIKey{})
+
+	if filters.OwnerID != "" {
+		query = query.Where("owner_id = ?", filters.OwnerID)
+	}
+	if !filters.IncludeRevoked {
+		query = query.Where("revoked_at IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count API keys: %w", err)
+	}
+
+	var keys []domain.APIKey
+	err := query.
+		Order("created_at DESC").
+		Limit(filters.Limit).
+		Offset(filters.Offset).
+		Find(&keys).Error
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list API keys: %w", err)
+	}
+
+	return keys, total, nil
+}
+
+func (r *apiKeyRepository) InvalidateKeyCache(ctx context.Context, hash string) error {
+	return r.keys.Delete(ctx, r.keys.Key(hash))
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/apikey/domain"
+	"ecommerce/internal/apikey/repository"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/logger"
+	"ecommerce/pkg/validator"
+)
+
+// keyPrefixLength is how much of a key is kept in the clear, enough to
+// tell a client's keys apart without weakening them
+const keyPrefixLength = 11
+
+// APIKeyService defines the API key service interface
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, req *domain.CreateAPIKeyRequest) (*domain.IssuedKey, error)
+	GetAPIKey(ctx context.Context, id uuid.UUID) (*domain.APIKey, error)
+	ListAPIKeys(ctx context.Context, filters *domain.APIKeyFilters) (*domain.APIKeyList, error)
+	RotateAPIKey(ctx context.Context, id uuid.UUID) (*domain.IssuedKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) error
+
+	ResolveAPIKey(ctx context.Context, key string) (*domain.APIKey, error)
+}
+
+type apiKeyService struct {
+	repo      repository.APIKeyRepository
+	logger    *logrus.Logger
+	validator *validator.Validator
+}
+
+// NewAPIKeyService creates a new API key service
+func NewAPIKeyService(repo repository.APIKeyRepository, logger *logrus.Logger) APIKeyService {
+	return &apiKeyService{
+		repo:      repo,
+		logger:    logger,
+		validator: validator.New(),
+	}
+}
+
+// log returns the logger of the request ctx belongs to
+func (s *apiKeyService) log(ctx context.Context) *logrus.Entry {
+	return logger.FromContext(ctx, s.logger)
+}
+
+// CreateAPIKey issues a key that acts as the caller, limited to the
+// requested scope. The response is the only time the key is returned,
+// apart from rotating it.
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, req *domain.CreateAPIKeyRequest) (*domain.IssuedKey, error) {
+	if err := requireIntegrator(ctx); err != nil {
+		return nil, err
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid create API key request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, errors.NewValidationError("Expiry must be in the future", nil)
+	}
+
+	secret, err := newKey()
+	if err != nil {
+		return nil, errors.NewInternalError("Failed to generate API key", err)
+	}
+
+	actor := auth.ActorFromContext(ctx)
+	key := &domain.APIKey{
+		Name:      req.Name,
+		OwnerID:   actor.ID,
+		Role:      actor.Role,
+		TenantID:  actor.Tenant,
+		Scope:     req.Scope,
+		Prefix:    secret[:keyPrefixLength],
+		Hash:      hashKey(secret),
+		ExpiresAt: req.ExpiresAt,
+	}
+
+	if err := s.repo.Create(ctx, key); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to create API key")
+		return nil, errors.NewInternalError("Failed to create API key", err)
+	}
+
+	s.log(ctx).WithField("api_key_id", key.ID).Info("API key created successfully")
+	return &domain.IssuedKey{APIKey: *key, Key: secret}, nil
+}
+
+func (s *apiKeyService) GetAPIKey(ctx context.Context, id uuid.UUID) (*domain.APIKey, error) {
+	return s.keyFor(ctx, id)
+}
+
+func (s *apiKeyService) ListAPIKeys(ctx context.Context, filters *domain.APIKeyFilters) (*domain.APIKeyList, error) {
+	if err := requireIntegrator(ctx); err != nil {
+		return nil, err
+	}
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		filters.OwnerID = auth.ActorID(ctx)
+	}
+
+	// Set default values
+	if filters.Limit <= 0 {
+		filters.Limit = 20
+	}
+	if filters.Limit > 100 {
+		filters.Limit = 100
+	}
+
+	keys, total, err := s.repo.List(ctx, filters)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list API keys")
+		return nil, errors.NewInternalError("Failed to list API keys", err)
+	}
+
+	return &domain.APIKeyList{
+		Keys:    keys,
+		Total:   total,
+		Limit:   filters.Limit,
+		Offset:  filters.Offset,
+		HasMore: int64(filters.Offset+filters.Limit) < total,
+	}, nil
+}
+
+// RotateAPIKey replaces a key's secret, keeping its name, scope and
+// expiry. The old secret stops working immediately.
+func (s *apiKeyService) RotateAPIKey(ctx context.Context, id uuid.UUID) (*domain.IssuedKey, error) {
+	key, err := s.keyFor(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !key.IsActive(time.Now()) {
+		return nil, errors.NewConflictError("Only active API keys can be rotated", nil).WithCode(errors.CodeAPIKeyInactive)
+	}
+
+	secret, err := newKey()
+	if err != nil {
+		return nil, errors.NewInternalError("Failed to generate API key", err)
+	}
+
+	previousHash := key.Hash
+	key.Prefix = secret[:keyPrefixLength]
+	key.Hash = hashKey(secret)
+
+	if err := s.repo.Update(ctx, key); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to rotate API key")
+		return nil, errors.NewInternalError("Failed to rotate API key", err)
+	}
+	s.invalidate(ctx, previousHash)
+
+	s.log(ctx).WithField("api_key_id", key.ID).Info("API key rotated successfully")
+	return &domain.IssuedKey{APIKey: *key, Key: secret}, nil
+}
+
+// RevokeAPIKey stops a key from being used. The record is kept so that
+// requests made with it can still be traced to its owner.
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	key, err := s.keyFor(ctx, id)
+	if err != nil {
+		return err
+	}
+	if key.RevokedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	key.RevokedAt = &now
+
+	if err := s.repo.Update(ctx, key); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to revoke API key")
+		return errors.NewInternalError("Failed to revoke API key", err)
+	}
+	s.invalidate(ctx, key.Hash)
+
+	s.log(ctx).WithField("api_key_id", key.ID).Info("API key revoked successfully")
+	return nil
+}
+
+// ResolveAPIKey returns the active key matching a presented key. Unknown,
+// revoked and expired keys are all reported as unauthorized.
+func (s *apiKeyService) ResolveAPIKey(ctx context.Context, secret string) (*domain.APIKey, error) {
+	key, err := s.repo.GetByHash(ctx, hashKey(secret))
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewUnauthorizedError("Invalid API key", nil).WithCode(errors.CodeAPIKeyInvalid)
+		}
+		s.log(ctx).WithError(err).Error("Failed to resolve API key")
+		return nil, errors.NewUnavailableError("Failed to resolve API key", err)
+	}
+
+	if !key.IsActive(time.Now()) {
+		return nil, errors.NewUnauthorizedError("Invalid API key", nil).WithCode(errors.CodeAPIKeyInvalid)
+	}
+
+	return key, nil
+}
+
+// keyFor returns a key the caller may manage. Other clients' keys are
+// reported as not found.
+func (s *apiKeyService) keyFor(ctx context.Context, id uuid.UUID) (*domain.APIKey, error) {
+	if err := requireIntegrator(ctx); err != nil {
+		return nil, err
+	}
+
+	key, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("API key not found", err).WithCode(errors.CodeAPIKeyNotFound)
+		}
+		s.log(ctx).WithError(err).Error("Failed to get API key")
+		return nil, errors.NewInternalError("Failed to get API key", err)
+	}
+
+	if !auth.HasRole(ctx, auth.RoleAdmin) && key.OwnerID != auth.ActorID(ctx) {
+		return nil, errors.NewNotFoundError("API key not found", nil).WithCode(errors.CodeAPIKeyNotFound)
+	}
+
+	return key, nil
+}
+
+// invalidate drops a key's cached lookup. Until it expires the cache would
+// keep accepting the key, so a failure is logged loudly.
+func (s *apiKeyService) invalidate(ctx context.Context, hash string) {
+	if err := s.repo.InvalidateKeyCache(context.WithoutCancel(ctx), hash); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to invalidate API key cache")
+	}
+}
+
+// requireIntegrator checks that the caller may manage API keys
+func requireIntegrator(ctx context.Context) error {
+	if auth.HasRole(ctx, auth.RoleAdmin) || auth.HasRole(ctx, auth.RoleIntegrator) {
+		return nil
+	}
+	return errors.NewForbiddenError("Managing API keys requires the integrator role", nil)
+}
+
+// newKey generates a random API key
+func newKey() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	return "ak_" + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashKey is the form a key is stored and looked up in. Keys are random
+// and long, so a fast unsalted hash is enough.
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,238 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	sharedcache "ecommerce/pkg/cache"
+	"ecommerce/pkg/tenant"
+)
+
+// generationKey holds the cache generation. Every key includes it, so a
+// purge only has to bump it; entries from older generations are never read
+// again and expire on their own.
+const generationKey = "respcache:generation"
+
+// Entry is a cached response
+type Entry struct {
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header"`
+	Body     []byte      `json:"body"`
+	StoredAt time.Time   `json:"stored_at"`
+}
+
+// Cache stores responses to anonymous GET requests in Redis
+type Cache struct {
+	client     redis.UniversalClient
+	responses  *sharedcache.Namespace
+	routes     []string // path prefixes whose responses may be cached
+	vary       []string // canonical request header names that are part of the key
+	defaultTTL time.Duration
+	maxTTL     time.Duration
+	maxBody    int
+}
+
+// New creates a response cache. Responses without their own freshness
+// lifetime are kept for defaultTTL; longer lifetimes are capped at maxTTL.
+func New(store *sharedcache.Cache, routes, vary []string, defaultTTL, maxTTL time.Duration, maxBody int) *Cache {
+	canonical := make([]string, 0, len(vary))
+	for _, name := range vary {
+		canonical = append(canonical, http.CanonicalHeaderKey(name))
+	}
+	sort.Strings(canonical)
+
+	return &Cache{
+		client:     store.Client(),
+		responses:  store.Namespace("respcache", maxTTL),
+		routes:     routes,
+		vary:       canonical,
+		defaultTTL: defaultTTL,
+		maxTTL:     maxTTL,
+		maxBody:    maxBody,
+	}
+}
+
+// MaxBody returns the largest response body that is cached
+func (c *Cache) MaxBody() int {
+	return c.maxBody
+}
+
+// Cacheable reports whether a request to a cleaned path may be answered
+// from the cache. Callers must only ask about anonymous requests, since
+// responses to authenticated ones can depend on who is asking.
+func (c *Cache) Cacheable(req *http.Request, path string) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	if hasDirective(req.Header, "no-store") {
+		return false
+	}
+	for _, prefix := range c.routes {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Key returns the cache key for a request: its tenant as a prefix, then a
+// hash of its path, its query parameters in a fixed order and the values of
+// the configured Vary headers
+func (c *Cache) Key(ctx context.Context, req *http.Request) (string, error) {
+	generation, err := c.client.Get(ctx, generationKey).Int64()
+	if err != nil && err != redis.Nil {
+		return "", fmt.Errorf("failed to get cache generation: %w", err)
+	}
+
+	var b strings.Builder
+	b.WriteString(req.URL.Path)
+	b.WriteByte('?')
+	b.WriteString(normalizeQuery(req.URL.Query()))
+	for _, name := range c.vary {
+		b.WriteByte('\n')
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(strings.Join(req.Header.Values(name), ","))
+	}
+
+	sum := sha256.Sum256([]byte(b.String()))
+	id := req.Header.Get(tenant.Header)
+	if id == "" {
+		id = tenant.Default
+	}
+	return c.responses.Key(strconv.FormatInt(generation, 10), id, hex.EncodeToString(sum[:])), nil
+}
+
+// Get returns the entry stored under key, or nil on a miss. A request with
+// Cache-Control: no-cache always misses, which refreshes the entry.
+func (c *Cache) Get(ctx context.Context, req *http.Request, key string) (*Entry, error) {
+	if hasDirective(req.Header, "no-cache") {
+		return nil, nil
+	}
+
+	entry, ok, err := sharedcache.Get[*Entry](ctx, c.responses, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached response: %w", err)
+	}
+	if !ok {
+		return nil, nil
+	}
+	return entry, nil
+}
+
+// Prepare decides whether an upstream response may be stored, and for how
+// long, before it is sent. Responses the service did not give a
+// Cache-Control header are marked cacheable by shared caches for the
+// default lifetime, so CDNs in front of the gateway can cache them too.
+func (c *Cache) Prepare(status int, header http.Header) (time.Duration, bool) {
+	if status != http.StatusOK || header.Get("Set-Cookie") != "" {
+		return 0, false
+	}
+
+	for _, name := range header.Values("Vary") {
+		for _, field := range strings.Split(name, ",") {
+			field = http.CanonicalHeaderKey(strings.TrimSpace(field))
+			if field == "*" || (field != "" && !c.varies(field)) {
+				return 0, false
+			}
+		}
+	}
+
+	if header.Get("Cache-Control") == "" {
+		seconds := int(c.defaultTTL.Seconds())
+		header.Set("Cache-Control", fmt.Sprintf("public, max-age=0, s-maxage=%d", seconds))
+		return c.defaultTTL, true
+	}
+
+	if hasDirective(header, "no-store") || hasDirective(header, "private") || hasDirective(header, "no-cache") {
+		return 0, false
+	}
+
+	ttl, ok := directiveSeconds(header, "s-maxage")
+	if !ok {
+		ttl, ok = directiveSeconds(header, "max-age")
+	}
+	if !ok {
+		ttl = c.defaultTTL
+	}
+	if ttl > c.maxTTL {
+		ttl = c.maxTTL
+	}
+	return ttl, ttl > 0
+}
+
+// Store saves a response for ttl
+func (c *Cache) Store(ctx context.Context, key string, entry *Entry, ttl time.Duration) error {
+	if err := sharedcache.SetWithTTL(ctx, c.responses, key, entry, ttl); err != nil {
+		return fmt.Errorf("failed to store cached response: %w", err)
+	}
+	return nil
+}
+
+// Purge invalidates every cached response
+func (c *Cache) Purge(ctx context.Context) error {
+	if err := c.client.Incr(ctx, generationKey).Err(); err != nil {
+		return fmt.Errorf("failed to purge response cache: %w", err)
+	}
+	return nil
+}
+
+func (c *Cache) varies(name string) bool {
+	for _, vary := range c.vary {
+		if vary == name {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeQuery encodes query parameters sorted by key and value, so the
+// same query written in a different order hits the same entry
+func normalizeQuery(query url.Values) string {
+	for _, values := range query {
+		sort.Strings(values)
+	}
+	return query.Encode()
+}
+
+// hasDirective reports whether a Cache-Control header carries a directive
+func hasDirective(header http.Header, directive string) bool {
+	for _, value := range header.Values("Cache-Control") {
+		for _, part := range strings.Split(value, ",") {
+			name, _, _ := strings.Cut(strings.TrimSpace(part), "=")
+			if strings.EqualFold(name, directive) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// directiveSeconds returns the value of a Cache-Control directive such as
+// max-age=60
+func directiveSeconds(header http.Header, directive string) (time.Duration, bool) {
+	for _, value := range header.Values("Cache-Control") {
+		for _, part := range strings.Split(value, ",") {
+			name, arg, found := strings.Cut(strings.TrimSpace(part), "=")
+			if !found || !strings.EqualFold(name, directive) {
+				continue
+			}
+			seconds, err := strconv.Atoi(strings.Trim(arg, `"`))
+			if err != nil || seconds < 0 {
+				return 0, false
+			}
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+	return 0, false
+}
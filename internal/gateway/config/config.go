@@ -0,0 +1,388 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+
+	productconfig "ecommerce/internal/product/config"
+)
+
+// Config holds all configuration for the API gateway
+type Config struct {
+	Env       string
+	HTTP      productconfig.HTTPConfig
+	Database  productconfig.DatabaseConfig
+	Redis     productconfig.RedisConfig
+	Debug     productconfig.DebugConfig
+	MTLS      productconfig.MTLSConfig
+	Logger    productconfig.LoggerConfig
+	Auth      AuthConfig
+	APIKeys   APIKeysConfig
+	Sessions  SessionsConfig
+	TwoFactor TwoFactorConfig
+	Login     LoginConfig
+	Lockout   LockoutConfig
+	Services  ServicesConfig
+	RateLimit RateLimitConfig
+	Cache     CacheConfig
+	Internal  InternalConfig
+}
+
+// AuthConfig holds token verification configuration. Tokens are verified
+// against the identity provider's JWKS when JWKSURL is set, and as HS256
+// tokens signed with JWTSecret otherwise.
+type AuthConfig struct {
+	JWKSURL        string
+	JWKSCacheTTL   int // seconds
+	JWKSTimeout    int // seconds
+	Issuer         string
+	Audience       string
+	JWTSecret      string
+	IdentitySecret string // signs the identity headers sent to the services
+}
+
+// APIKeysConfig holds API key configuration
+type APIKeysConfig struct {
+	CacheTTL int // seconds a resolved key is cached; bounds how long a revoked key could linger
+}
+
+// SessionsConfig holds session tracking configuration. Sessions are those
+// of the identity provider, named by the sid claim of its tokens.
+type SessionsConfig struct {
+	Enabled       bool
+	IdleTimeout   int // seconds a session may go unused before it ends
+	Retention     int // seconds an ended session is remembered, so its tokens stay refused
+	TouchInterval int // seconds between recordings of a session's use
+}
+
+// TwoFactorConfig holds two-factor authentication configuration. It is
+// disabled unless Secret is set.
+type TwoFactorConfig struct {
+	Secret        string   // seals TOTP secrets at rest
+	Issuer        string   // shown by authenticator apps
+	RequiredRoles []string // roles that must pass a second factor for the protected routes
+	Routes        []string // path prefixes whose changes are protected
+	Skew          int      // steps a code may be ahead or behind
+	RecoveryCodes int      // issued at a time
+}
+
+// LoginConfig holds sign-in through external identity providers. Each
+// provider is enabled by setting its client ID. The gateway issues the
+// tokens of the accounts signing in this way as HS256 tokens signed with
+// JWTSecret, so it cannot be used with JWKSURL.
+type LoginConfig struct {
+	CallbackURL string // public URL the login routes are served under, such as https://shop.example.com/api/v1/auth
+	CompleteURL string // storefront origin users are sent back to with their token
+	TokenTTL    int    // seconds an issued token is valid
+	StateTTL    int    // seconds a user has to complete a sign-in
+	Timeout     int    // seconds to wait for a provider
+	Google      GoogleLoginConfig
+	Apple       AppleLoginConfig
+}
+
+// GoogleLoginConfig holds the OAuth client signing in with Google
+type GoogleLoginConfig struct {
+	ClientID     string
+	ClientSecret string
+}
+
+// AppleLoginConfig holds the Services ID signing in with Apple. Apple
+// takes a JWT signed with the team's private key as the client secret.
+type AppleLoginConfig struct {
+	ClientID   string // the Services ID
+	TeamID     string
+	KeyID      string
+	PrivateKey string // PEM encoded PKCS #8 EC key
+}
+
+// Enabled reports whether any provider is configured
+func (c LoginConfig) Enabled() bool {
+	return c.Google.ClientID != "" || c.Apple.ClientID != ""
+}
+
+// LockoutConfig holds brute-force protection configuration for two-factor
+// codes and API keys
+type LockoutConfig struct {
+	Enabled        bool
+	Threshold      int // failures in a row that lock a subject out
+	Decay          int // seconds after which failures are forgotten
+	Duration       int // seconds of the first lockout, doubling with each that follows
+	MaxDuration    int // seconds a lockout lasts at most
+	Reset          int // seconds after a lockout ends that the next one no longer escalates
+	CaptchaAfter   int // failures after which two-factor attempts need a CAPTCHA; 0 never
+	CaptchaURL     string
+	CaptchaSecret  string
+	CaptchaTimeout int // seconds
+}
+
+// ServicesConfig holds the base URLs of the services behind the gateway
+type ServicesConfig struct {
+	ProductURL      string
+	OrderURL        string
+	PaymentURL      string
+	PromotionURL    string
+	NotificationURL string
+	WebhookURL      string
+	TaxURL          string
+	Timeout         int // seconds to wait for a service's response headers
+
+	RetryAttempts      int // attempts for safe requests without a body
+	BreakerFailures    int // consecutive failures that cut a service off
+	BreakerOpenTimeout int // seconds before a cut-off service is tried again
+}
+
+// RateLimitConfig holds per-client rate limiting configuration
+type RateLimitConfig struct {
+	Enabled        bool
+	Default        string   // requests/unit for routes without their own limit, such as 300/m
+	Routes         string   // comma separated prefix=requests/unit overrides
+	TrustedProxies []string // proxies whose X-Forwarded-For gives the client IP
+}
+
+// CacheConfig holds response cache configuration
+type CacheConfig struct {
+	Enabled    bool
+	Routes     []string // path prefixes of anonymous GET routes whose responses are cached
+	Vary       []string // request headers that are part of the cache key
+	DefaultTTL int      // seconds a response without its own lifetime is cached
+	MaxTTL     int      // seconds a response is cached at most
+	MaxBody    int      // bytes; larger responses are not cached
+}
+
+// InternalConfig holds configuration for the gateway's internal listener,
+// which takes events from the services and is not exposed publicly
+type InternalConfig struct {
+	Port string
+}
+
+// Load loads configuration from environment variables. The HTTP port,
+// database, Redis and secrets use the same variables as the services.
+func Load() (*Config, error) {
+	shared, err := productconfig.Load()
+	if err != nil {
+		return nil, err
+	}
+	return &Config{
+		Env:      shared.Env,
+		HTTP:     shared.HTTP,
+		Database: shared.Database,
+		Redis:    shared.Redis,
+		Debug:    shared.Debug,
+		MTLS:     shared.MTLS,
+		Logger:   shared.Logger,
+		Auth: AuthConfig{
+			JWKSURL:        getEnv("JWKS_URL", ""),
+			JWKSCacheTTL:   getEnvAsInt("JWKS_CACHE_TTL", 300),
+			JWKSTimeout:    getEnvAsInt("JWKS_TIMEOUT", 5),
+			Issuer:         getEnv("JWT_ISSUER", ""),
+			Audience:       getEnv("JWT_AUDIENCE", ""),
+			JWTSecret:      shared.Auth.JWTSecret,
+			IdentitySecret: shared.Auth.IdentitySecret,
+		},
+		APIKeys: APIKeysConfig{
+			CacheTTL: getEnvAsInt("APIKEY_CACHE_TTL", 300),
+		},
+		Sessions: SessionsConfig{
+			Enabled:       getEnvAsBool("SESSIONS_ENABLED", true),
+			IdleTimeout:   getEnvAsInt("SESSION_IDLE_TIMEOUT", 14*24*3600),
+			Retention:     getEnvAsInt("SESSION_RETENTION", 30*24*3600),
+			TouchInterval: getEnvAsInt("SESSION_TOUCH_INTERVAL", 60),
+		},
+		TwoFactor: TwoFactorConfig{
+			Secret:        getEnv("TWO_FACTOR_SECRET", ""),
+			Issuer:        getEnv("TWO_FACTOR_ISSUER", "ecommerce"),
+			RequiredRoles: getEnvAsList("TWO_FACTOR_REQUIRED_ROLES", "admin"),
+			Routes: getEnvAsList("TWO_FACTOR_ROUTES",
+				"/api/v1/products,/api/v1/categories,/api/v1/brands,/api/v1/attributes,/api/v1/imports,/api/v1/inventory,/api/v1/warehouses,/api/v1/api-keys"),
+			Skew:          getEnvAsInt("TWO_FACTOR_SKEW", 1),
+			RecoveryCodes: getEnvAsInt("TWO_FACTOR_RECOVERY_CODES", 10),
+		},
+		Login: LoginConfig{
+			CallbackURL: getEnv("LOGIN_CALLBACK_URL", ""),
+			CompleteURL: getEnv("LOGIN_COMPLETE_URL", ""),
+			TokenTTL:    getEnvAsInt("LOGIN_TOKEN_TTL", 3600),
+			StateTTL:    getEnvAsInt("LOGIN_STATE_TTL", 600),
+			Timeout:     getEnvAsInt("LOGIN_TIMEOUT", 10),
+			Google: GoogleLoginConfig{
+				ClientID:     getEnv("GOOGLE_CLIENT_ID", ""),
+				ClientSecret: getEnv("GOOGLE_CLIENT_SECRET", ""),
+			},
+			Apple: AppleLoginConfig{
+				ClientID:   getEnv("APPLE_CLIENT_ID", ""),
+				TeamID:     getEnv("APPLE_TEAM_ID", ""),
+				KeyID:      getEnv("APPLE_KEY_ID", ""),
+				PrivateKey: getEnv("APPLE_PRIVATE_KEY", ""),
+			},
+		},
+		Lockout: LockoutConfig{
+			Enabled:        getEnvAsBool("LOCKOUT_ENABLED", true),
+			Threshold:      getEnvAsInt("LOCKOUT_THRESHOLD", 5),
+			Decay:          getEnvAsInt("LOCKOUT_DECAY", 900),
+			Duration:       getEnvAsInt("LOCKOUT_DURATION", 60),
+			MaxDuration:    getEnvAsInt("LOCKOUT_MAX_DURATION", 3600),
+			Reset:          getEnvAsInt("LOCKOUT_RESET", 86400),
+			CaptchaAfter:   getEnvAsInt("CAPTCHA_AFTER_FAILURES", 3),
+			CaptchaURL:     getEnv("CAPTCHA_VERIFY_URL", ""),
+			CaptchaSecret:  getEnv("CAPTCHA_SECRET", ""),
+			CaptchaTimeout: getEnvAsInt("CAPTCHA_TIMEOUT", 5),
+		},
+		Services: ServicesConfig{
+			ProductURL:      getEnv("PRODUCT_SERVICE_URL", "http://localhost:8081"),
+			OrderURL:        getEnv("ORDER_SERVICE_URL", "http://localhost:8083"),
+			PaymentURL:      getEnv("PAYMENT_SERVICE_URL", "http://localhost:8087"),
+			PromotionURL:    getEnv("PROMOTION_SERVICE_URL", "http://localhost:8086"),
+			NotificationURL: getEnv("NOTIFICATION_SERVICE_URL", "http://localhost:8085"),
+			WebhookURL:      getEnv("WEBHOOK_SERVICE_URL", "http://localhost:8088"),
+			TaxURL:          getEnv("TAX_SERVICE_URL", "http://localhost:8089"),
+			Timeout:         getEnvAsInt("SERVICE_TIMEOUT", 30),
+
+			RetryAttempts:      getEnvAsInt("SERVICE_RETRY_ATTEMPTS", 2),
+			BreakerFailures:    getEnvAsInt("SERVICE_BREAKER_FAILURES", 5),
+			BreakerOpenTimeout: getEnvAsInt("SERVICE_BREAKER_OPEN_TIMEOUT", 30),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:        getEnvAsBool("RATE_LIMIT_ENABLED", true),
+			Default:        getEnv("RATE_LIMIT_DEFAULT", "300/m"),
+			Routes:         getEnv("RATE_LIMIT_ROUTES", "/api/v1/checkout=20/m,/api/v1/payments=60/m,/api/v1/users/me/two-factor=10/m"),
+			TrustedProxies: getEnvAsList("TRUSTED_PROXIES", ""),
+		},
+		Cache: CacheConfig{
+			Enabled:    getEnvAsBool("RESPONSE_CACHE_ENABLED", true),
+			Routes:     getEnvAsList("RESPONSE_CACHE_ROUTES", "/api/v1/products,/api/v1/categories,/api/v1/brands"),
+			Vary:       getEnvAsList("RESPONSE_CACHE_VARY", "Accept,Accept-Encoding,Accept-Language"),
+			DefaultTTL: getEnvAsInt("RESPONSE_CACHE_TTL", 60),
+			MaxTTL:     getEnvAsInt("RESPONSE_CACHE_MAX_TTL", 600),
+			MaxBody:    getEnvAsInt("RESPONSE_CACHE_MAX_BODY", 1<<20),
+		},
+		Internal: InternalConfig{
+			Port: getEnv("INTERNAL_HTTP_PORT", "8090"),
+		},
+	}, nil
+}
+
+// Validate reports every setting that is missing or invalid, so the gateway
+// refuses to start rather than run misconfigured
+func (c *Config) Validate() error {
+	errs := []error{
+		productconfig.ValidateEnv(c.Env),
+		c.HTTP.Validate(),
+		c.Database.Validate(c.Env),
+		c.Redis.Validate(),
+		c.Logger.Validate(),
+		c.MTLS.Validate(),
+		c.MTLS.ValidateURLs(map[string]string{
+			"PRODUCT_SERVICE_URL":      c.Services.ProductURL,
+			"ORDER_SERVICE_URL":        c.Services.OrderURL,
+			"PAYMENT_SERVICE_URL":      c.Services.PaymentURL,
+			"PROMOTION_SERVICE_URL":    c.Services.PromotionURL,
+			"NOTIFICATION_SERVICE_URL": c.Services.NotificationURL,
+			"WEBHOOK_SERVICE_URL":      c.Services.WebhookURL,
+			"TAX_SERVICE_URL":          c.Services.TaxURL,
+		}),
+	}
+	if c.Auth.IdentitySecret == "" {
+		errs = append(errs, errors.New("GATEWAY_IDENTITY_SECRET must be set"))
+	}
+	if c.Auth.JWKSURL == "" && c.Auth.JWTSecret == "" {
+		errs = append(errs, errors.New("either JWKS_URL or JWT_SECRET must be set"))
+	}
+	if c.TwoFactor.Secret != "" {
+		if len(c.TwoFactor.Secret) < 32 {
+			errs = append(errs, errors.New("TWO_FACTOR_SECRET must be at least 32 characters"))
+		}
+		if !c.Sessions.Enabled {
+			errs = append(errs, errors.New("TWO_FACTOR_SECRET requires SESSIONS_ENABLED"))
+		}
+		if c.TwoFactor.Skew < 0 || c.TwoFactor.Skew > 2 {
+			errs = append(errs, errors.New("TWO_FACTOR_SKEW must be between 0 and 2"))
+		}
+		if c.TwoFactor.RecoveryCodes <= 0 {
+			errs = append(errs, errors.New("TWO_FACTOR_RECOVERY_CODES must be positive"))
+		}
+	}
+	if c.Login.Enabled() {
+		if c.Auth.JWKSURL != "" || c.Auth.JWTSecret == "" {
+			errs = append(errs, errors.New("social login issues HS256 tokens, so it needs JWT_SECRET and no JWKS_URL"))
+		}
+		if c.Login.CallbackURL == "" || c.Login.CompleteURL == "" {
+			errs = append(errs, errors.New("LOGIN_CALLBACK_URL and LOGIN_COMPLETE_URL must be set for social login"))
+		}
+		if c.Login.TokenTTL <= 0 || c.Login.StateTTL <= 0 || c.Login.Timeout <= 0 {
+			errs = append(errs, errors.New("LOGIN_TOKEN_TTL, LOGIN_STATE_TTL and LOGIN_TIMEOUT must be positive"))
+		}
+		if c.Login.Google.ClientID != "" && c.Login.Google.ClientSecret == "" {
+			errs = append(errs, errors.New("GOOGLE_CLIENT_SECRET must be set with GOOGLE_CLIENT_ID"))
+		}
+		if c.Login.Apple.ClientID != "" && (c.Login.Apple.TeamID == "" || c.Login.Apple.KeyID == "" || c.Login.Apple.PrivateKey == "") {
+			errs = append(errs, errors.New("APPLE_TEAM_ID, APPLE_KEY_ID and APPLE_PRIVATE_KEY must be set with APPLE_CLIENT_ID"))
+		}
+	}
+	if c.Lockout.Enabled {
+		if c.Lockout.Threshold <= 0 || c.Lockout.Decay <= 0 || c.Lockout.Duration <= 0 {
+			errs = append(errs, errors.New("LOCKOUT_THRESHOLD, LOCKOUT_DECAY and LOCKOUT_DURATION must be positive"))
+		}
+		if c.Lockout.MaxDuration < c.Lockout.Duration {
+			errs = append(errs, errors.New("LOCKOUT_MAX_DURATION must be at least LOCKOUT_DURATION"))
+		}
+		if c.Lockout.Reset < 0 {
+			errs = append(errs, errors.New("LOCKOUT_RESET must not be negative"))
+		}
+		if c.Lockout.CaptchaURL != "" && c.Lockout.CaptchaSecret == "" {
+			errs = append(errs, errors.New("CAPTCHA_SECRET must be set with CAPTCHA_VERIFY_URL"))
+		}
+	}
+	if c.Sessions.Enabled {
+		if c.Sessions.IdleTimeout <= 0 {
+			errs = append(errs, errors.New("SESSION_IDLE_TIMEOUT must be positive"))
+		}
+		if c.Sessions.Retention <= 0 {
+			errs = append(errs, errors.New("SESSION_RETENTION must be positive"))
+		}
+		if c.Sessions.TouchInterval < 0 {
+			errs = append(errs, errors.New("SESSION_TOUCH_INTERVAL must not be negative"))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// getEnv gets an environment variable with a default value
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvAsInt gets an environment variable as integer with a default value
+func getEnvAsInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsBool gets an environment variable as boolean with a default value
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsList gets a comma-separated environment variable as a list with
+// a default value
+func getEnvAsList(key, defaultValue string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnv(key, defaultValue), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
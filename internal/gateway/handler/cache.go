@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ecommerce/internal/gateway/cache"
+	"ecommerce/internal/gateway/proxy"
+)
+
+// forwardCached answers an anonymous catalog request from the response
+// cache, or forwards it and caches the response. The cache is skipped when
+// Redis is unreachable.
+func (h *HTTPHandler) forwardCached(c *gin.Context, target *proxy.Target) {
+	req := c.Request
+	ctx := req.Context()
+
+	key, err := h.cache.Key(ctx, req)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to build response cache key")
+		target.ServeHTTP(c.Writer, req)
+		return
+	}
+
+	entry, err := h.cache.Get(ctx, req, key)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to read response cache")
+	}
+	if entry != nil {
+		writeCached(c, entry)
+		return
+	}
+
+	c.Header("X-Cache", "MISS")
+	recorder := &cacheRecorder{
+		ResponseWriter: c.Writer,
+		cache:          h.cache,
+		before:         c.Writer.Header().Clone(),
+		store:          req.Method == http.MethodGet,
+	}
+	target.ServeHTTP(recorder, req)
+
+	if !recorder.store {
+		return
+	}
+	stored := &cache.Entry{
+		Status:   recorder.status,
+		Header:   recorder.header,
+		Body:     recorder.body.Bytes(),
+		StoredAt: time.Now(),
+	}
+	if err := h.cache.Store(context.WithoutCancel(ctx), key, stored, recorder.ttl); err != nil {
+		h.logger.WithError(err).Error("Failed to store cached response")
+	}
+}
+
+// writeCached sends a cached response, telling the client how old it is
+func writeCached(c *gin.Context, entry *cache.Entry) {
+	header := c.Writer.Header()
+	for name, values := range entry.Header {
+		header[name] = values
+	}
+	header.Set("X-Cache", "HIT")
+	header.Set("Age", strconv.Itoa(int(time.Since(entry.StoredAt).Seconds())))
+
+	c.Writer.WriteHeader(entry.Status)
+	if c.Request.Method != http.MethodHead {
+		_, _ = c.Writer.Write(entry.Body)
+	}
+}
+
+// cacheRecorder passes a service's response through to the client while
+// keeping a copy to cache. Headers the gateway set before forwarding, such
+// as the rate limit headers, belong to this request and are not kept.
+type cacheRecorder struct {
+	http.ResponseWriter
+	cache  *cache.Cache
+	before http.Header
+
+	store  bool // false once the response turns out not to be cacheable
+	status int
+	header http.Header
+	body   bytes.Buffer
+	ttl    time.Duration
+}
+
+func (r *cacheRecorder) WriteHeader(status int) {
+	if r.status != 0 {
+		return
+	}
+	r.status = status
+
+	if r.store {
+		header := r.ResponseWriter.Header()
+		r.ttl, r.store = r.cache.Prepare(status, header)
+
+		r.header = make(http.Header)
+		for name, values := range header {
+			if _, ok := r.before[name]; !ok {
+				r.header[name] = append([]string(nil), values...)
+			}
+		}
+	}
+
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *cacheRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+	if r.store {
+		if r.body.Len()+len(p) > r.cache.MaxBody() {
+			r.store = false
+			r.body = bytes.Buffer{}
+		} else {
+			r.body.Write(p)
+		}
+	}
+	return r.ResponseWriter.Write(p)
+}
+
+// Flush lets streamed responses through as they arrive
+func (r *cacheRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap gives http.ResponseController access to the client's writer
+func (r *cacheRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,31 @@
+package handler_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/contracts"
+	"ecommerce/internal/gateway/handler"
+	"ecommerce/pkg/contract"
+)
+
+// TestProductServiceContract checks the gateway's internal routes against
+// what the product service relies on when forwarding events
+func TestProductServiceContract(t *testing.T) {
+	c, err := contract.Load(contracts.FS, "product-service.api-gateway.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	router := gin.New()
+	handler.NewHTTPHandler(nil, nil, nil, nil, nil, nil, nil, nil, "", logger).RegisterInternalRoutes(router)
+
+	contract.Verify(t, c, router, map[string]contract.State{})
+}
@@ -0,0 +1,470 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	apikeydomain "ecommerce/internal/apikey/domain"
+	apikeyservice "ecommerce/internal/apikey/service"
+	"ecommerce/internal/gateway/cache"
+	"ecommerce/internal/gateway/lockout"
+	"ecommerce/internal/gateway/proxy"
+	"ecommerce/internal/gateway/ratelimit"
+	productdomain "ecommerce/internal/product/domain"
+	sessiondomain "ecommerce/internal/session/domain"
+	sessionservice "ecommerce/internal/session/service"
+	twofactorservice "ecommerce/internal/twofactor/service"
+	"ecommerce/pkg/auth"
+	customErrors "ecommerce/pkg/errors"
+	"ecommerce/pkg/events"
+	"ecommerce/pkg/metrics"
+	"ecommerce/pkg/requestlog"
+	"ecommerce/pkg/response"
+	"ecommerce/pkg/tenant"
+)
+
+// HeaderAPIKey carries a machine client's API key
+const HeaderAPIKey = "X-API-Key"
+
+// caller is who a request is made by
+type caller struct {
+	actor  *auth.Actor
+	apiKey *apikeydomain.APIKey // set when authenticated with an API key
+
+	session   *sessiondomain.Session // the token was issued in, if tracked
+	twoFactor bool                   // passed a second factor, in the session or with the identity provider
+}
+
+// HTTPHandler handles HTTP requests for the API gateway
+type HTTPHandler struct {
+	proxy          *proxy.Proxy
+	verifier       auth.Verifier
+	keys           apikeyservice.APIKeyService
+	sessions       sessionservice.SessionService     // nil when session tracking is disabled
+	twoFactor      twofactorservice.TwoFactorService // nil when two-factor authentication is disabled
+	limiter        *ratelimit.Limiter                // nil when rate limiting is disabled
+	lockout        *lockout.Guard                    // nil when lockout is disabled
+	cache          *cache.Cache                      // nil when response caching is disabled
+	identitySecret []byte
+	logger         *logrus.Logger
+}
+
+// NewHTTPHandler creates a new HTTP handler
+func NewHTTPHandler(proxy *proxy.Proxy, verifier auth.Verifier, keys apikeyservice.APIKeyService, sessions sessionservice.SessionService, twoFactor twofactorservice.TwoFactorService, limiter *ratelimit.Limiter, guard *lockout.Guard, cache *cache.Cache, identitySecret string, logger *logrus.Logger) *HTTPHandler {
+	return &HTTPHandler{
+		proxy:          proxy,
+		verifier:       verifier,
+		keys:           keys,
+		sessions:       sessions,
+		twoFactor:      twoFactor,
+		limiter:        limiter,
+		lockout:        guard,
+		cache:          cache,
+		identitySecret: []byte(identitySecret),
+		logger:         logger,
+	}
+}
+
+// RegisterRoutes registers all HTTP routes. Everything that is not a
+// gateway route is proxied.
+func (h *HTTPHandler) RegisterRoutes(router *gin.Engine) {
+	// Health check
+	router.GET("/health", h.HealthCheck)
+	router.GET("/ready", h.ReadinessCheck)
+
+	router.NoRoute(h.Forward)
+}
+
+// RegisterInternalRoutes registers the routes of the internal listener,
+// which only the services can reach
+func (h *HTTPHandler) RegisterInternalRoutes(router *gin.Engine) {
+	router.POST("/api/v1/events", h.HandleEvent)
+
+	// Prometheus metrics
+	router.GET("/metrics", gin.WrapH(metrics.Default.Handler()))
+}
+
+// HandleEvent handles an event forwarded by another service. Any change to
+// the catalog purges the response cache; a failed purge is reported so the
+// event is sent again.
+func (h *HTTPHandler) HandleEvent(c *gin.Context) {
+	var event events.Event
+	if err := c.ShouldBindJSON(&event); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	purged := false
+	if h.cache != nil && event.Source == productdomain.EventSource {
+		if err := h.cache.Purge(c.Request.Context()); err != nil {
+			h.logger.WithError(err).Error("Failed to purge response cache")
+			response.Error(c, http.StatusServiceUnavailable, "Service unavailable", nil)
+			return
+		}
+		purged = true
+		h.logger.WithField("event_type", event.Type).Info("Response cache purged successfully")
+	}
+
+	response.Success(c, http.StatusAccepted, "Event accepted successfully", gin.H{
+		"purged": purged,
+	})
+}
+
+// Forward authenticates a request and forwards it to its service. A bearer
+// token or API key is verified here; the service receives the caller's
+// identity in signed headers instead of the credential. Requests to
+// protected routes must carry valid credentials, and invalid credentials
+// are rejected on any route.
+func (h *HTTPHandler) Forward(c *gin.Context) {
+	req := c.Request
+
+	// Route on the cleaned path and forward that same path, so dot segments
+	// cannot reach a different route than the one that was authorized
+	cleaned := path.Clean("/" + req.URL.Path)
+	if strings.HasSuffix(req.URL.Path, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	req.URL.Path = cleaned
+	req.URL.RawPath = ""
+
+	routePath := strings.TrimSuffix(cleaned, "/")
+	target := h.proxy.Match(routePath)
+	if target == nil {
+		response.Error(c, http.StatusNotFound, "Route not found", nil)
+		return
+	}
+
+	auth.StripIdentity(req.Header)
+
+	caller, ok := h.authenticate(c)
+	if !ok {
+		return
+	}
+	if caller != nil {
+		req = req.WithContext(auth.WithActor(req.Context(), caller.actor))
+		c.Request = req
+		requestlog.SetClient(c, clientID(c, caller))
+	}
+
+	// A caller's credentials name their tenant; anonymous requests may
+	// name one, such as the shop a storefront page belongs to
+	if caller != nil {
+		req.Header.Del(tenant.Header)
+	} else if id := req.Header.Get(tenant.Header); id != "" && !tenant.Valid(id) {
+		err := customErrors.NewValidationError("Invalid tenant ID", nil).WithCode(customErrors.CodeInvalidTenant)
+		response.Error(c, http.StatusBadRequest, "Invalid tenant ID", err)
+		return
+	}
+
+	if h.limiter != nil && !h.allow(c, routePath, caller) {
+		return
+	}
+
+	if caller == nil && !target.IsPublic(req.Method) {
+		h.unauthorized(c, "Authentication required", nil)
+		return
+	}
+	if caller != nil && caller.apiKey != nil && !caller.apiKey.Allows(req.Method) {
+		response.Error(c, http.StatusForbidden, "API key scope does not allow this request", nil)
+		return
+	}
+	if target.AdminOnly(req.Method) && (caller == nil || caller.actor.Role != auth.RoleAdmin) {
+		response.Error(c, http.StatusForbidden, "This request requires the admin role", nil)
+		return
+	}
+	if !h.secondFactor(c, routePath, caller) {
+		return
+	}
+
+	req.Header.Del("Authorization")
+	req.Header.Del(HeaderAPIKey)
+	if caller != nil {
+		auth.SignIdentity(req.Header, caller.actor, h.identitySecret)
+	}
+
+	// Only anonymous responses are cached, since what a signed-in caller
+	// sees can depend on who they are
+	if caller == nil && h.cache != nil && h.cache.Cacheable(req, routePath) {
+		h.forwardCached(c, target)
+		return
+	}
+
+	target.ServeHTTP(c.Writer, req)
+}
+
+// RequireUser authenticates requests to the gateway's own routes. These
+// manage credentials and sessions, so they take a user's bearer token and
+// refuse API keys, which could otherwise be used to mint more keys.
+func (h *HTTPHandler) RequireUser(c *gin.Context) {
+	caller, ok := h.authenticate(c)
+	if !ok {
+		c.Abort()
+		return
+	}
+	if caller != nil {
+		requestlog.SetClient(c, clientID(c, caller))
+	}
+
+	if h.limiter != nil && !h.allow(c, c.FullPath(), caller) {
+		c.Abort()
+		return
+	}
+
+	if caller == nil {
+		h.unauthorized(c, "Authentication required", nil)
+		c.Abort()
+		return
+	}
+	if caller.apiKey != nil {
+		response.Error(c, http.StatusForbidden, "API keys cannot be used to manage credentials", nil)
+		c.Abort()
+		return
+	}
+
+	ctx := auth.WithActor(c.Request.Context(), caller.actor)
+	if caller.session != nil {
+		ctx = sessionservice.WithSessionID(ctx, caller.session.ID)
+	}
+	if caller.twoFactor {
+		ctx = twofactorservice.WithVerified(ctx)
+	}
+	c.Request = c.Request.WithContext(ctx)
+
+	if !h.secondFactor(c, c.FullPath(), caller) {
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// authenticate resolves the caller from a bearer token or an API key. It
+// returns a nil caller for anonymous requests, and responds and returns
+// false when the credentials are invalid.
+func (h *HTTPHandler) authenticate(c *gin.Context) (*caller, bool) {
+	req := c.Request
+
+	header := req.Header.Get("Authorization")
+	key := req.Header.Get(HeaderAPIKey)
+	if header != "" && key != "" {
+		h.unauthorized(c, "Send either a bearer token or an API key", nil)
+		return nil, false
+	}
+
+	if key != "" {
+		// Keys are guessed from an IP, not for an account, so that is what
+		// is locked out
+		subject := "ip:" + c.ClientIP()
+		if h.locked(c, subject) {
+			return nil, false
+		}
+
+		apiKey, err := h.keys.ResolveAPIKey(req.Context(), key)
+		if err != nil {
+			if customErrors.IsUnauthorized(err) {
+				if !h.failed(c, subject) {
+					h.unauthorized(c, "Invalid API key", nil)
+				}
+				return nil, false
+			}
+			h.logger.WithError(err).Error("Failed to resolve API key")
+			response.Error(c, http.StatusServiceUnavailable, "Authentication unavailable", nil)
+			return nil, false
+		}
+		return &caller{
+			actor:  &auth.Actor{ID: apiKey.OwnerID, Role: apiKey.Role, Tenant: apiKey.TenantID},
+			apiKey: apiKey,
+		}, true
+	}
+
+	if header == "" {
+		return nil, true
+	}
+
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok {
+		h.unauthorized(c, "Unsupported authorization scheme", nil)
+		return nil, false
+	}
+
+	claims, err := h.verifier.Verify(req.Context(), token)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidToken) || errors.Is(err, auth.ErrTokenExpired) {
+			h.unauthorized(c, "Invalid or expired token", err)
+			return nil, false
+		}
+		h.logger.WithError(err).Error("Failed to verify token")
+		response.Error(c, http.StatusServiceUnavailable, "Authentication unavailable", nil)
+		return nil, false
+	}
+	if claims.Subject == "" {
+		h.unauthorized(c, "Invalid or expired token", nil)
+		return nil, false
+	}
+
+	// Tokens outlive the sessions they were issued in, so the session is
+	// checked too. Requests are let through when Redis is unreachable, as
+	// with rate limiting.
+	var session *sessiondomain.Session
+	if h.sessions != nil {
+		session, err = h.sessions.Track(req.Context(), claims, req.UserAgent(), c.ClientIP())
+		if err != nil {
+			if customErrors.IsUnauthorized(err) {
+				h.unauthorized(c, "Session has ended", err)
+				return nil, false
+			}
+			h.logger.WithError(err).Error("Failed to track session")
+		}
+	}
+
+	return &caller{
+		actor:     claims.Actor(),
+		session:   session,
+		twoFactor: (session != nil && session.TwoFactorAt != nil) || slices.Contains(claims.AMR, "mfa") || slices.Contains(claims.AMR, "otp"),
+	}, true
+}
+
+// secondFactor refuses protected changes by users who must pass a second
+// factor and have not, responding 403. API keys are let through, as they
+// are minted on routes that are themselves protected.
+func (h *HTTPHandler) secondFactor(c *gin.Context, path string, caller *caller) bool {
+	if h.twoFactor == nil || caller == nil || caller.apiKey != nil || caller.twoFactor {
+		return true
+	}
+	if !h.twoFactor.Protects(c.Request.Method, path) {
+		return true
+	}
+
+	required, err := h.twoFactor.Required(c.Request.Context())
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to check two-factor requirement")
+		response.Error(c, http.StatusServiceUnavailable, "Authentication unavailable", nil)
+		return false
+	}
+	if required {
+		err := customErrors.NewForbiddenError("Two-factor verification required", nil).WithCode(customErrors.CodeTwoFactorRequired)
+		response.Error(c, http.StatusForbidden, "Two-factor verification required", err)
+		return false
+	}
+	return true
+}
+
+// locked responds 429 when a subject is locked out of trying API keys.
+// Redis being unreachable lets requests through, as with rate limiting.
+func (h *HTTPHandler) locked(c *gin.Context, subject string) bool {
+	if h.lockout == nil {
+		return false
+	}
+
+	status, err := h.lockout.Check(c.Request.Context(), subject)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to check lockout")
+		return false
+	}
+	if status.Locked == 0 {
+		return false
+	}
+
+	lockout.Audit(c.Request.Context(), h.logger, lockout.EventLockedRefused, "api_key", subject, nil)
+	h.lockedOut(c, status.Locked)
+	return true
+}
+
+// failed counts an invalid API key against a subject, responding 429 and
+// returning true when that locks it out
+func (h *HTTPHandler) failed(c *gin.Context, subject string) bool {
+	if h.lockout == nil {
+		return false
+	}
+
+	status, err := h.lockout.Fail(c.Request.Context(), subject)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to count API key failure")
+		return false
+	}
+
+	lockout.Audit(c.Request.Context(), h.logger, lockout.EventAuthFailed, "api_key", subject, logrus.Fields{"failures": status.Failures})
+	if status.Locked == 0 {
+		return false
+	}
+
+	lockout.Audit(c.Request.Context(), h.logger, lockout.EventLockedOut, "api_key", subject, logrus.Fields{"locked_for": status.Locked.String()})
+	h.lockedOut(c, status.Locked)
+	return true
+}
+
+func (h *HTTPHandler) lockedOut(c *gin.Context, d time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(ceilSeconds(d)))
+	err := customErrors.NewForbiddenError("Too many invalid API keys", nil).WithCode(customErrors.CodeLockedOut)
+	response.Error(c, http.StatusTooManyRequests, "Too many invalid API keys", err)
+}
+
+// clientID tells callers apart by their API key, the API client their
+// token names, or by IP when anonymous
+func clientID(c *gin.Context, caller *caller) string {
+	if caller == nil {
+		return "ip:" + c.ClientIP()
+	}
+	if caller.apiKey != nil {
+		return "key:" + caller.apiKey.ID.String()
+	}
+	return "client:" + caller.actor.ID
+}
+
+// allow counts a request against the caller's rate limit and sets the
+// X-RateLimit headers, responding 429 when the limit is spent. Callers are
+// told apart by clientID. Requests are let through when Redis is
+// unreachable, so a limiter outage does not take the API down with it.
+func (h *HTTPHandler) allow(c *gin.Context, path string, caller *caller) bool {
+	result, err := h.limiter.Allow(c.Request.Context(), path, clientID(c, caller))
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to apply rate limit")
+		return true
+	}
+
+	c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+	if !result.Allowed {
+		c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+		response.Error(c, http.StatusTooManyRequests, "Rate limit exceeded", nil)
+		return false
+	}
+	return true
+}
+
+// ceilSeconds rounds a duration up to whole seconds for a header
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+func (h *HTTPHandler) unauthorized(c *gin.Context, message string, err error) {
+	c.Header("WWW-Authenticate", "Bearer")
+	response.Error(c, http.StatusUnauthorized, message, err)
+}
+
+// HealthCheck handles health check requests
+func (h *HTTPHandler) HealthCheck(c *gin.Context) {
+	response.Success(c, http.StatusOK, "Service is healthy", gin.H{
+		"service": "api-gateway",
+		"status":  "healthy",
+	})
+}
+
+// ReadinessCheck handles readiness check requests
+func (h *HTTPHandler) ReadinessCheck(c *gin.Context) {
+	response.Success(c, http.StatusOK, "Service is ready", gin.H{
+		"service": "api-gateway",
+		"status":  "ready",
+	})
+}
@@ -0,0 +1,36 @@
+package lockout
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/pkg/logger"
+	"ecommerce/pkg/metrics"
+)
+
+// Security events, recorded for monitoring
+const (
+	EventAuthFailed    = "auth.failed"    // a secret was guessed wrong
+	EventLockedOut     = "auth.locked"    // a subject failed too often and was locked out
+	EventLockedRefused = "auth.refused"   // an attempt was refused during a lockout
+	EventCaptchaFailed = "captcha.failed" // a required CAPTCHA was missing or not solved
+)
+
+var securityEvents = metrics.NewCounterVec(
+	"gateway_security_events_total",
+	"Security events at the gateway by event and what was attempted (two_factor or api_key).",
+	"event", "kind",
+)
+
+// Audit records a security event as a warning marked for monitoring, with
+// a counter to alert on. kind says what was attempted and subject who by.
+func Audit(ctx context.Context, log *logrus.Logger, event, kind, subject string, fields logrus.Fields) {
+	securityEvents.Inc(event, kind)
+	logger.FromContext(ctx, log).WithFields(fields).WithFields(logrus.Fields{
+		"audit":   "security",
+		"event":   event,
+		"kind":    kind,
+		"subject": subject,
+	}).Warn("Security event")
+}
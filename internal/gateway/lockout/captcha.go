@@ -0,0 +1,76 @@
+package lockout
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Captcha checks the token a CAPTCHA widget gave a client once it was
+// solved
+type Captcha interface {
+	Verify(ctx context.Context, token, ip string) (bool, error)
+}
+
+// SiteVerify checks CAPTCHA tokens with a siteverify endpoint, the API
+// reCAPTCHA, hCaptcha and Turnstile share
+type SiteVerify struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewSiteVerify creates a siteverify CAPTCHA checker
+func NewSiteVerify(verifyURL, secret string, timeout time.Duration) *SiteVerify {
+	return &SiteVerify{
+		url:    verifyURL,
+		secret: secret,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// siteVerifyResponse is the subset of a siteverify response the gateway
+// uses
+type siteVerifyResponse struct {
+	Success    bool     `json:"success"`
+	ErrorCodes []string `json:"error-codes"`
+}
+
+func (s *SiteVerify) Verify(ctx context.Context, token, ip string) (bool, error) {
+	if token == "" {
+		return false, nil
+	}
+
+	form := url.Values{}
+	form.Set("secret", s.secret)
+	form.Set("response", token)
+	if ip != "" {
+		form.Set("remoteip", ip)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, fmt.Errorf("failed to create CAPTCHA request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to verify CAPTCHA: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("CAPTCHA verification returned status %d", resp.StatusCode)
+	}
+
+	var result siteVerifyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, fmt.Errorf("failed to decode CAPTCHA response: %w", err)
+	}
+	return result.Success, nil
+}
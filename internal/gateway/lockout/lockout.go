@@ -0,0 +1,133 @@
+package lockout
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix namespaces the guard's counters in Redis. Each subject has its
+// recent failures under lockout:{<subject>}:failures, its lockout under
+// lockout:{<subject>}:locked and how many lockouts followed each other under
+// lockout:{<subject>}:level. The braces keep a subject's keys in one Redis
+// Cluster slot, as the fail script takes them all.
+const keyPrefix = "lockout:"
+
+// Policy says how failures lead to lockouts
+type Policy struct {
+	Threshold    int           // failures in a row that lock a subject out
+	Decay        time.Duration // failures are forgotten this long after the last one
+	Duration     time.Duration // of the first lockout; each one that follows doubles it
+	MaxDuration  time.Duration // a lockout lasts at most
+	Reset        time.Duration // lockouts stop escalating this long after the last one ends
+	CaptchaAfter int           // failures after which a CAPTCHA is asked for; 0 never
+}
+
+// Status is where a subject stands
+type Status struct {
+	Failures int           // since the last success, lockout or decay
+	Locked   time.Duration // left of the current lockout, zero when not locked out
+	Captcha  bool          // a CAPTCHA must be solved before the next attempt
+}
+
+// Guard counts failed attempts at a secret, such as a two-factor code, and
+// locks subjects out when they fail too often in a row. Counters live in
+// Redis so every gateway replica sees the same ones.
+type Guard struct {
+	client redis.UniversalClient
+	policy Policy
+}
+
+// New creates a guard
+func New(client redis.UniversalClient, policy Policy) *Guard {
+	return &Guard{client: client, policy: policy}
+}
+
+func keys(subject string) []string {
+	prefix := keyPrefix + "{" + subject + "}:"
+	return []string{prefix + "failures", prefix + "locked", prefix + "level"}
+}
+
+// Check returns a subject's status
+func (g *Guard) Check(ctx context.Context, subject string) (Status, error) {
+	k := keys(subject)
+	pipe := g.client.Pipeline()
+	failures := pipe.Get(ctx, k[0])
+	locked := pipe.PTTL(ctx, k[1])
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return Status{}, fmt.Errorf("failed to check lockout: %w", err)
+	}
+
+	count, _ := failures.Int()
+	return g.status(count, locked.Val()), nil
+}
+
+// fail counts a failure, locking the subject out once the threshold is
+// reached. The lockout doubles with each one that follows within the reset
+// period. Running as one script, concurrent failures cannot both slip under
+// the threshold.
+var fail = redis.NewScript(`
+local threshold = tonumber(ARGV[1])
+local decay_ms = tonumber(ARGV[2])
+local duration_ms = tonumber(ARGV[3])
+local max_ms = tonumber(ARGV[4])
+local reset_ms = tonumber(ARGV[5])
+
+local failures = redis.call('INCR', KEYS[1])
+redis.call('PEXPIRE', KEYS[1], decay_ms)
+if failures < threshold then
+	return {failures, 0}
+end
+
+redis.call('DEL', KEYS[1])
+local level = redis.call('INCR', KEYS[3])
+local locked_ms = math.min(max_ms, duration_ms * math.pow(2, level - 1))
+locked_ms = math.floor(locked_ms)
+redis.call('SET', KEYS[2], level, 'PX', locked_ms)
+redis.call('PEXPIRE', KEYS[3], locked_ms + reset_ms)
+return {failures, locked_ms}
+`)
+
+// Fail counts a failed attempt and returns the subject's status after it.
+// A subject that reaches the threshold is locked out and its failures
+// start over.
+func (g *Guard) Fail(ctx context.Context, subject string) (Status, error) {
+	result, err := fail.Run(ctx, g.client, keys(subject),
+		g.policy.Threshold,
+		g.policy.Decay.Milliseconds(),
+		g.policy.Duration.Milliseconds(),
+		g.policy.MaxDuration.Milliseconds(),
+		g.policy.Reset.Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return Status{}, fmt.Errorf("failed to count failure: %w", err)
+	}
+
+	locked := time.Duration(result[1]) * time.Millisecond
+	if locked > 0 {
+		return Status{Failures: int(result[0]), Locked: locked}, nil
+	}
+	return g.status(int(result[0]), 0), nil
+}
+
+// Succeed forgets a subject's failures. Its lockout level is kept, so
+// someone who guesses right once in a while still escalates.
+func (g *Guard) Succeed(ctx context.Context, subject string) error {
+	if err := g.client.Del(ctx, keys(subject)[0]).Err(); err != nil {
+		return fmt.Errorf("failed to reset failures: %w", err)
+	}
+	return nil
+}
+
+func (g *Guard) status(failures int, locked time.Duration) Status {
+	if locked < 0 {
+		locked = 0
+	}
+	return Status{
+		Failures: failures,
+		Locked:   locked,
+		Captcha:  g.policy.CaptchaAfter > 0 && failures >= g.policy.CaptchaAfter,
+	}
+}
@@ -0,0 +1,197 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/gateway/config"
+	"ecommerce/pkg/middleware"
+	"ecommerce/pkg/resilience"
+)
+
+// Route sends the requests under a path prefix to a service
+type Route struct {
+	Prefix   string
+	Upstream string
+	Public   []string // methods that may be called without a token
+	Admin    []string // methods only admins may call
+	Internal bool     // not served by the gateway at all
+}
+
+var readOnly = []string{http.MethodGet, http.MethodHead}
+
+// Routes returns the gateway's route table. Internal endpoints, such as
+// event intake and stock reservations, are deliberately absent so they are
+// only reachable inside the cluster. Internal endpoints under a route that
+// is served are listed as internal. Endpoints other services call that
+// admins may also use, such as capturing and refunding payments, are
+// limited to admins instead.
+func Routes(services config.ServicesConfig) []Route {
+	return []Route{
+		{Prefix: "/api/v1/products", Upstream: services.ProductURL, Public: readOnly},
+		{Prefix: "/api/v1/categories", Upstream: services.ProductURL, Public: readOnly},
+		{Prefix: "/api/v1/brands", Upstream: services.ProductURL, Public: readOnly},
+		{Prefix: "/api/v1/feeds", Upstream: services.ProductURL, Public: readOnly},
+		{Prefix: "/api/v1/sitemaps", Upstream: services.ProductURL, Public: readOnly},
+		{Prefix: "/api/v1/attributes", Upstream: services.ProductURL},
+		{Prefix: "/api/v1/reviews", Upstream: services.ProductURL},
+		{Prefix: "/api/v1/revisions", Upstream: services.ProductURL},
+		{Prefix: "/api/v1/changesets", Upstream: services.ProductURL},
+		{Prefix: "/api/v1/vendors", Upstream: services.ProductURL},
+		{Prefix: "/api/v1/imports", Upstream: services.ProductURL},
+		{Prefix: "/api/v1/inventory", Upstream: services.ProductURL},
+		{Prefix: "/api/v1/warehouses", Upstream: services.ProductURL},
+		{Prefix: "/api/v1/entitlements", Upstream: services.ProductURL},
+		{Prefix: "/api/v1/search", Upstream: services.ProductURL},
+		{Prefix: "/api/v1/cache", Upstream: services.ProductURL},
+		{Prefix: "/api/v1/audit", Upstream: services.ProductURL},
+		{Prefix: "/api/v1/checkout", Upstream: services.OrderURL},
+		{Prefix: "/api/v1/orders", Upstream: services.OrderURL},
+		{Prefix: "/api/v1/returns", Upstream: services.OrderURL},
+		{Prefix: "/api/v1/payments", Upstream: services.PaymentURL, Admin: []string{http.MethodPost}},
+		{Prefix: "/api/v1/payments/webhooks", Upstream: services.PaymentURL, Public: []string{http.MethodPost}},
+		{Prefix: "/api/v1/gift-cards", Upstream: services.PaymentURL},
+		{Prefix: "/api/v1/gift-cards/void", Upstream: services.PaymentURL, Admin: []string{http.MethodPost}},
+		{Prefix: "/api/v1/gift-cards/balance", Upstream: services.PaymentURL, Public: []string{http.MethodPost}},
+		{Prefix: "/api/v1/loyalty", Upstream: services.PaymentURL},
+		{Prefix: "/api/v1/loyalty/void", Upstream: services.PaymentURL, Admin: []string{http.MethodPost}},
+		{Prefix: "/api/v1/promotions", Upstream: services.PromotionURL},
+		{Prefix: "/api/v1/promotions/redeem", Upstream: services.PromotionURL, Internal: true},
+		{Prefix: "/api/v1/promotions/evaluate", Upstream: services.PromotionURL, Public: []string{http.MethodPost}},
+		{Prefix: "/api/v1/notifications", Upstream: services.NotificationURL},
+		{Prefix: "/api/v1/notifications/callbacks", Upstream: services.NotificationURL, Public: []string{http.MethodPost}},
+		{Prefix: "/api/v1/webhooks", Upstream: services.WebhookURL},
+		{Prefix: "/api/v1/tax", Upstream: services.TaxURL},
+		{Prefix: "/api/v1/tax/calculate", Upstream: services.TaxURL, Public: []string{http.MethodPost}},
+	}
+}
+
+// Target is a resolved route
+type Target struct {
+	Route
+	proxy *httputil.ReverseProxy
+}
+
+// IsPublic reports whether requests with the given method may be made
+// without a token
+func (t *Target) IsPublic(method string) bool {
+	return hasMethod(t.Public, method)
+}
+
+// AdminOnly reports whether requests with the given method need the admin
+// role
+func (t *Target) AdminOnly(method string) bool {
+	return hasMethod(t.Admin, method)
+}
+
+// hasMethod reports whether method is one of methods
+func hasMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeHTTP forwards a request to the route's service
+func (t *Target) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	t.proxy.ServeHTTP(w, r)
+}
+
+// Proxy matches requests to routes
+type Proxy struct {
+	targets []*Target
+}
+
+// New creates a proxy for a route table. Each service gets a circuit
+// breaker shared by all of its routes, so a failing service is cut off
+// quickly instead of tying up the gateway's connections. Services are
+// called over TLS with tlsConfig, such as a mutual TLS client
+// configuration; nil keeps the defaults.
+func New(routes []Route, services config.ServicesConfig, tlsConfig *tls.Config, logger *logrus.Logger) (*Proxy, error) {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.ResponseHeaderTimeout = time.Duration(services.Timeout) * time.Second
+	if tlsConfig != nil {
+		base.TLSClientConfig = tlsConfig
+	}
+
+	transports := make(map[string]*resilience.Transport)
+	targets := make([]*Target, 0, len(routes))
+	for _, route := range routes {
+		upstream, err := url.Parse(route.Upstream)
+		if err != nil || upstream.Host == "" {
+			return nil, fmt.Errorf("invalid upstream %q for %s", route.Upstream, route.Prefix)
+		}
+
+		transport, ok := transports[upstream.Host]
+		if !ok {
+			transport = &resilience.Transport{
+				Base:    base,
+				Breaker: resilience.NewBreaker(services.BreakerFailures, time.Duration(services.BreakerOpenTimeout)*time.Second),
+				Retry: resilience.RetryPolicy{
+					Attempts:  services.RetryAttempts,
+					BaseDelay: 50 * time.Millisecond,
+					MaxDelay:  time.Second,
+				},
+			}
+			transports[upstream.Host] = transport
+		}
+
+		proxy := httputil.NewSingleHostReverseProxy(upstream)
+		proxy.Transport = transport
+		// The gateway sends its own security headers; the service's would
+		// be added to them
+		proxy.ModifyResponse = func(resp *http.Response) error {
+			for _, name := range middleware.SecurityHeaderNames {
+				resp.Header.Del(name)
+			}
+			return nil
+		}
+		proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+			status := http.StatusBadGateway
+			if errors.Is(err, resilience.ErrCircuitOpen) {
+				status = http.StatusServiceUnavailable
+				logger.WithField("upstream", route.Upstream).Warn("Upstream circuit open, failing fast")
+			} else {
+				logger.WithError(err).WithField("path", r.URL.Path).Error("Upstream request failed")
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			w.Write([]byte(`{"success":false,"message":"Service unavailable"}`))
+		}
+
+		targets = append(targets, &Target{Route: route, proxy: proxy})
+	}
+
+	// The most specific prefix wins
+	sort.SliceStable(targets, func(i, j int) bool {
+		return len(targets[i].Prefix) > len(targets[j].Prefix)
+	})
+
+	return &Proxy{targets: targets}, nil
+}
+
+// Match returns the route for a cleaned request path, or nil, also for
+// internal routes. Prefixes match whole path segments, so /api/v1/products
+// does not match /api/v1/productsx.
+func (p *Proxy) Match(path string) *Target {
+	for _, target := range p.targets {
+		if path == target.Prefix || strings.HasPrefix(path, target.Prefix+"/") {
+			if target.Internal {
+				return nil
+			}
+			return target
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package proxy_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/contracts"
+	"ecommerce/internal/gateway/config"
+	"ecommerce/internal/gateway/proxy"
+	"ecommerce/pkg/contract"
+)
+
+// TestProductContract sends the anonymous catalog requests the storefront
+// makes through the gateway to the product service as the contract
+// describes it
+func TestProductContract(t *testing.T) {
+	c, err := contract.Load(contracts.FS, "api-gateway.product-service.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := contract.NewMock(t, c)
+
+	services := config.ServicesConfig{
+		ProductURL:      mock.URL,
+		OrderURL:        "http://order.invalid",
+		PaymentURL:      "http://payment.invalid",
+		PromotionURL:    "http://promotion.invalid",
+		NotificationURL: "http://notification.invalid",
+		WebhookURL:      "http://webhook.invalid",
+		TaxURL:          "http://tax.invalid",
+		Timeout:         5,
+		RetryAttempts:   1,
+		BreakerFailures: 5,
+	}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	p, err := proxy.New(proxy.Routes(services), services, nil, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, interaction := range c.Interactions {
+		path := strings.NewReplacer(
+			"{product_id}", "7d3c1f3e-2f0a-4c7e-9a55-1b2f3c4d5e6f",
+			"{slug}", "contract-product",
+		).Replace(interaction.Request.Path)
+
+		target := p.Match(path)
+		if target == nil || target.Upstream != services.ProductURL {
+			t.Errorf("%s: %s is not routed to the product service", interaction.Description, path)
+			continue
+		}
+		if !target.IsPublic(interaction.Request.Method) {
+			t.Errorf("%s: %s %s requires a token", interaction.Description, interaction.Request.Method, path)
+			continue
+		}
+
+		mock.Expect(interaction.Description)
+		rec := httptest.NewRecorder()
+		target.ServeHTTP(rec, httptest.NewRequest(interaction.Request.Method, path, nil))
+		if rec.Code != interaction.Response.Status {
+			t.Errorf("%s: status %d, want %d", interaction.Description, rec.Code, interaction.Response.Status)
+		}
+	}
+}
+
+// TestAdminRoutes checks that the endpoints other services use to move
+// money are limited to admins when called through the gateway, and that
+// internal endpoints are not served at all
+func TestAdminRoutes(t *testing.T) {
+	services := config.ServicesConfig{
+		ProductURL:      "http://product.invalid",
+		OrderURL:        "http://order.invalid",
+		PaymentURL:      "http://payment.invalid",
+		PromotionURL:    "http://promotion.invalid",
+		NotificationURL: "http://notification.invalid",
+		WebhookURL:      "http://webhook.invalid",
+		TaxURL:          "http://tax.invalid",
+		Timeout:         5,
+		RetryAttempts:   1,
+		BreakerFailures: 5,
+	}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	p, err := proxy.New(proxy.Routes(services), services, nil, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		admin  bool
+	}{
+		{http.MethodPost, "/api/v1/payments", true},
+		{http.MethodPost, "/api/v1/payments/void", true},
+		{http.MethodPost, "/api/v1/payments/7d3c1f3e-2f0a-4c7e-9a55-1b2f3c4d5e6f/capture", true},
+		{http.MethodPost, "/api/v1/payments/7d3c1f3e-2f0a-4c7e-9a55-1b2f3c4d5e6f/refunds", true},
+		{http.MethodGet, "/api/v1/payments/7d3c1f3e-2f0a-4c7e-9a55-1b2f3c4d5e6f", false},
+		{http.MethodPost, "/api/v1/payments/webhooks/stripe", false},
+		{http.MethodPost, "/api/v1/gift-cards/void", true},
+		{http.MethodPost, "/api/v1/gift-cards/balance", false},
+		{http.MethodPost, "/api/v1/loyalty/void", true},
+		{http.MethodPost, "/api/v1/loyalty/redeem", false},
+	}
+	for _, tt := range tests {
+		target := p.Match(tt.path)
+		if target == nil {
+			t.Errorf("%s is not routed", tt.path)
+			continue
+		}
+		if target.AdminOnly(tt.method) != tt.admin {
+			t.Errorf("%s %s: admin only %v, want %v", tt.method, tt.path, !tt.admin, tt.admin)
+		}
+	}
+
+	for _, path := range []string{"/api/v1/promotions/redeem", "/api/v1/promotions/redeem/x"} {
+		if target := p.Match(path); target != nil {
+			t.Errorf("internal %s is routed to %s", path, target.Upstream)
+		}
+	}
+	if target := p.Match("/api/v1/promotions/redeemed"); target == nil {
+		t.Errorf("/api/v1/promotions/redeemed is not routed")
+	}
+}
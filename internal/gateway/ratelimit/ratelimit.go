@@ -0,0 +1,180 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix namespaces the limiter's buckets in Redis
+const keyPrefix = "ratelimit:"
+
+// Limit allows a number of requests per window. Clients may burst up to the
+// full number, after which requests are admitted at the average rate.
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// ParseLimit parses a limit written as requests/unit, such as 100/m. The
+// unit is s, m or h.
+func ParseLimit(value string) (Limit, error) {
+	requests, unit, ok := strings.Cut(strings.TrimSpace(value), "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q", value)
+	}
+
+	n, err := strconv.Atoi(requests)
+	if err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q", value)
+	}
+
+	windows := map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}
+	window, ok := windows[unit]
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit unit in %q", value)
+	}
+
+	return Limit{Requests: n, Window: window}, nil
+}
+
+// Rule applies a limit to the requests under a path prefix
+type Rule struct {
+	Prefix string
+	Limit  Limit
+}
+
+// ParseRules parses per-route limits written as prefix=limit pairs
+// separated by commas, such as /api/v1/checkout=10/m,/api/v1/orders=120/m
+func ParseRules(value string) ([]Rule, error) {
+	var rules []Rule
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		prefix, limit, ok := strings.Cut(entry, "=")
+		if !ok || !strings.HasPrefix(prefix, "/") {
+			return nil, fmt.Errorf("invalid route limit %q", entry)
+		}
+		parsed, err := ParseLimit(limit)
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, Rule{Prefix: strings.TrimSuffix(prefix, "/"), Limit: parsed})
+	}
+	return rules, nil
+}
+
+// Result is the outcome of counting a request against its limit
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until the next request is admitted, when denied
+}
+
+// tokenBucket refills a bucket for the time since it was last used, then
+// takes a token if one is left. Time comes from the Redis server so every
+// gateway replica shares one clock. Buckets expire once they would be full,
+// so idle clients cost nothing.
+var tokenBucket = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local refill_ms = tonumber(ARGV[2])
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+
+tokens = math.min(capacity, tokens + math.max(0, now - ts) / refill_ms)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil((capacity - tokens) * refill_ms) + 1000)
+
+return {allowed, tostring(tokens)}
+`)
+
+// Limiter is a token bucket rate limiter backed by Redis, with a bucket per
+// client and route rule
+type Limiter struct {
+	client   redis.UniversalClient
+	rules    []Rule
+	fallback Limit
+}
+
+// New creates a limiter. Requests that match no rule share the fallback
+// limit.
+func New(client redis.UniversalClient, rules []Rule, fallback Limit) *Limiter {
+	sorted := append([]Rule(nil), rules...)
+	// The most specific prefix wins
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i].Prefix) > len(sorted[j].Prefix)
+	})
+
+	return &Limiter{
+		client:   client,
+		rules:    sorted,
+		fallback: fallback,
+	}
+}
+
+// Allow counts a request by a client to a path against its limit
+func (l *Limiter) Allow(ctx context.Context, path, client string) (*Result, error) {
+	scope, limit := "default", l.fallback
+	for _, rule := range l.rules {
+		if path == rule.Prefix || strings.HasPrefix(path, rule.Prefix+"/") {
+			scope, limit = rule.Prefix, rule.Limit
+			break
+		}
+	}
+
+	// Milliseconds for one token to be refilled
+	refill := float64(limit.Window.Milliseconds()) / float64(limit.Requests)
+
+	values, err := tokenBucket.Run(ctx, l.client, []string{keyPrefix + scope + ":" + client}, limit.Requests, refill).Slice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply rate limit: %w", err)
+	}
+	if len(values) != 2 {
+		return nil, fmt.Errorf("failed to apply rate limit: unexpected reply %v", values)
+	}
+
+	allowed, _ := values[0].(int64)
+	remaining, err := strconv.ParseFloat(fmt.Sprint(values[1]), 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply rate limit: %w", err)
+	}
+
+	result := &Result{
+		Allowed:   allowed == 1,
+		Limit:     limit.Requests,
+		Remaining: int(math.Floor(remaining)),
+		Reset:     time.Duration((float64(limit.Requests) - remaining) * refill * float64(time.Millisecond)),
+	}
+	if !result.Allowed {
+		result.RetryAfter = time.Duration((1 - remaining) * refill * float64(time.Millisecond))
+	}
+	return result, nil
+}
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	productconfig "ecommerce/internal/product/config"
+)
+
+// Config holds all configuration for the notification service
+type Config struct {
+	Env      string
+	HTTP     productconfig.HTTPConfig
+	Database productconfig.DatabaseConfig
+	Auth     productconfig.AuthConfig
+	Debug    productconfig.DebugConfig
+	MTLS     productconfig.MTLSConfig
+	Logger   productconfig.LoggerConfig
+	Jobs     productconfig.JobsConfig
+	Email    EmailConfig
+	SendGrid SendGridConfig
+	SES      SESConfig
+	SMS      SMSConfig
+	Twilio   TwilioConfig
+	Delivery DeliveryConfig
+	Alerts   AlertsConfig
+}
+
+// EmailConfig selects and configures the email provider
+type EmailConfig struct {
+	Provider string // log, sendgrid, ses
+	From     string
+	FromName string
+}
+
+// SendGridConfig holds SendGrid API configuration
+type SendGridConfig struct {
+	APIKey           string
+	APIURL           string
+	WebhookPublicKey string // base64 key that signs event webhooks
+}
+
+// SESConfig holds Amazon SES API configuration
+type SESConfig struct {
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	Endpoint        string // overrides the regional endpoint
+}
+
+// SMSConfig selects the SMS provider
+type SMSConfig struct {
+	Provider string // log, twilio
+}
+
+// TwilioConfig holds Twilio API configuration
+type TwilioConfig struct {
+	AccountSID  string
+	AuthToken   string
+	From        string
+	APIURL      string
+	CallbackURL string // public URL of the Twilio status callback route
+}
+
+// DeliveryConfig holds delivery configuration. Each notification is sent
+// by a background job, run by the workers JOBS_* configure.
+type DeliveryConfig struct {
+	MaxAttempts  int
+	RetryBackoff int // seconds before the first retry; doubles per attempt
+	Timeout      int // seconds per provider request
+}
+
+// AlertsConfig holds configuration for operational alerts
+type AlertsConfig struct {
+	AdminEmail        string
+	LowStockThreshold int // the product service's LOW_STOCK_THRESHOLD, quoted in alerts for products without their own
+}
+
+// Load loads configuration from environment variables. The HTTP, database
+// and auth settings use the same variables as the product service.
+func Load() (*Config, error) {
+	shared, err := productconfig.Load()
+	if err != nil {
+		return nil, err
+	}
+	return &Config{
+		Env:      shared.Env,
+		HTTP:     shared.HTTP,
+		Database: shared.Database,
+		Auth:     shared.Auth,
+		Debug:    shared.Debug,
+		MTLS:     shared.MTLS,
+		Logger:   shared.Logger,
+		Jobs:     shared.Jobs,
+		Email: EmailConfig{
+			Provider: getEnv("EMAIL_PROVIDER", "log"),
+			From:     getEnv("EMAIL_FROM", "no-reply@example.com"),
+			FromName: getEnv("EMAIL_FROM_NAME", "Shop"),
+		},
+		SendGrid: SendGridConfig{
+			APIKey:           getEnv("SENDGRID_API_KEY", ""),
+			APIURL:           getEnv("SENDGRID_API_URL", "https://api.sendgrid.com"),
+			WebhookPublicKey: getEnv("SENDGRID_WEBHOOK_PUBLIC_KEY", ""),
+		},
+		SES: SESConfig{
+			Region:          getEnv("AWS_REGION", "us-east-1"),
+			AccessKeyID:     getEnv("AWS_ACCESS_KEY_ID", ""),
+			SecretAccessKey: getEnv("AWS_SECRET_ACCESS_KEY", ""),
+			Endpoint:        getEnv("SES_ENDPOINT", ""),
+		},
+		SMS: SMSConfig{
+			Provider: getEnv("SMS_PROVIDER", "log"),
+		},
+		Twilio: TwilioConfig{
+			AccountSID:  getEnv("TWILIO_ACCOUNT_SID", ""),
+			AuthToken:   getEnv("TWILIO_AUTH_TOKEN", ""),
+			From:        getEnv("TWILIO_FROM", ""),
+			APIURL:      getEnv("TWILIO_API_URL", "https://api.twilio.com"),
+			CallbackURL: getEnv("TWILIO_CALLBACK_URL", ""),
+		},
+		Delivery: DeliveryConfig{
+			MaxAttempts:  getEnvAsInt("DELIVERY_MAX_ATTEMPTS", 5),
+			RetryBackoff: getEnvAsInt("DELIVERY_RETRY_BACKOFF", 30),
+			Timeout:      getEnvAsInt("DELIVERY_TIMEOUT", 10),
+		},
+		Alerts: AlertsConfig{
+			AdminEmail:        getEnv("ALERT_ADMIN_EMAIL", ""),
+			LowStockThreshold: getEnvAsInt("ALERT_LOW_STOCK_THRESHOLD", 5),
+		},
+	}, nil
+}
+
+// Validate reports every setting that is missing or invalid, so the service
+// refuses to start rather than run misconfigured
+func (c *Config) Validate() error {
+	errs := []error{
+		productconfig.ValidateEnv(c.Env),
+		c.HTTP.Validate(),
+		c.Database.Validate(c.Env),
+		c.Logger.Validate(),
+		c.MTLS.Validate(),
+		c.Auth.Validate(),
+		c.Jobs.Validate(),
+	}
+	if c.Delivery.MaxAttempts <= 0 {
+		errs = append(errs, errors.New("DELIVERY_MAX_ATTEMPTS must be at least 1"))
+	}
+	switch c.Email.Provider {
+	case "log", "ses":
+	case "sendgrid":
+		if c.SendGrid.APIKey == "" {
+			errs = append(errs, errors.New("SENDGRID_API_KEY is required for the sendgrid provider"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("EMAIL_PROVIDER must be log, sendgrid or ses, not %q", c.Email.Provider))
+	}
+	switch c.SMS.Provider {
+	case "log":
+	case "twilio":
+		if c.Twilio.AccountSID == "" || c.Twilio.AuthToken == "" || c.Twilio.From == "" {
+			errs = append(errs, errors.New("TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN and TWILIO_FROM are required for the twilio provider"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("SMS_PROVIDER must be log or twilio, not %q", c.SMS.Provider))
+	}
+	return errors.Join(errs...)
+}
+
+// getEnv gets an environment variable with a default value
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvAsInt gets an environment variable as integer with a default value
+func getEnvAsInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event types the notification service reacts to
+const (
+	EventOrderCreated     = "order.created"
+	EventStockLow         = "stock.low"
+	EventDownloadsGranted = "downloads.granted"
+	EventInvoiceIssued    = "invoice.issued"
+	EventProductReviewed  = "product.reviewed"
+)
+
+// OrderEvent is the part of an order event payload the templates use
+type OrderEvent struct {
+	ID         uuid.UUID        `json:"id"`
+	CustomerID string           `json:"customer_id"`
+	Email      string           `json:"email"`
+	Phone      string           `json:"phone"`
+	Status     string           `json:"status"`
+	Currency   string           `json:"currency"`
+	Subtotal   float64          `json:"subtotal"`
+	Total      float64          `json:"total"`
+	Items      []OrderEventItem `json:"items"`
+	CreatedAt  time.Time        `json:"created_at"`
+}
+
+// OrderEventItem is a line of an order event
+type OrderEventItem struct {
+	ProductID uuid.UUID `json:"product_id"`
+	SKU       string    `json:"sku"`
+	Name      string    `json:"name"`
+	Quantity  int       `json:"quantity"`
+	UnitPrice float64   `json:"unit_price"`
+	Total     float64   `json:"total"`
+}
+
+// ProductEvent is the part of a product event payload the templates use
+type ProductEvent struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	SKU      string    `json:"sku"`
+	Stock    int       `json:"stock"`
+	IsActive bool      `json:"is_active"`
+
+	LowStockThreshold *int `json:"low_stock_threshold"` // unset when the service-wide threshold applies
+}
+
+// DownloadsEvent is the part of a downloads.granted payload the templates use
+type DownloadsEvent struct {
+	OrderID      uuid.UUID          `json:"order_id"`
+	CustomerID   string             `json:"customer_id"`
+	Email        string             `json:"email"`
+	Entitlements []EntitlementEvent `json:"entitlements"`
+}
+
+// EntitlementEvent is a digital product an order was granted
+type EntitlementEvent struct {
+	ID          uuid.UUID `json:"id"`
+	ProductName string    `json:"product_name"`
+	Quantity    int       `json:"quantity"`
+	LicenseKey  string    `json:"license_key"`
+}
+
+// InvoiceEvent is the part of an invoice.issued payload the templates use
+type InvoiceEvent struct {
+	ID         uuid.UUID `json:"id"`
+	Number     string    `json:"number"`
+	OrderID    uuid.UUID `json:"order_id"`
+	CustomerID string    `json:"customer_id"`
+	Email      string    `json:"email"`
+	Currency   string    `json:"currency"`
+	Total      float64   `json:"total"`
+	URL        string    `json:"url"`
+	IssuedAt   time.Time `json:"issued_at"`
+}
+
+// ProductReviewedEvent is the part of a product.reviewed payload the
+// templates use. RevisionID is set when a proposed change was reviewed
+// rather than a new vendor product.
+type ProductReviewedEvent struct {
+	ProductID   uuid.UUID  `json:"product_id"`
+	ProductName string     `json:"product_name"`
+	RevisionID  *uuid.UUID `json:"revision_id"`
+	Status      string     `json:"status"`
+	Comment     string     `json:"comment"`
+	Email       string     `json:"email"`
+}
@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification channels
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+)
+
+// Notification statuses
+const (
+	StatusPending   = "pending" // queued, or waiting to be retried
+	StatusSent      = "sent"    // accepted by the provider
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+// Notification is one message to one recipient. Its subject and body are
+// rendered when it is queued, so retries send exactly what was first sent
+// even if the template changes in between.
+type Notification struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	DedupeKey     string     `json:"-" gorm:"not null;uniqueIndex"`
+	EventID       string     `json:"event_id,omitempty" gorm:"index"`
+	EventType     string     `json:"event_type,omitempty"`
+	Channel       string     `json:"channel" gorm:"not null"`
+	Template      string     `json:"template" gorm:"not null"`
+	Recipient     string     `json:"recipient" gorm:"not null;index"`
+	Subject       string     `json:"subject,omitempty"`
+	Body          string     `json:"body" gorm:"type:text;not null"`
+	Status        string     `json:"status" gorm:"not null"`
+	Provider      string     `json:"provider,omitempty"`
+	ProviderRef   string     `json:"provider_ref,omitempty" gorm:"index"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// NotificationFilters represents filters for notification queries
+type NotificationFilters struct {
+	Status    string `json:"status,omitempty"`
+	Channel   string `json:"channel,omitempty"`
+	Recipient string `json:"recipient,omitempty"`
+	EventID   string `json:"event_id,omitempty"`
+	Limit     int    `json:"limit,omitempty"`
+	Offset    int    `json:"offset,omitempty"`
+}
+
+// NotificationList represents a paginated list of notifications
+type NotificationList struct {
+	Notifications []Notification `json:"notifications"`
+	Total         int64          `json:"total"`
+	Limit         int            `json:"limit"`
+	Offset        int            `json:"offset"`
+	HasMore       bool           `json:"has_more"`
+}
+
+// DeliveryUpdate is a provider's report of what happened to a message after
+// it was accepted. Providers identify the message by the notification ID
+// they were given, by their own reference, or both.
+type DeliveryUpdate struct {
+	NotificationID string
+	ProviderRef    string
+	Status         string // delivered or failed
+	Error          string
+}
+
+// TableName returns the table name for Notification
+func (Notification) TableName() string {
+	return "notifications"
+}
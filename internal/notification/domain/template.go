@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Template names the service renders for the events it handles
+const (
+	TemplateOrderConfirmation = "order_confirmation"
+	TemplateLowStock          = "low_stock"
+	TemplateDownloadsReady    = "downloads_ready"
+	TemplateInvoice           = "invoice"
+	TemplateProductReviewed   = "product_reviewed"
+)
+
+// Template is a named message template for one channel. Subjects and SMS
+// bodies are Go text templates; email bodies are Go HTML templates, so
+// event data is escaped.
+type Template struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name      string    `json:"name" gorm:"not null;uniqueIndex:idx_notification_templates_name_channel"`
+	Channel   string    `json:"channel" gorm:"not null;uniqueIndex:idx_notification_templates_name_channel"`
+	Subject   string    `json:"subject,omitempty"`
+	Body      string    `json:"body" gorm:"type:text;not null"`
+	IsActive  bool      `json:"is_active" gorm:"default:true"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreateTemplateRequest represents the request to create a template
+type CreateTemplateRequest struct {
+	Name     string `json:"name" validate:"required,min=1,max=100"`
+	Channel  string `json:"channel" validate:"required,oneof=email sms"`
+	Subject  string `json:"subject" validate:"max=255"`
+	Body     string `json:"body" validate:"required"`
+	IsActive *bool  `json:"is_active"`
+}
+
+// UpdateTemplateRequest represents the request to update a template
+type UpdateTemplateRequest struct {
+	Subject  *string `json:"subject,omitempty" validate:"omitempty,max=255"`
+	Body     *string `json:"body,omitempty" validate:"omitempty,min=1"`
+	IsActive *bool   `json:"is_active,omitempty"`
+}
+
+// TableName returns the table name for Template
+func (Template) TableName() string {
+	return "notification_templates"
+}
@@ -0,0 +1,291 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/notification/domain"
+	"ecommerce/internal/notification/service"
+	"ecommerce/pkg/database"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/events"
+	"ecommerce/pkg/response"
+)
+
+// maxCallbackSize bounds the provider callback payloads the service will read
+const maxCallbackSize = 1 << 20
+
+// HTTPHandler handles HTTP requests for notification service
+type HTTPHandler struct {
+	service service.NotificationService
+	logger  *logrus.Logger
+}
+
+// NewHTTPHandler creates a new HTTP handler
+func NewHTTPHandler(service service.NotificationService, logger *logrus.Logger) *HTTPHandler {
+	return &HTTPHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers all HTTP routes
+func (h *HTTPHandler) RegisterRoutes(router *gin.Engine) {
+	api := router.Group("/api/v1")
+
+	// Event intake from other services
+	api.POST("/events", h.HandleEvent)
+
+	// Notification routes
+	notifications := api.Group("/notifications")
+	{
+		notifications.GET("", h.ListNotifications)
+		notifications.POST("/callbacks/:provider", h.HandleCallback)
+		notifications.GET("/templates", h.ListTemplates)
+		notifications.POST("/templates", h.CreateTemplate)
+		notifications.GET("/templates/:id", h.GetTemplate)
+		notifications.PUT("/templates/:id", h.UpdateTemplate)
+		notifications.DELETE("/templates/:id", h.DeleteTemplate)
+		notifications.GET("/:id", h.GetNotification)
+		notifications.POST("/:id/retry", h.RetryNotification)
+	}
+
+	// Health check
+	router.GET("/health", h.HealthCheck)
+	router.GET("/ready", h.ReadinessCheck)
+}
+
+// HandleEvent handles an event forwarded by another service
+func (h *HTTPHandler) HandleEvent(c *gin.Context) {
+	var event events.Event
+	if err := c.ShouldBindJSON(&event); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	queued, err := h.service.HandleEvent(c.Request.Context(), &event)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusAccepted, "Event accepted successfully", gin.H{
+		"queued": queued,
+	})
+}
+
+// HandleCallback handles delivery status reports from a provider. The raw
+// body is passed on untouched because the signature covers it.
+func (h *HTTPHandler) HandleCallback(c *gin.Context) {
+	payload, err := io.ReadAll(io.LimitReader(c.Request.Body, maxCallbackSize))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := h.service.HandleCallback(c.Request.Context(), c.Param("provider"), payload, c.Request.Header); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Callback processed successfully", nil)
+}
+
+// ListNotifications handles notification listing with filters
+func (h *HTTPHandler) ListNotifications(c *gin.Context) {
+	filters := &domain.NotificationFilters{
+		Status:    c.Query("status"),
+		Channel:   c.Query("channel"),
+		Recipient: c.Query("recipient"),
+		EventID:   c.Query("event_id"),
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			filters.Limit = l
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if o, err := strconv.Atoi(offset); err == nil {
+			filters.Offset = o
+		}
+	}
+
+	notifications, err := h.service.ListNotifications(c.Request.Context(), filters)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Notifications retrieved successfully", notifications)
+}
+
+// GetNotification handles getting a single notification
+func (h *HTTPHandler) GetNotification(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid notification ID", err)
+		return
+	}
+
+	notification, err := h.service.GetNotification(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Notification retrieved successfully", notification)
+}
+
+// RetryNotification handles queueing a failed notification again
+func (h *HTTPHandler) RetryNotification(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid notification ID", err)
+		return
+	}
+
+	notification, err := h.service.RetryNotification(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Notification queued for retry successfully", notification)
+}
+
+// CreateTemplate handles template creation
+func (h *HTTPHandler) CreateTemplate(c *gin.Context) {
+	var req domain.CreateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	template, err := h.service.CreateTemplate(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Template created successfully", template)
+}
+
+// GetTemplate handles getting a single template
+func (h *HTTPHandler) GetTemplate(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid template ID", err)
+		return
+	}
+
+	template, err := h.service.GetTemplate(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Template retrieved successfully", template)
+}
+
+// UpdateTemplate handles template updates
+func (h *HTTPHandler) UpdateTemplate(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid template ID", err)
+		return
+	}
+
+	var req domain.UpdateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	template, err := h.service.UpdateTemplate(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Template updated successfully", template)
+}
+
+// DeleteTemplate handles template deletion
+func (h *HTTPHandler) DeleteTemplate(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid template ID", err)
+		return
+	}
+
+	if err := h.service.DeleteTemplate(c.Request.Context(), id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Template deleted successfully", nil)
+}
+
+// ListTemplates handles template listing
+func (h *HTTPHandler) ListTemplates(c *gin.Context) {
+	templates, err := h.service.ListTemplates(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Templates retrieved successfully", templates)
+}
+
+// HealthCheck handles health check requests
+func (h *HTTPHandler) HealthCheck(c *gin.Context) {
+	response.Success(c, http.StatusOK, "Service is healthy", gin.H{
+		"service": "notification-service",
+		"status":  "healthy",
+	})
+}
+
+// ReadinessCheck handles readiness check requests
+func (h *HTTPHandler) ReadinessCheck(c *gin.Context) {
+	response.Success(c, http.StatusOK, "Service is ready", gin.H{
+		"service": "notification-service",
+		"status":  "ready",
+	})
+}
+
+// handleError handles service errors and converts them to appropriate HTTP responses
+func (h *HTTPHandler) handleError(c *gin.Context, err error) {
+	err = database.TranslateError(err)
+	switch {
+	case errors.IsNotFound(err):
+		response.Error(c, http.StatusNotFound, "Resource not found", err)
+	case errors.IsValidation(err):
+		response.Error(c, http.StatusBadRequest, "Validation failed", err)
+	case errors.IsConflict(err):
+		response.Error(c, http.StatusConflict, "Resource conflict", err)
+	case errors.IsUnauthorized(err):
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", err)
+	case errors.IsForbidden(err):
+		response.Error(c, http.StatusForbidden, "Forbidden", err)
+	case errors.IsUnavailable(err):
+		response.Error(c, http.StatusServiceUnavailable, "Service unavailable", err)
+	default:
+		h.logger.WithError(err).Error("Internal server error")
+		response.Error(c, http.StatusInternalServerError, "Internal server error", nil)
+	}
+}
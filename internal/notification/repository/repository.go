@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"ecommerce/internal/notification/domain"
+	"ecommerce/pkg/database"
+	customErrors "ecommerce/pkg/errors"
+)
+
+// NotificationRepository defines the interface for notification data operations
+type NotificationRepository interface {
+	Enqueue(ctx context.Context, notification *domain.Notification) (bool, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Notification, error)
+	GetByProviderRef(ctx context.Context, provider, providerRef string) (*domain.Notification, error)
+	Update(ctx context.Context, notification *domain.Notification) error
+	List(ctx context.Context, filters *domain.NotificationFilters) ([]domain.Notification, int64, error)
+
+	CreateTemplate(ctx context.Context, template *domain.Template) error
+	GetTemplate(ctx context.Context, id uuid.UUID) (*domain.Template, error)
+	GetTemplateByName(ctx context.Context, name, channel string) (*domain.Template, error)
+	UpdateTemplate(ctx context.Context, template *domain.Template) error
+	DeleteTemplate(ctx context.Context, id uuid.UUID) error
+	ListTemplates(ctx context.Context) ([]domain.Template, error)
+}
+
+type notificationRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+// NewNotificationRepository creates a new notification repository
+func NewNotificationRepository(db *gorm.DB, logger *logrus.Logger) NotificationRepository {
+	return &notificationRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Enqueue records a notification for delivery unless one with its dedupe
+// key already exists, and reports whether it was recorded. Inside a
+// transaction it is only recorded if the transaction commits.
+func (r *notificationRepository) Enqueue(ctx context.Context, notification *domain.Notification) (bool, error) {
+	result := database.Conn(ctx, r.db).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "dedupe_key"}},
+			DoNothing: true,
+		}).
+		Create(notification)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to enqueue notification: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *notificationRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Notification, error) {
+	var notification domain.Notification
+	err := r.db.WithContext(ctx).First(&notification, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Notification not found", err).WithCode(customErrors.CodeNotificationNotFound)
+		}
+		return nil, fmt.Errorf("failed to get notification: %w", err)
+	}
+
+	return &notification, nil
+}
+
+func (r *notificationRepository) GetByProviderRef(ctx context.Context, provider, providerRef string) (*domain.Notification, error) {
+	var notification domain.Notification
+	err := r.db.WithContext(ctx).
+		First(&notification, "provider = ? AND provider_ref = ?", provider, providerRef).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Notification not found", err).WithCode(customErrors.CodeNotificationNotFound)
+		}
+		return nil, fmt.Errorf("failed to get notification by provider reference: %w", err)
+	}
+
+	return &notification, nil
+}
+
+func (r *notificationRepository) Update(ctx context.Context, notification *domain.Notification) error {
+	if err := database.Conn(ctx, r.db).Save(notification).Error; err != nil {
+		return fmt.Errorf("failed to update notification: %w", err)
+	}
+	return nil
+}
+
+func (r *notificationRepository) List(ctx context.Context, filters *domain.NotificationFilters) ([]domain.Notification, int64, error) {
+	query := r.db.WithContext(ctx).Model(&domain.Notification{})
+
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.Channel != "" {
+		query = query.Where("channel = ?", filters.Channel)
+	}
+	if filters.Recipient != "" {
+		query = query.Where("recipient = ?", filters.Recipient)
+	}
+	if filters.EventID != "" {
+		query = query.Where("event_id = ?", filters.EventID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count notifications: %w", err)
+	}
+
+	var notifications []domain.Notification
+	err := query.
+		Order("created_at DESC").
+		Limit(filters.Limit).
+		Offset(filters.Offset).
+		Find(&notifications).Error
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list notifications: %w", err)
+	}
+
+	return notifications, total, nil
+}
+
+func (r *notificationRepository) CreateTemplate(ctx context.Context, template *domain.Template) error {
+	if err := r.db.WithContext(ctx).Create(template).Error; err != nil {
+		return fmt.Errorf("failed to create template: %w", err)
+	}
+	return nil
+}
+
+func (r *notificationRepository) GetTemplate(ctx context.Context, id uuid.UUID) (*domain.Template, error) {
+	var template domain.Template
+	err := r.db.WithContext(ctx).First(&template, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Template not found", err).WithCode(customErrors.CodeTemplateNotFound)
+		}
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+
+	return &template, nil
+}
+
+func (r *notificationRepository) GetTemplateByName(ctx context.Context, name, channel string) (*domain.Template, error) {
+	var template domain.Template
+	err := r.db.WithContext(ctx).First(&template, "name = ? AND channel = ?", name, channel).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Template not found", err).WithCode(customErrors.CodeTemplateNotFound)
+		}
+		return nil, fmt.Errorf("failed to get template by name: %w", err)
+	}
+
+	return &template, nil
+}
+
+func (r *notificationRepository) UpdateTemplate(ctx context.Context, template *domain.Template) error {
+	if err := r.db.WithContext(ctx).Save(template).Error; err != nil {
+		return fmt.Errorf("failed to update template: %w", err)
+	}
+	return nil
+}
+
+func (r *notificationRepository) DeleteTemplate(ctx context.Context, id uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Delete(&domain.Template{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	return nil
+}
+
+func (r *notificationRepository) ListTemplates(ctx context.Context) ([]domain.Template, error) {
+	var templates []domain.Template
+	if err := r.db.WithContext(ctx).Order("name ASC, channel ASC").Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	return templates, nil
+}
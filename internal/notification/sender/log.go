@@ -0,0 +1,36 @@
+package sender
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// Log is a sender for local development that writes messages to the log
+// instead of delivering them
+type Log struct {
+	channel string
+	logger  *logrus.Logger
+}
+
+// NewLog creates a log sender for a channel
+func NewLog(channel string, logger *logrus.Logger) *Log {
+	return &Log{channel: channel, logger: logger}
+}
+
+func (s *Log) Name() string {
+	return ProviderLog
+}
+
+func (s *Log) Send(ctx context.Context, msg *Message) (string, error) {
+	ref := "log_" + uuid.NewString()
+	s.logger.WithFields(logrus.Fields{
+		"channel":         s.channel,
+		"notification_id": msg.ID,
+		"to":              msg.To,
+		"subject":         msg.Subject,
+		"provider_ref":    ref,
+	}).Info(msg.Body)
+	return ref, nil
+}
@@ -0,0 +1,70 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"ecommerce/internal/notification/domain"
+)
+
+// Supported providers
+const (
+	ProviderLog      = "log"
+	ProviderSendGrid = "sendgrid"
+	ProviderSES      = "ses"
+	ProviderTwilio   = "twilio"
+)
+
+// Sender errors
+var (
+	// ErrRejected marks a message the provider refused outright, such as an
+	// invalid recipient. Retrying it cannot succeed.
+	ErrRejected         = errors.New("message rejected by provider")
+	ErrInvalidSignature = errors.New("invalid callback signature")
+)
+
+// Message is a rendered message to one recipient
+type Message struct {
+	ID      string // notification ID; providers echo it back in callbacks
+	To      string
+	Subject string // email only
+	Body    string
+}
+
+// Sender delivers messages through one provider on one channel
+type Sender interface {
+	Name() string
+	// Send hands the message to the provider and returns the provider's
+	// reference for it
+	Send(ctx context.Context, msg *Message) (string, error)
+}
+
+// StatusReporter is implemented by senders whose provider reports delivery
+// outcomes through signed callbacks
+type StatusReporter interface {
+	ParseCallback(payload []byte, header http.Header) ([]domain.DeliveryUpdate, error)
+}
+
+// IsRejected reports whether a send error is permanent
+func IsRejected(err error) bool {
+	return errors.Is(err, ErrRejected)
+}
+
+// statusError classifies a provider's HTTP error response; client errors
+// other than throttling are permanent, everything else is worth retrying
+func statusError(provider string, status int, body []byte) error {
+	if status >= 400 && status < 500 && status != http.StatusTooManyRequests && status != http.StatusRequestTimeout {
+		return fmt.Errorf("%w: %s status %d: %s", ErrRejected, provider, status, truncate(body))
+	}
+	return fmt.Errorf("%s status %d: %s", provider, status, truncate(body))
+}
+
+func truncate(body []byte) string {
+	const max = 512
+	if len(body) > max {
+		return string(body[:max]) + "..."
+	}
+	return string(body)
+}
@@ -0,0 +1,158 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"ecommerce/internal/notification/config"
+	"ecommerce/internal/notification/domain"
+)
+
+// SendGrid sends email through the SendGrid v3 mail API
+type SendGrid struct {
+	baseURL   string
+	apiKey    string
+	from      string
+	fromName  string
+	publicKey *ecdsa.PublicKey
+	client    *http.Client
+}
+
+// NewSendGrid creates a SendGrid sender. Delivery callbacks are accepted
+// only when a webhook verification key is configured.
+func NewSendGrid(cfg config.SendGridConfig, email config.EmailConfig, timeout time.Duration) (*SendGrid, error) {
+	s := &SendGrid{
+		baseURL:  strings.TrimRight(cfg.APIURL, "/"),
+		apiKey:   cfg.APIKey,
+		from:     email.From,
+		fromName: email.FromName,
+		client:   &http.Client{Timeout: timeout},
+	}
+
+	if cfg.WebhookPublicKey != "" {
+		der, err := base64.StdEncoding.DecodeString(cfg.WebhookPublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode sendgrid webhook key: %w", err)
+		}
+		key, err := x509.ParsePKIXPublicKey(der)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse sendgrid webhook key: %w", err)
+		}
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("sendgrid webhook key is not an ECDSA key")
+		}
+		s.publicKey = ecKey
+	}
+
+	return s, nil
+}
+
+func (s *SendGrid) Name() string {
+	return ProviderSendGrid
+}
+
+func (s *SendGrid) Send(ctx context.Context, msg *Message) (string, error) {
+	type address struct {
+		Email string `json:"email"`
+		Name  string `json:"name,omitempty"`
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"personalizations": []map[string]interface{}{{
+			"to":          []address{{Email: msg.To}},
+			"custom_args": map[string]string{"notification_id": msg.ID},
+		}},
+		"from":    address{Email: s.from, Name: s.fromName},
+		"subject": msg.Subject,
+		"content": []map[string]string{{"type": "text/html", "value": msg.Body}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode sendgrid request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/v3/mail/send", bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("failed to build sendgrid request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("sendgrid request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return "", statusError(ProviderSendGrid, resp.StatusCode, body)
+	}
+	return resp.Header.Get("X-Message-Id"), nil
+}
+
+// ParseCallback verifies and decodes a SendGrid event webhook. Events are
+// matched to notifications by the custom argument set when sending.
+func (s *SendGrid) ParseCallback(payload []byte, header http.Header) ([]domain.DeliveryUpdate, error) {
+	if err := s.verify(payload, header); err != nil {
+		return nil, err
+	}
+
+	var events []struct {
+		Event          string `json:"event"`
+		Reason         string `json:"reason"`
+		NotificationID string `json:"notification_id"`
+		MessageID      string `json:"sg_message_id"`
+	}
+	if err := json.Unmarshal(payload, &events); err != nil {
+		return nil, fmt.Errorf("failed to decode sendgrid events: %w", err)
+	}
+
+	var updates []domain.DeliveryUpdate
+	for _, event := range events {
+		update := domain.DeliveryUpdate{
+			NotificationID: event.NotificationID,
+			// sg_message_id is the X-Message-Id followed by a filter suffix
+			ProviderRef: strings.SplitN(event.MessageID, ".", 2)[0],
+			Error:       event.Reason,
+		}
+		switch event.Event {
+		case "delivered":
+			update.Status = domain.StatusDelivered
+		case "bounce", "dropped":
+			update.Status = domain.StatusFailed
+		default:
+			// processed, deferred and engagement events change nothing
+			continue
+		}
+		updates = append(updates, update)
+	}
+	return updates, nil
+}
+
+// verify checks the ECDSA signature SendGrid puts over the timestamp and body
+func (s *SendGrid) verify(payload []byte, header http.Header) error {
+	if s.publicKey == nil {
+		return ErrInvalidSignature
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(header.Get("X-Twilio-Email-Event-Webhook-Signature"))
+	if err != nil || len(signature) == 0 {
+		return ErrInvalidSignature
+	}
+
+	digest := sha256.Sum256(append([]byte(header.Get("X-Twilio-Email-Event-Webhook-Timestamp")), payload...))
+	if !ecdsa.VerifyASN1(s.publicKey, digest[:], signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
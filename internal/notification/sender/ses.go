@@ -0,0 +1,164 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"ecommerce/internal/notification/config"
+)
+
+// sesService is the SigV4 signing name of the SES API
+const sesService = "ses"
+
+// SES sends email through the Amazon SES v2 API. Requests are signed with
+// AWS Signature Version 4 directly rather than through the AWS SDK.
+type SES struct {
+	endpoint        string
+	region          string
+	accessKeyID     string
+	secretAccessKey string
+	from            string
+	client          *http.Client
+}
+
+// NewSES creates an SES sender
+func NewSES(cfg config.SESConfig, email config.EmailConfig, timeout time.Duration) *SES {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://email.%s.amazonaws.com", cfg.Region)
+	}
+
+	from := email.From
+	if email.FromName != "" {
+		from = fmt.Sprintf("%q <%s>", email.FromName, email.From)
+	}
+
+	return &SES{
+		endpoint:        strings.TrimRight(endpoint, "/"),
+		region:          cfg.Region,
+		accessKeyID:     cfg.AccessKeyID,
+		secretAccessKey: cfg.SecretAccessKey,
+		from:            from,
+		client:          &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *SES) Name() string {
+	return ProviderSES
+}
+
+func (s *SES) Send(ctx context.Context, msg *Message) (string, error) {
+	type content struct {
+		Data    string `json:"Data"`
+		Charset string `json:"Charset"`
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"FromEmailAddress": s.from,
+		"Destination":      map[string][]string{"ToAddresses": {msg.To}},
+		"Content": map[string]interface{}{
+			"Simple": map[string]interface{}{
+				"Subject": content{Data: msg.Subject, Charset: "UTF-8"},
+				"Body": map[string]content{
+					"Html": {Data: msg.Body, Charset: "UTF-8"},
+				},
+			},
+		},
+		"EmailTags": []map[string]string{{"Name": "notification_id", "Value": msg.ID}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode ses request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint+"/v2/email/outbound-emails", bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("failed to build ses request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	s.sign(req, payload, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("ses request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode >= 300 {
+		return "", statusError(ProviderSES, resp.StatusCode, body)
+	}
+
+	var result struct {
+		MessageID string `json:"MessageId"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("failed to decode ses response: %w", err)
+	}
+	return result.MessageID, nil
+}
+
+// sign adds AWS Signature Version 4 headers to the request
+func (s *SES) sign(req *http.Request, payload []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalPath(req.URL),
+		req.URL.RawQuery,
+		"content-type:" + req.Header.Get("Content-Type") + "\n" +
+			"host:" + req.URL.Host + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		"content-type;host;x-amz-date",
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, s.region, sesService, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, sesService)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=content-type;host;x-amz-date, Signature=%s",
+		s.accessKeyID, scope, signature,
+	))
+}
+
+func canonicalPath(u *url.URL) string {
+	if path := u.EscapedPath(); path != "" {
+		return path
+	}
+	return "/"
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,140 @@
+package sender
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"ecommerce/internal/notification/config"
+	"ecommerce/internal/notification/domain"
+)
+
+// Twilio sends SMS through the Twilio Messages API
+type Twilio struct {
+	baseURL     string
+	accountSID  string
+	authToken   string
+	from        string
+	callbackURL string
+	client      *http.Client
+}
+
+// NewTwilio creates a Twilio sender. Status callbacks are requested only
+// when a public callback URL is configured.
+func NewTwilio(cfg config.TwilioConfig, timeout time.Duration) *Twilio {
+	return &Twilio{
+		baseURL:     strings.TrimRight(cfg.APIURL, "/"),
+		accountSID:  cfg.AccountSID,
+		authToken:   cfg.AuthToken,
+		from:        cfg.From,
+		callbackURL: cfg.CallbackURL,
+		client:      &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *Twilio) Name() string {
+	return ProviderTwilio
+}
+
+func (s *Twilio) Send(ctx context.Context, msg *Message) (string, error) {
+	form := url.Values{}
+	form.Set("To", msg.To)
+	form.Set("From", s.from)
+	form.Set("Body", msg.Body)
+	if s.callbackURL != "" {
+		form.Set("StatusCallback", s.callbackURL)
+	}
+
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", s.baseURL, url.PathEscape(s.accountSID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to build twilio request: %w", err)
+	}
+	req.SetBasicAuth(s.accountSID, s.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("twilio request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode >= 300 {
+		return "", statusError(ProviderTwilio, resp.StatusCode, body)
+	}
+
+	var result struct {
+		SID string `json:"sid"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("failed to decode twilio response: %w", err)
+	}
+	return result.SID, nil
+}
+
+// ParseCallback verifies and decodes a Twilio message status callback.
+// Callbacks are matched to notifications by message SID.
+func (s *Twilio) ParseCallback(payload []byte, header http.Header) ([]domain.DeliveryUpdate, error) {
+	params, err := url.ParseQuery(string(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode twilio callback: %w", err)
+	}
+	if err := s.verify(params, header.Get("X-Twilio-Signature")); err != nil {
+		return nil, err
+	}
+
+	update := domain.DeliveryUpdate{ProviderRef: params.Get("MessageSid")}
+	switch params.Get("MessageStatus") {
+	case "delivered":
+		update.Status = domain.StatusDelivered
+	case "undelivered", "failed":
+		update.Status = domain.StatusFailed
+		update.Error = "Twilio error " + params.Get("ErrorCode")
+	default:
+		// queued, sending and sent are progress reports
+		return nil, nil
+	}
+	return []domain.DeliveryUpdate{update}, nil
+}
+
+// verify checks the X-Twilio-Signature header: an HMAC-SHA1, keyed with the
+// auth token, of the callback URL followed by the sorted POST parameters
+func (s *Twilio) verify(params url.Values, signature string) error {
+	if s.callbackURL == "" || signature == "" {
+		return ErrInvalidSignature
+	}
+
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var data strings.Builder
+	data.WriteString(s.callbackURL)
+	for _, key := range keys {
+		for _, value := range params[key] {
+			data.WriteString(key)
+			data.WriteString(value)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(s.authToken))
+	mac.Write([]byte(data.String()))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
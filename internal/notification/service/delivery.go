@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/notification/domain"
+	"ecommerce/internal/notification/sender"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/jobs"
+)
+
+// jobDeliver is the type of the background jobs that send notifications
+const jobDeliver = "notification.deliver"
+
+// deliveryPayload is the payload of a delivery job
+type deliveryPayload struct {
+	NotificationID uuid.UUID `json:"notification_id"`
+}
+
+// RegisterJobs has a worker pool run the service's delivery jobs
+func (s *notificationService) RegisterJobs(worker *jobs.Worker) {
+	worker.Handle(jobDeliver, s.deliverJob)
+}
+
+// queueDelivery queues the job that sends a notification, retrying failed
+// sends with exponential backoff until the attempts run out
+func (s *notificationService) queueDelivery(ctx context.Context, id uuid.UUID) error {
+	_, err := s.jobs.Enqueue(ctx, jobDeliver, deliveryPayload{NotificationID: id}, jobs.Options{
+		MaxAttempts: s.delivery.MaxAttempts,
+		Backoff:     time.Duration(s.delivery.RetryBackoff) * time.Second,
+	})
+	return err
+}
+
+// deliverJob makes one attempt to send a queued notification. Failed sends
+// fail the job, so it is retried, unless the provider rejected the message
+// outright.
+func (s *notificationService) deliverJob(ctx context.Context, job *jobs.Job) error {
+	var payload deliveryPayload
+	if err := job.Decode(&payload); err != nil {
+		return jobs.Permanent(fmt.Errorf("invalid delivery job payload: %w", err))
+	}
+
+	notification, err := s.repo.GetByID(ctx, payload.NotificationID)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return jobs.Permanent(err)
+		}
+		return err
+	}
+	// A notification settled some other way, such as by an earlier job
+	// whose outcome was recorded late, is not sent again
+	if notification.Status != domain.StatusPending {
+		return nil
+	}
+
+	return s.deliver(ctx, notification, job)
+}
+
+// deliver makes one attempt to send a notification and records the outcome
+func (s *notificationService) deliver(ctx context.Context, notification *domain.Notification, job *jobs.Job) error {
+	logger := s.logger.WithFields(logrus.Fields{
+		"notification_id": notification.ID,
+		"job_id":          job.ID,
+	})
+
+	channelSender, ok := s.senders[notification.Channel]
+	if !ok || channelSender == nil {
+		notification.Status = domain.StatusFailed
+		notification.LastError = "No sender configured for channel " + notification.Channel
+		s.save(ctx, notification, logger)
+		return jobs.Permanent(fmt.Errorf("no sender configured for channel %s", notification.Channel))
+	}
+
+	notification.Attempts++
+	notification.Provider = channelSender.Name()
+
+	ref, err := channelSender.Send(ctx, &sender.Message{
+		ID:      notification.ID.String(),
+		To:      notification.Recipient,
+		Subject: notification.Subject,
+		Body:    notification.Body,
+	})
+	if err != nil {
+		notification.LastError = err.Error()
+		if sender.IsRejected(err) || job.LastAttempt() {
+			notification.Status = domain.StatusFailed
+			notification.NextAttemptAt = nil
+			logger.WithError(err).Error("Notification delivery failed")
+		} else {
+			next := time.Now().Add(jobs.Backoff(time.Duration(job.Backoff)*time.Second, job.Attempts))
+			notification.NextAttemptAt = &next
+			logger.WithError(err).WithField("attempt", notification.Attempts).Warn("Notification delivery will be retried")
+		}
+		s.save(ctx, notification, logger)
+		if sender.IsRejected(err) {
+			return jobs.Permanent(err)
+		}
+		return err
+	}
+
+	now := time.Now()
+	notification.Status = domain.StatusSent
+	notification.ProviderRef = ref
+	notification.SentAt = &now
+	notification.NextAttemptAt = nil
+	notification.LastError = ""
+	if err := s.save(ctx, notification, logger); err != nil {
+		return err
+	}
+
+	logger.Info("Notification sent successfully")
+	return nil
+}
+
+// save records a delivery outcome. If a send cannot be recorded its job
+// fails and the notification is sent again, which is the lesser evil
+// compared to never sending it.
+func (s *notificationService) save(ctx context.Context, notification *domain.Notification, logger *logrus.Entry) error {
+	if err := s.repo.Update(context.WithoutCancel(ctx), notification); err != nil {
+		logger.WithError(err).Error("Failed to record notification delivery")
+		return err
+	}
+	return nil
+}
+
+// HandleCallback applies a provider's delivery report. Reports about
+// unknown messages, or that would move a settled message backwards, are
+// acknowledged and ignored so the provider stops retrying them.
+func (s *notificationService) HandleCallback(ctx context.Context, provider string, payload []byte, header http.Header) error {
+	var reporter sender.StatusReporter
+	for _, channelSender := range s.senders {
+		if channelSender != nil && channelSender.Name() == provider {
+			reporter, _ = channelSender.(sender.StatusReporter)
+		}
+	}
+	if reporter == nil {
+		return errors.NewNotFoundError("Provider does not send delivery callbacks", nil)
+	}
+
+	updates, err := reporter.ParseCallback(payload, header)
+	if err != nil {
+		if err == sender.ErrInvalidSignature {
+			s.log(ctx).WithField("provider", provider).Warn("Rejected callback with invalid signature")
+			return errors.NewValidationError("Invalid callback signature", err).WithCode(errors.CodeInvalidSignature)
+		}
+		return errors.NewValidationError("Invalid callback payload", err)
+	}
+
+	for _, update := range updates {
+		if err := s.applyDeliveryUpdate(ctx, provider, update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *notificationService) applyDeliveryUpdate(ctx context.Context, provider string, update domain.DeliveryUpdate) error {
+	var notification *domain.Notification
+	var err error
+	if id, parseErr := uuid.Parse(update.NotificationID); parseErr == nil {
+		notification, err = s.repo.GetByID(ctx, id)
+	} else {
+		notification, err = s.repo.GetByProviderRef(ctx, provider, update.ProviderRef)
+	}
+	if err != nil {
+		if errors.IsNotFound(err) {
+			s.log(ctx).WithField("provider_ref", update.ProviderRef).Warn("Ignoring callback for unknown notification")
+			return nil
+		}
+		return errors.NewInternalError("Failed to get notification", err)
+	}
+
+	// Only messages the provider has accepted can be delivered or bounce
+	if notification.Status != domain.StatusSent {
+		return nil
+	}
+
+	now := time.Now()
+	notification.Status = update.Status
+	if update.Status == domain.StatusDelivered {
+		notification.DeliveredAt = &now
+	} else {
+		notification.LastError = update.Error
+	}
+
+	if err := s.repo.Update(ctx, notification); err != nil {
+		s.log(ctx).WithError(err).WithField("notification_id", notification.ID).Error("Failed to record delivery status")
+		return errors.NewInternalError("Failed to record delivery status", err)
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"notification_id": notification.ID,
+		"status":          notification.Status,
+	}).Info("Delivery status recorded successfully")
+	return nil
+}
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/notification/domain"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/events"
+)
+
+// message is a notification an event calls for, before rendering
+type message struct {
+	channel   string
+	template  string
+	recipient string
+	dedupeKey string
+	data      interface{}
+}
+
+// HandleEvent queues the notifications an event calls for and reports how
+// many were queued. Events are delivered at least once, so notifications
+// are deduplicated and a repeated event queues nothing.
+func (s *notificationService) HandleEvent(ctx context.Context, event *events.Event) (int64, error) {
+	if event.ID == "" || event.Type == "" {
+		return 0, errors.NewValidationError("Event ID and type are required", nil)
+	}
+
+	logger := s.log(ctx).WithFields(logrus.Fields{
+		"event_id":   event.ID,
+		"event_type": event.Type,
+	})
+
+	messages, err := s.messagesFor(event)
+	if err != nil {
+		return 0, errors.NewValidationError("Invalid event payload", err)
+	}
+
+	now := time.Now()
+	var notifications []domain.Notification
+	for _, msg := range messages {
+		template, err := s.repo.GetTemplateByName(ctx, msg.template, msg.channel)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				logger.WithField("template", msg.template).Warn("Skipping notification without a template")
+				continue
+			}
+			return 0, errors.NewInternalError("Failed to get template", err)
+		}
+		if !template.IsActive {
+			continue
+		}
+
+		subject, body, err := render(template, msg.data)
+		if err != nil {
+			// A broken template must not block the event; the rest of its
+			// notifications still go out
+			logger.WithError(err).WithField("template", msg.template).Error("Failed to render template")
+			continue
+		}
+
+		notifications = append(notifications, domain.Notification{
+			DedupeKey:     msg.dedupeKey,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Channel:       msg.channel,
+			Template:      msg.template,
+			Recipient:     msg.recipient,
+			Subject:       subject,
+			Body:          body,
+			Status:        domain.StatusPending,
+			NextAttemptAt: &now,
+		})
+	}
+
+	// Each notification is recorded along with the job that sends it
+	var queued int64
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		for i := range notifications {
+			inserted, err := s.repo.Enqueue(ctx, &notifications[i])
+			if err != nil {
+				return err
+			}
+			if !inserted {
+				continue
+			}
+			if err := s.queueDelivery(ctx, notifications[i].ID); err != nil {
+				return err
+			}
+			queued++
+		}
+		return nil
+	})
+	if err != nil {
+		logger.WithError(err).Error("Failed to queue notifications")
+		return 0, errors.NewInternalError("Failed to queue notifications", err)
+	}
+
+	if queued > 0 {
+		logger.WithField("count", queued).Info("Notifications queued successfully")
+	}
+	return queued, nil
+}
+
+// messagesFor decides who hears about an event. Events the service has no
+// rule for call for nothing.
+func (s *notificationService) messagesFor(event *events.Event) ([]message, error) {
+	switch event.Type {
+	case domain.EventOrderCreated:
+		var order domain.OrderEvent
+		if err := event.Decode(&order); err != nil {
+			return nil, err
+		}
+
+		data := map[string]interface{}{"Order": order}
+		var messages []message
+		if order.Email != "" {
+			messages = append(messages, message{
+				channel:   domain.ChannelEmail,
+				template:  domain.TemplateOrderConfirmation,
+				recipient: order.Email,
+				dedupeKey: fmt.Sprintf("%s:%s:%s", event.ID, domain.TemplateOrderConfirmation, domain.ChannelEmail),
+				data:      data,
+			})
+		}
+		if order.Phone != "" {
+			messages = append(messages, message{
+				channel:   domain.ChannelSMS,
+				template:  domain.TemplateOrderConfirmation,
+				recipient: order.Phone,
+				dedupeKey: fmt.Sprintf("%s:%s:%s", event.ID, domain.TemplateOrderConfirmation, domain.ChannelSMS),
+				data:      data,
+			})
+		}
+		return messages, nil
+
+	case domain.EventDownloadsGranted:
+		var downloads domain.DownloadsEvent
+		if err := event.Decode(&downloads); err != nil {
+			return nil, err
+		}
+		if downloads.Email == "" {
+			return nil, nil
+		}
+
+		return []message{{
+			channel:   domain.ChannelEmail,
+			template:  domain.TemplateDownloadsReady,
+			recipient: downloads.Email,
+			dedupeKey: fmt.Sprintf("%s:%s:%s", event.ID, domain.TemplateDownloadsReady, domain.ChannelEmail),
+			data:      map[string]interface{}{"Downloads": downloads},
+		}}, nil
+
+	case domain.EventInvoiceIssued:
+		var invoice domain.InvoiceEvent
+		if err := event.Decode(&invoice); err != nil {
+			return nil, err
+		}
+		if invoice.Email == "" {
+			return nil, nil
+		}
+
+		return []message{{
+			channel:   domain.ChannelEmail,
+			template:  domain.TemplateInvoice,
+			recipient: invoice.Email,
+			dedupeKey: fmt.Sprintf("%s:%s:%s", event.ID, domain.TemplateInvoice, domain.ChannelEmail),
+			data:      map[string]interface{}{"Invoice": invoice},
+		}}, nil
+
+	case domain.EventProductReviewed:
+		var review domain.ProductReviewedEvent
+		if err := event.Decode(&review); err != nil {
+			return nil, err
+		}
+		if review.Email == "" {
+			return nil, nil
+		}
+
+		return []message{{
+			channel:   domain.ChannelEmail,
+			template:  domain.TemplateProductReviewed,
+			recipient: review.Email,
+			dedupeKey: fmt.Sprintf("%s:%s:%s", event.ID, domain.TemplateProductReviewed, domain.ChannelEmail),
+			data:      map[string]interface{}{"Review": review},
+		}}, nil
+
+	case domain.EventStockLow:
+		if s.alerts.AdminEmail == "" {
+			return nil, nil
+		}
+
+		var product domain.ProductEvent
+		if err := event.Decode(&product); err != nil {
+			return nil, err
+		}
+
+		threshold := s.alerts.LowStockThreshold
+		if product.LowStockThreshold != nil {
+			threshold = *product.LowStockThreshold
+		}
+
+		// The product service reports each shortage once, so the event
+		// itself is the dedupe key
+		return []message{{
+			channel:   domain.ChannelEmail,
+			template:  domain.TemplateLowStock,
+			recipient: s.alerts.AdminEmail,
+			dedupeKey: fmt.Sprintf("%s:%s:%s", event.ID, domain.TemplateLowStock, domain.ChannelEmail),
+			data: map[string]interface{}{
+				"Product":   product,
+				"Threshold": threshold,
+			},
+		}}, nil
+	}
+
+	return nil, nil
+}
+
+// render renders a template's subject and body with the event data
+func render(template *domain.Template, data interface{}) (string, string, error) {
+	var subject strings.Builder
+	if template.Subject != "" {
+		tmpl, err := texttemplate.New("subject").Option("missingkey=error").Parse(template.Subject)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to parse subject: %w", err)
+		}
+		if err := tmpl.Execute(&subject, data); err != nil {
+			return "", "", fmt.Errorf("failed to render subject: %w", err)
+		}
+	}
+
+	var body bytes.Buffer
+	if template.Channel == domain.ChannelEmail {
+		tmpl, err := htmltemplate.New("body").Option("missingkey=error").Parse(template.Body)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to parse body: %w", err)
+		}
+		if err := tmpl.Execute(&body, data); err != nil {
+			return "", "", fmt.Errorf("failed to render body: %w", err)
+		}
+	} else {
+		tmpl, err := texttemplate.New("body").Option("missingkey=error").Parse(template.Body)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to parse body: %w", err)
+		}
+		if err := tmpl.Execute(&body, data); err != nil {
+			return "", "", fmt.Errorf("failed to render body: %w", err)
+		}
+	}
+
+	return strings.TrimSpace(subject.String()), strings.TrimSpace(body.String()), nil
+}
+
+// validateTemplate checks that a template parses and that emails have a subject
+func validateTemplate(template *domain.Template) error {
+	if template.Channel == domain.ChannelEmail && strings.TrimSpace(template.Subject) == "" {
+		return errors.NewValidationError("Email templates require a subject", nil)
+	}
+	if _, err := texttemplate.New("subject").Parse(template.Subject); err != nil {
+		return errors.NewValidationError("Invalid subject template", err)
+	}
+
+	var err error
+	if template.Channel == domain.ChannelEmail {
+		_, err = htmltemplate.New("body").Parse(template.Body)
+	} else {
+		_, err = texttemplate.New("body").Parse(template.Body)
+	}
+	if err != nil {
+		return errors.NewValidationError("Invalid body template", err)
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/notification/config"
+	"ecommerce/internal/notification/domain"
+	"ecommerce/internal/notification/repository"
+	"ecommerce/internal/notification/sender"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/database"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/events"
+	"ecommerce/pkg/jobs"
+	"ecommerce/pkg/logger"
+	"ecommerce/pkg/validator"
+)
+
+// NotificationService defines the notification service interface
+type NotificationService interface {
+	HandleEvent(ctx context.Context, event *events.Event) (int64, error)
+	HandleCallback(ctx context.Context, provider string, payload []byte, header http.Header) error
+	RegisterJobs(worker *jobs.Worker)
+
+	GetNotification(ctx context.Context, id uuid.UUID) (*domain.Notification, error)
+	ListNotifications(ctx context.Context, filters *domain.NotificationFilters) (*domain.NotificationList, error)
+	RetryNotification(ctx context.Context, id uuid.UUID) (*domain.Notification, error)
+
+	CreateTemplate(ctx context.Context, req *domain.CreateTemplateRequest) (*domain.Template, error)
+	GetTemplate(ctx context.Context, id uuid.UUID) (*domain.Template, error)
+	UpdateTemplate(ctx context.Context, id uuid.UUID, req *domain.UpdateTemplateRequest) (*domain.Template, error)
+	DeleteTemplate(ctx context.Context, id uuid.UUID) error
+	ListTemplates(ctx context.Context) ([]domain.Template, error)
+}
+
+type notificationService struct {
+	repo      repository.NotificationRepository
+	jobs      *jobs.Queue
+	senders   map[string]sender.Sender // by channel
+	delivery  config.DeliveryConfig
+	alerts    config.AlertsConfig
+	logger    *logrus.Logger
+	validator *validator.Validator
+
+	// tx records notifications together with the jobs that send them
+	tx database.TxManager
+}
+
+// NewNotificationService creates a new notification service
+func NewNotificationService(repo repository.NotificationRepository, tx database.TxManager, queue *jobs.Queue, email, sms sender.Sender, delivery config.DeliveryConfig, alerts config.AlertsConfig, logger *logrus.Logger) NotificationService {
+	return &notificationService{
+		repo: repo,
+		jobs: queue,
+		senders: map[string]sender.Sender{
+			domain.ChannelEmail: email,
+			domain.ChannelSMS:   sms,
+		},
+		delivery:  delivery,
+		alerts:    alerts,
+		logger:    logger,
+		validator: validator.New(),
+		tx:        tx,
+	}
+}
+
+// log returns the logger of the request ctx belongs to
+func (s *notificationService) log(ctx context.Context) *logrus.Entry {
+	return logger.FromContext(ctx, s.logger)
+}
+
+func (s *notificationService) GetNotification(ctx context.Context, id uuid.UUID) (*domain.Notification, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Viewing notifications requires the admin role", nil)
+	}
+
+	notification, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Notification not found", err).WithCode(errors.CodeNotificationNotFound)
+		}
+		s.log(ctx).WithError(err).Error("Failed to get notification")
+		return nil, errors.NewInternalError("Failed to get notification", err)
+	}
+
+	return notification, nil
+}
+
+func (s *notificationService) ListNotifications(ctx context.Context, filters *domain.NotificationFilters) (*domain.NotificationList, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Viewing notifications requires the admin role", nil)
+	}
+
+	// Set default values
+	if filters.Limit <= 0 {
+		filters.Limit = 20
+	}
+	if filters.Limit > 100 {
+		filters.Limit = 100
+	}
+
+	notifications, total, err := s.repo.List(ctx, filters)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list notifications")
+		return nil, errors.NewInternalError("Failed to list notifications", err)
+	}
+
+	return &domain.NotificationList{
+		Notifications: notifications,
+		Total:         total,
+		Limit:         filters.Limit,
+		Offset:        filters.Offset,
+		HasMore:       int64(filters.Offset+filters.Limit) < total,
+	}, nil
+}
+
+// RetryNotification queues a failed notification to be sent again with a
+// fresh set of attempts
+func (s *notificationService) RetryNotification(ctx context.Context, id uuid.UUID) (*domain.Notification, error) {
+	notification, err := s.GetNotification(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if notification.Status != domain.StatusFailed {
+		return nil, errors.NewConflictError("Only failed notifications can be retried", nil).WithCode(errors.CodeNotificationNotFailed)
+	}
+
+	now := time.Now()
+	notification.Status = domain.StatusPending
+	notification.Attempts = 0
+	notification.NextAttemptAt = &now
+	notification.LastError = ""
+
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, notification); err != nil {
+			return err
+		}
+		return s.queueDelivery(ctx, notification.ID)
+	})
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to retry notification")
+		return nil, errors.NewInternalError("Failed to retry notification", err)
+	}
+
+	s.log(ctx).WithField("notification_id", notification.ID).Info("Notification queued for retry successfully")
+	return notification, nil
+}
+
+func (s *notificationService) CreateTemplate(ctx context.Context, req *domain.CreateTemplateRequest) (*domain.Template, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing templates requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid create template request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	template := &domain.Template{
+		Name:     req.Name,
+		Channel:  req.Channel,
+		Subject:  req.Subject,
+		Body:     req.Body,
+		IsActive: true,
+	}
+	if req.IsActive != nil {
+		template.IsActive = *req.IsActive
+	}
+	if err := validateTemplate(template); err != nil {
+		return nil, err
+	}
+
+	// Check if template already exists
+	existing, err := s.repo.GetTemplateByName(ctx, template.Name, template.Channel)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, errors.NewInternalError("Failed to validate template name", err)
+	}
+	if existing != nil {
+		return nil, errors.NewConflictError("Template with this name already exists for the channel", nil).WithCode(errors.CodeTemplateNameConflict)
+	}
+
+	if err := s.repo.CreateTemplate(ctx, template); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to create template")
+		return nil, errors.NewInternalError("Failed to create template", err)
+	}
+
+	s.log(ctx).WithField("template_id", template.ID).Info("Template created successfully")
+	return template, nil
+}
+
+func (s *notificationService) GetTemplate(ctx context.Context, id uuid.UUID) (*domain.Template, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing templates requires the admin role", nil)
+	}
+
+	template, err := s.repo.GetTemplate(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Template not found", err).WithCode(errors.CodeTemplateNotFound)
+		}
+		s.log(ctx).WithError(err).Error("Failed to get template")
+		return nil, errors.NewInternalError("Failed to get template", err)
+	}
+
+	return template, nil
+}
+
+func (s *notificationService) UpdateTemplate(ctx context.Context, id uuid.UUID, req *domain.UpdateTemplateRequest) (*domain.Template, error) {
+	template, err := s.GetTemplate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid update template request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	if req.Subject != nil {
+		template.Subject = *req.Subject
+	}
+	if req.Body != nil {
+		template.Body = *req.Body
+	}
+	if req.IsActive != nil {
+		template.IsActive = *req.IsActive
+	}
+	if err := validateTemplate(template); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateTemplate(ctx, template); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to update template")
+		return nil, errors.NewInternalError("Failed to update template", err)
+	}
+
+	s.log(ctx).WithField("template_id", template.ID).Info("Template updated successfully")
+	return template, nil
+}
+
+func (s *notificationService) DeleteTemplate(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.GetTemplate(ctx, id); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteTemplate(ctx, id); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to delete template")
+		return errors.NewInternalError("Failed to delete template", err)
+	}
+
+	s.log(ctx).WithField("template_id", id).Info("Template deleted successfully")
+	return nil
+}
+
+func (s *notificationService) ListTemplates(ctx context.Context) ([]domain.Template, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing templates requires the admin role", nil)
+	}
+
+	templates, err := s.repo.ListTemplates(ctx)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list templates")
+		return nil, errors.NewInternalError("Failed to list templates", err)
+	}
+
+	return templates, nil
+}
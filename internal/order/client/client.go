@@ -0,0 +1,148 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/resilience"
+	"ecommerce/pkg/tenant"
+)
+
+// envelope mirrors pkg/response.APIResponse as seen by a caller
+type envelope struct {
+	Success bool            `json:"success"`
+	Code    string          `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+	Error   interface{}     `json:"error"`
+
+	// Problem details members, for services that send RFC 7807 errors
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+}
+
+// httpClient calls another service's JSON API
+type httpClient struct {
+	baseURL string
+	client  *http.Client
+	policy  resilience.Policy
+}
+
+func newHTTPClient(baseURL string, client *http.Client, policy resilience.Policy) httpClient {
+	return httpClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  client,
+		policy:  policy,
+	}
+}
+
+// withoutRetry returns a copy of the client for calls that are not safe to
+// repeat
+func (c httpClient) withoutRetry() httpClient {
+	c.policy = c.policy.WithoutRetry()
+	return c
+}
+
+// do sends a request under the client's resilience policy and decodes the
+// response data into out. Error statuses are mapped back onto the error
+// types and codes the remote service used, and transport failures, 5xx responses and
+// an open circuit surface as unavailable errors. Only transport failures
+// and 5xx responses are retried.
+func (c httpClient) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	err := c.policy.Do(ctx, func(ctx context.Context) error {
+		return c.send(ctx, method, path, payload, out)
+	})
+	if err == resilience.ErrCircuitOpen {
+		return errors.NewUnavailableError("Service unavailable", err)
+	}
+	return err
+}
+
+// send makes one attempt at a request, marking the errors a retry cannot
+// fix as permanent
+func (c httpClient) send(ctx context.Context, method, path string, payload []byte, out interface{}) error {
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return resilience.Permanent(fmt.Errorf("failed to build request: %w", err))
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if id, ok := tenant.FromContext(ctx); ok {
+		req.Header.Set(tenant.Header, id)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return errors.NewUnavailableError("Service request failed", err)
+	}
+	defer resp.Body.Close()
+
+	var result envelope
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err != nil && resp.StatusCode < 300 {
+		return resilience.Permanent(fmt.Errorf("failed to decode response: %w", err))
+	}
+
+	if resp.StatusCode >= 300 {
+		err := statusError(resp.StatusCode, result)
+		if resp.StatusCode < 500 {
+			return resilience.Permanent(err)
+		}
+		return err
+	}
+
+	if out != nil && len(result.Data) > 0 {
+		if err := json.Unmarshal(result.Data, out); err != nil {
+			return resilience.Permanent(fmt.Errorf("failed to decode response data: %w", err))
+		}
+	}
+	return nil
+}
+
+func statusError(status int, result envelope) error {
+	message := result.Message
+	if detail, ok := result.Error.(string); ok && detail != "" {
+		message = detail
+	} else if result.Detail != "" {
+		message = result.Detail
+	}
+	if message == "" {
+		message = result.Title
+	}
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	cause := fmt.Errorf("status %d: %s", status, message)
+
+	switch {
+	case status == http.StatusNotFound:
+		return errors.NewNotFoundError(message, cause).WithCode(result.Code)
+	case status == http.StatusConflict:
+		return errors.NewConflictError(message, cause).WithCode(result.Code)
+	case status == http.StatusBadRequest || status == http.StatusUnprocessableEntity:
+		return errors.NewValidationError(message, cause).WithCode(result.Code)
+	case status >= 500:
+		return errors.NewUnavailableError(message, cause).WithCode(result.Code)
+	default:
+		return fmt.Errorf("unexpected response: %w", cause)
+	}
+}
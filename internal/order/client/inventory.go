@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"ecommerce/internal/order/domain"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/resilience"
+)
+
+// Inventory reserves and releases product stock, and restocks returns
+type Inventory interface {
+	Reserve(ctx context.Context, reference string, items []domain.CheckoutItem) ([]domain.ReservedItem, error)
+	Release(ctx context.Context, reference string) error
+	Restock(ctx context.Context, reference, warehouse string, items []domain.CheckoutItem) error
+}
+
+type inventoryClient struct {
+	httpClient
+}
+
+// NewInventoryClient creates an inventory client backed by the product service
+func NewInventoryClient(baseURL string, client *http.Client, policy resilience.Policy) Inventory {
+	return &inventoryClient{httpClient: newHTTPClient(baseURL, client, policy)}
+}
+
+// Reserve holds stock under a reference, priced for the customer group of
+// the caller. The product service keeps the first reservation made under a
+// reference, so retrying is safe.
+func (c *inventoryClient) Reserve(ctx context.Context, reference string, items []domain.CheckoutItem) ([]domain.ReservedItem, error) {
+	body := map[string]interface{}{
+		"reference": reference,
+		"items":     items,
+	}
+	if group := auth.Group(ctx); group != "" {
+		body["customer_group"] = group
+	}
+
+	var reservation struct {
+		Items []domain.ReservedItem `json:"items"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/v1/stock/reservations", body, &reservation); err != nil {
+		return nil, err
+	}
+	return reservation.Items, nil
+}
+
+// Release returns reserved stock. A reference the product service never saw
+// needs no releasing, so it is not an error.
+func (c *inventoryClient) Release(ctx context.Context, reference string) error {
+	err := c.do(ctx, http.MethodDelete, "/api/v1/stock/reservations/"+url.PathEscape(reference), nil, nil)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// Restock puts returned goods back into stock, in the named warehouse or
+// else the default one. The product service restocks a reference once, so
+// retrying is safe.
+func (c *inventoryClient) Restock(ctx context.Context, reference, warehouse string, items []domain.CheckoutItem) error {
+	body := map[string]interface{}{
+		"reference": reference,
+		"items":     items,
+	}
+	if warehouse != "" {
+		body["warehouse"] = warehouse
+	}
+	return c.do(ctx, http.MethodPost, "/api/v1/stock/returns", body, nil)
+}
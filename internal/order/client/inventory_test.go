@@ -0,0 +1,59 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"ecommerce/contracts"
+	"ecommerce/internal/order/client"
+	"ecommerce/internal/order/domain"
+	"ecommerce/pkg/contract"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/resilience"
+)
+
+// TestInventoryContract runs the inventory client against the product
+// service as the contract describes it
+func TestInventoryContract(t *testing.T) {
+	c, err := contract.Load(contracts.FS, "order-service.product-service.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := contract.NewMock(t, c)
+	inventory := client.NewInventoryClient(mock.URL, http.DefaultClient, resilience.Policy{})
+	ctx := context.Background()
+	productID := uuid.New()
+
+	mock.Expect("reserve available stock")
+	items, err := inventory.Reserve(ctx, "order-1", []domain.CheckoutItem{{ProductID: productID, Quantity: 2}})
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	if len(items) != 1 || items[0].Quantity != 2 || items[0].SKU == "" || items[0].UnitPrice <= 0 {
+		t.Fatalf("Reserve returned %+v", items)
+	}
+
+	mock.Expect("reserve more stock than is available")
+	_, err = inventory.Reserve(ctx, "order-2", []domain.CheckoutItem{{ProductID: productID, Quantity: 1000}})
+	if !errors.IsConflict(err) || errors.Code(err) != errors.CodeInsufficientStock {
+		t.Fatalf("Reserve: got %v, want an insufficient stock conflict", err)
+	}
+
+	mock.Expect("release a reservation")
+	if err := inventory.Release(ctx, "order-1"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+
+	mock.Expect("return stock from a customer return")
+	if err := inventory.Restock(ctx, "return-1", "", []domain.CheckoutItem{{ProductID: productID, Quantity: 1}}); err != nil {
+		t.Fatalf("Restock: %v", err)
+	}
+
+	mock.Expect("release an unknown reservation")
+	if err := inventory.Release(ctx, "unknown-reference"); err != nil {
+		t.Fatalf("Release of an unknown reservation: %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"ecommerce/internal/order/domain"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/resilience"
+)
+
+// Payments authorizes, voids and refunds payments, and redeems gift cards
+// and loyalty points
+type Payments interface {
+	Authorize(ctx context.Context, req *domain.PaymentAuthorization) (*domain.Payment, error)
+	Void(ctx context.Context, reference string) error
+	Refund(ctx context.Context, paymentID string, amount float64, reason string) (*domain.PaymentRefund, error)
+
+	RedeemGiftCard(ctx context.Context, req *domain.GiftCardRedemption) (*domain.RedeemedGiftCard, error)
+	VoidGiftCards(ctx context.Context, reference string) error
+
+	RedeemPoints(ctx context.Context, req *domain.PointsRedemption) (*domain.RedeemedPoints, error)
+	VoidPoints(ctx context.Context, customerID, reference string) error
+}
+
+type paymentClient struct {
+	httpClient
+}
+
+// NewPaymentClient creates a client for the payment service
+func NewPaymentClient(baseURL string, client *http.Client, policy resilience.Policy) Payments {
+	return &paymentClient{httpClient: newHTTPClient(baseURL, client, policy)}
+}
+
+// Authorize places a hold on the customer's payment method. The payment
+// service deduplicates on the reference, so retrying is safe.
+func (c *paymentClient) Authorize(ctx context.Context, req *domain.PaymentAuthorization) (*domain.Payment, error) {
+	var payment domain.Payment
+	if err := c.do(ctx, http.MethodPost, "/api/v1/payments", req, &payment); err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// Void releases the hold placed under a reference. Voiding by reference
+// rather than payment ID lets a checkout undo an authorization whose
+// response it never received; a reference with no payment needs no voiding.
+func (c *paymentClient) Void(ctx context.Context, reference string) error {
+	body := map[string]string{"reference": reference}
+	err := c.do(ctx, http.MethodPost, "/api/v1/payments/void", body, nil)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// Refund returns part of a captured payment to the customer; a zero amount
+// refunds whatever has not been refunded yet. Refunds carry no reference
+// to deduplicate on, so they are never retried.
+func (c *paymentClient) Refund(ctx context.Context, paymentID string, amount float64, reason string) (*domain.PaymentRefund, error) {
+	body := map[string]interface{}{"amount": amount, "reason": reason}
+
+	var refund domain.PaymentRefund
+	if err := c.withoutRetry().do(ctx, http.MethodPost, "/api/v1/payments/"+url.PathEscape(paymentID)+"/refunds", body, &refund); err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// RedeemGiftCard spends up to the requested amount of a gift card. The
+// payment service redeems a card once per reference, so retrying is safe.
+func (c *paymentClient) RedeemGiftCard(ctx context.Context, req *domain.GiftCardRedemption) (*domain.RedeemedGiftCard, error) {
+	var redemption domain.RedeemedGiftCard
+	if err := c.do(ctx, http.MethodPost, "/api/v1/gift-cards/redeem", req, &redemption); err != nil {
+		return nil, err
+	}
+	return &redemption, nil
+}
+
+// VoidGiftCards returns whatever was redeemed under a reference to the
+// gift cards it came from; a reference with no redemptions needs nothing
+func (c *paymentClient) VoidGiftCards(ctx context.Context, reference string) error {
+	body := map[string]string{"reference": reference}
+	return c.do(ctx, http.MethodPost, "/api/v1/gift-cards/void", body, nil)
+}
+
+// RedeemPoints pays for up to the requested amount with a customer's
+// loyalty points. The payment service redeems once per reference, so
+// retrying is safe.
+func (c *paymentClient) RedeemPoints(ctx context.Context, req *domain.PointsRedemption) (*domain.RedeemedPoints, error) {
+	var redemption domain.RedeemedPoints
+	if err := c.do(ctx, http.MethodPost, "/api/v1/loyalty/redeem", req, &redemption); err != nil {
+		return nil, err
+	}
+	return &redemption, nil
+}
+
+// VoidPoints gives back the points a customer redeemed under a reference;
+// a reference with no redemption needs nothing
+func (c *paymentClient) VoidPoints(ctx context.Context, customerID, reference string) error {
+	body := map[string]string{"customer_id": customerID, "reference": reference}
+	return c.do(ctx, http.MethodPost, "/api/v1/loyalty/void", body, nil)
+}
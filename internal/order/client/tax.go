@@ -0,0 +1,33 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"ecommerce/internal/order/domain"
+	"ecommerce/pkg/resilience"
+)
+
+// Taxes calculates the tax on a basket
+type Taxes interface {
+	Calculate(ctx context.Context, req *domain.TaxRequest) (*domain.Tax, error)
+}
+
+type taxClient struct {
+	httpClient
+}
+
+// NewTaxClient creates a client for the tax service
+func NewTaxClient(baseURL string, client *http.Client, policy resilience.Policy) Taxes {
+	return &taxClient{httpClient: newHTTPClient(baseURL, client, policy)}
+}
+
+// Calculate taxes a basket at an address. Calculating has no side effects,
+// so retrying is safe.
+func (c *taxClient) Calculate(ctx context.Context, req *domain.TaxRequest) (*domain.Tax, error) {
+	var tax domain.Tax
+	if err := c.do(ctx, http.MethodPost, "/api/v1/tax/calculate", req, &tax); err != nil {
+		return nil, err
+	}
+	return &tax, nil
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"strconv"
+
+	productconfig "ecommerce/internal/product/config"
+)
+
+// Config holds all configuration for the order service
+type Config struct {
+	Env      string
+	HTTP     productconfig.HTTPConfig
+	Database productconfig.DatabaseConfig
+	Auth     productconfig.AuthConfig
+	Debug    productconfig.DebugConfig
+	MTLS     productconfig.MTLSConfig
+	Logger   productconfig.LoggerConfig
+	Events   productconfig.EventsConfig
+	Redis    productconfig.RedisConfig
+	Schedule productconfig.ScheduleConfig
+	Services ServicesConfig
+	Checkout CheckoutConfig
+	Returns  ReturnsConfig
+	Invoices InvoicesConfig
+}
+
+// ServicesConfig holds the addresses of the services checkout coordinates
+type ServicesConfig struct {
+	ProductURL string
+	PaymentURL string
+	TaxURL     string
+	Timeout    int // seconds per attempt
+
+	RetryAttempts      int // attempts for calls that are safe to repeat
+	BreakerFailures    int // consecutive failures that cut a service off
+	BreakerOpenTimeout int // seconds before a cut-off service is tried again
+}
+
+// CheckoutConfig holds checkout saga configuration
+type CheckoutConfig struct {
+	Currency         string
+	PricesIncludeTax bool   // catalog prices already include tax, which is backed out of them rather than added
+	RecoverySchedule string // cron schedule of sweeps for abandoned checkouts, whose stock reservations they release
+	StaleAfter       int    // seconds before a pending checkout is considered abandoned
+	GuestSecret      string // signs guests' order tokens; guest checkout is disabled when unset
+}
+
+// ReturnsConfig holds returns configuration
+type ReturnsConfig struct {
+	Window int // days after an order is placed that its items can be returned
+}
+
+// InvoicesConfig holds invoice configuration. Invoice PDFs are kept in an
+// S3-compatible bucket; invoicing is disabled when no bucket is set.
+type InvoicesConfig struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool // address the bucket as endpoint/bucket, as MinIO expects
+	Timeout   int  // seconds
+	URLExpiry int  // seconds an invoice's download URL stays valid
+
+	Prefix        string // put in front of invoice numbers
+	Template      string // optional; path of a text/template file laying out invoices
+	SellerName    string
+	SellerAddress string
+	SellerTaxID   string
+}
+
+// Load loads configuration from environment variables. The HTTP, database,
+// auth, event, Redis and scheduler settings use the same variables as the
+// product service.
+func Load() (*Config, error) {
+	shared, err := productconfig.Load()
+	if err != nil {
+		return nil, err
+	}
+	return &Config{
+		Env:      shared.Env,
+		HTTP:     shared.HTTP,
+		Database: shared.Database,
+		Auth:     shared.Auth,
+		Debug:    shared.Debug,
+		MTLS:     shared.MTLS,
+		Logger:   shared.Logger,
+		Events:   shared.Events,
+		Redis:    shared.Redis,
+		Schedule: shared.Schedule,
+		Services: ServicesConfig{
+			ProductURL: getEnv("PRODUCT_SERVICE_URL", "http://localhost:8081"),
+			PaymentURL: getEnv("PAYMENT_SERVICE_URL", "http://localhost:8087"),
+			TaxURL:     getEnv("TAX_SERVICE_URL", "http://localhost:8089"),
+			Timeout:    getEnvAsInt("SERVICE_TIMEOUT", 10),
+
+			RetryAttempts:      getEnvAsInt("SERVICE_RETRY_ATTEMPTS", 3),
+			BreakerFailures:    getEnvAsInt("SERVICE_BREAKER_FAILURES", 5),
+			BreakerOpenTimeout: getEnvAsInt("SERVICE_BREAKER_OPEN_TIMEOUT", 30),
+		},
+		Checkout: CheckoutConfig{
+			Currency:         getEnv("CHECKOUT_CURRENCY", "usd"),
+			PricesIncludeTax: getEnvAsBool("CHECKOUT_PRICES_INCLUDE_TAX", false),
+			RecoverySchedule: getEnv("CHECKOUT_RECOVERY_SCHEDULE", "* * * * *"),
+			StaleAfter:       getEnvAsInt("CHECKOUT_STALE_AFTER", 300),
+			GuestSecret:      getEnv("GUEST_ORDER_SECRET", ""),
+		},
+		Returns: ReturnsConfig{
+			Window: getEnvAsInt("RETURN_WINDOW_DAYS", 30),
+		},
+		Invoices: InvoicesConfig{
+			Endpoint:  getEnv("S3_ENDPOINT", "http://localhost:9000"),
+			Region:    getEnv("S3_REGION", "us-east-1"),
+			Bucket:    getEnv("INVOICE_BUCKET", ""),
+			AccessKey: getEnv("S3_ACCESS_KEY", ""),
+			SecretKey: getEnv("S3_SECRET_KEY", ""),
+			PathStyle: getEnvAsBool("S3_PATH_STYLE", true),
+			Timeout:   getEnvAsInt("INVOICE_STORAGE_TIMEOUT", 30),
+			URLExpiry: getEnvAsInt("INVOICE_URL_EXPIRY", 7*24*3600),
+
+			Prefix:        getEnv("INVOICE_NUMBER_PREFIX", "INV-"),
+			Template:      getEnv("INVOICE_TEMPLATE", ""),
+			SellerName:    getEnv("INVOICE_SELLER_NAME", ""),
+			SellerAddress: getEnv("INVOICE_SELLER_ADDRESS", ""),
+			SellerTaxID:   getEnv("INVOICE_SELLER_TAX_ID", ""),
+		},
+	}, nil
+}
+
+// Validate reports every setting that is missing or invalid, so the service
+// refuses to start rather than run misconfigured
+func (c *Config) Validate() error {
+	errs := []error{
+		productconfig.ValidateEnv(c.Env),
+		c.HTTP.Validate(),
+		c.Database.Validate(c.Env),
+		c.Logger.Validate(),
+		c.MTLS.Validate(),
+		c.MTLS.ValidateURLs(map[string]string{
+			"PRODUCT_SERVICE_URL": c.Services.ProductURL,
+			"PAYMENT_SERVICE_URL": c.Services.PaymentURL,
+			"TAX_SERVICE_URL":     c.Services.TaxURL,
+		}),
+		c.MTLS.ValidateURLs(productconfig.ForwardURLs(c.Events.ForwardURLs)),
+		c.Auth.Validate(),
+		c.Redis.Validate(),
+		c.Schedule.Validate(),
+		productconfig.ValidateSchedule("CHECKOUT_RECOVERY_SCHEDULE", c.Checkout.RecoverySchedule),
+	}
+	if c.Services.ProductURL == "" || c.Services.PaymentURL == "" || c.Services.TaxURL == "" {
+		errs = append(errs, errors.New("PRODUCT_SERVICE_URL, PAYMENT_SERVICE_URL and TAX_SERVICE_URL must be set"))
+	}
+	if c.Checkout.Currency == "" {
+		errs = append(errs, errors.New("CHECKOUT_CURRENCY must be set"))
+	}
+	if c.Checkout.GuestSecret != "" && len(c.Checkout.GuestSecret) < 32 {
+		errs = append(errs, errors.New("GUEST_ORDER_SECRET must be at least 32 characters"))
+	}
+	if c.Returns.Window <= 0 {
+		errs = append(errs, errors.New("RETURN_WINDOW_DAYS must be positive"))
+	}
+	if c.Invoices.Bucket != "" && (c.Invoices.URLExpiry <= 0 || c.Invoices.URLExpiry > 7*24*3600) {
+		errs = append(errs, errors.New("INVOICE_URL_EXPIRY must be between 1 second and 7 days"))
+	}
+	return errors.Join(errs...)
+}
+
+// getEnv gets an environment variable with a default value
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvAsInt gets an environment variable as integer with a default value
+func getEnvAsInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsBool gets an environment variable as boolean with a default value
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,191 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Checkout statuses
+const (
+	CheckoutStatusPending   = "pending"
+	CheckoutStatusCompleted = "completed"
+	CheckoutStatusFailed    = "failed"
+)
+
+// Checkout saga steps, in the order they run
+const (
+	StepReserveStock     = "reserve_stock"
+	StepCalculateTax     = "calculate_tax"
+	StepRedeemGiftCards  = "redeem_gift_cards"
+	StepRedeemPoints     = "redeem_points"
+	StepAuthorizePayment = "authorize_payment"
+	StepCreateOrder      = "create_order"
+)
+
+// Payment statuses reported by the payment service
+const (
+	PaymentStatusAuthorized = "authorized"
+)
+
+// Checkout tracks one checkout saga. It is keyed by the client's idempotency
+// key so retries find the saga instead of starting another one, and records
+// which steps have completed so a failure can be compensated.
+type Checkout struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	IdempotencyKey string     `json:"-" gorm:"not null;uniqueIndex"`
+	RequestHash    string     `json:"-" gorm:"not null"`
+	CustomerID     string     `json:"customer_id" gorm:"not null"`
+	TenantID       string     `json:"-"` // the tenant whose catalog the checkout reserved stock in
+	Status         string     `json:"status" gorm:"not null"`
+	Step           string     `json:"step,omitempty"` // last step that completed
+	Attempt        int        `json:"attempt"`
+	Reference      string     `json:"-"` // stock reservation and payment reference of the current attempt
+	PaymentID      string     `json:"-"`
+	OrderID        *uuid.UUID `json:"order_id,omitempty" gorm:"type:uuid"`
+	Error          string     `json:"error,omitempty"`
+	Compensated    bool       `json:"-"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// CheckoutItem is a product and quantity being bought
+type CheckoutItem struct {
+	ProductID uuid.UUID `json:"product_id" validate:"required"`
+	Quantity  int       `json:"quantity" validate:"required,gt=0"`
+}
+
+// CheckoutRequest represents the request to check out a basket
+type CheckoutRequest struct {
+	Items         []CheckoutItem `json:"items" validate:"required,min=1,dive"`
+	PaymentMethod string         `json:"payment_method" validate:"required_without_all=GiftCards LoyaltyPoints"` // for what gift cards and points do not cover
+	GiftCards     []string       `json:"gift_cards,omitempty" validate:"max=5,dive,required,max=64"`
+	LoyaltyPoints int64          `json:"loyalty_points,omitempty" validate:"gte=0"` // the most points to spend
+	Address       Address        `json:"address"`
+	Phone         string         `json:"phone,omitempty" validate:"omitempty,e164"` // for SMS order updates
+
+	// Email is where a guest's order updates go. It is required of guests;
+	// customers' go to the email of their account.
+	Email string `json:"email,omitempty" validate:"omitempty,email,max=255"`
+}
+
+// Address is where an order is taxed: where it is delivered, or for orders
+// that ship nothing, the customer's billing address
+type Address struct {
+	Country    string `json:"country" validate:"required,iso3166_1_alpha2"`
+	Region     string `json:"region,omitempty" validate:"max=10"`
+	PostalCode string `json:"postal_code,omitempty" validate:"max=20"`
+	City       string `json:"city,omitempty" validate:"max=100"`
+}
+
+// CheckoutResult is the outcome of a checkout
+type CheckoutResult struct {
+	Checkout *Checkout `json:"checkout"`
+	Order    *Order    `json:"order,omitempty"`
+	Replayed bool      `json:"replayed"`
+
+	// OrderToken lets a guest look up the order, sent as the X-Order-Token
+	// header
+	OrderToken string `json:"order_token,omitempty"`
+}
+
+// ReservedItem is a product line reserved by the product service
+type ReservedItem struct {
+	ProductID  uuid.UUID `json:"product_id"`
+	CategoryID uuid.UUID `json:"category_id"`
+	SKU        string    `json:"sku"`
+	Name       string    `json:"name"`
+	Type       string    `json:"type"`
+	TaxClass   string    `json:"tax_class"`
+	Quantity   int       `json:"quantity"`
+	UnitPrice  float64   `json:"unit_price"`
+}
+
+// TaxItem is a line of the basket sent to the tax service
+type TaxItem struct {
+	ProductID uuid.UUID `json:"product_id"`
+	TaxClass  string    `json:"tax_class,omitempty"`
+	Quantity  int       `json:"quantity"`
+	UnitPrice float64   `json:"unit_price"`
+}
+
+// TaxRequest is the request sent to the tax service
+type TaxRequest struct {
+	Address          Address   `json:"address"`
+	Items            []TaxItem `json:"items"`
+	PricesIncludeTax bool      `json:"prices_include_tax"`
+}
+
+// TaxLine is the tax service's view of the tax on one line
+type TaxLine struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Rate      float64   `json:"rate"`
+	Tax       float64   `json:"tax"`
+}
+
+// Tax is the tax service's view of a taxed basket. Its lines are in the
+// order of the request's items.
+type Tax struct {
+	TotalExclusive float64   `json:"total_exclusive"`
+	Tax            float64   `json:"tax"`
+	TotalInclusive float64   `json:"total_inclusive"`
+	Lines          []TaxLine `json:"lines"`
+}
+
+// PaymentAuthorization is the request sent to the payment service
+type PaymentAuthorization struct {
+	Reference     string  `json:"reference"`
+	CustomerID    string  `json:"customer_id"`
+	Amount        float64 `json:"amount"`
+	Currency      string  `json:"currency"`
+	PaymentMethod string  `json:"payment_method"`
+}
+
+// GiftCardRedemption is the request sent to the payment service to spend
+// up to Amount of a gift card
+type GiftCardRedemption struct {
+	Code      string  `json:"code"`
+	Reference string  `json:"reference"`
+	Amount    float64 `json:"amount"`
+	Currency  string  `json:"currency"`
+}
+
+// RedeemedGiftCard is the payment service's view of a redemption
+type RedeemedGiftCard struct {
+	GiftCardID string  `json:"gift_card_id"`
+	Amount     float64 `json:"amount"`
+	Balance    float64 `json:"balance"`
+}
+
+// PointsRedemption is the request sent to the payment service to pay for
+// up to Amount with a customer's loyalty points
+type PointsRedemption struct {
+	CustomerID string  `json:"customer_id"`
+	Reference  string  `json:"reference"`
+	Points     int64   `json:"points"`
+	Amount     float64 `json:"amount"`
+}
+
+// RedeemedPoints is the payment service's view of a points redemption
+type RedeemedPoints struct {
+	Points int64   `json:"points"`
+	Amount float64 `json:"amount"`
+}
+
+// Payment is the payment service's view of an authorization
+type Payment struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+// PaymentRefund is the payment service's view of a refund
+type PaymentRefund struct {
+	ID     string  `json:"id"`
+	Amount float64 `json:"amount"`
+	Status string  `json:"status"`
+}
+
+// TableName returns the table name for Checkout
+func (Checkout) TableName() string {
+	return "checkouts"
+}
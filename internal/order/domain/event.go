@@ -0,0 +1,28 @@
+package domain
+
+// EventSource identifies events published by the order service
+const EventSource = "order-service"
+
+// Order event types, for an order's creation, each status it moves to and
+// a guest order being claimed by an account
+const (
+	EventOrderCreated    = "order.created"
+	EventOrderClaimed    = "order.claimed"
+	EventOrderProcessing = "order.processing"
+	EventOrderShipped    = "order.shipped"
+	EventOrderDelivered  = "order.delivered"
+	EventOrderCancelled  = "order.cancelled"
+)
+
+// Return event types, one for each status a return moves to
+const (
+	EventReturnRequested = "return.requested"
+	EventReturnApproved  = "return.approved"
+	EventReturnRejected  = "return.rejected"
+	EventReturnReceived  = "return.received"
+	EventReturnRefunded  = "return.refunded"
+	EventReturnCancelled = "return.cancelled"
+)
+
+// EventInvoiceIssued is published once an order's invoice can be downloaded
+const EventInvoiceIssued = "invoice.issued"
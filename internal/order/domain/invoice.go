@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Invoice is the numbered bill of an order. Numbers run on without gaps in
+// the order invoices are issued; the PDF is rendered once and kept in
+// storage, so it never changes after it was sent.
+type Invoice struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrderID   uuid.UUID  `json:"order_id" gorm:"type:uuid;not null;uniqueIndex"`
+	Sequence  int64      `json:"-" gorm:"not null;uniqueIndex"`
+	Number    string     `json:"number" gorm:"not null;uniqueIndex"`
+	Currency  string     `json:"currency" gorm:"not null"`
+	Subtotal  float64    `json:"subtotal"`
+	Tax       float64    `json:"tax"`
+	Total     float64    `json:"total"`
+	IssuedAt  time.Time  `json:"issued_at"`
+	StoredAt  *time.Time `json:"-"` // unset until the PDF is in storage
+	URL       string     `json:"url,omitempty" gorm:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// InvoiceIssued is the payload of invoice.issued, sent once an invoice's
+// PDF can be downloaded
+type InvoiceIssued struct {
+	ID         uuid.UUID `json:"id"`
+	Number     string    `json:"number"`
+	OrderID    uuid.UUID `json:"order_id"`
+	CustomerID string    `json:"customer_id"`
+	Email      string    `json:"email,omitempty"`
+	Currency   string    `json:"currency"`
+	Total      float64   `json:"total"`
+	URL        string    `json:"url"`
+	IssuedAt   time.Time `json:"issued_at"`
+}
+
+// TaxRateTotal is the part of an order taxed at one rate
+type TaxRateTotal struct {
+	Rate   float64 `json:"rate"` // percent
+	Amount float64 `json:"amount"`
+	Tax    float64 `json:"tax"`
+}
+
+// StorageKey is where an invoice's PDF is kept
+func (i *Invoice) StorageKey() string {
+	return "invoices/" + i.Number + ".pdf"
+}
+
+// TaxBreakdown totals an order's items by tax rate, lowest rate first
+func (o *Order) TaxBreakdown() []TaxRateTotal {
+	var lines []TaxRateTotal
+	for _, item := range o.Items {
+		i := slices.IndexFunc(lines, func(line TaxRateTotal) bool { return line.Rate == item.TaxRate })
+		if i < 0 {
+			lines = append(lines, TaxRateTotal{Rate: item.TaxRate})
+			i = len(lines) - 1
+		}
+		lines[i].Amount += item.Total
+		lines[i].Tax += item.Tax
+	}
+	for i := range lines {
+		lines[i].Amount = math.Round(lines[i].Amount*100) / 100
+		lines[i].Tax = math.Round(lines[i].Tax*100) / 100
+	}
+	slices.SortFunc(lines, func(a, b TaxRateTotal) int {
+		return cmp.Compare(a.Rate, b.Rate)
+	})
+	return lines
+}
+
+// TableName returns the table name for Invoice
+func (Invoice) TableName() string {
+	return "invoices"
+}
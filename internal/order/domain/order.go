@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Order statuses. A confirmed order is processed, shipped and delivered;
+// it can be cancelled until it ships.
+const (
+	OrderStatusConfirmed  = "confirmed"
+	OrderStatusProcessing = "processing"
+	OrderStatusShipped    = "shipped"
+	OrderStatusDelivered  = "delivered"
+	OrderStatusCancelled  = "cancelled"
+)
+
+// orderTransitions lists the statuses each order status can move to
+var orderTransitions = map[string][]string{
+	OrderStatusConfirmed:  {OrderStatusProcessing, OrderStatusShipped, OrderStatusCancelled},
+	OrderStatusProcessing: {OrderStatusShipped, OrderStatusCancelled},
+	OrderStatusShipped:    {OrderStatusDelivered},
+}
+
+// Product types as the product service reports them. Physical products
+// are shipped, and so are bundles, which ship their components.
+const (
+	ProductTypePhysical = "physical"
+	ProductTypeDigital  = "digital"
+	ProductTypeService  = "service"
+	ProductTypeBundle   = "bundle"
+	ProductTypeGiftCard = "gift_card"
+)
+
+// Order represents a placed order. Orders of digital products, services and
+// gift cards alone need no shipping. Whatever gift cards and loyalty points
+// did not cover of the total was charged to the payment method. Guest
+// orders were placed without an account, until one claims them.
+type Order struct {
+	ID               uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CustomerID       string      `json:"customer_id" gorm:"not null;index"`
+	Guest            bool        `json:"guest" gorm:"not null;default:false"`
+	Email            string      `json:"email,omitempty"`
+	Phone            string      `json:"phone,omitempty"`
+	Status           string      `json:"status" gorm:"not null"`
+	Currency         string      `json:"currency" gorm:"not null"`
+	Subtotal         float64     `json:"subtotal"`
+	Tax              float64     `json:"tax"`
+	Total            float64     `json:"total"`
+	GiftCardAmount   float64     `json:"gift_card_amount"`
+	PointsRedeemed   int64       `json:"points_redeemed"`
+	PointsAmount     float64     `json:"points_amount"` // what the points redeemed paid
+	PaymentID        string      `json:"payment_id"`
+	CheckoutID       uuid.UUID   `json:"checkout_id" gorm:"type:uuid"`
+	Items            []OrderItem `json:"items" gorm:"foreignKey:OrderID"`
+	RequiresShipping bool        `json:"requires_shipping" gorm:"not null"`
+	Country          string      `json:"country,omitempty"` // of the address the order was taxed at
+	Region           string      `json:"region,omitempty"`
+	PostalCode       string      `json:"postal_code,omitempty"`
+	CreatedAt        time.Time   `json:"created_at"`
+	UpdatedAt        time.Time   `json:"updated_at"`
+}
+
+// OrderItem is a product line of an order, captured at the price paid
+type OrderItem struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrderID   uuid.UUID `json:"-" gorm:"type:uuid;not null"`
+	ProductID uuid.UUID `json:"product_id" gorm:"type:uuid;not null"`
+	SKU       string    `json:"sku"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type" gorm:"not null;default:physical"`
+	Quantity  int       `json:"quantity"`
+	UnitPrice float64   `json:"unit_price"`
+	Total     float64   `json:"total"`
+	TaxClass  string    `json:"tax_class"`
+	TaxRate   float64   `json:"tax_rate"` // percent
+	Tax       float64   `json:"tax"`
+}
+
+// OrderEvent is an entry in an order's timeline: its creation or a change
+// of its status, with the event published for it
+type OrderEvent struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrderID    uuid.UUID `json:"order_id" gorm:"type:uuid;not null"`
+	Type       string    `json:"type" gorm:"not null"`
+	FromStatus string    `json:"from_status,omitempty"`
+	Status     string    `json:"status" gorm:"not null"`
+	Note       string    `json:"note,omitempty"`
+	ActorID    string    `json:"actor_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// UpdateOrderStatusRequest represents the request to move an order on
+type UpdateOrderStatusRequest struct {
+	Status string `json:"status" validate:"required,oneof=processing shipped delivered cancelled"`
+	Note   string `json:"note" validate:"max=1000"`
+}
+
+// OrderFilters represents filters for order queries
+type OrderFilters struct {
+	CustomerID string `json:"customer_id,omitempty"`
+	Status     string `json:"status,omitempty"`
+	Limit      int    `json:"limit,omitempty"`
+	Offset     int    `json:"offset,omitempty"`
+}
+
+// OrderList represents a paginated list of orders
+type OrderList struct {
+	Orders  []Order `json:"orders"`
+	Total   int64   `json:"total"`
+	Limit   int     `json:"limit"`
+	Offset  int     `json:"offset"`
+	HasMore bool    `json:"has_more"`
+}
+
+// GuestCustomerID is the customer a guest order is placed for until it is
+// claimed
+func GuestCustomerID(email string) string {
+	return "guest:" + strings.ToLower(email)
+}
+
+// CanTransition reports whether an order can move to the given status
+func (o *Order) CanTransition(to string) bool {
+	return slices.Contains(orderTransitions[o.Status], to)
+}
+
+// TableName returns the table name for Order
+func (Order) TableName() string {
+	return "orders"
+}
+
+// TableName returns the table name for OrderItem
+func (OrderItem) TableName() string {
+	return "order_items"
+}
+
+// TableName returns the table name for OrderEvent
+func (OrderEvent) TableName() string {
+	return "order_events"
+}
@@ -0,0 +1,169 @@
+package domain
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Return statuses. A customer requests a return, which is approved or
+// rejected. The goods of an approved return are received, and put back
+// into stock unless they cannot be sold again, before they are refunded.
+// A return can be cancelled until its goods are received.
+const (
+	ReturnStatusRequested = "requested"
+	ReturnStatusApproved  = "approved"
+	ReturnStatusRejected  = "rejected"
+	ReturnStatusReceived  = "received"
+	ReturnStatusRefunded  = "refunded"
+	ReturnStatusCancelled = "cancelled"
+)
+
+// Return reasons
+const (
+	ReturnReasonDamaged        = "damaged"
+	ReturnReasonDefective      = "defective"
+	ReturnReasonWrongItem      = "wrong_item"
+	ReturnReasonNotAsDescribed = "not_as_described"
+	ReturnReasonNoLongerNeeded = "no_longer_needed"
+	ReturnReasonOther          = "other"
+)
+
+// returnTransitions lists the statuses each return status can move to
+var returnTransitions = map[string][]string{
+	ReturnStatusRequested: {ReturnStatusApproved, ReturnStatusRejected, ReturnStatusCancelled},
+	ReturnStatusApproved:  {ReturnStatusReceived, ReturnStatusCancelled},
+	ReturnStatusReceived:  {ReturnStatusRefunded},
+}
+
+// Return is a customer's request to send back some of the items of an
+// order for a refund. Amount is what the returned items cost, tax
+// included; Refunded is what went back to the payment method.
+type Return struct {
+	ID         uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrderID    uuid.UUID    `json:"order_id" gorm:"type:uuid;not null;index"`
+	CustomerID string       `json:"customer_id" gorm:"not null;index"`
+	Status     string       `json:"status" gorm:"not null"`
+	Reason     string       `json:"reason" gorm:"not null"`
+	Note       string       `json:"note,omitempty"`       // the customer's explanation
+	Resolution string       `json:"resolution,omitempty"` // why the return was approved or rejected
+	Items      []ReturnItem `json:"items" gorm:"foreignKey:ReturnID"`
+	Amount     float64      `json:"amount"`
+	Refunded   float64      `json:"refunded"`
+	RefundID   string       `json:"refund_id,omitempty"`
+	Restocked  bool         `json:"restocked"`
+	ReviewedAt *time.Time   `json:"reviewed_at,omitempty"`
+	ReceivedAt *time.Time   `json:"received_at,omitempty"`
+	RefundedAt *time.Time   `json:"refunded_at,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+}
+
+// ReturnItem is an order item, or some of its units, sent back under a
+// return
+type ReturnItem struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ReturnID    uuid.UUID `json:"-" gorm:"type:uuid;not null"`
+	OrderItemID uuid.UUID `json:"order_item_id" gorm:"type:uuid;not null"`
+	ProductID   uuid.UUID `json:"product_id" gorm:"type:uuid;not null"`
+	SKU         string    `json:"sku"`
+	Name        string    `json:"name"`
+	Quantity    int       `json:"quantity"`
+	Amount      float64   `json:"amount"`
+}
+
+// ReturnItemRequest selects units of an order item to return
+type ReturnItemRequest struct {
+	OrderItemID uuid.UUID `json:"order_item_id" validate:"required"`
+	Quantity    int       `json:"quantity" validate:"required,gt=0"`
+}
+
+// CreateReturnRequest represents the request to return items of an order
+type CreateReturnRequest struct {
+	Reason string              `json:"reason" validate:"required,oneof=damaged defective wrong_item not_as_described no_longer_needed other"`
+	Note   string              `json:"note" validate:"max=1000"`
+	Items  []ReturnItemRequest `json:"items" validate:"required,min=1,max=100,dive"`
+}
+
+// ReviewReturnRequest represents the request to approve or reject a return
+type ReviewReturnRequest struct {
+	Resolution string `json:"resolution" validate:"max=1000"`
+}
+
+// ReceiveReturnRequest represents the request to record that a return's
+// goods arrived. They go back into stock in the named warehouse, or else the
+// default one, unless Restock is false.
+type ReceiveReturnRequest struct {
+	Restock   *bool  `json:"restock,omitempty"` // restocked when omitted
+	Warehouse string `json:"warehouse,omitempty" validate:"max=50"`
+}
+
+// RefundReturnRequest represents the request to refund a received return.
+// Without an amount, the return's amount is refunded, as far as the
+// payment method paid for the order.
+type RefundReturnRequest struct {
+	Amount *float64 `json:"amount,omitempty" validate:"omitempty,gt=0"`
+}
+
+// ReturnFilters represents filters for return queries
+type ReturnFilters struct {
+	CustomerID string     `json:"customer_id,omitempty"`
+	OrderID    *uuid.UUID `json:"order_id,omitempty"`
+	Status     string     `json:"status,omitempty"`
+	Limit      int        `json:"limit,omitempty"`
+	Offset     int        `json:"offset,omitempty"`
+}
+
+// ReturnList represents a paginated list of returns
+type ReturnList struct {
+	Returns []Return `json:"returns"`
+	Total   int64    `json:"total"`
+	Limit   int      `json:"limit"`
+	Offset  int      `json:"offset"`
+	HasMore bool     `json:"has_more"`
+}
+
+// CanTransition reports whether a return can move to the given status
+func (r *Return) CanTransition(to string) bool {
+	return slices.Contains(returnTransitions[r.Status], to)
+}
+
+// Open reports whether a return still holds on to the items it names, so
+// they cannot be returned again
+func (r *Return) Open() bool {
+	return r.Status != ReturnStatusRejected && r.Status != ReturnStatusCancelled
+}
+
+// Returnable reports whether an order item can be sent back. Only what was
+// shipped can be.
+func (i *OrderItem) Returnable() bool {
+	return i.Type == ProductTypePhysical || i.Type == ProductTypeBundle
+}
+
+// ReturnAmount is what quantity units of an order item cost, with their
+// share of the order's tax when prices did not include it
+func (o *Order) ReturnAmount(item *OrderItem, quantity int) float64 {
+	if item.Quantity == 0 || o.Subtotal == 0 {
+		return 0
+	}
+	amount := item.Total * float64(quantity) / float64(item.Quantity) * o.Total / o.Subtotal
+	return math.Round(amount*100) / 100
+}
+
+// Charged is what the payment method paid of an order, after gift cards
+// and loyalty points
+func (o *Order) Charged() float64 {
+	return math.Max(0, math.Round((o.Total-o.GiftCardAmount-o.PointsAmount)*100)/100)
+}
+
+// TableName returns the table name for Return
+func (Return) TableName() string {
+	return "returns"
+}
+
+// TableName returns the table name for ReturnItem
+func (ReturnItem) TableName() string {
+	return "return_items"
+}
@@ -0,0 +1,260 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/order/domain"
+	"ecommerce/internal/order/service"
+	"ecommerce/pkg/database"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/response"
+)
+
+// HTTPHandler handles HTTP requests for order service
+type HTTPHandler struct {
+	service service.OrderService
+	logger  *logrus.Logger
+}
+
+// NewHTTPHandler creates a new HTTP handler
+func NewHTTPHandler(service service.OrderService, logger *logrus.Logger) *HTTPHandler {
+	return &HTTPHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// orderToken passes on the order token a guest sends, in the X-Order-Token
+// header, to look up their order
+func orderToken(c *gin.Context) {
+	if token := c.GetHeader("X-Order-Token"); token != "" {
+		c.Request = c.Request.WithContext(service.WithOrderToken(c.Request.Context(), token))
+	}
+	c.Next()
+}
+
+// RegisterRoutes registers all HTTP routes
+func (h *HTTPHandler) RegisterRoutes(router *gin.Engine) {
+	api := router.Group("/api/v1")
+
+	// Checkout routes
+	api.POST("/checkout", h.Checkout)
+
+	// Order routes
+	orders := api.Group("/orders", orderToken)
+	{
+		orders.GET("", h.ListOrders)
+		orders.POST("/claim", h.ClaimOrders)
+		orders.GET("/:id", h.GetOrder)
+		orders.PUT("/:id/status", h.UpdateOrderStatus)
+		orders.GET("/:id/events", h.ListOrderEvents)
+		orders.GET("/:id/invoice", h.GetInvoice)
+		orders.POST("/:id/returns", h.CreateReturn)
+		orders.GET("/:id/returns", h.ListOrderReturns)
+	}
+
+	// Return routes
+	returns := api.Group("/returns")
+	{
+		returns.GET("", h.ListReturns)
+		returns.GET("/:id", h.GetReturn)
+		returns.POST("/:id/approve", h.ApproveReturn)
+		returns.POST("/:id/reject", h.RejectReturn)
+		returns.POST("/:id/receive", h.ReceiveReturn)
+		returns.POST("/:id/refund", h.RefundReturn)
+		returns.POST("/:id/cancel", h.CancelReturn)
+	}
+
+	// Health check
+	router.GET("/health", h.HealthCheck)
+	router.GET("/ready", h.ReadinessCheck)
+}
+
+// Checkout handles checking out a basket. Clients must send an
+// Idempotency-Key header and reuse it when retrying the same checkout.
+func (h *HTTPHandler) Checkout(c *gin.Context) {
+	var req domain.CheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	result, err := h.service.Checkout(c.Request.Context(), c.GetHeader("Idempotency-Key"), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	if result.Replayed {
+		c.Header("Idempotent-Replayed", "true")
+		response.Success(c, http.StatusOK, "Checkout already completed", result)
+		return
+	}
+	response.Success(c, http.StatusCreated, "Checkout completed successfully", result)
+}
+
+// GetOrder handles getting a single order
+func (h *HTTPHandler) GetOrder(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid order ID", err)
+		return
+	}
+
+	order, err := h.service.GetOrder(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Order retrieved successfully", order)
+}
+
+// UpdateOrderStatus handles moving an order on through fulfilment
+func (h *HTTPHandler) UpdateOrderStatus(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid order ID", err)
+		return
+	}
+
+	var req domain.UpdateOrderStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	order, err := h.service.UpdateOrderStatus(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Order status updated successfully", order)
+}
+
+// ListOrderEvents handles getting an order's status timeline
+func (h *HTTPHandler) ListOrderEvents(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid order ID", err)
+		return
+	}
+
+	events, err := h.service.ListOrderEvents(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Order events retrieved successfully", gin.H{
+		"events": events,
+	})
+}
+
+// GetInvoice handles getting an order's invoice
+func (h *HTTPHandler) GetInvoice(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid order ID", err)
+		return
+	}
+
+	invoice, err := h.service.GetInvoice(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Invoice retrieved successfully", invoice)
+}
+
+// ClaimOrders handles attaching guest orders to the caller's account
+func (h *HTTPHandler) ClaimOrders(c *gin.Context) {
+	orders, err := h.service.ClaimOrders(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Orders claimed successfully", gin.H{
+		"orders": orders,
+	})
+}
+
+// ListOrders handles order listing with filters
+func (h *HTTPHandler) ListOrders(c *gin.Context) {
+	filters := &domain.OrderFilters{
+		CustomerID: c.Query("customer_id"),
+		Status:     c.Query("status"),
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			filters.Limit = l
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if o, err := strconv.Atoi(offset); err == nil {
+			filters.Offset = o
+		}
+	}
+
+	orders, err := h.service.ListOrders(c.Request.Context(), filters)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Orders retrieved successfully", orders)
+}
+
+// HealthCheck handles health check requests
+func (h *HTTPHandler) HealthCheck(c *gin.Context) {
+	response.Success(c, http.StatusOK, "Service is healthy", gin.H{
+		"service": "order-service",
+		"status":  "healthy",
+	})
+}
+
+// ReadinessCheck handles readiness check requests
+func (h *HTTPHandler) ReadinessCheck(c *gin.Context) {
+	response.Success(c, http.StatusOK, "Service is ready", gin.H{
+		"service": "order-service",
+		"status":  "ready",
+	})
+}
+
+// handleError handles service errors and converts them to appropriate HTTP responses
+func (h *HTTPHandler) handleError(c *gin.Context, err error) {
+	err = database.TranslateError(err)
+	switch {
+	case errors.IsNotFound(err):
+		response.Error(c, http.StatusNotFound, "Resource not found", err)
+	case errors.IsValidation(err):
+		response.Error(c, http.StatusBadRequest, "Validation failed", err)
+	case errors.IsConflict(err):
+		response.Error(c, http.StatusConflict, "Resource conflict", err)
+	case errors.IsUnauthorized(err):
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", err)
+	case errors.IsForbidden(err):
+		response.Error(c, http.StatusForbidden, "Forbidden", err)
+	case errors.IsUnavailable(err):
+		response.Error(c, http.StatusServiceUnavailable, "Service unavailable", err)
+	default:
+		h.logger.WithError(err).Error("Internal server error")
+		response.Error(c, http.StatusInternalServerError, "Internal server error", nil)
+	}
+}
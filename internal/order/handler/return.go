@@ -0,0 +1,231 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"ecommerce/internal/order/domain"
+	"ecommerce/pkg/response"
+)
+
+// CreateReturn handles requesting the return of items of an order
+func (h *HTTPHandler) CreateReturn(c *gin.Context) {
+	idStr := c.Param("id")
+	orderID, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid order ID", err)
+		return
+	}
+
+	var req domain.CreateReturnRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	ret, err := h.service.CreateReturn(c.Request.Context(), orderID, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Return requested successfully", ret)
+}
+
+// ListOrderReturns handles listing the returns of an order
+func (h *HTTPHandler) ListOrderReturns(c *gin.Context) {
+	idStr := c.Param("id")
+	orderID, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid order ID", err)
+		return
+	}
+
+	h.listReturns(c, &domain.ReturnFilters{OrderID: &orderID})
+}
+
+// ListReturns handles return listing with filters
+func (h *HTTPHandler) ListReturns(c *gin.Context) {
+	filters := &domain.ReturnFilters{
+		CustomerID: c.Query("customer_id"),
+		Status:     c.Query("status"),
+	}
+
+	if orderID := c.Query("order_id"); orderID != "" {
+		if id, err := uuid.Parse(orderID); err == nil {
+			filters.OrderID = &id
+		}
+	}
+
+	h.listReturns(c, filters)
+}
+
+func (h *HTTPHandler) listReturns(c *gin.Context, filters *domain.ReturnFilters) {
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			filters.Limit = l
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if o, err := strconv.Atoi(offset); err == nil {
+			filters.Offset = o
+		}
+	}
+
+	returns, err := h.service.ListReturns(c.Request.Context(), filters)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Returns retrieved successfully", returns)
+}
+
+// GetReturn handles getting a single return
+func (h *HTTPHandler) GetReturn(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid return ID", err)
+		return
+	}
+
+	ret, err := h.service.GetReturn(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Return retrieved successfully", ret)
+}
+
+// ApproveReturn handles approving a requested return
+func (h *HTTPHandler) ApproveReturn(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid return ID", err)
+		return
+	}
+
+	var req domain.ReviewReturnRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.logger.WithError(err).Error("Invalid request body")
+			response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+	}
+
+	ret, err := h.service.ApproveReturn(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Return approved successfully", ret)
+}
+
+// RejectReturn handles rejecting a requested return
+func (h *HTTPHandler) RejectReturn(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid return ID", err)
+		return
+	}
+
+	var req domain.ReviewReturnRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.logger.WithError(err).Error("Invalid request body")
+			response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+	}
+
+	ret, err := h.service.RejectReturn(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Return rejected successfully", ret)
+}
+
+// ReceiveReturn handles recording that a return's goods arrived
+func (h *HTTPHandler) ReceiveReturn(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid return ID", err)
+		return
+	}
+
+	var req domain.ReceiveReturnRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.logger.WithError(err).Error("Invalid request body")
+			response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+	}
+
+	ret, err := h.service.ReceiveReturn(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Return received successfully", ret)
+}
+
+// RefundReturn handles refunding a received return
+func (h *HTTPHandler) RefundReturn(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid return ID", err)
+		return
+	}
+
+	var req domain.RefundReturnRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.logger.WithError(err).Error("Invalid request body")
+			response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+	}
+
+	ret, err := h.service.RefundReturn(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Return refunded successfully", ret)
+}
+
+// CancelReturn handles withdrawing a return
+func (h *HTTPHandler) CancelReturn(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid return ID", err)
+		return
+	}
+
+	ret, err := h.service.CancelReturn(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Return cancelled successfully", ret)
+}
@@ -0,0 +1,148 @@
+// Package invoice lays out order invoices and renders them as PDF. The
+// layout is a Go text template, so shops can replace the built-in one with
+// their own.
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"ecommerce/internal/order/config"
+	"ecommerce/internal/order/domain"
+	"ecommerce/pkg/pdf"
+)
+
+// defaultTemplate lays out an invoice in columns that fit an A4 page
+const defaultTemplate = `{{with .Seller.Name}}{{.}}
+{{end}}{{range .Seller.Address}}{{.}}
+{{end}}{{with .Seller.TaxID}}Tax ID: {{.}}
+{{end}}
+INVOICE {{.Invoice.Number}}
+
+Issued:    {{date .Invoice.IssuedAt}}
+Order:     {{.Order.ID}}
+Placed:    {{date .Order.CreatedAt}}
+Customer:  {{with .Order.Email}}{{.}}{{else}}{{.Order.CustomerID}}{{end}}
+{{with .Order.Country}}Ship to:   {{with $.Order.PostalCode}}{{.}} {{end}}{{with $.Order.Region}}{{.}} {{end}}{{.}}
+{{end}}
+{{left 36 "Item"}} {{right 5 "Qty"}} {{right 12 "Unit price"}} {{right 7 "Tax %"}} {{right 14 "Amount"}}
+{{rule 78}}
+{{range .Order.Items}}{{left 36 .Name}} {{right 5 .Quantity}} {{right 12 (money .UnitPrice)}} {{right 7 (percent .TaxRate)}} {{right 14 (money .Total)}}
+{{with .SKU}}  SKU {{.}}
+{{end}}{{end}}{{rule 78}}
+
+{{left 20 "Tax rate"}} {{right 14 "Amount"}} {{right 14 "Tax"}}
+{{range .TaxLines}}{{left 20 (percent .Rate)}} {{right 14 (money .Amount)}} {{right 14 (money .Tax)}}
+{{end}}
+{{right 62 "Subtotal"}} {{right 15 (money .Order.Subtotal)}}
+{{right 62 "Tax"}} {{right 15 (money .Order.Tax)}}
+{{right 62 (printf "Total %s" .Currency)}} {{right 15 (money .Order.Total)}}
+{{if .Order.GiftCardAmount}}{{right 62 "Paid by gift card"}} {{right 15 (money .Order.GiftCardAmount)}}
+{{end}}{{if .Order.PointsAmount}}{{right 62 "Paid with loyalty points"}} {{right 15 (money .Order.PointsAmount)}}
+{{end}}{{if or .Order.GiftCardAmount .Order.PointsAmount}}{{right 62 "Charged to payment method"}} {{right 15 (money .Order.Charged)}}
+{{end}}`
+
+// Seller is who issues the invoices
+type Seller struct {
+	Name    string
+	Address []string // one line each
+	TaxID   string
+}
+
+// data is what invoice templates are executed with
+type data struct {
+	Invoice  *domain.Invoice
+	Order    *domain.Order
+	Seller   Seller
+	Currency string
+	TaxLines []domain.TaxRateTotal
+}
+
+// Renderer renders invoices with one layout
+type Renderer struct {
+	template *template.Template
+	seller   Seller
+}
+
+// NewRenderer creates a renderer with the configured layout, or the
+// built-in one when none is configured
+func NewRenderer(cfg config.InvoicesConfig) (*Renderer, error) {
+	layout := defaultTemplate
+	if cfg.Template != "" {
+		content, err := os.ReadFile(cfg.Template)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read invoice template: %w", err)
+		}
+		layout = string(content)
+	}
+
+	tmpl, err := template.New("invoice").Option("missingkey=error").Funcs(funcs).Parse(layout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse invoice template: %w", err)
+	}
+
+	seller := Seller{Name: cfg.SellerName, TaxID: cfg.SellerTaxID}
+	for _, line := range strings.Split(cfg.SellerAddress, ";") {
+		if line = strings.TrimSpace(line); line != "" {
+			seller.Address = append(seller.Address, line)
+		}
+	}
+
+	return &Renderer{template: tmpl, seller: seller}, nil
+}
+
+// Render renders an order's invoice as a PDF
+func (r *Renderer) Render(invoice *domain.Invoice, order *domain.Order) ([]byte, error) {
+	var text bytes.Buffer
+	err := r.template.Execute(&text, &data{
+		Invoice:  invoice,
+		Order:    order,
+		Seller:   r.seller,
+		Currency: strings.ToUpper(invoice.Currency),
+		TaxLines: order.TaxBreakdown(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render invoice: %w", err)
+	}
+
+	return pdf.Render("Invoice "+invoice.Number, text.String()), nil
+}
+
+// funcs are the helpers templates lay invoices out with
+var funcs = template.FuncMap{
+	"date": func(t time.Time) string {
+		return t.Format("2006-01-02")
+	},
+	"money": func(amount float64) string {
+		return fmt.Sprintf("%.2f", amount)
+	},
+	"percent": func(rate float64) string {
+		return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.3f", rate), "0"), ".") + "%"
+	},
+	// left and right fit a value into a column, cutting it short if it is
+	// too wide
+	"left": func(width int, value interface{}) string {
+		s := fit(width, value)
+		return s + strings.Repeat(" ", width-utf8.RuneCountInString(s))
+	},
+	"right": func(width int, value interface{}) string {
+		s := fit(width, value)
+		return strings.Repeat(" ", width-utf8.RuneCountInString(s)) + s
+	},
+	"rule": func(width int) string {
+		return strings.Repeat("-", width)
+	},
+}
+
+func fit(width int, value interface{}) string {
+	s := fmt.Sprint(value)
+	if runes := []rune(s); len(runes) > width {
+		return string(runes[:width])
+	}
+	return s
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"ecommerce/internal/order/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+// CreateInvoice numbers an invoice and records it, reporting false, with
+// the order's invoice in place of the new one, if the order already has
+// one. Invoices are numbered one at a time, so numbers have no gaps.
+func (r *orderRepository) CreateInvoice(ctx context.Context, invoice *domain.Invoice, prefix string) (bool, error) {
+	created := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("LOCK TABLE invoices IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+			return fmt.Errorf("failed to lock invoices: %w", err)
+		}
+
+		var existing domain.Invoice
+		err := tx.First(&existing, "order_id = ?", invoice.OrderID).Error
+		if err == nil {
+			*invoice = existing
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to get invoice: %w", err)
+		}
+
+		var last int64
+		if err := tx.Model(&domain.Invoice{}).Select("COALESCE(MAX(sequence), 0)").Scan(&last).Error; err != nil {
+			return fmt.Errorf("failed to number invoice: %w", err)
+		}
+		invoice.Sequence = last + 1
+		invoice.Number = fmt.Sprintf("%s%06d", prefix, invoice.Sequence)
+
+		if err := tx.Create(invoice).Error; err != nil {
+			return fmt.Errorf("failed to create invoice: %w", err)
+		}
+		created = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return created, nil
+}
+
+func (r *orderRepository) GetInvoiceByOrder(ctx context.Context, orderID uuid.UUID) (*domain.Invoice, error) {
+	var invoice domain.Invoice
+	err := r.db.WithContext(ctx).First(&invoice, "order_id = ?", orderID).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Invoice not found", err).WithCode(customErrors.CodeInvoiceNotFound)
+		}
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+
+	return &invoice, nil
+}
+
+// MarkInvoiceStored records that an invoice's PDF is in storage, reporting
+// false if it already was
+func (r *orderRepository) MarkInvoiceStored(ctx context.Context, invoice *domain.Invoice) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&domain.Invoice{}).
+		Where("id = ? AND stored_at IS NULL", invoice.ID).
+		Update("stored_at", now)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to mark invoice stored: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	invoice.StoredAt = &now
+	return true, nil
+}
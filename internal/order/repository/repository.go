@@ -0,0 +1,322 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"ecommerce/internal/order/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+// OrderRepository defines the order repository interface
+type OrderRepository interface {
+	GetOrder(ctx context.Context, id uuid.UUID) (*domain.Order, error)
+	ListOrders(ctx context.Context, filters *domain.OrderFilters) ([]domain.Order, int64, error)
+	UpdateOrderStatus(ctx context.Context, order *domain.Order, event *domain.OrderEvent) (bool, error)
+	ListOrderEvents(ctx context.Context, orderID uuid.UUID) ([]domain.OrderEvent, error)
+	ClaimGuestOrders(ctx context.Context, email, customerID string) ([]domain.Order, error)
+
+	CreateCheckout(ctx context.Context, checkout *domain.Checkout) (bool, error)
+	GetCheckout(ctx context.Context, id uuid.UUID) (*domain.Checkout, error)
+	GetCheckoutByKey(ctx context.Context, key string) (*domain.Checkout, error)
+	SaveCheckout(ctx context.Context, checkout *domain.Checkout, expectedStatus string) (bool, error)
+	CompleteCheckout(ctx context.Context, checkout *domain.Checkout, order *domain.Order) error
+	ListUnsettledCheckouts(ctx context.Context, staleBefore time.Time, limit int) ([]domain.Checkout, error)
+
+	CreateReturn(ctx context.Context, ret *domain.Return) error
+	GetReturn(ctx context.Context, id uuid.UUID) (*domain.Return, error)
+	ListReturns(ctx context.Context, filters *domain.ReturnFilters) ([]domain.Return, int64, error)
+	SaveReturn(ctx context.Context, ret *domain.Return, expectedStatus string) (bool, error)
+	RefundedAmount(ctx context.Context, orderID uuid.UUID) (float64, error)
+
+	CreateInvoice(ctx context.Context, invoice *domain.Invoice, prefix string) (bool, error)
+	GetInvoiceByOrder(ctx context.Context, orderID uuid.UUID) (*domain.Invoice, error)
+	MarkInvoiceStored(ctx context.Context, invoice *domain.Invoice) (bool, error)
+}
+
+type orderRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+// NewOrderRepository creates a new order repository
+func NewOrderRepository(db *gorm.DB, logger *logrus.Logger) OrderRepository {
+	return &orderRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *orderRepository) GetOrder(ctx context.Context, id uuid.UUID) (*domain.Order, error) {
+	var order domain.Order
+	err := r.db.WithContext(ctx).Preload("Items").First(&order, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Order not found", err).WithCode(customErrors.CodeOrderNotFound)
+		}
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	return &order, nil
+}
+
+func (r *orderRepository) ListOrders(ctx context.Context, filters *domain.OrderFilters) ([]domain.Order, int64, error) {
+	query := r.db.WithContext(ctx).Model(&domain.Order{})
+
+	if filters.CustomerID != "" {
+		query = query.Where("customer_id = ?", filters.CustomerID)
+	}
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count orders: %w", err)
+	}
+
+	var orders []domain.Order
+	err := query.
+		Preload("Items").
+		Order("created_at DESC").
+		Limit(filters.Limit).
+		Offset(filters.Offset).
+		Find(&orders).Error
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list orders: %w", err)
+	}
+
+	return orders, total, nil
+}
+
+// UpdateOrderStatus moves an order from the event's previous status to its
+// new one and adds the event to the order's timeline, together. It reports
+// false when the order is no longer in the previous status because another
+// request moved it first.
+func (r *orderRepository) UpdateOrderStatus(ctx context.Context, order *domain.Order, event *domain.OrderEvent) (bool, error) {
+	moved := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(order).
+			Where("status = ?", event.FromStatus).
+			Updates(map[string]interface{}{"status": event.Status, "updated_at": time.Now()})
+		if result.Error != nil {
+			return fmt.Errorf("failed to update order status: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		event.OrderID = order.ID
+		if err := tx.Create(event).Error; err != nil {
+			return fmt.Errorf("failed to record order event: %w", err)
+		}
+		moved = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	if moved {
+		order.Status = event.Status
+	}
+	return moved, nil
+}
+
+// ClaimGuestOrders moves the guest orders placed with an email, and their
+// checkouts, to a customer, recording the claim in each order's timeline
+func (r *orderRepository) ClaimGuestOrders(ctx context.Context, email, customerID string) ([]domain.Order, error) {
+	var orders []domain.Order
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("guest AND LOWER(email) = LOWER(?)", email).
+			Order("created_at ASC").
+			Find(&orders).Error
+		if err != nil {
+			return fmt.Errorf("failed to lock guest orders: %w", err)
+		}
+		if len(orders) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, len(orders))
+		checkoutIDs := make([]uuid.UUID, len(orders))
+		events := make([]domain.OrderEvent, len(orders))
+		for i := range orders {
+			ids[i] = orders[i].ID
+			checkoutIDs[i] = orders[i].CheckoutID
+			events[i] = domain.OrderEvent{
+				OrderID:    orders[i].ID,
+				Type:       domain.EventOrderClaimed,
+				FromStatus: orders[i].Status,
+				Status:     orders[i].Status,
+				ActorID:    customerID,
+			}
+		}
+
+		now := time.Now()
+		err = tx.Model(&domain.Order{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{"customer_id": customerID, "guest": false, "updated_at": now}).Error
+		if err != nil {
+			return fmt.Errorf("failed to claim guest orders: %w", err)
+		}
+		err = tx.Model(&domain.Checkout{}).
+			Where("id IN ?", checkoutIDs).
+			Update("customer_id", customerID).Error
+		if err != nil {
+			return fmt.Errorf("failed to claim guest checkouts: %w", err)
+		}
+		if err := tx.Create(&events).Error; err != nil {
+			return fmt.Errorf("failed to record order events: %w", err)
+		}
+
+		return tx.Preload("Items").Where("id IN ?", ids).Order("created_at ASC").Find(&orders).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// ListOrderEvents returns an order's timeline, oldest first
+func (r *orderRepository) ListOrderEvents(ctx context.Context, orderID uuid.UUID) ([]domain.OrderEvent, error) {
+	var events []domain.OrderEvent
+	err := r.db.WithContext(ctx).
+		Where("order_id = ?", orderID).
+		Order("created_at ASC, id ASC").
+		Find(&events).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list order events: %w", err)
+	}
+
+	return events, nil
+}
+
+// CreateCheckout starts a checkout unless one already exists for its
+// idempotency key, in which case it reports false and creates nothing
+func (r *orderRepository) CreateCheckout(ctx context.Context, checkout *domain.Checkout) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "idempotency_key"}},
+			DoNothing: true,
+		}).
+		Create(checkout)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to create checkout: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *orderRepository) GetCheckout(ctx context.Context, id uuid.UUID) (*domain.Checkout, error) {
+	var checkout domain.Checkout
+	err := r.db.WithContext(ctx).First(&checkout, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Checkout not found", err).WithCode(customErrors.CodeCheckoutNotFound)
+		}
+		return nil, fmt.Errorf("failed to get checkout: %w", err)
+	}
+
+	return &checkout, nil
+}
+
+func (r *orderRepository) GetCheckoutByKey(ctx context.Context, key string) (*domain.Checkout, error) {
+	var checkout domain.Checkout
+	err := r.db.WithContext(ctx).First(&checkout, "idempotency_key = ?", key).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Checkout not found", err).WithCode(customErrors.CodeCheckoutNotFound)
+		}
+		return nil, fmt.Errorf("failed to get checkout by key: %w", err)
+	}
+
+	return &checkout, nil
+}
+
+// SaveCheckout persists checkout progress only if the stored checkout is
+// still in expectedStatus. It reports false when another process, such as
+// the recovery sweep, has already moved the checkout on.
+func (r *orderRepository) SaveCheckout(ctx context.Context, checkout *domain.Checkout, expectedStatus string) (bool, error) {
+	saved, err := saveCheckout(r.db.WithContext(ctx), checkout, expectedStatus)
+	if err != nil {
+		return false, fmt.Errorf("failed to update checkout: %w", err)
+	}
+	return saved, nil
+}
+
+// CompleteCheckout creates the order, starting its timeline, and marks the
+// pending checkout completed in one transaction, so a checkout is never
+// completed without its order. It fails with a conflict if the checkout is
+// no longer pending.
+func (r *orderRepository) CompleteCheckout(ctx context.Context, checkout *domain.Checkout, order *domain.Order) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(order).Error; err != nil {
+			return fmt.Errorf("failed to create order: %w", err)
+		}
+		created := domain.OrderEvent{
+			OrderID: order.ID,
+			Type:    domain.EventOrderCreated,
+			Status:  order.Status,
+			ActorID: checkout.CustomerID,
+		}
+		if err := tx.Create(&created).Error; err != nil {
+			return fmt.Errorf("failed to record order creation: %w", err)
+		}
+
+		checkout.OrderID = &order.ID
+		checkout.Status = domain.CheckoutStatusCompleted
+		checkout.Step = domain.StepCreateOrder
+		saved, err := saveCheckout(tx, checkout, domain.CheckoutStatusPending)
+		if err != nil {
+			return fmt.Errorf("failed to complete checkout: %w", err)
+		}
+		if !saved {
+			return customErrors.NewConflictError("Checkout was abandoned before the order was created", nil).WithCode(customErrors.CodeCheckoutAbandoned)
+		}
+		return nil
+	})
+}
+
+func saveCheckout(db *gorm.DB, checkout *domain.Checkout, expectedStatus string) (bool, error) {
+	result := db.Model(checkout).
+		Where("status = ?", expectedStatus).
+		Select("*").
+		Omit("id", "created_at").
+		Updates(checkout)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListUnsettledCheckouts returns checkouts that still hold stock or payment:
+// failed checkouts whose compensation did not finish, and pending checkouts
+// that have not progressed since staleBefore
+func (r *orderRepository) ListUnsettledCheckouts(ctx context.Context, staleBefore time.Time, limit int) ([]domain.Checkout, error) {
+	var checkouts []domain.Checkout
+	err := r.db.WithContext(ctx).
+		Where("(status = ? AND NOT compensated) OR (status = ? AND updated_at < ?)",
+			domain.CheckoutStatusFailed, domain.CheckoutStatusPending, staleBefore).
+		Order("updated_at ASC").
+		Limit(limit).
+		Find(&checkouts).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unsettled checkouts: %w", err)
+	}
+
+	return checkouts, nil
+}
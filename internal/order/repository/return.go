@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"ecommerce/internal/order/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+// CreateReturn creates a return with its items. The order is locked while
+// its open returns are added up, so concurrent requests cannot return more
+// units of an item than were ordered between them.
+func (r *orderRepository) CreateReturn(ctx context.Context, ret *domain.Return) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var order domain.Order
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Items").
+			First(&order, "id = ?", ret.OrderID).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return customErrors.NewNotFoundError("Order not found", err).WithCode(customErrors.CodeOrderNotFound)
+			}
+			return fmt.Errorf("failed to lock order: %w", err)
+		}
+
+		var open []struct {
+			OrderItemID uuid.UUID
+			Quantity    int
+		}
+		err = tx.Table("return_items i").
+			Select("i.order_item_id, SUM(i.quantity) AS quantity").
+			Joins("JOIN returns r ON r.id = i.return_id").
+			Where("r.order_id = ? AND r.status NOT IN ?", ret.OrderID, []string{domain.ReturnStatusRejected, domain.ReturnStatusCancelled}).
+			Group("i.order_item_id").
+			Scan(&open).Error
+		if err != nil {
+			return fmt.Errorf("failed to count returned items: %w", err)
+		}
+
+		returned := make(map[uuid.UUID]int, len(open)+len(ret.Items))
+		for _, row := range open {
+			returned[row.OrderItemID] = row.Quantity
+		}
+		for _, item := range ret.Items {
+			returned[item.OrderItemID] += item.Quantity
+		}
+		for _, item := range order.Items {
+			if returned[item.ID] > item.Quantity {
+				return customErrors.NewConflictError(fmt.Sprintf("Only %d of %s can be returned", item.Quantity, item.SKU), nil).WithCode(customErrors.CodeReturnExceedsOrder)
+			}
+		}
+
+		if err := tx.Create(ret).Error; err != nil {
+			return fmt.Errorf("failed to create return: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *orderRepository) GetReturn(ctx context.Context, id uuid.UUID) (*domain.Return, error) {
+	var ret domain.Return
+	err := r.db.WithContext(ctx).Preload("Items").First(&ret, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Return not found", err).WithCode(customErrors.CodeReturnNotFound)
+		}
+		return nil, fmt.Errorf("failed to get return: %w", err)
+	}
+
+	return &ret, nil
+}
+
+func (r *orderRepository) ListReturns(ctx context.Context, filters *domain.ReturnFilters) ([]domain.Return, int64, error) {
+	query := r.db.WithContext(ctx).Model(&domain.Return{})
+
+	if filters.CustomerID != "" {
+		query = query.Where("customer_id = ?", filters.CustomerID)
+	}
+	if filters.OrderID != nil {
+		query = query.Where("order_id = ?", *filters.OrderID)
+	}
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count returns: %w", err)
+	}
+
+	var returns []domain.Return
+	err := query.
+		Preload("Items").
+		Order("created_at DESC").
+		Limit(filters.Limit).
+		Offset(filters.Offset).
+		Find(&returns).Error
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list returns: %w", err)
+	}
+
+	return returns, total, nil
+}
+
+// SaveReturn persists a return only if the stored return is still in
+// expectedStatus. It reports false when another request has already moved
+// the return on. Its items never change.
+func (r *orderRepository) SaveReturn(ctx context.Context, ret *domain.Return, expectedStatus string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(ret).
+		Where("status = ?", expectedStatus).
+		Select("*").
+		Omit(clause.Associations, "id", "order_id", "customer_id", "created_at").
+		Updates(ret)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to update return: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// RefundedAmount adds up what the returns of an order have refunded to its
+// payment method
+func (r *orderRepository) RefundedAmount(ctx context.Context, orderID uuid.UUID) (float64, error) {
+	var refunded float64
+	err := r.db.WithContext(ctx).
+		Model(&domain.Return{}).
+		Select("COALESCE(SUM(refunded), 0)").
+		Where("order_id = ?", orderID).
+		Scan(&refunded).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to add up refunds: %w", err)
+	}
+	return refunded, nil
+}
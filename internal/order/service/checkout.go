@@ -0,0 +1,502 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/order/domain"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/tenant"
+)
+
+// recoveryBatchSize bounds how many checkouts one recovery sweep settles
+const recoveryBatchSize = 100
+
+// Checkout runs the checkout saga: reserve stock, tax the reserved basket,
+// redeem gift cards and loyalty points, authorize payment for what they
+// leave, then create the order. If a step fails, the steps before it are
+// compensated by voiding the payment and the gift card and points
+// redemptions and releasing the stock.
+//
+// The idempotency key makes retries safe. Retrying a completed checkout
+// returns its order, retrying one that is still running is a conflict, and
+// retrying one that failed and has been fully compensated runs it again.
+//
+// Guests check out with an email instead of an account, when guest
+// checkout is enabled. Their orders come with a token to look them up by.
+func (s *orderService) Checkout(ctx context.Context, idempotencyKey string, req *domain.CheckoutRequest) (*domain.CheckoutResult, error) {
+	actor := auth.ActorFromContext(ctx)
+	if actor == nil && s.guestKey == nil {
+		return nil, errors.NewUnauthorizedError("Authentication required to check out", nil).WithCode(errors.CodeAuthenticationRequired)
+	}
+	if idempotencyKey == "" || len(idempotencyKey) > 255 {
+		return nil, errors.NewValidationError("An Idempotency-Key header of at most 255 characters is required", nil).WithCode(errors.CodeIdempotencyKeyRequired)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid checkout request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	var customerID string
+	if actor != nil {
+		customerID = actor.ID
+	} else {
+		if req.Email == "" {
+			return nil, errors.NewValidationError("An email is required to check out as a guest", nil)
+		}
+		if req.LoyaltyPoints > 0 {
+			return nil, errors.NewValidationError("Guests cannot redeem loyalty points", nil)
+		}
+		customerID = domain.GuestCustomerID(req.Email)
+	}
+
+	hash, err := requestHash(customerID, req)
+	if err != nil {
+		return nil, errors.NewInternalError("Failed to hash checkout request", err)
+	}
+
+	checkout := &domain.Checkout{
+		ID:             uuid.New(),
+		IdempotencyKey: idempotencyKey,
+		RequestHash:    hash,
+		CustomerID:     customerID,
+		TenantID:       tenant.ID(ctx),
+		Status:         domain.CheckoutStatusPending,
+		Attempt:        1,
+	}
+	checkout.Reference = attemptReference(checkout)
+
+	created, err := s.repo.CreateCheckout(ctx, checkout)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to create checkout")
+		return nil, errors.NewInternalError("Failed to start checkout", err)
+	}
+	var result *domain.CheckoutResult
+	if !created {
+		existing, replayed, err := s.resumeCheckout(ctx, idempotencyKey, hash)
+		if err != nil {
+			return nil, err
+		}
+		result, checkout = replayed, existing
+	}
+	if result == nil {
+		if result, err = s.runCheckout(ctx, checkout, req); err != nil {
+			return nil, err
+		}
+	}
+
+	if result.Order != nil && result.Order.Guest {
+		result.OrderToken = s.orderToken(result.Order.ID)
+	}
+	return result, nil
+}
+
+// resumeCheckout handles a repeated idempotency key. It returns the stored
+// result of a completed checkout, or the checkout to run again when its
+// previous attempt failed and was fully compensated.
+func (s *orderService) resumeCheckout(ctx context.Context, key, hash string) (*domain.Checkout, *domain.CheckoutResult, error) {
+	existing, err := s.repo.GetCheckoutByKey(ctx, key)
+	if err != nil {
+		return nil, nil, errors.NewInternalError("Failed to get checkout", err)
+	}
+	if existing.RequestHash != hash {
+		return nil, nil, errors.NewConflictError("Idempotency key was already used for a different request", nil).WithCode(errors.CodeIdempotencyKeyReused)
+	}
+
+	switch existing.Status {
+	case domain.CheckoutStatusCompleted:
+		order, err := s.repo.GetOrder(ctx, *existing.OrderID)
+		if err != nil {
+			return nil, nil, errors.NewInternalError("Failed to get order", err)
+		}
+		return nil, &domain.CheckoutResult{Checkout: existing, Order: order, Replayed: true}, nil
+	case domain.CheckoutStatusPending:
+		return nil, nil, errors.NewConflictError("Checkout is already in progress", nil).WithCode(errors.CodeCheckoutInProgress)
+	}
+
+	if !existing.Compensated {
+		return nil, nil, errors.NewConflictError("Previous checkout attempt is still being rolled back", nil).WithCode(errors.CodeCheckoutInProgress)
+	}
+
+	// The failed attempt left nothing behind, so start a fresh one
+	existing.Status = domain.CheckoutStatusPending
+	existing.Attempt++
+	existing.Reference = attemptReference(existing)
+	existing.Step = ""
+	existing.PaymentID = ""
+	existing.Error = ""
+	existing.Compensated = false
+
+	saved, err := s.repo.SaveCheckout(ctx, existing, domain.CheckoutStatusFailed)
+	if err != nil {
+		return nil, nil, errors.NewInternalError("Failed to restart checkout", err)
+	}
+	if !saved {
+		return nil, nil, errors.NewConflictError("Checkout is already in progress", nil).WithCode(errors.CodeCheckoutInProgress)
+	}
+	return existing, nil, nil
+}
+
+func (s *orderService) runCheckout(ctx context.Context, checkout *domain.Checkout, req *domain.CheckoutRequest) (*domain.CheckoutResult, error) {
+	// Reserve stock; this also prices the basket
+	items, err := s.inventory.Reserve(ctx, checkout.Reference, req.Items)
+	if err != nil {
+		return nil, s.fail(ctx, checkout, domain.StepReserveStock, err)
+	}
+	if err := s.advance(ctx, checkout, domain.StepReserveStock); err != nil {
+		return nil, err
+	}
+
+	// Tax the basket at the prices it was reserved at
+	tax, err := s.calculateTax(ctx, req.Address, items)
+	if err != nil {
+		return nil, s.fail(ctx, checkout, domain.StepCalculateTax, err)
+	}
+
+	// Customers' orders go to the email of their account
+	email := req.Email
+	actor := auth.ActorFromContext(ctx)
+	if actor != nil {
+		email = actor.Email
+	}
+
+	order := &domain.Order{
+		CustomerID: checkout.CustomerID,
+		Guest:      actor == nil,
+		Email:      email,
+		Phone:      req.Phone,
+		Status:     domain.OrderStatusConfirmed,
+		Currency:   s.currency,
+		CheckoutID: checkout.ID,
+		Country:    req.Address.Country,
+		Region:     req.Address.Region,
+		PostalCode: req.Address.PostalCode,
+	}
+	for i, item := range items {
+		total := round(item.UnitPrice * float64(item.Quantity))
+		order.Items = append(order.Items, domain.OrderItem{
+			ProductID: item.ProductID,
+			SKU:       item.SKU,
+			Name:      item.Name,
+			Type:      item.Type,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			Total:     total,
+			TaxClass:  item.TaxClass,
+			TaxRate:   tax.Lines[i].Rate,
+			Tax:       tax.Lines[i].Tax,
+		})
+		order.Subtotal += total
+		if item.Type != domain.ProductTypeDigital && item.Type != domain.ProductTypeService && item.Type != domain.ProductTypeGiftCard {
+			order.RequiresShipping = true
+		}
+	}
+	order.Subtotal = round(order.Subtotal)
+	order.Tax = tax.Tax
+	order.Total = tax.TotalInclusive
+
+	// Spend the gift cards on the total before the payment method
+	if len(req.GiftCards) > 0 {
+		if err := s.redeemGiftCards(ctx, checkout, req.GiftCards, order); err != nil {
+			return nil, s.fail(ctx, checkout, domain.StepRedeemGiftCards, err)
+		}
+		if err := s.advance(ctx, checkout, domain.StepRedeemGiftCards); err != nil {
+			return nil, err
+		}
+	}
+
+	// Then the loyalty points the customer offered
+	if req.LoyaltyPoints > 0 && order.GiftCardAmount < order.Total {
+		if err := s.redeemPoints(ctx, checkout, req.LoyaltyPoints, order); err != nil {
+			return nil, s.fail(ctx, checkout, domain.StepRedeemPoints, err)
+		}
+		if err := s.advance(ctx, checkout, domain.StepRedeemPoints); err != nil {
+			return nil, err
+		}
+	}
+
+	// Authorize payment for whatever the gift cards and points left
+	if due := round(order.Total - order.GiftCardAmount - order.PointsAmount); due > 0 {
+		if req.PaymentMethod == "" {
+			cause := errors.NewValidationError(fmt.Sprintf("Gift cards and points leave %.2f to pay; a payment method is required", due), nil).WithCode(errors.CodePaymentNotAuthorized)
+			return nil, s.fail(ctx, checkout, domain.StepAuthorizePayment, cause)
+		}
+		payment, err := s.payments.Authorize(ctx, &domain.PaymentAuthorization{
+			Reference:     checkout.Reference,
+			CustomerID:    checkout.CustomerID,
+			Amount:        due,
+			Currency:      order.Currency,
+			PaymentMethod: req.PaymentMethod,
+		})
+		if err != nil {
+			return nil, s.fail(ctx, checkout, domain.StepAuthorizePayment, err)
+		}
+		if payment.Status != domain.PaymentStatusAuthorized {
+			cause := errors.NewValidationError("Payment was not authorized", fmt.Errorf("payment status %s", payment.Status)).WithCode(errors.CodePaymentNotAuthorized)
+			return nil, s.fail(ctx, checkout, domain.StepAuthorizePayment, cause)
+		}
+		checkout.PaymentID = payment.ID
+		if err := s.advance(ctx, checkout, domain.StepAuthorizePayment); err != nil {
+			return nil, err
+		}
+	}
+
+	// Create the order and complete the checkout together
+	order.PaymentID = checkout.PaymentID
+	if err := s.repo.CompleteCheckout(ctx, checkout, order); err != nil {
+		return nil, s.fail(ctx, checkout, domain.StepCreateOrder, err)
+	}
+
+	s.publish(ctx, domain.EventOrderCreated, order)
+
+	// Issue the invoice along with the order. One that fails to issue now
+	// is issued when it is first asked for.
+	if s.storage != nil {
+		if _, err := s.issueInvoice(ctx, order); err != nil {
+			s.log(ctx).WithError(err).WithField("order_id", order.ID).Warn("Failed to issue invoice")
+		}
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"checkout_id": checkout.ID,
+		"order_id":    order.ID,
+	}).Info("Checkout completed successfully")
+	return &domain.CheckoutResult{Checkout: checkout, Order: order}, nil
+}
+
+// calculateTax taxes the reserved items at the checkout's address, with a
+// line of tax for every item
+func (s *orderService) calculateTax(ctx context.Context, address domain.Address, items []domain.ReservedItem) (*domain.Tax, error) {
+	req := &domain.TaxRequest{
+		Address:          address,
+		Items:            make([]domain.TaxItem, len(items)),
+		PricesIncludeTax: s.inclusive,
+	}
+	for i, item := range items {
+		req.Items[i] = domain.TaxItem{
+			ProductID: item.ProductID,
+			TaxClass:  item.TaxClass,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+		}
+	}
+
+	tax, err := s.taxes.Calculate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(tax.Lines) != len(items) {
+		return nil, errors.NewInternalError("Tax service returned the wrong number of lines", fmt.Errorf("%d lines for %d items", len(tax.Lines), len(items)))
+	}
+	return tax, nil
+}
+
+// redeemGiftCards spends the gift cards in the order given until the order
+// total is covered, and records what they paid on the order. Cards are
+// redeemed under the attempt's reference, so compensation can void them all
+// and a code listed twice is only spent once.
+func (s *orderService) redeemGiftCards(ctx context.Context, checkout *domain.Checkout, codes []string, order *domain.Order) error {
+	redeemed := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		remaining := round(order.Total - order.GiftCardAmount)
+		if remaining <= 0 {
+			break
+		}
+
+		redemption, err := s.payments.RedeemGiftCard(ctx, &domain.GiftCardRedemption{
+			Code:      code,
+			Reference: checkout.Reference,
+			Amount:    remaining,
+			Currency:  order.Currency,
+		})
+		if err != nil {
+			return err
+		}
+		if redeemed[redemption.GiftCardID] {
+			continue
+		}
+		redeemed[redemption.GiftCardID] = true
+		order.GiftCardAmount = round(order.GiftCardAmount + redemption.Amount)
+	}
+	return nil
+}
+
+// redeemPoints pays for what the gift cards left of the order total with
+// up to the given number of the customer's loyalty points
+func (s *orderService) redeemPoints(ctx context.Context, checkout *domain.Checkout, points int64, order *domain.Order) error {
+	redemption, err := s.payments.RedeemPoints(ctx, &domain.PointsRedemption{
+		CustomerID: checkout.CustomerID,
+		Reference:  checkout.Reference,
+		Points:     points,
+		Amount:     round(order.Total - order.GiftCardAmount),
+	})
+	if err != nil {
+		return err
+	}
+	order.PointsRedeemed = redemption.Points
+	order.PointsAmount = redemption.Amount
+	return nil
+}
+
+// advance records that a step completed. If the checkout was abandoned in
+// the meantime the recovery sweep owns its compensation, so the saga stops.
+func (s *orderService) advance(ctx context.Context, checkout *domain.Checkout, step string) error {
+	checkout.Step = step
+	saved, err := s.repo.SaveCheckout(ctx, checkout, domain.CheckoutStatusPending)
+	if err != nil {
+		s.log(ctx).WithError(err).WithField("checkout_id", checkout.ID).Error("Failed to record checkout progress")
+		return s.fail(ctx, checkout, step, errors.NewInternalError("Failed to record checkout progress", err))
+	}
+	if !saved {
+		return errors.NewConflictError("Checkout was abandoned", nil).WithCode(errors.CodeCheckoutAbandoned)
+	}
+	return nil
+}
+
+// fail marks the checkout failed, compensates it and returns the error that
+// caused the failure so the caller sees why the checkout did not go through
+func (s *orderService) fail(ctx context.Context, checkout *domain.Checkout, step string, cause error) error {
+	s.log(ctx).WithError(cause).WithFields(logrus.Fields{
+		"checkout_id": checkout.ID,
+		"step":        step,
+	}).Warn("Checkout step failed")
+
+	// Compensation must finish even if the client has gone away
+	ctx = context.WithoutCancel(ctx)
+
+	checkout.Status = domain.CheckoutStatusFailed
+	checkout.OrderID = nil
+	checkout.Error = failureReason(cause)
+	saved, err := s.repo.SaveCheckout(ctx, checkout, domain.CheckoutStatusPending)
+	if err != nil {
+		// Still compensate; the checkout stays pending and the recovery
+		// sweep will settle it once it goes stale
+		s.log(ctx).WithError(err).WithField("checkout_id", checkout.ID).Error("Failed to record checkout failure")
+	} else if !saved {
+		return cause
+	}
+
+	s.compensate(ctx, checkout)
+	return cause
+}
+
+// compensate undoes whatever an attempt may have done, in reverse step
+// order. Every call is idempotent and tolerate steps that never ran, so
+// they are safe whatever point the attempt reached, including when a step
+// failed without the saga learning whether it took effect.
+func (s *orderService) compensate(ctx context.Context, checkout *domain.Checkout) bool {
+	logger := s.log(ctx).WithField("checkout_id", checkout.ID)
+	settled := true
+
+	if err := s.payments.Void(ctx, checkout.Reference); err != nil {
+		logger.WithError(err).Error("Failed to void checkout payment")
+		settled = false
+	}
+	if err := s.payments.VoidGiftCards(ctx, checkout.Reference); err != nil {
+		logger.WithError(err).Error("Failed to void checkout gift cards")
+		settled = false
+	}
+	if err := s.payments.VoidPoints(ctx, checkout.CustomerID, checkout.Reference); err != nil {
+		logger.WithError(err).Error("Failed to void checkout loyalty points")
+		settled = false
+	}
+	if err := s.inventory.Release(ctx, checkout.Reference); err != nil {
+		logger.WithError(err).Error("Failed to release checkout stock")
+		settled = false
+	}
+	if !settled {
+		return false
+	}
+
+	checkout.Compensated = true
+	if _, err := s.repo.SaveCheckout(ctx, checkout, domain.CheckoutStatusFailed); err != nil {
+		logger.WithError(err).Error("Failed to record checkout compensation")
+		return false
+	}
+
+	logger.Info("Checkout compensated successfully")
+	return true
+}
+
+// RecoverCheckouts settles checkouts a failed or crashed saga left behind:
+// it compensates failures whose compensation did not finish, and abandons
+// and compensates pending checkouts that have stopped making progress
+func (s *orderService) RecoverCheckouts(ctx context.Context) (int, error) {
+	checkouts, err := s.repo.ListUnsettledCheckouts(ctx, time.Now().Add(-s.staleAfter), recoveryBatchSize)
+	if err != nil {
+		return 0, errors.NewInternalError("Failed to list unsettled checkouts", err)
+	}
+
+	recovered := 0
+	for i := range checkouts {
+		checkout := &checkouts[i]
+		// Undo the checkout in the catalog of the tenant it was made in
+		ctx := ctx
+		if checkout.TenantID != "" {
+			ctx = tenant.WithID(ctx, checkout.TenantID)
+		}
+		if checkout.Status == domain.CheckoutStatusPending {
+			checkout.Status = domain.CheckoutStatusFailed
+			checkout.Error = "Checkout was abandoned"
+			saved, err := s.repo.SaveCheckout(ctx, checkout, domain.CheckoutStatusPending)
+			if err != nil {
+				return recovered, errors.NewInternalError("Failed to abandon checkout", err)
+			}
+			if !saved {
+				continue
+			}
+		}
+
+		if s.compensate(ctx, checkout) {
+			recovered++
+		}
+	}
+
+	return recovered, nil
+}
+
+// attemptReference identifies one attempt of a checkout to the product and
+// payment services. Each attempt gets its own so a retry never replays the
+// reservation or authorization of an attempt that was already undone.
+func attemptReference(checkout *domain.Checkout) string {
+	return fmt.Sprintf("checkout-%s-%d", checkout.ID, checkout.Attempt)
+}
+
+// requestHash fingerprints a checkout request so a reused idempotency key
+// can be told apart from a genuine retry
+func requestHash(customerID string, req *domain.CheckoutRequest) (string, error) {
+	payload, err := json.Marshal(struct {
+		CustomerID string                  `json:"customer_id"`
+		Request    *domain.CheckoutRequest `json:"request"`
+	}{customerID, req})
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// failureReason is the message stored on a failed checkout
+func failureReason(err error) string {
+	if appErr, ok := err.(*errors.AppError); ok {
+		return appErr.Message
+	}
+	return "Checkout failed"
+}
+
+// round rounds an amount to whole cents
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
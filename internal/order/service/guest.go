@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/order/domain"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+)
+
+type orderTokenKey struct{}
+
+// WithOrderToken returns a copy of ctx carrying an order token, which lets
+// a guest see the order it was issued for
+func WithOrderToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, orderTokenKey{}, token)
+}
+
+// orderTokenFromContext returns the order token in ctx, or "" when absent
+func orderTokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(orderTokenKey{}).(string)
+	return token
+}
+
+// guestKey derives the key order tokens are signed with, so the secret
+// itself is never used directly
+func guestKey(secret string) []byte {
+	if secret == "" {
+		return nil
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("order-token"))
+	return mac.Sum(nil)
+}
+
+// orderToken signs an order's ID. Tokens do not expire; they are as
+// lasting as the order they let a guest look up.
+func (s *orderService) orderToken(id uuid.UUID) string {
+	mac := hmac.New(sha256.New, s.guestKey)
+	mac.Write(id[:])
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// validOrderToken reports whether ctx carries the token of an order
+func (s *orderService) validOrderToken(ctx context.Context, id uuid.UUID) bool {
+	token := orderTokenFromContext(ctx)
+	if s.guestKey == nil || token == "" {
+		return false
+	}
+	return hmac.Equal([]byte(token), []byte(s.orderToken(id)))
+}
+
+// ClaimOrders attaches the guest orders placed with the caller's email to
+// their account and returns them. The identity provider vouches for the
+// email in the caller's token, so only the owner of an address can claim
+// its orders.
+func (s *orderService) ClaimOrders(ctx context.Context) ([]domain.Order, error) {
+	actor := auth.ActorFromContext(ctx)
+	if actor == nil {
+		return nil, errors.NewUnauthorizedError("Authentication required to claim orders", nil).WithCode(errors.CodeAuthenticationRequired)
+	}
+	if actor.Email == "" {
+		return nil, errors.NewValidationError("Your account has no email to claim orders by", nil)
+	}
+
+	orders, err := s.repo.ClaimGuestOrders(ctx, actor.Email, actor.ID)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to claim guest orders")
+		return nil, errors.NewInternalError("Failed to claim orders", err)
+	}
+
+	for i := range orders {
+		s.publish(ctx, domain.EventOrderClaimed, &orders[i])
+	}
+
+	if len(orders) > 0 {
+		s.log(ctx).WithFields(logrus.Fields{
+			"customer_id": actor.ID,
+			"count":       len(orders),
+		}).Info("Guest orders claimed successfully")
+	}
+	return orders, nil
+}
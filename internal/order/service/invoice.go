@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/order/domain"
+	"ecommerce/pkg/errors"
+)
+
+// GetInvoice returns an order's invoice with a link to download its PDF,
+// issuing the invoice if the order has none yet. Cancelled orders are not
+// invoiced, though an invoice issued before the cancellation stays
+// available.
+func (s *orderService) GetInvoice(ctx context.Context, orderID uuid.UUID) (*domain.Invoice, error) {
+	if s.storage == nil {
+		return nil, errors.NewUnavailableError("Invoice storage is not configured", nil)
+	}
+
+	order, err := s.GetOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice, err := s.repo.GetInvoiceByOrder(ctx, order.ID)
+	if err != nil && !errors.IsNotFound(err) {
+		s.log(ctx).WithError(err).Error("Failed to get invoice")
+		return nil, errors.NewInternalError("Failed to get invoice", err)
+	}
+	if invoice == nil || invoice.StoredAt == nil {
+		if invoice == nil && order.Status == domain.OrderStatusCancelled {
+			return nil, errors.NewNotFoundError("Cancelled orders are not invoiced", nil).WithCode(errors.CodeInvoiceNotFound)
+		}
+		if invoice, err = s.issueInvoice(ctx, order); err != nil {
+			return nil, err
+		}
+	}
+
+	if invoice.URL == "" {
+		if invoice.URL, err = s.invoiceURL(invoice); err != nil {
+			s.log(ctx).WithError(err).Error("Failed to sign invoice download")
+			return nil, errors.NewInternalError("Failed to create invoice URL", err)
+		}
+	}
+	return invoice, nil
+}
+
+// issueInvoice numbers an order's invoice and puts its PDF in storage, then
+// emails it to the customer. An invoice whose PDF could not be stored keeps
+// its number and is stored on the next attempt; it is only sent once.
+func (s *orderService) issueInvoice(ctx context.Context, order *domain.Order) (*domain.Invoice, error) {
+	invoice := &domain.Invoice{
+		OrderID:  order.ID,
+		Currency: order.Currency,
+		Subtotal: order.Subtotal,
+		Tax:      order.Tax,
+		Total:    order.Total,
+		IssuedAt: time.Now(),
+	}
+	if _, err := s.repo.CreateInvoice(ctx, invoice, s.invoicing.Prefix); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to create invoice")
+		return nil, errors.NewInternalError("Failed to create invoice", err)
+	}
+	if invoice.StoredAt != nil {
+		return invoice, nil
+	}
+
+	pdf, err := s.invoices.Render(invoice, order)
+	if err != nil {
+		s.log(ctx).WithError(err).WithField("invoice", invoice.Number).Error("Failed to render invoice")
+		return nil, errors.NewInternalError("Failed to render invoice", err)
+	}
+	if err := s.storage.Put(ctx, invoice.StorageKey(), "application/pdf", pdf); err != nil {
+		s.log(ctx).WithError(err).WithField("invoice", invoice.Number).Error("Failed to store invoice")
+		return nil, errors.NewUnavailableError("Failed to store invoice", err)
+	}
+
+	stored, err := s.repo.MarkInvoiceStored(ctx, invoice)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to mark invoice stored")
+		return nil, errors.NewInternalError("Failed to store invoice", err)
+	}
+	if !stored {
+		// Another request stored it first, and sent it
+		return invoice, nil
+	}
+
+	if invoice.URL, err = s.invoiceURL(invoice); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to sign invoice download")
+		return nil, errors.NewInternalError("Failed to create invoice URL", err)
+	}
+	s.publish(ctx, domain.EventInvoiceIssued, &domain.InvoiceIssued{
+		ID:         invoice.ID,
+		Number:     invoice.Number,
+		OrderID:    order.ID,
+		CustomerID: order.CustomerID,
+		Email:      order.Email,
+		Currency:   invoice.Currency,
+		Total:      invoice.Total,
+		URL:        invoice.URL,
+		IssuedAt:   invoice.IssuedAt,
+	})
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"order_id": order.ID,
+		"invoice":  invoice.Number,
+	}).Info("Invoice issued successfully")
+	return invoice, nil
+}
+
+// invoiceURL signs a link to download an invoice's PDF
+func (s *orderService) invoiceURL(invoice *domain.Invoice) (string, error) {
+	expiry := time.Duration(s.invoicing.URLExpiry) * time.Second
+	return s.storage.PresignGet(invoice.StorageKey(), invoice.Number+".pdf", expiry)
+}
@@ -0,0 +1,404 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/order/domain"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+)
+
+// returnEvents maps each status a return moves to onto the event it
+// publishes
+var returnEvents = map[string]string{
+	domain.ReturnStatusRequested: domain.EventReturnRequested,
+	domain.ReturnStatusApproved:  domain.EventReturnApproved,
+	domain.ReturnStatusRejected:  domain.EventReturnRejected,
+	domain.ReturnStatusReceived:  domain.EventReturnReceived,
+	domain.ReturnStatusRefunded:  domain.EventReturnRefunded,
+	domain.ReturnStatusCancelled: domain.EventReturnCancelled,
+}
+
+// CreateReturn requests the return of items of an order. Only shipped
+// items of an order that was not cancelled can be returned, within the
+// return window and no more of them than were ordered.
+func (s *orderService) CreateReturn(ctx context.Context, orderID uuid.UUID, req *domain.CreateReturnRequest) (*domain.Return, error) {
+	// Guests claim their order into an account to return its items
+	if auth.ActorFromContext(ctx) == nil {
+		return nil, errors.NewUnauthorizedError("Authentication required to return items", nil).WithCode(errors.CodeAuthenticationRequired)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid create return request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	order, err := s.GetOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status == domain.OrderStatusCancelled {
+		return nil, errors.NewConflictError("Items of a cancelled order cannot be returned", nil).WithCode(errors.CodeReturnNotAllowed)
+	}
+	if time.Since(order.CreatedAt) > s.window {
+		return nil, errors.NewConflictError("The return window for this order has closed", nil).WithCode(errors.CodeReturnNotAllowed)
+	}
+
+	// Combine repeated lines for the same order item
+	quantities := make(map[uuid.UUID]int, len(req.Items))
+	var selected []uuid.UUID
+	for _, item := range req.Items {
+		if _, ok := quantities[item.OrderItemID]; !ok {
+			selected = append(selected, item.OrderItemID)
+		}
+		quantities[item.OrderItemID] += item.Quantity
+	}
+
+	ret := &domain.Return{
+		OrderID:    order.ID,
+		CustomerID: order.CustomerID,
+		Status:     domain.ReturnStatusRequested,
+		Reason:     req.Reason,
+		Note:       req.Note,
+	}
+	for _, id := range selected {
+		item := orderItem(order, id)
+		if item == nil {
+			return nil, errors.NewValidationError(fmt.Sprintf("Order item %s not found", id), nil)
+		}
+		if !item.Returnable() {
+			return nil, errors.NewValidationError(fmt.Sprintf("%s was not shipped and cannot be returned", item.SKU), nil).WithCode(errors.CodeReturnNotAllowed)
+		}
+		if quantities[id] > item.Quantity {
+			return nil, errors.NewValidationError(fmt.Sprintf("Only %d of %s were ordered", item.Quantity, item.SKU), nil).WithCode(errors.CodeReturnExceedsOrder)
+		}
+
+		amount := order.ReturnAmount(item, quantities[id])
+		ret.Items = append(ret.Items, domain.ReturnItem{
+			OrderItemID: item.ID,
+			ProductID:   item.ProductID,
+			SKU:         item.SKU,
+			Name:        item.Name,
+			Quantity:    quantities[id],
+			Amount:      amount,
+		})
+		ret.Amount += amount
+	}
+	ret.Amount = round(ret.Amount)
+
+	if err := s.repo.CreateReturn(ctx, ret); err != nil {
+		if errors.IsConflict(err) {
+			return nil, err
+		}
+		s.log(ctx).WithError(err).Error("Failed to create return")
+		return nil, errors.NewInternalError("Failed to create return", err)
+	}
+
+	s.publish(ctx, domain.EventReturnRequested, ret)
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"return_id": ret.ID,
+		"order_id":  order.ID,
+	}).Info("Return requested successfully")
+	return ret, nil
+}
+
+// GetReturn returns a return. Customers only see their own.
+func (s *orderService) GetReturn(ctx context.Context, id uuid.UUID) (*domain.Return, error) {
+	actor := auth.ActorFromContext(ctx)
+	if actor == nil {
+		return nil, errors.NewUnauthorizedError("Authentication required to view returns", nil).WithCode(errors.CodeAuthenticationRequired)
+	}
+
+	ret, err := s.repo.GetReturn(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, err
+		}
+		s.log(ctx).WithError(err).Error("Failed to get return")
+		return nil, errors.NewInternalError("Failed to get return", err)
+	}
+
+	if ret.CustomerID != actor.ID && actor.Role != auth.RoleAdmin {
+		return nil, errors.NewNotFoundError("Return not found", nil).WithCode(errors.CodeReturnNotFound)
+	}
+
+	return ret, nil
+}
+
+// ListReturns lists returns, newest first. Customers only see their own.
+func (s *orderService) ListReturns(ctx context.Context, filters *domain.ReturnFilters) (*domain.ReturnList, error) {
+	actor := auth.ActorFromContext(ctx)
+	if actor == nil {
+		return nil, errors.NewUnauthorizedError("Authentication required to view returns", nil).WithCode(errors.CodeAuthenticationRequired)
+	}
+	if actor.Role != auth.RoleAdmin {
+		filters.CustomerID = actor.ID
+	}
+
+	// Set default values
+	if filters.Limit <= 0 {
+		filters.Limit = 20
+	}
+	if filters.Limit > 100 {
+		filters.Limit = 100
+	}
+
+	returns, total, err := s.repo.ListReturns(ctx, filters)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list returns")
+		return nil, errors.NewInternalError("Failed to list returns", err)
+	}
+
+	return &domain.ReturnList{
+		Returns: returns,
+		Total:   total,
+		Limit:   filters.Limit,
+		Offset:  filters.Offset,
+		HasMore: int64(filters.Offset+filters.Limit) < total,
+	}, nil
+}
+
+// ApproveReturn accepts a requested return, so its goods can be sent back
+func (s *orderService) ApproveReturn(ctx context.Context, id uuid.UUID, req *domain.ReviewReturnRequest) (*domain.Return, error) {
+	return s.reviewReturn(ctx, id, req, domain.ReturnStatusApproved)
+}
+
+// RejectReturn turns down a requested return
+func (s *orderService) RejectReturn(ctx context.Context, id uuid.UUID, req *domain.ReviewReturnRequest) (*domain.Return, error) {
+	return s.reviewReturn(ctx, id, req, domain.ReturnStatusRejected)
+}
+
+func (s *orderService) reviewReturn(ctx context.Context, id uuid.UUID, req *domain.ReviewReturnRequest, status string) (*domain.Return, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Reviewing returns requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid review return request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	ret, err := s.GetReturn(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	ret.Resolution = req.Resolution
+	ret.ReviewedAt = &now
+	if err := s.moveReturn(ctx, ret, status); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// ReceiveReturn records that the goods of an approved return arrived and,
+// unless they cannot be sold again, puts them back into stock. Stock is
+// returned before the return moves on, and once per return, so a failed
+// call can be retried.
+func (s *orderService) ReceiveReturn(ctx context.Context, id uuid.UUID, req *domain.ReceiveReturnRequest) (*domain.Return, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Receiving returns requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid receive return request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	ret, err := s.GetReturn(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !ret.CanTransition(domain.ReturnStatusReceived) {
+		return nil, invalidReturnState(ret, domain.ReturnStatusReceived)
+	}
+
+	if req.Restock == nil || *req.Restock {
+		items := make([]domain.CheckoutItem, len(ret.Items))
+		for i, item := range ret.Items {
+			items[i] = domain.CheckoutItem{ProductID: item.ProductID, Quantity: item.Quantity}
+		}
+		if err := s.inventory.Restock(ctx, restockReference(ret), req.Warehouse, items); err != nil {
+			s.log(ctx).WithError(err).WithField("return_id", ret.ID).Error("Failed to restock return")
+			return nil, err
+		}
+		ret.Restocked = true
+	}
+
+	now := time.Now()
+	ret.ReceivedAt = &now
+	if err := s.moveReturn(ctx, ret, domain.ReturnStatusReceived); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// RefundReturn refunds a received return to the payment method the order
+// was paid with. What gift cards and loyalty points paid for is not
+// refunded to it, so no return refunds more than the payment method was
+// charged across the order's returns. The return is marked refunded before
+// the payment service is called, so two calls cannot both refund it.
+func (s *orderService) RefundReturn(ctx context.Context, id uuid.UUID, req *domain.RefundReturnRequest) (*domain.Return, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Refunding returns requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid refund return request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	ret, err := s.GetReturn(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !ret.CanTransition(domain.ReturnStatusRefunded) {
+		return nil, invalidReturnState(ret, domain.ReturnStatusRefunded)
+	}
+
+	order, err := s.GetOrder(ctx, ret.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	refunded, err := s.repo.RefundedAmount(ctx, order.ID)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to add up refunds")
+		return nil, errors.NewInternalError("Failed to refund return", err)
+	}
+	available := math.Max(0, round(order.Charged()-refunded))
+	if order.PaymentID == "" {
+		available = 0
+	}
+
+	amount := math.Min(ret.Amount, available)
+	if req.Amount != nil {
+		amount = round(*req.Amount)
+		if amount > available {
+			return nil, errors.NewValidationError(fmt.Sprintf("At most %.2f can be refunded to the payment method", available), nil).WithCode(errors.CodeRefundExceedsAmount)
+		}
+	}
+
+	// Claim the return, then refund it
+	now := time.Now()
+	ret.Status = domain.ReturnStatusRefunded
+	ret.Refunded = amount
+	ret.RefundedAt = &now
+	claimed, err := s.repo.SaveReturn(ctx, ret, domain.ReturnStatusReceived)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to update return")
+		return nil, errors.NewInternalError("Failed to refund return", err)
+	}
+	if !claimed {
+		return nil, errors.NewConflictError("Return was changed by another request", nil).WithCode(errors.CodeReturnInvalidState)
+	}
+
+	if amount > 0 {
+		refund, err := s.payments.Refund(ctx, order.PaymentID, amount, fmt.Sprintf("Return %s", ret.ID))
+		if err != nil {
+			s.log(ctx).WithError(err).WithField("return_id", ret.ID).Error("Failed to refund return")
+			s.releaseReturn(ctx, ret)
+			return nil, err
+		}
+		ret.RefundID = refund.ID
+		if _, err := s.repo.SaveReturn(ctx, ret, domain.ReturnStatusRefunded); err != nil {
+			// The money went back; only the refund's ID is lost
+			s.log(ctx).WithError(err).WithFields(logrus.Fields{
+				"return_id": ret.ID,
+				"refund_id": refund.ID,
+			}).Error("Failed to record refund")
+		}
+	}
+
+	s.publish(ctx, domain.EventReturnRefunded, ret)
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"return_id": ret.ID,
+		"amount":    amount,
+	}).Info("Return refunded successfully")
+	return ret, nil
+}
+
+// CancelReturn withdraws a return before its goods are received.
+// Customers can cancel their own returns.
+func (s *orderService) CancelReturn(ctx context.Context, id uuid.UUID) (*domain.Return, error) {
+	ret, err := s.GetReturn(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.moveReturn(ctx, ret, domain.ReturnStatusCancelled); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// moveReturn saves a return in a new status and publishes the event for it.
+// It fails with a conflict when the return cannot move there, including
+// when another request moved it first.
+func (s *orderService) moveReturn(ctx context.Context, ret *domain.Return, status string) error {
+	if !ret.CanTransition(status) {
+		return invalidReturnState(ret, status)
+	}
+
+	from := ret.Status
+	ret.Status = status
+	saved, err := s.repo.SaveReturn(ctx, ret, from)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to update return")
+		return errors.NewInternalError("Failed to update return", err)
+	}
+	if !saved {
+		return errors.NewConflictError("Return was changed by another request", nil).WithCode(errors.CodeReturnInvalidState)
+	}
+
+	s.publish(ctx, returnEvents[status], ret)
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"return_id": ret.ID,
+		"status":    status,
+	}).Info("Return updated successfully")
+	return nil
+}
+
+// releaseReturn puts a return whose refund failed back to received, so
+// the refund can be tried again
+func (s *orderService) releaseReturn(ctx context.Context, ret *domain.Return) {
+	ret.Status = domain.ReturnStatusReceived
+	ret.Refunded = 0
+	ret.RefundedAt = nil
+	if _, err := s.repo.SaveReturn(ctx, ret, domain.ReturnStatusRefunded); err != nil {
+		s.log(ctx).WithError(err).WithField("return_id", ret.ID).Error("Failed to release return after a failed refund")
+	}
+}
+
+// invalidReturnState is the error for a return that cannot move to status
+func invalidReturnState(ret *domain.Return, status string) error {
+	return errors.NewConflictError(fmt.Sprintf("A %s return cannot be %s", ret.Status, status), nil).WithCode(errors.CodeReturnInvalidState)
+}
+
+// restockReference is the reference a return's goods are restocked under
+func restockReference(ret *domain.Return) string {
+	return "return-" + ret.ID.String()
+}
+
+// orderItem returns the item of an order with an ID, or nil
+func orderItem(order *domain.Order, id uuid.UUID) *domain.OrderItem {
+	for i := range order.Items {
+		if order.Items[i].ID == id {
+			return &order.Items[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/order/client"
+	"ecommerce/internal/order/config"
+	"ecommerce/internal/order/domain"
+	"ecommerce/internal/order/invoice"
+	"ecommerce/internal/order/repository"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/events"
+	"ecommerce/pkg/logger"
+	"ecommerce/pkg/storage"
+	"ecommerce/pkg/validator"
+)
+
+// OrderService defines the order service interface
+type OrderService interface {
+	Checkout(ctx context.Context, idempotencyKey string, req *domain.CheckoutRequest) (*domain.CheckoutResult, error)
+	RecoverCheckouts(ctx context.Context) (int, error)
+
+	GetOrder(ctx context.Context, id uuid.UUID) (*domain.Order, error)
+	ListOrders(ctx context.Context, filters *domain.OrderFilters) (*domain.OrderList, error)
+	UpdateOrderStatus(ctx context.Context, id uuid.UUID, req *domain.UpdateOrderStatusRequest) (*domain.Order, error)
+	ListOrderEvents(ctx context.Context, id uuid.UUID) ([]domain.OrderEvent, error)
+	ClaimOrders(ctx context.Context) ([]domain.Order, error)
+
+	CreateReturn(ctx context.Context, orderID uuid.UUID, req *domain.CreateReturnRequest) (*domain.Return, error)
+	GetReturn(ctx context.Context, id uuid.UUID) (*domain.Return, error)
+	ListReturns(ctx context.Context, filters *domain.ReturnFilters) (*domain.ReturnList, error)
+	ApproveReturn(ctx context.Context, id uuid.UUID, req *domain.ReviewReturnRequest) (*domain.Return, error)
+	RejectReturn(ctx context.Context, id uuid.UUID, req *domain.ReviewReturnRequest) (*domain.Return, error)
+	ReceiveReturn(ctx context.Context, id uuid.UUID, req *domain.ReceiveReturnRequest) (*domain.Return, error)
+	RefundReturn(ctx context.Context, id uuid.UUID, req *domain.RefundReturnRequest) (*domain.Return, error)
+	CancelReturn(ctx context.Context, id uuid.UUID) (*domain.Return, error)
+
+	GetInvoice(ctx context.Context, orderID uuid.UUID) (*domain.Invoice, error)
+}
+
+type orderService struct {
+	repo       repository.OrderRepository
+	inventory  client.Inventory
+	payments   client.Payments
+	taxes      client.Taxes
+	publisher  events.Publisher
+	currency   string
+	inclusive  bool // catalog prices include tax
+	staleAfter time.Duration
+	guestKey   []byte        // signs guests' order tokens; nil when guests cannot check out
+	window     time.Duration // after an order, for returning its items
+	storage    *storage.S3   // of invoices; nil when invoicing is disabled
+	invoices   *invoice.Renderer
+	invoicing  config.InvoicesConfig
+	logger     *logrus.Logger
+	validator  *validator.Validator
+}
+
+// NewOrderService creates a new order service
+func NewOrderService(repo repository.OrderRepository, inventory client.Inventory, payments client.Payments, taxes client.Taxes, publisher events.Publisher, invoiceStorage *storage.S3, invoices *invoice.Renderer, cfg config.CheckoutConfig, returns config.ReturnsConfig, invoicing config.InvoicesConfig, logger *logrus.Logger) OrderService {
+	return &orderService{
+		repo:       repo,
+		inventory:  inventory,
+		payments:   payments,
+		taxes:      taxes,
+		publisher:  publisher,
+		currency:   cfg.Currency,
+		inclusive:  cfg.PricesIncludeTax,
+		staleAfter: time.Duration(cfg.StaleAfter) * time.Second,
+		guestKey:   guestKey(cfg.GuestSecret),
+		window:     time.Duration(returns.Window) * 24 * time.Hour,
+		storage:    invoiceStorage,
+		invoices:   invoices,
+		invoicing:  invoicing,
+		logger:     logger,
+		validator:  validator.New(),
+	}
+}
+
+// log returns the logger of the request ctx belongs to
+func (s *orderService) log(ctx context.Context) *logrus.Entry {
+	return logger.FromContext(ctx, s.logger)
+}
+
+func (s *orderService) GetOrder(ctx context.Context, id uuid.UUID) (*domain.Order, error) {
+	actor := auth.ActorFromContext(ctx)
+	if actor == nil && orderTokenFromContext(ctx) == "" {
+		return nil, errors.NewUnauthorizedError("Authentication required to view orders", nil).WithCode(errors.CodeAuthenticationRequired)
+	}
+
+	order, err := s.repo.GetOrder(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Order not found", err).WithCode(errors.CodeOrderNotFound)
+		}
+		s.log(ctx).WithError(err).Error("Failed to get order")
+		return nil, errors.NewInternalError("Failed to get order", err)
+	}
+
+	// Customers only see their own orders, and guests the order their
+	// token was issued for
+	if s.validOrderToken(ctx, order.ID) {
+		return order, nil
+	}
+	if actor == nil || (order.CustomerID != actor.ID && actor.Role != auth.RoleAdmin) {
+		return nil, errors.NewNotFoundError("Order not found", nil).WithCode(errors.CodeOrderNotFound)
+	}
+
+	return order, nil
+}
+
+func (s *orderService) ListOrders(ctx context.Context, filters *domain.OrderFilters) (*domain.OrderList, error) {
+	actor := auth.ActorFromContext(ctx)
+	if actor == nil {
+		return nil, errors.NewUnauthorizedError("Authentication required to view orders", nil).WithCode(errors.CodeAuthenticationRequired)
+	}
+	if actor.Role != auth.RoleAdmin {
+		filters.CustomerID = actor.ID
+	}
+
+	// Set default values
+	if filters.Limit <= 0 {
+		filters.Limit = 20
+	}
+	if filters.Limit > 100 {
+		filters.Limit = 100
+	}
+
+	orders, total, err := s.repo.ListOrders(ctx, filters)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list orders")
+		return nil, errors.NewInternalError("Failed to list orders", err)
+	}
+
+	return &domain.OrderList{
+		Orders:  orders,
+		Total:   total,
+		Limit:   filters.Limit,
+		Offset:  filters.Offset,
+		HasMore: int64(filters.Offset+filters.Limit) < total,
+	}, nil
+}
+
+// publish publishes an order or return event; failures are logged and never
+// fail the operation that raised the event
+func (s *orderService) publish(ctx context.Context, eventType string, data interface{}) {
+	event, err := events.New(eventType, domain.EventSource, data)
+	if err != nil {
+		s.log(ctx).WithError(err).WithField("event_type", eventType).Error("Failed to build event")
+		return
+	}
+
+	if err := s.publisher.Publish(ctx, event); err != nil {
+		s.log(ctx).WithError(err).WithField("event_type", eventType).Error("Failed to publish event")
+	}
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/order/domain"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/tenant"
+)
+
+// orderEvents maps each status an order moves to onto the event it
+// publishes
+var orderEvents = map[string]string{
+	domain.OrderStatusProcessing: domain.EventOrderProcessing,
+	domain.OrderStatusShipped:    domain.EventOrderShipped,
+	domain.OrderStatusDelivered:  domain.EventOrderDelivered,
+	domain.OrderStatusCancelled:  domain.EventOrderCancelled,
+}
+
+// UpdateOrderStatus moves an order on through fulfilment, recording the
+// change in its timeline and publishing an event for it. Cancelling an
+// order first gives back its stock, payment hold, gift cards and loyalty
+// points; each of those is safe to repeat, so a cancellation that fails
+// part way can be retried.
+func (s *orderService) UpdateOrderStatus(ctx context.Context, id uuid.UUID, req *domain.UpdateOrderStatusRequest) (*domain.Order, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Updating orders requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid update order status request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	order, err := s.GetOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !order.CanTransition(req.Status) {
+		return nil, errors.NewConflictError(fmt.Sprintf("A %s order cannot be %s", order.Status, req.Status), nil).WithCode(errors.CodeOrderInvalidState)
+	}
+
+	if req.Status == domain.OrderStatusCancelled {
+		if err := s.releaseOrder(ctx, order); err != nil {
+			return nil, err
+		}
+	}
+
+	event := &domain.OrderEvent{
+		Type:       orderEvents[req.Status],
+		FromStatus: order.Status,
+		Status:     req.Status,
+		Note:       req.Note,
+		ActorID:    auth.ActorID(ctx),
+	}
+	moved, err := s.repo.UpdateOrderStatus(ctx, order, event)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to update order status")
+		return nil, errors.NewInternalError("Failed to update order status", err)
+	}
+	if !moved {
+		return nil, errors.NewConflictError("Order was changed by another request", nil).WithCode(errors.CodeOrderInvalidState)
+	}
+
+	s.publish(ctx, event.Type, order)
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"order_id": order.ID,
+		"status":   order.Status,
+	}).Info("Order status updated successfully")
+	return order, nil
+}
+
+// ListOrderEvents returns an order's timeline, oldest first. Customers
+// only see the timelines of their own orders.
+func (s *orderService) ListOrderEvents(ctx context.Context, id uuid.UUID) ([]domain.OrderEvent, error) {
+	if _, err := s.GetOrder(ctx, id); err != nil {
+		return nil, err
+	}
+
+	events, err := s.repo.ListOrderEvents(ctx, id)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list order events")
+		return nil, errors.NewInternalError("Failed to list order events", err)
+	}
+
+	return events, nil
+}
+
+// releaseOrder gives back what the checkout of a cancelled order holds.
+// A captured payment cannot be voided, so an order that was charged is
+// refused here and has to be refunded through a return instead.
+func (s *orderService) releaseOrder(ctx context.Context, order *domain.Order) error {
+	checkout, err := s.repo.GetCheckout(ctx, order.CheckoutID)
+	if err != nil {
+		s.log(ctx).WithError(err).WithField("order_id", order.ID).Error("Failed to get order checkout")
+		return errors.NewInternalError("Failed to cancel order", err)
+	}
+
+	if err := s.payments.Void(ctx, checkout.Reference); err != nil {
+		return err
+	}
+	if err := s.payments.VoidGiftCards(ctx, checkout.Reference); err != nil {
+		return err
+	}
+	if err := s.payments.VoidPoints(ctx, checkout.CustomerID, checkout.Reference); err != nil {
+		return err
+	}
+	if checkout.TenantID != "" {
+		ctx = tenant.WithID(ctx, checkout.TenantID)
+	}
+	return s.inventory.Release(ctx, checkout.Reference)
+}
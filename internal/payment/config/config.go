@@ -0,0 +1,110 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	productconfig "ecommerce/internal/product/config"
+)
+
+// Config holds all configuration for the payment service
+type Config struct {
+	Env      string
+	HTTP     productconfig.HTTPConfig
+	Database productconfig.DatabaseConfig
+	Auth     productconfig.AuthConfig
+	Debug    productconfig.DebugConfig
+	MTLS     productconfig.MTLSConfig
+	Logger   productconfig.LoggerConfig
+	Provider string
+	Stripe   StripeConfig
+	Loyalty  LoyaltyConfig
+}
+
+// StripeConfig holds Stripe API configuration
+type StripeConfig struct {
+	SecretKey     string
+	WebhookSecret string
+	APIURL        string
+	Timeout       int // seconds
+}
+
+// LoyaltyConfig holds loyalty points configuration
+type LoyaltyConfig struct {
+	// PointsPerUnit is how many points pay for one unit of currency
+	PointsPerUnit int
+}
+
+// Load loads configuration from environment variables. The HTTP, database
+// and auth settings use the same variables as the product service.
+func Load() (*Config, error) {
+	shared, err := productconfig.Load()
+	if err != nil {
+		return nil, err
+	}
+	return &Config{
+		Env:      shared.Env,
+		HTTP:     shared.HTTP,
+		Database: shared.Database,
+		Auth:     shared.Auth,
+		Debug:    shared.Debug,
+		MTLS:     shared.MTLS,
+		Logger:   shared.Logger,
+		Provider: getEnv("PAYMENT_PROVIDER", "sandbox"),
+		Stripe: StripeConfig{
+			SecretKey:     getEnv("STRIPE_SECRET_KEY", ""),
+			WebhookSecret: getEnv("STRIPE_WEBHOOK_SECRET", ""),
+			APIURL:        getEnv("STRIPE_API_URL", "https://api.stripe.com"),
+			Timeout:       getEnvAsInt("STRIPE_TIMEOUT", 30),
+		},
+		Loyalty: LoyaltyConfig{
+			PointsPerUnit: getEnvAsInt("LOYALTY_POINTS_PER_UNIT", 100),
+		},
+	}, nil
+}
+
+// Validate reports every setting that is missing or invalid, so the service
+// refuses to start rather than run misconfigured
+func (c *Config) Validate() error {
+	errs := []error{
+		productconfig.ValidateEnv(c.Env),
+		c.HTTP.Validate(),
+		c.Database.Validate(c.Env),
+		c.Logger.Validate(),
+		c.MTLS.Validate(),
+		c.Auth.Validate(),
+	}
+	switch c.Provider {
+	case "stripe":
+		if c.Stripe.SecretKey == "" {
+			errs = append(errs, errors.New("STRIPE_SECRET_KEY is required for the stripe provider"))
+		}
+	case "sandbox":
+	default:
+		errs = append(errs, fmt.Errorf("PAYMENT_PROVIDER must be stripe or sandbox, not %q", c.Provider))
+	}
+	if c.Loyalty.PointsPerUnit <= 0 {
+		errs = append(errs, errors.New("LOYALTY_POINTS_PER_UNIT must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
+// getEnv gets an environment variable with a default value
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvAsInt gets an environment variable as integer with a default value
+func getEnvAsInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
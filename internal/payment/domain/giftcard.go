@@ -0,0 +1,186 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Gift card statuses
+const (
+	GiftCardStatusActive   = "active"
+	GiftCardStatusDisabled = "disabled"
+)
+
+// Gift card transaction types. Issuing and voiding a redemption credit the
+// card; redeeming debits it.
+const (
+	GiftCardTransactionIssue  = "issue"
+	GiftCardTransactionRedeem = "redeem"
+	GiftCardTransactionVoid   = "void"
+)
+
+// ProductTypeGiftCard is the product type the product service reports for
+// gift cards, which are issued when an order for them is created
+const ProductTypeGiftCard = "gift_card"
+
+// GiftCard is a prepaid balance customers spend at checkout with its code.
+// Every change to the balance is recorded as a transaction.
+type GiftCard struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Code           string     `json:"code" gorm:"not null;uniqueIndex"`
+	Currency       string     `json:"currency" gorm:"not null"`
+	InitialBalance float64    `json:"initial_balance" gorm:"not null"`
+	Balance        float64    `json:"balance" gorm:"not null"`
+	Status         string     `json:"status" gorm:"not null"`
+	PurchaserID    string     `json:"purchaser_id,omitempty"`
+	OrderID        *uuid.UUID `json:"order_id,omitempty" gorm:"type:uuid"`
+	ProductID      *uuid.UUID `json:"product_id,omitempty" gorm:"type:uuid"`
+	Serial         int        `json:"-"` // position among the cards issued for the same order line
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	Transactions []GiftCardTransaction `json:"transactions,omitempty" gorm:"foreignKey:GiftCardID"`
+}
+
+// GiftCardTransaction is a change to a gift card's balance. Amounts are
+// positive for credits and negative for debits. The reference ties
+// redemptions to the checkout that made them, so they can be voided
+// together and are not repeated when a checkout is retried.
+type GiftCardTransaction struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	GiftCardID   uuid.UUID `json:"gift_card_id" gorm:"type:uuid;not null"`
+	Type         string    `json:"type" gorm:"not null"`
+	Amount       float64   `json:"amount" gorm:"not null"`
+	BalanceAfter float64   `json:"balance_after" gorm:"not null"`
+	Reference    string    `json:"reference,omitempty"`
+	ActorID      string    `json:"actor_id,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// IssueGiftCardRequest represents the request to issue a gift card by hand
+type IssueGiftCardRequest struct {
+	Amount      float64    `json:"amount" validate:"gt=0"`
+	Currency    string     `json:"currency" validate:"required,len=3"`
+	PurchaserID string     `json:"purchaser_id,omitempty" validate:"max=255"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// UpdateGiftCardRequest represents the request to disable or re-enable a
+// gift card
+type UpdateGiftCardRequest struct {
+	Status    *string    `json:"status,omitempty" validate:"omitempty,oneof=active disabled"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// GiftCardBalanceRequest represents the request to check a gift card's
+// balance by its code
+type GiftCardBalanceRequest struct {
+	Code string `json:"code" validate:"required,max=64"`
+}
+
+// RedeemGiftCardRequest represents the request to spend up to Amount of a
+// gift card's balance under a reference. Cards with less left are spent in
+// full, so the rest can be paid another way.
+type RedeemGiftCardRequest struct {
+	Code      string  `json:"code" validate:"required,max=64"`
+	Reference string  `json:"reference" validate:"required,max=255"`
+	Amount    float64 `json:"amount" validate:"gt=0"`
+	Currency  string  `json:"currency" validate:"required,len=3"`
+}
+
+// VoidGiftCardsRequest represents the request to return what was redeemed
+// under a reference to the cards it came from
+type VoidGiftCardsRequest struct {
+	Reference string `json:"reference" validate:"required,max=255"`
+}
+
+// GiftCardFilters represents filters for gift card queries
+type GiftCardFilters struct {
+	PurchaserID string `json:"purchaser_id,omitempty"`
+	Status      string `json:"status,omitempty"`
+	Limit       int    `json:"limit,omitempty"`
+	Offset      int    `json:"offset,omitempty"`
+}
+
+// GiftCardList represents a paginated list of gift cards
+type GiftCardList struct {
+	GiftCards []GiftCard `json:"gift_cards"`
+	Total     int64      `json:"total"`
+	Limit     int        `json:"limit"`
+	Offset    int        `json:"offset"`
+	HasMore   bool       `json:"has_more"`
+}
+
+// GiftCardBalance is what anyone holding a gift card's code may learn of it
+type GiftCardBalance struct {
+	Code      string     `json:"code"`
+	Balance   float64    `json:"balance"`
+	Currency  string     `json:"currency"`
+	Status    string     `json:"status"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// GiftCardRedemption is the outcome of redeeming a gift card
+type GiftCardRedemption struct {
+	GiftCardID uuid.UUID `json:"gift_card_id"`
+	Reference  string    `json:"reference"`
+	Amount     float64   `json:"amount"` // spent, at most the amount asked for
+	Balance    float64   `json:"balance"`
+}
+
+// OrderEvent is the part of an order.created payload gift cards are issued
+// and loyalty points earned from. Guests have no account to earn points in.
+type OrderEvent struct {
+	ID           uuid.UUID        `json:"id"`
+	CheckoutID   uuid.UUID        `json:"checkout_id"`
+	CustomerID   string           `json:"customer_id"`
+	Guest        bool             `json:"guest"`
+	Currency     string           `json:"currency"`
+	Total        float64          `json:"total"`
+	PointsAmount float64          `json:"points_amount"`
+	Items        []OrderEventItem `json:"items"`
+}
+
+// OrderEventItem is a product line of an order
+type OrderEventItem struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Type      string    `json:"type"`
+	Quantity  int       `json:"quantity"`
+	UnitPrice float64   `json:"unit_price"`
+	Total     float64   `json:"total"`
+}
+
+// EventOutcome reports what handling an event did
+type EventOutcome struct {
+	GiftCardsIssued int   `json:"gift_cards_issued"`
+	PointsEarned    int64 `json:"points_earned"`
+}
+
+// NormalizeGiftCardCode canonicalizes a code as customers may type it, in
+// any case and with or without its dashes
+func NormalizeGiftCardCode(code string) string {
+	code = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(code), " ", ""))
+	code = strings.ReplaceAll(code, "-", "")
+	if len(code) != 16 {
+		return code
+	}
+	return code[:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:]
+}
+
+// UsableAt reports whether the card can be spent at t
+func (g *GiftCard) UsableAt(t time.Time) bool {
+	return g.Status == GiftCardStatusActive && (g.ExpiresAt == nil || t.Before(*g.ExpiresAt))
+}
+
+// TableName returns the table name for GiftCard
+func (GiftCard) TableName() string {
+	return "gift_cards"
+}
+
+// TableName returns the table name for GiftCardTransaction
+func (GiftCardTransaction) TableName() string {
+	return "gift_card_transactions"
+}
@@ -0,0 +1,169 @@
+package domain
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Loyalty transaction types. Points are earned from orders, redeemed at
+// checkout, and voided back when a checkout that redeemed them fails.
+const (
+	LoyaltyTransactionEarn   = "earn"
+	LoyaltyTransactionRedeem = "redeem"
+	LoyaltyTransactionVoid   = "void"
+)
+
+// EarnRule awards points for every unit of currency spent on the products
+// it applies to. When several rules apply to a product, the most generous
+// one wins.
+type EarnRule struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name          string     `json:"name" gorm:"not null"`
+	ProductType   string     `json:"product_type,omitempty"` // every product type when empty
+	PointsPerUnit float64    `json:"points_per_unit" gorm:"not null"`
+	MinOrderTotal float64    `json:"min_order_total"`
+	Active        bool       `json:"active" gorm:"not null"`
+	StartsAt      *time.Time `json:"starts_at,omitempty"`
+	EndsAt        *time.Time `json:"ends_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// LoyaltyAccount holds a customer's points
+type LoyaltyAccount struct {
+	CustomerID     string    `json:"customer_id" gorm:"primary_key"`
+	Balance        int64     `json:"balance" gorm:"not null"`
+	LifetimeEarned int64     `json:"lifetime_earned" gorm:"not null"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// LoyaltyTransaction is a change to a customer's points. Points are
+// positive for credits and negative for debits. A customer earns, redeems
+// and voids at most once per reference.
+type LoyaltyTransaction struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CustomerID   string    `json:"customer_id" gorm:"not null"`
+	Type         string    `json:"type" gorm:"not null"`
+	Points       int64     `json:"points" gorm:"not null"`
+	BalanceAfter int64     `json:"balance_after" gorm:"not null"`
+	Reference    string    `json:"reference"`
+	Description  string    `json:"description,omitempty"`
+	ActorID      string    `json:"actor_id,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// CreateEarnRuleRequest represents the request to create an earn rule
+type CreateEarnRuleRequest struct {
+	Name          string     `json:"name" validate:"required,max=255"`
+	ProductType   string     `json:"product_type,omitempty" validate:"omitempty,oneof=physical digital service bundle"`
+	PointsPerUnit float64    `json:"points_per_unit" validate:"gt=0,lte=1000"`
+	MinOrderTotal float64    `json:"min_order_total" validate:"gte=0"`
+	Active        *bool      `json:"active,omitempty"` // active when omitted
+	StartsAt      *time.Time `json:"starts_at,omitempty"`
+	EndsAt        *time.Time `json:"ends_at,omitempty"`
+}
+
+// UpdateEarnRuleRequest represents the request to update an earn rule
+type UpdateEarnRuleRequest struct {
+	Name          *string    `json:"name,omitempty" validate:"omitempty,max=255"`
+	PointsPerUnit *float64   `json:"points_per_unit,omitempty" validate:"omitempty,gt=0,lte=1000"`
+	MinOrderTotal *float64   `json:"min_order_total,omitempty" validate:"omitempty,gte=0"`
+	Active        *bool      `json:"active,omitempty"`
+	StartsAt      *time.Time `json:"starts_at,omitempty"`
+	EndsAt        *time.Time `json:"ends_at,omitempty"`
+}
+
+// RedeemPointsRequest represents the request to spend a customer's points
+// on up to Amount of an order under a reference. Customers short of points
+// spend what they have, so the rest can be paid another way.
+type RedeemPointsRequest struct {
+	CustomerID string  `json:"customer_id" validate:"required,max=255"`
+	Reference  string  `json:"reference" validate:"required,max=255"`
+	Points     int64   `json:"points" validate:"gt=0"` // the most the customer agreed to spend
+	Amount     float64 `json:"amount" validate:"gt=0"`
+}
+
+// VoidPointsRequest represents the request to give back the points a
+// customer redeemed under a reference
+type VoidPointsRequest struct {
+	CustomerID string `json:"customer_id" validate:"required,max=255"`
+	Reference  string `json:"reference" validate:"required,max=255"`
+}
+
+// PointsRedemption is the outcome of redeeming points
+type PointsRedemption struct {
+	Reference string  `json:"reference"`
+	Points    int64   `json:"points"`
+	Amount    float64 `json:"amount"` // what the points paid, at most the amount asked for
+	Balance   int64   `json:"balance"`
+}
+
+// LoyaltyTransactionFilters represents filters for points history queries
+type LoyaltyTransactionFilters struct {
+	CustomerID string `json:"customer_id,omitempty"`
+	Type       string `json:"type,omitempty"`
+	Limit      int    `json:"limit,omitempty"`
+	Offset     int    `json:"offset,omitempty"`
+}
+
+// LoyaltyTransactionList represents a paginated points history
+type LoyaltyTransactionList struct {
+	Transactions []LoyaltyTransaction `json:"transactions"`
+	Total        int64                `json:"total"`
+	Limit        int                  `json:"limit"`
+	Offset       int                  `json:"offset"`
+	HasMore      bool                 `json:"has_more"`
+}
+
+// ActiveAt reports whether the rule awards points at t
+func (r *EarnRule) ActiveAt(t time.Time) bool {
+	return r.Active &&
+		(r.StartsAt == nil || !t.Before(*r.StartsAt)) &&
+		(r.EndsAt == nil || t.Before(*r.EndsAt))
+}
+
+// EarnedPoints is what an order earns under the rules. Gift cards earn
+// nothing, being spent on other purchases that do, and neither does the
+// part of the order paid for with points.
+func EarnedPoints(rules []EarnRule, order *OrderEvent, at time.Time) int64 {
+	var points float64
+	for _, item := range order.Items {
+		if item.Type == ProductTypeGiftCard {
+			continue
+		}
+		var rate float64
+		for i := range rules {
+			rule := &rules[i]
+			if !rule.ActiveAt(at) || order.Total < rule.MinOrderTotal {
+				continue
+			}
+			if rule.ProductType != "" && rule.ProductType != item.Type {
+				continue
+			}
+			rate = math.Max(rate, rule.PointsPerUnit)
+		}
+		points += item.Total * rate
+	}
+	if order.Total > 0 && order.PointsAmount > 0 {
+		points *= math.Max(0, 1-order.PointsAmount/order.Total)
+	}
+	return int64(math.Floor(points))
+}
+
+// TableName returns the table name for EarnRule
+func (EarnRule) TableName() string {
+	return "loyalty_earn_rules"
+}
+
+// TableName returns the table name for LoyaltyAccount
+func (LoyaltyAccount) TableName() string {
+	return "loyalty_accounts"
+}
+
+// TableName returns the table name for LoyaltyTransaction
+func (LoyaltyTransaction) TableName() string {
+	return "loyalty_transactions"
+}
@@ -0,0 +1,142 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Payment statuses
+const (
+	StatusPending    = "pending" // awaiting the provider, e.g. customer authentication
+	StatusAuthorized = "authorized"
+	StatusCaptured   = "captured"
+	StatusRefunded   = "refunded"
+	StatusVoided     = "voided"
+	StatusFailed     = "failed"
+)
+
+// Refund statuses
+const (
+	RefundStatusPending   = "pending"
+	RefundStatusSucceeded = "succeeded"
+	RefundStatusFailed    = "failed"
+)
+
+// transitions lists the statuses each payment status may move to. Provider
+// notifications can arrive late or out of order, so a payment only ever
+// moves forward along its lifecycle.
+var transitions = map[string][]string{
+	StatusPending:    {StatusAuthorized, StatusCaptured, StatusVoided, StatusFailed},
+	StatusAuthorized: {StatusCaptured, StatusVoided, StatusFailed},
+	StatusCaptured:   {StatusRefunded},
+}
+
+// CanTransition reports whether a payment may move from one status to another
+func CanTransition(from, to string) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Payment is a payment taken through a provider. The reference is chosen by
+// the caller and makes authorization safe to retry.
+type Payment struct {
+	ID             uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Reference      string    `json:"reference" gorm:"not null;uniqueIndex"`
+	CustomerID     string    `json:"customer_id"`
+	Provider       string    `json:"provider" gorm:"not null"`
+	ProviderRef    string    `json:"provider_ref,omitempty" gorm:"index"`
+	Amount         float64   `json:"amount" gorm:"not null"`
+	Currency       string    `json:"currency" gorm:"not null"`
+	Status         string    `json:"status" gorm:"not null"`
+	AmountCaptured float64   `json:"amount_captured"`
+	AmountRefunded float64   `json:"amount_refunded"`
+	FailureReason  string    `json:"failure_reason,omitempty"`
+	Refunds        []Refund  `json:"refunds,omitempty" gorm:"foreignKey:PaymentID"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Refund returns part or all of a captured payment
+type Refund struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	PaymentID   uuid.UUID `json:"payment_id" gorm:"type:uuid;not null"`
+	ProviderRef string    `json:"provider_ref,omitempty" gorm:"index"`
+	Amount      float64   `json:"amount" gorm:"not null"`
+	Reason      string    `json:"reason,omitempty"`
+	Status      string    `json:"status" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CompletedCheckout is a checkout the order service turned into an order.
+// Nothing redeemed under its references is voided after that.
+type CompletedCheckout struct {
+	CheckoutID  uuid.UUID `json:"checkout_id" gorm:"type:uuid;primary_key"`
+	OrderID     uuid.UUID `json:"order_id" gorm:"type:uuid;not null"`
+	CompletedAt time.Time `json:"completed_at" gorm:"autoCreateTime"`
+}
+
+// CheckoutOfReference returns the checkout a reference was made for. The
+// order service references each checkout attempt as
+// checkout-<checkout ID>-<attempt>; other references report false.
+func CheckoutOfReference(reference string) (uuid.UUID, bool) {
+	rest, ok := strings.CutPrefix(reference, "checkout-")
+	if !ok {
+		return uuid.Nil, false
+	}
+	i := strings.LastIndexByte(rest, '-')
+	if i < 0 {
+		return uuid.Nil, false
+	}
+	id, err := uuid.Parse(rest[:i])
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// AuthorizePaymentRequest represents the request to authorize a payment
+type AuthorizePaymentRequest struct {
+	Reference     string  `json:"reference" validate:"required,max=255"`
+	CustomerID    string  `json:"customer_id"`
+	Amount        float64 `json:"amount" validate:"gt=0"`
+	Currency      string  `json:"currency" validate:"required,len=3"`
+	PaymentMethod string  `json:"payment_method" validate:"required"`
+}
+
+// VoidPaymentRequest represents the request to void an authorization
+type VoidPaymentRequest struct {
+	Reference string `json:"reference" validate:"required"`
+}
+
+// CapturePaymentRequest represents the request to capture an authorization
+type CapturePaymentRequest struct {
+	Amount float64 `json:"amount" validate:"gte=0"` // zero captures the full amount
+}
+
+// RefundPaymentRequest represents the request to refund a captured payment
+type RefundPaymentRequest struct {
+	Amount float64 `json:"amount" validate:"gte=0"` // zero refunds what remains
+	Reason string  `json:"reason" validate:"max=255"`
+}
+
+// TableName returns the table name for Payment
+func (Payment) TableName() string {
+	return "payments"
+}
+
+// TableName returns the table name for Refund
+func (Refund) TableName() string {
+	return "payment_refunds"
+}
+
+// TableName returns the table name for CompletedCheckout
+func (CompletedCheckout) TableName() string {
+	return "completed_checkouts"
+}
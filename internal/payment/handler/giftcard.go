@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"ecommerce/internal/payment/domain"
+	"ecommerce/pkg/response"
+)
+
+// IssueGiftCard handles issuing a gift card by hand
+func (h *HTTPHandler) IssueGiftCard(c *gin.Context) {
+	var req domain.IssueGiftCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	card, err := h.service.IssueGiftCard(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Gift card issued successfully", card)
+}
+
+// GetGiftCard handles getting a single gift card with its transactions
+func (h *HTTPHandler) GetGiftCard(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid gift card ID", err)
+		return
+	}
+
+	card, err := h.service.GetGiftCard(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Gift card retrieved successfully", card)
+}
+
+// UpdateGiftCard handles disabling, re-enabling or extending a gift card
+func (h *HTTPHandler) UpdateGiftCard(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid gift card ID", err)
+		return
+	}
+
+	var req domain.UpdateGiftCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	card, err := h.service.UpdateGiftCard(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Gift card updated successfully", card)
+}
+
+// ListGiftCards handles listing gift cards
+func (h *HTTPHandler) ListGiftCards(c *gin.Context) {
+	filters := &domain.GiftCardFilters{
+		PurchaserID: c.Query("purchaser_id"),
+		Status:      c.Query("status"),
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			filters.Limit = l
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if o, err := strconv.Atoi(offset); err == nil {
+			filters.Offset = o
+		}
+	}
+
+	cards, err := h.service.ListGiftCards(c.Request.Context(), filters)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Gift cards retrieved successfully", cards)
+}
+
+// GiftCardBalance handles checking the balance of a gift card by its code.
+// The code travels in the body so it stays out of access logs.
+func (h *HTTPHandler) GiftCardBalance(c *gin.Context) {
+	var req domain.GiftCardBalanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	balance, err := h.service.GiftCardBalance(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Gift card balance retrieved successfully", balance)
+}
+
+// RedeemGiftCard handles spending a gift card's balance
+func (h *HTTPHandler) RedeemGiftCard(c *gin.Context) {
+	var req domain.RedeemGiftCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	redemption, err := h.service.RedeemGiftCard(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Gift card redeemed successfully", redemption)
+}
+
+// VoidGiftCards handles returning what was redeemed under a reference
+func (h *HTTPHandler) VoidGiftCards(c *gin.Context) {
+	var req domain.VoidGiftCardsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	voids, err := h.service.VoidGiftCards(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Gift card redemptions voided successfully", gin.H{
+		"transactions": voids,
+	})
+}
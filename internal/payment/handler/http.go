@@ -0,0 +1,269 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/payment/domain"
+	"ecommerce/internal/payment/service"
+	"ecommerce/pkg/database"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/events"
+	"ecommerce/pkg/response"
+)
+
+// maxWebhookSize bounds the webhook payloads the service will read
+const maxWebhookSize = 1 << 20
+
+// HTTPHandler handles HTTP requests for payment service
+type HTTPHandler struct {
+	service service.PaymentService
+	logger  *logrus.Logger
+}
+
+// NewHTTPHandler creates a new HTTP handler
+func NewHTTPHandler(service service.PaymentService, logger *logrus.Logger) *HTTPHandler {
+	return &HTTPHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers all HTTP routes
+func (h *HTTPHandler) RegisterRoutes(router *gin.Engine) {
+	api := router.Group("/api/v1")
+
+	// Payment routes
+	payments := api.Group("/payments")
+	{
+		payments.POST("", h.AuthorizePayment)
+		payments.POST("/void", h.VoidPayment)
+		payments.POST("/webhooks/:provider", h.HandleWebhook)
+		payments.GET("/:id", h.GetPayment)
+		payments.POST("/:id/capture", h.CapturePayment)
+		payments.POST("/:id/refunds", h.RefundPayment)
+	}
+
+	// Gift card routes
+	giftCards := api.Group("/gift-cards")
+	{
+		giftCards.POST("", h.IssueGiftCard)
+		giftCards.GET("", h.ListGiftCards)
+		giftCards.POST("/balance", h.GiftCardBalance)
+		giftCards.POST("/redeem", h.RedeemGiftCard)
+		giftCards.POST("/void", h.VoidGiftCards)
+		giftCards.GET("/:id", h.GetGiftCard)
+		giftCards.PUT("/:id", h.UpdateGiftCard)
+	}
+
+	// Loyalty routes
+	loyalty := api.Group("/loyalty")
+	{
+		loyalty.GET("/account", h.GetLoyaltyAccount)
+		loyalty.GET("/history", h.ListPointsHistory)
+		loyalty.POST("/redeem", h.RedeemPoints)
+		loyalty.POST("/void", h.VoidPoints)
+		loyalty.POST("/rules", h.CreateEarnRule)
+		loyalty.GET("/rules", h.ListEarnRules)
+		loyalty.GET("/rules/:id", h.GetEarnRule)
+		loyalty.PUT("/rules/:id", h.UpdateEarnRule)
+		loyalty.DELETE("/rules/:id", h.DeleteEarnRule)
+	}
+
+	// Events forwarded by other services
+	api.POST("/events", h.HandleEvent)
+
+	// Health check
+	router.GET("/health", h.HealthCheck)
+	router.GET("/ready", h.ReadinessCheck)
+}
+
+// AuthorizePayment handles authorizing a payment. Repeating a request with
+// the same reference returns the original payment with 200 instead of 201.
+func (h *HTTPHandler) AuthorizePayment(c *gin.Context) {
+	var req domain.AuthorizePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	payment, created, err := h.service.Authorize(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	if !created {
+		response.Success(c, http.StatusOK, "Payment retrieved successfully", payment)
+		return
+	}
+	response.Success(c, http.StatusCreated, "Payment processed successfully", payment)
+}
+
+// VoidPayment handles voiding the authorization placed under a reference
+func (h *HTTPHandler) VoidPayment(c *gin.Context) {
+	var req domain.VoidPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	payment, err := h.service.Void(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Payment voided successfully", payment)
+}
+
+// GetPayment handles getting a single payment
+func (h *HTTPHandler) GetPayment(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid payment ID", err)
+		return
+	}
+
+	payment, err := h.service.GetPayment(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Payment retrieved successfully", payment)
+}
+
+// CapturePayment handles capturing an authorized payment
+func (h *HTTPHandler) CapturePayment(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid payment ID", err)
+		return
+	}
+
+	var req domain.CapturePaymentRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.logger.WithError(err).Error("Invalid request body")
+			response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+	}
+
+	payment, err := h.service.Capture(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Payment captured successfully", payment)
+}
+
+// RefundPayment handles refunding a captured payment
+func (h *HTTPHandler) RefundPayment(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid payment ID", err)
+		return
+	}
+
+	var req domain.RefundPaymentRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.logger.WithError(err).Error("Invalid request body")
+			response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+	}
+
+	refund, err := h.service.Refund(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Refund processed successfully", refund)
+}
+
+// HandleWebhook handles asynchronous notifications from a payment provider.
+// The raw body is passed on untouched because the signature covers it.
+func (h *HTTPHandler) HandleWebhook(c *gin.Context) {
+	payload, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookSize))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := h.service.HandleWebhook(c.Request.Context(), c.Param("provider"), payload, c.Request.Header); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Webhook processed successfully", nil)
+}
+
+// HandleEvent handles an event forwarded by another service
+func (h *HTTPHandler) HandleEvent(c *gin.Context) {
+	var event events.Event
+	if err := c.ShouldBindJSON(&event); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	outcome, err := h.service.HandleEvent(c.Request.Context(), &event)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusAccepted, "Event accepted successfully", outcome)
+}
+
+// HealthCheck handles health check requests
+func (h *HTTPHandler) HealthCheck(c *gin.Context) {
+	response.Success(c, http.StatusOK, "Service is healthy", gin.H{
+		"service": "payment-service",
+		"status":  "healthy",
+	})
+}
+
+// ReadinessCheck handles readiness check requests
+func (h *HTTPHandler) ReadinessCheck(c *gin.Context) {
+	response.Success(c, http.StatusOK, "Service is ready", gin.H{
+		"service": "payment-service",
+		"status":  "ready",
+	})
+}
+
+// handleError handles service errors and converts them to appropriate HTTP responses
+func (h *HTTPHandler) handleError(c *gin.Context, err error) {
+	err = database.TranslateError(err)
+	switch {
+	case errors.IsNotFound(err):
+		response.Error(c, http.StatusNotFound, "Resource not found", err)
+	case errors.IsValidation(err):
+		response.Error(c, http.StatusBadRequest, "Validation failed", err)
+	case errors.IsConflict(err):
+		response.Error(c, http.StatusConflict, "Resource conflict", err)
+	case errors.IsUnauthorized(err):
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", err)
+	case errors.IsForbidden(err):
+		response.Error(c, http.StatusForbidden, "Forbidden", err)
+	case errors.IsUnavailable(err):
+		response.Error(c, http.StatusServiceUnavailable, "Service unavailable", err)
+	default:
+		h.logger.WithError(err).Error("Internal server error")
+		response.Error(c, http.StatusInternalServerError, "Internal server error", nil)
+	}
+}
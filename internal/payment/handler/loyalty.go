@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"ecommerce/internal/payment/domain"
+	"ecommerce/pkg/response"
+)
+
+// CreateEarnRule handles creating a loyalty earn rule
+func (h *HTTPHandler) CreateEarnRule(c *gin.Context) {
+	var req domain.CreateEarnRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	rule, err := h.service.CreateEarnRule(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Earn rule created successfully", rule)
+}
+
+// GetEarnRule handles getting a single earn rule
+func (h *HTTPHandler) GetEarnRule(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid earn rule ID", err)
+		return
+	}
+
+	rule, err := h.service.GetEarnRule(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Earn rule retrieved successfully", rule)
+}
+
+// UpdateEarnRule handles updating an earn rule
+func (h *HTTPHandler) UpdateEarnRule(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid earn rule ID", err)
+		return
+	}
+
+	var req domain.UpdateEarnRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	rule, err := h.service.UpdateEarnRule(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Earn rule updated successfully", rule)
+}
+
+// DeleteEarnRule handles deleting an earn rule
+func (h *HTTPHandler) DeleteEarnRule(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid earn rule ID", err)
+		return
+	}
+
+	if err := h.service.DeleteEarnRule(c.Request.Context(), id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Earn rule deleted successfully", nil)
+}
+
+// ListEarnRules handles listing the earn rules
+func (h *HTTPHandler) ListEarnRules(c *gin.Context) {
+	rules, err := h.service.ListEarnRules(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Earn rules retrieved successfully", gin.H{
+		"rules": rules,
+	})
+}
+
+// GetLoyaltyAccount handles getting a customer's points balance
+func (h *HTTPHandler) GetLoyaltyAccount(c *gin.Context) {
+	account, err := h.service.GetLoyaltyAccount(c.Request.Context(), c.Query("customer_id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Loyalty account retrieved successfully", account)
+}
+
+// ListPointsHistory handles listing the transactions on a customer's points
+func (h *HTTPHandler) ListPointsHistory(c *gin.Context) {
+	filters := &domain.LoyaltyTransactionFilters{
+		CustomerID: c.Query("customer_id"),
+		Type:       c.Query("type"),
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			filters.Limit = l
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if o, err := strconv.Atoi(offset); err == nil {
+			filters.Offset = o
+		}
+	}
+
+	history, err := h.service.ListPointsHistory(c.Request.Context(), filters)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Points history retrieved successfully", history)
+}
+
+// RedeemPoints handles paying with loyalty points
+func (h *HTTPHandler) RedeemPoints(c *gin.Context) {
+	var req domain.RedeemPointsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	redemption, err := h.service.RedeemPoints(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Loyalty points redeemed successfully", redemption)
+}
+
+// VoidPoints handles giving back the points redeemed under a reference
+func (h *HTTPHandler) VoidPoints(c *gin.Context) {
+	var req domain.VoidPointsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	void, err := h.service.VoidPoints(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Loyalty points voided successfully", void)
+}
@@ -0,0 +1,79 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Supported providers
+const (
+	ProviderStripe  = "stripe"
+	ProviderSandbox = "sandbox"
+)
+
+// Provider errors
+var (
+	ErrInvalidSignature   = errors.New("invalid webhook signature")
+	ErrWebhookUnsupported = errors.New("provider does not send webhooks")
+)
+
+// Provider is a payment processor. Amounts are in major currency units;
+// implementations convert to whatever the processor expects.
+type Provider interface {
+	Name() string
+	// Authorize places a hold for the amount. The idempotency key makes
+	// retries return the original authorization instead of a second hold.
+	Authorize(ctx context.Context, req *AuthorizeRequest) (*Result, error)
+	Capture(ctx context.Context, providerRef string, amount float64) (*Result, error)
+	Void(ctx context.Context, providerRef string) (*Result, error)
+	Refund(ctx context.Context, req *RefundRequest) (*RefundResult, error)
+	// ParseWebhook verifies and decodes an asynchronous notification
+	ParseWebhook(payload []byte, header http.Header) (*WebhookEvent, error)
+}
+
+// AuthorizeRequest is a request to hold funds on a payment method
+type AuthorizeRequest struct {
+	IdempotencyKey string
+	Amount         float64
+	Currency       string
+	PaymentMethod  string
+	CustomerID     string
+}
+
+// RefundRequest is a request to return captured funds
+type RefundRequest struct {
+	IdempotencyKey string
+	ProviderRef    string
+	Amount         float64
+	Reason         string
+}
+
+// Result is the provider's view of a payment after an operation. A declined
+// payment is a result with a failed status, not an error.
+type Result struct {
+	ProviderRef   string
+	Status        string
+	FailureReason string
+}
+
+// RefundResult is the provider's view of a refund
+type RefundResult struct {
+	ProviderRef string
+	Status      string
+}
+
+// WebhookEvent is a decoded provider notification about a payment or refund.
+// Reference is the payment reference or refund ID the service sent, when the
+// provider echoes it back; it identifies objects whose response was lost.
+type WebhookEvent struct {
+	ID             string
+	Type           string
+	Reference      string
+	PaymentRef     string
+	PaymentStatus  string
+	AmountCaptured float64
+	FailureReason  string
+	RefundRef      string
+	RefundStatus   string
+}
@@ -0,0 +1,50 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/payment/domain"
+)
+
+// Sandbox is an in-process provider for local development and tests. It
+// approves every payment method except those containing "declined",
+// mirroring the naming of Stripe's test payment methods.
+type Sandbox struct{}
+
+// NewSandbox creates a sandbox provider
+func NewSandbox() *Sandbox {
+	return &Sandbox{}
+}
+
+func (p *Sandbox) Name() string {
+	return ProviderSandbox
+}
+
+func (p *Sandbox) Authorize(ctx context.Context, req *AuthorizeRequest) (*Result, error) {
+	result := &Result{ProviderRef: "sandbox_" + uuid.NewString(), Status: domain.StatusAuthorized}
+	if strings.Contains(strings.ToLower(req.PaymentMethod), "declined") {
+		result.Status = domain.StatusFailed
+		result.FailureReason = "Your card was declined."
+	}
+	return result, nil
+}
+
+func (p *Sandbox) Capture(ctx context.Context, providerRef string, amount float64) (*Result, error) {
+	return &Result{ProviderRef: providerRef, Status: domain.StatusCaptured}, nil
+}
+
+func (p *Sandbox) Void(ctx context.Context, providerRef string) (*Result, error) {
+	return &Result{ProviderRef: providerRef, Status: domain.StatusVoided}, nil
+}
+
+func (p *Sandbox) Refund(ctx context.Context, req *RefundRequest) (*RefundResult, error) {
+	return &RefundResult{ProviderRef: "sandbox_re_" + uuid.NewString(), Status: domain.RefundStatusSucceeded}, nil
+}
+
+func (p *Sandbox) ParseWebhook(payload []byte, header http.Header) (*WebhookEvent, error) {
+	return nil, ErrWebhookUnsupported
+}
@@ -0,0 +1,351 @@
+package provider
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"ecommerce/internal/payment/config"
+	"ecommerce/internal/payment/domain"
+	"ecommerce/pkg/errors"
+)
+
+// webhookTolerance is how old a signed webhook may be before it is rejected
+// as a possible replay
+const webhookTolerance = 5 * time.Minute
+
+// Stripe processes payments as Stripe PaymentIntents. Intents are confirmed
+// with manual capture, so authorizing holds funds and capturing takes them.
+type Stripe struct {
+	baseURL       string
+	secretKey     string
+	webhookSecret string
+	client        *http.Client
+}
+
+// NewStripe creates a Stripe provider
+func NewStripe(cfg config.StripeConfig) *Stripe {
+	return &Stripe{
+		baseURL:       strings.TrimRight(cfg.APIURL, "/"),
+		secretKey:     cfg.SecretKey,
+		webhookSecret: cfg.WebhookSecret,
+		client: &http.Client{
+			Timeout: time.Duration(cfg.Timeout) * time.Second,
+		},
+	}
+}
+
+// paymentIntent is the subset of a Stripe PaymentIntent the service uses
+type paymentIntent struct {
+	ID             string `json:"id"`
+	Object         string `json:"object"`
+	Status         string `json:"status"`
+	AmountReceived int64  `json:"amount_received"`
+	Metadata       struct {
+		Reference string `json:"reference"`
+	} `json:"metadata"`
+	LastPaymentError *struct {
+		Message string `json:"message"`
+	} `json:"last_payment_error"`
+}
+
+// stripeRefund is the subset of a Stripe Refund the service uses
+type stripeRefund struct {
+	ID       string `json:"id"`
+	Object   string `json:"object"`
+	Status   string `json:"status"`
+	Metadata struct {
+		RefundID string `json:"refund_id"`
+	} `json:"metadata"`
+}
+
+// stripeError is the error body Stripe returns with non-2xx responses
+type stripeError struct {
+	Error struct {
+		Type          string         `json:"type"`
+		Code          string         `json:"code"`
+		Message       string         `json:"message"`
+		PaymentIntent *paymentIntent `json:"payment_intent"`
+	} `json:"error"`
+}
+
+func (p *Stripe) Name() string {
+	return ProviderStripe
+}
+
+func (p *Stripe) Authorize(ctx context.Context, req *AuthorizeRequest) (*Result, error) {
+	form := url.Values{}
+	form.Set("amount", strconv.FormatInt(minorUnits(req.Amount), 10))
+	form.Set("currency", strings.ToLower(req.Currency))
+	form.Set("payment_method", req.PaymentMethod)
+	form.Set("capture_method", "manual")
+	form.Set("confirm", "true")
+	form.Set("automatic_payment_methods[enabled]", "true")
+	form.Set("automatic_payment_methods[allow_redirects]", "never")
+	form.Set("metadata[reference]", req.IdempotencyKey)
+	if req.CustomerID != "" {
+		form.Set("metadata[customer_id]", req.CustomerID)
+	}
+
+	var intent paymentIntent
+	if err := p.post(ctx, "/v1/payment_intents", form, req.IdempotencyKey, &intent); err != nil {
+		return declined(err)
+	}
+	return intentResult(&intent), nil
+}
+
+func (p *Stripe) Capture(ctx context.Context, providerRef string, amount float64) (*Result, error) {
+	form := url.Values{}
+	if amount > 0 {
+		form.Set("amount_to_capture", strconv.FormatInt(minorUnits(amount), 10))
+	}
+
+	var intent paymentIntent
+	if err := p.post(ctx, "/v1/payment_intents/"+url.PathEscape(providerRef)+"/capture", form, "", &intent); err != nil {
+		return nil, err
+	}
+	return intentResult(&intent), nil
+}
+
+func (p *Stripe) Void(ctx context.Context, providerRef string) (*Result, error) {
+	var intent paymentIntent
+	if err := p.post(ctx, "/v1/payment_intents/"+url.PathEscape(providerRef)+"/cancel", url.Values{}, "", &intent); err != nil {
+		return nil, err
+	}
+	return intentResult(&intent), nil
+}
+
+func (p *Stripe) Refund(ctx context.Context, req *RefundRequest) (*RefundResult, error) {
+	form := url.Values{}
+	form.Set("payment_intent", req.ProviderRef)
+	form.Set("amount", strconv.FormatInt(minorUnits(req.Amount), 10))
+	form.Set("metadata[refund_id]", req.IdempotencyKey)
+	if req.Reason != "" {
+		form.Set("metadata[reason]", req.Reason)
+	}
+
+	var refund stripeRefund
+	if err := p.post(ctx, "/v1/refunds", form, req.IdempotencyKey, &refund); err != nil {
+		return nil, err
+	}
+	return &RefundResult{ProviderRef: refund.ID, Status: refundStatus(refund.Status)}, nil
+}
+
+// ParseWebhook verifies the Stripe-Signature header and decodes events
+// about payment intents and refunds. Other event types decode with no
+// payment or refund reference and are ignored by the service.
+func (p *Stripe) ParseWebhook(payload []byte, header http.Header) (*WebhookEvent, error) {
+	if err := p.verifySignature(payload, header.Get("Stripe-Signature"), time.Now()); err != nil {
+		return nil, err
+	}
+
+	var event struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+		Data struct {
+			Object json.RawMessage `json:"object"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook: %w", err)
+	}
+
+	result := &WebhookEvent{ID: event.ID, Type: event.Type}
+
+	var object struct {
+		Object string `json:"object"`
+	}
+	if err := json.Unmarshal(event.Data.Object, &object); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook object: %w", err)
+	}
+
+	switch object.Object {
+	case "payment_intent":
+		var intent paymentIntent
+		if err := json.Unmarshal(event.Data.Object, &intent); err != nil {
+			return nil, fmt.Errorf("failed to decode payment intent: %w", err)
+		}
+		status := intentResult(&intent)
+		result.Reference = intent.Metadata.Reference
+		result.PaymentRef = status.ProviderRef
+		result.PaymentStatus = status.Status
+		result.AmountCaptured = float64(intent.AmountReceived) / 100
+		result.FailureReason = status.FailureReason
+	case "refund":
+		var refund stripeRefund
+		if err := json.Unmarshal(event.Data.Object, &refund); err != nil {
+			return nil, fmt.Errorf("failed to decode refund: %w", err)
+		}
+		result.Reference = refund.Metadata.RefundID
+		result.RefundRef = refund.ID
+		result.RefundStatus = refundStatus(refund.Status)
+	}
+
+	return result, nil
+}
+
+// verifySignature checks a Stripe-Signature header of the form
+// t=<unix time>,v1=<hex hmac>[,v1=...] against the signed payload
+func (p *Stripe) verifySignature(payload []byte, header string, now time.Time) error {
+	if p.webhookSecret == "" || header == "" {
+		return ErrInvalidSignature
+	}
+
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > webhookTolerance || age < -webhookTolerance {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(p.webhookSecret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	expected := mac.Sum(nil)
+
+	for _, signature := range signatures {
+		decoded, err := hex.DecodeString(signature)
+		if err == nil && hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// post sends a form-encoded request to the Stripe API. Card errors carry the
+// payment intent they declined, which is returned alongside the error.
+func (p *Stripe) post(ctx context.Context, path string, form url.Values, idempotencyKey string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to build stripe request: %w", err)
+	}
+	req.SetBasicAuth(p.secretKey, "")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return errors.NewUnavailableError("Stripe request failed", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return errors.NewUnavailableError("Failed to read stripe response", err)
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if err := json.Unmarshal(body, out); err != nil {
+			return fmt.Errorf("failed to decode stripe response: %w", err)
+		}
+		return nil
+	}
+
+	var apiErr stripeError
+	_ = json.Unmarshal(body, &apiErr)
+	cause := fmt.Errorf("stripe status %d: %s", resp.StatusCode, apiErr.Error.Message)
+
+	switch {
+	case apiErr.Error.Type == "card_error":
+		return &cardError{message: apiErr.Error.Message, intent: apiErr.Error.PaymentIntent}
+	case resp.StatusCode == http.StatusNotFound:
+		return errors.NewNotFoundError("Stripe object not found", cause)
+	case resp.StatusCode == http.StatusConflict || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return errors.NewUnavailableError("Stripe is unavailable", cause)
+	default:
+		return errors.NewValidationError(apiErr.Error.Message, cause)
+	}
+}
+
+// cardError is a payment the card issuer or Stripe declined
+type cardError struct {
+	message string
+	intent  *paymentIntent
+}
+
+func (e *cardError) Error() string {
+	return "card declined: " + e.message
+}
+
+// declined turns a card error into a failed result; other errors pass through
+func declined(err error) (*Result, error) {
+	cardErr, ok := err.(*cardError)
+	if !ok {
+		return nil, err
+	}
+
+	result := &Result{Status: domain.StatusFailed, FailureReason: cardErr.message}
+	if cardErr.intent != nil {
+		result.ProviderRef = cardErr.intent.ID
+	}
+	return result, nil
+}
+
+// intentResult maps a PaymentIntent status onto a payment status
+func intentResult(intent *paymentIntent) *Result {
+	result := &Result{ProviderRef: intent.ID}
+	switch intent.Status {
+	case "requires_capture":
+		result.Status = domain.StatusAuthorized
+	case "succeeded":
+		result.Status = domain.StatusCaptured
+	case "canceled":
+		result.Status = domain.StatusVoided
+	case "requires_payment_method":
+		result.Status = domain.StatusFailed
+		result.FailureReason = "Payment method was declined"
+		if intent.LastPaymentError != nil && intent.LastPaymentError.Message != "" {
+			result.FailureReason = intent.LastPaymentError.Message
+		}
+	default:
+		// requires_action, requires_confirmation and processing settle
+		// asynchronously and are reported by webhook
+		result.Status = domain.StatusPending
+	}
+	return result
+}
+
+func refundStatus(status string) string {
+	switch status {
+	case "succeeded":
+		return domain.RefundStatusSucceeded
+	case "failed", "canceled":
+		return domain.RefundStatusFailed
+	default:
+		return domain.RefundStatusPending
+	}
+}
+
+// minorUnits converts an amount to the smallest currency unit. Stripe's
+// zero-decimal currencies are not supported.
+func minorUnits(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
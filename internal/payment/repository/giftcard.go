@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"ecommerce/internal/payment/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+func (r *paymentRepository) IssueGiftCards(ctx context.Context, cards []domain.GiftCard, actorID string) (int, error) {
+	issued := 0
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range cards {
+			card := &cards[i]
+			result := tx.Omit(clause.Associations).
+				Clauses(clause.OnConflict{
+					Columns:   []clause.Column{{Name: "order_id"}, {Name: "product_id"}, {Name: "serial"}},
+					DoNothing: true,
+				}).
+				Create(card)
+			if result.Error != nil {
+				return fmt.Errorf("failed to create gift card: %w", result.Error)
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+
+			issue := domain.GiftCardTransaction{
+				GiftCardID:   card.ID,
+				Type:         domain.GiftCardTransactionIssue,
+				Amount:       card.Balance,
+				BalanceAfter: card.Balance,
+				ActorID:      actorID,
+			}
+			if card.OrderID != nil {
+				issue.Reference = card.OrderID.String()
+			}
+			if err := tx.Create(&issue).Error; err != nil {
+				return fmt.Errorf("failed to record gift card issue: %w", err)
+			}
+			card.Transactions = []domain.GiftCardTransaction{issue}
+			issued++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return issued, nil
+}
+
+func (r *paymentRepository) GetGiftCard(ctx context.Context, id uuid.UUID) (*domain.GiftCard, error) {
+	var card domain.GiftCard
+	err := r.db.WithContext(ctx).
+		Preload("Transactions", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		First(&card, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Gift card not found", err).WithCode(customErrors.CodeGiftCardNotFound)
+		}
+		return nil, fmt.Errorf("failed to get gift card: %w", err)
+	}
+
+	return &card, nil
+}
+
+func (r *paymentRepository) GetGiftCardByCode(ctx context.Context, code string) (*domain.GiftCard, error) {
+	var card domain.GiftCard
+	err := r.db.WithContext(ctx).First(&card, "code = ?", code).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Gift card not found", err).WithCode(customErrors.CodeGiftCardNotFound)
+		}
+		return nil, fmt.Errorf("failed to get gift card by code: %w", err)
+	}
+
+	return &card, nil
+}
+
+// UpdateGiftCard saves a card's status and expiry. The balance only ever
+// changes through transactions.
+func (r *paymentRepository) UpdateGiftCard(ctx context.Context, card *domain.GiftCard) error {
+	err := r.db.WithContext(ctx).
+		Model(card).
+		Select("status", "expires_at", "updated_at").
+		Updates(card).Error
+	if err != nil {
+		return fmt.Errorf("failed to update gift card: %w", err)
+	}
+	return nil
+}
+
+func (r *paymentRepository) ListGiftCards(ctx context.Context, filters *domain.GiftCardFilters) ([]domain.GiftCard, int64, error) {
+	query := r.db.WithContext(ctx).Model(&domain.GiftCard{})
+
+	if filters.PurchaserID != "" {
+		query = query.Where("purchaser_id = ?", filters.PurchaserID)
+	}
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count gift cards: %w", err)
+	}
+
+	var cards []domain.GiftCard
+	err := query.
+		Order("created_at DESC").
+		Limit(filters.Limit).
+		Offset(filters.Offset).
+		Find(&cards).Error
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list gift cards: %w", err)
+	}
+
+	return cards, total, nil
+}
+
+func (r *paymentRepository) RedeemGiftCard(ctx context.Context, req *domain.RedeemGiftCardRequest, actorID string, now time.Time) (*domain.GiftCardTransaction, error) {
+	var redemption domain.GiftCardTransaction
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the card so concurrent redemptions spend its balance in turn
+		var card domain.GiftCard
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&card, "code = ?", req.Code).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return customErrors.NewNotFoundError("Gift card not found", err).WithCode(customErrors.CodeGiftCardNotFound)
+			}
+			return fmt.Errorf("failed to get gift card: %w", err)
+		}
+
+		err = tx.Where("gift_card_id = ? AND type = ? AND reference = ?", card.ID, domain.GiftCardTransactionRedeem, req.Reference).
+			Take(&redemption).Error
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to get gift card redemption: %w", err)
+		}
+
+		if !card.UsableAt(now) {
+			return customErrors.NewConflictError("Gift card is disabled or has expired", nil).WithCode(customErrors.CodeGiftCardUnusable)
+		}
+		if card.Currency != req.Currency {
+			return customErrors.NewValidationError(fmt.Sprintf("Gift card is in %s", card.Currency), nil).WithCode(customErrors.CodeGiftCardCurrency)
+		}
+		amount := math.Min(card.Balance, req.Amount)
+		if amount <= 0 {
+			return customErrors.NewConflictError("Gift card has no balance left", nil).WithCode(customErrors.CodeGiftCardEmpty)
+		}
+
+		card.Balance = math.Round((card.Balance-amount)*100) / 100
+		if err := tx.Model(&card).Update("balance", card.Balance).Error; err != nil {
+			return fmt.Errorf("failed to debit gift card: %w", err)
+		}
+
+		redemption = domain.GiftCardTransaction{
+			GiftCardID:   card.ID,
+			Type:         domain.GiftCardTransactionRedeem,
+			Amount:       -amount,
+			BalanceAfter: card.Balance,
+			Reference:    req.Reference,
+			ActorID:      actorID,
+		}
+		if err := tx.Create(&redemption).Error; err != nil {
+			return fmt.Errorf("failed to record gift card redemption: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &redemption, nil
+}
+
+func (r *paymentRepository) VoidGiftCardRedemptions(ctx context.Context, reference, actorID string) ([]domain.GiftCardTransaction, error) {
+	var voids []domain.GiftCardTransaction
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var redemptions []domain.GiftCardTransaction
+		err := tx.Where("type = ? AND reference = ?", domain.GiftCardTransactionRedeem, reference).
+			Where("NOT EXISTS (SELECT 1 FROM gift_card_transactions v WHERE v.gift_card_id = gift_card_transactions.gift_card_id AND v.type = ? AND v.reference = gift_card_transactions.reference)", domain.GiftCardTransactionVoid).
+			Order("gift_card_id").
+			Find(&redemptions).Error
+		if err != nil {
+			return fmt.Errorf("failed to list gift card redemptions: %w", err)
+		}
+
+		for _, redemption := range redemptions {
+			var card domain.GiftCard
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&card, "id = ?", redemption.GiftCardID).Error; err != nil {
+				return fmt.Errorf("failed to get gift card: %w", err)
+			}
+
+			card.Balance = math.Round((card.Balance-redemption.Amount)*100) / 100
+			if err := tx.Model(&card).Update("balance", card.Balance).Error; err != nil {
+				return fmt.Errorf("failed to credit gift card: %w", err)
+			}
+
+			void := domain.GiftCardTransaction{
+				GiftCardID:   card.ID,
+				Type:         domain.GiftCardTransactionVoid,
+				Amount:       -redemption.Amount,
+				BalanceAfter: card.Balance,
+				Reference:    reference,
+				ActorID:      actorID,
+			}
+			if err := tx.Create(&void).Error; err != nil {
+				return fmt.Errorf("failed to record gift card void: %w", err)
+			}
+			voids = append(voids, void)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return voids, nil
+}
@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"ecommerce/internal/payment/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+func (r *paymentRepository) CreateEarnRule(ctx context.Context, rule *domain.EarnRule) error {
+	if err := r.db.WithContext(ctx).Create(rule).Error; err != nil {
+		return fmt.Errorf("failed to create earn rule: %w", err)
+	}
+	return nil
+}
+
+func (r *paymentRepository) GetEarnRule(ctx context.Context, id uuid.UUID) (*domain.EarnRule, error) {
+	var rule domain.EarnRule
+	err := r.db.WithContext(ctx).First(&rule, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Earn rule not found", err).WithCode(customErrors.CodeEarnRuleNotFound)
+		}
+		return nil, fmt.Errorf("failed to get earn rule: %w", err)
+	}
+
+	return &rule, nil
+}
+
+func (r *paymentRepository) UpdateEarnRule(ctx context.Context, rule *domain.EarnRule) error {
+	if err := r.db.WithContext(ctx).Save(rule).Error; err != nil {
+		return fmt.Errorf("failed to update earn rule: %w", err)
+	}
+	return nil
+}
+
+func (r *paymentRepository) DeleteEarnRule(ctx context.Context, id uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Delete(&domain.EarnRule{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete earn rule: %w", err)
+	}
+	return nil
+}
+
+func (r *paymentRepository) ListEarnRules(ctx context.Context) ([]domain.EarnRule, error) {
+	var rules []domain.EarnRule
+	if err := r.db.WithContext(ctx).Order("created_at ASC").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to list earn rules: %w", err)
+	}
+	return rules, nil
+}
+
+func (r *paymentRepository) GetLoyaltyAccount(ctx context.Context, customerID string) (*domain.LoyaltyAccount, error) {
+	var account domain.LoyaltyAccount
+	err := r.db.WithContext(ctx).First(&account, "customer_id = ?", customerID).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &domain.LoyaltyAccount{CustomerID: customerID}, nil
+		}
+		return nil, fmt.Errorf("failed to get loyalty account: %w", err)
+	}
+
+	return &account, nil
+}
+
+func (r *paymentRepository) EarnPoints(ctx context.Context, transaction *domain.LoyaltyTransaction) (bool, error) {
+	earned := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		account, err := lockLoyaltyAccount(tx, transaction.CustomerID)
+		if err != nil {
+			return err
+		}
+		if _, found, err := findLoyaltyTransaction(tx, transaction.CustomerID, transaction.Type, transaction.Reference); err != nil || found {
+			return err
+		}
+
+		account.Balance += transaction.Points
+		account.LifetimeEarned += transaction.Points
+		err = tx.Model(account).Updates(map[string]interface{}{
+			"balance":         account.Balance,
+			"lifetime_earned": account.LifetimeEarned,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to credit loyalty account: %w", err)
+		}
+
+		transaction.BalanceAfter = account.Balance
+		if err := tx.Create(transaction).Error; err != nil {
+			return fmt.Errorf("failed to record points earned: %w", err)
+		}
+		earned = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return earned, nil
+}
+
+func (r *paymentRepository) RedeemPoints(ctx context.Context, customerID, reference string, maxPoints int64, actorID string) (*domain.LoyaltyTransaction, error) {
+	var redemption *domain.LoyaltyTransaction
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		account, err := lockLoyaltyAccount(tx, customerID)
+		if err != nil {
+			return err
+		}
+		existing, found, err := findLoyaltyTransaction(tx, customerID, domain.LoyaltyTransactionRedeem, reference)
+		if err != nil {
+			return err
+		}
+		if found {
+			redemption = existing
+			return nil
+		}
+
+		points := min(account.Balance, maxPoints)
+		if points <= 0 {
+			return customErrors.NewConflictError("Not enough loyalty points", nil).WithCode(customErrors.CodeInsufficientPoints)
+		}
+
+		account.Balance -= points
+		if err := tx.Model(account).Update("balance", account.Balance).Error; err != nil {
+			return fmt.Errorf("failed to debit loyalty account: %w", err)
+		}
+
+		redemption = &domain.LoyaltyTransaction{
+			CustomerID:   customerID,
+			Type:         domain.LoyaltyTransactionRedeem,
+			Points:       -points,
+			BalanceAfter: account.Balance,
+			Reference:    reference,
+			ActorID:      actorID,
+		}
+		if err := tx.Create(redemption).Error; err != nil {
+			return fmt.Errorf("failed to record points redeemed: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return redemption, nil
+}
+
+func (r *paymentRepository) VoidPointsRedemption(ctx context.Context, customerID, reference, actorID string) (*domain.LoyaltyTransaction, error) {
+	var void *domain.LoyaltyTransaction
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		account, err := lockLoyaltyAccount(tx, customerID)
+		if err != nil {
+			return err
+		}
+		redemption, found, err := findLoyaltyTransaction(tx, customerID, domain.LoyaltyTransactionRedeem, reference)
+		if err != nil || !found {
+			return err
+		}
+		if _, found, err := findLoyaltyTransaction(tx, customerID, domain.LoyaltyTransactionVoid, reference); err != nil || found {
+			return err
+		}
+
+		account.Balance -= redemption.Points
+		if err := tx.Model(account).Update("balance", account.Balance).Error; err != nil {
+			return fmt.Errorf("failed to credit loyalty account: %w", err)
+		}
+
+		void = &domain.LoyaltyTransaction{
+			CustomerID:   customerID,
+			Type:         domain.LoyaltyTransactionVoid,
+			Points:       -redemption.Points,
+			BalanceAfter: account.Balance,
+			Reference:    reference,
+			ActorID:      actorID,
+		}
+		if err := tx.Create(void).Error; err != nil {
+			return fmt.Errorf("failed to record points voided: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return void, nil
+}
+
+func (r *paymentRepository) ListLoyaltyTransactions(ctx context.Context, filters *domain.LoyaltyTransactionFilters) ([]domain.LoyaltyTransaction, int64, error) {
+	query := r.db.WithContext(ctx).Model(&domain.LoyaltyTransaction{})
+
+	if filters.CustomerID != "" {
+		query = query.Where("customer_id = ?", filters.CustomerID)
+	}
+	if filters.Type != "" {
+		query = query.Where("type = ?", filters.Type)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count loyalty transactions: %w", err)
+	}
+
+	var transactions []domain.LoyaltyTransaction
+	err := query.
+		Order("created_at DESC").
+		Limit(filters.Limit).
+		Offset(filters.Offset).
+		Find(&transactions).Error
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list loyalty transactions: %w", err)
+	}
+
+	return transactions, total, nil
+}
+
+// lockLoyaltyAccount locks a customer's account, opening it first if need
+// be, so changes to the customer's points are made one at a time
+func lockLoyaltyAccount(tx *gorm.DB, customerID string) (*domain.LoyaltyAccount, error) {
+	err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&domain.LoyaltyAccount{CustomerID: customerID}).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to open loyalty account: %w", err)
+	}
+
+	var account domain.LoyaltyAccount
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&account, "customer_id = ?", customerID).Error; err != nil {
+		return nil, fmt.Errorf("failed to get loyalty account: %w", err)
+	}
+	return &account, nil
+}
+
+// findLoyaltyTransaction finds a customer's transaction of a type under a
+// reference
+func findLoyaltyTransaction(tx *gorm.DB, customerID, transactionType, reference string) (*domain.LoyaltyTransaction, bool, error) {
+	var transaction domain.LoyaltyTransaction
+	err := tx.Where("customer_id = ? AND type = ? AND reference = ?", customerID, transactionType, reference).
+		Take(&transaction).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to get loyalty transaction: %w", err)
+	}
+	return &transaction, true, nil
+}
@@ -0,0 +1,289 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"ecommerce/internal/payment/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+// PaymentRepository defines the interface for payment data operations
+type PaymentRepository interface {
+	Create(ctx context.Context, payment *domain.Payment) (bool, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Payment, error)
+	GetByReference(ctx context.Context, reference string) (*domain.Payment, error)
+	GetByProviderRef(ctx context.Context, providerRef string) (*domain.Payment, error)
+	Update(ctx context.Context, payment *domain.Payment, expectedStatus string) (bool, error)
+
+	ReserveRefund(ctx context.Context, refund *domain.Refund) error
+	UpdateRefund(ctx context.Context, refund *domain.Refund) error
+	ReleaseRefund(ctx context.Context, refund *domain.Refund) error
+	GetRefund(ctx context.Context, id uuid.UUID) (*domain.Refund, error)
+	GetRefundByProviderRef(ctx context.Context, providerRef string) (*domain.Refund, error)
+
+	// CompleteCheckout records that a checkout became an order. Recording
+	// it again does nothing.
+	CompleteCheckout(ctx context.Context, checkout *domain.CompletedCheckout) error
+	// IsCheckoutCompleted reports whether a checkout became an order
+	IsCheckoutCompleted(ctx context.Context, checkoutID uuid.UUID) (bool, error)
+
+	// IssueGiftCards creates gift cards with their issue transactions and
+	// reports how many it created. Cards already issued for the same order
+	// line are skipped, so issuing for an order twice creates nothing.
+	IssueGiftCards(ctx context.Context, cards []domain.GiftCard, actorID string) (int, error)
+	GetGiftCard(ctx context.Context, id uuid.UUID) (*domain.GiftCard, error)
+	GetGiftCardByCode(ctx context.Context, code string) (*domain.GiftCard, error)
+	UpdateGiftCard(ctx context.Context, card *domain.GiftCard) error
+	ListGiftCards(ctx context.Context, filters *domain.GiftCardFilters) ([]domain.GiftCard, int64, error)
+
+	// RedeemGiftCard debits a gift card by up to the request's amount under
+	// its reference. Redeeming a card again under the same reference returns
+	// the first redemption.
+	RedeemGiftCard(ctx context.Context, req *domain.RedeemGiftCardRequest, actorID string, now time.Time) (*domain.GiftCardTransaction, error)
+	// VoidGiftCardRedemptions credits back every redemption made under a
+	// reference that has not been voided yet and returns the void
+	// transactions
+	VoidGiftCardRedemptions(ctx context.Context, reference, actorID string) ([]domain.GiftCardTransaction, error)
+
+	CreateEarnRule(ctx context.Context, rule *domain.EarnRule) error
+	GetEarnRule(ctx context.Context, id uuid.UUID) (*domain.EarnRule, error)
+	UpdateEarnRule(ctx context.Context, rule *domain.EarnRule) error
+	DeleteEarnRule(ctx context.Context, id uuid.UUID) error
+	ListEarnRules(ctx context.Context) ([]domain.EarnRule, error)
+
+	// GetLoyaltyAccount returns a customer's points, which are zero for
+	// customers who have never earned any
+	GetLoyaltyAccount(ctx context.Context, customerID string) (*domain.LoyaltyAccount, error)
+	// EarnPoints credits a customer's points and reports whether it did;
+	// points already earned under the transaction's reference are not
+	// credited again
+	EarnPoints(ctx context.Context, transaction *domain.LoyaltyTransaction) (bool, error)
+	// RedeemPoints debits up to maxPoints of a customer's points under a
+	// reference. Redeeming again under the same reference returns the first
+	// redemption.
+	RedeemPoints(ctx context.Context, customerID, reference string, maxPoints int64, actorID string) (*domain.LoyaltyTransaction, error)
+	// VoidPointsRedemption credits back the points a customer redeemed under
+	// a reference, unless they already were, and returns the void
+	// transaction, or nil when there was nothing to void
+	VoidPointsRedemption(ctx context.Context, customerID, reference, actorID string) (*domain.LoyaltyTransaction, error)
+	ListLoyaltyTransactions(ctx context.Context, filters *domain.LoyaltyTransactionFilters) ([]domain.LoyaltyTransaction, int64, error)
+}
+
+type paymentRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+// NewPaymentRepository creates a new payment repository
+func NewPaymentRepository(db *gorm.DB, logger *logrus.Logger) PaymentRepository {
+	return &paymentRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Create records a payment unless one already exists for its reference, in
+// which case it reports false and creates nothing
+func (r *paymentRepository) Create(ctx context.Context, payment *domain.Payment) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "reference"}},
+			DoNothing: true,
+		}).
+		Create(payment)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to create payment: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *paymentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Payment, error) {
+	var payment domain.Payment
+	err := r.db.WithContext(ctx).
+		Preload("Refunds", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		First(&payment, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Payment not found", err).WithCode(customErrors.CodePaymentNotFound)
+		}
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+
+	return &payment, nil
+}
+
+func (r *paymentRepository) GetByReference(ctx context.Context, reference string) (*domain.Payment, error) {
+	var payment domain.Payment
+	err := r.db.WithContext(ctx).First(&payment, "reference = ?", reference).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Payment not found", err).WithCode(customErrors.CodePaymentNotFound)
+		}
+		return nil, fmt.Errorf("failed to get payment by reference: %w", err)
+	}
+
+	return &payment, nil
+}
+
+func (r *paymentRepository) GetByProviderRef(ctx context.Context, providerRef string) (*domain.Payment, error) {
+	var payment domain.Payment
+	err := r.db.WithContext(ctx).First(&payment, "provider_ref = ?", providerRef).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Payment not found", err).WithCode(customErrors.CodePaymentNotFound)
+		}
+		return nil, fmt.Errorf("failed to get payment by provider reference: %w", err)
+	}
+
+	return &payment, nil
+}
+
+// Update persists a payment only if the stored payment is still in
+// expectedStatus. It reports false when a concurrent request or webhook has
+// already moved the payment on. amount_refunded is owned by the refund
+// methods and is never written from here.
+func (r *paymentRepository) Update(ctx context.Context, payment *domain.Payment, expectedStatus string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(payment).
+		Where("status = ?", expectedStatus).
+		Select("*").
+		Omit("id", "reference", "amount_refunded", "created_at", clause.Associations).
+		Updates(payment)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to update payment: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ReserveRefund records a pending refund and counts it against the captured
+// amount in one transaction. The increment is guarded in SQL so concurrent
+// refunds cannot return more than was captured.
+func (r *paymentRepository) ReserveRefund(ctx context.Context, refund *domain.Refund) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.Payment{}).
+			Where("id = ? AND status = ? AND amount_refunded + ? <= amount_captured", refund.PaymentID, domain.StatusCaptured, refund.Amount).
+			Update("amount_refunded", gorm.Expr("amount_refunded + ?", refund.Amount))
+		if result.Error != nil {
+			return fmt.Errorf("failed to reserve refund: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return customErrors.NewConflictError("Refund exceeds the amount left to refund", nil).WithCode(customErrors.CodeRefundExceedsAmount)
+		}
+
+		if err := tx.Create(refund).Error; err != nil {
+			return fmt.Errorf("failed to create refund: %w", err)
+		}
+		return nil
+	})
+}
+
+// UpdateRefund records the provider's outcome for a pending refund. Refunds
+// that have already settled are left as they are.
+func (r *paymentRepository) UpdateRefund(ctx context.Context, refund *domain.Refund) error {
+	err := r.db.WithContext(ctx).
+		Model(refund).
+		Where("status = ?", domain.RefundStatusPending).
+		Select("provider_ref", "status", "updated_at").
+		Updates(refund).Error
+	if err != nil {
+		return fmt.Errorf("failed to update refund: %w", err)
+	}
+	return nil
+}
+
+// ReleaseRefund marks a refund failed and returns its amount to the payment
+// so it can be refunded again. A payment that the refund had fully refunded
+// goes back to captured. Releasing a refund that already failed is a no-op.
+func (r *paymentRepository) ReleaseRefund(ctx context.Context, refund *domain.Refund) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(refund).
+			Where("status <> ?", domain.RefundStatusFailed).
+			Updates(map[string]interface{}{
+				"provider_ref": refund.ProviderRef,
+				"status":       domain.RefundStatusFailed,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to fail refund: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		refund.Status = domain.RefundStatusFailed
+
+		err := tx.Model(&domain.Payment{}).
+			Where("id = ?", refund.PaymentID).
+			Updates(map[string]interface{}{
+				"amount_refunded": gorm.Expr("amount_refunded - ?", refund.Amount),
+				"status": gorm.Expr("CASE WHEN status = ? THEN ? ELSE status END",
+					domain.StatusRefunded, domain.StatusCaptured),
+			}).Error
+		if err != nil {
+			return fmt.Errorf("failed to release refund: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *paymentRepository) GetRefund(ctx context.Context, id uuid.UUID) (*domain.Refund, error) {
+	var refund domain.Refund
+	err := r.db.WithContext(ctx).First(&refund, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Refund not found", err).WithCode(customErrors.CodeRefundNotFound)
+		}
+		return nil, fmt.Errorf("failed to get refund: %w", err)
+	}
+
+	return &refund, nil
+}
+
+func (r *paymentRepository) GetRefundByProviderRef(ctx context.Context, providerRef string) (*domain.Refund, error) {
+	var refund domain.Refund
+	err := r.db.WithContext(ctx).First(&refund, "provider_ref = ?", providerRef).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Refund not found", err).WithCode(customErrors.CodeRefundNotFound)
+		}
+		return nil, fmt.Errorf("failed to get refund by provider reference: %w", err)
+	}
+
+	return &refund, nil
+}
+
+// CompleteCheckout records a completed checkout unless it already is
+func (r *paymentRepository) CompleteCheckout(ctx context.Context, checkout *domain.CompletedCheckout) error {
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(checkout).Error
+	if err != nil {
+		return fmt.Errorf("failed to record completed checkout: %w", err)
+	}
+	return nil
+}
+
+func (r *paymentRepository) IsCheckoutCompleted(ctx context.Context, checkoutID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&domain.CompletedCheckout{}).
+		Where("checkout_id = ?", checkoutID).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check checkout completion: %w", err)
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,106 @@
+package service_test
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/payment/config"
+	"ecommerce/internal/payment/domain"
+	"ecommerce/internal/payment/repository"
+	"ecommerce/internal/payment/service"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+)
+
+// TestMoneyMovementRequiresAdmin checks that signed-in callers who are not
+// admins cannot void, capture or refund payments. Only admins and other
+// services, whose calls carry no actor, may.
+func TestMoneyMovementRequiresAdmin(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	// The caller is refused before the repository is used
+	s := service.NewPaymentService(nil, nil, config.LoyaltyConfig{PointsPerUnit: 100}, logger)
+
+	id := uuid.New()
+	changes := map[string]func(ctx context.Context) error{
+		"void": func(ctx context.Context) error {
+			_, err := s.Void(ctx, &domain.VoidPaymentRequest{Reference: "checkout-1-1"})
+			return err
+		},
+		"capture": func(ctx context.Context) error {
+			_, err := s.Capture(ctx, id, &domain.CapturePaymentRequest{})
+			return err
+		},
+		"refund": func(ctx context.Context) error {
+			_, err := s.Refund(ctx, id, &domain.RefundPaymentRequest{})
+			return err
+		},
+		"void gift cards": func(ctx context.Context) error {
+			_, err := s.VoidGiftCards(ctx, &domain.VoidGiftCardsRequest{Reference: "checkout-1-1"})
+			return err
+		},
+		"void own points": func(ctx context.Context) error {
+			_, err := s.VoidPoints(ctx, &domain.VoidPointsRequest{CustomerID: auth.ActorID(ctx), Reference: "checkout-1-1"})
+			return err
+		},
+	}
+
+	callers := map[string]context.Context{
+		"customer": auth.WithActor(context.Background(), &auth.Actor{ID: "customer-1", Role: "customer"}),
+		"vendor":   auth.WithActor(context.Background(), &auth.Actor{ID: "vendor-1", Role: auth.RoleVendor}),
+	}
+	for caller, ctx := range callers {
+		for change, fn := range changes {
+			t.Run(caller+"/"+change, func(t *testing.T) {
+				if err := fn(ctx); !errors.IsForbidden(err) {
+					t.Fatalf("expected forbidden, got %v", err)
+				}
+			})
+		}
+	}
+}
+
+// completedCheckouts is a repository that only knows which checkouts have
+// completed. Any other call panics on the nil embedded repository.
+type completedCheckouts struct {
+	repository.PaymentRepository
+	completed map[uuid.UUID]bool
+}
+
+func (r completedCheckouts) IsCheckoutCompleted(ctx context.Context, checkoutID uuid.UUID) (bool, error) {
+	return r.completed[checkoutID], nil
+}
+
+// TestCompletedCheckoutsAreNotVoided checks that what a completed checkout
+// redeemed stays spent, even when checkout asks for it back
+func TestCompletedCheckoutsAreNotVoided(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	checkoutID := uuid.New()
+	repo := completedCheckouts{completed: map[uuid.UUID]bool{checkoutID: true}}
+	s := service.NewPaymentService(repo, nil, config.LoyaltyConfig{PointsPerUnit: 100}, logger)
+
+	reference := "checkout-" + checkoutID.String() + "-2"
+	callers := map[string]context.Context{
+		"internal": context.Background(),
+		"admin":    auth.WithActor(context.Background(), &auth.Actor{ID: "admin-1", Role: auth.RoleAdmin}),
+	}
+	for caller, ctx := range callers {
+		t.Run(caller, func(t *testing.T) {
+			_, err := s.VoidGiftCards(ctx, &domain.VoidGiftCardsRequest{Reference: reference})
+			if !errors.IsConflict(err) {
+				t.Fatalf("gift cards: expected conflict, got %v", err)
+			}
+			_, err = s.VoidPoints(ctx, &domain.VoidPointsRequest{CustomerID: "customer-1", Reference: reference})
+			if !errors.IsConflict(err) {
+				t.Fatalf("points: expected conflict, got %v", err)
+			}
+		})
+	}
+}
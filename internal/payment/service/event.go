@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/payment/domain"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/events"
+)
+
+// eventOrderCreated is the event the order service publishes for every
+// order it creates
+const eventOrderCreated = "order.created"
+
+// HandleEvent handles an event forwarded by another service. Each order the
+// order service creates completes its checkout, has its gift cards issued
+// and earns its customer loyalty points. Events are delivered at least
+// once, so a repeated event does nothing.
+func (s *paymentService) HandleEvent(ctx context.Context, event *events.Event) (*domain.EventOutcome, error) {
+	if event.ID == "" || event.Type == "" {
+		return nil, errors.NewValidationError("Event ID and type are required", nil)
+	}
+	outcome := &domain.EventOutcome{}
+	if event.Type != eventOrderCreated {
+		return outcome, nil
+	}
+
+	var order domain.OrderEvent
+	if err := event.Decode(&order); err != nil {
+		return nil, errors.NewValidationError("Invalid event payload", err)
+	}
+
+	// Stop voids of what the checkout redeemed before anything else
+	if order.CheckoutID != uuid.Nil {
+		if err := s.repo.CompleteCheckout(ctx, &domain.CompletedCheckout{CheckoutID: order.CheckoutID, OrderID: order.ID}); err != nil {
+			s.log(ctx).WithError(err).WithField("order_id", order.ID).Error("Failed to record completed checkout")
+			return nil, errors.NewInternalError("Failed to record completed checkout", err)
+		}
+	}
+
+	issued, err := s.issueGiftCards(ctx, &order)
+	if err != nil {
+		return nil, err
+	}
+	outcome.GiftCardsIssued = issued
+
+	earned, err := s.earnPoints(ctx, &order, event.OccurredAt)
+	if err != nil {
+		return nil, err
+	}
+	outcome.PointsEarned = earned
+
+	return outcome, nil
+}
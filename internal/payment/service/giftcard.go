@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/payment/domain"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+)
+
+// IssueGiftCard issues a gift card outside of an order, for example as a
+// goodwill gesture
+func (s *paymentService) IssueGiftCard(ctx context.Context, req *domain.IssueGiftCardRequest) (*domain.GiftCard, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Issuing gift cards requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid issue gift card request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	card, err := newGiftCard(round(req.Amount), req.Currency)
+	if err != nil {
+		return nil, errors.NewInternalError("Failed to issue gift card", err)
+	}
+	card.PurchaserID = req.PurchaserID
+	card.ExpiresAt = req.ExpiresAt
+
+	cards := []domain.GiftCard{*card}
+	if _, err := s.repo.IssueGiftCards(ctx, cards, auth.ActorID(ctx)); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to issue gift card")
+		return nil, errors.NewInternalError("Failed to issue gift card", err)
+	}
+
+	s.log(ctx).WithField("gift_card_id", cards[0].ID).Info("Gift card issued successfully")
+	return &cards[0], nil
+}
+
+// GetGiftCard returns a gift card with its transaction log. Customers see
+// the cards they bought.
+func (s *paymentService) GetGiftCard(ctx context.Context, id uuid.UUID) (*domain.GiftCard, error) {
+	actor := auth.ActorFromContext(ctx)
+	if actor == nil {
+		return nil, errors.NewUnauthorizedError("Authentication required to view gift cards", nil).WithCode(errors.CodeAuthenticationRequired)
+	}
+
+	card, err := s.repo.GetGiftCard(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, err
+		}
+		s.log(ctx).WithError(err).Error("Failed to get gift card")
+		return nil, errors.NewInternalError("Failed to get gift card", err)
+	}
+
+	if card.PurchaserID != actor.ID && actor.Role != auth.RoleAdmin {
+		return nil, errors.NewNotFoundError("Gift card not found", nil).WithCode(errors.CodeGiftCardNotFound)
+	}
+
+	return card, nil
+}
+
+// UpdateGiftCard disables, re-enables or changes the expiry of a gift card
+func (s *paymentService) UpdateGiftCard(ctx context.Context, id uuid.UUID, req *domain.UpdateGiftCardRequest) (*domain.GiftCard, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing gift cards requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid update gift card request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	card, err := s.GetGiftCard(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update fields
+	if req.Status != nil {
+		card.Status = *req.Status
+	}
+	if req.ExpiresAt != nil {
+		card.ExpiresAt = req.ExpiresAt
+	}
+
+	if err := s.repo.UpdateGiftCard(ctx, card); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to update gift card")
+		return nil, errors.NewInternalError("Failed to update gift card", err)
+	}
+
+	s.log(ctx).WithField("gift_card_id", card.ID).Info("Gift card updated successfully")
+	return card, nil
+}
+
+// ListGiftCards lists gift cards. Customers only see the cards they bought.
+func (s *paymentService) ListGiftCards(ctx context.Context, filters *domain.GiftCardFilters) (*domain.GiftCardList, error) {
+	actor := auth.ActorFromContext(ctx)
+	if actor == nil {
+		return nil, errors.NewUnauthorizedError("Authentication required to view gift cards", nil).WithCode(errors.CodeAuthenticationRequired)
+	}
+	if actor.Role != auth.RoleAdmin {
+		filters.PurchaserID = actor.ID
+	}
+
+	// Set default values
+	if filters.Limit <= 0 {
+		filters.Limit = 20
+	}
+	if filters.Limit > 100 {
+		filters.Limit = 100
+	}
+
+	cards, total, err := s.repo.ListGiftCards(ctx, filters)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list gift cards")
+		return nil, errors.NewInternalError("Failed to list gift cards", err)
+	}
+
+	return &domain.GiftCardList{
+		GiftCards: cards,
+		Total:     total,
+		Limit:     filters.Limit,
+		Offset:    filters.Offset,
+		HasMore:   int64(filters.Offset+filters.Limit) < total,
+	}, nil
+}
+
+// GiftCardBalance reports what is left on the gift card with a code.
+// Knowing the code is enough, as it is to spend the card.
+func (s *paymentService) GiftCardBalance(ctx context.Context, req *domain.GiftCardBalanceRequest) (*domain.GiftCardBalance, error) {
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	card, err := s.repo.GetGiftCardByCode(ctx, domain.NormalizeGiftCardCode(req.Code))
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, err
+		}
+		s.log(ctx).WithError(err).Error("Failed to get gift card")
+		return nil, errors.NewInternalError("Failed to get gift card", err)
+	}
+
+	status := card.Status
+	if status == domain.GiftCardStatusActive && !card.UsableAt(time.Now()) {
+		status = "expired"
+	}
+	return &domain.GiftCardBalance{
+		Code:      card.Code,
+		Balance:   card.Balance,
+		Currency:  card.Currency,
+		Status:    status,
+		ExpiresAt: card.ExpiresAt,
+	}, nil
+}
+
+// RedeemGiftCard spends a gift card's balance, up to the amount asked for.
+// Holding the code is what entitles a caller to spend the card. Checkout
+// redeems each card under its attempt's reference, so a retried call
+// returns the first redemption instead of spending the card twice.
+func (s *paymentService) RedeemGiftCard(ctx context.Context, req *domain.RedeemGiftCardRequest) (*domain.GiftCardRedemption, error) {
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid redeem gift card request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+	req.Code = domain.NormalizeGiftCardCode(req.Code)
+	req.Currency = strings.ToLower(req.Currency)
+	req.Amount = round(req.Amount)
+
+	redemption, err := s.repo.RedeemGiftCard(ctx, req, auth.ActorID(ctx), time.Now())
+	if err != nil {
+		if errors.IsNotFound(err) || errors.IsConflict(err) || errors.IsValidation(err) {
+			return nil, err
+		}
+		s.log(ctx).WithError(err).Error("Failed to redeem gift card")
+		return nil, errors.NewInternalError("Failed to redeem gift card", err)
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"gift_card_id": redemption.GiftCardID,
+		"reference":    redemption.Reference,
+	}).Info("Gift card redeemed successfully")
+	return &domain.GiftCardRedemption{
+		GiftCardID: redemption.GiftCardID,
+		Reference:  redemption.Reference,
+		Amount:     -redemption.Amount,
+		Balance:    redemption.BalanceAfter,
+	}, nil
+}
+
+// VoidGiftCards returns everything redeemed under a reference to the cards
+// it came from. Voiding again does nothing, so compensation can retry.
+// Redemptions of a checkout that has completed paid for its order and are
+// not voided.
+func (s *paymentService) VoidGiftCards(ctx context.Context, req *domain.VoidGiftCardsRequest) ([]domain.GiftCardTransaction, error) {
+	if err := checkInternal(ctx, "Voiding gift card redemptions requires the admin role"); err != nil {
+		return nil, err
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid void gift cards request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+	if err := s.checkVoidable(ctx, req.Reference); err != nil {
+		return nil, err
+	}
+
+	voids, err := s.repo.VoidGiftCardRedemptions(ctx, req.Reference, auth.ActorID(ctx))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to void gift card redemptions")
+		return nil, errors.NewInternalError("Failed to void gift card redemptions", err)
+	}
+
+	if len(voids) > 0 {
+		s.log(ctx).WithFields(logrus.Fields{
+			"reference": req.Reference,
+			"voided":    len(voids),
+		}).Info("Gift card redemptions voided successfully")
+	}
+	return voids, nil
+}
+
+// issueGiftCards issues every gift card an order bought, at the price paid
+// for it, and reports how many it issued. Cards are numbered within their
+// order line, so issuing for an order again issues nothing.
+func (s *paymentService) issueGiftCards(ctx context.Context, order *domain.OrderEvent) (int, error) {
+	var cards []domain.GiftCard
+	for _, item := range order.Items {
+		if item.Type != domain.ProductTypeGiftCard {
+			continue
+		}
+		for serial := 0; serial < item.Quantity; serial++ {
+			card, err := newGiftCard(round(item.UnitPrice), order.Currency)
+			if err != nil {
+				return 0, errors.NewInternalError("Failed to issue gift card", err)
+			}
+			card.PurchaserID = order.CustomerID
+			card.OrderID = &order.ID
+			card.ProductID = &item.ProductID
+			card.Serial = serial
+			cards = append(cards, *card)
+		}
+	}
+	if len(cards) == 0 {
+		return 0, nil
+	}
+
+	issued, err := s.repo.IssueGiftCards(ctx, cards, "")
+	if err != nil {
+		s.log(ctx).WithError(err).WithField("order_id", order.ID).Error("Failed to issue gift cards")
+		return 0, errors.NewInternalError("Failed to issue gift cards", err)
+	}
+
+	if issued > 0 {
+		s.log(ctx).WithFields(logrus.Fields{
+			"order_id": order.ID,
+			"issued":   issued,
+		}).Info("Gift cards issued successfully")
+	}
+	return issued, nil
+}
+
+// newGiftCard returns an active gift card holding amount under a new code
+func newGiftCard(amount float64, currency string) (*domain.GiftCard, error) {
+	code, err := newGiftCardCode()
+	if err != nil {
+		return nil, err
+	}
+	return &domain.GiftCard{
+		ID:             uuid.New(),
+		Code:           code,
+		Currency:       strings.ToLower(currency),
+		InitialBalance: amount,
+		Balance:        amount,
+		Status:         domain.GiftCardStatusActive,
+	}, nil
+}
+
+// newGiftCardCode returns a random gift card code in four groups of four
+// characters, carrying 80 bits of randomness
+func newGiftCardCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate gift card code: %w", err)
+	}
+	return domain.NormalizeGiftCardCode(base32.StdEncoding.EncodeToString(b)), nil
+}
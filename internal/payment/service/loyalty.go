@@ -0,0 +1,337 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/payment/domain"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+)
+
+func (s *paymentService) CreateEarnRule(ctx context.Context, req *domain.CreateEarnRuleRequest) (*domain.EarnRule, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing earn rules requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid create earn rule request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	rule := &domain.EarnRule{
+		Name:          req.Name,
+		ProductType:   req.ProductType,
+		PointsPerUnit: req.PointsPerUnit,
+		MinOrderTotal: round(req.MinOrderTotal),
+		Active:        req.Active == nil || *req.Active,
+		StartsAt:      req.StartsAt,
+		EndsAt:        req.EndsAt,
+	}
+	if err := validateEarnRule(rule); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateEarnRule(ctx, rule); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to create earn rule")
+		return nil, errors.NewInternalError("Failed to create earn rule", err)
+	}
+
+	s.log(ctx).WithField("earn_rule_id", rule.ID).Info("Earn rule created successfully")
+	return rule, nil
+}
+
+func (s *paymentService) GetEarnRule(ctx context.Context, id uuid.UUID) (*domain.EarnRule, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing earn rules requires the admin role", nil)
+	}
+
+	rule, err := s.repo.GetEarnRule(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, err
+		}
+		s.log(ctx).WithError(err).Error("Failed to get earn rule")
+		return nil, errors.NewInternalError("Failed to get earn rule", err)
+	}
+
+	return rule, nil
+}
+
+func (s *paymentService) UpdateEarnRule(ctx context.Context, id uuid.UUID, req *domain.UpdateEarnRuleRequest) (*domain.EarnRule, error) {
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid update earn rule request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	rule, err := s.GetEarnRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update fields
+	if req.Name != nil {
+		rule.Name = *req.Name
+	}
+	if req.PointsPerUnit != nil {
+		rule.PointsPerUnit = *req.PointsPerUnit
+	}
+	if req.MinOrderTotal != nil {
+		rule.MinOrderTotal = round(*req.MinOrderTotal)
+	}
+	if req.Active != nil {
+		rule.Active = *req.Active
+	}
+	if req.StartsAt != nil {
+		rule.StartsAt = req.StartsAt
+	}
+	if req.EndsAt != nil {
+		rule.EndsAt = req.EndsAt
+	}
+	if err := validateEarnRule(rule); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateEarnRule(ctx, rule); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to update earn rule")
+		return nil, errors.NewInternalError("Failed to update earn rule", err)
+	}
+
+	s.log(ctx).WithField("earn_rule_id", rule.ID).Info("Earn rule updated successfully")
+	return rule, nil
+}
+
+func (s *paymentService) DeleteEarnRule(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.GetEarnRule(ctx, id); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteEarnRule(ctx, id); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to delete earn rule")
+		return errors.NewInternalError("Failed to delete earn rule", err)
+	}
+
+	s.log(ctx).WithField("earn_rule_id", id).Info("Earn rule deleted successfully")
+	return nil
+}
+
+func (s *paymentService) ListEarnRules(ctx context.Context) ([]domain.EarnRule, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing earn rules requires the admin role", nil)
+	}
+
+	rules, err := s.repo.ListEarnRules(ctx)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list earn rules")
+		return nil, errors.NewInternalError("Failed to list earn rules", err)
+	}
+
+	return rules, nil
+}
+
+// GetLoyaltyAccount returns a customer's points. Customers see their own;
+// admins may name anyone's.
+func (s *paymentService) GetLoyaltyAccount(ctx context.Context, customerID string) (*domain.LoyaltyAccount, error) {
+	customerID, err := loyaltyCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.repo.GetLoyaltyAccount(ctx, customerID)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to get loyalty account")
+		return nil, errors.NewInternalError("Failed to get loyalty account", err)
+	}
+
+	return account, nil
+}
+
+// ListPointsHistory lists the transactions on a customer's points, newest
+// first
+func (s *paymentService) ListPointsHistory(ctx context.Context, filters *domain.LoyaltyTransactionFilters) (*domain.LoyaltyTransactionList, error) {
+	customerID, err := loyaltyCustomer(ctx, filters.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	filters.CustomerID = customerID
+
+	// Set default values
+	if filters.Limit <= 0 {
+		filters.Limit = 20
+	}
+	if filters.Limit > 100 {
+		filters.Limit = 100
+	}
+
+	transactions, total, err := s.repo.ListLoyaltyTransactions(ctx, filters)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list loyalty transactions")
+		return nil, errors.NewInternalError("Failed to list points history", err)
+	}
+
+	return &domain.LoyaltyTransactionList{
+		Transactions: transactions,
+		Total:        total,
+		Limit:        filters.Limit,
+		Offset:       filters.Offset,
+		HasMore:      int64(filters.Offset+filters.Limit) < total,
+	}, nil
+}
+
+// RedeemPoints pays for up to the requested amount with a customer's
+// points, spending no more than the customer agreed to. Checkout redeems
+// under its attempt's reference, so a retried call returns the first
+// redemption instead of spending the points twice. Customers may only
+// spend their own points.
+func (s *paymentService) RedeemPoints(ctx context.Context, req *domain.RedeemPointsRequest) (*domain.PointsRedemption, error) {
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid redeem points request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+	if err := s.checkPointsOwner(ctx, req.CustomerID); err != nil {
+		return nil, err
+	}
+
+	// Points are spent in whole, so the last one may pay a little over
+	amount := round(req.Amount)
+	needed := int64(math.Ceil(amount * float64(s.loyalty.PointsPerUnit)))
+	redemption, err := s.repo.RedeemPoints(ctx, req.CustomerID, req.Reference, min(req.Points, needed), auth.ActorID(ctx))
+	if err != nil {
+		if errors.IsConflict(err) {
+			return nil, err
+		}
+		s.log(ctx).WithError(err).Error("Failed to redeem loyalty points")
+		return nil, errors.NewInternalError("Failed to redeem loyalty points", err)
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"customer_id": req.CustomerID,
+		"reference":   req.Reference,
+		"points":      -redemption.Points,
+	}).Info("Loyalty points redeemed successfully")
+	return &domain.PointsRedemption{
+		Reference: redemption.Reference,
+		Points:    -redemption.Points,
+		Amount:    math.Min(amount, s.pointsValue(-redemption.Points)),
+		Balance:   redemption.BalanceAfter,
+	}, nil
+}
+
+// VoidPoints gives back the points a customer redeemed under a reference.
+// Voiding again does nothing, so compensation can retry. Points a completed
+// checkout redeemed paid for its order and are not given back.
+func (s *paymentService) VoidPoints(ctx context.Context, req *domain.VoidPointsRequest) (*domain.LoyaltyTransaction, error) {
+	if err := checkInternal(ctx, "Voiding loyalty points requires the admin role"); err != nil {
+		return nil, err
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid void points request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+	if err := s.checkVoidable(ctx, req.Reference); err != nil {
+		return nil, err
+	}
+
+	void, err := s.repo.VoidPointsRedemption(ctx, req.CustomerID, req.Reference, auth.ActorID(ctx))
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to void loyalty points")
+		return nil, errors.NewInternalError("Failed to void loyalty points", err)
+	}
+
+	if void != nil {
+		s.log(ctx).WithFields(logrus.Fields{
+			"customer_id": req.CustomerID,
+			"reference":   req.Reference,
+		}).Info("Loyalty points voided successfully")
+	}
+	return void, nil
+}
+
+// earnPoints credits a customer with the points an order earns under the
+// earn rules in force when it was placed, and reports how many
+func (s *paymentService) earnPoints(ctx context.Context, order *domain.OrderEvent, placedAt time.Time) (int64, error) {
+	if order.CustomerID == "" || order.Guest {
+		return 0, nil
+	}
+
+	rules, err := s.repo.ListEarnRules(ctx)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list earn rules")
+		return 0, errors.NewInternalError("Failed to list earn rules", err)
+	}
+	if placedAt.IsZero() {
+		placedAt = time.Now()
+	}
+	points := domain.EarnedPoints(rules, order, placedAt)
+	if points <= 0 {
+		return 0, nil
+	}
+
+	earned, err := s.repo.EarnPoints(ctx, &domain.LoyaltyTransaction{
+		CustomerID:  order.CustomerID,
+		Type:        domain.LoyaltyTransactionEarn,
+		Points:      points,
+		Reference:   order.ID.String(),
+		Description: fmt.Sprintf("Order %s", order.ID),
+	})
+	if err != nil {
+		s.log(ctx).WithError(err).WithField("order_id", order.ID).Error("Failed to earn loyalty points")
+		return 0, errors.NewInternalError("Failed to earn loyalty points", err)
+	}
+	if !earned {
+		return 0, nil
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"order_id": order.ID,
+		"points":   points,
+	}).Info("Loyalty points earned successfully")
+	return points, nil
+}
+
+// pointsValue is what a number of points pays
+func (s *paymentService) pointsValue(points int64) float64 {
+	return round(float64(points) / float64(s.loyalty.PointsPerUnit))
+}
+
+// checkPointsOwner lets customers spend only their own points. Calls
+// without an actor come from other services inside the cluster, such as
+// checkout, which spend points on the customer's behalf.
+func (s *paymentService) checkPointsOwner(ctx context.Context, customerID string) error {
+	actor := auth.ActorFromContext(ctx)
+	if actor != nil && actor.ID != customerID && actor.Role != auth.RoleAdmin {
+		return errors.NewForbiddenError("Customers can only spend their own points", nil)
+	}
+	return nil
+}
+
+// loyaltyCustomer resolves whose points a request is about: the caller's
+// own, or for admins, whichever customer they name
+func loyaltyCustomer(ctx context.Context, customerID string) (string, error) {
+	actor := auth.ActorFromContext(ctx)
+	if actor == nil {
+		return "", errors.NewUnauthorizedError("Authentication required to view loyalty points", nil).WithCode(errors.CodeAuthenticationRequired)
+	}
+	if actor.Role != auth.RoleAdmin || customerID == "" {
+		return actor.ID, nil
+	}
+	return customerID, nil
+}
+
+// validateEarnRule checks the parts of a rule that span fields
+func validateEarnRule(rule *domain.EarnRule) error {
+	if rule.StartsAt != nil && rule.EndsAt != nil && !rule.EndsAt.After(*rule.StartsAt) {
+		return errors.NewValidationError("An earn rule must end after it starts", nil)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/payment/domain"
+	"ecommerce/internal/payment/provider"
+	"ecommerce/pkg/errors"
+)
+
+// Refund returns part or all of a captured payment. The amount is reserved
+// against the payment before the provider is called, so concurrent refunds
+// can never return more than was captured. A refund the provider declines is
+// returned with a failed status and its amount becomes refundable again.
+func (s *paymentService) Refund(ctx context.Context, id uuid.UUID, req *domain.RefundPaymentRequest) (*domain.Refund, error) {
+	if err := checkInternal(ctx, "Refunding payments requires the admin role"); err != nil {
+		return nil, err
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid refund request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	payment, err := s.getPayment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != domain.StatusCaptured {
+		return nil, errors.NewConflictError("Only captured payments can be refunded", nil).WithCode(errors.CodePaymentNotCaptured)
+	}
+
+	amount := round(req.Amount)
+	if amount == 0 {
+		amount = round(payment.AmountCaptured - payment.AmountRefunded)
+	}
+	if amount <= 0 {
+		return nil, errors.NewConflictError("Payment has already been fully refunded", nil).WithCode(errors.CodePaymentAlreadyRefunded)
+	}
+
+	refund := &domain.Refund{
+		ID:        uuid.New(),
+		PaymentID: payment.ID,
+		Amount:    amount,
+		Reason:    req.Reason,
+		Status:    domain.RefundStatusPending,
+	}
+	if err := s.repo.ReserveRefund(ctx, refund); err != nil {
+		if errors.IsConflict(err) {
+			return nil, err
+		}
+		s.log(ctx).WithError(err).WithField("payment_id", payment.ID).Error("Failed to reserve refund")
+		return nil, errors.NewInternalError("Failed to create refund", err)
+	}
+
+	result, err := s.provider.Refund(ctx, &provider.RefundRequest{
+		IdempotencyKey: refund.ID.String(),
+		ProviderRef:    payment.ProviderRef,
+		Amount:         refund.Amount,
+		Reason:         refund.Reason,
+	})
+	if err != nil {
+		s.log(ctx).WithError(err).WithField("refund_id", refund.ID).Error("Payment provider refund failed")
+		if errors.IsUnavailable(err) {
+			// The provider may still have refunded; the refund stays pending
+			// and its webhook settles it
+			return nil, err
+		}
+		s.releaseRefund(ctx, refund)
+		return nil, providerError("Failed to refund payment", err)
+	}
+
+	refund.ProviderRef = result.ProviderRef
+	refund.Status = result.Status
+	if err := s.settleRefund(ctx, refund); err != nil {
+		return nil, err
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"payment_id": payment.ID,
+		"refund_id":  refund.ID,
+		"status":     refund.Status,
+	}).Info("Refund processed successfully")
+	return refund, nil
+}
+
+// settleRefund records a refund's outcome. A failed refund is released; a
+// successful one that brings the payment's refunds up to the captured amount
+// marks the payment refunded.
+func (s *paymentService) settleRefund(ctx context.Context, refund *domain.Refund) error {
+	if refund.Status == domain.RefundStatusFailed {
+		s.releaseRefund(ctx, refund)
+		return nil
+	}
+
+	if err := s.repo.UpdateRefund(ctx, refund); err != nil {
+		s.log(ctx).WithError(err).WithField("refund_id", refund.ID).Error("Failed to update refund")
+		return errors.NewInternalError("Failed to update refund", err)
+	}
+	if refund.Status != domain.RefundStatusSucceeded {
+		return nil
+	}
+
+	payment, err := s.getPayment(ctx, refund.PaymentID)
+	if err != nil {
+		return err
+	}
+	if payment.Status != domain.StatusCaptured || round(payment.AmountRefunded) < round(payment.AmountCaptured) {
+		return nil
+	}
+	for _, other := range payment.Refunds {
+		if other.Status == domain.RefundStatusPending {
+			return nil
+		}
+	}
+
+	_, err = s.apply(ctx, payment, &provider.Result{Status: domain.StatusRefunded})
+	return err
+}
+
+// releaseRefund fails a refund and makes its amount refundable again. It
+// runs even if the caller has gone away so no amount stays reserved.
+func (s *paymentService) releaseRefund(ctx context.Context, refund *domain.Refund) {
+	if err := s.repo.ReleaseRefund(context.WithoutCancel(ctx), refund); err != nil {
+		s.log(ctx).WithError(err).WithField("refund_id", refund.ID).Error("Failed to release refund")
+	}
+}
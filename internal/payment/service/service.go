@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/payment/config"
+	"ecommerce/internal/payment/domain"
+	"ecommerce/internal/payment/provider"
+	"ecommerce/internal/payment/repository"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/events"
+	"ecommerce/pkg/logger"
+	"ecommerce/pkg/validator"
+)
+
+// PaymentService defines the payment service interface
+type PaymentService interface {
+	Authorize(ctx context.Context, req *domain.AuthorizePaymentRequest) (*domain.Payment, bool, error)
+	Void(ctx context.Context, req *domain.VoidPaymentRequest) (*domain.Payment, error)
+	Capture(ctx context.Context, id uuid.UUID, req *domain.CapturePaymentRequest) (*domain.Payment, error)
+	Refund(ctx context.Context, id uuid.UUID, req *domain.RefundPaymentRequest) (*domain.Refund, error)
+	GetPayment(ctx context.Context, id uuid.UUID) (*domain.Payment, error)
+
+	HandleWebhook(ctx context.Context, providerName string, payload []byte, header http.Header) error
+
+	IssueGiftCard(ctx context.Context, req *domain.IssueGiftCardRequest) (*domain.GiftCard, error)
+	GetGiftCard(ctx context.Context, id uuid.UUID) (*domain.GiftCard, error)
+	UpdateGiftCard(ctx context.Context, id uuid.UUID, req *domain.UpdateGiftCardRequest) (*domain.GiftCard, error)
+	ListGiftCards(ctx context.Context, filters *domain.GiftCardFilters) (*domain.GiftCardList, error)
+	GiftCardBalance(ctx context.Context, req *domain.GiftCardBalanceRequest) (*domain.GiftCardBalance, error)
+	RedeemGiftCard(ctx context.Context, req *domain.RedeemGiftCardRequest) (*domain.GiftCardRedemption, error)
+	VoidGiftCards(ctx context.Context, req *domain.VoidGiftCardsRequest) ([]domain.GiftCardTransaction, error)
+
+	CreateEarnRule(ctx context.Context, req *domain.CreateEarnRuleRequest) (*domain.EarnRule, error)
+	GetEarnRule(ctx context.Context, id uuid.UUID) (*domain.EarnRule, error)
+	UpdateEarnRule(ctx context.Context, id uuid.UUID, req *domain.UpdateEarnRuleRequest) (*domain.EarnRule, error)
+	DeleteEarnRule(ctx context.Context, id uuid.UUID) error
+	ListEarnRules(ctx context.Context) ([]domain.EarnRule, error)
+	GetLoyaltyAccount(ctx context.Context, customerID string) (*domain.LoyaltyAccount, error)
+	ListPointsHistory(ctx context.Context, filters *domain.LoyaltyTransactionFilters) (*domain.LoyaltyTransactionList, error)
+	RedeemPoints(ctx context.Context, req *domain.RedeemPointsRequest) (*domain.PointsRedemption, error)
+	VoidPoints(ctx context.Context, req *domain.VoidPointsRequest) (*domain.LoyaltyTransaction, error)
+
+	HandleEvent(ctx context.Context, event *events.Event) (*domain.EventOutcome, error)
+}
+
+type paymentService struct {
+	repo      repository.PaymentRepository
+	provider  provider.Provider
+	loyalty   config.LoyaltyConfig
+	logger    *logrus.Logger
+	validator *validator.Validator
+}
+
+// NewPaymentService creates a new payment service
+func NewPaymentService(repo repository.PaymentRepository, provider provider.Provider, loyalty config.LoyaltyConfig, logger *logrus.Logger) PaymentService {
+	return &paymentService{
+		repo:      repo,
+		provider:  provider,
+		loyalty:   loyalty,
+		logger:    logger,
+		validator: validator.New(),
+	}
+}
+
+// log returns the logger of the request ctx belongs to
+func (s *paymentService) log(ctx context.Context) *logrus.Entry {
+	return logger.FromContext(ctx, s.logger)
+}
+
+// checkVoidable refuses voids of what a completed checkout redeemed, which
+// paid for its order
+func (s *paymentService) checkVoidable(ctx context.Context, reference string) error {
+	checkoutID, ok := domain.CheckoutOfReference(reference)
+	if !ok {
+		return nil
+	}
+	completed, err := s.repo.IsCheckoutCompleted(ctx, checkoutID)
+	if err != nil {
+		s.log(ctx).WithError(err).WithField("checkout_id", checkoutID).Error("Failed to check checkout completion")
+		return errors.NewInternalError("Failed to check checkout", err)
+	}
+	if completed {
+		return errors.NewConflictError("The checkout has completed and its order is paid for", nil).WithCode(errors.CodeCheckoutCompleted)
+	}
+	return nil
+}
+
+// checkInternal lets only admins and other services inside the cluster,
+// whose calls carry no actor, move money on a payment or undo a checkout
+func checkInternal(ctx context.Context, message string) error {
+	actor := auth.ActorFromContext(ctx)
+	if actor != nil && actor.Role != auth.RoleAdmin {
+		return errors.NewForbiddenError(message, nil)
+	}
+	return nil
+}
+
+// Authorize places a hold on the customer's payment method. The reference
+// makes it idempotent: repeating a request returns the payment it created,
+// and the boolean result reports whether this call created it. A declined
+// payment is returned with a failed status rather than as an error.
+func (s *paymentService) Authorize(ctx context.Context, req *domain.AuthorizePaymentRequest) (*domain.Payment, bool, error) {
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid payment request")
+		return nil, false, errors.NewValidationError("Invalid request", err)
+	}
+
+	payment := &domain.Payment{
+		ID:         uuid.New(),
+		Reference:  req.Reference,
+		CustomerID: req.CustomerID,
+		Provider:   s.provider.Name(),
+		Amount:     round(req.Amount),
+		Currency:   strings.ToLower(req.Currency),
+		Status:     domain.StatusPending,
+	}
+
+	created, err := s.repo.Create(ctx, payment)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to create payment")
+		return nil, false, errors.NewInternalError("Failed to create payment", err)
+	}
+
+	if !created {
+		existing, err := s.repo.GetByReference(ctx, req.Reference)
+		if err != nil {
+			return nil, false, errors.NewInternalError("Failed to get payment", err)
+		}
+		if existing.Amount != payment.Amount || existing.Currency != payment.Currency {
+			return nil, false, errors.NewConflictError("Payment reference was already used for a different amount", nil).WithCode(errors.CodePaymentReferenceReused)
+		}
+		// Only a payment whose provider call never completed is retried; the
+		// provider deduplicates on the reference, so no second hold is placed
+		if existing.Status != domain.StatusPending || existing.ProviderRef != "" {
+			return existing, false, nil
+		}
+		payment = existing
+	}
+
+	result, err := s.provider.Authorize(ctx, &provider.AuthorizeRequest{
+		IdempotencyKey: payment.Reference,
+		Amount:         payment.Amount,
+		Currency:       payment.Currency,
+		PaymentMethod:  req.PaymentMethod,
+		CustomerID:     payment.CustomerID,
+	})
+	if err != nil {
+		// The payment stays pending and is retried by the next request with
+		// the same reference, or settled by a webhook
+		s.log(ctx).WithError(err).WithField("payment_id", payment.ID).Error("Payment provider authorization failed")
+		return nil, false, providerError("Failed to authorize payment", err)
+	}
+
+	payment, err = s.apply(ctx, payment, result)
+	if err != nil {
+		return nil, false, err
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"payment_id": payment.ID,
+		"status":     payment.Status,
+	}).Info("Payment authorization processed successfully")
+	return payment, created, nil
+}
+
+// Void releases the hold placed under a reference. Voiding a payment that
+// was already voided or failed does nothing, so compensation can retry.
+func (s *paymentService) Void(ctx context.Context, req *domain.VoidPaymentRequest) (*domain.Payment, error) {
+	if err := checkInternal(ctx, "Voiding payments requires the admin role"); err != nil {
+		return nil, err
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid void request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	payment, err := s.repo.GetByReference(ctx, req.Reference)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Payment not found", err).WithCode(errors.CodePaymentNotFound)
+		}
+		s.log(ctx).WithError(err).Error("Failed to get payment")
+		return nil, errors.NewInternalError("Failed to get payment", err)
+	}
+
+	switch payment.Status {
+	case domain.StatusVoided, domain.StatusFailed:
+		return payment, nil
+	case domain.StatusCaptured, domain.StatusRefunded:
+		return nil, errors.NewConflictError("Captured payments must be refunded", nil).WithCode(errors.CodePaymentAlreadyCaptured)
+	}
+
+	if payment.ProviderRef == "" {
+		// The provider never confirmed the authorization. Voiding locally
+		// means a late confirmation is cancelled when its webhook arrives.
+		s.log(ctx).WithField("payment_id", payment.ID).Warn("Voiding payment the provider has not confirmed")
+		return s.apply(ctx, payment, &provider.Result{Status: domain.StatusVoided})
+	}
+
+	result, err := s.provider.Void(ctx, payment.ProviderRef)
+	if err != nil {
+		s.log(ctx).WithError(err).WithField("payment_id", payment.ID).Error("Payment provider void failed")
+		return nil, providerError("Failed to void payment", err)
+	}
+
+	payment, err = s.apply(ctx, payment, result)
+	if err != nil {
+		return nil, err
+	}
+
+	s.log(ctx).WithField("payment_id", payment.ID).Info("Payment voided successfully")
+	return payment, nil
+}
+
+// Capture takes an authorized payment, in full or in part. Whatever is not
+// captured is released by the provider.
+func (s *paymentService) Capture(ctx context.Context, id uuid.UUID, req *domain.CapturePaymentRequest) (*domain.Payment, error) {
+	if err := checkInternal(ctx, "Capturing payments requires the admin role"); err != nil {
+		return nil, err
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid capture request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	payment, err := s.getPayment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != domain.StatusAuthorized {
+		return nil, errors.NewConflictError(fmt.Sprintf("Cannot capture a %s payment", payment.Status), nil).WithCode(errors.CodePaymentInvalidState)
+	}
+
+	amount := round(req.Amount)
+	if amount == 0 {
+		amount = payment.Amount
+	}
+	if amount > payment.Amount {
+		return nil, errors.NewValidationError("Capture amount exceeds the authorized amount", nil).WithCode(errors.CodeCaptureExceedsAmount)
+	}
+
+	result, err := s.provider.Capture(ctx, payment.ProviderRef, amount)
+	if err != nil {
+		s.log(ctx).WithError(err).WithField("payment_id", payment.ID).Error("Payment provider capture failed")
+		return nil, providerError("Failed to capture payment", err)
+	}
+
+	if result.Status == domain.StatusCaptured {
+		payment.AmountCaptured = amount
+	}
+	payment, err = s.apply(ctx, payment, result)
+	if err != nil {
+		return nil, err
+	}
+
+	s.log(ctx).WithField("payment_id", payment.ID).Info("Payment captured successfully")
+	return payment, nil
+}
+
+func (s *paymentService) GetPayment(ctx context.Context, id uuid.UUID) (*domain.Payment, error) {
+	actor := auth.ActorFromContext(ctx)
+	if actor == nil {
+		return nil, errors.NewUnauthorizedError("Authentication required to view payments", nil).WithCode(errors.CodeAuthenticationRequired)
+	}
+
+	payment, err := s.getPayment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Customers only see their own payments
+	if payment.CustomerID != actor.ID && actor.Role != auth.RoleAdmin {
+		return nil, errors.NewNotFoundError("Payment not found", nil).WithCode(errors.CodePaymentNotFound)
+	}
+
+	return payment, nil
+}
+
+// getPayment loads a payment with its refunds
+func (s *paymentService) getPayment(ctx context.Context, id uuid.UUID) (*domain.Payment, error) {
+	payment, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Payment not found", err).WithCode(errors.CodePaymentNotFound)
+		}
+		s.log(ctx).WithError(err).Error("Failed to get payment")
+		return nil, errors.NewInternalError("Failed to get payment", err)
+	}
+	return payment, nil
+}
+
+// apply records a provider result on the payment. If the payment changed
+// underneath the request, for example because a webhook settled it first,
+// the stored payment wins and is returned instead.
+func (s *paymentService) apply(ctx context.Context, payment *domain.Payment, result *provider.Result) (*domain.Payment, error) {
+	previous := payment.Status
+	if result.ProviderRef != "" {
+		payment.ProviderRef = result.ProviderRef
+	}
+	if result.Status != previous {
+		if !domain.CanTransition(previous, result.Status) {
+			return nil, errors.NewConflictError(fmt.Sprintf("Payment cannot move from %s to %s", previous, result.Status), nil).WithCode(errors.CodePaymentInvalidState)
+		}
+		payment.Status = result.Status
+		payment.FailureReason = result.FailureReason
+	}
+
+	saved, err := s.repo.Update(ctx, payment, previous)
+	if err != nil {
+		s.log(ctx).WithError(err).WithField("payment_id", payment.ID).Error("Failed to update payment")
+		return nil, errors.NewInternalError("Failed to update payment", err)
+	}
+	if !saved {
+		return s.getPayment(ctx, payment.ID)
+	}
+	return payment, nil
+}
+
+// providerError keeps the error types a provider reports and treats anything
+// else as the provider being unreachable
+func providerError(message string, err error) error {
+	if appErr, ok := err.(*errors.AppError); ok {
+		return appErr
+	}
+	return errors.NewUnavailableError(message, err)
+}
+
+// round rounds an amount to whole cents
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/payment/domain"
+	"ecommerce/internal/payment/provider"
+	"ecommerce/pkg/errors"
+)
+
+// HandleWebhook applies an asynchronous provider notification. Providers
+// retry until they are acknowledged, so events about payments this service
+// does not know, and events that would move a payment backwards, are
+// acknowledged and ignored rather than rejected.
+func (s *paymentService) HandleWebhook(ctx context.Context, providerName string, payload []byte, header http.Header) error {
+	if providerName != s.provider.Name() {
+		return errors.NewNotFoundError("Unknown payment provider", nil)
+	}
+
+	event, err := s.provider.ParseWebhook(payload, header)
+	if err != nil {
+		switch err {
+		case provider.ErrWebhookUnsupported:
+			return errors.NewNotFoundError("Payment provider does not send webhooks", err)
+		case provider.ErrInvalidSignature:
+			s.log(ctx).WithField("provider", providerName).Warn("Rejected webhook with invalid signature")
+			return errors.NewValidationError("Invalid webhook signature", err).WithCode(errors.CodeInvalidSignature)
+		default:
+			return errors.NewValidationError("Invalid webhook payload", err)
+		}
+	}
+
+	logger := s.log(ctx).WithFields(logrus.Fields{
+		"event_id":   event.ID,
+		"event_type": event.Type,
+	})
+
+	switch {
+	case event.PaymentRef != "":
+		err = s.applyPaymentEvent(ctx, event, logger)
+	case event.RefundRef != "":
+		err = s.applyRefundEvent(ctx, event, logger)
+	default:
+		logger.Debug("Ignoring webhook event")
+	}
+	return err
+}
+
+func (s *paymentService) applyPaymentEvent(ctx context.Context, event *provider.WebhookEvent, logger *logrus.Entry) error {
+	payment, err := s.repo.GetByProviderRef(ctx, event.PaymentRef)
+	if errors.IsNotFound(err) && event.Reference != "" {
+		// The authorization response was lost before its provider
+		// reference was recorded
+		payment, err = s.repo.GetByReference(ctx, event.Reference)
+	}
+	if err != nil {
+		if errors.IsNotFound(err) {
+			logger.Warn("Ignoring webhook for unknown payment")
+			return nil
+		}
+		return errors.NewInternalError("Failed to get payment", err)
+	}
+
+	if payment.ProviderRef == "" && payment.Status == domain.StatusVoided {
+		return s.voidLateAuthorization(ctx, payment, event, logger)
+	}
+
+	if event.PaymentStatus == payment.Status || !domain.CanTransition(payment.Status, event.PaymentStatus) {
+		logger.WithField("payment_id", payment.ID).Debug("Ignoring stale payment webhook")
+		return nil
+	}
+
+	if event.PaymentStatus == domain.StatusCaptured && payment.AmountCaptured == 0 {
+		payment.AmountCaptured = event.AmountCaptured
+	}
+	payment, err = s.apply(ctx, payment, &provider.Result{
+		ProviderRef:   event.PaymentRef,
+		Status:        event.PaymentStatus,
+		FailureReason: event.FailureReason,
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"payment_id": payment.ID,
+		"status":     payment.Status,
+	}).Info("Payment webhook applied successfully")
+	return nil
+}
+
+// voidLateAuthorization cancels a hold the provider confirmed after the
+// payment was voided locally, since nobody will ever capture it
+func (s *paymentService) voidLateAuthorization(ctx context.Context, payment *domain.Payment, event *provider.WebhookEvent, logger *logrus.Entry) error {
+	logger = logger.WithField("payment_id", payment.ID)
+
+	if event.PaymentStatus == domain.StatusAuthorized || event.PaymentStatus == domain.StatusPending {
+		if _, err := s.provider.Void(ctx, event.PaymentRef); err != nil {
+			logger.WithError(err).Error("Failed to void late authorization")
+			return providerError("Failed to void payment", err)
+		}
+		logger.Warn("Voided authorization confirmed after the payment was voided")
+	}
+
+	payment.ProviderRef = event.PaymentRef
+	if _, err := s.repo.Update(ctx, payment, domain.StatusVoided); err != nil {
+		return errors.NewInternalError("Failed to update payment", err)
+	}
+	return nil
+}
+
+func (s *paymentService) applyRefundEvent(ctx context.Context, event *provider.WebhookEvent, logger *logrus.Entry) error {
+	refund, err := s.repo.GetRefundByProviderRef(ctx, event.RefundRef)
+	if errors.IsNotFound(err) && event.Reference != "" {
+		// The refund response was lost before its provider reference was
+		// recorded; the refund ID was sent along with the request
+		if id, parseErr := uuid.Parse(event.Reference); parseErr == nil {
+			refund, err = s.repo.GetRefund(ctx, id)
+		}
+	}
+	if err != nil {
+		if errors.IsNotFound(err) {
+			logger.Warn("Ignoring webhook for unknown refund")
+			return nil
+		}
+		return errors.NewInternalError("Failed to get refund", err)
+	}
+
+	// Only pending refunds are settled by webhook
+	if refund.Status != domain.RefundStatusPending || event.RefundStatus == domain.RefundStatusPending {
+		return nil
+	}
+
+	refund.ProviderRef = event.RefundRef
+	refund.Status = event.RefundStatus
+	if err := s.settleRefund(ctx, refund); err != nil {
+		return err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"refund_id": refund.ID,
+		"status":    refund.Status,
+	}).Info("Refund webhook applied successfully")
+	return nil
+}
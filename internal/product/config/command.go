@@ -0,0 +1,88 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// redacted replaces secrets in printed configuration
+const redacted = "[REDACTED]"
+
+// secretFields are the words in a setting's name that mark it as a secret
+var secretFields = []string{"password", "secret", "token", "salt", "apikey", "accesskey", "privatekey"}
+
+// Validator is a service's configuration
+type Validator interface {
+	Validate() error
+}
+
+// Command runs the config subcommand of a service:
+//
+//   - print writes the configuration the service would run with as JSON,
+//     with secrets redacted
+//   - validate reports every invalid setting, failing if there are any
+func Command(cfg Validator, args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: config print|validate")
+	}
+
+	switch args[0] {
+	case "print":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(redact(cfg))
+	case "validate":
+		if err := cfg.Validate(); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "Configuration is valid")
+		return nil
+	default:
+		return fmt.Errorf("unknown config command %q; use print or validate", args[0])
+	}
+}
+
+// redact returns a copy of a configuration with its secrets blanked out
+func redact(cfg interface{}) interface{} {
+	value := reflect.ValueOf(cfg)
+	if value.Kind() == reflect.Pointer {
+		value = value.Elem()
+	}
+	copied := reflect.New(value.Type()).Elem()
+	copied.Set(value)
+	redactStruct(copied)
+	return copied.Interface()
+}
+
+// redactStruct blanks out the non-empty secret strings of a struct, at any
+// depth
+func redactStruct(value reflect.Value) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		switch field.Kind() {
+		case reflect.Struct:
+			redactStruct(field)
+		case reflect.String:
+			if field.String() != "" && secret(value.Type().Field(i).Name) {
+				field.SetString(redacted)
+			}
+		}
+	}
+}
+
+// secret reports whether a setting's name marks it as a secret
+func secret(name string) bool {
+	name = strings.ToLower(name)
+	if name == "key" {
+		return true
+	}
+	for _, word := range secretFields {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
@@ -3,20 +3,57 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 )
 
 // Config holds all configuration for the product service
 type Config struct {
-	HTTP     HTTPConfig
-	GRPC     GRPCConfig
-	Database DatabaseConfig
-	Redis    RedisConfig
-	Logger   LoggerConfig
+	Env       string // development or production; see Validate
+	HTTP      HTTPConfig
+	GRPC      GRPCConfig
+	Database  DatabaseConfig
+	Redis     RedisConfig
+	Cache     CacheConfig
+	Logger    LoggerConfig
+	Events    EventsConfig
+	Search    SearchConfig
+	Import    ImportConfig
+	Jobs      JobsConfig
+	Schedule  ScheduleConfig
+	Stock     StockConfig
+	Sale      SaleConfig
+	Publish   PublishConfig
+	FlashSale FlashSaleConfig
+	Locale    LocaleConfig
+	Media     MediaConfig
+	Images    ImagesConfig
+	Store     StorefrontConfig
+	Feed      FeedConfig
+	Sitemap   SitemapConfig
+	Suggest   SuggestConfig
+	Live      LiveConfig
+	Health    HealthConfig
+	Auth      AuthConfig
+	MTLS      MTLSConfig
+	Debug     DebugConfig
 }
 
 // HTTPConfig holds HTTP server configuration
 type HTTPConfig struct {
-	Port string
+	Port           string
+	ErrorFormat    string // json or problem (RFC 7807); clients can ask for problem details either way
+	RequestTimeout int    // seconds a request may run before its context is cancelled; 0 for no limit
+
+	// Request bodies over MaxBodySize kilobytes are refused, but on the
+	// paths in MaxBodySizes, such as uploads, which have their own; 0 for
+	// no limit
+	MaxBodySize  int
+	MaxBodySizes map[string]int
+
+	// Security headers sent on every response
+	HSTSMaxAge     int    // seconds browsers keep to HTTPS; 0 sends no Strict-Transport-Security
+	HSTSSubdomains bool   // HSTS covers subdomains too
+	FrameOptions   string // X-Frame-Options: DENY or SAMEORIGIN; empty sends none
 }
 
 // GRPCConfig holds gRPC server configuration
@@ -36,6 +73,17 @@ type DatabaseConfig struct {
 	MaxIdleConns    int
 	MaxOpenConns    int
 	ConnMaxLifetime int
+	MigrateOnStart  bool // apply pending migrations at startup instead of refusing to start
+
+	// Postgres cancels statements running longer than StatementTimeout
+	// seconds, so a pathological query can't hold a connection
+	// indefinitely; 0 for no limit
+	StatementTimeout int
+
+	// Replicas are read replicas of the primary, as host or host:port,
+	// sharing its user, password and database name. Reads are spread over
+	// them; writes always go to the primary.
+	Replicas []string
 }
 
 // RedisConfig holds Redis configuration
@@ -50,50 +98,446 @@ type RedisConfig struct {
 	DialTimeout  int
 	ReadTimeout  int
 	WriteTimeout int
+
+	// Topology; Host and Port are only used for a single node
+	Mode             string   // standalone, sentinel or cluster
+	Addrs            []string // sentinels in sentinel mode, seed nodes in cluster mode
+	MasterName       string   // master the sentinels monitor
+	SentinelPassword string
+	MinRetryBackoff  int // milliseconds before the first retry, e.g. while a failover promotes a replica
+	MaxRetryBackoff  int // milliseconds between retries at most
+
+	// Caching of catalog reads
+	CacheEnabled bool           // false bypasses every product cache, for debugging stale data
+	CacheTTLs    map[string]int // seconds entries live per cache namespace, e.g. product or products:list; 0 disables the namespace
+	CacheCodec   string         // how cached values are stored: json or msgpack
+	CacheJitter  int            // percent of its TTL a cache entry may expire early by, spreading out expiries
+}
+
+// CacheConfig holds configuration of the in-process cache of hot products,
+// kept in front of Redis, and of warming the caches ahead of traffic
+type CacheConfig struct {
+	LocalSize    int    // products held per replica; 0 disables the cache
+	LocalTTL     int    // seconds a product is held, bounding how stale a replica can serve it
+	WarmSchedule string // cron schedule of warm-ups of the storefront listing; empty disables them
+	WarmPages    int    // listing pages, and their products, loaded per warm-up
 }
 
 // LoggerConfig holds logger configuration
 type LoggerConfig struct {
 	Level string
+
+	// Every request is logged with its outcome when Requests is set, but
+	// for the routes in SkipPaths, by full path. The JSON bodies of
+	// BodySamplePercent percent of requests are logged too, up to
+	// BodyMaxBytes each, with passwords, tokens and the like redacted, as
+	// well as any field named in RedactFields.
+	Requests          bool
+	SkipPaths         []string
+	BodySamplePercent int
+	BodyMaxBytes      int
+	RedactFields      []string
+}
+
+// EventsConfig holds in-process event bus configuration
+type EventsConfig struct {
+	BufferSize     int
+	ForwardURLs    []string // optional; events are also POSTed to each
+	ForwardTimeout int      // seconds
+}
+
+// SearchConfig holds product search backend configuration
+type SearchConfig struct {
+	Backend               string // postgres, elasticsearch
+	ElasticsearchURL      string
+	ElasticsearchIndex    string
+	ElasticsearchUsername string
+	ElasticsearchPassword string
+	Timeout               int
+}
+
+// ImportConfig holds bulk product import configuration
+type ImportConfig struct {
+	MaxFileSize int // megabytes
+	BatchSize   int
+}
+
+// JobsConfig holds configuration of the workers running background jobs,
+// such as imports and feed exports, from the queue the replicas share
+type JobsConfig struct {
+	Workers      int // jobs each replica runs at once
+	PollInterval int // milliseconds between checks for due jobs while idle
+	Timeout      int // seconds a job may run before it is interrupted and retried
+	Retention    int // hours completed jobs are kept; 0 keeps them
+}
+
+// ScheduleConfig holds configuration of the scheduler running recurring
+// catalog tasks, such as feed exports and sale checks, on cron schedules.
+// Only the replica holding the scheduler's lock in Redis runs them.
+type ScheduleConfig struct {
+	LockTTL int // seconds before another replica takes over from a leader that stopped renewing its lock
+}
+
+// StockConfig holds stock level configuration
+type StockConfig struct {
+	LowThreshold   int // stock.low is published when stock falls to this level, unless a product sets its own
+	CheckInterval  int // seconds between checks for unreported shortages; 0 disables the check
+	CheckBatchSize int // shortages reported per check
+}
+
+// SaleConfig holds scheduled sale configuration
+type SaleConfig struct {
+	CheckSchedule  string // cron schedule of checks for sale windows that opened or closed; empty disables the check
+	CheckBatchSize int    // products switched per check
+}
+
+// PublishConfig holds scheduled publishing configuration
+type PublishConfig struct {
+	CheckInterval  int // seconds between checks for drafts due to be published; 0 disables the check
+	CheckBatchSize int // products published per check
+}
+
+// FlashSaleConfig holds flash sale configuration. Purchases are taken in
+// Redis and written to Postgres in the background.
+type FlashSaleConfig struct {
+	SyncInterval  int // seconds between writes of the purchases taken; 0 disables writing them
+	SyncBatchSize int // purchases written per query
+}
+
+// LocaleConfig holds catalog content locale configuration
+type LocaleConfig struct {
+	Default   string   // locale of the content stored on products and categories
+	Supported []string // other locales content can be translated into
+}
+
+// MediaConfig holds product image storage configuration. Images are kept
+// in an S3-compatible bucket; media is disabled when no bucket is set.
+type MediaConfig struct {
+	Endpoint       string
+	Region         string
+	Bucket         string
+	AccessKey      string
+	SecretKey      string
+	PathStyle      bool   // address the bucket as endpoint/bucket, as MinIO expects
+	PublicURL      string // optional; base URL images are served from, such as a CDN in front of the bucket
+	Timeout        int    // seconds
+	MaxUploadSize  int    // megabytes
+	MaxMegapixels  int    // larger images are refused rather than decoded
+	AllowedTypes   []string
+	UploadExpiry   int   // seconds an upload URL stays valid
+	DownloadExpiry int   // seconds a digital product's download URL stays valid
+	ThumbnailSizes []int // pixels; each thumbnail fits a square box of this size
+	PurgeInterval  int   // seconds between purges of abandoned uploads and deleted products' images; 0 disables the purge
+	PurgeBatchSize int   // images removed per purge
+	RetentionDays  int   // days a deleted product's images are kept so restoring it brings them back
+}
+
+// ImagesConfig holds image delivery configuration. Image URLs in responses
+// can be rewritten to a CDN or imgproxy server that resizes on the fly.
+type ImagesConfig struct {
+	Mode       string   // empty (serve as stored), cdn or imgproxy
+	BaseURL    string   // CDN or imgproxy URL
+	Origins    []string // URL prefixes images are stored under
+	Key        string   // imgproxy signing key, hex encoded
+	Salt       string   // imgproxy signing salt, hex encoded
+	Format     string   // format variants are converted to, e.g. webp; empty keeps the source format
+	ThumbSize  int      // pixels; 0 keeps the source size
+	MediumSize int
+	FullSize   int
+}
+
+// StorefrontConfig describes the storefront whose pages feeds and sitemaps
+// link to. Both are only served when its URL is set.
+type StorefrontConfig struct {
+	URL          string // product links and relative image URLs are resolved against it
+	ProductPath  string // path of a product page; {slug} is replaced with the product's slug
+	CategoryPath string // path of a category page; {slug} is replaced with the category's slug
+}
+
+// FeedConfig holds product feed configuration
+type FeedConfig struct {
+	Title           string
+	Currency        string // ISO 4217 code prices are listed in
+	BatchSize       int    // products rendered per query during a rebuild
+	RebuildInterval int    // seconds between full rebuilds; changes in between are applied from product events
+	ExportSchedule  string // cron schedule of uploads to media storage; empty disables the export
+	ExportKey       string // object key the exported feed is stored under
+}
+
+// SitemapConfig holds sitemap configuration. Sitemaps are rebuilt after
+// catalog changes, kept in Redis and uploaded to media storage when it is
+// configured.
+type SitemapConfig struct {
+	URL             string // URL the sitemap files are published under, listed in the index; defaults to the storefront URL
+	ShardSize       int    // URLs per sitemap file; the protocol allows at most 50,000
+	BatchSize       int    // products read per query during a build
+	RefreshSchedule string // cron schedule of checks for catalog changes; empty disables rebuilding in the background
+	ExportPrefix    string // object key prefix uploaded files are stored under
+}
+
+// SuggestConfig holds search suggestion configuration. Suggestions are
+// served from a Redis index kept up to date from catalog events and
+// rebuilt in full now and then.
+type SuggestConfig struct {
+	Limit           int // suggestions returned when the request doesn't ask for a number
+	MaxLimit        int
+	BatchSize       int // products read per query during a rebuild
+	RebuildInterval int // seconds between full rebuilds
+	CheckInterval   int // seconds between checks whether a rebuild is due; 0 disables rebuilding in the background
+}
+
+// LiveConfig holds configuration of the stream of stock and price updates
+// storefront pages subscribe to
+type LiveConfig struct {
+	MaxProducts       int // products one stream may watch
+	BufferSize        int // updates queued for a stream before it is dropped as too slow
+	HeartbeatInterval int // seconds between keep-alive comments on an idle stream
+}
+
+// HealthConfig holds readiness check configuration
+type HealthConfig struct {
+	Timeout int // seconds each dependency gets to respond
+}
+
+// DebugConfig holds configuration of the runtime diagnostics server
+type DebugConfig struct {
+	Host    string // interface the port is bound to; loopback unless set
+	Port    string // internal port serving pprof, expvar and dumps; empty to serve none
+	DumpDir string // where dumps are written; the temporary directory when empty
+}
+
+// AuthConfig holds authentication configuration
+type AuthConfig struct {
+	JWTSecret      string
+	IdentitySecret string // signs the identity headers set by the gateway
+}
+
+// MTLSConfig holds mutual TLS configuration for traffic between the
+// gateway and the services. When it is enabled the services' URLs must
+// use https.
+type MTLSConfig struct {
+	Enabled        bool
+	CertFile       string   // PEM certificate chain, such as an X.509 SVID written by spiffe-helper
+	KeyFile        string   // PEM private key
+	CAFile         string   // PEM bundle of the CAs peers' certificates are issued by
+	AllowedIDs     []string // SPIFFE IDs or DNS names of accepted peers, e.g. spiffe://shop.internal/*; empty accepts any the CA vouches for
+	ReloadInterval int      // seconds between checks for rotated files
 }
 
-// Load loads configuration from environment variables
-func Load() *Config {
+// Load loads configuration from environment variables, and from the file
+// CONFIG_FILE names, if any, for the variables the environment leaves unset.
+// The configuration is not validated.
+func Load() (*Config, error) {
+	if path := os.Getenv(FileEnv); path != "" {
+		if err := loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
 	return &Config{
+		Env: getEnv("APP_ENV", EnvDevelopment),
 		HTTP: HTTPConfig{
-			Port: getEnv("HTTP_PORT", "8080"),
+			Port:           getEnv("HTTP_PORT", "8080"),
+			ErrorFormat:    getEnv("ERROR_FORMAT", "json"),
+			RequestTimeout: getEnvAsInt("HTTP_REQUEST_TIMEOUT", 30),
+			MaxBodySize:    getEnvAsInt("HTTP_MAX_BODY_SIZE", 1024),
+			MaxBodySizes: getEnvAsIntMap("HTTP_MAX_BODY_SIZE_OVERRIDES", map[string]int{
+				"/api/v1/products/import": getEnvAsInt("IMPORT_MAX_FILE_SIZE", 50) << 10,
+			}),
+			HSTSMaxAge:     getEnvAsInt("HTTP_HSTS_MAX_AGE", 31536000),
+			HSTSSubdomains: getEnvAsBool("HTTP_HSTS_INCLUDE_SUBDOMAINS", false),
+			FrameOptions:   getEnv("HTTP_FRAME_OPTIONS", "DENY"),
 		},
 		GRPC: GRPCConfig{
 			Port: getEnv("GRPC_PORT", "50051"),
 		},
 		Database: DatabaseConfig{
-			Host:            getEnv("DB_HOST", "localhost"),
-			Port:            getEnvAsInt("DB_PORT", 5432),
-			User:            getEnv("DB_USER", "postgres"),
-			Password:        getEnv("DB_PASSWORD", "password"),
-			Name:            getEnv("DB_NAME", "ecommerce"),
-			SSLMode:         getEnv("DB_SSLMODE", "disable"),
-			TimeZone:        getEnv("DB_TIMEZONE", "UTC"),
-			MaxIdleConns:    getEnvAsInt("DB_MAX_IDLE_CONNS", 10),
-			MaxOpenConns:    getEnvAsInt("DB_MAX_OPEN_CONNS", 100),
-			ConnMaxLifetime: getEnvAsInt("DB_CONN_MAX_LIFETIME", 60),
+			Host:             getEnv("DB_HOST", "localhost"),
+			Port:             getEnvAsInt("DB_PORT", 5432),
+			User:             getEnv("DB_USER", "postgres"),
+			Password:         getEnv("DB_PASSWORD", "password"),
+			Name:             getEnv("DB_NAME", "ecommerce"),
+			SSLMode:          getEnv("DB_SSLMODE", "disable"),
+			TimeZone:         getEnv("DB_TIMEZONE", "UTC"),
+			MaxIdleConns:     getEnvAsInt("DB_MAX_IDLE_CONNS", 10),
+			MaxOpenConns:     getEnvAsInt("DB_MAX_OPEN_CONNS", 100),
+			ConnMaxLifetime:  getEnvAsInt("DB_CONN_MAX_LIFETIME", 60),
+			MigrateOnStart:   getEnvAsBool("DB_MIGRATE_ON_START", false),
+			StatementTimeout: getEnvAsInt("DB_STATEMENT_TIMEOUT", 30),
+			Replicas:         getEnvAsList("DB_REPLICAS"),
 		},
 		Redis: RedisConfig{
-			Host:         getEnv("REDIS_HOST", "localhost"),
-			Port:         getEnvAsInt("REDIS_PORT", 6379),
-			Password:     getEnv("REDIS_PASSWORD", ""),
-			DB:           getEnvAsInt("REDIS_DB", 0),
-			PoolSize:     getEnvAsInt("REDIS_POOL_SIZE", 10),
-			MinIdleConns: getEnvAsInt("REDIS_MIN_IDLE_CONNS", 5),
-			MaxRetries:   getEnvAsInt("REDIS_MAX_RETRIES", 3),
-			DialTimeout:  getEnvAsInt("REDIS_DIAL_TIMEOUT", 5),
-			ReadTimeout:  getEnvAsInt("REDIS_READ_TIMEOUT", 3),
-			WriteTimeout: getEnvAsInt("REDIS_WRITE_TIMEOUT", 3),
+			Host:             getEnv("REDIS_HOST", "localhost"),
+			Port:             getEnvAsInt("REDIS_PORT", 6379),
+			Password:         getEnv("REDIS_PASSWORD", ""),
+			DB:               getEnvAsInt("REDIS_DB", 0),
+			PoolSize:         getEnvAsInt("REDIS_POOL_SIZE", 10),
+			MinIdleConns:     getEnvAsInt("REDIS_MIN_IDLE_CONNS", 5),
+			MaxRetries:       getEnvAsInt("REDIS_MAX_RETRIES", 3),
+			DialTimeout:      getEnvAsInt("REDIS_DIAL_TIMEOUT", 5),
+			ReadTimeout:      getEnvAsInt("REDIS_READ_TIMEOUT", 3),
+			WriteTimeout:     getEnvAsInt("REDIS_WRITE_TIMEOUT", 3),
+			Mode:             getEnv("REDIS_MODE", "standalone"),
+			Addrs:            getEnvAsList("REDIS_ADDRS"),
+			MasterName:       getEnv("REDIS_MASTER_NAME", ""),
+			SentinelPassword: getEnv("REDIS_SENTINEL_PASSWORD", ""),
+			MinRetryBackoff:  getEnvAsInt("REDIS_MIN_RETRY_BACKOFF", 8),
+			MaxRetryBackoff:  getEnvAsInt("REDIS_MAX_RETRY_BACKOFF", 512),
+			CacheEnabled:     getEnvAsBool("CACHE_ENABLED", true),
+			CacheTTLs: getEnvAsIntMap("CACHE_TTL_OVERRIDES", map[string]int{
+				"product":                  getEnvAsInt("CACHE_PRODUCT_TTL", 600),
+				"products:list":            getEnvAsInt("CACHE_LIST_TTL", 300),
+				"products:category_counts": getEnvAsInt("CACHE_LIST_TTL", 300),
+				"search:synonyms":          600,
+			}),
+			CacheCodec:  getEnv("CACHE_CODEC", "json"),
+			CacheJitter: getEnvAsInt("CACHE_TTL_JITTER", 10),
+		},
+		Cache: CacheConfig{
+			LocalSize: getEnvAsInt("PRODUCT_LOCAL_CACHE_SIZE", 0),
+			LocalTTL:  getEnvAsInt("PRODUCT_LOCAL_CACHE_TTL", 5),
+
+			WarmSchedule: getEnv("CACHE_WARM_SCHEDULE", "*/5 * * * *"),
+			WarmPages:    getEnvAsInt("CACHE_WARM_PAGES", 3),
 		},
 		Logger: LoggerConfig{
-			Level: getEnv("LOG_LEVEL", "info"),
+			Level:             getEnv("LOG_LEVEL", "info"),
+			Requests:          getEnvAsBool("LOG_REQUESTS", true),
+			SkipPaths:         strings.Split(getEnv("LOG_SKIP_PATHS", "/health,/metrics"), ","),
+			BodySamplePercent: getEnvAsInt("LOG_BODY_SAMPLE_PERCENT", 0),
+			BodyMaxBytes:      getEnvAsInt("LOG_BODY_MAX_BYTES", 4096),
+			RedactFields:      getEnvAsList("LOG_REDACT_FIELDS"),
 		},
-	}
+		Events: EventsConfig{
+			BufferSize:     getEnvAsInt("EVENT_BUFFER_SIZE", 1024),
+			ForwardURLs:    getEnvAsList("EVENT_FORWARD_URL"),
+			ForwardTimeout: getEnvAsInt("EVENT_FORWARD_TIMEOUT", 5),
+		},
+		Search: SearchConfig{
+			Backend:               getEnv("SEARCH_BACKEND", "postgres"),
+			ElasticsearchURL:      getEnv("ELASTICSEARCH_URL", "http://localhost:9200"),
+			ElasticsearchIndex:    getEnv("ELASTICSEARCH_INDEX", "products"),
+			ElasticsearchUsername: getEnv("ELASTICSEARCH_USERNAME", ""),
+			ElasticsearchPassword: getEnv("ELASTICSEARCH_PASSWORD", ""),
+			Timeout:               getEnvAsInt("SEARCH_TIMEOUT", 5),
+		},
+		Import: ImportConfig{
+			MaxFileSize: getEnvAsInt("IMPORT_MAX_FILE_SIZE", 50),
+			BatchSize:   getEnvAsInt("IMPORT_BATCH_SIZE", 500),
+		},
+		Jobs: JobsConfig{
+			Workers:      getEnvAsInt("JOBS_WORKERS", 4),
+			PollInterval: getEnvAsInt("JOBS_POLL_INTERVAL", 1000),
+			Timeout:      getEnvAsInt("JOBS_TIMEOUT", 600),
+			Retention:    getEnvAsInt("JOBS_RETENTION", 168),
+		},
+		Schedule: ScheduleConfig{
+			LockTTL: getEnvAsInt("SCHEDULER_LOCK_TTL", 30),
+		},
+		Stock: StockConfig{
+			LowThreshold:   getEnvAsInt("LOW_STOCK_THRESHOLD", 5),
+			CheckInterval:  getEnvAsInt("LOW_STOCK_CHECK_INTERVAL", 300),
+			CheckBatchSize: getEnvAsInt("LOW_STOCK_CHECK_BATCH_SIZE", 500),
+		},
+		Sale: SaleConfig{
+			CheckSchedule:  getEnv("SALE_CHECK_SCHEDULE", "* * * * *"),
+			CheckBatchSize: getEnvAsInt("SALE_CHECK_BATCH_SIZE", 500),
+		},
+		Publish: PublishConfig{
+			CheckInterval:  getEnvAsInt("PUBLISH_CHECK_INTERVAL", 60),
+			CheckBatchSize: getEnvAsInt("PUBLISH_CHECK_BATCH_SIZE", 500),
+		},
+		FlashSale: FlashSaleConfig{
+			SyncInterval:  getEnvAsInt("FLASH_SALE_SYNC_INTERVAL", 5),
+			SyncBatchSize: getEnvAsInt("FLASH_SALE_SYNC_BATCH_SIZE", 500),
+		},
+		Locale: LocaleConfig{
+			Default:   getEnv("DEFAULT_LOCALE", "en"),
+			Supported: getEnvAsList("SUPPORTED_LOCALES"),
+		},
+		Media: MediaConfig{
+			Endpoint:       getEnv("S3_ENDPOINT", "http://localhost:9000"),
+			Region:         getEnv("S3_REGION", "us-east-1"),
+			Bucket:         getEnv("S3_BUCKET", ""),
+			AccessKey:      getEnv("S3_ACCESS_KEY", ""),
+			SecretKey:      getEnv("S3_SECRET_KEY", ""),
+			PathStyle:      getEnvAsBool("S3_PATH_STYLE", true),
+			PublicURL:      getEnv("MEDIA_PUBLIC_URL", ""),
+			Timeout:        getEnvAsInt("MEDIA_TIMEOUT", 30),
+			MaxUploadSize:  getEnvAsInt("MEDIA_MAX_UPLOAD_SIZE", 10),
+			MaxMegapixels:  getEnvAsInt("MEDIA_MAX_MEGAPIXELS", 40),
+			AllowedTypes:   getEnvAsList("MEDIA_ALLOWED_TYPES"),
+			UploadExpiry:   getEnvAsInt("MEDIA_UPLOAD_EXPIRY", 900),
+			DownloadExpiry: getEnvAsInt("MEDIA_DOWNLOAD_EXPIRY", 3600),
+			ThumbnailSizes: getEnvAsIntList("MEDIA_THUMBNAIL_SIZES", []int{150, 300, 600}),
+			PurgeInterval:  getEnvAsInt("MEDIA_PURGE_INTERVAL", 3600),
+			PurgeBatchSize: getEnvAsInt("MEDIA_PURGE_BATCH_SIZE", 100),
+			RetentionDays:  getEnvAsInt("MEDIA_RETENTION_DAYS", 30),
+		},
+		Images: ImagesConfig{
+			Mode:       getEnv("IMAGE_URL_MODE", ""),
+			BaseURL:    getEnv("IMAGE_BASE_URL", ""),
+			Origins:    getEnvAsList("IMAGE_ORIGINS"),
+			Key:        getEnv("IMGPROXY_KEY", ""),
+			Salt:       getEnv("IMGPROXY_SALT", ""),
+			Format:     getEnv("IMAGE_FORMAT", ""),
+			ThumbSize:  getEnvAsInt("IMAGE_THUMB_SIZE", 150),
+			MediumSize: getEnvAsInt("IMAGE_MEDIUM_SIZE", 600),
+			FullSize:   getEnvAsInt("IMAGE_FULL_SIZE", 0),
+		},
+		Store: StorefrontConfig{
+			URL:          getEnv("STOREFRONT_URL", ""),
+			ProductPath:  getEnv("STOREFRONT_PRODUCT_PATH", "/products/{slug}"),
+			CategoryPath: getEnv("STOREFRONT_CATEGORY_PATH", "/categories/{slug}"),
+		},
+		Feed: FeedConfig{
+			Title:           getEnv("FEED_TITLE", "Products"),
+			Currency:        getEnv("FEED_CURRENCY", "USD"),
+			BatchSize:       getEnvAsInt("FEED_BATCH_SIZE", 500),
+			RebuildInterval: getEnvAsInt("FEED_REBUILD_INTERVAL", 86400),
+			ExportSchedule:  getEnv("FEED_EXPORT_SCHEDULE", "*/15 * * * *"),
+			ExportKey:       getEnv("FEED_EXPORT_KEY", "feeds/google-merchant.xml"),
+		},
+		Sitemap: SitemapConfig{
+			URL:             getEnv("SITEMAP_URL", ""),
+			ShardSize:       getEnvAsInt("SITEMAP_SHARD_SIZE", 50000),
+			BatchSize:       getEnvAsInt("SITEMAP_BATCH_SIZE", 1000),
+			RefreshSchedule: getEnv("SITEMAP_REFRESH_SCHEDULE", "*/5 * * * *"),
+			ExportPrefix:    getEnv("SITEMAP_EXPORT_PREFIX", "sitemaps/"),
+		},
+		Suggest: SuggestConfig{
+			Limit:           getEnvAsInt("SUGGEST_LIMIT", 8),
+			MaxLimit:        getEnvAsInt("SUGGEST_MAX_LIMIT", 20),
+			BatchSize:       getEnvAsInt("SUGGEST_BATCH_SIZE", 1000),
+			RebuildInterval: getEnvAsInt("SUGGEST_REBUILD_INTERVAL", 86400),
+			CheckInterval:   getEnvAsInt("SUGGEST_CHECK_INTERVAL", 60),
+		},
+		Live: LiveConfig{
+			MaxProducts:       getEnvAsInt("LIVE_MAX_PRODUCTS", 50),
+			BufferSize:        getEnvAsInt("LIVE_BUFFER_SIZE", 64),
+			HeartbeatInterval: getEnvAsInt("LIVE_HEARTBEAT_INTERVAL", 15),
+		},
+		Health: HealthConfig{
+			Timeout: getEnvAsInt("HEALTH_CHECK_TIMEOUT", 2),
+		},
+		Auth: AuthConfig{
+			JWTSecret:      getEnv("JWT_SECRET", ""),
+			IdentitySecret: getEnv("GATEWAY_IDENTITY_SECRET", ""),
+		},
+		MTLS: MTLSConfig{
+			Enabled:        getEnvAsBool("MTLS_ENABLED", false),
+			CertFile:       getEnv("MTLS_CERT_FILE", ""),
+			KeyFile:        getEnv("MTLS_KEY_FILE", ""),
+			CAFile:         getEnv("MTLS_CA_FILE", ""),
+			AllowedIDs:     getEnvAsList("MTLS_ALLOWED_IDS"),
+			ReloadInterval: getEnvAsInt("MTLS_RELOAD_INTERVAL", 60),
+		},
+		Debug: DebugConfig{
+			Host:    getEnv("DEBUG_HOST", "127.0.0.1"),
+			Port:    getEnv("DEBUG_PORT", ""),
+			DumpDir: getEnv("DEBUG_DUMP_DIR", ""),
+		},
+	}, nil
 }
 
 // getEnv gets an environment variable with a default value
@@ -113,3 +557,61 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// getEnvAsBool gets an environment variable as boolean with a default value
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsList gets a comma-separated environment variable as a list
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvAsIntMap gets a comma-separated list of name=integer pairs, e.g.
+// "product=60,products:list=30", applied over defaults. Malformed pairs
+// are skipped.
+func getEnvAsIntMap(key string, defaults map[string]int) map[string]int {
+	values := make(map[string]int, len(defaults))
+	for name, value := range defaults {
+		values[name] = value
+	}
+	for _, pair := range getEnvAsList(key) {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		if intValue, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+			values[strings.TrimSpace(name)] = intValue
+		}
+	}
+	return values
+}
+
+// getEnvAsIntList gets a comma-separated environment variable as a list of
+// integers with a default value. Entries that are not integers are skipped.
+func getEnvAsIntList(key string, defaultValue []int) []int {
+	values := getEnvAsList(key)
+	if len(values) == 0 {
+		return defaultValue
+	}
+
+	var ints []int
+	for _, value := range values {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			ints = append(ints, intValue)
+		}
+	}
+	return ints
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the configuration file, YAML or TOML by its extension,
+// read before the environment
+const FileEnv = "CONFIG_FILE"
+
+// loadFile sets the variables a configuration file gives that the
+// environment does not, so environment variables always win over the file.
+// Keys are the variables' names, in any case, and nested tables are joined
+// with underscores, so
+//
+//	db:
+//	  host: localhost
+//	  replicas: [replica-1, replica-2]
+//
+// sets DB_HOST and DB_REPLICAS, lists being comma separated.
+func loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	values := make(map[string]interface{})
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		return fmt.Errorf("unsupported config file type %q; use .yaml, .yml or .toml", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	vars := make(map[string]string)
+	if err := flatten("", values, vars); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if os.Getenv(name) != "" {
+			continue
+		}
+		if err := os.Setenv(name, vars[name]); err != nil {
+			return fmt.Errorf("failed to set %s from config file: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// flatten collects the variables a decoded file value sets, under prefix
+func flatten(prefix string, value interface{}, vars map[string]string) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			name := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(key), "-", "_"))
+			if prefix != "" {
+				name = prefix + "_" + name
+			}
+			if err := flatten(name, item, vars); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			switch item.(type) {
+			case map[string]interface{}, []interface{}:
+				return fmt.Errorf("%s: lists may only hold plain values", prefix)
+			}
+			items = append(items, fmt.Sprint(item))
+		}
+		vars[prefix] = strings.Join(items, ",")
+	case nil:
+	default:
+		if prefix == "" {
+			return fmt.Errorf("expected a table of settings")
+		}
+		vars[prefix] = fmt.Sprint(v)
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"ecommerce/pkg/middleware"
+)
+
+// Headers returns the security headers the HTTP server sends
+func (c HTTPConfig) Headers() middleware.HeadersConfig {
+	return middleware.HeadersConfig{
+		HSTSMaxAge:     time.Duration(c.HSTSMaxAge) * time.Second,
+		HSTSSubdomains: c.HSTSSubdomains,
+		FrameOptions:   c.FrameOptions,
+	}
+}
+
+// BodyLimit returns the middleware refusing oversized request bodies
+func (c HTTPConfig) BodyLimit() gin.HandlerFunc {
+	overrides := make(map[string]int64, len(c.MaxBodySizes))
+	for path, size := range c.MaxBodySizes {
+		overrides[path] = int64(size) << 10
+	}
+	return middleware.BodyLimit(int64(c.MaxBodySize)<<10, overrides)
+}
@@ -0,0 +1,276 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/pkg/mtls"
+	"ecommerce/pkg/schedule"
+)
+
+// Environments a service can run in, set with APP_ENV. Production refuses
+// the defaults that are only fit for local development.
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// defaultDBPassword is the development database's password, which DB_PASSWORD
+// defaults to
+const defaultDBPassword = "password"
+
+// Validate reports every setting that is missing or invalid, so a service
+// refuses to start rather than run misconfigured
+func (c *Config) Validate() error {
+	errs := []error{
+		ValidateEnv(c.Env),
+		c.HTTP.Validate(),
+		c.Database.Validate(c.Env),
+		c.Redis.Validate(),
+		c.Logger.Validate(),
+		c.Auth.Validate(),
+		c.MTLS.Validate(),
+		c.MTLS.ValidateURLs(ForwardURLs(c.Events.ForwardURLs)),
+		c.Jobs.Validate(),
+		c.Schedule.Validate(),
+		ValidateSchedule("SALE_CHECK_SCHEDULE", c.Sale.CheckSchedule),
+		ValidateSchedule("FEED_EXPORT_SCHEDULE", c.Feed.ExportSchedule),
+		ValidateSchedule("SITEMAP_REFRESH_SCHEDULE", c.Sitemap.RefreshSchedule),
+		ValidateSchedule("CACHE_WARM_SCHEDULE", c.Cache.WarmSchedule),
+	}
+	switch c.Search.Backend {
+	case "postgres":
+	case "elasticsearch":
+		if c.Search.ElasticsearchURL == "" {
+			errs = append(errs, errors.New("ELASTICSEARCH_URL must be set for the elasticsearch backend"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("SEARCH_BACKEND must be postgres or elasticsearch, not %q", c.Search.Backend))
+	}
+	switch c.Images.Mode {
+	case "", "cdn":
+	case "imgproxy":
+		if c.Images.Key == "" || c.Images.Salt == "" {
+			errs = append(errs, errors.New("IMGPROXY_KEY and IMGPROXY_SALT must be set for imgproxy image URLs"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("IMAGE_URL_MODE must be empty, cdn or imgproxy, not %q", c.Images.Mode))
+	}
+	if c.Sitemap.ShardSize <= 0 || c.Sitemap.ShardSize > 50000 {
+		errs = append(errs, errors.New("SITEMAP_SHARD_SIZE must be between 1 and 50000"))
+	}
+	if c.Cache.WarmSchedule != "" && c.Cache.WarmPages <= 0 {
+		errs = append(errs, errors.New("CACHE_WARM_PAGES must be at least 1 when cache warming is scheduled"))
+	}
+	return errors.Join(errs...)
+}
+
+// ValidateEnv checks APP_ENV names a known environment
+func ValidateEnv(env string) error {
+	if env != EnvDevelopment && env != EnvProduction {
+		return fmt.Errorf("APP_ENV must be %s or %s, not %q", EnvDevelopment, EnvProduction, env)
+	}
+	return nil
+}
+
+// Validate checks the HTTP server settings
+func (c HTTPConfig) Validate() error {
+	var errs []error
+	if !validPort(c.Port) {
+		errs = append(errs, fmt.Errorf("HTTP_PORT must be a port number, not %q", c.Port))
+	}
+	if c.ErrorFormat != "json" && c.ErrorFormat != "problem" {
+		errs = append(errs, fmt.Errorf("ERROR_FORMAT must be json or problem, not %q", c.ErrorFormat))
+	}
+	if c.RequestTimeout < 0 {
+		errs = append(errs, errors.New("HTTP_REQUEST_TIMEOUT must not be negative"))
+	}
+	if c.MaxBodySize < 0 {
+		errs = append(errs, errors.New("HTTP_MAX_BODY_SIZE must not be negative"))
+	}
+	if c.HSTSMaxAge < 0 {
+		errs = append(errs, errors.New("HTTP_HSTS_MAX_AGE must not be negative"))
+	}
+	switch c.FrameOptions {
+	case "", "DENY", "SAMEORIGIN":
+	default:
+		errs = append(errs, fmt.Errorf("HTTP_FRAME_OPTIONS must be empty, DENY or SAMEORIGIN, not %q", c.FrameOptions))
+	}
+	return errors.Join(errs...)
+}
+
+// Validate checks the database settings. In production the password must
+// be set to something other than the development default.
+func (c DatabaseConfig) Validate(env string) error {
+	var errs []error
+	if c.Host == "" {
+		errs = append(errs, errors.New("DB_HOST must be set"))
+	}
+	if c.Port <= 0 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("DB_PORT must be a port number, not %d", c.Port))
+	}
+	if c.User == "" {
+		errs = append(errs, errors.New("DB_USER must be set"))
+	}
+	if c.Name == "" {
+		errs = append(errs, errors.New("DB_NAME must be set"))
+	}
+	if env == EnvProduction && (c.Password == "" || c.Password == defaultDBPassword) {
+		errs = append(errs, errors.New("DB_PASSWORD must be set in production"))
+	}
+	if c.StatementTimeout < 0 {
+		errs = append(errs, errors.New("DB_STATEMENT_TIMEOUT must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// Validate checks the Redis settings
+func (c RedisConfig) Validate() error {
+	var errs []error
+	switch c.Mode {
+	case "standalone":
+		if c.Host == "" {
+			errs = append(errs, errors.New("REDIS_HOST must be set"))
+		}
+	case "sentinel":
+		if c.MasterName == "" || len(c.Addrs) == 0 {
+			errs = append(errs, errors.New("REDIS_MASTER_NAME and REDIS_ADDRS must be set in sentinel mode"))
+		}
+	case "cluster":
+		if len(c.Addrs) == 0 {
+			errs = append(errs, errors.New("REDIS_ADDRS must be set in cluster mode"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("REDIS_MODE must be standalone, sentinel or cluster, not %q", c.Mode))
+	}
+	if c.CacheCodec != "json" && c.CacheCodec != "msgpack" {
+		errs = append(errs, fmt.Errorf("CACHE_CODEC must be json or msgpack, not %q", c.CacheCodec))
+	}
+	return errors.Join(errs...)
+}
+
+// Validate checks the logging settings
+func (c LoggerConfig) Validate() error {
+	var errs []error
+	switch c.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Errorf("LOG_LEVEL must be debug, info, warn or error, not %q", c.Level))
+	}
+	if c.BodySamplePercent < 0 || c.BodySamplePercent > 100 {
+		errs = append(errs, errors.New("LOG_BODY_SAMPLE_PERCENT must be between 0 and 100"))
+	}
+	return errors.Join(errs...)
+}
+
+// Validate checks the background job worker settings
+func (c JobsConfig) Validate() error {
+	var errs []error
+	if c.Workers <= 0 {
+		errs = append(errs, errors.New("JOBS_WORKERS must be at least 1"))
+	}
+	if c.PollInterval <= 0 {
+		errs = append(errs, errors.New("JOBS_POLL_INTERVAL must be positive"))
+	}
+	if c.Timeout <= 0 {
+		errs = append(errs, errors.New("JOBS_TIMEOUT must be positive"))
+	}
+	if c.Retention < 0 {
+		errs = append(errs, errors.New("JOBS_RETENTION must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// Validate checks the scheduler settings
+func (c ScheduleConfig) Validate() error {
+	if c.LockTTL <= 0 {
+		return errors.New("SCHEDULER_LOCK_TTL must be positive")
+	}
+	return nil
+}
+
+// ValidateSchedule checks the named setting holds a cron schedule, or is
+// empty to disable its task
+func ValidateSchedule(name, spec string) error {
+	if spec == "" {
+		return nil
+	}
+	if _, err := schedule.Parse(spec); err != nil {
+		return fmt.Errorf("%s must be a cron schedule: %w", name, err)
+	}
+	return nil
+}
+
+// Validate checks a service can authenticate callers: through the
+// gateway's signed identity headers, or bearer tokens, or both
+func (c AuthConfig) Validate() error {
+	if c.JWTSecret == "" && c.IdentitySecret == "" {
+		return errors.New("JWT_SECRET or GATEWAY_IDENTITY_SECRET must be set")
+	}
+	return nil
+}
+
+// Validate checks the mutual TLS settings
+func (c MTLSConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	var errs []error
+	if c.CertFile == "" || c.KeyFile == "" || c.CAFile == "" {
+		errs = append(errs, errors.New("MTLS_CERT_FILE, MTLS_KEY_FILE and MTLS_CA_FILE must be set when MTLS_ENABLED"))
+	}
+	if c.ReloadInterval <= 0 {
+		errs = append(errs, errors.New("MTLS_RELOAD_INTERVAL must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
+// ValidateURLs checks the named settings hold https URLs when mutual TLS
+// is enabled, as services then only serve over TLS
+func (c MTLSConfig) ValidateURLs(urls map[string]string) error {
+	if !c.Enabled {
+		return nil
+	}
+	var errs []error
+	for name, url := range urls {
+		if url != "" && !strings.HasPrefix(url, "https://") {
+			errs = append(errs, fmt.Errorf("%s must be an https URL when MTLS_ENABLED", name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Identity loads the service's certificate, or returns nil when mutual TLS
+// is disabled
+func (c MTLSConfig) Identity(logger *logrus.Logger) (*mtls.Identity, error) {
+	if !c.Enabled {
+		return nil, nil
+	}
+	return mtls.Load(mtls.Config{
+		CertFile:   c.CertFile,
+		KeyFile:    c.KeyFile,
+		CAFile:     c.CAFile,
+		AllowedIDs: c.AllowedIDs,
+		Reload:     time.Duration(c.ReloadInterval) * time.Second,
+	}, logger)
+}
+
+// ForwardURLs names the event forwarding URLs for ValidateURLs
+func ForwardURLs(urls []string) map[string]string {
+	named := make(map[string]string, len(urls))
+	for i, url := range urls {
+		named[fmt.Sprintf("EVENT_FORWARD_URL entry %d", i+1)] = url
+	}
+	return named
+}
+
+// validPort reports whether port is a TCP port number
+func validPort(port string) bool {
+	number, err := strconv.Atoi(port)
+	return err == nil && number > 0 && number <= 65535
+}
@@ -0,0 +1,186 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Attribute value types
+const (
+	AttributeTypeString  = "string"
+	AttributeTypeNumber  = "number"
+	AttributeTypeBoolean = "boolean"
+	AttributeTypeEnum    = "enum"
+)
+
+// AttributeDefinition declares an attribute that products in a category
+// (and its subcategories) may or must carry
+type AttributeDefinition struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CategoryID uuid.UUID `json:"category_id" gorm:"type:uuid;not null"`
+	Key        string    `json:"key" gorm:"not null"`
+	Name       string    `json:"name" gorm:"not null"`
+	Type       string    `json:"type" gorm:"not null"`
+	Unit       string    `json:"unit,omitempty"`
+	Required   bool      `json:"required"`
+	Options    []string  `json:"options,omitempty" gorm:"type:jsonb;serializer:json"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// ProductAttribute is a typed attribute value stored on a product
+type ProductAttribute struct {
+	ProductID uuid.UUID `json:"-" gorm:"type:uuid;primaryKey"`
+	Key       string    `json:"key" gorm:"primaryKey"`
+	Value     string    `json:"value" gorm:"not null"`
+	Unit      string    `json:"unit,omitempty"`
+	Type      string    `json:"type" gorm:"not null"`
+}
+
+// CreateAttributeDefinitionRequest represents the request to define a category attribute
+type CreateAttributeDefinitionRequest struct {
+	Key      string   `json:"key" validate:"required,min=1,max=64"`
+	Name     string   `json:"name" validate:"required,min=1,max=100"`
+	Type     string   `json:"type" validate:"required,oneof=string number boolean enum"`
+	Unit     string   `json:"unit" validate:"max=20"`
+	Required bool     `json:"required"`
+	Options  []string `json:"options"`
+}
+
+// UpdateAttributeDefinitionRequest represents the request to update a category attribute.
+// The key and type are fixed once products may carry values for them.
+type UpdateAttributeDefinitionRequest struct {
+	Name     *string  `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
+	Unit     *string  `json:"unit,omitempty" validate:"omitempty,max=20"`
+	Required *bool    `json:"required,omitempty"`
+	Options  []string `json:"options,omitempty"`
+}
+
+// ValidateAttributes checks raw attribute values against a set of definitions
+// and returns them normalized to their string form. Values may be given as
+// JSON-typed values or as strings, as they arrive from CSV imports.
+func ValidateAttributes(defs []AttributeDefinition, values map[string]interface{}) ([]ProductAttribute, error) {
+	byKey := make(map[string]AttributeDefinition, len(defs))
+	for _, def := range defs {
+		byKey[def.Key] = def
+	}
+
+	var problems []string
+	for key := range values {
+		if _, ok := byKey[key]; !ok {
+			problems = append(problems, fmt.Sprintf("%s: unknown attribute", key))
+		}
+	}
+
+	attributes := make([]ProductAttribute, 0, len(values))
+	for _, def := range defs {
+		raw, ok := values[def.Key]
+		if !ok || raw == nil || raw == "" {
+			if def.Required {
+				problems = append(problems, fmt.Sprintf("%s: required", def.Key))
+			}
+			continue
+		}
+
+		value, err := normalizeAttribute(def, raw)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", def.Key, err))
+			continue
+		}
+		attributes = append(attributes, ProductAttribute{
+			Key:   def.Key,
+			Value: value,
+			Unit:  def.Unit,
+			Type:  def.Type,
+		})
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, fmt.Errorf("invalid attributes: %s", strings.Join(problems, "; "))
+	}
+	return attributes, nil
+}
+
+// AttributeValues converts stored attributes back into a value map
+func AttributeValues(attributes []ProductAttribute) map[string]interface{} {
+	values := make(map[string]interface{}, len(attributes))
+	for _, attribute := range attributes {
+		values[attribute.Key] = attribute.Value
+	}
+	return values
+}
+
+func normalizeAttribute(def AttributeDefinition, raw interface{}) (string, error) {
+	switch def.Type {
+	case AttributeTypeNumber:
+		switch v := raw.(type) {
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		case string:
+			n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return "", errors.New("must be a number")
+			}
+			return strconv.FormatFloat(n, 'f', -1, 64), nil
+		}
+		return "", errors.New("must be a number")
+	case AttributeTypeBoolean:
+		switch v := raw.(type) {
+		case bool:
+			return strconv.FormatBool(v), nil
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return "", errors.New("must be a boolean")
+			}
+			return strconv.FormatBool(b), nil
+		}
+		return "", errors.New("must be a boolean")
+	case AttributeTypeEnum:
+		v, ok := raw.(string)
+		if !ok {
+			return "", fmt.Errorf("must be one of %s", strings.Join(def.Options, ", "))
+		}
+		for _, option := range def.Options {
+			if v == option {
+				return v, nil
+			}
+		}
+		return "", fmt.Errorf("must be one of %s", strings.Join(def.Options, ", "))
+	default:
+		v, ok := raw.(string)
+		if !ok {
+			return "", errors.New("must be a string")
+		}
+		return v, nil
+	}
+}
+
+// TableName returns the table name for AttributeDefinition
+func (AttributeDefinition) TableName() string {
+	return "attribute_definitions"
+}
+
+// TenantParent implements tenant.Parent: attribute definitions belong to the tenant of
+// their category
+func (AttributeDefinition) TenantParent() (string, string) {
+	return "category_id", "categories"
+}
+
+// TableName returns the table name for ProductAttribute
+func (ProductAttribute) TableName() string {
+	return "product_attributes"
+}
+
+// TenantParent implements tenant.Parent: attribute values belong to the tenant of
+// their product
+func (ProductAttribute) TenantParent() (string, string) {
+	return "product_id", "products"
+}
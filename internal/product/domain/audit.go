@@ -0,0 +1,126 @@
+package domain
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audited entity types
+const (
+	AuditEntityProduct   = "product"
+	AuditEntityCategory  = "category"
+	AuditEntityBrand     = "brand"
+	AuditEntityWarehouse = "warehouse"
+	AuditEntityVendor    = "vendor"
+)
+
+// Audited actions
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+)
+
+// auditIgnoredFields are excluded from change diffs
+var auditIgnoredFields = map[string]bool{
+	"created_at":   true,
+	"updated_at":   true,
+	"category":     true,
+	"brand":        true,
+	"parent":       true,
+	"children":     true,
+	"breadcrumbs":  true,
+	"availability": true,
+	"rank":         true,
+	"highlight":    true,
+}
+
+// AuditEvent records a single mutation of a catalog entity
+type AuditEvent struct {
+	ID         uuid.UUID              `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID   string                 `json:"tenant_id,omitempty" gorm:"not null"`
+	ActorID    string                 `json:"actor_id" gorm:"not null"`
+	EntityType string                 `json:"entity_type" gorm:"not null"`
+	EntityID   uuid.UUID              `json:"entity_id" gorm:"type:uuid;not null"`
+	Action     string                 `json:"action" gorm:"not null"`
+	Changes    map[string]FieldChange `json:"changes,omitempty" gorm:"type:jsonb;serializer:json"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// FieldChange holds the before and after values of a changed field
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditFilters represents filters for audit queries
+type AuditFilters struct {
+	EntityType string     `json:"entity_type,omitempty"`
+	EntityID   *uuid.UUID `json:"entity_id,omitempty"`
+	ActorID    string     `json:"actor_id,omitempty"`
+	From       *time.Time `json:"from,omitempty"`
+	To         *time.Time `json:"to,omitempty"`
+	Limit      int        `json:"limit,omitempty"`
+	Offset     int        `json:"offset,omitempty"`
+}
+
+// AuditEventList represents a paginated list of audit events
+type AuditEventList struct {
+	Events  []AuditEvent `json:"events"`
+	Total   int64        `json:"total"`
+	Limit   int          `json:"limit"`
+	Offset  int          `json:"offset"`
+	HasMore bool         `json:"has_more"`
+}
+
+// DiffFields compares the JSON representations of two entities and returns
+// the fields that differ. A nil before or after yields a full snapshot.
+func DiffFields(before, after interface{}) map[string]FieldChange {
+	beforeFields := toFieldMap(before)
+	afterFields := toFieldMap(after)
+
+	changes := make(map[string]FieldChange)
+	for key, value := range afterFields {
+		if auditIgnoredFields[key] {
+			continue
+		}
+		if previous, ok := beforeFields[key]; !ok || !reflect.DeepEqual(previous, value) {
+			changes[key] = FieldChange{Before: beforeFields[key], After: value}
+		}
+	}
+	for key, value := range beforeFields {
+		if auditIgnoredFields[key] {
+			continue
+		}
+		if _, ok := afterFields[key]; !ok {
+			changes[key] = FieldChange{Before: value}
+		}
+	}
+
+	return changes
+}
+
+func toFieldMap(entity interface{}) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if entity == nil {
+		return fields
+	}
+	if v := reflect.ValueOf(entity); v.Kind() == reflect.Ptr && v.IsNil() {
+		return fields
+	}
+	payload, err := json.Marshal(entity)
+	if err != nil {
+		return fields
+	}
+	_ = json.Unmarshal(payload, &fields)
+	return fields
+}
+
+// TableName returns the table name for AuditEvent
+func (AuditEvent) TableName() string {
+	return "audit_events"
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Availability statuses, as reported on products
+const (
+	AvailabilityInStock    = "in_stock"
+	AvailabilityBackorder  = "backorder"
+	AvailabilityPreorder   = "preorder"
+	AvailabilityOutOfStock = "out_of_stock"
+)
+
+// PreorderAt reports whether the product takes preorders at t: it is
+// flagged for preorder and has not been released yet
+func (p *Product) PreorderAt(t time.Time) bool {
+	return p.Preorder && (p.ReleaseDate == nil || t.Before(*p.ReleaseDate))
+}
+
+// CanOversellAt reports whether the product may be reserved beyond its
+// stock at t, taking its stock below zero
+func (p *Product) CanOversellAt(t time.Time) bool {
+	return p.AllowBackorder || p.PreorderAt(t)
+}
+
+// AvailabilityAt returns the product's availability status at t. A
+// preorder is reported as such until its release, whatever the stock, and
+// products that hold no stock are always in stock, bundles as long as
+// their components make one up.
+func (p *Product) AvailabilityAt(t time.Time) string {
+	switch {
+	case p.PreorderAt(t):
+		return AvailabilityPreorder
+	case p.BundleStock != nil && *p.BundleStock <= 0:
+		return AvailabilityOutOfStock
+	case p.Stock > 0 || !p.IsPhysical():
+		return AvailabilityInStock
+	case p.AllowBackorder:
+		return AvailabilityBackorder
+	default:
+		return AvailabilityOutOfStock
+	}
+}
+
+// BackorderStock adds the part of a quantity that stock levels could not
+// cover to what was taken from them, charging it to the given warehouse.
+// The warehouse's part grows when one was already taken from it.
+func BackorderStock(taken []StockLevel, warehouseID uuid.UUID, quantity int) []StockLevel {
+	for _, part := range taken {
+		quantity -= part.Quantity
+	}
+	if quantity <= 0 {
+		return taken
+	}
+
+	for idx := range taken {
+		if taken[idx].WarehouseID == warehouseID {
+			taken[idx].Quantity += quantity
+			return taken
+		}
+	}
+	return append(taken, StockLevel{WarehouseID: warehouseID, Quantity: quantity})
+}
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Brand represents a product brand or manufacturer
+type Brand struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID    string    `json:"tenant_id,omitempty" gorm:"not null"`
+	Name        string    `json:"name" gorm:"not null;unique" validate:"required,min=1,max=100"`
+	Description string    `json:"description"`
+	LogoURL     string    `json:"logo_url"`
+	IsActive    bool      `json:"is_active" gorm:"default:true"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CreateBrandRequest represents the request to create a brand
+type CreateBrandRequest struct {
+	Name        string `json:"name" validate:"required,min=1,max=100"`
+	Description string `json:"description"`
+	LogoURL     string `json:"logo_url" validate:"omitempty,url"`
+}
+
+// UpdateBrandRequest represents the request to update a brand
+type UpdateBrandRequest struct {
+	Name        *string `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
+	Description *string `json:"description,omitempty"`
+	LogoURL     *string `json:"logo_url,omitempty" validate:"omitempty,url"`
+	IsActive    *bool   `json:"is_active,omitempty"`
+}
+
+// TableName returns the table name for Brand
+func (Brand) TableName() string {
+	return "brands"
+}
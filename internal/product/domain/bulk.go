@@ -0,0 +1,52 @@
+package domain
+
+import "github.com/google/uuid"
+
+// Bulk update actions
+const (
+	BulkActionSetPrice     = "set_price"
+	BulkActionActivate     = "activate"
+	BulkActionDeactivate   = "deactivate"
+	BulkActionMoveCategory = "move_category"
+)
+
+// BulkOperation is a single change in a bulk update. Price goes with
+// set_price and CategoryID with move_category.
+type BulkOperation struct {
+	ProductID  uuid.UUID  `json:"product_id" validate:"required"`
+	Action     string     `json:"action" validate:"required,oneof=set_price activate deactivate move_category"`
+	Price      *float64   `json:"price,omitempty" validate:"omitempty,gt=0"`
+	CategoryID *uuid.UUID `json:"category_id,omitempty"`
+}
+
+// BulkUpdateRequest represents the request to change many products at once
+type BulkUpdateRequest struct {
+	Operations []BulkOperation `json:"operations" validate:"required,min=1,max=500,dive"`
+}
+
+// ProductChange is a prepared bulk operation: the columns to set on a
+// product and, when its category changes, its revalidated attributes
+type ProductChange struct {
+	ProductID         uuid.UUID
+	Columns           map[string]interface{}
+	ReplaceAttributes bool
+	Attributes        []ProductAttribute
+}
+
+// BulkOperationResult reports the outcome of one operation, by its position
+// in the request
+type BulkOperationResult struct {
+	Index     int       `json:"index"`
+	ProductID uuid.UUID `json:"product_id"`
+	Action    string    `json:"action"`
+	Success   bool      `json:"success"`
+	Code      string    `json:"code,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// BulkUpdateResult reports the outcome of a bulk update
+type BulkUpdateResult struct {
+	Succeeded int                   `json:"succeeded"`
+	Failed    int                   `json:"failed"`
+	Results   []BulkOperationResult `json:"results"`
+}
@@ -0,0 +1,126 @@
+package domain
+
+import (
+	"bytes"
+	"cmp"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Bundle pricing. A fixed bundle is sold at its own price; a computed one
+// at the total of its components' regular prices less its discount.
+const (
+	BundlePricingFixed    = "fixed"
+	BundlePricingComputed = "computed"
+)
+
+// BundleComponent is a product sold as part of a bundle, in the quantity
+// each bundle holds
+type BundleComponent struct {
+	BundleID    uuid.UUID `json:"-" gorm:"type:uuid;primaryKey"`
+	ComponentID uuid.UUID `json:"product_id" gorm:"type:uuid;primaryKey"`
+	Quantity    int       `json:"quantity" gorm:"not null"`
+
+	// Filled in on reads
+	SKU   string `json:"sku,omitempty" gorm:"-"`
+	Name  string `json:"name,omitempty" gorm:"-"`
+	Stock int    `json:"stock" gorm:"-"`
+}
+
+// BundleComponentInput is a component of a bundle as given in a request
+type BundleComponentInput struct {
+	ProductID uuid.UUID `json:"product_id" validate:"required"`
+	Quantity  int       `json:"quantity" validate:"required,gt=0"`
+}
+
+// SetBundleComponentsRequest replaces the components of a bundle
+type SetBundleComponentsRequest struct {
+	Components []BundleComponentInput `json:"components" validate:"required,min=1,max=50,dive"`
+}
+
+// StockDemand is stock a reservation takes of one product, either for the
+// product itself or as a component of the bundle it names
+type StockDemand struct {
+	ProductID uuid.UUID
+	Quantity  int
+	BundleID  *uuid.UUID
+}
+
+// BundleDemands returns the stock reserving items takes: each item itself,
+// and for each bundle among them its components, in the quantities the
+// bundles hold. Demands come ordered by product, so reservations lock
+// products in a fixed order, with a product's own demand ahead of those of
+// the bundles it is part of.
+func BundleDemands(items []StockItem, components []BundleComponent) []StockDemand {
+	demands := make([]StockDemand, 0, len(items)+len(components))
+	for _, item := range items {
+		demands = append(demands, StockDemand{ProductID: item.ProductID, Quantity: item.Quantity})
+		for _, component := range components {
+			if component.BundleID == item.ProductID {
+				bundleID := item.ProductID
+				demands = append(demands, StockDemand{
+					ProductID: component.ComponentID,
+					Quantity:  component.Quantity * item.Quantity,
+					BundleID:  &bundleID,
+				})
+			}
+		}
+	}
+	slices.SortStableFunc(demands, func(a, b StockDemand) int {
+		return cmp.Or(
+			bytes.Compare(a.ProductID[:], b.ProductID[:]),
+			cmp.Compare(boolRank(a.BundleID != nil), boolRank(b.BundleID != nil)),
+		)
+	})
+	return demands
+}
+
+// boolRank orders false ahead of true
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// BundlePrice returns the price of a computed bundle whose components cost
+// total, less a discount in percent
+func BundlePrice(total, discount float64) float64 {
+	return math.Round(total*(100-discount)) / 100
+}
+
+// BundleAvailable returns how many whole bundles the components' stock
+// makes up at t, or false when no component limits it because none holds
+// stock or all of those that do can be oversold. A missing component makes
+// up no bundles.
+func BundleAvailable(components []BundleComponent, products map[uuid.UUID]*Product, t time.Time) (int, bool) {
+	available, limited := 0, false
+	for _, component := range components {
+		product, ok := products[component.ComponentID]
+		if !ok {
+			return 0, true
+		}
+		if !product.IsPhysical() || product.CanOversellAt(t) {
+			continue
+		}
+		n := max(product.Stock, 0) / component.Quantity
+		if !limited || n < available {
+			available, limited = n, true
+		}
+	}
+	return available, limited
+}
+
+// TableName returns the table name for BundleComponent
+func (BundleComponent) TableName() string {
+	return "bundle_components"
+}
+
+// TenantParent implements tenant.Parent: components belong to the tenant of
+// their bundle
+func (BundleComponent) TenantParent() (string, string) {
+	return "bundle_id", "products"
+}
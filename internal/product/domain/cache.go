@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Cache namespaces of the product service, the first part of their keys
+const (
+	CacheNamespaceProduct        = "product"                  // product:<tenant>:<id>, a product read by ID
+	CacheNamespaceProductList    = "products:list"            // a page of a product listing
+	CacheNamespaceCategoryCounts = "products:category_counts" // product counts per category
+	CacheNamespaceSynonyms       = "search:synonyms"          // every search synonym
+)
+
+// CacheEntry describes a cached key, for debugging stale data
+type CacheEntry struct {
+	Key       string          `json:"key"`
+	Namespace string          `json:"namespace"`
+	Exists    bool            `json:"exists"`
+	TTL       *int64          `json:"ttl_seconds,omitempty"` // seconds left; omitted when the key doesn't expire
+	Size      int             `json:"size"`
+	Value     json.RawMessage `json:"value,omitempty"`
+	Local     bool            `json:"local"` // held in the local cache of the replica that answered
+}
+
+// CacheKeyList represents cached keys matching a pattern
+type CacheKeyList struct {
+	Keys      []string `json:"keys"`
+	Truncated bool     `json:"truncated"` // more keys match than were listed
+}
+
+// PurgeCacheRequest represents the request to drop cached keys, given by
+// name, by pattern or both
+type PurgeCacheRequest struct {
+	Keys    []string `json:"keys,omitempty" validate:"omitempty,max=1000,dive,required"`
+	Pattern string   `json:"pattern,omitempty"`
+}
+
+// PurgeCacheResult reports how many keys a purge dropped
+type PurgeCacheResult struct {
+	Deleted int64 `json:"deleted"`
+}
+
+// WarmCacheRequest represents the request to load products into the cache
+type WarmCacheRequest struct {
+	ProductIDs []uuid.UUID `json:"product_ids" validate:"required,min=1,max=500"`
+	Async      bool        `json:"async"` // queue the warming as a background job instead of waiting for it
+}
+
+// WarmCacheResult reports how many of the requested products were cached;
+// the rest don't exist. Queued warmings report only what was requested.
+type WarmCacheResult struct {
+	Requested int  `json:"requested"`
+	Cached    int  `json:"cached"`
+	Queued    bool `json:"queued,omitempty"`
+}
+
+// CacheNamespace returns the namespace a cache key belongs to, or "" when
+// it isn't one of the product service's
+func CacheNamespace(key string) string {
+	switch {
+	case strings.HasPrefix(key, CacheNamespaceProductList+":"):
+		return CacheNamespaceProductList
+	case key == CacheNamespaceCategoryCounts, strings.HasPrefix(key, CacheNamespaceCategoryCounts+":"):
+		return CacheNamespaceCategoryCounts
+	case strings.HasPrefix(key, CacheNamespaceProduct+":"):
+		return CacheNamespaceProduct
+	}
+	return ""
+}
+
+// ValidCachePattern reports whether a key pattern can only match keys of
+// the product service, so admin tools can't reach other data in Redis
+func ValidCachePattern(pattern string) bool {
+	return strings.HasPrefix(pattern, CacheNamespaceProduct)
+}
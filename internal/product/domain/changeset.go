@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Changeset statuses
+const (
+	ChangesetStatusDraft      = "draft"       // being put together
+	ChangesetStatusScheduled  = "scheduled"   // applied once ApplyAt passes
+	ChangesetStatusApplied    = "applied"     // every edit is live
+	ChangesetStatusFailed     = "failed"      // could not be applied, so none of it was
+	ChangesetStatusRolledBack = "rolled_back" // applied, then undone
+)
+
+// Changeset is a named set of product edits staged to go live together,
+// such as the price changes of a campaign. Its edits are applied in one
+// transaction, now or at ApplyAt, and can be rolled back the same way.
+type Changeset struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID     string     `json:"tenant_id,omitempty" gorm:"not null"`
+	Name         string     `json:"name" gorm:"not null"`
+	Description  string     `json:"description,omitempty"`
+	Status       string     `json:"status" gorm:"not null;default:draft"`
+	ApplyAt      *time.Time `json:"apply_at,omitempty"`
+	AppliedAt    *time.Time `json:"applied_at,omitempty"`
+	RolledBackAt *time.Time `json:"rolled_back_at,omitempty"`
+	Error        string     `json:"error,omitempty"` // why the last attempt to apply it failed
+	CreatedBy    string     `json:"created_by,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+
+	Items []ChangesetItem `json:"items,omitempty" gorm:"foreignKey:ChangesetID"`
+}
+
+// ChangesetItem is the edit a changeset makes to one product. Patch is a
+// JSON Merge Patch of the product, as PATCH /products/:id takes; Revert is
+// the patch that undoes it, recorded as it is applied.
+type ChangesetItem struct {
+	ID          uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ChangesetID uuid.UUID       `json:"changeset_id" gorm:"type:uuid;not null"`
+	ProductID   uuid.UUID       `json:"product_id" gorm:"type:uuid;not null"`
+	Patch       json.RawMessage `json:"patch" gorm:"type:jsonb;serializer:json;not null"`
+	Revert      json.RawMessage `json:"revert,omitempty" gorm:"type:jsonb;serializer:json"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+
+	// Filled in by previews: what the item would change in the product as
+	// it is now, or why it can't
+	Changes map[string]FieldChange `json:"changes,omitempty" gorm:"-"`
+	Problem string                 `json:"problem,omitempty" gorm:"-"`
+}
+
+// CreateChangesetRequest represents the request to create a changeset
+type CreateChangesetRequest struct {
+	Name        string `json:"name" validate:"required,min=1,max=200"`
+	Description string `json:"description" validate:"max=2000"`
+}
+
+// UpdateChangesetRequest represents the request to update a changeset
+type UpdateChangesetRequest struct {
+	Name        *string `json:"name,omitempty" validate:"omitempty,min=1,max=200"`
+	Description *string `json:"description,omitempty" validate:"omitempty,max=2000"`
+}
+
+// SetChangesetItemRequest sets the edit a changeset makes to a product
+type SetChangesetItemRequest struct {
+	Patch json.RawMessage `json:"patch" validate:"required"`
+}
+
+// ScheduleChangesetRequest represents the request to apply a changeset at
+// a later time
+type ScheduleChangesetRequest struct {
+	ApplyAt time.Time `json:"apply_at" validate:"required"`
+}
+
+// ChangesetFilters represents filters for changeset queries
+type ChangesetFilters struct {
+	Status string `json:"status,omitempty"`
+	Limit  int    `json:"limit,omitempty"`
+	Offset int    `json:"offset,omitempty"`
+}
+
+// ChangesetList represents a paginated list of changesets
+type ChangesetList struct {
+	Changesets []Changeset `json:"changesets"`
+	Total      int64       `json:"total"`
+	Limit      int         `json:"limit"`
+	Offset     int         `json:"offset"`
+	HasMore    bool        `json:"has_more"`
+}
+
+// Editable reports whether the changeset's edits may still change: once it
+// is applied they are what went live
+func (c *Changeset) Editable() bool {
+	return c.Status == ChangesetStatusDraft || c.Status == ChangesetStatusScheduled || c.Status == ChangesetStatusFailed
+}
+
+// TableName returns the table name for Changeset
+func (Changeset) TableName() string {
+	return "changesets"
+}
+
+// TableName returns the table name for ChangesetItem
+func (ChangesetItem) TableName() string {
+	return "changeset_items"
+}
+
+// TenantParent implements tenant.Parent: changeset items belong to the
+// tenant of their changeset
+func (ChangesetItem) TenantParent() (string, string) {
+	return "changeset_id", "changesets"
+}
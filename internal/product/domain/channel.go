@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// Sales channels products are sold through
+const (
+	ChannelWeb         = "web"
+	ChannelMobileApp   = "mobile_app"
+	ChannelMarketplace = "marketplace"
+	ChannelPOS         = "pos"
+)
+
+// Channels lists every sales channel
+var Channels = []string{ChannelWeb, ChannelMobileApp, ChannelMarketplace, ChannelPOS}
+
+// ValidChannel reports whether channel is a known sales channel
+func ValidChannel(channel string) bool {
+	return slices.Contains(Channels, channel)
+}
+
+// ChannelSettings sets how a product is sold through one sales channel.
+// Products are visible in every channel at their regular price unless
+// their settings for it say otherwise.
+type ChannelSettings struct {
+	Hidden bool     `json:"hidden,omitempty"`
+	Price  *float64 `json:"price,omitempty"` // replaces the regular price in the channel; a sale still applies
+}
+
+// ChannelSettingsInput is the settings of a channel as given in a request
+type ChannelSettingsInput struct {
+	Channel string   `json:"channel" validate:"required,oneof=web mobile_app marketplace pos"`
+	Hidden  bool     `json:"hidden"`
+	Price   *float64 `json:"price,omitempty" validate:"omitempty,gt=0"`
+}
+
+// SetProductChannelsRequest replaces the channel settings of a product; an
+// empty list makes it visible everywhere at its regular price
+type SetProductChannelsRequest struct {
+	Channels []ChannelSettingsInput `json:"channels" validate:"max=10,dive"`
+}
+
+// ProductChannels turns the inputs into the settings stored on a product,
+// checking that no channel is given twice. Channels set to the defaults are
+// left out.
+func ProductChannels(inputs []ChannelSettingsInput) (map[string]ChannelSettings, error) {
+	channels := make(map[string]ChannelSettings, len(inputs))
+	seen := make(map[string]bool, len(inputs))
+	for _, input := range inputs {
+		if seen[input.Channel] {
+			return nil, fmt.Errorf("channel %q given more than once", input.Channel)
+		}
+		seen[input.Channel] = true
+		if input.Hidden || input.Price != nil {
+			channels[input.Channel] = ChannelSettings{Hidden: input.Hidden, Price: input.Price}
+		}
+	}
+	if len(channels) == 0 {
+		return nil, nil
+	}
+	return channels, nil
+}
+
+// VisibleIn reports whether the product is sold through a channel. Every
+// product is visible when no channel is asked for.
+func (p *Product) VisibleIn(channel string) bool {
+	return channel == "" || !p.Channels[channel].Hidden
+}
+
+// channelPrice returns the price that replaces the regular price in the
+// channel the product is priced for, if any
+func (p *Product) channelPrice() *float64 {
+	if p.Channel == "" {
+		return nil
+	}
+	return p.Channels[p.Channel].Price
+}
+
+type channelKey struct{}
+
+// WithChannel returns a copy of ctx asking for products priced for a sales
+// channel
+func WithChannel(ctx context.Context, channel string) context.Context {
+	return context.WithValue(ctx, channelKey{}, channel)
+}
+
+// ChannelFromContext returns the sales channel stored in ctx, or "" when
+// products should be priced regardless of channel
+func ChannelFromContext(ctx context.Context) string {
+	channel, _ := ctx.Value(channelKey{}).(string)
+	return channel
+}
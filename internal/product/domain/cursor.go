@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ProductCursor identifies the last product of a page for keyset pagination.
+// Products are ordered by (created_at, id) so the pair is unique and stable.
+type ProductCursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        uuid.UUID `json:"i"`
+}
+
+// NewProductCursor builds a cursor pointing after the given product
+func NewProductCursor(product *Product) *ProductCursor {
+	return &ProductCursor{
+		CreatedAt: product.CreatedAt,
+		ID:        product.ID,
+	}
+}
+
+// Encode returns the opaque string form of the cursor
+func (c *ProductCursor) Encode() string {
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+// DecodeProductCursor parses an opaque cursor string
+func DecodeProductCursor(encoded string) (*ProductCursor, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor ProductCursor
+	if err := json.Unmarshal(payload, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.ID == uuid.Nil || cursor.CreatedAt.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
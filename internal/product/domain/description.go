@@ -0,0 +1,10 @@
+package domain
+
+import "ecommerce/pkg/richtext"
+
+// SetDescription sets a product's description to the HTML of description
+// the storefront may render, and its text to what search indexes
+func (p *Product) SetDescription(description string) {
+	p.Description = richtext.Sanitize(description)
+	p.DescriptionText = richtext.PlainText(p.Description)
+}
@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Product types. Only physical products hold stock and are shipped; digital
+// products are delivered as downloads once ordered, and services need
+// neither. Bundles hold no stock of their own but ship their components.
+// Gift cards are issued by the payment service when they are ordered.
+const (
+	ProductTypePhysical = "physical"
+	ProductTypeDigital  = "digital"
+	ProductTypeService  = "service"
+	ProductTypeBundle   = "bundle"
+	ProductTypeGiftCard = "gift_card"
+)
+
+// IsPhysical reports whether the product holds stock and is shipped
+func (p *Product) IsPhysical() bool {
+	return p.Type == "" || p.Type == ProductTypePhysical
+}
+
+// DigitalAsset is a file customers download once they have ordered a
+// digital product. The file itself is kept in object storage under the key.
+type DigitalAsset struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProductID   uuid.UUID `json:"product_id" gorm:"type:uuid;not null"`
+	StorageKey  string    `json:"storage_key" gorm:"not null"`
+	Filename    string    `json:"filename" gorm:"not null"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CreateDigitalAssetRequest represents the request to attach a file already
+// in object storage to a digital product. The filename defaults to the last
+// part of the key.
+type CreateDigitalAssetRequest struct {
+	StorageKey string `json:"storage_key" validate:"required,max=1024"`
+	Filename   string `json:"filename,omitempty" validate:"max=255"`
+}
+
+// Entitlement grants the customer of an order the downloads of a digital
+// product in it, under a license key issued when the order was fulfilled.
+// An order has at most one entitlement per product.
+type Entitlement struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrderID     uuid.UUID  `json:"order_id" gorm:"type:uuid;not null"`
+	CustomerID  string     `json:"customer_id" gorm:"not null"`
+	ProductID   uuid.UUID  `json:"product_id" gorm:"type:uuid;not null"`
+	ProductName string     `json:"product_name" gorm:"not null"`
+	Quantity    int        `json:"quantity" gorm:"not null"`
+	LicenseKey  string     `json:"license_key" gorm:"not null;unique"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Downloads   []Download `json:"downloads,omitempty" gorm:"-"`
+}
+
+// Download is a signed link to one of an entitlement's files, valid until
+// it expires
+type Download struct {
+	AssetID     uuid.UUID `json:"asset_id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type,omitempty"`
+	Size        int64     `json:"size,omitempty"`
+	URL         string    `json:"url"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// EntitlementFilters represents options for listing entitlements
+type EntitlementFilters struct {
+	CustomerID string     `json:"customer_id,omitempty"`
+	OrderID    *uuid.UUID `json:"order_id,omitempty"`
+	Limit      int        `json:"limit,omitempty"`
+	Offset     int        `json:"offset,omitempty"`
+}
+
+// EntitlementList represents a paginated list of entitlements
+type EntitlementList struct {
+	Entitlements []Entitlement `json:"entitlements"`
+	Total        int64         `json:"total"`
+	Limit        int           `json:"limit"`
+	Offset       int           `json:"offset"`
+	HasMore      bool          `json:"has_more"`
+}
+
+// OrderEvent is the part of an order service event that fulfillment reads
+type OrderEvent struct {
+	ID         uuid.UUID        `json:"id"`
+	CustomerID string           `json:"customer_id"`
+	Email      string           `json:"email,omitempty"`
+	Items      []OrderEventItem `json:"items"`
+}
+
+// OrderEventItem is a product line of an order
+type OrderEventItem struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Name      string    `json:"name"`
+	Quantity  int       `json:"quantity"`
+}
+
+// DownloadsGranted is the payload of downloads.granted, listing the
+// entitlements an order was just granted
+type DownloadsGranted struct {
+	OrderID      uuid.UUID     `json:"order_id"`
+	CustomerID   string        `json:"customer_id"`
+	Email        string        `json:"email,omitempty"`
+	Entitlements []Entitlement `json:"entitlements"`
+}
+
+// TableName returns the table name for DigitalAsset
+func (DigitalAsset) TableName() string {
+	return "digital_assets"
+}
+
+// TenantParent implements tenant.Parent: digital assets belong to the tenant of
+// their product
+func (DigitalAsset) TenantParent() (string, string) {
+	return "product_id", "products"
+}
+
+// TableName returns the table name for Entitlement
+func (Entitlement) TableName() string {
+	return "entitlements"
+}
+
+// TenantParent implements tenant.Parent: entitlements belong to the tenant of
+// their product
+func (Entitlement) TenantParent() (string, string) {
+	return "product_id", "products"
+}
@@ -0,0 +1,41 @@
+package domain
+
+// EventSource identifies events published by the product service
+const EventSource = "product-service"
+
+// Product event types
+const (
+	EventProductCreated  = "product.created"
+	EventProductUpdated  = "product.updated"
+	EventProductDeleted  = "product.deleted"
+	EventProductRestored = "product.restored"
+	EventStockLow        = "stock.low"
+	EventStockChanged    = "stock.changed"
+)
+
+// Fulfillment event types. Downloads are granted for the digital products
+// of each order the order service reports created.
+const (
+	EventOrderCreated     = "order.created"
+	EventDownloadsGranted = "downloads.granted"
+)
+
+// Review event types. Whoever submitted a vendor product or a change to a
+// product for review hears how it went.
+const (
+	EventProductReviewed = "product.reviewed"
+)
+
+// Category event types
+const (
+	EventCategoryCreated = "category.created"
+	EventCategoryUpdated = "category.updated"
+	EventCategoryDeleted = "category.deleted"
+)
+
+// Brand event types
+const (
+	EventBrandCreated = "brand.created"
+	EventBrandUpdated = "brand.updated"
+	EventBrandDeleted = "brand.deleted"
+)
@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// productFieldNames are the JSON names of a product's fields
+var productFieldNames = jsonFieldNames(reflect.TypeOf(Product{}))
+
+// ProductFields is a sparse fieldset: the product fields a client asked
+// for. The zero value selects every field.
+type ProductFields []string
+
+// ParseProductFields parses a comma separated list of product fields, such
+// as name,price,sku. The ID is always included.
+func ParseProductFields(raw string) (ProductFields, error) {
+	selected := map[string]bool{"id": true}
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !productFieldNames[name] {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		selected[name] = true
+	}
+	if len(selected) == 1 {
+		return nil, nil
+	}
+
+	fields := make(ProductFields, 0, len(selected))
+	for name := range selected {
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+	return fields, nil
+}
+
+// Includes reports whether a field is selected
+func (f ProductFields) Includes(name string) bool {
+	if len(f) == 0 {
+		return true
+	}
+	for _, field := range f {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Select trims products to the selected fields
+func (f ProductFields) Select(products []Product) []SparseProduct {
+	sparse := make([]SparseProduct, len(products))
+	for i := range products {
+		sparse[i] = SparseProduct{Product: &products[i], Fields: f}
+	}
+	return sparse
+}
+
+// SparseProduct is a product that serializes only the selected fields
+type SparseProduct struct {
+	Product *Product
+	Fields  ProductFields
+}
+
+// MarshalJSON implements json.Marshaler
+func (p SparseProduct) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(p.Product)
+	if err != nil || len(p.Fields) == 0 {
+		return data, err
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+	for name := range members {
+		if !p.Fields.Includes(name) {
+			delete(members, name)
+		}
+	}
+	return json.Marshal(members)
+}
+
+// SparseProductList is a product list trimmed to the selected fields
+type SparseProductList struct {
+	*ProductList
+	Products []SparseProduct `json:"products"`
+}
+
+// jsonFieldNames returns the JSON names of a struct's fields
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FlashSale sells a limited quantity of a product at a sale price for a
+// short window. A product has at most one open flash sale. Its quantity is
+// set aside from the product's stock when the sale is set up, so regular
+// orders can't take it, and what is left unsold is put back once it closes.
+//
+// While the sale runs, purchases are taken in Redis and written here in
+// the background; Sold counts those written so far.
+type FlashSale struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID  string    `json:"tenant_id,omitempty" gorm:"not null"`
+	ProductID uuid.UUID `json:"product_id" gorm:"type:uuid;not null"`
+	StartsAt  time.Time `json:"starts_at" gorm:"not null"`
+	EndsAt    time.Time `json:"ends_at" gorm:"not null"`
+	Price     float64   `json:"price" gorm:"not null"`
+	Quantity  int       `json:"quantity" gorm:"not null"`
+	Sold      int       `json:"sold" gorm:"not null;default:0"`
+
+	// PerUserLimit bounds the units one customer may buy; 0 for no limit
+	PerUserLimit int `json:"per_user_limit" gorm:"not null;default:0"`
+
+	// Held is the stock set aside for the sale; products that hold no
+	// stock have none
+	Held int `json:"held" gorm:"not null;default:0"`
+
+	ClosedAt  *time.Time `json:"closed_at,omitempty"`
+	CreatedBy string     `json:"created_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+
+	// Remaining is the quantity still for sale, filled in on reads
+	Remaining int `json:"remaining" gorm:"-"`
+}
+
+// FlashSalePurchase is units of a flash sale bought by one customer. Its ID
+// is given when the purchase is taken, so writing it is safe to repeat.
+type FlashSalePurchase struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key"`
+	FlashSaleID uuid.UUID `json:"flash_sale_id" gorm:"type:uuid;not null"`
+	ProductID   uuid.UUID `json:"product_id" gorm:"type:uuid;not null"`
+	UserID      string    `json:"user_id" gorm:"not null"`
+	Quantity    int       `json:"quantity" gorm:"not null"`
+	Price       float64   `json:"price" gorm:"not null"`
+	PurchasedAt time.Time `json:"purchased_at" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// SetFlashSaleRequest represents the request to set up a product's flash
+// sale, or change it before it starts
+type SetFlashSaleRequest struct {
+	StartsAt     time.Time `json:"starts_at" validate:"required"`
+	EndsAt       time.Time `json:"ends_at" validate:"required,gtfield=StartsAt"`
+	Price        float64   `json:"price" validate:"required,gt=0"`
+	Quantity     int       `json:"quantity" validate:"required,gt=0"`
+	PerUserLimit int       `json:"per_user_limit" validate:"gte=0"`
+}
+
+// FlashSalePurchaseRequest represents the request to buy units of a flash
+// sale
+type FlashSalePurchaseRequest struct {
+	Quantity int `json:"quantity" validate:"required,gt=0"`
+}
+
+// Started reports whether the sale's window has opened
+func (f *FlashSale) Started(now time.Time) bool {
+	return !now.Before(f.StartsAt)
+}
+
+// Running reports whether the sale takes purchases at now
+func (f *FlashSale) Running(now time.Time) bool {
+	return f.ClosedAt == nil && f.Started(now) && now.Before(f.EndsAt)
+}
+
+// Unsold is the stock held for the sale that nobody bought
+func (f *FlashSale) Unsold() int {
+	return max(f.Held-f.Sold, 0)
+}
+
+// ValidateFor checks that the sale can be held on a product: it must be
+// for sale, and sold at a discount
+func (f *FlashSale) ValidateFor(product *Product) error {
+	if !product.IsPublished() || !product.IsActive {
+		return errors.New("flash sales are only held on published products")
+	}
+	if f.Price >= product.Price {
+		return errors.New("flash sale price must be below the product's price")
+	}
+	if !f.EndsAt.After(time.Now()) {
+		return errors.New("flash sale must end in the future")
+	}
+	return nil
+}
+
+// TableName returns the table name for FlashSale
+func (FlashSale) TableName() string {
+	return "flash_sales"
+}
+
+// TableName returns the table name for FlashSalePurchase
+func (FlashSalePurchase) TableName() string {
+	return "flash_sale_purchases"
+}
+
+// TenantParent implements tenant.Parent: purchases belong to the tenant of
+// their sale
+func (FlashSalePurchase) TenantParent() (string, string) {
+	return "flash_sale_id", "flash_sales"
+}
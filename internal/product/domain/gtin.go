@@ -0,0 +1,42 @@
+package domain
+
+import "errors"
+
+// ValidateGTIN checks that the product's GTIN, if it has one, is a GTIN-8,
+// UPC-A (GTIN-12), EAN-13 or GTIN-14 with a correct check digit
+func (p *Product) ValidateGTIN() error {
+	if p.GTIN == "" {
+		return nil
+	}
+	return validateGTIN(p.GTIN)
+}
+
+func validateGTIN(gtin string) error {
+	switch len(gtin) {
+	case 8, 12, 13, 14:
+	default:
+		return errors.New("GTIN must have 8, 12, 13 or 14 digits")
+	}
+
+	// Digits are weighted 3 and 1 alternately from the right, starting
+	// with the digit before the check digit
+	sum := 0
+	for i := 0; i < len(gtin); i++ {
+		c := gtin[i]
+		if c < '0' || c > '9' {
+			return errors.New("GTIN must only contain digits")
+		}
+		if i == len(gtin)-1 {
+			break
+		}
+		digit := int(c - '0')
+		if (len(gtin)-1-i)%2 == 1 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	if check := (10 - sum%10) % 10; int(gtin[len(gtin)-1]-'0') != check {
+		return errors.New("GTIN check digit is wrong")
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Import job statuses
+const (
+	ImportStatusPending   = "pending"
+	ImportStatusRunning   = "running"
+	ImportStatusCompleted = "completed"
+	ImportStatusFailed    = "failed"
+)
+
+// MaxImportRowErrors caps the number of row errors stored on a job
+const MaxImportRowErrors = 1000
+
+// ImportJob tracks the progress of an asynchronous product import
+type ImportJob struct {
+	ID            uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID      string           `json:"tenant_id,omitempty" gorm:"not null"`
+	Filename      string           `json:"filename"`
+	Status        string           `json:"status" gorm:"not null;default:pending"`
+	TotalRows     int              `json:"total_rows"`
+	ProcessedRows int              `json:"processed_rows"`
+	SucceededRows int              `json:"succeeded_rows"`
+	FailedRows    int              `json:"failed_rows"`
+	Errors        []ImportRowError `json:"errors" gorm:"type:jsonb;serializer:json"`
+	Message       string           `json:"message,omitempty"`
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+	CompletedAt   *time.Time       `json:"completed_at,omitempty"`
+}
+
+// ImportRowError describes why a single row could not be imported.
+// Row numbers are 1-based and include the header row.
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	SKU   string `json:"sku,omitempty"`
+	Error string `json:"error"`
+}
+
+// AddError records a row failure, keeping at most MaxImportRowErrors entries
+func (j *ImportJob) AddError(row int, sku string, message string) {
+	j.FailedRows++
+	if len(j.Errors) < MaxImportRowErrors {
+		j.Errors = append(j.Errors, ImportRowError{Row: row, SKU: sku, Error: message})
+	}
+}
+
+// TableName returns the table name for ImportJob
+func (ImportJob) TableName() string {
+	return "import_jobs"
+}
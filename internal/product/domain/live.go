@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProductUpdate is a change to a product's stock or price, pushed to the
+// storefront pages watching the product. Every update carries the
+// product's stock; those made by a change to the product itself also
+// carry its prices.
+type ProductUpdate struct {
+	ProductID          uuid.UUID      `json:"product_id"`
+	Stock              int            `json:"stock"`
+	AvailabilityStatus string         `json:"availability_status,omitempty"`
+	Prices             *ProductPrices `json:"prices,omitempty"`
+	At                 time.Time      `json:"at"`
+}
+
+// ProductPrices are the prices of a product as shown to a shopper outside
+// any customer group or sales channel, buying a single unit
+type ProductPrices struct {
+	Price          float64  `json:"price"`
+	SalePrice      *float64 `json:"sale_price,omitempty"`
+	EffectivePrice float64  `json:"effective_price"`
+	OnSale         bool     `json:"on_sale"`
+}
+
+// NewProductUpdate returns the update a change to a product makes, with its
+// prices in force at t
+func NewProductUpdate(product *Product, t time.Time) ProductUpdate {
+	public := *product
+	public.Channel = ""
+
+	return ProductUpdate{
+		ProductID:          product.ID,
+		Stock:              product.Stock,
+		AvailabilityStatus: product.AvailabilityAt(t),
+		Prices: &ProductPrices{
+			Price:          product.Price,
+			SalePrice:      product.SalePrice,
+			EffectivePrice: public.PriceFor("", 1, t),
+			OnSale:         product.OnSaleAt(t),
+		},
+		At: t,
+	}
+}
+
+// NewStockUpdate returns the update a stock movement makes
+func NewStockUpdate(movement *StockMovement) ProductUpdate {
+	return ProductUpdate{
+		ProductID: movement.ProductID,
+		Stock:     movement.Balance,
+		At:        movement.CreatedAt,
+	}
+}
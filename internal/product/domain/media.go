@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"ecommerce/pkg/media"
+)
+
+// Media statuses. Media is pending from the moment an upload URL is handed
+// out until the upload is checked and its thumbnails are made.
+const (
+	MediaStatusPending = "pending"
+	MediaStatusReady   = "ready"
+)
+
+// Media is an image of a product kept in object storage
+type Media struct {
+	ID          uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProductID   uuid.UUID        `json:"product_id" gorm:"type:uuid;not null"`
+	StorageKey  string           `json:"-" gorm:"not null;uniqueIndex"`
+	Filename    string           `json:"filename" gorm:"not null"`
+	ContentType string           `json:"content_type" gorm:"not null"`
+	Size        int64            `json:"size"`
+	Width       int              `json:"width,omitempty"`
+	Height      int              `json:"height,omitempty"`
+	Status      string           `json:"status" gorm:"not null;default:pending"`
+	Thumbnails  []MediaThumbnail `json:"thumbnails" gorm:"type:jsonb;serializer:json"`
+	URL         string           `json:"url,omitempty" gorm:"-"`
+	Variants    *media.Variants  `json:"variants,omitempty" gorm:"-"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// MediaThumbnail is a scaled down copy of a media image, fitted into a
+// square box of Size pixels. It is stored next to the original, under a key
+// derived from the original's.
+type MediaThumbnail struct {
+	Size        int    `json:"size"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	ContentType string `json:"content_type"`
+	URL         string `json:"url,omitempty"`
+}
+
+// CreateMediaUploadRequest represents the request for a URL to upload a
+// product image to
+type CreateMediaUploadRequest struct {
+	Filename    string `json:"filename" validate:"required,max=255"`
+	ContentType string `json:"content_type" validate:"required"`
+	Size        int64  `json:"size" validate:"required,gt=0"`
+}
+
+// MediaUpload tells a client where and how to upload a file. The upload
+// must send the listed headers, and is only accepted once completed.
+type MediaUpload struct {
+	Media     *Media            `json:"media"`
+	UploadURL string            `json:"upload_url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// TableName returns the table name for Media
+func (Media) TableName() string {
+	return "product_media"
+}
+
+// TenantParent implements tenant.Parent: media belong to the tenant of
+// their product
+func (Media) TenantParent() (string, string) {
+	return "product_id", "products"
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// OnSaleAt reports whether the product's sale price applies at t
+func (p *Product) OnSaleAt(t time.Time) bool {
+	if p.SalePrice == nil {
+		return false
+	}
+	if p.SaleStartsAt != nil && t.Before(*p.SaleStartsAt) {
+		return false
+	}
+	if p.SaleEndsAt != nil && !t.Before(*p.SaleEndsAt) {
+		return false
+	}
+	return true
+}
+
+// PriceAt returns the price in force at t: the sale price inside the sale
+// window, the regular price otherwise
+func (p *Product) PriceAt(t time.Time) float64 {
+	if p.OnSaleAt(t) {
+		return *p.SalePrice
+	}
+	return p.Price
+}
+
+// ValidateSale checks that a sale undercuts the regular price and that its
+// window ends after it starts
+func (p *Product) ValidateSale() error {
+	if p.SalePrice != nil && *p.SalePrice >= p.Price {
+		return errors.New("sale price must be below the regular price")
+	}
+	if p.SaleStartsAt != nil && p.SaleEndsAt != nil && !p.SaleEndsAt.After(*p.SaleStartsAt) {
+		return errors.New("sale must end after it starts")
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler. The effective price, for the
+// customer group and quantity filled in on read, and the availability
+// status are worked out on the way out, so products served from a cache
+// still switch price when a sale window opens or closes, and leave
+// preorder on their release date.
+func (p Product) MarshalJSON() ([]byte, error) {
+	type product Product
+
+	now := time.Now()
+	p.EffectivePrice = p.PriceFor(p.CustomerGroup, p.PricedQuantity, now)
+	p.OnSale = p.OnSaleAt(now)
+	p.AvailabilityStatus = p.AvailabilityAt(now)
+	return json.Marshal(product(p))
+}
@@ -1,31 +1,149 @@
 package domain
 
 import (
+	"bytes"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"ecommerce/pkg/media"
 )
 
 // Product represents a product in the system
 type Product struct {
-	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	Name        string    `json:"name" gorm:"not null" validate:"required,min=1,max=255"`
-	Description string    `json:"description" gorm:"type:text"`
-	Price       float64   `json:"price" gorm:"not null" validate:"required,gt=0"`
-	CategoryID  uuid.UUID `json:"category_id" gorm:"type:uuid"`
-	Category    *Category `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
-	Stock       int       `json:"stock" gorm:"default:0" validate:"gte=0"`
-	ImageURL    string    `json:"image_url"`
-	SKU         string    `json:"sku" gorm:"unique"`
-	IsActive    bool      `json:"is_active" gorm:"default:true"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID    string         `json:"tenant_id,omitempty" gorm:"not null"` // the tenant the product belongs to; see pkg/tenant
+	Name        string         `json:"name" gorm:"not null" validate:"required,min=1,max=255"`
+	Slug        string         `json:"slug" gorm:"uniqueIndex"`
+	Description string         `json:"description" gorm:"type:text"`
+	Price       float64        `json:"price" gorm:"not null" validate:"required,gt=0"`
+	CategoryID  uuid.UUID      `json:"category_id" gorm:"type:uuid"`
+	Category    *Category      `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	BrandID     *uuid.UUID     `json:"brand_id,omitempty" gorm:"type:uuid;index"`
+	Brand       *Brand         `json:"brand,omitempty" gorm:"foreignKey:BrandID"`
+	Stock       int            `json:"stock" gorm:"default:0" validate:"gte=0"`
+	ImageURL    string         `json:"image_url"`
+	SKU         string         `json:"sku" gorm:"unique"`
+	GTIN        string         `json:"gtin,omitempty"`
+	IsActive    bool           `json:"is_active" gorm:"default:true"`
+	Type        string         `json:"type" gorm:"not null;default:physical"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Description is HTML the storefront renders, sanitized on write to an
+	// allowlist of tags and attributes; DescriptionText is its text, which
+	// search indexes. Both are set with SetDescription.
+	DescriptionText string `json:"description_text,omitempty" gorm:"type:text"`
+
+	// Only published products are shown on the storefront; is_active says
+	// whether a published product can be bought. A draft with PublishAt set
+	// is published by the scheduled publishing check once that time passes.
+	Status      string     `json:"status" gorm:"not null;default:published"`
+	PublishAt   *time.Time `json:"publish_at,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+
+	// Stock at or below the threshold is reported with stock.low; without
+	// one the service-wide threshold applies. The alert time is set by the
+	// low stock check and cleared when stock recovers.
+	LowStockThreshold *int       `json:"low_stock_threshold,omitempty" validate:"omitempty,gte=0"`
+	LowStockAlertedAt *time.Time `json:"low_stock_alerted_at,omitempty" gorm:"->"`
+
+	// A sale price applies from SaleStartsAt until SaleEndsAt; either end
+	// may be left open. EffectivePrice and OnSale report the price in force
+	// when the product is serialized. SaleActive is kept in step with the
+	// window by the sale check, which announces each change.
+	SalePrice      *float64   `json:"sale_price,omitempty" validate:"omitempty,gt=0"`
+	SaleStartsAt   *time.Time `json:"sale_starts_at,omitempty"`
+	SaleEndsAt     *time.Time `json:"sale_ends_at,omitempty"`
+	SaleActive     bool       `json:"-" gorm:"->"`
+	EffectivePrice float64    `json:"effective_price" gorm:"-"`
+	OnSale         bool       `json:"on_sale" gorm:"-"`
+
+	// Backordered and preordered products can be reserved beyond their
+	// stock, which then goes negative. A preorder lasts until ReleaseDate,
+	// or indefinitely without one. AvailabilityStatus is worked out when
+	// the product is serialized.
+	AllowBackorder     bool       `json:"allow_backorder" gorm:"not null;default:false"`
+	Preorder           bool       `json:"preorder" gorm:"not null;default:false"`
+	ReleaseDate        *time.Time `json:"release_date,omitempty"`
+	AvailabilityStatus string     `json:"availability_status" gorm:"-"`
+
+	// A bundle is sold at its own price, or with computed pricing at the
+	// total of its components' prices less BundleDiscount percent, kept up
+	// to date as they change. Components and BundleStock, the number of
+	// bundles the components' stock makes up, are filled in on reads of a
+	// single bundle; BundleStock is left unset when no component limits it.
+	BundlePricing  string            `json:"bundle_pricing,omitempty"`
+	BundleDiscount float64           `json:"bundle_discount,omitempty" gorm:"not null;default:0"`
+	Components     []BundleComponent `json:"components,omitempty" gorm:"-"`
+	BundleStock    *int              `json:"bundle_stock,omitempty" gorm:"-"`
+
+	// Stock and prices count units of measure. Orders take multiples of
+	// QuantityIncrement, so a product sold in packs of six has 6, and at
+	// least MinOrderQuantity and at most MaxOrderQuantity units; a zero
+	// limit is no limit.
+	UnitOfMeasure     string `json:"unit_of_measure" gorm:"not null;default:each"`
+	QuantityIncrement int    `json:"quantity_increment" gorm:"not null;default:1"`
+	MinOrderQuantity  int    `json:"min_order_quantity,omitempty" gorm:"not null;default:0"`
+	MaxOrderQuantity  int    `json:"max_order_quantity,omitempty" gorm:"not null;default:0"`
+
+	// TaxClass picks the rate the tax service charges on the product
+	TaxClass string `json:"tax_class" gorm:"not null;default:standard"`
+
+	// Price tiers price the product lower for customer groups and larger
+	// quantities. The tiers open to the requesting customer are filled in
+	// on reads, with the group and quantity EffectivePrice is worked out
+	// for.
+	PriceTiers     []PriceTier `json:"price_tiers,omitempty" gorm:"-"`
+	CustomerGroup  string      `json:"customer_group,omitempty" gorm:"-"`
+	PricedQuantity int         `json:"priced_quantity,omitempty" gorm:"-"`
+
+	// Channels hides the product from sales channels or prices it
+	// differently there. Channel is the channel EffectivePrice is worked
+	// out for, filled in on reads that ask for one.
+	Channels map[string]ChannelSettings `json:"channels,omitempty" gorm:"type:jsonb;serializer:json"`
+	Channel  string                     `json:"channel,omitempty" gorm:"-"`
+
+	// Products listed by a marketplace vendor belong to the vendor, who
+	// alone may change them, and wait for approval before they can be
+	// published. ApprovalStatus is empty for the shop's own products;
+	// ReviewComment is what the reviewer last said about the product.
+	VendorID       *uuid.UUID `json:"vendor_id,omitempty" gorm:"type:uuid;index"`
+	ApprovalStatus string     `json:"approval_status,omitempty" gorm:"not null;default:''"`
+	ReviewComment  string     `json:"review_comment,omitempty"`
+
+	Attributes []ProductAttribute `json:"attributes,omitempty" gorm:"foreignKey:ProductID"`
+
+	// Images is image_url sized for delivery through the image CDN; filled
+	// in on reads when one is configured
+	Images *media.Variants `json:"images,omitempty" gorm:"-"`
+
+	// Breadcrumbs lead from the top-level category down to the product's
+	// own; filled in on reads
+	Breadcrumbs []Breadcrumb `json:"breadcrumbs,omitempty" gorm:"-"`
+
+	// Availability is the product's stock in each warehouse holding it;
+	// filled in on reads that ask for a warehouse
+	Availability []StockLevel `json:"availability,omitempty" gorm:"-"`
+
+	// Denormalized from approved reviews; maintained by the review workflow
+	RatingAverage float64 `json:"rating_average" gorm:"->"`
+	ReviewCount   int     `json:"review_count" gorm:"->"`
+
+	// Search-only fields, populated by full-text queries
+	Rank      float64 `json:"rank,omitempty" gorm:"->;-:migration"`
+	Highlight string  `json:"highlight,omitempty" gorm:"->;-:migration"`
 }
 
 // Category represents a product category
 type Category struct {
 	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID    string     `json:"tenant_id,omitempty" gorm:"not null"`
 	Name        string     `json:"name" gorm:"not null;unique" validate:"required,min=1,max=100"`
+	Slug        string     `json:"slug" gorm:"uniqueIndex"`
 	Description string     `json:"description"`
 	ParentID    *uuid.UUID `json:"parent_id" gorm:"type:uuid"`
 	Parent      *Category  `json:"parent,omitempty" gorm:"foreignKey:ParentID"`
@@ -33,17 +151,73 @@ type Category struct {
 	IsActive    bool       `json:"is_active" gorm:"default:true"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// Breadcrumbs lead from the top-level category down to this one; filled
+	// in on reads
+	Breadcrumbs []Breadcrumb `json:"breadcrumbs,omitempty" gorm:"-"`
+
+	// Counts of live, active, published products, filled in by the category
+	// listing. The total includes subcategories and is only set on request.
+	ProductCount      *int64 `json:"product_count,omitempty" gorm:"-"`
+	TotalProductCount *int64 `json:"total_product_count,omitempty" gorm:"-"`
+}
+
+// CategoryFilters represents options for the category listing
+type CategoryFilters struct {
+	IncludeDescendants bool `json:"include_descendants,omitempty"` // also count products in subcategories
+}
+
+// CategoryProductCount is the number of products in a category, directly
+// and including its subcategories
+type CategoryProductCount struct {
+	Direct int64 `json:"direct"`
+	Total  int64 `json:"total"`
+}
+
+// Breadcrumb is one category on the path to a category or product
+type Breadcrumb struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+	Slug string    `json:"slug"`
 }
 
 // CreateProductRequest represents the request to create a product
 type CreateProductRequest struct {
-	Name        string    `json:"name" validate:"required,min=1,max=255"`
-	Description string    `json:"description"`
-	Price       float64   `json:"price" validate:"required,gt=0"`
-	CategoryID  uuid.UUID `json:"category_id" validate:"required"`
-	Stock       int       `json:"stock" validate:"gte=0"`
-	ImageURL    string    `json:"image_url"`
-	SKU         string    `json:"sku" validate:"required"`
+	Name        string     `json:"name" validate:"required,min=1,max=255"`
+	Description string     `json:"description"`
+	Price       float64    `json:"price" validate:"required,gt=0"`
+	CategoryID  uuid.UUID  `json:"category_id" validate:"required"`
+	BrandID     *uuid.UUID `json:"brand_id,omitempty"`
+	Stock       int        `json:"stock" validate:"gte=0"`
+	ImageURL    string     `json:"image_url"`
+	SKU         string     `json:"sku" validate:"required"`
+	GTIN        string     `json:"gtin,omitempty"`
+	Type        string     `json:"type,omitempty" validate:"omitempty,oneof=physical digital service bundle gift_card"` // physical when omitted
+
+	Status    string     `json:"status,omitempty" validate:"omitempty,oneof=draft published archived"` // published when omitted
+	PublishAt *time.Time `json:"publish_at,omitempty"`                                                 // drafts only
+
+	LowStockThreshold *int `json:"low_stock_threshold,omitempty" validate:"omitempty,gte=0"`
+
+	SalePrice    *float64   `json:"sale_price,omitempty" validate:"omitempty,gt=0"`
+	SaleStartsAt *time.Time `json:"sale_starts_at,omitempty"`
+	SaleEndsAt   *time.Time `json:"sale_ends_at,omitempty"`
+
+	AllowBackorder bool       `json:"allow_backorder,omitempty"`
+	Preorder       bool       `json:"preorder,omitempty"`
+	ReleaseDate    *time.Time `json:"release_date,omitempty"`
+
+	BundlePricing  string  `json:"bundle_pricing,omitempty" validate:"omitempty,oneof=fixed computed"` // bundles only; fixed when omitted
+	BundleDiscount float64 `json:"bundle_discount,omitempty" validate:"gte=0,lt=100"`
+
+	UnitOfMeasure     string `json:"unit_of_measure,omitempty" validate:"omitempty,max=20"` // each when omitted
+	QuantityIncrement int    `json:"quantity_increment,omitempty" validate:"gte=0"`         // 1 when omitted
+	MinOrderQuantity  int    `json:"min_order_quantity,omitempty" validate:"gte=0"`
+	MaxOrderQuantity  int    `json:"max_order_quantity,omitempty" validate:"gte=0"`
+
+	TaxClass string `json:"tax_class,omitempty" validate:"omitempty,oneof=standard reduced zero exempt"` // standard when omitted
+
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
 }
 
 // UpdateProductRequest represents the request to update a product
@@ -52,35 +226,340 @@ type UpdateProductRequest struct {
 	Description *string    `json:"description,omitempty"`
 	Price       *float64   `json:"price,omitempty" validate:"omitempty,gt=0"`
 	CategoryID  *uuid.UUID `json:"category_id,omitempty"`
+	BrandID     *uuid.UUID `json:"brand_id,omitempty"`
 	Stock       *int       `json:"stock,omitempty" validate:"omitempty,gte=0"`
 	ImageURL    *string    `json:"image_url,omitempty"`
 	SKU         *string    `json:"sku,omitempty"`
+	GTIN        *string    `json:"gtin,omitempty"`
 	IsActive    *bool      `json:"is_active,omitempty"`
+	Type        *string    `json:"type,omitempty" validate:"omitempty,oneof=physical digital service bundle gift_card"`
+
+	Status    *string    `json:"status,omitempty" validate:"omitempty,oneof=draft published archived"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+
+	LowStockThreshold *int `json:"low_stock_threshold,omitempty" validate:"omitempty,gte=0"`
+
+	SalePrice    *float64   `json:"sale_price,omitempty" validate:"omitempty,gt=0"`
+	SaleStartsAt *time.Time `json:"sale_starts_at,omitempty"`
+	SaleEndsAt   *time.Time `json:"sale_ends_at,omitempty"`
+
+	AllowBackorder *bool      `json:"allow_backorder,omitempty"`
+	Preorder       *bool      `json:"preorder,omitempty"`
+	ReleaseDate    *time.Time `json:"release_date,omitempty"`
+
+	BundlePricing  *string  `json:"bundle_pricing,omitempty" validate:"omitempty,oneof=fixed computed"`
+	BundleDiscount *float64 `json:"bundle_discount,omitempty" validate:"omitempty,gte=0,lt=100"`
+
+	UnitOfMeasure     *string `json:"unit_of_measure,omitempty" validate:"omitempty,min=1,max=20"`
+	QuantityIncrement *int    `json:"quantity_increment,omitempty" validate:"omitempty,gte=1"`
+	MinOrderQuantity  *int    `json:"min_order_quantity,omitempty" validate:"omitempty,gte=0"` // 0 removes the limit
+	MaxOrderQuantity  *int    `json:"max_order_quantity,omitempty" validate:"omitempty,gte=0"` // 0 removes the limit
+
+	TaxClass *string `json:"tax_class,omitempty" validate:"omitempty,oneof=standard reduced zero exempt"`
+
+	Attributes map[string]interface{} `json:"attributes,omitempty"` // replaces all attributes when set
+}
+
+// DuplicateProductRequest represents the request to copy a product. The
+// SKU and name are suggested from the original when omitted.
+type DuplicateProductRequest struct {
+	SKU  string `json:"sku,omitempty"`
+	Name string `json:"name,omitempty" validate:"omitempty,min=1,max=255"`
+}
+
+// ProductDocument is the part of a product that merge patches apply to.
+// Description, GTIN, brand, image, publish time, low stock threshold, sale,
+// release date, order quantity limits and attributes may be removed by a
+// patch; the other fields are required.
+type ProductDocument struct {
+	Name        string     `json:"name" validate:"required,min=1,max=255"`
+	Description string     `json:"description"`
+	Price       float64    `json:"price" validate:"required,gt=0"`
+	CategoryID  uuid.UUID  `json:"category_id" validate:"required"`
+	BrandID     *uuid.UUID `json:"brand_id"`
+	Stock       int        `json:"stock"` // below zero when oversold; a patch cannot set it there
+	ImageURL    string     `json:"image_url"`
+	SKU         string     `json:"sku" validate:"required"`
+	GTIN        string     `json:"gtin"`
+	IsActive    bool       `json:"is_active"`
+	Type        string     `json:"type" validate:"required,oneof=physical digital service bundle gift_card"`
+
+	Status    string     `json:"status" validate:"required,oneof=draft published archived"`
+	PublishAt *time.Time `json:"publish_at"`
+
+	LowStockThreshold *int `json:"low_stock_threshold" validate:"omitempty,gte=0"`
+
+	SalePrice    *float64   `json:"sale_price" validate:"omitempty,gt=0"`
+	SaleStartsAt *time.Time `json:"sale_starts_at"`
+	SaleEndsAt   *time.Time `json:"sale_ends_at"`
+
+	AllowBackorder bool       `json:"allow_backorder"`
+	Preorder       bool       `json:"preorder"`
+	ReleaseDate    *time.Time `json:"release_date"`
+
+	BundlePricing  string  `json:"bundle_pricing,omitempty" validate:"omitempty,oneof=fixed computed"`
+	BundleDiscount float64 `json:"bundle_discount" validate:"gte=0,lt=100"`
+
+	UnitOfMeasure     string `json:"unit_of_measure" validate:"required,max=20"`
+	QuantityIncrement int    `json:"quantity_increment" validate:"required,gte=1"`
+	MinOrderQuantity  int    `json:"min_order_quantity" validate:"gte=0"`
+	MaxOrderQuantity  int    `json:"max_order_quantity" validate:"gte=0"`
+
+	TaxClass string `json:"tax_class" validate:"required,oneof=standard reduced zero exempt"`
+
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// ProductDocumentRequired lists the members a patch may not remove
+var ProductDocumentRequired = []string{"name", "price", "category_id", "stock", "sku", "is_active", "type", "status", "unit_of_measure", "quantity_increment", "tax_class"}
+
+// NewProductDocument returns the patchable fields of a product
+func NewProductDocument(product *Product) *ProductDocument {
+	attributes := make(map[string]interface{}, len(product.Attributes))
+	for _, attribute := range product.Attributes {
+		attributes[attribute.Key] = attribute.Value
+	}
+
+	return &ProductDocument{
+		Name:        product.Name,
+		Description: product.Description,
+		Price:       product.Price,
+		CategoryID:  product.CategoryID,
+		BrandID:     product.BrandID,
+		Stock:       product.Stock,
+		ImageURL:    product.ImageURL,
+		SKU:         product.SKU,
+		GTIN:        product.GTIN,
+		IsActive:    product.IsActive,
+		Type:        product.Type,
+		Attributes:  attributes,
+
+		Status:    product.Status,
+		PublishAt: product.PublishAt,
+
+		LowStockThreshold: product.LowStockThreshold,
+
+		SalePrice:    product.SalePrice,
+		SaleStartsAt: product.SaleStartsAt,
+		SaleEndsAt:   product.SaleEndsAt,
+
+		AllowBackorder: product.AllowBackorder,
+		Preorder:       product.Preorder,
+		ReleaseDate:    product.ReleaseDate,
+
+		BundlePricing:  product.BundlePricing,
+		BundleDiscount: product.BundleDiscount,
+
+		UnitOfMeasure:     product.UnitOfMeasure,
+		QuantityIncrement: product.QuantityIncrement,
+		MinOrderQuantity:  product.MinOrderQuantity,
+		MaxOrderQuantity:  product.MaxOrderQuantity,
+
+		TaxClass: product.TaxClass,
+	}
+}
+
+// ProductClears lists the optional fields an update removes, which an
+// update request cannot express
+type ProductClears struct {
+	Brand             bool
+	PublishAt         bool
+	LowStockThreshold bool
+	SalePrice         bool
+	SaleStartsAt      bool
+	SaleEndsAt        bool
+	ReleaseDate       bool
+}
+
+// Changes returns the update that turns d into next
+func (d *ProductDocument) Changes(next *ProductDocument) (*UpdateProductRequest, ProductClears) {
+	req := &UpdateProductRequest{}
+	if next.Name != d.Name {
+		req.Name = &next.Name
+	}
+	if next.Description != d.Description {
+		req.Description = &next.Description
+	}
+	if next.Price != d.Price {
+		req.Price = &next.Price
+	}
+	if next.CategoryID != d.CategoryID {
+		req.CategoryID = &next.CategoryID
+	}
+	if next.BrandID != nil && (d.BrandID == nil || *next.BrandID != *d.BrandID) {
+		req.BrandID = next.BrandID
+	}
+	if next.Stock != d.Stock {
+		req.Stock = &next.Stock
+	}
+	if next.ImageURL != d.ImageURL {
+		req.ImageURL = &next.ImageURL
+	}
+	if next.SKU != d.SKU {
+		req.SKU = &next.SKU
+	}
+	if next.GTIN != d.GTIN {
+		req.GTIN = &next.GTIN
+	}
+	if next.IsActive != d.IsActive {
+		req.IsActive = &next.IsActive
+	}
+	if next.Type != d.Type {
+		req.Type = &next.Type
+	}
+	if next.Status != d.Status {
+		req.Status = &next.Status
+	}
+	if next.PublishAt != nil && (d.PublishAt == nil || !next.PublishAt.Equal(*d.PublishAt)) {
+		req.PublishAt = next.PublishAt
+	}
+	if next.LowStockThreshold != nil && (d.LowStockThreshold == nil || *next.LowStockThreshold != *d.LowStockThreshold) {
+		req.LowStockThreshold = next.LowStockThreshold
+	}
+	if next.SalePrice != nil && (d.SalePrice == nil || *next.SalePrice != *d.SalePrice) {
+		req.SalePrice = next.SalePrice
+	}
+	if next.SaleStartsAt != nil && (d.SaleStartsAt == nil || !next.SaleStartsAt.Equal(*d.SaleStartsAt)) {
+		req.SaleStartsAt = next.SaleStartsAt
+	}
+	if next.SaleEndsAt != nil && (d.SaleEndsAt == nil || !next.SaleEndsAt.Equal(*d.SaleEndsAt)) {
+		req.SaleEndsAt = next.SaleEndsAt
+	}
+	if next.AllowBackorder != d.AllowBackorder {
+		req.AllowBackorder = &next.AllowBackorder
+	}
+	if next.Preorder != d.Preorder {
+		req.Preorder = &next.Preorder
+	}
+	if next.ReleaseDate != nil && (d.ReleaseDate == nil || !next.ReleaseDate.Equal(*d.ReleaseDate)) {
+		req.ReleaseDate = next.ReleaseDate
+	}
+	if next.BundlePricing != d.BundlePricing {
+		req.BundlePricing = &next.BundlePricing
+	}
+	if next.BundleDiscount != d.BundleDiscount {
+		req.BundleDiscount = &next.BundleDiscount
+	}
+	if next.UnitOfMeasure != d.UnitOfMeasure {
+		req.UnitOfMeasure = &next.UnitOfMeasure
+	}
+	if next.QuantityIncrement != d.QuantityIncrement {
+		req.QuantityIncrement = &next.QuantityIncrement
+	}
+	if next.MinOrderQuantity != d.MinOrderQuantity {
+		req.MinOrderQuantity = &next.MinOrderQuantity
+	}
+	if next.MaxOrderQuantity != d.MaxOrderQuantity {
+		req.MaxOrderQuantity = &next.MaxOrderQuantity
+	}
+	if next.TaxClass != d.TaxClass {
+		req.TaxClass = &next.TaxClass
+	}
+	if !sameJSON(next.Attributes, d.Attributes) {
+		req.Attributes = next.Attributes
+		if req.Attributes == nil {
+			req.Attributes = map[string]interface{}{}
+		}
+	}
+
+	return req, ProductClears{
+		Brand:             next.BrandID == nil && d.BrandID != nil,
+		PublishAt:         next.PublishAt == nil && d.PublishAt != nil,
+		LowStockThreshold: next.LowStockThreshold == nil && d.LowStockThreshold != nil,
+		SalePrice:         next.SalePrice == nil && d.SalePrice != nil,
+		SaleStartsAt:      next.SaleStartsAt == nil && d.SaleStartsAt != nil,
+		SaleEndsAt:        next.SaleEndsAt == nil && d.SaleEndsAt != nil,
+		ReleaseDate:       next.ReleaseDate == nil && d.ReleaseDate != nil,
+	}
+}
+
+// sameJSON reports whether a and b encode to the same JSON. Attributes
+// decoded from a patch hold JSON's types rather than those they were read
+// with, which only differ in type, not in what they say.
+func sameJSON(a, b interface{}) bool {
+	x, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	y, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(x, y)
 }
 
 // ProductFilters represents filters for product queries
 type ProductFilters struct {
 	CategoryID *uuid.UUID `json:"category_id,omitempty"`
+	BrandID    *uuid.UUID `json:"brand_id,omitempty"`
 	MinPrice   *float64   `json:"min_price,omitempty"`
 	MaxPrice   *float64   `json:"max_price,omitempty"`
 	Search     string     `json:"search,omitempty"`
 	IsActive   *bool      `json:"is_active,omitempty"`
 	InStock    *bool      `json:"in_stock,omitempty"`
+	Warehouse  string     `json:"warehouse,omitempty"` // stock and in_stock count this warehouse only
+	Channel    string     `json:"channel,omitempty"`   // only products visible in this sales channel, priced for it
+	VendorID   *uuid.UUID `json:"vendor_id,omitempty"` // only products sold by this vendor
 	Limit      int        `json:"limit,omitempty"`
 	Offset     int        `json:"offset,omitempty"`
-	SortBy     string     `json:"sort_by,omitempty"`    // name, price, created_at
+	Cursor     string     `json:"cursor,omitempty"`     // opaque keyset cursor, takes precedence over offset
+	SortBy     string     `json:"sort_by,omitempty"`    // name, price, created_at, rating, relevance
 	SortOrder  string     `json:"sort_order,omitempty"` // asc, desc
+	Facets     bool       `json:"facets,omitempty"`     // include facet counts in the response
+
+	IncludeDeleted bool   `json:"include_deleted,omitempty"` // admin only: include soft-deleted products
+	Status         string `json:"status,omitempty"`          // storefront callers only ever see published products
+	ApprovalStatus string `json:"approval_status,omitempty"` // only vendor products in this approval status
+
+	Fields ProductFields `json:"fields,omitempty"` // associations outside the fieldset are not loaded
+
+	// After is the decoded form of Cursor, populated by the service layer
+	After *ProductCursor `json:"-"`
+
+	// SearchAlternatives are rephrasings of Search using synonyms, populated
+	// by the service layer; products matching any of them match the search
+	SearchAlternatives []string `json:"-"`
 }
 
 // ProductList represents a paginated list of products
 type ProductList struct {
-	Products []Product `json:"products"`
-	Total    int64     `json:"total"`
-	Limit    int       `json:"limit"`
-	Offset   int       `json:"offset"`
-	HasMore  bool      `json:"has_more"`
+	Products   []Product      `json:"products"`
+	Total      int64          `json:"total"`
+	Limit      int            `json:"limit"`
+	Offset     int            `json:"offset"`
+	HasMore    bool           `json:"has_more"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	Facets     *ProductFacets `json:"facets,omitempty"`
+}
+
+// ProductFacets holds aggregate counts used to render filter sidebars.
+// Each facet is computed with every filter applied except its own.
+type ProductFacets struct {
+	Categories  []FacetCount      `json:"categories"`
+	Brands      []FacetCount      `json:"brands"`
+	PriceRanges []PriceRangeFacet `json:"price_ranges"`
+	InStock     int64             `json:"in_stock"`
+	OutOfStock  int64             `json:"out_of_stock"`
 }
 
+// FacetCount represents the number of products matching a facet value
+type FacetCount struct {
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"`
+	Count int64  `json:"count"`
+}
+
+// PriceRangeFacet represents the number of products in a price bucket.
+// Min is inclusive, Max is exclusive and nil for the open-ended bucket.
+type PriceRangeFacet struct {
+	Min   float64  `json:"min"`
+	Max   *float64 `json:"max,omitempty"`
+	Count int64    `json:"count"`
+}
+
+// PriceBucketBounds are the lower bounds of the price facet buckets
+var PriceBucketBounds = []float64{0, 25, 50, 100, 250, 500}
+
 // CreateCategoryRequest represents the request to create a category
 type CreateCategoryRequest struct {
 	Name        string     `json:"name" validate:"required,min=1,max=100"`
@@ -96,6 +575,17 @@ type UpdateCategoryRequest struct {
 	IsActive    *bool      `json:"is_active,omitempty"`
 }
 
+// MoveCategoryRequest represents the request to move a category subtree.
+// A null parent moves it to the top level.
+type MoveCategoryRequest struct {
+	ParentID *uuid.UUID `json:"parent_id"`
+}
+
+// MergeCategoryRequest represents the request to merge a category into another
+type MergeCategoryRequest struct {
+	TargetID uuid.UUID `json:"target_id" validate:"required"`
+}
+
 // TableName returns the table name for Product
 func (Product) TableName() string {
 	return "products"
@@ -105,3 +595,38 @@ func (Product) TableName() string {
 func (Category) TableName() string {
 	return "categories"
 }
+
+// BuildCategoryTree nests a flat, parent-before-child list of categories.
+// Categories whose parent is not in the list become roots.
+func BuildCategoryTree(categories []Category) []Category {
+	children := make(map[uuid.UUID][]int, len(categories))
+	present := make(map[uuid.UUID]bool, len(categories))
+	for _, category := range categories {
+		present[category.ID] = true
+	}
+
+	var roots []int
+	for i, category := range categories {
+		if category.ParentID != nil && present[*category.ParentID] {
+			children[*category.ParentID] = append(children[*category.ParentID], i)
+		} else {
+			roots = append(roots, i)
+		}
+	}
+
+	var build func(idx int) Category
+	build = func(idx int) Category {
+		node := categories[idx]
+		node.Children = make([]Category, 0, len(children[node.ID]))
+		for _, child := range children[node.ID] {
+			node.Children = append(node.Children, build(child))
+		}
+		return node
+	}
+
+	tree := make([]Category, 0, len(roots))
+	for _, idx := range roots {
+		tree = append(tree, build(idx))
+	}
+	return tree
+}
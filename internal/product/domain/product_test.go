@@ -0,0 +1,71 @@
+package domain_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/mergepatch"
+)
+
+func TestProductDocumentChanges(t *testing.T) {
+	product := &domain.Product{
+		Name:       "Trail Shoe",
+		Price:      89.99,
+		CategoryID: uuid.New(),
+		Stock:      5,
+		SKU:        "TRAIL-1",
+		Attributes: []domain.ProductAttribute{
+			{Key: "size", Value: "42", Type: "number"},
+			{Key: "color", Value: "blue", Type: "string"},
+		},
+	}
+
+	// patch applies a merge patch to the product's document as PATCH does
+	patch := func(t *testing.T, patch string) (*domain.ProductDocument, *domain.ProductDocument) {
+		current := domain.NewProductDocument(product)
+		doc, err := json.Marshal(current)
+		if err != nil {
+			t.Fatal(err)
+		}
+		merged, err := mergepatch.Apply(doc, []byte(patch))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var next domain.ProductDocument
+		if err := json.Unmarshal(merged, &next); err != nil {
+			t.Fatal(err)
+		}
+		return current, &next
+	}
+
+	t.Run("patch not touching attributes", func(t *testing.T) {
+		current, next := patch(t, `{"name":"Trail Shoe 2"}`)
+		req, _ := current.Changes(next)
+		if req.Attributes != nil {
+			t.Errorf("Attributes = %v, want no change", req.Attributes)
+		}
+		if req.Name == nil || *req.Name != "Trail Shoe 2" {
+			t.Errorf("Name = %v, want Trail Shoe 2", req.Name)
+		}
+	})
+
+	t.Run("values differing only in type", func(t *testing.T) {
+		current := &domain.ProductDocument{Attributes: map[string]interface{}{"size": 42}}
+		next := &domain.ProductDocument{Attributes: map[string]interface{}{"size": float64(42)}}
+		req, _ := current.Changes(next)
+		if req.Attributes != nil {
+			t.Errorf("Attributes = %v, want no change", req.Attributes)
+		}
+	})
+
+	t.Run("patch changing an attribute", func(t *testing.T) {
+		current, next := patch(t, `{"attributes":{"color":"red"}}`)
+		req, _ := current.Changes(next)
+		if req.Attributes["color"] != "red" || req.Attributes["size"] != "42" {
+			t.Errorf("Attributes = %v, want color red and size kept", req.Attributes)
+		}
+	})
+}
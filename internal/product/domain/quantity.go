@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// UnitEach is the unit of measure of products sold by the piece
+const UnitEach = "each"
+
+// ValidateQuantities checks that the order quantity limits leave some
+// quantity that can be ordered
+func (p *Product) ValidateQuantities() error {
+	if p.QuantityIncrement < 1 {
+		return errors.New("quantity increment must be at least 1")
+	}
+	if p.MaxOrderQuantity == 0 {
+		return nil
+	}
+	if p.MinOrderQuantity > p.MaxOrderQuantity {
+		return errors.New("minimum order quantity must not exceed the maximum")
+	}
+	if p.MaxOrderQuantity/p.QuantityIncrement*p.QuantityIncrement < p.MinOrderQuantity {
+		return errors.New("no multiple of the quantity increment lies between the minimum and maximum order quantity")
+	}
+	return nil
+}
+
+// CheckQuantity returns why quantity units of the product cannot be ordered
+// at once, or nil when they can
+func (p *Product) CheckQuantity(quantity int) error {
+	if p.QuantityIncrement > 1 && quantity%p.QuantityIncrement != 0 {
+		return fmt.Errorf("%s is sold in multiples of %d %s", p.SKU, p.QuantityIncrement, p.UnitOfMeasure)
+	}
+	if quantity < p.MinOrderQuantity {
+		return fmt.Errorf("%s is sold in orders of at least %d %s", p.SKU, p.MinOrderQuantity, p.UnitOfMeasure)
+	}
+	if p.MaxOrderQuantity > 0 && quantity > p.MaxOrderQuantity {
+		return fmt.Errorf("%s is sold in orders of at most %d %s", p.SKU, p.MaxOrderQuantity, p.UnitOfMeasure)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Product relation types
+const (
+	RelationRelated   = "related"
+	RelationUpsell    = "upsell"
+	RelationCrossSell = "cross_sell"
+	RelationAccessory = "accessory"
+)
+
+// ProductRelation links a product to another product it should be shown with
+type ProductRelation struct {
+	ProductID uuid.UUID `json:"product_id" gorm:"type:uuid;primaryKey"`
+	RelatedID uuid.UUID `json:"related_id" gorm:"type:uuid;primaryKey"`
+	Type      string    `json:"type" gorm:"primaryKey"`
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RelatedProduct is a resolved product relation
+type RelatedProduct struct {
+	Type     string   `json:"type"`
+	Position int      `json:"position"`
+	Product  *Product `json:"product"`
+}
+
+// CreateProductRelationRequest represents the request to link two products
+type CreateProductRelationRequest struct {
+	RelatedID uuid.UUID `json:"related_id" validate:"required"`
+	Type      string    `json:"type" validate:"required,oneof=related upsell cross_sell accessory"`
+	Position  int       `json:"position" validate:"gte=0"`
+}
+
+// TableName returns the table name for ProductRelation
+func (ProductRelation) TableName() string {
+	return "product_relations"
+}
+
+// TenantParent implements tenant.Parent: relations belong to the tenant of
+// their product
+func (ProductRelation) TenantParent() (string, string) {
+	return "product_id", "products"
+}
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Review moderation statuses
+const (
+	ReviewStatusPending  = "pending"
+	ReviewStatusApproved = "approved"
+	ReviewStatusRejected = "rejected"
+)
+
+// Review is a customer rating and optional text review of a product.
+// Only approved reviews count towards the product's rating.
+type Review struct {
+	ID             uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProductID      uuid.UUID `json:"product_id" gorm:"type:uuid;not null"`
+	UserID         string    `json:"user_id" gorm:"not null"`
+	Rating         int       `json:"rating" gorm:"not null"`
+	Title          string    `json:"title,omitempty"`
+	Body           string    `json:"body,omitempty" gorm:"type:text"`
+	Status         string    `json:"status" gorm:"not null;default:pending"`
+	ModerationNote string    `json:"moderation_note,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// CreateReviewRequest represents the request to review a product
+type CreateReviewRequest struct {
+	Rating int    `json:"rating" validate:"required,min=1,max=5"`
+	Title  string `json:"title" validate:"max=200"`
+	Body   string `json:"body" validate:"max=5000"`
+}
+
+// ModerateReviewRequest represents a moderation decision on a review
+type ModerateReviewRequest struct {
+	Status string `json:"status" validate:"required,oneof=approved rejected"`
+	Note   string `json:"note" validate:"max=500"`
+}
+
+// ReviewFilters represents filters for review queries
+type ReviewFilters struct {
+	ProductID *uuid.UUID `json:"product_id,omitempty"`
+	Status    string     `json:"status,omitempty"`
+	Limit     int        `json:"limit,omitempty"`
+	Offset    int        `json:"offset,omitempty"`
+}
+
+// ReviewList represents a paginated list of reviews
+type ReviewList struct {
+	Reviews []Review `json:"reviews"`
+	Total   int64    `json:"total"`
+	Limit   int      `json:"limit"`
+	Offset  int      `json:"offset"`
+	HasMore bool     `json:"has_more"`
+}
+
+// TableName returns the table name for Review
+func (Review) TableName() string {
+	return "reviews"
+}
+
+// TenantParent implements tenant.Parent: reviews belong to the tenant of
+// their product
+func (Review) TenantParent() (string, string) {
+	return "product_id", "products"
+}
@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Revision review statuses
+const (
+	RevisionStatusPending  = "pending"
+	RevisionStatusApproved = "approved"
+	RevisionStatusRejected = "rejected"
+)
+
+// ProductRevision is a change to a live product proposed for review. Patch
+// is a JSON Merge Patch of the product, as PATCH /products/:id takes; it is
+// applied once a reviewer approves it. Changes is what the patch would
+// change in the product as it is now, filled in on reads of one revision.
+type ProductRevision struct {
+	ID             uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProductID      uuid.UUID       `json:"product_id" gorm:"type:uuid;not null"`
+	Status         string          `json:"status" gorm:"not null;default:pending"`
+	Patch          json.RawMessage `json:"patch" gorm:"type:jsonb;serializer:json;not null"`
+	Note           string          `json:"note,omitempty"`
+	SubmittedBy    string          `json:"submitted_by" gorm:"not null"`
+	SubmitterEmail string          `json:"submitter_email,omitempty"`
+	ReviewedBy     string          `json:"reviewed_by,omitempty"`
+	ReviewComment  string          `json:"review_comment,omitempty"`
+	ReviewedAt     *time.Time      `json:"reviewed_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+
+	Changes map[string]FieldChange `json:"changes,omitempty" gorm:"-"`
+}
+
+// SubmitRevisionRequest represents the request to propose a change to a
+// product
+type SubmitRevisionRequest struct {
+	Patch json.RawMessage `json:"patch" validate:"required"`
+	Note  string          `json:"note" validate:"max=1000"`
+}
+
+// ReviewRequest represents a reviewer's decision on a product or a change to
+// one; the comment is passed on to the submitter
+type ReviewRequest struct {
+	Comment string `json:"comment" validate:"max=2000"`
+}
+
+// RevisionFilters represents filters for revision queries
+type RevisionFilters struct {
+	ProductID *uuid.UUID `json:"product_id,omitempty"`
+	Status    string     `json:"status,omitempty"`
+	Limit     int        `json:"limit,omitempty"`
+	Offset    int        `json:"offset,omitempty"`
+}
+
+// RevisionList represents a paginated list of revisions
+type RevisionList struct {
+	Revisions []ProductRevision `json:"revisions"`
+	Total     int64             `json:"total"`
+	Limit     int               `json:"limit"`
+	Offset    int               `json:"offset"`
+	HasMore   bool              `json:"has_more"`
+}
+
+// ProductReviewed is the payload of product.reviewed. RevisionID is set
+// when a proposed change was reviewed, and unset for a vendor's new
+// product.
+type ProductReviewed struct {
+	ProductID   uuid.UUID  `json:"product_id"`
+	ProductName string     `json:"product_name"`
+	RevisionID  *uuid.UUID `json:"revision_id,omitempty"`
+	Status      string     `json:"status"`
+	Comment     string     `json:"comment,omitempty"`
+	SubmittedBy string     `json:"submitted_by,omitempty"`
+	Email       string     `json:"email,omitempty"`
+}
+
+// TableName returns the table name for ProductRevision
+func (ProductRevision) TableName() string {
+	return "product_revisions"
+}
+
+// TenantParent implements tenant.Parent: revisions belong to the tenant of
+// their product
+func (ProductRevision) TenantParent() (string, string) {
+	return "product_id", "products"
+}
@@ -0,0 +1,144 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxSearchAlternatives bounds the rephrasings a search is expanded into
+const MaxSearchAlternatives = 10
+
+// Suggestion types
+const (
+	SuggestionProduct  = "product"
+	SuggestionCategory = "category"
+	SuggestionBrand    = "brand"
+)
+
+// Suggestion is a product, category or brand whose name matches what a
+// shopper has typed so far
+type Suggestion struct {
+	Type string    `json:"type"`
+	ID   uuid.UUID `json:"id"`
+	Text string    `json:"text"`
+	Slug string    `json:"slug,omitempty"` // brands have none
+}
+
+// SearchSynonym lists words searched for alongside a term, e.g. t-shirt for
+// tee. Expansion is one way; the reverse needs a synonym of its own.
+type SearchSynonym struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID  string    `json:"tenant_id,omitempty" gorm:"not null"`
+	Term      string    `json:"term" gorm:"not null;uniqueIndex"`
+	Synonyms  []string  `json:"synonyms" gorm:"type:jsonb;serializer:json"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreateSearchSynonymRequest represents the request to create a synonym
+type CreateSearchSynonymRequest struct {
+	Term     string   `json:"term" validate:"required,max=100"`
+	Synonyms []string `json:"synonyms" validate:"required,min=1,max=20,dive,required,max=100"`
+}
+
+// UpdateSearchSynonymRequest represents the request to update a synonym
+type UpdateSearchSynonymRequest struct {
+	Term     *string  `json:"term,omitempty" validate:"omitempty,max=100"`
+	Synonyms []string `json:"synonyms,omitempty" validate:"omitempty,min=1,max=20,dive,required,max=100"`
+}
+
+// ZeroResultSearch is a search query that found no products, counted over
+// every time it was searched
+type ZeroResultSearch struct {
+	ID              uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID        string    `json:"tenant_id,omitempty" gorm:"not null"`
+	Query           string    `json:"query" gorm:"not null;uniqueIndex"`
+	Count           int64     `json:"count" gorm:"not null;default:1"`
+	FirstSearchedAt time.Time `json:"first_searched_at"`
+	LastSearchedAt  time.Time `json:"last_searched_at"`
+}
+
+// ZeroResultFilters represents filters for the zero-result search report
+type ZeroResultFilters struct {
+	Since  *time.Time `json:"since,omitempty"` // only queries searched since then
+	Limit  int        `json:"limit,omitempty"`
+	Offset int        `json:"offset,omitempty"`
+}
+
+// ZeroResultSearchList represents a paginated list of zero-result searches,
+// most searched first
+type ZeroResultSearchList struct {
+	Searches []ZeroResultSearch `json:"searches"`
+	Total    int64              `json:"total"`
+	Limit    int                `json:"limit"`
+	Offset   int                `json:"offset"`
+	HasMore  bool               `json:"has_more"`
+}
+
+// NormalizeSearchTerm lower-cases a term and collapses its whitespace, so
+// queries and synonym terms compare equal however they were typed
+func NormalizeSearchTerm(term string) string {
+	return strings.Join(strings.Fields(strings.ToLower(term)), " ")
+}
+
+// ExpandSearch returns rephrasings of a query with one of its terms
+// replaced by a synonym, at most MaxSearchAlternatives of them. Terms are
+// matched as whole words, and may be several words long.
+func ExpandSearch(query string, synonyms []SearchSynonym) []string {
+	words := strings.Fields(NormalizeSearchTerm(query))
+	if len(words) == 0 {
+		return nil
+	}
+
+	var alternatives []string
+	seen := map[string]bool{strings.Join(words, " "): true}
+	for _, synonym := range synonyms {
+		term := strings.Fields(synonym.Term)
+		if len(term) == 0 {
+			continue
+		}
+		for start := 0; start+len(term) <= len(words); start++ {
+			if !equalWords(words[start:start+len(term)], term) {
+				continue
+			}
+			for _, replacement := range synonym.Synonyms {
+				rephrased := make([]string, 0, len(words))
+				rephrased = append(rephrased, words[:start]...)
+				rephrased = append(rephrased, NormalizeSearchTerm(replacement))
+				rephrased = append(rephrased, words[start+len(term):]...)
+
+				alternative := strings.Join(rephrased, " ")
+				if seen[alternative] {
+					continue
+				}
+				seen[alternative] = true
+				alternatives = append(alternatives, alternative)
+				if len(alternatives) == MaxSearchAlternatives {
+					return alternatives
+				}
+			}
+		}
+	}
+	return alternatives
+}
+
+func equalWords(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// TableName returns the table name for SearchSynonym
+func (SearchSynonym) TableName() string {
+	return "search_synonyms"
+}
+
+// TableName returns the table name for ZeroResultSearch
+func (ZeroResultSearch) TableName() string {
+	return "zero_result_searches"
+}
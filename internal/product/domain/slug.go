@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxSlugLength leaves room for a collision suffix within the 255 column limit
+const maxSlugLength = 200
+
+// SlugRedirect maps a retired slug to the entity that used to own it
+type SlugRedirect struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID   string    `json:"tenant_id,omitempty" gorm:"not null"`
+	EntityType string    `json:"entity_type" gorm:"not null"`
+	EntityID   uuid.UUID `json:"entity_id" gorm:"type:uuid;not null"`
+	Slug       string    `json:"slug" gorm:"not null"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Slugify converts a name into a lowercase, hyphen-separated URL slug.
+// Characters outside a-z and 0-9 collapse into single hyphens.
+func Slugify(name string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			hyphen = false
+			continue
+		}
+		if !hyphen && b.Len() > 0 {
+			b.WriteByte('-')
+			hyphen = true
+		}
+	}
+
+	slug := strings.TrimSuffix(b.String(), "-")
+	if len(slug) > maxSlugLength {
+		slug = strings.TrimSuffix(slug[:maxSlugLength], "-")
+	}
+	return slug
+}
+
+// TableName returns the table name for SlugRedirect
+func (SlugRedirect) TableName() string {
+	return "slug_redirects"
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Product statuses
+const (
+	ProductStatusDraft     = "draft"     // being prepared, hidden from the storefront
+	ProductStatusPublished = "published" // shown on the storefront
+	ProductStatusArchived  = "archived"  // retired, hidden from the storefront
+)
+
+// PublishProductRequest represents the request to publish a product, now or
+// at a later time
+type PublishProductRequest struct {
+	PublishAt *time.Time `json:"publish_at,omitempty"` // publishes immediately when omitted or past
+}
+
+// IsPublished reports whether the product is shown on the storefront
+func (p *Product) IsPublished() bool {
+	return p.Status == ProductStatusPublished
+}
+
+// SetStatus moves the product to a status, recording when it was published
+func (p *Product) SetStatus(status string, now time.Time) {
+	if status == ProductStatusPublished && p.Status != ProductStatusPublished {
+		p.PublishedAt = &now
+	}
+	if status != ProductStatusDraft {
+		p.PublishAt = nil
+	}
+	p.Status = status
+}
+
+// ValidateSchedule checks that only drafts are scheduled for publishing
+func (p *Product) ValidateSchedule() error {
+	if p.PublishAt != nil && p.Status != ProductStatusDraft {
+		return errors.New("only draft products can be scheduled for publishing")
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Stock movement reasons
+const (
+	StockReasonInitial     = "initial"     // stock a product was created with
+	StockReasonRestock     = "restock"     // goods received
+	StockReasonAdjustment  = "adjustment"  // a correction, such as after a stock count
+	StockReasonReservation = "reservation" // held for a checkout
+	StockReasonRelease     = "release"     // a reservation given back
+	StockReasonImport      = "import"      // set by a bulk import or seed
+	StockReasonCount       = "count"       // set to the level a stock count found
+	StockReasonDamaged     = "damaged"     // written off as damaged
+	StockReasonShrinkage   = "shrinkage"   // lost or stolen
+	StockReasonReturn      = "return"      // a customer return put back into stock
+	StockReasonFlashSale   = "flash_sale"  // set aside for a flash sale, or its unsold units put back
+)
+
+// Stock reservation statuses
+const (
+	ReservationStatusReserved = "reserved"
+	ReservationStatusReleased = "released"
+)
+
+// StockReservation holds stock of one product in one warehouse aside for a
+// pending checkout. Reserved stock is already deducted from the product;
+// releasing the reservation puts it back in the same warehouse. A product
+// reserved from several warehouses has a row for each. Products that hold
+// no stock are reserved without a warehouse, only to price them. The
+// components of a reserved bundle have rows of their own naming it.
+type StockReservation struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Reference   string     `json:"reference" gorm:"not null"`
+	ProductID   uuid.UUID  `json:"product_id" gorm:"type:uuid;not null"`
+	WarehouseID *uuid.UUID `json:"warehouse_id,omitempty" gorm:"type:uuid"`
+	BundleID    *uuid.UUID `json:"bundle_id,omitempty" gorm:"type:uuid"`
+	Quantity    int        `json:"quantity" gorm:"not null"`
+	UnitPrice   float64    `json:"unit_price" gorm:"not null"`
+	Status      string     `json:"status" gorm:"not null;default:reserved"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// StockItem is a product and quantity to reserve
+type StockItem struct {
+	ProductID uuid.UUID `json:"product_id" validate:"required"`
+	Quantity  int       `json:"quantity" validate:"required,gt=0"`
+}
+
+// ReserveStockRequest represents the request to reserve stock. The reference
+// is chosen by the caller and makes the request safe to retry. Stock comes
+// from the named warehouse, or else from the highest priority warehouses
+// holding it.
+type ReserveStockRequest struct {
+	Reference string      `json:"reference" validate:"required,max=100"`
+	Warehouse string      `json:"warehouse,omitempty" validate:"max=50"`
+	Items     []StockItem `json:"items" validate:"required,min=1,dive"`
+
+	// CustomerGroup prices the items for the customer the caller reserves
+	// for; the caller's own group applies without one
+	CustomerGroup string `json:"customer_group,omitempty" validate:"max=50"`
+
+	// Channel is the sales channel the items are sold through; products
+	// hidden from it can't be reserved, and its prices apply
+	Channel string `json:"channel,omitempty" validate:"omitempty,oneof=web mobile_app marketplace pos"`
+}
+
+// ReturnStockRequest represents the request to put the goods of a customer
+// return back into stock. The reference names the return and makes the
+// request safe to retry. Stock goes to the named warehouse, or else the
+// default one.
+type ReturnStockRequest struct {
+	Reference string      `json:"reference" validate:"required,max=100"`
+	Warehouse string      `json:"warehouse,omitempty" validate:"max=50"`
+	Items     []StockItem `json:"items" validate:"required,min=1,dive"`
+}
+
+// ReservedItem is a reserved product line, priced at the time of reservation
+type ReservedItem struct {
+	ProductID  uuid.UUID `json:"product_id"`
+	CategoryID uuid.UUID `json:"category_id"`
+	SKU        string    `json:"sku"`
+	Name       string    `json:"name"`
+	Type       string    `json:"type"`
+	TaxClass   string    `json:"tax_class"`
+	Quantity   int       `json:"quantity"`
+	UnitPrice  float64   `json:"unit_price"`
+}
+
+// Reservation is the stock held under one reference
+type Reservation struct {
+	Reference string         `json:"reference"`
+	Status    string         `json:"status"`
+	Items     []ReservedItem `json:"items"`
+}
+
+// StockMovement is an entry in a product's stock ledger. Every change to a
+// product's stock is recorded with the signed quantity and the level it
+// left, in the same transaction as the change, so a product's stock always
+// equals the sum of its movements. Each movement also changes the stock
+// level of its warehouse; movements recorded without one go to the default
+// warehouse.
+type StockMovement struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProductID   uuid.UUID  `json:"product_id" gorm:"type:uuid;not null"`
+	WarehouseID *uuid.UUID `json:"warehouse_id,omitempty" gorm:"type:uuid"`
+	Delta       int        `json:"delta" gorm:"not null"`
+	Balance     int        `json:"balance" gorm:"not null"` // the product's stock, across warehouses
+	Reason      string     `json:"reason" gorm:"not null"`
+	Reference   string     `json:"reference,omitempty"` // reservation reference or import job
+	ActorID     string     `json:"actor_id,omitempty"`
+	Note        string     `json:"note,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// AdjustStockRequest represents a manual change to a product's stock, in
+// the named warehouse or else the default one
+type AdjustStockRequest struct {
+	Quantity  int    `json:"quantity" validate:"required"` // negative to remove stock
+	Reason    string `json:"reason" validate:"required,oneof=restock adjustment count damaged shrinkage return"`
+	Note      string `json:"note" validate:"max=500"`
+	Warehouse string `json:"warehouse,omitempty" validate:"max=50"`
+}
+
+// StockAdjustment is one row of a bulk stock adjustment. It either changes
+// the stock of the product with the SKU by Delta or sets it to Absolute.
+// With a warehouse, both apply to the stock held there; without one, Delta
+// applies to the default warehouse and Absolute to the product's total.
+type StockAdjustment struct {
+	SKU       string `json:"sku" validate:"required,max=100"`
+	Delta     *int   `json:"delta,omitempty"`
+	Absolute  *int   `json:"absolute,omitempty" validate:"omitempty,gte=0"`
+	Reason    string `json:"reason" validate:"required,oneof=restock adjustment count damaged shrinkage return"`
+	Note      string `json:"note,omitempty" validate:"max=500"`
+	Warehouse string `json:"warehouse,omitempty" validate:"max=50"`
+}
+
+// BulkStockAdjustmentRequest represents the request to adjust the stock of
+// many products at once. The reference, such as a stock count or delivery
+// number, is recorded on every movement.
+type BulkStockAdjustmentRequest struct {
+	Reference   string            `json:"reference,omitempty" validate:"max=100"`
+	Adjustments []StockAdjustment `json:"adjustments" validate:"required,min=1,max=1000,dive"`
+}
+
+// StockAdjustmentResult reports the outcome of one adjustment, by its
+// position in the request
+type StockAdjustmentResult struct {
+	Index     int        `json:"index"`
+	SKU       string     `json:"sku"`
+	ProductID *uuid.UUID `json:"product_id,omitempty"`
+	Delta     int        `json:"delta"`
+	Balance   int        `json:"balance"`
+	Success   bool       `json:"success"`
+	Code      string     `json:"code,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// BulkStockAdjustmentResult reports the outcome of a bulk stock adjustment.
+// Adjustments apply together or not at all: when any fails, Applied is
+// false and no stock was changed.
+type BulkStockAdjustmentResult struct {
+	Applied   bool                    `json:"applied"`
+	Succeeded int                     `json:"succeeded"`
+	Failed    int                     `json:"failed"`
+	Results   []StockAdjustmentResult `json:"results"`
+}
+
+// StockChanged is the payload of stock.changed, published for each
+// movement a stock adjustment records
+type StockChanged struct {
+	StockMovement
+	SKU string `json:"sku"`
+}
+
+// StockMovementFilters represents filters for a product's stock history
+type StockMovementFilters struct {
+	Reason string `json:"reason,omitempty"`
+	Limit  int    `json:"limit,omitempty"`
+	Offset int    `json:"offset,omitempty"`
+}
+
+// StockMovementList represents a paginated stock history, newest first
+type StockMovementList struct {
+	Movements []StockMovement `json:"movements"`
+	Total     int64           `json:"total"`
+	Limit     int             `json:"limit"`
+	Offset    int             `json:"offset"`
+	HasMore   bool            `json:"has_more"`
+}
+
+// StockDrift is a product whose stock does not match its ledger
+type StockDrift struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Stock     int       `json:"stock"`
+	Ledger    int       `json:"ledger"`
+}
+
+// LowStockFilters represents filters for the low stock listing
+type LowStockFilters struct {
+	CategoryID *uuid.UUID `json:"category_id,omitempty"`
+	Limit      int        `json:"limit,omitempty"`
+	Offset     int        `json:"offset,omitempty"`
+}
+
+// StockThreshold returns the stock level at or below which a product is
+// low on stock, given the service-wide default
+func (p *Product) StockThreshold(defaultThreshold int) int {
+	if p.LowStockThreshold != nil {
+		return *p.LowStockThreshold
+	}
+	return defaultThreshold
+}
+
+// IsLowStock reports whether a product is at or below its threshold
+func (p *Product) IsLowStock(defaultThreshold int) bool {
+	return p.Stock <= p.StockThreshold(defaultThreshold)
+}
+
+// TableName returns the table name for StockReservation
+func (StockReservation) TableName() string {
+	return "stock_reservations"
+}
+
+// TenantParent implements tenant.Parent: reservations belong to the tenant of
+// their product
+func (StockReservation) TenantParent() (string, string) {
+	return "product_id", "products"
+}
+
+// TableName returns the table name for StockMovement
+func (StockMovement) TableName() string {
+	return "inventory_movements"
+}
+
+// TenantParent implements tenant.Parent: stock movements belong to the tenant of
+// their product
+func (StockMovement) TenantParent() (string, string) {
+	return "product_id", "products"
+}
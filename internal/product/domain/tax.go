@@ -0,0 +1,10 @@
+package domain
+
+// Tax classes, as the tax service knows them. Products are charged the rate
+// of their class where they are delivered; exempt products are never taxed.
+const (
+	TaxClassStandard = "standard"
+	TaxClassReduced  = "reduced"
+	TaxClassZero     = "zero"
+	TaxClassExempt   = "exempt"
+)
@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Customer groups. Customers outside any group are priced as retail.
+const (
+	CustomerGroupRetail    = "retail"
+	CustomerGroupWholesale = "wholesale"
+	CustomerGroupVIP       = "vip"
+)
+
+// PriceTier prices a product for a customer group, or for every group when
+// the group is empty, once at least MinQuantity units are bought. A tier
+// from one unit overrides the group's price outright; larger minimums are
+// quantity breaks.
+type PriceTier struct {
+	ID            uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProductID     uuid.UUID `json:"-" gorm:"type:uuid;not null"`
+	CustomerGroup string    `json:"customer_group,omitempty" gorm:"not null"`
+	MinQuantity   int       `json:"min_quantity" gorm:"not null"`
+	Price         float64   `json:"price" gorm:"not null"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// PriceTierInput is a price tier as given in a request
+type PriceTierInput struct {
+	CustomerGroup string  `json:"customer_group,omitempty" validate:"omitempty,oneof=retail wholesale vip"`
+	MinQuantity   int     `json:"min_quantity" validate:"required,gt=0"`
+	Price         float64 `json:"price" validate:"required,gt=0"`
+}
+
+// SetPriceTiersRequest replaces the price tiers of a product; an empty list
+// removes them
+type SetPriceTiersRequest struct {
+	Tiers []PriceTierInput `json:"tiers" validate:"max=100,dive"`
+}
+
+// NormalizeCustomerGroup returns the group a customer is priced as
+func NormalizeCustomerGroup(group string) string {
+	if group == "" {
+		return CustomerGroupRetail
+	}
+	return group
+}
+
+// Applies reports whether the tier prices quantity units bought by a
+// customer in group
+func (t PriceTier) Applies(group string, quantity int) bool {
+	return (t.CustomerGroup == "" || t.CustomerGroup == NormalizeCustomerGroup(group)) && quantity >= t.MinQuantity
+}
+
+// PriceFor returns the price in force at t for quantity units bought by a
+// customer in group: the lowest of the price at t and the prices of the
+// product's tiers that apply. Outside a sale, the price of the channel the
+// product is priced for replaces its regular price. A quantity below one
+// prices a single unit.
+func (p *Product) PriceFor(group string, quantity int, t time.Time) float64 {
+	price := p.PriceAt(t)
+	if channelPrice := p.channelPrice(); channelPrice != nil && !p.OnSaleAt(t) {
+		price = *channelPrice
+	}
+	for _, tier := range p.PriceTiers {
+		if tier.Applies(group, max(quantity, 1)) && tier.Price < price {
+			price = tier.Price
+		}
+	}
+	return price
+}
+
+// ValidatePriceTiers checks that no two tiers price the same group and
+// minimum quantity
+func ValidatePriceTiers(tiers []PriceTierInput) error {
+	seen := make(map[PriceTierInput]bool, len(tiers))
+	for _, tier := range tiers {
+		key := PriceTierInput{CustomerGroup: tier.CustomerGroup, MinQuantity: tier.MinQuantity}
+		if seen[key] {
+			return fmt.Errorf("more than one tier for %d units of group %q", tier.MinQuantity, tier.CustomerGroup)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+type quantityKey struct{}
+
+// WithQuantity returns a copy of ctx asking for products priced for the
+// given quantity
+func WithQuantity(ctx context.Context, quantity int) context.Context {
+	return context.WithValue(ctx, quantityKey{}, quantity)
+}
+
+// QuantityFromContext returns the quantity stored in ctx, or 1 when
+// products should be priced by the unit
+func QuantityFromContext(ctx context.Context) int {
+	if quantity, ok := ctx.Value(quantityKey{}).(int); ok && quantity > 0 {
+		return quantity
+	}
+	return 1
+}
+
+// TableName returns the table name for PriceTier
+func (PriceTier) TableName() string {
+	return "product_price_tiers"
+}
+
+// TenantParent implements tenant.Parent: price tiers belong to the tenant of
+// their product
+func (PriceTier) TenantParent() (string, string) {
+	return "product_id", "products"
+}
@@ -0,0 +1,177 @@
+package domain
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProductTranslation holds a product's name and description in a locale
+// other than the default one. An empty description falls back to the
+// default locale's.
+type ProductTranslation struct {
+	ProductID   uuid.UUID `json:"product_id" gorm:"type:uuid;primaryKey"`
+	Locale      string    `json:"locale" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"not null"`
+	Description string    `json:"description" gorm:"type:text"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CategoryTranslation holds a category's name and description in a locale
+// other than the default one. An empty description falls back to the
+// default locale's.
+type CategoryTranslation struct {
+	CategoryID  uuid.UUID `json:"category_id" gorm:"type:uuid;primaryKey"`
+	Locale      string    `json:"locale" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"not null"`
+	Description string    `json:"description" gorm:"type:text"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// UpsertProductTranslationRequest represents the request to set a product's
+// content in a locale
+type UpsertProductTranslationRequest struct {
+	Name        string `json:"name" validate:"required,min=1,max=255"`
+	Description string `json:"description"`
+}
+
+// UpsertCategoryTranslationRequest represents the request to set a
+// category's content in a locale
+type UpsertCategoryTranslationRequest struct {
+	Name        string `json:"name" validate:"required,min=1,max=100"`
+	Description string `json:"description"`
+}
+
+type localeKey struct{}
+
+// WithLocale returns a copy of ctx asking for content in the given locale
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// LocaleFromContext returns the locale stored in ctx, or "" when content
+// should be served in the default locale
+func LocaleFromContext(ctx context.Context) string {
+	locale, _ := ctx.Value(localeKey{}).(string)
+	return locale
+}
+
+// NormalizeLocale canonicalizes a language tag such as pt_br into pt-BR.
+// It returns "" for tags that are not a language optionally followed by a
+// region.
+func NormalizeLocale(tag string) string {
+	language, region, _ := strings.Cut(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"), "-")
+	if !isLetters(language, 2, 3) {
+		return ""
+	}
+	language = strings.ToLower(language)
+	if region == "" {
+		return language
+	}
+	if !isLetters(region, 2, 2) {
+		return ""
+	}
+	return language + "-" + strings.ToUpper(region)
+}
+
+// ResolveLocale picks the locale to serve content in: the requested locale
+// if it is supported, otherwise the best supported match from an
+// Accept-Language header, otherwise the default. A regional tag such as
+// fr-CA matches its language when only fr is supported.
+func ResolveLocale(requested, acceptLanguage string, supported []string, defaultLocale string) string {
+	match := func(tag string) string {
+		tag = NormalizeLocale(tag)
+		if tag == "" {
+			return ""
+		}
+		language, _, _ := strings.Cut(tag, "-")
+		fallback := ""
+		for _, locale := range append([]string{defaultLocale}, supported...) {
+			locale = NormalizeLocale(locale)
+			if locale == "" {
+				continue
+			}
+			if locale == tag {
+				return locale
+			}
+			if fallback == "" && locale == language {
+				fallback = locale
+			}
+		}
+		return fallback
+	}
+
+	if locale := match(requested); locale != "" {
+		return locale
+	}
+
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if tag == "" || tag == "*" || q <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{tag: tag, q: q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	for _, tag := range tags {
+		if locale := match(tag.tag); locale != "" {
+			return locale
+		}
+	}
+	return NormalizeLocale(defaultLocale)
+}
+
+// isLetters reports whether s is between minLen and maxLen ASCII letters long
+func isLetters(s string, minLen, maxLen int) bool {
+	if len(s) < minLen || len(s) > maxLen {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// TableName returns the table name for ProductTranslation
+func (ProductTranslation) TableName() string {
+	return "product_translations"
+}
+
+// TenantParent implements tenant.Parent: translations belong to the tenant of
+// their product
+func (ProductTranslation) TenantParent() (string, string) {
+	return "product_id", "products"
+}
+
+// TableName returns the table name for CategoryTranslation
+func (CategoryTranslation) TableName() string {
+	return "category_translations"
+}
+
+// TenantParent implements tenant.Parent: translations belong to the tenant of
+// their category
+func (CategoryTranslation) TenantParent() (string, string) {
+	return "category_id", "categories"
+}
@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Vendor statuses
+const (
+	VendorStatusActive    = "active"    // may sell and manage their products
+	VendorStatusSuspended = "suspended" // their products stay listed, but they can't change them
+)
+
+// Approval statuses of vendor products
+const (
+	ApprovalPending  = "pending"  // awaiting review; kept off the storefront
+	ApprovalApproved = "approved" // may be published
+	ApprovalRejected = "rejected" // turned down; the vendor's next change resubmits it
+)
+
+// Vendor is a seller on the marketplace. The user named by OwnerID signs in
+// with the vendor role and manages the vendor's products, and only those.
+type Vendor struct {
+	ID       uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID string    `json:"tenant_id,omitempty" gorm:"not null"`
+	Name     string    `json:"name" gorm:"not null"`
+	Email    string    `json:"email,omitempty"`
+	OwnerID  string    `json:"owner_id" gorm:"not null"`
+	Status   string    `json:"status" gorm:"not null;default:active"`
+
+	// CommissionRate is the percentage of the vendor's sales the
+	// marketplace keeps
+	CommissionRate float64 `json:"commission_rate" gorm:"not null;default:0"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreateVendorRequest represents the request to create a vendor
+type CreateVendorRequest struct {
+	Name           string  `json:"name" validate:"required,min=1,max=100"`
+	Email          string  `json:"email" validate:"omitempty,email"`
+	OwnerID        string  `json:"owner_id" validate:"required,max=100"`
+	CommissionRate float64 `json:"commission_rate" validate:"gte=0,lte=100"`
+}
+
+// UpdateVendorRequest represents the request to update a vendor
+type UpdateVendorRequest struct {
+	Name           *string  `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
+	Email          *string  `json:"email,omitempty" validate:"omitempty,email"`
+	CommissionRate *float64 `json:"commission_rate,omitempty" validate:"omitempty,gte=0,lte=100"`
+	Status         *string  `json:"status,omitempty" validate:"omitempty,oneof=active suspended"`
+}
+
+// IsActive reports whether the vendor may manage their products
+func (v *Vendor) IsActive() bool {
+	return v.Status == VendorStatusActive
+}
+
+// TableName returns the table name for Vendor
+func (Vendor) TableName() string {
+	return "vendors"
+}
+
+// SoldBy reports whether the product is sold by the vendor
+func (p *Product) SoldBy(vendor *Vendor) bool {
+	return vendor != nil && p.VendorID != nil && *p.VendorID == vendor.ID
+}
+
+// Approved reports whether the product may be published: products the shop
+// lists itself always may, vendor products once they are approved
+func (p *Product) Approved() bool {
+	return p.ApprovalStatus == "" || p.ApprovalStatus == ApprovalApproved
+}
+
+// ValidateApproval checks that a vendor product is not published before it
+// is approved
+func (p *Product) ValidateApproval() error {
+	if p.IsPublished() && !p.Approved() {
+		return errors.New("vendor products must be approved before they are published")
+	}
+	return nil
+}
+
+type vendorKey struct{}
+
+// WithVendor returns a copy of ctx acting for the vendor the caller sells as
+func WithVendor(ctx context.Context, vendor *Vendor) context.Context {
+	return context.WithValue(ctx, vendorKey{}, vendor)
+}
+
+// VendorFromContext returns the vendor ctx acts for, or nil when the caller
+// is not a vendor
+func VendorFromContext(ctx context.Context) *Vendor {
+	vendor, _ := ctx.Value(vendorKey{}).(*Vendor)
+	return vendor
+}
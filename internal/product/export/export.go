@@ -0,0 +1,91 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"ecommerce/internal/product/domain"
+)
+
+// Writer serializes batches of products to an output stream
+type Writer interface {
+	WriteHeader() error
+	Write(products []domain.Product) error
+}
+
+// csvColumns match the columns accepted by the product importer
+var csvColumns = []string{
+	"id", "sku", "gtin", "slug", "name", "description", "price", "category_id", "brand_id", "stock", "image_url", "is_active", "created_at", "updated_at",
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+// NewCSVWriter creates a CSV export writer
+func NewCSVWriter(w io.Writer) Writer {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) WriteHeader() error {
+	if err := c.w.Write(csvColumns); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Write(products []domain.Product) error {
+	for _, product := range products {
+		var brandID string
+		if product.BrandID != nil {
+			brandID = product.BrandID.String()
+		}
+		record := []string{
+			product.ID.String(),
+			product.SKU,
+			product.GTIN,
+			product.Slug,
+			product.Name,
+			product.Description,
+			strconv.FormatFloat(product.Price, 'f', 2, 64),
+			product.CategoryID.String(),
+			brandID,
+			strconv.Itoa(product.Stock),
+			product.ImageURL,
+			strconv.FormatBool(product.IsActive),
+			product.CreatedAt.UTC().Format(time.RFC3339),
+			product.UpdatedAt.UTC().Format(time.RFC3339),
+		}
+		if err := c.w.Write(record); err != nil {
+			return err
+		}
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type jsonlWriter struct {
+	enc *json.Encoder
+}
+
+// NewJSONLWriter creates a JSON Lines export writer
+func NewJSONLWriter(w io.Writer) Writer {
+	return &jsonlWriter{enc: json.NewEncoder(w)}
+}
+
+func (j *jsonlWriter) WriteHeader() error {
+	return nil
+}
+
+func (j *jsonlWriter) Write(products []domain.Product) error {
+	for i := range products {
+		if err := j.enc.Encode(&products[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/product/config"
+	"ecommerce/internal/product/domain"
+	"ecommerce/internal/product/repository"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/events"
+	"ecommerce/pkg/jobs"
+	"ecommerce/pkg/media"
+	"ecommerce/pkg/storage"
+	"ecommerce/pkg/tenant"
+)
+
+// exportContentType is the content type the feed is exported with
+const exportContentType = "application/xml; charset=utf-8"
+
+// ExportJobType is the type of the background jobs that export the feed
+const ExportJobType = "feed.export"
+
+// Generator maintains the Google Merchant Center feed of the catalog's
+// active, published products. Each product's entry is rendered once and
+// kept; product events re-render only the products they concern. A
+// periodic full rebuild picks up what events don't cover, such as renamed
+// categories and brands.
+type Generator struct {
+	repo    repository.ProductRepository
+	images  *media.Builder
+	storage *storage.S3
+	store   config.StorefrontConfig
+	config  config.FeedConfig
+	logger  *logrus.Logger
+
+	// rebuildMu is held for a whole rebuild so two never run at once
+	rebuildMu sync.Mutex
+
+	mu       sync.Mutex
+	items    map[uuid.UUID][]byte // rendered entries; nil until the first build
+	changed  map[uuid.UUID]bool   // products changed while a rebuild runs
+	document []byte               // assembled feed; nil when an entry changed since
+	modified time.Time
+	exported time.Time // modified time of the last exported document
+}
+
+// New creates a feed generator. Storage is only needed to export the feed
+// and may be nil.
+func New(repo repository.ProductRepository, images *media.Builder, storage *storage.S3, store config.StorefrontConfig, cfg config.FeedConfig, logger *logrus.Logger) *Generator {
+	store.URL = strings.TrimRight(store.URL, "/")
+	return &Generator{
+		repo:    repo,
+		images:  images,
+		storage: storage,
+		store:   store,
+		config:  cfg,
+		logger:  logger,
+	}
+}
+
+// Register subscribes the generator to product events on the bus
+func (g *Generator) Register(bus *events.Bus) {
+	bus.Subscribe(domain.EventProductCreated, g.handleChange)
+	bus.Subscribe(domain.EventProductUpdated, g.handleChange)
+	bus.Subscribe(domain.EventProductRestored, g.handleChange)
+	bus.Subscribe(domain.EventProductDeleted, g.handleChange)
+}
+
+// RegisterJobs has a worker pool run the feed's export jobs
+func (g *Generator) RegisterJobs(worker *jobs.Worker) {
+	worker.Handle(ExportJobType, func(ctx context.Context, _ *jobs.Job) error {
+		return g.Export(ctx)
+	})
+}
+
+// Document returns the feed and when it last changed, building it on first
+// use
+func (g *Generator) Document(ctx context.Context) ([]byte, time.Time, error) {
+	g.rebuildMu.Lock()
+	g.mu.Lock()
+	built := g.items != nil
+	g.mu.Unlock()
+	if !built {
+		if err := g.rebuild(ctx); err != nil {
+			g.rebuildMu.Unlock()
+			return nil, time.Time{}, err
+		}
+	}
+	g.rebuildMu.Unlock()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.document == nil {
+		g.assemble()
+	}
+	return g.document, g.modified, nil
+}
+
+// Rebuild renders every entry of the feed afresh
+func (g *Generator) Rebuild(ctx context.Context) error {
+	g.rebuildMu.Lock()
+	defer g.rebuildMu.Unlock()
+	return g.rebuild(ctx)
+}
+
+// Export uploads the feed to media storage for Merchant Center to fetch on
+// a schedule. Nothing is uploaded when the feed hasn't changed since the
+// last export.
+func (g *Generator) Export(ctx context.Context) error {
+	if g.storage == nil {
+		return errors.NewUnavailableError("Media storage is not configured", nil)
+	}
+
+	document, modified, err := g.Document(ctx)
+	if err != nil {
+		return err
+	}
+	g.mu.Lock()
+	unchanged := modified.Equal(g.exported)
+	g.mu.Unlock()
+	if unchanged {
+		return nil
+	}
+
+	if err := g.storage.Put(ctx, g.config.ExportKey, exportContentType, document); err != nil {
+		return fmt.Errorf("failed to export feed: %w", err)
+	}
+
+	g.mu.Lock()
+	g.exported = modified
+	g.mu.Unlock()
+
+	g.logger.WithFields(logrus.Fields{
+		"key":   g.config.ExportKey,
+		"bytes": len(document),
+	}).Info("Product feed exported successfully")
+	return nil
+}
+
+// rebuild renders every active, published product and replaces the
+// entries with the result. Products changed while it runs are re-rendered
+// afterwards, as the rebuild may have read them before the change.
+func (g *Generator) rebuild(ctx context.Context) error {
+	g.mu.Lock()
+	g.changed = make(map[uuid.UUID]bool)
+	g.mu.Unlock()
+
+	items, err := g.renderAll(ctx)
+
+	g.mu.Lock()
+	changed := g.changed
+	g.changed = nil
+	if err == nil {
+		g.items = items
+		g.document = nil
+	}
+	g.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for id := range changed {
+		if err := g.refresh(ctx, id); err != nil {
+			g.logger.WithError(err).WithField("product_id", id).Error("Failed to refresh feed item")
+		}
+	}
+
+	g.logger.WithField("items", len(items)).Info("Product feed rebuilt successfully")
+	return nil
+}
+
+// renderAll renders the entries of every product the feed lists
+func (g *Generator) renderAll(ctx context.Context) (map[uuid.UUID][]byte, error) {
+	brands, err := g.repo.ListBrands(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load brands: %w", err)
+	}
+	brandNames := make(map[uuid.UUID]string, len(brands))
+	for _, brand := range brands {
+		brandNames[brand.ID] = brand.Name
+	}
+
+	active := true
+	filters := &domain.ProductFilters{
+		IsActive: &active,
+		Status:   domain.ProductStatusPublished,
+	}
+
+	now := time.Now()
+	items := make(map[uuid.UUID][]byte)
+	err = g.repo.Iterate(ctx, filters, g.config.BatchSize, func(products []domain.Product) error {
+		categoryIDs := make([]uuid.UUID, 0, len(products))
+		for _, product := range products {
+			categoryIDs = append(categoryIDs, product.CategoryID)
+		}
+		paths, err := g.repo.GetCategoryPaths(ctx, categoryIDs)
+		if err != nil {
+			return fmt.Errorf("failed to load category paths: %w", err)
+		}
+
+		for idx := range products {
+			product := &products[idx]
+			var brand string
+			if product.BrandID != nil {
+				brand = brandNames[*product.BrandID]
+			}
+			data, err := g.renderItem(product, brand, paths[product.CategoryID], now)
+			if err != nil {
+				return err
+			}
+			if data != nil {
+				items[product.ID] = data
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (g *Generator) handleChange(ctx context.Context, event events.Event) error {
+	var payload domain.Product
+	if err := event.Decode(&payload); err != nil {
+		return fmt.Errorf("failed to decode product event: %w", err)
+	}
+	// The feed lists the default tenant's catalog; other tenants' products
+	// look gone and are left out
+	return g.refresh(tenant.WithID(ctx, tenant.Default), payload.ID)
+}
+
+// refresh re-renders a product's entry, or removes it when the product is
+// gone or no longer listed. Before the first build there is nothing to
+// refresh.
+func (g *Generator) refresh(ctx context.Context, id uuid.UUID) error {
+	g.mu.Lock()
+	if g.changed != nil {
+		g.changed[id] = true
+	}
+	built := g.items != nil
+	g.mu.Unlock()
+	if !built {
+		return nil
+	}
+
+	var data []byte
+	product, err := g.repo.GetByID(ctx, id)
+	switch {
+	case errors.IsNotFound(err):
+	case err != nil:
+		return fmt.Errorf("failed to load product for feed: %w", err)
+	case product.IsActive && product.Status == domain.ProductStatusPublished:
+		var brand string
+		if product.Brand != nil {
+			brand = product.Brand.Name
+		}
+		paths, err := g.repo.GetCategoryPaths(ctx, []uuid.UUID{product.CategoryID})
+		if err != nil {
+			return fmt.Errorf("failed to load category path: %w", err)
+		}
+		if data, err = g.renderItem(product, brand, paths[product.CategoryID], time.Now()); err != nil {
+			return err
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	previous, listed := g.items[id]
+	switch {
+	case data == nil && !listed:
+		return nil
+	case data == nil:
+		delete(g.items, id)
+	case listed && string(previous) == string(data):
+		return nil
+	default:
+		g.items[id] = data
+	}
+	g.document = nil
+
+	g.logger.WithField("product_id", id).Debug("Feed item refreshed")
+	return nil
+}
+
+// assemble joins the entries into the feed document. Entries are ordered
+// by product ID so unchanged catalogs produce identical documents.
+func (g *Generator) assemble() {
+	ids := make([]uuid.UUID, 0, len(g.items))
+	size := 0
+	for id, data := range g.items {
+		ids = append(ids, id)
+		size += len(data) + 1
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].String() < ids[j].String() })
+
+	var b strings.Builder
+	b.Grow(size + 1024)
+	g.writeHeader(&b)
+	for _, id := range ids {
+		b.Write(g.items[id])
+		b.WriteByte('\n')
+	}
+	writeFooter(&b)
+
+	g.document = []byte(b.String())
+	g.modified = time.Now().UTC()
+}
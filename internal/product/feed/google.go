@@ -0,0 +1,147 @@
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"ecommerce/internal/product/domain"
+)
+
+// googleNamespace is the namespace of Google Merchant Center attributes
+const googleNamespace = "http://base.google.com/ns/1.0"
+
+// Merchant Center limits on attribute lengths, in characters
+const (
+	maxTitleLength       = 150
+	maxDescriptionLength = 5000
+)
+
+// item is a product entry in a Google Merchant Center RSS feed
+type item struct {
+	XMLName                xml.Name `xml:"item"`
+	ID                     string   `xml:"g:id"`
+	Title                  string   `xml:"g:title"`
+	Description            string   `xml:"g:description"`
+	Link                   string   `xml:"g:link"`
+	ImageLink              string   `xml:"g:image_link"`
+	Availability           string   `xml:"g:availability"`
+	AvailabilityDate       string   `xml:"g:availability_date,omitempty"`
+	Price                  string   `xml:"g:price"`
+	SalePrice              string   `xml:"g:sale_price,omitempty"`
+	SalePriceEffectiveDate string   `xml:"g:sale_price_effective_date,omitempty"`
+	Brand                  string   `xml:"g:brand,omitempty"`
+	GTIN                   string   `xml:"g:gtin,omitempty"`
+	IdentifierExists       string   `xml:"g:identifier_exists,omitempty"`
+	Condition              string   `xml:"g:condition"`
+	ProductType            string   `xml:"g:product_type,omitempty"`
+}
+
+// renderItem renders a product's feed entry. Products without an image
+// render nothing, as Merchant Center rejects them.
+func (g *Generator) renderItem(product *domain.Product, brand string, path []domain.Breadcrumb, now time.Time) ([]byte, error) {
+	imageLink := g.imageLink(product.ImageURL)
+	if imageLink == "" {
+		return nil, nil
+	}
+
+	description := product.DescriptionText
+	if strings.TrimSpace(description) == "" {
+		description = product.Name
+	}
+
+	entry := item{
+		ID:           product.SKU,
+		Title:        truncate(product.Name, maxTitleLength),
+		Description:  truncate(description, maxDescriptionLength),
+		Link:         g.store.URL + strings.ReplaceAll(g.store.ProductPath, "{slug}", product.Slug),
+		ImageLink:    imageLink,
+		Availability: product.AvailabilityAt(now),
+		Price:        g.price(product.Price),
+		Brand:        brand,
+		GTIN:         product.GTIN,
+		Condition:    "new",
+	}
+	// Merchant Center wants to know when a preorder ships
+	if entry.Availability == domain.AvailabilityPreorder && product.ReleaseDate != nil {
+		entry.AvailabilityDate = product.ReleaseDate.UTC().Format(time.RFC3339)
+	}
+	if product.GTIN == "" {
+		entry.IdentifierExists = "no"
+	}
+
+	// A sale with both ends set is listed ahead of time and switched by
+	// Merchant Center; an open-ended one only while it runs, relying on the
+	// sale check's events to re-render the entry when it starts or ends
+	if product.SalePrice != nil {
+		switch {
+		case product.SaleStartsAt != nil && product.SaleEndsAt != nil && product.SaleEndsAt.After(now):
+			entry.SalePrice = g.price(*product.SalePrice)
+			entry.SalePriceEffectiveDate = product.SaleStartsAt.UTC().Format(time.RFC3339) + "/" + product.SaleEndsAt.UTC().Format(time.RFC3339)
+		case product.OnSaleAt(now):
+			entry.SalePrice = g.price(*product.SalePrice)
+		}
+	}
+
+	names := make([]string, len(path))
+	for idx, crumb := range path {
+		names[idx] = crumb.Name
+	}
+	entry.ProductType = strings.Join(names, " > ")
+
+	data, err := xml.Marshal(entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render feed item: %w", err)
+	}
+	return data, nil
+}
+
+// imageLink returns the absolute URL of a product image, delivered through
+// the image CDN at full size when one is configured
+func (g *Generator) imageLink(source string) string {
+	if source == "" {
+		return ""
+	}
+	if variants := g.images.Variants(source); variants != nil {
+		source = variants.Full
+	}
+	if strings.HasPrefix(source, "/") && !strings.HasPrefix(source, "//") {
+		source = g.store.URL + source
+	}
+	return source
+}
+
+// price formats an amount in the feed's currency, e.g. 15.00 USD
+func (g *Generator) price(amount float64) string {
+	return fmt.Sprintf("%.2f %s", amount, g.config.Currency)
+}
+
+// writeHeader writes the start of the feed document, up to the first item
+func (g *Generator) writeHeader(b *strings.Builder) {
+	b.WriteString(xml.Header)
+	b.WriteString(`<rss version="2.0" xmlns:g="` + googleNamespace + `">` + "\n<channel>\n")
+	writeElement(b, "title", g.config.Title)
+	writeElement(b, "link", g.store.URL)
+	writeElement(b, "description", g.config.Title)
+}
+
+// writeFooter closes the feed document
+func writeFooter(b *strings.Builder) {
+	b.WriteString("</channel>\n</rss>\n")
+}
+
+func writeElement(b *strings.Builder, name, value string) {
+	b.WriteString("<" + name + ">")
+	xml.EscapeText(b, []byte(value))
+	b.WriteString("</" + name + ">\n")
+}
+
+// truncate shortens s to at most n characters
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
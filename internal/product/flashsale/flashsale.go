@@ -0,0 +1,273 @@
+// Package flashsale takes flash sale purchases in Redis. A running sale is
+// armed there with its remaining quantity and what each customer bought,
+// and every purchase is checked and counted by one script, so however many
+// arrive at once the sale never sells more than it has or more to one
+// customer than its limit allows. Purchases are queued for the product
+// service to write to Postgres in the background.
+package flashsale
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"ecommerce/internal/product/domain"
+)
+
+// Outcome is what came of a purchase
+type Outcome int
+
+// Purchase outcomes
+const (
+	Taken        Outcome = iota // the units are the customer's
+	NotArmed                    // the sale is not in Redis, or belongs to another tenant
+	NotRunning                  // the sale has not started or has ended
+	SoldOut                     // fewer units are left than asked for
+	LimitReached                // the customer would buy more than the sale allows
+)
+
+// armedTTL is how long a sale's state outlives its end, in case it is never
+// disarmed. Purchases still queued are kept until they are written.
+const armedTTL = 24 * time.Hour
+
+// arm loads a sale's state unless it is already armed, so instances arming
+// a sale at once don't reset what it has sold.
+//
+// KEYS[1] is the sale's hash and KEYS[2] its buyers; ARGV holds the sale's
+// id, tenant, price, remaining quantity, per-user limit, window in unix
+// milliseconds and expiry time, followed by each buyer and the units they
+// bought.
+var arm = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return 0
+end
+redis.call('DEL', KEYS[2])
+redis.call('HSET', KEYS[1], 'id', ARGV[1], 'tenant', ARGV[2], 'price', ARGV[3], 'remaining', ARGV[4], 'limit', ARGV[5], 'starts', ARGV[6], 'ends', ARGV[7])
+for i = 9, #ARGV, 2 do
+	redis.call('HSET', KEYS[2], ARGV[i], ARGV[i + 1])
+end
+redis.call('PEXPIREAT', KEYS[1], ARGV[8])
+redis.call('PEXPIREAT', KEYS[2], ARGV[8])
+return 1
+`)
+
+// purchase takes units of an armed sale for a customer: provided the sale
+// is running by Redis' clock, has the units left and the customer stays
+// within the limit, it counts them and queues the purchase. It returns the
+// outcome first: 1 for success, 0 when the sale is not armed, -1 when it
+// is not running, -2 when it is sold out, -3 when the limit is reached and
+// -4 when the sale belongs to another tenant. A success is followed by the
+// remaining quantity, the sale's id and its price.
+//
+// KEYS[1] is the sale's hash, KEYS[2] its buyers and KEYS[3] its queue of
+// purchases; ARGV holds the customer, the purchase id, the quantity, the
+// purchase time in unix milliseconds and the caller's tenant.
+var purchase = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return {0}
+end
+local sale = redis.call('HMGET', KEYS[1], 'remaining', 'limit', 'starts', 'ends', 'id', 'price', 'tenant')
+if ARGV[5] ~= '' and sale[7] ~= ARGV[5] then
+	return {-4}
+end
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+if now < tonumber(sale[3]) or now >= tonumber(sale[4]) then
+	return {-1}
+end
+local quantity = tonumber(ARGV[3])
+local remaining = tonumber(sale[1])
+if remaining < quantity then
+	return {-2}
+end
+local limit = tonumber(sale[2])
+if limit > 0 and tonumber(redis.call('HGET', KEYS[2], ARGV[1]) or '0') + quantity > limit then
+	return {-3}
+end
+redis.call('HINCRBY', KEYS[1], 'remaining', -quantity)
+redis.call('HINCRBY', KEYS[2], ARGV[1], quantity)
+redis.call('RPUSH', KEYS[3], cjson.encode({id = ARGV[2], sale = sale[5], user = ARGV[1], quantity = quantity, price = sale[6], at = ARGV[4]}))
+return {1, remaining - quantity, sale[5], sale[6]}
+`)
+
+// ack drops written purchases from the head of a sale's queue, each only
+// while it is still at the head. Instances may sync the same sale at once;
+// whichever acks second finds the purchases gone and leaves the ones queued
+// behind them alone.
+//
+// KEYS[1] is the sale's queue; ARGV holds the ids of the purchases written,
+// oldest first.
+var ack = redis.NewScript(`
+local dropped = 0
+for i = 1, #ARGV do
+	local head = redis.call('LINDEX', KEYS[1], 0)
+	if head and cjson.decode(head).id == ARGV[i] then
+		redis.call('LPOP', KEYS[1])
+		dropped = dropped + 1
+	end
+end
+return dropped
+`)
+
+// queued is a purchase as it waits in a sale's queue
+type queued struct {
+	ID       uuid.UUID `json:"id"`
+	Sale     uuid.UUID `json:"sale"`
+	User     string    `json:"user"`
+	Quantity int       `json:"quantity"`
+	Price    string    `json:"price"`
+	At       string    `json:"at"`
+}
+
+// Store holds the flash sales running in Redis
+type Store struct {
+	redis redis.UniversalClient
+}
+
+// New creates a flash sale store
+func New(redisClient redis.UniversalClient) *Store {
+	return &Store{redis: redisClient}
+}
+
+// keys returns the keys of a product's sale: its hash, buyers and queue of
+// purchases. They share a hash tag, so in a Redis Cluster they sit in one
+// slot and a script can use them together.
+func keys(productID uuid.UUID) []string {
+	tag := "{flashsale:" + productID.String() + "}"
+	return []string{tag + ":sale", tag + ":buyers", tag + ":pending"}
+}
+
+// Arm loads a running sale into Redis with the quantity it has left and
+// the units each customer bought, unless it is already armed
+func (s *Store) Arm(ctx context.Context, sale *domain.FlashSale, remaining int, buyers map[string]int) error {
+	args := []interface{}{
+		sale.ID.String(),
+		sale.TenantID,
+		strconv.FormatFloat(sale.Price, 'f', -1, 64),
+		remaining,
+		sale.PerUserLimit,
+		sale.StartsAt.UnixMilli(),
+		sale.EndsAt.UnixMilli(),
+		sale.EndsAt.Add(armedTTL).UnixMilli(),
+	}
+	for user, quantity := range buyers {
+		args = append(args, user, quantity)
+	}
+
+	if err := arm.Run(ctx, s.redis, keys(sale.ProductID)[:2], args...).Err(); err != nil {
+		return fmt.Errorf("failed to arm flash sale: %w", err)
+	}
+	return nil
+}
+
+// Purchase takes the units of a purchase from a product's running sale for
+// a caller of the given tenant, and queues it. Once taken, the purchase
+// has its sale and price filled in, and the quantity left is returned.
+func (s *Store) Purchase(ctx context.Context, tenantID string, p *domain.FlashSalePurchase) (Outcome, int, error) {
+	result, err := purchase.Run(ctx, s.redis, keys(p.ProductID),
+		p.UserID, p.ID.String(), p.Quantity, strconv.FormatInt(p.PurchasedAt.UnixMilli(), 10), tenantID,
+	).Slice()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to take flash sale purchase: %w", err)
+	}
+
+	switch result[0].(int64) {
+	case 0, -4:
+		return NotArmed, 0, nil
+	case -1:
+		return NotRunning, 0, nil
+	case -2:
+		return SoldOut, 0, nil
+	case -3:
+		return LimitReached, 0, nil
+	}
+
+	if p.FlashSaleID, err = uuid.Parse(result[2].(string)); err != nil {
+		return 0, 0, fmt.Errorf("invalid flash sale id: %w", err)
+	}
+	if p.Price, err = strconv.ParseFloat(result[3].(string), 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid flash sale price: %w", err)
+	}
+	return Taken, int(result[1].(int64)), nil
+}
+
+// Remaining returns the quantity a product's sale has left, and whether it
+// is armed
+func (s *Store) Remaining(ctx context.Context, productID uuid.UUID) (int, bool, error) {
+	remaining, err := s.redis.HGet(ctx, keys(productID)[0], "remaining").Int()
+	if errors.Is(err, redis.Nil) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get flash sale: %w", err)
+	}
+	return remaining, true, nil
+}
+
+// Pending returns up to n of the purchases queued for a product's sale,
+// oldest first
+func (s *Store) Pending(ctx context.Context, productID uuid.UUID, n int) ([]domain.FlashSalePurchase, error) {
+	entries, err := s.redis.LRange(ctx, keys(productID)[2], 0, int64(n)-1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queued flash sale purchases: %w", err)
+	}
+
+	purchases := make([]domain.FlashSalePurchase, len(entries))
+	for i, entry := range entries {
+		var q queued
+		if err := json.Unmarshal([]byte(entry), &q); err != nil {
+			return nil, fmt.Errorf("invalid queued flash sale purchase: %w", err)
+		}
+		price, err := strconv.ParseFloat(q.Price, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid queued flash sale price: %w", err)
+		}
+		at, err := strconv.ParseInt(q.At, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid queued flash sale purchase time: %w", err)
+		}
+		purchases[i] = domain.FlashSalePurchase{
+			ID:          q.ID,
+			FlashSaleID: q.Sale,
+			ProductID:   productID,
+			UserID:      q.User,
+			Quantity:    q.Quantity,
+			Price:       price,
+			PurchasedAt: time.UnixMilli(at),
+		}
+	}
+	return purchases, nil
+}
+
+// Ack drops purchases returned by Pending from the queue of a product's
+// sale, once they are written. Only those purchases are dropped, however
+// many were queued or dropped by others since.
+func (s *Store) Ack(ctx context.Context, productID uuid.UUID, purchases []domain.FlashSalePurchase) error {
+	if len(purchases) == 0 {
+		return nil
+	}
+
+	ids := make([]interface{}, len(purchases))
+	for i, purchase := range purchases {
+		ids[i] = purchase.ID.String()
+	}
+	if err := ack.Run(ctx, s.redis, keys(productID)[2:], ids...).Err(); err != nil {
+		return fmt.Errorf("failed to drop queued flash sale purchases: %w", err)
+	}
+	return nil
+}
+
+// Disarm removes a product's sale from Redis, with any purchases still
+// queued
+func (s *Store) Disarm(ctx context.Context, productID uuid.UUID) error {
+	if err := s.redis.Del(ctx, keys(productID)...).Err(); err != nil {
+		return fmt.Errorf("failed to disarm flash sale: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package handler_test
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/contracts"
+	"ecommerce/internal/product/config"
+	"ecommerce/internal/product/domain"
+	"ecommerce/internal/product/handler"
+	"ecommerce/internal/product/repository/memory"
+	"ecommerce/internal/product/search"
+	"ecommerce/internal/product/service"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/contract"
+	"ecommerce/pkg/events"
+	"ecommerce/pkg/health"
+	"ecommerce/pkg/media"
+	"ecommerce/pkg/response"
+)
+
+// discard drops published events
+type discard struct{}
+
+func (discard) Publish(ctx context.Context, event events.Event) error { return nil }
+
+// TestConsumerContracts replays what the services calling the product
+// service rely on against its routes, backed by the in-memory repository
+func TestConsumerContracts(t *testing.T) {
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	images, err := media.NewBuilder(media.Config{
+		Mode:       cfg.Images.Mode,
+		BaseURL:    cfg.Images.BaseURL,
+		Origins:    cfg.Images.Origins,
+		Key:        cfg.Images.Key,
+		Salt:       cfg.Images.Salt,
+		Format:     cfg.Images.Format,
+		ThumbSize:  cfg.Images.ThumbSize,
+		MediumSize: cfg.Images.MediumSize,
+		FullSize:   cfg.Images.FullSize,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := memory.NewProductRepository()
+	productService := service.NewProductService(repo, repo, search.NewPostgresSearcher(repo), discard{}, nil, nil, images, nil, cfg.Stock, cfg.Sale, cfg.Publish, cfg.FlashSale, cfg.Locale, cfg.Media, logger)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(response.Format(cfg.HTTP.ErrorFormat))
+	router.Use(auth.Middleware(cfg.Auth.JWTSecret, cfg.Auth.IdentitySecret))
+	handler.NewHTTPHandler(productService, nil, nil, nil, nil, cfg, health.NewRegistry(0), logger).RegisterRoutes(router)
+
+	// published creates a published product with stock in a category and
+	// brand of its own
+	published := func(t *testing.T) *domain.Product {
+		ctx := context.Background()
+		suffix := uuid.NewString()[:8]
+
+		category := &domain.Category{Name: "Contract Category " + suffix, Slug: "contract-category-" + suffix}
+		if err := repo.CreateCategory(ctx, category); err != nil {
+			t.Fatal(err)
+		}
+		brand := &domain.Brand{Name: "Contract Brand " + suffix}
+		if err := repo.CreateBrand(ctx, brand); err != nil {
+			t.Fatal(err)
+		}
+		product := &domain.Product{
+			Name:       "Contract Product",
+			Slug:       "contract-product-" + suffix,
+			Price:      19.99,
+			CategoryID: category.ID,
+			BrandID:    &brand.ID,
+			Stock:      10,
+			SKU:        "CONTRACT-" + suffix,
+			Status:     domain.ProductStatusPublished,
+		}
+		if err := repo.Create(ctx, product, domain.StockMovement{Reason: domain.StockReasonInitial}); err != nil {
+			t.Fatal(err)
+		}
+		return product
+	}
+
+	states := map[string]contract.State{
+		"a published product": func(t *testing.T) map[string]string {
+			product := published(t)
+			return map[string]string{"product_id": product.ID.String(), "slug": product.Slug}
+		},
+		"a published product with stock": func(t *testing.T) map[string]string {
+			product := published(t)
+			return map[string]string{"product_id": product.ID.String(), "reference": uuid.NewString()}
+		},
+		"a stock reservation": func(t *testing.T) map[string]string {
+			product := published(t)
+			reference := uuid.NewString()
+			items := []domain.StockItem{{ProductID: product.ID, Quantity: 1}}
+			if _, err := repo.ReserveStock(context.Background(), reference, items, domain.StockMovement{Reason: domain.StockReasonReservation}); err != nil {
+				t.Fatal(err)
+			}
+			return map[string]string{"reference": reference}
+		},
+	}
+
+	for _, name := range []string{"order-service.product-service.json", "api-gateway.product-service.json"} {
+		c, err := contract.Load(contracts.FS, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Run(c.Consumer, func(t *testing.T) {
+			contract.Verify(t, c, router, states)
+		})
+	}
+}
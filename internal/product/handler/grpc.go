@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"ecommerce/internal/product/service"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/database"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/tenant"
+	"ecommerce/proto/productpb"
+)
+
+// GRPCHandler serves the API defined in proto/product.proto, over gRPC to
+// the other services and as JSON on the paths of proto/product.http.yaml
+type GRPCHandler struct {
+	productpb.UnimplementedProductServiceServer
+
+	service service.ProductService
+	logger  *logrus.Logger
+}
+
+// NewGRPCHandler creates a new gRPC handler
+func NewGRPCHandler(service service.ProductService, logger *logrus.Logger) *GRPCHandler {
+	return &GRPCHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Register registers the handler with a gRPC server
+func (h *GRPCHandler) Register(server *grpc.Server) {
+	productpb.RegisterProductServiceServer(server, h)
+}
+
+// Transcoder returns the HTTP handler serving the RPCs as JSON, for the
+// router's unmatched paths. Calls go straight to the handler, skipping the
+// gRPC interceptors, so the router must run auth.Middleware and
+// tenant.Middleware before it: they scope the context to the caller and
+// their tenant just as Identity does.
+func (h *GRPCHandler) Transcoder(ctx context.Context) (gin.HandlerFunc, error) {
+	mux := runtime.NewServeMux(runtime.WithRoutingErrorHandler(notRouted))
+	if err := productpb.RegisterProductServiceHandlerServer(ctx, mux, h); err != nil {
+		return nil, err
+	}
+	return func(c *gin.Context) {
+		// The router marks unmatched paths 404 before they get here
+		c.Status(http.StatusOK)
+		mux.ServeHTTP(c.Writer, c.Request)
+	}, nil
+}
+
+// notRouted answers requests no RPC is transcoded for as the router does
+// without a transcoder
+func notRouted(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, status int) {
+	message := strings.ToLower(http.StatusText(status))
+	if status == http.StatusNotFound {
+		message = "page not found"
+	}
+	http.Error(w, fmt.Sprintf("%d %s", status, message), status)
+}
+
+// GetProduct handles getting a product by ID
+func (h *GRPCHandler) GetProduct(ctx context.Context, req *productpb.GetProductRequest) (*productpb.GetProductResponse, error) {
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid product ID")
+	}
+
+	product, err := h.service.GetProduct(ctx, id)
+	if err != nil {
+		return nil, h.grpcError(err)
+	}
+
+	return &productpb.GetProductResponse{
+		Id:          product.ID.String(),
+		Name:        product.Name,
+		Description: product.Description,
+		Price:       float32(product.Price),
+	}, nil
+}
+
+// grpcError converts a service error to the status it is reported with,
+// matching the HTTP statuses of handleError once transcoded
+func (h *GRPCHandler) grpcError(err error) error {
+	err = database.TranslateError(err)
+	switch {
+	case errors.IsNotFound(err):
+		return status.Error(codes.NotFound, errors.Message(err))
+	case errors.IsValidation(err):
+		return status.Error(codes.InvalidArgument, errors.Message(err))
+	case errors.IsConflict(err):
+		return status.Error(codes.Aborted, errors.Message(err))
+	case errors.IsUnauthorized(err):
+		return status.Error(codes.Unauthenticated, errors.Message(err))
+	case errors.IsForbidden(err):
+		return status.Error(codes.PermissionDenied, errors.Message(err))
+	case errors.IsUnavailable(err):
+		return status.Error(codes.Unavailable, errors.Message(err))
+	default:
+		h.logger.WithError(err).Error("Internal server error")
+		return status.Error(codes.Internal, "Internal server error")
+	}
+}
+
+// Identity returns the interceptors scoping every gRPC call to its caller
+// and their tenant, as auth.Middleware and tenant.Middleware do over HTTP.
+// Callers are named by signed identity metadata from the gateway, or else
+// a bearer token. Authenticated calls are scoped to the caller's tenant
+// whatever they ask for; anonymous ones to the tenant named by their
+// x-tenant-id metadata, or Default.
+func Identity(jwtSecret, identitySecret string) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	unary := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := identify(ctx, jwtSecret, identitySecret)
+		if err != nil {
+			return nil, err
+		}
+		return next(ctx, req)
+	}
+	stream := func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, next grpc.StreamHandler) error {
+		ctx, err := identify(ss.Context(), jwtSecret, identitySecret)
+		if err != nil {
+			return err
+		}
+		return next(srv, &identifiedStream{ServerStream: ss, ctx: ctx})
+	}
+	return unary, stream
+}
+
+// identify returns ctx scoped to the caller named by its incoming metadata
+// and to their tenant
+func identify(ctx context.Context, jwtSecret, identitySecret string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	header := make(http.Header, len(md))
+	for key, values := range md {
+		header[http.CanonicalHeaderKey(key)] = values
+	}
+
+	id := tenant.Default
+	if actor := auth.Identify(header, jwtSecret, identitySecret); actor != nil {
+		ctx = auth.WithActor(ctx, actor)
+		id = tenant.Resolve(actor)
+	} else if requested := header.Get(tenant.Header); requested != "" {
+		if !tenant.Valid(requested) {
+			return nil, status.Error(codes.InvalidArgument, "Invalid tenant ID")
+		}
+		id = requested
+	}
+	return tenant.WithID(ctx, id), nil
+}
+
+// identifiedStream is a server stream whose context is scoped to its caller
+type identifiedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the stream's scoped context
+func (s *identifiedStream) Context() context.Context {
+	return s.ctx
+}
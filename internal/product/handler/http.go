@@ -1,36 +1,71 @@
 package handler
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
 	"net/http"
+	"path"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 
+	"ecommerce/internal/product/config"
 	"ecommerce/internal/product/domain"
+	"ecommerce/internal/product/export"
+	"ecommerce/internal/product/feed"
+	"ecommerce/internal/product/live"
 	"ecommerce/internal/product/service"
+	"ecommerce/internal/product/sitemap"
+	"ecommerce/internal/product/suggest"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/database"
 	"ecommerce/pkg/errors"
+	"ecommerce/pkg/events"
+	"ecommerce/pkg/health"
+	"ecommerce/pkg/mergepatch"
+	"ecommerce/pkg/metrics"
 	"ecommerce/pkg/response"
 )
 
 // HTTPHandler handles HTTP requests for product service
 type HTTPHandler struct {
 	service service.ProductService
+	feed    *feed.Generator    // nil when feeds are not configured
+	sitemap *sitemap.Generator // nil when sitemaps are not configured
+	config  *config.Config
+	health  *health.Registry
 	logger  *logrus.Logger
+
+	suggester *suggest.Suggester
+	live      *live.Hub // nil when product updates are not streamed
 }
 
 // NewHTTPHandler creates a new HTTP handler
-func NewHTTPHandler(service service.ProductService, logger *logrus.Logger) *HTTPHandler {
+func NewHTTPHandler(service service.ProductService, feed *feed.Generator, sitemap *sitemap.Generator, suggester *suggest.Suggester, live *live.Hub, cfg *config.Config, health *health.Registry, logger *logrus.Logger) *HTTPHandler {
 	return &HTTPHandler{
-		service: service,
-		logger:  logger,
+		service:   service,
+		feed:      feed,
+		sitemap:   sitemap,
+		config:    cfg,
+		health:    health,
+		logger:    logger,
+		suggester: suggester,
+		live:      live,
 	}
 }
 
 // RegisterRoutes registers all HTTP routes
 func (h *HTTPHandler) RegisterRoutes(router *gin.Engine) {
-	api := router.Group("/api/v1")
+	api := router.Group("/api/v1", h.vendorScope)
+
+	// Event intake from other services
+	api.POST("/events", h.HandleEvent)
 
 	// Product routes
 	products := api.Group("/products")
@@ -38,9 +73,50 @@ func (h *HTTPHandler) RegisterRoutes(router *gin.Engine) {
 		products.POST("", h.CreateProduct)
 		products.GET("", h.ListProducts)
 		products.GET("/search", h.SearchProducts)
+		products.GET("/suggest", h.SuggestProducts)
+		products.GET("/stream", h.StreamProducts)
+		products.GET("/low-stock", h.ListLowStockProducts)
+		products.POST("/bulk", h.BulkUpdateProducts)
+		products.POST("/import", h.ImportProducts)
+		products.GET("/export", h.ExportProducts)
+		products.GET("/slug/:slug", h.GetProductBySlug)
+		products.PUT("/sku/:sku", h.UpsertProductBySKU)
 		products.GET("/:id", h.GetProduct)
 		products.PUT("/:id", h.UpdateProduct)
+		products.PATCH("/:id", h.PatchProduct)
 		products.DELETE("/:id", h.DeleteProduct)
+		products.POST("/:id/restore", h.RestoreProduct)
+		products.POST("/:id/duplicate", h.DuplicateProduct)
+		products.POST("/:id/publish", h.PublishProduct)
+		products.POST("/:id/unpublish", h.UnpublishProduct)
+		products.GET("/:id/stock-history", h.GetStockHistory)
+		products.POST("/:id/stock/adjustments", h.AdjustStock)
+		products.GET("/:id/related", h.GetRelatedProducts)
+		products.POST("/:id/related", h.AddProductRelation)
+		products.DELETE("/:id/related/:relatedId", h.RemoveProductRelation)
+		products.GET("/:id/translations", h.ListProductTranslations)
+		products.PUT("/:id/translations/:locale", h.UpsertProductTranslation)
+		products.DELETE("/:id/translations/:locale", h.DeleteProductTranslation)
+		products.GET("/:id/media", h.ListProductMedia)
+		products.POST("/:id/media/uploads", h.CreateMediaUpload)
+		products.POST("/:id/media/:mediaId/complete", h.CompleteMediaUpload)
+		products.DELETE("/:id/media/:mediaId", h.DeleteMedia)
+		products.GET("/:id/assets", h.ListDigitalAssets)
+		products.POST("/:id/assets", h.CreateDigitalAsset)
+		products.DELETE("/:id/assets/:assetId", h.DeleteDigitalAsset)
+		products.PUT("/:id/components", h.SetBundleComponents)
+		products.PUT("/:id/price-tiers", h.SetPriceTiers)
+		products.PUT("/:id/channels", h.SetProductChannels)
+		products.GET("/:id/flash-sale", h.GetFlashSale)
+		products.PUT("/:id/flash-sale", h.SetFlashSale)
+		products.DELETE("/:id/flash-sale", h.DeleteFlashSale)
+		products.POST("/:id/flash-sale/purchases", h.PurchaseFlashSale)
+		products.POST("/:id/approve", h.ApproveProduct)
+		products.POST("/:id/reject", h.RejectProduct)
+		products.GET("/:id/revisions", h.ListProductRevisions)
+		products.POST("/:id/revisions", h.SubmitRevision)
+		products.GET("/:id/reviews", h.ListProductReviews)
+		products.POST("/:id/reviews", h.CreateReview)
 	}
 
 	// Category routes
@@ -48,14 +124,224 @@ func (h *HTTPHandler) RegisterRoutes(router *gin.Engine) {
 	{
 		categories.POST("", h.CreateCategory)
 		categories.GET("", h.ListCategories)
+		categories.GET("/tree", h.GetCategoryTree)
+		categories.GET("/slug/:slug", h.GetCategoryBySlug)
 		categories.GET("/:id", h.GetCategory)
 		categories.PUT("/:id", h.UpdateCategory)
 		categories.DELETE("/:id", h.DeleteCategory)
+		categories.POST("/:id/move", h.MoveCategory)
+		categories.POST("/:id/merge", h.MergeCategory)
+		categories.GET("/:id/attributes", h.ListAttributeDefinitions)
+		categories.POST("/:id/attributes", h.CreateAttributeDefinition)
+		categories.GET("/:id/translations", h.ListCategoryTranslations)
+		categories.PUT("/:id/translations/:locale", h.UpsertCategoryTranslation)
+		categories.DELETE("/:id/translations/:locale", h.DeleteCategoryTranslation)
+	}
+
+	// Attribute definition routes
+	attributes := api.Group("/attributes")
+	{
+		attributes.PUT("/:id", h.UpdateAttributeDefinition)
+		attributes.DELETE("/:id", h.DeleteAttributeDefinition)
+	}
+
+	// Brand routes
+	brands := api.Group("/brands")
+	{
+		brands.POST("", h.CreateBrand)
+		brands.GET("", h.ListBrands)
+		brands.GET("/:id", h.GetBrand)
+		brands.PUT("/:id", h.UpdateBrand)
+		brands.DELETE("/:id", h.DeleteBrand)
+	}
+
+	// Vendor routes
+	vendors := api.Group("/vendors")
+	{
+		vendors.POST("", h.CreateVendor)
+		vendors.GET("", h.ListVendors)
+		vendors.GET("/me", h.GetOwnVendor)
+		vendors.GET("/:id", h.GetVendor)
+		vendors.PUT("/:id", h.UpdateVendor)
+	}
+
+	// Warehouse routes
+	warehouses := api.Group("/warehouses")
+	{
+		warehouses.POST("", h.CreateWarehouse)
+		warehouses.GET("", h.ListWarehouses)
+		warehouses.GET("/:id", h.GetWarehouse)
+		warehouses.PUT("/:id", h.UpdateWarehouse)
+	}
+
+	// Review moderation routes
+	reviews := api.Group("/reviews")
+	{
+		reviews.GET("", h.ListReviews)
+		reviews.PUT("/:id/moderate", h.ModerateReview)
+	}
+
+	// Product revision review routes
+	revisions := api.Group("/revisions")
+	{
+		revisions.GET("", h.ListRevisions)
+		revisions.GET("/:id", h.GetRevision)
+		revisions.POST("/:id/approve", h.ApproveRevision)
+		revisions.POST("/:id/reject", h.RejectRevision)
+	}
+
+	// Changeset routes
+	changesets := api.Group("/changesets")
+	{
+		changesets.POST("", h.CreateChangeset)
+		changesets.GET("", h.ListChangesets)
+		changesets.GET("/:id", h.GetChangeset)
+		changesets.PUT("/:id", h.UpdateChangeset)
+		changesets.DELETE("/:id", h.DeleteChangeset)
+		changesets.GET("/:id/preview", h.PreviewChangeset)
+		changesets.PUT("/:id/items/:productId", h.SetChangesetItem)
+		changesets.DELETE("/:id/items/:productId", h.DeleteChangesetItem)
+		changesets.POST("/:id/apply", h.ApplyChangeset)
+		changesets.POST("/:id/schedule", h.ScheduleChangeset)
+		changesets.POST("/:id/unschedule", h.UnscheduleChangeset)
+		changesets.POST("/:id/rollback", h.RollbackChangeset)
+	}
+
+	// Inventory routes
+	inventory := api.Group("/inventory")
+	{
+		inventory.POST("/adjustments", h.BulkAdjustStock)
+	}
+
+	// Stock reservation routes
+	reservations := api.Group("/stock/reservations")
+	{
+		reservations.POST("", h.ReserveStock)
+		reservations.GET("/:reference", h.GetStockReservation)
+		reservations.DELETE("/:reference", h.ReleaseStock)
+	}
+	api.POST("/stock/returns", h.ReturnStock)
+
+	// Download routes
+	entitlements := api.Group("/entitlements")
+	{
+		entitlements.GET("", h.ListEntitlements)
+		entitlements.GET("/:id", h.GetEntitlement)
+	}
+
+	// Import routes
+	imports := api.Group("/imports")
+	{
+		imports.GET("/:id", h.GetImportJob)
+	}
+
+	// Feed routes
+	feeds := api.Group("/feeds")
+	{
+		feeds.GET("/google-merchant.xml", h.GetGoogleMerchantFeed)
+	}
+
+	// Sitemap routes
+	sitemaps := api.Group("/sitemaps")
+	{
+		sitemaps.GET("/:name", h.GetSitemap)
+	}
+
+	// Search tuning routes
+	searchTuning := api.Group("/search")
+	{
+		searchTuning.GET("/synonyms", h.ListSearchSynonyms)
+		searchTuning.POST("/synonyms", h.CreateSearchSynonym)
+		searchTuning.PUT("/synonyms/:id", h.UpdateSearchSynonym)
+		searchTuning.DELETE("/synonyms/:id", h.DeleteSearchSynonym)
+		searchTuning.GET("/zero-results", h.ListZeroResultSearches)
+		searchTuning.DELETE("/zero-results/:id", h.DeleteZeroResultSearch)
+	}
+
+	// Cache debugging routes
+	cache := api.Group("/cache")
+	{
+		cache.GET("/keys", h.ListCacheKeys)
+		cache.GET("/entry", h.InspectCacheEntry)
+		cache.POST("/purge", h.PurgeCache)
+		cache.POST("/warm", h.WarmCache)
+	}
+
+	// Audit routes
+	audit := api.Group("/audit")
+	{
+		audit.GET("", h.ListAuditEvents)
+		audit.GET("/:id", h.GetAuditEvent)
 	}
 
 	// Health check
 	router.GET("/health", h.HealthCheck)
 	router.GET("/ready", h.ReadinessCheck)
+
+	// Prometheus metrics
+	router.GET("/metrics", gin.WrapH(metrics.Default.Handler()))
+}
+
+// vendorRoutes are the only changes vendors may make to the catalog, each
+// saying whether it changes a product, which must then be the vendor's own
+var vendorRoutes = map[string]bool{
+	"POST /api/v1/products":                             false,
+	"PUT /api/v1/products/:id":                          true,
+	"PATCH /api/v1/products/:id":                        true,
+	"DELETE /api/v1/products/:id":                       true,
+	"POST /api/v1/products/:id/duplicate":               true,
+	"POST /api/v1/products/:id/publish":                 true,
+	"POST /api/v1/products/:id/unpublish":               true,
+	"POST /api/v1/products/:id/stock/adjustments":       true,
+	"PUT /api/v1/products/:id/translations/:locale":     true,
+	"DELETE /api/v1/products/:id/translations/:locale":  true,
+	"POST /api/v1/products/:id/media/uploads":           true,
+	"POST /api/v1/products/:id/media/:mediaId/complete": true,
+	"DELETE /api/v1/products/:id/media/:mediaId":        true,
+	"POST /api/v1/products/:id/assets":                  true,
+	"DELETE /api/v1/products/:id/assets/:assetId":       true,
+	"POST /api/v1/products/:id/revisions":               true,
+}
+
+// vendorScope makes requests by vendors act for the vendor they sell as,
+// and keeps their changes to the routes in vendorRoutes and to their own
+// products
+func (h *HTTPHandler) vendorScope(c *gin.Context) {
+	ctx, err := h.service.VendorScope(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		c.Abort()
+		return
+	}
+	c.Request = c.Request.WithContext(ctx)
+
+	if !auth.HasRole(ctx, auth.RoleVendor) || c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+		c.Next()
+		return
+	}
+
+	ownProduct, allowed := vendorRoutes[c.Request.Method+" "+c.FullPath()]
+	if !allowed {
+		h.handleError(c, errors.NewForbiddenError("Vendors may only manage their own products", nil))
+		c.Abort()
+		return
+	}
+	var productID *uuid.UUID
+	if ownProduct {
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+			c.Abort()
+			return
+		}
+		productID = &id
+	}
+	if err := h.service.AuthorizeVendorChange(ctx, productID); err != nil {
+		h.handleError(c, err)
+		c.Abort()
+		return
+	}
+	c.Next()
 }
 
 // CreateProduct handles product creation
@@ -76,7 +362,30 @@ func (h *HTTPHandler) CreateProduct(c *gin.Context) {
 	response.Success(c, http.StatusCreated, "Product created successfully", product)
 }
 
-// GetProduct handles getting a single product
+// UpsertProductBySKU handles creating or replacing a product by SKU
+func (h *HTTPHandler) UpsertProductBySKU(c *gin.Context) {
+	var req domain.CreateProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	product, created, err := h.service.UpsertProductBySKU(c.Request.Context(), c.Param("sku"), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	if created {
+		response.Success(c, http.StatusCreated, "Product created successfully", product)
+		return
+	}
+	response.Success(c, http.StatusOK, "Product updated successfully", product)
+}
+
+// GetProduct handles getting a single product, with its stock in each
+// warehouse or, given ?warehouse=, in that one
 func (h *HTTPHandler) GetProduct(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -85,13 +394,52 @@ func (h *HTTPHandler) GetProduct(c *gin.Context) {
 		return
 	}
 
-	product, err := h.service.GetProduct(c.Request.Context(), id)
+	fields, ok := parseFields(c)
+	if !ok {
+		return
+	}
+
+	product, err := h.service.GetProduct(h.priced(c), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	if err := h.service.AddAvailability(c.Request.Context(), c.Query("warehouse"), product); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Product retrieved successfully", domain.SparseProduct{Product: product, Fields: fields})
+}
+
+// GetProductBySlug handles getting a product by slug, redirecting retired slugs
+func (h *HTTPHandler) GetProductBySlug(c *gin.Context) {
+	slug := c.Param("slug")
+	fields, ok := parseFields(c)
+	if !ok {
+		return
+	}
+
+	product, err := h.service.GetProductBySlug(h.priced(c), slug)
 	if err != nil {
 		h.handleError(c, err)
 		return
 	}
 
-	response.Success(c, http.StatusOK, "Product retrieved successfully", product)
+	if product.Slug != slug {
+		target := path.Join(path.Dir(c.Request.URL.Path), product.Slug)
+		if c.Request.URL.RawQuery != "" {
+			target += "?" + c.Request.URL.RawQuery
+		}
+		c.Redirect(http.StatusMovedPermanently, target)
+		return
+	}
+	if err := h.service.AddAvailability(c.Request.Context(), c.Query("warehouse"), product); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Product retrieved successfully", domain.SparseProduct{Product: product, Fields: fields})
 }
 
 // UpdateProduct handles product updates
@@ -119,8 +467,8 @@ func (h *HTTPHandler) UpdateProduct(c *gin.Context) {
 	response.Success(c, http.StatusOK, "Product updated successfully", product)
 }
 
-// DeleteProduct handles product deletion
-func (h *HTTPHandler) DeleteProduct(c *gin.Context) {
+// PatchProduct handles product updates sent as a JSON Merge Patch
+func (h *HTTPHandler) PatchProduct(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
@@ -128,200 +476,2503 @@ func (h *HTTPHandler) DeleteProduct(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.DeleteProduct(c.Request.Context(), id); err != nil {
-		h.handleError(c, err)
+	if contentType := c.ContentType(); contentType != mergepatch.ContentType && contentType != "application/json" {
+		c.Header("Accept-Patch", mergepatch.ContentType)
+		response.Error(c, http.StatusUnsupportedMediaType, "Unsupported media type", nil)
 		return
 	}
 
-	response.Success(c, http.StatusOK, "Product deleted successfully", nil)
-}
-
-// ListProducts handles product listing with filters
-func (h *HTTPHandler) ListProducts(c *gin.Context) {
-	filters := &domain.ProductFilters{}
-
-	// Parse query parameters
-	if categoryID := c.Query("category_id"); categoryID != "" {
-		if id, err := uuid.Parse(categoryID); err == nil {
-			filters.CategoryID = &id
-		}
-	}
-
-	if minPrice := c.Query("min_price"); minPrice != "" {
-		if price, err := strconv.ParseFloat(minPrice, 64); err == nil {
-			filters.MinPrice = &price
-		}
+	patch, err := c.GetRawData()
+	if err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
 	}
 
-	if maxPrice := c.Query("max_price"); maxPrice != "" {
-		if price, err := strconv.ParseFloat(maxPrice, 64); err == nil {
-			filters.MaxPrice = &price
-		}
+	product, err := h.service.PatchProduct(c.Request.Context(), id, patch)
+	if err != nil {
+		h.handleError(c, err)
+		return
 	}
 
-	filters.Search = c.Query("search")
+	response.Success(c, http.StatusOK, "Product updated successfully", product)
+}
 
-	if isActive := c.Query("is_active"); isActive != "" {
-		if active, err := strconv.ParseBool(isActive); err == nil {
-			filters.IsActive = &active
-		}
+// DeleteProduct handles product deletion
+func (h *HTTPHandler) DeleteProduct(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
 	}
 
-	if inStock := c.Query("in_stock"); inStock != "" {
-		if stock, err := strconv.ParseBool(inStock); err == nil {
-			filters.InStock = &stock
-		}
+	if err := h.service.DeleteProduct(c.Request.Context(), id); err != nil {
+		h.handleError(c, err)
+		return
 	}
 
-	if limit := c.Query("limit"); limit != "" {
-		if l, err := strconv.Atoi(limit); err == nil {
-			filters.Limit = l
-		}
-	}
+	response.Success(c, http.StatusOK, "Product deleted successfully", nil)
+}
 
-	if offset := c.Query("offset"); offset != "" {
-		if o, err := strconv.Atoi(offset); err == nil {
-			filters.Offset = o
-		}
+// RestoreProduct handles restoring a soft-deleted product
+func (h *HTTPHandler) RestoreProduct(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
 	}
 
-	filters.SortBy = c.DefaultQuery("sort_by", "created_at")
-	filters.SortOrder = c.DefaultQuery("sort_order", "desc")
-
-	productList, err := h.service.ListProducts(c.Request.Context(), filters)
+	product, err := h.service.RestoreProduct(c.Request.Context(), id)
 	if err != nil {
 		h.handleError(c, err)
 		return
 	}
 
-	response.Success(c, http.StatusOK, "Products retrieved successfully", productList)
+	response.Success(c, http.StatusOK, "Product restored successfully", product)
 }
 
-// SearchProducts handles product search
-func (h *HTTPHandler) SearchProducts(c *gin.Context) {
-	query := c.Query("q")
-	if query == "" {
-		response.Error(c, http.StatusBadRequest, "Search query is required", nil)
+// DuplicateProduct handles copying a product into a new draft. The body is
+// optional.
+func (h *HTTPHandler) DuplicateProduct(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
 		return
 	}
 
-	filters := &domain.ProductFilters{}
-
-	// Parse additional filters
-	if categoryID := c.Query("category_id"); categoryID != "" {
-		if id, err := uuid.Parse(categoryID); err == nil {
-			filters.CategoryID = &id
-		}
-	}
-
-	if limit := c.Query("limit"); limit != "" {
-		if l, err := strconv.Atoi(limit); err == nil {
-			filters.Limit = l
-		}
-	}
-
-	if offset := c.Query("offset"); offset != "" {
-		if o, err := strconv.Atoi(offset); err == nil {
-			filters.Offset = o
-		}
+	var req domain.DuplicateProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
 	}
 
-	productList, err := h.service.SearchProducts(c.Request.Context(), query, filters)
+	product, err := h.service.DuplicateProduct(c.Request.Context(), id, &req)
 	if err != nil {
 		h.handleError(c, err)
 		return
 	}
 
-	response.Success(c, http.StatusOK, "Search results retrieved successfully", productList)
+	response.Success(c, http.StatusCreated, "Product duplicated successfully", product)
 }
 
-// CreateCategory handles category creation
-func (h *HTTPHandler) CreateCategory(c *gin.Context) {
-	var req domain.CreateCategoryRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+// PublishProduct handles publishing a product now or scheduling it. The
+// body is optional.
+func (h *HTTPHandler) PublishProduct(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	var req domain.PublishProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
 		h.logger.WithError(err).Error("Invalid request body")
 		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
 
-	category, err := h.service.CreateCategory(c.Request.Context(), &req)
+	product, err := h.service.PublishProduct(c.Request.Context(), id, &req)
 	if err != nil {
 		h.handleError(c, err)
 		return
 	}
 
-	response.Success(c, http.StatusCreated, "Category created successfully", category)
+	message := "Product published successfully"
+	if !product.IsPublished() {
+		message = "Product scheduled for publishing successfully"
+	}
+	response.Success(c, http.StatusOK, message, product)
 }
 
-// GetCategory handles getting a single category
-func (h *HTTPHandler) GetCategory(c *gin.Context) {
+// UnpublishProduct handles taking a product off the storefront
+func (h *HTTPHandler) UnpublishProduct(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, "Invalid category ID", err)
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
 		return
 	}
 
-	category, err := h.service.GetCategory(c.Request.Context(), id)
+	product, err := h.service.UnpublishProduct(c.Request.Context(), id)
 	if err != nil {
 		h.handleError(c, err)
 		return
 	}
 
-	response.Success(c, http.StatusOK, "Category retrieved successfully", category)
+	response.Success(c, http.StatusOK, "Product unpublished successfully", product)
 }
 
-// UpdateCategory handles category updates
-func (h *HTTPHandler) UpdateCategory(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := uuid.Parse(idStr)
+// ApproveProduct handles approving a vendor product awaiting review. The
+// body is optional.
+func (h *HTTPHandler) ApproveProduct(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, "Invalid category ID", err)
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
 		return
 	}
 
-	var req domain.UpdateCategoryRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	var req domain.ReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
 		h.logger.WithError(err).Error("Invalid request body")
 		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
 
-	category, err := h.service.UpdateCategory(c.Request.Context(), id, &req)
+	product, err := h.service.ApproveProduct(c.Request.Context(), id, &req)
 	if err != nil {
 		h.handleError(c, err)
 		return
 	}
 
-	response.Success(c, http.StatusOK, "Category updated successfully", category)
+	response.Success(c, http.StatusOK, "Product approved successfully", product)
 }
 
-// DeleteCategory handles category deletion
-func (h *HTTPHandler) DeleteCategory(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := uuid.Parse(idStr)
+// RejectProduct handles turning down a vendor product awaiting review. The
+// body is optional.
+func (h *HTTPHandler) RejectProduct(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, "Invalid category ID", err)
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
 		return
 	}
 
-	if err := h.service.DeleteCategory(c.Request.Context(), id); err != nil {
+	var req domain.ReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	product, err := h.service.RejectProduct(c.Request.Context(), id, &req)
+	if err != nil {
 		h.handleError(c, err)
 		return
 	}
 
-	response.Success(c, http.StatusOK, "Category deleted successfully", nil)
+	response.Success(c, http.StatusOK, "Product rejected successfully", product)
+}
+
+// ListProducts handles product listing with filters
+func (h *HTTPHandler) ListProducts(c *gin.Context) {
+	filters := parseProductFilters(c)
+
+	fields, ok := parseFields(c)
+	if !ok {
+		return
+	}
+	filters.Fields = fields
+
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			filters.Limit = l
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if o, err := strconv.Atoi(offset); err == nil {
+			filters.Offset = o
+		}
+	}
+
+	filters.Cursor = c.Query("cursor")
+
+	if facets := c.Query("facets"); facets != "" {
+		if include, err := strconv.ParseBool(facets); err == nil {
+			filters.Facets = include
+		}
+	}
+
+	filters.SortBy = c.DefaultQuery("sort_by", "created_at")
+	filters.SortOrder = c.DefaultQuery("sort_order", "desc")
+
+	productList, err := h.service.ListProducts(h.priced(c), filters)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Products retrieved successfully", sparseProductList(productList, fields))
+}
+
+// SearchProducts handles product search
+func (h *HTTPHandler) SearchProducts(c *gin.Context) {
+	query := c.Query("q")
+	if query == "" {
+		response.Error(c, http.StatusBadRequest, "Search query is required", nil)
+		return
+	}
+
+	filters := &domain.ProductFilters{}
+
+	fields, ok := parseFields(c)
+	if !ok {
+		return
+	}
+	filters.Fields = fields
+
+	// Parse additional filters
+	if categoryID := c.Query("category_id"); categoryID != "" {
+		if id, err := uuid.Parse(categoryID); err == nil {
+			filters.CategoryID = &id
+		}
+	}
+
+	if brandID := c.Query("brand_id"); brandID != "" {
+		if id, err := uuid.Parse(brandID); err == nil {
+			filters.BrandID = &id
+		}
+	}
+
+	if vendorID := c.Query("vendor_id"); vendorID != "" {
+		if id, err := uuid.Parse(vendorID); err == nil {
+			filters.VendorID = &id
+		}
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			filters.Limit = l
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if o, err := strconv.Atoi(offset); err == nil {
+			filters.Offset = o
+		}
+	}
+
+	filters.Cursor = c.Query("cursor")
+
+	if facets := c.Query("facets"); facets != "" {
+		if include, err := strconv.ParseBool(facets); err == nil {
+			filters.Facets = include
+		}
+	}
+
+	filters.SortBy = c.DefaultQuery("sort_by", "relevance")
+	filters.SortOrder = c.DefaultQuery("sort_order", "desc")
+
+	productList, err := h.service.SearchProducts(h.priced(c), query, filters)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Search results retrieved successfully", sparseProductList(productList, fields))
+}
+
+// SuggestProducts handles type-ahead suggestions for what a shopper has
+// typed so far
+func (h *HTTPHandler) SuggestProducts(c *gin.Context) {
+	limit := 0
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil {
+			limit = parsed
+		}
+	}
+
+	suggestions, err := h.suggester.Suggest(c.Request.Context(), c.Query("q"), limit)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Suggestions retrieved successfully", suggestions)
+}
+
+// StreamProducts streams the stock and price changes of the products named
+// by the ids parameter as server-sent events, so storefront pages update
+// without polling. Each product's current stock and prices are sent first,
+// and again whenever the client reconnects.
+func (h *HTTPHandler) StreamProducts(c *gin.Context) {
+	if h.live == nil {
+		h.handleError(c, errors.NewNotFoundError("Product updates are not configured", nil))
+		return
+	}
+
+	var ids []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for _, value := range strings.Split(c.Query("ids"), ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		id, err := uuid.Parse(value)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+			return
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		response.Error(c, http.StatusBadRequest, "ids is required", nil)
+		return
+	}
+	if limit := h.config.Live.MaxProducts; limit > 0 && len(ids) > limit {
+		response.Error(c, http.StatusBadRequest, fmt.Sprintf("At most %d products can be watched", limit), nil)
+		return
+	}
+
+	// Subscribe before reading the products, so changes made in between
+	// are not missed
+	subscription := h.live.Subscribe(ids)
+	defer h.live.Unsubscribe(subscription)
+
+	now := time.Now()
+	current := make([]domain.ProductUpdate, len(ids))
+	for i, id := range ids {
+		product, err := h.service.GetProduct(c.Request.Context(), id)
+		if err != nil {
+			h.handleError(c, err)
+			return
+		}
+		current[i] = domain.NewProductUpdate(product, now)
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	for _, update := range current {
+		c.SSEvent(updateEvent(update), update)
+	}
+	c.Writer.Flush()
+
+	// Comment lines keep proxies from closing a quiet stream
+	var heartbeat <-chan time.Time
+	if interval := time.Duration(h.config.Live.HeartbeatInterval) * time.Second; interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-subscription.Done():
+			return
+		case update := <-subscription.Updates():
+			c.SSEvent(updateEvent(update), update)
+			c.Writer.Flush()
+		case <-heartbeat:
+			if _, err := io.WriteString(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// updateEvent names the event a product update is streamed as: "product"
+// when it carries prices, "stock" when only the stock changed
+func updateEvent(update domain.ProductUpdate) string {
+	if update.Prices != nil {
+		return "product"
+	}
+	return "stock"
+}
+
+// ListLowStockProducts handles listing products at or below their low stock threshold
+func (h *HTTPHandler) ListLowStockProducts(c *gin.Context) {
+	filters := &domain.LowStockFilters{}
+
+	if categoryID := c.Query("category_id"); categoryID != "" {
+		if id, err := uuid.Parse(categoryID); err == nil {
+			filters.CategoryID = &id
+		}
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			filters.Limit = l
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if o, err := strconv.Atoi(offset); err == nil {
+			filters.Offset = o
+		}
+	}
+
+	productList, err := h.service.ListLowStockProducts(c.Request.Context(), filters)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Low stock products retrieved successfully", productList)
+}
+
+// AdjustStock handles a manual change to a product's stock
+func (h *HTTPHandler) AdjustStock(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	var req domain.AdjustStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	movement, err := h.service.AdjustStock(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Stock adjusted successfully", movement)
+}
+
+// BulkAdjustStock handles adjusting the stock of many products by SKU
+func (h *HTTPHandler) BulkAdjustStock(c *gin.Context) {
+	var req domain.BulkStockAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	result, err := h.service.BulkAdjustStock(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	if !result.Applied {
+		response.Success(c, http.StatusOK, "Stock adjustments rejected, no stock was changed", result)
+		return
+	}
+	response.Success(c, http.StatusOK, "Stock adjusted successfully", result)
+}
+
+// GetStockHistory handles listing a product's stock movements
+func (h *HTTPHandler) GetStockHistory(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	filters := &domain.StockMovementFilters{
+		Reason: c.Query("reason"),
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			filters.Limit = l
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if o, err := strconv.Atoi(offset); err == nil {
+			filters.Offset = o
+		}
+	}
+
+	history, err := h.service.GetStockHistory(c.Request.Context(), id, filters)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Stock history retrieved successfully", history)
+}
+
+// AddProductRelation handles linking a product to a related product
+func (h *HTTPHandler) AddProductRelation(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	var req domain.CreateProductRelationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	relation, err := h.service.AddProductRelation(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Product relation saved successfully", relation)
+}
+
+// RemoveProductRelation handles unlinking related products. An optional
+// type query parameter limits removal to one relation type.
+func (h *HTTPHandler) RemoveProductRelation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+	relatedID, err := uuid.Parse(c.Param("relatedId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid related product ID", err)
+		return
+	}
+
+	if err := h.service.RemoveProductRelation(c.Request.Context(), id, relatedID, c.Query("type")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Product relation deleted successfully", nil)
+}
+
+// ListProductTranslations handles listing a product's translations
+func (h *HTTPHandler) ListProductTranslations(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	translations, err := h.service.ListProductTranslations(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Product translations retrieved successfully", translations)
+}
+
+// UpsertProductTranslation handles setting a product's content in a locale
+func (h *HTTPHandler) UpsertProductTranslation(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	var req domain.UpsertProductTranslationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	translation, err := h.service.UpsertProductTranslation(c.Request.Context(), id, c.Param("locale"), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Product translation saved successfully", translation)
+}
+
+// DeleteProductTranslation handles removing a product's content in a locale
+func (h *HTTPHandler) DeleteProductTranslation(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	if err := h.service.DeleteProductTranslation(c.Request.Context(), id, c.Param("locale")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Product translation deleted successfully", nil)
+}
+
+// ListProductMedia handles listing a product's images
+func (h *HTTPHandler) ListProductMedia(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	media, err := h.service.ListProductMedia(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Product media retrieved successfully", media)
+}
+
+// CreateMediaUpload handles requests for a URL to upload a product image to
+func (h *HTTPHandler) CreateMediaUpload(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	var req domain.CreateMediaUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	upload, err := h.service.CreateMediaUpload(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Media upload created successfully", upload)
+}
+
+// CompleteMediaUpload handles attaching an uploaded image to its product
+func (h *HTTPHandler) CompleteMediaUpload(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+	mediaID, err := uuid.Parse(c.Param("mediaId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid media ID", err)
+		return
+	}
+
+	media, err := h.service.CompleteMediaUpload(c.Request.Context(), id, mediaID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Media upload completed successfully", media)
+}
+
+// DeleteMedia handles removing a product image
+func (h *HTTPHandler) DeleteMedia(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+	mediaID, err := uuid.Parse(c.Param("mediaId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid media ID", err)
+		return
+	}
+
+	if err := h.service.DeleteMedia(c.Request.Context(), id, mediaID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Media deleted successfully", nil)
+}
+
+// ListDigitalAssets handles listing a digital product's downloadable files
+func (h *HTTPHandler) ListDigitalAssets(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	assets, err := h.service.ListDigitalAssets(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Digital assets retrieved successfully", assets)
+}
+
+// CreateDigitalAsset handles attaching an uploaded file to a digital product
+func (h *HTTPHandler) CreateDigitalAsset(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	var req domain.CreateDigitalAssetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	asset, err := h.service.CreateDigitalAsset(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Digital asset created successfully", asset)
+}
+
+// DeleteDigitalAsset handles detaching a file from a digital product
+func (h *HTTPHandler) DeleteDigitalAsset(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+	assetID, err := uuid.Parse(c.Param("assetId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid asset ID", err)
+		return
+	}
+
+	if err := h.service.DeleteDigitalAsset(c.Request.Context(), id, assetID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Digital asset deleted successfully", nil)
+}
+
+// SetBundleComponents handles replacing the components of a bundle
+func (h *HTTPHandler) SetBundleComponents(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	var req domain.SetBundleComponentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	product, err := h.service.SetBundleComponents(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Bundle components set successfully", product)
+}
+
+// SetPriceTiers handles replacing the price tiers of a product
+func (h *HTTPHandler) SetPriceTiers(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	var req domain.SetPriceTiersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	product, err := h.service.SetPriceTiers(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Price tiers set successfully", product)
+}
+
+// SetProductChannels handles replacing the sales channel settings of a
+// product
+func (h *HTTPHandler) SetProductChannels(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	var req domain.SetProductChannelsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	product, err := h.service.SetProductChannels(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Product channels set successfully", product)
+}
+
+// ListEntitlements handles listing the downloads customers are entitled to
+func (h *HTTPHandler) ListEntitlements(c *gin.Context) {
+	filters := &domain.EntitlementFilters{
+		CustomerID: c.Query("customer_id"),
+	}
+
+	if orderID := c.Query("order_id"); orderID != "" {
+		id, err := uuid.Parse(orderID)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid order ID", err)
+			return
+		}
+		filters.OrderID = &id
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			filters.Limit = l
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if o, err := strconv.Atoi(offset); err == nil {
+			filters.Offset = o
+		}
+	}
+
+	entitlements, err := h.service.ListEntitlements(c.Request.Context(), filters)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Entitlements retrieved successfully", entitlements)
+}
+
+// GetEntitlement handles getting an entitlement with its download links
+func (h *HTTPHandler) GetEntitlement(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid entitlement ID", err)
+		return
+	}
+
+	entitlement, err := h.service.GetEntitlement(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Entitlement retrieved successfully", entitlement)
+}
+
+// HandleEvent handles an event forwarded by another service
+func (h *HTTPHandler) HandleEvent(c *gin.Context) {
+	var event events.Event
+	if err := c.ShouldBindJSON(&event); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	granted, err := h.service.HandleEvent(c.Request.Context(), &event)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusAccepted, "Event accepted successfully", gin.H{
+		"granted": granted,
+	})
+}
+
+// GetRelatedProducts handles listing the products linked to a product
+func (h *HTTPHandler) GetRelatedProducts(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	related, err := h.service.GetRelatedProducts(h.priced(c), id, c.Query("type"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Related products retrieved successfully", related)
+}
+
+// CreateReview handles submitting a product review
+func (h *HTTPHandler) CreateReview(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	var req domain.CreateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	review, err := h.service.CreateReview(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Review submitted for moderation", review)
+}
+
+// ListProductReviews handles listing the published reviews of a product
+func (h *HTTPHandler) ListProductReviews(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	reviews, err := h.service.ListProductReviews(c.Request.Context(), id, parseReviewFilters(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Reviews retrieved successfully", reviews)
+}
+
+// ListReviews handles listing reviews for moderation
+func (h *HTTPHandler) ListReviews(c *gin.Context) {
+	reviews, err := h.service.ListReviews(c.Request.Context(), parseReviewFilters(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Reviews retrieved successfully", reviews)
+}
+
+// ModerateReview handles approving or rejecting a review
+func (h *HTTPHandler) ModerateReview(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid review ID", err)
+		return
+	}
+
+	var req domain.ModerateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	review, err := h.service.ModerateReview(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Review moderated successfully", review)
+}
+
+// SubmitRevision handles proposing a change to a product for review
+func (h *HTTPHandler) SubmitRevision(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	var req domain.SubmitRevisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	revision, err := h.service.SubmitRevision(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Revision submitted for review", revision)
+}
+
+// ListProductRevisions handles listing the changes proposed to a product
+func (h *HTTPHandler) ListProductRevisions(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	revisions, err := h.service.ListProductRevisions(c.Request.Context(), id, parseRevisionFilters(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Revisions retrieved successfully", revisions)
+}
+
+// ListRevisions handles listing revisions for review
+func (h *HTTPHandler) ListRevisions(c *gin.Context) {
+	revisions, err := h.service.ListRevisions(c.Request.Context(), parseRevisionFilters(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Revisions retrieved successfully", revisions)
+}
+
+// GetRevision handles getting a revision with its changes to the live
+// product
+func (h *HTTPHandler) GetRevision(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid revision ID", err)
+		return
+	}
+
+	revision, err := h.service.GetRevision(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Revision retrieved successfully", revision)
+}
+
+// ApproveRevision handles approving and applying a revision. The body is
+// optional.
+func (h *HTTPHandler) ApproveRevision(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid revision ID", err)
+		return
+	}
+
+	var req domain.ReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	revision, err := h.service.ApproveRevision(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Revision approved successfully", revision)
+}
+
+// RejectRevision handles turning down a revision. The body is optional.
+func (h *HTTPHandler) RejectRevision(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid revision ID", err)
+		return
+	}
+
+	var req domain.ReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	revision, err := h.service.RejectRevision(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Revision rejected successfully", revision)
+}
+
+// CreateChangeset handles creating a changeset
+func (h *HTTPHandler) CreateChangeset(c *gin.Context) {
+	var req domain.CreateChangesetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	changeset, err := h.service.CreateChangeset(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Changeset created successfully", changeset)
+}
+
+// ListChangesets handles listing changesets
+func (h *HTTPHandler) ListChangesets(c *gin.Context) {
+	filters := &domain.ChangesetFilters{Status: c.Query("status")}
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			filters.Limit = l
+		}
+	}
+	if offset := c.Query("offset"); offset != "" {
+		if o, err := strconv.Atoi(offset); err == nil {
+			filters.Offset = o
+		}
+	}
+
+	changesets, err := h.service.ListChangesets(c.Request.Context(), filters)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Changesets retrieved successfully", changesets)
+}
+
+// GetChangeset handles getting a changeset with its edits
+func (h *HTTPHandler) GetChangeset(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid changeset ID", err)
+		return
+	}
+
+	changeset, err := h.service.GetChangeset(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Changeset retrieved successfully", changeset)
+}
+
+// UpdateChangeset handles renaming or describing a changeset
+func (h *HTTPHandler) UpdateChangeset(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid changeset ID", err)
+		return
+	}
+
+	var req domain.UpdateChangesetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	changeset, err := h.service.UpdateChangeset(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Changeset updated successfully", changeset)
+}
+
+// DeleteChangeset handles deleting a changeset
+func (h *HTTPHandler) DeleteChangeset(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid changeset ID", err)
+		return
+	}
+
+	if err := h.service.DeleteChangeset(c.Request.Context(), id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Changeset deleted successfully", nil)
+}
+
+// PreviewChangeset handles previewing what a changeset would change
+func (h *HTTPHandler) PreviewChangeset(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid changeset ID", err)
+		return
+	}
+
+	changeset, err := h.service.PreviewChangeset(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Changeset previewed successfully", changeset)
+}
+
+// SetChangesetItem handles setting the edit a changeset makes to a product
+func (h *HTTPHandler) SetChangesetItem(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid changeset ID", err)
+		return
+	}
+	productID, err := uuid.Parse(c.Param("productId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	var req domain.SetChangesetItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	item, err := h.service.SetChangesetItem(c.Request.Context(), id, productID, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Changeset item saved successfully", item)
+}
+
+// DeleteChangesetItem handles dropping the edit a changeset makes to a
+// product
+func (h *HTTPHandler) DeleteChangesetItem(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid changeset ID", err)
+		return
+	}
+	productID, err := uuid.Parse(c.Param("productId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	if err := h.service.DeleteChangesetItem(c.Request.Context(), id, productID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Changeset item deleted successfully", nil)
+}
+
+// ApplyChangeset handles applying a changeset now
+func (h *HTTPHandler) ApplyChangeset(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid changeset ID", err)
+		return
+	}
+
+	changeset, err := h.service.ApplyChangeset(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Changeset applied successfully", changeset)
+}
+
+// ScheduleChangeset handles scheduling a changeset to be applied later
+func (h *HTTPHandler) ScheduleChangeset(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid changeset ID", err)
+		return
+	}
+
+	var req domain.ScheduleChangesetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	changeset, err := h.service.ScheduleChangeset(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Changeset scheduled successfully", changeset)
+}
+
+// UnscheduleChangeset handles returning a scheduled changeset to draft
+func (h *HTTPHandler) UnscheduleChangeset(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid changeset ID", err)
+		return
+	}
+
+	changeset, err := h.service.UnscheduleChangeset(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Changeset unscheduled successfully", changeset)
+}
+
+// RollbackChangeset handles undoing an applied changeset
+func (h *HTTPHandler) RollbackChangeset(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid changeset ID", err)
+		return
+	}
+
+	changeset, err := h.service.RollbackChangeset(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Changeset rolled back successfully", changeset)
+}
+
+// SetFlashSale handles setting up a product's flash sale
+func (h *HTTPHandler) SetFlashSale(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	var req domain.SetFlashSaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	sale, err := h.service.SetFlashSale(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Flash sale set successfully", sale)
+}
+
+// GetFlashSale handles getting a product's flash sale
+func (h *HTTPHandler) GetFlashSale(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	sale, err := h.service.GetFlashSale(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Flash sale retrieved successfully", sale)
+}
+
+// DeleteFlashSale handles calling off a product's flash sale
+func (h *HTTPHandler) DeleteFlashSale(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	if err := h.service.DeleteFlashSale(c.Request.Context(), id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Flash sale deleted successfully", nil)
+}
+
+// PurchaseFlashSale handles buying units of a product's flash sale
+func (h *HTTPHandler) PurchaseFlashSale(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	var req domain.FlashSalePurchaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	purchase, err := h.service.PurchaseFlashSale(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Flash sale purchase taken", purchase)
+}
+
+// ReserveStock handles reserving stock for a checkout
+func (h *HTTPHandler) ReserveStock(c *gin.Context) {
+	var req domain.ReserveStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	reservation, err := h.service.ReserveStock(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Stock reserved successfully", reservation)
+}
+
+// ReturnStock handles putting returned goods back into stock
+func (h *HTTPHandler) ReturnStock(c *gin.Context) {
+	var req domain.ReturnStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	movements, err := h.service.ReturnStock(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Stock returned successfully", gin.H{
+		"movements": movements,
+	})
+}
+
+// GetStockReservation handles getting a stock reservation
+func (h *HTTPHandler) GetStockReservation(c *gin.Context) {
+	reservation, err := h.service.GetStockReservation(c.Request.Context(), c.Param("reference"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Stock reservation retrieved successfully", reservation)
+}
+
+// ReleaseStock handles releasing a stock reservation
+func (h *HTTPHandler) ReleaseStock(c *gin.Context) {
+	reservation, err := h.service.ReleaseStock(c.Request.Context(), c.Param("reference"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Stock released successfully", reservation)
+}
+
+// CreateCategory handles category creation
+func (h *HTTPHandler) CreateCategory(c *gin.Context) {
+	var req domain.CreateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	category, err := h.service.CreateCategory(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Category created successfully", category)
+}
+
+// GetCategory handles getting a single category
+func (h *HTTPHandler) GetCategory(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid category ID", err)
+		return
+	}
+
+	category, err := h.service.GetCategory(h.localized(c), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Category retrieved successfully", category)
+}
+
+// GetCategoryBySlug handles getting a category by slug, redirecting retired slugs
+func (h *HTTPHandler) GetCategoryBySlug(c *gin.Context) {
+	slug := c.Param("slug")
+	category, err := h.service.GetCategoryBySlug(h.localized(c), slug)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	if category.Slug != slug {
+		c.Redirect(http.StatusMovedPermanently, path.Join(path.Dir(c.Request.URL.Path), category.Slug))
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Category retrieved successfully", category)
+}
+
+// UpdateCategory handles category updates
+func (h *HTTPHandler) UpdateCategory(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid category ID", err)
+		return
+	}
+
+	var req domain.UpdateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	category, err := h.service.UpdateCategory(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Category updated successfully", category)
+}
+
+// DeleteCategory handles category deletion
+func (h *HTTPHandler) DeleteCategory(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid category ID", err)
+		return
+	}
+
+	if err := h.service.DeleteCategory(c.Request.Context(), id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Category deleted successfully", nil)
+}
+
+// MoveCategory handles moving a category subtree under a new parent
+func (h *HTTPHandler) MoveCategory(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid category ID", err)
+		return
+	}
+
+	var req domain.MoveCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	category, err := h.service.MoveCategory(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Category moved successfully", category)
+}
+
+// MergeCategory handles merging a category into another
+func (h *HTTPHandler) MergeCategory(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid category ID", err)
+		return
+	}
+
+	var req domain.MergeCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	category, err := h.service.MergeCategory(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Category merged successfully", category)
+}
+
+// ListCategoryTranslations handles listing a category's translations
+func (h *HTTPHandler) ListCategoryTranslations(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid category ID", err)
+		return
+	}
+
+	translations, err := h.service.ListCategoryTranslations(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Category translations retrieved successfully", translations)
+}
+
+// UpsertCategoryTranslation handles setting a category's content in a locale
+func (h *HTTPHandler) UpsertCategoryTranslation(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid category ID", err)
+		return
+	}
+
+	var req domain.UpsertCategoryTranslationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	translation, err := h.service.UpsertCategoryTranslation(c.Request.Context(), id, c.Param("locale"), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Category translation saved successfully", translation)
+}
+
+// DeleteCategoryTranslation handles removing a category's content in a locale
+func (h *HTTPHandler) DeleteCategoryTranslation(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid category ID", err)
+		return
+	}
+
+	if err := h.service.DeleteCategoryTranslation(c.Request.Context(), id, c.Param("locale")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Category translation deleted successfully", nil)
 }
 
 // ListCategories handles category listing
 func (h *HTTPHandler) ListCategories(c *gin.Context) {
-	categories, err := h.service.ListCategories(c.Request.Context())
+	filters := &domain.CategoryFilters{}
+	if includeDescendants := c.Query("include_descendants"); includeDescendants != "" {
+		if include, err := strconv.ParseBool(includeDescendants); err == nil {
+			filters.IncludeDescendants = include
+		}
+	}
+
+	categories, err := h.service.ListCategories(h.localized(c), filters)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Categories retrieved successfully", categories)
+}
+
+// GetCategoryTree handles getting the nested category hierarchy
+func (h *HTTPHandler) GetCategoryTree(c *gin.Context) {
+	var rootID *uuid.UUID
+	if root := c.Query("root_id"); root != "" {
+		id, err := uuid.Parse(root)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid category ID", err)
+			return
+		}
+		rootID = &id
+	}
+
+	tree, err := h.service.GetCategoryTree(h.localized(c), rootID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Category tree retrieved successfully", tree)
+}
+
+// CreateAttributeDefinition handles defining an attribute on a category
+func (h *HTTPHandler) CreateAttributeDefinition(c *gin.Context) {
+	idStr := c.Param("id")
+	categoryID, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid category ID", err)
+		return
+	}
+
+	var req domain.CreateAttributeDefinitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	def, err := h.service.CreateAttributeDefinition(c.Request.Context(), categoryID, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Attribute definition created successfully", def)
+}
+
+// ListAttributeDefinitions handles listing the attributes that apply to a category
+func (h *HTTPHandler) ListAttributeDefinitions(c *gin.Context) {
+	idStr := c.Param("id")
+	categoryID, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid category ID", err)
+		return
+	}
+
+	defs, err := h.service.ListAttributeDefinitions(c.Request.Context(), categoryID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Attribute definitions retrieved successfully", defs)
+}
+
+// UpdateAttributeDefinition handles attribute definition updates
+func (h *HTTPHandler) UpdateAttributeDefinition(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid attribute ID", err)
+		return
+	}
+
+	var req domain.UpdateAttributeDefinitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	def, err := h.service.UpdateAttributeDefinition(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Attribute definition updated successfully", def)
+}
+
+// DeleteAttributeDefinition handles attribute definition deletion
+func (h *HTTPHandler) DeleteAttributeDefinition(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid attribute ID", err)
+		return
+	}
+
+	if err := h.service.DeleteAttributeDefinition(c.Request.Context(), id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Attribute definition deleted successfully", nil)
+}
+
+// CreateBrand handles brand creation
+func (h *HTTPHandler) CreateBrand(c *gin.Context) {
+	var req domain.CreateBrandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	brand, err := h.service.CreateBrand(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Brand created successfully", brand)
+}
+
+// GetBrand handles getting a single brand
+func (h *HTTPHandler) GetBrand(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid brand ID", err)
+		return
+	}
+
+	brand, err := h.service.GetBrand(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Brand retrieved successfully", brand)
+}
+
+// UpdateBrand handles brand updates
+func (h *HTTPHandler) UpdateBrand(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid brand ID", err)
+		return
+	}
+
+	var req domain.UpdateBrandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	brand, err := h.service.UpdateBrand(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Brand updated successfully", brand)
+}
+
+// DeleteBrand handles brand deletion
+func (h *HTTPHandler) DeleteBrand(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid brand ID", err)
+		return
+	}
+
+	if err := h.service.DeleteBrand(c.Request.Context(), id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Brand deleted successfully", nil)
+}
+
+// ListBrands handles brand listing
+func (h *HTTPHandler) ListBrands(c *gin.Context) {
+	brands, err := h.service.ListBrands(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Brands retrieved successfully", brands)
+}
+
+// CreateVendor handles vendor creation
+func (h *HTTPHandler) CreateVendor(c *gin.Context) {
+	var req domain.CreateVendorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	vendor, err := h.service.CreateVendor(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Vendor created successfully", vendor)
+}
+
+// GetVendor handles getting a single vendor
+func (h *HTTPHandler) GetVendor(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid vendor ID", err)
+		return
+	}
+
+	vendor, err := h.service.GetVendor(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Vendor retrieved successfully", vendor)
+}
+
+// GetOwnVendor handles getting the vendor the caller sells as
+func (h *HTTPHandler) GetOwnVendor(c *gin.Context) {
+	vendor, err := h.service.GetOwnVendor(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Vendor retrieved successfully", vendor)
+}
+
+// UpdateVendor handles vendor updates
+func (h *HTTPHandler) UpdateVendor(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid vendor ID", err)
+		return
+	}
+
+	var req domain.UpdateVendorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	vendor, err := h.service.UpdateVendor(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Vendor updated successfully", vendor)
+}
+
+// ListVendors handles listing vendors
+func (h *HTTPHandler) ListVendors(c *gin.Context) {
+	vendors, err := h.service.ListVendors(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Vendors retrieved successfully", vendors)
+}
+
+// CreateWarehouse handles warehouse creation
+func (h *HTTPHandler) CreateWarehouse(c *gin.Context) {
+	var req domain.CreateWarehouseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	warehouse, err := h.service.CreateWarehouse(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Warehouse created successfully", warehouse)
+}
+
+// GetWarehouse handles getting a single warehouse
+func (h *HTTPHandler) GetWarehouse(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid warehouse ID", err)
+		return
+	}
+
+	warehouse, err := h.service.GetWarehouse(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Warehouse retrieved successfully", warehouse)
+}
+
+// UpdateWarehouse handles warehouse updates
+func (h *HTTPHandler) UpdateWarehouse(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid warehouse ID", err)
+		return
+	}
+
+	var req domain.UpdateWarehouseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	warehouse, err := h.service.UpdateWarehouse(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Warehouse updated successfully", warehouse)
+}
+
+// ListWarehouses handles warehouse listing
+func (h *HTTPHandler) ListWarehouses(c *gin.Context) {
+	warehouses, err := h.service.ListWarehouses(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Warehouses retrieved successfully", warehouses)
+}
+
+// BulkUpdateProducts handles applying many product changes at once
+func (h *HTTPHandler) BulkUpdateProducts(c *gin.Context) {
+	var req domain.BulkUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	result, err := h.service.BulkUpdateProducts(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Bulk update completed successfully", result)
+}
+
+// ImportProducts handles CSV product import uploads
+func (h *HTTPHandler) ImportProducts(c *gin.Context) {
+	maxBytes := int64(h.config.Import.MaxFileSize) << 20
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "CSV file is required in the 'file' field", err)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		response.Error(c, http.StatusRequestEntityTooLarge, "Import file is too large", err)
+		return
+	}
+
+	job, err := h.service.ImportProducts(c.Request.Context(), header.Filename, data)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusAccepted, "Product import queued", job)
+}
+
+// ExportProducts streams the filtered catalog as CSV or JSON Lines
+func (h *HTTPHandler) ExportProducts(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "jsonl" {
+		response.Error(c, http.StatusBadRequest, "Unsupported export format, use csv or jsonl", nil)
+		return
+	}
+
+	filters := parseProductFilters(c)
+
+	var writer export.Writer
+	switch format {
+	case "jsonl":
+		c.Header("Content-Type", "application/x-ndjson")
+		writer = export.NewJSONLWriter(c.Writer)
+	default:
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		writer = export.NewCSVWriter(c.Writer)
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=products.%s", format))
+	c.Status(http.StatusOK)
+
+	if err := writer.WriteHeader(); err != nil {
+		h.logger.WithError(err).Error("Failed to write export header")
+		return
+	}
+
+	err := h.service.ExportProducts(c.Request.Context(), filters, func(products []domain.Product) error {
+		if err := writer.Write(products); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		// Headers are already sent, so the client sees a truncated stream
+		h.logger.WithError(err).Error("Product export aborted")
+		return
+	}
+}
+
+// GetGoogleMerchantFeed serves the catalog as a Google Merchant Center
+// product feed
+func (h *HTTPHandler) GetGoogleMerchantFeed(c *gin.Context) {
+	if h.feed == nil {
+		h.handleError(c, errors.NewNotFoundError("Product feed is not configured", nil))
+		return
+	}
+
+	document, modified, err := h.feed.Document(c.Request.Context())
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to generate product feed")
+		response.Error(c, http.StatusInternalServerError, "Failed to generate product feed", nil)
+		return
+	}
+
+	// ServeContent answers conditional requests from the modification time
+	c.Header("Content-Type", "application/xml; charset=utf-8")
+	http.ServeContent(c.Writer, c.Request, "google-merchant.xml", modified, bytes.NewReader(document))
+}
+
+// GetSitemap serves a file of the storefront sitemap: the index at
+// sitemap.xml or one of the files it lists
+func (h *HTTPHandler) GetSitemap(c *gin.Context) {
+	if h.sitemap == nil {
+		h.handleError(c, errors.NewNotFoundError("Sitemap is not configured", nil))
+		return
+	}
+
+	name := c.Param("name")
+	document, generated, err := h.sitemap.File(c.Request.Context(), name)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "application/xml; charset=utf-8")
+	http.ServeContent(c.Writer, c.Request, name, generated, bytes.NewReader(document))
+}
+
+// GetImportJob handles import progress polling
+func (h *HTTPHandler) GetImportJob(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid import ID", err)
+		return
+	}
+
+	job, err := h.service.GetImportJob(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Import job retrieved successfully", job)
+}
+
+// ListSearchSynonyms handles search synonym listing
+func (h *HTTPHandler) ListSearchSynonyms(c *gin.Context) {
+	synonyms, err := h.service.ListSearchSynonyms(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Search synonyms retrieved successfully", synonyms)
+}
+
+// CreateSearchSynonym handles search synonym creation
+func (h *HTTPHandler) CreateSearchSynonym(c *gin.Context) {
+	var req domain.CreateSearchSynonymRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	synonym, err := h.service.CreateSearchSynonym(c.Request.Context(), &req)
 	if err != nil {
 		h.handleError(c, err)
 		return
 	}
 
-	response.Success(c, http.StatusOK, "Categories retrieved successfully", categories)
+	response.Success(c, http.StatusCreated, "Search synonym created successfully", synonym)
+}
+
+// UpdateSearchSynonym handles search synonym updates
+func (h *HTTPHandler) UpdateSearchSynonym(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid synonym ID", err)
+		return
+	}
+
+	var req domain.UpdateSearchSynonymRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	synonym, err := h.service.UpdateSearchSynonym(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Search synonym updated successfully", synonym)
+}
+
+// DeleteSearchSynonym handles search synonym deletion
+func (h *HTTPHandler) DeleteSearchSynonym(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid synonym ID", err)
+		return
+	}
+
+	if err := h.service.DeleteSearchSynonym(c.Request.Context(), id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Search synonym deleted successfully", nil)
+}
+
+// ListZeroResultSearches handles the zero-result search report
+func (h *HTTPHandler) ListZeroResultSearches(c *gin.Context) {
+	filters := &domain.ZeroResultFilters{}
+
+	if since := c.Query("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid since date, expected RFC 3339", err)
+			return
+		}
+		filters.Since = &t
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			filters.Limit = l
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if o, err := strconv.Atoi(offset); err == nil {
+			filters.Offset = o
+		}
+	}
+
+	searches, err := h.service.ListZeroResultSearches(c.Request.Context(), filters)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Zero-result searches retrieved successfully", searches)
+}
+
+// DeleteZeroResultSearch handles dismissing a query from the zero-result report
+func (h *HTTPHandler) DeleteZeroResultSearch(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid search ID", err)
+		return
+	}
+
+	if err := h.service.DeleteZeroResultSearch(c.Request.Context(), id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Zero-result search deleted successfully", nil)
+}
+
+// ListCacheKeys handles listing cached keys matching a pattern
+func (h *HTTPHandler) ListCacheKeys(c *gin.Context) {
+	limit := 0
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil {
+			limit = parsed
+		}
+	}
+
+	keys, err := h.service.ListCacheKeys(c.Request.Context(), c.Query("pattern"), limit)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Cache keys retrieved successfully", keys)
+}
+
+// InspectCacheEntry handles inspecting a single cached key
+func (h *HTTPHandler) InspectCacheEntry(c *gin.Context) {
+	key := c.Query("key")
+	if key == "" {
+		response.Error(c, http.StatusBadRequest, "Cache key is required", nil)
+		return
+	}
+
+	entry, err := h.service.InspectCacheEntry(c.Request.Context(), key)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Cache entry retrieved successfully", entry)
+}
+
+// PurgeCache handles dropping cached keys
+func (h *HTTPHandler) PurgeCache(c *gin.Context) {
+	var req domain.PurgeCacheRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	result, err := h.service.PurgeCache(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Cache purged successfully", result)
+}
+
+// WarmCache handles loading products into the cache
+func (h *HTTPHandler) WarmCache(c *gin.Context) {
+	var req domain.WarmCacheRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	result, err := h.service.WarmCache(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	if result.Queued {
+		response.Success(c, http.StatusAccepted, "Cache warming queued", result)
+		return
+	}
+	response.Success(c, http.StatusOK, "Cache warmed successfully", result)
+}
+
+// ListAuditEvents handles audit log queries
+func (h *HTTPHandler) ListAuditEvents(c *gin.Context) {
+	filters := &domain.AuditFilters{
+		EntityType: c.Query("entity_type"),
+		ActorID:    c.Query("actor_id"),
+	}
+
+	if entityID := c.Query("entity_id"); entityID != "" {
+		id, err := uuid.Parse(entityID)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid entity ID", err)
+			return
+		}
+		filters.EntityID = &id
+	}
+
+	if from := c.Query("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid from date, expected RFC 3339", err)
+			return
+		}
+		filters.From = &t
+	}
+
+	if to := c.Query("to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid to date, expected RFC 3339", err)
+			return
+		}
+		filters.To = &t
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			filters.Limit = l
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if o, err := strconv.Atoi(offset); err == nil {
+			filters.Offset = o
+		}
+	}
+
+	events, err := h.service.ListAuditEvents(c.Request.Context(), filters)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Audit events retrieved successfully", events)
+}
+
+// GetAuditEvent handles getting a single audit event
+func (h *HTTPHandler) GetAuditEvent(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid audit event ID", err)
+		return
+	}
+
+	event, err := h.service.GetAuditEvent(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Audit event retrieved successfully", event)
 }
 
 // HealthCheck handles health check requests
@@ -332,17 +2983,181 @@ func (h *HTTPHandler) HealthCheck(c *gin.Context) {
 	})
 }
 
-// ReadinessCheck handles readiness check requests
+// ReadinessCheck handles readiness check requests. The service is ready
+// when every dependency registered with the health registry responds.
 func (h *HTTPHandler) ReadinessCheck(c *gin.Context) {
-	// TODO: Add actual readiness checks (database, redis connectivity)
+	report := h.health.Check(c.Request.Context())
+	if !report.Healthy {
+		h.logger.WithField("checks", report.Checks).Warn("Readiness check failed")
+		c.JSON(http.StatusServiceUnavailable, response.APIResponse{
+			Success: false,
+			Code:    errors.CodeUnavailable,
+			Message: "Service is not ready",
+			Data: gin.H{
+				"service": "product-service",
+				"status":  "not_ready",
+				"checks":  report.Checks,
+			},
+		})
+		return
+	}
+
 	response.Success(c, http.StatusOK, "Service is ready", gin.H{
 		"service": "product-service",
 		"status":  "ready",
+		"checks":  report.Checks,
 	})
 }
 
+// localized resolves the locale catalog content is served in, from the
+// locale query parameter or else the Accept-Language header, announces it
+// in the response and returns the request context asking for it
+func (h *HTTPHandler) localized(c *gin.Context) context.Context {
+	locale := domain.ResolveLocale(c.Query("locale"), c.GetHeader("Accept-Language"), h.config.Locale.Supported, h.config.Locale.Default)
+	c.Header("Content-Language", locale)
+	c.Writer.Header().Add("Vary", "Accept-Language")
+
+	if locale == domain.NormalizeLocale(h.config.Locale.Default) {
+		return c.Request.Context()
+	}
+	return domain.WithLocale(c.Request.Context(), locale)
+}
+
+// priced returns the localized request context, asking for products priced
+// for the quantity query parameter when one is given
+func (h *HTTPHandler) priced(c *gin.Context) context.Context {
+	ctx := h.localized(c)
+	if quantity, err := strconv.Atoi(c.Query("quantity")); err == nil && quantity > 0 {
+		return domain.WithQuantity(ctx, quantity)
+	}
+	return ctx
+}
+
+// parseFields parses the fields query parameter, which trims product
+// responses to the listed fields. It responds and returns false when the
+// parameter names an unknown field.
+func parseFields(c *gin.Context) (domain.ProductFields, bool) {
+	fields, err := domain.ParseProductFields(c.Query("fields"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid fields", err)
+		return nil, false
+	}
+	return fields, true
+}
+
+// sparseProductList trims the products of a list to the selected fields
+func sparseProductList(list *domain.ProductList, fields domain.ProductFields) interface{} {
+	if len(fields) == 0 {
+		return list
+	}
+	return domain.SparseProductList{ProductList: list, Products: fields.Select(list.Products)}
+}
+
+// parseProductFilters parses the catalog filter query parameters shared by list and export
+func parseProductFilters(c *gin.Context) *domain.ProductFilters {
+	filters := &domain.ProductFilters{}
+
+	if categoryID := c.Query("category_id"); categoryID != "" {
+		if id, err := uuid.Parse(categoryID); err == nil {
+			filters.CategoryID = &id
+		}
+	}
+
+	if brandID := c.Query("brand_id"); brandID != "" {
+		if id, err := uuid.Parse(brandID); err == nil {
+			filters.BrandID = &id
+		}
+	}
+
+	if vendorID := c.Query("vendor_id"); vendorID != "" {
+		if id, err := uuid.Parse(vendorID); err == nil {
+			filters.VendorID = &id
+		}
+	}
+
+	if minPrice := c.Query("min_price"); minPrice != "" {
+		if price, err := strconv.ParseFloat(minPrice, 64); err == nil {
+			filters.MinPrice = &price
+		}
+	}
+
+	if maxPrice := c.Query("max_price"); maxPrice != "" {
+		if price, err := strconv.ParseFloat(maxPrice, 64); err == nil {
+			filters.MaxPrice = &price
+		}
+	}
+
+	filters.Search = c.Query("search")
+
+	if isActive := c.Query("is_active"); isActive != "" {
+		if active, err := strconv.ParseBool(isActive); err == nil {
+			filters.IsActive = &active
+		}
+	}
+
+	filters.Status = c.Query("status")
+	filters.ApprovalStatus = c.Query("approval_status")
+
+	if inStock := c.Query("in_stock"); inStock != "" {
+		if stock, err := strconv.ParseBool(inStock); err == nil {
+			filters.InStock = &stock
+		}
+	}
+
+	filters.Warehouse = c.Query("warehouse")
+	filters.Channel = c.Query("channel")
+
+	if includeDeleted := c.Query("include_deleted"); includeDeleted != "" {
+		if include, err := strconv.ParseBool(includeDeleted); err == nil {
+			filters.IncludeDeleted = include
+		}
+	}
+
+	return filters
+}
+
+// parseReviewFilters parses the review status and pagination query parameters
+func parseReviewFilters(c *gin.Context) *domain.ReviewFilters {
+	filters := &domain.ReviewFilters{Status: c.Query("status")}
+
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			filters.Limit = l
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if o, err := strconv.Atoi(offset); err == nil {
+			filters.Offset = o
+		}
+	}
+
+	return filters
+}
+
+// parseRevisionFilters parses the revision status and pagination query
+// parameters
+func parseRevisionFilters(c *gin.Context) *domain.RevisionFilters {
+	filters := &domain.RevisionFilters{Status: c.Query("status")}
+
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			filters.Limit = l
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if o, err := strconv.Atoi(offset); err == nil {
+			filters.Offset = o
+		}
+	}
+
+	return filters
+}
+
 // handleError handles service errors and converts them to appropriate HTTP responses
 func (h *HTTPHandler) handleError(c *gin.Context, err error) {
+	err = database.TranslateError(err)
 	switch {
 	case errors.IsNotFound(err):
 		response.Error(c, http.StatusNotFound, "Resource not found", err)
@@ -350,6 +3165,12 @@ func (h *HTTPHandler) handleError(c *gin.Context, err error) {
 		response.Error(c, http.StatusBadRequest, "Validation failed", err)
 	case errors.IsConflict(err):
 		response.Error(c, http.StatusConflict, "Resource conflict", err)
+	case errors.IsUnauthorized(err):
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", err)
+	case errors.IsForbidden(err):
+		response.Error(c, http.StatusForbidden, "Forbidden", err)
+	case errors.IsUnavailable(err):
+		response.Error(c, http.StatusServiceUnavailable, "Service unavailable", err)
 	default:
 		h.logger.WithError(err).Error("Internal server error")
 		response.Error(c, http.StatusInternalServerError, "Internal server error", nil)
@@ -0,0 +1,189 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"sort"
+)
+
+// jpegQuality is the quality thumbnails are encoded at
+const jpegQuality = 85
+
+// Content types the package can decode
+const (
+	TypeJPEG = "image/jpeg"
+	TypePNG  = "image/png"
+	TypeGIF  = "image/gif"
+)
+
+// Thumbnail is an encoded, scaled down copy of an image
+type Thumbnail struct {
+	Size        int // bounding box the image was scaled to fit
+	Width       int
+	Height      int
+	ContentType string
+	Data        []byte
+}
+
+// CanDecode reports whether images of a content type can be thumbnailed
+func CanDecode(contentType string) bool {
+	switch contentType {
+	case TypeJPEG, TypePNG, TypeGIF:
+		return true
+	}
+	return false
+}
+
+// ThumbnailType is the content type thumbnails of an image are encoded as.
+// JPEGs stay JPEGs; everything else becomes a PNG so transparency survives.
+func ThumbnailType(contentType string) string {
+	if contentType == TypeJPEG {
+		return TypeJPEG
+	}
+	return TypePNG
+}
+
+// Decode decodes an image, refusing images of more than maxPixels pixels
+// before their pixels are allocated
+func Decode(data []byte, contentType string, maxPixels int) (image.Image, error) {
+	var decodeConfig func([]byte) (image.Config, error)
+	var decode func([]byte) (image.Image, error)
+	switch contentType {
+	case TypeJPEG:
+		decodeConfig = func(b []byte) (image.Config, error) { return jpeg.DecodeConfig(bytes.NewReader(b)) }
+		decode = func(b []byte) (image.Image, error) { return jpeg.Decode(bytes.NewReader(b)) }
+	case TypePNG:
+		decodeConfig = func(b []byte) (image.Config, error) { return png.DecodeConfig(bytes.NewReader(b)) }
+		decode = func(b []byte) (image.Image, error) { return png.Decode(bytes.NewReader(b)) }
+	case TypeGIF:
+		// Only the first frame of an animation is used
+		decodeConfig = func(b []byte) (image.Config, error) { return gif.DecodeConfig(bytes.NewReader(b)) }
+		decode = func(b []byte) (image.Image, error) { return gif.Decode(bytes.NewReader(b)) }
+	default:
+		return nil, fmt.Errorf("unsupported image type %q", contentType)
+	}
+
+	cfg, err := decodeConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image header: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, fmt.Errorf("image has no pixels")
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, fmt.Errorf("image is %dx%d, more than %d pixels", cfg.Width, cfg.Height, maxPixels)
+	}
+
+	img, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, nil
+}
+
+// Thumbnails scales img to fit within each size x size box, keeping its
+// aspect ratio, and encodes the results. Images are never scaled up, so
+// boxes larger than the image get a copy at its own size.
+func Thumbnails(img image.Image, contentType string, sizes []int) ([]Thumbnail, error) {
+	// Scale from the largest box down, each from the previous result, so a
+	// large original is only read once
+	sorted := append([]int(nil), sizes...)
+	sort.Sort(sort.Reverse(sort.IntSlice(sorted)))
+
+	thumbnails := make([]Thumbnail, 0, len(sorted))
+	source := img
+	for _, size := range sorted {
+		if size <= 0 {
+			continue
+		}
+		scaled := fit(source, size)
+
+		var buf bytes.Buffer
+		outputType := ThumbnailType(contentType)
+		if outputType == TypeJPEG {
+			if err := jpeg.Encode(&buf, scaled, &jpeg.Options{Quality: jpegQuality}); err != nil {
+				return nil, fmt.Errorf("failed to encode %dpx thumbnail: %w", size, err)
+			}
+		} else if err := png.Encode(&buf, scaled); err != nil {
+			return nil, fmt.Errorf("failed to encode %dpx thumbnail: %w", size, err)
+		}
+
+		bounds := scaled.Bounds()
+		thumbnails = append(thumbnails, Thumbnail{
+			Size:        size,
+			Width:       bounds.Dx(),
+			Height:      bounds.Dy(),
+			ContentType: outputType,
+			Data:        buf.Bytes(),
+		})
+		source = scaled
+	}
+
+	// Hand them back smallest first
+	for i, j := 0, len(thumbnails)-1; i < j; i, j = i+1, j-1 {
+		thumbnails[i], thumbnails[j] = thumbnails[j], thumbnails[i]
+	}
+	return thumbnails, nil
+}
+
+// fit scales img down to fit within a size x size box
+func fit(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= size && height <= size {
+		return img
+	}
+
+	if width >= height {
+		height = max(1, height*size/width)
+		width = size
+	} else {
+		width = max(1, width*size/height)
+		height = size
+	}
+	return resize(img, width, height)
+}
+
+// resize scales img down to width x height by averaging the source pixels
+// each destination pixel covers, which avoids the aliasing of nearest
+// neighbour sampling
+func resize(img image.Image, width, height int) *image.NRGBA {
+	src := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := src.Min.Y + y*src.Dy()/height
+		y1 := max(y0+1, src.Min.Y+(y+1)*src.Dy()/height)
+		for x := 0; x < width; x++ {
+			x0 := src.Min.X + x*src.Dx()/width
+			x1 := max(x0+1, src.Min.X+(x+1)*src.Dx()/width)
+
+			// Sum premultiplied channels so transparent pixels don't bleed
+			// their color into the average
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r += uint64(pr)
+					g += uint64(pg)
+					b += uint64(pb)
+					a += uint64(pa)
+					n++
+				}
+			}
+
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,379 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/internal/product/repository"
+	"ecommerce/pkg/events"
+	"ecommerce/pkg/jobs"
+	"ecommerce/pkg/validator"
+)
+
+// JobType is the type of the background jobs that run imports
+const JobType = "product.import"
+
+// Payload is the payload of an import job
+type Payload struct {
+	ImportID uuid.UUID `json:"import_id"`
+	Data     []byte    `json:"data"`
+}
+
+// requiredColumns must be present in the CSV header
+var requiredColumns = []string{"sku", "name", "price", "category_id"}
+
+// attributeColumnPrefix marks CSV columns holding product attribute values
+const attributeColumnPrefix = "attr."
+
+// Importer processes product CSV imports as background jobs
+type Importer struct {
+	repo      repository.ProductRepository
+	publisher events.Publisher
+	logger    *logrus.Logger
+	validator *validator.Validator
+	batchSize int
+}
+
+// New creates a new importer
+func New(repo repository.ProductRepository, publisher events.Publisher, logger *logrus.Logger, batchSize int) *Importer {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	return &Importer{
+		repo:      repo,
+		publisher: publisher,
+		logger:    logger,
+		validator: validator.New(),
+		batchSize: batchSize,
+	}
+}
+
+// Register has the importer run the import jobs of a worker pool
+func (i *Importer) Register(worker *jobs.Worker) {
+	worker.Handle(JobType, i.handle)
+}
+
+// handle runs an import job. An import interrupted by shutdown or its
+// timeout keeps the progress it recorded and fails the job so it is run
+// again; imports upsert by SKU, so starting the file over is safe. Any other
+// failure is the file's and is recorded on the import rather than retried.
+func (i *Importer) handle(ctx context.Context, j *jobs.Job) error {
+	var payload Payload
+	if err := j.Decode(&payload); err != nil {
+		return jobs.Permanent(fmt.Errorf("invalid import job payload: %w", err))
+	}
+
+	// The import is updated outside the job's context, so an interrupted
+	// import still records how far it got
+	record := context.WithoutCancel(ctx)
+	logger := i.logger.WithField("import_id", payload.ImportID)
+
+	job, err := i.repo.GetImportJob(record, payload.ImportID)
+	if err != nil {
+		return fmt.Errorf("failed to load import job: %w", err)
+	}
+
+	// A retried import starts over
+	job.Status = domain.ImportStatusRunning
+	job.TotalRows, job.ProcessedRows, job.SucceededRows, job.FailedRows = 0, 0, 0, 0
+	job.Errors = nil
+	if err := i.repo.UpdateImportJob(record, job); err != nil {
+		logger.WithError(err).Error("Failed to update import job")
+	}
+
+	err = i.run(ctx, job, payload.Data)
+	interrupted := err != nil && ctx.Err() != nil
+	if interrupted && !j.LastAttempt() {
+		logger.WithError(err).Warn("Product import interrupted; will be retried")
+		return err
+	}
+	if err != nil {
+		logger.WithError(err).Error("Product import failed")
+		job.Status = domain.ImportStatusFailed
+		job.Message = err.Error()
+	} else {
+		job.Status = domain.ImportStatusCompleted
+	}
+
+	now := time.Now()
+	job.CompletedAt = &now
+	if err := i.repo.UpdateImportJob(record, job); err != nil {
+		logger.WithError(err).Error("Failed to update import job")
+	}
+
+	if err := i.repo.InvalidateProductCache(record); err != nil {
+		logger.WithError(err).Error("Failed to invalidate product cache")
+	}
+
+	logger.WithFields(logrus.Fields{
+		"succeeded": job.SucceededRows,
+		"failed":    job.FailedRows,
+	}).Info("Product import finished")
+	if interrupted {
+		return err
+	}
+	return nil
+}
+
+// run parses the CSV and upserts valid rows in batches, recording progress on the job
+func (i *Importer) run(ctx context.Context, job *domain.ImportJob, data []byte) error {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for idx, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = idx
+	}
+	for _, name := range requiredColumns {
+		if _, ok := columns[name]; !ok {
+			return fmt.Errorf("missing required column %q", name)
+		}
+	}
+
+	// Count rows up front so progress can be reported as a fraction
+	job.TotalRows = bytes.Count(data, []byte("\n"))
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		job.TotalRows++
+	}
+	job.TotalRows-- // header
+
+	knownCategories := make(map[uuid.UUID]bool)
+	knownBrands := make(map[uuid.UUID]bool)
+	attributeDefs := make(map[uuid.UUID][]domain.AttributeDefinition)
+	batch := make([]domain.Product, 0, i.batchSize)
+	batchRows := make([]int, 0, i.batchSize)
+	batchSKUs := make(map[string]bool, i.batchSize)
+	row := 1
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("import interrupted after %d rows: %w", job.ProcessedRows, err)
+		}
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		row++
+		if err != nil {
+			job.ProcessedRows++
+			job.AddError(row, "", err.Error())
+			continue
+		}
+
+		product, err := i.parseRow(ctx, record, columns, knownCategories, knownBrands, attributeDefs)
+		if err != nil {
+			job.ProcessedRows++
+			job.AddError(row, field(record, columns, "sku"), err.Error())
+			continue
+		}
+
+		// A batch cannot upsert the same SKU twice, in any case, so flush
+		// before repeating one
+		sku := strings.ToLower(product.SKU)
+		if batchSKUs[sku] || len(batch) >= i.batchSize {
+			i.flush(ctx, job, batch, batchRows)
+			batch = batch[:0]
+			batchRows = batchRows[:0]
+			batchSKUs = make(map[string]bool, i.batchSize)
+		}
+		batch = append(batch, *product)
+		batchRows = append(batchRows, row)
+		batchSKUs[sku] = true
+	}
+	i.flush(ctx, job, batch, batchRows)
+
+	// Row count may differ from the newline estimate when fields contain newlines
+	job.TotalRows = job.ProcessedRows
+	return nil
+}
+
+// flush upserts a batch and persists the job's progress
+func (i *Importer) flush(ctx context.Context, job *domain.ImportJob, batch []domain.Product, rows []int) {
+	if len(batch) == 0 {
+		return
+	}
+
+	job.ProcessedRows += len(batch)
+	movement := domain.StockMovement{Reason: domain.StockReasonImport, Reference: job.ID.String()}
+	if err := i.repo.UpsertBatch(ctx, batch, movement); err != nil {
+		for idx := range batch {
+			job.AddError(rows[idx], batch[idx].SKU, err.Error())
+		}
+	} else {
+		job.SucceededRows += len(batch)
+		for idx := range batch {
+			i.publish(ctx, &batch[idx])
+		}
+	}
+
+	if err := i.repo.UpdateImportJob(ctx, job); err != nil {
+		i.logger.WithError(err).WithField("import_id", job.ID).Error("Failed to update import progress")
+	}
+}
+
+// parseRow converts a CSV record into a validated product
+func (i *Importer) parseRow(ctx context.Context, record []string, columns map[string]int, knownCategories, knownBrands map[uuid.UUID]bool, attributeDefs map[uuid.UUID][]domain.AttributeDefinition) (*domain.Product, error) {
+	req := domain.CreateProductRequest{
+		SKU:         field(record, columns, "sku"),
+		GTIN:        field(record, columns, "gtin"),
+		Name:        field(record, columns, "name"),
+		Description: field(record, columns, "description"),
+		ImageURL:    field(record, columns, "image_url"),
+	}
+
+	price, err := strconv.ParseFloat(field(record, columns, "price"), 64)
+	if err != nil {
+		return nil, errors.New("invalid price")
+	}
+	req.Price = price
+
+	categoryID, err := uuid.Parse(field(record, columns, "category_id"))
+	if err != nil {
+		return nil, errors.New("invalid category_id")
+	}
+	req.CategoryID = categoryID
+
+	if value := field(record, columns, "brand_id"); value != "" {
+		brandID, err := uuid.Parse(value)
+		if err != nil {
+			return nil, errors.New("invalid brand_id")
+		}
+		req.BrandID = &brandID
+	}
+
+	if value := field(record, columns, "stock"); value != "" {
+		stock, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, errors.New("invalid stock")
+		}
+		req.Stock = stock
+	}
+
+	isActive := true
+	if value := field(record, columns, "is_active"); value != "" {
+		isActive, err = strconv.ParseBool(value)
+		if err != nil {
+			return nil, errors.New("invalid is_active")
+		}
+	}
+
+	if err := i.validator.Validate(&req); err != nil {
+		return nil, err
+	}
+
+	known, checked := knownCategories[categoryID]
+	if !checked {
+		_, err := i.repo.GetCategory(ctx, categoryID)
+		known = err == nil
+		knownCategories[categoryID] = known
+	}
+	if !known {
+		return nil, errors.New("category not found")
+	}
+
+	if req.BrandID != nil {
+		known, checked := knownBrands[*req.BrandID]
+		if !checked {
+			_, err := i.repo.GetBrand(ctx, *req.BrandID)
+			known = err == nil
+			knownBrands[*req.BrandID] = known
+		}
+		if !known {
+			return nil, errors.New("brand not found")
+		}
+	}
+
+	// Attribute columns replace the product's attributes; without any the
+	// existing attributes are left untouched
+	var attributes []domain.ProductAttribute
+	values := attributeValues(record, columns)
+	if values != nil {
+		defs, loaded := attributeDefs[categoryID]
+		if !loaded {
+			defs, err = i.repo.ListAttributeDefinitions(ctx, categoryID)
+			if err != nil {
+				return nil, err
+			}
+			attributeDefs[categoryID] = defs
+		}
+		attributes, err = domain.ValidateAttributes(defs, values)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// New rows go straight to the storefront; existing rows keep their status
+	now := time.Now()
+	product := &domain.Product{
+		Name:        req.Name,
+		Price:       req.Price,
+		CategoryID:  req.CategoryID,
+		BrandID:     req.BrandID,
+		Stock:       req.Stock,
+		ImageURL:    req.ImageURL,
+		SKU:         req.SKU,
+		GTIN:        req.GTIN,
+		IsActive:    isActive,
+		Attributes:  attributes,
+		Status:      domain.ProductStatusPublished,
+		PublishedAt: &now,
+	}
+	product.SetDescription(req.Description)
+	if err := product.ValidateGTIN(); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// attributeValues collects the non-empty attribute columns of a record, or
+// returns nil when the CSV has no attribute columns
+func attributeValues(record []string, columns map[string]int) map[string]interface{} {
+	var values map[string]interface{}
+	for name := range columns {
+		key, ok := strings.CutPrefix(name, attributeColumnPrefix)
+		if !ok {
+			continue
+		}
+		if values == nil {
+			values = make(map[string]interface{})
+		}
+		if value := field(record, columns, name); value != "" {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+func (i *Importer) publish(ctx context.Context, product *domain.Product) {
+	event, err := events.New(domain.EventProductUpdated, domain.EventSource, product)
+	if err != nil {
+		return
+	}
+	if err := i.publisher.Publish(ctx, event); err != nil {
+		i.logger.WithError(err).WithField("product_id", product.ID).Error("Failed to publish event")
+	}
+}
+
+// field returns the trimmed value of a named column, or "" when absent
+func field(record []string, columns map[string]int, name string) string {
+	idx, ok := columns[name]
+	if !ok || idx >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[idx])
+}
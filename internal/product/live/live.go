@@ -0,0 +1,236 @@
+// Package live pushes stock and price changes to the storefront pages
+// watching a product, so they update without polling. Changes are taken
+// from the event bus of whichever replica makes them and shared with every
+// replica over a Redis channel, each of which passes them on to the
+// streams it serves.
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/product/config"
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/events"
+)
+
+// channel is the Redis channel updates are shared on
+const channel = "product:updates"
+
+// Subscription receives the updates of the products it watches until it
+// is closed
+type Subscription struct {
+	products []uuid.UUID
+	updates  chan domain.ProductUpdate
+	done     chan struct{}
+	once     sync.Once
+}
+
+// Updates returns the subscription's updates
+func (s *Subscription) Updates() <-chan domain.ProductUpdate {
+	return s.updates
+}
+
+// Done is closed once the subscription ends: when it is closed, falls too
+// far behind or the hub shuts down
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *Subscription) end() {
+	s.once.Do(func() { close(s.done) })
+}
+
+// Hub passes product updates on to the subscriptions watching them
+type Hub struct {
+	redis  redis.UniversalClient
+	config config.LiveConfig
+	logger *logrus.Logger
+
+	mu          sync.Mutex
+	subscribers map[uuid.UUID]map[*Subscription]struct{}
+	closed      bool
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New creates a hub
+func New(redisClient redis.UniversalClient, cfg config.LiveConfig, logger *logrus.Logger) *Hub {
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = 64
+	}
+
+	return &Hub{
+		redis:       redisClient,
+		config:      cfg,
+		logger:      logger,
+		subscribers: make(map[uuid.UUID]map[*Subscription]struct{}),
+	}
+}
+
+// Register subscribes the hub to stock and product changes on the bus
+func (h *Hub) Register(bus *events.Bus) {
+	bus.Subscribe(domain.EventProductUpdated, h.handleProductUpdated)
+	bus.Subscribe(domain.EventStockChanged, h.handleStockChanged)
+}
+
+// Start listens for the updates shared by every replica, this one
+// included, until Close is called
+func (h *Hub) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	h.cancel = cancel
+	h.done = make(chan struct{})
+
+	pubsub := h.redis.Subscribe(ctx, channel)
+	go func() {
+		defer close(h.done)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-messages:
+				if !ok {
+					return
+				}
+				var update domain.ProductUpdate
+				if err := json.Unmarshal([]byte(message.Payload), &update); err != nil {
+					h.logger.WithError(err).Warn("Invalid product update")
+					continue
+				}
+				h.deliver(update)
+			}
+		}
+	}()
+}
+
+// Close ends every subscription and stops listening for updates. Streams
+// are long-lived, so it is called as the HTTP server shuts down rather
+// than waiting for them.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return
+	}
+	h.closed = true
+	for _, subscriptions := range h.subscribers {
+		for subscription := range subscriptions {
+			subscription.end()
+		}
+	}
+	h.subscribers = nil
+	h.mu.Unlock()
+
+	if h.cancel != nil {
+		h.cancel()
+		<-h.done
+	}
+}
+
+// Subscribe watches products for updates. The subscription must be closed
+// with Unsubscribe; it comes back already done once the hub is closed.
+func (h *Hub) Subscribe(products []uuid.UUID) *Subscription {
+	subscription := &Subscription{
+		products: products,
+		updates:  make(chan domain.ProductUpdate, h.config.BufferSize),
+		done:     make(chan struct{}),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		subscription.end()
+		return subscription
+	}
+	for _, id := range products {
+		if h.subscribers[id] == nil {
+			h.subscribers[id] = make(map[*Subscription]struct{})
+		}
+		h.subscribers[id][subscription] = struct{}{}
+	}
+	return subscription
+}
+
+// Unsubscribe stops a subscription's updates
+func (h *Hub) Unsubscribe(subscription *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.remove(subscription)
+	subscription.end()
+}
+
+func (h *Hub) handleProductUpdated(ctx context.Context, event events.Event) error {
+	var product domain.Product
+	if err := event.Decode(&product); err != nil {
+		return err
+	}
+	// Some changes only name the product that changed
+	if product.Price <= 0 {
+		return nil
+	}
+	h.share(ctx, domain.NewProductUpdate(&product, time.Now()))
+	return nil
+}
+
+func (h *Hub) handleStockChanged(ctx context.Context, event events.Event) error {
+	var change domain.StockChanged
+	if err := event.Decode(&change); err != nil {
+		return err
+	}
+	h.share(ctx, domain.NewStockUpdate(&change.StockMovement))
+	return nil
+}
+
+// share sends an update to every replica. Should Redis be unavailable, the
+// streams this replica serves are still sent it.
+func (h *Hub) share(ctx context.Context, update domain.ProductUpdate) {
+	payload, err := json.Marshal(update)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to encode product update")
+		return
+	}
+	if err := h.redis.Publish(ctx, channel, payload).Err(); err != nil {
+		h.logger.WithError(err).Warn("Failed to share product update")
+		h.deliver(update)
+	}
+}
+
+// deliver sends an update to the subscriptions watching its product here.
+// A subscription whose updates are not read fast enough is ended rather
+// than left to miss some, so its client reconnects and starts afresh.
+func (h *Hub) deliver(update domain.ProductUpdate) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for subscription := range h.subscribers[update.ProductID] {
+		select {
+		case subscription.updates <- update:
+		default:
+			h.remove(subscription)
+			subscription.end()
+		}
+	}
+}
+
+// remove drops a subscription from the products it watches; callers hold mu
+func (h *Hub) remove(subscription *Subscription) {
+	for _, id := range subscription.products {
+		subscriptions := h.subscribers[id]
+		delete(subscriptions, subscription)
+		if len(subscriptions) == 0 {
+			delete(h.subscribers, id)
+		}
+	}
+}
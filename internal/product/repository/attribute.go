@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"ecommerce/internal/product/domain"
+	customErrors "ecommerce/pkg/errors"
+	"ecommerce/pkg/tenant"
+)
+
+func (r *productRepository) CreateAttributeDefinition(ctx context.Context, def *domain.AttributeDefinition) error {
+	if err := r.conn(ctx).Create(def).Error; err != nil {
+		return fmt.Errorf("failed to create attribute definition: %w", err)
+	}
+	return nil
+}
+
+func (r *productRepository) GetAttributeDefinition(ctx context.Context, id uuid.UUID) (*domain.AttributeDefinition, error) {
+	var def domain.AttributeDefinition
+	err := r.conn(ctx).First(&def, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Attribute definition not found", err).WithCode(customErrors.CodeAttributeNotFound)
+		}
+		return nil, fmt.Errorf("failed to get attribute definition: %w", err)
+	}
+
+	return &def, nil
+}
+
+func (r *productRepository) UpdateAttributeDefinition(ctx context.Context, def *domain.AttributeDefinition) error {
+	if err := r.conn(ctx).Save(def).Error; err != nil {
+		return fmt.Errorf("failed to update attribute definition: %w", err)
+	}
+	return nil
+}
+
+func (r *productRepository) DeleteAttributeDefinition(ctx context.Context, id uuid.UUID) error {
+	if err := r.conn(ctx).Delete(&domain.AttributeDefinition{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete attribute definition: %w", err)
+	}
+	return nil
+}
+
+// ListAttributeDefinitions returns the definitions that apply to a category,
+// including those inherited from its ancestors. A definition on a closer
+// category overrides an inherited one with the same key.
+func (r *productRepository) ListAttributeDefinitions(ctx context.Context, categoryID uuid.UUID) ([]domain.AttributeDefinition, error) {
+	var defs []domain.AttributeDefinition
+	err := r.conn(ctx).Raw(`
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id, 0 AS depth
+			FROM categories
+			WHERE id = ? AND ?
+			UNION ALL
+			SELECT c.id, c.parent_id, a.depth + 1
+			FROM categories c
+			JOIN ancestors a ON c.id = a.parent_id
+			WHERE a.depth < ?
+		)
+		SELECT d.*
+		FROM attribute_definitions d
+		JOIN ancestors a ON a.id = d.category_id
+		ORDER BY a.depth, d.key`, categoryID, tenant.Filter(ctx, "categories"), maxCategoryDepth).
+		Scan(&defs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attribute definitions: %w", err)
+	}
+
+	seen := make(map[string]bool, len(defs))
+	effective := make([]domain.AttributeDefinition, 0, len(defs))
+	for _, def := range defs {
+		if seen[def.Key] {
+			continue
+		}
+		seen[def.Key] = true
+		effective = append(effective, def)
+	}
+	sort.Slice(effective, func(i, j int) bool { return effective[i].Key < effective[j].Key })
+
+	return effective, nil
+}
+
+func (r *productRepository) ReplaceProductAttributes(ctx context.Context, productID uuid.UUID, attributes []domain.ProductAttribute) error {
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		return replaceAttributes(tx, []uuid.UUID{productID}, map[uuid.UUID][]domain.ProductAttribute{productID: attributes})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to replace product attributes: %w", err)
+	}
+
+	r.invalidateProductIDs(ctx, []uuid.UUID{productID})
+	return nil
+}
+
+// replaceAttributes swaps the stored attributes of the given products
+func replaceAttributes(tx *gorm.DB, productIDs []uuid.UUID, attributes map[uuid.UUID][]domain.ProductAttribute) error {
+	if err := tx.Where("product_id IN ?", productIDs).Delete(&domain.ProductAttribute{}).Error; err != nil {
+		return err
+	}
+
+	var rows []domain.ProductAttribute
+	for _, productID := range productIDs {
+		for _, attribute := range attributes[productID] {
+			attribute.ProductID = productID
+			rows = append(rows, attribute)
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.Create(&rows).Error
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"ecommerce/internal/product/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+func (r *productRepository) CreateAuditEvent(ctx context.Context, event *domain.AuditEvent) error {
+	if err := r.conn(ctx).Create(event).Error; err != nil {
+		return fmt.Errorf("failed to create audit event: %w", err)
+	}
+	return nil
+}
+
+func (r *productRepository) GetAuditEvent(ctx context.Context, id uuid.UUID) (*domain.AuditEvent, error) {
+	var event domain.AuditEvent
+	err := r.conn(ctx).First(&event, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Audit event not found", err).WithCode(customErrors.CodeAuditEventNotFound)
+		}
+		return nil, fmt.Errorf("failed to get audit event: %w", err)
+	}
+
+	return &event, nil
+}
+
+func (r *productRepository) ListAuditEvents(ctx context.Context, filters *domain.AuditFilters) ([]domain.AuditEvent, int64, error) {
+	query := r.conn(ctx).Model(&domain.AuditEvent{})
+
+	if filters.EntityType != "" {
+		query = query.Where("entity_type = ?", filters.EntityType)
+	}
+	if filters.EntityID != nil {
+		query = query.Where("entity_id = ?", *filters.EntityID)
+	}
+	if filters.ActorID != "" {
+		query = query.Where("actor_id = ?", filters.ActorID)
+	}
+	if filters.From != nil {
+		query = query.Where("created_at >= ?", *filters.From)
+	}
+	if filters.To != nil {
+		query = query.Where("created_at < ?", *filters.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit events: %w", err)
+	}
+
+	var events []domain.AuditEvent
+	err := query.
+		Order("created_at DESC").
+		Offset(filters.Offset).
+		Limit(filters.Limit).
+		Find(&events).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit events: %w", err)
+	}
+
+	return events, total, nil
+}
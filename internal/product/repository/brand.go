@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"ecommerce/internal/product/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+func (r *productRepository) CreateBrand(ctx context.Context, brand *domain.Brand) error {
+	if err := r.conn(ctx).Create(brand).Error; err != nil {
+		return fmt.Errorf("failed to create brand: %w", err)
+	}
+	return nil
+}
+
+func (r *productRepository) GetBrand(ctx context.Context, id uuid.UUID) (*domain.Brand, error) {
+	var brand domain.Brand
+	err := r.conn(ctx).First(&brand, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Brand not found", err).WithCode(customErrors.CodeBrandNotFound)
+		}
+		return nil, fmt.Errorf("failed to get brand: %w", err)
+	}
+
+	return &brand, nil
+}
+
+func (r *productRepository) GetBrandByName(ctx context.Context, name string) (*domain.Brand, error) {
+	var brand domain.Brand
+	err := r.conn(ctx).First(&brand, "name = ?", name).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Brand not found", err).WithCode(customErrors.CodeBrandNotFound)
+		}
+		return nil, fmt.Errorf("failed to get brand by name: %w", err)
+	}
+
+	return &brand, nil
+}
+
+func (r *productRepository) UpdateBrand(ctx context.Context, brand *domain.Brand) error {
+	if err := r.conn(ctx).Save(brand).Error; err != nil {
+		return fmt.Errorf("failed to update brand: %w", err)
+	}
+	return nil
+}
+
+func (r *productRepository) DeleteBrand(ctx context.Context, id uuid.UUID) error {
+	if err := r.conn(ctx).Delete(&domain.Brand{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete brand: %w", err)
+	}
+	return nil
+}
+
+func (r *productRepository) ListBrands(ctx context.Context) ([]domain.Brand, error) {
+	var brands []domain.Brand
+	err := r.conn(ctx).
+		Where("is_active = ?", true).
+		Order("name ASC").
+		Find(&brands).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list brands: %w", err)
+	}
+
+	return brands, nil
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"ecommerce/internal/product/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+// BulkUpdate applies prepared product changes in a single transaction and
+// reports the outcome of each. A change that fails is rolled back to its
+// savepoint and the others still apply.
+func (r *productRepository) BulkUpdate(ctx context.Context, changes []domain.ProductChange) ([]error, error) {
+	results := make([]error, len(changes))
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		for i, change := range changes {
+			savepoint := fmt.Sprintf("bulk_%d", i)
+			if err := tx.SavePoint(savepoint).Error; err != nil {
+				return err
+			}
+
+			if err := applyChange(tx, change); err != nil {
+				if rollbackErr := tx.RollbackTo(savepoint).Error; rollbackErr != nil {
+					return rollbackErr
+				}
+				results[i] = err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply bulk update: %w", err)
+	}
+	return results, nil
+}
+
+// applyChange writes one prepared change
+func applyChange(tx *gorm.DB, change domain.ProductChange) error {
+	if len(change.Columns) > 0 {
+		result := tx.Model(&domain.Product{}).Where("id = ?", change.ProductID).Updates(change.Columns)
+		if result.Error != nil {
+			return fmt.Errorf("failed to update product: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return customErrors.NewNotFoundError("Product not found", nil).WithCode(customErrors.CodeProductNotFound)
+		}
+	}
+
+	if change.ReplaceAttributes {
+		attributes := map[uuid.UUID][]domain.ProductAttribute{change.ProductID: change.Attributes}
+		if err := replaceAttributes(tx, []uuid.UUID{change.ProductID}, attributes); err != nil {
+			return fmt.Errorf("failed to replace product attributes: %w", err)
+		}
+	}
+	return nil
+}
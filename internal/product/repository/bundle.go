@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/tenant"
+)
+
+// bundlePriceSQL is the price of a computed bundle b: its components'
+// regular prices less its discount
+const bundlePriceSQL = "(SELECT ROUND(SUM(c.price * bc.quantity) * (100 - b.bundle_discount) / 100, 2) " +
+	"FROM bundle_components bc JOIN products c ON c.id = bc.component_id WHERE bc.bundle_id = b.id)"
+
+// ReplaceBundleComponents replaces the components of a bundle
+func (r *productRepository) ReplaceBundleComponents(ctx context.Context, bundleID uuid.UUID, components []domain.BundleComponent) error {
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("bundle_id = ?", bundleID).Delete(&domain.BundleComponent{}).Error; err != nil {
+			return fmt.Errorf("failed to remove bundle components: %w", err)
+		}
+		if len(components) == 0 {
+			return nil
+		}
+		for i := range components {
+			components[i].BundleID = bundleID
+		}
+		if err := tx.Create(&components).Error; err != nil {
+			return fmt.Errorf("failed to add bundle components: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	r.invalidateProductIDs(ctx, []uuid.UUID{bundleID})
+	return nil
+}
+
+// ListBundleComponents lists the components of bundles, in product order
+func (r *productRepository) ListBundleComponents(ctx context.Context, bundleIDs []uuid.UUID) ([]domain.BundleComponent, error) {
+	if len(bundleIDs) == 0 {
+		return nil, nil
+	}
+
+	var components []domain.BundleComponent
+	err := r.conn(ctx).
+		Where("bundle_id IN ?", bundleIDs).
+		Order("bundle_id, component_id").
+		Find(&components).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bundle components: %w", err)
+	}
+	return components, nil
+}
+
+// RepriceBundles brings the prices of computed bundles up to date with
+// their components: the product itself when it is one, and the bundles it
+// is a component of. It returns the bundles whose price changed.
+func (r *productRepository) RepriceBundles(ctx context.Context, productID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.conn(ctx).Raw(
+		"UPDATE products b SET price = "+bundlePriceSQL+", updated_at = NOW() "+
+			"WHERE b.deleted_at IS NULL AND b.type = ? AND b.bundle_pricing = ? AND ? "+
+			"AND (b.id = ? OR b.id IN (SELECT bundle_id FROM bundle_components WHERE component_id = ?)) "+
+			"AND "+bundlePriceSQL+" > 0 AND b.price IS DISTINCT FROM "+bundlePriceSQL+" "+
+			"RETURNING b.id",
+		domain.ProductTypeBundle, domain.BundlePricingComputed, tenant.Filter(ctx, "b"), productID, productID,
+	).Scan(&ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to reprice bundles: %w", err)
+	}
+
+	r.invalidateProductIDs(ctx, ids)
+	return ids, nil
+}
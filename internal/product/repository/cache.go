@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/cache"
+)
+
+// InspectCacheKey describes a cached key and its value
+func (r *productRepository) InspectCacheKey(ctx context.Context, key string) (*domain.CacheEntry, error) {
+	entry := &domain.CacheEntry{
+		Key:       key,
+		Namespace: domain.CacheNamespace(key),
+	}
+	_, entry.Local = r.local.Get(key)
+
+	var value *redis.StringCmd
+	var ttl *redis.DurationCmd
+	_, err := r.redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		value = pipe.Get(ctx, key)
+		ttl = pipe.TTL(ctx, key)
+		return nil
+	})
+	if err == redis.Nil {
+		return entry, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect cache key: %w", err)
+	}
+
+	data, _ := value.Bytes()
+	entry.Exists = true
+	entry.Size = len(data)
+	entry.Value = r.cache.Inspect(data)
+	if remaining := ttl.Val(); remaining > 0 {
+		seconds := int64(remaining / time.Second)
+		entry.TTL = &seconds
+	}
+	return entry, nil
+}
+
+// ListCacheKeys returns up to limit cached keys matching a pattern, and
+// whether more match
+func (r *productRepository) ListCacheKeys(ctx context.Context, pattern string, limit int) ([]string, bool, error) {
+	keys := []string{}
+	truncated := false
+	err := r.cache.Scan(ctx, pattern, func(batch []string) error {
+		for _, key := range batch {
+			if len(keys) == limit {
+				truncated = true
+				return cache.StopScan
+			}
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to scan cache keys: %w", err)
+	}
+	return keys, truncated, nil
+}
+
+// PurgeCacheKeys drops the given keys and those matching a pattern, from
+// Redis and from the local cache of every replica
+func (r *productRepository) PurgeCacheKeys(ctx context.Context, keys []string, pattern string) (int64, error) {
+	var deleted int64
+	purge := func(batch []string) error {
+		if len(batch) == 0 {
+			return nil
+		}
+		n, err := r.cache.Delete(ctx, batch...)
+		if err != nil {
+			return fmt.Errorf("failed to purge cache keys: %w", err)
+		}
+		deleted += n
+		r.local.Invalidate(ctx, batch...)
+		return nil
+	}
+
+	if err := purge(keys); err != nil {
+		return deleted, err
+	}
+	if pattern == "" {
+		return deleted, nil
+	}
+
+	if err := r.cache.Scan(ctx, pattern, purge); err != nil {
+		return deleted, fmt.Errorf("failed to scan cache keys: %w", err)
+	}
+	return deleted, nil
+}
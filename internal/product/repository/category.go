@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/cache"
+	"ecommerce/pkg/database"
+	customErrors "ecommerce/pkg/errors"
+	"ecommerce/pkg/tenant"
+)
+
+// MoveCategory moves a category, and with it its whole subtree, under a new
+// parent, or to the top level when parentID is nil
+func (r *productRepository) MoveCategory(ctx context.Context, id uuid.UUID, parentID *uuid.UUID) error {
+	return r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockCategoryTree(tx); err != nil {
+			return err
+		}
+
+		if parentID != nil {
+			if err := checkNotInSubtree(tx, *parentID, id, "Parent assignment would create a category cycle"); err != nil {
+				return err
+			}
+		}
+
+		result := tx.Model(&domain.Category{}).Where("id = ?", id).Update("parent_id", parentID)
+		if result.Error != nil {
+			return fmt.Errorf("failed to move category: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return customErrors.NewNotFoundError("Category not found", nil).WithCode(customErrors.CodeCategoryNotFound)
+		}
+		return nil
+	})
+}
+
+// MergeCategory folds the source category into the target and deletes it.
+// Products, subcategories and slug redirects move to the target, along with
+// attribute definitions the target does not define itself; the source's
+// slug redirects to the target. It returns the IDs of the moved products.
+func (r *productRepository) MergeCategory(ctx context.Context, sourceID, targetID uuid.UUID) ([]uuid.UUID, error) {
+	var moved []uuid.UUID
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockCategoryTree(tx); err != nil {
+			return err
+		}
+
+		var source domain.Category
+		if err := tx.First(&source, "id = ?", sourceID).Error; err != nil {
+			return fmt.Errorf("failed to get category: %w", err)
+		}
+
+		// The source's children move to the target, so the target must not
+		// sit beneath the source
+		if err := checkNotInSubtree(tx, targetID, sourceID, "Cannot merge a category into one of its subcategories"); err != nil {
+			return err
+		}
+
+		err := tx.Raw("UPDATE products SET category_id = ?, updated_at = NOW() WHERE category_id = ? AND ? RETURNING id",
+			targetID, sourceID, tenant.Filter(ctx, "products")).Scan(&moved).Error
+		if err != nil {
+			return fmt.Errorf("failed to move products: %w", err)
+		}
+
+		err = tx.Model(&domain.Category{}).Where("parent_id = ?", sourceID).Update("parent_id", targetID).Error
+		if err != nil {
+			return fmt.Errorf("failed to move subcategories: %w", err)
+		}
+
+		// Definitions the target already has win; the rest go with the
+		// source when it is deleted
+		err = tx.Exec(`
+			UPDATE attribute_definitions SET category_id = ?, updated_at = NOW()
+			WHERE category_id = ? AND key NOT IN (
+				SELECT key FROM attribute_definitions WHERE category_id = ?
+			)`,
+			targetID, sourceID, targetID,
+		).Error
+		if err != nil {
+			return fmt.Errorf("failed to move attribute definitions: %w", err)
+		}
+
+		err = tx.Model(&domain.SlugRedirect{}).
+			Where("entity_type = ? AND entity_id = ?", domain.AuditEntityCategory, sourceID).
+			Update("entity_id", targetID).Error
+		if err != nil {
+			return fmt.Errorf("failed to move slug redirects: %w", err)
+		}
+
+		if err := tx.Delete(&domain.Category{}, "id = ?", sourceID).Error; err != nil {
+			return fmt.Errorf("failed to delete category: %w", err)
+		}
+
+		redirect := &domain.SlugRedirect{
+			EntityType: domain.AuditEntityCategory,
+			EntityID:   targetID,
+			Slug:       source.Slug,
+		}
+		err = tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "entity_type"}, {Name: "slug"}},
+			DoUpdates: clause.AssignmentColumns([]string{"entity_id", "created_at"}),
+		}).Create(redirect).Error
+		if err != nil {
+			return fmt.Errorf("failed to record slug redirect: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.invalidateProductIDs(ctx, moved)
+	return moved, nil
+}
+
+// GetCategoryPaths returns the breadcrumbs of each of the given categories,
+// from the top level down to the category itself
+func (r *productRepository) GetCategoryPaths(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]domain.Breadcrumb, error) {
+	paths := make(map[uuid.UUID][]domain.Breadcrumb, len(ids))
+	if len(ids) == 0 {
+		return paths, nil
+	}
+
+	var rows []struct {
+		CategoryID uuid.UUID
+		ID         uuid.UUID
+		Name       string
+		Slug       string
+	}
+	err := r.conn(ctx).Raw(`
+		WITH RECURSIVE ancestors AS (
+			SELECT id AS category_id, id, parent_id, name, slug, 0 AS depth
+			FROM categories
+			WHERE id IN ? AND ?
+			UNION ALL
+			SELECT a.category_id, c.id, c.parent_id, c.name, c.slug, a.depth + 1
+			FROM categories c
+			JOIN ancestors a ON c.id = a.parent_id
+			WHERE a.depth < ?
+		)
+		SELECT category_id, id, name, slug FROM ancestors ORDER BY category_id, depth DESC`,
+		ids, tenant.Filter(ctx, "categories"), maxCategoryDepth,
+	).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load category paths: %w", err)
+	}
+
+	for _, row := range rows {
+		paths[row.CategoryID] = append(paths[row.CategoryID], domain.Breadcrumb{
+			ID:   row.ID,
+			Name: row.Name,
+			Slug: row.Slug,
+		})
+	}
+	return paths, nil
+}
+
+// CategoryProductCounts counts the live, active, published products of
+// every category that has any, directly and including subcategories. The
+// counts are cached with the product listings and invalidated with them.
+func (r *productRepository) CategoryProductCounts(ctx context.Context) (map[uuid.UUID]domain.CategoryProductCount, error) {
+	key, ok := cacheKey(ctx, r.categoryCounts)
+	if !ok {
+		return r.countCategoryProducts(ctx)
+	}
+	return cache.GetOrLoad(database.WithPrimary(ctx), r.categoryCounts, key, r.countCategoryProducts)
+}
+
+func (r *productRepository) countCategoryProducts(ctx context.Context) (map[uuid.UUID]domain.CategoryProductCount, error) {
+	var rows []struct {
+		CategoryID uuid.UUID
+		Direct     int64
+		Total      int64
+	}
+	err := r.conn(ctx).Raw(`
+		WITH RECURSIVE direct AS (
+			SELECT category_id, COUNT(*) AS count
+			FROM products
+			WHERE deleted_at IS NULL AND is_active AND status = ? AND category_id IS NOT NULL AND ?
+			GROUP BY category_id
+		), tree AS (
+			SELECT id AS root_id, id, 0 AS depth
+			FROM categories
+			WHERE ?
+			UNION ALL
+			SELECT t.root_id, c.id, t.depth + 1
+			FROM categories c
+			JOIN tree t ON c.parent_id = t.id
+			WHERE t.depth < ?
+		)
+		SELECT t.root_id AS category_id,
+			COALESCE(SUM(d.count) FILTER (WHERE t.id = t.root_id), 0) AS direct,
+			SUM(d.count) AS total
+		FROM tree t
+		JOIN direct d ON d.category_id = t.id
+		GROUP BY t.root_id`,
+		domain.ProductStatusPublished, tenant.Filter(ctx, "products"), tenant.Filter(ctx, "categories"), maxCategoryDepth,
+	).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count category products: %w", err)
+	}
+
+	counts := make(map[uuid.UUID]domain.CategoryProductCount, len(rows))
+	for _, row := range rows {
+		counts[row.CategoryID] = domain.CategoryProductCount{Direct: row.Direct, Total: row.Total}
+	}
+	return counts, nil
+}
+
+// lockCategoryTree serializes changes to the shape of the category tree, so
+// two concurrent moves cannot together create a cycle that neither would
+// alone. Reads are not blocked.
+func lockCategoryTree(tx *gorm.DB) error {
+	if err := tx.Exec("LOCK TABLE categories IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+		return fmt.Errorf("failed to lock categories: %w", err)
+	}
+	return nil
+}
+
+// checkNotInSubtree fails with a validation error when id is rootID or one
+// of its descendants
+func checkNotInSubtree(tx *gorm.DB, id, rootID uuid.UUID, message string) error {
+	ancestors, err := categoryAncestorIDs(tx, id)
+	if err != nil {
+		return err
+	}
+	for _, ancestorID := range ancestors {
+		if ancestorID == rootID {
+			return customErrors.NewValidationError(message, nil)
+		}
+	}
+	return nil
+}
+
+// categoryAncestorIDs returns a category's ID followed by those of its
+// ancestors, nearest first
+func categoryAncestorIDs(db *gorm.DB, id uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := db.Raw(`
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id, 0 AS depth
+			FROM categories
+			WHERE id = ? AND ?
+			UNION ALL
+			SELECT c.id, c.parent_id, a.depth + 1
+			FROM categories c
+			JOIN ancestors a ON c.id = a.parent_id
+			WHERE a.depth < ?
+		)
+		SELECT id FROM ancestors ORDER BY depth`, id, tenant.Filter(db.Statement.Context, "categories"), maxCategoryDepth).
+		Scan(&ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load category ancestors: %w", err)
+	}
+
+	return ids, nil
+}
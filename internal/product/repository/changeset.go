@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"ecommerce/internal/product/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+func (r *productRepository) CreateChangeset(ctx context.Context, changeset *domain.Changeset) error {
+	if err := r.conn(ctx).Omit("Items").Create(changeset).Error; err != nil {
+		return fmt.Errorf("failed to create changeset: %w", err)
+	}
+	return nil
+}
+
+// GetChangeset returns a changeset with its items, oldest first
+func (r *productRepository) GetChangeset(ctx context.Context, id uuid.UUID) (*domain.Changeset, error) {
+	var changeset domain.Changeset
+	err := r.conn(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC, id ASC") }).
+		First(&changeset, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Changeset not found", err).WithCode(customErrors.CodeChangesetNotFound)
+		}
+		return nil, fmt.Errorf("failed to get changeset: %w", err)
+	}
+
+	return &changeset, nil
+}
+
+// UpdateChangeset saves a changeset, leaving its items alone
+func (r *productRepository) UpdateChangeset(ctx context.Context, changeset *domain.Changeset) error {
+	if err := r.conn(ctx).Omit("Items").Save(changeset).Error; err != nil {
+		return fmt.Errorf("failed to update changeset: %w", err)
+	}
+	return nil
+}
+
+// TransitionChangeset saves a changeset's status and the times and error
+// that go with it, provided it is still in one of the from statuses. It
+// reports whether it was, so only one of several instances moves it on.
+func (r *productRepository) TransitionChangeset(ctx context.Context, changeset *domain.Changeset, from ...string) (bool, error) {
+	result := r.conn(ctx).
+		Model(changeset).
+		Where("status IN ?", from).
+		Select("status", "apply_at", "applied_at", "rolled_back_at", "error", "updated_at").
+		Updates(changeset)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to update changeset status: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *productRepository) DeleteChangeset(ctx context.Context, id uuid.UUID) error {
+	result := r.conn(ctx).Delete(&domain.Changeset{}, "id = ?", id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete changeset: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return customErrors.NewNotFoundError("Changeset not found", nil).WithCode(customErrors.CodeChangesetNotFound)
+	}
+	return nil
+}
+
+// ListChangesets lists changesets without their items, newest first
+func (r *productRepository) ListChangesets(ctx context.Context, filters *domain.ChangesetFilters) ([]domain.Changeset, int64, error) {
+	query := r.conn(ctx).Model(&domain.Changeset{})
+
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count changesets: %w", err)
+	}
+
+	var changesets []domain.Changeset
+	err := query.
+		Order("created_at DESC").
+		Limit(filters.Limit).
+		Offset(filters.Offset).
+		Find(&changesets).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list changesets: %w", err)
+	}
+
+	return changesets, total, nil
+}
+
+// ListDueChangesets lists the scheduled changesets whose time to apply has
+// come, without their items, earliest first
+func (r *productRepository) ListDueChangesets(ctx context.Context, limit int) ([]domain.Changeset, error) {
+	var changesets []domain.Changeset
+	err := r.conn(ctx).
+		Where("status = ? AND apply_at <= ?", domain.ChangesetStatusScheduled, time.Now()).
+		Order("apply_at ASC").
+		Limit(limit).
+		Find(&changesets).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due changesets: %w", err)
+	}
+	return changesets, nil
+}
+
+// SaveChangesetItem sets the edit a changeset makes to a product,
+// replacing any it had
+func (r *productRepository) SaveChangesetItem(ctx context.Context, item *domain.ChangesetItem) error {
+	err := r.conn(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "changeset_id"}, {Name: "product_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"patch", "revert", "updated_at"}),
+		}).
+		Create(item).Error
+	if err != nil {
+		return fmt.Errorf("failed to save changeset item: %w", err)
+	}
+	return nil
+}
+
+func (r *productRepository) DeleteChangesetItem(ctx context.Context, changesetID, productID uuid.UUID) (bool, error) {
+	result := r.conn(ctx).
+		Where("changeset_id = ? AND product_id = ?", changesetID, productID).
+		Delete(&domain.ChangesetItem{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete changeset item: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
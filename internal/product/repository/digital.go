@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"ecommerce/internal/product/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+func (r *productRepository) CreateDigitalAsset(ctx context.Context, asset *domain.DigitalAsset) error {
+	if err := r.conn(ctx).Create(asset).Error; err != nil {
+		return fmt.Errorf("failed to create digital asset: %w", err)
+	}
+	return nil
+}
+
+func (r *productRepository) GetDigitalAsset(ctx context.Context, id uuid.UUID) (*domain.DigitalAsset, error) {
+	var asset domain.DigitalAsset
+	err := r.conn(ctx).First(&asset, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Digital asset not found", err).WithCode(customErrors.CodeDigitalAssetNotFound)
+		}
+		return nil, fmt.Errorf("failed to get digital asset: %w", err)
+	}
+
+	return &asset, nil
+}
+
+func (r *productRepository) DeleteDigitalAsset(ctx context.Context, id uuid.UUID) error {
+	if err := r.conn(ctx).Delete(&domain.DigitalAsset{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete digital asset: %w", err)
+	}
+	return nil
+}
+
+// ListDigitalAssets lists a product's downloadable files, oldest first
+func (r *productRepository) ListDigitalAssets(ctx context.Context, productID uuid.UUID) ([]domain.DigitalAsset, error) {
+	var assets []domain.DigitalAsset
+	err := r.conn(ctx).
+		Where("product_id = ?", productID).
+		Order("created_at ASC, id ASC").
+		Find(&assets).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list digital assets: %w", err)
+	}
+	return assets, nil
+}
+
+// GrantEntitlements records entitlements and returns those that were new.
+// An order already entitled to a product keeps the entitlement it has, so
+// granting is safe to repeat.
+func (r *productRepository) GrantEntitlements(ctx context.Context, entitlements []domain.Entitlement) ([]domain.Entitlement, error) {
+	var granted []domain.Entitlement
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, entitlement := range entitlements {
+			var inserted []domain.Entitlement
+			err := tx.Raw(`
+				INSERT INTO entitlements (order_id, customer_id, product_id, product_name, quantity, license_key)
+				VALUES (?, ?, ?, ?, ?, ?)
+				ON CONFLICT (order_id, product_id) DO NOTHING
+				RETURNING *`,
+				entitlement.OrderID, entitlement.CustomerID, entitlement.ProductID,
+				entitlement.ProductName, entitlement.Quantity, entitlement.LicenseKey,
+			).Scan(&inserted).Error
+			if err != nil {
+				return fmt.Errorf("failed to grant entitlement: %w", err)
+			}
+			granted = append(granted, inserted...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return granted, nil
+}
+
+func (r *productRepository) GetEntitlement(ctx context.Context, id uuid.UUID) (*domain.Entitlement, error) {
+	var entitlement domain.Entitlement
+	err := r.conn(ctx).First(&entitlement, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Entitlement not found", err).WithCode(customErrors.CodeEntitlementNotFound)
+		}
+		return nil, fmt.Errorf("failed to get entitlement: %w", err)
+	}
+
+	return &entitlement, nil
+}
+
+// ListEntitlements lists entitlements, newest first
+func (r *productRepository) ListEntitlements(ctx context.Context, filters *domain.EntitlementFilters) ([]domain.Entitlement, int64, error) {
+	query := r.conn(ctx).Model(&domain.Entitlement{})
+	if filters.CustomerID != "" {
+		query = query.Where("customer_id = ?", filters.CustomerID)
+	}
+	if filters.OrderID != nil {
+		query = query.Where("order_id = ?", *filters.OrderID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count entitlements: %w", err)
+	}
+
+	var entitlements []domain.Entitlement
+	err := query.
+		Order("created_at DESC, id DESC").
+		Limit(filters.Limit).
+		Offset(filters.Offset).
+		Find(&entitlements).Error
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list entitlements: %w", err)
+	}
+
+	return entitlements, total, nil
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/database"
+	customErrors "ecommerce/pkg/errors"
+)
+
+// flashSaleOpenKey is the unique index giving each product at most one open
+// flash sale
+const flashSaleOpenKey = "idx_flash_sales_open_product"
+
+func (r *productRepository) CreateFlashSale(ctx context.Context, sale *domain.FlashSale) error {
+	err := r.conn(ctx).Create(sale).Error
+	if database.IsUniqueViolation(err, flashSaleOpenKey) {
+		return customErrors.NewConflictError("Product already has a flash sale", err).WithCode(customErrors.CodeFlashSaleExists)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create flash sale: %w", err)
+	}
+	return nil
+}
+
+// GetFlashSale returns a product's open flash sale
+func (r *productRepository) GetFlashSale(ctx context.Context, productID uuid.UUID) (*domain.FlashSale, error) {
+	var sale domain.FlashSale
+	err := r.conn(ctx).First(&sale, "product_id = ? AND closed_at IS NULL", productID).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Flash sale not found", err).WithCode(customErrors.CodeFlashSaleNotFound)
+		}
+		return nil, fmt.Errorf("failed to get flash sale: %w", err)
+	}
+
+	return &sale, nil
+}
+
+func (r *productRepository) UpdateFlashSale(ctx context.Context, sale *domain.FlashSale) error {
+	if err := r.conn(ctx).Save(sale).Error; err != nil {
+		return fmt.Errorf("failed to update flash sale: %w", err)
+	}
+	return nil
+}
+
+func (r *productRepository) DeleteFlashSale(ctx context.Context, id uuid.UUID) error {
+	result := r.conn(ctx).Delete(&domain.FlashSale{}, "id = ?", id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete flash sale: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return customErrors.NewNotFoundError("Flash sale not found", nil).WithCode(customErrors.CodeFlashSaleNotFound)
+	}
+	return nil
+}
+
+// CloseFlashSale marks a flash sale closed, provided it is still open. It
+// reports whether it was, so only one of several instances closes it.
+func (r *productRepository) CloseFlashSale(ctx context.Context, sale *domain.FlashSale) (bool, error) {
+	now := time.Now()
+	result := r.conn(ctx).
+		Model(&domain.FlashSale{}).
+		Where("id = ? AND closed_at IS NULL", sale.ID).
+		Updates(map[string]interface{}{"closed_at": now, "updated_at": now})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to close flash sale: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	sale.ClosedAt, sale.UpdatedAt = &now, now
+	return true, nil
+}
+
+// ListOpenFlashSales lists the flash sales that have started and are not
+// yet closed, earliest first
+func (r *productRepository) ListOpenFlashSales(ctx context.Context, limit int) ([]domain.FlashSale, error) {
+	var sales []domain.FlashSale
+	err := r.conn(ctx).
+		Where("closed_at IS NULL AND starts_at <= ?", time.Now()).
+		Order("starts_at ASC").
+		Limit(limit).
+		Find(&sales).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open flash sales: %w", err)
+	}
+	return sales, nil
+}
+
+// RecordFlashSalePurchases writes purchases of a flash sale and adds them to
+// what it sold. Purchases already written are skipped, so a batch can be
+// written again after a failure; the units newly written are returned.
+func (r *productRepository) RecordFlashSalePurchases(ctx context.Context, saleID uuid.UUID, purchases []domain.FlashSalePurchase) (int, error) {
+	if len(purchases) == 0 {
+		return 0, nil
+	}
+
+	var recorded int
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the sale, so instances writing the same batch don't both
+		// count it
+		var sale domain.FlashSale
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&sale, "id = ?", saleID).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return customErrors.NewNotFoundError("Flash sale not found", err).WithCode(customErrors.CodeFlashSaleNotFound)
+			}
+			return fmt.Errorf("failed to get flash sale: %w", err)
+		}
+
+		ids := make([]uuid.UUID, len(purchases))
+		for i, purchase := range purchases {
+			ids[i] = purchase.ID
+		}
+		var written []uuid.UUID
+		if err := tx.Model(&domain.FlashSalePurchase{}).Where("id IN ?", ids).Pluck("id", &written).Error; err != nil {
+			return fmt.Errorf("failed to get flash sale purchases: %w", err)
+		}
+		seen := make(map[uuid.UUID]bool, len(written))
+		for _, id := range written {
+			seen[id] = true
+		}
+
+		var fresh []domain.FlashSalePurchase
+		for _, purchase := range purchases {
+			if seen[purchase.ID] {
+				continue
+			}
+			seen[purchase.ID] = true
+			purchase.FlashSaleID = saleID
+			fresh = append(fresh, purchase)
+			recorded += purchase.Quantity
+		}
+		if len(fresh) == 0 {
+			return nil
+		}
+
+		if err := tx.Create(&fresh).Error; err != nil {
+			return fmt.Errorf("failed to record flash sale purchases: %w", err)
+		}
+		err = tx.Model(&domain.FlashSale{}).
+			Where("id = ?", saleID).
+			Updates(map[string]interface{}{"sold": gorm.Expr("sold + ?", recorded), "updated_at": time.Now()}).Error
+		if err != nil {
+			return fmt.Errorf("failed to update flash sale: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return recorded, nil
+}
+
+// FlashSaleBuyers returns the units each customer bought of a flash sale,
+// as written so far
+func (r *productRepository) FlashSaleBuyers(ctx context.Context, saleID uuid.UUID) (map[string]int, error) {
+	var rows []struct {
+		UserID   string
+		Quantity int
+	}
+	err := r.conn(ctx).
+		Model(&domain.FlashSalePurchase{}).
+		Select("user_id, SUM(quantity) AS quantity").
+		Where("flash_sale_id = ?", saleID).
+		Group("user_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flash sale buyers: %w", err)
+	}
+
+	buyers := make(map[string]int, len(rows))
+	for _, row := range rows {
+		buyers[row.UserID] = row.Quantity
+	}
+	return buyers, nil
+}
@@ -0,0 +1,307 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/database"
+	customErrors "ecommerce/pkg/errors"
+	"ecommerce/pkg/tenant"
+)
+
+// upsertColumns are the columns overwritten when an upserted SKU already exists
+var upsertColumns = []string{
+	"name", "description", "description_text", "price", "category_id", "brand_id", "stock", "image_url", "gtin", "is_active",
+	"updated_at",
+}
+
+// UpsertBatch creates or overwrites products by SKU, ignoring case. Stock that changes as
+// a result is recorded in the stock ledger with the given movement.
+func (r *productRepository) UpsertBatch(ctx context.Context, products []domain.Product, movement domain.StockMovement) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	// New rows need unique slugs; existing SKUs keep theirs on conflict
+	reserved := make(map[string]bool, len(products))
+	for idx := range products {
+		if products[idx].Slug != "" {
+			continue
+		}
+		slug, err := r.uniqueSlug(ctx, domain.AuditEntityProduct, domain.Slugify(products[idx].Name), uuid.Nil, reserved)
+		if err != nil {
+			return err
+		}
+		products[idx].Slug = slug
+		reserved[slug] = true
+	}
+
+	skus := make([]string, 0, len(products))
+	for _, product := range products {
+		skus = append(skus, strings.ToLower(product.SKU))
+	}
+
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the rows being overwritten so the ledger sees the stock they
+		// had right before the upsert
+		var existing []struct {
+			SKU   string
+			Stock int
+		}
+		err := tx.Raw("SELECT LOWER(sku) AS sku, stock FROM products WHERE LOWER(sku) IN ? AND deleted_at IS NULL AND ? FOR UPDATE",
+			skus, tenant.Filter(ctx, "products")).
+			Scan(&existing).Error
+		if err != nil {
+			return err
+		}
+		previous := make(map[string]int, len(existing))
+		for _, row := range existing {
+			previous[row.SKU] = row.Stock
+		}
+
+		err = tx.Omit("Attributes").
+			Clauses(clause.OnConflict{
+				Columns:     []clause.Column{{Name: "tenant_id"}, {Name: "LOWER(sku)", Raw: true}},
+				TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "deleted_at IS NULL"}}},
+				DoUpdates:   clause.AssignmentColumns(upsertColumns),
+			}).
+			Create(&products).Error
+		if err != nil {
+			return err
+		}
+
+		var movements []domain.StockMovement
+		for _, product := range products {
+			if delta := product.Stock - previous[strings.ToLower(product.SKU)]; delta != 0 {
+				movements = append(movements, ledgerEntry(movement, product.ID, delta, product.Stock))
+			}
+		}
+		if len(movements) > 0 {
+			if err := recordMovements(tx, movements); err != nil {
+				return err
+			}
+		}
+
+		// Rows that carry attributes replace whatever the product had before
+		var ids []uuid.UUID
+		attributes := make(map[uuid.UUID][]domain.ProductAttribute)
+		for _, product := range products {
+			if product.Attributes != nil {
+				ids = append(ids, product.ID)
+				attributes[product.ID] = product.Attributes
+			}
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		return replaceAttributes(tx, ids, attributes)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upsert products: %w", err)
+	}
+
+	// Invalidate per-product cache entries
+	ids := make([]uuid.UUID, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID)
+	}
+	r.invalidateProductIDs(ctx, ids)
+
+	return nil
+}
+
+// skuUpsertColumns are the columns UpsertBySKU overwrites when the SKU
+// already exists: everything a create request sets, but not the slug or
+// whether the product is active
+var skuUpsertColumns = []string{
+	"name", "description", "description_text", "price", "category_id", "brand_id", "stock", "image_url", "gtin", "status",
+	"publish_at", "type", "low_stock_threshold", "sale_price", "sale_starts_at", "sale_ends_at", "allow_backorder",
+	"preorder", "release_date", "bundle_pricing", "bundle_discount", "unit_of_measure", "quantity_increment",
+	"min_order_quantity", "max_order_quantity", "tax_class", "updated_at",
+}
+
+// upsertedProduct is a product as returned by an upsert, with whether the
+// upsert inserted it
+type upsertedProduct struct {
+	domain.Product
+	Inserted bool `gorm:"->"`
+}
+
+// TableName returns the table name for upsertedProduct
+func (upsertedProduct) TableName() string {
+	return "products"
+}
+
+// UpsertBySKU creates a product, or overwrites the live product with its SKU
+// in any case, in a single INSERT ... ON CONFLICT, and reports whether it was created.
+// The product is updated with the row as stored. Stock that changes as a
+// result is recorded in the stock ledger with the given movement, and the
+// product's attributes replace those it had before.
+func (r *productRepository) UpsertBySKU(ctx context.Context, product *domain.Product, movement domain.StockMovement) (bool, error) {
+	var created bool
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the row being overwritten so the ledger sees the stock it had
+		// right before the upsert
+		var previous []int
+		err := tx.Raw("SELECT stock FROM products WHERE LOWER(sku) = LOWER(?) AND deleted_at IS NULL AND ? FOR UPDATE",
+			product.SKU, tenant.Filter(ctx, "products")).
+			Scan(&previous).Error
+		if err != nil {
+			return err
+		}
+		previousStock := 0
+		if len(previous) > 0 {
+			previousStock = previous[0]
+		}
+
+		// A product keeps the time it was first published; the status
+		// change sets it only when the stored product isn't published yet.
+		// xmax is zero only on rows the statement inserted.
+		assignments := append(clause.AssignmentColumns(skuUpsertColumns), clause.Assignment{
+			Column: clause.Column{Name: "published_at"},
+			Value: gorm.Expr("CASE WHEN products.status = ? THEN products.published_at "+
+				"ELSE COALESCE(excluded.published_at, products.published_at) END", domain.ProductStatusPublished),
+		})
+		row := upsertedProduct{Product: *product}
+		err = tx.Omit("Attributes").
+			Clauses(clause.OnConflict{
+				Columns:     []clause.Column{{Name: "tenant_id"}, {Name: "LOWER(sku)", Raw: true}},
+				TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "deleted_at IS NULL"}}},
+				DoUpdates:   assignments,
+			}, clause.Returning{Columns: []clause.Column{
+				{Name: "*", Raw: true},
+				{Name: "(xmax = 0) AS inserted", Raw: true},
+			}}).
+			Create(&row).Error
+		if err != nil {
+			return err
+		}
+		attributes := product.Attributes
+		*product = row.Product
+		product.Attributes = attributes
+		created = row.Inserted
+
+		if delta := product.Stock - previousStock; delta != 0 {
+			entries := []domain.StockMovement{ledgerEntry(movement, product.ID, delta, product.Stock)}
+			if err := recordMovements(tx, entries); err != nil {
+				return err
+			}
+		}
+
+		return replaceAttributes(tx, []uuid.UUID{product.ID}, map[uuid.UUID][]domain.ProductAttribute{
+			product.ID: attributes,
+		})
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to upsert product: %w", err)
+	}
+
+	r.invalidateProductIDs(ctx, []uuid.UUID{product.ID})
+
+	return created, nil
+}
+
+// ExistingSKUs reports which of the given SKUs belong to live products,
+// ignoring case
+func (r *productRepository) ExistingSKUs(ctx context.Context, skus []string) (map[string]bool, error) {
+	existing := make(map[string]bool, len(skus))
+	if len(skus) == 0 {
+		return existing, nil
+	}
+
+	lowered := make([]string, 0, len(skus))
+	for _, sku := range skus {
+		lowered = append(lowered, strings.ToLower(sku))
+	}
+
+	var found []string
+	err := r.conn(database.WithPrimary(ctx)).Model(&domain.Product{}).
+		Where("LOWER(sku) IN ?", lowered).
+		Pluck("LOWER(sku)", &found).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing SKUs: %w", err)
+	}
+
+	taken := make(map[string]bool, len(found))
+	for _, sku := range found {
+		taken[sku] = true
+	}
+	for _, sku := range skus {
+		if taken[strings.ToLower(sku)] {
+			existing[sku] = true
+		}
+	}
+	return existing, nil
+}
+
+// ProductIDsBySKU looks up the live products with the given SKUs, ignoring
+// case. The result is keyed by the SKUs as given; unknown SKUs are missing.
+func (r *productRepository) ProductIDsBySKU(ctx context.Context, skus []string) (map[string]uuid.UUID, error) {
+	ids := make(map[string]uuid.UUID, len(skus))
+	if len(skus) == 0 {
+		return ids, nil
+	}
+
+	lowered := make([]string, 0, len(skus))
+	for _, sku := range skus {
+		lowered = append(lowered, strings.ToLower(sku))
+	}
+
+	var rows []struct {
+		ID  uuid.UUID
+		SKU string
+	}
+	err := r.conn(database.WithPrimary(ctx)).Model(&domain.Product{}).
+		Where("LOWER(sku) IN ?", lowered).
+		Select("id, LOWER(sku) AS sku").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up SKUs: %w", err)
+	}
+
+	found := make(map[string]uuid.UUID, len(rows))
+	for _, row := range rows {
+		found[row.SKU] = row.ID
+	}
+	for _, sku := range skus {
+		if id, ok := found[strings.ToLower(sku)]; ok {
+			ids[sku] = id
+		}
+	}
+	return ids, nil
+}
+
+func (r *productRepository) CreateImportJob(ctx context.Context, job *domain.ImportJob) error {
+	if err := r.conn(ctx).Create(job).Error; err != nil {
+		return fmt.Errorf("failed to create import job: %w", err)
+	}
+	return nil
+}
+
+func (r *productRepository) GetImportJob(ctx context.Context, id uuid.UUID) (*domain.ImportJob, error) {
+	var job domain.ImportJob
+	err := r.conn(ctx).First(&job, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Import job not found", err).WithCode(customErrors.CodeImportJobNotFound)
+		}
+		return nil, fmt.Errorf("failed to get import job: %w", err)
+	}
+
+	return &job, nil
+}
+
+func (r *productRepository) UpdateImportJob(ctx context.Context, job *domain.ImportJob) error {
+	if err := r.conn(ctx).Save(job).Error; err != nil {
+		return fmt.Errorf("failed to update import job: %w", err)
+	}
+	return nil
+}
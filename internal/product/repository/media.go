@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"ecommerce/internal/product/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+func (r *productRepository) CreateMedia(ctx context.Context, media *domain.Media) error {
+	if err := r.conn(ctx).Create(media).Error; err != nil {
+		return fmt.Errorf("failed to create media: %w", err)
+	}
+	return nil
+}
+
+func (r *productRepository) GetMedia(ctx context.Context, id uuid.UUID) (*domain.Media, error) {
+	var media domain.Media
+	err := r.conn(ctx).First(&media, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Media not found", err).WithCode(customErrors.CodeMediaNotFound)
+		}
+		return nil, fmt.Errorf("failed to get media: %w", err)
+	}
+
+	return &media, nil
+}
+
+func (r *productRepository) UpdateMedia(ctx context.Context, media *domain.Media) error {
+	if err := r.conn(ctx).Save(media).Error; err != nil {
+		return fmt.Errorf("failed to update media: %w", err)
+	}
+	return nil
+}
+
+func (r *productRepository) DeleteMedia(ctx context.Context, id uuid.UUID) error {
+	if err := r.conn(ctx).Delete(&domain.Media{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete media: %w", err)
+	}
+	return nil
+}
+
+// ListProductMedia lists a product's media oldest first, optionally only
+// that with the given status
+func (r *productRepository) ListProductMedia(ctx context.Context, productID uuid.UUID, status string) ([]domain.Media, error) {
+	query := r.conn(ctx).Where("product_id = ?", productID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var media []domain.Media
+	if err := query.Order("created_at ASC").Find(&media).Error; err != nil {
+		return nil, fmt.Errorf("failed to list product media: %w", err)
+	}
+	return media, nil
+}
+
+// ListExpiredMedia finds media that is no longer wanted: uploads started
+// before abandonedBefore that were never completed, and the media of
+// products deleted before deletedBefore
+func (r *productRepository) ListExpiredMedia(ctx context.Context, abandonedBefore, deletedBefore time.Time, limit int) ([]domain.Media, error) {
+	var media []domain.Media
+	err := r.conn(ctx).
+		Where("(status = ? AND created_at < ?) OR product_id IN (?)",
+			domain.MediaStatusPending, abandonedBefore,
+			r.db.Unscoped().Model(&domain.Product{}).Select("id").Where("deleted_at < ?", deletedBefore),
+		).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&media).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired media: %w", err)
+	}
+	return media, nil
+}
@@ -0,0 +1,146 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/product/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+// saveDefinition stores an attribute definition, keeping keys unique within
+// a category
+func (s *store) saveDefinition(def domain.AttributeDefinition) error {
+	for _, other := range s.definitions {
+		if other.ID != def.ID && other.CategoryID == def.CategoryID && other.Key == def.Key {
+			return uniqueViolation("attribute_definitions_category_id_key_key")
+		}
+	}
+	if _, ok := s.categories[def.CategoryID]; !ok {
+		return foreignKeyViolation("attribute_definitions", "attribute_definitions_category_id_fkey")
+	}
+	s.definitions[def.ID] = *copyDefinition(def)
+	return nil
+}
+
+// copyDefinition copies a definition and its options
+func copyDefinition(def domain.AttributeDefinition) *domain.AttributeDefinition {
+	def.Options = slices.Clone(def.Options)
+	return &def
+}
+
+func (r *ProductRepository) CreateAttributeDefinition(ctx context.Context, def *domain.AttributeDefinition) error {
+	err := r.atomically(func(s *store) error {
+		now := time.Now()
+		if def.ID == uuid.Nil {
+			def.ID = uuid.New()
+		}
+		if def.CreatedAt.IsZero() {
+			def.CreatedAt = now
+		}
+		if def.UpdatedAt.IsZero() {
+			def.UpdatedAt = now
+		}
+		if _, ok := s.definitions[def.ID]; ok {
+			return uniqueViolation("attribute_definitions_pkey")
+		}
+		return s.saveDefinition(*def)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create attribute definition: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) GetAttributeDefinition(ctx context.Context, id uuid.UUID) (*domain.AttributeDefinition, error) {
+	var def *domain.AttributeDefinition
+	r.locked(func(s *store) {
+		if found, ok := s.definitions[id]; ok {
+			def = copyDefinition(found)
+		}
+	})
+	if def == nil {
+		return nil, notFound("Attribute definition not found", customErrors.CodeAttributeNotFound)
+	}
+	return def, nil
+}
+
+func (r *ProductRepository) UpdateAttributeDefinition(ctx context.Context, def *domain.AttributeDefinition) error {
+	err := r.atomically(func(s *store) error {
+		now := time.Now()
+		def.UpdatedAt = now
+		if def.CreatedAt.IsZero() {
+			def.CreatedAt = now
+		}
+		return s.saveDefinition(*def)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update attribute definition: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) DeleteAttributeDefinition(ctx context.Context, id uuid.UUID) error {
+	r.locked(func(s *store) {
+		delete(s.definitions, id)
+	})
+	return nil
+}
+
+// ListAttributeDefinitions returns the definitions that apply to a category,
+// including those inherited from its ancestors. A definition on a closer
+// category overrides an inherited one with the same key.
+func (r *ProductRepository) ListAttributeDefinitions(ctx context.Context, categoryID uuid.UUID) ([]domain.AttributeDefinition, error) {
+	seen := make(map[string]bool)
+	effective := []domain.AttributeDefinition{}
+	r.locked(func(s *store) {
+		for _, ancestorID := range s.ancestorIDs(categoryID) {
+			for _, def := range s.definitions {
+				if def.CategoryID == ancestorID && !seen[def.Key] {
+					seen[def.Key] = true
+					effective = append(effective, *copyDefinition(def))
+				}
+			}
+		}
+	})
+	slices.SortFunc(effective, func(a, b domain.AttributeDefinition) int { return strings.Compare(a.Key, b.Key) })
+	return effective, nil
+}
+
+func (r *ProductRepository) ReplaceProductAttributes(ctx context.Context, productID uuid.UUID, attributes []domain.ProductAttribute) error {
+	err := r.atomically(func(s *store) error {
+		return s.replaceAttributes(productID, attributes)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to replace product attributes: %w", err)
+	}
+	return nil
+}
+
+// replaceAttributes swaps the stored attributes of a product
+func (s *store) replaceAttributes(productID uuid.UUID, attributes []domain.ProductAttribute) error {
+	if len(attributes) == 0 {
+		delete(s.attributes, productID)
+		return nil
+	}
+	if _, ok := s.products[productID]; !ok {
+		return foreignKeyViolation("product_attributes", "product_attributes_product_id_fkey")
+	}
+
+	rows := make([]domain.ProductAttribute, 0, len(attributes))
+	for _, attribute := range attributes {
+		if slices.ContainsFunc(rows, func(row domain.ProductAttribute) bool { return row.Key == attribute.Key }) {
+			return uniqueViolation("product_attributes_pkey")
+		}
+		attribute.ProductID = productID
+		rows = append(rows, attribute)
+	}
+	slices.SortFunc(rows, func(a, b domain.ProductAttribute) int { return strings.Compare(a.Key, b.Key) })
+	s.attributes[productID] = rows
+	return nil
+}
@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/product/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+func (r *ProductRepository) CreateAuditEvent(ctx context.Context, event *domain.AuditEvent) error {
+	err := r.atomically(func(s *store) error {
+		if event.ID == uuid.Nil {
+			event.ID = uuid.New()
+		}
+		if event.CreatedAt.IsZero() {
+			event.CreatedAt = time.Now()
+		}
+		if _, ok := s.audit[event.ID]; ok {
+			return uniqueViolation("audit_events_pkey")
+		}
+		s.audit[event.ID] = *copyAuditEvent(*event)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create audit event: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) GetAuditEvent(ctx context.Context, id uuid.UUID) (*domain.AuditEvent, error) {
+	var event *domain.AuditEvent
+	r.locked(func(s *store) {
+		if found, ok := s.audit[id]; ok {
+			event = copyAuditEvent(found)
+		}
+	})
+	if event == nil {
+		return nil, notFound("Audit event not found", customErrors.CodeAuditEventNotFound)
+	}
+	return event, nil
+}
+
+func (r *ProductRepository) ListAuditEvents(ctx context.Context, filters *domain.AuditFilters) ([]domain.AuditEvent, int64, error) {
+	var events []domain.AuditEvent
+	r.locked(func(s *store) {
+		for _, event := range s.audit {
+			if filters.EntityType != "" && event.EntityType != filters.EntityType {
+				continue
+			}
+			if filters.EntityID != nil && event.EntityID != *filters.EntityID {
+				continue
+			}
+			if filters.ActorID != "" && event.ActorID != filters.ActorID {
+				continue
+			}
+			if filters.From != nil && event.CreatedAt.Before(*filters.From) {
+				continue
+			}
+			if filters.To != nil && !event.CreatedAt.Before(*filters.To) {
+				continue
+			}
+			events = append(events, *copyAuditEvent(event))
+		}
+	})
+	slices.SortFunc(events, func(a, b domain.AuditEvent) int { return b.CreatedAt.Compare(a.CreatedAt) })
+
+	start, end := page(len(events), filters.Offset, filters.Limit)
+	return events[start:end], int64(len(events)), nil
+}
+
+// copyAuditEvent copies an audit event and its changes
+func copyAuditEvent(event domain.AuditEvent) *domain.AuditEvent {
+	event.Changes = maps.Clone(event.Changes)
+	return &event
+}
@@ -0,0 +1,125 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/product/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+// saveBrand stores a brand, keeping names unique
+func (s *store) saveBrand(brand domain.Brand) error {
+	for _, other := range s.brands {
+		if other.ID != brand.ID && other.Name == brand.Name {
+			return uniqueViolation("brands_name_key")
+		}
+	}
+	s.brands[brand.ID] = brand
+	return nil
+}
+
+func (r *ProductRepository) CreateBrand(ctx context.Context, brand *domain.Brand) error {
+	err := r.atomically(func(s *store) error {
+		now := time.Now()
+		if brand.ID == uuid.Nil {
+			brand.ID = uuid.New()
+		}
+		if brand.CreatedAt.IsZero() {
+			brand.CreatedAt = now
+		}
+		if brand.UpdatedAt.IsZero() {
+			brand.UpdatedAt = now
+		}
+		// gorm writes the column default in place of a false is_active
+		brand.IsActive = true
+		if _, ok := s.brands[brand.ID]; ok {
+			return uniqueViolation("brands_pkey")
+		}
+		return s.saveBrand(*brand)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create brand: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) GetBrand(ctx context.Context, id uuid.UUID) (*domain.Brand, error) {
+	var brand *domain.Brand
+	r.locked(func(s *store) {
+		if found, ok := s.brands[id]; ok {
+			brand = &found
+		}
+	})
+	if brand == nil {
+		return nil, notFound("Brand not found", customErrors.CodeBrandNotFound)
+	}
+	return brand, nil
+}
+
+func (r *ProductRepository) GetBrandByName(ctx context.Context, name string) (*domain.Brand, error) {
+	var brand *domain.Brand
+	r.locked(func(s *store) {
+		for _, found := range s.brands {
+			if found.Name == name {
+				brand = &found
+			}
+		}
+	})
+	if brand == nil {
+		return nil, notFound("Brand not found", customErrors.CodeBrandNotFound)
+	}
+	return brand, nil
+}
+
+func (r *ProductRepository) UpdateBrand(ctx context.Context, brand *domain.Brand) error {
+	err := r.atomically(func(s *store) error {
+		now := time.Now()
+		brand.UpdatedAt = now
+		if brand.CreatedAt.IsZero() {
+			brand.CreatedAt = now
+		}
+		return s.saveBrand(*brand)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update brand: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) DeleteBrand(ctx context.Context, id uuid.UUID) error {
+	err := r.atomically(func(s *store) error {
+		for _, p := range s.products {
+			if p.BrandID != nil && *p.BrandID == id {
+				return referencedViolation("brands", "products_brand_id_fkey")
+			}
+		}
+		delete(s.brands, id)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete brand: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) ListBrands(ctx context.Context) ([]domain.Brand, error) {
+	var brands []domain.Brand
+	r.locked(func(s *store) {
+		for _, brand := range s.brands {
+			if brand.IsActive {
+				brands = append(brands, brand)
+			}
+		}
+	})
+	slices.SortFunc(brands, func(a, b domain.Brand) int {
+		return cmp.Or(strings.Compare(a.Name, b.Name), strings.Compare(a.ID.String(), b.ID.String()))
+	})
+	return brands, nil
+}
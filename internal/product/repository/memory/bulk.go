@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/product/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+// BulkUpdate applies prepared product changes and reports the outcome of
+// each. A change that fails is undone and the others still apply.
+func (r *ProductRepository) BulkUpdate(ctx context.Context, changes []domain.ProductChange) ([]error, error) {
+	results := make([]error, len(changes))
+	for i, change := range changes {
+		results[i] = r.atomically(func(s *store) error {
+			return s.applyChange(change)
+		})
+	}
+	return results, nil
+}
+
+// applyChange writes one prepared change
+func (s *store) applyChange(change domain.ProductChange) error {
+	if len(change.Columns) > 0 {
+		p, ok := s.live(change.ProductID)
+		if !ok {
+			return customErrors.NewNotFoundError("Product not found", nil).WithCode(customErrors.CodeProductNotFound)
+		}
+		for column, value := range change.Columns {
+			if err := setColumn(p, column, value); err != nil {
+				return fmt.Errorf("failed to update product: %w", err)
+			}
+		}
+		p.UpdatedAt = time.Now()
+		if err := s.checkProduct(p); err != nil {
+			return fmt.Errorf("failed to update product: %w", err)
+		}
+	}
+
+	if change.ReplaceAttributes {
+		if err := s.replaceAttributes(change.ProductID, change.Attributes); err != nil {
+			return fmt.Errorf("failed to replace product attributes: %w", err)
+		}
+	}
+	return nil
+}
+
+// setColumn sets the field of a product stored in a column, for the
+// columns bulk changes write
+func setColumn(p *domain.Product, column string, value interface{}) error {
+	var ok bool
+	switch column {
+	case "price":
+		p.Price, ok = value.(float64)
+	case "is_active":
+		p.IsActive, ok = value.(bool)
+	case "category_id":
+		p.CategoryID, ok = value.(uuid.UUID)
+	case "name":
+		p.Name, ok = value.(string)
+	case "description":
+		p.Description, ok = value.(string)
+	case "status":
+		p.Status, ok = value.(string)
+	default:
+		return fmt.Errorf("unsupported column %q", column)
+	}
+	if !ok {
+		return fmt.Errorf("unsupported value %v for column %q", value, column)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package memory
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/product/domain"
+)
+
+// ReplaceBundleComponents replaces the components of a bundle
+func (r *ProductRepository) ReplaceBundleComponents(ctx context.Context, bundleID uuid.UUID, components []domain.BundleComponent) error {
+	return r.atomically(func(s *store) error {
+		s.components = slices.DeleteFunc(s.components, func(component domain.BundleComponent) bool {
+			return component.BundleID == bundleID
+		})
+		for _, component := range components {
+			component.BundleID = bundleID
+			if _, ok := s.products[bundleID]; !ok {
+				return fmt.Errorf("failed to add bundle components: %w", foreignKeyViolation("bundle_components", "bundle_components_bundle_id_fkey"))
+			}
+			if _, ok := s.products[component.ComponentID]; !ok {
+				return fmt.Errorf("failed to add bundle components: %w", foreignKeyViolation("bundle_components", "bundle_components_component_id_fkey"))
+			}
+			if slices.ContainsFunc(s.components, func(other domain.BundleComponent) bool {
+				return other.BundleID == bundleID && other.ComponentID == component.ComponentID
+			}) {
+				return fmt.Errorf("failed to add bundle components: %w", uniqueViolation("bundle_components_pkey"))
+			}
+			s.components = append(s.components, domain.BundleComponent{
+				BundleID:    bundleID,
+				ComponentID: component.ComponentID,
+				Quantity:    component.Quantity,
+			})
+		}
+		return nil
+	})
+}
+
+// ListBundleComponents lists the components of bundles, in product order
+func (r *ProductRepository) ListBundleComponents(ctx context.Context, bundleIDs []uuid.UUID) ([]domain.BundleComponent, error) {
+	var components []domain.BundleComponent
+	r.locked(func(s *store) {
+		for _, component := range s.components {
+			if slices.Contains(bundleIDs, component.BundleID) {
+				components = append(components, component)
+			}
+		}
+	})
+	slices.SortFunc(components, func(a, b domain.BundleComponent) int {
+		return cmp.Or(bytes.Compare(a.BundleID[:], b.BundleID[:]), bytes.Compare(a.ComponentID[:], b.ComponentID[:]))
+	})
+	return components, nil
+}
+
+// RepriceBundles brings the prices of computed bundles up to date with
+// their components: the product itself when it is one, and the bundles it
+// is a component of. It returns the bundles whose price changed.
+func (r *ProductRepository) RepriceBundles(ctx context.Context, productID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	r.locked(func(s *store) {
+		now := time.Now()
+		for _, bundle := range s.products {
+			if bundle.DeletedAt.Valid || bundle.Type != domain.ProductTypeBundle || bundle.BundlePricing != domain.BundlePricingComputed {
+				continue
+			}
+
+			var total float64
+			contains := bundle.ID == productID
+			for _, component := range s.components {
+				if component.BundleID != bundle.ID {
+					continue
+				}
+				contains = contains || component.ComponentID == productID
+				if p, ok := s.products[component.ComponentID]; ok {
+					total += p.Price * float64(component.Quantity)
+				}
+			}
+			price := domain.BundlePrice(total, bundle.BundleDiscount)
+			if !contains || price <= 0 || price == bundle.Price {
+				continue
+			}
+			bundle.Price = price
+			bundle.UpdatedAt = now
+			ids = append(ids, bundle.ID)
+		}
+	})
+	return ids, nil
+}
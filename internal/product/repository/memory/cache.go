@@ -0,0 +1,26 @@
+package memory
+
+import (
+	"context"
+
+	"ecommerce/internal/product/domain"
+)
+
+// The memory repository caches nothing, so there is never anything to
+// inspect or drop
+
+func (r *ProductRepository) InvalidateProductCache(ctx context.Context) error {
+	return nil
+}
+
+func (r *ProductRepository) InspectCacheKey(ctx context.Context, key string) (*domain.CacheEntry, error) {
+	return &domain.CacheEntry{Key: key, Namespace: domain.CacheNamespace(key)}, nil
+}
+
+func (r *ProductRepository) ListCacheKeys(ctx context.Context, pattern string, limit int) ([]string, bool, error) {
+	return []string{}, false, nil
+}
+
+func (r *ProductRepository) PurgeCacheKeys(ctx context.Context, keys []string, pattern string) (int64, error) {
+	return 0, nil
+}
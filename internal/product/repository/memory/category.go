@@ -0,0 +1,391 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"ecommerce/internal/product/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+// maxCategoryDepth bounds walks of the category tree in case of corrupt data
+const maxCategoryDepth = 100
+
+// copyCategory copies a category without its parent and children
+func copyCategory(category domain.Category) *domain.Category {
+	category.ParentID = clone(category.ParentID)
+	category.Parent = nil
+	category.Children = nil
+	category.Breadcrumbs = nil
+	category.ProductCount = nil
+	category.TotalProductCount = nil
+	return &category
+}
+
+// loadCategory copies a category out with its parent and children
+func (s *store) loadCategory(category domain.Category) *domain.Category {
+	loaded := copyCategory(category)
+	if category.ParentID != nil {
+		if parent, ok := s.categories[*category.ParentID]; ok {
+			loaded.Parent = copyCategory(parent)
+		}
+	}
+	for _, child := range s.children(category.ID, false) {
+		loaded.Children = append(loaded.Children, *copyCategory(child))
+	}
+	return loaded
+}
+
+// children returns the subcategories of a category by name, optionally
+// only the active ones
+func (s *store) children(id uuid.UUID, active bool) []domain.Category {
+	var children []domain.Category
+	for _, category := range s.categories {
+		if category.ParentID != nil && *category.ParentID == id && (category.IsActive || !active) {
+			children = append(children, category)
+		}
+	}
+	slices.SortFunc(children, compareCategories)
+	return children
+}
+
+// compareCategories orders categories by name
+func compareCategories(a, b domain.Category) int {
+	return cmp.Or(strings.Compare(a.Name, b.Name), strings.Compare(a.ID.String(), b.ID.String()))
+}
+
+// saveCategory stores a category, enforcing its constraints
+func (s *store) saveCategory(category domain.Category) error {
+	for _, other := range s.categories {
+		if other.ID == category.ID {
+			continue
+		}
+		if other.Name == category.Name {
+			return uniqueViolation("categories_name_key")
+		}
+		if category.Slug != "" && other.Slug == category.Slug {
+			return uniqueViolation("idx_categories_slug")
+		}
+	}
+	if category.ParentID != nil {
+		if _, ok := s.categories[*category.ParentID]; !ok {
+			return foreignKeyViolation("categories", "categories_parent_id_fkey")
+		}
+	}
+	s.categories[category.ID] = *copyCategory(category)
+	return nil
+}
+
+func (r *ProductRepository) CreateCategory(ctx context.Context, category *domain.Category) error {
+	err := r.atomically(func(s *store) error {
+		now := time.Now()
+		if category.ID == uuid.Nil {
+			category.ID = uuid.New()
+		}
+		if category.CreatedAt.IsZero() {
+			category.CreatedAt = now
+		}
+		if category.UpdatedAt.IsZero() {
+			category.UpdatedAt = now
+		}
+		// gorm writes the column default in place of a false is_active
+		category.IsActive = true
+		if _, ok := s.categories[category.ID]; ok {
+			return uniqueViolation("categories_pkey")
+		}
+		return s.saveCategory(*category)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create category: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) GetCategory(ctx context.Context, id uuid.UUID) (*domain.Category, error) {
+	var category *domain.Category
+	r.locked(func(s *store) {
+		if found, ok := s.categories[id]; ok {
+			category = s.loadCategory(found)
+		}
+	})
+	if category == nil {
+		return nil, notFound("Category not found", customErrors.CodeCategoryNotFound)
+	}
+	return category, nil
+}
+
+func (r *ProductRepository) GetCategoryByName(ctx context.Context, name string) (*domain.Category, error) {
+	var category *domain.Category
+	r.locked(func(s *store) {
+		for _, found := range s.categories {
+			if found.Name == name {
+				category = copyCategory(found)
+			}
+		}
+	})
+	if category == nil {
+		return nil, notFound("Category not found", customErrors.CodeCategoryNotFound)
+	}
+	return category, nil
+}
+
+func (r *ProductRepository) UpdateCategory(ctx context.Context, category *domain.Category) error {
+	err := r.atomically(func(s *store) error {
+		now := time.Now()
+		category.UpdatedAt = now
+		if category.CreatedAt.IsZero() {
+			category.CreatedAt = now
+		}
+		return s.saveCategory(*category)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update category: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) DeleteCategory(ctx context.Context, id uuid.UUID) error {
+	err := r.atomically(func(s *store) error {
+		for _, p := range s.products {
+			if p.CategoryID == id {
+				return referencedViolation("categories", "products_category_id_fkey")
+			}
+		}
+		if len(s.children(id, false)) > 0 {
+			return referencedViolation("categories", "categories_parent_id_fkey")
+		}
+		s.deleteCategory(id)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+	return nil
+}
+
+// deleteCategory removes a category along with the rows that cascade with it
+func (s *store) deleteCategory(id uuid.UUID) {
+	delete(s.categories, id)
+	for defID, def := range s.definitions {
+		if def.CategoryID == id {
+			delete(s.definitions, defID)
+		}
+	}
+	for key := range s.categoryTranslations {
+		if key.id == id {
+			delete(s.categoryTranslations, key)
+		}
+	}
+}
+
+func (r *ProductRepository) ListCategories(ctx context.Context) ([]domain.Category, error) {
+	var categories []domain.Category
+	r.locked(func(s *store) {
+		for _, category := range s.categories {
+			if category.IsActive {
+				categories = append(categories, *s.loadCategory(category))
+			}
+		}
+	})
+	slices.SortFunc(categories, compareCategories)
+	return categories, nil
+}
+
+// ListCategorySubtree returns the active categories beneath a root, or the
+// whole active tree when rootID is nil, ordered by depth and then name. A
+// category whose parent is inactive is left out with its subtree.
+func (r *ProductRepository) ListCategorySubtree(ctx context.Context, rootID *uuid.UUID) ([]domain.Category, error) {
+	var categories []domain.Category
+	r.locked(func(s *store) {
+		var level []domain.Category
+		for _, category := range s.categories {
+			if !category.IsActive {
+				continue
+			}
+			if (rootID == nil && category.ParentID == nil) || (rootID != nil && category.ID == *rootID) {
+				level = append(level, category)
+			}
+		}
+		slices.SortFunc(level, compareCategories)
+
+		for depth := 0; len(level) > 0 && depth <= maxCategoryDepth; depth++ {
+			var next []domain.Category
+			for _, category := range level {
+				categories = append(categories, *copyCategory(category))
+				next = append(next, s.children(category.ID, true)...)
+			}
+			slices.SortFunc(next, compareCategories)
+			level = next
+		}
+	})
+	return categories, nil
+}
+
+func (r *ProductRepository) GetCategoryAncestorIDs(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	r.locked(func(s *store) {
+		ids = s.ancestorIDs(id)
+	})
+	return ids, nil
+}
+
+// ancestorIDs returns a category's ID followed by those of its ancestors,
+// nearest first, or nothing when the category does not exist
+func (s *store) ancestorIDs(id uuid.UUID) []uuid.UUID {
+	var ids []uuid.UUID
+	for depth := 0; depth <= maxCategoryDepth; depth++ {
+		category, ok := s.categories[id]
+		if !ok {
+			break
+		}
+		ids = append(ids, id)
+		if category.ParentID == nil {
+			break
+		}
+		id = *category.ParentID
+	}
+	return ids
+}
+
+// GetCategoryPaths returns the breadcrumbs of each of the given categories,
+// from the top level down to the category itself
+func (r *ProductRepository) GetCategoryPaths(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]domain.Breadcrumb, error) {
+	paths := make(map[uuid.UUID][]domain.Breadcrumb, len(ids))
+	r.locked(func(s *store) {
+		for _, id := range ids {
+			ancestors := s.ancestorIDs(id)
+			for i := len(ancestors) - 1; i >= 0; i-- {
+				category := s.categories[ancestors[i]]
+				paths[id] = append(paths[id], domain.Breadcrumb{
+					ID:   category.ID,
+					Name: category.Name,
+					Slug: category.Slug,
+				})
+			}
+		}
+	})
+	return paths, nil
+}
+
+// CategoryProductCounts counts the live, active, published products of
+// every category that has any, directly and including subcategories
+func (r *ProductRepository) CategoryProductCounts(ctx context.Context) (map[uuid.UUID]domain.CategoryProductCount, error) {
+	counts := make(map[uuid.UUID]domain.CategoryProductCount)
+	r.locked(func(s *store) {
+		for _, p := range s.products {
+			if p.DeletedAt.Valid || !p.IsActive || p.Status != domain.ProductStatusPublished {
+				continue
+			}
+			for i, id := range s.ancestorIDs(p.CategoryID) {
+				count := counts[id]
+				if i == 0 {
+					count.Direct++
+				}
+				count.Total++
+				counts[id] = count
+			}
+		}
+	})
+	return counts, nil
+}
+
+// MoveCategory moves a category, and with it its whole subtree, under a new
+// parent, or to the top level when parentID is nil
+func (r *ProductRepository) MoveCategory(ctx context.Context, id uuid.UUID, parentID *uuid.UUID) error {
+	return r.atomically(func(s *store) error {
+		if parentID != nil {
+			if slices.Contains(s.ancestorIDs(*parentID), id) {
+				return customErrors.NewValidationError("Parent assignment would create a category cycle", nil)
+			}
+		}
+
+		category, ok := s.categories[id]
+		if !ok {
+			return customErrors.NewNotFoundError("Category not found", nil).WithCode(customErrors.CodeCategoryNotFound)
+		}
+		if parentID != nil {
+			if _, ok := s.categories[*parentID]; !ok {
+				return fmt.Errorf("failed to move category: %w", foreignKeyViolation("categories", "categories_parent_id_fkey"))
+			}
+		}
+		category.ParentID = clone(parentID)
+		s.categories[id] = category
+		return nil
+	})
+}
+
+// MergeCategory folds the source category into the target and deletes it.
+// Products, subcategories and slug redirects move to the target, along with
+// attribute definitions the target does not define itself; the source's
+// slug redirects to the target. It returns the IDs of the moved products.
+func (r *ProductRepository) MergeCategory(ctx context.Context, sourceID, targetID uuid.UUID) ([]uuid.UUID, error) {
+	var moved []uuid.UUID
+	err := r.atomically(func(s *store) error {
+		source, ok := s.categories[sourceID]
+		if !ok {
+			return fmt.Errorf("failed to get category: %w", gorm.ErrRecordNotFound)
+		}
+
+		// The source's children move to the target, so the target must not
+		// sit beneath the source
+		if slices.Contains(s.ancestorIDs(targetID), sourceID) {
+			return customErrors.NewValidationError("Cannot merge a category into one of its subcategories", nil)
+		}
+		if _, ok := s.categories[targetID]; !ok {
+			return fmt.Errorf("failed to move products: %w", foreignKeyViolation("products", "products_category_id_fkey"))
+		}
+
+		now := time.Now()
+		for _, p := range s.products {
+			if p.CategoryID == sourceID {
+				p.CategoryID = targetID
+				p.UpdatedAt = now
+				moved = append(moved, p.ID)
+			}
+		}
+
+		for id, category := range s.categories {
+			if category.ParentID != nil && *category.ParentID == sourceID {
+				category.ParentID = &targetID
+				s.categories[id] = category
+			}
+		}
+
+		// Definitions the target already has win; the rest go with the
+		// source when it is deleted
+		targetKeys := make(map[string]bool)
+		for _, def := range s.definitions {
+			if def.CategoryID == targetID {
+				targetKeys[def.Key] = true
+			}
+		}
+		for id, def := range s.definitions {
+			if def.CategoryID == sourceID && !targetKeys[def.Key] {
+				def.CategoryID = targetID
+				def.UpdatedAt = now
+				s.definitions[id] = def
+			}
+		}
+
+		for i, redirect := range s.redirects {
+			if redirect.EntityType == domain.AuditEntityCategory && redirect.EntityID == sourceID {
+				s.redirects[i].EntityID = targetID
+			}
+		}
+
+		s.deleteCategory(sourceID)
+		s.redirect(domain.AuditEntityCategory, targetID, source.Slug, now)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return moved, nil
+}
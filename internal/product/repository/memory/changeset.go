@@ -0,0 +1,221 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/product/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+// copyChangesetItem copies an item so the store shares no memory with it
+func copyChangesetItem(item domain.ChangesetItem) domain.ChangesetItem {
+	item.Patch = slices.Clone(item.Patch)
+	item.Revert = slices.Clone(item.Revert)
+	item.Changes, item.Problem = nil, ""
+	return item
+}
+
+func (r *ProductRepository) CreateChangeset(ctx context.Context, changeset *domain.Changeset) error {
+	err := r.atomically(func(s *store) error {
+		now := time.Now()
+		if changeset.ID == uuid.Nil {
+			changeset.ID = uuid.New()
+		}
+		if changeset.CreatedAt.IsZero() {
+			changeset.CreatedAt = now
+		}
+		if changeset.UpdatedAt.IsZero() {
+			changeset.UpdatedAt = now
+		}
+		if changeset.Status == "" {
+			changeset.Status = domain.ChangesetStatusDraft
+		}
+		if _, ok := s.changesets[changeset.ID]; ok {
+			return uniqueViolation("changesets_pkey")
+		}
+		stored := *changeset
+		stored.Items = nil
+		s.changesets[changeset.ID] = stored
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create changeset: %w", err)
+	}
+	return nil
+}
+
+// GetChangeset returns a changeset with its items, oldest first
+func (r *ProductRepository) GetChangeset(ctx context.Context, id uuid.UUID) (*domain.Changeset, error) {
+	var changeset *domain.Changeset
+	r.locked(func(s *store) {
+		found, ok := s.changesets[id]
+		if !ok {
+			return
+		}
+		for _, item := range s.changesetItems {
+			if item.ChangesetID == id {
+				found.Items = append(found.Items, copyChangesetItem(item))
+			}
+		}
+		changeset = &found
+	})
+	if changeset == nil {
+		return nil, notFound("Changeset not found", customErrors.CodeChangesetNotFound)
+	}
+
+	slices.SortFunc(changeset.Items, func(a, b domain.ChangesetItem) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), slices.Compare(a.ID[:], b.ID[:]))
+	})
+	return changeset, nil
+}
+
+// UpdateChangeset saves a changeset, leaving its items alone
+func (r *ProductRepository) UpdateChangeset(ctx context.Context, changeset *domain.Changeset) error {
+	r.locked(func(s *store) {
+		now := time.Now()
+		changeset.UpdatedAt = now
+		if changeset.CreatedAt.IsZero() {
+			changeset.CreatedAt = now
+		}
+		stored := *changeset
+		stored.Items = nil
+		s.changesets[changeset.ID] = stored
+	})
+	return nil
+}
+
+// TransitionChangeset saves a changeset's status and the times and error
+// that go with it, provided it is still in one of the from statuses
+func (r *ProductRepository) TransitionChangeset(ctx context.Context, changeset *domain.Changeset, from ...string) (bool, error) {
+	var moved bool
+	r.locked(func(s *store) {
+		stored, ok := s.changesets[changeset.ID]
+		if !ok || !slices.Contains(from, stored.Status) {
+			return
+		}
+		changeset.UpdatedAt = time.Now()
+		stored.Status = changeset.Status
+		stored.ApplyAt = changeset.ApplyAt
+		stored.AppliedAt = changeset.AppliedAt
+		stored.RolledBackAt = changeset.RolledBackAt
+		stored.Error = changeset.Error
+		stored.UpdatedAt = changeset.UpdatedAt
+		s.changesets[changeset.ID] = stored
+		moved = true
+	})
+	return moved, nil
+}
+
+func (r *ProductRepository) DeleteChangeset(ctx context.Context, id uuid.UUID) error {
+	var deleted bool
+	r.locked(func(s *store) {
+		if _, deleted = s.changesets[id]; !deleted {
+			return
+		}
+		delete(s.changesets, id)
+		for itemID, item := range s.changesetItems {
+			if item.ChangesetID == id {
+				delete(s.changesetItems, itemID)
+			}
+		}
+	})
+	if !deleted {
+		return notFound("Changeset not found", customErrors.CodeChangesetNotFound)
+	}
+	return nil
+}
+
+// ListChangesets lists changesets without their items, newest first
+func (r *ProductRepository) ListChangesets(ctx context.Context, filters *domain.ChangesetFilters) ([]domain.Changeset, int64, error) {
+	var changesets []domain.Changeset
+	r.locked(func(s *store) {
+		for _, changeset := range s.changesets {
+			if filters.Status != "" && changeset.Status != filters.Status {
+				continue
+			}
+			changesets = append(changesets, changeset)
+		}
+	})
+	slices.SortFunc(changesets, func(a, b domain.Changeset) int { return b.CreatedAt.Compare(a.CreatedAt) })
+
+	start, end := page(len(changesets), filters.Offset, filters.Limit)
+	return changesets[start:end], int64(len(changesets)), nil
+}
+
+// ListDueChangesets lists the scheduled changesets whose time to apply has
+// come, without their items, earliest first
+func (r *ProductRepository) ListDueChangesets(ctx context.Context, limit int) ([]domain.Changeset, error) {
+	now := time.Now()
+
+	var changesets []domain.Changeset
+	r.locked(func(s *store) {
+		for _, changeset := range s.changesets {
+			if changeset.Status == domain.ChangesetStatusScheduled && changeset.ApplyAt != nil && !changeset.ApplyAt.After(now) {
+				changesets = append(changesets, changeset)
+			}
+		}
+	})
+	slices.SortFunc(changesets, func(a, b domain.Changeset) int { return a.ApplyAt.Compare(*b.ApplyAt) })
+
+	_, end := page(len(changesets), 0, limit)
+	return changesets[:end], nil
+}
+
+// SaveChangesetItem sets the edit a changeset makes to a product,
+// replacing any it had
+func (r *ProductRepository) SaveChangesetItem(ctx context.Context, item *domain.ChangesetItem) error {
+	err := r.atomically(func(s *store) error {
+		now := time.Now()
+		for id, existing := range s.changesetItems {
+			if existing.ChangesetID == item.ChangesetID && existing.ProductID == item.ProductID {
+				existing.Patch = item.Patch
+				existing.Revert = item.Revert
+				existing.UpdatedAt = now
+				s.changesetItems[id] = copyChangesetItem(existing)
+				item.ID, item.CreatedAt, item.UpdatedAt = existing.ID, existing.CreatedAt, now
+				return nil
+			}
+		}
+
+		if _, ok := s.changesets[item.ChangesetID]; !ok {
+			return foreignKeyViolation("changeset_items", "changeset_items_changeset_id_fkey")
+		}
+		if _, ok := s.products[item.ProductID]; !ok {
+			return foreignKeyViolation("changeset_items", "changeset_items_product_id_fkey")
+		}
+		if item.ID == uuid.Nil {
+			item.ID = uuid.New()
+		}
+		if item.CreatedAt.IsZero() {
+			item.CreatedAt = now
+		}
+		if item.UpdatedAt.IsZero() {
+			item.UpdatedAt = now
+		}
+		s.changesetItems[item.ID] = copyChangesetItem(*item)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save changeset item: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) DeleteChangesetItem(ctx context.Context, changesetID, productID uuid.UUID) (bool, error) {
+	var deleted bool
+	r.locked(func(s *store) {
+		for id, item := range s.changesetItems {
+			if item.ChangesetID == changesetID && item.ProductID == productID {
+				delete(s.changesetItems, id)
+				deleted = true
+			}
+		}
+	})
+	return deleted, nil
+}
@@ -0,0 +1,148 @@
+package memory
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/product/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+func (r *ProductRepository) CreateDigitalAsset(ctx context.Context, asset *domain.DigitalAsset) error {
+	err := r.atomically(func(s *store) error {
+		if asset.ID == uuid.Nil {
+			asset.ID = uuid.New()
+		}
+		if asset.CreatedAt.IsZero() {
+			asset.CreatedAt = time.Now()
+		}
+		if _, ok := s.assets[asset.ID]; ok {
+			return uniqueViolation("digital_assets_pkey")
+		}
+		if _, ok := s.products[asset.ProductID]; !ok {
+			return foreignKeyViolation("digital_assets", "digital_assets_product_id_fkey")
+		}
+		s.assets[asset.ID] = *asset
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create digital asset: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) GetDigitalAsset(ctx context.Context, id uuid.UUID) (*domain.DigitalAsset, error) {
+	var asset *domain.DigitalAsset
+	r.locked(func(s *store) {
+		if found, ok := s.assets[id]; ok {
+			asset = &found
+		}
+	})
+	if asset == nil {
+		return nil, notFound("Digital asset not found", customErrors.CodeDigitalAssetNotFound)
+	}
+	return asset, nil
+}
+
+func (r *ProductRepository) DeleteDigitalAsset(ctx context.Context, id uuid.UUID) error {
+	r.locked(func(s *store) {
+		delete(s.assets, id)
+	})
+	return nil
+}
+
+// ListDigitalAssets lists a product's downloadable files, oldest first
+func (r *ProductRepository) ListDigitalAssets(ctx context.Context, productID uuid.UUID) ([]domain.DigitalAsset, error) {
+	var assets []domain.DigitalAsset
+	r.locked(func(s *store) {
+		for _, asset := range s.assets {
+			if asset.ProductID == productID {
+				assets = append(assets, asset)
+			}
+		}
+	})
+	slices.SortFunc(assets, func(a, b domain.DigitalAsset) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), bytes.Compare(a.ID[:], b.ID[:]))
+	})
+	return assets, nil
+}
+
+// GrantEntitlements records entitlements and returns those that were new.
+// An order already entitled to a product keeps the entitlement it has, so
+// granting is safe to repeat.
+func (r *ProductRepository) GrantEntitlements(ctx context.Context, entitlements []domain.Entitlement) ([]domain.Entitlement, error) {
+	var granted []domain.Entitlement
+	err := r.atomically(func(s *store) error {
+		now := time.Now()
+		for _, entitlement := range entitlements {
+			if slices.ContainsFunc(s.entitlements, func(other domain.Entitlement) bool {
+				return other.OrderID == entitlement.OrderID && other.ProductID == entitlement.ProductID
+			}) {
+				continue
+			}
+			if slices.ContainsFunc(s.entitlements, func(other domain.Entitlement) bool {
+				return other.LicenseKey == entitlement.LicenseKey
+			}) {
+				return fmt.Errorf("failed to grant entitlement: %w", uniqueViolation("entitlements_license_key_key"))
+			}
+			if _, ok := s.products[entitlement.ProductID]; !ok {
+				return fmt.Errorf("failed to grant entitlement: %w", foreignKeyViolation("entitlements", "entitlements_product_id_fkey"))
+			}
+
+			entitlement.ID = uuid.New()
+			entitlement.CreatedAt = now
+			entitlement.Downloads = nil
+			s.entitlements = append(s.entitlements, entitlement)
+			granted = append(granted, entitlement)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return granted, nil
+}
+
+func (r *ProductRepository) GetEntitlement(ctx context.Context, id uuid.UUID) (*domain.Entitlement, error) {
+	var entitlement *domain.Entitlement
+	r.locked(func(s *store) {
+		for _, found := range s.entitlements {
+			if found.ID == id {
+				entitlement = &found
+				return
+			}
+		}
+	})
+	if entitlement == nil {
+		return nil, notFound("Entitlement not found", customErrors.CodeEntitlementNotFound)
+	}
+	return entitlement, nil
+}
+
+// ListEntitlements lists entitlements, newest first
+func (r *ProductRepository) ListEntitlements(ctx context.Context, filters *domain.EntitlementFilters) ([]domain.Entitlement, int64, error) {
+	var entitlements []domain.Entitlement
+	r.locked(func(s *store) {
+		for _, entitlement := range s.entitlements {
+			if filters.CustomerID != "" && entitlement.CustomerID != filters.CustomerID {
+				continue
+			}
+			if filters.OrderID != nil && entitlement.OrderID != *filters.OrderID {
+				continue
+			}
+			entitlements = append(entitlements, entitlement)
+		}
+	})
+	slices.SortFunc(entitlements, func(a, b domain.Entitlement) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), bytes.Compare(b.ID[:], a.ID[:]))
+	})
+
+	start, end := page(len(entitlements), filters.Offset, filters.Limit)
+	return entitlements[start:end], int64(len(entitlements)), nil
+}
@@ -0,0 +1,193 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/product/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+func (r *ProductRepository) CreateFlashSale(ctx context.Context, sale *domain.FlashSale) error {
+	err := r.atomically(func(s *store) error {
+		if _, ok := s.products[sale.ProductID]; !ok {
+			return foreignKeyViolation("flash_sales", "flash_sales_product_id_fkey")
+		}
+		for _, existing := range s.flashSales {
+			if existing.ProductID == sale.ProductID && existing.ClosedAt == nil {
+				return customErrors.NewConflictError("Product already has a flash sale", uniqueViolation("idx_flash_sales_open_product")).WithCode(customErrors.CodeFlashSaleExists)
+			}
+		}
+
+		now := time.Now()
+		if sale.ID == uuid.Nil {
+			sale.ID = uuid.New()
+		}
+		if sale.CreatedAt.IsZero() {
+			sale.CreatedAt = now
+		}
+		if sale.UpdatedAt.IsZero() {
+			sale.UpdatedAt = now
+		}
+		stored := *sale
+		stored.Remaining = 0
+		s.flashSales[sale.ID] = stored
+		return nil
+	})
+	if err != nil {
+		if customErrors.IsConflict(err) {
+			return err
+		}
+		return fmt.Errorf("failed to create flash sale: %w", err)
+	}
+	return nil
+}
+
+// GetFlashSale returns a product's open flash sale
+func (r *ProductRepository) GetFlashSale(ctx context.Context, productID uuid.UUID) (*domain.FlashSale, error) {
+	var sale *domain.FlashSale
+	r.locked(func(s *store) {
+		for _, found := range s.flashSales {
+			if found.ProductID == productID && found.ClosedAt == nil {
+				sale = &found
+				return
+			}
+		}
+	})
+	if sale == nil {
+		return nil, notFound("Flash sale not found", customErrors.CodeFlashSaleNotFound)
+	}
+	return sale, nil
+}
+
+func (r *ProductRepository) UpdateFlashSale(ctx context.Context, sale *domain.FlashSale) error {
+	r.locked(func(s *store) {
+		now := time.Now()
+		sale.UpdatedAt = now
+		if sale.CreatedAt.IsZero() {
+			sale.CreatedAt = now
+		}
+		stored := *sale
+		stored.Remaining = 0
+		s.flashSales[sale.ID] = stored
+	})
+	return nil
+}
+
+func (r *ProductRepository) DeleteFlashSale(ctx context.Context, id uuid.UUID) error {
+	var deleted bool
+	r.locked(func(s *store) {
+		if _, deleted = s.flashSales[id]; !deleted {
+			return
+		}
+		delete(s.flashSales, id)
+		for purchaseID, purchase := range s.flashSalePurchases {
+			if purchase.FlashSaleID == id {
+				delete(s.flashSalePurchases, purchaseID)
+			}
+		}
+	})
+	if !deleted {
+		return notFound("Flash sale not found", customErrors.CodeFlashSaleNotFound)
+	}
+	return nil
+}
+
+// CloseFlashSale marks a flash sale closed, provided it is still open
+func (r *ProductRepository) CloseFlashSale(ctx context.Context, sale *domain.FlashSale) (bool, error) {
+	var closed bool
+	r.locked(func(s *store) {
+		stored, ok := s.flashSales[sale.ID]
+		if !ok || stored.ClosedAt != nil {
+			return
+		}
+		now := time.Now()
+		stored.ClosedAt, stored.UpdatedAt = &now, now
+		s.flashSales[sale.ID] = stored
+		sale.ClosedAt, sale.UpdatedAt = &now, now
+		closed = true
+	})
+	return closed, nil
+}
+
+// ListOpenFlashSales lists the flash sales that have started and are not
+// yet closed, earliest first
+func (r *ProductRepository) ListOpenFlashSales(ctx context.Context, limit int) ([]domain.FlashSale, error) {
+	now := time.Now()
+
+	var sales []domain.FlashSale
+	r.locked(func(s *store) {
+		for _, sale := range s.flashSales {
+			if sale.ClosedAt == nil && sale.Started(now) {
+				sales = append(sales, sale)
+			}
+		}
+	})
+	slices.SortFunc(sales, func(a, b domain.FlashSale) int { return a.StartsAt.Compare(b.StartsAt) })
+
+	_, end := page(len(sales), 0, limit)
+	return sales[:end], nil
+}
+
+// RecordFlashSalePurchases writes purchases of a flash sale and adds them to
+// what it sold. Purchases already written are skipped; the units newly
+// written are returned.
+func (r *ProductRepository) RecordFlashSalePurchases(ctx context.Context, saleID uuid.UUID, purchases []domain.FlashSalePurchase) (int, error) {
+	if len(purchases) == 0 {
+		return 0, nil
+	}
+
+	var recorded int
+	err := r.atomically(func(s *store) error {
+		sale, ok := s.flashSales[saleID]
+		if !ok {
+			return notFound("Flash sale not found", customErrors.CodeFlashSaleNotFound)
+		}
+
+		now := time.Now()
+		for _, purchase := range purchases {
+			if _, ok := s.flashSalePurchases[purchase.ID]; ok {
+				continue
+			}
+			if _, ok := s.products[purchase.ProductID]; !ok {
+				return foreignKeyViolation("flash_sale_purchases", "flash_sale_purchases_product_id_fkey")
+			}
+			purchase.FlashSaleID = saleID
+			if purchase.CreatedAt.IsZero() {
+				purchase.CreatedAt = now
+			}
+			s.flashSalePurchases[purchase.ID] = purchase
+			recorded += purchase.Quantity
+		}
+
+		sale.Sold += recorded
+		sale.UpdatedAt = now
+		s.flashSales[saleID] = sale
+		return nil
+	})
+	if err != nil {
+		if customErrors.IsNotFound(err) {
+			return 0, err
+		}
+		return 0, fmt.Errorf("failed to record flash sale purchases: %w", err)
+	}
+	return recorded, nil
+}
+
+// FlashSaleBuyers returns the units each customer bought of a flash sale,
+// as written so far
+func (r *ProductRepository) FlashSaleBuyers(ctx context.Context, saleID uuid.UUID) (map[string]int, error) {
+	buyers := make(map[string]int)
+	r.locked(func(s *store) {
+		for _, purchase := range s.flashSalePurchases {
+			if purchase.FlashSaleID == saleID {
+				buyers[purchase.UserID] += purchase.Quantity
+			}
+		}
+	})
+	return buyers, nil
+}
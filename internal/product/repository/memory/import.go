@@ -0,0 +1,245 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/product/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+// UpsertBatch creates or overwrites products by SKU, ignoring case. Stock that changes as
+// a result is recorded in the stock ledger with the given movement.
+func (r *ProductRepository) UpsertBatch(ctx context.Context, products []domain.Product, movement domain.StockMovement) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	err := r.atomically(func(s *store) error {
+		// New rows need unique slugs; existing SKUs keep theirs
+		reserved := make(map[string]bool, len(products))
+		for idx := range products {
+			if products[idx].Slug != "" {
+				continue
+			}
+			slug, err := s.uniqueSlug(domain.AuditEntityProduct, domain.Slugify(products[idx].Name), uuid.Nil, reserved)
+			if err != nil {
+				return err
+			}
+			products[idx].Slug = slug
+			reserved[slug] = true
+		}
+
+		now := time.Now()
+		for idx := range products {
+			product := &products[idx]
+			previous := 0
+			if existing, ok := s.liveBySKU(product.SKU); ok {
+				previous = existing.Stock
+				existing.Name = product.Name
+				existing.Description = product.Description
+				existing.DescriptionText = product.DescriptionText
+				existing.DescriptionText = product.DescriptionText
+				existing.Price = product.Price
+				existing.CategoryID = product.CategoryID
+				existing.BrandID = clone(product.BrandID)
+				existing.Stock = product.Stock
+				existing.ImageURL = product.ImageURL
+				existing.GTIN = product.GTIN
+				existing.IsActive = product.IsActive
+				existing.UpdatedAt = now
+				if err := s.checkProduct(existing); err != nil {
+					return err
+				}
+				product.ID = existing.ID
+			} else if err := s.insertProduct(product, now); err != nil {
+				return err
+			}
+
+			if delta := product.Stock - previous; delta != 0 {
+				if _, err := s.record(ledgerEntry(movement, product.ID, delta, product.Stock), now); err != nil {
+					return err
+				}
+			}
+		}
+
+		// Rows that carry attributes replace whatever the product had before
+		for _, product := range products {
+			if product.Attributes == nil {
+				continue
+			}
+			if err := s.replaceAttributes(product.ID, product.Attributes); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upsert products: %w", err)
+	}
+	return nil
+}
+
+// UpsertBySKU creates a product, or overwrites the live product with its SKU
+// in any case, and reports whether it was created. The product is updated
+// with the row as stored. Stock that changes as a result is recorded in the
+// stock ledger with the given movement, and the product's attributes
+// replace those it had before.
+func (r *ProductRepository) UpsertBySKU(ctx context.Context, product *domain.Product, movement domain.StockMovement) (bool, error) {
+	var created bool
+	err := r.atomically(func(s *store) error {
+		now := time.Now()
+		attributes := product.Attributes
+
+		previous := 0
+		existing, ok := s.liveBySKU(product.SKU)
+		if ok {
+			previous = existing.Stock
+			// A product keeps the time it was first published
+			if existing.Status != domain.ProductStatusPublished && product.PublishedAt != nil {
+				existing.PublishedAt = clone(product.PublishedAt)
+			}
+			existing.Name = product.Name
+			existing.Description = product.Description
+			existing.Price = product.Price
+			existing.CategoryID = product.CategoryID
+			existing.BrandID = clone(product.BrandID)
+			existing.Stock = product.Stock
+			existing.ImageURL = product.ImageURL
+			existing.GTIN = product.GTIN
+			// gorm leaves an empty type to the column default
+			existing.Type = cmp.Or(product.Type, domain.ProductTypePhysical)
+			existing.Status = product.Status
+			existing.PublishAt = clone(product.PublishAt)
+			existing.LowStockThreshold = clone(product.LowStockThreshold)
+			existing.SalePrice = clone(product.SalePrice)
+			existing.SaleStartsAt = clone(product.SaleStartsAt)
+			existing.SaleEndsAt = clone(product.SaleEndsAt)
+			existing.AllowBackorder = product.AllowBackorder
+			existing.Preorder = product.Preorder
+			existing.ReleaseDate = clone(product.ReleaseDate)
+			existing.BundlePricing = product.BundlePricing
+			existing.BundleDiscount = product.BundleDiscount
+			// gorm leaves these to the column defaults too
+			existing.UnitOfMeasure = cmp.Or(product.UnitOfMeasure, domain.UnitEach)
+			existing.QuantityIncrement = cmp.Or(product.QuantityIncrement, 1)
+			existing.TaxClass = cmp.Or(product.TaxClass, domain.TaxClassStandard)
+			existing.MinOrderQuantity = product.MinOrderQuantity
+			existing.MaxOrderQuantity = product.MaxOrderQuantity
+			existing.UpdatedAt = now
+			if err := s.checkProduct(existing); err != nil {
+				return err
+			}
+		} else {
+			if err := s.insertProduct(product, now); err != nil {
+				return err
+			}
+			existing = s.products[product.ID]
+			created = true
+		}
+		*product = *copyProduct(existing)
+		product.Attributes = attributes
+
+		if delta := product.Stock - previous; delta != 0 {
+			if _, err := s.record(ledgerEntry(movement, product.ID, delta, product.Stock), now); err != nil {
+				return err
+			}
+		}
+		return s.replaceAttributes(product.ID, attributes)
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to upsert product: %w", err)
+	}
+	return created, nil
+}
+
+// ExistingSKUs reports which of the given SKUs belong to live products,
+// ignoring case
+func (r *ProductRepository) ExistingSKUs(ctx context.Context, skus []string) (map[string]bool, error) {
+	existing := make(map[string]bool, len(skus))
+	r.locked(func(s *store) {
+		for _, sku := range skus {
+			if _, ok := s.liveBySKU(sku); ok {
+				existing[sku] = true
+			}
+		}
+	})
+	return existing, nil
+}
+
+// ProductIDsBySKU looks up the live products with the given SKUs, ignoring
+// case. The result is keyed by the SKUs as given; unknown SKUs are missing.
+func (r *ProductRepository) ProductIDsBySKU(ctx context.Context, skus []string) (map[string]uuid.UUID, error) {
+	ids := make(map[string]uuid.UUID, len(skus))
+	r.locked(func(s *store) {
+		for _, sku := range skus {
+			if p, ok := s.liveBySKU(sku); ok {
+				ids[sku] = p.ID
+			}
+		}
+	})
+	return ids, nil
+}
+
+func (r *ProductRepository) CreateImportJob(ctx context.Context, job *domain.ImportJob) error {
+	err := r.atomically(func(s *store) error {
+		now := time.Now()
+		if job.ID == uuid.Nil {
+			job.ID = uuid.New()
+		}
+		if job.CreatedAt.IsZero() {
+			job.CreatedAt = now
+		}
+		if job.UpdatedAt.IsZero() {
+			job.UpdatedAt = now
+		}
+		if job.Status == "" {
+			job.Status = domain.ImportStatusPending
+		}
+		if _, ok := s.imports[job.ID]; ok {
+			return uniqueViolation("import_jobs_pkey")
+		}
+		s.imports[job.ID] = *copyImportJob(*job)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create import job: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) GetImportJob(ctx context.Context, id uuid.UUID) (*domain.ImportJob, error) {
+	var job *domain.ImportJob
+	r.locked(func(s *store) {
+		if found, ok := s.imports[id]; ok {
+			job = copyImportJob(found)
+		}
+	})
+	if job == nil {
+		return nil, notFound("Import job not found", customErrors.CodeImportJobNotFound)
+	}
+	return job, nil
+}
+
+func (r *ProductRepository) UpdateImportJob(ctx context.Context, job *domain.ImportJob) error {
+	r.locked(func(s *store) {
+		now := time.Now()
+		job.UpdatedAt = now
+		if job.CreatedAt.IsZero() {
+			job.CreatedAt = now
+		}
+		s.imports[job.ID] = *copyImportJob(*job)
+	})
+	return nil
+}
+
+// copyImportJob copies an import job and its error report
+func copyImportJob(job domain.ImportJob) *domain.ImportJob {
+	job.Errors = slices.Clone(job.Errors)
+	return &job
+}
@@ -0,0 +1,138 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/product/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+// saveMedia stores media, keeping storage keys unique
+func (s *store) saveMedia(media domain.Media) error {
+	for _, other := range s.media {
+		if other.ID != media.ID && other.StorageKey == media.StorageKey {
+			return uniqueViolation("product_media_storage_key_key")
+		}
+	}
+	if _, ok := s.products[media.ProductID]; !ok {
+		return foreignKeyViolation("product_media", "product_media_product_id_fkey")
+	}
+	s.media[media.ID] = *copyMedia(media)
+	return nil
+}
+
+// copyMedia copies media and its thumbnails, without the URLs filled in
+// on reads
+func copyMedia(media domain.Media) *domain.Media {
+	media.Thumbnails = slices.Clone(media.Thumbnails)
+	media.URL = ""
+	media.Variants = nil
+	return &media
+}
+
+func (r *ProductRepository) CreateMedia(ctx context.Context, media *domain.Media) error {
+	err := r.atomically(func(s *store) error {
+		now := time.Now()
+		if media.ID == uuid.Nil {
+			media.ID = uuid.New()
+		}
+		if media.CreatedAt.IsZero() {
+			media.CreatedAt = now
+		}
+		if media.UpdatedAt.IsZero() {
+			media.UpdatedAt = now
+		}
+		if media.Status == "" {
+			media.Status = domain.MediaStatusPending
+		}
+		if _, ok := s.media[media.ID]; ok {
+			return uniqueViolation("product_media_pkey")
+		}
+		return s.saveMedia(*media)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create media: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) GetMedia(ctx context.Context, id uuid.UUID) (*domain.Media, error) {
+	var media *domain.Media
+	r.locked(func(s *store) {
+		if found, ok := s.media[id]; ok {
+			media = copyMedia(found)
+		}
+	})
+	if media == nil {
+		return nil, notFound("Media not found", customErrors.CodeMediaNotFound)
+	}
+	return media, nil
+}
+
+func (r *ProductRepository) UpdateMedia(ctx context.Context, media *domain.Media) error {
+	err := r.atomically(func(s *store) error {
+		now := time.Now()
+		media.UpdatedAt = now
+		if media.CreatedAt.IsZero() {
+			media.CreatedAt = now
+		}
+		return s.saveMedia(*media)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update media: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) DeleteMedia(ctx context.Context, id uuid.UUID) error {
+	r.locked(func(s *store) {
+		delete(s.media, id)
+	})
+	return nil
+}
+
+// ListProductMedia lists a product's media oldest first, optionally only
+// that with the given status
+func (r *ProductRepository) ListProductMedia(ctx context.Context, productID uuid.UUID, status string) ([]domain.Media, error) {
+	var media []domain.Media
+	r.locked(func(s *store) {
+		for _, found := range s.media {
+			if found.ProductID == productID && (status == "" || found.Status == status) {
+				media = append(media, *copyMedia(found))
+			}
+		}
+	})
+	slices.SortFunc(media, compareMedia)
+	return media, nil
+}
+
+// ListExpiredMedia finds media that is no longer wanted: uploads started
+// before abandonedBefore that were never completed, and the media of
+// products deleted before deletedBefore
+func (r *ProductRepository) ListExpiredMedia(ctx context.Context, abandonedBefore, deletedBefore time.Time, limit int) ([]domain.Media, error) {
+	var media []domain.Media
+	r.locked(func(s *store) {
+		for _, found := range s.media {
+			abandoned := found.Status == domain.MediaStatusPending && found.CreatedAt.Before(abandonedBefore)
+			p, ok := s.products[found.ProductID]
+			deleted := ok && p.DeletedAt.Valid && p.DeletedAt.Time.Before(deletedBefore)
+			if abandoned || deleted {
+				media = append(media, *copyMedia(found))
+			}
+		}
+	})
+	slices.SortFunc(media, compareMedia)
+
+	start, end := page(len(media), 0, limit)
+	return media[start:end], nil
+}
+
+// compareMedia orders media oldest first
+func compareMedia(a, b domain.Media) int {
+	return a.CreatedAt.Compare(b.CreatedAt)
+}
@@ -0,0 +1,286 @@
+// Package memory holds in-memory implementations of the repositories, for
+// unit testing the service layer without Postgres or Redis. They follow the
+// Postgres implementations closely enough to pass the same contract tests:
+// the same not found and conflict errors, the same constraints, ordering and
+// stock ledger. Full-text search is approximated by matching words.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/internal/product/repository"
+	"ecommerce/pkg/database"
+	customErrors "ecommerce/pkg/errors"
+	"ecommerce/pkg/tenant"
+)
+
+// ProductRepository keeps the catalog in memory. It is safe for concurrent
+// use, and is also a database.TxManager over itself.
+type ProductRepository struct {
+	mu   sync.Mutex
+	data *store
+
+	// tx serializes transactions, which are rolled back by restoring a
+	// snapshot of the data taken when they began
+	tx sync.Mutex
+}
+
+var (
+	_ repository.ProductRepository = (*ProductRepository)(nil)
+	_ database.TxManager           = (*ProductRepository)(nil)
+)
+
+// NewProductRepository creates an empty product repository
+func NewProductRepository() *ProductRepository {
+	return &ProductRepository{data: newStore()}
+}
+
+// store is the data of a repository. Values are copied in and out, so
+// callers never share memory with it; products are kept by pointer and
+// changed in place under the repository's lock.
+type store struct {
+	products             map[uuid.UUID]*domain.Product
+	attributes           map[uuid.UUID][]domain.ProductAttribute
+	categories           map[uuid.UUID]domain.Category
+	brands               map[uuid.UUID]domain.Brand
+	vendors              map[uuid.UUID]domain.Vendor
+	warehouses           map[uuid.UUID]domain.Warehouse
+	levels               map[levelKey]domain.StockLevel
+	definitions          map[uuid.UUID]domain.AttributeDefinition
+	synonyms             map[uuid.UUID]domain.SearchSynonym
+	zeroResults          map[uuid.UUID]domain.ZeroResultSearch
+	redirects            []domain.SlugRedirect
+	relations            []domain.ProductRelation
+	productTranslations  map[translationKey]domain.ProductTranslation
+	categoryTranslations map[translationKey]domain.CategoryTranslation
+	media                map[uuid.UUID]domain.Media
+	assets               map[uuid.UUID]domain.DigitalAsset
+	components           []domain.BundleComponent
+	tiers                []domain.PriceTier
+	entitlements         []domain.Entitlement
+	reviews              map[uuid.UUID]domain.Review
+	revisions            map[uuid.UUID]domain.ProductRevision
+	changesets           map[uuid.UUID]domain.Changeset
+	changesetItems       map[uuid.UUID]domain.ChangesetItem
+	flashSales           map[uuid.UUID]domain.FlashSale
+	flashSalePurchases   map[uuid.UUID]domain.FlashSalePurchase
+	reservations         []domain.StockReservation
+	movements            []domain.StockMovement
+	imports              map[uuid.UUID]domain.ImportJob
+	audit                map[uuid.UUID]domain.AuditEvent
+}
+
+// levelKey identifies the stock level of a product in a warehouse
+type levelKey struct {
+	product   uuid.UUID
+	warehouse uuid.UUID
+}
+
+// translationKey identifies a translation of an entity
+type translationKey struct {
+	id     uuid.UUID
+	locale string
+}
+
+// newStore creates an empty store holding the default tenant's default
+// warehouse, as the schema does
+func newStore() *store {
+	now := time.Now()
+	warehouse := domain.Warehouse{
+		ID:        uuid.New(),
+		TenantID:  tenant.Default,
+		Code:      domain.DefaultWarehouseCode,
+		Name:      "Default warehouse",
+		IsDefault: true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	return &store{
+		products:             make(map[uuid.UUID]*domain.Product),
+		attributes:           make(map[uuid.UUID][]domain.ProductAttribute),
+		categories:           make(map[uuid.UUID]domain.Category),
+		brands:               make(map[uuid.UUID]domain.Brand),
+		vendors:              make(map[uuid.UUID]domain.Vendor),
+		warehouses:           map[uuid.UUID]domain.Warehouse{warehouse.ID: warehouse},
+		levels:               make(map[levelKey]domain.StockLevel),
+		definitions:          make(map[uuid.UUID]domain.AttributeDefinition),
+		synonyms:             make(map[uuid.UUID]domain.SearchSynonym),
+		zeroResults:          make(map[uuid.UUID]domain.ZeroResultSearch),
+		productTranslations:  make(map[translationKey]domain.ProductTranslation),
+		categoryTranslations: make(map[translationKey]domain.CategoryTranslation),
+		media:                make(map[uuid.UUID]domain.Media),
+		assets:               make(map[uuid.UUID]domain.DigitalAsset),
+		reviews:              make(map[uuid.UUID]domain.Review),
+		revisions:            make(map[uuid.UUID]domain.ProductRevision),
+		changesets:           make(map[uuid.UUID]domain.Changeset),
+		changesetItems:       make(map[uuid.UUID]domain.ChangesetItem),
+		flashSales:           make(map[uuid.UUID]domain.FlashSale),
+		flashSalePurchases:   make(map[uuid.UUID]domain.FlashSalePurchase),
+		imports:              make(map[uuid.UUID]domain.ImportJob),
+		audit:                make(map[uuid.UUID]domain.AuditEvent),
+	}
+}
+
+// clone copies the store deeply enough that changes to either leave the
+// other alone
+func (s *store) clone() *store {
+	products := make(map[uuid.UUID]*domain.Product, len(s.products))
+	for id, product := range s.products {
+		products[id] = copyProduct(product)
+	}
+	return &store{
+		products:             products,
+		attributes:           maps.Clone(s.attributes),
+		categories:           maps.Clone(s.categories),
+		brands:               maps.Clone(s.brands),
+		vendors:              maps.Clone(s.vendors),
+		warehouses:           maps.Clone(s.warehouses),
+		levels:               maps.Clone(s.levels),
+		definitions:          maps.Clone(s.definitions),
+		synonyms:             maps.Clone(s.synonyms),
+		zeroResults:          maps.Clone(s.zeroResults),
+		redirects:            slices.Clone(s.redirects),
+		relations:            slices.Clone(s.relations),
+		productTranslations:  maps.Clone(s.productTranslations),
+		categoryTranslations: maps.Clone(s.categoryTranslations),
+		media:                maps.Clone(s.media),
+		assets:               maps.Clone(s.assets),
+		components:           slices.Clone(s.components),
+		tiers:                slices.Clone(s.tiers),
+		entitlements:         slices.Clone(s.entitlements),
+		reviews:              maps.Clone(s.reviews),
+		revisions:            maps.Clone(s.revisions),
+		changesets:           maps.Clone(s.changesets),
+		changesetItems:       maps.Clone(s.changesetItems),
+		flashSales:           maps.Clone(s.flashSales),
+		flashSalePurchases:   maps.Clone(s.flashSalePurchases),
+		reservations:         slices.Clone(s.reservations),
+		movements:            slices.Clone(s.movements),
+		imports:              maps.Clone(s.imports),
+		audit:                maps.Clone(s.audit),
+	}
+}
+
+type txKey struct{}
+
+// WithinTransaction runs fn as one unit of work: when it fails, every change
+// made since it began is undone. Transactions run one at a time; changes
+// made outside one while it runs are undone with it.
+func (r *ProductRepository) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(txKey{}) != nil {
+		return fn(ctx)
+	}
+
+	r.tx.Lock()
+	defer r.tx.Unlock()
+
+	r.mu.Lock()
+	snapshot := r.data.clone()
+	r.mu.Unlock()
+
+	if err := fn(context.WithValue(ctx, txKey{}, true)); err != nil {
+		r.mu.Lock()
+		r.data = snapshot
+		r.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// atomically runs fn with the repository locked, undoing its changes when
+// it fails, like the transactions the Postgres repository wraps its
+// multi-statement writes in
+func (r *ProductRepository) atomically(fn func(s *store) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshot := r.data.clone()
+	if err := fn(r.data); err != nil {
+		r.data = snapshot
+		return err
+	}
+	return nil
+}
+
+// locked runs fn with the repository locked
+func (r *ProductRepository) locked(fn func(s *store)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fn(r.data)
+}
+
+// uniqueViolation returns the error Postgres rejects a write breaking a
+// unique constraint with
+func uniqueViolation(constraint string) error {
+	return &pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           "23505",
+		Message:        fmt.Sprintf("duplicate key value violates unique constraint %q", constraint),
+		ConstraintName: constraint,
+	}
+}
+
+// foreignKeyViolation returns the error Postgres rejects a write breaking a
+// foreign key with
+func foreignKeyViolation(table, constraint string) error {
+	return &pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           "23503",
+		Message:        fmt.Sprintf("insert or update on table %q violates foreign key constraint %q", table, constraint),
+		TableName:      table,
+		ConstraintName: constraint,
+	}
+}
+
+// referencedViolation returns the error Postgres rejects deleting a row
+// that is still referenced with
+func referencedViolation(table, constraint string) error {
+	return &pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           "23503",
+		Message:        fmt.Sprintf("update or delete on table %q violates foreign key constraint %q", table, constraint),
+		TableName:      table,
+		ConstraintName: constraint,
+	}
+}
+
+// notFound returns the error the Postgres repository reports a missing
+// entity with
+func notFound(message, code string) error {
+	err := customErrors.NewNotFoundError(message, gorm.ErrRecordNotFound)
+	if code != "" {
+		err = err.WithCode(code)
+	}
+	return err
+}
+
+// page returns the bounds of a page of n items. A negative limit is no
+// limit; as with SQL, a zero limit is an empty page.
+func page(n, offset, limit int) (int, int) {
+	start := min(max(offset, 0), n)
+	end := n
+	if limit >= 0 {
+		end = min(start+limit, n)
+	}
+	return start, end
+}
+
+// clone copies the value p points to
+func clone[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
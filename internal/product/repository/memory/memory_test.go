@@ -0,0 +1,17 @@
+package memory_test
+
+import (
+	"testing"
+
+	"ecommerce/internal/product/repository"
+	"ecommerce/internal/product/repository/memory"
+	"ecommerce/internal/product/repository/repositorytest"
+	"ecommerce/pkg/database"
+)
+
+func TestProductRepositoryContract(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) (repository.ProductRepository, database.TxManager) {
+		repo := memory.NewProductRepository()
+		return repo, repo
+	})
+}
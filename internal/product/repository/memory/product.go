@@ -0,0 +1,610 @@
+package memory
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"ecommerce/internal/product/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+// skuIndex is the unique index keeping the SKUs of live products unique,
+// ignoring case, as named in Postgres
+const skuIndex = "idx_products_sku_live_lower"
+
+// skuConflict returns the error for a write rejected because another live
+// product has the SKU
+func skuConflict(cause error) error {
+	return customErrors.NewConflictError("SKU already exists", cause).WithCode(customErrors.CodeProductSKUConflict)
+}
+
+// copyProduct copies a product without its associations, so the copy
+// shares no memory with the original
+func copyProduct(p *domain.Product) *domain.Product {
+	c := *p
+	c.BrandID = clone(p.BrandID)
+	c.VendorID = clone(p.VendorID)
+	c.PublishAt = clone(p.PublishAt)
+	c.PublishedAt = clone(p.PublishedAt)
+	c.LowStockThreshold = clone(p.LowStockThreshold)
+	c.LowStockAlertedAt = clone(p.LowStockAlertedAt)
+	c.SalePrice = clone(p.SalePrice)
+	c.SaleStartsAt = clone(p.SaleStartsAt)
+	c.SaleEndsAt = clone(p.SaleEndsAt)
+	c.ReleaseDate = clone(p.ReleaseDate)
+	c.Category = nil
+	c.Brand = nil
+	c.Attributes = nil
+	c.Images = nil
+	c.Breadcrumbs = nil
+	c.Availability = nil
+	c.Components = nil
+	c.BundleStock = nil
+	c.Rank = 0
+	c.Highlight = ""
+	return &c
+}
+
+// load copies a stored product out with the associations asked for
+func (s *store) load(p *domain.Product, category, brand, attributes bool) domain.Product {
+	product := *copyProduct(p)
+	if category {
+		if c, ok := s.categories[p.CategoryID]; ok {
+			product.Category = copyCategory(c)
+		}
+	}
+	if brand && p.BrandID != nil {
+		if b, ok := s.brands[*p.BrandID]; ok {
+			product.Brand = &b
+		}
+	}
+	if attributes {
+		product.Attributes = slices.Clone(s.attributes[p.ID])
+	}
+	return product
+}
+
+// loadAll copies a stored product out with all its associations
+func (s *store) loadAll(p *domain.Product) *domain.Product {
+	product := s.load(p, true, true, true)
+	return &product
+}
+
+// live returns the product with the ID unless it is missing or deleted
+func (s *store) live(id uuid.UUID) (*domain.Product, bool) {
+	p, ok := s.products[id]
+	if !ok || p.DeletedAt.Valid {
+		return nil, false
+	}
+	return p, true
+}
+
+// liveBySKU returns the live product with an SKU, ignoring case
+func (s *store) liveBySKU(sku string) (*domain.Product, bool) {
+	for _, p := range s.products {
+		if !p.DeletedAt.Valid && strings.EqualFold(p.SKU, sku) {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// checkProduct enforces the constraints Postgres checks on a product row
+func (s *store) checkProduct(p *domain.Product) error {
+	if !p.DeletedAt.Valid {
+		if other, ok := s.liveBySKU(p.SKU); ok && other.ID != p.ID {
+			return skuConflict(uniqueViolation(skuIndex))
+		}
+	}
+	if p.Slug != "" {
+		for _, other := range s.products {
+			if other.ID != p.ID && other.Slug == p.Slug {
+				return uniqueViolation("idx_products_slug")
+			}
+		}
+	}
+	if _, ok := s.categories[p.CategoryID]; !ok {
+		return foreignKeyViolation("products", "products_category_id_fkey")
+	}
+	if p.BrandID != nil {
+		if _, ok := s.brands[*p.BrandID]; !ok {
+			return foreignKeyViolation("products", "products_brand_id_fkey")
+		}
+	}
+	if p.VendorID != nil {
+		if _, ok := s.vendors[*p.VendorID]; !ok {
+			return foreignKeyViolation("products", "products_vendor_id_fkey")
+		}
+	}
+	return nil
+}
+
+// insertProduct stores a new product, filling in what Postgres would
+func (s *store) insertProduct(product *domain.Product, now time.Time) error {
+	if product.ID == uuid.Nil {
+		product.ID = uuid.New()
+	}
+	if product.CreatedAt.IsZero() {
+		product.CreatedAt = now
+	}
+	if product.UpdatedAt.IsZero() {
+		product.UpdatedAt = now
+	}
+	if product.Status == "" {
+		product.Status = domain.ProductStatusPublished
+	}
+	if product.Type == "" {
+		product.Type = domain.ProductTypePhysical
+	}
+	if product.UnitOfMeasure == "" {
+		product.UnitOfMeasure = domain.UnitEach
+	}
+	if product.QuantityIncrement == 0 {
+		product.QuantityIncrement = 1
+	}
+	if product.TaxClass == "" {
+		product.TaxClass = domain.TaxClassStandard
+	}
+	// gorm writes the column default in place of a false is_active
+	product.IsActive = true
+	if _, ok := s.products[product.ID]; ok {
+		return uniqueViolation("products_pkey")
+	}
+
+	stored := copyProduct(product)
+	// Read-only columns start out at their defaults
+	stored.LowStockAlertedAt = nil
+	stored.SaleActive = false
+	stored.RatingAverage = 0
+	stored.ReviewCount = 0
+	if err := s.checkProduct(stored); err != nil {
+		return err
+	}
+	s.products[stored.ID] = stored
+	return nil
+}
+
+// record appends a movement to the stock ledger and applies it to the stock
+// level of its warehouse, the default one when it names none. A movement
+// that would take a warehouse below zero is rejected, unless the product
+// can be oversold.
+func (s *store) record(movement domain.StockMovement, now time.Time) (domain.StockMovement, error) {
+	if movement.ID == uuid.Nil {
+		movement.ID = uuid.New()
+	}
+	if movement.CreatedAt.IsZero() {
+		movement.CreatedAt = now
+	}
+	if movement.WarehouseID == nil {
+		id := s.defaultWarehouse(movement.ProductID).ID
+		movement.WarehouseID = &id
+	}
+
+	key := levelKey{product: movement.ProductID, warehouse: *movement.WarehouseID}
+	level, ok := s.levels[key]
+	if !ok {
+		if _, ok := s.warehouses[key.warehouse]; !ok {
+			return movement, foreignKeyViolation("inventory_movements", "inventory_movements_warehouse_id_fkey")
+		}
+		level = domain.StockLevel{ProductID: key.product, WarehouseID: key.warehouse}
+	}
+	if level.Quantity+movement.Delta < 0 && movement.Delta < 0 && !s.canOversell(movement.ProductID, now) {
+		return movement, customErrors.NewConflictError("Insufficient stock in warehouse", nil).WithCode(customErrors.CodeInsufficientStock)
+	}
+	if movement.Delta != 0 {
+		level.Quantity += movement.Delta
+		level.UpdatedAt = now
+		s.levels[key] = level
+	}
+
+	s.movements = append(s.movements, movement)
+	return movement, nil
+}
+
+// canOversell reports whether a product can be reserved beyond its stock
+func (s *store) canOversell(id uuid.UUID, now time.Time) bool {
+	p, ok := s.products[id]
+	return ok && p.CanOversellAt(now)
+}
+
+// ledgerEntry fills in a movement for one product's stock change
+func ledgerEntry(movement domain.StockMovement, productID uuid.UUID, delta, balance int) domain.StockMovement {
+	movement.ProductID = productID
+	movement.Delta = delta
+	movement.Balance = balance
+	return movement
+}
+
+// Create creates a product and opens its stock ledger with the given
+// movement, which records the initial stock
+func (r *ProductRepository) Create(ctx context.Context, product *domain.Product, movement domain.StockMovement) error {
+	err := r.atomically(func(s *store) error {
+		now := time.Now()
+		if err := s.insertProduct(product, now); err != nil {
+			return err
+		}
+		if product.Stock != 0 {
+			_, err := s.record(ledgerEntry(movement, product.ID, product.Stock, product.Stock), now)
+			return err
+		}
+		return nil
+	})
+	if customErrors.IsConflict(err) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create product: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Product, error) {
+	var product *domain.Product
+	r.locked(func(s *store) {
+		if p, ok := s.live(id); ok {
+			product = s.loadAll(p)
+		}
+	})
+	if product == nil {
+		return nil, notFound("Product not found", customErrors.CodeProductNotFound)
+	}
+	return product, nil
+}
+
+// GetByIDs loads several products at once, keyed by ID. Products that
+// don't exist are left out.
+func (r *ProductRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*domain.Product, error) {
+	products := make(map[uuid.UUID]*domain.Product, len(ids))
+	r.locked(func(s *store) {
+		for _, id := range ids {
+			if p, ok := s.live(id); ok {
+				products[id] = s.loadAll(p)
+			}
+		}
+	})
+	return products, nil
+}
+
+func (r *ProductRepository) GetBySKU(ctx context.Context, sku string) (*domain.Product, error) {
+	var product *domain.Product
+	r.locked(func(s *store) {
+		if p, ok := s.liveBySKU(sku); ok {
+			product = s.loadAll(p)
+		}
+	})
+	if product == nil {
+		return nil, notFound("Product not found", customErrors.CodeProductNotFound)
+	}
+	return product, nil
+}
+
+// Update saves a product's fields except its stock, which only changes
+// through SetStock, AdjustStock and reservations so that it stays in step
+// with the stock ledger. Like gorm's Save, it creates the product when it
+// does not exist.
+func (r *ProductRepository) Update(ctx context.Context, product *domain.Product) error {
+	err := r.atomically(func(s *store) error {
+		now := time.Now()
+		product.UpdatedAt = now
+
+		existing, ok := s.products[product.ID]
+		if !ok {
+			return s.insertProduct(product, now)
+		}
+
+		stored := copyProduct(product)
+		stored.Stock = existing.Stock
+		stored.LowStockAlertedAt = existing.LowStockAlertedAt
+		stored.SaleActive = existing.SaleActive
+		stored.RatingAverage = existing.RatingAverage
+		stored.ReviewCount = existing.ReviewCount
+		if err := s.checkProduct(stored); err != nil {
+			return err
+		}
+		s.products[stored.ID] = stored
+		return nil
+	})
+	if customErrors.IsConflict(err) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update product: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.locked(func(s *store) {
+		if p, ok := s.live(id); ok {
+			p.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		}
+	})
+	return nil
+}
+
+func (r *ProductRepository) GetDeleted(ctx context.Context, id uuid.UUID) (*domain.Product, error) {
+	var product *domain.Product
+	r.locked(func(s *store) {
+		if p, ok := s.products[id]; ok && p.DeletedAt.Valid {
+			product = s.loadAll(p)
+		}
+	})
+	if product == nil {
+		return nil, notFound("Deleted product not found", customErrors.CodeProductNotFound)
+	}
+	return product, nil
+}
+
+func (r *ProductRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	return r.atomically(func(s *store) error {
+		p, ok := s.products[id]
+		if !ok || !p.DeletedAt.Valid {
+			return nil
+		}
+		if other, ok := s.liveBySKU(p.SKU); ok && other.ID != id {
+			return skuConflict(uniqueViolation(skuIndex))
+		}
+		p.DeletedAt = gorm.DeletedAt{}
+		return nil
+	})
+}
+
+func (r *ProductRepository) List(ctx context.Context, filters *domain.ProductFilters) ([]domain.Product, int64, error) {
+	var products []domain.Product
+	var total int64
+	r.locked(func(s *store) {
+		products, total = s.list(filters, time.Now())
+	})
+	return products, total, nil
+}
+
+// list returns a page of the products matching filters, and how many match
+func (s *store) list(filters *domain.ProductFilters, now time.Time) ([]domain.Product, int64) {
+	matched := s.filter(filters, now)
+	total := int64(len(matched))
+
+	// Rank full-text matches
+	ranks := make(map[uuid.UUID]float64, len(matched))
+	if filters.Search != "" {
+		for _, p := range matched {
+			ranks[p.ID] = searchRank(p, filters)
+		}
+	}
+
+	desc := strings.EqualFold(filters.SortOrder, "desc")
+	slices.SortStableFunc(matched, func(a, b *domain.Product) int {
+		var c int
+		switch filters.SortBy {
+		case "name":
+			c = strings.Compare(a.Name, b.Name)
+		case "price":
+			c = cmp.Compare(a.PriceAt(now), b.PriceAt(now))
+		case "rating":
+			c = cmp.Or(cmp.Compare(a.RatingAverage, b.RatingAverage), cmp.Compare(a.ReviewCount, b.ReviewCount))
+		case "relevance":
+			if filters.Search != "" {
+				c = cmp.Compare(ranks[a.ID], ranks[b.ID])
+			}
+		}
+		// Ties, and the orders that end with the ID, fall back to the
+		// keyset order
+		if c == 0 {
+			c = compareKeyset(a, b)
+		}
+		if desc {
+			return -c
+		}
+		return c
+	})
+
+	// Apply pagination: keyset when a cursor is given, offset otherwise
+	if filters.After != nil {
+		after := &domain.Product{CreatedAt: filters.After.CreatedAt, ID: filters.After.ID}
+		matched = slices.DeleteFunc(matched, func(p *domain.Product) bool {
+			c := compareKeyset(p, after)
+			if desc {
+				return c >= 0
+			}
+			return c <= 0
+		})
+	} else if filters.Offset > 0 {
+		matched = matched[min(filters.Offset, len(matched)):]
+	}
+	if filters.Limit > 0 && len(matched) > filters.Limit {
+		matched = matched[:filters.Limit]
+	}
+
+	// Only load the associations the caller asked for
+	products := make([]domain.Product, 0, len(matched))
+	for _, p := range matched {
+		product := s.load(p, filters.Fields.Includes("category"), filters.Fields.Includes("brand"), filters.Fields.Includes("attributes"))
+		product.Rank = ranks[p.ID]
+		products = append(products, product)
+	}
+	return products, total
+}
+
+func (r *ProductRepository) Facets(ctx context.Context, filters *domain.ProductFilters) (*domain.ProductFacets, error) {
+	now := time.Now()
+	facets := &domain.ProductFacets{}
+
+	r.locked(func(s *store) {
+		// Category facet ignores the category filter
+		categoryFilters := *filters
+		categoryFilters.CategoryID = nil
+		categoryCounts := make(map[uuid.UUID]int64)
+		for _, p := range s.filter(&categoryFilters, now) {
+			categoryCounts[p.CategoryID]++
+		}
+		facets.Categories = make([]domain.FacetCount, 0, len(categoryCounts))
+		for id, count := range categoryCounts {
+			facets.Categories = append(facets.Categories, domain.FacetCount{
+				Value: id.String(),
+				Label: s.categories[id].Name,
+				Count: count,
+			})
+		}
+		sortFacets(facets.Categories)
+
+		// Brand facet ignores the brand filter; unbranded products are not counted
+		brandFilters := *filters
+		brandFilters.BrandID = nil
+		brandCounts := make(map[uuid.UUID]int64)
+		for _, p := range s.filter(&brandFilters, now) {
+			if p.BrandID == nil {
+				continue
+			}
+			if _, ok := s.brands[*p.BrandID]; ok {
+				brandCounts[*p.BrandID]++
+			}
+		}
+		facets.Brands = make([]domain.FacetCount, 0, len(brandCounts))
+		for id, count := range brandCounts {
+			facets.Brands = append(facets.Brands, domain.FacetCount{
+				Value: id.String(),
+				Label: s.brands[id].Name,
+				Count: count,
+			})
+		}
+		sortFacets(facets.Brands)
+
+		// Price facet ignores the price filters
+		priceFilters := *filters
+		priceFilters.MinPrice = nil
+		priceFilters.MaxPrice = nil
+		bounds := domain.PriceBucketBounds
+		counts := make([]int64, len(bounds))
+		for _, p := range s.filter(&priceFilters, now) {
+			price := p.PriceAt(now)
+			for i, lower := range bounds {
+				if price >= lower && (i+1 == len(bounds) || price < bounds[i+1]) {
+					counts[i]++
+				}
+			}
+		}
+		facets.PriceRanges = make([]domain.PriceRangeFacet, 0, len(bounds))
+		for i, lower := range bounds {
+			bucket := domain.PriceRangeFacet{Min: lower, Count: counts[i]}
+			if i+1 < len(bounds) {
+				upper := bounds[i+1]
+				bucket.Max = &upper
+			}
+			facets.PriceRanges = append(facets.PriceRanges, bucket)
+		}
+
+		// Stock facet ignores the in-stock filter
+		stockFilters := *filters
+		stockFilters.InStock = nil
+		for _, p := range s.filter(&stockFilters, now) {
+			if p.Stock > 0 {
+				facets.InStock++
+			} else {
+				facets.OutOfStock++
+			}
+		}
+	})
+
+	return facets, nil
+}
+
+func (r *ProductRepository) Iterate(ctx context.Context, filters *domain.ProductFilters, batchSize int, fn func([]domain.Product) error) error {
+	var after *domain.Product
+	for {
+		var products []domain.Product
+		r.locked(func(s *store) {
+			matched := s.filter(filters, time.Now())
+			slices.SortFunc(matched, compareKeyset)
+			for _, p := range matched {
+				if after != nil && compareKeyset(p, after) <= 0 {
+					continue
+				}
+				if len(products) == batchSize {
+					break
+				}
+				products = append(products, s.load(p, false, false, false))
+			}
+		})
+		if len(products) == 0 {
+			return nil
+		}
+
+		if err := fn(products); err != nil {
+			return err
+		}
+		if len(products) < batchSize {
+			return nil
+		}
+
+		last := products[len(products)-1]
+		after = &domain.Product{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+}
+
+// filter returns the stored products matching product filters
+func (s *store) filter(filters *domain.ProductFilters, now time.Time) []*domain.Product {
+	var matched []*domain.Product
+	for _, p := range s.products {
+		if p.DeletedAt.Valid && !filters.IncludeDeleted {
+			continue
+		}
+		if filters.CategoryID != nil && p.CategoryID != *filters.CategoryID {
+			continue
+		}
+		if filters.BrandID != nil && (p.BrandID == nil || *p.BrandID != *filters.BrandID) {
+			continue
+		}
+		if filters.VendorID != nil && (p.VendorID == nil || *p.VendorID != *filters.VendorID) {
+			continue
+		}
+		if filters.MinPrice != nil && p.PriceAt(now) < *filters.MinPrice {
+			continue
+		}
+		if filters.MaxPrice != nil && p.PriceAt(now) > *filters.MaxPrice {
+			continue
+		}
+		if filters.Search != "" && !searchMatches(p, filters) {
+			continue
+		}
+		if filters.IsActive != nil && p.IsActive != *filters.IsActive {
+			continue
+		}
+		if filters.Status != "" && p.Status != filters.Status {
+			continue
+		}
+		if filters.ApprovalStatus != "" && p.ApprovalStatus != filters.ApprovalStatus {
+			continue
+		}
+		if filters.InStock != nil && *filters.InStock && p.Stock <= 0 {
+			continue
+		}
+		if !p.VisibleIn(filters.Channel) {
+			continue
+		}
+		matched = append(matched, p)
+	}
+	return matched
+}
+
+// compareKeyset orders products by creation time and then ID, the order
+// keyset pagination walks
+func compareKeyset(a, b *domain.Product) int {
+	if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+		return c
+	}
+	return bytes.Compare(a.ID[:], b.ID[:])
+}
+
+// sortFacets orders facet values by count, largest first
+func sortFacets(facets []domain.FacetCount) {
+	slices.SortFunc(facets, func(a, b domain.FacetCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), strings.Compare(a.Label, b.Label), strings.Compare(a.Value, b.Value))
+	})
+}
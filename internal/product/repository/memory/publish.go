@@ -0,0 +1,36 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"ecommerce/internal/product/domain"
+)
+
+// PublishDue publishes the drafts whose scheduled publish time has passed
+// and returns them. Vendor products wait for their approval.
+func (r *ProductRepository) PublishDue(ctx context.Context, limit int) ([]domain.Product, error) {
+	var published []domain.Product
+	r.locked(func(s *store) {
+		now := time.Now()
+
+		var due []*domain.Product
+		for _, p := range s.products {
+			if !p.DeletedAt.Valid && p.Status == domain.ProductStatusDraft && p.PublishAt != nil && !p.PublishAt.After(now) && p.Approved() {
+				due = append(due, p)
+			}
+		}
+		slices.SortFunc(due, func(a, b *domain.Product) int { return a.PublishAt.Compare(*b.PublishAt) })
+
+		start, end := page(len(due), 0, limit)
+		for _, p := range due[start:end] {
+			p.Status = domain.ProductStatusPublished
+			p.PublishedAt = &now
+			p.PublishAt = nil
+			p.UpdatedAt = now
+			published = append(published, *copyProduct(p))
+		}
+	})
+	return published, nil
+}
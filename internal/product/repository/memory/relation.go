@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/product/domain"
+)
+
+func (r *ProductRepository) UpsertRelation(ctx context.Context, relation *domain.ProductRelation) error {
+	err := r.atomically(func(s *store) error {
+		for i, existing := range s.relations {
+			if existing.ProductID == relation.ProductID && existing.RelatedID == relation.RelatedID && existing.Type == relation.Type {
+				s.relations[i].Position = relation.Position
+				return nil
+			}
+		}
+
+		if _, ok := s.products[relation.ProductID]; !ok {
+			return foreignKeyViolation("product_relations", "product_relations_product_id_fkey")
+		}
+		if _, ok := s.products[relation.RelatedID]; !ok {
+			return foreignKeyViolation("product_relations", "product_relations_related_id_fkey")
+		}
+		if relation.CreatedAt.IsZero() {
+			relation.CreatedAt = time.Now()
+		}
+		s.relations = append(s.relations, *relation)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save product relation: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) DeleteRelation(ctx context.Context, productID, relatedID uuid.UUID, relationType string) (bool, error) {
+	var deleted bool
+	r.locked(func(s *store) {
+		s.relations = slices.DeleteFunc(s.relations, func(relation domain.ProductRelation) bool {
+			match := relation.ProductID == productID && relation.RelatedID == relatedID &&
+				(relationType == "" || relation.Type == relationType)
+			deleted = deleted || match
+			return match
+		})
+	})
+	return deleted, nil
+}
+
+func (r *ProductRepository) ListRelations(ctx context.Context, productID uuid.UUID, relationType string) ([]domain.ProductRelation, error) {
+	var relations []domain.ProductRelation
+	r.locked(func(s *store) {
+		for _, relation := range s.relations {
+			if relation.ProductID == productID && (relationType == "" || relation.Type == relationType) {
+				relations = append(relations, relation)
+			}
+		}
+	})
+	slices.SortFunc(relations, func(a, b domain.ProductRelation) int {
+		return cmp.Or(strings.Compare(a.Type, b.Type), cmp.Compare(a.Position, b.Position), a.CreatedAt.Compare(b.CreatedAt))
+	})
+	return relations, nil
+}
@@ -0,0 +1,139 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/product/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+// saveReview stores a review, keeping one per user and product
+func (s *store) saveReview(review domain.Review) error {
+	for _, other := range s.reviews {
+		if other.ID != review.ID && other.ProductID == review.ProductID && other.UserID == review.UserID {
+			return uniqueViolation("reviews_product_id_user_id_key")
+		}
+	}
+	if _, ok := s.products[review.ProductID]; !ok {
+		return foreignKeyViolation("reviews", "reviews_product_id_fkey")
+	}
+	s.reviews[review.ID] = review
+	return nil
+}
+
+func (r *ProductRepository) CreateReview(ctx context.Context, review *domain.Review) error {
+	err := r.atomically(func(s *store) error {
+		now := time.Now()
+		if review.ID == uuid.Nil {
+			review.ID = uuid.New()
+		}
+		if review.CreatedAt.IsZero() {
+			review.CreatedAt = now
+		}
+		if review.UpdatedAt.IsZero() {
+			review.UpdatedAt = now
+		}
+		if review.Status == "" {
+			review.Status = domain.ReviewStatusPending
+		}
+		if _, ok := s.reviews[review.ID]; ok {
+			return uniqueViolation("reviews_pkey")
+		}
+		return s.saveReview(*review)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create review: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) GetReview(ctx context.Context, id uuid.UUID) (*domain.Review, error) {
+	var review *domain.Review
+	r.locked(func(s *store) {
+		if found, ok := s.reviews[id]; ok {
+			review = &found
+		}
+	})
+	if review == nil {
+		return nil, notFound("Review not found", customErrors.CodeReviewNotFound)
+	}
+	return review, nil
+}
+
+func (r *ProductRepository) GetUserReview(ctx context.Context, productID uuid.UUID, userID string) (*domain.Review, error) {
+	var review *domain.Review
+	r.locked(func(s *store) {
+		for _, found := range s.reviews {
+			if found.ProductID == productID && found.UserID == userID {
+				review = &found
+			}
+		}
+	})
+	if review == nil {
+		return nil, notFound("Review not found", customErrors.CodeReviewNotFound)
+	}
+	return review, nil
+}
+
+func (r *ProductRepository) UpdateReview(ctx context.Context, review *domain.Review) error {
+	err := r.atomically(func(s *store) error {
+		now := time.Now()
+		review.UpdatedAt = now
+		if review.CreatedAt.IsZero() {
+			review.CreatedAt = now
+		}
+		return s.saveReview(*review)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update review: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) ListReviews(ctx context.Context, filters *domain.ReviewFilters) ([]domain.Review, int64, error) {
+	var reviews []domain.Review
+	r.locked(func(s *store) {
+		for _, review := range s.reviews {
+			if filters.ProductID != nil && review.ProductID != *filters.ProductID {
+				continue
+			}
+			if filters.Status != "" && review.Status != filters.Status {
+				continue
+			}
+			reviews = append(reviews, review)
+		}
+	})
+	slices.SortFunc(reviews, func(a, b domain.Review) int { return b.CreatedAt.Compare(a.CreatedAt) })
+
+	start, end := page(len(reviews), filters.Offset, filters.Limit)
+	return reviews[start:end], int64(len(reviews)), nil
+}
+
+// RefreshProductRating recomputes a product's denormalized rating from its approved reviews
+func (r *ProductRepository) RefreshProductRating(ctx context.Context, productID uuid.UUID) error {
+	r.locked(func(s *store) {
+		p, ok := s.products[productID]
+		if !ok {
+			return
+		}
+
+		total, count := 0, 0
+		for _, review := range s.reviews {
+			if review.ProductID == productID && review.Status == domain.ReviewStatusApproved {
+				total += review.Rating
+				count++
+			}
+		}
+		p.RatingAverage = 0
+		if count > 0 {
+			p.RatingAverage = float64(total) / float64(count)
+		}
+		p.ReviewCount = count
+	})
+	return nil
+}
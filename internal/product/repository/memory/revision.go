@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/product/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+// saveRevision stores a revision of a stored product
+func (s *store) saveRevision(revision domain.ProductRevision) error {
+	if _, ok := s.products[revision.ProductID]; !ok {
+		return foreignKeyViolation("product_revisions", "product_revisions_product_id_fkey")
+	}
+	revision.Patch = slices.Clone(revision.Patch)
+	s.revisions[revision.ID] = revision
+	return nil
+}
+
+func (r *ProductRepository) CreateRevision(ctx context.Context, revision *domain.ProductRevision) error {
+	err := r.atomically(func(s *store) error {
+		now := time.Now()
+		if revision.ID == uuid.Nil {
+			revision.ID = uuid.New()
+		}
+		if revision.CreatedAt.IsZero() {
+			revision.CreatedAt = now
+		}
+		if revision.UpdatedAt.IsZero() {
+			revision.UpdatedAt = now
+		}
+		if revision.Status == "" {
+			revision.Status = domain.RevisionStatusPending
+		}
+		if _, ok := s.revisions[revision.ID]; ok {
+			return uniqueViolation("product_revisions_pkey")
+		}
+		return s.saveRevision(*revision)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create revision: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) GetRevision(ctx context.Context, id uuid.UUID) (*domain.ProductRevision, error) {
+	var revision *domain.ProductRevision
+	r.locked(func(s *store) {
+		if found, ok := s.revisions[id]; ok {
+			found.Patch = slices.Clone(found.Patch)
+			revision = &found
+		}
+	})
+	if revision == nil {
+		return nil, notFound("Revision not found", customErrors.CodeRevisionNotFound)
+	}
+	return revision, nil
+}
+
+func (r *ProductRepository) UpdateRevision(ctx context.Context, revision *domain.ProductRevision) error {
+	err := r.atomically(func(s *store) error {
+		now := time.Now()
+		revision.UpdatedAt = now
+		if revision.CreatedAt.IsZero() {
+			revision.CreatedAt = now
+		}
+		return s.saveRevision(*revision)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update revision: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) ListRevisions(ctx context.Context, filters *domain.RevisionFilters) ([]domain.ProductRevision, int64, error) {
+	var revisions []domain.ProductRevision
+	r.locked(func(s *store) {
+		for _, revision := range s.revisions {
+			if filters.ProductID != nil && revision.ProductID != *filters.ProductID {
+				continue
+			}
+			if filters.Status != "" && revision.Status != filters.Status {
+				continue
+			}
+			revision.Patch = slices.Clone(revision.Patch)
+			revisions = append(revisions, revision)
+		}
+	})
+	slices.SortFunc(revisions, func(a, b domain.ProductRevision) int { return b.CreatedAt.Compare(a.CreatedAt) })
+
+	start, end := page(len(revisions), filters.Offset, filters.Limit)
+	return revisions[start:end], int64(len(revisions)), nil
+}
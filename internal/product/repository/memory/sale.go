@@ -0,0 +1,29 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"ecommerce/internal/product/domain"
+)
+
+// SyncSales flips the sale flag of products whose sale window opened or
+// closed since it was last set and returns them, so each switch of price is
+// announced once
+func (r *ProductRepository) SyncSales(ctx context.Context, limit int) ([]domain.Product, error) {
+	var switched []domain.Product
+	r.locked(func(s *store) {
+		now := time.Now()
+		for _, p := range s.products {
+			if len(switched) == limit {
+				break
+			}
+			if p.DeletedAt.Valid || (p.SalePrice == nil && !p.SaleActive) || p.SaleActive == p.OnSaleAt(now) {
+				continue
+			}
+			p.SaleActive = !p.SaleActive
+			switched = append(switched, *copyProduct(p))
+		}
+	})
+	return switched, nil
+}
@@ -0,0 +1,227 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/product/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+func (r *ProductRepository) CreateSearchSynonym(ctx context.Context, synonym *domain.SearchSynonym) error {
+	err := r.atomically(func(s *store) error {
+		now := time.Now()
+		if synonym.ID == uuid.Nil {
+			synonym.ID = uuid.New()
+		}
+		if synonym.CreatedAt.IsZero() {
+			synonym.CreatedAt = now
+		}
+		if synonym.UpdatedAt.IsZero() {
+			synonym.UpdatedAt = now
+		}
+		return s.saveSynonym(*synonym)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create search synonym: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) GetSearchSynonym(ctx context.Context, id uuid.UUID) (*domain.SearchSynonym, error) {
+	var synonym *domain.SearchSynonym
+	r.locked(func(s *store) {
+		if found, ok := s.synonyms[id]; ok {
+			synonym = copySynonym(found)
+		}
+	})
+	if synonym == nil {
+		return nil, notFound("Search synonym not found", customErrors.CodeSynonymNotFound)
+	}
+	return synonym, nil
+}
+
+func (r *ProductRepository) GetSearchSynonymByTerm(ctx context.Context, term string) (*domain.SearchSynonym, error) {
+	var synonym *domain.SearchSynonym
+	r.locked(func(s *store) {
+		for _, found := range s.synonyms {
+			if found.Term == term {
+				synonym = copySynonym(found)
+			}
+		}
+	})
+	if synonym == nil {
+		return nil, notFound("Search synonym not found", customErrors.CodeSynonymNotFound)
+	}
+	return synonym, nil
+}
+
+func (r *ProductRepository) UpdateSearchSynonym(ctx context.Context, synonym *domain.SearchSynonym) error {
+	err := r.atomically(func(s *store) error {
+		synonym.UpdatedAt = time.Now()
+		return s.saveSynonym(*synonym)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update search synonym: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) DeleteSearchSynonym(ctx context.Context, id uuid.UUID) error {
+	r.locked(func(s *store) {
+		delete(s.synonyms, id)
+	})
+	return nil
+}
+
+// ListSearchSynonyms returns every synonym ordered by term
+func (r *ProductRepository) ListSearchSynonyms(ctx context.Context) ([]domain.SearchSynonym, error) {
+	var synonyms []domain.SearchSynonym
+	r.locked(func(s *store) {
+		for _, synonym := range s.synonyms {
+			synonyms = append(synonyms, *copySynonym(synonym))
+		}
+	})
+	slices.SortFunc(synonyms, func(a, b domain.SearchSynonym) int { return strings.Compare(a.Term, b.Term) })
+	return synonyms, nil
+}
+
+// saveSynonym stores a synonym, keeping terms unique
+func (s *store) saveSynonym(synonym domain.SearchSynonym) error {
+	for _, other := range s.synonyms {
+		if other.ID != synonym.ID && other.Term == synonym.Term {
+			return uniqueViolation("search_synonyms_term_key")
+		}
+	}
+	s.synonyms[synonym.ID] = *copySynonym(synonym)
+	return nil
+}
+
+// copySynonym copies a synonym and its list of words
+func copySynonym(synonym domain.SearchSynonym) *domain.SearchSynonym {
+	synonym.Synonyms = slices.Clone(synonym.Synonyms)
+	return &synonym
+}
+
+// RecordZeroResultSearch counts a search that found nothing
+func (r *ProductRepository) RecordZeroResultSearch(ctx context.Context, query string) error {
+	r.locked(func(s *store) {
+		now := time.Now()
+		for id, search := range s.zeroResults {
+			if search.Query == query {
+				search.Count++
+				search.LastSearchedAt = now
+				s.zeroResults[id] = search
+				return
+			}
+		}
+		id := uuid.New()
+		s.zeroResults[id] = domain.ZeroResultSearch{
+			ID:              id,
+			Query:           query,
+			Count:           1,
+			FirstSearchedAt: now,
+			LastSearchedAt:  now,
+		}
+	})
+	return nil
+}
+
+// ListZeroResultSearches returns zero-result searches, most searched first
+func (r *ProductRepository) ListZeroResultSearches(ctx context.Context, filters *domain.ZeroResultFilters) ([]domain.ZeroResultSearch, int64, error) {
+	var searches []domain.ZeroResultSearch
+	r.locked(func(s *store) {
+		for _, search := range s.zeroResults {
+			if filters.Since != nil && search.LastSearchedAt.Before(*filters.Since) {
+				continue
+			}
+			searches = append(searches, search)
+		}
+	})
+	slices.SortFunc(searches, func(a, b domain.ZeroResultSearch) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), b.LastSearchedAt.Compare(a.LastSearchedAt))
+	})
+
+	start, end := page(len(searches), filters.Offset, filters.Limit)
+	return searches[start:end], int64(len(searches)), nil
+}
+
+// DeleteZeroResultSearch removes a query from the report, reporting whether
+// it was there
+func (r *ProductRepository) DeleteZeroResultSearch(ctx context.Context, id uuid.UUID) (bool, error) {
+	var deleted bool
+	r.locked(func(s *store) {
+		_, deleted = s.zeroResults[id]
+		delete(s.zeroResults, id)
+	})
+	return deleted, nil
+}
+
+// searchWords splits text into lowercase words
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// wordMatches stands in for stemming: a word matches another when either
+// starts with the other, so "shirt" matches "shirts"
+func wordMatches(word, query string) bool {
+	return strings.HasPrefix(word, query) || strings.HasPrefix(query, word)
+}
+
+// textMatches counts how many words of a query appear in text, and whether
+// all of them do
+func textMatches(words []string, query []string) (int, bool) {
+	count := 0
+	for _, q := range query {
+		if slices.ContainsFunc(words, func(word string) bool { return wordMatches(word, q) }) {
+			count++
+		}
+	}
+	return count, count == len(query)
+}
+
+// searchTexts returns a search and its alternatives, split into words
+func searchTexts(filters *domain.ProductFilters) [][]string {
+	texts := make([][]string, 0, len(filters.SearchAlternatives)+1)
+	for _, text := range append([]string{filters.Search}, filters.SearchAlternatives...) {
+		if words := searchWords(text); len(words) > 0 {
+			texts = append(texts, words)
+		}
+	}
+	return texts
+}
+
+// searchMatches reports whether a product's name and description contain
+// every word of the search or of one of its alternatives
+func searchMatches(p *domain.Product, filters *domain.ProductFilters) bool {
+	words := append(searchWords(p.Name), searchWords(p.DescriptionText)...)
+	for _, query := range searchTexts(filters) {
+		if _, all := textMatches(words, query); all {
+			return true
+		}
+	}
+	return false
+}
+
+// searchRank scores a match, weighting words found in the name above those
+// found in the description as the search vector does
+func searchRank(p *domain.Product, filters *domain.ProductFilters) float64 {
+	name := searchWords(p.Name)
+	description := searchWords(p.DescriptionText)
+	var rank float64
+	for _, query := range searchTexts(filters) {
+		inName, _ := textMatches(name, query)
+		inDescription, _ := textMatches(description, query)
+		rank = max(rank, (float64(inName)+0.4*float64(inDescription))/float64(len(query)))
+	}
+	return rank
+}
@@ -0,0 +1,145 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/product/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+func (r *ProductRepository) GetBySlug(ctx context.Context, slug string) (*domain.Product, error) {
+	var product *domain.Product
+	r.locked(func(s *store) {
+		for _, p := range s.products {
+			if !p.DeletedAt.Valid && p.Slug == slug {
+				product = s.loadAll(p)
+			}
+		}
+	})
+	if product == nil {
+		return nil, notFound("Product not found", customErrors.CodeProductNotFound)
+	}
+	return product, nil
+}
+
+func (r *ProductRepository) GetCategoryBySlug(ctx context.Context, slug string) (*domain.Category, error) {
+	var category *domain.Category
+	r.locked(func(s *store) {
+		for _, found := range s.categories {
+			if found.Slug == slug {
+				category = s.loadCategory(found)
+			}
+		}
+	})
+	if category == nil {
+		return nil, notFound("Category not found", customErrors.CodeCategoryNotFound)
+	}
+	return category, nil
+}
+
+// UniqueSlug returns base, or base with the lowest free numeric suffix.
+// Slugs held by other live or deleted entities and their redirects are
+// all considered taken.
+func (r *ProductRepository) UniqueSlug(ctx context.Context, entityType, base string, excludeID uuid.UUID) (string, error) {
+	var slug string
+	var err error
+	r.locked(func(s *store) {
+		slug, err = s.uniqueSlug(entityType, base, excludeID, nil)
+	})
+	return slug, err
+}
+
+// uniqueSlug is UniqueSlug, also avoiding the reserved set
+func (s *store) uniqueSlug(entityType, base string, excludeID uuid.UUID, reserved map[string]bool) (string, error) {
+	if entityType != domain.AuditEntityProduct && entityType != domain.AuditEntityCategory {
+		return "", fmt.Errorf("unsupported slug entity type %q", entityType)
+	}
+	if base == "" {
+		base = entityType
+	}
+
+	used := make(map[string]bool)
+	candidate := func(slug string) bool {
+		return slug == base || strings.HasPrefix(slug, base+"-")
+	}
+	if entityType == domain.AuditEntityProduct {
+		for _, p := range s.products {
+			if p.ID != excludeID && candidate(p.Slug) {
+				used[p.Slug] = true
+			}
+		}
+	} else {
+		for _, category := range s.categories {
+			if category.ID != excludeID && candidate(category.Slug) {
+				used[category.Slug] = true
+			}
+		}
+	}
+	for _, redirect := range s.redirects {
+		if redirect.EntityType == entityType && redirect.EntityID != excludeID && candidate(redirect.Slug) {
+			used[redirect.Slug] = true
+		}
+	}
+
+	slug := base
+	for n := 2; used[slug] || reserved[slug]; n++ {
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+	return slug, nil
+}
+
+func (r *ProductRepository) GetSlugRedirect(ctx context.Context, entityType, slug string) (*domain.SlugRedirect, error) {
+	var redirect *domain.SlugRedirect
+	r.locked(func(s *store) {
+		for _, found := range s.redirects {
+			if found.EntityType == entityType && found.Slug == slug {
+				redirect = &found
+			}
+		}
+	})
+	if redirect == nil {
+		return nil, notFound("Slug redirect not found", "")
+	}
+	return redirect, nil
+}
+
+func (r *ProductRepository) RecordSlugChange(ctx context.Context, entityType string, entityID uuid.UUID, oldSlug, newSlug string) error {
+	r.locked(func(s *store) {
+		// An entity reclaiming one of its old slugs no longer needs the redirect
+		for i, redirect := range s.redirects {
+			if redirect.EntityType == entityType && redirect.Slug == newSlug {
+				s.redirects = slices.Delete(s.redirects, i, i+1)
+				break
+			}
+		}
+
+		if oldSlug != "" {
+			s.redirect(entityType, entityID, oldSlug, time.Now())
+		}
+	})
+	return nil
+}
+
+// redirect points a slug at an entity, replacing any redirect it had
+func (s *store) redirect(entityType string, entityID uuid.UUID, slug string, now time.Time) {
+	for i, redirect := range s.redirects {
+		if redirect.EntityType == entityType && redirect.Slug == slug {
+			s.redirects[i].EntityID = entityID
+			s.redirects[i].CreatedAt = now
+			return
+		}
+	}
+	s.redirects = append(s.redirects, domain.SlugRedirect{
+		ID:         uuid.New(),
+		EntityType: entityType,
+		EntityID:   entityID,
+		Slug:       slug,
+		CreatedAt:  now,
+	})
+}
@@ -0,0 +1,409 @@
+package memory
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/product/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+// ReserveStock deducts stock for every item under the given reference, or
+// for none of them if any product is short. Stock comes from the warehouse
+// the movement names, or else from the highest priority warehouses holding
+// it, with a reservation row for each warehouse drawn on. Backordered and
+// preordered products are never short: what the warehouses cannot cover is
+// charged to the named or default warehouse, taking its stock below zero.
+// Products that hold no stock are reserved without taking any, and bundles
+// take their components' stock in rows of their own. Replaying a reference
+// returns the reservation it already made. Each deduction is recorded in
+// the stock ledger with the given movement.
+func (r *ProductRepository) ReserveStock(ctx context.Context, reference string, items []domain.StockItem, movement domain.StockMovement) ([]domain.StockReservation, error) {
+	var reservations []domain.StockReservation
+	err := r.atomically(func(s *store) error {
+		reservations = s.reservationsFor(reference, "")
+		if len(reservations) > 0 {
+			return nil
+		}
+
+		now := time.Now()
+		for _, item := range domain.BundleDemands(items, s.components) {
+			p, ok := s.live(item.ProductID)
+			if !ok || !p.IsActive || p.Status != domain.ProductStatusPublished || (p.IsPhysical() && p.Stock < item.Quantity && !p.CanOversellAt(now)) {
+				return customErrors.NewConflictError(fmt.Sprintf("Insufficient stock for product %s", item.ProductID), nil).WithCode(customErrors.CodeInsufficientStock)
+			}
+			p.UpdatedAt = now
+			if !p.IsPhysical() {
+				reservations = append(reservations, domain.StockReservation{
+					ID:        uuid.New(),
+					Reference: reference,
+					ProductID: item.ProductID,
+					BundleID:  item.BundleID,
+					Quantity:  item.Quantity,
+					UnitPrice: p.PriceAt(now),
+					Status:    domain.ReservationStatusReserved,
+					CreatedAt: now,
+					UpdatedAt: now,
+				})
+				continue
+			}
+			taken := domain.AllocateStock(s.stockLevels(item.ProductID, movement.WarehouseID, true), item.Quantity)
+			if p.CanOversellAt(now) {
+				warehouseID := s.defaultWarehouse(item.ProductID).ID
+				if movement.WarehouseID != nil {
+					warehouseID = *movement.WarehouseID
+				}
+				taken = domain.BackorderStock(taken, warehouseID, item.Quantity)
+			}
+			remaining := item.Quantity
+			for _, part := range taken {
+				remaining -= part.Quantity
+			}
+			if remaining > 0 {
+				return customErrors.NewConflictError(fmt.Sprintf("Insufficient stock in warehouse for product %s", item.ProductID), nil).WithCode(customErrors.CodeInsufficientStock)
+			}
+
+			for _, part := range taken {
+				warehouseID := part.WarehouseID
+				p.Stock -= part.Quantity
+				p.UpdatedAt = now
+
+				if slices.ContainsFunc(reservations, func(reservation domain.StockReservation) bool {
+					return reservation.ProductID == item.ProductID && reservation.WarehouseID != nil && *reservation.WarehouseID == warehouseID &&
+						sameBundle(reservation.BundleID, item.BundleID)
+				}) {
+					return fmt.Errorf("failed to record stock reservation: %w", uniqueViolation("stock_reservations_reference_product_id_warehouse_id_bundle_id_key"))
+				}
+				reservations = append(reservations, domain.StockReservation{
+					ID:          uuid.New(),
+					Reference:   reference,
+					ProductID:   item.ProductID,
+					WarehouseID: &warehouseID,
+					BundleID:    item.BundleID,
+					Quantity:    part.Quantity,
+					UnitPrice:   p.PriceAt(now),
+					Status:      domain.ReservationStatusReserved,
+					CreatedAt:   now,
+					UpdatedAt:   now,
+				})
+				entry := ledgerEntry(movement, item.ProductID, -part.Quantity, p.Stock)
+				entry.WarehouseID = &warehouseID
+				if _, err := s.record(entry, now); err != nil {
+					return err
+				}
+			}
+		}
+		s.reservations = append(s.reservations, reservations...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+// ReleaseStock returns reserved stock to its products, in the warehouses it
+// was reserved from; reservations without a warehouse took none. Reservations
+// that were already released are left alone, so releasing is safe to repeat.
+// Each return is recorded in the stock ledger with the given movement.
+func (r *ProductRepository) ReleaseStock(ctx context.Context, reference string, movement domain.StockMovement) ([]domain.StockReservation, error) {
+	var released []domain.StockReservation
+	err := r.atomically(func(s *store) error {
+		released = s.reservationsFor(reference, domain.ReservationStatusReserved)
+
+		now := time.Now()
+		for _, reservation := range released {
+			p, ok := s.products[reservation.ProductID]
+			if !ok || reservation.WarehouseID == nil {
+				continue
+			}
+			p.Stock += reservation.Quantity
+			p.UpdatedAt = now
+			entry := ledgerEntry(movement, reservation.ProductID, reservation.Quantity, p.Stock)
+			entry.WarehouseID = reservation.WarehouseID
+			if _, err := s.record(entry, now); err != nil {
+				return err
+			}
+		}
+
+		for i, reservation := range s.reservations {
+			if reservation.Reference == reference && reservation.Status == domain.ReservationStatusReserved {
+				s.reservations[i].Status = domain.ReservationStatusReleased
+				s.reservations[i].UpdatedAt = now
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return released, nil
+}
+
+// ReturnStock puts returned goods back into stock, in the warehouse the
+// movement names or else the default one. Bundles return their components'
+// stock, and products that hold no stock take none back. Stock already
+// returned under the movement's reference is not returned again, so
+// returning is safe to repeat; it then records nothing. Each return is
+// recorded in the stock ledger with the given movement.
+func (r *ProductRepository) ReturnStock(ctx context.Context, items []domain.StockItem, movement domain.StockMovement) ([]domain.StockMovement, error) {
+	var movements []domain.StockMovement
+	err := r.atomically(func(s *store) error {
+		for _, entry := range s.movements {
+			if entry.Reason == movement.Reason && entry.Reference == movement.Reference {
+				return nil
+			}
+		}
+
+		now := time.Now()
+		for _, demand := range domain.BundleDemands(items, s.components) {
+			p, ok := s.products[demand.ProductID]
+			if !ok || !p.IsPhysical() {
+				continue
+			}
+			p.Stock += demand.Quantity
+			p.UpdatedAt = now
+			entry, err := s.record(ledgerEntry(movement, demand.ProductID, demand.Quantity, p.Stock), now)
+			if err != nil {
+				return err
+			}
+			movements = append(movements, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+func (r *ProductRepository) GetStockReservations(ctx context.Context, reference string) ([]domain.StockReservation, error) {
+	var reservations []domain.StockReservation
+	r.locked(func(s *store) {
+		reservations = s.reservationsFor(reference, "")
+	})
+	return reservations, nil
+}
+
+// reservationsFor returns the reservations under a reference, optionally
+// only those with the given status, ordered by product and warehouse
+func (s *store) reservationsFor(reference, status string) []domain.StockReservation {
+	var reservations []domain.StockReservation
+	for _, reservation := range s.reservations {
+		if reservation.Reference == reference && (status == "" || reservation.Status == status) {
+			reservations = append(reservations, reservation)
+		}
+	}
+	slices.SortFunc(reservations, func(a, b domain.StockReservation) int {
+		return cmp.Or(bytes.Compare(a.ProductID[:], b.ProductID[:]), compareWarehouses(a.WarehouseID, b.WarehouseID))
+	})
+	return reservations
+}
+
+// sameBundle reports whether two reservation rows were taken for the same
+// bundle, or both for no bundle
+func sameBundle(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// compareWarehouses orders warehouse IDs as Postgres does, with no
+// warehouse last
+func compareWarehouses(a, b *uuid.UUID) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	return bytes.Compare(a[:], b[:])
+}
+
+// SetStock sets a product's stock to an absolute level, such as after a
+// stock count, and records the difference in the stock ledger. When the
+// movement names a warehouse, the level is the stock held there; otherwise
+// it is the product's total, and the difference goes to the default
+// warehouse. It returns nil when the stock is already at that level.
+func (r *ProductRepository) SetStock(ctx context.Context, id uuid.UUID, stock int, movement domain.StockMovement) (*domain.StockMovement, error) {
+	var recorded *domain.StockMovement
+	err := r.atomically(func(s *store) error {
+		p, ok := s.live(id)
+		if !ok {
+			return customErrors.NewNotFoundError("Product not found", nil).WithCode(customErrors.CodeProductNotFound)
+		}
+
+		level := p.Stock
+		if movement.WarehouseID != nil {
+			level = s.levels[levelKey{product: id, warehouse: *movement.WarehouseID}].Quantity
+		}
+		if level == stock {
+			return nil
+		}
+
+		now := time.Now()
+		delta := stock - level
+		p.Stock += delta
+		p.UpdatedAt = now
+		entry, err := s.record(ledgerEntry(movement, id, delta, p.Stock), now)
+		if err != nil {
+			return err
+		}
+		recorded = &entry
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return recorded, nil
+}
+
+// AdjustStock adds delta to a product's stock, which may be negative, and
+// records it in the stock ledger. The change applies to the warehouse the
+// movement names, or else the default one. Stock cannot be taken below
+// zero, in total or in the warehouse, except in a warehouse of a product
+// that can be oversold; stock already below zero can still be added to.
+func (r *ProductRepository) AdjustStock(ctx context.Context, id uuid.UUID, delta int, movement domain.StockMovement) (*domain.StockMovement, error) {
+	var entry domain.StockMovement
+	err := r.atomically(func(s *store) error {
+		p, ok := s.live(id)
+		if !ok {
+			return customErrors.NewNotFoundError("Product not found", nil).WithCode(customErrors.CodeProductNotFound)
+		}
+		if p.Stock+delta < 0 && delta < 0 {
+			return customErrors.NewConflictError("Insufficient stock for adjustment", nil).WithCode(customErrors.CodeInsufficientStock)
+		}
+
+		now := time.Now()
+		p.Stock += delta
+		p.UpdatedAt = now
+		var err error
+		entry, err = s.record(ledgerEntry(movement, id, delta, p.Stock), now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// ListStockMovements lists a product's stock ledger, newest first
+func (r *ProductRepository) ListStockMovements(ctx context.Context, productID uuid.UUID, filters *domain.StockMovementFilters) ([]domain.StockMovement, int64, error) {
+	var movements []domain.StockMovement
+	r.locked(func(s *store) {
+		for _, movement := range s.movements {
+			if movement.ProductID == productID && (filters.Reason == "" || movement.Reason == filters.Reason) {
+				movements = append(movements, movement)
+			}
+		}
+	})
+	slices.SortFunc(movements, func(a, b domain.StockMovement) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), bytes.Compare(b.ID[:], a.ID[:]))
+	})
+
+	start, end := page(len(movements), filters.Offset, filters.Limit)
+	return movements[start:end], int64(len(movements)), nil
+}
+
+// StockDrift finds products whose stock does not equal the sum of their
+// stock ledger, which means stock was changed without being recorded
+func (r *ProductRepository) StockDrift(ctx context.Context, limit int) ([]domain.StockDrift, error) {
+	var drift []domain.StockDrift
+	r.locked(func(s *store) {
+		ledger := make(map[uuid.UUID]int)
+		for _, movement := range s.movements {
+			ledger[movement.ProductID] += movement.Delta
+		}
+		for _, p := range s.products {
+			if p.Stock != ledger[p.ID] {
+				drift = append(drift, domain.StockDrift{ProductID: p.ID, Stock: p.Stock, Ledger: ledger[p.ID]})
+			}
+		}
+	})
+	slices.SortFunc(drift, func(a, b domain.StockDrift) int { return bytes.Compare(a.ProductID[:], b.ProductID[:]) })
+
+	start, end := page(len(drift), 0, limit)
+	return drift[start:end], nil
+}
+
+// ListLowStock lists live, active products at or below their low stock
+// threshold, lowest stock first
+func (r *ProductRepository) ListLowStock(ctx context.Context, defaultThreshold int, filters *domain.LowStockFilters) ([]domain.Product, int64, error) {
+	var products []domain.Product
+	r.locked(func(s *store) {
+		for _, p := range s.products {
+			if p.DeletedAt.Valid || !p.IsActive || !p.IsLowStock(defaultThreshold) {
+				continue
+			}
+			if filters.CategoryID != nil && p.CategoryID != *filters.CategoryID {
+				continue
+			}
+			products = append(products, s.load(p, true, true, false))
+		}
+	})
+	slices.SortFunc(products, func(a, b domain.Product) int {
+		return cmp.Or(cmp.Compare(a.Stock, b.Stock), strings.Compare(a.Name, b.Name))
+	})
+
+	start, end := page(len(products), filters.Offset, filters.Limit)
+	return products[start:end], int64(len(products)), nil
+}
+
+// MarkLowStock records a low stock alert on live, active products that are
+// at or below their threshold and have not been reported yet, and returns
+// the products it marked. Only the given products are considered, or every
+// product when ids is empty.
+func (r *ProductRepository) MarkLowStock(ctx context.Context, defaultThreshold int, ids []uuid.UUID, limit int) ([]domain.Product, error) {
+	var marked []domain.Product
+	r.locked(func(s *store) {
+		var due []*domain.Product
+		for _, p := range s.products {
+			if p.DeletedAt.Valid || !p.IsActive || p.LowStockAlertedAt != nil || !p.IsLowStock(defaultThreshold) {
+				continue
+			}
+			if len(ids) > 0 && !slices.Contains(ids, p.ID) {
+				continue
+			}
+			due = append(due, p)
+		}
+		slices.SortFunc(due, func(a, b *domain.Product) int { return cmp.Compare(a.Stock, b.Stock) })
+
+		now := time.Now()
+		start, end := page(len(due), 0, limit)
+		for _, p := range due[start:end] {
+			p.LowStockAlertedAt = &now
+			marked = append(marked, *copyProduct(p))
+		}
+	})
+	return marked, nil
+}
+
+// ResetLowStock clears the low stock alert of products whose stock has
+// recovered, so their next shortage is reported again. Only the given
+// products are considered, or every product when ids is empty.
+func (r *ProductRepository) ResetLowStock(ctx context.Context, defaultThreshold int, ids []uuid.UUID) (int64, error) {
+	var reset int64
+	r.locked(func(s *store) {
+		for _, p := range s.products {
+			if p.LowStockAlertedAt == nil || p.IsLowStock(defaultThreshold) {
+				continue
+			}
+			if len(ids) > 0 && !slices.Contains(ids, p.ID) {
+				continue
+			}
+			p.LowStockAlertedAt = nil
+			reset++
+		}
+	})
+	return reset, nil
+}
@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/product/domain"
+)
+
+// ReplacePriceTiers replaces the price tiers of a product
+func (r *ProductRepository) ReplacePriceTiers(ctx context.Context, productID uuid.UUID, tiers []domain.PriceTier) error {
+	return r.atomically(func(s *store) error {
+		s.tiers = slices.DeleteFunc(s.tiers, func(tier domain.PriceTier) bool {
+			return tier.ProductID == productID
+		})
+		if len(tiers) == 0 {
+			return nil
+		}
+		if _, ok := s.products[productID]; !ok {
+			return fmt.Errorf("failed to add price tiers: %w", foreignKeyViolation("product_price_tiers", "product_price_tiers_product_id_fkey"))
+		}
+		now := time.Now()
+		for i := range tiers {
+			tiers[i].ProductID = productID
+			if tiers[i].ID == uuid.Nil {
+				tiers[i].ID = uuid.New()
+			}
+			if tiers[i].CreatedAt.IsZero() {
+				tiers[i].CreatedAt = now
+			}
+			if slices.ContainsFunc(s.tiers, func(other domain.PriceTier) bool {
+				return other.ProductID == productID && other.CustomerGroup == tiers[i].CustomerGroup && other.MinQuantity == tiers[i].MinQuantity
+			}) {
+				return fmt.Errorf("failed to add price tiers: %w", uniqueViolation("product_price_tiers_product_id_customer_group_min_quantity_key"))
+			}
+			s.tiers = append(s.tiers, tiers[i])
+		}
+		return nil
+	})
+}
+
+// ListPriceTiers lists the price tiers of products, by product, group and
+// minimum quantity
+func (r *ProductRepository) ListPriceTiers(ctx context.Context, productIDs []uuid.UUID) ([]domain.PriceTier, error) {
+	var tiers []domain.PriceTier
+	r.locked(func(s *store) {
+		for _, tier := range s.tiers {
+			if slices.Contains(productIDs, tier.ProductID) {
+				tiers = append(tiers, tier)
+			}
+		}
+	})
+	slices.SortFunc(tiers, func(a, b domain.PriceTier) int {
+		return cmp.Or(
+			bytes.Compare(a.ProductID[:], b.ProductID[:]),
+			cmp.Compare(a.CustomerGroup, b.CustomerGroup),
+			cmp.Compare(a.MinQuantity, b.MinQuantity),
+		)
+	})
+	return tiers, nil
+}
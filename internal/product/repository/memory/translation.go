@@ -0,0 +1,147 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/product/domain"
+)
+
+func (r *ProductRepository) UpsertProductTranslation(ctx context.Context, translation *domain.ProductTranslation) error {
+	err := r.atomically(func(s *store) error {
+		now := time.Now()
+		key := translationKey{id: translation.ProductID, locale: translation.Locale}
+		if existing, ok := s.productTranslations[key]; ok {
+			existing.Name = translation.Name
+			existing.Description = translation.Description
+			existing.UpdatedAt = now
+			s.productTranslations[key] = existing
+			return nil
+		}
+
+		if _, ok := s.products[translation.ProductID]; !ok {
+			return foreignKeyViolation("product_translations", "product_translations_product_id_fkey")
+		}
+		if translation.CreatedAt.IsZero() {
+			translation.CreatedAt = now
+		}
+		if translation.UpdatedAt.IsZero() {
+			translation.UpdatedAt = now
+		}
+		s.productTranslations[key] = *translation
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save product translation: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) DeleteProductTranslation(ctx context.Context, productID uuid.UUID, locale string) (bool, error) {
+	var deleted bool
+	r.locked(func(s *store) {
+		key := translationKey{id: productID, locale: locale}
+		_, deleted = s.productTranslations[key]
+		delete(s.productTranslations, key)
+	})
+	return deleted, nil
+}
+
+func (r *ProductRepository) ListProductTranslations(ctx context.Context, productID uuid.UUID) ([]domain.ProductTranslation, error) {
+	var translations []domain.ProductTranslation
+	r.locked(func(s *store) {
+		for key, translation := range s.productTranslations {
+			if key.id == productID {
+				translations = append(translations, translation)
+			}
+		}
+	})
+	slices.SortFunc(translations, func(a, b domain.ProductTranslation) int { return strings.Compare(a.Locale, b.Locale) })
+	return translations, nil
+}
+
+// GetProductTranslations loads the translations of products into a locale,
+// keyed by product ID. Products without one are left out.
+func (r *ProductRepository) GetProductTranslations(ctx context.Context, ids []uuid.UUID, locale string) (map[uuid.UUID]domain.ProductTranslation, error) {
+	translations := make(map[uuid.UUID]domain.ProductTranslation)
+	r.locked(func(s *store) {
+		for _, id := range ids {
+			if translation, ok := s.productTranslations[translationKey{id: id, locale: locale}]; ok {
+				translations[id] = translation
+			}
+		}
+	})
+	return translations, nil
+}
+
+func (r *ProductRepository) UpsertCategoryTranslation(ctx context.Context, translation *domain.CategoryTranslation) error {
+	err := r.atomically(func(s *store) error {
+		now := time.Now()
+		key := translationKey{id: translation.CategoryID, locale: translation.Locale}
+		if existing, ok := s.categoryTranslations[key]; ok {
+			existing.Name = translation.Name
+			existing.Description = translation.Description
+			existing.UpdatedAt = now
+			s.categoryTranslations[key] = existing
+			return nil
+		}
+
+		if _, ok := s.categories[translation.CategoryID]; !ok {
+			return foreignKeyViolation("category_translations", "category_translations_category_id_fkey")
+		}
+		if translation.CreatedAt.IsZero() {
+			translation.CreatedAt = now
+		}
+		if translation.UpdatedAt.IsZero() {
+			translation.UpdatedAt = now
+		}
+		s.categoryTranslations[key] = *translation
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save category translation: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) DeleteCategoryTranslation(ctx context.Context, categoryID uuid.UUID, locale string) (bool, error) {
+	var deleted bool
+	r.locked(func(s *store) {
+		key := translationKey{id: categoryID, locale: locale}
+		_, deleted = s.categoryTranslations[key]
+		delete(s.categoryTranslations, key)
+	})
+	return deleted, nil
+}
+
+func (r *ProductRepository) ListCategoryTranslations(ctx context.Context, categoryID uuid.UUID) ([]domain.CategoryTranslation, error) {
+	var translations []domain.CategoryTranslation
+	r.locked(func(s *store) {
+		for key, translation := range s.categoryTranslations {
+			if key.id == categoryID {
+				translations = append(translations, translation)
+			}
+		}
+	})
+	slices.SortFunc(translations, func(a, b domain.CategoryTranslation) int { return strings.Compare(a.Locale, b.Locale) })
+	return translations, nil
+}
+
+// GetCategoryTranslations loads the translations of categories into a
+// locale, keyed by category ID. Categories without one are left out.
+func (r *ProductRepository) GetCategoryTranslations(ctx context.Context, ids []uuid.UUID, locale string) (map[uuid.UUID]domain.CategoryTranslation, error) {
+	translations := make(map[uuid.UUID]domain.CategoryTranslation)
+	r.locked(func(s *store) {
+		for _, id := range ids {
+			if translation, ok := s.categoryTranslations[translationKey{id: id, locale: locale}]; ok {
+				translations[id] = translation
+			}
+		}
+	})
+	return translations, nil
+}
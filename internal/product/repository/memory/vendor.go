@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/product/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+// vendorOwnerKey is the unique constraint giving each user at most one
+// vendor, as named in Postgres
+const vendorOwnerKey = "vendors_owner_id_key"
+
+// saveVendor stores a vendor, keeping owners unique
+func (s *store) saveVendor(vendor domain.Vendor) error {
+	for _, other := range s.vendors {
+		if other.ID != vendor.ID && other.OwnerID == vendor.OwnerID {
+			return customErrors.NewConflictError("User already owns a vendor", uniqueViolation(vendorOwnerKey)).WithCode(customErrors.CodeVendorOwnerConflict)
+		}
+	}
+	s.vendors[vendor.ID] = vendor
+	return nil
+}
+
+func (r *ProductRepository) CreateVendor(ctx context.Context, vendor *domain.Vendor) error {
+	err := r.atomically(func(s *store) error {
+		now := time.Now()
+		if vendor.ID == uuid.Nil {
+			vendor.ID = uuid.New()
+		}
+		if vendor.CreatedAt.IsZero() {
+			vendor.CreatedAt = now
+		}
+		if vendor.UpdatedAt.IsZero() {
+			vendor.UpdatedAt = now
+		}
+		if vendor.Status == "" {
+			vendor.Status = domain.VendorStatusActive
+		}
+		if _, ok := s.vendors[vendor.ID]; ok {
+			return uniqueViolation("vendors_pkey")
+		}
+		return s.saveVendor(*vendor)
+	})
+	if customErrors.IsConflict(err) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create vendor: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) GetVendor(ctx context.Context, id uuid.UUID) (*domain.Vendor, error) {
+	var vendor *domain.Vendor
+	r.locked(func(s *store) {
+		if found, ok := s.vendors[id]; ok {
+			vendor = &found
+		}
+	})
+	if vendor == nil {
+		return nil, notFound("Vendor not found", customErrors.CodeVendorNotFound)
+	}
+	return vendor, nil
+}
+
+func (r *ProductRepository) GetVendorByOwner(ctx context.Context, ownerID string) (*domain.Vendor, error) {
+	var vendor *domain.Vendor
+	r.locked(func(s *store) {
+		for _, found := range s.vendors {
+			if found.OwnerID == ownerID {
+				vendor = &found
+			}
+		}
+	})
+	if vendor == nil {
+		return nil, notFound("Vendor not found", customErrors.CodeVendorNotFound)
+	}
+	return vendor, nil
+}
+
+func (r *ProductRepository) UpdateVendor(ctx context.Context, vendor *domain.Vendor) error {
+	err := r.atomically(func(s *store) error {
+		now := time.Now()
+		vendor.UpdatedAt = now
+		if vendor.CreatedAt.IsZero() {
+			vendor.CreatedAt = now
+		}
+		return s.saveVendor(*vendor)
+	})
+	if customErrors.IsConflict(err) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update vendor: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) ListVendors(ctx context.Context) ([]domain.Vendor, error) {
+	var vendors []domain.Vendor
+	r.locked(func(s *store) {
+		for _, vendor := range s.vendors {
+			vendors = append(vendors, vendor)
+		}
+	})
+	slices.SortFunc(vendors, func(a, b domain.Vendor) int {
+		return cmp.Or(strings.Compare(a.Name, b.Name), strings.Compare(a.ID.String(), b.ID.String()))
+	})
+	return vendors, nil
+}
@@ -0,0 +1,196 @@
+package memory
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/product/domain"
+	customErrors "ecommerce/pkg/errors"
+	"ecommerce/pkg/tenant"
+)
+
+// warehouseCodeKey is the unique constraint keeping warehouse codes
+// unique within a tenant, as named in Postgres
+const warehouseCodeKey = "warehouses_code_key"
+
+// defaultWarehouse returns the warehouse a product's stock changes that
+// name none go to: the default warehouse of the product's tenant, created
+// for a tenant that has none yet
+func (s *store) defaultWarehouse(productID uuid.UUID) domain.Warehouse {
+	tenantID := tenant.Default
+	if p, ok := s.products[productID]; ok && p.TenantID != "" {
+		tenantID = p.TenantID
+	}
+	for _, warehouse := range s.warehouses {
+		if warehouse.IsDefault && warehouse.TenantID == tenantID {
+			return warehouse
+		}
+	}
+
+	now := time.Now()
+	warehouse := domain.Warehouse{
+		ID:        uuid.New(),
+		TenantID:  tenantID,
+		Code:      domain.DefaultWarehouseCode,
+		Name:      "Default warehouse",
+		IsDefault: true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	s.warehouses[warehouse.ID] = warehouse
+	return warehouse
+}
+
+// saveWarehouse stores a warehouse, keeping codes unique within its tenant
+func (s *store) saveWarehouse(warehouse domain.Warehouse) error {
+	for _, other := range s.warehouses {
+		if other.ID != warehouse.ID && other.TenantID == warehouse.TenantID && other.Code == warehouse.Code {
+			return customErrors.NewConflictError("Warehouse code already exists", uniqueViolation(warehouseCodeKey)).WithCode(customErrors.CodeWarehouseCodeConflict)
+		}
+	}
+	s.warehouses[warehouse.ID] = warehouse
+	return nil
+}
+
+// inTenant reports whether a row of a tenant is seen by ctx: system
+// contexts see every tenant's
+func inTenant(ctx context.Context, tenantID string) bool {
+	id, ok := tenant.FromContext(ctx)
+	return !ok || id == tenantID
+}
+
+func (r *ProductRepository) CreateWarehouse(ctx context.Context, warehouse *domain.Warehouse) error {
+	err := r.atomically(func(s *store) error {
+		now := time.Now()
+		if warehouse.ID == uuid.Nil {
+			warehouse.ID = uuid.New()
+		}
+		if warehouse.CreatedAt.IsZero() {
+			warehouse.CreatedAt = now
+		}
+		if warehouse.UpdatedAt.IsZero() {
+			warehouse.UpdatedAt = now
+		}
+		// is_default is read-only; only the schema and the first stock
+		// change of a tenant create the default
+		warehouse.IsDefault = false
+		warehouse.TenantID = tenant.ID(ctx)
+		if _, ok := s.warehouses[warehouse.ID]; ok {
+			return uniqueViolation("warehouses_pkey")
+		}
+		return s.saveWarehouse(*warehouse)
+	})
+	if customErrors.IsConflict(err) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create warehouse: %w", err)
+	}
+	return nil
+}
+
+func (r *ProductRepository) GetWarehouse(ctx context.Context, id uuid.UUID) (*domain.Warehouse, error) {
+	var warehouse *domain.Warehouse
+	r.locked(func(s *store) {
+		if found, ok := s.warehouses[id]; ok && inTenant(ctx, found.TenantID) {
+			warehouse = &found
+		}
+	})
+	if warehouse == nil {
+		return nil, notFound("Warehouse not found", customErrors.CodeWarehouseNotFound)
+	}
+	return warehouse, nil
+}
+
+func (r *ProductRepository) GetWarehouseByCode(ctx context.Context, code string) (*domain.Warehouse, error) {
+	var warehouse *domain.Warehouse
+	r.locked(func(s *store) {
+		for _, found := range s.warehouses {
+			if found.Code == code && inTenant(ctx, found.TenantID) {
+				warehouse = &found
+			}
+		}
+	})
+	if warehouse == nil {
+		return nil, notFound("Warehouse not found", customErrors.CodeWarehouseNotFound)
+	}
+	return warehouse, nil
+}
+
+func (r *ProductRepository) UpdateWarehouse(ctx context.Context, warehouse *domain.Warehouse) error {
+	err := r.atomically(func(s *store) error {
+		existing, ok := s.warehouses[warehouse.ID]
+		if ok {
+			warehouse.IsDefault = existing.IsDefault
+			warehouse.TenantID = existing.TenantID
+		}
+		warehouse.UpdatedAt = time.Now()
+		return s.saveWarehouse(*warehouse)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update warehouse: %w", err)
+	}
+	return nil
+}
+
+// ListWarehouses lists warehouses in the order reservations take stock
+// from them: highest priority first
+func (r *ProductRepository) ListWarehouses(ctx context.Context) ([]domain.Warehouse, error) {
+	var warehouses []domain.Warehouse
+	r.locked(func(s *store) {
+		for _, warehouse := range s.warehouses {
+			if inTenant(ctx, warehouse.TenantID) {
+				warehouses = append(warehouses, warehouse)
+			}
+		}
+	})
+	slices.SortFunc(warehouses, func(a, b domain.Warehouse) int {
+		return cmp.Or(cmp.Compare(b.Priority, a.Priority), strings.Compare(a.Code, b.Code))
+	})
+	return warehouses, nil
+}
+
+// ListStockLevels lists the stock levels of products, optionally in one
+// warehouse only, by product and then in the order stock is taken from
+// their warehouses
+func (r *ProductRepository) ListStockLevels(ctx context.Context, productIDs []uuid.UUID, warehouseID *uuid.UUID) ([]domain.StockLevel, error) {
+	var levels []domain.StockLevel
+	r.locked(func(s *store) {
+		for _, id := range productIDs {
+			levels = append(levels, s.stockLevels(id, warehouseID, false)...)
+		}
+	})
+	slices.SortStableFunc(levels, func(a, b domain.StockLevel) int {
+		return bytes.Compare(a.ProductID[:], b.ProductID[:])
+	})
+	return levels, nil
+}
+
+// stockLevels returns a product's stock levels, in one warehouse or in all
+// of them, in the order reservations take from them: highest priority
+// first, then those holding the most. With held, empty levels are left out.
+func (s *store) stockLevels(productID uuid.UUID, warehouseID *uuid.UUID, held bool) []domain.StockLevel {
+	var levels []domain.StockLevel
+	for key, level := range s.levels {
+		if key.product != productID || (warehouseID != nil && key.warehouse != *warehouseID) || (held && level.Quantity <= 0) {
+			continue
+		}
+		level.WarehouseCode = s.warehouses[key.warehouse].Code
+		levels = append(levels, level)
+	}
+	slices.SortFunc(levels, func(a, b domain.StockLevel) int {
+		return cmp.Or(
+			cmp.Compare(s.warehouses[b.WarehouseID].Priority, s.warehouses[a.WarehouseID].Priority),
+			cmp.Compare(b.Quantity, a.Quantity),
+			strings.Compare(a.WarehouseCode, b.WarehouseCode),
+		)
+	})
+	return levels
+}
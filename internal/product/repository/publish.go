@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/tenant"
+)
+
+// PublishDue publishes the drafts whose scheduled publish time has passed
+// and returns them. Vendor products wait for their approval. Rows locked by
+// another instance are skipped.
+func (r *productRepository) PublishDue(ctx context.Context, limit int) ([]domain.Product, error) {
+	now := time.Now()
+
+	var published []uuid.UUID
+	err := r.conn(ctx).Raw(`
+		UPDATE products SET status = ?, published_at = ?, publish_at = NULL, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM products
+			WHERE deleted_at IS NULL AND status = ? AND publish_at <= ? AND approval_status IN ? AND ?
+			ORDER BY publish_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id`,
+		domain.ProductStatusPublished, now, now, domain.ProductStatusDraft, now, []string{"", domain.ApprovalApproved}, tenant.Filter(ctx, "products"), limit,
+	).Scan(&published).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish scheduled products: %w", err)
+	}
+	if len(published) == 0 {
+		return nil, nil
+	}
+	r.invalidateProductIDs(ctx, published)
+
+	var products []domain.Product
+	if err := r.conn(ctx).Where("id IN ?", published).Find(&products).Error; err != nil {
+		return nil, fmt.Errorf("failed to get published products: %w", err)
+	}
+	return products, nil
+}
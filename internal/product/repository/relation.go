@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+
+	"ecommerce/internal/product/domain"
+)
+
+func (r *productRepository) UpsertRelation(ctx context.Context, relation *domain.ProductRelation) error {
+	err := r.conn(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "product_id"}, {Name: "related_id"}, {Name: "type"}},
+			DoUpdates: clause.AssignmentColumns([]string{"position"}),
+		}).
+		Create(relation).Error
+	if err != nil {
+		return fmt.Errorf("failed to save product relation: %w", err)
+	}
+	return nil
+}
+
+func (r *productRepository) DeleteRelation(ctx context.Context, productID, relatedID uuid.UUID, relationType string) (bool, error) {
+	query := r.conn(ctx).Where("product_id = ? AND related_id = ?", productID, relatedID)
+	if relationType != "" {
+		query = query.Where("type = ?", relationType)
+	}
+
+	result := query.Delete(&domain.ProductRelation{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete product relation: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *productRepository) ListRelations(ctx context.Context, productID uuid.UUID, relationType string) ([]domain.ProductRelation, error) {
+	query := r.conn(ctx).Where("product_id = ?", productID)
+	if relationType != "" {
+		query = query.Where("type = ?", relationType)
+	}
+
+	var relations []domain.ProductRelation
+	if err := query.Order("type ASC, position ASC, created_at ASC").Find(&relations).Error; err != nil {
+		return nil, fmt.Errorf("failed to list product relations: %w", err)
+	}
+
+	return relations, nil
+}
@@ -2,9 +2,10 @@ package repository
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 	"time"
 
@@ -13,18 +14,29 @@ import (
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 
+	"ecommerce/internal/product/config"
 	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/cache"
+	"ecommerce/pkg/database"
 	customErrors "ecommerce/pkg/errors"
+	"ecommerce/pkg/localcache"
+	"ecommerce/pkg/logger"
+	"ecommerce/pkg/tenant"
 )
 
 // ProductRepository defines the product repository interface
 type ProductRepository interface {
-	Create(ctx context.Context, product *domain.Product) error
+	Create(ctx context.Context, product *domain.Product, movement domain.StockMovement) error
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Product, error)
+	GetByIDs(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*domain.Product, error)
 	GetBySKU(ctx context.Context, sku string) (*domain.Product, error)
 	Update(ctx context.Context, product *domain.Product) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	GetDeleted(ctx context.Context, id uuid.UUID) (*domain.Product, error)
+	Restore(ctx context.Context, id uuid.UUID) error
 	List(ctx context.Context, filters *domain.ProductFilters) ([]domain.Product, int64, error)
+	Facets(ctx context.Context, filters *domain.ProductFilters) (*domain.ProductFacets, error)
+	Iterate(ctx context.Context, filters *domain.ProductFilters, batchSize int, fn func([]domain.Product) error) error
 
 	CreateCategory(ctx context.Context, category *domain.Category) error
 	GetCategory(ctx context.Context, id uuid.UUID) (*domain.Category, error)
@@ -32,72 +44,364 @@ type ProductRepository interface {
 	UpdateCategory(ctx context.Context, category *domain.Category) error
 	DeleteCategory(ctx context.Context, id uuid.UUID) error
 	ListCategories(ctx context.Context) ([]domain.Category, error)
+	ListCategorySubtree(ctx context.Context, rootID *uuid.UUID) ([]domain.Category, error)
+	GetCategoryAncestorIDs(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error)
+	GetCategoryPaths(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]domain.Breadcrumb, error)
+	CategoryProductCounts(ctx context.Context) (map[uuid.UUID]domain.CategoryProductCount, error)
+	MoveCategory(ctx context.Context, id uuid.UUID, parentID *uuid.UUID) error
+	MergeCategory(ctx context.Context, sourceID, targetID uuid.UUID) ([]uuid.UUID, error)
+
+	CreateBrand(ctx context.Context, brand *domain.Brand) error
+	GetBrand(ctx context.Context, id uuid.UUID) (*domain.Brand, error)
+	GetBrandByName(ctx context.Context, name string) (*domain.Brand, error)
+	UpdateBrand(ctx context.Context, brand *domain.Brand) error
+	DeleteBrand(ctx context.Context, id uuid.UUID) error
+	ListBrands(ctx context.Context) ([]domain.Brand, error)
+
+	CreateVendor(ctx context.Context, vendor *domain.Vendor) error
+	GetVendor(ctx context.Context, id uuid.UUID) (*domain.Vendor, error)
+	GetVendorByOwner(ctx context.Context, ownerID string) (*domain.Vendor, error)
+	UpdateVendor(ctx context.Context, vendor *domain.Vendor) error
+	ListVendors(ctx context.Context) ([]domain.Vendor, error)
+
+	CreateWarehouse(ctx context.Context, warehouse *domain.Warehouse) error
+	GetWarehouse(ctx context.Context, id uuid.UUID) (*domain.Warehouse, error)
+	GetWarehouseByCode(ctx context.Context, code string) (*domain.Warehouse, error)
+	UpdateWarehouse(ctx context.Context, warehouse *domain.Warehouse) error
+	ListWarehouses(ctx context.Context) ([]domain.Warehouse, error)
+	ListStockLevels(ctx context.Context, productIDs []uuid.UUID, warehouseID *uuid.UUID) ([]domain.StockLevel, error)
+
+	CreateAttributeDefinition(ctx context.Context, def *domain.AttributeDefinition) error
+	GetAttributeDefinition(ctx context.Context, id uuid.UUID) (*domain.AttributeDefinition, error)
+	UpdateAttributeDefinition(ctx context.Context, def *domain.AttributeDefinition) error
+	DeleteAttributeDefinition(ctx context.Context, id uuid.UUID) error
+	ListAttributeDefinitions(ctx context.Context, categoryID uuid.UUID) ([]domain.AttributeDefinition, error)
+	ReplaceProductAttributes(ctx context.Context, productID uuid.UUID, attributes []domain.ProductAttribute) error
+
+	CreateSearchSynonym(ctx context.Context, synonym *domain.SearchSynonym) error
+	GetSearchSynonym(ctx context.Context, id uuid.UUID) (*domain.SearchSynonym, error)
+	GetSearchSynonymByTerm(ctx context.Context, term string) (*domain.SearchSynonym, error)
+	UpdateSearchSynonym(ctx context.Context, synonym *domain.SearchSynonym) error
+	DeleteSearchSynonym(ctx context.Context, id uuid.UUID) error
+	ListSearchSynonyms(ctx context.Context) ([]domain.SearchSynonym, error)
+	RecordZeroResultSearch(ctx context.Context, query string) error
+	ListZeroResultSearches(ctx context.Context, filters *domain.ZeroResultFilters) ([]domain.ZeroResultSearch, int64, error)
+	DeleteZeroResultSearch(ctx context.Context, id uuid.UUID) (bool, error)
+
+	GetBySlug(ctx context.Context, slug string) (*domain.Product, error)
+	GetCategoryBySlug(ctx context.Context, slug string) (*domain.Category, error)
+	UniqueSlug(ctx context.Context, entityType, base string, excludeID uuid.UUID) (string, error)
+	GetSlugRedirect(ctx context.Context, entityType, slug string) (*domain.SlugRedirect, error)
+	RecordSlugChange(ctx context.Context, entityType string, entityID uuid.UUID, oldSlug, newSlug string) error
+
+	UpsertRelation(ctx context.Context, relation *domain.ProductRelation) error
+	DeleteRelation(ctx context.Context, productID, relatedID uuid.UUID, relationType string) (bool, error)
+	ListRelations(ctx context.Context, productID uuid.UUID, relationType string) ([]domain.ProductRelation, error)
+
+	UpsertProductTranslation(ctx context.Context, translation *domain.ProductTranslation) error
+	DeleteProductTranslation(ctx context.Context, productID uuid.UUID, locale string) (bool, error)
+	ListProductTranslations(ctx context.Context, productID uuid.UUID) ([]domain.ProductTranslation, error)
+	GetProductTranslations(ctx context.Context, ids []uuid.UUID, locale string) (map[uuid.UUID]domain.ProductTranslation, error)
+	UpsertCategoryTranslation(ctx context.Context, translation *domain.CategoryTranslation) error
+	DeleteCategoryTranslation(ctx context.Context, categoryID uuid.UUID, locale string) (bool, error)
+	ListCategoryTranslations(ctx context.Context, categoryID uuid.UUID) ([]domain.CategoryTranslation, error)
+	GetCategoryTranslations(ctx context.Context, ids []uuid.UUID, locale string) (map[uuid.UUID]domain.CategoryTranslation, error)
+
+	CreateMedia(ctx context.Context, media *domain.Media) error
+	GetMedia(ctx context.Context, id uuid.UUID) (*domain.Media, error)
+	UpdateMedia(ctx context.Context, media *domain.Media) error
+	DeleteMedia(ctx context.Context, id uuid.UUID) error
+	ListProductMedia(ctx context.Context, productID uuid.UUID, status string) ([]domain.Media, error)
+	ListExpiredMedia(ctx context.Context, abandonedBefore, deletedBefore time.Time, limit int) ([]domain.Media, error)
+
+	ReplaceBundleComponents(ctx context.Context, bundleID uuid.UUID, components []domain.BundleComponent) error
+	ListBundleComponents(ctx context.Context, bundleIDs []uuid.UUID) ([]domain.BundleComponent, error)
+	RepriceBundles(ctx context.Context, productID uuid.UUID) ([]uuid.UUID, error)
+
+	ReplacePriceTiers(ctx context.Context, productID uuid.UUID, tiers []domain.PriceTier) error
+	ListPriceTiers(ctx context.Context, productIDs []uuid.UUID) ([]domain.PriceTier, error)
+
+	CreateDigitalAsset(ctx context.Context, asset *domain.DigitalAsset) error
+	GetDigitalAsset(ctx context.Context, id uuid.UUID) (*domain.DigitalAsset, error)
+	DeleteDigitalAsset(ctx context.Context, id uuid.UUID) error
+	ListDigitalAssets(ctx context.Context, productID uuid.UUID) ([]domain.DigitalAsset, error)
+	GrantEntitlements(ctx context.Context, entitlements []domain.Entitlement) ([]domain.Entitlement, error)
+	GetEntitlement(ctx context.Context, id uuid.UUID) (*domain.Entitlement, error)
+	ListEntitlements(ctx context.Context, filters *domain.EntitlementFilters) ([]domain.Entitlement, int64, error)
+
+	CreateReview(ctx context.Context, review *domain.Review) error
+	GetReview(ctx context.Context, id uuid.UUID) (*domain.Review, error)
+	GetUserReview(ctx context.Context, productID uuid.UUID, userID string) (*domain.Review, error)
+	UpdateReview(ctx context.Context, review *domain.Review) error
+	ListReviews(ctx context.Context, filters *domain.ReviewFilters) ([]domain.Review, int64, error)
+	RefreshProductRating(ctx context.Context, productID uuid.UUID) error
+
+	CreateRevision(ctx context.Context, revision *domain.ProductRevision) error
+	GetRevision(ctx context.Context, id uuid.UUID) (*domain.ProductRevision, error)
+	UpdateRevision(ctx context.Context, revision *domain.ProductRevision) error
+	ListRevisions(ctx context.Context, filters *domain.RevisionFilters) ([]domain.ProductRevision, int64, error)
+
+	CreateChangeset(ctx context.Context, changeset *domain.Changeset) error
+	GetChangeset(ctx context.Context, id uuid.UUID) (*domain.Changeset, error)
+	UpdateChangeset(ctx context.Context, changeset *domain.Changeset) error
+	TransitionChangeset(ctx context.Context, changeset *domain.Changeset, from ...string) (bool, error)
+	DeleteChangeset(ctx context.Context, id uuid.UUID) error
+	ListChangesets(ctx context.Context, filters *domain.ChangesetFilters) ([]domain.Changeset, int64, error)
+	ListDueChangesets(ctx context.Context, limit int) ([]domain.Changeset, error)
+	SaveChangesetItem(ctx context.Context, item *domain.ChangesetItem) error
+	DeleteChangesetItem(ctx context.Context, changesetID, productID uuid.UUID) (bool, error)
+
+	CreateFlashSale(ctx context.Context, sale *domain.FlashSale) error
+	GetFlashSale(ctx context.Context, productID uuid.UUID) (*domain.FlashSale, error)
+	UpdateFlashSale(ctx context.Context, sale *domain.FlashSale) error
+	DeleteFlashSale(ctx context.Context, id uuid.UUID) error
+	CloseFlashSale(ctx context.Context, sale *domain.FlashSale) (bool, error)
+	ListOpenFlashSales(ctx context.Context, limit int) ([]domain.FlashSale, error)
+	RecordFlashSalePurchases(ctx context.Context, saleID uuid.UUID, purchases []domain.FlashSalePurchase) (int, error)
+	FlashSaleBuyers(ctx context.Context, saleID uuid.UUID) (map[string]int, error)
+
+	ReserveStock(ctx context.Context, reference string, items []domain.StockItem, movement domain.StockMovement) ([]domain.StockReservation, error)
+	ReleaseStock(ctx context.Context, reference string, movement domain.StockMovement) ([]domain.StockReservation, error)
+	ReturnStock(ctx context.Context, items []domain.StockItem, movement domain.StockMovement) ([]domain.StockMovement, error)
+	SetStock(ctx context.Context, id uuid.UUID, stock int, movement domain.StockMovement) (*domain.StockMovement, error)
+	AdjustStock(ctx context.Context, id uuid.UUID, delta int, movement domain.StockMovement) (*domain.StockMovement, error)
+	ListStockMovements(ctx context.Context, productID uuid.UUID, filters *domain.StockMovementFilters) ([]domain.StockMovement, int64, error)
+	StockDrift(ctx context.Context, limit int) ([]domain.StockDrift, error)
+	GetStockReservations(ctx context.Context, reference string) ([]domain.StockReservation, error)
+	ListLowStock(ctx context.Context, defaultThreshold int, filters *domain.LowStockFilters) ([]domain.Product, int64, error)
+	MarkLowStock(ctx context.Context, defaultThreshold int, ids []uuid.UUID, limit int) ([]domain.Product, error)
+	ResetLowStock(ctx context.Context, defaultThreshold int, ids []uuid.UUID) (int64, error)
+	SyncSales(ctx context.Context, limit int) ([]domain.Product, error)
+	PublishDue(ctx context.Context, limit int) ([]domain.Product, error)
+
+	UpsertBatch(ctx context.Context, products []domain.Product, movement domain.StockMovement) error
+	UpsertBySKU(ctx context.Context, product *domain.Product, movement domain.StockMovement) (bool, error)
+	BulkUpdate(ctx context.Context, changes []domain.ProductChange) ([]error, error)
+	ExistingSKUs(ctx context.Context, skus []string) (map[string]bool, error)
+	ProductIDsBySKU(ctx context.Context, skus []string) (map[string]uuid.UUID, error)
+
+	CreateImportJob(ctx context.Context, job *domain.ImportJob) error
+	GetImportJob(ctx context.Context, id uuid.UUID) (*domain.ImportJob, error)
+	UpdateImportJob(ctx context.Context, job *domain.ImportJob) error
+
+	CreateAuditEvent(ctx context.Context, event *domain.AuditEvent) error
+	GetAuditEvent(ctx context.Context, id uuid.UUID) (*domain.AuditEvent, error)
+	ListAuditEvents(ctx context.Context, filters *domain.AuditFilters) ([]domain.AuditEvent, int64, error)
 
 	InvalidateProductCache(ctx context.Context) error
+	InspectCacheKey(ctx context.Context, key string) (*domain.CacheEntry, error)
+	ListCacheKeys(ctx context.Context, pattern string, limit int) ([]string, bool, error)
+	PurgeCacheKeys(ctx context.Context, keys []string, pattern string) (int64, error)
 }
 
+const (
+	// searchLanguage is the text search configuration used for product search
+	searchLanguage = "english"
+	// maxCategoryDepth bounds recursive category queries in case of corrupt data
+	maxCategoryDepth = 100
+	// headlineOptions controls the highlighted snippet returned with search results
+	headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2"
+)
+
 type productRepository struct {
 	db     *gorm.DB
-	redis  *redis.Client
+	redis  redis.UniversalClient
+	local  *localcache.Cache // hot products, in front of Redis; nil when disabled
 	logger *logrus.Logger
+
+	// Cache namespaces; those without a TTL are disabled
+	products       *cache.Namespace
+	productLists   *cache.Namespace
+	categoryCounts *cache.Namespace
+	synonyms       *cache.Namespace
+	cache          *cache.Cache
 }
 
-// NewProductRepository creates a new product repository
-func NewProductRepository(db *gorm.DB, redisClient *redis.Client, logger *logrus.Logger) ProductRepository {
+// NewProductRepository creates a new product repository. Cache namespaces
+// take their TTLs from the Redis configuration; the local cache may be nil.
+func NewProductRepository(db *gorm.DB, redisClient redis.UniversalClient, store *cache.Cache, local *localcache.Cache, cacheConfig config.RedisConfig, logger *logrus.Logger) ProductRepository {
+	ttl := func(namespace string) time.Duration {
+		if !cacheConfig.CacheEnabled {
+			return 0
+		}
+		return time.Duration(cacheConfig.CacheTTLs[namespace]) * time.Second
+	}
+
 	return &productRepository{
-		db:     db,
-		redis:  redisClient,
-		logger: logger,
+		db:             db,
+		redis:          redisClient,
+		local:          local,
+		logger:         logger,
+		products:       store.Namespace(domain.CacheNamespaceProduct, ttl(domain.CacheNamespaceProduct)).WithLocal(local),
+		productLists:   store.Namespace(domain.CacheNamespaceProductList, ttl(domain.CacheNamespaceProductList)),
+		categoryCounts: store.Namespace(domain.CacheNamespaceCategoryCounts, ttl(domain.CacheNamespaceCategoryCounts)),
+		synonyms:       store.Namespace(domain.CacheNamespaceSynonyms, ttl(domain.CacheNamespaceSynonyms)),
+		cache:          store,
 	}
 }
 
-func (r *productRepository) Create(ctx context.Context, product *domain.Product) error {
-	if err := r.db.WithContext(ctx).Create(product).Error; err != nil {
+// skuIndex is the unique index keeping the SKUs of live products unique,
+// ignoring case
+const skuIndex = "idx_products_sku_live_lower"
+
+// skuConflict returns the error for a write rejected because another live
+// product has the SKU
+func skuConflict(cause error) error {
+	return customErrors.NewConflictError("SKU already exists", cause).WithCode(customErrors.CodeProductSKUConflict)
+}
+
+// conn returns the connection to query with: the transaction the context
+// carries, if any, or the database
+func (r *productRepository) conn(ctx context.Context) *gorm.DB {
+	return database.Conn(ctx, r.db)
+}
+
+// cacheKey returns the key of an entry of a cache namespace for the tenant
+// ctx is scoped to. Each tenant's entries share a prefix, so they can be
+// purged together. System contexts see every tenant and get no key: they
+// read around the cache, so no tenant is served what another may not see.
+func cacheKey(ctx context.Context, n *cache.Namespace, parts ...string) (string, bool) {
+	id, ok := tenant.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	return n.Key(append([]string{id}, parts...)...), true
+}
+
+// log returns the logger of the request ctx belongs to
+func (r *productRepository) log(ctx context.Context) *logrus.Entry {
+	return logger.FromContext(ctx, r.logger)
+}
+
+// Create creates a product and opens its stock ledger with the given
+// movement, which records the initial stock
+func (r *productRepository) Create(ctx context.Context, product *domain.Product, movement domain.StockMovement) error {
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(product).Error; err != nil {
+			return err
+		}
+		if product.Stock == 0 {
+			return nil
+		}
+
+		return recordMovements(tx, []domain.StockMovement{ledgerEntry(movement, product.ID, product.Stock, product.Stock)})
+	})
+	if database.IsUniqueViolation(err, skuIndex) {
+		return skuConflict(err)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to create product: %w", err)
 	}
 	return nil
 }
 
 func (r *productRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Product, error) {
-	// Try cache first
-	cacheKey := fmt.Sprintf("product:%s", id.String())
-	cached, err := r.redis.Get(ctx, cacheKey).Result()
-	if err == nil {
-		var product domain.Product
-		if err := json.Unmarshal([]byte(cached), &product); err == nil {
-			return &product, nil
-		}
-	}
+	// Try the local cache, then Redis. Loads read from the primary, so a
+	// lagging replica can't put back a copy that was just invalidated.
+	key, ok := cacheKey(ctx, r.products, id.String())
+	if !ok {
+		return r.loadProduct(ctx, id)
+	}
+	return cache.GetOrLoad(database.WithPrimary(ctx), r.products, key, func(ctx context.Context) (*domain.Product, error) {
+		return r.loadProduct(ctx, id)
+	})
+}
 
+// loadProduct reads a product from the database
+func (r *productRepository) loadProduct(ctx context.Context, id uuid.UUID) (*domain.Product, error) {
 	var product domain.Product
-	err = r.db.WithContext(ctx).
+	err := r.conn(ctx).
 		Preload("Category").
+		Preload("Brand").
+		Preload("Attributes").
 		First(&product, "id = ?", id).Error
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, customErrors.NewNotFoundError("Product not found", err)
+			return nil, customErrors.NewNotFoundError("Product not found", err).WithCode(customErrors.CodeProductNotFound)
 		}
 		return nil, fmt.Errorf("failed to get product: %w", err)
 	}
 
-	// Cache the result
-	if productJSON, err := json.Marshal(product); err == nil {
-		r.redis.Set(ctx, cacheKey, productJSON, 10*time.Minute)
+	return &product, nil
+}
+
+// GetByIDs loads several products at once, keyed by ID. Cached products are
+// read in a single MGET and the rest in a single query, then written back
+// to the cache in one pipeline. Products that don't exist are left out.
+func (r *productRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*domain.Product, error) {
+	products := make(map[uuid.UUID]*domain.Product, len(ids))
+	if len(ids) == 0 {
+		return products, nil
+	}
+
+	keys := make(map[uuid.UUID]string, len(ids))
+	for _, id := range ids {
+		if key, ok := cacheKey(ctx, r.products, id.String()); ok {
+			keys[id] = key
+		}
 	}
 
-	return &product, nil
+	// A failed MGET only costs the cache hits; the database has everything
+	cached, err := cache.GetMany[*domain.Product](ctx, r.products, slices.Collect(maps.Values(keys)))
+	if err != nil {
+		r.log(ctx).WithError(err).Warn("Failed to read cached products")
+	}
+	var missing []uuid.UUID
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if product, ok := cached[keys[id]]; ok {
+			products[id] = product
+			continue
+		}
+		missing = append(missing, id)
+	}
+	if len(missing) == 0 {
+		return products, nil
+	}
+
+	var loaded []domain.Product
+	err = r.conn(database.WithPrimary(ctx)).
+		Preload("Category").
+		Preload("Brand").
+		Preload("Attributes").
+		Where("id IN ?", missing).
+		Find(&loaded).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+
+	// Cache the results
+	uncached := make(map[string]*domain.Product, len(loaded))
+	for idx := range loaded {
+		product := &loaded[idx]
+		products[product.ID] = product
+		if key, ok := keys[product.ID]; ok {
+			uncached[key] = product
+		}
+	}
+	if err := cache.SetMany(ctx, r.products, uncached); err != nil {
+		r.log(ctx).WithError(err).Warn("Failed to cache products")
+	}
+
+	return products, nil
 }
 
 func (r *productRepository) GetBySKU(ctx context.Context, sku string) (*domain.Product, error) {
 	var product domain.Product
-	err := r.db.WithContext(ctx).
+	err := r.conn(ctx).
 		Preload("Category").
-		First(&product, "sku = ?", sku).Error
+		Preload("Brand").
+		Preload("Attributes").
+		First(&product, "LOWER(sku) = LOWER(?)", sku).Error
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, customErrors.NewNotFoundError("Product not found", err)
+			return nil, customErrors.NewNotFoundError("Product not found", err).WithCode(customErrors.CodeProductNotFound)
 		}
 		return nil, fmt.Errorf("failed to get product by SKU: %w", err)
 	}
@@ -105,81 +409,157 @@ func (r *productRepository) GetBySKU(ctx context.Context, sku string) (*domain.P
 	return &product, nil
 }
 
+// Update saves a product's fields except its stock, which only changes
+// through SetStock, AdjustStock and reservations so that it stays in step
+// with the stock ledger
 func (r *productRepository) Update(ctx context.Context, product *domain.Product) error {
-	if err := r.db.WithContext(ctx).Save(product).Error; err != nil {
+	if err := r.conn(ctx).Omit("stock").Save(product).Error; err != nil {
+		if database.IsUniqueViolation(err, skuIndex) {
+			return skuConflict(err)
+		}
 		return fmt.Errorf("failed to update product: %w", err)
 	}
 
 	// Invalidate cache
-	cacheKey := fmt.Sprintf("product:%s", product.ID.String())
-	r.redis.Del(ctx, cacheKey)
+	r.invalidateProductIDs(ctx, []uuid.UUID{product.ID})
 
 	return nil
 }
 
 func (r *productRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	if err := r.db.WithContext(ctx).Delete(&domain.Product{}, "id = ?", id).Error; err != nil {
+	if err := r.conn(ctx).Delete(&domain.Product{}, "id = ?", id).Error; err != nil {
 		return fmt.Errorf("failed to delete product: %w", err)
 	}
 
 	// Invalidate cache
-	cacheKey := fmt.Sprintf("product:%s", id.String())
-	r.redis.Del(ctx, cacheKey)
+	r.invalidateProductIDs(ctx, []uuid.UUID{id})
 
 	return nil
 }
 
-func (r *productRepository) List(ctx context.Context, filters *domain.ProductFilters) ([]domain.Product, int64, error) {
-	// Try cache for common queries
-	cacheKey := r.buildCacheKey(filters)
-	if cacheKey != "" {
-		cached, err := r.redis.Get(ctx, cacheKey).Result()
-		if err == nil {
-			var result struct {
-				Products []domain.Product `json:"products"`
-				Total    int64            `json:"total"`
-			}
-			if err := json.Unmarshal([]byte(cached), &result); err == nil {
-				return result.Products, result.Total, nil
-			}
+func (r *productRepository) GetDeleted(ctx context.Context, id uuid.UUID) (*domain.Product, error) {
+	var product domain.Product
+	err := r.conn(ctx).
+		Unscoped().
+		Preload("Category").
+		Preload("Brand").
+		Preload("Attributes").
+		First(&product, "id = ? AND deleted_at IS NOT NULL", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Deleted product not found", err).WithCode(customErrors.CodeProductNotFound)
 		}
+		return nil, fmt.Errorf("failed to get deleted product: %w", err)
 	}
 
-	query := r.db.WithContext(ctx).Model(&domain.Product{}).Preload("Category")
+	return &product, nil
+}
 
-	// Apply filters
-	if filters.CategoryID != nil {
-		query = query.Where("category_id = ?", *filters.CategoryID)
+func (r *productRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	err := r.conn(ctx).
+		Unscoped().
+		Model(&domain.Product{}).
+		Where("id = ?", id).
+		Update("deleted_at", nil).Error
+	if database.IsUniqueViolation(err, skuIndex) {
+		return skuConflict(err)
 	}
-	if filters.MinPrice != nil {
-		query = query.Where("price >= ?", *filters.MinPrice)
+	if err != nil {
+		return fmt.Errorf("failed to restore product: %w", err)
 	}
-	if filters.MaxPrice != nil {
-		query = query.Where("price <= ?", *filters.MaxPrice)
+
+	// Invalidate cache
+	r.invalidateProductIDs(ctx, []uuid.UUID{id})
+
+	return nil
+}
+
+func (r *productRepository) List(ctx context.Context, filters *domain.ProductFilters) ([]domain.Product, int64, error) {
+	// Try cache for common queries
+	cacheKey := r.buildCacheKey(ctx, filters)
+	if cacheKey != "" {
+		if cached, ok, _ := cache.Get[productPage](ctx, r.productLists, cacheKey); ok {
+			return cached.Products, cached.Total, nil
+		}
 	}
-	if filters.Search != "" {
-		searchTerm := "%" + strings.ToLower(filters.Search) + "%"
-		query = query.Where("LOWER(name) LIKE ? OR LOWER(description) LIKE ?", searchTerm, searchTerm)
+
+	// Pages that are cached are read from the primary, like products by ID;
+	// the rest may come from a replica
+	if cacheKey != "" {
+		ctx = database.WithPrimary(ctx)
 	}
-	if filters.IsActive != nil {
-		query = query.Where("is_active = ?", *filters.IsActive)
+	query := r.conn(ctx).Model(&domain.Product{})
+
+	// Only load the associations the caller asked for
+	if filters.Fields.Includes("category") {
+		query = query.Preload("Category")
 	}
-	if filters.InStock != nil && *filters.InStock {
-		query = query.Where("stock > 0")
+	if filters.Fields.Includes("brand") {
+		query = query.Preload("Brand")
+	}
+	if filters.Fields.Includes("attributes") {
+		query = query.Preload("Attributes")
 	}
 
+	// Apply filters
+	query = applyFilters(query, filters)
+
 	// Count total
 	var total int64
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, fmt.Errorf("failed to count products: %w", err)
 	}
 
-	// Apply sorting
-	orderClause := fmt.Sprintf("%s %s", filters.SortBy, strings.ToUpper(filters.SortOrder))
+	// Rank and highlight full-text matches
+	if filters.Search != "" {
+		tsQuery, tsArgs := searchTSQuery(filters)
+		args := append([]interface{}{}, tsArgs...)
+		args = append(args, searchLanguage)
+		args = append(args, tsArgs...)
+		args = append(args, headlineOptions)
+		query = query.Select(
+			"products.*, "+
+				"ts_rank(products.search_vector, "+tsQuery+") AS rank, "+
+				"ts_headline(?, coalesce(nullif(products.description_text, ''), products.name), "+tsQuery+", ?) AS highlight",
+			args...,
+		)
+	}
+
+	// Apply sorting. The service only lets the known sorts through; each
+	// maps to a fixed expression so nothing from the request reaches SQL.
+	sortOrder := "DESC"
+	if filters.SortOrder == "asc" {
+		sortOrder = "ASC"
+	}
+	var orderClause string
+	switch filters.SortBy {
+	case "name":
+		orderClause = fmt.Sprintf("products.name %s, id %s", sortOrder, sortOrder)
+	case "price":
+		orderClause = fmt.Sprintf("%s %s, id %s", effectivePriceSQL, sortOrder, sortOrder)
+	case "rating":
+		orderClause = fmt.Sprintf("rating_average %s, review_count %s", sortOrder, sortOrder)
+	case "relevance":
+		if filters.Search != "" {
+			orderClause = fmt.Sprintf("rank %s, id %s", sortOrder, sortOrder)
+		} else {
+			orderClause = fmt.Sprintf("created_at %s, id %s", sortOrder, sortOrder)
+		}
+	default:
+		// Tie-break on id so keyset pagination is stable
+		orderClause = fmt.Sprintf("created_at %s, id %s", sortOrder, sortOrder)
+	}
 	query = query.Order(orderClause)
 
-	// Apply pagination
-	if filters.Offset > 0 {
+	// Apply pagination: keyset when a cursor is given, offset otherwise
+	if filters.After != nil {
+		if sortOrder == "ASC" {
+			query = query.Where("(created_at, id) > (?, ?)", filters.After.CreatedAt, filters.After.ID)
+		} else {
+			query = query.Where("(created_at, id) < (?, ?)", filters.After.CreatedAt, filters.After.ID)
+		}
+	} else if filters.Offset > 0 {
 		query = query.Offset(filters.Offset)
 	}
 	if filters.Limit > 0 {
@@ -193,23 +573,153 @@ func (r *productRepository) List(ctx context.Context, filters *domain.ProductFil
 
 	// Cache the result for common queries
 	if cacheKey != "" {
-		result := struct {
-			Products []domain.Product `json:"products"`
-			Total    int64            `json:"total"`
-		}{
-			Products: products,
-			Total:    total,
+		cache.Set(ctx, r.productLists, cacheKey, productPage{Products: products, Total: total})
+	}
+
+	return products, total, nil
+}
+
+func (r *productRepository) Facets(ctx context.Context, filters *domain.ProductFilters) (*domain.ProductFacets, error) {
+	facets := &domain.ProductFacets{}
+
+	// Category facet ignores the category filter
+	categoryFilters := *filters
+	categoryFilters.CategoryID = nil
+	var categoryRows []struct {
+		CategoryID uuid.UUID
+		Name       string
+		Count      int64
+	}
+	err := applyFilters(r.conn(ctx).Model(&domain.Product{}), &categoryFilters).
+		Select("products.category_id, categories.name, COUNT(*) AS count").
+		Joins("LEFT JOIN categories ON categories.id = products.category_id").
+		Group("products.category_id, categories.name").
+		Order("count DESC").
+		Scan(&categoryRows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate category facets: %w", err)
+	}
+	facets.Categories = make([]domain.FacetCount, 0, len(categoryRows))
+	for _, row := range categoryRows {
+		facets.Categories = append(facets.Categories, domain.FacetCount{
+			Value: row.CategoryID.String(),
+			Label: row.Name,
+			Count: row.Count,
+		})
+	}
+
+	// Brand facet ignores the brand filter; unbranded products are not counted
+	brandFilters := *filters
+	brandFilters.BrandID = nil
+	var brandRows []struct {
+		BrandID uuid.UUID
+		Name    string
+		Count   int64
+	}
+	err = applyFilters(r.conn(ctx).Model(&domain.Product{}), &brandFilters).
+		Select("products.brand_id, brands.name, COUNT(*) AS count").
+		Joins("JOIN brands ON brands.id = products.brand_id").
+		Group("products.brand_id, brands.name").
+		Order("count DESC").
+		Scan(&brandRows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate brand facets: %w", err)
+	}
+	facets.Brands = make([]domain.FacetCount, 0, len(brandRows))
+	for _, row := range brandRows {
+		facets.Brands = append(facets.Brands, domain.FacetCount{
+			Value: row.BrandID.String(),
+			Label: row.Name,
+			Count: row.Count,
+		})
+	}
+
+	// Price facet ignores the price filters
+	priceFilters := *filters
+	priceFilters.MinPrice = nil
+	priceFilters.MaxPrice = nil
+	bounds := domain.PriceBucketBounds
+	columns := make([]string, 0, len(bounds))
+	args := make([]interface{}, 0, len(bounds)*2)
+	for i, lower := range bounds {
+		if i+1 < len(bounds) {
+			columns = append(columns, "COUNT(*) FILTER (WHERE "+effectivePriceSQL+" >= ? AND "+effectivePriceSQL+" < ?)")
+			args = append(args, lower, bounds[i+1])
+		} else {
+			columns = append(columns, "COUNT(*) FILTER (WHERE "+effectivePriceSQL+" >= ?)")
+			args = append(args, lower)
 		}
-		if resultJSON, err := json.Marshal(result); err == nil {
-			r.redis.Set(ctx, cacheKey, resultJSON, 5*time.Minute)
+	}
+	counts := make([]int64, len(bounds))
+	dest := make([]interface{}, len(bounds))
+	for i := range counts {
+		dest[i] = &counts[i]
+	}
+	err = applyFilters(r.conn(ctx).Model(&domain.Product{}), &priceFilters).
+		Select(strings.Join(columns, ", "), args...).
+		Row().Scan(dest...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate price facets: %w", err)
+	}
+	facets.PriceRanges = make([]domain.PriceRangeFacet, 0, len(bounds))
+	for i, lower := range bounds {
+		bucket := domain.PriceRangeFacet{Min: lower, Count: counts[i]}
+		if i+1 < len(bounds) {
+			upper := bounds[i+1]
+			bucket.Max = &upper
 		}
+		facets.PriceRanges = append(facets.PriceRanges, bucket)
 	}
 
-	return products, total, nil
+	// Stock facet ignores the in-stock filter
+	stockFilters := *filters
+	stockFilters.InStock = nil
+	var stockRow struct {
+		InStock    int64
+		OutOfStock int64
+	}
+	stock, stockArgs := stockSQL(filters)
+	err = applyFilters(r.conn(ctx).Model(&domain.Product{}), &stockFilters).
+		Select("COUNT(*) FILTER (WHERE "+stock+" > 0) AS in_stock, COUNT(*) FILTER (WHERE "+stock+" <= 0) AS out_of_stock", append(stockArgs, stockArgs...)...).
+		Scan(&stockRow).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate stock facets: %w", err)
+	}
+	facets.InStock = stockRow.InStock
+	facets.OutOfStock = stockRow.OutOfStock
+
+	return facets, nil
+}
+
+func (r *productRepository) Iterate(ctx context.Context, filters *domain.ProductFilters, batchSize int, fn func([]domain.Product) error) error {
+	var after *domain.ProductCursor
+	for {
+		query := applyFilters(r.conn(ctx).Model(&domain.Product{}), filters)
+		if after != nil {
+			query = query.Where("(created_at, id) > (?, ?)", after.CreatedAt, after.ID)
+		}
+
+		var products []domain.Product
+		if err := query.Order("created_at ASC, id ASC").Limit(batchSize).Find(&products).Error; err != nil {
+			return fmt.Errorf("failed to iterate products: %w", err)
+		}
+		if len(products) == 0 {
+			return nil
+		}
+
+		if err := fn(products); err != nil {
+			return err
+		}
+		if len(products) < batchSize {
+			return nil
+		}
+
+		after = domain.NewProductCursor(&products[len(products)-1])
+	}
 }
 
 func (r *productRepository) CreateCategory(ctx context.Context, category *domain.Category) error {
-	if err := r.db.WithContext(ctx).Create(category).Error; err != nil {
+	if err := r.conn(ctx).Create(category).Error; err != nil {
 		return fmt.Errorf("failed to create category: %w", err)
 	}
 	return nil
@@ -217,14 +727,14 @@ func (r *productRepository) CreateCategory(ctx context.Context, category *domain
 
 func (r *productRepository) GetCategory(ctx context.Context, id uuid.UUID) (*domain.Category, error) {
 	var category domain.Category
-	err := r.db.WithContext(ctx).
+	err := r.conn(ctx).
 		Preload("Parent").
 		Preload("Children").
 		First(&category, "id = ?", id).Error
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, customErrors.NewNotFoundError("Category not found", err)
+			return nil, customErrors.NewNotFoundError("Category not found", err).WithCode(customErrors.CodeCategoryNotFound)
 		}
 		return nil, fmt.Errorf("failed to get category: %w", err)
 	}
@@ -234,11 +744,11 @@ func (r *productRepository) GetCategory(ctx context.Context, id uuid.UUID) (*dom
 
 func (r *productRepository) GetCategoryByName(ctx context.Context, name string) (*domain.Category, error) {
 	var category domain.Category
-	err := r.db.WithContext(ctx).First(&category, "name = ?", name).Error
+	err := r.conn(ctx).First(&category, "name = ?", name).Error
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, customErrors.NewNotFoundError("Category not found", err)
+			return nil, customErrors.NewNotFoundError("Category not found", err).WithCode(customErrors.CodeCategoryNotFound)
 		}
 		return nil, fmt.Errorf("failed to get category by name: %w", err)
 	}
@@ -247,14 +757,14 @@ func (r *productRepository) GetCategoryByName(ctx context.Context, name string)
 }
 
 func (r *productRepository) UpdateCategory(ctx context.Context, category *domain.Category) error {
-	if err := r.db.WithContext(ctx).Save(category).Error; err != nil {
+	if err := r.conn(ctx).Save(category).Error; err != nil {
 		return fmt.Errorf("failed to update category: %w", err)
 	}
 	return nil
 }
 
 func (r *productRepository) DeleteCategory(ctx context.Context, id uuid.UUID) error {
-	if err := r.db.WithContext(ctx).Delete(&domain.Category{}, "id = ?", id).Error; err != nil {
+	if err := r.conn(ctx).Delete(&domain.Category{}, "id = ?", id).Error; err != nil {
 		return fmt.Errorf("failed to delete category: %w", err)
 	}
 	return nil
@@ -262,7 +772,7 @@ func (r *productRepository) DeleteCategory(ctx context.Context, id uuid.UUID) er
 
 func (r *productRepository) ListCategories(ctx context.Context) ([]domain.Category, error) {
 	var categories []domain.Category
-	err := r.db.WithContext(ctx).
+	err := r.conn(ctx).
 		Preload("Parent").
 		Preload("Children").
 		Where("is_active = ?", true).
@@ -276,48 +786,180 @@ func (r *productRepository) ListCategories(ctx context.Context) ([]domain.Catego
 	return categories, nil
 }
 
-func (r *productRepository) InvalidateProductCache(ctx context.Context) error {
-	// Delete all product-related cache keys
-	keys, err := r.redis.Keys(ctx, "product:*").Result()
-	if err != nil {
-		return err
+func (r *productRepository) ListCategorySubtree(ctx context.Context, rootID *uuid.UUID) ([]domain.Category, error) {
+	anchor := "parent_id IS NULL"
+	var args []interface{}
+	if rootID != nil {
+		anchor = "id = ?"
+		args = append(args, *rootID)
+	}
+	args = append(args, tenant.Filter(ctx, "categories"), maxCategoryDepth)
+
+	query := fmt.Sprintf(`
+		WITH RECURSIVE tree AS (
+			SELECT id, name, slug, description, parent_id, is_active, created_at, updated_at, 0 AS depth
+			FROM categories
+			WHERE %s AND is_active AND ?
+			UNION ALL
+			SELECT c.id, c.name, c.slug, c.description, c.parent_id, c.is_active, c.created_at, c.updated_at, t.depth + 1
+			FROM categories c
+			JOIN tree t ON c.parent_id = t.id
+			WHERE c.is_active AND t.depth < ?
+		)
+		SELECT id, name, slug, description, parent_id, is_active, created_at, updated_at
+		FROM tree
+		ORDER BY depth, name`, anchor)
+
+	var categories []domain.Category
+	if err := r.conn(ctx).Raw(query, args...).Scan(&categories).Error; err != nil {
+		return nil, fmt.Errorf("failed to load category tree: %w", err)
 	}
 
-	if len(keys) > 0 {
-		return r.redis.Del(ctx, keys...).Err()
+	return categories, nil
+}
+
+func (r *productRepository) GetCategoryAncestorIDs(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error) {
+	return categoryAncestorIDs(r.conn(ctx), id)
+}
+
+func (r *productRepository) InvalidateProductCache(ctx context.Context) error {
+	// Delete all product-related cache keys, along with the list and
+	// category count keys: only the tenant's own, in a context scoped to
+	// one
+	patterns := []string{"product:*", "products:*"}
+	if id, ok := tenant.FromContext(ctx); ok {
+		patterns = []string{
+			r.products.Key(id, "*"),
+			r.productLists.Key(id),
+			r.productLists.Key(id, "*"),
+			r.categoryCounts.Key(id),
+		}
 	}
+	for _, pattern := range patterns {
+		err := r.cache.Scan(ctx, pattern, func(keys []string) error {
+			_, err := r.cache.Delete(ctx, keys...)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	r.local.InvalidateAll(ctx)
 
-	// Also delete list cache keys
-	listKeys, err := r.redis.Keys(ctx, "products:*").Result()
-	if err != nil {
-		return err
+	return nil
+}
+
+// applyFilters adds the WHERE clauses for product filters to a query
+func applyFilters(query *gorm.DB, filters *domain.ProductFilters) *gorm.DB {
+	if filters.IncludeDeleted {
+		query = query.Unscoped()
+	}
+	if filters.CategoryID != nil {
+		query = query.Where("products.category_id = ?", *filters.CategoryID)
+	}
+	if filters.BrandID != nil {
+		query = query.Where("products.brand_id = ?", *filters.BrandID)
+	}
+	if filters.VendorID != nil {
+		query = query.Where("products.vendor_id = ?", *filters.VendorID)
+	}
+	if filters.MinPrice != nil {
+		query = query.Where(effectivePriceSQL+" >= ?", *filters.MinPrice)
+	}
+	if filters.MaxPrice != nil {
+		query = query.Where(effectivePriceSQL+" <= ?", *filters.MaxPrice)
+	}
+	if filters.Search != "" {
+		tsQuery, args := searchTSQuery(filters)
+		query = query.Where("products.search_vector @@ "+tsQuery, args...)
+	}
+	if filters.IsActive != nil {
+		query = query.Where("products.is_active = ?", *filters.IsActive)
+	}
+	if filters.Status != "" {
+		query = query.Where("products.status = ?", filters.Status)
+	}
+	if filters.ApprovalStatus != "" {
+		query = query.Where("products.approval_status = ?", filters.ApprovalStatus)
 	}
+	if filters.InStock != nil && *filters.InStock {
+		stock, args := stockSQL(filters)
+		query = query.Where(stock+" > 0", args...)
+	}
+	if filters.Channel != "" {
+		query = query.Where("NOT COALESCE((products.channels -> ? ->> 'hidden')::boolean, FALSE)", filters.Channel)
+	}
+	return query
+}
 
-	if len(listKeys) > 0 {
-		return r.redis.Del(ctx, listKeys...).Err()
+// stockSQL returns the expression for a product's stock under the filters:
+// its total, or what the filtered warehouse holds
+func stockSQL(filters *domain.ProductFilters) (string, []interface{}) {
+	if filters.Warehouse == "" {
+		return "products.stock", nil
 	}
+	return "COALESCE((SELECT l.quantity FROM stock_levels l JOIN warehouses w ON w.id = l.warehouse_id " +
+		"WHERE l.product_id = products.id AND w.code = ?), 0)", []interface{}{filters.Warehouse}
+}
 
-	return nil
+// productPage is a page of a product listing, as cached
+type productPage struct {
+	Products []domain.Product `json:"products"`
+	Total    int64            `json:"total"`
 }
 
-func (r *productRepository) buildCacheKey(filters *domain.ProductFilters) string {
+func (r *productRepository) buildCacheKey(ctx context.Context, filters *domain.ProductFilters) string {
+	if !r.productLists.Enabled() {
+		return ""
+	}
+
 	// Only cache simple queries to avoid cache explosion
 	if filters.Search != "" || filters.MinPrice != nil || filters.MaxPrice != nil {
 		return ""
 	}
 
-	key := "products:list"
+	key, ok := cacheKey(ctx, r.productLists)
+	if !ok {
+		return ""
+	}
 	if filters.CategoryID != nil {
 		key += fmt.Sprintf(":cat_%s", filters.CategoryID.String())
 	}
+	if filters.BrandID != nil {
+		key += fmt.Sprintf(":brand_%s", filters.BrandID.String())
+	}
+	if filters.VendorID != nil {
+		key += fmt.Sprintf(":vendor_%s", filters.VendorID.String())
+	}
 	if filters.IsActive != nil {
 		key += fmt.Sprintf(":active_%t", *filters.IsActive)
 	}
+	if filters.Status != "" {
+		key += fmt.Sprintf(":status_%s", filters.Status)
+	}
+	if filters.ApprovalStatus != "" {
+		key += fmt.Sprintf(":approval_%s", filters.ApprovalStatus)
+	}
 	if filters.InStock != nil {
 		key += fmt.Sprintf(":stock_%t", *filters.InStock)
 	}
+	if filters.Warehouse != "" {
+		key += fmt.Sprintf(":warehouse_%s", filters.Warehouse)
+	}
+	if filters.Channel != "" {
+		key += fmt.Sprintf(":channel_%s", filters.Channel)
+	}
+	if filters.IncludeDeleted {
+		key += ":deleted_true"
+	}
 	key += fmt.Sprintf(":limit_%d:offset_%d", filters.Limit, filters.Offset)
+	if filters.Cursor != "" {
+		key += fmt.Sprintf(":cursor_%s", filters.Cursor)
+	}
 	key += fmt.Sprintf(":sort_%s_%s", filters.SortBy, filters.SortOrder)
+	if len(filters.Fields) > 0 {
+		key += fmt.Sprintf(":fields_%s", strings.Join(filters.Fields, ","))
+	}
 
 	return key
 }
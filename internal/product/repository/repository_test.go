@@ -0,0 +1,60 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"ecommerce/internal/product/config"
+	"ecommerce/internal/product/repository"
+	"ecommerce/internal/product/repository/repositorytest"
+	"ecommerce/migrations"
+	"ecommerce/pkg/cache"
+	"ecommerce/pkg/database"
+	"ecommerce/pkg/migrate"
+)
+
+// TestProductRepositoryContract runs the contract tests against Postgres
+// and Redis, when TEST_DATABASE_URL and TEST_REDIS_ADDR point at instances
+// the tests may write to
+func TestProductRepositoryContract(t *testing.T) {
+	dsn, addr := os.Getenv("TEST_DATABASE_URL"), os.Getenv("TEST_REDIS_ADDR")
+	if dsn == "" || addr == "" {
+		t.Skip("TEST_DATABASE_URL and TEST_REDIS_ADDR are not set")
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: gormlogger.Default.LogMode(gormlogger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to connect to Postgres: %v", err)
+	}
+	migrator, err := migrate.New(db, migrations.FS, logger)
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	redisClient := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { redisClient.Close() })
+
+	store := cache.New(redisClient, cache.Options{}, logger)
+	repo := repository.NewProductRepository(db, redisClient, store, nil, config.RedisConfig{}, logger)
+	tx := database.NewTxManager(db)
+
+	repositorytest.Run(t, func(t *testing.T) (repository.ProductRepository, database.TxManager) {
+		return repo, tx
+	})
+}
@@ -0,0 +1,1544 @@
+// Package repositorytest holds the contract tests every ProductRepository
+// implementation must pass, so the in-memory repository services are unit
+// tested against behaves like the Postgres one.
+//
+// The tests may share a database with other data: everything they create
+// has unique names, SKUs and slugs, and they only look at what they created.
+package repositorytest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/internal/product/repository"
+	"ecommerce/pkg/database"
+	customErrors "ecommerce/pkg/errors"
+)
+
+// Factory returns the repository under test and the transaction manager
+// that spans it
+type Factory func(t *testing.T) (repository.ProductRepository, database.TxManager)
+
+// Run runs the contract tests against the repositories newRepository returns
+func Run(t *testing.T, newRepository Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, c *contract)
+	}{
+		{"Products", testProducts},
+		{"SoftDelete", testSoftDelete},
+		{"StockLedger", testStockLedger},
+		{"Reservations", testReservations},
+		{"Warehouses", testWarehouses},
+		{"Backorders", testBackorders},
+		{"DigitalProducts", testDigitalProducts},
+		{"Bundles", testBundles},
+		{"PriceTiers", testPriceTiers},
+		{"Channels", testChannels},
+		{"Vendors", testVendors},
+		{"Revisions", testRevisions},
+		{"Changesets", testChangesets},
+		{"FlashSales", testFlashSales},
+		{"List", testList},
+		{"CategoryTree", testCategoryTree},
+		{"Slugs", testSlugs},
+		{"UpsertBySKU", testUpsertBySKU},
+		{"Reviews", testReviews},
+		{"NotFound", testNotFound},
+		{"Transactions", testTransactions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, tx := newRepository(t)
+			tt.test(t, &contract{repo: repo, tx: tx, ctx: context.Background()})
+		})
+	}
+}
+
+// contract is the state of one contract test
+type contract struct {
+	repo repository.ProductRepository
+	tx   database.TxManager
+	ctx  context.Context
+}
+
+// unique returns a name no other test run uses
+func unique(prefix string) string {
+	return prefix + "-" + uuid.NewString()[:13]
+}
+
+// category creates a top-level category, or a subcategory of parent
+func (c *contract) category(t *testing.T, parent *domain.Category) *domain.Category {
+	t.Helper()
+	name := unique("category")
+	category := &domain.Category{Name: name, Slug: name, IsActive: true}
+	if parent != nil {
+		category.ParentID = &parent.ID
+	}
+	if err := c.repo.CreateCategory(c.ctx, category); err != nil {
+		t.Fatalf("CreateCategory: %v", err)
+	}
+	return category
+}
+
+// product creates a published, active product in a category
+func (c *contract) product(t *testing.T, category *domain.Category, price float64, stock int) *domain.Product {
+	t.Helper()
+	name := unique("product")
+	product := &domain.Product{
+		Name:       name,
+		Slug:       name,
+		SKU:        strings.ToUpper(name),
+		Price:      price,
+		CategoryID: category.ID,
+		Stock:      stock,
+		IsActive:   true,
+		Status:     domain.ProductStatusPublished,
+	}
+	if err := c.repo.Create(c.ctx, product, domain.StockMovement{Reason: domain.StockReasonInitial}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return product
+}
+
+// get loads a live product
+func (c *contract) get(t *testing.T, id uuid.UUID) *domain.Product {
+	t.Helper()
+	product, err := c.repo.GetByID(c.ctx, id)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	return product
+}
+
+// expectCode fails unless err carries the application code
+func expectCode(t *testing.T, err error, code string) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("expected an error with code %s, got nil", code)
+	}
+	if got := customErrors.Code(err); got != code {
+		t.Fatalf("expected code %s, got %q (%v)", code, got, err)
+	}
+}
+
+func testProducts(t *testing.T, c *contract) {
+	category := c.category(t, nil)
+	product := c.product(t, category, 10, 5)
+	if product.ID == uuid.Nil {
+		t.Fatal("Create did not assign an ID")
+	}
+
+	got := c.get(t, product.ID)
+	if got.Name != product.Name || got.Price != 10 || got.Stock != 5 || got.CategoryID != category.ID {
+		t.Fatalf("GetByID returned %+v", got)
+	}
+	if got.UnitOfMeasure != domain.UnitEach || got.QuantityIncrement != 1 {
+		t.Fatalf("product created without order quantities is sold %d %q at a time", got.QuantityIncrement, got.UnitOfMeasure)
+	}
+
+	bySKU, err := c.repo.GetBySKU(c.ctx, strings.ToLower(product.SKU))
+	if err != nil {
+		t.Fatalf("GetBySKU ignoring case: %v", err)
+	}
+	if bySKU.ID != product.ID {
+		t.Fatalf("GetBySKU returned %s, want %s", bySKU.ID, product.ID)
+	}
+
+	duplicate := &domain.Product{
+		Name:       unique("product"),
+		Slug:       unique("product"),
+		SKU:        strings.ToLower(product.SKU),
+		Price:      1,
+		CategoryID: category.ID,
+	}
+	err = c.repo.Create(c.ctx, duplicate, domain.StockMovement{Reason: domain.StockReasonInitial})
+	if !customErrors.IsConflict(err) {
+		t.Fatalf("expected a conflict creating a duplicate SKU, got %v", err)
+	}
+	expectCode(t, err, customErrors.CodeProductSKUConflict)
+
+	// Stock only changes through the ledger
+	got.Name = unique("renamed")
+	got.Stock = 100
+	got.Category = nil
+	if err := c.repo.Update(c.ctx, got); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	updated := c.get(t, product.ID)
+	if updated.Name != got.Name {
+		t.Fatalf("Update did not save the name: %q", updated.Name)
+	}
+	if updated.Stock != 5 {
+		t.Fatalf("Update changed stock to %d", updated.Stock)
+	}
+
+	found, err := c.repo.GetByIDs(c.ctx, []uuid.UUID{product.ID, uuid.New()})
+	if err != nil {
+		t.Fatalf("GetByIDs: %v", err)
+	}
+	if len(found) != 1 || found[product.ID] == nil {
+		t.Fatalf("GetByIDs returned %d products", len(found))
+	}
+}
+
+func testSoftDelete(t *testing.T, c *contract) {
+	category := c.category(t, nil)
+	product := c.product(t, category, 10, 0)
+
+	if err := c.repo.Delete(c.ctx, product.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	_, err := c.repo.GetByID(c.ctx, product.ID)
+	expectCode(t, err, customErrors.CodeProductNotFound)
+
+	deleted, err := c.repo.GetDeleted(c.ctx, product.ID)
+	if err != nil {
+		t.Fatalf("GetDeleted: %v", err)
+	}
+	if !deleted.DeletedAt.Valid {
+		t.Fatal("GetDeleted returned a product without a deletion time")
+	}
+
+	// The SKU is free again once the product is deleted, so restoring it
+	// conflicts with the product that took it
+	replacement := &domain.Product{
+		Name:       unique("product"),
+		Slug:       unique("product"),
+		SKU:        product.SKU,
+		Price:      1,
+		CategoryID: category.ID,
+	}
+	if err := c.repo.Create(c.ctx, replacement, domain.StockMovement{Reason: domain.StockReasonInitial}); err != nil {
+		t.Fatalf("Create reusing a deleted SKU: %v", err)
+	}
+	expectCode(t, c.repo.Restore(c.ctx, product.ID), customErrors.CodeProductSKUConflict)
+
+	if err := c.repo.Delete(c.ctx, replacement.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := c.repo.Restore(c.ctx, product.ID); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	c.get(t, product.ID)
+}
+
+func testStockLedger(t *testing.T, c *contract) {
+	category := c.category(t, nil)
+	product := c.product(t, category, 10, 5)
+
+	movements, total, err := c.repo.ListStockMovements(c.ctx, product.ID, &domain.StockMovementFilters{Limit: 10})
+	if err != nil {
+		t.Fatalf("ListStockMovements: %v", err)
+	}
+	if total != 1 || len(movements) != 1 {
+		t.Fatalf("expected the initial movement only, got %d", total)
+	}
+	if m := movements[0]; m.Reason != domain.StockReasonInitial || m.Delta != 5 || m.Balance != 5 {
+		t.Fatalf("initial movement is %+v", m)
+	}
+
+	entry, err := c.repo.AdjustStock(c.ctx, product.ID, -2, domain.StockMovement{Reason: domain.StockReasonAdjustment})
+	if err != nil {
+		t.Fatalf("AdjustStock: %v", err)
+	}
+	if entry.Delta != -2 || entry.Balance != 3 {
+		t.Fatalf("AdjustStock recorded %+v", entry)
+	}
+
+	_, err = c.repo.AdjustStock(c.ctx, product.ID, -4, domain.StockMovement{Reason: domain.StockReasonAdjustment})
+	if !customErrors.IsConflict(err) {
+		t.Fatalf("expected a conflict adjusting below zero, got %v", err)
+	}
+	expectCode(t, err, customErrors.CodeInsufficientStock)
+
+	_, err = c.repo.AdjustStock(c.ctx, uuid.New(), 1, domain.StockMovement{Reason: domain.StockReasonRestock})
+	expectCode(t, err, customErrors.CodeProductNotFound)
+
+	entry, err = c.repo.SetStock(c.ctx, product.ID, 3, domain.StockMovement{Reason: domain.StockReasonAdjustment})
+	if err != nil {
+		t.Fatalf("SetStock: %v", err)
+	}
+	if entry != nil {
+		t.Fatalf("SetStock to the current level recorded %+v", entry)
+	}
+	entry, err = c.repo.SetStock(c.ctx, product.ID, 7, domain.StockMovement{Reason: domain.StockReasonAdjustment})
+	if err != nil {
+		t.Fatalf("SetStock: %v", err)
+	}
+	if entry == nil || entry.Delta != 4 || entry.Balance != 7 {
+		t.Fatalf("SetStock recorded %+v", entry)
+	}
+
+	movements, total, err = c.repo.ListStockMovements(c.ctx, product.ID, &domain.StockMovementFilters{
+		Reason: domain.StockReasonAdjustment,
+		Limit:  10,
+	})
+	if err != nil {
+		t.Fatalf("ListStockMovements: %v", err)
+	}
+	if total != 2 || movements[0].Delta != 4 {
+		t.Fatalf("expected two adjustments, newest first, got %+v", movements)
+	}
+
+	drift, err := c.repo.StockDrift(c.ctx, 1000)
+	if err != nil {
+		t.Fatalf("StockDrift: %v", err)
+	}
+	for _, d := range drift {
+		if d.ProductID == product.ID {
+			t.Fatalf("product drifted from its ledger: %+v", d)
+		}
+	}
+}
+
+func testReservations(t *testing.T, c *contract) {
+	category := c.category(t, nil)
+	plenty := c.product(t, category, 10, 5)
+	scarce := c.product(t, category, 20, 1)
+	movement := domain.StockMovement{Reason: domain.StockReasonReservation}
+
+	reference := unique("order")
+	_, err := c.repo.ReserveStock(c.ctx, reference, []domain.StockItem{
+		{ProductID: plenty.ID, Quantity: 2},
+		{ProductID: scarce.ID, Quantity: 2},
+	}, movement)
+	expectCode(t, err, customErrors.CodeInsufficientStock)
+	if stock := c.get(t, plenty.ID).Stock; stock != 5 {
+		t.Fatalf("a failed reservation left stock at %d", stock)
+	}
+
+	items := []domain.StockItem{
+		{ProductID: plenty.ID, Quantity: 2},
+		{ProductID: scarce.ID, Quantity: 1},
+	}
+	reserved, err := c.repo.ReserveStock(c.ctx, reference, items, movement)
+	if err != nil {
+		t.Fatalf("ReserveStock: %v", err)
+	}
+	if len(reserved) != 2 {
+		t.Fatalf("ReserveStock reserved %d items", len(reserved))
+	}
+	for _, reservation := range reserved {
+		if reservation.Status != domain.ReservationStatusReserved {
+			t.Fatalf("reservation has status %q", reservation.Status)
+		}
+		if reservation.ProductID == scarce.ID && reservation.UnitPrice != 20 {
+			t.Fatalf("reservation priced at %v", reservation.UnitPrice)
+		}
+	}
+
+	// Replaying the reference returns the reservation without taking more
+	replayed, err := c.repo.ReserveStock(c.ctx, reference, items, movement)
+	if err != nil {
+		t.Fatalf("ReserveStock replay: %v", err)
+	}
+	if len(replayed) != 2 {
+		t.Fatalf("replay returned %d items", len(replayed))
+	}
+	if stock := c.get(t, plenty.ID).Stock; stock != 3 {
+		t.Fatalf("stock is %d after reserving 2 of 5", stock)
+	}
+
+	released, err := c.repo.ReleaseStock(c.ctx, reference, domain.StockMovement{Reason: domain.StockReasonRelease})
+	if err != nil {
+		t.Fatalf("ReleaseStock: %v", err)
+	}
+	if len(released) != 2 {
+		t.Fatalf("ReleaseStock released %d items", len(released))
+	}
+	if stock := c.get(t, plenty.ID).Stock; stock != 5 {
+		t.Fatalf("stock is %d after release", stock)
+	}
+
+	released, err = c.repo.ReleaseStock(c.ctx, reference, domain.StockMovement{Reason: domain.StockReasonRelease})
+	if err != nil {
+		t.Fatalf("ReleaseStock again: %v", err)
+	}
+	if len(released) != 0 {
+		t.Fatalf("releasing twice released %d items", len(released))
+	}
+
+	reservations, err := c.repo.GetStockReservations(c.ctx, reference)
+	if err != nil {
+		t.Fatalf("GetStockReservations: %v", err)
+	}
+	for _, reservation := range reservations {
+		if reservation.Status != domain.ReservationStatusReleased {
+			t.Fatalf("released reservation has status %q", reservation.Status)
+		}
+	}
+}
+
+func testWarehouses(t *testing.T, c *contract) {
+	fallback, err := c.repo.GetWarehouseByCode(c.ctx, domain.DefaultWarehouseCode)
+	if err != nil {
+		t.Fatalf("GetWarehouseByCode: %v", err)
+	}
+	if !fallback.IsDefault {
+		t.Fatalf("warehouse %q is not the default", fallback.Code)
+	}
+
+	north := &domain.Warehouse{Code: unique("north"), Name: "North", Priority: 10}
+	if err := c.repo.CreateWarehouse(c.ctx, north); err != nil {
+		t.Fatalf("CreateWarehouse: %v", err)
+	}
+	south := &domain.Warehouse{Code: unique("south"), Name: "South"}
+	if err := c.repo.CreateWarehouse(c.ctx, south); err != nil {
+		t.Fatalf("CreateWarehouse: %v", err)
+	}
+	err = c.repo.CreateWarehouse(c.ctx, &domain.Warehouse{Code: north.Code, Name: "Copy"})
+	expectCode(t, err, customErrors.CodeWarehouseCodeConflict)
+	if _, err := c.repo.GetWarehouseByCode(c.ctx, unique("missing")); !customErrors.IsNotFound(err) {
+		t.Fatalf("expected not found for a missing warehouse, got %v", err)
+	}
+
+	// Stock created without a warehouse is held in the default one
+	category := c.category(t, nil)
+	product := c.product(t, category, 10, 5)
+	adjust := func(warehouse *domain.Warehouse, delta int) error {
+		_, err := c.repo.AdjustStock(c.ctx, product.ID, delta, domain.StockMovement{
+			WarehouseID: &warehouse.ID,
+			Reason:      domain.StockReasonRestock,
+		})
+		return err
+	}
+	if err := adjust(north, 3); err != nil {
+		t.Fatalf("AdjustStock: %v", err)
+	}
+	if err := adjust(south, 4); err != nil {
+		t.Fatalf("AdjustStock: %v", err)
+	}
+	if stock := c.get(t, product.ID).Stock; stock != 12 {
+		t.Fatalf("stock is %d after restocking two warehouses", stock)
+	}
+
+	// The product has the stock, but not in the warehouse
+	expectCode(t, adjust(north, -4), customErrors.CodeInsufficientStock)
+	if stock := c.get(t, product.ID).Stock; stock != 12 {
+		t.Fatalf("a failed adjustment left stock at %d", stock)
+	}
+
+	levels := func(warehouseID *uuid.UUID) map[string]int {
+		t.Helper()
+		found, err := c.repo.ListStockLevels(c.ctx, []uuid.UUID{product.ID}, warehouseID)
+		if err != nil {
+			t.Fatalf("ListStockLevels: %v", err)
+		}
+		held := make(map[string]int, len(found))
+		for _, level := range found {
+			held[level.WarehouseCode] = level.Quantity
+		}
+		return held
+	}
+	held := levels(nil)
+	if len(held) != 3 || held[fallback.Code] != 5 || held[north.Code] != 3 || held[south.Code] != 4 {
+		t.Fatalf("stock levels are %v", held)
+	}
+	if held := levels(&south.ID); len(held) != 1 || held[south.Code] != 4 {
+		t.Fatalf("stock levels in one warehouse are %v", held)
+	}
+
+	// Setting the stock of a warehouse changes the product's total by the
+	// difference
+	entry, err := c.repo.SetStock(c.ctx, product.ID, 1, domain.StockMovement{
+		WarehouseID: &south.ID,
+		Reason:      domain.StockReasonCount,
+	})
+	if err != nil {
+		t.Fatalf("SetStock: %v", err)
+	}
+	if entry == nil || entry.Delta != -3 || entry.Balance != 9 || *entry.WarehouseID != south.ID {
+		t.Fatalf("SetStock recorded %+v", entry)
+	}
+
+	// A reservation takes from the highest priority warehouse first, then
+	// from those holding the most
+	movement := domain.StockMovement{Reason: domain.StockReasonReservation}
+	reference := unique("order")
+	reserved, err := c.repo.ReserveStock(c.ctx, reference, []domain.StockItem{{ProductID: product.ID, Quantity: 6}}, movement)
+	if err != nil {
+		t.Fatalf("ReserveStock: %v", err)
+	}
+	taken := make(map[uuid.UUID]int)
+	for _, reservation := range reserved {
+		taken[*reservation.WarehouseID] += reservation.Quantity
+	}
+	if len(reserved) != 2 || taken[north.ID] != 3 || taken[fallback.ID] != 3 {
+		t.Fatalf("reservation took %v", taken)
+	}
+	if held := levels(nil); held[fallback.Code] != 2 || held[north.Code] != 0 || held[south.Code] != 1 {
+		t.Fatalf("stock levels after reserving are %v", held)
+	}
+
+	movement.WarehouseID = &south.ID
+	_, err = c.repo.ReserveStock(c.ctx, unique("order"), []domain.StockItem{{ProductID: product.ID, Quantity: 2}}, movement)
+	expectCode(t, err, customErrors.CodeInsufficientStock)
+	if stock := c.get(t, product.ID).Stock; stock != 3 {
+		t.Fatalf("a failed reservation left stock at %d", stock)
+	}
+
+	// Releasing puts the stock back where it came from
+	if _, err := c.repo.ReleaseStock(c.ctx, reference, domain.StockMovement{Reason: domain.StockReasonRelease}); err != nil {
+		t.Fatalf("ReleaseStock: %v", err)
+	}
+	if held := levels(nil); held[fallback.Code] != 5 || held[north.Code] != 3 || held[south.Code] != 1 {
+		t.Fatalf("stock levels after releasing are %v", held)
+	}
+	if stock := c.get(t, product.ID).Stock; stock != 9 {
+		t.Fatalf("stock is %d after release", stock)
+	}
+
+	drift, err := c.repo.StockDrift(c.ctx, 1000)
+	if err != nil {
+		t.Fatalf("StockDrift: %v", err)
+	}
+	for _, d := range drift {
+		if d.ProductID == product.ID {
+			t.Fatalf("product drifted from its ledger: %+v", d)
+		}
+	}
+}
+
+func testBackorders(t *testing.T, c *contract) {
+	category := c.category(t, nil)
+	backordered := c.product(t, category, 10, 2)
+	backordered.AllowBackorder = true
+	if err := c.repo.Update(c.ctx, backordered); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	released := time.Now().Add(-time.Hour)
+	shipped := c.product(t, category, 10, 0)
+	shipped.Preorder = true
+	shipped.ReleaseDate = &released
+	if err := c.repo.Update(c.ctx, shipped); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	upcoming := c.product(t, category, 10, 0)
+	upcoming.Preorder = true
+	if err := c.repo.Update(c.ctx, upcoming); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	// A preorder ends on its release date
+	movement := domain.StockMovement{Reason: domain.StockReasonReservation}
+	_, err := c.repo.ReserveStock(c.ctx, unique("order"), []domain.StockItem{{ProductID: shipped.ID, Quantity: 1}}, movement)
+	expectCode(t, err, customErrors.CodeInsufficientStock)
+
+	// Stock is taken while it lasts; the rest is owed by the default
+	// warehouse
+	reference := unique("order")
+	reserved, err := c.repo.ReserveStock(c.ctx, reference, []domain.StockItem{
+		{ProductID: backordered.ID, Quantity: 5},
+		{ProductID: upcoming.ID, Quantity: 3},
+	}, movement)
+	if err != nil {
+		t.Fatalf("ReserveStock: %v", err)
+	}
+	if len(reserved) != 2 {
+		t.Fatalf("ReserveStock reserved %d rows", len(reserved))
+	}
+	if stock := c.get(t, backordered.ID).Stock; stock != -3 {
+		t.Fatalf("backordered stock is %d after reserving 5 of 2", stock)
+	}
+	if stock := c.get(t, upcoming.ID).Stock; stock != -3 {
+		t.Fatalf("preordered stock is %d after reserving 3", stock)
+	}
+	levels, err := c.repo.ListStockLevels(c.ctx, []uuid.UUID{backordered.ID}, nil)
+	if err != nil {
+		t.Fatalf("ListStockLevels: %v", err)
+	}
+	if len(levels) != 1 || levels[0].WarehouseCode != domain.DefaultWarehouseCode || levels[0].Quantity != -3 {
+		t.Fatalf("stock levels are %+v", levels)
+	}
+
+	// Manual adjustments still cannot oversell, but restocking pays back
+	// what is owed
+	_, err = c.repo.AdjustStock(c.ctx, backordered.ID, -1, domain.StockMovement{Reason: domain.StockReasonShrinkage})
+	expectCode(t, err, customErrors.CodeInsufficientStock)
+	entry, err := c.repo.AdjustStock(c.ctx, backordered.ID, 4, domain.StockMovement{Reason: domain.StockReasonRestock})
+	if err != nil {
+		t.Fatalf("AdjustStock: %v", err)
+	}
+	if entry.Balance != 1 {
+		t.Fatalf("restocking left a balance of %d", entry.Balance)
+	}
+
+	if _, err := c.repo.ReleaseStock(c.ctx, reference, domain.StockMovement{Reason: domain.StockReasonRelease}); err != nil {
+		t.Fatalf("ReleaseStock: %v", err)
+	}
+	if stock := c.get(t, upcoming.ID).Stock; stock != 0 {
+		t.Fatalf("preordered stock is %d after release", stock)
+	}
+
+	drift, err := c.repo.StockDrift(c.ctx, 1000)
+	if err != nil {
+		t.Fatalf("StockDrift: %v", err)
+	}
+	for _, d := range drift {
+		if d.ProductID == backordered.ID || d.ProductID == upcoming.ID {
+			t.Fatalf("product drifted from its ledger: %+v", d)
+		}
+	}
+}
+
+func testDigitalProducts(t *testing.T, c *contract) {
+	category := c.category(t, nil)
+	physical := c.product(t, category, 10, 1)
+	digital := c.product(t, category, 5, 0)
+	digital.Type = domain.ProductTypeDigital
+	if err := c.repo.Update(c.ctx, digital); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got := c.get(t, physical.ID).Type; got != domain.ProductTypePhysical {
+		t.Fatalf("product created without a type has type %q", got)
+	}
+
+	// Digital products are reserved without a warehouse or any stock
+	reference := unique("order")
+	movement := domain.StockMovement{Reason: domain.StockReasonReservation}
+	reserved, err := c.repo.ReserveStock(c.ctx, reference, []domain.StockItem{
+		{ProductID: physical.ID, Quantity: 1},
+		{ProductID: digital.ID, Quantity: 3},
+	}, movement)
+	if err != nil {
+		t.Fatalf("ReserveStock: %v", err)
+	}
+	if len(reserved) != 2 {
+		t.Fatalf("ReserveStock reserved %d rows", len(reserved))
+	}
+	for _, reservation := range reserved {
+		if (reservation.ProductID == digital.ID) != (reservation.WarehouseID == nil) {
+			t.Fatalf("reservation of %s has warehouse %v", reservation.ProductID, reservation.WarehouseID)
+		}
+	}
+	if stock := c.get(t, digital.ID).Stock; stock != 0 {
+		t.Fatalf("digital stock is %d after reserving", stock)
+	}
+	if _, err := c.repo.ReleaseStock(c.ctx, reference, domain.StockMovement{Reason: domain.StockReasonRelease}); err != nil {
+		t.Fatalf("ReleaseStock: %v", err)
+	}
+	if stock := c.get(t, digital.ID).Stock; stock != 0 {
+		t.Fatalf("digital stock is %d after release", stock)
+	}
+
+	asset := &domain.DigitalAsset{ProductID: digital.ID, StorageKey: "downloads/" + unique("asset"), Filename: "manual.pdf"}
+	if err := c.repo.CreateDigitalAsset(c.ctx, asset); err != nil {
+		t.Fatalf("CreateDigitalAsset: %v", err)
+	}
+	assets, err := c.repo.ListDigitalAssets(c.ctx, digital.ID)
+	if err != nil {
+		t.Fatalf("ListDigitalAssets: %v", err)
+	}
+	if len(assets) != 1 || assets[0].ID != asset.ID {
+		t.Fatalf("ListDigitalAssets returned %+v", assets)
+	}
+
+	// Granting is idempotent per order and product
+	orderID := uuid.New()
+	customer := unique("customer")
+	entitlement := domain.Entitlement{
+		OrderID:     orderID,
+		CustomerID:  customer,
+		ProductID:   digital.ID,
+		ProductName: digital.Name,
+		Quantity:    3,
+		LicenseKey:  unique("license"),
+	}
+	granted, err := c.repo.GrantEntitlements(c.ctx, []domain.Entitlement{entitlement})
+	if err != nil {
+		t.Fatalf("GrantEntitlements: %v", err)
+	}
+	if len(granted) != 1 || granted[0].ID == uuid.Nil {
+		t.Fatalf("GrantEntitlements granted %+v", granted)
+	}
+	entitlement.LicenseKey = unique("license")
+	again, err := c.repo.GrantEntitlements(c.ctx, []domain.Entitlement{entitlement})
+	if err != nil {
+		t.Fatalf("GrantEntitlements: %v", err)
+	}
+	if len(again) != 0 {
+		t.Fatalf("granting again granted %+v", again)
+	}
+
+	found, err := c.repo.GetEntitlement(c.ctx, granted[0].ID)
+	if err != nil {
+		t.Fatalf("GetEntitlement: %v", err)
+	}
+	if found.LicenseKey != granted[0].LicenseKey {
+		t.Fatalf("entitlement has license key %q, granted %q", found.LicenseKey, granted[0].LicenseKey)
+	}
+	list, total, err := c.repo.ListEntitlements(c.ctx, &domain.EntitlementFilters{CustomerID: customer, Limit: 10})
+	if err != nil {
+		t.Fatalf("ListEntitlements: %v", err)
+	}
+	if total != 1 || len(list) != 1 || list[0].OrderID != orderID {
+		t.Fatalf("ListEntitlements returned %d of %d", len(list), total)
+	}
+
+	if err := c.repo.DeleteDigitalAsset(c.ctx, asset.ID); err != nil {
+		t.Fatalf("DeleteDigitalAsset: %v", err)
+	}
+	_, err = c.repo.GetDigitalAsset(c.ctx, asset.ID)
+	expectCode(t, err, customErrors.CodeDigitalAssetNotFound)
+}
+
+// testBundles checks that reserving a bundle reserves its components, and
+// that computed bundles follow their components' prices
+func testBundles(t *testing.T, c *contract) {
+	category := c.category(t, nil)
+	shirt := c.product(t, category, 20, 5)
+	socks := c.product(t, category, 5, 10)
+	bundle := c.product(t, category, 40, 0)
+	bundle.Type = domain.ProductTypeBundle
+	bundle.BundlePricing = domain.BundlePricingComputed
+	bundle.BundleDiscount = 10
+	if err := c.repo.Update(c.ctx, bundle); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err := c.repo.ReplaceBundleComponents(c.ctx, bundle.ID, []domain.BundleComponent{
+		{ComponentID: shirt.ID, Quantity: 1},
+		{ComponentID: socks.ID, Quantity: 2},
+	}); err != nil {
+		t.Fatalf("ReplaceBundleComponents: %v", err)
+	}
+	components, err := c.repo.ListBundleComponents(c.ctx, []uuid.UUID{bundle.ID})
+	if err != nil {
+		t.Fatalf("ListBundleComponents: %v", err)
+	}
+	if len(components) != 2 {
+		t.Fatalf("ListBundleComponents returned %+v", components)
+	}
+
+	// (20 + 2 * 5) less 10%
+	repriced, err := c.repo.RepriceBundles(c.ctx, socks.ID)
+	if err != nil {
+		t.Fatalf("RepriceBundles: %v", err)
+	}
+	if len(repriced) != 1 || repriced[0] != bundle.ID {
+		t.Fatalf("RepriceBundles repriced %v", repriced)
+	}
+	if price := c.get(t, bundle.ID).Price; price != 27 {
+		t.Fatalf("computed bundle price is %v, want 27", price)
+	}
+	if repriced, err := c.repo.RepriceBundles(c.ctx, socks.ID); err != nil || len(repriced) != 0 {
+		t.Fatalf("repricing again repriced %v (%v)", repriced, err)
+	}
+
+	// Two bundles and a shirt of its own
+	reference := unique("order")
+	reserved, err := c.repo.ReserveStock(c.ctx, reference, []domain.StockItem{
+		{ProductID: bundle.ID, Quantity: 2},
+		{ProductID: shirt.ID, Quantity: 1},
+	}, domain.StockMovement{Reason: domain.StockReasonReservation})
+	if err != nil {
+		t.Fatalf("ReserveStock: %v", err)
+	}
+	if len(reserved) != 4 {
+		t.Fatalf("ReserveStock reserved %d rows", len(reserved))
+	}
+	for _, reservation := range reserved {
+		switch {
+		case reservation.ProductID == bundle.ID:
+			if reservation.WarehouseID != nil || reservation.BundleID != nil || reservation.Quantity != 2 {
+				t.Fatalf("bundle reserved as %+v", reservation)
+			}
+		case reservation.BundleID != nil:
+			if *reservation.BundleID != bundle.ID {
+				t.Fatalf("component reserved for bundle %s", reservation.BundleID)
+			}
+		case reservation.ProductID != shirt.ID || reservation.Quantity != 1:
+			t.Fatalf("unexpected reservation %+v", reservation)
+		}
+	}
+	if stock := c.get(t, shirt.ID).Stock; stock != 2 {
+		t.Fatalf("shirt stock is %d after reserving, want 2", stock)
+	}
+	if stock := c.get(t, socks.ID).Stock; stock != 6 {
+		t.Fatalf("socks stock is %d after reserving, want 6", stock)
+	}
+
+	// Components run short for a bundle as for any other order
+	_, err = c.repo.ReserveStock(c.ctx, unique("order"), []domain.StockItem{
+		{ProductID: bundle.ID, Quantity: 3},
+	}, domain.StockMovement{Reason: domain.StockReasonReservation})
+	expectCode(t, err, customErrors.CodeInsufficientStock)
+
+	if _, err := c.repo.ReleaseStock(c.ctx, reference, domain.StockMovement{Reason: domain.StockReasonRelease}); err != nil {
+		t.Fatalf("ReleaseStock: %v", err)
+	}
+	if stock := c.get(t, shirt.ID).Stock; stock != 5 {
+		t.Fatalf("shirt stock is %d after release, want 5", stock)
+	}
+	if stock := c.get(t, socks.ID).Stock; stock != 10 {
+		t.Fatalf("socks stock is %d after release, want 10", stock)
+	}
+
+	// A returned bundle puts its components back into stock, once
+	returned := domain.StockMovement{Reason: domain.StockReasonReturn, Reference: unique("return")}
+	items := []domain.StockItem{{ProductID: bundle.ID, Quantity: 1}, {ProductID: shirt.ID, Quantity: 1}}
+	movements, err := c.repo.ReturnStock(c.ctx, items, returned)
+	if err != nil {
+		t.Fatalf("ReturnStock: %v", err)
+	}
+	if len(movements) != 3 {
+		t.Fatalf("ReturnStock recorded %d movements, want 3", len(movements))
+	}
+	if stock := c.get(t, shirt.ID).Stock; stock != 7 {
+		t.Fatalf("shirt stock is %d after return, want 7", stock)
+	}
+	if stock := c.get(t, socks.ID).Stock; stock != 12 {
+		t.Fatalf("socks stock is %d after return, want 12", stock)
+	}
+	if movements, err := c.repo.ReturnStock(c.ctx, items, returned); err != nil || len(movements) != 0 {
+		t.Fatalf("returning again recorded %v (%v)", movements, err)
+	}
+	if stock := c.get(t, shirt.ID).Stock; stock != 7 {
+		t.Fatalf("shirt stock is %d after returning again, want 7", stock)
+	}
+}
+
+// testPriceTiers checks that a product's price tiers are replaced as a
+// whole and price by group and quantity
+func testPriceTiers(t *testing.T, c *contract) {
+	category := c.category(t, nil)
+	product := c.product(t, category, 20, 5)
+	other := c.product(t, category, 10, 5)
+
+	if err := c.repo.ReplacePriceTiers(c.ctx, product.ID, []domain.PriceTier{
+		{CustomerGroup: domain.CustomerGroupWholesale, MinQuantity: 1, Price: 15},
+		{MinQuantity: 10, Price: 18},
+	}); err != nil {
+		t.Fatalf("ReplacePriceTiers: %v", err)
+	}
+	if err := c.repo.ReplacePriceTiers(c.ctx, other.ID, []domain.PriceTier{
+		{CustomerGroup: domain.CustomerGroupVIP, MinQuantity: 1, Price: 8},
+	}); err != nil {
+		t.Fatalf("ReplacePriceTiers: %v", err)
+	}
+
+	tiers, err := c.repo.ListPriceTiers(c.ctx, []uuid.UUID{product.ID})
+	if err != nil {
+		t.Fatalf("ListPriceTiers: %v", err)
+	}
+	if len(tiers) != 2 || tiers[0].CustomerGroup != "" || tiers[1].CustomerGroup != domain.CustomerGroupWholesale {
+		t.Fatalf("ListPriceTiers returned %+v", tiers)
+	}
+
+	product.PriceTiers = tiers
+	now := time.Now()
+	for _, tt := range []struct {
+		group    string
+		quantity int
+		want     float64
+	}{
+		{"", 1, 20},
+		{"", 10, 18},
+		{domain.CustomerGroupWholesale, 1, 15},
+		{domain.CustomerGroupWholesale, 10, 15},
+		{domain.CustomerGroupVIP, 10, 18},
+	} {
+		if price := product.PriceFor(tt.group, tt.quantity, now); price != tt.want {
+			t.Fatalf("%d units for group %q cost %v, want %v", tt.quantity, tt.group, price, tt.want)
+		}
+	}
+
+	// Two tiers for the same group and quantity
+	err = c.repo.ReplacePriceTiers(c.ctx, product.ID, []domain.PriceTier{
+		{MinQuantity: 5, Price: 19},
+		{MinQuantity: 5, Price: 17},
+	})
+	if !database.IsUniqueViolation(err, "product_price_tiers_product_id_customer_group_min_quantity_key") {
+		t.Fatalf("duplicate tiers: %v", err)
+	}
+	if tiers, err := c.repo.ListPriceTiers(c.ctx, []uuid.UUID{product.ID}); err != nil || len(tiers) != 2 {
+		t.Fatalf("failed replace left tiers %+v (%v)", tiers, err)
+	}
+
+	if err := c.repo.ReplacePriceTiers(c.ctx, product.ID, nil); err != nil {
+		t.Fatalf("ReplacePriceTiers: %v", err)
+	}
+	tiers, err = c.repo.ListPriceTiers(c.ctx, []uuid.UUID{product.ID, other.ID})
+	if err != nil {
+		t.Fatalf("ListPriceTiers: %v", err)
+	}
+	if len(tiers) != 1 || tiers[0].ProductID != other.ID {
+		t.Fatalf("ListPriceTiers returned %+v after clearing", tiers)
+	}
+}
+
+// testChannels checks that products hidden from a sales channel are left
+// out of its listings, and that channel prices replace regular ones
+func testChannels(t *testing.T, c *contract) {
+	category := c.category(t, nil)
+	everywhere := c.product(t, category, 20, 1)
+	offline := c.product(t, category, 30, 1)
+
+	marketplacePrice := 25.0
+	offline.Channels = map[string]domain.ChannelSettings{
+		domain.ChannelWeb:         {Hidden: true},
+		domain.ChannelMarketplace: {Price: &marketplacePrice},
+	}
+	if err := c.repo.Update(c.ctx, offline); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	for _, tt := range []struct {
+		channel string
+		want    int64
+	}{
+		{"", 2},
+		{domain.ChannelWeb, 1},
+		{domain.ChannelMarketplace, 2},
+	} {
+		products, total, err := c.repo.List(c.ctx, &domain.ProductFilters{CategoryID: &category.ID, Channel: tt.channel, Limit: 10})
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		if total != tt.want || int64(len(products)) != tt.want {
+			t.Fatalf("channel %q lists %d of %d products, want %d", tt.channel, len(products), total, tt.want)
+		}
+		if tt.channel == domain.ChannelWeb && products[0].ID != everywhere.ID {
+			t.Fatalf("web lists %s, want %s", products[0].ID, everywhere.ID)
+		}
+	}
+
+	stored := c.get(t, offline.ID)
+	now := time.Now()
+	if price := stored.PriceFor("", 1, now); price != 30 {
+		t.Fatalf("price outside any channel is %v, want 30", price)
+	}
+	stored.Channel = domain.ChannelMarketplace
+	if price := stored.PriceFor("", 1, now); price != 25 {
+		t.Fatalf("marketplace price is %v, want 25", price)
+	}
+}
+
+func testVendors(t *testing.T, c *contract) {
+	owner := unique("owner")
+	vendor := &domain.Vendor{Name: unique("vendor"), OwnerID: owner, CommissionRate: 12.5}
+	if err := c.repo.CreateVendor(c.ctx, vendor); err != nil {
+		t.Fatalf("CreateVendor: %v", err)
+	}
+	if vendor.Status != domain.VendorStatusActive {
+		t.Fatalf("new vendor is %q, want %q", vendor.Status, domain.VendorStatusActive)
+	}
+	err := c.repo.CreateVendor(c.ctx, &domain.Vendor{Name: unique("vendor"), OwnerID: owner})
+	expectCode(t, err, customErrors.CodeVendorOwnerConflict)
+
+	found, err := c.repo.GetVendorByOwner(c.ctx, owner)
+	if err != nil {
+		t.Fatalf("GetVendorByOwner: %v", err)
+	}
+	if found.ID != vendor.ID || found.CommissionRate != 12.5 {
+		t.Fatalf("GetVendorByOwner = %+v, want %+v", found, vendor)
+	}
+
+	category := c.category(t, nil)
+	c.product(t, category, 10, 1)
+	sold := c.product(t, category, 20, 1)
+	due := time.Now().Add(-time.Minute)
+	sold.VendorID = &vendor.ID
+	sold.ApprovalStatus = domain.ApprovalPending
+	sold.Status = domain.ProductStatusDraft
+	sold.PublishAt = &due
+	if err := c.repo.Update(c.ctx, sold); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	for _, filters := range []domain.ProductFilters{
+		{VendorID: &vendor.ID},
+		{ApprovalStatus: domain.ApprovalPending},
+	} {
+		filters.CategoryID, filters.Limit = &category.ID, 10
+		products, total, err := c.repo.List(c.ctx, &filters)
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		if total != 1 || len(products) != 1 || products[0].ID != sold.ID {
+			t.Fatalf("List(%+v) = %d of %d products, want only the vendor's", filters, len(products), total)
+		}
+	}
+
+	// Scheduled vendor products wait for their approval
+	if _, err := c.repo.PublishDue(c.ctx, 1000); err != nil {
+		t.Fatalf("PublishDue: %v", err)
+	}
+	if stored := c.get(t, sold.ID); stored.IsPublished() {
+		t.Fatal("product awaiting approval was published")
+	}
+	sold = c.get(t, sold.ID)
+	sold.ApprovalStatus = domain.ApprovalApproved
+	if err := c.repo.Update(c.ctx, sold); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if _, err := c.repo.PublishDue(c.ctx, 1000); err != nil {
+		t.Fatalf("PublishDue: %v", err)
+	}
+	if stored := c.get(t, sold.ID); !stored.IsPublished() {
+		t.Fatal("approved product was not published")
+	}
+}
+
+func testRevisions(t *testing.T, c *contract) {
+	category := c.category(t, nil)
+	product := c.product(t, category, 10, 1)
+
+	revision := &domain.ProductRevision{
+		ProductID:   product.ID,
+		Patch:       json.RawMessage(`{"price":12}`),
+		SubmittedBy: unique("user"),
+	}
+	if err := c.repo.CreateRevision(c.ctx, revision); err != nil {
+		t.Fatalf("CreateRevision: %v", err)
+	}
+	if revision.Status != domain.RevisionStatusPending {
+		t.Fatalf("new revision is %q, want %q", revision.Status, domain.RevisionStatusPending)
+	}
+	err := c.repo.CreateRevision(c.ctx, &domain.ProductRevision{ProductID: uuid.New(), Patch: json.RawMessage(`{}`), SubmittedBy: "user"})
+	if !customErrors.IsValidation(database.TranslateError(err)) {
+		t.Fatalf("expected a validation error revising a missing product, got %v", err)
+	}
+
+	now := time.Now()
+	revision.Status = domain.RevisionStatusRejected
+	revision.ReviewComment = "too expensive"
+	revision.ReviewedAt = &now
+	if err := c.repo.UpdateRevision(c.ctx, revision); err != nil {
+		t.Fatalf("UpdateRevision: %v", err)
+	}
+	stored, err := c.repo.GetRevision(c.ctx, revision.ID)
+	if err != nil {
+		t.Fatalf("GetRevision: %v", err)
+	}
+	var patch map[string]float64
+	if err := json.Unmarshal(stored.Patch, &patch); err != nil || patch["price"] != 12 {
+		t.Fatalf("GetRevision returned patch %s: %v", stored.Patch, err)
+	}
+	if stored.Status != domain.RevisionStatusRejected || stored.ReviewComment != "too expensive" || stored.ReviewedAt == nil {
+		t.Fatalf("GetRevision = %+v, want the review", stored)
+	}
+
+	pending := &domain.ProductRevision{ProductID: product.ID, Patch: json.RawMessage(`{"stock":3}`), SubmittedBy: "user"}
+	if err := c.repo.CreateRevision(c.ctx, pending); err != nil {
+		t.Fatalf("CreateRevision: %v", err)
+	}
+	revisions, total, err := c.repo.ListRevisions(c.ctx, &domain.RevisionFilters{ProductID: &product.ID, Limit: 10})
+	if err != nil {
+		t.Fatalf("ListRevisions: %v", err)
+	}
+	if total != 2 || len(revisions) != 2 || revisions[0].ID != pending.ID {
+		t.Fatalf("listed %d of %d revisions, want the newest of 2 first", len(revisions), total)
+	}
+	revisions, total, err = c.repo.ListRevisions(c.ctx, &domain.RevisionFilters{
+		ProductID: &product.ID,
+		Status:    domain.RevisionStatusPending,
+		Limit:     10,
+	})
+	if err != nil {
+		t.Fatalf("ListRevisions: %v", err)
+	}
+	if total != 1 || revisions[0].ID != pending.ID {
+		t.Fatalf("listed %d pending revisions, want 1", total)
+	}
+}
+
+func testChangesets(t *testing.T, c *contract) {
+	category := c.category(t, nil)
+	product := c.product(t, category, 10, 1)
+
+	changeset := &domain.Changeset{Name: unique("campaign")}
+	if err := c.repo.CreateChangeset(c.ctx, changeset); err != nil {
+		t.Fatalf("CreateChangeset: %v", err)
+	}
+	if changeset.Status != domain.ChangesetStatusDraft {
+		t.Fatalf("new changeset is %q, want %q", changeset.Status, domain.ChangesetStatusDraft)
+	}
+
+	item := &domain.ChangesetItem{ChangesetID: changeset.ID, ProductID: product.ID, Patch: json.RawMessage(`{"price":8}`)}
+	if err := c.repo.SaveChangesetItem(c.ctx, item); err != nil {
+		t.Fatalf("SaveChangesetItem: %v", err)
+	}
+	replaced := &domain.ChangesetItem{ChangesetID: changeset.ID, ProductID: product.ID, Patch: json.RawMessage(`{"price":7}`)}
+	if err := c.repo.SaveChangesetItem(c.ctx, replaced); err != nil {
+		t.Fatalf("SaveChangesetItem: %v", err)
+	}
+	if replaced.ID != item.ID {
+		t.Fatalf("saving a product's edit again created item %s, want %s", replaced.ID, item.ID)
+	}
+	err := c.repo.SaveChangesetItem(c.ctx, &domain.ChangesetItem{ChangesetID: changeset.ID, ProductID: uuid.New(), Patch: json.RawMessage(`{}`)})
+	if !customErrors.IsValidation(database.TranslateError(err)) {
+		t.Fatalf("expected a validation error editing a missing product, got %v", err)
+	}
+
+	stored, err := c.repo.GetChangeset(c.ctx, changeset.ID)
+	if err != nil {
+		t.Fatalf("GetChangeset: %v", err)
+	}
+	var patch map[string]float64
+	if len(stored.Items) != 1 || json.Unmarshal(stored.Items[0].Patch, &patch) != nil || patch["price"] != 7 {
+		t.Fatalf("GetChangeset returned items %+v, want the replaced edit", stored.Items)
+	}
+
+	// Only changesets still in the expected status move on
+	due := time.Now().Add(-time.Minute)
+	stored.Status = domain.ChangesetStatusScheduled
+	stored.ApplyAt = &due
+	if moved, err := c.repo.TransitionChangeset(c.ctx, stored, domain.ChangesetStatusApplied); err != nil || moved {
+		t.Fatalf("TransitionChangeset from the wrong status = %v, %v", moved, err)
+	}
+	if moved, err := c.repo.TransitionChangeset(c.ctx, stored, domain.ChangesetStatusDraft); err != nil || !moved {
+		t.Fatalf("TransitionChangeset = %v, %v", moved, err)
+	}
+
+	scheduled, err := c.repo.ListDueChangesets(c.ctx, 1000)
+	if err != nil {
+		t.Fatalf("ListDueChangesets: %v", err)
+	}
+	var found bool
+	for _, changeset := range scheduled {
+		found = found || changeset.ID == stored.ID
+	}
+	if !found {
+		t.Fatal("ListDueChangesets left out a changeset due to be applied")
+	}
+
+	changesets, total, err := c.repo.ListChangesets(c.ctx, &domain.ChangesetFilters{Status: domain.ChangesetStatusScheduled, Limit: 1000})
+	if err != nil {
+		t.Fatalf("ListChangesets: %v", err)
+	}
+	if total < 1 || len(changesets) == 0 || changesets[0].Status != domain.ChangesetStatusScheduled {
+		t.Fatalf("ListChangesets listed %d of %d scheduled changesets", len(changesets), total)
+	}
+
+	if deleted, err := c.repo.DeleteChangesetItem(c.ctx, changeset.ID, product.ID); err != nil || !deleted {
+		t.Fatalf("DeleteChangesetItem = %v, %v", deleted, err)
+	}
+	if err := c.repo.DeleteChangeset(c.ctx, changeset.ID); err != nil {
+		t.Fatalf("DeleteChangeset: %v", err)
+	}
+	_, err = c.repo.GetChangeset(c.ctx, changeset.ID)
+	expectCode(t, err, customErrors.CodeChangesetNotFound)
+}
+
+func testFlashSales(t *testing.T, c *contract) {
+	category := c.category(t, nil)
+	product := c.product(t, category, 10, 5)
+
+	now := time.Now()
+	sale := &domain.FlashSale{
+		ProductID:    product.ID,
+		StartsAt:     now.Add(-time.Minute),
+		EndsAt:       now.Add(time.Hour),
+		Price:        5,
+		Quantity:     3,
+		PerUserLimit: 2,
+		Held:         3,
+	}
+	if err := c.repo.CreateFlashSale(c.ctx, sale); err != nil {
+		t.Fatalf("CreateFlashSale: %v", err)
+	}
+	err := c.repo.CreateFlashSale(c.ctx, &domain.FlashSale{ProductID: product.ID, StartsAt: now, EndsAt: now.Add(time.Hour), Price: 5, Quantity: 1})
+	expectCode(t, err, customErrors.CodeFlashSaleExists)
+
+	open, err := c.repo.ListOpenFlashSales(c.ctx, 1000)
+	if err != nil {
+		t.Fatalf("ListOpenFlashSales: %v", err)
+	}
+	var found bool
+	for _, s := range open {
+		found = found || s.ID == sale.ID
+	}
+	if !found {
+		t.Fatal("ListOpenFlashSales left out a running sale")
+	}
+
+	// Writing a batch again only writes what it didn't before
+	first := domain.FlashSalePurchase{ID: uuid.New(), ProductID: product.ID, UserID: "buyer-1", Quantity: 2, Price: 5, PurchasedAt: now}
+	second := domain.FlashSalePurchase{ID: uuid.New(), ProductID: product.ID, UserID: "buyer-2", Quantity: 1, Price: 5, PurchasedAt: now}
+	if n, err := c.repo.RecordFlashSalePurchases(c.ctx, sale.ID, []domain.FlashSalePurchase{first}); err != nil || n != 2 {
+		t.Fatalf("RecordFlashSalePurchases = %d, %v, want 2", n, err)
+	}
+	if n, err := c.repo.RecordFlashSalePurchases(c.ctx, sale.ID, []domain.FlashSalePurchase{first, second}); err != nil || n != 1 {
+		t.Fatalf("RecordFlashSalePurchases again = %d, %v, want 1", n, err)
+	}
+
+	stored, err := c.repo.GetFlashSale(c.ctx, product.ID)
+	if err != nil {
+		t.Fatalf("GetFlashSale: %v", err)
+	}
+	if stored.Sold != 3 || stored.Unsold() != 0 {
+		t.Fatalf("flash sale sold %d, %d unsold, want 3 and 0", stored.Sold, stored.Unsold())
+	}
+	buyers, err := c.repo.FlashSaleBuyers(c.ctx, sale.ID)
+	if err != nil {
+		t.Fatalf("FlashSaleBuyers: %v", err)
+	}
+	if buyers["buyer-1"] != 2 || buyers["buyer-2"] != 1 {
+		t.Fatalf("FlashSaleBuyers = %v", buyers)
+	}
+
+	// Only one instance closes a sale, after which the product can have
+	// another
+	if closed, err := c.repo.CloseFlashSale(c.ctx, stored); err != nil || !closed {
+		t.Fatalf("CloseFlashSale = %v, %v", closed, err)
+	}
+	if closed, err := c.repo.CloseFlashSale(c.ctx, stored); err != nil || closed {
+		t.Fatalf("CloseFlashSale again = %v, %v", closed, err)
+	}
+	_, err = c.repo.GetFlashSale(c.ctx, product.ID)
+	expectCode(t, err, customErrors.CodeFlashSaleNotFound)
+
+	next := &domain.FlashSale{ProductID: product.ID, StartsAt: now.Add(time.Hour), EndsAt: now.Add(2 * time.Hour), Price: 6, Quantity: 1}
+	if err := c.repo.CreateFlashSale(c.ctx, next); err != nil {
+		t.Fatalf("CreateFlashSale after closing: %v", err)
+	}
+	if err := c.repo.DeleteFlashSale(c.ctx, next.ID); err != nil {
+		t.Fatalf("DeleteFlashSale: %v", err)
+	}
+	err = c.repo.DeleteFlashSale(c.ctx, next.ID)
+	expectCode(t, err, customErrors.CodeFlashSaleNotFound)
+}
+
+func testList(t *testing.T, c *contract) {
+	category := c.category(t, nil)
+	expensive := c.product(t, category, 30, 1)
+	cheap := c.product(t, category, 10, 0)
+	middle := c.product(t, category, 20, 1)
+
+	list := func(filters domain.ProductFilters) ([]domain.Product, int64) {
+		t.Helper()
+		filters.CategoryID = &category.ID
+		if filters.SortBy == "" {
+			filters.SortBy, filters.SortOrder = "price", "asc"
+		}
+		products, total, err := c.repo.List(c.ctx, &filters)
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		return products, total
+	}
+	ids := func(products []domain.Product) []uuid.UUID {
+		ids := make([]uuid.UUID, 0, len(products))
+		for _, product := range products {
+			ids = append(ids, product.ID)
+		}
+		return ids
+	}
+	expect := func(products []domain.Product, want ...*domain.Product) {
+		t.Helper()
+		got := ids(products)
+		if len(got) != len(want) {
+			t.Fatalf("listed %d products, want %d", len(got), len(want))
+		}
+		for i, product := range want {
+			if got[i] != product.ID {
+				t.Fatalf("product %d is %s, want %s", i, got[i], product.ID)
+			}
+		}
+	}
+
+	products, total := list(domain.ProductFilters{})
+	if total != 3 {
+		t.Fatalf("total is %d, want 3", total)
+	}
+	expect(products, cheap, middle, expensive)
+
+	products, total = list(domain.ProductFilters{SortOrder: "desc", SortBy: "price", Limit: 1, Offset: 1})
+	if total != 3 {
+		t.Fatalf("a page reported a total of %d, want 3", total)
+	}
+	expect(products, middle)
+
+	minPrice := 15.0
+	inStock := true
+	products, total = list(domain.ProductFilters{MinPrice: &minPrice, InStock: &inStock})
+	if total != 2 {
+		t.Fatalf("filtered total is %d, want 2", total)
+	}
+	expect(products, middle, expensive)
+
+	// Keyset pages follow on from the last product of the previous page
+	first, _ := list(domain.ProductFilters{SortBy: "created_at", SortOrder: "asc", Limit: 2})
+	expect(first, expensive, cheap)
+	rest, _ := list(domain.ProductFilters{
+		SortBy:    "created_at",
+		SortOrder: "asc",
+		Limit:     2,
+		After:     domain.NewProductCursor(&first[1]),
+	})
+	expect(rest, middle)
+}
+
+func testCategoryTree(t *testing.T, c *contract) {
+	root := c.category(t, nil)
+	child := c.category(t, root)
+	grandchild := c.category(t, child)
+
+	ancestors, err := c.repo.GetCategoryAncestorIDs(c.ctx, grandchild.ID)
+	if err != nil {
+		t.Fatalf("GetCategoryAncestorIDs: %v", err)
+	}
+	if len(ancestors) != 3 || ancestors[0] != grandchild.ID || ancestors[1] != child.ID || ancestors[2] != root.ID {
+		t.Fatalf("GetCategoryAncestorIDs returned %v", ancestors)
+	}
+
+	paths, err := c.repo.GetCategoryPaths(c.ctx, []uuid.UUID{grandchild.ID})
+	if err != nil {
+		t.Fatalf("GetCategoryPaths: %v", err)
+	}
+	path := paths[grandchild.ID]
+	if len(path) != 3 || path[0].ID != root.ID || path[2].Slug != grandchild.Slug {
+		t.Fatalf("GetCategoryPaths returned %+v", path)
+	}
+
+	err = c.repo.MoveCategory(c.ctx, root.ID, &grandchild.ID)
+	if !customErrors.IsValidation(err) {
+		t.Fatalf("expected a validation error moving a category under itself, got %v", err)
+	}
+
+	// Merging moves the products and leaves the old slug pointing at the target
+	product := c.product(t, child, 10, 0)
+	target := c.category(t, nil)
+	moved, err := c.repo.MergeCategory(c.ctx, child.ID, target.ID)
+	if err != nil {
+		t.Fatalf("MergeCategory: %v", err)
+	}
+	if len(moved) != 1 || moved[0] != product.ID {
+		t.Fatalf("MergeCategory moved %v", moved)
+	}
+	if got := c.get(t, product.ID); got.CategoryID != target.ID {
+		t.Fatalf("merged product is in %s, want %s", got.CategoryID, target.ID)
+	}
+	if got, err := c.repo.GetCategory(c.ctx, grandchild.ID); err != nil || got.ParentID == nil || *got.ParentID != target.ID {
+		t.Fatalf("merged subcategory was not moved: %v", err)
+	}
+	redirect, err := c.repo.GetSlugRedirect(c.ctx, domain.AuditEntityCategory, child.Slug)
+	if err != nil {
+		t.Fatalf("GetSlugRedirect: %v", err)
+	}
+	if redirect.EntityID != target.ID {
+		t.Fatalf("merged slug redirects to %s, want %s", redirect.EntityID, target.ID)
+	}
+	if _, err := c.repo.GetCategory(c.ctx, child.ID); !customErrors.IsNotFound(err) {
+		t.Fatalf("expected the merged category to be gone, got %v", err)
+	}
+}
+
+func testSlugs(t *testing.T, c *contract) {
+	category := c.category(t, nil)
+	product := c.product(t, category, 10, 0)
+
+	slug, err := c.repo.UniqueSlug(c.ctx, domain.AuditEntityProduct, product.Slug, uuid.Nil)
+	if err != nil {
+		t.Fatalf("UniqueSlug: %v", err)
+	}
+	if slug != product.Slug+"-2" {
+		t.Fatalf("UniqueSlug returned %q for a taken slug", slug)
+	}
+	slug, err = c.repo.UniqueSlug(c.ctx, domain.AuditEntityProduct, product.Slug, product.ID)
+	if err != nil {
+		t.Fatalf("UniqueSlug: %v", err)
+	}
+	if slug != product.Slug {
+		t.Fatalf("UniqueSlug returned %q for the product's own slug", slug)
+	}
+
+	renamed := unique("renamed")
+	if err := c.repo.RecordSlugChange(c.ctx, domain.AuditEntityProduct, product.ID, product.Slug, renamed); err != nil {
+		t.Fatalf("RecordSlugChange: %v", err)
+	}
+	redirect, err := c.repo.GetSlugRedirect(c.ctx, domain.AuditEntityProduct, product.Slug)
+	if err != nil {
+		t.Fatalf("GetSlugRedirect: %v", err)
+	}
+	if redirect.EntityID != product.ID {
+		t.Fatalf("old slug redirects to %s, want %s", redirect.EntityID, product.ID)
+	}
+
+	_, err = c.repo.GetSlugRedirect(c.ctx, domain.AuditEntityProduct, unique("missing"))
+	if !customErrors.IsNotFound(err) {
+		t.Fatalf("expected not found for an unknown slug, got %v", err)
+	}
+}
+
+func testUpsertBySKU(t *testing.T, c *contract) {
+	category := c.category(t, nil)
+	name := unique("upserted")
+	product := &domain.Product{
+		Name:       name,
+		Slug:       name,
+		SKU:        strings.ToUpper(name),
+		Price:      10,
+		CategoryID: category.ID,
+		Stock:      4,
+		IsActive:   true,
+		Status:     domain.ProductStatusPublished,
+		Attributes: []domain.ProductAttribute{},
+	}
+	movement := domain.StockMovement{Reason: domain.StockReasonImport}
+
+	created, err := c.repo.UpsertBySKU(c.ctx, product, movement)
+	if err != nil {
+		t.Fatalf("UpsertBySKU: %v", err)
+	}
+	if !created || product.ID == uuid.Nil {
+		t.Fatalf("UpsertBySKU created %v with ID %s", created, product.ID)
+	}
+	id := product.ID
+
+	update := &domain.Product{
+		Name:       name + " v2",
+		Slug:       unique("ignored"),
+		SKU:        strings.ToLower(name),
+		Price:      12,
+		CategoryID: category.ID,
+		Stock:      9,
+		IsActive:   true,
+		Status:     domain.ProductStatusPublished,
+	}
+	created, err = c.repo.UpsertBySKU(c.ctx, update, movement)
+	if err != nil {
+		t.Fatalf("UpsertBySKU: %v", err)
+	}
+	if created || update.ID != id {
+		t.Fatalf("UpsertBySKU of an existing SKU created %v with ID %s, want %s", created, update.ID, id)
+	}
+	if update.Slug != name {
+		t.Fatalf("UpsertBySKU changed the slug to %q", update.Slug)
+	}
+
+	stored := c.get(t, id)
+	if stored.Price != 12 || stored.Stock != 9 {
+		t.Fatalf("UpsertBySKU stored price %v and stock %d", stored.Price, stored.Stock)
+	}
+	movements, total, err := c.repo.ListStockMovements(c.ctx, id, &domain.StockMovementFilters{Limit: 10})
+	if err != nil {
+		t.Fatalf("ListStockMovements: %v", err)
+	}
+	if total != 2 || movements[0].Delta != 5 {
+		t.Fatalf("expected the upsert to record a change of 5, got %+v", movements)
+	}
+
+	missing := unique("missing")
+	existing, err := c.repo.ExistingSKUs(c.ctx, []string{strings.ToUpper(name), missing})
+	if err != nil {
+		t.Fatalf("ExistingSKUs: %v", err)
+	}
+	if !existing[strings.ToUpper(name)] || existing[missing] {
+		t.Fatalf("ExistingSKUs returned %v", existing)
+	}
+
+	ids, err := c.repo.ProductIDsBySKU(c.ctx, []string{strings.ToUpper(name), missing})
+	if err != nil {
+		t.Fatalf("ProductIDsBySKU: %v", err)
+	}
+	if len(ids) != 1 || ids[strings.ToUpper(name)] != id {
+		t.Fatalf("ProductIDsBySKU returned %v", ids)
+	}
+}
+
+func testReviews(t *testing.T, c *contract) {
+	category := c.category(t, nil)
+	product := c.product(t, category, 10, 0)
+
+	for i, review := range []domain.Review{
+		{Rating: 4, Status: domain.ReviewStatusApproved},
+		{Rating: 2, Status: domain.ReviewStatusApproved},
+		{Rating: 1, Status: domain.ReviewStatusPending},
+	} {
+		review.ProductID = product.ID
+		review.UserID = unique("user")
+		if err := c.repo.CreateReview(c.ctx, &review); err != nil {
+			t.Fatalf("CreateReview %d: %v", i, err)
+		}
+		if i == 0 {
+			duplicate := domain.Review{ProductID: product.ID, UserID: review.UserID, Rating: 5}
+			err := c.repo.CreateReview(c.ctx, &duplicate)
+			if !customErrors.IsConflict(database.TranslateError(err)) {
+				t.Fatalf("expected a conflict reviewing twice, got %v", err)
+			}
+		}
+	}
+
+	if err := c.repo.RefreshProductRating(c.ctx, product.ID); err != nil {
+		t.Fatalf("RefreshProductRating: %v", err)
+	}
+	rated := c.get(t, product.ID)
+	if rated.RatingAverage != 3 || rated.ReviewCount != 2 {
+		t.Fatalf("rating is %v from %d reviews, want 3 from 2", rated.RatingAverage, rated.ReviewCount)
+	}
+
+	reviews, total, err := c.repo.ListReviews(c.ctx, &domain.ReviewFilters{
+		ProductID: &product.ID,
+		Status:    domain.ReviewStatusApproved,
+		Limit:     10,
+	})
+	if err != nil {
+		t.Fatalf("ListReviews: %v", err)
+	}
+	if total != 2 || len(reviews) != 2 {
+		t.Fatalf("listed %d approved reviews, want 2", total)
+	}
+}
+
+func testNotFound(t *testing.T, c *contract) {
+	id := uuid.New()
+
+	_, err := c.repo.GetByID(c.ctx, id)
+	expectCode(t, err, customErrors.CodeProductNotFound)
+	_, err = c.repo.GetBySKU(c.ctx, unique("missing"))
+	expectCode(t, err, customErrors.CodeProductNotFound)
+	_, err = c.repo.GetCategory(c.ctx, id)
+	if !customErrors.IsNotFound(err) {
+		t.Fatalf("GetCategory: expected not found, got %v", err)
+	}
+	_, err = c.repo.GetMedia(c.ctx, id)
+	expectCode(t, err, customErrors.CodeMediaNotFound)
+	_, err = c.repo.GetReview(c.ctx, id)
+	expectCode(t, err, customErrors.CodeReviewNotFound)
+	_, err = c.repo.GetImportJob(c.ctx, id)
+	expectCode(t, err, customErrors.CodeImportJobNotFound)
+	_, err = c.repo.GetAuditEvent(c.ctx, id)
+	expectCode(t, err, customErrors.CodeAuditEventNotFound)
+}
+
+func testTransactions(t *testing.T, c *contract) {
+	failure := errors.New("rolled back")
+	var category *domain.Category
+
+	err := c.tx.WithinTransaction(c.ctx, func(ctx context.Context) error {
+		name := unique("category")
+		category = &domain.Category{Name: name, Slug: name, IsActive: true}
+		if err := c.repo.CreateCategory(ctx, category); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("WithinTransaction returned %v", err)
+	}
+	if _, err := c.repo.GetCategory(c.ctx, category.ID); !customErrors.IsNotFound(err) {
+		t.Fatalf("expected the rolled back category to be gone, got %v", err)
+	}
+
+	err = c.tx.WithinTransaction(c.ctx, func(ctx context.Context) error {
+		name := unique("category")
+		category = &domain.Category{Name: name, Slug: name, IsActive: true}
+		return c.repo.CreateCategory(ctx, category)
+	})
+	if err != nil {
+		t.Fatalf("WithinTransaction: %v", err)
+	}
+	if _, err := c.repo.GetCategory(c.ctx, category.ID); err != nil {
+		t.Fatalf("GetCategory after commit: %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"ecommerce/internal/product/domain"
+	customErrors "ecommerce/pkg/errors"
+	"ecommerce/pkg/tenant"
+)
+
+func (r *productRepository) CreateReview(ctx context.Context, review *domain.Review) error {
+	if err := r.conn(ctx).Create(review).Error; err != nil {
+		return fmt.Errorf("failed to create review: %w", err)
+	}
+	return nil
+}
+
+func (r *productRepository) GetReview(ctx context.Context, id uuid.UUID) (*domain.Review, error) {
+	var review domain.Review
+	err := r.conn(ctx).First(&review, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Review not found", err).WithCode(customErrors.CodeReviewNotFound)
+		}
+		return nil, fmt.Errorf("failed to get review: %w", err)
+	}
+
+	return &review, nil
+}
+
+func (r *productRepository) GetUserReview(ctx context.Context, productID uuid.UUID, userID string) (*domain.Review, error) {
+	var review domain.Review
+	err := r.conn(ctx).First(&review, "product_id = ? AND user_id = ?", productID, userID).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Review not found", err).WithCode(customErrors.CodeReviewNotFound)
+		}
+		return nil, fmt.Errorf("failed to get review: %w", err)
+	}
+
+	return &review, nil
+}
+
+func (r *productRepository) UpdateReview(ctx context.Context, review *domain.Review) error {
+	if err := r.conn(ctx).Save(review).Error; err != nil {
+		return fmt.Errorf("failed to update review: %w", err)
+	}
+	return nil
+}
+
+func (r *productRepository) ListReviews(ctx context.Context, filters *domain.ReviewFilters) ([]domain.Review, int64, error) {
+	query := r.conn(ctx).Model(&domain.Review{})
+
+	if filters.ProductID != nil {
+		query = query.Where("product_id = ?", *filters.ProductID)
+	}
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count reviews: %w", err)
+	}
+
+	var reviews []domain.Review
+	err := query.
+		Order("created_at DESC").
+		Offset(filters.Offset).
+		Limit(filters.Limit).
+		Find(&reviews).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list reviews: %w", err)
+	}
+
+	return reviews, total, nil
+}
+
+// RefreshProductRating recomputes a product's denormalized rating from its approved reviews
+func (r *productRepository) RefreshProductRating(ctx context.Context, productID uuid.UUID) error {
+	err := r.conn(ctx).Exec(`
+		UPDATE products SET
+			rating_average = COALESCE(stats.average, 0),
+			review_count = stats.count
+		FROM (
+			SELECT AVG(rating) AS average, COUNT(*) AS count
+			FROM reviews
+			WHERE product_id = ? AND status = ?
+		) AS stats
+		WHERE products.id = ? AND ?`, productID, domain.ReviewStatusApproved, productID, tenant.Filter(ctx, "products")).Error
+	if err != nil {
+		return fmt.Errorf("failed to refresh product rating: %w", err)
+	}
+
+	r.invalidateProductIDs(ctx, []uuid.UUID{productID})
+	return nil
+}
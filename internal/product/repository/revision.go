@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"ecommerce/internal/product/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+func (r *productRepository) CreateRevision(ctx context.Context, revision *domain.ProductRevision) error {
+	if err := r.conn(ctx).Create(revision).Error; err != nil {
+		return fmt.Errorf("failed to create revision: %w", err)
+	}
+	return nil
+}
+
+func (r *productRepository) GetRevision(ctx context.Context, id uuid.UUID) (*domain.ProductRevision, error) {
+	var revision domain.ProductRevision
+	err := r.conn(ctx).First(&revision, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Revision not found", err).WithCode(customErrors.CodeRevisionNotFound)
+		}
+		return nil, fmt.Errorf("failed to get revision: %w", err)
+	}
+
+	return &revision, nil
+}
+
+func (r *productRepository) UpdateRevision(ctx context.Context, revision *domain.ProductRevision) error {
+	if err := r.conn(ctx).Save(revision).Error; err != nil {
+		return fmt.Errorf("failed to update revision: %w", err)
+	}
+	return nil
+}
+
+func (r *productRepository) ListRevisions(ctx context.Context, filters *domain.RevisionFilters) ([]domain.ProductRevision, int64, error) {
+	query := r.conn(ctx).Model(&domain.ProductRevision{})
+
+	if filters.ProductID != nil {
+		query = query.Where("product_id = ?", *filters.ProductID)
+	}
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count revisions: %w", err)
+	}
+
+	var revisions []domain.ProductRevision
+	err := query.
+		Order("created_at DESC").
+		Offset(filters.Offset).
+		Limit(filters.Limit).
+		Find(&revisions).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list revisions: %w", err)
+	}
+
+	return revisions, total, nil
+}
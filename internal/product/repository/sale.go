@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/tenant"
+)
+
+// saleOpenSQL is true for products whose sale price applies right now
+const saleOpenSQL = "(products.sale_price IS NOT NULL" +
+	" AND (products.sale_starts_at IS NULL OR products.sale_starts_at <= NOW())" +
+	" AND (products.sale_ends_at IS NULL OR products.sale_ends_at > NOW()))"
+
+// effectivePriceSQL is the price in force right now, which filters, sorting
+// and reservations use in place of the regular price
+const effectivePriceSQL = "(CASE WHEN " + saleOpenSQL + " THEN products.sale_price ELSE products.price END)"
+
+// SyncSales flips the sale flag of products whose sale window opened or
+// closed since it was last set and returns them, so each switch of price is
+// announced once. Rows locked by another instance are skipped.
+func (r *productRepository) SyncSales(ctx context.Context, limit int) ([]domain.Product, error) {
+	var switched []uuid.UUID
+	err := r.conn(ctx).Raw(`
+		UPDATE products SET sale_active = NOT sale_active
+		WHERE id IN (
+			SELECT id FROM products
+			WHERE deleted_at IS NULL AND (sale_price IS NOT NULL OR sale_active)
+				AND sale_active <> `+saleOpenSQL+` AND ?
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id`,
+		tenant.Filter(ctx, "products"), limit,
+	).Scan(&switched).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to sync sales: %w", err)
+	}
+	if len(switched) == 0 {
+		return nil, nil
+	}
+	r.invalidateProductIDs(ctx, switched)
+
+	var products []domain.Product
+	if err := r.conn(ctx).Where("id IN ?", switched).Find(&products).Error; err != nil {
+		return nil, fmt.Errorf("failed to get products on sale: %w", err)
+	}
+	return products, nil
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/cache"
+	"ecommerce/pkg/database"
+	customErrors "ecommerce/pkg/errors"
+)
+
+func (r *productRepository) CreateSearchSynonym(ctx context.Context, synonym *domain.SearchSynonym) error {
+	if err := r.conn(ctx).Create(synonym).Error; err != nil {
+		return fmt.Errorf("failed to create search synonym: %w", err)
+	}
+	r.invalidateSynonyms(ctx)
+	return nil
+}
+
+func (r *productRepository) GetSearchSynonym(ctx context.Context, id uuid.UUID) (*domain.SearchSynonym, error) {
+	var synonym domain.SearchSynonym
+	err := r.conn(ctx).First(&synonym, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Search synonym not found", err).WithCode(customErrors.CodeSynonymNotFound)
+		}
+		return nil, fmt.Errorf("failed to get search synonym: %w", err)
+	}
+
+	return &synonym, nil
+}
+
+func (r *productRepository) GetSearchSynonymByTerm(ctx context.Context, term string) (*domain.SearchSynonym, error) {
+	var synonym domain.SearchSynonym
+	err := r.conn(ctx).First(&synonym, "term = ?", term).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Search synonym not found", err).WithCode(customErrors.CodeSynonymNotFound)
+		}
+		return nil, fmt.Errorf("failed to get search synonym by term: %w", err)
+	}
+
+	return &synonym, nil
+}
+
+func (r *productRepository) UpdateSearchSynonym(ctx context.Context, synonym *domain.SearchSynonym) error {
+	if err := r.conn(ctx).Save(synonym).Error; err != nil {
+		return fmt.Errorf("failed to update search synonym: %w", err)
+	}
+	r.invalidateSynonyms(ctx)
+	return nil
+}
+
+func (r *productRepository) DeleteSearchSynonym(ctx context.Context, id uuid.UUID) error {
+	if err := r.conn(ctx).Delete(&domain.SearchSynonym{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete search synonym: %w", err)
+	}
+	r.invalidateSynonyms(ctx)
+	return nil
+}
+
+// ListSearchSynonyms returns every synonym ordered by term. They are cached
+// under a single key per tenant, as each search expands against all of them.
+func (r *productRepository) ListSearchSynonyms(ctx context.Context) ([]domain.SearchSynonym, error) {
+	load := func(ctx context.Context) ([]domain.SearchSynonym, error) {
+		var synonyms []domain.SearchSynonym
+		if err := r.conn(ctx).Order("term ASC").Find(&synonyms).Error; err != nil {
+			return nil, fmt.Errorf("failed to list search synonyms: %w", err)
+		}
+		return synonyms, nil
+	}
+	key, ok := cacheKey(ctx, r.synonyms)
+	if !ok {
+		return load(ctx)
+	}
+	return cache.GetOrLoad(database.WithPrimary(ctx), r.synonyms, key, load)
+}
+
+// invalidateSynonyms drops the cached synonyms of the tenant ctx is scoped
+// to, which are the only ones it can change
+func (r *productRepository) invalidateSynonyms(ctx context.Context) {
+	if key, ok := cacheKey(ctx, r.synonyms); ok {
+		r.synonyms.Delete(ctx, key)
+	}
+}
+
+// RecordZeroResultSearch counts a search that found nothing
+func (r *productRepository) RecordZeroResultSearch(ctx context.Context, query string) error {
+	now := time.Now()
+	search := &domain.ZeroResultSearch{
+		Query:           query,
+		Count:           1,
+		FirstSearchedAt: now,
+		LastSearchedAt:  now,
+	}
+	err := r.conn(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "tenant_id"}, {Name: "query"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"count":            gorm.Expr("zero_result_searches.count + 1"),
+			"last_searched_at": now,
+		}),
+	}).Create(search).Error
+	if err != nil {
+		return fmt.Errorf("failed to record zero-result search: %w", err)
+	}
+	return nil
+}
+
+// ListZeroResultSearches returns zero-result searches, most searched first
+func (r *productRepository) ListZeroResultSearches(ctx context.Context, filters *domain.ZeroResultFilters) ([]domain.ZeroResultSearch, int64, error) {
+	query := r.conn(ctx).Model(&domain.ZeroResultSearch{})
+	if filters.Since != nil {
+		query = query.Where("last_searched_at >= ?", *filters.Since)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count zero-result searches: %w", err)
+	}
+
+	var searches []domain.ZeroResultSearch
+	err := query.
+		Order("count DESC, last_searched_at DESC").
+		Offset(filters.Offset).
+		Limit(filters.Limit).
+		Find(&searches).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list zero-result searches: %w", err)
+	}
+
+	return searches, total, nil
+}
+
+// DeleteZeroResultSearch removes a query from the report, reporting whether
+// it was there
+func (r *productRepository) DeleteZeroResultSearch(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.conn(ctx).Delete(&domain.ZeroResultSearch{}, "id = ?", id)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete zero-result search: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// searchTSQuery returns the full-text query matching a search or any of
+// its alternatives
+func searchTSQuery(filters *domain.ProductFilters) (string, []interface{}) {
+	parts := make([]string, 0, len(filters.SearchAlternatives)+1)
+	args := make([]interface{}, 0, 2*(len(filters.SearchAlternatives)+1))
+	for _, text := range append([]string{filters.Search}, filters.SearchAlternatives...) {
+		parts = append(parts, "websearch_to_tsquery(?, ?)")
+		args = append(args, searchLanguage, text)
+	}
+	return "(" + strings.Join(parts, " || ") + ")", args
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/database"
+	customErrors "ecommerce/pkg/errors"
+	"ecommerce/pkg/tenant"
+)
+
+// slugTables maps audit entity types to the tables that own their slugs
+var slugTables = map[string]string{
+	domain.AuditEntityProduct:  "products",
+	domain.AuditEntityCategory: "categories",
+}
+
+func (r *productRepository) GetBySlug(ctx context.Context, slug string) (*domain.Product, error) {
+	var product domain.Product
+	err := r.conn(ctx).
+		Preload("Category").
+		Preload("Brand").
+		Preload("Attributes").
+		First(&product, "slug = ?", slug).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Product not found", err).WithCode(customErrors.CodeProductNotFound)
+		}
+		return nil, fmt.Errorf("failed to get product by slug: %w", err)
+	}
+
+	return &product, nil
+}
+
+func (r *productRepository) GetCategoryBySlug(ctx context.Context, slug string) (*domain.Category, error) {
+	var category domain.Category
+	err := r.conn(ctx).
+		Preload("Parent").
+		Preload("Children").
+		First(&category, "slug = ?", slug).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Category not found", err).WithCode(customErrors.CodeCategoryNotFound)
+		}
+		return nil, fmt.Errorf("failed to get category by slug: %w", err)
+	}
+
+	return &category, nil
+}
+
+func (r *productRepository) UniqueSlug(ctx context.Context, entityType, base string, excludeID uuid.UUID) (string, error) {
+	return r.uniqueSlug(ctx, entityType, base, excludeID, nil)
+}
+
+// uniqueSlug returns base, or base with the lowest free numeric suffix. Slugs
+// held by other live or deleted entities, their redirects, and the reserved
+// set are all considered taken.
+func (r *productRepository) uniqueSlug(ctx context.Context, entityType, base string, excludeID uuid.UUID, reserved map[string]bool) (string, error) {
+	table, ok := slugTables[entityType]
+	if !ok {
+		return "", fmt.Errorf("unsupported slug entity type %q", entityType)
+	}
+	if base == "" {
+		base = entityType
+	}
+
+	// The slug is about to be written, so check it on the primary
+	var taken []string
+	err := r.conn(database.WithPrimary(ctx)).Raw(fmt.Sprintf(`
+		SELECT slug FROM %s WHERE (slug = @base OR slug LIKE @pattern) AND id <> @id AND @entities
+		UNION
+		SELECT slug FROM slug_redirects
+		WHERE entity_type = @type AND (slug = @base OR slug LIKE @pattern) AND entity_id <> @id AND @redirects`, table),
+		map[string]interface{}{
+			"base":      base,
+			"pattern":   base + "-%",
+			"id":        excludeID,
+			"type":      entityType,
+			"entities":  tenant.Filter(ctx, table),
+			"redirects": tenant.Filter(ctx, "slug_redirects"),
+		}).
+		Scan(&taken).Error
+	if err != nil {
+		return "", fmt.Errorf("failed to check slug availability: %w", err)
+	}
+
+	used := make(map[string]bool, len(taken))
+	for _, slug := range taken {
+		used[slug] = true
+	}
+
+	slug := base
+	for n := 2; used[slug] || reserved[slug]; n++ {
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+	return slug, nil
+}
+
+func (r *productRepository) GetSlugRedirect(ctx context.Context, entityType, slug string) (*domain.SlugRedirect, error) {
+	var redirect domain.SlugRedirect
+	err := r.conn(ctx).
+		First(&redirect, "entity_type = ? AND slug = ?", entityType, slug).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Slug redirect not found", err)
+		}
+		return nil, fmt.Errorf("failed to get slug redirect: %w", err)
+	}
+
+	return &redirect, nil
+}
+
+func (r *productRepository) RecordSlugChange(ctx context.Context, entityType string, entityID uuid.UUID, oldSlug, newSlug string) error {
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		// An entity reclaiming one of its old slugs no longer needs the redirect
+		if err := tx.Where("entity_type = ? AND slug = ?", entityType, newSlug).
+			Delete(&domain.SlugRedirect{}).Error; err != nil {
+			return err
+		}
+
+		if oldSlug == "" {
+			return nil
+		}
+		redirect := &domain.SlugRedirect{
+			EntityType: entityType,
+			EntityID:   entityID,
+			Slug:       oldSlug,
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "entity_type"}, {Name: "slug"}},
+			DoUpdates: clause.AssignmentColumns([]string{"entity_id", "created_at"}),
+		}).Create(redirect).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record slug change: %w", err)
+	}
+
+	return nil
+}
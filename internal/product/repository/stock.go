@@ -0,0 +1,711 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"ecommerce/internal/product/domain"
+	customErrors "ecommerce/pkg/errors"
+	"ecommerce/pkg/tenant"
+)
+
+// physicalSQL says whether a product holds stock
+const physicalSQL = "(products.type = '" + domain.ProductTypePhysical + "')"
+
+// oversellSQL says whether a product can be reserved beyond its stock right
+// now: it allows backorders, or takes preorders until its release date
+const oversellSQL = "(products.allow_backorder OR (products.preorder AND (products.release_date IS NULL OR products.release_date > NOW())))"
+
+// ReserveStock deducts stock for every item under the given reference, or
+// for none of them if any product is short. Stock comes from the warehouse
+// the movement names, or else from the highest priority warehouses holding
+// it, with a reservation row for each warehouse drawn on. Backordered and
+// preordered products are never short: what the warehouses cannot cover is
+// charged to the named or default warehouse, taking its stock below zero.
+// Products that hold no stock are reserved without taking any, and bundles
+// take their components' stock in rows of their own. Replaying a reference
+// returns the reservation it already made. Each deduction is recorded in
+// the stock ledger with the given movement.
+func (r *productRepository) ReserveStock(ctx context.Context, reference string, items []domain.StockItem, movement domain.StockMovement) ([]domain.StockReservation, error) {
+	var reservations []domain.StockReservation
+	var movements []domain.StockMovement
+
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("reference = ?", reference).Find(&reservations).Error; err != nil {
+			return fmt.Errorf("failed to get stock reservation: %w", err)
+		}
+		if len(reservations) > 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, len(items))
+		for i, item := range items {
+			ids[i] = item.ProductID
+		}
+		var components []domain.BundleComponent
+		if err := tx.Where("bundle_id IN ?", ids).Find(&components).Error; err != nil {
+			return fmt.Errorf("failed to get bundle components: %w", err)
+		}
+
+		// Demands come ordered by product, so concurrent reservations lock
+		// products in the same order and cannot deadlock
+		for _, item := range domain.BundleDemands(items, components) {
+			var row struct {
+				Price    float64
+				Stock    int
+				Oversell bool
+				Physical bool
+			}
+			result := tx.Raw(
+				"UPDATE products SET stock = CASE WHEN "+physicalSQL+" THEN stock - ? ELSE stock END, updated_at = NOW() "+
+					"WHERE id = ? AND deleted_at IS NULL AND is_active AND status = 'published' "+
+					"AND (NOT "+physicalSQL+" OR stock >= ? OR "+oversellSQL+") AND ? "+
+					"RETURNING "+effectivePriceSQL+" AS price, stock, "+oversellSQL+" AS oversell, "+physicalSQL+" AS physical",
+				item.Quantity, item.ProductID, item.Quantity, tenant.Filter(ctx, "products"),
+			).Scan(&row)
+			if result.Error != nil {
+				return fmt.Errorf("failed to reserve stock: %w", result.Error)
+			}
+			if result.RowsAffected == 0 {
+				return customErrors.NewConflictError(fmt.Sprintf("Insufficient stock for product %s", item.ProductID), nil).WithCode(customErrors.CodeInsufficientStock)
+			}
+			if !row.Physical {
+				reservations = append(reservations, domain.StockReservation{
+					Reference: reference,
+					ProductID: item.ProductID,
+					BundleID:  item.BundleID,
+					Quantity:  item.Quantity,
+					UnitPrice: row.Price,
+					Status:    domain.ReservationStatusReserved,
+				})
+				continue
+			}
+
+			levels, err := lockStockLevels(tx, item.ProductID, movement.WarehouseID)
+			if err != nil {
+				return err
+			}
+			taken := domain.AllocateStock(levels, item.Quantity)
+			if row.Oversell {
+				warehouseID := movement.WarehouseID
+				if warehouseID == nil {
+					if warehouseID, err = defaultWarehouseID(tx, item.ProductID); err != nil {
+						return err
+					}
+				}
+				taken = domain.BackorderStock(taken, *warehouseID, item.Quantity)
+			}
+			balance := row.Stock + item.Quantity
+			for _, part := range taken {
+				warehouseID := part.WarehouseID
+				balance -= part.Quantity
+				reservations = append(reservations, domain.StockReservation{
+					Reference:   reference,
+					ProductID:   item.ProductID,
+					WarehouseID: &warehouseID,
+					BundleID:    item.BundleID,
+					Quantity:    part.Quantity,
+					UnitPrice:   row.Price,
+					Status:      domain.ReservationStatusReserved,
+				})
+				entry := ledgerEntry(movement, item.ProductID, -part.Quantity, balance)
+				entry.WarehouseID = &warehouseID
+				movements = append(movements, entry)
+			}
+			if balance != row.Stock {
+				return customErrors.NewConflictError(fmt.Sprintf("Insufficient stock in warehouse for product %s", item.ProductID), nil).WithCode(customErrors.CodeInsufficientStock)
+			}
+		}
+
+		if err := tx.Create(&reservations).Error; err != nil {
+			return fmt.Errorf("failed to record stock reservation: %w", err)
+		}
+		return recordMovements(tx, movements)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.invalidateProducts(ctx, reservations)
+	return reservations, nil
+}
+
+// lockStockLevels locks a product's stock levels, in one warehouse or in
+// all of them, and returns those holding stock in the order reservations
+// take from them
+func lockStockLevels(tx *gorm.DB, productID uuid.UUID, warehouseID *uuid.UUID) ([]domain.StockLevel, error) {
+	query := tx.Table("stock_levels l").
+		Select("l.product_id, l.warehouse_id, w.code AS warehouse_code, l.quantity").
+		Joins("JOIN warehouses w ON w.id = l.warehouse_id").
+		Where("l.product_id = ? AND l.quantity > 0", productID)
+	if warehouseID != nil {
+		query = query.Where("l.warehouse_id = ?", *warehouseID)
+	}
+
+	var levels []domain.StockLevel
+	err := query.Order(warehouseOrder).Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "l"}}).
+		Scan(&levels).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock stock levels: %w", err)
+	}
+	return levels, nil
+}
+
+// ReleaseStock returns reserved stock to its products, in the warehouses it
+// was reserved from; reservations without a warehouse took none. Reservations
+// that were already released are left alone, so releasing is safe to repeat.
+// Each return is recorded in the stock ledger with the given movement.
+func (r *productRepository) ReleaseStock(ctx context.Context, reference string, movement domain.StockMovement) ([]domain.StockReservation, error) {
+	var released []domain.StockReservation
+
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("reference = ? AND status = ?", reference, domain.ReservationStatusReserved).
+			Order("product_id, warehouse_id").
+			Find(&released).Error
+		if err != nil {
+			return fmt.Errorf("failed to get stock reservation: %w", err)
+		}
+
+		movements := make([]domain.StockMovement, 0, len(released))
+		for _, reservation := range released {
+			if reservation.WarehouseID == nil {
+				continue
+			}
+			var stock int
+			err := tx.Raw(
+				"UPDATE products SET stock = stock + ?, updated_at = NOW() WHERE id = ? RETURNING stock",
+				reservation.Quantity, reservation.ProductID,
+			).Scan(&stock).Error
+			if err != nil {
+				return fmt.Errorf("failed to release stock: %w", err)
+			}
+			entry := ledgerEntry(movement, reservation.ProductID, reservation.Quantity, stock)
+			entry.WarehouseID = reservation.WarehouseID
+			movements = append(movements, entry)
+		}
+		if len(movements) > 0 {
+			if err := recordMovements(tx, movements); err != nil {
+				return err
+			}
+		}
+
+		if len(released) > 0 {
+			err := tx.Model(&domain.StockReservation{}).
+				Where("reference = ? AND status = ?", reference, domain.ReservationStatusReserved).
+				Update("status", domain.ReservationStatusReleased).Error
+			if err != nil {
+				return fmt.Errorf("failed to update stock reservation: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.invalidateProducts(ctx, released)
+	return released, nil
+}
+
+// ReturnStock puts returned goods back into stock, in the warehouse the
+// movement names or else the default one. Bundles return their components'
+// stock, and products that hold no stock take none back. Stock already
+// returned under the movement's reference is not returned again, so
+// returning is safe to repeat; it then records nothing. Each return is
+// recorded in the stock ledger with the given movement.
+func (r *productRepository) ReturnStock(ctx context.Context, items []domain.StockItem, movement domain.StockMovement) ([]domain.StockMovement, error) {
+	var movements []domain.StockMovement
+
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		ids := make([]uuid.UUID, len(items))
+		for i, item := range items {
+			ids[i] = item.ProductID
+		}
+		var components []domain.BundleComponent
+		if err := tx.Where("bundle_id IN ?", ids).Find(&components).Error; err != nil {
+			return fmt.Errorf("failed to get bundle components: %w", err)
+		}
+		demands := domain.BundleDemands(items, components)
+
+		// Lock the products in order first, so a concurrent return under
+		// the same reference waits and then finds this one's movements
+		locked := make([]uuid.UUID, 0, len(demands))
+		for _, demand := range demands {
+			locked = append(locked, demand.ProductID)
+		}
+		var held []uuid.UUID
+		if err := tx.Raw("SELECT id FROM products WHERE id IN ? AND ? ORDER BY id FOR UPDATE", locked, tenant.Filter(ctx, "products")).Scan(&held).Error; err != nil {
+			return fmt.Errorf("failed to lock products: %w", err)
+		}
+
+		var returned int64
+		err := tx.Model(&domain.StockMovement{}).
+			Where("reason = ? AND reference = ?", movement.Reason, movement.Reference).
+			Count(&returned).Error
+		if err != nil {
+			return fmt.Errorf("failed to get stock returns: %w", err)
+		}
+		if returned > 0 {
+			return nil
+		}
+
+		for _, demand := range demands {
+			var stock []int
+			err := tx.Raw(
+				"UPDATE products SET stock = stock + ?, updated_at = NOW() WHERE id = ? AND "+physicalSQL+" AND ? RETURNING stock",
+				demand.Quantity, demand.ProductID, tenant.Filter(ctx, "products"),
+			).Scan(&stock).Error
+			if err != nil {
+				return fmt.Errorf("failed to return stock: %w", err)
+			}
+			if len(stock) == 0 {
+				continue
+			}
+			movements = append(movements, ledgerEntry(movement, demand.ProductID, demand.Quantity, stock[0]))
+		}
+		if len(movements) == 0 {
+			return nil
+		}
+		return recordMovements(tx, movements)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	changed := make([]uuid.UUID, 0, len(movements))
+	for _, entry := range movements {
+		changed = append(changed, entry.ProductID)
+	}
+	r.invalidateProductIDs(ctx, changed)
+	return movements, nil
+}
+
+func (r *productRepository) GetStockReservations(ctx context.Context, reference string) ([]domain.StockReservation, error) {
+	var reservations []domain.StockReservation
+	err := r.conn(ctx).
+		Where("reference = ?", reference).
+		Order("product_id, warehouse_id").
+		Find(&reservations).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock reservations: %w", err)
+	}
+
+	return reservations, nil
+}
+
+// SetStock sets a product's stock to an absolute level, such as after a
+// stock count, and records the difference in the stock ledger. When the
+// movement names a warehouse, the level is the stock held there; otherwise
+// it is the product's total, and the difference goes to the default
+// warehouse. It returns nil when the stock is already at that level.
+func (r *productRepository) SetStock(ctx context.Context, id uuid.UUID, stock int, movement domain.StockMovement) (*domain.StockMovement, error) {
+	var recorded *domain.StockMovement
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		var current []int
+		err := tx.Raw("SELECT stock FROM products WHERE id = ? AND deleted_at IS NULL AND ? FOR UPDATE", id, tenant.Filter(ctx, "products")).
+			Scan(&current).Error
+		if err != nil {
+			return fmt.Errorf("failed to lock product stock: %w", err)
+		}
+		if len(current) == 0 {
+			return customErrors.NewNotFoundError("Product not found", nil).WithCode(customErrors.CodeProductNotFound)
+		}
+
+		level := current[0]
+		if movement.WarehouseID != nil {
+			var held []int
+			err := tx.Raw("SELECT quantity FROM stock_levels WHERE product_id = ? AND warehouse_id = ?", id, *movement.WarehouseID).
+				Scan(&held).Error
+			if err != nil {
+				return fmt.Errorf("failed to get stock level: %w", err)
+			}
+			level = 0
+			if len(held) > 0 {
+				level = held[0]
+			}
+		}
+		if level == stock {
+			return nil
+		}
+
+		delta := stock - level
+		err = tx.Exec("UPDATE products SET stock = stock + ?, updated_at = NOW() WHERE id = ?", delta, id).Error
+		if err != nil {
+			return fmt.Errorf("failed to set stock: %w", err)
+		}
+
+		entries := []domain.StockMovement{ledgerEntry(movement, id, delta, current[0]+delta)}
+		if err := recordMovements(tx, entries); err != nil {
+			return err
+		}
+		recorded = &entries[0]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.invalidateProductIDs(ctx, []uuid.UUID{id})
+	return recorded, nil
+}
+
+// AdjustStock adds delta to a product's stock, which may be negative, and
+// records it in the stock ledger. The change applies to the warehouse the
+// movement names, or else the default one. Stock cannot be taken below
+// zero, in total or in the warehouse, except in a warehouse of a product
+// that can be oversold; stock already below zero can still be added to.
+func (r *productRepository) AdjustStock(ctx context.Context, id uuid.UUID, delta int, movement domain.StockMovement) (*domain.StockMovement, error) {
+	var entry domain.StockMovement
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		var stock []int
+		err := tx.Raw(
+			"UPDATE products SET stock = stock + ?, updated_at = NOW() "+
+				"WHERE id = ? AND deleted_at IS NULL AND (stock + ? >= 0 OR ? > 0) AND ? RETURNING stock",
+			delta, id, delta, delta, tenant.Filter(ctx, "products"),
+		).Scan(&stock).Error
+		if err != nil {
+			return fmt.Errorf("failed to adjust stock: %w", err)
+		}
+		if len(stock) == 0 {
+			var count int64
+			if err := tx.Model(&domain.Product{}).Where("id = ?", id).Count(&count).Error; err != nil {
+				return fmt.Errorf("failed to get product: %w", err)
+			}
+			if count == 0 {
+				return customErrors.NewNotFoundError("Product not found", nil).WithCode(customErrors.CodeProductNotFound)
+			}
+			return customErrors.NewConflictError("Insufficient stock for adjustment", nil).WithCode(customErrors.CodeInsufficientStock)
+		}
+
+		entries := []domain.StockMovement{ledgerEntry(movement, id, delta, stock[0])}
+		if err := recordMovements(tx, entries); err != nil {
+			return err
+		}
+		entry = entries[0]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.invalidateProductIDs(ctx, []uuid.UUID{id})
+	return &entry, nil
+}
+
+// ListStockMovements lists a product's stock ledger, newest first
+func (r *productRepository) ListStockMovements(ctx context.Context, productID uuid.UUID, filters *domain.StockMovementFilters) ([]domain.StockMovement, int64, error) {
+	query := r.conn(ctx).Model(&domain.StockMovement{}).Where("product_id = ?", productID)
+	if filters.Reason != "" {
+		query = query.Where("reason = ?", filters.Reason)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count stock movements: %w", err)
+	}
+
+	var movements []domain.StockMovement
+	err := query.
+		Order("created_at DESC, id DESC").
+		Limit(filters.Limit).
+		Offset(filters.Offset).
+		Find(&movements).Error
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list stock movements: %w", err)
+	}
+
+	return movements, total, nil
+}
+
+// StockDrift finds products whose stock does not equal the sum of their
+// stock ledger, which means stock was changed without being recorded
+func (r *productRepository) StockDrift(ctx context.Context, limit int) ([]domain.StockDrift, error) {
+	var drift []domain.StockDrift
+	err := r.conn(ctx).Raw(`
+		SELECT p.id AS product_id, p.stock, COALESCE(SUM(m.delta), 0) AS ledger
+		FROM products p
+		LEFT JOIN inventory_movements m ON m.product_id = p.id
+		WHERE ?
+		GROUP BY p.id, p.stock
+		HAVING p.stock <> COALESCE(SUM(m.delta), 0)
+		ORDER BY p.id
+		LIMIT ?`,
+		tenant.Filter(ctx, "p"), limit,
+	).Scan(&drift).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to check stock ledger: %w", err)
+	}
+
+	return drift, nil
+}
+
+// recordMovements writes movements to the stock ledger and applies each to
+// the stock level of its warehouse, filling in the default warehouse of the
+// product's tenant for movements that name none. Every stock change goes
+// through here, so a product's levels always add up to its stock.
+func recordMovements(tx *gorm.DB, movements []domain.StockMovement) error {
+	defaults := make(map[uuid.UUID]*uuid.UUID)
+	for i := range movements {
+		if movements[i].WarehouseID != nil {
+			continue
+		}
+		productID := movements[i].ProductID
+		if defaults[productID] == nil {
+			id, err := defaultWarehouseID(tx, productID)
+			if err != nil {
+				return err
+			}
+			defaults[productID] = id
+		}
+		movements[i].WarehouseID = defaults[productID]
+	}
+
+	if err := tx.Create(&movements).Error; err != nil {
+		return fmt.Errorf("failed to record stock movements: %w", err)
+	}
+	for _, movement := range movements {
+		if err := applyStockLevel(tx, movement.ProductID, *movement.WarehouseID, movement.Delta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// defaultWarehouseID returns the ID of the default warehouse of a product's
+// tenant, creating it for a tenant whose first stock change this is. The
+// lookup goes by the product, so it holds in system contexts too.
+func defaultWarehouseID(tx *gorm.DB, productID uuid.UUID) (*uuid.UUID, error) {
+	var ids []uuid.UUID
+	lookup := func() error {
+		return tx.Raw(
+			"SELECT w.id FROM warehouses w JOIN products p ON p.tenant_id = w.tenant_id WHERE p.id = ? AND w.is_default",
+			productID,
+		).Scan(&ids).Error
+	}
+	if err := lookup(); err != nil {
+		return nil, fmt.Errorf("failed to get default warehouse: %w", err)
+	}
+	if len(ids) == 0 {
+		err := tx.Exec(
+			"INSERT INTO warehouses (tenant_id, code, name, is_default) "+
+				"SELECT tenant_id, ?, 'Default warehouse', TRUE FROM products WHERE id = ? "+
+				"ON CONFLICT DO NOTHING",
+			domain.DefaultWarehouseCode, productID,
+		).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to create default warehouse: %w", err)
+		}
+		// A concurrent change may have created it first
+		if err := lookup(); err != nil {
+			return nil, fmt.Errorf("failed to get default warehouse: %w", err)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("failed to get default warehouse: no default warehouse for product %s", productID)
+	}
+	return &ids[0], nil
+}
+
+// applyStockLevel adds delta to the stock a product holds in a warehouse,
+// rejecting a change that would take it below zero unless the product can
+// be oversold. Callers hold the lock on the product row, so the level
+// cannot be created concurrently.
+func applyStockLevel(tx *gorm.DB, productID, warehouseID uuid.UUID, delta int) error {
+	if delta == 0 {
+		return nil
+	}
+
+	result := tx.Exec(
+		"UPDATE stock_levels SET quantity = quantity + ?, updated_at = NOW() "+
+			"WHERE product_id = ? AND warehouse_id = ? "+
+			"AND (quantity + ? >= 0 OR ? > 0 OR (SELECT "+oversellSQL+" FROM products WHERE id = ?))",
+		delta, productID, warehouseID, delta, delta, productID,
+	)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update stock level: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+	if delta < 0 {
+		var oversell bool
+		if err := tx.Raw("SELECT "+oversellSQL+" FROM products WHERE id = ?", productID).Row().Scan(&oversell); err != nil {
+			return fmt.Errorf("failed to get product: %w", err)
+		}
+		if !oversell {
+			return customErrors.NewConflictError("Insufficient stock in warehouse", nil).WithCode(customErrors.CodeInsufficientStock)
+		}
+	}
+
+	err := tx.Exec(
+		"INSERT INTO stock_levels (product_id, warehouse_id, quantity) VALUES (?, ?, ?) "+
+			"ON CONFLICT (product_id, warehouse_id) DO UPDATE SET quantity = stock_levels.quantity + EXCLUDED.quantity, updated_at = NOW()",
+		productID, warehouseID, delta,
+	).Error
+	if err != nil {
+		return fmt.Errorf("failed to create stock level: %w", err)
+	}
+	return nil
+}
+
+// ledgerEntry fills in a movement for one product's stock change
+func ledgerEntry(movement domain.StockMovement, productID uuid.UUID, delta, balance int) domain.StockMovement {
+	movement.ProductID = productID
+	movement.Delta = delta
+	movement.Balance = balance
+	return movement
+}
+
+// ListLowStock lists live, active products at or below their low stock
+// threshold, lowest stock first
+func (r *productRepository) ListLowStock(ctx context.Context, defaultThreshold int, filters *domain.LowStockFilters) ([]domain.Product, int64, error) {
+	query := r.conn(ctx).Model(&domain.Product{}).
+		Where("is_active AND stock <= COALESCE(low_stock_threshold, ?)", defaultThreshold)
+	if filters.CategoryID != nil {
+		query = query.Where("category_id = ?", *filters.CategoryID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count low stock products: %w", err)
+	}
+
+	var products []domain.Product
+	err := query.
+		Preload("Category").
+		Preload("Brand").
+		Order("stock ASC, name ASC").
+		Limit(filters.Limit).
+		Offset(filters.Offset).
+		Find(&products).Error
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list low stock products: %w", err)
+	}
+
+	return products, total, nil
+}
+
+// MarkLowStock records a low stock alert on live, active products that are
+// at or below their threshold and have not been reported yet, and returns
+// the products it marked. Only the given products are considered, or every
+// product when ids is empty. Rows locked by another instance are skipped,
+// so each shortage is reported once.
+func (r *productRepository) MarkLowStock(ctx context.Context, defaultThreshold int, ids []uuid.UUID, limit int) ([]domain.Product, error) {
+	filter := ""
+	args := []interface{}{time.Now(), tenant.Filter(ctx, "products"), defaultThreshold}
+	if len(ids) > 0 {
+		filter = " AND id IN ?"
+		args = append(args, ids)
+	}
+	args = append(args, limit)
+
+	var marked []uuid.UUID
+	err := r.conn(ctx).Raw(`
+		UPDATE products SET low_stock_alerted_at = ?
+		WHERE id IN (
+			SELECT id FROM products
+			WHERE deleted_at IS NULL AND is_active AND low_stock_alerted_at IS NULL AND ?
+				AND stock <= COALESCE(low_stock_threshold, ?)`+filter+`
+			ORDER BY stock
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id`,
+		args...,
+	).Scan(&marked).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark low stock products: %w", err)
+	}
+	if len(marked) == 0 {
+		return nil, nil
+	}
+	r.invalidateProductIDs(ctx, marked)
+
+	var products []domain.Product
+	if err := r.conn(ctx).Where("id IN ?", marked).Order("stock").Find(&products).Error; err != nil {
+		return nil, fmt.Errorf("failed to get low stock products: %w", err)
+	}
+	return products, nil
+}
+
+// ResetLowStock clears the low stock alert of products whose stock has
+// recovered, so their next shortage is reported again. Only the given
+// products are considered, or every product when ids is empty.
+func (r *productRepository) ResetLowStock(ctx context.Context, defaultThreshold int, ids []uuid.UUID) (int64, error) {
+	filter := ""
+	args := []interface{}{tenant.Filter(ctx, "products"), defaultThreshold}
+	if len(ids) > 0 {
+		filter = " AND id IN ?"
+		args = append(args, ids)
+	}
+
+	var reset []uuid.UUID
+	err := r.conn(ctx).Raw(`
+		UPDATE products SET low_stock_alerted_at = NULL
+		WHERE low_stock_alerted_at IS NOT NULL AND ?
+			AND stock > COALESCE(low_stock_threshold, ?)`+filter+`
+		RETURNING id`,
+		args...,
+	).Scan(&reset).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to reset low stock products: %w", err)
+	}
+
+	r.invalidateProductIDs(ctx, reset)
+	return int64(len(reset)), nil
+}
+
+// invalidateProducts drops cached copies of products whose stock changed
+func (r *productRepository) invalidateProducts(ctx context.Context, reservations []domain.StockReservation) {
+	if len(reservations) == 0 {
+		return
+	}
+
+	ids := make([]uuid.UUID, 0, len(reservations))
+	for _, reservation := range reservations {
+		ids = append(ids, reservation.ProductID)
+	}
+	r.invalidateProductIDs(ctx, ids)
+}
+
+// invalidateProductIDs drops cached copies of products, from Redis and
+// from the local cache of every replica. In a system context the products'
+// tenants, which their keys are under, are looked up first.
+func (r *productRepository) invalidateProductIDs(ctx context.Context, ids []uuid.UUID) {
+	if len(ids) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(ids))
+	if id, ok := tenant.FromContext(ctx); ok {
+		for _, productID := range ids {
+			keys = append(keys, r.products.Key(id, productID.String()))
+		}
+	} else {
+		var rows []struct {
+			ID       uuid.UUID
+			TenantID string
+		}
+		err := r.conn(ctx).Model(&domain.Product{}).Unscoped().Select("id", "tenant_id").Where("id IN ?", ids).Scan(&rows).Error
+		if err != nil {
+			r.log(ctx).WithError(err).Warn("Failed to look up the tenants of cached products")
+			return
+		}
+		for _, row := range rows {
+			keys = append(keys, r.products.Key(row.TenantID, row.ID.String()))
+		}
+	}
+	if err := r.products.Delete(ctx, keys...); err != nil {
+		r.log(ctx).WithError(err).Warn("Failed to invalidate cached products")
+	}
+}
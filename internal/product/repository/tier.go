@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"ecommerce/internal/product/domain"
+)
+
+// ReplacePriceTiers replaces the price tiers of a product
+func (r *productRepository) ReplacePriceTiers(ctx context.Context, productID uuid.UUID, tiers []domain.PriceTier) error {
+	return r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", productID).Delete(&domain.PriceTier{}).Error; err != nil {
+			return fmt.Errorf("failed to remove price tiers: %w", err)
+		}
+		if len(tiers) == 0 {
+			return nil
+		}
+		for i := range tiers {
+			tiers[i].ProductID = productID
+		}
+		if err := tx.Create(&tiers).Error; err != nil {
+			return fmt.Errorf("failed to add price tiers: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListPriceTiers lists the price tiers of products, by product, group and
+// minimum quantity
+func (r *productRepository) ListPriceTiers(ctx context.Context, productIDs []uuid.UUID) ([]domain.PriceTier, error) {
+	if len(productIDs) == 0 {
+		return nil, nil
+	}
+
+	var tiers []domain.PriceTier
+	err := r.conn(ctx).
+		Where("product_id IN ?", productIDs).
+		Order("product_id, customer_group, min_quantity").
+		Find(&tiers).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list price tiers: %w", err)
+	}
+	return tiers, nil
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+
+	"ecommerce/internal/product/domain"
+)
+
+func (r *productRepository) UpsertProductTranslation(ctx context.Context, translation *domain.ProductTranslation) error {
+	err := r.conn(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "product_id"}, {Name: "locale"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name", "description", "updated_at"}),
+		}).
+		Create(translation).Error
+	if err != nil {
+		return fmt.Errorf("failed to save product translation: %w", err)
+	}
+	return nil
+}
+
+func (r *productRepository) DeleteProductTranslation(ctx context.Context, productID uuid.UUID, locale string) (bool, error) {
+	result := r.conn(ctx).
+		Where("product_id = ? AND locale = ?", productID, locale).
+		Delete(&domain.ProductTranslation{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete product translation: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *productRepository) ListProductTranslations(ctx context.Context, productID uuid.UUID) ([]domain.ProductTranslation, error) {
+	var translations []domain.ProductTranslation
+	err := r.conn(ctx).
+		Where("product_id = ?", productID).
+		Order("locale ASC").
+		Find(&translations).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list product translations: %w", err)
+	}
+	return translations, nil
+}
+
+// GetProductTranslations loads the translations of products into a locale,
+// keyed by product ID. Products without one are left out.
+func (r *productRepository) GetProductTranslations(ctx context.Context, ids []uuid.UUID, locale string) (map[uuid.UUID]domain.ProductTranslation, error) {
+	translations := make(map[uuid.UUID]domain.ProductTranslation)
+	if len(ids) == 0 {
+		return translations, nil
+	}
+
+	var rows []domain.ProductTranslation
+	err := r.conn(ctx).
+		Where("product_id IN ? AND locale = ?", ids, locale).
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product translations: %w", err)
+	}
+
+	for _, row := range rows {
+		translations[row.ProductID] = row
+	}
+	return translations, nil
+}
+
+func (r *productRepository) UpsertCategoryTranslation(ctx context.Context, translation *domain.CategoryTranslation) error {
+	err := r.conn(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "category_id"}, {Name: "locale"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name", "description", "updated_at"}),
+		}).
+		Create(translation).Error
+	if err != nil {
+		return fmt.Errorf("failed to save category translation: %w", err)
+	}
+	return nil
+}
+
+func (r *productRepository) DeleteCategoryTranslation(ctx context.Context, categoryID uuid.UUID, locale string) (bool, error) {
+	result := r.conn(ctx).
+		Where("category_id = ? AND locale = ?", categoryID, locale).
+		Delete(&domain.CategoryTranslation{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete category translation: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *productRepository) ListCategoryTranslations(ctx context.Context, categoryID uuid.UUID) ([]domain.CategoryTranslation, error) {
+	var translations []domain.CategoryTranslation
+	err := r.conn(ctx).
+		Where("category_id = ?", categoryID).
+		Order("locale ASC").
+		Find(&translations).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list category translations: %w", err)
+	}
+	return translations, nil
+}
+
+// GetCategoryTranslations loads the translations of categories into a
+// locale, keyed by category ID. Categories without one are left out.
+func (r *productRepository) GetCategoryTranslations(ctx context.Context, ids []uuid.UUID, locale string) (map[uuid.UUID]domain.CategoryTranslation, error) {
+	translations := make(map[uuid.UUID]domain.CategoryTranslation)
+	if len(ids) == 0 {
+		return translations, nil
+	}
+
+	var rows []domain.CategoryTranslation
+	err := r.conn(ctx).
+		Where("category_id IN ? AND locale = ?", ids, locale).
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category translations: %w", err)
+	}
+
+	for _, row := range rows {
+		translations[row.CategoryID] = row
+	}
+	return translations, nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/database"
+	customErrors "ecommerce/pkg/errors"
+)
+
+// vendorOwnerKey is the unique constraint giving each user at most one
+// vendor
+const vendorOwnerKey = "vendors_owner_id_key"
+
+// vendorOwnerConflict returns the error for a write rejected because the
+// user already owns a vendor
+func vendorOwnerConflict(cause error) error {
+	return customErrors.NewConflictError("User already owns a vendor", cause).WithCode(customErrors.CodeVendorOwnerConflict)
+}
+
+func (r *productRepository) CreateVendor(ctx context.Context, vendor *domain.Vendor) error {
+	err := r.conn(ctx).Create(vendor).Error
+	if database.IsUniqueViolation(err, vendorOwnerKey) {
+		return vendorOwnerConflict(err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create vendor: %w", err)
+	}
+	return nil
+}
+
+func (r *productRepository) GetVendor(ctx context.Context, id uuid.UUID) (*domain.Vendor, error) {
+	var vendor domain.Vendor
+	err := r.conn(ctx).First(&vendor, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Vendor not found", err).WithCode(customErrors.CodeVendorNotFound)
+		}
+		return nil, fmt.Errorf("failed to get vendor: %w", err)
+	}
+
+	return &vendor, nil
+}
+
+func (r *productRepository) GetVendorByOwner(ctx context.Context, ownerID string) (*domain.Vendor, error) {
+	var vendor domain.Vendor
+	err := r.conn(ctx).First(&vendor, "owner_id = ?", ownerID).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Vendor not found", err).WithCode(customErrors.CodeVendorNotFound)
+		}
+		return nil, fmt.Errorf("failed to get vendor by owner: %w", err)
+	}
+
+	return &vendor, nil
+}
+
+func (r *productRepository) UpdateVendor(ctx context.Context, vendor *domain.Vendor) error {
+	if err := r.conn(ctx).Save(vendor).Error; err != nil {
+		return fmt.Errorf("failed to update vendor: %w", err)
+	}
+	return nil
+}
+
+func (r *productRepository) ListVendors(ctx context.Context) ([]domain.Vendor, error) {
+	var vendors []domain.Vendor
+	if err := r.conn(ctx).Order("name ASC").Order("id").Find(&vendors).Error; err != nil {
+		return nil, fmt.Errorf("failed to list vendors: %w", err)
+	}
+	return vendors, nil
+}
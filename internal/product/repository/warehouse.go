@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/database"
+	customErrors "ecommerce/pkg/errors"
+)
+
+// warehouseCodeKey is the unique constraint keeping warehouse codes unique
+const warehouseCodeKey = "warehouses_code_key"
+
+// warehouseOrder is the order stock is taken from warehouses in: highest
+// priority first, then those holding the most
+const warehouseOrder = "w.priority DESC, l.quantity DESC, w.code"
+
+// warehouseCodeConflict returns the error for a write rejected because
+// another warehouse has the code
+func warehouseCodeConflict(cause error) error {
+	return customErrors.NewConflictError("Warehouse code already exists", cause).WithCode(customErrors.CodeWarehouseCodeConflict)
+}
+
+func (r *productRepository) CreateWarehouse(ctx context.Context, warehouse *domain.Warehouse) error {
+	err := r.conn(ctx).Create(warehouse).Error
+	if database.IsUniqueViolation(err, warehouseCodeKey) {
+		return warehouseCodeConflict(err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create warehouse: %w", err)
+	}
+	return nil
+}
+
+func (r *productRepository) GetWarehouse(ctx context.Context, id uuid.UUID) (*domain.Warehouse, error) {
+	var warehouse domain.Warehouse
+	err := r.conn(ctx).First(&warehouse, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Warehouse not found", err).WithCode(customErrors.CodeWarehouseNotFound)
+		}
+		return nil, fmt.Errorf("failed to get warehouse: %w", err)
+	}
+
+	return &warehouse, nil
+}
+
+func (r *productRepository) GetWarehouseByCode(ctx context.Context, code string) (*domain.Warehouse, error) {
+	var warehouse domain.Warehouse
+	err := r.conn(ctx).First(&warehouse, "code = ?", code).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Warehouse not found", err).WithCode(customErrors.CodeWarehouseNotFound)
+		}
+		return nil, fmt.Errorf("failed to get warehouse by code: %w", err)
+	}
+
+	return &warehouse, nil
+}
+
+func (r *productRepository) UpdateWarehouse(ctx context.Context, warehouse *domain.Warehouse) error {
+	if err := r.conn(ctx).Save(warehouse).Error; err != nil {
+		return fmt.Errorf("failed to update warehouse: %w", err)
+	}
+	return nil
+}
+
+// ListWarehouses lists warehouses in the order reservations take stock
+// from them: highest priority first
+func (r *productRepository) ListWarehouses(ctx context.Context) ([]domain.Warehouse, error) {
+	var warehouses []domain.Warehouse
+	err := r.conn(ctx).
+		Order("priority DESC, code ASC").
+		Find(&warehouses).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list warehouses: %w", err)
+	}
+
+	return warehouses, nil
+}
+
+// ListStockLevels lists the stock levels of products, optionally in one
+// warehouse only, by product and then in the order stock is taken from
+// their warehouses
+func (r *productRepository) ListStockLevels(ctx context.Context, productIDs []uuid.UUID, warehouseID *uuid.UUID) ([]domain.StockLevel, error) {
+	if len(productIDs) == 0 {
+		return nil, nil
+	}
+
+	query := r.conn(ctx).
+		Table("stock_levels l").
+		Select("l.product_id, l.warehouse_id, w.code AS warehouse_code, l.quantity, l.updated_at").
+		Joins("JOIN warehouses w ON w.id = l.warehouse_id").
+		Where("l.product_id IN ?", productIDs)
+	if warehouseID != nil {
+		query = query.Where("l.warehouse_id = ?", *warehouseID)
+	}
+
+	var levels []domain.StockLevel
+	if err := query.Order("l.product_id, " + warehouseOrder).Scan(&levels).Error; err != nil {
+		return nil, fmt.Errorf("failed to list stock levels: %w", err)
+	}
+
+	return levels, nil
+}
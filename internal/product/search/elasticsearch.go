@@ -0,0 +1,517 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/product/config"
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/tenant"
+)
+
+// ElasticsearchSearcher searches and indexes products in Elasticsearch or OpenSearch
+type ElasticsearchSearcher struct {
+	baseURL  string
+	index    string
+	username string
+	password string
+	client   *http.Client
+}
+
+// NewElasticsearchSearcher creates a new Elasticsearch searcher
+func NewElasticsearchSearcher(cfg config.SearchConfig) *ElasticsearchSearcher {
+	return &ElasticsearchSearcher{
+		baseURL:  strings.TrimRight(cfg.ElasticsearchURL, "/"),
+		index:    cfg.ElasticsearchIndex,
+		username: cfg.ElasticsearchUsername,
+		password: cfg.ElasticsearchPassword,
+		client: &http.Client{
+			Timeout: time.Duration(cfg.Timeout) * time.Second,
+		},
+	}
+}
+
+// indexMapping defines the product index settings and field mappings
+var indexMapping = map[string]interface{}{
+	"mappings": map[string]interface{}{
+		"properties": map[string]interface{}{
+			"id":               map[string]interface{}{"type": "keyword"},
+			"name":             map[string]interface{}{"type": "text", "fields": map[string]interface{}{"keyword": map[string]interface{}{"type": "keyword"}}},
+			"description":      map[string]interface{}{"type": "text", "index": false},
+			"description_text": map[string]interface{}{"type": "text"},
+			"sku":              map[string]interface{}{"type": "keyword"},
+			"slug":             map[string]interface{}{"type": "keyword"},
+			"category_id":      map[string]interface{}{"type": "keyword"},
+			"brand_id":         map[string]interface{}{"type": "keyword"},
+			"vendor_id":        map[string]interface{}{"type": "keyword"},
+			"price":            map[string]interface{}{"type": "double"},
+			"rating_average":   map[string]interface{}{"type": "double"},
+			"review_count":     map[string]interface{}{"type": "integer"},
+			"stock":            map[string]interface{}{"type": "integer"},
+			"is_active":        map[string]interface{}{"type": "boolean"},
+			"status":           map[string]interface{}{"type": "keyword"},
+			"approval_status":  map[string]interface{}{"type": "keyword"},
+			"tenant_id":        map[string]interface{}{"type": "keyword"},
+			"channels":         map[string]interface{}{"type": "object", "dynamic": false, "properties": channelMappings()},
+			"created_at":       map[string]interface{}{"type": "date"},
+			"updated_at":       map[string]interface{}{"type": "date"},
+		},
+	},
+}
+
+// Ping checks that the cluster is reachable and the product index exists
+func (s *ElasticsearchSearcher) Ping(ctx context.Context) error {
+	resp, err := s.do(ctx, http.MethodHead, "/"+s.index, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status checking index: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// EnsureIndex creates the product index if it does not exist
+func (s *ElasticsearchSearcher) EnsureIndex(ctx context.Context) error {
+	resp, err := s.do(ctx, http.MethodHead, "/"+s.index, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("unexpected status checking index: %d", resp.StatusCode)
+	}
+
+	resp, err = s.do(ctx, http.MethodPut, "/"+s.index, indexMapping)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp, "create index")
+}
+
+// Index adds or replaces a product document
+func (s *ElasticsearchSearcher) Index(ctx context.Context, product *domain.Product) error {
+	resp, err := s.do(ctx, http.MethodPut, fmt.Sprintf("/%s/_doc/%s", s.index, product.ID), product)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp, "index product")
+}
+
+// Delete removes a product document
+func (s *ElasticsearchSearcher) Delete(ctx context.Context, id uuid.UUID) error {
+	resp, err := s.do(ctx, http.MethodDelete, fmt.Sprintf("/%s/_doc/%s", s.index, id), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return checkResponse(resp, "delete product")
+}
+
+// Search runs a fuzzy, relevance-ranked product search
+func (s *ElasticsearchSearcher) Search(ctx context.Context, filters *domain.ProductFilters) ([]domain.Product, int64, error) {
+	body := map[string]interface{}{
+		"query":            buildQuery(ctx, filters),
+		"sort":             buildSort(filters),
+		"size":             filters.Limit,
+		"track_total_hits": true,
+		"highlight": map[string]interface{}{
+			"pre_tags":  []string{"<mark>"},
+			"post_tags": []string{"</mark>"},
+			"fields": map[string]interface{}{
+				"description_text": map[string]interface{}{"number_of_fragments": 2},
+				"name":             map[string]interface{}{"number_of_fragments": 0},
+			},
+		},
+	}
+
+	if filters.After != nil {
+		body["search_after"] = []interface{}{filters.After.CreatedAt.UnixMilli(), filters.After.ID.String()}
+	} else if filters.Offset > 0 {
+		body["from"] = filters.Offset
+	}
+
+	resp, err := s.do(ctx, http.MethodPost, fmt.Sprintf("/%s/_search", s.index), body)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, "search products"); err != nil {
+		return nil, 0, err
+	}
+
+	var result struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				Score     float64             `json:"_score"`
+				Source    domain.Product      `json:"_source"`
+				Highlight map[string][]string `json:"highlight"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode search response: %w", err)
+	}
+
+	products := make([]domain.Product, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		product := hit.Source
+		product.Rank = hit.Score
+		if fragments := hit.Highlight["description_text"]; len(fragments) > 0 {
+			product.Highlight = strings.Join(fragments, " ... ")
+		} else if fragments := hit.Highlight["name"]; len(fragments) > 0 {
+			product.Highlight = fragments[0]
+		}
+		products = append(products, product)
+	}
+
+	return products, result.Hits.Total.Value, nil
+}
+
+// Facets computes facet counts with Elasticsearch aggregations. Each
+// aggregation is wrapped in a filter that omits the facet's own field.
+func (s *ElasticsearchSearcher) Facets(ctx context.Context, filters *domain.ProductFilters) (*domain.ProductFacets, error) {
+	categoryFilters := *filters
+	categoryFilters.CategoryID = nil
+	brandFilters := *filters
+	brandFilters.BrandID = nil
+	priceFilters := *filters
+	priceFilters.MinPrice = nil
+	priceFilters.MaxPrice = nil
+	stockFilters := *filters
+	stockFilters.InStock = nil
+
+	bounds := domain.PriceBucketBounds
+	ranges := make([]interface{}, 0, len(bounds))
+	for i, lower := range bounds {
+		bucket := map[string]interface{}{"from": lower}
+		if i+1 < len(bounds) {
+			bucket["to"] = bounds[i+1]
+		}
+		ranges = append(ranges, bucket)
+	}
+
+	body := map[string]interface{}{
+		"size":  0,
+		"query": map[string]interface{}{"bool": map[string]interface{}{"must": buildMust(filters), "filter": tenantFilter(ctx)}},
+		"aggs": map[string]interface{}{
+			"categories": map[string]interface{}{
+				"filter": filterQuery(&categoryFilters),
+				"aggs": map[string]interface{}{
+					"values": map[string]interface{}{"terms": map[string]interface{}{"field": "category_id", "size": 100}},
+				},
+			},
+			"brands": map[string]interface{}{
+				"filter": filterQuery(&brandFilters),
+				"aggs": map[string]interface{}{
+					"values": map[string]interface{}{"terms": map[string]interface{}{"field": "brand_id", "size": 100}},
+				},
+			},
+			"prices": map[string]interface{}{
+				"filter": filterQuery(&priceFilters),
+				"aggs": map[string]interface{}{
+					"values": map[string]interface{}{"range": map[string]interface{}{"field": "price", "ranges": ranges}},
+				},
+			},
+			"stock": map[string]interface{}{
+				"filter": filterQuery(&stockFilters),
+				"aggs": map[string]interface{}{
+					"in_stock": map[string]interface{}{"filter": map[string]interface{}{"range": map[string]interface{}{"stock": map[string]interface{}{"gt": 0}}}},
+				},
+			},
+		},
+	}
+
+	resp, err := s.do(ctx, http.MethodPost, fmt.Sprintf("/%s/_search", s.index), body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, "aggregate facets"); err != nil {
+		return nil, err
+	}
+
+	type bucket struct {
+		Key      interface{} `json:"key"`
+		DocCount int64       `json:"doc_count"`
+	}
+	var result struct {
+		Aggregations struct {
+			Categories struct {
+				Values struct {
+					Buckets []bucket `json:"buckets"`
+				} `json:"values"`
+			} `json:"categories"`
+			Brands struct {
+				Values struct {
+					Buckets []bucket `json:"buckets"`
+				} `json:"values"`
+			} `json:"brands"`
+			Prices struct {
+				Values struct {
+					Buckets []bucket `json:"buckets"`
+				} `json:"values"`
+			} `json:"prices"`
+			Stock struct {
+				DocCount int64 `json:"doc_count"`
+				InStock  struct {
+					DocCount int64 `json:"doc_count"`
+				} `json:"in_stock"`
+			} `json:"stock"`
+		} `json:"aggregations"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode facet response: %w", err)
+	}
+
+	facets := &domain.ProductFacets{
+		Categories:  make([]domain.FacetCount, 0, len(result.Aggregations.Categories.Values.Buckets)),
+		Brands:      make([]domain.FacetCount, 0, len(result.Aggregations.Brands.Values.Buckets)),
+		PriceRanges: make([]domain.PriceRangeFacet, 0, len(bounds)),
+		InStock:     result.Aggregations.Stock.InStock.DocCount,
+		OutOfStock:  result.Aggregations.Stock.DocCount - result.Aggregations.Stock.InStock.DocCount,
+	}
+	for _, b := range result.Aggregations.Categories.Values.Buckets {
+		facets.Categories = append(facets.Categories, domain.FacetCount{
+			Value: fmt.Sprint(b.Key),
+			Count: b.DocCount,
+		})
+	}
+	for _, b := range result.Aggregations.Brands.Values.Buckets {
+		facets.Brands = append(facets.Brands, domain.FacetCount{
+			Value: fmt.Sprint(b.Key),
+			Count: b.DocCount,
+		})
+	}
+	for i, b := range result.Aggregations.Prices.Values.Buckets {
+		if i >= len(bounds) {
+			break
+		}
+		priceRange := domain.PriceRangeFacet{Min: bounds[i], Count: b.DocCount}
+		if i+1 < len(bounds) {
+			upper := bounds[i+1]
+			priceRange.Max = &upper
+		}
+		facets.PriceRanges = append(facets.PriceRanges, priceRange)
+	}
+
+	return facets, nil
+}
+
+func buildQuery(ctx context.Context, filters *domain.ProductFilters) map[string]interface{} {
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"must":   buildMust(filters),
+			"filter": append(buildFilter(filters), tenantFilter(ctx)...),
+		},
+	}
+}
+
+// tenantFilter limits a search to the tenant ctx is scoped to. Documents
+// indexed before tenancy have no tenant and belong to the default one.
+func tenantFilter(ctx context.Context) []interface{} {
+	id, ok := tenant.FromContext(ctx)
+	if !ok {
+		return []interface{}{}
+	}
+	term := map[string]interface{}{"term": map[string]interface{}{"tenant_id": id}}
+	if id != tenant.Default {
+		return []interface{}{term}
+	}
+	return []interface{}{map[string]interface{}{"bool": map[string]interface{}{
+		"should": []interface{}{
+			term,
+			map[string]interface{}{"bool": map[string]interface{}{"must_not": map[string]interface{}{"exists": map[string]interface{}{"field": "tenant_id"}}}},
+		},
+		"minimum_should_match": 1,
+	}}}
+}
+
+func filterQuery(filters *domain.ProductFilters) map[string]interface{} {
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"filter": buildFilter(filters),
+		},
+	}
+}
+
+func buildMust(filters *domain.ProductFilters) []interface{} {
+	var must []interface{}
+	if filters.Search != "" {
+		// Synonym rephrasings match alongside the query as typed
+		should := make([]interface{}, 0, len(filters.SearchAlternatives)+1)
+		for _, text := range append([]string{filters.Search}, filters.SearchAlternatives...) {
+			should = append(should, map[string]interface{}{
+				"multi_match": map[string]interface{}{
+					"query":     text,
+					"fields":    []string{"name^3", "description_text", "sku^2"},
+					"fuzziness": "AUTO",
+					"operator":  "and",
+				},
+			})
+		}
+		must = append(must, map[string]interface{}{
+			"bool": map[string]interface{}{
+				"should":               should,
+				"minimum_should_match": 1,
+			},
+		})
+	} else {
+		must = append(must, map[string]interface{}{"match_all": map[string]interface{}{}})
+	}
+	return must
+}
+
+func buildFilter(filters *domain.ProductFilters) []interface{} {
+	filter := []interface{}{}
+	if filters.CategoryID != nil {
+		filter = append(filter, map[string]interface{}{"term": map[string]interface{}{"category_id": filters.CategoryID.String()}})
+	}
+	if filters.BrandID != nil {
+		filter = append(filter, map[string]interface{}{"term": map[string]interface{}{"brand_id": filters.BrandID.String()}})
+	}
+	if filters.VendorID != nil {
+		filter = append(filter, map[string]interface{}{"term": map[string]interface{}{"vendor_id": filters.VendorID.String()}})
+	}
+	if filters.MinPrice != nil || filters.MaxPrice != nil {
+		priceRange := map[string]interface{}{}
+		if filters.MinPrice != nil {
+			priceRange["gte"] = *filters.MinPrice
+		}
+		if filters.MaxPrice != nil {
+			priceRange["lte"] = *filters.MaxPrice
+		}
+		filter = append(filter, map[string]interface{}{"range": map[string]interface{}{"price": priceRange}})
+	}
+	if filters.IsActive != nil {
+		filter = append(filter, map[string]interface{}{"term": map[string]interface{}{"is_active": *filters.IsActive}})
+	}
+	if filters.Status == domain.ProductStatusPublished {
+		// Documents indexed before products had a status were all published
+		filter = append(filter, map[string]interface{}{"bool": map[string]interface{}{
+			"should": []interface{}{
+				map[string]interface{}{"term": map[string]interface{}{"status": filters.Status}},
+				map[string]interface{}{"bool": map[string]interface{}{"must_not": map[string]interface{}{"exists": map[string]interface{}{"field": "status"}}}},
+			},
+			"minimum_should_match": 1,
+		}})
+	} else if filters.Status != "" {
+		filter = append(filter, map[string]interface{}{"term": map[string]interface{}{"status": filters.Status}})
+	}
+	if filters.ApprovalStatus != "" {
+		filter = append(filter, map[string]interface{}{"term": map[string]interface{}{"approval_status": filters.ApprovalStatus}})
+	}
+	if filters.InStock != nil && *filters.InStock {
+		filter = append(filter, map[string]interface{}{"range": map[string]interface{}{"stock": map[string]interface{}{"gt": 0}}})
+	}
+	if filters.Channel != "" {
+		filter = append(filter, map[string]interface{}{"bool": map[string]interface{}{
+			"must_not": map[string]interface{}{"term": map[string]interface{}{"channels." + filters.Channel + ".hidden": true}},
+		}})
+	}
+	return filter
+}
+
+// channelMappings maps the settings of each sales channel a product has
+func channelMappings() map[string]interface{} {
+	properties := make(map[string]interface{}, len(domain.Channels))
+	for _, channel := range domain.Channels {
+		properties[channel] = map[string]interface{}{"properties": map[string]interface{}{
+			"hidden": map[string]interface{}{"type": "boolean"},
+			"price":  map[string]interface{}{"type": "double"},
+		}}
+	}
+	return properties
+}
+
+func buildSort(filters *domain.ProductFilters) []interface{} {
+	order := strings.ToLower(filters.SortOrder)
+	if order != "asc" {
+		order = "desc"
+	}
+
+	switch filters.SortBy {
+	case "relevance":
+		return []interface{}{
+			map[string]interface{}{"_score": order},
+			map[string]interface{}{"created_at": "desc"},
+		}
+	case "price":
+		return []interface{}{map[string]interface{}{"price": order}}
+	case "rating":
+		return []interface{}{
+			map[string]interface{}{"rating_average": order},
+			map[string]interface{}{"review_count": order},
+		}
+	case "name":
+		return []interface{}{map[string]interface{}{"name.keyword": order}}
+	default:
+		return []interface{}{
+			map[string]interface{}{"created_at": order},
+			map[string]interface{}{"id": order},
+		}
+	}
+}
+
+func (s *ElasticsearchSearcher) do(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if s.username != "" {
+		req.SetBasicAuth(s.username, s.password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("elasticsearch request failed: %w", err)
+	}
+	return resp, nil
+}
+
+func checkResponse(resp *http.Response, action string) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("failed to %s: status %d: %s", action, resp.StatusCode, strings.TrimSpace(string(detail)))
+}
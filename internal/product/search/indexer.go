@@ -0,0 +1,111 @@
+package search
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/internal/product/repository"
+	"ecommerce/pkg/events"
+)
+
+// Indexer keeps the Elasticsearch index in sync with product events
+type Indexer struct {
+	searcher *ElasticsearchSearcher
+	repo     repository.ProductRepository
+	logger   *logrus.Logger
+}
+
+// NewIndexer creates a new search indexer
+func NewIndexer(searcher *ElasticsearchSearcher, repo repository.ProductRepository, logger *logrus.Logger) *Indexer {
+	return &Indexer{
+		searcher: searcher,
+		repo:     repo,
+		logger:   logger,
+	}
+}
+
+// Register subscribes the indexer to product events on the bus
+func (i *Indexer) Register(bus *events.Bus) {
+	bus.Subscribe(domain.EventProductCreated, i.handleUpsert)
+	bus.Subscribe(domain.EventProductUpdated, i.handleUpsert)
+	bus.Subscribe(domain.EventProductRestored, i.handleUpsert)
+	bus.Subscribe(domain.EventProductDeleted, i.handleDelete)
+}
+
+func (i *Indexer) handleUpsert(ctx context.Context, event events.Event) error {
+	var payload domain.Product
+	if err := event.Decode(&payload); err != nil {
+		return fmt.Errorf("failed to decode product event: %w", err)
+	}
+
+	// Reload so the indexed document carries its category
+	product, err := i.repo.GetByID(ctx, payload.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load product for indexing: %w", err)
+	}
+
+	if err := i.searcher.Index(ctx, product); err != nil {
+		return err
+	}
+
+	i.logger.WithField("product_id", product.ID).Debug("Product indexed")
+	return nil
+}
+
+func (i *Indexer) handleDelete(ctx context.Context, event events.Event) error {
+	var payload domain.Product
+	if err := event.Decode(&payload); err != nil {
+		return fmt.Errorf("failed to decode product event: %w", err)
+	}
+
+	if err := i.searcher.Delete(ctx, payload.ID); err != nil {
+		return err
+	}
+
+	i.logger.WithField("product_id", payload.ID).Debug("Product removed from index")
+	return nil
+}
+
+// Reindex creates the index when it is missing and indexes every product
+// in batches, returning how many were indexed. Use it to rebuild the index
+// after a mapping change or a bulk load that published no events.
+func (i *Indexer) Reindex(ctx context.Context, batchSize int) (int, error) {
+	if err := i.searcher.EnsureIndex(ctx); err != nil {
+		return 0, err
+	}
+
+	indexed := 0
+	err := i.repo.Iterate(ctx, &domain.ProductFilters{}, batchSize, func(batch []domain.Product) error {
+		ids := make([]uuid.UUID, len(batch))
+		for n := range batch {
+			ids[n] = batch[n].ID
+		}
+
+		// Reload with associations, as the indexed documents carry them
+		products, err := i.repo.GetByIDs(ctx, ids)
+		if err != nil {
+			return fmt.Errorf("failed to load products for indexing: %w", err)
+		}
+		for _, id := range ids {
+			product, ok := products[id]
+			if !ok {
+				continue // deleted since the batch was read
+			}
+			if err := i.searcher.Index(ctx, product); err != nil {
+				return err
+			}
+			indexed++
+		}
+
+		i.logger.WithField("indexed", indexed).Info("Reindexing products")
+		return nil
+	})
+	if err != nil {
+		return indexed, err
+	}
+	return indexed, nil
+}
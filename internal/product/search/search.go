@@ -0,0 +1,37 @@
+package search
+
+import (
+	"context"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/internal/product/repository"
+)
+
+// Backend names accepted in configuration
+const (
+	BackendPostgres      = "postgres"
+	BackendElasticsearch = "elasticsearch"
+)
+
+// Searcher defines the product search interface
+type Searcher interface {
+	Search(ctx context.Context, filters *domain.ProductFilters) ([]domain.Product, int64, error)
+	Facets(ctx context.Context, filters *domain.ProductFilters) (*domain.ProductFacets, error)
+}
+
+type postgresSearcher struct {
+	repo repository.ProductRepository
+}
+
+// NewPostgresSearcher creates a searcher backed by PostgreSQL full-text search
+func NewPostgresSearcher(repo repository.ProductRepository) Searcher {
+	return &postgresSearcher{repo: repo}
+}
+
+func (s *postgresSearcher) Search(ctx context.Context, filters *domain.ProductFilters) ([]domain.Product, int64, error) {
+	return s.repo.List(ctx, filters)
+}
+
+func (s *postgresSearcher) Facets(ctx context.Context, filters *domain.ProductFilters) (*domain.ProductFacets, error) {
+	return s.repo.Facets(ctx, filters)
+}
@@ -0,0 +1,79 @@
+package seed
+
+// category is a seeded category and what its products look like
+type category struct {
+	name        string
+	description string
+	parent      string // name of the parent category, empty for top level
+	nouns       []string
+	minPrice    float64
+	maxPrice    float64
+}
+
+// categories are listed parents first so parents exist before children
+var categories = []category{
+	{name: "Electronics", description: "Devices, gadgets and accessories"},
+	{name: "Phones", parent: "Electronics", description: "Smartphones and accessories",
+		nouns: []string{"Smartphone", "Phone Case", "Wireless Charger", "Screen Protector", "USB-C Cable"}, minPrice: 9, maxPrice: 1199},
+	{name: "Laptops", parent: "Electronics", description: "Notebooks, ultrabooks and workstations",
+		nouns: []string{"Laptop", "Ultrabook", "Chromebook", "Laptop Sleeve", "Docking Station"}, minPrice: 29, maxPrice: 2899},
+	{name: "Audio", parent: "Electronics", description: "Headphones, speakers and microphones",
+		nouns: []string{"Headphones", "Earbuds", "Bluetooth Speaker", "Soundbar", "Microphone"}, minPrice: 19, maxPrice: 599},
+	{name: "Home & Kitchen", description: "Everything for the home"},
+	{name: "Cookware", parent: "Home & Kitchen", description: "Pots, pans and kitchen tools",
+		nouns: []string{"Frying Pan", "Saucepan", "Chef's Knife", "Cutting Board", "Dutch Oven"}, minPrice: 12, maxPrice: 349},
+	{name: "Furniture", parent: "Home & Kitchen", description: "Tables, chairs and storage",
+		nouns: []string{"Desk Chair", "Bookshelf", "Coffee Table", "Floor Lamp", "Storage Bench"}, minPrice: 39, maxPrice: 1299},
+	{name: "Clothing", description: "Apparel for every season"},
+	{name: "Men's Clothing", parent: "Clothing", description: "Shirts, trousers and outerwear",
+		nouns: []string{"T-Shirt", "Hoodie", "Chinos", "Rain Jacket", "Oxford Shirt"}, minPrice: 15, maxPrice: 249},
+	{name: "Women's Clothing", parent: "Clothing", description: "Dresses, tops and outerwear",
+		nouns: []string{"Sweater", "Midi Dress", "Denim Jacket", "Leggings", "Blouse"}, minPrice: 15, maxPrice: 269},
+	{name: "Sports & Outdoors", description: "Gear for training and the outdoors",
+		nouns: []string{"Yoga Mat", "Running Shoes", "Water Bottle", "Backpack", "Camping Tent"}, minPrice: 9, maxPrice: 499},
+	{name: "Books", description: "Fiction and non-fiction",
+		nouns: []string{"Novel", "Cookbook", "Field Guide", "Biography", "Travel Guide"}, minPrice: 6, maxPrice: 59},
+	{name: "Toys & Games", description: "Toys, puzzles and board games",
+		nouns: []string{"Board Game", "Jigsaw Puzzle", "Building Set", "Plush Toy", "Card Game"}, minPrice: 8, maxPrice: 149},
+	{name: "Beauty", description: "Skincare, haircare and fragrance",
+		nouns: []string{"Face Serum", "Moisturizer", "Shampoo", "Eau de Parfum", "Lip Balm"}, minPrice: 5, maxPrice: 189},
+}
+
+// brands are made up so seeded data is never mistaken for real listings
+var brands = []struct {
+	name        string
+	description string
+}{
+	{"Acme", "Everyday essentials since 1952"},
+	{"Northwind", "Outdoor and travel gear"},
+	{"Lumen", "Consumer electronics"},
+	{"Oakridge", "Solid wood furniture"},
+	{"Bellhaven", "Kitchen and home"},
+	{"Velo", "Performance sportswear"},
+	{"Marlowe & Finch", "Independent publisher"},
+	{"Pinecrest", "Organic personal care"},
+	{"Kestrel", "Audio equipment"},
+	{"Juniper Lane", "Contemporary fashion"},
+	{"Brightblock", "Educational toys"},
+	{"Halcyon", "Premium lifestyle goods"},
+}
+
+var adjectives = []string{
+	"Classic", "Premium", "Compact", "Essential", "Pro", "Ultra", "Eco", "Deluxe",
+	"Lightweight", "Heritage", "Everyday", "Smart", "Vintage", "Modern", "Travel",
+}
+
+var colors = []string{
+	"Black", "White", "Navy", "Charcoal", "Forest Green", "Sand", "Burgundy", "Slate", "Sky Blue", "Oat",
+}
+
+var features = []string{
+	"built to last",
+	"designed for everyday use",
+	"backed by a two-year warranty",
+	"made from responsibly sourced materials",
+	"easy to clean",
+	"loved by thousands of customers",
+	"thoughtfully packaged with no plastic",
+	"tested for durability",
+}
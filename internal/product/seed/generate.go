@@ -0,0 +1,343 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/internal/product/repository"
+	"ecommerce/pkg/tenant"
+)
+
+// DefaultGeneratePrefix marks generated products
+const DefaultGeneratePrefix = "LOAD-"
+
+// generateBatchSize is how many products are copied per transaction
+const generateBatchSize = 10000
+
+// Skew of the generated catalog: a few categories and brands hold most
+// products, as in a real store, so filtered listings range from huge to
+// small
+const (
+	categorySkew = 1.3
+	brandSkew    = 1.1
+)
+
+// generateAge is how far back generated products were created
+const generateAge = 2 * 365 * 24 * time.Hour
+
+var (
+	productColumns  = []string{"id", "name", "slug", "description", "description_text", "price", "category_id", "brand_id", "stock", "image_url", "sku", "is_active", "status", "published_at", "created_at", "updated_at"}
+	movementColumns = []string{"id", "product_id", "warehouse_id", "delta", "balance", "reason", "reference", "created_at"}
+	levelColumns    = []string{"product_id", "warehouse_id", "quantity", "updated_at"}
+)
+
+// GenerateOptions controls a generation run
+type GenerateOptions struct {
+	Products int    // how many products to generate
+	Seed     uint64 // random seed; the same seed generates the same products, under new IDs
+	Prefix   string // SKU prefix marking the generated products
+}
+
+// Generator fills the catalog with millions of products for performance
+// testing the list and search endpoints. Unlike the seeder it bypasses the
+// repository and copies rows straight into Postgres, and it does not skip
+// existing products: a run fails if its SKUs are taken, so clean up the
+// previous run or pick another prefix. Like seeding, it publishes no
+// events and leaves caches and search indexes alone.
+type Generator struct {
+	seeder *Seeder
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+// NewGenerator creates a new generator. Categories and brands are created
+// through repo; products are copied into db.
+func NewGenerator(repo repository.ProductRepository, db *gorm.DB, logger *logrus.Logger) *Generator {
+	return &Generator{seeder: New(repo, logger), db: db, logger: logger}
+}
+
+// Run generates products into the seeded categories and brands, which are
+// created first when missing. Products are spread over the categories and
+// brands on Zipf distributions, and their stock is held in the default
+// warehouse and recorded in the stock ledger as imported. Each batch is committed on its own, so a failed run
+// leaves the batches before it behind.
+func (g *Generator) Run(ctx context.Context, opts GenerateOptions) (*Result, error) {
+	if opts.Prefix == "" {
+		opts.Prefix = DefaultGeneratePrefix
+	}
+	result := &Result{}
+	rng := rand.New(rand.NewPCG(opts.Seed, opts.Seed))
+
+	categoryIDs, created, err := g.seeder.seedCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result.Categories = created
+
+	brandIDs, created, err := g.seeder.seedBrands(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result.Brands = created
+
+	// Generated products belong to the default tenant, and hold their stock
+	// in its default warehouse
+	var warehouseID uuid.UUID
+	err = g.db.WithContext(ctx).Raw("SELECT id FROM warehouses WHERE is_default AND tenant_id = ?", tenant.Default).Row().Scan(&warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get default warehouse: %w", err)
+	}
+
+	// Shuffle the leaf categories and brands so the seed decides which are
+	// the big ones
+	var leaves []category
+	for _, c := range categories {
+		if len(c.nouns) > 0 {
+			leaves = append(leaves, c)
+		}
+	}
+	rng.Shuffle(len(leaves), func(i, j int) { leaves[i], leaves[j] = leaves[j], leaves[i] })
+	brandOrder := rng.Perm(len(brands))
+
+	gen := &catalogGenerator{
+		rng:         rng,
+		prefix:      opts.Prefix,
+		now:         time.Now().UTC(),
+		leaves:      leaves,
+		categoryIDs: categoryIDs,
+		brandIDs:    brandIDs,
+		brandOrder:  brandOrder,
+		warehouseID: warehouseID,
+		categories:  rand.NewZipf(rng, categorySkew, 1, uint64(len(leaves)-1)),
+		brands:      rand.NewZipf(rng, brandSkew, 1, uint64(len(brands)-1)),
+	}
+
+	err = g.withConn(ctx, func(conn *pgx.Conn) error {
+		for start := 0; start < opts.Products; start += generateBatchSize {
+			end := min(start+generateBatchSize, opts.Products)
+
+			products := make([][]any, 0, end-start)
+			movements := make([][]any, 0, end-start)
+			levels := make([][]any, 0, end-start)
+			for n := start; n < end; n++ {
+				product, movement, level := gen.product(n + 1)
+				products = append(products, product)
+				if movement != nil {
+					movements = append(movements, movement)
+					levels = append(levels, level)
+				}
+			}
+
+			if err := copyBatch(ctx, conn, products, movements, levels); err != nil {
+				return err
+			}
+			result.Products += len(products)
+
+			g.logger.WithField("count", end).Info("Generated products")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Refresh the planner's statistics, or queries against the new rows
+	// are planned as if the tables were still small
+	if err := g.db.WithContext(ctx).Exec("ANALYZE products, inventory_movements, stock_levels").Error; err != nil {
+		return nil, fmt.Errorf("failed to analyze products: %w", err)
+	}
+
+	return result, nil
+}
+
+// Clean deletes the products a run generated with the given prefix, and
+// their stock ledger and levels, and returns how many were deleted
+func (g *Generator) Clean(ctx context.Context, prefix string) (int64, error) {
+	if prefix == "" {
+		prefix = DefaultGeneratePrefix
+	}
+	result := g.db.WithContext(ctx).Exec("DELETE FROM products WHERE sku LIKE ?", prefix+"%")
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete generated products: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// withConn runs fn on a pgx connection from the primary's pool, for the
+// COPY protocol database/sql does not expose
+func (g *Generator) withConn(ctx context.Context, fn func(conn *pgx.Conn) error) error {
+	sqlDB, err := g.db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get underlying sql.DB: %w", err)
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %w", err)
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		pgxConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("database driver %T does not support COPY", driverConn)
+		}
+		return fn(pgxConn.Conn())
+	})
+}
+
+// copyBatch copies products, their opening stock movements and their stock
+// levels in one transaction
+func copyBatch(ctx context.Context, conn *pgx.Conn, products, movements, levels [][]any) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"products"}, productColumns, pgx.CopyFromRows(products)); err != nil {
+		return fmt.Errorf("failed to copy products: %w", err)
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"inventory_movements"}, movementColumns, pgx.CopyFromRows(movements)); err != nil {
+		return fmt.Errorf("failed to copy stock movements: %w", err)
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"stock_levels"}, levelColumns, pgx.CopyFromRows(levels)); err != nil {
+		return fmt.Errorf("failed to copy stock levels: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit products: %w", err)
+	}
+	return nil
+}
+
+// catalogGenerator makes the rows of a generated catalog
+type catalogGenerator struct {
+	rng    *rand.Rand
+	prefix string
+	now    time.Time
+
+	leaves      []category
+	categoryIDs map[string]uuid.UUID
+	brandIDs    []uuid.UUID
+	brandOrder  []int // brand indexes, most popular first
+	warehouseID uuid.UUID
+	categories  *rand.Zipf
+	brands      *rand.Zipf
+}
+
+// product returns the row of the nth product, and of its opening stock
+// movement and stock level, which are nil when it has no stock
+func (c *catalogGenerator) product(n int) ([]any, []any, []any) {
+	rng := c.rng
+	leaf := c.leaves[c.categories.Uint64()]
+	brandIndex := c.brandOrder[c.brands.Uint64()]
+	brandName := brands[brandIndex].name
+
+	noun := pick(rng, leaf.nouns)
+	adjective := pick(rng, adjectives)
+	color := pick(rng, colors)
+	name := fmt.Sprintf("%s %s %s %s", brandName, adjective, noun, color)
+	sku := fmt.Sprintf("%s%08d", c.prefix, n)
+	order := rng.Perm(len(features))
+	description := fmt.Sprintf("The %s %s from %s in %s, %s and %s.",
+		adjective, noun, brandName, color, features[order[0]], features[order[1]])
+
+	// Roughly one product in twenty is out of stock, and a few carry deep
+	// stock
+	stock := 0
+	switch roll := rng.IntN(100); {
+	case roll < 5:
+	case roll < 95:
+		stock = rng.IntN(200) + 1
+	default:
+		stock = rng.IntN(10000) + 200
+	}
+
+	// A few products are drafts, archived or unavailable
+	status := domain.ProductStatusPublished
+	switch roll := rng.IntN(100); {
+	case roll < 2:
+		status = domain.ProductStatusDraft
+	case roll < 3:
+		status = domain.ProductStatusArchived
+	}
+	active := rng.IntN(50) > 0
+
+	createdAt := c.now.Add(-time.Duration(rng.Int64N(int64(generateAge)))).Truncate(time.Microsecond)
+	updatedAt := createdAt.Add(time.Duration(rng.Int64N(int64(c.now.Sub(createdAt)) + 1))).Truncate(time.Microsecond)
+	var publishedAt pgtype.Timestamptz
+	if status != domain.ProductStatusDraft {
+		publishedAt = pgtype.Timestamptz{Time: createdAt, Valid: true}
+	}
+
+	id := uuid.New()
+	product := []any{
+		pgtype.UUID{Bytes: id, Valid: true},
+		name,
+		domain.Slugify(fmt.Sprintf("%s %s", name, sku)),
+		description,
+		description, // plain text, so it is its own text
+		c.price(leaf),
+		pgtype.UUID{Bytes: c.categoryIDs[leaf.name], Valid: true},
+		pgtype.UUID{Bytes: c.brandIDs[brandIndex], Valid: true},
+		stock,
+		fmt.Sprintf("https://picsum.photos/seed/%s/600/600", sku),
+		sku,
+		active,
+		status,
+		publishedAt,
+		createdAt,
+		updatedAt,
+	}
+	if stock == 0 {
+		return product, nil, nil
+	}
+
+	warehouse := pgtype.UUID{Bytes: c.warehouseID, Valid: true}
+	movement := []any{
+		pgtype.UUID{Bytes: uuid.New(), Valid: true},
+		pgtype.UUID{Bytes: id, Valid: true},
+		warehouse,
+		stock,
+		stock,
+		domain.StockReasonImport,
+		"generate",
+		createdAt,
+	}
+	level := []any{
+		pgtype.UUID{Bytes: id, Valid: true},
+		warehouse,
+		stock,
+		createdAt,
+	}
+	return product, movement, level
+}
+
+// price draws a price from a category's range. Prices are spread evenly
+// over orders of magnitude, so cheap products outnumber expensive ones,
+// and end the way shop prices do.
+func (c *catalogGenerator) price(leaf category) float64 {
+	low, high := math.Log(leaf.minPrice), math.Log(leaf.maxPrice)
+	price := math.Floor(math.Exp(low + (high-low)*c.rng.Float64()))
+
+	switch roll := c.rng.IntN(10); {
+	case roll < 6:
+		return price + 0.99
+	case roll < 8:
+		return price + 0.95
+	case roll < 9:
+		return price + 0.49
+	default:
+		return max(price, 1)
+	}
+}
@@ -0,0 +1,223 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/internal/product/repository"
+	"ecommerce/pkg/errors"
+)
+
+// batchSize is how many products are written per statement
+const batchSize = 500
+
+// skuPrefix marks seeded products
+const skuPrefix = "SEED-"
+
+// Options controls a seeding run
+type Options struct {
+	Products int    // how many products to generate
+	Seed     uint64 // random seed; the same seed generates the same catalog
+	Upsert   bool   // overwrite seeded products that already exist instead of skipping them
+}
+
+// Result reports what a seeding run wrote
+type Result struct {
+	Categories int `json:"categories"`
+	Brands     int `json:"brands"`
+	Products   int `json:"products"`
+	Skipped    int `json:"skipped"`
+}
+
+// Seeder fills the catalog with generated data for local development and
+// integration tests. Products are keyed by SEED- SKUs, so runs with the
+// same seed are idempotent. Seeding writes straight to the database and
+// publishes no events, so a search index must be rebuilt separately.
+type Seeder struct {
+	repo   repository.ProductRepository
+	logger *logrus.Logger
+}
+
+// New creates a new seeder
+func New(repo repository.ProductRepository, logger *logrus.Logger) *Seeder {
+	return &Seeder{repo: repo, logger: logger}
+}
+
+// Run seeds categories, brands and products. Existing categories and brands
+// are reused by name.
+func (s *Seeder) Run(ctx context.Context, opts Options) (*Result, error) {
+	result := &Result{}
+	rng := rand.New(rand.NewPCG(opts.Seed, opts.Seed))
+
+	categoryIDs, created, err := s.seedCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result.Categories = created
+
+	brandIDs, created, err := s.seedBrands(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result.Brands = created
+
+	// Only leaf categories, the ones with nouns, get products
+	var leaves []category
+	for _, c := range categories {
+		if len(c.nouns) > 0 {
+			leaves = append(leaves, c)
+		}
+	}
+
+	for start := 0; start < opts.Products; start += batchSize {
+		end := min(start+batchSize, opts.Products)
+
+		batch := make([]domain.Product, 0, end-start)
+		skus := make([]string, 0, end-start)
+		for n := start; n < end; n++ {
+			product := generateProduct(rng, n+1, leaves, categoryIDs, brandIDs)
+			batch = append(batch, product)
+			skus = append(skus, product.SKU)
+		}
+
+		if !opts.Upsert {
+			existing, err := s.repo.ExistingSKUs(ctx, skus)
+			if err != nil {
+				return nil, err
+			}
+			fresh := batch[:0]
+			for _, product := range batch {
+				if !existing[product.SKU] {
+					fresh = append(fresh, product)
+				}
+			}
+			result.Skipped += len(batch) - len(fresh)
+			batch = fresh
+		}
+
+		movement := domain.StockMovement{Reason: domain.StockReasonImport, Reference: "seed"}
+		if err := s.repo.UpsertBatch(ctx, batch, movement); err != nil {
+			return nil, err
+		}
+		result.Products += len(batch)
+
+		s.logger.WithField("count", end).Info("Seeded products")
+	}
+
+	return result, nil
+}
+
+func (s *Seeder) seedCategories(ctx context.Context) (map[string]uuid.UUID, int, error) {
+	ids := make(map[string]uuid.UUID, len(categories))
+	created := 0
+
+	for _, c := range categories {
+		existing, err := s.repo.GetCategoryByName(ctx, c.name)
+		if err == nil {
+			ids[c.name] = existing.ID
+			continue
+		}
+		if !errors.IsNotFound(err) {
+			return nil, 0, err
+		}
+
+		category := &domain.Category{
+			Name:        c.name,
+			Slug:        domain.Slugify(c.name),
+			Description: c.description,
+			IsActive:    true,
+		}
+		if c.parent != "" {
+			parentID := ids[c.parent]
+			category.ParentID = &parentID
+		}
+		if err := s.repo.CreateCategory(ctx, category); err != nil {
+			return nil, 0, err
+		}
+		ids[c.name] = category.ID
+		created++
+	}
+
+	return ids, created, nil
+}
+
+func (s *Seeder) seedBrands(ctx context.Context) ([]uuid.UUID, int, error) {
+	ids := make([]uuid.UUID, 0, len(brands))
+	created := 0
+
+	for _, b := range brands {
+		existing, err := s.repo.GetBrandByName(ctx, b.name)
+		if err == nil {
+			ids = append(ids, existing.ID)
+			continue
+		}
+		if !errors.IsNotFound(err) {
+			return nil, 0, err
+		}
+
+		brand := &domain.Brand{
+			Name:        b.name,
+			Description: b.description,
+			IsActive:    true,
+		}
+		if err := s.repo.CreateBrand(ctx, brand); err != nil {
+			return nil, 0, err
+		}
+		ids = append(ids, brand.ID)
+		created++
+	}
+
+	return ids, created, nil
+}
+
+// generateProduct makes the nth product in one of the leaf categories
+func generateProduct(rng *rand.Rand, n int, leaves []category, categoryIDs map[string]uuid.UUID, brandIDs []uuid.UUID) domain.Product {
+	c := leaves[rng.IntN(len(leaves))]
+	noun := pick(rng, c.nouns)
+	adjective := pick(rng, adjectives)
+	color := pick(rng, colors)
+	brandIndex := rng.IntN(len(brands))
+	brandID := brandIDs[brandIndex]
+
+	name := fmt.Sprintf("%s %s %s %s", brands[brandIndex].name, adjective, noun, color)
+	sku := fmt.Sprintf("%s%06d", skuPrefix, n)
+
+	// Prices cluster towards the low end of the range and end in .99
+	spread := c.maxPrice - c.minPrice
+	price := math.Floor(c.minPrice+spread*rng.Float64()*rng.Float64()) + 0.99
+
+	// Roughly one product in twenty is out of stock
+	stock := 0
+	if rng.IntN(20) > 0 {
+		stock = rng.IntN(500) + 1
+	}
+
+	// Two different selling points for the description
+	order := rng.Perm(len(features))
+
+	product := domain.Product{
+		Name:       name,
+		Slug:       domain.Slugify(fmt.Sprintf("%s %s", name, sku)),
+		Price:      price,
+		CategoryID: categoryIDs[c.name],
+		BrandID:    &brandID,
+		Stock:      stock,
+		ImageURL:   fmt.Sprintf("https://picsum.photos/seed/%s/600/600", sku),
+		SKU:        sku,
+		IsActive:   true,
+		Status:     domain.ProductStatusPublished,
+	}
+	product.SetDescription(fmt.Sprintf("The %s %s from %s in %s, %s and %s.",
+		adjective, noun, brands[brandIndex].name, color, features[order[0]], features[order[1]]))
+	return product
+}
+
+func pick(rng *rand.Rand, values []string) string {
+	return values[rng.IntN(len(values))]
+}
@@ -0,0 +1,231 @@
+package service_test
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/product/config"
+	"ecommerce/internal/product/domain"
+	"ecommerce/internal/product/repository/memory"
+	"ecommerce/internal/product/search"
+	"ecommerce/internal/product/service"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/events"
+)
+
+// discard drops published events
+type discard struct{}
+
+func (discard) Publish(ctx context.Context, event events.Event) error { return nil }
+
+// newService returns a product service over an in-memory repository
+func newService(t *testing.T) (service.ProductService, *memory.ProductRepository) {
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	repo := memory.NewProductRepository()
+	return service.NewProductService(repo, repo, search.NewPostgresSearcher(repo), discard{}, nil, nil, nil, nil, cfg.Stock, cfg.Sale, cfg.Publish, cfg.FlashSale, cfg.Locale, cfg.Media, logger), repo
+}
+
+// expectForbidden makes each change as a customer and as an anonymous
+// caller and expects every one to be refused
+func expectForbidden(t *testing.T, changes map[string]func(ctx context.Context) error) {
+	callers := map[string]context.Context{
+		"customer":  auth.WithActor(context.Background(), &auth.Actor{ID: "customer-1", Role: "customer"}),
+		"anonymous": context.Background(),
+	}
+	for caller, ctx := range callers {
+		for change, fn := range changes {
+			t.Run(caller+"/"+change, func(t *testing.T) {
+				if err := fn(ctx); !errors.IsForbidden(err) {
+					t.Fatalf("expected forbidden, got %v", err)
+				}
+			})
+		}
+	}
+}
+
+// TestCatalogChangesRequireAdmin checks that callers who are neither
+// admins nor the product's vendor cannot change the catalog
+func TestCatalogChangesRequireAdmin(t *testing.T) {
+	s, repo := newService(t)
+
+	category := &domain.Category{Name: "Authorization", Slug: "authorization"}
+	if err := repo.CreateCategory(context.Background(), category); err != nil {
+		t.Fatal(err)
+	}
+	admin := auth.WithActor(context.Background(), &auth.Actor{ID: "admin-1", Role: auth.RoleAdmin})
+	product, err := s.CreateProduct(admin, &domain.CreateProductRequest{Name: "Lamp", Price: 20, CategoryID: category.ID, Stock: 5, SKU: "AUTH-LAMP"})
+	if err != nil {
+		t.Fatalf("CreateProduct as admin: %v", err)
+	}
+
+	name := "Renamed"
+	changes := map[string]func(ctx context.Context) error{
+		"create": func(ctx context.Context) error {
+			_, err := s.CreateProduct(ctx, &domain.CreateProductRequest{Name: "Chair", Price: 20, CategoryID: category.ID, SKU: "AUTH-CHAIR"})
+			return err
+		},
+		"upsert": func(ctx context.Context) error {
+			_, _, err := s.UpsertProductBySKU(ctx, product.SKU, &domain.CreateProductRequest{Name: "Lamp", Price: 1, CategoryID: category.ID})
+			return err
+		},
+		"update": func(ctx context.Context) error {
+			_, err := s.UpdateProduct(ctx, product.ID, &domain.UpdateProductRequest{Name: &name})
+			return err
+		},
+		"patch": func(ctx context.Context) error {
+			_, err := s.PatchProduct(ctx, product.ID, []byte(`{"price": 1}`))
+			return err
+		},
+		"delete": func(ctx context.Context) error {
+			return s.DeleteProduct(ctx, product.ID)
+		},
+		"duplicate": func(ctx context.Context) error {
+			_, err := s.DuplicateProduct(ctx, product.ID, &domain.DuplicateProductRequest{})
+			return err
+		},
+		"unpublish": func(ctx context.Context) error {
+			_, err := s.UnpublishProduct(ctx, product.ID)
+			return err
+		},
+	}
+
+	expectForbidden(t, changes)
+
+	unchanged, err := s.GetProduct(admin, product.ID)
+	if err != nil {
+		t.Fatalf("GetProduct: %v", err)
+	}
+	if unchanged.Name != product.Name || unchanged.Price != product.Price {
+		t.Fatalf("product changed to %q at %v", unchanged.Name, unchanged.Price)
+	}
+}
+
+// TestBrandChangesRequireAdmin checks that only admins manage brands
+func TestBrandChangesRequireAdmin(t *testing.T) {
+	s, _ := newService(t)
+
+	admin := auth.WithActor(context.Background(), &auth.Actor{ID: "admin-1", Role: auth.RoleAdmin})
+	brand, err := s.CreateBrand(admin, &domain.CreateBrandRequest{Name: "Acme"})
+	if err != nil {
+		t.Fatalf("CreateBrand as admin: %v", err)
+	}
+
+	name := "Renamed"
+	expectForbidden(t, map[string]func(ctx context.Context) error{
+		"create": func(ctx context.Context) error {
+			_, err := s.CreateBrand(ctx, &domain.CreateBrandRequest{Name: "Globex"})
+			return err
+		},
+		"update": func(ctx context.Context) error {
+			_, err := s.UpdateBrand(ctx, brand.ID, &domain.UpdateBrandRequest{Name: &name})
+			return err
+		},
+		"delete": func(ctx context.Context) error {
+			return s.DeleteBrand(ctx, brand.ID)
+		},
+	})
+
+	unchanged, err := s.GetBrand(admin, brand.ID)
+	if err != nil {
+		t.Fatalf("GetBrand: %v", err)
+	}
+	if unchanged.Name != brand.Name {
+		t.Fatalf("brand renamed to %q", unchanged.Name)
+	}
+}
+
+// TestAttributeDefinitionChangesRequireAdmin checks that only admins manage
+// the attributes a category's products carry
+func TestAttributeDefinitionChangesRequireAdmin(t *testing.T) {
+	s, repo := newService(t)
+
+	category := &domain.Category{Name: "Attributes", Slug: "attributes"}
+	if err := repo.CreateCategory(context.Background(), category); err != nil {
+		t.Fatal(err)
+	}
+	admin := auth.WithActor(context.Background(), &auth.Actor{ID: "admin-1", Role: auth.RoleAdmin})
+	def, err := s.CreateAttributeDefinition(admin, category.ID, &domain.CreateAttributeDefinitionRequest{Key: "color", Name: "Color", Type: "string"})
+	if err != nil {
+		t.Fatalf("CreateAttributeDefinition as admin: %v", err)
+	}
+
+	name := "Renamed"
+	expectForbidden(t, map[string]func(ctx context.Context) error{
+		"create": func(ctx context.Context) error {
+			_, err := s.CreateAttributeDefinition(ctx, category.ID, &domain.CreateAttributeDefinitionRequest{Key: "size", Name: "Size", Type: "string"})
+			return err
+		},
+		"update": func(ctx context.Context) error {
+			_, err := s.UpdateAttributeDefinition(ctx, def.ID, &domain.UpdateAttributeDefinitionRequest{Name: &name})
+			return err
+		},
+		"delete": func(ctx context.Context) error {
+			return s.DeleteAttributeDefinition(ctx, def.ID)
+		},
+	})
+
+	defs, err := s.ListAttributeDefinitions(admin, category.ID)
+	if err != nil {
+		t.Fatalf("ListAttributeDefinitions: %v", err)
+	}
+	if len(defs) != 1 || defs[0].Name != def.Name {
+		t.Fatalf("attribute definitions changed to %+v", defs)
+	}
+}
+
+// TestCategoryChangesRequireAdmin checks that only admins change the
+// category tree
+func TestCategoryChangesRequireAdmin(t *testing.T) {
+	s, _ := newService(t)
+
+	admin := auth.WithActor(context.Background(), &auth.Actor{ID: "admin-1", Role: auth.RoleAdmin})
+	category, err := s.CreateCategory(admin, &domain.CreateCategoryRequest{Name: "Lighting"})
+	if err != nil {
+		t.Fatalf("CreateCategory as admin: %v", err)
+	}
+	other, err := s.CreateCategory(admin, &domain.CreateCategoryRequest{Name: "Furniture"})
+	if err != nil {
+		t.Fatalf("CreateCategory as admin: %v", err)
+	}
+
+	name := "Renamed"
+	expectForbidden(t, map[string]func(ctx context.Context) error{
+		"create": func(ctx context.Context) error {
+			_, err := s.CreateCategory(ctx, &domain.CreateCategoryRequest{Name: "Garden"})
+			return err
+		},
+		"update": func(ctx context.Context) error {
+			_, err := s.UpdateCategory(ctx, category.ID, &domain.UpdateCategoryRequest{Name: &name})
+			return err
+		},
+		"delete": func(ctx context.Context) error {
+			return s.DeleteCategory(ctx, category.ID)
+		},
+		"move": func(ctx context.Context) error {
+			_, err := s.MoveCategory(ctx, category.ID, &domain.MoveCategoryRequest{ParentID: &other.ID})
+			return err
+		},
+		"merge": func(ctx context.Context) error {
+			_, err := s.MergeCategory(ctx, category.ID, &domain.MergeCategoryRequest{TargetID: other.ID})
+			return err
+		},
+	})
+
+	unchanged, err := s.GetCategory(admin, category.ID)
+	if err != nil {
+		t.Fatalf("GetCategory: %v", err)
+	}
+	if unchanged.Name != category.Name || unchanged.ParentID != nil {
+		t.Fatalf("category changed to %q under %v", unchanged.Name, unchanged.ParentID)
+	}
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+)
+
+// BulkUpdateProducts applies many product changes in one transaction and
+// reports the outcome of each operation. Operations that cannot be applied
+// fail on their own without holding back the rest.
+func (s *productService) BulkUpdateProducts(ctx context.Context, req *domain.BulkUpdateRequest) (*domain.BulkUpdateResult, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Bulk updates require the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid bulk update request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	results := make([]domain.BulkOperationResult, len(req.Operations))
+	fail := func(i int, err error) {
+		results[i].Code = errors.Code(err)
+		results[i].Error = errors.Message(err)
+	}
+
+	ids := make([]uuid.UUID, len(req.Operations))
+	for i, op := range req.Operations {
+		ids[i] = op.ProductID
+	}
+	stored, err := s.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to get products for bulk update")
+		return nil, errors.NewInternalError("Failed to get products", err)
+	}
+
+	// Operations on the same product build on each other, so they are
+	// prepared against a working copy that starts from the stored product
+	originals := make(map[uuid.UUID]domain.Product)
+	working := make(map[uuid.UUID]*domain.Product)
+	categories := make(map[uuid.UUID]error)
+
+	var changes []domain.ProductChange
+	var indexes []int
+	for i, op := range req.Operations {
+		results[i] = domain.BulkOperationResult{Index: i, ProductID: op.ProductID, Action: op.Action}
+
+		product, ok := working[op.ProductID]
+		if !ok {
+			product, ok = stored[op.ProductID]
+			if !ok {
+				fail(i, errors.NewNotFoundError("Product not found", nil).WithCode(errors.CodeProductNotFound))
+				continue
+			}
+			originals[op.ProductID] = *product
+			working[op.ProductID] = product
+		}
+
+		change, err := s.prepareBulkOperation(ctx, op, product, categories)
+		if err != nil {
+			fail(i, err)
+			continue
+		}
+		if len(change.Columns) == 0 && !change.ReplaceAttributes {
+			results[i].Success = true // already in the requested state
+			continue
+		}
+		changes = append(changes, change)
+		indexes = append(indexes, i)
+	}
+
+	var outcomes []error
+	if len(changes) > 0 {
+		outcomes, err = s.repo.BulkUpdate(ctx, changes)
+		if err != nil {
+			s.log(ctx).WithError(err).Error("Failed to apply bulk update")
+			return nil, errors.NewInternalError("Failed to apply bulk update", err)
+		}
+	}
+
+	updated := make(map[uuid.UUID]bool)
+	for j, outcome := range outcomes {
+		i := indexes[j]
+		if outcome != nil {
+			fail(i, outcome)
+			continue
+		}
+		results[i].Success = true
+		updated[changes[j].ProductID] = true
+	}
+
+	result := &domain.BulkUpdateResult{Results: results}
+	for _, r := range results {
+		if r.Success {
+			result.Succeeded++
+		} else {
+			result.Failed++
+		}
+	}
+	if len(updated) == 0 {
+		return result, nil
+	}
+
+	// Invalidate cache
+	if err := s.repo.InvalidateProductCache(ctx); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to invalidate product cache")
+	}
+
+	updatedIDs := make([]uuid.UUID, 0, len(updated))
+	for id := range updated {
+		updatedIDs = append(updatedIDs, id)
+	}
+	products, err := s.repo.GetByIDs(ctx, updatedIDs)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to get updated products")
+	}
+	for id := range updated {
+		before := originals[id]
+		product, ok := products[id]
+		if !ok {
+			continue
+		}
+		s.audit(ctx, domain.AuditEntityProduct, id, domain.AuditActionUpdate, &before, product)
+		s.publish(ctx, domain.EventProductUpdated, product)
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"succeeded": result.Succeeded,
+		"failed":    result.Failed,
+	}).Info("Bulk update applied successfully")
+	return result, nil
+}
+
+// prepareBulkOperation applies an operation to the working copy of a
+// product and returns the change to store. Categories are looked up once
+// per request; known holds the outcome of each lookup.
+func (s *productService) prepareBulkOperation(ctx context.Context, op domain.BulkOperation, product *domain.Product, known map[uuid.UUID]error) (domain.ProductChange, error) {
+	change := domain.ProductChange{ProductID: product.ID, Columns: map[string]interface{}{}}
+
+	switch op.Action {
+	case domain.BulkActionSetPrice:
+		if op.Price == nil {
+			return change, errors.NewValidationError("Price is required to set the price", nil)
+		}
+		previous := product.Price
+		product.Price = *op.Price
+		if err := product.ValidateSale(); err != nil {
+			product.Price = previous
+			return change, errors.NewValidationError("Invalid sale", err)
+		}
+		change.Columns["price"] = product.Price
+
+	case domain.BulkActionActivate, domain.BulkActionDeactivate:
+		product.IsActive = op.Action == domain.BulkActionActivate
+		change.Columns["is_active"] = product.IsActive
+
+	case domain.BulkActionMoveCategory:
+		if op.CategoryID == nil {
+			return change, errors.NewValidationError("Category ID is required to move a product", nil)
+		}
+		categoryErr, ok := known[*op.CategoryID]
+		if !ok {
+			if _, err := s.repo.GetCategory(ctx, *op.CategoryID); err != nil {
+				categoryErr = errors.NewInternalError("Failed to verify category", err)
+				if errors.IsNotFound(err) {
+					categoryErr = errors.NewNotFoundError("Category not found", err).WithCode(errors.CodeCategoryNotFound)
+				}
+			}
+			known[*op.CategoryID] = categoryErr
+		}
+		if categoryErr != nil {
+			return change, categoryErr
+		}
+		if *op.CategoryID == product.CategoryID {
+			return change, nil
+		}
+
+		defs, err := s.repo.ListAttributeDefinitions(ctx, *op.CategoryID)
+		if err != nil {
+			return change, errors.NewInternalError("Failed to validate attributes", err)
+		}
+		attributes, err := domain.ValidateAttributes(defs, carryOverAttributes(defs, product.Attributes))
+		if err != nil {
+			return change, errors.NewValidationError("Invalid attributes", err)
+		}
+
+		product.CategoryID = *op.CategoryID
+		product.Category = nil
+		product.Attributes = attributes
+		change.Columns["category_id"] = product.CategoryID
+		change.ReplaceAttributes = true
+		change.Attributes = attributes
+	}
+
+	return change, nil
+}
+
+// carryOverAttributes keeps the attribute values a category defines, for a
+// product moving into it
+func carryOverAttributes(defs []domain.AttributeDefinition, attributes []domain.ProductAttribute) map[string]interface{} {
+	values := make(map[string]interface{}, len(attributes))
+	for _, def := range defs {
+		for _, attribute := range attributes {
+			if attribute.Key == def.Key {
+				values[def.Key] = attribute.Value
+			}
+		}
+	}
+	return values
+}
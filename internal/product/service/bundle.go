@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+)
+
+// SetBundleComponents replaces the products a bundle is made up of. A
+// computed bundle is repriced from its new components.
+func (s *productService) SetBundleComponents(ctx context.Context, id uuid.UUID, req *domain.SetBundleComponentsRequest) (*domain.Product, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing bundles requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid bundle components request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	bundle, err := s.GetProduct(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if bundle.Type != domain.ProductTypeBundle {
+		return nil, errors.NewValidationError("Only bundles have components", nil)
+	}
+	before := *bundle
+
+	ids := make([]uuid.UUID, len(req.Components))
+	for i, component := range req.Components {
+		ids[i] = component.ProductID
+	}
+	products, err := s.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, errors.NewInternalError("Failed to get products", err)
+	}
+
+	components := make([]domain.BundleComponent, 0, len(req.Components))
+	seen := make(map[uuid.UUID]bool, len(req.Components))
+	for _, component := range req.Components {
+		product, ok := products[component.ProductID]
+		if !ok {
+			return nil, errors.NewValidationError(fmt.Sprintf("Product %s not found", component.ProductID), nil)
+		}
+		if product.Type == domain.ProductTypeBundle {
+			return nil, errors.NewValidationError("Bundles cannot contain other bundles", nil)
+		}
+		if product.Type == domain.ProductTypeGiftCard {
+			return nil, errors.NewValidationError("Bundles cannot contain gift cards", nil)
+		}
+		if seen[product.ID] {
+			return nil, errors.NewValidationError(fmt.Sprintf("Product %s is listed more than once", product.ID), nil)
+		}
+		seen[product.ID] = true
+		components = append(components, domain.BundleComponent{
+			ComponentID: product.ID,
+			Quantity:    component.Quantity,
+		})
+	}
+
+	if err := s.repo.ReplaceBundleComponents(ctx, id, components); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to set bundle components")
+		return nil, errors.NewInternalError("Failed to set bundle components", err)
+	}
+
+	// Invalidate cache
+	if err := s.repo.InvalidateProductCache(ctx); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to invalidate product cache")
+		return nil, errors.NewInternalError("Failed to invalidate cache", err)
+	}
+
+	s.repriceBundles(ctx, bundle)
+	s.addBundle(ctx, bundle)
+
+	s.publish(ctx, domain.EventProductUpdated, bundle)
+	s.audit(ctx, domain.AuditEntityProduct, bundle.ID, domain.AuditActionUpdate, &before, bundle)
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"product_id": id,
+		"components": len(components),
+	}).Info("Bundle components set successfully")
+	return bundle, nil
+}
+
+// validateBundlePricing checks that only bundles are priced as one
+func validateBundlePricing(product *domain.Product) error {
+	if product.Type == domain.ProductTypeBundle {
+		if product.BundlePricing == domain.BundlePricingFixed && product.BundleDiscount != 0 {
+			return errors.NewValidationError("Only computed bundles take a discount", nil)
+		}
+		return nil
+	}
+	if product.BundlePricing != "" || product.BundleDiscount != 0 {
+		return errors.NewValidationError("Only bundles have bundle pricing", nil)
+	}
+	return nil
+}
+
+// repriceBundles brings computed bundles up to date after a change to the
+// product, which is itself a bundle or may be part of some, and publishes
+// the bundles whose price changed. A failure is logged; the bundles keep
+// their old prices until they are next repriced.
+func (s *productService) repriceBundles(ctx context.Context, product *domain.Product) {
+	ids, err := s.repo.RepriceBundles(ctx, product.ID)
+	if err != nil {
+		s.log(ctx).WithError(err).WithField("product_id", product.ID).Error("Failed to reprice bundles")
+		return
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	bundles, err := s.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to get repriced bundles")
+		return
+	}
+	for _, id := range ids {
+		bundle, ok := bundles[id]
+		if !ok {
+			continue
+		}
+		if id == product.ID {
+			product.Price = bundle.Price
+			product.UpdatedAt = bundle.UpdatedAt
+			continue
+		}
+		s.publish(ctx, domain.EventProductUpdated, bundle)
+	}
+}
+
+// addBundle fills in a bundle's components and how many of it their stock
+// makes up. A failure is logged and the bundle goes out without them.
+func (s *productService) addBundle(ctx context.Context, product *domain.Product) {
+	if product.Type != domain.ProductTypeBundle {
+		return
+	}
+
+	components, err := s.repo.ListBundleComponents(ctx, []uuid.UUID{product.ID})
+	if err != nil {
+		s.log(ctx).WithError(err).Warn("Failed to load bundle components")
+		return
+	}
+	ids := make([]uuid.UUID, len(components))
+	for i, component := range components {
+		ids[i] = component.ComponentID
+	}
+	products, err := s.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		s.log(ctx).WithError(err).Warn("Failed to load bundle components")
+		return
+	}
+
+	for i := range components {
+		if component, ok := products[components[i].ComponentID]; ok {
+			components[i].SKU = component.SKU
+			components[i].Name = component.Name
+			components[i].Stock = component.Stock
+		}
+	}
+	product.Components = components
+	if available, limited := domain.BundleAvailable(components, products, time.Now()); limited {
+		product.BundleStock = &available
+	}
+}
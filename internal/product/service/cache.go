@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/jobs"
+	"ecommerce/pkg/tenant"
+)
+
+// jobWarmCache is the type of the background jobs that warm the cache
+const jobWarmCache = "cache.warm"
+
+// operatesCache reports whether the caller may work on cache keys directly.
+// Keys span every tenant, so only admins of the default tenant, who run the
+// platform, may.
+func operatesCache(ctx context.Context) bool {
+	return auth.HasRole(ctx, auth.RoleAdmin) && tenant.ID(ctx) == tenant.Default
+}
+
+// InspectCacheEntry describes a cached key, for debugging stale data
+func (s *productService) InspectCacheEntry(ctx context.Context, key string) (*domain.CacheEntry, error) {
+	if !operatesCache(ctx) {
+		return nil, errors.NewForbiddenError("Inspecting the cache requires the admin role of the default tenant", nil)
+	}
+	if domain.CacheNamespace(key) == "" {
+		return nil, errors.NewValidationError("Cache key is not one of the product service's", nil)
+	}
+
+	entry, err := s.repo.InspectCacheKey(ctx, key)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to inspect cache key")
+		return nil, errors.NewInternalError("Failed to inspect cache key", err)
+	}
+	return entry, nil
+}
+
+// ListCacheKeys lists cached keys matching a pattern, at most limit of them
+func (s *productService) ListCacheKeys(ctx context.Context, pattern string, limit int) (*domain.CacheKeyList, error) {
+	if !operatesCache(ctx) {
+		return nil, errors.NewForbiddenError("Inspecting the cache requires the admin role of the default tenant", nil)
+	}
+	if pattern == "" {
+		pattern = domain.CacheNamespaceProduct + "*"
+	}
+	if !domain.ValidCachePattern(pattern) {
+		return nil, errors.NewValidationError("Cache key pattern must start with "+domain.CacheNamespaceProduct, nil)
+	}
+
+	// Set default values
+	if limit <= 0 {
+		limit = 100
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+
+	keys, truncated, err := s.repo.ListCacheKeys(ctx, pattern, limit)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list cache keys")
+		return nil, errors.NewInternalError("Failed to list cache keys", err)
+	}
+	return &domain.CacheKeyList{Keys: keys, Truncated: truncated}, nil
+}
+
+// PurgeCache drops cached keys so the next read goes to the database
+func (s *productService) PurgeCache(ctx context.Context, req *domain.PurgeCacheRequest) (*domain.PurgeCacheResult, error) {
+	if !operatesCache(ctx) {
+		return nil, errors.NewForbiddenError("Purging the cache requires the admin role of the default tenant", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid purge cache request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+	if len(req.Keys) == 0 && req.Pattern == "" {
+		return nil, errors.NewValidationError("Give the keys or a pattern to purge", nil)
+	}
+	for _, key := range req.Keys {
+		if domain.CacheNamespace(key) == "" {
+			return nil, errors.NewValidationError("Cache key "+key+" is not one of the product service's", nil)
+		}
+	}
+	if req.Pattern != "" && !domain.ValidCachePattern(req.Pattern) {
+		return nil, errors.NewValidationError("Cache key pattern must start with "+domain.CacheNamespaceProduct, nil)
+	}
+
+	deleted, err := s.repo.PurgeCacheKeys(ctx, req.Keys, req.Pattern)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to purge cache")
+		return nil, errors.NewInternalError("Failed to purge cache", err)
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"keys":    len(req.Keys),
+		"pattern": req.Pattern,
+		"deleted": deleted,
+		"actor":   auth.ActorID(ctx),
+	}).Info("Cache purged successfully")
+	return &domain.PurgeCacheResult{Deleted: deleted}, nil
+}
+
+// WarmCache loads products into the cache ahead of expected traffic, such
+// as a flash sale
+func (s *productService) WarmCache(ctx context.Context, req *domain.WarmCacheRequest) (*domain.WarmCacheResult, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Warming the cache requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid warm cache request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	if req.Async {
+		payload := domain.WarmCacheRequest{ProductIDs: req.ProductIDs}
+		if _, err := s.jobs.Enqueue(ctx, jobWarmCache, payload, jobs.Options{}); err != nil {
+			s.log(ctx).WithError(err).Error("Failed to queue cache warming")
+			return nil, errors.NewInternalError("Failed to queue cache warming", err)
+		}
+		s.log(ctx).WithField("products", len(req.ProductIDs)).Info("Cache warming queued")
+		return &domain.WarmCacheResult{Requested: len(req.ProductIDs), Queued: true}, nil
+	}
+
+	products, err := s.repo.GetByIDs(ctx, req.ProductIDs)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to warm cache")
+		return nil, errors.NewInternalError("Failed to warm cache", err)
+	}
+
+	s.log(ctx).WithField("products", len(products)).Info("Cache warmed successfully")
+	return &domain.WarmCacheResult{Requested: len(req.ProductIDs), Cached: len(products)}, nil
+}
+
+// WarmCatalog loads the first pages of the storefront listing, and the
+// products on them, into the cache ahead of traffic, and reports how many
+// products were loaded
+func (s *productService) WarmCatalog(ctx context.Context, pages int) (int, error) {
+	warmed := 0
+	offset := 0
+	for page := 0; page < pages; page++ {
+		list, err := s.ListProducts(ctx, &domain.ProductFilters{Offset: offset})
+		if err != nil {
+			return warmed, err
+		}
+
+		ids := make([]uuid.UUID, len(list.Products))
+		for i := range list.Products {
+			ids[i] = list.Products[i].ID
+		}
+		if len(ids) > 0 {
+			products, err := s.repo.GetByIDs(ctx, ids)
+			if err != nil {
+				return warmed, errors.NewInternalError("Failed to warm cache", err)
+			}
+			warmed += len(products)
+		}
+
+		if !list.HasMore {
+			break
+		}
+		offset += list.Limit
+	}
+	return warmed, nil
+}
+
+// RegisterJobs has a worker pool run the service's background jobs
+func (s *productService) RegisterJobs(worker *jobs.Worker) {
+	worker.Handle(jobWarmCache, s.warmCacheJob)
+}
+
+// warmCacheJob loads the products of a queued warm cache request into the
+// cache
+func (s *productService) warmCacheJob(ctx context.Context, job *jobs.Job) error {
+	var req domain.WarmCacheRequest
+	if err := job.Decode(&req); err != nil {
+		return jobs.Permanent(fmt.Errorf("invalid cache warming payload: %w", err))
+	}
+
+	products, err := s.repo.GetByIDs(ctx, req.ProductIDs)
+	if err != nil {
+		return fmt.Errorf("failed to warm cache: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"job_id":   job.ID,
+		"products": len(products),
+	}).Info("Cache warmed successfully")
+	return nil
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+)
+
+// MoveCategory moves a category and its subtree under a new parent, or to
+// the top level
+func (s *productService) MoveCategory(ctx context.Context, id uuid.UUID, req *domain.MoveCategoryRequest) (*domain.Category, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Moving categories requires the admin role", nil)
+	}
+
+	category, err := s.GetCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	before := *category
+
+	if req.ParentID != nil {
+		if _, err := s.repo.GetCategory(ctx, *req.ParentID); err != nil {
+			if errors.IsNotFound(err) {
+				return nil, errors.NewNotFoundError("Parent category not found", err).WithCode(errors.CodeCategoryNotFound)
+			}
+			return nil, errors.NewInternalError("Failed to verify parent category", err)
+		}
+	}
+
+	if err := s.repo.MoveCategory(ctx, id, req.ParentID); err != nil {
+		if errors.IsValidation(err) || errors.IsNotFound(err) {
+			return nil, err
+		}
+		s.log(ctx).WithError(err).Error("Failed to move category")
+		return nil, errors.NewInternalError("Failed to move category", err)
+	}
+	category.ParentID = req.ParentID
+	category.Parent = nil
+
+	// Product counts roll up the tree, so the move changes them
+	if err := s.repo.InvalidateProductCache(ctx); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to invalidate product cache")
+	}
+	s.addCategoryBreadcrumbs(ctx, category)
+
+	s.publish(ctx, domain.EventCategoryUpdated, category)
+	s.audit(ctx, domain.AuditEntityCategory, id, domain.AuditActionUpdate, &before, category)
+
+	s.log(ctx).WithField("category_id", id).Info("Category moved successfully")
+	return category, nil
+}
+
+// MergeCategory merges a category into another: its products, subcategories
+// and slug move to the target and the category is deleted
+func (s *productService) MergeCategory(ctx context.Context, id uuid.UUID, req *domain.MergeCategoryRequest) (*domain.Category, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Merging categories requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid merge category request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+	if req.TargetID == id {
+		return nil, errors.NewValidationError("Cannot merge a category into itself", nil)
+	}
+
+	source, err := s.GetCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.GetCategory(ctx, req.TargetID); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Target category not found", err).WithCode(errors.CodeCategoryNotFound)
+		}
+		return nil, errors.NewInternalError("Failed to get target category", err)
+	}
+
+	moved, err := s.repo.MergeCategory(ctx, id, req.TargetID)
+	if err != nil {
+		if errors.IsValidation(err) {
+			return nil, err
+		}
+		s.log(ctx).WithError(err).Error("Failed to merge category")
+		return nil, errors.NewInternalError("Failed to merge category", err)
+	}
+
+	// Invalidate cache
+	if err := s.repo.InvalidateProductCache(ctx); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to invalidate product cache")
+	}
+
+	// Moved products are announced so search indexes pick up their category
+	products, err := s.repo.GetByIDs(ctx, moved)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to get moved products")
+	}
+	for _, productID := range moved {
+		product, ok := products[productID]
+		if !ok {
+			continue
+		}
+		s.publish(ctx, domain.EventProductUpdated, product)
+	}
+
+	s.publish(ctx, domain.EventCategoryDeleted, &domain.Category{ID: id})
+	s.audit(ctx, domain.AuditEntityCategory, id, domain.AuditActionDelete, source, nil)
+
+	target, err := s.GetCategory(ctx, req.TargetID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"category_id": id,
+		"target_id":   req.TargetID,
+		"products":    len(moved),
+	}).Info("Category merged successfully")
+	return target, nil
+}
+
+// addBreadcrumbs fills in the breadcrumbs of products, and of their
+// categories when loaded. A failure is logged and the products go out
+// without them.
+func (s *productService) addBreadcrumbs(ctx context.Context, products ...*domain.Product) {
+	ids := make([]uuid.UUID, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.CategoryID)
+	}
+
+	paths, err := s.repo.GetCategoryPaths(ctx, ids)
+	if err != nil {
+		s.log(ctx).WithError(err).Warn("Failed to load category breadcrumbs")
+		return
+	}
+	for _, product := range products {
+		product.Breadcrumbs = paths[product.CategoryID]
+		if product.Category != nil {
+			product.Category.Breadcrumbs = paths[product.CategoryID]
+		}
+	}
+}
+
+// addCategoryBreadcrumbs fills in the breadcrumbs of categories. A failure
+// is logged and the categories go out without them.
+func (s *productService) addCategoryBreadcrumbs(ctx context.Context, categories ...*domain.Category) {
+	ids := make([]uuid.UUID, 0, len(categories))
+	for _, category := range categories {
+		ids = append(ids, category.ID)
+	}
+
+	paths, err := s.repo.GetCategoryPaths(ctx, ids)
+	if err != nil {
+		s.log(ctx).WithError(err).Warn("Failed to load category breadcrumbs")
+		return
+	}
+	for _, category := range categories {
+		category.Breadcrumbs = paths[category.ID]
+	}
+}
+
+// addProductCounts fills in the product counts of categories, including
+// subcategories when the filters ask for it. A failure is logged and the
+// categories go out without them.
+func (s *productService) addProductCounts(ctx context.Context, filters *domain.CategoryFilters, categories ...*domain.Category) {
+	counts, err := s.repo.CategoryProductCounts(ctx)
+	if err != nil {
+		s.log(ctx).WithError(err).Warn("Failed to count category products")
+		return
+	}
+	for _, category := range categories {
+		count := counts[category.ID]
+		category.ProductCount = &count.Direct
+		if filters != nil && filters.IncludeDescendants {
+			category.TotalProductCount = &count.Total
+		}
+	}
+}
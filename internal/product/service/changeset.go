@@ -0,0 +1,519 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/database"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/mergepatch"
+	"ecommerce/pkg/tenant"
+)
+
+func (s *productService) CreateChangeset(ctx context.Context, req *domain.CreateChangesetRequest) (*domain.Changeset, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing changesets requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid create changeset request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	changeset := &domain.Changeset{
+		Name:        req.Name,
+		Description: req.Description,
+		Status:      domain.ChangesetStatusDraft,
+		CreatedBy:   auth.ActorID(ctx),
+	}
+	if err := s.repo.CreateChangeset(ctx, changeset); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to create changeset")
+		return nil, errors.NewInternalError("Failed to create changeset", err)
+	}
+
+	s.log(ctx).WithField("changeset_id", changeset.ID).Info("Changeset created successfully")
+	return changeset, nil
+}
+
+// GetChangeset returns a changeset with its edits
+func (s *productService) GetChangeset(ctx context.Context, id uuid.UUID) (*domain.Changeset, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Viewing changesets requires the admin role", nil)
+	}
+
+	changeset, err := s.repo.GetChangeset(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Changeset not found", err).WithCode(errors.CodeChangesetNotFound)
+		}
+		s.log(ctx).WithError(err).Error("Failed to get changeset")
+		return nil, errors.NewInternalError("Failed to get changeset", err)
+	}
+
+	return changeset, nil
+}
+
+func (s *productService) UpdateChangeset(ctx context.Context, id uuid.UUID, req *domain.UpdateChangesetRequest) (*domain.Changeset, error) {
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid update changeset request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	changeset, err := s.GetChangeset(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		changeset.Name = *req.Name
+	}
+	if req.Description != nil {
+		changeset.Description = *req.Description
+	}
+
+	if err := s.repo.UpdateChangeset(ctx, changeset); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to update changeset")
+		return nil, errors.NewInternalError("Failed to update changeset", err)
+	}
+
+	s.log(ctx).WithField("changeset_id", id).Info("Changeset updated successfully")
+	return changeset, nil
+}
+
+// DeleteChangeset deletes a changeset that is not live. An applied
+// changeset has to be rolled back first, as its edits are what undo it.
+func (s *productService) DeleteChangeset(ctx context.Context, id uuid.UUID) error {
+	changeset, err := s.GetChangeset(ctx, id)
+	if err != nil {
+		return err
+	}
+	if changeset.Status == domain.ChangesetStatusApplied {
+		return errors.NewConflictError("Applied changesets must be rolled back before they are deleted", nil).WithCode(errors.CodeChangesetInvalidState)
+	}
+
+	if err := s.repo.DeleteChangeset(ctx, id); err != nil {
+		if errors.IsNotFound(err) {
+			return err
+		}
+		s.log(ctx).WithError(err).Error("Failed to delete changeset")
+		return errors.NewInternalError("Failed to delete changeset", err)
+	}
+
+	s.log(ctx).WithField("changeset_id", id).Info("Changeset deleted successfully")
+	return nil
+}
+
+func (s *productService) ListChangesets(ctx context.Context, filters *domain.ChangesetFilters) (*domain.ChangesetList, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Viewing changesets requires the admin role", nil)
+	}
+
+	switch filters.Status {
+	case "", domain.ChangesetStatusDraft, domain.ChangesetStatusScheduled, domain.ChangesetStatusApplied,
+		domain.ChangesetStatusFailed, domain.ChangesetStatusRolledBack:
+	default:
+		return nil, errors.NewValidationError("Invalid status filter", nil)
+	}
+
+	// Set default values
+	if filters.Limit <= 0 {
+		filters.Limit = 20
+	}
+	if filters.Limit > 100 {
+		filters.Limit = 100
+	}
+
+	changesets, total, err := s.repo.ListChangesets(ctx, filters)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list changesets")
+		return nil, errors.NewInternalError("Failed to list changesets", err)
+	}
+
+	return &domain.ChangesetList{
+		Changesets: changesets,
+		Total:      total,
+		Limit:      filters.Limit,
+		Offset:     filters.Offset,
+		HasMore:    int64(filters.Offset+filters.Limit) < total,
+	}, nil
+}
+
+// SetChangesetItem sets the edit a changeset makes to a product. The patch
+// is checked against the product as it is now; the item comes back with
+// what it would change.
+func (s *productService) SetChangesetItem(ctx context.Context, id, productID uuid.UUID, req *domain.SetChangesetItemRequest) (*domain.ChangesetItem, error) {
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid set changeset item request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	changeset, err := s.editableChangeset(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.repo.GetByID(ctx, productID)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Product not found", err).WithCode(errors.CodeProductNotFound)
+		}
+		return nil, errors.NewInternalError("Failed to get product", err)
+	}
+	current, next, err := s.mergePatch(ctx, product, req.Patch)
+	if err != nil {
+		return nil, err
+	}
+
+	item := &domain.ChangesetItem{ChangesetID: changeset.ID, ProductID: productID, Patch: req.Patch}
+	if err := s.repo.SaveChangesetItem(ctx, item); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to save changeset item")
+		return nil, errors.NewInternalError("Failed to save changeset item", err)
+	}
+	item.Changes = domain.DiffFields(current, next)
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"changeset_id": id,
+		"product_id":   productID,
+	}).Info("Changeset item saved successfully")
+	return item, nil
+}
+
+// DeleteChangesetItem drops the edit a changeset makes to a product
+func (s *productService) DeleteChangesetItem(ctx context.Context, id, productID uuid.UUID) error {
+	if _, err := s.editableChangeset(ctx, id); err != nil {
+		return err
+	}
+
+	deleted, err := s.repo.DeleteChangesetItem(ctx, id, productID)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to delete changeset item")
+		return errors.NewInternalError("Failed to delete changeset item", err)
+	}
+	if !deleted {
+		return errors.NewNotFoundError("Changeset item not found", nil).WithCode(errors.CodeChangesetItemNotFound)
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"changeset_id": id,
+		"product_id":   productID,
+	}).Info("Changeset item deleted successfully")
+	return nil
+}
+
+// PreviewChangeset returns a changeset with what each of its edits would
+// change in the products as they are now, or why it can't be applied. For
+// an applied changeset it previews the rollback.
+func (s *productService) PreviewChangeset(ctx context.Context, id uuid.UUID) (*domain.Changeset, error) {
+	changeset, err := s.GetChangeset(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range changeset.Items {
+		item := &changeset.Items[i]
+		patch := item.Patch
+		if changeset.Status == domain.ChangesetStatusApplied {
+			patch = item.Revert
+		} else if !changeset.Editable() {
+			continue
+		}
+
+		product, err := s.repo.GetByID(ctx, item.ProductID)
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				return nil, errors.NewInternalError("Failed to get product", err)
+			}
+			item.Problem = "Product no longer exists"
+			continue
+		}
+		current, next, err := s.mergePatch(ctx, product, patch)
+		if err != nil {
+			item.Problem = errors.Message(err)
+			continue
+		}
+		item.Changes = domain.DiffFields(current, next)
+	}
+
+	return changeset, nil
+}
+
+// ApplyChangeset applies every edit of a changeset now, in one transaction
+func (s *productService) ApplyChangeset(ctx context.Context, id uuid.UUID) (*domain.Changeset, error) {
+	changeset, err := s.editableChangeset(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.applyChangeset(ctx, changeset); err != nil {
+		return nil, err
+	}
+	return changeset, nil
+}
+
+// ScheduleChangeset sets a changeset to be applied at a later time
+func (s *productService) ScheduleChangeset(ctx context.Context, id uuid.UUID, req *domain.ScheduleChangesetRequest) (*domain.Changeset, error) {
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid schedule changeset request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+	if !req.ApplyAt.After(time.Now()) {
+		return nil, errors.NewValidationError("Invalid apply time", fmt.Errorf("apply_at must be in the future"))
+	}
+
+	changeset, err := s.editableChangeset(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(changeset.Items) == 0 {
+		return nil, errors.NewValidationError("Changeset has no edits", nil)
+	}
+
+	from := changeset.Status
+	applyAt := req.ApplyAt
+	changeset.Status = domain.ChangesetStatusScheduled
+	changeset.ApplyAt = &applyAt
+	changeset.Error = ""
+	if err := s.transitionChangeset(ctx, changeset, from); err != nil {
+		return nil, err
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"changeset_id": id,
+		"apply_at":     applyAt,
+	}).Info("Changeset scheduled successfully")
+	return changeset, nil
+}
+
+// UnscheduleChangeset returns a scheduled changeset to draft
+func (s *productService) UnscheduleChangeset(ctx context.Context, id uuid.UUID) (*domain.Changeset, error) {
+	changeset, err := s.GetChangeset(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if changeset.Status != domain.ChangesetStatusScheduled {
+		return nil, errors.NewConflictError("Changeset is not scheduled", nil).WithCode(errors.CodeChangesetInvalidState)
+	}
+
+	changeset.Status = domain.ChangesetStatusDraft
+	changeset.ApplyAt = nil
+	if err := s.transitionChangeset(ctx, changeset, domain.ChangesetStatusScheduled); err != nil {
+		return nil, err
+	}
+
+	s.log(ctx).WithField("changeset_id", id).Info("Changeset unscheduled successfully")
+	return changeset, nil
+}
+
+// RollbackChangeset undoes every edit of an applied changeset, in one
+// transaction. The fields it changed go back to their values from before
+// it was applied, whatever they have been changed to since.
+func (s *productService) RollbackChangeset(ctx context.Context, id uuid.UUID) (*domain.Changeset, error) {
+	changeset, err := s.GetChangeset(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if changeset.Status != domain.ChangesetStatusApplied {
+		return nil, errors.NewConflictError("Only applied changesets can be rolled back", nil).WithCode(errors.CodeChangesetInvalidState)
+	}
+
+	txCtx, flush := s.deferEvents(ctx)
+	now := time.Now()
+	err = s.tx.WithinTransaction(txCtx, func(ctx context.Context) error {
+		changeset.Status = domain.ChangesetStatusRolledBack
+		changeset.RolledBackAt = &now
+		if err := s.transitionChangeset(ctx, changeset, domain.ChangesetStatusApplied); err != nil {
+			return err
+		}
+
+		for i := range changeset.Items {
+			item := &changeset.Items[i]
+			if _, err := s.patchChangesetProduct(ctx, item.ProductID, item.Revert); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.log(ctx).WithError(err).WithField("changeset_id", id).Error("Failed to roll back changeset")
+		return nil, err
+	}
+	flush()
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"changeset_id": id,
+		"products":     len(changeset.Items),
+	}).Info("Changeset rolled back successfully")
+	return changeset, nil
+}
+
+// ApplyDueChangesets applies the scheduled changesets whose time has come
+// and reports how many it applied. Those that fail are marked failed and
+// left for an admin to fix and schedule again.
+func (s *productService) ApplyDueChangesets(ctx context.Context) (int, error) {
+	due, err := s.repo.ListDueChangesets(ctx, s.publishing.CheckBatchSize)
+	if err != nil {
+		return 0, errors.NewInternalError("Failed to list scheduled changesets", err)
+	}
+
+	var applied int
+	for _, scheduled := range due {
+		// Each changeset is applied within its own tenant, as an admin
+		// there would apply it
+		scoped := ctx
+		if scheduled.TenantID != "" {
+			scoped = tenant.WithID(ctx, scheduled.TenantID)
+		}
+
+		changeset, err := s.repo.GetChangeset(scoped, scheduled.ID)
+		if err != nil {
+			s.log(ctx).WithError(err).WithField("changeset_id", scheduled.ID).Error("Failed to get scheduled changeset")
+			continue
+		}
+		if changeset.Status != domain.ChangesetStatusScheduled {
+			continue
+		}
+		if err := s.applyChangeset(scoped, changeset); err != nil {
+			continue
+		}
+		applied++
+	}
+
+	return applied, nil
+}
+
+// editableChangeset returns a changeset whose edits may still change
+func (s *productService) editableChangeset(ctx context.Context, id uuid.UUID) (*domain.Changeset, error) {
+	changeset, err := s.GetChangeset(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !changeset.Editable() {
+		return nil, errors.NewConflictError(fmt.Sprintf("Changeset is %s", changeset.Status), nil).WithCode(errors.CodeChangesetInvalidState)
+	}
+	return changeset, nil
+}
+
+// transitionChangeset moves a changeset on from the status it was read in,
+// failing when it has moved on since
+func (s *productService) transitionChangeset(ctx context.Context, changeset *domain.Changeset, from ...string) error {
+	moved, err := s.repo.TransitionChangeset(ctx, changeset, from...)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to update changeset status")
+		return errors.NewInternalError("Failed to update changeset", err)
+	}
+	if !moved {
+		return errors.NewConflictError("Changeset was changed by another request", nil).WithCode(errors.CodeChangesetInvalidState)
+	}
+	return nil
+}
+
+// applyChangeset applies the edits of a changeset in one transaction,
+// recording how to undo each, and marks it applied. When any edit cannot
+// be applied none are, and the changeset is marked failed with the reason.
+func (s *productService) applyChangeset(ctx context.Context, changeset *domain.Changeset) error {
+	if len(changeset.Items) == 0 {
+		return errors.NewValidationError("Changeset has no edits", nil)
+	}
+
+	from := changeset.Status
+	txCtx, flush := s.deferEvents(ctx)
+	now := time.Now()
+	err := s.tx.WithinTransaction(txCtx, func(ctx context.Context) error {
+		changeset.Status = domain.ChangesetStatusApplied
+		changeset.AppliedAt = &now
+		changeset.Error = ""
+		if err := s.transitionChangeset(ctx, changeset, from); err != nil {
+			return err
+		}
+
+		for i := range changeset.Items {
+			item := &changeset.Items[i]
+			revert, err := s.patchChangesetProduct(ctx, item.ProductID, item.Patch)
+			if err != nil {
+				return err
+			}
+			item.Revert = revert
+			if err := s.repo.SaveChangesetItem(ctx, item); err != nil {
+				return errors.NewInternalError("Failed to save changeset item", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		changeset.Status, changeset.AppliedAt = from, nil
+		for i := range changeset.Items {
+			changeset.Items[i].Revert = nil
+		}
+		s.log(ctx).WithError(err).WithField("changeset_id", changeset.ID).Error("Failed to apply changeset")
+
+		// Another request moved the changeset on first; it is theirs
+		if errors.Code(err) == errors.CodeChangesetInvalidState {
+			return err
+		}
+
+		changeset.Status = domain.ChangesetStatusFailed
+		changeset.Error = errors.Message(err)
+		if _, terr := s.repo.TransitionChangeset(ctx, changeset, from); terr != nil {
+			s.log(ctx).WithError(terr).WithField("changeset_id", changeset.ID).Error("Failed to mark changeset failed")
+		}
+		return err
+	}
+	flush()
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"changeset_id": changeset.ID,
+		"products":     len(changeset.Items),
+	}).Info("Changeset applied successfully")
+	return nil
+}
+
+// patchChangesetProduct applies a changeset's merge patch to a product and
+// returns the patch that undoes it. Failures name the product, so the
+// changeset's error says which edit stopped it.
+func (s *productService) patchChangesetProduct(ctx context.Context, productID uuid.UUID, patch json.RawMessage) (json.RawMessage, error) {
+	notApplicable := func(err error) error {
+		if errors.IsInternal(err) {
+			return err
+		}
+		message := fmt.Sprintf("Product %s cannot be changed: %s", productID, errors.Message(err))
+		return errors.NewConflictError(message, err).WithCode(errors.CodeChangesetNotApplicable)
+	}
+
+	product, err := s.repo.GetByID(database.WithPrimary(ctx), productID)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, notApplicable(err)
+		}
+		return nil, errors.NewInternalError("Failed to get product", err)
+	}
+
+	current, next, err := s.mergePatch(ctx, product, patch)
+	if err != nil {
+		return nil, notApplicable(err)
+	}
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return nil, errors.NewInternalError("Failed to encode product", err)
+	}
+	revert, err := mergepatch.Revert(doc, patch)
+	if err != nil {
+		return nil, notApplicable(errors.NewValidationError("Invalid merge patch", err))
+	}
+
+	update, clears := current.Changes(next)
+	if _, err := s.updateProduct(ctx, product, update, clears); err != nil {
+		return nil, notApplicable(err)
+	}
+	return revert, nil
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+)
+
+// SetProductChannels replaces the sales channel settings of a product: the
+// channels it is hidden from and its prices in each
+func (s *productService) SetProductChannels(ctx context.Context, id uuid.UUID, req *domain.SetProductChannelsRequest) (*domain.Product, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing sales channels requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid product channels request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+	channels, err := domain.ProductChannels(req.Channels)
+	if err != nil {
+		return nil, errors.NewValidationError("Invalid channels", err)
+	}
+
+	product, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Product not found", err).WithCode(errors.CodeProductNotFound)
+		}
+		return nil, errors.NewInternalError("Failed to get product", err)
+	}
+	before := *product
+
+	product.Channels = channels
+	if err := s.repo.Update(ctx, product); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to set product channels")
+		return nil, errors.NewInternalError("Failed to set product channels", err)
+	}
+
+	// Listings filtered by channel change with the product
+	if err := s.repo.InvalidateProductCache(ctx); err != nil {
+		s.log(ctx).WithError(err).Warn("Failed to invalidate product cache")
+	}
+
+	s.publish(ctx, domain.EventProductUpdated, product)
+	s.audit(ctx, domain.AuditEntityProduct, product.ID, domain.AuditActionUpdate, &before, product)
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"product_id": id,
+		"channels":   len(channels),
+	}).Info("Product channels set successfully")
+	return s.GetProduct(ctx, id)
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/events"
+	"ecommerce/pkg/storage"
+)
+
+// CreateDigitalAsset attaches a file already uploaded to the media bucket
+// to a digital product, for customers to download once they order it
+func (s *productService) CreateDigitalAsset(ctx context.Context, productID uuid.UUID, req *domain.CreateDigitalAssetRequest) (*domain.DigitalAsset, error) {
+	if !s.managesProduct(ctx, productID) {
+		return nil, errors.NewForbiddenError("Managing digital assets requires the admin role", nil)
+	}
+	if s.storage == nil {
+		return nil, errors.NewUnavailableError("Media storage is not configured", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid digital asset request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	product, err := s.GetProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product.Type != domain.ProductTypeDigital {
+		return nil, errors.NewValidationError("Only digital products have downloadable files", nil)
+	}
+
+	info, err := s.storage.Head(ctx, req.StorageKey)
+	if err != nil {
+		if err == storage.ErrNotFound {
+			return nil, errors.NewValidationError("File has not been uploaded", nil).WithCode(errors.CodeMediaNotUploaded)
+		}
+		s.log(ctx).WithError(err).Error("Failed to check digital asset")
+		return nil, errors.NewInternalError("Failed to check file", err)
+	}
+
+	filename := req.Filename
+	if filename == "" {
+		filename = path.Base(req.StorageKey)
+	}
+	asset := &domain.DigitalAsset{
+		ProductID:   productID,
+		StorageKey:  req.StorageKey,
+		Filename:    path.Base(strings.ReplaceAll(filename, "\\", "/")),
+		ContentType: info.ContentType,
+		Size:        info.Size,
+	}
+	if err := s.repo.CreateDigitalAsset(ctx, asset); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to create digital asset")
+		return nil, errors.NewInternalError("Failed to create digital asset", err)
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"product_id": productID,
+		"asset_id":   asset.ID,
+	}).Info("Digital asset created successfully")
+	return asset, nil
+}
+
+// ListDigitalAssets lists the files of a digital product
+func (s *productService) ListDigitalAssets(ctx context.Context, productID uuid.UUID) ([]domain.DigitalAsset, error) {
+	if !s.managesProduct(ctx, productID) {
+		return nil, errors.NewForbiddenError("Managing digital assets requires the admin role", nil)
+	}
+
+	if _, err := s.GetProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	assets, err := s.repo.ListDigitalAssets(ctx, productID)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list digital assets")
+		return nil, errors.NewInternalError("Failed to list digital assets", err)
+	}
+	return assets, nil
+}
+
+// DeleteDigitalAsset detaches a file from a digital product. The object is
+// left in the bucket, as it was uploaded outside the service and may be
+// shared with other products.
+func (s *productService) DeleteDigitalAsset(ctx context.Context, productID, assetID uuid.UUID) error {
+	if !s.managesProduct(ctx, productID) {
+		return errors.NewForbiddenError("Managing digital assets requires the admin role", nil)
+	}
+
+	asset, err := s.getDigitalAsset(ctx, productID, assetID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteDigitalAsset(ctx, asset.ID); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to delete digital asset")
+		return errors.NewInternalError("Failed to delete digital asset", err)
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"product_id": productID,
+		"asset_id":   assetID,
+	}).Info("Digital asset deleted successfully")
+	return nil
+}
+
+// getDigitalAsset returns a product's asset, treating one that belongs to
+// another product as missing
+func (s *productService) getDigitalAsset(ctx context.Context, productID, assetID uuid.UUID) (*domain.DigitalAsset, error) {
+	asset, err := s.repo.GetDigitalAsset(ctx, assetID)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, err
+		}
+		s.log(ctx).WithError(err).Error("Failed to get digital asset")
+		return nil, errors.NewInternalError("Failed to get digital asset", err)
+	}
+	if asset.ProductID != productID {
+		return nil, errors.NewNotFoundError("Digital asset not found", nil).WithCode(errors.CodeDigitalAssetNotFound)
+	}
+	return asset, nil
+}
+
+// HandleEvent handles an event forwarded by another service and reports
+// how many entitlements it granted. Each order the order service creates
+// is entitled to the downloads of the digital products in it. Events are
+// delivered at least once, so a repeated event grants nothing.
+func (s *productService) HandleEvent(ctx context.Context, event *events.Event) (int, error) {
+	if event.ID == "" || event.Type == "" {
+		return 0, errors.NewValidationError("Event ID and type are required", nil)
+	}
+	if event.Type != domain.EventOrderCreated {
+		return 0, nil
+	}
+
+	var order domain.OrderEvent
+	if err := event.Decode(&order); err != nil {
+		return 0, errors.NewValidationError("Invalid event payload", err)
+	}
+
+	ids := make([]uuid.UUID, len(order.Items))
+	for i, item := range order.Items {
+		ids[i] = item.ProductID
+	}
+	products, err := s.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to get ordered products")
+		return 0, errors.NewInternalError("Failed to get products", err)
+	}
+
+	var entitlements []domain.Entitlement
+	for _, item := range order.Items {
+		product, ok := products[item.ProductID]
+		if !ok || product.Type != domain.ProductTypeDigital {
+			continue
+		}
+		licenseKey, err := newLicenseKey()
+		if err != nil {
+			return 0, errors.NewInternalError("Failed to issue license key", err)
+		}
+		entitlements = append(entitlements, domain.Entitlement{
+			OrderID:     order.ID,
+			CustomerID:  order.CustomerID,
+			ProductID:   product.ID,
+			ProductName: item.Name,
+			Quantity:    item.Quantity,
+			LicenseKey:  licenseKey,
+		})
+	}
+	if len(entitlements) == 0 {
+		return 0, nil
+	}
+
+	granted, err := s.repo.GrantEntitlements(ctx, entitlements)
+	if err != nil {
+		s.log(ctx).WithError(err).WithField("order_id", order.ID).Error("Failed to grant entitlements")
+		return 0, errors.NewInternalError("Failed to grant entitlements", err)
+	}
+	if len(granted) == 0 {
+		return 0, nil
+	}
+
+	s.publish(ctx, domain.EventDownloadsGranted, &domain.DownloadsGranted{
+		OrderID:      order.ID,
+		CustomerID:   order.CustomerID,
+		Email:        order.Email,
+		Entitlements: granted,
+	})
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"order_id": order.ID,
+		"count":    len(granted),
+	}).Info("Downloads granted successfully")
+	return len(granted), nil
+}
+
+// ListEntitlements lists entitlements. Customers only see their own.
+func (s *productService) ListEntitlements(ctx context.Context, filters *domain.EntitlementFilters) (*domain.EntitlementList, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		actorID := auth.ActorID(ctx)
+		if actorID == auth.AnonymousActor {
+			return nil, errors.NewUnauthorizedError("Listing downloads requires signing in", nil)
+		}
+		filters.CustomerID = actorID
+	}
+
+	// Set default values
+	if filters.Limit <= 0 {
+		filters.Limit = 20
+	}
+	if filters.Limit > 100 {
+		filters.Limit = 100
+	}
+
+	entitlements, total, err := s.repo.ListEntitlements(ctx, filters)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list entitlements")
+		return nil, errors.NewInternalError("Failed to list entitlements", err)
+	}
+
+	return &domain.EntitlementList{
+		Entitlements: entitlements,
+		Total:        total,
+		Limit:        filters.Limit,
+		Offset:       filters.Offset,
+		HasMore:      int64(filters.Offset+filters.Limit) < total,
+	}, nil
+}
+
+// GetEntitlement returns an entitlement with signed links to download its
+// files. Customers can only get their own.
+func (s *productService) GetEntitlement(ctx context.Context, id uuid.UUID) (*domain.Entitlement, error) {
+	entitlement, err := s.repo.GetEntitlement(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, err
+		}
+		s.log(ctx).WithError(err).Error("Failed to get entitlement")
+		return nil, errors.NewInternalError("Failed to get entitlement", err)
+	}
+	if !auth.HasRole(ctx, auth.RoleAdmin) && entitlement.CustomerID != auth.ActorID(ctx) {
+		return nil, errors.NewNotFoundError("Entitlement not found", nil).WithCode(errors.CodeEntitlementNotFound)
+	}
+	if s.storage == nil {
+		return nil, errors.NewUnavailableError("Media storage is not configured", nil)
+	}
+
+	assets, err := s.repo.ListDigitalAssets(ctx, entitlement.ProductID)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list digital assets")
+		return nil, errors.NewInternalError("Failed to list digital assets", err)
+	}
+
+	expiry := time.Duration(s.media.DownloadExpiry) * time.Second
+	expiresAt := time.Now().Add(expiry)
+	entitlement.Downloads = make([]domain.Download, 0, len(assets))
+	for _, asset := range assets {
+		url, err := s.storage.PresignGet(asset.StorageKey, asset.Filename, expiry)
+		if err != nil {
+			s.log(ctx).WithError(err).Error("Failed to sign download")
+			return nil, errors.NewInternalError("Failed to create download URL", err)
+		}
+		entitlement.Downloads = append(entitlement.Downloads, domain.Download{
+			AssetID:     asset.ID,
+			Filename:    asset.Filename,
+			ContentType: asset.ContentType,
+			Size:        asset.Size,
+			URL:         url,
+			ExpiresAt:   expiresAt,
+		})
+	}
+
+	return entitlement, nil
+}
+
+// newLicenseKey returns a random license key in five groups of five
+// characters
+func newLicenseKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate license key: %w", err)
+	}
+	key := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b)[:25]
+
+	groups := make([]string, 0, 5)
+	for i := 0; i < len(key); i += 5 {
+		groups = append(groups, key[i:i+5])
+	}
+	return strings.Join(groups, "-"), nil
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/database"
+	"ecommerce/pkg/errors"
+)
+
+// maxSKUSuggestions bounds the search for a free SKU for a copy
+const maxSKUSuggestions = 100
+
+// DuplicateProduct creates a draft copy of a product, with its attributes,
+// price tiers, a bundle's components, its images, translations and sales
+// channel settings, for preparing a similar item. Stock, reviews and sale
+// prices belong to the original and are not copied. The copy is created as
+// one unit, so it is never left with only part of what it was copied with.
+func (s *productService) DuplicateProduct(ctx context.Context, id uuid.UUID, req *domain.DuplicateProductRequest) (*domain.Product, error) {
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid duplicate product request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	original, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Product not found", err).WithCode(errors.CodeProductNotFound)
+		}
+		return nil, errors.NewInternalError("Failed to get product", err)
+	}
+	if !visible(ctx, original) {
+		return nil, errors.NewNotFoundError("Product not found", nil).WithCode(errors.CodeProductNotFound)
+	}
+	if !s.managesProduct(ctx, original.ID) {
+		return nil, errors.NewForbiddenError("Duplicating a product requires the admin role", nil).WithCode(errors.CodeProductNotOwned)
+	}
+
+	sku := req.SKU
+	if sku == "" {
+		sku, err = s.suggestSKU(ctx, original.SKU)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	name := req.Name
+	if name == "" {
+		name = copyName(original.Name)
+	}
+
+	attributes := make(map[string]interface{}, len(original.Attributes))
+	for _, attribute := range original.Attributes {
+		attributes[attribute.Key] = attribute.Value
+	}
+
+	product, err := s.newProduct(ctx, &domain.CreateProductRequest{
+		Name:        name,
+		Description: original.Description,
+		Price:       original.Price,
+		CategoryID:  original.CategoryID,
+		BrandID:     original.BrandID,
+		ImageURL:    original.ImageURL,
+		SKU:         sku,
+		Type:        original.Type,
+		Status:      domain.ProductStatusDraft,
+		Attributes:  attributes,
+
+		LowStockThreshold: original.LowStockThreshold,
+
+		AllowBackorder: original.AllowBackorder,
+		Preorder:       original.Preorder,
+		ReleaseDate:    original.ReleaseDate,
+
+		BundlePricing:  original.BundlePricing,
+		BundleDiscount: original.BundleDiscount,
+
+		UnitOfMeasure:     original.UnitOfMeasure,
+		QuantityIncrement: original.QuantityIncrement,
+		MinOrderQuantity:  original.MinOrderQuantity,
+		MaxOrderQuantity:  original.MaxOrderQuantity,
+
+		TaxClass: original.TaxClass,
+	})
+	if err != nil {
+		return nil, err
+	}
+	product.Channels = maps.Clone(original.Channels)
+
+	// Images are copied in storage too, so deleting either product's
+	// images leaves the other's in place
+	var copied []*domain.Media
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.insertProduct(ctx, product); err != nil {
+			return err
+		}
+
+		if original.Type == domain.ProductTypeBundle {
+			components, err := s.repo.ListBundleComponents(ctx, []uuid.UUID{original.ID})
+			if err != nil {
+				return errors.NewInternalError("Failed to get bundle components", err)
+			}
+			if err := s.repo.ReplaceBundleComponents(ctx, product.ID, components); err != nil {
+				s.log(ctx).WithError(err).Error("Failed to copy bundle components")
+				return errors.NewInternalError("Failed to copy bundle components", err)
+			}
+		}
+
+		tiers, err := s.repo.ListPriceTiers(ctx, []uuid.UUID{original.ID})
+		if err != nil {
+			return errors.NewInternalError("Failed to get price tiers", err)
+		}
+		if len(tiers) > 0 {
+			for i := range tiers {
+				tiers[i].ID = uuid.Nil
+			}
+			if err := s.repo.ReplacePriceTiers(ctx, product.ID, tiers); err != nil {
+				s.log(ctx).WithError(err).Error("Failed to copy price tiers")
+				return errors.NewInternalError("Failed to copy price tiers", err)
+			}
+			product.PriceTiers = tiers
+		}
+
+		translations, err := s.repo.ListProductTranslations(ctx, original.ID)
+		if err != nil {
+			return errors.NewInternalError("Failed to get product translations", err)
+		}
+		for _, translation := range translations {
+			translation.ProductID = product.ID
+			if req.Name == "" {
+				translation.Name = copyName(translation.Name)
+			}
+			if err := s.repo.UpsertProductTranslation(ctx, &translation); err != nil {
+				s.log(ctx).WithError(err).Error("Failed to copy product translations")
+				return errors.NewInternalError("Failed to copy product translations", err)
+			}
+		}
+
+		if s.storage == nil {
+			return nil
+		}
+		images, err := s.repo.ListProductMedia(ctx, original.ID, domain.MediaStatusReady)
+		if err != nil {
+			return errors.NewInternalError("Failed to get product media", err)
+		}
+		for i := range images {
+			media, err := s.copyMedia(ctx, &images[i], product.ID)
+			if err != nil {
+				s.log(ctx).WithError(err).Error("Failed to copy media")
+				return errors.NewInternalError("Failed to copy media", err)
+			}
+			copied = append(copied, media)
+			if err := s.repo.CreateMedia(ctx, media); err != nil {
+				s.log(ctx).WithError(err).Error("Failed to copy media")
+				return errors.NewInternalError("Failed to copy media", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		for _, media := range copied {
+			if err := s.removeMediaObjects(ctx, media); err != nil {
+				s.log(ctx).WithError(err).WithField("media_id", media.ID).Warn("Failed to remove copied media")
+			}
+		}
+		return nil, err
+	}
+
+	// Invalidate cache
+	if err := s.repo.InvalidateProductCache(ctx); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to invalidate product cache")
+		return nil, errors.NewInternalError("Failed to invalidate cache", err)
+	}
+
+	if product.Type == domain.ProductTypeBundle {
+		s.repriceBundles(ctx, product)
+		s.addBundle(ctx, product)
+	}
+
+	s.publish(ctx, domain.EventProductCreated, product)
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"product_id":  product.ID,
+		"original_id": original.ID,
+		"media":       len(copied),
+	}).Info("Product duplicated successfully")
+	return product, nil
+}
+
+// suggestSKU finds a free SKU for a copy of the product with the given SKU
+func (s *productService) suggestSKU(ctx context.Context, sku string) (string, error) {
+	for i := 1; i <= maxSKUSuggestions; i++ {
+		candidate := sku + "-COPY"
+		if i > 1 {
+			candidate = fmt.Sprintf("%s-COPY-%d", sku, i)
+		}
+
+		_, err := s.repo.GetBySKU(database.WithPrimary(ctx), candidate)
+		if errors.IsNotFound(err) {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", errors.NewInternalError("Failed to validate SKU", err)
+		}
+	}
+	return "", errors.NewConflictError("No free SKU for the copy; please provide one", nil).WithCode(errors.CodeProductSKUConflict)
+}
+
+// copyName names a copy of a product, keeping within the name length limit
+func copyName(name string) string {
+	const suffix = " (copy)"
+	runes := []rune(name)
+	if limit := 255 - len(suffix); len(runes) > limit {
+		runes = runes[:limit]
+	}
+	return string(runes) + suffix
+}
@@ -0,0 +1,412 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/internal/product/flashsale"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/tenant"
+)
+
+// flashSaleCloseDelay is how long after its end a flash sale is closed,
+// leaving purchases taken by clocks running behind time to be written
+const flashSaleCloseDelay = 30 * time.Second
+
+// SetFlashSale sets up a product's flash sale, or changes it until it
+// starts. The sale's quantity is taken from the product's stock at once,
+// so regular orders can't sell it.
+func (s *productService) SetFlashSale(ctx context.Context, productID uuid.UUID, req *domain.SetFlashSaleRequest) (*domain.FlashSale, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing flash sales requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid set flash sale request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	product, err := s.repo.GetByID(ctx, productID)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Product not found", err).WithCode(errors.CodeProductNotFound)
+		}
+		return nil, errors.NewInternalError("Failed to get product", err)
+	}
+
+	sale, err := s.repo.GetFlashSale(ctx, productID)
+	if err != nil && !errors.IsNotFound(err) {
+		s.log(ctx).WithError(err).Error("Failed to get flash sale")
+		return nil, errors.NewInternalError("Failed to get flash sale", err)
+	}
+	held := 0
+	if sale != nil {
+		if sale.Started(time.Now()) {
+			return nil, errors.NewConflictError("Flash sale has already started", nil).WithCode(errors.CodeFlashSaleStarted)
+		}
+		held = sale.Held
+	} else {
+		sale = &domain.FlashSale{ProductID: productID, CreatedBy: auth.ActorID(ctx)}
+	}
+
+	sale.StartsAt = req.StartsAt
+	sale.EndsAt = req.EndsAt
+	sale.Price = req.Price
+	sale.Quantity = req.Quantity
+	sale.PerUserLimit = req.PerUserLimit
+	sale.Held = 0
+	if product.IsPhysical() {
+		sale.Held = req.Quantity
+	}
+	if err := sale.ValidateFor(product); err != nil {
+		return nil, errors.NewValidationError(err.Error(), nil)
+	}
+
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if sale.ID == uuid.Nil {
+			if err := s.repo.CreateFlashSale(ctx, sale); err != nil {
+				if errors.IsConflict(err) {
+					return err
+				}
+				s.log(ctx).WithError(err).Error("Failed to create flash sale")
+				return errors.NewInternalError("Failed to create flash sale", err)
+			}
+		} else if err := s.repo.UpdateFlashSale(ctx, sale); err != nil {
+			s.log(ctx).WithError(err).Error("Failed to update flash sale")
+			return errors.NewInternalError("Failed to update flash sale", err)
+		}
+
+		return s.holdFlashSaleStock(ctx, sale, sale.Held-held, "Set aside for flash sale")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// A sale is armed once it starts, from what is stored now
+	if err := s.flashSales.Disarm(ctx, productID); err != nil {
+		s.log(ctx).WithError(err).Warn("Failed to disarm flash sale")
+	}
+	if sale.Held != held {
+		s.flashSaleStockChanged(ctx, productID)
+	}
+	sale.Remaining = sale.Quantity - sale.Sold
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"flash_sale_id": sale.ID,
+		"product_id":    productID,
+	}).Info("Flash sale set successfully")
+	return sale, nil
+}
+
+// GetFlashSale returns a product's open flash sale, with the quantity it
+// has left
+func (s *productService) GetFlashSale(ctx context.Context, productID uuid.UUID) (*domain.FlashSale, error) {
+	sale, err := s.repo.GetFlashSale(ctx, productID)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, err
+		}
+		s.log(ctx).WithError(err).Error("Failed to get flash sale")
+		return nil, errors.NewInternalError("Failed to get flash sale", err)
+	}
+
+	// A running sale's purchases are counted in Redis before they are
+	// written here
+	sale.Remaining = sale.Quantity - sale.Sold
+	remaining, armed, err := s.flashSales.Remaining(ctx, productID)
+	if err != nil {
+		s.log(ctx).WithError(err).Warn("Failed to get flash sale from Redis")
+	} else if armed {
+		sale.Remaining = remaining
+	}
+
+	return sale, nil
+}
+
+// DeleteFlashSale calls off a product's flash sale before it starts,
+// putting the stock it held back
+func (s *productService) DeleteFlashSale(ctx context.Context, productID uuid.UUID) error {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return errors.NewForbiddenError("Managing flash sales requires the admin role", nil)
+	}
+
+	sale, err := s.repo.GetFlashSale(ctx, productID)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return err
+		}
+		s.log(ctx).WithError(err).Error("Failed to get flash sale")
+		return errors.NewInternalError("Failed to get flash sale", err)
+	}
+	if sale.Started(time.Now()) {
+		return errors.NewConflictError("Flash sale has already started; it closes when it ends", nil).WithCode(errors.CodeFlashSaleStarted)
+	}
+
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.DeleteFlashSale(ctx, sale.ID); err != nil {
+			if errors.IsNotFound(err) {
+				return err
+			}
+			s.log(ctx).WithError(err).Error("Failed to delete flash sale")
+			return errors.NewInternalError("Failed to delete flash sale", err)
+		}
+		return s.holdFlashSaleStock(ctx, sale, -sale.Held, "Flash sale called off")
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.flashSales.Disarm(ctx, productID); err != nil {
+		s.log(ctx).WithError(err).Warn("Failed to disarm flash sale")
+	}
+	if sale.Held > 0 {
+		s.flashSaleStockChanged(ctx, productID)
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"flash_sale_id": sale.ID,
+		"product_id":    productID,
+	}).Info("Flash sale deleted successfully")
+	return nil
+}
+
+// PurchaseFlashSale buys units of a product's running flash sale for the
+// caller. The purchase is taken in Redis, which keeps the sale from selling
+// more than it has however many purchases arrive at once, and written to
+// Postgres in the background.
+func (s *productService) PurchaseFlashSale(ctx context.Context, productID uuid.UUID, req *domain.FlashSalePurchaseRequest) (*domain.FlashSalePurchase, error) {
+	actor := auth.ActorFromContext(ctx)
+	if actor == nil {
+		return nil, errors.NewUnauthorizedError("Authentication required to buy in a flash sale", nil).WithCode(errors.CodeAuthenticationRequired)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid flash sale purchase request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	purchase := &domain.FlashSalePurchase{
+		ID:          uuid.New(),
+		ProductID:   productID,
+		UserID:      actor.ID,
+		Quantity:    req.Quantity,
+		PurchasedAt: time.Now(),
+	}
+	tenantID, _ := tenant.FromContext(ctx)
+
+	outcome, remaining, err := s.flashSales.Purchase(ctx, tenantID, purchase)
+	if err == nil && outcome == flashsale.NotArmed {
+		// The sale just started, or Redis lost it: arm it from what is
+		// written, and try again
+		if err := s.armFlashSale(ctx, productID); err != nil {
+			return nil, err
+		}
+		outcome, remaining, err = s.flashSales.Purchase(ctx, tenantID, purchase)
+	}
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to take flash sale purchase")
+		return nil, errors.NewInternalError("Failed to buy in flash sale", err)
+	}
+	switch outcome {
+	case flashsale.NotArmed:
+		return nil, errors.NewNotFoundError("Flash sale not found", nil).WithCode(errors.CodeFlashSaleNotFound)
+	case flashsale.NotRunning:
+		return nil, errors.NewConflictError("Flash sale is not running", nil).WithCode(errors.CodeFlashSaleNotRunning)
+	case flashsale.SoldOut:
+		return nil, errors.NewConflictError("Flash sale is sold out", nil).WithCode(errors.CodeFlashSaleSoldOut)
+	case flashsale.LimitReached:
+		return nil, errors.NewConflictError("Flash sale purchase limit reached", nil).WithCode(errors.CodeFlashSaleLimitReached)
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"flash_sale_id": purchase.FlashSaleID,
+		"purchase_id":   purchase.ID,
+		"quantity":      purchase.Quantity,
+		"remaining":     remaining,
+	}).Info("Flash sale purchase taken")
+	return purchase, nil
+}
+
+// armFlashSale loads a product's running flash sale into Redis, with what
+// it sold as written so far
+func (s *productService) armFlashSale(ctx context.Context, productID uuid.UUID) error {
+	sale, err := s.repo.GetFlashSale(ctx, productID)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return err
+		}
+		s.log(ctx).WithError(err).Error("Failed to get flash sale")
+		return errors.NewInternalError("Failed to get flash sale", err)
+	}
+	// Only running sales are armed, so a sale is never armed before its
+	// last change
+	if !sale.Running(time.Now()) {
+		return errors.NewConflictError("Flash sale is not running", nil).WithCode(errors.CodeFlashSaleNotRunning)
+	}
+
+	buyers, err := s.repo.FlashSaleBuyers(ctx, sale.ID)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to get flash sale buyers")
+		return errors.NewInternalError("Failed to get flash sale", err)
+	}
+	if err := s.flashSales.Arm(ctx, sale, sale.Quantity-sale.Sold, buyers); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to arm flash sale")
+		return errors.NewInternalError("Failed to start flash sale", err)
+	}
+
+	s.log(ctx).WithField("flash_sale_id", sale.ID).Info("Flash sale armed")
+	return nil
+}
+
+// SyncFlashSales writes the purchases taken in Redis for running flash
+// sales to Postgres, and closes the sales that have ended, putting the
+// stock they held but didn't sell back. It returns the units written.
+func (s *productService) SyncFlashSales(ctx context.Context) (int, error) {
+	open, err := s.repo.ListOpenFlashSales(ctx, s.flashSale.SyncBatchSize)
+	if err != nil {
+		return 0, errors.NewInternalError("Failed to list open flash sales", err)
+	}
+
+	var written int
+	for _, sale := range open {
+		// Each sale is written within its own tenant
+		scoped := ctx
+		if sale.TenantID != "" {
+			scoped = tenant.WithID(ctx, sale.TenantID)
+		}
+		log := s.log(ctx).WithField("flash_sale_id", sale.ID)
+
+		n, err := s.writeFlashSalePurchases(scoped, &sale)
+		written += n
+		if err != nil {
+			log.WithError(err).Error("Failed to write flash sale purchases")
+			continue
+		}
+
+		if time.Since(sale.EndsAt) > flashSaleCloseDelay {
+			if err := s.closeFlashSale(scoped, sale.ProductID); err != nil {
+				log.WithError(err).Error("Failed to close flash sale")
+			}
+		}
+	}
+
+	return written, nil
+}
+
+// writeFlashSalePurchases writes the purchases queued in Redis for a sale,
+// a batch at a time. Each batch is dropped from the queue once written;
+// writing one again after a failure, or on another instance at the same
+// time, skips what is already written.
+func (s *productService) writeFlashSalePurchases(ctx context.Context, sale *domain.FlashSale) (int, error) {
+	var written int
+	for {
+		queued, err := s.flashSales.Pending(ctx, sale.ProductID, s.flashSale.SyncBatchSize)
+		if err != nil {
+			return written, err
+		}
+		if len(queued) == 0 {
+			return written, nil
+		}
+
+		purchases := make([]domain.FlashSalePurchase, 0, len(queued))
+		for _, purchase := range queued {
+			if purchase.FlashSaleID != sale.ID {
+				s.log(ctx).WithField("purchase_id", purchase.ID).Warn("Dropping queued purchase of another flash sale")
+				continue
+			}
+			purchases = append(purchases, purchase)
+		}
+
+		n, err := s.repo.RecordFlashSalePurchases(ctx, sale.ID, purchases)
+		if err != nil {
+			return written, err
+		}
+		written += n
+		if err := s.flashSales.Ack(ctx, sale.ProductID, queued); err != nil {
+			return written, err
+		}
+		if len(queued) < s.flashSale.SyncBatchSize {
+			return written, nil
+		}
+	}
+}
+
+// closeFlashSale closes a product's ended flash sale once its purchases are
+// written, putting the stock it held but didn't sell back
+func (s *productService) closeFlashSale(ctx context.Context, productID uuid.UUID) error {
+	sale, err := s.repo.GetFlashSale(ctx, productID)
+	if err != nil {
+		return err
+	}
+
+	var closed bool
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if closed, err = s.repo.CloseFlashSale(ctx, sale); err != nil || !closed {
+			return err
+		}
+		return s.holdFlashSaleStock(ctx, sale, -sale.Unsold(), "Unsold in flash sale")
+	})
+	if err != nil || !closed {
+		return err
+	}
+
+	if err := s.flashSales.Disarm(ctx, productID); err != nil {
+		s.log(ctx).WithError(err).Warn("Failed to disarm flash sale")
+	}
+	if sale.Unsold() > 0 {
+		s.flashSaleStockChanged(ctx, productID)
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"flash_sale_id": sale.ID,
+		"product_id":    productID,
+		"sold":          sale.Sold,
+	}).Info("Flash sale closed")
+	return nil
+}
+
+// holdFlashSaleStock takes delta units of a product's stock for its flash
+// sale, or puts them back when delta is negative, through the stock ledger
+func (s *productService) holdFlashSaleStock(ctx context.Context, sale *domain.FlashSale, delta int, note string) error {
+	if delta == 0 {
+		return nil
+	}
+
+	_, err := s.repo.AdjustStock(ctx, sale.ProductID, -delta, domain.StockMovement{
+		Reason:    domain.StockReasonFlashSale,
+		Reference: "flash-sale:" + sale.ID.String(),
+		ActorID:   auth.ActorID(ctx),
+		Note:      note,
+	})
+	if err != nil {
+		if errors.IsNotFound(err) || errors.IsConflict(err) {
+			return err
+		}
+		s.log(ctx).WithError(err).Error("Failed to adjust stock for flash sale")
+		return errors.NewInternalError("Failed to adjust stock", err)
+	}
+	return nil
+}
+
+// flashSaleStockChanged lets listeners know a flash sale moved a product's
+// stock
+func (s *productService) flashSaleStockChanged(ctx context.Context, productID uuid.UUID) {
+	if err := s.repo.InvalidateProductCache(ctx); err != nil {
+		s.log(ctx).WithError(err).Warn("Failed to invalidate product cache")
+	}
+
+	product, err := s.repo.GetByID(ctx, productID)
+	if err != nil {
+		s.log(ctx).WithError(err).Warn("Failed to get product")
+		return
+	}
+	s.publish(ctx, domain.EventProductUpdated, product)
+	s.checkLowStock(ctx, product)
+}
@@ -0,0 +1,444 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/internal/product/imaging"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/storage"
+)
+
+// uploadCompletionGrace is how long after its URL expires an upload may
+// still be completed before it counts as abandoned
+const uploadCompletionGrace = time.Hour
+
+// mediaExtensions are the file extensions objects are stored with
+var mediaExtensions = map[string]string{
+	imaging.TypeJPEG: ".jpg",
+	imaging.TypePNG:  ".png",
+	imaging.TypeGIF:  ".gif",
+	"image/webp":     ".webp",
+	"image/avif":     ".avif",
+}
+
+// CreateMediaUpload hands out a pre-signed URL to upload a product image
+// to. The image is only attached once the upload is completed.
+func (s *productService) CreateMediaUpload(ctx context.Context, productID uuid.UUID, req *domain.CreateMediaUploadRequest) (*domain.MediaUpload, error) {
+	if !s.managesProduct(ctx, productID) {
+		return nil, errors.NewForbiddenError("Managing media requires the admin role", nil)
+	}
+	if s.storage == nil {
+		return nil, errors.NewUnavailableError("Media storage is not configured", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid media upload request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+	contentType := mediaType(req.ContentType)
+	if !s.mediaTypeAllowed(contentType) {
+		return nil, errors.NewValidationError(fmt.Sprintf("Content type %s is not allowed", req.ContentType), nil)
+	}
+	if req.Size > s.maxUploadBytes() {
+		return nil, errors.NewValidationError(fmt.Sprintf("File is larger than %d MB", s.media.MaxUploadSize), nil)
+	}
+
+	if _, err := s.GetProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	id := uuid.New()
+	media := &domain.Media{
+		ID:          id,
+		ProductID:   productID,
+		StorageKey:  fmt.Sprintf("products/%s/%s/original%s", productID, id, mediaExtensions[contentType]),
+		Filename:    path.Base(strings.ReplaceAll(req.Filename, "\\", "/")),
+		ContentType: contentType,
+		Size:        req.Size,
+		Status:      domain.MediaStatusPending,
+	}
+
+	expiry := time.Duration(s.media.UploadExpiry) * time.Second
+	uploadURL, err := s.storage.PresignPut(media.StorageKey, contentType, expiry)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to sign media upload")
+		return nil, errors.NewInternalError("Failed to create upload URL", err)
+	}
+
+	if err := s.repo.CreateMedia(ctx, media); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to create media")
+		return nil, errors.NewInternalError("Failed to create media", err)
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"product_id": productID,
+		"media_id":   media.ID,
+	}).Info("Media upload created successfully")
+
+	return &domain.MediaUpload{
+		Media:     s.withMediaURLs(media),
+		UploadURL: uploadURL,
+		Method:    http.MethodPut,
+		Headers:   map[string]string{"Content-Type": contentType},
+		ExpiresAt: time.Now().Add(expiry),
+	}, nil
+}
+
+// CompleteMediaUpload checks an uploaded file and makes its thumbnails. A
+// file that is missing is reported so the client can finish uploading; one
+// that is too large or is not what it claimed to be is removed along with
+// its media, and a new upload has to be started.
+func (s *productService) CompleteMediaUpload(ctx context.Context, productID, mediaID uuid.UUID) (*domain.Media, error) {
+	if !s.managesProduct(ctx, productID) {
+		return nil, errors.NewForbiddenError("Managing media requires the admin role", nil)
+	}
+	if s.storage == nil {
+		return nil, errors.NewUnavailableError("Media storage is not configured", nil)
+	}
+
+	media, err := s.getProductMedia(ctx, productID, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	if media.Status == domain.MediaStatusReady {
+		return s.withMediaURLs(media), nil
+	}
+
+	info, err := s.storage.Head(ctx, media.StorageKey)
+	if err != nil {
+		if err == storage.ErrNotFound {
+			return nil, errors.NewValidationError("File has not been uploaded", nil).WithCode(errors.CodeMediaNotUploaded)
+		}
+		s.log(ctx).WithError(err).Error("Failed to check media upload")
+		return nil, errors.NewInternalError("Failed to check upload", err)
+	}
+	if info.Size > s.maxUploadBytes() {
+		return nil, s.rejectMedia(ctx, media, fmt.Sprintf("File is larger than %d MB", s.media.MaxUploadSize), nil)
+	}
+
+	data, err := s.storage.Get(ctx, media.StorageKey, s.maxUploadBytes())
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to download media upload")
+		return nil, errors.NewInternalError("Failed to read upload", err)
+	}
+
+	// The storage only holds the client to the declared type; the content
+	// itself has to match it too
+	if detected := mediaType(http.DetectContentType(data)); detected != media.ContentType {
+		return nil, s.rejectMedia(ctx, media, fmt.Sprintf("File content is %s, not %s", detected, media.ContentType), nil)
+	}
+
+	media.Size = int64(len(data))
+	media.Thumbnails = nil
+	if imaging.CanDecode(media.ContentType) {
+		img, err := imaging.Decode(data, media.ContentType, s.media.MaxMegapixels*1000000)
+		if err != nil {
+			return nil, s.rejectMedia(ctx, media, "File is not a valid image", err)
+		}
+		bounds := img.Bounds()
+		media.Width, media.Height = bounds.Dx(), bounds.Dy()
+
+		thumbnails, err := imaging.Thumbnails(img, media.ContentType, s.media.ThumbnailSizes)
+		if err != nil {
+			s.log(ctx).WithError(err).Error("Failed to make thumbnails")
+			return nil, errors.NewInternalError("Failed to make thumbnails", err)
+		}
+		for _, thumbnail := range thumbnails {
+			key := thumbnailKey(media.StorageKey, thumbnail.Size, thumbnail.ContentType)
+			if err := s.storage.Put(ctx, key, thumbnail.ContentType, thumbnail.Data); err != nil {
+				// The media stays pending, so completing it again redoes the
+				// thumbnails and the purge cleans up if nobody does
+				s.removeMediaObjects(ctx, media)
+				s.log(ctx).WithError(err).Error("Failed to store thumbnail")
+				return nil, errors.NewInternalError("Failed to store thumbnails", err)
+			}
+			media.Thumbnails = append(media.Thumbnails, domain.MediaThumbnail{
+				Size:        thumbnail.Size,
+				Width:       thumbnail.Width,
+				Height:      thumbnail.Height,
+				ContentType: thumbnail.ContentType,
+			})
+		}
+	}
+
+	media.Status = domain.MediaStatusReady
+	if err := s.repo.UpdateMedia(ctx, media); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to update media")
+		return nil, errors.NewInternalError("Failed to update media", err)
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"product_id": productID,
+		"media_id":   media.ID,
+		"thumbnails": len(media.Thumbnails),
+	}).Info("Media upload completed successfully")
+	return s.withMediaURLs(media), nil
+}
+
+// ListProductMedia lists a product's images. Uploads that are not complete
+// are only shown to admins.
+func (s *productService) ListProductMedia(ctx context.Context, productID uuid.UUID) ([]domain.Media, error) {
+	if _, err := s.GetProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	status := domain.MediaStatusReady
+	if auth.HasRole(ctx, auth.RoleAdmin) {
+		status = ""
+	}
+	media, err := s.repo.ListProductMedia(ctx, productID, status)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list product media")
+		return nil, errors.NewInternalError("Failed to list product media", err)
+	}
+
+	for i := range media {
+		s.withMediaURLs(&media[i])
+	}
+	return media, nil
+}
+
+func (s *productService) DeleteMedia(ctx context.Context, productID, mediaID uuid.UUID) error {
+	if !s.managesProduct(ctx, productID) {
+		return errors.NewForbiddenError("Managing media requires the admin role", nil)
+	}
+	if s.storage == nil {
+		return errors.NewUnavailableError("Media storage is not configured", nil)
+	}
+
+	media, err := s.getProductMedia(ctx, productID, mediaID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.removeMediaObjects(ctx, media); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to remove media from storage")
+		return errors.NewInternalError("Failed to delete media", err)
+	}
+	if err := s.repo.DeleteMedia(ctx, media.ID); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to delete media")
+		return errors.NewInternalError("Failed to delete media", err)
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"product_id": productID,
+		"media_id":   mediaID,
+	}).Info("Media deleted successfully")
+	return nil
+}
+
+// PurgeMedia removes uploads that were started but never completed, and
+// the images of products deleted longer ago than the retention period, from
+// storage and the database. It reports how many were removed. Images are
+// kept for a while after their product is deleted so restoring it brings
+// them back.
+func (s *productService) PurgeMedia(ctx context.Context) (int, error) {
+	if s.storage == nil {
+		return 0, nil
+	}
+
+	now := time.Now()
+	abandonedBefore := now.Add(-time.Duration(s.media.UploadExpiry)*time.Second - uploadCompletionGrace)
+	deletedBefore := now.AddDate(0, 0, -s.media.RetentionDays)
+
+	expired, err := s.repo.ListExpiredMedia(ctx, abandonedBefore, deletedBefore, s.media.PurgeBatchSize)
+	if err != nil {
+		return 0, errors.NewInternalError("Failed to list expired media", err)
+	}
+
+	purged := 0
+	for i := range expired {
+		media := &expired[i]
+		logger := s.log(ctx).WithField("media_id", media.ID)
+		if err := s.removeMediaObjects(ctx, media); err != nil {
+			logger.WithError(err).Error("Failed to remove expired media from storage")
+			continue
+		}
+		if err := s.repo.DeleteMedia(ctx, media.ID); err != nil {
+			logger.WithError(err).Error("Failed to delete expired media")
+			continue
+		}
+		purged++
+	}
+
+	if purged > 0 {
+		s.log(ctx).WithField("count", purged).Info("Expired media purged successfully")
+	}
+	return purged, nil
+}
+
+// copyMedia copies an image and its thumbnails in storage for another
+// product and returns the media for the copy, which is left to the caller
+// to create
+func (s *productService) copyMedia(ctx context.Context, media *domain.Media, productID uuid.UUID) (*domain.Media, error) {
+	id := uuid.New()
+	copied := *media
+	copied.ID = id
+	copied.ProductID = productID
+	copied.StorageKey = fmt.Sprintf("products/%s/%s/original%s", productID, id, mediaExtensions[media.ContentType])
+	copied.Thumbnails = append([]domain.MediaThumbnail(nil), media.Thumbnails...)
+	copied.CreatedAt = time.Time{}
+	copied.UpdatedAt = time.Time{}
+
+	keys := map[string]string{media.StorageKey: copied.StorageKey}
+	types := map[string]string{media.StorageKey: media.ContentType}
+	for _, thumbnail := range media.Thumbnails {
+		from := thumbnailKey(media.StorageKey, thumbnail.Size, thumbnail.ContentType)
+		keys[from] = thumbnailKey(copied.StorageKey, thumbnail.Size, thumbnail.ContentType)
+		types[from] = thumbnail.ContentType
+	}
+
+	for from, to := range keys {
+		if err := s.copyObject(ctx, from, to, types[from]); err != nil {
+			// Objects copied so far are not referenced by any media
+			if cleanupErr := s.removeMediaObjects(ctx, &copied); cleanupErr != nil {
+				s.log(ctx).WithError(cleanupErr).Warn("Failed to remove partly copied media")
+			}
+			return nil, err
+		}
+	}
+	return &copied, nil
+}
+
+// copyObject copies an object in storage to another key
+func (s *productService) copyObject(ctx context.Context, from, to, contentType string) error {
+	data, err := s.storage.Get(ctx, from, s.maxUploadBytes())
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", from, err)
+	}
+	if err := s.storage.Put(ctx, to, contentType, data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", to, err)
+	}
+	return nil
+}
+
+// getProductMedia loads media, treating media of another product as missing
+func (s *productService) getProductMedia(ctx context.Context, productID, mediaID uuid.UUID) (*domain.Media, error) {
+	media, err := s.repo.GetMedia(ctx, mediaID)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Media not found", err).WithCode(errors.CodeMediaNotFound)
+		}
+		return nil, errors.NewInternalError("Failed to get media", err)
+	}
+	if media.ProductID != productID {
+		return nil, errors.NewNotFoundError("Media not found", nil).WithCode(errors.CodeMediaNotFound)
+	}
+	return media, nil
+}
+
+// rejectMedia removes an upload that failed validation, together with its
+// media, and returns the validation error to report
+func (s *productService) rejectMedia(ctx context.Context, media *domain.Media, reason string, cause error) error {
+	logger := s.log(ctx).WithFields(logrus.Fields{
+		"media_id": media.ID,
+		"reason":   reason,
+	})
+	logger.Warn("Rejected media upload")
+
+	if err := s.removeMediaObjects(ctx, media); err != nil {
+		// The media stays pending, so the purge removes the file later
+		logger.WithError(err).Error("Failed to remove rejected upload")
+	} else if err := s.repo.DeleteMedia(ctx, media.ID); err != nil {
+		logger.WithError(err).Error("Failed to delete rejected media")
+	}
+
+	return errors.NewValidationError(reason, cause).WithCode(errors.CodeMediaRejected)
+}
+
+// removeMediaObjects deletes an image and its thumbnails from storage. Every
+// configured size is tried, not just the recorded ones, so thumbnails of an
+// upload that failed partway are removed too.
+func (s *productService) removeMediaObjects(ctx context.Context, media *domain.Media) error {
+	keys := map[string]bool{media.StorageKey: true}
+	for _, thumbnail := range media.Thumbnails {
+		keys[thumbnailKey(media.StorageKey, thumbnail.Size, thumbnail.ContentType)] = true
+	}
+	for _, size := range s.media.ThumbnailSizes {
+		keys[thumbnailKey(media.StorageKey, size, imaging.ThumbnailType(media.ContentType))] = true
+	}
+
+	for key := range keys {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// withMediaURLs fills in the URLs media and its thumbnails are served from
+func (s *productService) withMediaURLs(media *domain.Media) *domain.Media {
+	media.URL = s.mediaURL(media.StorageKey)
+	if media.Status == domain.MediaStatusReady {
+		media.Variants = s.images.Variants(media.URL)
+	}
+	for i := range media.Thumbnails {
+		thumbnail := &media.Thumbnails[i]
+		thumbnail.URL = s.mediaURL(thumbnailKey(media.StorageKey, thumbnail.Size, thumbnail.ContentType))
+	}
+	return media
+}
+
+// addImageVariants fills in the delivery URLs of products' images
+func (s *productService) addImageVariants(products ...*domain.Product) {
+	for _, product := range products {
+		product.Images = s.images.Variants(product.ImageURL)
+	}
+}
+
+// mediaURL is the URL an object is served from: under the public URL when
+// one is configured, otherwise straight from the bucket
+func (s *productService) mediaURL(key string) string {
+	if s.media.PublicURL != "" {
+		return strings.TrimRight(s.media.PublicURL, "/") + "/" + key
+	}
+	if s.storage == nil {
+		return ""
+	}
+	return s.storage.URL(key)
+}
+
+// mediaTypeAllowed reports whether uploads of a content type are accepted.
+// Without a configured list, every type thumbnails can be made of is.
+func (s *productService) mediaTypeAllowed(contentType string) bool {
+	if len(s.media.AllowedTypes) == 0 {
+		return imaging.CanDecode(contentType)
+	}
+	for _, allowed := range s.media.AllowedTypes {
+		if mediaType(allowed) == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *productService) maxUploadBytes() int64 {
+	return int64(s.media.MaxUploadSize) << 20
+}
+
+// mediaType strips parameters from a content type and lower-cases it
+func mediaType(contentType string) string {
+	parsed, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return parsed
+}
+
+// thumbnailKey is where a thumbnail is stored: next to the original, named
+// after the size of its box
+func thumbnailKey(originalKey string, size int, contentType string) string {
+	return fmt.Sprintf("%s/%d%s", path.Dir(originalKey), size, mediaExtensions[contentType])
+}
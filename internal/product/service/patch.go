@@ -0,0 +1,91 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/mergepatch"
+)
+
+// PatchProduct applies a JSON Merge Patch to a product. The patch is merged
+// into the product's document and the result is validated as a whole, so a
+// patch can clear optional fields with null but cannot remove required ones.
+func (s *productService) PatchProduct(ctx context.Context, id uuid.UUID, patch []byte) (*domain.Product, error) {
+	if !s.managesProduct(ctx, id) {
+		return nil, errors.NewForbiddenError("Updating a product requires the admin role", nil).WithCode(errors.CodeProductNotOwned)
+	}
+
+	product, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Product not found", err).WithCode(errors.CodeProductNotFound)
+		}
+		return nil, errors.NewInternalError("Failed to get product", err)
+	}
+
+	current, next, err := s.mergePatch(ctx, product, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	req, clears := current.Changes(next)
+	return s.updateProduct(ctx, product, req, clears)
+}
+
+// mergePatch merges a JSON Merge Patch into the document of a product and
+// validates the result, returning the documents before and after
+func (s *productService) mergePatch(ctx context.Context, product *domain.Product, patch []byte) (*domain.ProductDocument, *domain.ProductDocument, error) {
+	current := domain.NewProductDocument(product)
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return nil, nil, errors.NewInternalError("Failed to encode product", err)
+	}
+
+	merged, err := mergepatch.Apply(doc, patch)
+	if err != nil {
+		return nil, nil, errors.NewValidationError("Invalid merge patch", err)
+	}
+
+	next, err := decodeProductDocument(merged)
+	if err != nil {
+		return nil, nil, errors.NewValidationError("Invalid merge patch", err)
+	}
+	if err := s.validator.Validate(next); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid patch product request")
+		return nil, nil, errors.NewValidationError("Invalid request", err)
+	}
+	if next.Stock < 0 && next.Stock != current.Stock {
+		return nil, nil, errors.NewValidationError("Invalid request", fmt.Errorf("stock cannot be set below zero"))
+	}
+
+	return current, next, nil
+}
+
+// decodeProductDocument decodes a merged product document, rejecting
+// members that are not patchable and required members the patch removed
+func decodeProductDocument(data []byte) (*domain.ProductDocument, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, fmt.Errorf("patch must be a JSON object")
+	}
+	for _, name := range domain.ProductDocumentRequired {
+		if _, ok := members[name]; !ok {
+			return nil, fmt.Errorf("%s cannot be removed", name)
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var doc domain.ProductDocument
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
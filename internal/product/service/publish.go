@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+)
+
+// PublishProduct puts a product on the storefront, or schedules a draft to
+// be published at a later time
+func (s *productService) PublishProduct(ctx context.Context, id uuid.UUID, req *domain.PublishProductRequest) (*domain.Product, error) {
+	if !s.managesProduct(ctx, id) {
+		return nil, errors.NewForbiddenError("Publishing a product requires the admin role", nil).WithCode(errors.CodeProductNotOwned)
+	}
+
+	product, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Product not found", err).WithCode(errors.CodeProductNotFound)
+		}
+		return nil, errors.NewInternalError("Failed to get product", err)
+	}
+	if product.IsPublished() {
+		return nil, errors.NewConflictError("Product is already published", nil).WithCode(errors.CodeProductAlreadyPublished)
+	}
+
+	update := &domain.UpdateProductRequest{}
+	if req.PublishAt != nil && req.PublishAt.After(time.Now()) {
+		draft := domain.ProductStatusDraft
+		update.Status = &draft
+		update.PublishAt = req.PublishAt
+	} else {
+		published := domain.ProductStatusPublished
+		update.Status = &published
+	}
+
+	return s.updateProduct(ctx, product, update, domain.ProductClears{})
+}
+
+// UnpublishProduct takes a product off the storefront and cancels any
+// scheduled publishing, leaving it as a draft
+func (s *productService) UnpublishProduct(ctx context.Context, id uuid.UUID) (*domain.Product, error) {
+	if !s.managesProduct(ctx, id) {
+		return nil, errors.NewForbiddenError("Unpublishing a product requires the admin role", nil).WithCode(errors.CodeProductNotOwned)
+	}
+
+	product, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Product not found", err).WithCode(errors.CodeProductNotFound)
+		}
+		return nil, errors.NewInternalError("Failed to get product", err)
+	}
+	if product.Status == domain.ProductStatusDraft && product.PublishAt == nil {
+		return nil, errors.NewConflictError("Product is not published", nil).WithCode(errors.CodeProductNotPublished)
+	}
+
+	draft := domain.ProductStatusDraft
+	update := &domain.UpdateProductRequest{Status: &draft}
+	return s.updateProduct(ctx, product, update, domain.ProductClears{PublishAt: true})
+}
+
+// PublishDue publishes the drafts whose scheduled publish time has passed
+// and reports how many it published
+func (s *productService) PublishDue(ctx context.Context) (int, error) {
+	products, err := s.repo.PublishDue(ctx, s.publishing.CheckBatchSize)
+	if err != nil {
+		return 0, errors.NewInternalError("Failed to publish scheduled products", err)
+	}
+	if len(products) == 0 {
+		return 0, nil
+	}
+
+	if err := s.repo.InvalidateProductCache(ctx); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to invalidate product cache")
+	}
+	for i := range products {
+		s.publish(ctx, domain.EventProductUpdated, &products[i])
+	}
+
+	s.log(ctx).WithField("count", len(products)).Info("Scheduled products published successfully")
+	return len(products), nil
+}
+
+// visible reports whether the caller may see a product. Drafts and
+// archived products are only shown to admins and to the vendor selling
+// them.
+func visible(ctx context.Context, product *domain.Product) bool {
+	return product.IsPublished() || auth.HasRole(ctx, auth.RoleAdmin) || product.SoldBy(domain.VendorFromContext(ctx))
+}
+
+// ownListing reports whether a listing is of the products the calling
+// vendor sells
+func ownListing(ctx context.Context, filters *domain.ProductFilters) bool {
+	vendor := domain.VendorFromContext(ctx)
+	return vendor != nil && filters.VendorID != nil && *filters.VendorID == vendor.ID
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+)
+
+func (s *productService) CreateReview(ctx context.Context, productID uuid.UUID, req *domain.CreateReviewRequest) (*domain.Review, error) {
+	actor := auth.ActorFromContext(ctx)
+	if actor == nil {
+		return nil, errors.NewUnauthorizedError("Authentication required to review products", nil).WithCode(errors.CodeAuthenticationRequired)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid create review request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	// Verify product exists
+	if _, err := s.repo.GetByID(ctx, productID); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Product not found", err).WithCode(errors.CodeProductNotFound)
+		}
+		return nil, errors.NewInternalError("Failed to get product", err)
+	}
+
+	// One review per user per product
+	existing, err := s.repo.GetUserReview(ctx, productID, actor.ID)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, errors.NewInternalError("Failed to check existing review", err)
+	}
+	if existing != nil {
+		return nil, errors.NewConflictError("Product already reviewed by this user", nil).WithCode(errors.CodeReviewAlreadyExists)
+	}
+
+	review := &domain.Review{
+		ProductID: productID,
+		UserID:    actor.ID,
+		Rating:    req.Rating,
+		Title:     req.Title,
+		Body:      req.Body,
+		Status:    domain.ReviewStatusPending,
+	}
+
+	if err := s.repo.CreateReview(ctx, review); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to create review")
+		return nil, errors.NewInternalError("Failed to create review", err)
+	}
+
+	s.log(ctx).WithField("review_id", review.ID).Info("Review created successfully")
+	return review, nil
+}
+
+func (s *productService) ListProductReviews(ctx context.Context, productID uuid.UUID, filters *domain.ReviewFilters) (*domain.ReviewList, error) {
+	if _, err := s.repo.GetByID(ctx, productID); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Product not found", err).WithCode(errors.CodeProductNotFound)
+		}
+		return nil, errors.NewInternalError("Failed to get product", err)
+	}
+
+	// Only moderators may see reviews that are not yet published
+	if filters.Status == "" || !auth.HasRole(ctx, auth.RoleAdmin) {
+		filters.Status = domain.ReviewStatusApproved
+	}
+	filters.ProductID = &productID
+
+	return s.listReviews(ctx, filters)
+}
+
+func (s *productService) ListReviews(ctx context.Context, filters *domain.ReviewFilters) (*domain.ReviewList, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Review moderation requires the admin role", nil)
+	}
+	if filters.Status == "" {
+		filters.Status = domain.ReviewStatusPending
+	}
+
+	return s.listReviews(ctx, filters)
+}
+
+func (s *productService) ModerateReview(ctx context.Context, id uuid.UUID, req *domain.ModerateReviewRequest) (*domain.Review, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Review moderation requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid moderate review request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	review, err := s.repo.GetReview(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Review not found", err).WithCode(errors.CodeReviewNotFound)
+		}
+		return nil, errors.NewInternalError("Failed to get review", err)
+	}
+	previousStatus := review.Status
+
+	review.Status = req.Status
+	review.ModerationNote = req.Note
+
+	if err := s.repo.UpdateReview(ctx, review); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to update review")
+		return nil, errors.NewInternalError("Failed to update review", err)
+	}
+
+	// The product rating only changes when a review enters or leaves the approved set
+	if previousStatus != review.Status && (previousStatus == domain.ReviewStatusApproved || review.Status == domain.ReviewStatusApproved) {
+		if err := s.repo.RefreshProductRating(ctx, review.ProductID); err != nil {
+			s.log(ctx).WithError(err).Error("Failed to refresh product rating")
+			return nil, errors.NewInternalError("Failed to refresh product rating", err)
+		}
+		if err := s.repo.InvalidateProductCache(ctx); err != nil {
+			s.log(ctx).WithError(err).Error("Failed to invalidate product cache")
+		}
+		s.publish(ctx, domain.EventProductUpdated, &domain.Product{ID: review.ProductID})
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"review_id": review.ID,
+		"status":    review.Status,
+	}).Info("Review moderated successfully")
+	return review, nil
+}
+
+// listReviews applies pagination defaults and runs the review query
+func (s *productService) listReviews(ctx context.Context, filters *domain.ReviewFilters) (*domain.ReviewList, error) {
+	// Set default values
+	if filters.Limit <= 0 {
+		filters.Limit = 20
+	}
+	if filters.Limit > 100 {
+		filters.Limit = 100
+	}
+
+	reviews, total, err := s.repo.ListReviews(ctx, filters)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list reviews")
+		return nil, errors.NewInternalError("Failed to list reviews", err)
+	}
+
+	return &domain.ReviewList{
+		Reviews: reviews,
+		Total:   total,
+		Limit:   filters.Limit,
+		Offset:  filters.Offset,
+		HasMore: int64(filters.Offset+filters.Limit) < total,
+	}, nil
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+)
+
+// SubmitRevision proposes a change to a product for review. The change is
+// checked against the product as it is now, but only applied once a
+// reviewer approves it.
+func (s *productService) SubmitRevision(ctx context.Context, productID uuid.UUID, req *domain.SubmitRevisionRequest) (*domain.ProductRevision, error) {
+	actor := auth.ActorFromContext(ctx)
+	if actor == nil {
+		return nil, errors.NewUnauthorizedError("Authentication required to propose changes", nil).WithCode(errors.CodeAuthenticationRequired)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid submit revision request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	product, err := s.repo.GetByID(ctx, productID)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Product not found", err).WithCode(errors.CodeProductNotFound)
+		}
+		return nil, errors.NewInternalError("Failed to get product", err)
+	}
+
+	current, next, err := s.mergePatch(ctx, product, req.Patch)
+	if err != nil {
+		return nil, err
+	}
+	changes := domain.DiffFields(current, next)
+	if len(changes) == 0 {
+		return nil, errors.NewValidationError("Revision changes nothing", nil)
+	}
+
+	// Vendors hear about the review at the vendor's address when their
+	// credentials carry none
+	email := actor.Email
+	if vendor := domain.VendorFromContext(ctx); vendor != nil && email == "" {
+		email = vendor.Email
+	}
+
+	revision := &domain.ProductRevision{
+		ProductID:      productID,
+		Status:         domain.RevisionStatusPending,
+		Patch:          req.Patch,
+		Note:           req.Note,
+		SubmittedBy:    actor.ID,
+		SubmitterEmail: email,
+	}
+	if err := s.repo.CreateRevision(ctx, revision); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to create revision")
+		return nil, errors.NewInternalError("Failed to submit revision", err)
+	}
+	revision.Changes = changes
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"revision_id": revision.ID,
+		"product_id":  productID,
+	}).Info("Revision submitted successfully")
+	return revision, nil
+}
+
+// GetRevision returns a revision. Pending revisions carry what they would
+// change in the product as it is now.
+func (s *productService) GetRevision(ctx context.Context, id uuid.UUID) (*domain.ProductRevision, error) {
+	revision, err := s.repo.GetRevision(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Revision not found", err).WithCode(errors.CodeRevisionNotFound)
+		}
+		s.log(ctx).WithError(err).Error("Failed to get revision")
+		return nil, errors.NewInternalError("Failed to get revision", err)
+	}
+
+	// Submitters may follow their own revisions
+	if revision.SubmittedBy != auth.ActorID(ctx) && !s.managesProduct(ctx, revision.ProductID) {
+		return nil, errors.NewNotFoundError("Revision not found", nil).WithCode(errors.CodeRevisionNotFound)
+	}
+
+	if revision.Status == domain.RevisionStatusPending {
+		product, err := s.repo.GetByID(ctx, revision.ProductID)
+		if err != nil {
+			return nil, errors.NewInternalError("Failed to get product", err)
+		}
+		current, next, err := s.mergePatch(ctx, product, revision.Patch)
+		if err != nil {
+			// The product changed since the revision was submitted in a way
+			// that leaves the patch invalid; the reviewer has to reject it
+			s.log(ctx).WithError(err).WithField("revision_id", id).Warn("Revision no longer applies")
+			return revision, nil
+		}
+		revision.Changes = domain.DiffFields(current, next)
+	}
+
+	return revision, nil
+}
+
+// ListProductRevisions lists the changes proposed to a product
+func (s *productService) ListProductRevisions(ctx context.Context, productID uuid.UUID, filters *domain.RevisionFilters) (*domain.RevisionList, error) {
+	if !s.managesProduct(ctx, productID) {
+		return nil, errors.NewForbiddenError("Viewing revisions requires the admin role", nil)
+	}
+	filters.ProductID = &productID
+
+	return s.listRevisions(ctx, filters)
+}
+
+// ListRevisions lists the changes proposed to any product, by default those
+// awaiting review
+func (s *productService) ListRevisions(ctx context.Context, filters *domain.RevisionFilters) (*domain.RevisionList, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Reviewing revisions requires the admin role", nil)
+	}
+	if filters.Status == "" {
+		filters.Status = domain.RevisionStatusPending
+	}
+
+	return s.listRevisions(ctx, filters)
+}
+
+// ApproveRevision applies a proposed change to its product
+func (s *productService) ApproveRevision(ctx context.Context, id uuid.UUID, req *domain.ReviewRequest) (*domain.ProductRevision, error) {
+	return s.reviewRevision(ctx, id, domain.RevisionStatusApproved, req)
+}
+
+// RejectRevision turns down a proposed change, leaving its product as it is
+func (s *productService) RejectRevision(ctx context.Context, id uuid.UUID, req *domain.ReviewRequest) (*domain.ProductRevision, error) {
+	return s.reviewRevision(ctx, id, domain.RevisionStatusRejected, req)
+}
+
+// reviewRevision decides on a pending revision, applying it when approved,
+// and lets the submitter know
+func (s *productService) reviewRevision(ctx context.Context, id uuid.UUID, status string, req *domain.ReviewRequest) (*domain.ProductRevision, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Reviewing revisions requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid review request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	revision, err := s.repo.GetRevision(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Revision not found", err).WithCode(errors.CodeRevisionNotFound)
+		}
+		return nil, errors.NewInternalError("Failed to get revision", err)
+	}
+	if revision.Status != domain.RevisionStatusPending {
+		return nil, errors.NewConflictError("Revision is not awaiting review", nil).WithCode(errors.CodeRevisionNotPending)
+	}
+
+	product, err := s.repo.GetByID(ctx, revision.ProductID)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Product not found", err).WithCode(errors.CodeProductNotFound)
+		}
+		return nil, errors.NewInternalError("Failed to get product", err)
+	}
+
+	if status == domain.RevisionStatusApproved {
+		current, next, err := s.mergePatch(ctx, product, revision.Patch)
+		if err != nil {
+			return nil, err
+		}
+		update, clears := current.Changes(next)
+		if product, err = s.updateProduct(ctx, product, update, clears); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	revision.Status = status
+	revision.ReviewedBy = auth.ActorID(ctx)
+	revision.ReviewComment = req.Comment
+	revision.ReviewedAt = &now
+	if err := s.repo.UpdateRevision(ctx, revision); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to update revision")
+		return nil, errors.NewInternalError("Failed to review revision", err)
+	}
+
+	s.publish(ctx, domain.EventProductReviewed, &domain.ProductReviewed{
+		ProductID:   product.ID,
+		ProductName: product.Name,
+		RevisionID:  &revision.ID,
+		Status:      status,
+		Comment:     req.Comment,
+		SubmittedBy: revision.SubmittedBy,
+		Email:       revision.SubmitterEmail,
+	})
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"revision_id": id,
+		"product_id":  revision.ProductID,
+		"status":      status,
+	}).Info("Revision reviewed successfully")
+	return revision, nil
+}
+
+// listRevisions applies pagination defaults and runs the revision query
+func (s *productService) listRevisions(ctx context.Context, filters *domain.RevisionFilters) (*domain.RevisionList, error) {
+	switch filters.Status {
+	case "", domain.RevisionStatusPending, domain.RevisionStatusApproved, domain.RevisionStatusRejected:
+	default:
+		return nil, errors.NewValidationError("Invalid status filter", nil)
+	}
+
+	// Set default values
+	if filters.Limit <= 0 {
+		filters.Limit = 20
+	}
+	if filters.Limit > 100 {
+		filters.Limit = 100
+	}
+
+	revisions, total, err := s.repo.ListRevisions(ctx, filters)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list revisions")
+		return nil, errors.NewInternalError("Failed to list revisions", err)
+	}
+
+	return &domain.RevisionList{
+		Revisions: revisions,
+		Total:     total,
+		Limit:     filters.Limit,
+		Offset:    filters.Offset,
+		HasMore:   int64(filters.Offset+filters.Limit) < total,
+	}, nil
+}
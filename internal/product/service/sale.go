@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/errors"
+)
+
+// CheckSales switches the price of products whose sale window opened or
+// closed. The effective price is worked out whenever a product is served,
+// so this only clears the caches holding the old price and announces the
+// switch with product.updated. It returns how many products switched.
+func (s *productService) CheckSales(ctx context.Context) (int, error) {
+	products, err := s.repo.SyncSales(ctx, s.sale.CheckBatchSize)
+	if err != nil {
+		return 0, errors.NewInternalError("Failed to sync sales", err)
+	}
+	if len(products) == 0 {
+		return 0, nil
+	}
+
+	// Cached listings are sorted and filtered by the old price
+	if err := s.repo.InvalidateProductCache(ctx); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to invalidate product cache")
+	}
+	for i := range products {
+		s.publish(ctx, domain.EventProductUpdated, &products[i])
+	}
+
+	s.log(ctx).WithField("count", len(products)).Info("Sale prices switched successfully")
+	return len(products), nil
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+)
+
+func (s *productService) ListSearchSynonyms(ctx context.Context) ([]domain.SearchSynonym, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing search synonyms requires the admin role", nil)
+	}
+
+	synonyms, err := s.repo.ListSearchSynonyms(ctx)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list search synonyms")
+		return nil, errors.NewInternalError("Failed to list search synonyms", err)
+	}
+
+	return synonyms, nil
+}
+
+func (s *productService) CreateSearchSynonym(ctx context.Context, req *domain.CreateSearchSynonymRequest) (*domain.SearchSynonym, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing search synonyms requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid create search synonym request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	synonym := &domain.SearchSynonym{Term: domain.NormalizeSearchTerm(req.Term)}
+	if err := s.setSynonyms(synonym, req.Synonyms); err != nil {
+		return nil, err
+	}
+	if err := s.checkSynonymTerm(ctx, synonym.Term, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateSearchSynonym(ctx, synonym); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to create search synonym")
+		return nil, errors.NewInternalError("Failed to create search synonym", err)
+	}
+
+	s.log(ctx).WithField("synonym_id", synonym.ID).Info("Search synonym created successfully")
+	return synonym, nil
+}
+
+func (s *productService) UpdateSearchSynonym(ctx context.Context, id uuid.UUID, req *domain.UpdateSearchSynonymRequest) (*domain.SearchSynonym, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing search synonyms requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid update search synonym request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	synonym, err := s.repo.GetSearchSynonym(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Search synonym not found", err).WithCode(errors.CodeSynonymNotFound)
+		}
+		return nil, errors.NewInternalError("Failed to get search synonym", err)
+	}
+
+	if req.Term != nil {
+		synonym.Term = domain.NormalizeSearchTerm(*req.Term)
+		if err := s.checkSynonymTerm(ctx, synonym.Term, id); err != nil {
+			return nil, err
+		}
+	}
+	if req.Synonyms != nil {
+		if err := s.setSynonyms(synonym, req.Synonyms); err != nil {
+			return nil, err
+		}
+	} else if err := s.setSynonyms(synonym, synonym.Synonyms); err != nil {
+		// A new term may now appear among the existing synonyms
+		return nil, err
+	}
+
+	if err := s.repo.UpdateSearchSynonym(ctx, synonym); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to update search synonym")
+		return nil, errors.NewInternalError("Failed to update search synonym", err)
+	}
+
+	s.log(ctx).WithField("synonym_id", id).Info("Search synonym updated successfully")
+	return synonym, nil
+}
+
+func (s *productService) DeleteSearchSynonym(ctx context.Context, id uuid.UUID) error {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return errors.NewForbiddenError("Managing search synonyms requires the admin role", nil)
+	}
+
+	if _, err := s.repo.GetSearchSynonym(ctx, id); err != nil {
+		if errors.IsNotFound(err) {
+			return errors.NewNotFoundError("Search synonym not found", err).WithCode(errors.CodeSynonymNotFound)
+		}
+		return errors.NewInternalError("Failed to get search synonym", err)
+	}
+
+	if err := s.repo.DeleteSearchSynonym(ctx, id); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to delete search synonym")
+		return errors.NewInternalError("Failed to delete search synonym", err)
+	}
+
+	s.log(ctx).WithField("synonym_id", id).Info("Search synonym deleted successfully")
+	return nil
+}
+
+// ListZeroResultSearches reports the storefront searches that found no
+// products, most searched first
+func (s *productService) ListZeroResultSearches(ctx context.Context, filters *domain.ZeroResultFilters) (*domain.ZeroResultSearchList, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Viewing search reports requires the admin role", nil)
+	}
+
+	// Set default values
+	if filters.Limit <= 0 {
+		filters.Limit = 50
+	}
+	if filters.Limit > 500 {
+		filters.Limit = 500
+	}
+
+	searches, total, err := s.repo.ListZeroResultSearches(ctx, filters)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list zero-result searches")
+		return nil, errors.NewInternalError("Failed to list zero-result searches", err)
+	}
+
+	return &domain.ZeroResultSearchList{
+		Searches: searches,
+		Total:    total,
+		Limit:    filters.Limit,
+		Offset:   filters.Offset,
+		HasMore:  int64(filters.Offset+filters.Limit) < total,
+	}, nil
+}
+
+// DeleteZeroResultSearch removes a query from the report, typically once a
+// synonym or product covers it
+func (s *productService) DeleteZeroResultSearch(ctx context.Context, id uuid.UUID) error {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return errors.NewForbiddenError("Viewing search reports requires the admin role", nil)
+	}
+
+	deleted, err := s.repo.DeleteZeroResultSearch(ctx, id)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to delete zero-result search")
+		return errors.NewInternalError("Failed to delete zero-result search", err)
+	}
+	if !deleted {
+		return errors.NewNotFoundError("Zero-result search not found", nil)
+	}
+
+	s.log(ctx).WithField("search_id", id).Info("Zero-result search deleted successfully")
+	return nil
+}
+
+// expandSearch adds the synonym rephrasings of a search to its filters. A
+// failure is logged and the search runs as typed.
+func (s *productService) expandSearch(ctx context.Context, filters *domain.ProductFilters) {
+	filters.SearchAlternatives = nil
+	if filters.Search == "" {
+		return
+	}
+
+	synonyms, err := s.repo.ListSearchSynonyms(ctx)
+	if err != nil {
+		s.log(ctx).WithError(err).Warn("Failed to load search synonyms")
+		return
+	}
+	filters.SearchAlternatives = domain.ExpandSearch(filters.Search, synonyms)
+}
+
+// recordZeroResults counts a storefront search that found nothing. Only
+// the first page counts, so paging past the end of results doesn't.
+func (s *productService) recordZeroResults(ctx context.Context, filters *domain.ProductFilters, total int64) {
+	if filters.Search == "" || total > 0 || filters.Offset > 0 || filters.After != nil || auth.HasRole(ctx, auth.RoleAdmin) {
+		return
+	}
+
+	query := domain.NormalizeSearchTerm(filters.Search)
+	if err := s.repo.RecordZeroResultSearch(ctx, query); err != nil {
+		s.log(ctx).WithError(err).WithFields(logrus.Fields{"query": query}).Warn("Failed to record zero-result search")
+	}
+}
+
+// setSynonyms normalizes and deduplicates a synonym's words, dropping the
+// term itself
+func (s *productService) setSynonyms(synonym *domain.SearchSynonym, words []string) error {
+	seen := map[string]bool{synonym.Term: true}
+	normalized := make([]string, 0, len(words))
+	for _, word := range words {
+		word = domain.NormalizeSearchTerm(word)
+		if word == "" || seen[word] {
+			continue
+		}
+		seen[word] = true
+		normalized = append(normalized, word)
+	}
+	if synonym.Term == "" || len(normalized) == 0 {
+		return errors.NewValidationError("A synonym needs a term and at least one other word", nil)
+	}
+	synonym.Synonyms = normalized
+	return nil
+}
+
+// checkSynonymTerm rejects a term another synonym already has
+func (s *productService) checkSynonymTerm(ctx context.Context, term string, id uuid.UUID) error {
+	existing, err := s.repo.GetSearchSynonymByTerm(ctx, term)
+	if err != nil && !errors.IsNotFound(err) {
+		return errors.NewInternalError("Failed to validate synonym term", err)
+	}
+	if existing != nil && existing.ID != id {
+		return errors.NewConflictError("A synonym for this term already exists", nil).WithCode(errors.CodeSynonymTermConflict)
+	}
+	return nil
+}
@@ -2,95 +2,418 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 
+	"ecommerce/internal/product/config"
 	"ecommerce/internal/product/domain"
+	"ecommerce/internal/product/flashsale"
+	"ecommerce/internal/product/importer"
 	"ecommerce/internal/product/repository"
+	"ecommerce/internal/product/search"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/database"
 	"ecommerce/pkg/errors"
+	"ecommerce/pkg/events"
+	"ecommerce/pkg/jobs"
+	"ecommerce/pkg/logger"
+	"ecommerce/pkg/media"
+	"ecommerce/pkg/storage"
 	"ecommerce/pkg/validator"
 )
 
 // ProductService defines the product service interface
 type ProductService interface {
 	CreateProduct(ctx context.Context, req *domain.CreateProductRequest) (*domain.Product, error)
+	UpsertProductBySKU(ctx context.Context, sku string, req *domain.CreateProductRequest) (*domain.Product, bool, error)
 	GetProduct(ctx context.Context, id uuid.UUID) (*domain.Product, error)
+	GetProductBySlug(ctx context.Context, slug string) (*domain.Product, error)
 	UpdateProduct(ctx context.Context, id uuid.UUID, req *domain.UpdateProductRequest) (*domain.Product, error)
+	PatchProduct(ctx context.Context, id uuid.UUID, patch []byte) (*domain.Product, error)
 	DeleteProduct(ctx context.Context, id uuid.UUID) error
+	RestoreProduct(ctx context.Context, id uuid.UUID) (*domain.Product, error)
+	DuplicateProduct(ctx context.Context, id uuid.UUID, req *domain.DuplicateProductRequest) (*domain.Product, error)
+	BulkUpdateProducts(ctx context.Context, req *domain.BulkUpdateRequest) (*domain.BulkUpdateResult, error)
+
+	AddProductRelation(ctx context.Context, productID uuid.UUID, req *domain.CreateProductRelationRequest) (*domain.ProductRelation, error)
+	RemoveProductRelation(ctx context.Context, productID, relatedID uuid.UUID, relationType string) error
+	GetRelatedProducts(ctx context.Context, productID uuid.UUID, relationType string) ([]domain.RelatedProduct, error)
+	ListProductTranslations(ctx context.Context, id uuid.UUID) ([]domain.ProductTranslation, error)
+	UpsertProductTranslation(ctx context.Context, id uuid.UUID, locale string, req *domain.UpsertProductTranslationRequest) (*domain.ProductTranslation, error)
+	DeleteProductTranslation(ctx context.Context, id uuid.UUID, locale string) error
+	CreateMediaUpload(ctx context.Context, productID uuid.UUID, req *domain.CreateMediaUploadRequest) (*domain.MediaUpload, error)
+	CompleteMediaUpload(ctx context.Context, productID, mediaID uuid.UUID) (*domain.Media, error)
+	ListProductMedia(ctx context.Context, productID uuid.UUID) ([]domain.Media, error)
+	DeleteMedia(ctx context.Context, productID, mediaID uuid.UUID) error
+	PurgeMedia(ctx context.Context) (int, error)
+	CreateDigitalAsset(ctx context.Context, productID uuid.UUID, req *domain.CreateDigitalAssetRequest) (*domain.DigitalAsset, error)
+	ListDigitalAssets(ctx context.Context, productID uuid.UUID) ([]domain.DigitalAsset, error)
+	DeleteDigitalAsset(ctx context.Context, productID, assetID uuid.UUID) error
+	SetBundleComponents(ctx context.Context, id uuid.UUID, req *domain.SetBundleComponentsRequest) (*domain.Product, error)
+	SetPriceTiers(ctx context.Context, id uuid.UUID, req *domain.SetPriceTiersRequest) (*domain.Product, error)
+	SetProductChannels(ctx context.Context, id uuid.UUID, req *domain.SetProductChannelsRequest) (*domain.Product, error)
+	ApproveProduct(ctx context.Context, id uuid.UUID, req *domain.ReviewRequest) (*domain.Product, error)
+	RejectProduct(ctx context.Context, id uuid.UUID, req *domain.ReviewRequest) (*domain.Product, error)
+	SubmitRevision(ctx context.Context, productID uuid.UUID, req *domain.SubmitRevisionRequest) (*domain.ProductRevision, error)
+	ListProductRevisions(ctx context.Context, productID uuid.UUID, filters *domain.RevisionFilters) (*domain.RevisionList, error)
+	ListRevisions(ctx context.Context, filters *domain.RevisionFilters) (*domain.RevisionList, error)
+	GetRevision(ctx context.Context, id uuid.UUID) (*domain.ProductRevision, error)
+	ApproveRevision(ctx context.Context, id uuid.UUID, req *domain.ReviewRequest) (*domain.ProductRevision, error)
+	RejectRevision(ctx context.Context, id uuid.UUID, req *domain.ReviewRequest) (*domain.ProductRevision, error)
+
+	CreateChangeset(ctx context.Context, req *domain.CreateChangesetRequest) (*domain.Changeset, error)
+	GetChangeset(ctx context.Context, id uuid.UUID) (*domain.Changeset, error)
+	UpdateChangeset(ctx context.Context, id uuid.UUID, req *domain.UpdateChangesetRequest) (*domain.Changeset, error)
+	DeleteChangeset(ctx context.Context, id uuid.UUID) error
+	ListChangesets(ctx context.Context, filters *domain.ChangesetFilters) (*domain.ChangesetList, error)
+	SetChangesetItem(ctx context.Context, id, productID uuid.UUID, req *domain.SetChangesetItemRequest) (*domain.ChangesetItem, error)
+	DeleteChangesetItem(ctx context.Context, id, productID uuid.UUID) error
+	PreviewChangeset(ctx context.Context, id uuid.UUID) (*domain.Changeset, error)
+	ApplyChangeset(ctx context.Context, id uuid.UUID) (*domain.Changeset, error)
+	ScheduleChangeset(ctx context.Context, id uuid.UUID, req *domain.ScheduleChangesetRequest) (*domain.Changeset, error)
+	UnscheduleChangeset(ctx context.Context, id uuid.UUID) (*domain.Changeset, error)
+	RollbackChangeset(ctx context.Context, id uuid.UUID) (*domain.Changeset, error)
+	ApplyDueChangesets(ctx context.Context) (int, error)
+
+	SetFlashSale(ctx context.Context, productID uuid.UUID, req *domain.SetFlashSaleRequest) (*domain.FlashSale, error)
+	GetFlashSale(ctx context.Context, productID uuid.UUID) (*domain.FlashSale, error)
+	DeleteFlashSale(ctx context.Context, productID uuid.UUID) error
+	PurchaseFlashSale(ctx context.Context, productID uuid.UUID, req *domain.FlashSalePurchaseRequest) (*domain.FlashSalePurchase, error)
+	SyncFlashSales(ctx context.Context) (int, error)
+	HandleEvent(ctx context.Context, event *events.Event) (int, error)
+	ListEntitlements(ctx context.Context, filters *domain.EntitlementFilters) (*domain.EntitlementList, error)
+	GetEntitlement(ctx context.Context, id uuid.UUID) (*domain.Entitlement, error)
+
+	CreateReview(ctx context.Context, productID uuid.UUID, req *domain.CreateReviewRequest) (*domain.Review, error)
+	ListProductReviews(ctx context.Context, productID uuid.UUID, filters *domain.ReviewFilters) (*domain.ReviewList, error)
+	ListReviews(ctx context.Context, filters *domain.ReviewFilters) (*domain.ReviewList, error)
+	ModerateReview(ctx context.Context, id uuid.UUID, req *domain.ModerateReviewRequest) (*domain.Review, error)
+
+	ReserveStock(ctx context.Context, req *domain.ReserveStockRequest) (*domain.Reservation, error)
+	ListLowStockProducts(ctx context.Context, filters *domain.LowStockFilters) (*domain.ProductList, error)
+	CheckLowStock(ctx context.Context) (int, error)
+	AdjustStock(ctx context.Context, id uuid.UUID, req *domain.AdjustStockRequest) (*domain.StockMovement, error)
+	BulkAdjustStock(ctx context.Context, req *domain.BulkStockAdjustmentRequest) (*domain.BulkStockAdjustmentResult, error)
+	GetStockHistory(ctx context.Context, id uuid.UUID, filters *domain.StockMovementFilters) (*domain.StockMovementList, error)
+	CheckStockConsistency(ctx context.Context) ([]domain.StockDrift, error)
+	CheckSales(ctx context.Context) (int, error)
+	PublishProduct(ctx context.Context, id uuid.UUID, req *domain.PublishProductRequest) (*domain.Product, error)
+	UnpublishProduct(ctx context.Context, id uuid.UUID) (*domain.Product, error)
+	PublishDue(ctx context.Context) (int, error)
+	ReleaseStock(ctx context.Context, reference string) (*domain.Reservation, error)
+	ReturnStock(ctx context.Context, req *domain.ReturnStockRequest) ([]domain.StockMovement, error)
+	GetStockReservation(ctx context.Context, reference string) (*domain.Reservation, error)
+
 	ListProducts(ctx context.Context, filters *domain.ProductFilters) (*domain.ProductList, error)
 	SearchProducts(ctx context.Context, query string, filters *domain.ProductFilters) (*domain.ProductList, error)
+	ListSearchSynonyms(ctx context.Context) ([]domain.SearchSynonym, error)
+	CreateSearchSynonym(ctx context.Context, req *domain.CreateSearchSynonymRequest) (*domain.SearchSynonym, error)
+	UpdateSearchSynonym(ctx context.Context, id uuid.UUID, req *domain.UpdateSearchSynonymRequest) (*domain.SearchSynonym, error)
+	DeleteSearchSynonym(ctx context.Context, id uuid.UUID) error
+	ListZeroResultSearches(ctx context.Context, filters *domain.ZeroResultFilters) (*domain.ZeroResultSearchList, error)
+	DeleteZeroResultSearch(ctx context.Context, id uuid.UUID) error
 
 	CreateCategory(ctx context.Context, req *domain.CreateCategoryRequest) (*domain.Category, error)
 	GetCategory(ctx context.Context, id uuid.UUID) (*domain.Category, error)
+	GetCategoryBySlug(ctx context.Context, slug string) (*domain.Category, error)
 	UpdateCategory(ctx context.Context, id uuid.UUID, req *domain.UpdateCategoryRequest) (*domain.Category, error)
 	DeleteCategory(ctx context.Context, id uuid.UUID) error
-	ListCategories(ctx context.Context) ([]domain.Category, error)
+	MoveCategory(ctx context.Context, id uuid.UUID, req *domain.MoveCategoryRequest) (*domain.Category, error)
+	MergeCategory(ctx context.Context, id uuid.UUID, req *domain.MergeCategoryRequest) (*domain.Category, error)
+	ListCategories(ctx context.Context, filters *domain.CategoryFilters) ([]domain.Category, error)
+	GetCategoryTree(ctx context.Context, rootID *uuid.UUID) ([]domain.Category, error)
+	ListCategoryTranslations(ctx context.Context, id uuid.UUID) ([]domain.CategoryTranslation, error)
+	UpsertCategoryTranslation(ctx context.Context, id uuid.UUID, locale string, req *domain.UpsertCategoryTranslationRequest) (*domain.CategoryTranslation, error)
+	DeleteCategoryTranslation(ctx context.Context, id uuid.UUID, locale string) error
+
+	CreateAttributeDefinition(ctx context.Context, categoryID uuid.UUID, req *domain.CreateAttributeDefinitionRequest) (*domain.AttributeDefinition, error)
+	UpdateAttributeDefinition(ctx context.Context, id uuid.UUID, req *domain.UpdateAttributeDefinitionRequest) (*domain.AttributeDefinition, error)
+	DeleteAttributeDefinition(ctx context.Context, id uuid.UUID) error
+	ListAttributeDefinitions(ctx context.Context, categoryID uuid.UUID) ([]domain.AttributeDefinition, error)
+
+	CreateBrand(ctx context.Context, req *domain.CreateBrandRequest) (*domain.Brand, error)
+	GetBrand(ctx context.Context, id uuid.UUID) (*domain.Brand, error)
+	UpdateBrand(ctx context.Context, id uuid.UUID, req *domain.UpdateBrandRequest) (*domain.Brand, error)
+	DeleteBrand(ctx context.Context, id uuid.UUID) error
+	ListBrands(ctx context.Context) ([]domain.Brand, error)
+
+	CreateVendor(ctx context.Context, req *domain.CreateVendorRequest) (*domain.Vendor, error)
+	GetVendor(ctx context.Context, id uuid.UUID) (*domain.Vendor, error)
+	GetOwnVendor(ctx context.Context) (*domain.Vendor, error)
+	UpdateVendor(ctx context.Context, id uuid.UUID, req *domain.UpdateVendorRequest) (*domain.Vendor, error)
+	ListVendors(ctx context.Context) ([]domain.Vendor, error)
+	VendorScope(ctx context.Context) (context.Context, error)
+	AuthorizeVendorChange(ctx context.Context, productID *uuid.UUID) error
+
+	CreateWarehouse(ctx context.Context, req *domain.CreateWarehouseRequest) (*domain.Warehouse, error)
+	GetWarehouse(ctx context.Context, id uuid.UUID) (*domain.Warehouse, error)
+	UpdateWarehouse(ctx context.Context, id uuid.UUID, req *domain.UpdateWarehouseRequest) (*domain.Warehouse, error)
+	ListWarehouses(ctx context.Context) ([]domain.Warehouse, error)
+	AddAvailability(ctx context.Context, warehouse string, products ...*domain.Product) error
+
+	ExportProducts(ctx context.Context, filters *domain.ProductFilters, fn func([]domain.Product) error) error
+	ImportProducts(ctx context.Context, filename string, data []byte) (*domain.ImportJob, error)
+	GetImportJob(ctx context.Context, id uuid.UUID) (*domain.ImportJob, error)
+
+	GetAuditEvent(ctx context.Context, id uuid.UUID) (*domain.AuditEvent, error)
+	ListAuditEvents(ctx context.Context, filters *domain.AuditFilters) (*domain.AuditEventList, error)
+
+	InspectCacheEntry(ctx context.Context, key string) (*domain.CacheEntry, error)
+	ListCacheKeys(ctx context.Context, pattern string, limit int) (*domain.CacheKeyList, error)
+	PurgeCache(ctx context.Context, req *domain.PurgeCacheRequest) (*domain.PurgeCacheResult, error)
+	WarmCache(ctx context.Context, req *domain.WarmCacheRequest) (*domain.WarmCacheResult, error)
+	WarmCatalog(ctx context.Context, pages int) (int, error)
+
+	RegisterJobs(worker *jobs.Worker)
 }
 
 type productService struct {
-	repo      repository.ProductRepository
-	logger    *logrus.Logger
-	validator *validator.Validator
+	repo       repository.ProductRepository
+	catalog    search.Searcher
+	searcher   search.Searcher
+	publisher  events.Publisher
+	jobs       *jobs.Queue
+	storage    *storage.S3
+	images     *media.Builder
+	flashSales *flashsale.Store
+	stock      config.StockConfig
+	sale       config.SaleConfig
+	publishing config.PublishConfig
+	flashSale  config.FlashSaleConfig
+	locales    config.LocaleConfig
+	media      config.MediaConfig
+	logger     *logrus.Logger
+	validator  *validator.Validator
+
+	// tx runs repository calls that must succeed or fail together
+	tx database.TxManager
 }
 
 // NewProductService creates a new product service
-func NewProductService(repo repository.ProductRepository, logger *logrus.Logger) ProductService {
+func NewProductService(repo repository.ProductRepository, tx database.TxManager, searcher search.Searcher, publisher events.Publisher, queue *jobs.Queue, mediaStorage *storage.S3, images *media.Builder, flashSales *flashsale.Store, stock config.StockConfig, sale config.SaleConfig, publishing config.PublishConfig, flashSale config.FlashSaleConfig, locales config.LocaleConfig, mediaConfig config.MediaConfig, logger *logrus.Logger) ProductService {
 	return &productService{
-		repo:      repo,
-		logger:    logger,
-		validator: validator.New(),
+		repo:       repo,
+		catalog:    search.NewPostgresSearcher(repo),
+		searcher:   searcher,
+		publisher:  publisher,
+		jobs:       queue,
+		storage:    mediaStorage,
+		images:     images,
+		flashSales: flashSales,
+		stock:      stock,
+		sale:       sale,
+		publishing: publishing,
+		flashSale:  flashSale,
+		locales:    locales,
+		media:      mediaConfig,
+		logger:     logger,
+		validator:  validator.New(),
+		tx:         tx,
 	}
 }
 
+// log returns the logger of the request ctx belongs to
+func (s *productService) log(ctx context.Context) *logrus.Entry {
+	return logger.FromContext(ctx, s.logger)
+}
+
 func (s *productService) CreateProduct(ctx context.Context, req *domain.CreateProductRequest) (*domain.Product, error) {
-	// Validate request
-	if err := s.validator.Validate(req); err != nil {
-		s.logger.WithError(err).Error("Invalid create product request")
-		return nil, errors.NewValidationError("Invalid request", err)
+	if !s.managesCatalog(ctx) {
+		return nil, errors.NewForbiddenError("Creating products requires the admin role", nil)
+	}
+
+	product, err := s.newProduct(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the SKU, create the product and record its audit event as one
+	// unit, so a product is never left without its audit trail
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		return s.insertProduct(ctx, product)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Invalidate cache
+	if err := s.repo.InvalidateProductCache(ctx); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to invalidate product cache")
+		return nil, errors.NewInternalError("Failed to invalidate cache", err)
 	}
 
-	// Check if SKU already exists
-	existing, err := s.repo.GetBySKU(ctx, req.SKU)
+	s.publish(ctx, domain.EventProductCreated, product)
+
+	s.log(ctx).WithField("product_id", product.ID).Info("Product created successfully")
+	return product, nil
+}
+
+// insertProduct checks a new product's SKU is free, gives it a slug and
+// creates it with its audit event. Callers run it in a transaction.
+func (s *productService) insertProduct(ctx context.Context, product *domain.Product) error {
+	// Check if SKU already exists, on the primary as a replica may not
+	// have a product created just before
+	existing, err := s.repo.GetBySKU(database.WithPrimary(ctx), product.SKU)
 	if err != nil && !errors.IsNotFound(err) {
-		s.logger.WithError(err).Error("Failed to check SKU uniqueness")
-		return nil, errors.NewInternalError("Failed to validate SKU", err)
+		s.log(ctx).WithError(err).Error("Failed to check SKU uniqueness")
+		return errors.NewInternalError("Failed to validate SKU", err)
 	}
 	if existing != nil {
-		return nil, errors.NewConflictError("SKU already exists", nil)
+		return errors.NewConflictError("SKU already exists", nil).WithCode(errors.CodeProductSKUConflict)
+	}
+
+	slug, err := s.repo.UniqueSlug(ctx, domain.AuditEntityProduct, domain.Slugify(product.Name), uuid.Nil)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to generate product slug")
+		return errors.NewInternalError("Failed to generate slug", err)
+	}
+	product.Slug = slug
+
+	movement := domain.StockMovement{Reason: domain.StockReasonInitial, ActorID: auth.ActorID(ctx)}
+	if err := s.repo.Create(ctx, product, movement); err != nil {
+		if errors.IsConflict(err) {
+			return err
+		}
+		s.log(ctx).WithError(err).Error("Failed to create product")
+		return errors.NewInternalError("Failed to create product", err)
+	}
+
+	if err := s.repo.CreateAuditEvent(ctx, s.auditEvent(ctx, domain.AuditEntityProduct, product.ID, domain.AuditActionCreate, nil, product)); err != nil {
+		s.log(ctx).WithError(err).WithField("product_id", product.ID).Error("Failed to record audit event")
+		return errors.NewInternalError("Failed to create product", err)
+	}
+	return nil
+}
+
+// newProduct validates a create request and builds the product it
+// describes; the SKU's uniqueness and the slug are left to the caller
+func (s *productService) newProduct(ctx context.Context, req *domain.CreateProductRequest) (*domain.Product, error) {
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid create product request")
+		return nil, errors.NewValidationError("Invalid request", err)
 	}
 
 	// Verify category exists
 	if _, err := s.repo.GetCategory(ctx, req.CategoryID); err != nil {
 		if errors.IsNotFound(err) {
-			return nil, errors.NewNotFoundError("Category not found", err)
+			return nil, errors.NewNotFoundError("Category not found", err).WithCode(errors.CodeCategoryNotFound)
 		}
 		return nil, errors.NewInternalError("Failed to verify category", err)
 	}
 
-	product := &domain.Product{
-		Name:        req.Name,
-		Description: req.Description,
-		Price:       req.Price,
-		CategoryID:  req.CategoryID,
-		Stock:       req.Stock,
-		ImageURL:    req.ImageURL,
-		SKU:         req.SKU,
-		IsActive:    true,
+	// Verify brand exists if specified
+	if req.BrandID != nil {
+		if _, err := s.repo.GetBrand(ctx, *req.BrandID); err != nil {
+			if errors.IsNotFound(err) {
+				return nil, errors.NewNotFoundError("Brand not found", err).WithCode(errors.CodeBrandNotFound)
+			}
+			return nil, errors.NewInternalError("Failed to verify brand", err)
+		}
 	}
 
-	if err := s.repo.Create(ctx, product); err != nil {
-		s.logger.WithError(err).Error("Failed to create product")
-		return nil, errors.NewInternalError("Failed to create product", err)
+	// Validate attributes against the category's definitions
+	defs, err := s.repo.ListAttributeDefinitions(ctx, req.CategoryID)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to load attribute definitions")
+		return nil, errors.NewInternalError("Failed to validate attributes", err)
+	}
+	attributes, err := domain.ValidateAttributes(defs, req.Attributes)
+	if err != nil {
+		return nil, errors.NewValidationError("Invalid attributes", err)
 	}
 
-	// Invalidate cache
-	if err := s.repo.InvalidateProductCache(ctx); err != nil {
-		s.logger.WithError(err).Error("Failed to invalidate product cache")
-		return nil, errors.NewInternalError("Failed to invalidate cache", err)
+	product := &domain.Product{
+		Name:       req.Name,
+		Price:      req.Price,
+		CategoryID: req.CategoryID,
+		BrandID:    req.BrandID,
+		Stock:      req.Stock,
+		ImageURL:   req.ImageURL,
+		SKU:        req.SKU,
+		GTIN:       req.GTIN,
+		IsActive:   true,
+		Type:       req.Type,
+		Attributes: attributes,
+
+		LowStockThreshold: req.LowStockThreshold,
+
+		SalePrice:    req.SalePrice,
+		SaleStartsAt: req.SaleStartsAt,
+		SaleEndsAt:   req.SaleEndsAt,
+
+		AllowBackorder: req.AllowBackorder,
+		Preorder:       req.Preorder,
+		ReleaseDate:    req.ReleaseDate,
+
+		BundlePricing:  req.BundlePricing,
+		BundleDiscount: req.BundleDiscount,
+
+		UnitOfMeasure:     req.UnitOfMeasure,
+		QuantityIncrement: req.QuantityIncrement,
+		MinOrderQuantity:  req.MinOrderQuantity,
+		MaxOrderQuantity:  req.MaxOrderQuantity,
+
+		TaxClass: req.TaxClass,
+	}
+	product.SetDescription(req.Description)
+	if product.Type == "" {
+		product.Type = domain.ProductTypePhysical
+	}
+	if product.UnitOfMeasure == "" {
+		product.UnitOfMeasure = domain.UnitEach
+	}
+	if product.QuantityIncrement == 0 {
+		product.QuantityIncrement = 1
+	}
+	if product.TaxClass == "" {
+		// Gift cards are a means of payment; what they buy is taxed
+		product.TaxClass = domain.TaxClassStandard
+		if product.Type == domain.ProductTypeGiftCard {
+			product.TaxClass = domain.TaxClassExempt
+		}
+	}
+	if product.Type == domain.ProductTypeBundle && product.BundlePricing == "" {
+		product.BundlePricing = domain.BundlePricingFixed
+	}
+	if err := validateBundlePricing(product); err != nil {
+		return nil, err
+	}
+	if !product.IsPhysical() && product.Stock != 0 {
+		return nil, errors.NewValidationError("Only physical products hold stock", nil).WithCode(errors.CodeProductNotStocked)
+	}
+	// Products vendors list are theirs, and start as drafts awaiting review
+	status := req.Status
+	if status == "" {
+		status = domain.ProductStatusPublished
+	}
+	if vendor := domain.VendorFromContext(ctx); vendor != nil {
+		product.VendorID = &vendor.ID
+		product.ApprovalStatus = domain.ApprovalPending
+		if req.Status == "" {
+			status = domain.ProductStatusDraft
+		}
+	}
+	product.SetStatus(status, time.Now())
+	product.PublishAt = req.PublishAt
+	if err := product.ValidateSchedule(); err != nil {
+		return nil, errors.NewValidationError("Invalid publish time", err)
+	}
+	if err := product.ValidateApproval(); err != nil {
+		return nil, errors.NewConflictError("Product is not approved", err).WithCode(errors.CodeProductNotApproved)
+	}
+	if err := product.ValidateSale(); err != nil {
+		return nil, errors.NewValidationError("Invalid sale", err)
+	}
+	if err := product.ValidateGTIN(); err != nil {
+		return nil, errors.NewValidationError("Invalid GTIN", err)
+	}
+	if err := product.ValidateQuantities(); err != nil {
+		return nil, errors.NewValidationError("Invalid order quantities", err)
 	}
 
-	s.logger.WithField("product_id", product.ID).Info("Product created successfully")
 	return product, nil
 }
 
@@ -98,19 +421,60 @@ func (s *productService) GetProduct(ctx context.Context, id uuid.UUID) (*domain.
 	product, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		if errors.IsNotFound(err) {
-			return nil, errors.NewNotFoundError("Product not found", err)
+			return nil, errors.NewNotFoundError("Product not found", err).WithCode(errors.CodeProductNotFound)
 		}
-		s.logger.WithError(err).Error("Failed to get product")
+		s.log(ctx).WithError(err).Error("Failed to get product")
 		return nil, errors.NewInternalError("Failed to get product", err)
 	}
+	if !visible(ctx, product) {
+		return nil, errors.NewNotFoundError("Product not found", nil).WithCode(errors.CodeProductNotFound)
+	}
+	s.addBreadcrumbs(ctx, product)
+	s.localize(ctx, product)
+	s.addImageVariants(product)
+	s.addBundle(ctx, product)
+	s.addPricing(ctx, product)
 
 	return product, nil
 }
 
+func (s *productService) GetProductBySlug(ctx context.Context, slug string) (*domain.Product, error) {
+	product, err := s.repo.GetBySlug(ctx, slug)
+	if err == nil {
+		if !visible(ctx, product) {
+			return nil, errors.NewNotFoundError("Product not found", nil).WithCode(errors.CodeProductNotFound)
+		}
+		s.addBreadcrumbs(ctx, product)
+		s.localize(ctx, product)
+		s.addImageVariants(product)
+		s.addBundle(ctx, product)
+		s.addPricing(ctx, product)
+		return product, nil
+	}
+	if !errors.IsNotFound(err) {
+		s.log(ctx).WithError(err).Error("Failed to get product by slug")
+		return nil, errors.NewInternalError("Failed to get product", err)
+	}
+
+	redirect, err := s.repo.GetSlugRedirect(ctx, domain.AuditEntityProduct, slug)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Product not found", err).WithCode(errors.CodeProductNotFound)
+		}
+		return nil, errors.NewInternalError("Failed to resolve slug", err)
+	}
+
+	return s.GetProduct(ctx, redirect.EntityID)
+}
+
 func (s *productService) UpdateProduct(ctx context.Context, id uuid.UUID, req *domain.UpdateProductRequest) (*domain.Product, error) {
+	if !s.managesProduct(ctx, id) {
+		return nil, errors.NewForbiddenError("Updating a product requires the admin role", nil).WithCode(errors.CodeProductNotOwned)
+	}
+
 	// Validate request
 	if err := s.validator.Validate(req); err != nil {
-		s.logger.WithError(err).Error("Invalid update product request")
+		s.log(ctx).WithError(err).Error("Invalid update product request")
 		return nil, errors.NewValidationError("Invalid request", err)
 	}
 
@@ -118,19 +482,28 @@ func (s *productService) UpdateProduct(ctx context.Context, id uuid.UUID, req *d
 	product, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		if errors.IsNotFound(err) {
-			return nil, errors.NewNotFoundError("Product not found", err)
+			return nil, errors.NewNotFoundError("Product not found", err).WithCode(errors.CodeProductNotFound)
 		}
 		return nil, errors.NewInternalError("Failed to get product", err)
 	}
 
+	return s.updateProduct(ctx, product, req, domain.ProductClears{})
+}
+
+// updateProduct applies a validated update to a product, removing the
+// optional fields listed in clears
+func (s *productService) updateProduct(ctx context.Context, product *domain.Product, req *domain.UpdateProductRequest, clears domain.ProductClears) (*domain.Product, error) {
+	id := product.ID
+	before := *product
+
 	// Check SKU uniqueness if being updated
 	if req.SKU != nil && *req.SKU != product.SKU {
-		existing, err := s.repo.GetBySKU(ctx, *req.SKU)
+		existing, err := s.repo.GetBySKU(database.WithPrimary(ctx), *req.SKU)
 		if err != nil && !errors.IsNotFound(err) {
 			return nil, errors.NewInternalError("Failed to validate SKU", err)
 		}
 		if existing != nil {
-			return nil, errors.NewConflictError("SKU already exists", nil)
+			return nil, errors.NewConflictError("SKU already exists", nil).WithCode(errors.CodeProductSKUConflict)
 		}
 	}
 
@@ -138,18 +511,64 @@ func (s *productService) UpdateProduct(ctx context.Context, id uuid.UUID, req *d
 	if req.CategoryID != nil {
 		if _, err := s.repo.GetCategory(ctx, *req.CategoryID); err != nil {
 			if errors.IsNotFound(err) {
-				return nil, errors.NewNotFoundError("Category not found", err)
+				return nil, errors.NewNotFoundError("Category not found", err).WithCode(errors.CodeCategoryNotFound)
 			}
 			return nil, errors.NewInternalError("Failed to verify category", err)
 		}
 	}
 
+	// Verify brand exists if being updated
+	if req.BrandID != nil {
+		if _, err := s.repo.GetBrand(ctx, *req.BrandID); err != nil {
+			if errors.IsNotFound(err) {
+				return nil, errors.NewNotFoundError("Brand not found", err).WithCode(errors.CodeBrandNotFound)
+			}
+			return nil, errors.NewInternalError("Failed to verify brand", err)
+		}
+	}
+
+	// Revalidate attributes when they are replaced or the category changes
+	categoryChanged := req.CategoryID != nil && *req.CategoryID != product.CategoryID
+	replaceAttributes := req.Attributes != nil || categoryChanged
+	var attributes []domain.ProductAttribute
+	if replaceAttributes {
+		categoryID := product.CategoryID
+		if req.CategoryID != nil {
+			categoryID = *req.CategoryID
+		}
+		defs, err := s.repo.ListAttributeDefinitions(ctx, categoryID)
+		if err != nil {
+			s.log(ctx).WithError(err).Error("Failed to load attribute definitions")
+			return nil, errors.NewInternalError("Failed to validate attributes", err)
+		}
+
+		values := req.Attributes
+		if values == nil {
+			values = carryOverAttributes(defs, product.Attributes)
+		}
+
+		attributes, err = domain.ValidateAttributes(defs, values)
+		if err != nil {
+			return nil, errors.NewValidationError("Invalid attributes", err)
+		}
+	}
+
+	// Regenerate the slug on rename, keeping the old one as a redirect
+	if req.Name != nil && *req.Name != product.Name {
+		slug, err := s.repo.UniqueSlug(ctx, domain.AuditEntityProduct, domain.Slugify(*req.Name), id)
+		if err != nil {
+			s.log(ctx).WithError(err).Error("Failed to generate product slug")
+			return nil, errors.NewInternalError("Failed to generate slug", err)
+		}
+		product.Slug = slug
+	}
+
 	// Update fields
 	if req.Name != nil {
 		product.Name = *req.Name
 	}
 	if req.Description != nil {
-		product.Description = *req.Description
+		product.SetDescription(*req.Description)
 	}
 	if req.Price != nil {
 		product.Price = *req.Price
@@ -157,6 +576,50 @@ func (s *productService) UpdateProduct(ctx context.Context, id uuid.UUID, req *d
 	if req.CategoryID != nil {
 		product.CategoryID = *req.CategoryID
 	}
+	if req.BrandID != nil {
+		product.BrandID = req.BrandID
+		product.Brand = nil // drop the stale association so Save keeps the new ID
+	}
+	if clears.Brand {
+		product.BrandID = nil
+		product.Brand = nil
+	}
+	if req.LowStockThreshold != nil {
+		product.LowStockThreshold = req.LowStockThreshold
+	}
+	if clears.LowStockThreshold {
+		product.LowStockThreshold = nil
+	}
+	if req.SalePrice != nil {
+		product.SalePrice = req.SalePrice
+	}
+	if clears.SalePrice {
+		product.SalePrice = nil
+	}
+	if req.SaleStartsAt != nil {
+		product.SaleStartsAt = req.SaleStartsAt
+	}
+	if clears.SaleStartsAt {
+		product.SaleStartsAt = nil
+	}
+	if req.SaleEndsAt != nil {
+		product.SaleEndsAt = req.SaleEndsAt
+	}
+	if clears.SaleEndsAt {
+		product.SaleEndsAt = nil
+	}
+	if req.AllowBackorder != nil {
+		product.AllowBackorder = *req.AllowBackorder
+	}
+	if req.Preorder != nil {
+		product.Preorder = *req.Preorder
+	}
+	if req.ReleaseDate != nil {
+		product.ReleaseDate = req.ReleaseDate
+	}
+	if clears.ReleaseDate {
+		product.ReleaseDate = nil
+	}
 	if req.Stock != nil {
 		product.Stock = *req.Stock
 	}
@@ -166,149 +629,626 @@ func (s *productService) UpdateProduct(ctx context.Context, id uuid.UUID, req *d
 	if req.SKU != nil {
 		product.SKU = *req.SKU
 	}
+	if req.GTIN != nil {
+		product.GTIN = *req.GTIN
+	}
 	if req.IsActive != nil {
 		product.IsActive = *req.IsActive
 	}
+	if req.Type != nil {
+		product.Type = *req.Type
+	}
+	if req.BundlePricing != nil {
+		product.BundlePricing = *req.BundlePricing
+	}
+	if req.BundleDiscount != nil {
+		product.BundleDiscount = *req.BundleDiscount
+	}
+	if product.Type == domain.ProductTypeBundle && product.BundlePricing == "" {
+		product.BundlePricing = domain.BundlePricingFixed
+	}
+	if req.UnitOfMeasure != nil {
+		product.UnitOfMeasure = *req.UnitOfMeasure
+	}
+	if req.QuantityIncrement != nil {
+		product.QuantityIncrement = *req.QuantityIncrement
+	}
+	if req.MinOrderQuantity != nil {
+		product.MinOrderQuantity = *req.MinOrderQuantity
+	}
+	if req.MaxOrderQuantity != nil {
+		product.MaxOrderQuantity = *req.MaxOrderQuantity
+	}
+	if req.TaxClass != nil {
+		product.TaxClass = *req.TaxClass
+	}
+	if req.Status != nil {
+		product.SetStatus(*req.Status, time.Now())
+	}
+	if req.PublishAt != nil {
+		product.PublishAt = req.PublishAt
+	}
+	if clears.PublishAt {
+		product.PublishAt = nil
+	}
+
+	if replaceAttributes {
+		product.Attributes = nil
+	}
+
+	// A vendor changing a product that was turned down submits it again
+	if product.SoldBy(domain.VendorFromContext(ctx)) && product.ApprovalStatus == domain.ApprovalRejected {
+		product.ApprovalStatus = domain.ApprovalPending
+	}
+
+	if err := product.ValidateSchedule(); err != nil {
+		return nil, errors.NewValidationError("Invalid publish time", err)
+	}
+	if err := product.ValidateApproval(); err != nil {
+		return nil, errors.NewConflictError("Product is not approved", err).WithCode(errors.CodeProductNotApproved)
+	}
+	if err := product.ValidateSale(); err != nil {
+		return nil, errors.NewValidationError("Invalid sale", err)
+	}
+	if err := product.ValidateGTIN(); err != nil {
+		return nil, errors.NewValidationError("Invalid GTIN", err)
+	}
+	if err := product.ValidateQuantities(); err != nil {
+		return nil, errors.NewValidationError("Invalid order quantities", err)
+	}
+	if !product.IsPhysical() && product.Stock != 0 {
+		return nil, errors.NewValidationError("Only physical products hold stock", nil).WithCode(errors.CodeProductNotStocked)
+	}
+	if err := validateBundlePricing(product); err != nil {
+		return nil, err
+	}
 
 	if err := s.repo.Update(ctx, product); err != nil {
-		s.logger.WithError(err).Error("Failed to update product")
+		if errors.IsConflict(err) {
+			return nil, err
+		}
+		s.log(ctx).WithError(err).Error("Failed to update product")
 		return nil, errors.NewInternalError("Failed to update product", err)
 	}
 
+	// Stock goes through the ledger; setting it outright is an adjustment
+	if product.Stock != before.Stock {
+		movement := domain.StockMovement{Reason: domain.StockReasonAdjustment, ActorID: auth.ActorID(ctx)}
+		if _, err := s.repo.SetStock(ctx, id, product.Stock, movement); err != nil {
+			s.log(ctx).WithError(err).Error("Failed to update product stock")
+			return nil, errors.NewInternalError("Failed to update product stock", err)
+		}
+	}
+
+	if replaceAttributes {
+		if err := s.repo.ReplaceProductAttributes(ctx, id, attributes); err != nil {
+			s.log(ctx).WithError(err).Error("Failed to update product attributes")
+			return nil, errors.NewInternalError("Failed to update product attributes", err)
+		}
+		product.Attributes = attributes
+	}
+
 	// Invalidate cache
 	if err := s.repo.InvalidateProductCache(ctx); err != nil {
-		s.logger.WithError(err).Error("Failed to invalidate product cache")
+		s.log(ctx).WithError(err).Error("Failed to invalidate product cache")
 		return nil, errors.NewInternalError("Failed to invalidate cache", err)
 	}
 
-	s.logger.WithField("product_id", product.ID).Info("Product updated successfully")
+	if product.Slug != before.Slug {
+		s.recordSlugChange(ctx, domain.AuditEntityProduct, id, before.Slug, product.Slug)
+	}
+
+	if product.Price != before.Price || product.Type != before.Type || product.BundlePricing != before.BundlePricing || product.BundleDiscount != before.BundleDiscount {
+		s.repriceBundles(ctx, product)
+	}
+
+	s.publish(ctx, domain.EventProductUpdated, product)
+	if product.Stock != before.Stock || product.StockThreshold(s.stock.LowThreshold) != before.StockThreshold(s.stock.LowThreshold) {
+		s.checkLowStock(ctx, product)
+	}
+	s.audit(ctx, domain.AuditEntityProduct, product.ID, domain.AuditActionUpdate, &before, product)
+
+	s.log(ctx).WithField("product_id", product.ID).Info("Product updated successfully")
 	return product, nil
 }
 
 func (s *productService) DeleteProduct(ctx context.Context, id uuid.UUID) error {
+	if !s.managesProduct(ctx, id) {
+		return errors.NewForbiddenError("Deleting a product requires the admin role", nil).WithCode(errors.CodeProductNotOwned)
+	}
+
 	// Check if product exists
-	if _, err := s.repo.GetByID(ctx, id); err != nil {
+	product, err := s.repo.GetByID(ctx, id)
+	if err != nil {
 		if errors.IsNotFound(err) {
-			return errors.NewNotFoundError("Product not found", err)
+			return errors.NewNotFoundError("Product not found", err).WithCode(errors.CodeProductNotFound)
 		}
 		return errors.NewInternalError("Failed to get product", err)
 	}
 
 	if err := s.repo.Delete(ctx, id); err != nil {
-		s.logger.WithError(err).Error("Failed to delete product")
+		s.log(ctx).WithError(err).Error("Failed to delete product")
 		return errors.NewInternalError("Failed to delete product", err)
 	}
 
 	// Invalidate cache
 	if err := s.repo.InvalidateProductCache(ctx); err != nil {
-		s.logger.WithError(err).Error("Failed to invalidate product cache")
+		s.log(ctx).WithError(err).Error("Failed to invalidate product cache")
 		return errors.NewInternalError("Failed to invalidate cache", err)
 	}
 
-	s.logger.WithField("product_id", id).Info("Product deleted successfully")
+	s.publish(ctx, domain.EventProductDeleted, &domain.Product{ID: id})
+	s.audit(ctx, domain.AuditEntityProduct, id, domain.AuditActionDelete, product, nil)
+
+	s.log(ctx).WithField("product_id", id).Info("Product deleted successfully")
 	return nil
 }
 
-func (s *productService) ListProducts(ctx context.Context, filters *domain.ProductFilters) (*domain.ProductList, error) {
-	// Set default values
-	if filters.Limit <= 0 {
-		filters.Limit = 20
+func (s *productService) RestoreProduct(ctx context.Context, id uuid.UUID) (*domain.Product, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Restoring a product requires the admin role", nil)
 	}
-	if filters.Limit > 100 {
-		filters.Limit = 100
+
+	product, err := s.repo.GetDeleted(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Deleted product not found", err).WithCode(errors.CodeProductNotFound)
+		}
+		return nil, errors.NewInternalError("Failed to get product", err)
 	}
-	if filters.SortBy == "" {
-		filters.SortBy = "created_at"
+
+	// The SKU may have been reused by a live product since deletion
+	existing, err := s.repo.GetBySKU(database.WithPrimary(ctx), product.SKU)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, errors.NewInternalError("Failed to validate SKU", err)
 	}
-	if filters.SortOrder == "" {
-		filters.SortOrder = "desc"
+	if existing != nil {
+		return nil, errors.NewConflictError("SKU is in use by another product", nil).WithCode(errors.CodeProductSKUConflict)
 	}
 
-	products, total, err := s.repo.List(ctx, filters)
-	if err != nil {
-		s.logger.WithError(err).Error("Failed to list products")
-		return nil, errors.NewInternalError("Failed to list products", err)
+	if err := s.repo.Restore(ctx, id); err != nil {
+		if errors.IsConflict(err) {
+			return nil, errors.NewConflictError("SKU is in use by another product", err).WithCode(errors.CodeProductSKUConflict)
+		}
+		s.log(ctx).WithError(err).Error("Failed to restore product")
+		return nil, errors.NewInternalError("Failed to restore product", err)
 	}
 
-	return &domain.ProductList{
-		Products: products,
-		Total:    total,
-		Limit:    filters.Limit,
-		Offset:   filters.Offset,
-		HasMore:  int64(filters.Offset+filters.Limit) < total,
-	}, nil
-}
+	// Invalidate cache
+	if err := s.repo.InvalidateProductCache(ctx); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to invalidate product cache")
+		return nil, errors.NewInternalError("Failed to invalidate cache", err)
+	}
 
-func (s *productService) SearchProducts(ctx context.Context, query string, filters *domain.ProductFilters) (*domain.ProductList, error) {
-	if query == "" {
-		return s.ListProducts(ctx, filters)
+	product, err = s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, errors.NewInternalError("Failed to get product", err)
 	}
 
-	// Set search query in filters
-	filters.Search = query
+	s.publish(ctx, domain.EventProductRestored, product)
+	s.audit(ctx, domain.AuditEntityProduct, id, domain.AuditActionRestore, nil, nil)
 
-	return s.ListProducts(ctx, filters)
+	s.log(ctx).WithField("product_id", id).Info("Product restored successfully")
+	return product, nil
 }
 
-func (s *productService) CreateCategory(ctx context.Context, req *domain.CreateCategoryRequest) (*domain.Category, error) {
+func (s *productService) AddProductRelation(ctx context.Context, productID uuid.UUID, req *domain.CreateProductRelationRequest) (*domain.ProductRelation, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing related products requires the admin role", nil)
+	}
+
 	// Validate request
 	if err := s.validator.Validate(req); err != nil {
-		s.logger.WithError(err).Error("Invalid create category request")
+		s.log(ctx).WithError(err).Error("Invalid create product relation request")
 		return nil, errors.NewValidationError("Invalid request", err)
 	}
+	if req.RelatedID == productID {
+		return nil, errors.NewValidationError("A product cannot be related to itself", nil)
+	}
 
-	// Check if name already exists
-	existing, err := s.repo.GetCategoryByName(ctx, req.Name)
-	if err != nil && !errors.IsNotFound(err) {
-		return nil, errors.NewInternalError("Failed to validate category name", err)
+	// Verify both products exist
+	if _, err := s.repo.GetByID(ctx, productID); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Product not found", err).WithCode(errors.CodeProductNotFound)
+		}
+		return nil, errors.NewInternalError("Failed to get product", err)
 	}
-	if existing != nil {
-		return nil, errors.NewConflictError("Category name already exists", nil)
+	if _, err := s.repo.GetByID(ctx, req.RelatedID); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Related product not found", err).WithCode(errors.CodeProductNotFound)
+		}
+		return nil, errors.NewInternalError("Failed to get related product", err)
 	}
 
-	// Verify parent category exists if specified
-	if req.ParentID != nil {
-		if _, err := s.repo.GetCategory(ctx, *req.ParentID); err != nil {
-			if errors.IsNotFound(err) {
-				return nil, errors.NewNotFoundError("Parent category not found", err)
-			}
-			return nil, errors.NewInternalError("Failed to verify parent category", err)
-		}
+	relation := &domain.ProductRelation{
+		ProductID: productID,
+		RelatedID: req.RelatedID,
+		Type:      req.Type,
+		Position:  req.Position,
+	}
+	if err := s.repo.UpsertRelation(ctx, relation); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to save product relation")
+		return nil, errors.NewInternalError("Failed to save product relation", err)
 	}
 
-	category := &domain.Category{
-		Name:        req.Name,
-		Description: req.Description,
-		ParentID:    req.ParentID,
-		IsActive:    true,
+	s.log(ctx).WithField("product_id", productID).Info("Product relation saved successfully")
+	return relation, nil
+}
+
+func (s *productService) RemoveProductRelation(ctx context.Context, productID, relatedID uuid.UUID, relationType string) error {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return errors.NewForbiddenError("Managing related products requires the admin role", nil)
 	}
 
-	if err := s.repo.CreateCategory(ctx, category); err != nil {
-		s.logger.WithError(err).Error("Failed to create category")
-		return nil, errors.NewInternalError("Failed to create category", err)
+	deleted, err := s.repo.DeleteRelation(ctx, productID, relatedID, relationType)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to delete product relation")
+		return errors.NewInternalError("Failed to delete product relation", err)
+	}
+	if !deleted {
+		return errors.NewNotFoundError("Product relation not found", nil).WithCode(errors.CodeProductRelationNotFound)
 	}
 
-	s.logger.WithField("category_id", category.ID).Info("Category created successfully")
-	return category, nil
+	s.log(ctx).WithField("product_id", productID).Info("Product relation deleted successfully")
+	return nil
 }
 
-func (s *productService) GetCategory(ctx context.Context, id uuid.UUID) (*domain.Category, error) {
-	category, err := s.repo.GetCategory(ctx, id)
-	if err != nil {
+func (s *productService) GetRelatedProducts(ctx context.Context, productID uuid.UUID, relationType string) ([]domain.RelatedProduct, error) {
+	if _, err := s.repo.GetByID(ctx, productID); err != nil {
 		if errors.IsNotFound(err) {
-			return nil, errors.NewNotFoundError("Category not found", err)
+			return nil, errors.NewNotFoundError("Product not found", err).WithCode(errors.CodeProductNotFound)
 		}
-		s.logger.WithError(err).Error("Failed to get category")
-		return nil, errors.NewInternalError("Failed to get category", err)
+		return nil, errors.NewInternalError("Failed to get product", err)
 	}
 
-	return category, nil
-}
+	relations, err := s.repo.ListRelations(ctx, productID, relationType)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list product relations")
+		return nil, errors.NewInternalError("Failed to list related products", err)
+	}
+
+	// Resolve through the product cache so storefront widgets rarely hit
+	// the database
+	ids := make([]uuid.UUID, len(relations))
+	for i, relation := range relations {
+		ids[i] = relation.RelatedID
+	}
+	products, err := s.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, errors.NewInternalError("Failed to get related products", err)
+	}
+
+	related := make([]domain.RelatedProduct, 0, len(relations))
+	for _, relation := range relations {
+		product, ok := products[relation.RelatedID]
+		if !ok {
+			continue // soft-deleted since it was linked
+		}
+		if !product.IsActive || !product.IsPublished() {
+			continue
+		}
+		related = append(related, domain.RelatedProduct{
+			Type:     relation.Type,
+			Position: relation.Position,
+			Product:  product,
+		})
+	}
+
+	pointers := make([]*domain.Product, len(related))
+	for i := range related {
+		pointers[i] = related[i].Product
+	}
+	s.localize(ctx, pointers...)
+	s.addImageVariants(pointers...)
+	s.addPricing(ctx, pointers...)
+
+	return related, nil
+}
+
+func (s *productService) ListProducts(ctx context.Context, filters *domain.ProductFilters) (*domain.ProductList, error) {
+	return s.listProducts(ctx, filters, s.catalog)
+}
+
+func (s *productService) SearchProducts(ctx context.Context, query string, filters *domain.ProductFilters) (*domain.ProductList, error) {
+	if query == "" {
+		return s.ListProducts(ctx, filters)
+	}
+
+	// Set search query in filters, ranking by relevance unless asked otherwise
+	filters.Search = query
+	if filters.SortBy == "" {
+		filters.SortBy = "relevance"
+	}
+
+	// The search index only holds total stock, so stock in one warehouse
+	// is searched in the database
+	if filters.Warehouse != "" {
+		return s.listProducts(ctx, filters, s.catalog)
+	}
+	return s.listProducts(ctx, filters, s.searcher)
+}
+
+// listProducts applies pagination defaults and runs the query against the given backend
+func (s *productService) listProducts(ctx context.Context, filters *domain.ProductFilters, backend search.Searcher) (*domain.ProductList, error) {
+	switch filters.Status {
+	case "", domain.ProductStatusDraft, domain.ProductStatusPublished, domain.ProductStatusArchived:
+	default:
+		return nil, errors.NewValidationError("Invalid status filter", nil)
+	}
+	switch filters.ApprovalStatus {
+	case "", domain.ApprovalPending, domain.ApprovalApproved, domain.ApprovalRejected:
+	default:
+		return nil, errors.NewValidationError("Invalid approval status filter", nil)
+	}
+
+	// Drafts and archived products stay off the storefront; vendors see
+	// all of their own
+	if !auth.HasRole(ctx, auth.RoleAdmin) && !ownListing(ctx, filters) {
+		filters.Status = domain.ProductStatusPublished
+	}
+
+	// Products listed for a channel are priced for it
+	if filters.Channel != "" {
+		if !domain.ValidChannel(filters.Channel) {
+			return nil, errors.NewValidationError("Invalid channel filter", nil)
+		}
+		ctx = domain.WithChannel(ctx, filters.Channel)
+	}
+
+	if _, err := s.resolveWarehouse(ctx, filters.Warehouse); err != nil {
+		return nil, err
+	}
+
+	// Set default values
+	if filters.Limit <= 0 {
+		filters.Limit = 20
+	}
+	if filters.Limit > 100 {
+		filters.Limit = 100
+	}
+	if filters.SortBy == "" {
+		filters.SortBy = "created_at"
+	}
+	if filters.SortOrder == "" {
+		filters.SortOrder = "desc"
+	}
+	switch filters.SortBy {
+	case "name", "price", "created_at", "rating", "relevance":
+	default:
+		return nil, errors.NewValidationError("Invalid sort_by, must be one of name, price, created_at, rating, relevance", nil)
+	}
+	switch filters.SortOrder {
+	case "asc", "desc":
+	default:
+		return nil, errors.NewValidationError("Invalid sort_order, must be asc or desc", nil)
+	}
+
+	// Decode keyset cursor; it takes precedence over offset
+	if filters.Cursor != "" {
+		if filters.SortBy != "created_at" {
+			return nil, errors.NewValidationError("Cursor pagination requires sort_by=created_at", nil)
+		}
+		cursor, err := domain.DecodeProductCursor(filters.Cursor)
+		if err != nil {
+			return nil, errors.NewValidationError("Invalid cursor", err)
+		}
+		filters.After = cursor
+		filters.Offset = 0
+	}
+
+	// In cursor mode fetch one extra row to know whether another page exists
+	limit := filters.Limit
+	if filters.After != nil {
+		filters.Limit = limit + 1
+	}
+
+	s.expandSearch(ctx, filters)
+	products, total, err := backend.Search(ctx, filters)
+	filters.Limit = limit
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list products")
+		return nil, errors.NewInternalError("Failed to list products", err)
+	}
+	s.recordZeroResults(ctx, filters, total)
+
+	hasMore := int64(filters.Offset+filters.Limit) < total
+	if filters.After != nil {
+		hasMore = len(products) > limit
+		if hasMore {
+			products = products[:limit]
+		}
+	}
+
+	pointers := make([]*domain.Product, len(products))
+	for i := range products {
+		pointers[i] = &products[i]
+	}
+	if len(products) > 0 && (filters.Fields.Includes("breadcrumbs") || filters.Fields.Includes("category")) {
+		s.addBreadcrumbs(ctx, pointers...)
+	}
+	s.localize(ctx, pointers...)
+	s.addImageVariants(pointers...)
+	s.addPricing(ctx, pointers...)
+	if filters.Warehouse != "" {
+		if err := s.AddAvailability(ctx, filters.Warehouse, pointers...); err != nil {
+			return nil, err
+		}
+	}
+
+	var nextCursor string
+	if hasMore && filters.SortBy == "created_at" && len(products) > 0 {
+		nextCursor = domain.NewProductCursor(&products[len(products)-1]).Encode()
+	}
+
+	var facets *domain.ProductFacets
+	if filters.Facets {
+		facets, err = backend.Facets(ctx, filters)
+		if err != nil {
+			s.log(ctx).WithError(err).Error("Failed to compute product facets")
+			return nil, errors.NewInternalError("Failed to compute product facets", err)
+		}
+		s.labelFacets(ctx, facets)
+	}
+
+	return &domain.ProductList{
+		Products:   products,
+		Total:      total,
+		Limit:      filters.Limit,
+		Offset:     filters.Offset,
+		HasMore:    hasMore,
+		NextCursor: nextCursor,
+		Facets:     facets,
+	}, nil
+}
+
+// labelFacets fills in category and brand names for backends that only return IDs
+func (s *productService) labelFacets(ctx context.Context, facets *domain.ProductFacets) {
+	if needsLabels(facets.Categories) {
+		categories, err := s.repo.ListCategories(ctx)
+		if err != nil {
+			s.log(ctx).WithError(err).Warn("Failed to load category names for facets")
+		} else {
+			names := make(map[string]string, len(categories))
+			for _, category := range categories {
+				names[category.ID.String()] = category.Name
+			}
+			applyLabels(facets.Categories, names)
+		}
+	}
+
+	if needsLabels(facets.Brands) {
+		brands, err := s.repo.ListBrands(ctx)
+		if err != nil {
+			s.log(ctx).WithError(err).Warn("Failed to load brand names for facets")
+		} else {
+			names := make(map[string]string, len(brands))
+			for _, brand := range brands {
+				names[brand.ID.String()] = brand.Name
+			}
+			applyLabels(facets.Brands, names)
+		}
+	}
+}
+
+func needsLabels(counts []domain.FacetCount) bool {
+	for _, count := range counts {
+		if count.Label == "" {
+			return true
+		}
+	}
+	return false
+}
+
+func applyLabels(counts []domain.FacetCount, names map[string]string) {
+	for i := range counts {
+		if counts[i].Label == "" {
+			counts[i].Label = names[counts[i].Value]
+		}
+	}
+}
+
+func (s *productService) CreateCategory(ctx context.Context, req *domain.CreateCategoryRequest) (*domain.Category, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing categories requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid create category request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	// Check if name already exists
+	existing, err := s.repo.GetCategoryByName(ctx, req.Name)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, errors.NewInternalError("Failed to validate category name", err)
+	}
+	if existing != nil {
+		return nil, errors.NewConflictError("Category name already exists", nil).WithCode(errors.CodeCategoryNameConflict)
+	}
+
+	// Verify parent category exists if specified
+	if req.ParentID != nil {
+		if _, err := s.repo.GetCategory(ctx, *req.ParentID); err != nil {
+			if errors.IsNotFound(err) {
+				return nil, errors.NewNotFoundError("Parent category not found", err).WithCode(errors.CodeCategoryNotFound)
+			}
+			return nil, errors.NewInternalError("Failed to verify parent category", err)
+		}
+	}
+
+	category := &domain.Category{
+		Name:        req.Name,
+		Description: req.Description,
+		ParentID:    req.ParentID,
+		IsActive:    true,
+	}
+
+	slug, err := s.repo.UniqueSlug(ctx, domain.AuditEntityCategory, domain.Slugify(req.Name), uuid.Nil)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to generate category slug")
+		return nil, errors.NewInternalError("Failed to generate slug", err)
+	}
+	category.Slug = slug
+
+	if err := s.repo.CreateCategory(ctx, category); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to create category")
+		return nil, errors.NewInternalError("Failed to create category", err)
+	}
+
+	s.publish(ctx, domain.EventCategoryCreated, category)
+	s.audit(ctx, domain.AuditEntityCategory, category.ID, domain.AuditActionCreate, nil, category)
+
+	s.log(ctx).WithField("category_id", category.ID).Info("Category created successfully")
+	return category, nil
+}
+
+func (s *productService) GetCategory(ctx context.Context, id uuid.UUID) (*domain.Category, error) {
+	category, err := s.repo.GetCategory(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Category not found", err).WithCode(errors.CodeCategoryNotFound)
+		}
+		s.log(ctx).WithError(err).Error("Failed to get category")
+		return nil, errors.NewInternalError("Failed to get category", err)
+	}
+	s.addCategoryBreadcrumbs(ctx, category)
+	s.localizeCategories(ctx, category)
+
+	return category, nil
+}
+
+func (s *productService) GetCategoryBySlug(ctx context.Context, slug string) (*domain.Category, error) {
+	category, err := s.repo.GetCategoryBySlug(ctx, slug)
+	if err == nil {
+		s.addCategoryBreadcrumbs(ctx, category)
+		s.localizeCategories(ctx, category)
+		return category, nil
+	}
+	if !errors.IsNotFound(err) {
+		s.log(ctx).WithError(err).Error("Failed to get category by slug")
+		return nil, errors.NewInternalError("Failed to get category", err)
+	}
+
+	redirect, err := s.repo.GetSlugRedirect(ctx, domain.AuditEntityCategory, slug)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Category not found", err).WithCode(errors.CodeCategoryNotFound)
+		}
+		return nil, errors.NewInternalError("Failed to resolve slug", err)
+	}
+
+	return s.GetCategory(ctx, redirect.EntityID)
+}
 
 func (s *productService) UpdateCategory(ctx context.Context, id uuid.UUID, req *domain.UpdateCategoryRequest) (*domain.Category, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing categories requires the admin role", nil)
+	}
+
 	// Validate request
 	if err := s.validator.Validate(req); err != nil {
-		s.logger.WithError(err).Error("Invalid update category request")
+		s.log(ctx).WithError(err).Error("Invalid update category request")
 		return nil, errors.NewValidationError("Invalid request", err)
 	}
 
@@ -316,10 +1256,11 @@ func (s *productService) UpdateCategory(ctx context.Context, id uuid.UUID, req *
 	category, err := s.repo.GetCategory(ctx, id)
 	if err != nil {
 		if errors.IsNotFound(err) {
-			return nil, errors.NewNotFoundError("Category not found", err)
+			return nil, errors.NewNotFoundError("Category not found", err).WithCode(errors.CodeCategoryNotFound)
 		}
 		return nil, errors.NewInternalError("Failed to get category", err)
 	}
+	before := *category
 
 	// Check name uniqueness if being updated
 	if req.Name != nil && *req.Name != category.Name {
@@ -328,7 +1269,7 @@ func (s *productService) UpdateCategory(ctx context.Context, id uuid.UUID, req *
 			return nil, errors.NewInternalError("Failed to validate category name", err)
 		}
 		if existing != nil {
-			return nil, errors.NewConflictError("Category name already exists", nil)
+			return nil, errors.NewConflictError("Category name already exists", nil).WithCode(errors.CodeCategoryNameConflict)
 		}
 	}
 
@@ -336,10 +1277,32 @@ func (s *productService) UpdateCategory(ctx context.Context, id uuid.UUID, req *
 	if req.ParentID != nil {
 		if _, err := s.repo.GetCategory(ctx, *req.ParentID); err != nil {
 			if errors.IsNotFound(err) {
-				return nil, errors.NewNotFoundError("Parent category not found", err)
+				return nil, errors.NewNotFoundError("Parent category not found", err).WithCode(errors.CodeCategoryNotFound)
 			}
 			return nil, errors.NewInternalError("Failed to verify parent category", err)
 		}
+
+		// Reject parents that are the category itself or one of its descendants
+		ancestors, err := s.repo.GetCategoryAncestorIDs(ctx, *req.ParentID)
+		if err != nil {
+			s.log(ctx).WithError(err).Error("Failed to load category ancestors")
+			return nil, errors.NewInternalError("Failed to verify parent category", err)
+		}
+		for _, ancestorID := range ancestors {
+			if ancestorID == id {
+				return nil, errors.NewValidationError("Parent assignment would create a category cycle", nil).WithCode(errors.CodeCategoryCycle)
+			}
+		}
+	}
+
+	// Regenerate the slug on rename, keeping the old one as a redirect
+	if req.Name != nil && *req.Name != category.Name {
+		slug, err := s.repo.UniqueSlug(ctx, domain.AuditEntityCategory, domain.Slugify(*req.Name), id)
+		if err != nil {
+			s.log(ctx).WithError(err).Error("Failed to generate category slug")
+			return nil, errors.NewInternalError("Failed to generate slug", err)
+		}
+		category.Slug = slug
 	}
 
 	// Update fields
@@ -357,19 +1320,31 @@ func (s *productService) UpdateCategory(ctx context.Context, id uuid.UUID, req *
 	}
 
 	if err := s.repo.UpdateCategory(ctx, category); err != nil {
-		s.logger.WithError(err).Error("Failed to update category")
+		s.log(ctx).WithError(err).Error("Failed to update category")
 		return nil, errors.NewInternalError("Failed to update category", err)
 	}
 
-	s.logger.WithField("category_id", category.ID).Info("Category updated successfully")
+	if category.Slug != before.Slug {
+		s.recordSlugChange(ctx, domain.AuditEntityCategory, id, before.Slug, category.Slug)
+	}
+
+	s.publish(ctx, domain.EventCategoryUpdated, category)
+	s.audit(ctx, domain.AuditEntityCategory, category.ID, domain.AuditActionUpdate, &before, category)
+
+	s.log(ctx).WithField("category_id", category.ID).Info("Category updated successfully")
 	return category, nil
 }
 
 func (s *productService) DeleteCategory(ctx context.Context, id uuid.UUID) error {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return errors.NewForbiddenError("Managing categories requires the admin role", nil)
+	}
+
 	// Check if category exists
-	if _, err := s.repo.GetCategory(ctx, id); err != nil {
+	category, err := s.repo.GetCategory(ctx, id)
+	if err != nil {
 		if errors.IsNotFound(err) {
-			return errors.NewNotFoundError("Category not found", err)
+			return errors.NewNotFoundError("Category not found", err).WithCode(errors.CodeCategoryNotFound)
 		}
 		return errors.NewInternalError("Failed to get category", err)
 	}
@@ -381,24 +1356,521 @@ func (s *productService) DeleteCategory(ctx context.Context, id uuid.UUID) error
 		return errors.NewInternalError("Failed to check category usage", err)
 	}
 	if len(products) > 0 {
-		return errors.NewConflictError("Cannot delete category with products", nil)
+		return errors.NewConflictError("Cannot delete category with products", nil).WithCode(errors.CodeCategoryHasProducts)
 	}
 
 	if err := s.repo.DeleteCategory(ctx, id); err != nil {
-		s.logger.WithError(err).Error("Failed to delete category")
+		s.log(ctx).WithError(err).Error("Failed to delete category")
 		return errors.NewInternalError("Failed to delete category", err)
 	}
 
-	s.logger.WithField("category_id", id).Info("Category deleted successfully")
+	s.publish(ctx, domain.EventCategoryDeleted, &domain.Category{ID: id})
+	s.audit(ctx, domain.AuditEntityCategory, id, domain.AuditActionDelete, category, nil)
+
+	s.log(ctx).WithField("category_id", id).Info("Category deleted successfully")
 	return nil
 }
 
-func (s *productService) ListCategories(ctx context.Context) ([]domain.Category, error) {
+func (s *productService) ListCategories(ctx context.Context, filters *domain.CategoryFilters) ([]domain.Category, error) {
 	categories, err := s.repo.ListCategories(ctx)
 	if err != nil {
-		s.logger.WithError(err).Error("Failed to list categories")
+		s.log(ctx).WithError(err).Error("Failed to list categories")
 		return nil, errors.NewInternalError("Failed to list categories", err)
 	}
 
+	if len(categories) > 0 {
+		pointers := make([]*domain.Category, len(categories))
+		for i := range categories {
+			pointers[i] = &categories[i]
+		}
+		s.addCategoryBreadcrumbs(ctx, pointers...)
+		s.addProductCounts(ctx, filters, pointers...)
+		s.localizeCategories(ctx, pointers...)
+	}
+
 	return categories, nil
 }
+
+func (s *productService) GetCategoryTree(ctx context.Context, rootID *uuid.UUID) ([]domain.Category, error) {
+	if rootID != nil {
+		if _, err := s.repo.GetCategory(ctx, *rootID); err != nil {
+			if errors.IsNotFound(err) {
+				return nil, errors.NewNotFoundError("Category not found", err).WithCode(errors.CodeCategoryNotFound)
+			}
+			return nil, errors.NewInternalError("Failed to get category", err)
+		}
+	}
+
+	categories, err := s.repo.ListCategorySubtree(ctx, rootID)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to load category tree")
+		return nil, errors.NewInternalError("Failed to load category tree", err)
+	}
+
+	tree := domain.BuildCategoryTree(categories)
+	pointers := make([]*domain.Category, len(tree))
+	for i := range tree {
+		pointers[i] = &tree[i]
+	}
+	s.localizeCategories(ctx, pointers...)
+
+	return tree, nil
+}
+
+func (s *productService) CreateAttributeDefinition(ctx context.Context, categoryID uuid.UUID, req *domain.CreateAttributeDefinitionRequest) (*domain.AttributeDefinition, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing attribute definitions requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid create attribute definition request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+	if req.Type == domain.AttributeTypeEnum && len(req.Options) == 0 {
+		return nil, errors.NewValidationError("Enum attributes require options", nil)
+	}
+
+	// Verify category exists
+	if _, err := s.repo.GetCategory(ctx, categoryID); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Category not found", err).WithCode(errors.CodeCategoryNotFound)
+		}
+		return nil, errors.NewInternalError("Failed to verify category", err)
+	}
+
+	// Keys are unique per category; inherited keys may be overridden
+	defs, err := s.repo.ListAttributeDefinitions(ctx, categoryID)
+	if err != nil {
+		return nil, errors.NewInternalError("Failed to validate attribute key", err)
+	}
+	for _, def := range defs {
+		if def.Key == req.Key && def.CategoryID == categoryID {
+			return nil, errors.NewConflictError("Attribute key already exists", nil).WithCode(errors.CodeAttributeKeyConflict)
+		}
+	}
+
+	def := &domain.AttributeDefinition{
+		CategoryID: categoryID,
+		Key:        req.Key,
+		Name:       req.Name,
+		Type:       req.Type,
+		Unit:       req.Unit,
+		Required:   req.Required,
+	}
+	if req.Type == domain.AttributeTypeEnum {
+		def.Options = req.Options
+	}
+
+	if err := s.repo.CreateAttributeDefinition(ctx, def); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to create attribute definition")
+		return nil, errors.NewInternalError("Failed to create attribute definition", err)
+	}
+
+	s.log(ctx).WithField("attribute_id", def.ID).Info("Attribute definition created successfully")
+	return def, nil
+}
+
+func (s *productService) UpdateAttributeDefinition(ctx context.Context, id uuid.UUID, req *domain.UpdateAttributeDefinitionRequest) (*domain.AttributeDefinition, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing attribute definitions requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid update attribute definition request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	def, err := s.repo.GetAttributeDefinition(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Attribute definition not found", err).WithCode(errors.CodeAttributeNotFound)
+		}
+		return nil, errors.NewInternalError("Failed to get attribute definition", err)
+	}
+
+	// Update fields
+	if req.Name != nil {
+		def.Name = *req.Name
+	}
+	if req.Unit != nil {
+		def.Unit = *req.Unit
+	}
+	if req.Required != nil {
+		def.Required = *req.Required
+	}
+	if req.Options != nil {
+		if def.Type != domain.AttributeTypeEnum {
+			return nil, errors.NewValidationError("Only enum attributes have options", nil)
+		}
+		if len(req.Options) == 0 {
+			return nil, errors.NewValidationError("Enum attributes require options", nil)
+		}
+		def.Options = req.Options
+	}
+
+	if err := s.repo.UpdateAttributeDefinition(ctx, def); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to update attribute definition")
+		return nil, errors.NewInternalError("Failed to update attribute definition", err)
+	}
+
+	s.log(ctx).WithField("attribute_id", def.ID).Info("Attribute definition updated successfully")
+	return def, nil
+}
+
+func (s *productService) DeleteAttributeDefinition(ctx context.Context, id uuid.UUID) error {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return errors.NewForbiddenError("Managing attribute definitions requires the admin role", nil)
+	}
+
+	if _, err := s.repo.GetAttributeDefinition(ctx, id); err != nil {
+		if errors.IsNotFound(err) {
+			return errors.NewNotFoundError("Attribute definition not found", err).WithCode(errors.CodeAttributeNotFound)
+		}
+		return errors.NewInternalError("Failed to get attribute definition", err)
+	}
+
+	if err := s.repo.DeleteAttributeDefinition(ctx, id); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to delete attribute definition")
+		return errors.NewInternalError("Failed to delete attribute definition", err)
+	}
+
+	s.log(ctx).WithField("attribute_id", id).Info("Attribute definition deleted successfully")
+	return nil
+}
+
+func (s *productService) ListAttributeDefinitions(ctx context.Context, categoryID uuid.UUID) ([]domain.AttributeDefinition, error) {
+	if _, err := s.repo.GetCategory(ctx, categoryID); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Category not found", err).WithCode(errors.CodeCategoryNotFound)
+		}
+		return nil, errors.NewInternalError("Failed to get category", err)
+	}
+
+	defs, err := s.repo.ListAttributeDefinitions(ctx, categoryID)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list attribute definitions")
+		return nil, errors.NewInternalError("Failed to list attribute definitions", err)
+	}
+
+	return defs, nil
+}
+
+func (s *productService) CreateBrand(ctx context.Context, req *domain.CreateBrandRequest) (*domain.Brand, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing brands requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid create brand request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	// Check if name already exists
+	existing, err := s.repo.GetBrandByName(ctx, req.Name)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, errors.NewInternalError("Failed to validate brand name", err)
+	}
+	if existing != nil {
+		return nil, errors.NewConflictError("Brand name already exists", nil).WithCode(errors.CodeBrandNameConflict)
+	}
+
+	brand := &domain.Brand{
+		Name:        req.Name,
+		Description: req.Description,
+		LogoURL:     req.LogoURL,
+		IsActive:    true,
+	}
+
+	if err := s.repo.CreateBrand(ctx, brand); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to create brand")
+		return nil, errors.NewInternalError("Failed to create brand", err)
+	}
+
+	s.publish(ctx, domain.EventBrandCreated, brand)
+	s.audit(ctx, domain.AuditEntityBrand, brand.ID, domain.AuditActionCreate, nil, brand)
+
+	s.log(ctx).WithField("brand_id", brand.ID).Info("Brand created successfully")
+	return brand, nil
+}
+
+func (s *productService) GetBrand(ctx context.Context, id uuid.UUID) (*domain.Brand, error) {
+	brand, err := s.repo.GetBrand(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Brand not found", err).WithCode(errors.CodeBrandNotFound)
+		}
+		s.log(ctx).WithError(err).Error("Failed to get brand")
+		return nil, errors.NewInternalError("Failed to get brand", err)
+	}
+
+	return brand, nil
+}
+
+func (s *productService) UpdateBrand(ctx context.Context, id uuid.UUID, req *domain.UpdateBrandRequest) (*domain.Brand, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing brands requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid update brand request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	// Get existing brand
+	brand, err := s.repo.GetBrand(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Brand not found", err).WithCode(errors.CodeBrandNotFound)
+		}
+		return nil, errors.NewInternalError("Failed to get brand", err)
+	}
+	before := *brand
+
+	// Check name uniqueness if being updated
+	if req.Name != nil && *req.Name != brand.Name {
+		existing, err := s.repo.GetBrandByName(ctx, *req.Name)
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, errors.NewInternalError("Failed to validate brand name", err)
+		}
+		if existing != nil {
+			return nil, errors.NewConflictError("Brand name already exists", nil).WithCode(errors.CodeBrandNameConflict)
+		}
+	}
+
+	// Update fields
+	if req.Name != nil {
+		brand.Name = *req.Name
+	}
+	if req.Description != nil {
+		brand.Description = *req.Description
+	}
+	if req.LogoURL != nil {
+		brand.LogoURL = *req.LogoURL
+	}
+	if req.IsActive != nil {
+		brand.IsActive = *req.IsActive
+	}
+
+	if err := s.repo.UpdateBrand(ctx, brand); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to update brand")
+		return nil, errors.NewInternalError("Failed to update brand", err)
+	}
+
+	// Products embed their brand, so cached entries are stale
+	if err := s.repo.InvalidateProductCache(ctx); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to invalidate product cache")
+	}
+
+	s.publish(ctx, domain.EventBrandUpdated, brand)
+	s.audit(ctx, domain.AuditEntityBrand, brand.ID, domain.AuditActionUpdate, &before, brand)
+
+	s.log(ctx).WithField("brand_id", brand.ID).Info("Brand updated successfully")
+	return brand, nil
+}
+
+func (s *productService) DeleteBrand(ctx context.Context, id uuid.UUID) error {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return errors.NewForbiddenError("Managing brands requires the admin role", nil)
+	}
+
+	// Check if brand exists
+	brand, err := s.repo.GetBrand(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return errors.NewNotFoundError("Brand not found", err).WithCode(errors.CodeBrandNotFound)
+		}
+		return errors.NewInternalError("Failed to get brand", err)
+	}
+
+	// Check if brand has products
+	filters := &domain.ProductFilters{BrandID: &id, Limit: 1}
+	products, _, err := s.repo.List(ctx, filters)
+	if err != nil {
+		return errors.NewInternalError("Failed to check brand usage", err)
+	}
+	if len(products) > 0 {
+		return errors.NewConflictError("Cannot delete brand with products", nil).WithCode(errors.CodeBrandHasProducts)
+	}
+
+	if err := s.repo.DeleteBrand(ctx, id); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to delete brand")
+		return errors.NewInternalError("Failed to delete brand", err)
+	}
+
+	s.publish(ctx, domain.EventBrandDeleted, &domain.Brand{ID: id})
+	s.audit(ctx, domain.AuditEntityBrand, id, domain.AuditActionDelete, brand, nil)
+
+	s.log(ctx).WithField("brand_id", id).Info("Brand deleted successfully")
+	return nil
+}
+
+func (s *productService) ListBrands(ctx context.Context) ([]domain.Brand, error) {
+	brands, err := s.repo.ListBrands(ctx)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list brands")
+		return nil, errors.NewInternalError("Failed to list brands", err)
+	}
+
+	return brands, nil
+}
+
+// exportBatchSize is the number of products fetched per export page
+const exportBatchSize = 1000
+
+func (s *productService) ExportProducts(ctx context.Context, filters *domain.ProductFilters, fn func([]domain.Product) error) error {
+	if err := s.repo.Iterate(ctx, filters, exportBatchSize, fn); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to export products")
+		return errors.NewInternalError("Failed to export products", err)
+	}
+	return nil
+}
+
+func (s *productService) ImportProducts(ctx context.Context, filename string, data []byte) (*domain.ImportJob, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Importing products requires the admin role", nil)
+	}
+
+	job := &domain.ImportJob{
+		Filename: filename,
+		Status:   domain.ImportStatusPending,
+	}
+
+	// The import is only recorded along with the job that runs it
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.CreateImportJob(ctx, job); err != nil {
+			return err
+		}
+		_, err := s.jobs.Enqueue(ctx, importer.JobType, importer.Payload{ImportID: job.ID, Data: data}, jobs.Options{})
+		return err
+	})
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to create import job")
+		return nil, errors.NewInternalError("Failed to create import job", err)
+	}
+
+	s.log(ctx).WithField("import_id", job.ID).Info("Product import queued")
+	return job, nil
+}
+
+func (s *productService) GetImportJob(ctx context.Context, id uuid.UUID) (*domain.ImportJob, error) {
+	job, err := s.repo.GetImportJob(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Import job not found", err).WithCode(errors.CodeImportJobNotFound)
+		}
+		s.log(ctx).WithError(err).Error("Failed to get import job")
+		return nil, errors.NewInternalError("Failed to get import job", err)
+	}
+
+	return job, nil
+}
+
+func (s *productService) GetAuditEvent(ctx context.Context, id uuid.UUID) (*domain.AuditEvent, error) {
+	event, err := s.repo.GetAuditEvent(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Audit event not found", err).WithCode(errors.CodeAuditEventNotFound)
+		}
+		s.log(ctx).WithError(err).Error("Failed to get audit event")
+		return nil, errors.NewInternalError("Failed to get audit event", err)
+	}
+
+	return event, nil
+}
+
+func (s *productService) ListAuditEvents(ctx context.Context, filters *domain.AuditFilters) (*domain.AuditEventList, error) {
+	// Set default values
+	if filters.Limit <= 0 {
+		filters.Limit = 50
+	}
+	if filters.Limit > 500 {
+		filters.Limit = 500
+	}
+	if filters.From != nil && filters.To != nil && filters.To.Before(*filters.From) {
+		return nil, errors.NewValidationError("Invalid date range", nil)
+	}
+
+	events, total, err := s.repo.ListAuditEvents(ctx, filters)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list audit events")
+		return nil, errors.NewInternalError("Failed to list audit events", err)
+	}
+
+	return &domain.AuditEventList{
+		Events:  events,
+		Total:   total,
+		Limit:   filters.Limit,
+		Offset:  filters.Offset,
+		HasMore: int64(filters.Offset+filters.Limit) < total,
+	}, nil
+}
+
+// auditEvent builds the audit event of a catalog mutation
+func (s *productService) auditEvent(ctx context.Context, entityType string, entityID uuid.UUID, action string, before, after interface{}) *domain.AuditEvent {
+	return &domain.AuditEvent{
+		ActorID:    auth.ActorID(ctx),
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		Changes:    domain.DiffFields(before, after),
+	}
+}
+
+// audit records a catalog mutation; failures are logged rather than failing the request
+func (s *productService) audit(ctx context.Context, entityType string, entityID uuid.UUID, action string, before, after interface{}) {
+	event := s.auditEvent(ctx, entityType, entityID, action, before, after)
+	if err := s.repo.CreateAuditEvent(ctx, event); err != nil {
+		s.log(ctx).WithError(err).WithFields(logrus.Fields{
+			"entity_type": entityType,
+			"entity_id":   entityID,
+		}).Error("Failed to record audit event")
+	}
+}
+
+// recordSlugChange keeps a redirect from the old slug; failures are logged
+// since the rename itself has already been persisted
+func (s *productService) recordSlugChange(ctx context.Context, entityType string, id uuid.UUID, oldSlug, newSlug string) {
+	if err := s.repo.RecordSlugChange(ctx, entityType, id, oldSlug, newSlug); err != nil {
+		s.log(ctx).WithError(err).WithField("entity_id", id).Error("Failed to record slug redirect")
+	}
+}
+
+// publish publishes a product or category event; failures are logged
+// rather than failing the request
+func (s *productService) publish(ctx context.Context, eventType string, payload interface{}) {
+	event, err := events.New(eventType, domain.EventSource, payload)
+	if err != nil {
+		s.log(ctx).WithError(err).WithField("event_type", eventType).Error("Failed to build event")
+		return
+	}
+
+	if deferred, ok := ctx.Value(deferredEventsKey{}).(*[]events.Event); ok {
+		*deferred = append(*deferred, event)
+		return
+	}
+
+	if err := s.publisher.Publish(ctx, event); err != nil {
+		s.log(ctx).WithError(err).WithField("event_type", eventType).Error("Failed to publish event")
+	}
+}
+
+type deferredEventsKey struct{}
+
+// deferEvents returns a copy of ctx that holds back the events published
+// with it, for changes made in a transaction that may yet roll back, and a
+// function that publishes them once it has committed
+func (s *productService) deferEvents(ctx context.Context) (context.Context, func()) {
+	deferred := new([]events.Event)
+	flush := func() {
+		for _, event := range *deferred {
+			if err := s.publisher.Publish(ctx, event); err != nil {
+				s.log(ctx).WithError(err).WithField("event_type", event.Type).Error("Failed to publish event")
+			}
+		}
+	}
+	return context.WithValue(ctx, deferredEventsKey{}, deferred), flush
+}
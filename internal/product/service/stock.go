@@ -0,0 +1,615 @@
+package service
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+)
+
+func (s *productService) ReserveStock(ctx context.Context, req *domain.ReserveStockRequest) (*domain.Reservation, error) {
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid reserve stock request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	// Combine repeated lines for the same product
+	var items []domain.StockItem
+	positions := make(map[string]int)
+	for _, item := range req.Items {
+		if i, ok := positions[item.ProductID.String()]; ok {
+			items[i].Quantity += item.Quantity
+			continue
+		}
+		positions[item.ProductID.String()] = len(items)
+		items = append(items, item)
+	}
+
+	// Verify products exist and can be ordered in these quantities
+	ids := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		ids[i] = item.ProductID
+	}
+	products, err := s.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, errors.NewInternalError("Failed to get products", err)
+	}
+	for _, item := range items {
+		product, ok := products[item.ProductID]
+		if !ok {
+			return nil, errors.NewValidationError(fmt.Sprintf("Product %s not found", item.ProductID), nil)
+		}
+		if err := product.CheckQuantity(item.Quantity); err != nil {
+			return nil, errors.NewValidationError("Invalid order quantity", err).WithCode(errors.CodeInvalidOrderQuantity)
+		}
+		if !product.VisibleIn(req.Channel) {
+			return nil, errors.NewValidationError(fmt.Sprintf("Product %s is not sold through %s", item.ProductID, req.Channel), nil).WithCode(errors.CodeProductNotInChannel)
+		}
+	}
+
+	warehouseID, err := s.resolveWarehouse(ctx, req.Warehouse)
+	if err != nil {
+		return nil, err
+	}
+
+	movement := domain.StockMovement{
+		WarehouseID: warehouseID,
+		Reason:      domain.StockReasonReservation,
+		Reference:   req.Reference,
+		ActorID:     auth.ActorID(ctx),
+	}
+	reservations, err := s.repo.ReserveStock(ctx, req.Reference, items, movement)
+	if err != nil {
+		if errors.IsConflict(err) {
+			return nil, err
+		}
+		s.log(ctx).WithError(err).Error("Failed to reserve stock")
+		return nil, errors.NewInternalError("Failed to reserve stock", err)
+	}
+
+	reservation, err := s.buildReservation(ctx, req.Reference, reservations, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.priceReservation(ctx, reservation, cmp.Or(req.CustomerGroup, auth.Group(ctx)), channelPrices(products, req.Channel)); err != nil {
+		return nil, err
+	}
+
+	s.log(ctx).WithField("reference", req.Reference).Info("Stock reserved successfully")
+	return reservation, nil
+}
+
+func (s *productService) ReleaseStock(ctx context.Context, reference string) (*domain.Reservation, error) {
+	reservations, err := s.repo.GetStockReservations(ctx, reference)
+	if err != nil {
+		return nil, errors.NewInternalError("Failed to get stock reservation", err)
+	}
+	if len(reservations) == 0 {
+		return nil, errors.NewNotFoundError("Stock reservation not found", nil).WithCode(errors.CodeReservationNotFound)
+	}
+
+	movement := domain.StockMovement{
+		Reason:    domain.StockReasonRelease,
+		Reference: reference,
+		ActorID:   auth.ActorID(ctx),
+	}
+	released, err := s.repo.ReleaseStock(ctx, reference, movement)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to release stock")
+		return nil, errors.NewInternalError("Failed to release stock", err)
+	}
+	for i := range reservations {
+		reservations[i].Status = domain.ReservationStatusReleased
+	}
+
+	reservation, err := s.buildReservation(ctx, reference, reservations, len(released) > 0)
+	if err != nil {
+		return nil, err
+	}
+
+	s.log(ctx).WithField("reference", reference).Info("Stock released successfully")
+	return reservation, nil
+}
+
+// ReturnStock puts the goods of a customer return back into stock. Stock is
+// returned once per reference, so the order service can retry.
+func (s *productService) ReturnStock(ctx context.Context, req *domain.ReturnStockRequest) ([]domain.StockMovement, error) {
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid return stock request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	warehouseID, err := s.resolveWarehouse(ctx, req.Warehouse)
+	if err != nil {
+		return nil, err
+	}
+
+	movements, err := s.repo.ReturnStock(ctx, req.Items, domain.StockMovement{
+		WarehouseID: warehouseID,
+		Reason:      domain.StockReasonReturn,
+		Reference:   req.Reference,
+		ActorID:     auth.ActorID(ctx),
+	})
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to return stock")
+		return nil, errors.NewInternalError("Failed to return stock", err)
+	}
+	if len(movements) == 0 {
+		return movements, nil
+	}
+
+	// Invalidate cache
+	if err := s.repo.InvalidateProductCache(ctx); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to invalidate product cache")
+	}
+
+	changed := make([]uuid.UUID, 0, len(movements))
+	for i := range movements {
+		if !slices.Contains(changed, movements[i].ProductID) {
+			changed = append(changed, movements[i].ProductID)
+		}
+	}
+	products, err := s.repo.GetByIDs(ctx, changed)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to get returned products")
+	}
+	for i := range movements {
+		if product, ok := products[movements[i].ProductID]; ok {
+			s.publish(ctx, domain.EventStockChanged, &domain.StockChanged{StockMovement: movements[i], SKU: product.SKU})
+		}
+	}
+	for _, id := range changed {
+		product, ok := products[id]
+		if !ok {
+			continue
+		}
+		s.publish(ctx, domain.EventProductUpdated, product)
+		s.checkLowStock(ctx, product)
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"reference": req.Reference,
+		"movements": len(movements),
+	}).Info("Stock returned successfully")
+	return movements, nil
+}
+
+func (s *productService) GetStockReservation(ctx context.Context, reference string) (*domain.Reservation, error) {
+	reservations, err := s.repo.GetStockReservations(ctx, reference)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to get stock reservation")
+		return nil, errors.NewInternalError("Failed to get stock reservation", err)
+	}
+	if len(reservations) == 0 {
+		return nil, errors.NewNotFoundError("Stock reservation not found", nil).WithCode(errors.CodeReservationNotFound)
+	}
+
+	return s.buildReservation(ctx, reference, reservations, false)
+}
+
+// buildReservation resolves reservation rows into priced product lines,
+// one per product however many warehouses it was reserved from. When the
+// stock of the products just changed, product.updated is published so
+// search indexes pick up the new levels.
+func (s *productService) buildReservation(ctx context.Context, reference string, reservations []domain.StockReservation, changed bool) (*domain.Reservation, error) {
+	reservation := &domain.Reservation{
+		Reference: reference,
+		Status:    reservations[0].Status,
+		Items:     make([]domain.ReservedItem, 0, len(reservations)),
+	}
+
+	// Rows come ordered by product, so a product's rows are adjacent. The
+	// rows reserving a bundle's components are not lines of their own.
+	var lines []domain.StockReservation
+	ids := make([]uuid.UUID, 0, len(reservations))
+	for _, row := range reservations {
+		if n := len(ids); n == 0 || ids[n-1] != row.ProductID {
+			ids = append(ids, row.ProductID)
+		}
+		if row.BundleID != nil {
+			continue
+		}
+		if n := len(lines); n > 0 && lines[n-1].ProductID == row.ProductID {
+			lines[n-1].Quantity += row.Quantity
+			continue
+		}
+		lines = append(lines, row)
+	}
+	products, err := s.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, errors.NewInternalError("Failed to get products", err)
+	}
+
+	if changed {
+		for _, id := range ids {
+			if product, ok := products[id]; ok {
+				s.publish(ctx, domain.EventProductUpdated, product)
+				s.checkLowStock(ctx, product)
+			}
+		}
+	}
+
+	for _, row := range lines {
+		product, ok := products[row.ProductID]
+		if !ok {
+			return nil, errors.NewInternalError(fmt.Sprintf("Reserved product %s not found", row.ProductID), nil)
+		}
+
+		reservation.Items = append(reservation.Items, domain.ReservedItem{
+			ProductID:  product.ID,
+			CategoryID: product.CategoryID,
+			SKU:        product.SKU,
+			Name:       product.Name,
+			Type:       product.Type,
+			TaxClass:   product.TaxClass,
+			Quantity:   row.Quantity,
+			UnitPrice:  row.UnitPrice,
+		})
+	}
+
+	return reservation, nil
+}
+
+// AdjustStock records a manual stock change, such as goods received or a
+// correction after a stock count
+func (s *productService) AdjustStock(ctx context.Context, id uuid.UUID, req *domain.AdjustStockRequest) (*domain.StockMovement, error) {
+	if !s.managesProduct(ctx, id) {
+		return nil, errors.NewForbiddenError("Adjusting stock requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid adjust stock request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	product, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Product not found", err).WithCode(errors.CodeProductNotFound)
+		}
+		s.log(ctx).WithError(err).Error("Failed to get product")
+		return nil, errors.NewInternalError("Failed to get product", err)
+	}
+	if !product.IsPhysical() {
+		return nil, errors.NewConflictError("Only physical products hold stock", nil).WithCode(errors.CodeProductNotStocked)
+	}
+
+	warehouseID, err := s.resolveWarehouse(ctx, req.Warehouse)
+	if err != nil {
+		return nil, err
+	}
+
+	movement, err := s.repo.AdjustStock(ctx, id, req.Quantity, domain.StockMovement{
+		WarehouseID: warehouseID,
+		Reason:      req.Reason,
+		ActorID:     auth.ActorID(ctx),
+		Note:        req.Note,
+	})
+	if err != nil {
+		if errors.IsNotFound(err) || errors.IsConflict(err) {
+			return nil, err
+		}
+		s.log(ctx).WithError(err).Error("Failed to adjust stock")
+		return nil, errors.NewInternalError("Failed to adjust stock", err)
+	}
+
+	// Invalidate cache
+	if err := s.repo.InvalidateProductCache(ctx); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to invalidate product cache")
+		return nil, errors.NewInternalError("Failed to invalidate cache", err)
+	}
+
+	product, err = s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, errors.NewInternalError("Failed to get product", err)
+	}
+	s.publish(ctx, domain.EventProductUpdated, product)
+	s.checkLowStock(ctx, product)
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"product_id": id,
+		"delta":      movement.Delta,
+	}).Info("Stock adjusted successfully")
+	return movement, nil
+}
+
+// BulkAdjustStock applies many stock adjustments, given by SKU, in one
+// transaction. Every adjustment is recorded in the stock ledger and
+// published as stock.changed. They apply together or not at all: when any
+// fails, none is applied and the result reports why each one failed.
+func (s *productService) BulkAdjustStock(ctx context.Context, req *domain.BulkStockAdjustmentRequest) (*domain.BulkStockAdjustmentResult, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Adjusting stock requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid bulk stock adjustment request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	results := make([]domain.StockAdjustmentResult, len(req.Adjustments))
+	fail := func(i int, err error) {
+		results[i].Success = false
+		results[i].Code = errors.Code(err)
+		results[i].Error = errors.Message(err)
+	}
+
+	skus := make([]string, len(req.Adjustments))
+	for i, row := range req.Adjustments {
+		skus[i] = row.SKU
+		results[i] = domain.StockAdjustmentResult{Index: i, SKU: row.SKU}
+	}
+	ids, err := s.repo.ProductIDsBySKU(ctx, skus)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to look up SKUs for stock adjustment")
+		return nil, errors.NewInternalError("Failed to get products", err)
+	}
+
+	// Apply the rows in product order, so concurrent adjustments lock
+	// products in the same order and cannot deadlock. Rows for the same
+	// product keep their order and build on each other.
+	order := make([]int, len(req.Adjustments))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		idA, idB := ids[skus[a]], ids[skus[b]]
+		return bytes.Compare(idA[:], idB[:])
+	})
+
+	var changes []domain.StockChanged
+	rejected := errors.NewConflictError("Stock adjustments rejected", nil)
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		changes = changes[:0]
+		failed := false
+		for _, i := range order {
+			row := req.Adjustments[i]
+			if (row.Delta == nil) == (row.Absolute == nil) {
+				fail(i, errors.NewValidationError("Give either a delta or an absolute stock level", nil))
+				failed = true
+				continue
+			}
+			if row.Delta != nil && *row.Delta == 0 {
+				fail(i, errors.NewValidationError("Delta must not be zero", nil))
+				failed = true
+				continue
+			}
+			id, ok := ids[row.SKU]
+			if !ok {
+				fail(i, errors.NewNotFoundError("Product not found", nil).WithCode(errors.CodeProductNotFound))
+				failed = true
+				continue
+			}
+			results[i].ProductID = &id
+			warehouseID, err := s.resolveWarehouse(ctx, row.Warehouse)
+			if err != nil {
+				if !errors.IsValidation(err) {
+					return err
+				}
+				fail(i, err)
+				failed = true
+				continue
+			}
+
+			movement := domain.StockMovement{
+				WarehouseID: warehouseID,
+				Reason:      row.Reason,
+				Reference:   req.Reference,
+				ActorID:     auth.ActorID(ctx),
+				Note:        row.Note,
+			}
+			var entry *domain.StockMovement
+			if row.Delta != nil {
+				entry, err = s.repo.AdjustStock(ctx, id, *row.Delta, movement)
+			} else {
+				entry, err = s.repo.SetStock(ctx, id, *row.Absolute, movement)
+			}
+			if err != nil {
+				if !errors.IsNotFound(err) && !errors.IsConflict(err) {
+					return err
+				}
+				fail(i, err)
+				failed = true
+				continue
+			}
+
+			results[i].Success = true
+			if entry == nil {
+				results[i].Balance = *row.Absolute // already at that level
+				continue
+			}
+			results[i].Delta = entry.Delta
+			results[i].Balance = entry.Balance
+			changes = append(changes, domain.StockChanged{StockMovement: *entry, SKU: row.SKU})
+		}
+
+		if failed {
+			return rejected
+		}
+		return nil
+	})
+	if err != nil && err != rejected {
+		s.log(ctx).WithError(err).Error("Failed to apply stock adjustments")
+		return nil, errors.NewInternalError("Failed to adjust stock", err)
+	}
+
+	result := &domain.BulkStockAdjustmentResult{Applied: err == nil, Results: results}
+	for _, r := range results {
+		if r.Success {
+			result.Succeeded++
+		} else {
+			result.Failed++
+		}
+	}
+	if !result.Applied {
+		s.log(ctx).WithField("failed", result.Failed).Info("Stock adjustments rejected")
+		return result, nil
+	}
+	if len(changes) == 0 {
+		return result, nil
+	}
+
+	// Invalidate cache
+	if err := s.repo.InvalidateProductCache(ctx); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to invalidate product cache")
+	}
+
+	changed := make([]uuid.UUID, 0, len(changes))
+	for i := range changes {
+		s.publish(ctx, domain.EventStockChanged, &changes[i])
+		if !slices.Contains(changed, changes[i].ProductID) {
+			changed = append(changed, changes[i].ProductID)
+		}
+	}
+	products, err := s.repo.GetByIDs(ctx, changed)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to get adjusted products")
+	}
+	for _, id := range changed {
+		product, ok := products[id]
+		if !ok {
+			continue
+		}
+		s.publish(ctx, domain.EventProductUpdated, product)
+		s.checkLowStock(ctx, product)
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"adjustments": result.Succeeded,
+		"movements":   len(changes),
+		"reference":   req.Reference,
+	}).Info("Stock adjustments applied successfully")
+	return result, nil
+}
+
+// GetStockHistory lists a product's stock movements, newest first
+func (s *productService) GetStockHistory(ctx context.Context, id uuid.UUID, filters *domain.StockMovementFilters) (*domain.StockMovementList, error) {
+	if !s.managesProduct(ctx, id) {
+		return nil, errors.NewForbiddenError("Viewing stock history requires the admin role", nil)
+	}
+
+	if _, err := s.GetProduct(ctx, id); err != nil {
+		return nil, err
+	}
+
+	// Set default values
+	if filters.Limit <= 0 {
+		filters.Limit = 20
+	}
+	if filters.Limit > 100 {
+		filters.Limit = 100
+	}
+
+	movements, total, err := s.repo.ListStockMovements(ctx, id, filters)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list stock movements")
+		return nil, errors.NewInternalError("Failed to list stock movements", err)
+	}
+
+	return &domain.StockMovementList{
+		Movements: movements,
+		Total:     total,
+		Limit:     filters.Limit,
+		Offset:    filters.Offset,
+		HasMore:   int64(filters.Offset+filters.Limit) < total,
+	}, nil
+}
+
+// CheckStockConsistency finds products whose stock no longer matches their
+// ledger. Every stock change is recorded with it, so any drift means stock
+// was written around the service and needs looking into.
+func (s *productService) CheckStockConsistency(ctx context.Context) ([]domain.StockDrift, error) {
+	drift, err := s.repo.StockDrift(ctx, s.stock.CheckBatchSize)
+	if err != nil {
+		return nil, errors.NewInternalError("Failed to check stock ledger", err)
+	}
+	return drift, nil
+}
+
+// ListLowStockProducts lists the products at or below their low stock
+// threshold, lowest stock first
+func (s *productService) ListLowStockProducts(ctx context.Context, filters *domain.LowStockFilters) (*domain.ProductList, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Viewing low stock requires the admin role", nil)
+	}
+
+	// Set default values
+	if filters.Limit <= 0 {
+		filters.Limit = 20
+	}
+	if filters.Limit > 100 {
+		filters.Limit = 100
+	}
+
+	products, total, err := s.repo.ListLowStock(ctx, s.stock.LowThreshold, filters)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list low stock products")
+		return nil, errors.NewInternalError("Failed to list low stock products", err)
+	}
+
+	return &domain.ProductList{
+		Products: products,
+		Total:    total,
+		Limit:    filters.Limit,
+		Offset:   filters.Offset,
+		HasMore:  int64(filters.Offset+filters.Limit) < total,
+	}, nil
+}
+
+// CheckLowStock reports the shortages that stock changes made outside the
+// service, such as imports, left unreported, and rearms the alert of
+// products whose stock recovered. It returns how many products it reported.
+func (s *productService) CheckLowStock(ctx context.Context) (int, error) {
+	if _, err := s.repo.ResetLowStock(ctx, s.stock.LowThreshold, nil); err != nil {
+		return 0, errors.NewInternalError("Failed to reset low stock alerts", err)
+	}
+
+	products, err := s.repo.MarkLowStock(ctx, s.stock.LowThreshold, nil, s.stock.CheckBatchSize)
+	if err != nil {
+		return 0, errors.NewInternalError("Failed to mark low stock products", err)
+	}
+	for i := range products {
+		s.publish(ctx, domain.EventStockLow, &products[i])
+	}
+
+	if len(products) > 0 {
+		s.log(ctx).WithField("count", len(products)).Info("Low stock reported successfully")
+	}
+	return len(products), nil
+}
+
+// checkLowStock publishes stock.low when a stock change leaves a product at
+// or below its low stock threshold. The alert is recorded on the product,
+// so each shortage is reported once, and cleared when stock recovers.
+func (s *productService) checkLowStock(ctx context.Context, product *domain.Product) {
+	ids := []uuid.UUID{product.ID}
+
+	if !product.IsLowStock(s.stock.LowThreshold) {
+		if _, err := s.repo.ResetLowStock(ctx, s.stock.LowThreshold, ids); err != nil {
+			s.log(ctx).WithError(err).WithField("product_id", product.ID).Error("Failed to reset low stock alert")
+		}
+		return
+	}
+
+	products, err := s.repo.MarkLowStock(ctx, s.stock.LowThreshold, ids, 1)
+	if err != nil {
+		// The periodic check reports it instead
+		s.log(ctx).WithError(err).WithField("product_id", product.ID).Error("Failed to mark low stock product")
+		return
+	}
+	for i := range products {
+		s.publish(ctx, domain.EventStockLow, &products[i])
+	}
+}
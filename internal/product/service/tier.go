@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+)
+
+// SetPriceTiers replaces the group prices and quantity breaks of a product
+func (s *productService) SetPriceTiers(ctx context.Context, id uuid.UUID, req *domain.SetPriceTiersRequest) (*domain.Product, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing price tiers requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid price tiers request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+	if err := domain.ValidatePriceTiers(req.Tiers); err != nil {
+		return nil, errors.NewValidationError("Invalid price tiers", err)
+	}
+
+	product, err := s.GetProduct(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	before := *product
+
+	tiers := make([]domain.PriceTier, len(req.Tiers))
+	for i, tier := range req.Tiers {
+		tiers[i] = domain.PriceTier{
+			CustomerGroup: tier.CustomerGroup,
+			MinQuantity:   tier.MinQuantity,
+			Price:         tier.Price,
+		}
+	}
+	if err := s.repo.ReplacePriceTiers(ctx, id, tiers); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to set price tiers")
+		return nil, errors.NewInternalError("Failed to set price tiers", err)
+	}
+	product.PriceTiers = tiers
+
+	s.publish(ctx, domain.EventProductUpdated, product)
+	s.audit(ctx, domain.AuditEntityProduct, product.ID, domain.AuditActionUpdate, &before, product)
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"product_id": id,
+		"tiers":      len(tiers),
+	}).Info("Price tiers set successfully")
+	return product, nil
+}
+
+// addPricing fills in the price tiers open to the requesting customer, and
+// the group, quantity and sales channel products are priced for. Admins see
+// every tier and the settings of every channel; other callers only those of
+// the channel asked for. A failure is logged and the products go out at
+// their regular prices.
+func (s *productService) addPricing(ctx context.Context, products ...*domain.Product) {
+	if len(products) == 0 {
+		return
+	}
+
+	group := domain.NormalizeCustomerGroup(auth.Group(ctx))
+	quantity := domain.QuantityFromContext(ctx)
+	channel := domain.ChannelFromContext(ctx)
+	admin := auth.HasRole(ctx, auth.RoleAdmin)
+	for _, product := range products {
+		product.CustomerGroup = group
+		product.PricedQuantity = quantity
+		product.Channel = channel
+		if !admin {
+			product.Channels = channelSettings(product.Channels, channel)
+		}
+	}
+
+	ids := make([]uuid.UUID, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+	tiers, err := s.repo.ListPriceTiers(ctx, ids)
+	if err != nil {
+		s.log(ctx).WithError(err).Warn("Failed to load price tiers")
+		return
+	}
+
+	byProduct := make(map[uuid.UUID][]domain.PriceTier, len(products))
+	for _, tier := range tiers {
+		if admin || tier.CustomerGroup == "" || tier.CustomerGroup == group {
+			byProduct[tier.ProductID] = append(byProduct[tier.ProductID], tier)
+		}
+	}
+	for _, product := range products {
+		product.PriceTiers = byProduct[product.ID]
+	}
+}
+
+// channelSettings returns the settings of a channel alone, or nil when it
+// has none
+func channelSettings(channels map[string]domain.ChannelSettings, channel string) map[string]domain.ChannelSettings {
+	settings, ok := channels[channel]
+	if !ok {
+		return nil
+	}
+	return map[string]domain.ChannelSettings{channel: settings}
+}
+
+// channelPrices returns the prices that replace the regular prices of
+// products in a channel, for those not on sale
+func channelPrices(products map[uuid.UUID]*domain.Product, channel string) map[uuid.UUID]float64 {
+	prices := make(map[uuid.UUID]float64)
+	now := time.Now()
+	for id, product := range products {
+		if price := product.Channels[channel].Price; price != nil && channel != "" && !product.OnSaleAt(now) {
+			prices[id] = *price
+		}
+	}
+	return prices
+}
+
+// priceReservation reprices reserved lines at their channel prices, and for
+// the customer group where their tiers make them cheaper than the price
+// they were reserved at
+func (s *productService) priceReservation(ctx context.Context, reservation *domain.Reservation, group string, channelPrices map[uuid.UUID]float64) error {
+	for i, item := range reservation.Items {
+		if price, ok := channelPrices[item.ProductID]; ok {
+			reservation.Items[i].UnitPrice = price
+		}
+	}
+
+	ids := make([]uuid.UUID, len(reservation.Items))
+	for i, item := range reservation.Items {
+		ids[i] = item.ProductID
+	}
+	tiers, err := s.repo.ListPriceTiers(ctx, ids)
+	if err != nil {
+		return errors.NewInternalError("Failed to get price tiers", err)
+	}
+	if len(tiers) == 0 {
+		return nil
+	}
+
+	byProduct := make(map[uuid.UUID][]domain.PriceTier)
+	for _, tier := range tiers {
+		byProduct[tier.ProductID] = append(byProduct[tier.ProductID], tier)
+	}
+	now := time.Now()
+	for i, item := range reservation.Items {
+		product := domain.Product{Price: item.UnitPrice, PriceTiers: byProduct[item.ProductID]}
+		reservation.Items[i].UnitPrice = product.PriceFor(group, item.Quantity, now)
+	}
+	return nil
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/richtext"
+)
+
+func (s *productService) ListProductTranslations(ctx context.Context, id uuid.UUID) ([]domain.ProductTranslation, error) {
+	if !s.managesProduct(ctx, id) {
+		return nil, errors.NewForbiddenError("Managing translations requires the admin role", nil)
+	}
+	if _, err := s.GetProduct(ctx, id); err != nil {
+		return nil, err
+	}
+
+	translations, err := s.repo.ListProductTranslations(ctx, id)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list product translations")
+		return nil, errors.NewInternalError("Failed to list product translations", err)
+	}
+
+	return translations, nil
+}
+
+// UpsertProductTranslation sets a product's name and description in a
+// locale other than the default one
+func (s *productService) UpsertProductTranslation(ctx context.Context, id uuid.UUID, locale string, req *domain.UpsertProductTranslationRequest) (*domain.ProductTranslation, error) {
+	if !s.managesProduct(ctx, id) {
+		return nil, errors.NewForbiddenError("Managing translations requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid product translation request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+	locale, err := s.translationLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.GetProduct(ctx, id); err != nil {
+		return nil, err
+	}
+
+	translation := &domain.ProductTranslation{
+		ProductID:   id,
+		Locale:      locale,
+		Name:        req.Name,
+		Description: richtext.Sanitize(req.Description),
+	}
+	if err := s.repo.UpsertProductTranslation(ctx, translation); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to save product translation")
+		return nil, errors.NewInternalError("Failed to save product translation", err)
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"product_id": id,
+		"locale":     locale,
+	}).Info("Product translation saved successfully")
+	return translation, nil
+}
+
+func (s *productService) DeleteProductTranslation(ctx context.Context, id uuid.UUID, locale string) error {
+	if !s.managesProduct(ctx, id) {
+		return errors.NewForbiddenError("Managing translations requires the admin role", nil)
+	}
+
+	locale = domain.NormalizeLocale(locale)
+	deleted, err := s.repo.DeleteProductTranslation(ctx, id, locale)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to delete product translation")
+		return errors.NewInternalError("Failed to delete product translation", err)
+	}
+	if !deleted {
+		return errors.NewNotFoundError("Product translation not found", nil).WithCode(errors.CodeTranslationNotFound)
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"product_id": id,
+		"locale":     locale,
+	}).Info("Product translation deleted successfully")
+	return nil
+}
+
+func (s *productService) ListCategoryTranslations(ctx context.Context, id uuid.UUID) ([]domain.CategoryTranslation, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing translations requires the admin role", nil)
+	}
+	if _, err := s.GetCategory(ctx, id); err != nil {
+		return nil, err
+	}
+
+	translations, err := s.repo.ListCategoryTranslations(ctx, id)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list category translations")
+		return nil, errors.NewInternalError("Failed to list category translations", err)
+	}
+
+	return translations, nil
+}
+
+// UpsertCategoryTranslation sets a category's name and description in a
+// locale other than the default one
+func (s *productService) UpsertCategoryTranslation(ctx context.Context, id uuid.UUID, locale string, req *domain.UpsertCategoryTranslationRequest) (*domain.CategoryTranslation, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing translations requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid category translation request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+	locale, err := s.translationLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.GetCategory(ctx, id); err != nil {
+		return nil, err
+	}
+
+	translation := &domain.CategoryTranslation{
+		CategoryID:  id,
+		Locale:      locale,
+		Name:        req.Name,
+		Description: req.Description,
+	}
+	if err := s.repo.UpsertCategoryTranslation(ctx, translation); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to save category translation")
+		return nil, errors.NewInternalError("Failed to save category translation", err)
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"category_id": id,
+		"locale":      locale,
+	}).Info("Category translation saved successfully")
+	return translation, nil
+}
+
+func (s *productService) DeleteCategoryTranslation(ctx context.Context, id uuid.UUID, locale string) error {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return errors.NewForbiddenError("Managing translations requires the admin role", nil)
+	}
+
+	locale = domain.NormalizeLocale(locale)
+	deleted, err := s.repo.DeleteCategoryTranslation(ctx, id, locale)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to delete category translation")
+		return errors.NewInternalError("Failed to delete category translation", err)
+	}
+	if !deleted {
+		return errors.NewNotFoundError("Category translation not found", nil).WithCode(errors.CodeTranslationNotFound)
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"category_id": id,
+		"locale":      locale,
+	}).Info("Category translation deleted successfully")
+	return nil
+}
+
+// translationLocale checks that content can be translated into a locale and
+// returns it in canonical form. Default locale content lives on the product
+// or category itself.
+func (s *productService) translationLocale(locale string) (string, error) {
+	normalized := domain.NormalizeLocale(locale)
+	if normalized == "" {
+		return "", errors.NewValidationError("Invalid locale", nil)
+	}
+	if normalized == domain.NormalizeLocale(s.locales.Default) {
+		return "", errors.NewValidationError("Content in the default locale is set on the entity itself", nil)
+	}
+	for _, supported := range s.locales.Supported {
+		if domain.NormalizeLocale(supported) == normalized {
+			return normalized, nil
+		}
+	}
+	return "", errors.NewValidationError("Unsupported locale", nil).WithCode(errors.CodeUnsupportedLocale)
+}
+
+// localize swaps in the names and descriptions of products, and of their
+// categories and breadcrumbs, for the locale in ctx. Content without a
+// translation stays in the default locale. A failure is logged and the
+// products go out untranslated.
+func (s *productService) localize(ctx context.Context, products ...*domain.Product) {
+	locale := domain.LocaleFromContext(ctx)
+	if locale == "" || len(products) == 0 {
+		return
+	}
+
+	ids := make([]uuid.UUID, 0, len(products))
+	var categories []*domain.Category
+	for _, product := range products {
+		ids = append(ids, product.ID)
+		if product.Category != nil {
+			categories = append(categories, product.Category)
+		}
+	}
+
+	translations, err := s.repo.GetProductTranslations(ctx, ids, locale)
+	if err != nil {
+		s.log(ctx).WithError(err).Warn("Failed to load product translations")
+		return
+	}
+	for _, product := range products {
+		translation, ok := translations[product.ID]
+		if !ok {
+			continue
+		}
+		product.Name = translation.Name
+		if translation.Description != "" {
+			product.SetDescription(translation.Description)
+		}
+	}
+
+	var crumbs []*domain.Breadcrumb
+	for _, product := range products {
+		for i := range product.Breadcrumbs {
+			crumbs = append(crumbs, &product.Breadcrumbs[i])
+		}
+	}
+	s.translateCategories(ctx, locale, categories, crumbs)
+}
+
+// localizeCategories swaps in the names and descriptions of categories, and
+// of their parents, children and breadcrumbs, for the locale in ctx
+func (s *productService) localizeCategories(ctx context.Context, categories ...*domain.Category) {
+	locale := domain.LocaleFromContext(ctx)
+	if locale == "" || len(categories) == 0 {
+		return
+	}
+
+	// Collect every category reachable from the ones given, so a tree is
+	// translated with a single query
+	var all []*domain.Category
+	var crumbs []*domain.Breadcrumb
+	var walk func(category *domain.Category)
+	walk = func(category *domain.Category) {
+		all = append(all, category)
+		for i := range category.Breadcrumbs {
+			crumbs = append(crumbs, &category.Breadcrumbs[i])
+		}
+		if category.Parent != nil {
+			walk(category.Parent)
+		}
+		for i := range category.Children {
+			walk(&category.Children[i])
+		}
+	}
+	for _, category := range categories {
+		walk(category)
+	}
+
+	s.translateCategories(ctx, locale, all, crumbs)
+}
+
+// translateCategories applies a locale's category translations to
+// categories and breadcrumbs. A failure is logged and they are left as is.
+func (s *productService) translateCategories(ctx context.Context, locale string, categories []*domain.Category, crumbs []*domain.Breadcrumb) {
+	if len(categories) == 0 && len(crumbs) == 0 {
+		return
+	}
+
+	ids := make([]uuid.UUID, 0, len(categories)+len(crumbs))
+	for _, category := range categories {
+		ids = append(ids, category.ID)
+	}
+	for _, crumb := range crumbs {
+		ids = append(ids, crumb.ID)
+	}
+
+	translations, err := s.repo.GetCategoryTranslations(ctx, ids, locale)
+	if err != nil {
+		s.log(ctx).WithError(err).Warn("Failed to load category translations")
+		return
+	}
+	for _, category := range categories {
+		if translation, ok := translations[category.ID]; ok {
+			category.Name = translation.Name
+			if translation.Description != "" {
+				category.Description = translation.Description
+			}
+		}
+	}
+	for _, crumb := range crumbs {
+		if translation, ok := translations[crumb.ID]; ok {
+			crumb.Name = translation.Name
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/database"
+	"ecommerce/pkg/errors"
+)
+
+// UpsertProductBySKU creates the product with a SKU, or replaces the one
+// that has it, so integrations syncing the catalog from another system can
+// retry freely. The request is validated as for CreateProduct and describes
+// the whole product: optional fields it leaves out are cleared and the
+// status defaults to published. An existing product keeps its slug and
+// whether it is active. It reports whether the product was created.
+func (s *productService) UpsertProductBySKU(ctx context.Context, sku string, req *domain.CreateProductRequest) (*domain.Product, bool, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, false, errors.NewForbiddenError("Upserting products requires the admin role", nil)
+	}
+	if req.SKU == "" {
+		req.SKU = sku
+	}
+	if req.SKU != sku {
+		return nil, false, errors.NewValidationError("Invalid request", fmt.Errorf("sku %q does not match the SKU in the path", req.SKU))
+	}
+
+	product, err := s.newProduct(ctx, req)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Upsert the product and record its audit event as one unit
+	var before *domain.Product
+	var created bool
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		existing, err := s.repo.GetBySKU(database.WithPrimary(ctx), sku)
+		if err != nil && !errors.IsNotFound(err) {
+			s.log(ctx).WithError(err).Error("Failed to look up product by SKU")
+			return errors.NewInternalError("Failed to upsert product", err)
+		}
+		before = existing
+
+		movement := domain.StockMovement{Reason: domain.StockReasonAdjustment, ActorID: auth.ActorID(ctx)}
+		if existing == nil {
+			movement.Reason = domain.StockReasonInitial
+
+			slug, err := s.repo.UniqueSlug(ctx, domain.AuditEntityProduct, domain.Slugify(req.Name), uuid.Nil)
+			if err != nil {
+				s.log(ctx).WithError(err).Error("Failed to generate product slug")
+				return errors.NewInternalError("Failed to generate slug", err)
+			}
+			product.Slug = slug
+		}
+
+		created, err = s.repo.UpsertBySKU(ctx, product, movement)
+		if err != nil {
+			s.log(ctx).WithError(err).WithField("sku", sku).Error("Failed to upsert product")
+			return errors.NewInternalError("Failed to upsert product", err)
+		}
+
+		action := domain.AuditActionUpdate
+		var previous interface{}
+		if created {
+			action = domain.AuditActionCreate
+		} else if before != nil {
+			previous = before
+		}
+		if err := s.repo.CreateAuditEvent(ctx, s.auditEvent(ctx, domain.AuditEntityProduct, product.ID, action, previous, product)); err != nil {
+			s.log(ctx).WithError(err).WithField("product_id", product.ID).Error("Failed to record audit event")
+			return errors.NewInternalError("Failed to upsert product", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Invalidate cache
+	if err := s.repo.InvalidateProductCache(ctx); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to invalidate product cache")
+		return nil, false, errors.NewInternalError("Failed to invalidate cache", err)
+	}
+
+	if created {
+		s.publish(ctx, domain.EventProductCreated, product)
+	} else {
+		s.publish(ctx, domain.EventProductUpdated, product)
+		if before == nil || product.Stock != before.Stock || product.StockThreshold(s.stock.LowThreshold) != before.StockThreshold(s.stock.LowThreshold) {
+			s.checkLowStock(ctx, product)
+		}
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"product_id": product.ID,
+		"sku":        sku,
+		"created":    created,
+	}).Info("Product upserted successfully")
+	return product, created, nil
+}
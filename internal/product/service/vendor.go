@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+)
+
+func (s *productService) CreateVendor(ctx context.Context, req *domain.CreateVendorRequest) (*domain.Vendor, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing vendors requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid create vendor request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	vendor := &domain.Vendor{
+		Name:           req.Name,
+		Email:          req.Email,
+		OwnerID:        req.OwnerID,
+		CommissionRate: req.CommissionRate,
+		Status:         domain.VendorStatusActive,
+	}
+	if err := s.repo.CreateVendor(ctx, vendor); err != nil {
+		if errors.IsConflict(err) {
+			return nil, err
+		}
+		s.log(ctx).WithError(err).Error("Failed to create vendor")
+		return nil, errors.NewInternalError("Failed to create vendor", err)
+	}
+
+	s.audit(ctx, domain.AuditEntityVendor, vendor.ID, domain.AuditActionCreate, nil, vendor)
+
+	s.log(ctx).WithField("vendor_id", vendor.ID).Info("Vendor created successfully")
+	return vendor, nil
+}
+
+// GetVendor returns a vendor. Vendors may only see their own.
+func (s *productService) GetVendor(ctx context.Context, id uuid.UUID) (*domain.Vendor, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		if vendor := domain.VendorFromContext(ctx); vendor == nil || vendor.ID != id {
+			return nil, errors.NewForbiddenError("Viewing vendors requires the admin role", nil)
+		}
+	}
+
+	vendor, err := s.repo.GetVendor(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Vendor not found", err).WithCode(errors.CodeVendorNotFound)
+		}
+		s.log(ctx).WithError(err).Error("Failed to get vendor")
+		return nil, errors.NewInternalError("Failed to get vendor", err)
+	}
+
+	return vendor, nil
+}
+
+// GetOwnVendor returns the vendor the caller sells as
+func (s *productService) GetOwnVendor(ctx context.Context) (*domain.Vendor, error) {
+	vendor := domain.VendorFromContext(ctx)
+	if vendor == nil {
+		return nil, errors.NewNotFoundError("Caller is not a vendor", nil).WithCode(errors.CodeVendorNotFound)
+	}
+	return vendor, nil
+}
+
+func (s *productService) UpdateVendor(ctx context.Context, id uuid.UUID, req *domain.UpdateVendorRequest) (*domain.Vendor, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing vendors requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid update vendor request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	vendor, err := s.GetVendor(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	before := *vendor
+
+	if req.Name != nil {
+		vendor.Name = *req.Name
+	}
+	if req.Email != nil {
+		vendor.Email = *req.Email
+	}
+	if req.CommissionRate != nil {
+		vendor.CommissionRate = *req.CommissionRate
+	}
+	if req.Status != nil {
+		vendor.Status = *req.Status
+	}
+
+	if err := s.repo.UpdateVendor(ctx, vendor); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to update vendor")
+		return nil, errors.NewInternalError("Failed to update vendor", err)
+	}
+
+	s.audit(ctx, domain.AuditEntityVendor, vendor.ID, domain.AuditActionUpdate, &before, vendor)
+
+	s.log(ctx).WithField("vendor_id", id).Info("Vendor updated successfully")
+	return vendor, nil
+}
+
+func (s *productService) ListVendors(ctx context.Context) ([]domain.Vendor, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Viewing vendors requires the admin role", nil)
+	}
+
+	vendors, err := s.repo.ListVendors(ctx)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list vendors")
+		return nil, errors.NewInternalError("Failed to list vendors", err)
+	}
+
+	return vendors, nil
+}
+
+// VendorScope returns ctx acting for the vendor the caller sells as, when
+// they have the vendor role and a vendor. Other callers' contexts are
+// returned as they are.
+func (s *productService) VendorScope(ctx context.Context) (context.Context, error) {
+	if !auth.HasRole(ctx, auth.RoleVendor) {
+		return ctx, nil
+	}
+
+	vendor, err := s.repo.GetVendorByOwner(ctx, auth.ActorID(ctx))
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return ctx, nil
+		}
+		s.log(ctx).WithError(err).Error("Failed to get vendor")
+		return nil, errors.NewInternalError("Failed to get vendor", err)
+	}
+	return domain.WithVendor(ctx, vendor), nil
+}
+
+// AuthorizeVendorChange checks that a vendor may change the catalog: that
+// they have an active vendor and, for a change to a product, that they
+// sell it. Callers without the vendor role pass; each operation checks
+// them itself, with managesCatalog or managesProduct.
+func (s *productService) AuthorizeVendorChange(ctx context.Context, productID *uuid.UUID) error {
+	if !auth.HasRole(ctx, auth.RoleVendor) {
+		return nil
+	}
+
+	vendor := domain.VendorFromContext(ctx)
+	if vendor == nil {
+		return errors.NewForbiddenError("Caller is not a vendor", nil).WithCode(errors.CodeVendorNotFound)
+	}
+	if !vendor.IsActive() {
+		return errors.NewForbiddenError("Vendor is suspended", nil).WithCode(errors.CodeVendorSuspended)
+	}
+	if productID == nil {
+		return nil
+	}
+
+	product, err := s.repo.GetByID(ctx, *productID)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return errors.NewNotFoundError("Product not found", err).WithCode(errors.CodeProductNotFound)
+		}
+		return errors.NewInternalError("Failed to get product", err)
+	}
+	if !product.SoldBy(vendor) {
+		return errors.NewForbiddenError("Vendors may only change their own products", nil).WithCode(errors.CodeProductNotOwned)
+	}
+	return nil
+}
+
+// managesCatalog reports whether the caller may add products to the
+// catalog: admins and active vendors may
+func (s *productService) managesCatalog(ctx context.Context) bool {
+	if auth.HasRole(ctx, auth.RoleAdmin) {
+		return true
+	}
+	return auth.HasRole(ctx, auth.RoleVendor) && s.AuthorizeVendorChange(ctx, nil) == nil
+}
+
+// managesProduct reports whether the caller may manage the details of a
+// product: admins may manage any, active vendors those they sell
+func (s *productService) managesProduct(ctx context.Context, id uuid.UUID) bool {
+	if auth.HasRole(ctx, auth.RoleAdmin) {
+		return true
+	}
+	return auth.HasRole(ctx, auth.RoleVendor) && s.AuthorizeVendorChange(ctx, &id) == nil
+}
+
+// ApproveProduct approves a vendor product awaiting review, so it can be
+// published. Products scheduled for publishing go live at their time.
+func (s *productService) ApproveProduct(ctx context.Context, id uuid.UUID, req *domain.ReviewRequest) (*domain.Product, error) {
+	return s.reviewProduct(ctx, id, domain.ApprovalApproved, req)
+}
+
+// RejectProduct turns down a vendor product awaiting review
+func (s *productService) RejectProduct(ctx context.Context, id uuid.UUID, req *domain.ReviewRequest) (*domain.Product, error) {
+	return s.reviewProduct(ctx, id, domain.ApprovalRejected, req)
+}
+
+// reviewProduct moves a vendor product awaiting review to an approval
+// status and lets the vendor know
+func (s *productService) reviewProduct(ctx context.Context, id uuid.UUID, approval string, req *domain.ReviewRequest) (*domain.Product, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Reviewing vendor products requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid review request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	product, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Product not found", err).WithCode(errors.CodeProductNotFound)
+		}
+		return nil, errors.NewInternalError("Failed to get product", err)
+	}
+	if product.ApprovalStatus != domain.ApprovalPending {
+		return nil, errors.NewConflictError("Product is not awaiting review", nil).WithCode(errors.CodeProductNotPendingReview)
+	}
+	before := *product
+
+	product.ApprovalStatus = approval
+	product.ReviewComment = req.Comment
+	if err := s.repo.Update(ctx, product); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to review product")
+		return nil, errors.NewInternalError("Failed to review product", err)
+	}
+
+	// Listings filtered by approval status change with the product
+	if err := s.repo.InvalidateProductCache(ctx); err != nil {
+		s.log(ctx).WithError(err).Warn("Failed to invalidate product cache")
+	}
+
+	s.publish(ctx, domain.EventProductUpdated, product)
+	s.audit(ctx, domain.AuditEntityProduct, product.ID, domain.AuditActionUpdate, &before, product)
+
+	reviewed := &domain.ProductReviewed{
+		ProductID:   product.ID,
+		ProductName: product.Name,
+		Status:      approval,
+		Comment:     req.Comment,
+	}
+	if product.VendorID != nil {
+		if vendor, err := s.repo.GetVendor(ctx, *product.VendorID); err == nil {
+			reviewed.SubmittedBy = vendor.OwnerID
+			reviewed.Email = vendor.Email
+		} else {
+			s.log(ctx).WithError(err).Warn("Failed to get vendor of reviewed product")
+		}
+	}
+	s.publish(ctx, domain.EventProductReviewed, reviewed)
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"product_id": id,
+		"vendor_id":  product.VendorID,
+		"approval":   approval,
+	}).Info("Vendor product reviewed successfully")
+	return s.GetProduct(ctx, id)
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/product/domain"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+)
+
+func (s *productService) CreateWarehouse(ctx context.Context, req *domain.CreateWarehouseRequest) (*domain.Warehouse, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing warehouses requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid create warehouse request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	warehouse := &domain.Warehouse{
+		Code:     req.Code,
+		Name:     req.Name,
+		Priority: req.Priority,
+	}
+	if err := s.repo.CreateWarehouse(ctx, warehouse); err != nil {
+		if errors.IsConflict(err) {
+			return nil, err
+		}
+		s.log(ctx).WithError(err).Error("Failed to create warehouse")
+		return nil, errors.NewInternalError("Failed to create warehouse", err)
+	}
+
+	s.audit(ctx, domain.AuditEntityWarehouse, warehouse.ID, domain.AuditActionCreate, nil, warehouse)
+
+	s.log(ctx).WithField("warehouse_id", warehouse.ID).Info("Warehouse created successfully")
+	return warehouse, nil
+}
+
+func (s *productService) GetWarehouse(ctx context.Context, id uuid.UUID) (*domain.Warehouse, error) {
+	warehouse, err := s.repo.GetWarehouse(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Warehouse not found", err).WithCode(errors.CodeWarehouseNotFound)
+		}
+		s.log(ctx).WithError(err).Error("Failed to get warehouse")
+		return nil, errors.NewInternalError("Failed to get warehouse", err)
+	}
+
+	return warehouse, nil
+}
+
+func (s *productService) UpdateWarehouse(ctx context.Context, id uuid.UUID, req *domain.UpdateWarehouseRequest) (*domain.Warehouse, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing warehouses requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid update warehouse request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	warehouse, err := s.GetWarehouse(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	before := *warehouse
+
+	if req.Name != nil {
+		warehouse.Name = *req.Name
+	}
+	if req.Priority != nil {
+		warehouse.Priority = *req.Priority
+	}
+
+	if err := s.repo.UpdateWarehouse(ctx, warehouse); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to update warehouse")
+		return nil, errors.NewInternalError("Failed to update warehouse", err)
+	}
+
+	s.audit(ctx, domain.AuditEntityWarehouse, warehouse.ID, domain.AuditActionUpdate, &before, warehouse)
+
+	s.log(ctx).WithField("warehouse_id", id).Info("Warehouse updated successfully")
+	return warehouse, nil
+}
+
+func (s *productService) ListWarehouses(ctx context.Context) ([]domain.Warehouse, error) {
+	warehouses, err := s.repo.ListWarehouses(ctx)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list warehouses")
+		return nil, errors.NewInternalError("Failed to list warehouses", err)
+	}
+
+	return warehouses, nil
+}
+
+// AddAvailability fills in the stock products hold in each warehouse. With
+// a warehouse code, only that warehouse is listed and each product's stock
+// is what it holds there.
+func (s *productService) AddAvailability(ctx context.Context, warehouse string, products ...*domain.Product) error {
+	warehouseID, err := s.resolveWarehouse(ctx, warehouse)
+	if err != nil || len(products) == 0 {
+		return err
+	}
+
+	ids := make([]uuid.UUID, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+	levels, err := s.repo.ListStockLevels(ctx, ids, warehouseID)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list stock levels")
+		return errors.NewInternalError("Failed to get product availability", err)
+	}
+
+	byProduct := make(map[uuid.UUID][]domain.StockLevel, len(products))
+	for _, level := range levels {
+		byProduct[level.ProductID] = append(byProduct[level.ProductID], level)
+	}
+	for _, product := range products {
+		product.Availability = byProduct[product.ID]
+		if warehouseID == nil {
+			continue
+		}
+		product.Stock = 0
+		for _, level := range product.Availability {
+			product.Stock += level.Quantity
+		}
+	}
+	return nil
+}
+
+// resolveWarehouse looks up the warehouse with a code, for requests that
+// name one. It returns nil for an empty code.
+func (s *productService) resolveWarehouse(ctx context.Context, code string) (*uuid.UUID, error) {
+	if code == "" {
+		return nil, nil
+	}
+
+	warehouse, err := s.repo.GetWarehouseByCode(ctx, code)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewValidationError(fmt.Sprintf("Warehouse %s not found", code), err).WithCode(errors.CodeWarehouseNotFound)
+		}
+		return nil, errors.NewInternalError("Failed to get warehouse", err)
+	}
+	return &warehouse.ID, nil
+}
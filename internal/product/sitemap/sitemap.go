@@ -0,0 +1,377 @@
+package sitemap
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/product/config"
+	"ecommerce/internal/product/domain"
+	"ecommerce/internal/product/repository"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/events"
+	"ecommerce/pkg/storage"
+)
+
+// IndexFile is the name of the sitemap index, which lists the other files
+const IndexFile = "sitemap.xml"
+
+const (
+	// maxShardSize is the most URLs the sitemap protocol allows in one file
+	maxShardSize = 50000
+
+	namespace   = "http://www.sitemaps.org/schemas/sitemap/0.9"
+	contentType = "application/xml; charset=utf-8"
+
+	// filesKey is a Redis hash of file name to document, along with the
+	// time the files were generated
+	filesKey       = "sitemap:files"
+	generatedField = "generated_at"
+	// staleKey is set by catalog changes the cached files don't reflect
+	staleKey = "sitemap:stale"
+)
+
+type urlSet struct {
+	XMLName xml.Name   `xml:"urlset"`
+	Xmlns   string     `xml:"xmlns,attr"`
+	URLs    []urlEntry `xml:"url"`
+}
+
+type urlEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name   `xml:"sitemapindex"`
+	Xmlns    string     `xml:"xmlns,attr"`
+	Sitemaps []urlEntry `xml:"sitemap"`
+}
+
+// Generator builds the storefront sitemap from product and category slugs.
+// Large catalogs are split into files of at most the shard size, listed in
+// a sitemap index. The files are cached in Redis, where every replica
+// serves them from, and uploaded to media storage when it is configured.
+// Catalog events mark the cache stale; it is rebuilt on the next refresh.
+type Generator struct {
+	repo    repository.ProductRepository
+	redis   redis.UniversalClient
+	storage *storage.S3
+	store   config.StorefrontConfig
+	config  config.SitemapConfig
+	logger  *logrus.Logger
+
+	// mu is held for a whole build so a replica never runs two at once
+	mu sync.Mutex
+}
+
+// New creates a sitemap generator. Storage is only needed to upload the
+// files and may be nil.
+func New(repo repository.ProductRepository, redisClient redis.UniversalClient, storage *storage.S3, store config.StorefrontConfig, cfg config.SitemapConfig, logger *logrus.Logger) *Generator {
+	store.URL = strings.TrimRight(store.URL, "/")
+	cfg.URL = strings.TrimRight(cfg.URL, "/")
+	if cfg.URL == "" {
+		cfg.URL = store.URL
+	}
+	if cfg.ShardSize <= 0 || cfg.ShardSize > maxShardSize {
+		cfg.ShardSize = maxShardSize
+	}
+
+	return &Generator{
+		repo:    repo,
+		redis:   redisClient,
+		storage: storage,
+		store:   store,
+		config:  cfg,
+		logger:  logger,
+	}
+}
+
+// Register subscribes the generator to catalog events on the bus
+func (g *Generator) Register(bus *events.Bus) {
+	for _, eventType := range []string{
+		domain.EventProductCreated,
+		domain.EventProductUpdated,
+		domain.EventProductRestored,
+		domain.EventProductDeleted,
+		domain.EventCategoryCreated,
+		domain.EventCategoryUpdated,
+		domain.EventCategoryDeleted,
+	} {
+		bus.Subscribe(eventType, g.handleChange)
+	}
+}
+
+// File returns a sitemap file and when the sitemap was generated. The
+// sitemap is built first when none is cached.
+func (g *Generator) File(ctx context.Context, name string) ([]byte, time.Time, error) {
+	document, generated, err := g.cachedFile(ctx, name)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if generated.IsZero() {
+		if err := g.Refresh(ctx); err != nil {
+			return nil, time.Time{}, err
+		}
+		if document, generated, err = g.cachedFile(ctx, name); err != nil {
+			return nil, time.Time{}, err
+		}
+	}
+	if document == nil {
+		return nil, time.Time{}, errors.NewNotFoundError("Sitemap not found", nil)
+	}
+	return document, generated, nil
+}
+
+// Refresh rebuilds the sitemap when the catalog changed since it was
+// built, or when none is cached
+func (g *Generator) Refresh(ctx context.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	cached, err := g.redis.Exists(ctx, filesKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to check cached sitemap: %w", err)
+	}
+	// Clearing the flag claims the rebuild, so only one replica runs it;
+	// changes made while it runs set the flag again
+	stale, err := g.redis.Del(ctx, staleKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to check sitemap changes: %w", err)
+	}
+	if cached > 0 && stale == 0 {
+		return nil
+	}
+
+	if err := g.build(ctx); err != nil {
+		if err := g.redis.Set(ctx, staleKey, 1, 0).Err(); err != nil {
+			g.logger.WithError(err).Error("Failed to mark sitemap stale")
+		}
+		return err
+	}
+	return nil
+}
+
+func (g *Generator) handleChange(ctx context.Context, event events.Event) error {
+	if err := g.redis.Set(ctx, staleKey, 1, 0).Err(); err != nil {
+		return fmt.Errorf("failed to mark sitemap stale: %w", err)
+	}
+	return nil
+}
+
+// cachedFile returns a file from the cache and when it was generated. A
+// zero time means no sitemap is cached; a nil document with a generation
+// time means the sitemap has no such file.
+func (g *Generator) cachedFile(ctx context.Context, name string) ([]byte, time.Time, error) {
+	values, err := g.redis.HMGet(ctx, filesKey, name, generatedField).Result()
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to get cached sitemap: %w", err)
+	}
+
+	generatedAt, _ := values[1].(string)
+	generated, err := time.Parse(time.RFC3339Nano, generatedAt)
+	if err != nil {
+		return nil, time.Time{}, nil
+	}
+	document, ok := values[0].(string)
+	if !ok || name == generatedField {
+		return nil, generated, nil
+	}
+	return []byte(document), generated, nil
+}
+
+// build renders the sitemap files and replaces the cached ones
+func (g *Generator) build(ctx context.Context) error {
+	b := &builder{url: g.config.URL, size: g.config.ShardSize, files: make(map[string][]byte)}
+
+	categories, err := g.repo.ListCategories(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list categories: %w", err)
+	}
+	if err := b.start("categories"); err != nil {
+		return err
+	}
+	for _, category := range categories {
+		if !category.IsActive || category.Slug == "" {
+			continue
+		}
+		if err := b.add(g.pageURL(g.store.CategoryPath, category.Slug), category.UpdatedAt); err != nil {
+			return err
+		}
+	}
+
+	// Drafts and archived products aren't on the storefront; published
+	// products that can't be bought still are
+	if err := b.start("products"); err != nil {
+		return err
+	}
+	filters := &domain.ProductFilters{Status: domain.ProductStatusPublished}
+	err = g.repo.Iterate(ctx, filters, g.config.BatchSize, func(products []domain.Product) error {
+		for _, product := range products {
+			if product.Slug == "" {
+				continue
+			}
+			if err := b.add(g.pageURL(g.store.ProductPath, product.Slug), product.UpdatedAt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := b.finish(); err != nil {
+		return err
+	}
+	if err := g.save(ctx, b.files); err != nil {
+		return err
+	}
+
+	g.logger.WithFields(logrus.Fields{
+		"files": len(b.files),
+		"urls":  b.urls,
+	}).Info("Sitemap built successfully")
+	return nil
+}
+
+// save replaces the cached files, and the uploaded ones when media
+// storage is configured
+func (g *Generator) save(ctx context.Context, files map[string][]byte) error {
+	previous, err := g.redis.HKeys(ctx, filesKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to list cached sitemap files: %w", err)
+	}
+
+	fields := make(map[string]interface{}, len(files)+1)
+	for name, document := range files {
+		fields[name] = document
+	}
+	fields[generatedField] = time.Now().UTC().Format(time.RFC3339Nano)
+
+	_, err = g.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, filesKey)
+		pipe.HSet(ctx, filesKey, fields)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to cache sitemap: %w", err)
+	}
+
+	if g.storage == nil {
+		return nil
+	}
+	for name, document := range files {
+		if err := g.storage.Put(ctx, g.config.ExportPrefix+name, contentType, document); err != nil {
+			return fmt.Errorf("failed to upload sitemap %s: %w", name, err)
+		}
+	}
+	// Files left over from a larger catalog would still be crawled
+	for _, name := range previous {
+		if _, ok := files[name]; ok || name == generatedField {
+			continue
+		}
+		if err := g.storage.Delete(ctx, g.config.ExportPrefix+name); err != nil {
+			g.logger.WithError(err).WithField("file", name).Error("Failed to remove old sitemap file")
+		}
+	}
+	return nil
+}
+
+// pageURL returns the storefront URL of a page
+func (g *Generator) pageURL(path, slug string) string {
+	return g.store.URL + strings.ReplaceAll(path, "{slug}", slug)
+}
+
+// builder splits URLs into sitemap files of at most size URLs, named
+// sitemap-<kind>-<n>.xml, and indexes them
+type builder struct {
+	url   string
+	size  int
+	files map[string][]byte
+	index sitemapIndex
+	urls  int
+
+	kind    string
+	shards  int
+	shard   []urlEntry
+	lastMod time.Time
+}
+
+// start begins the files of a kind of page, finishing the previous kind's
+func (b *builder) start(kind string) error {
+	if err := b.flush(); err != nil {
+		return err
+	}
+	b.kind = kind
+	b.shards = 0
+	return nil
+}
+
+func (b *builder) add(loc string, lastMod time.Time) error {
+	b.shard = append(b.shard, urlEntry{Loc: loc, LastMod: formatTime(lastMod)})
+	if lastMod.After(b.lastMod) {
+		b.lastMod = lastMod
+	}
+	b.urls++
+	if len(b.shard) >= b.size {
+		return b.flush()
+	}
+	return nil
+}
+
+// flush renders the URLs added since the last file into a new file
+func (b *builder) flush() error {
+	if len(b.shard) == 0 {
+		return nil
+	}
+	b.shards++
+	name := fmt.Sprintf("sitemap-%s-%d.xml", b.kind, b.shards)
+
+	document, err := render(urlSet{Xmlns: namespace, URLs: b.shard})
+	if err != nil {
+		return err
+	}
+	b.files[name] = document
+	b.index.Sitemaps = append(b.index.Sitemaps, urlEntry{Loc: b.url + "/" + name, LastMod: formatTime(b.lastMod)})
+
+	b.shard = nil
+	b.lastMod = time.Time{}
+	return nil
+}
+
+// finish renders the last file and the index
+func (b *builder) finish() error {
+	if err := b.flush(); err != nil {
+		return err
+	}
+	b.index.Xmlns = namespace
+	document, err := render(b.index)
+	if err != nil {
+		return err
+	}
+	b.files[IndexFile] = document
+	return nil
+}
+
+func render(v interface{}) ([]byte, error) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render sitemap: %w", err)
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// formatTime formats a last modification time in W3C datetime format
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
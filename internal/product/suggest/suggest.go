@@ -0,0 +1,473 @@
+package suggest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/product/config"
+	"ecommerce/internal/product/domain"
+	"ecommerce/internal/product/repository"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/events"
+	"ecommerce/pkg/tenant"
+)
+
+// Every key shares the {suggest} hash tag, so in a Redis Cluster they sit
+// in one slot and can be swapped and updated together in a transaction
+const (
+	// indexKey is a sorted set with every member at score 0, so members
+	// are ordered by their bytes and a prefix is a range of them. Members
+	// are "<key text>\x00<type>\x00<id>\x00<slug>\x00<text>", which lets a
+	// lookup answer from a single range query.
+	indexKey = "{suggest}:index"
+	// entriesKey is a hash of "<type>:<id>" to the indexed suggestion, used
+	// to find an entry's members when it changes
+	entriesKey = "{suggest}:entries"
+	builtKey   = "{suggest}:built_at"
+	// buildingKey is held while a rebuild runs; changes made meanwhile are
+	// queued in pendingKey and applied to the new index once it is swapped in
+	buildingKey = "{suggest}:building"
+	pendingKey  = "{suggest}:pending"
+
+	buildTTL = 30 * time.Minute
+
+	// maxKeyWords bounds the words of a name matched as the start of a
+	// suggestion, and so the members each entry takes
+	maxKeyWords = 8
+)
+
+// Suggester serves type-ahead suggestions for product, category and brand
+// names from a Redis index. Any word of a name can start a match, so
+// "shirt" suggests "Blue Shirt". Catalog events keep the index up to date;
+// it is rebuilt in full when missing and every rebuild interval.
+type Suggester struct {
+	repo   repository.ProductRepository
+	redis  redis.UniversalClient
+	config config.SuggestConfig
+	logger *logrus.Logger
+}
+
+// New creates a suggester
+func New(repo repository.ProductRepository, redisClient redis.UniversalClient, cfg config.SuggestConfig, logger *logrus.Logger) *Suggester {
+	if cfg.MaxLimit <= 0 {
+		cfg.MaxLimit = 20
+	}
+	if cfg.Limit <= 0 || cfg.Limit > cfg.MaxLimit {
+		cfg.Limit = cfg.MaxLimit
+	}
+
+	return &Suggester{
+		repo:   repo,
+		redis:  redisClient,
+		config: cfg,
+		logger: logger,
+	}
+}
+
+// Register subscribes the suggester to catalog events on the bus
+func (s *Suggester) Register(bus *events.Bus) {
+	for _, eventType := range []string{
+		domain.EventProductCreated,
+		domain.EventProductUpdated,
+		domain.EventProductRestored,
+		domain.EventProductDeleted,
+	} {
+		bus.Subscribe(eventType, s.handleChange(domain.SuggestionProduct))
+	}
+	for _, eventType := range []string{
+		domain.EventCategoryCreated,
+		domain.EventCategoryUpdated,
+		domain.EventCategoryDeleted,
+	} {
+		bus.Subscribe(eventType, s.handleChange(domain.SuggestionCategory))
+	}
+	for _, eventType := range []string{
+		domain.EventBrandCreated,
+		domain.EventBrandUpdated,
+		domain.EventBrandDeleted,
+	} {
+		bus.Subscribe(eventType, s.handleChange(domain.SuggestionBrand))
+	}
+}
+
+// Suggest returns up to limit suggestions whose name has a word starting
+// with the query. A non-positive limit uses the configured default.
+func (s *Suggester) Suggest(ctx context.Context, query string, limit int) ([]domain.Suggestion, error) {
+	if limit <= 0 {
+		limit = s.config.Limit
+	}
+	if limit > s.config.MaxLimit {
+		limit = s.config.MaxLimit
+	}
+
+	suggestions := []domain.Suggestion{}
+	prefix := keyText(query)
+	if prefix == "" {
+		return suggestions, nil
+	}
+
+	// An entry matches once for each of its words the query starts, so
+	// read ahead enough to fill the limit after dropping repeats
+	members, err := s.redis.ZRangeByLex(ctx, indexKey, &redis.ZRangeBy{
+		Min:   "[" + prefix,
+		Max:   "[" + prefix + "\xff",
+		Count: int64(limit * 2),
+	}).Result()
+	if err != nil {
+		return nil, errors.NewUnavailableError("Suggestions are unavailable", err)
+	}
+
+	seen := make(map[string]bool, len(members))
+	for _, member := range members {
+		suggestion, ok := parseMember(member)
+		if !ok {
+			continue
+		}
+		ref := entryRef(suggestion.Type, suggestion.ID)
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		suggestions = append(suggestions, suggestion)
+		if len(suggestions) == limit {
+			break
+		}
+	}
+	return suggestions, nil
+}
+
+// Refresh rebuilds the index when it is missing or older than the rebuild
+// interval, unless another replica is already rebuilding it
+func (s *Suggester) Refresh(ctx context.Context) error {
+	builtAt, err := s.redis.Get(ctx, builtKey).Result()
+	if err != nil && err != redis.Nil {
+		return fmt.Errorf("failed to check suggestion index: %w", err)
+	}
+	if built, err := time.Parse(time.RFC3339Nano, builtAt); err == nil {
+		if s.config.RebuildInterval <= 0 || time.Since(built) < time.Duration(s.config.RebuildInterval)*time.Second {
+			return nil
+		}
+	}
+
+	claimed, err := s.redis.SetNX(ctx, buildingKey, 1, buildTTL).Result()
+	if err != nil {
+		return fmt.Errorf("failed to claim suggestion index rebuild: %w", err)
+	}
+	if !claimed {
+		return nil
+	}
+	defer func() {
+		if err := s.redis.Del(context.WithoutCancel(ctx), buildingKey).Err(); err != nil {
+			s.logger.WithError(err).Error("Failed to release suggestion index rebuild")
+		}
+	}()
+
+	return s.rebuild(ctx)
+}
+
+func (s *Suggester) handleChange(kind string) events.Handler {
+	return func(ctx context.Context, event events.Event) error {
+		var payload struct {
+			ID uuid.UUID `json:"id"`
+		}
+		if err := event.Decode(&payload); err != nil {
+			return fmt.Errorf("failed to decode %s event: %w", kind, err)
+		}
+
+		// Suggestions come from the default tenant's catalog; entries of
+		// other tenants look gone and are left out
+		if err := s.sync(tenant.WithID(ctx, tenant.Default), kind, payload.ID); err != nil {
+			return err
+		}
+
+		// A rebuild in progress may have read the entry before the change
+		building, err := s.redis.Exists(ctx, buildingKey).Result()
+		if err != nil {
+			return fmt.Errorf("failed to check suggestion index rebuild: %w", err)
+		}
+		if building > 0 {
+			if err := s.redis.SAdd(ctx, pendingKey, entryRef(kind, payload.ID)).Err(); err != nil {
+				return fmt.Errorf("failed to queue suggestion change: %w", err)
+			}
+		}
+		return nil
+	}
+}
+
+// sync brings an entry up to date with the catalog, removing it when the
+// product, category or brand is gone or no longer shown on the storefront
+func (s *Suggester) sync(ctx context.Context, kind string, id uuid.UUID) error {
+	suggestion, err := s.load(ctx, kind, id)
+	if err != nil {
+		return err
+	}
+
+	ref := entryRef(kind, id)
+	var previous []string
+	stored, err := s.redis.HGet(ctx, entriesKey, ref).Result()
+	switch {
+	case err == nil:
+		var old domain.Suggestion
+		if err := json.Unmarshal([]byte(stored), &old); err == nil {
+			previous = members(old)
+		}
+	case err != redis.Nil:
+		return fmt.Errorf("failed to get suggestion entry: %w", err)
+	}
+
+	_, err = s.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if len(previous) > 0 {
+			pipe.ZRem(ctx, indexKey, toInterfaces(previous)...)
+		}
+		if suggestion == nil {
+			pipe.HDel(ctx, entriesKey, ref)
+			return nil
+		}
+		return add(ctx, pipe, indexKey, entriesKey, *suggestion)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update suggestion entry: %w", err)
+	}
+	return nil
+}
+
+// load returns the suggestion for a product, category or brand, or nil when
+// it shouldn't be suggested
+func (s *Suggester) load(ctx context.Context, kind string, id uuid.UUID) (*domain.Suggestion, error) {
+	switch kind {
+	case domain.SuggestionProduct:
+		product, err := s.repo.GetByID(ctx, id)
+		if errors.IsNotFound(err) || (err == nil && product.Status != domain.ProductStatusPublished) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load product for suggestions: %w", err)
+		}
+		return &domain.Suggestion{Type: kind, ID: product.ID, Text: product.Name, Slug: product.Slug}, nil
+	case domain.SuggestionCategory:
+		category, err := s.repo.GetCategory(ctx, id)
+		if errors.IsNotFound(err) || (err == nil && !category.IsActive) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load category for suggestions: %w", err)
+		}
+		return &domain.Suggestion{Type: kind, ID: category.ID, Text: category.Name, Slug: category.Slug}, nil
+	case domain.SuggestionBrand:
+		brand, err := s.repo.GetBrand(ctx, id)
+		if errors.IsNotFound(err) || (err == nil && !brand.IsActive) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load brand for suggestions: %w", err)
+		}
+		return &domain.Suggestion{Type: kind, ID: brand.ID, Text: brand.Name}, nil
+	}
+	return nil, fmt.Errorf("unknown suggestion type %q", kind)
+}
+
+// rebuild indexes the whole catalog into new keys and swaps them in, then
+// applies the changes made while it ran
+func (s *Suggester) rebuild(ctx context.Context) error {
+	started := time.Now()
+	buildIndexKey := indexKey + ":build"
+	buildEntriesKey := entriesKey + ":build"
+	if err := s.redis.Del(ctx, buildIndexKey, buildEntriesKey).Err(); err != nil {
+		return fmt.Errorf("failed to clear suggestion index build: %w", err)
+	}
+
+	var suggestions []domain.Suggestion
+	entries := 0
+	flush := func() error {
+		if len(suggestions) == 0 {
+			return nil
+		}
+		_, err := s.redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, suggestion := range suggestions {
+				if len(members(suggestion)) == 0 {
+					continue
+				}
+				entries++
+				if err := add(ctx, pipe, buildIndexKey, buildEntriesKey, suggestion); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write suggestion index: %w", err)
+		}
+		suggestions = suggestions[:0]
+		return nil
+	}
+
+	categories, err := s.repo.ListCategories(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list categories: %w", err)
+	}
+	for _, category := range categories {
+		suggestions = append(suggestions, domain.Suggestion{Type: domain.SuggestionCategory, ID: category.ID, Text: category.Name, Slug: category.Slug})
+	}
+	brands, err := s.repo.ListBrands(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list brands: %w", err)
+	}
+	for _, brand := range brands {
+		suggestions = append(suggestions, domain.Suggestion{Type: domain.SuggestionBrand, ID: brand.ID, Text: brand.Name})
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	filters := &domain.ProductFilters{Status: domain.ProductStatusPublished}
+	err = s.repo.Iterate(ctx, filters, s.config.BatchSize, func(products []domain.Product) error {
+		for _, product := range products {
+			suggestions = append(suggestions, domain.Suggestion{Type: domain.SuggestionProduct, ID: product.ID, Text: product.Name, Slug: product.Slug})
+		}
+		return flush()
+	})
+	if err != nil {
+		return err
+	}
+
+	// An empty catalog leaves nothing to rename, so swap in empty keys
+	_, err = s.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if entries == 0 {
+			pipe.Del(ctx, indexKey, entriesKey)
+		} else {
+			pipe.Rename(ctx, buildIndexKey, indexKey)
+			pipe.Rename(ctx, buildEntriesKey, entriesKey)
+		}
+		pipe.Set(ctx, builtKey, started.UTC().Format(time.RFC3339Nano), 0)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to swap in suggestion index: %w", err)
+	}
+
+	if err := s.applyPending(ctx); err != nil {
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"entries":  entries,
+		"duration": time.Since(started).String(),
+	}).Info("Suggestion index rebuilt successfully")
+	return nil
+}
+
+// applyPending syncs the entries changed while a rebuild ran
+func (s *Suggester) applyPending(ctx context.Context) error {
+	for {
+		ref, err := s.redis.SPop(ctx, pendingKey).Result()
+		if err == redis.Nil {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read queued suggestion changes: %w", err)
+		}
+
+		kind, id, ok := parseRef(ref)
+		if !ok {
+			continue
+		}
+		if err := s.sync(ctx, kind, id); err != nil {
+			return err
+		}
+	}
+}
+
+// add writes a suggestion's members and entry
+func add(ctx context.Context, pipe redis.Pipeliner, index, entries string, suggestion domain.Suggestion) error {
+	keys := members(suggestion)
+	if len(keys) == 0 {
+		return nil
+	}
+	entry, err := json.Marshal(suggestion)
+	if err != nil {
+		return fmt.Errorf("failed to encode suggestion entry: %w", err)
+	}
+
+	scored := make([]redis.Z, len(keys))
+	for idx, key := range keys {
+		scored[idx] = redis.Z{Member: key}
+	}
+	pipe.ZAdd(ctx, index, scored...)
+	pipe.HSet(ctx, entries, entryRef(suggestion.Type, suggestion.ID), entry)
+	return nil
+}
+
+// members returns the index members of a suggestion, one for each word of
+// its name that a match can start at
+func members(suggestion domain.Suggestion) []string {
+	words := strings.Fields(keyText(suggestion.Text))
+	if len(words) > maxKeyWords {
+		words = words[:maxKeyWords]
+	}
+
+	tail := strings.Join([]string{suggestion.Type, suggestion.ID.String(), clean(suggestion.Slug), clean(suggestion.Text)}, "\x00")
+	keys := make([]string, 0, len(words))
+	seen := make(map[string]bool, len(words))
+	for idx := range words {
+		key := strings.Join(words[idx:], " ")
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key+"\x00"+tail)
+	}
+	return keys
+}
+
+func parseMember(member string) (domain.Suggestion, bool) {
+	parts := strings.Split(member, "\x00")
+	if len(parts) != 5 {
+		return domain.Suggestion{}, false
+	}
+	id, err := uuid.Parse(parts[2])
+	if err != nil {
+		return domain.Suggestion{}, false
+	}
+	return domain.Suggestion{Type: parts[1], ID: id, Slug: parts[3], Text: parts[4]}, true
+}
+
+// keyText normalizes text for matching
+func keyText(text string) string {
+	return domain.NormalizeSearchTerm(clean(text))
+}
+
+// clean drops the separator byte of index members
+func clean(text string) string {
+	return strings.ReplaceAll(text, "\x00", "")
+}
+
+func entryRef(kind string, id uuid.UUID) string {
+	return kind + ":" + id.String()
+}
+
+func parseRef(ref string) (string, uuid.UUID, bool) {
+	kind, rawID, ok := strings.Cut(ref, ":")
+	if !ok {
+		return "", uuid.Nil, false
+	}
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return "", uuid.Nil, false
+	}
+	return kind, id, true
+}
+
+func toInterfaces(values []string) []interface{} {
+	result := make([]interface{}, len(values))
+	for idx, value := range values {
+		result[idx] = value
+	}
+	return result
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"errors"
+
+	productconfig "ecommerce/internal/product/config"
+)
+
+// Config holds all configuration for the promotion service
+type Config struct {
+	Env      string
+	HTTP     productconfig.HTTPConfig
+	Database productconfig.DatabaseConfig
+	Auth     productconfig.AuthConfig
+	Debug    productconfig.DebugConfig
+	MTLS     productconfig.MTLSConfig
+	Logger   productconfig.LoggerConfig
+}
+
+// Load loads configuration from environment variables. The promotion service
+// reads the same HTTP, database and auth variables as the product service.
+func Load() (*Config, error) {
+	shared, err := productconfig.Load()
+	if err != nil {
+		return nil, err
+	}
+	return &Config{
+		Env:      shared.Env,
+		HTTP:     shared.HTTP,
+		Database: shared.Database,
+		Auth:     shared.Auth,
+		Debug:    shared.Debug,
+		MTLS:     shared.MTLS,
+		Logger:   shared.Logger,
+	}, nil
+}
+
+// Validate reports every setting that is missing or invalid, so the service
+// refuses to start rather than run misconfigured
+func (c *Config) Validate() error {
+	return errors.Join(
+		productconfig.ValidateEnv(c.Env),
+		c.HTTP.Validate(),
+		c.Database.Validate(c.Env),
+		c.Logger.Validate(),
+		c.MTLS.Validate(),
+		c.Auth.Validate(),
+	)
+}
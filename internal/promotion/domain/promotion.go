@@ -0,0 +1,183 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Promotion types
+const (
+	TypePercentage = "percentage"
+	TypeFixed      = "fixed"
+	TypeBuyXGetY   = "buy_x_get_y"
+)
+
+// Promotion scopes
+const (
+	ScopeAll        = "all"
+	ScopeProducts   = "products"
+	ScopeCategories = "categories"
+)
+
+// Promotion represents a discount rule. Promotions without a coupon code
+// apply automatically to every eligible basket.
+type Promotion struct {
+	ID          uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name        string      `json:"name" gorm:"not null"`
+	Description string      `json:"description"`
+	Type        string      `json:"type" gorm:"not null"`
+	Value       float64     `json:"value"`                  // percent off, amount off, or percent off the free items (100 when zero)
+	BuyQuantity int         `json:"buy_quantity,omitempty"` // buy_x_get_y only
+	GetQuantity int         `json:"get_quantity,omitempty"` // buy_x_get_y only
+	MinSubtotal float64     `json:"min_subtotal"`
+	Scope       string      `json:"scope" gorm:"not null;default:all"`
+	ProductIDs  []uuid.UUID `json:"product_ids,omitempty" gorm:"type:jsonb;serializer:json"`
+	CategoryIDs []uuid.UUID `json:"category_ids,omitempty" gorm:"type:jsonb;serializer:json"`
+	CouponCode  *string     `json:"coupon_code,omitempty" gorm:"uniqueIndex"`
+	UsageLimit  int         `json:"usage_limit"` // 0 means unlimited
+	UsageCount  int         `json:"usage_count"`
+	Priority    int         `json:"priority"`
+	Stackable   bool        `json:"stackable"`
+	IsActive    bool        `json:"is_active" gorm:"default:true"`
+	StartsAt    *time.Time  `json:"starts_at,omitempty"`
+	EndsAt      *time.Time  `json:"ends_at,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// InWindow reports whether the promotion's validity window contains t
+func (p *Promotion) InWindow(t time.Time) bool {
+	if p.StartsAt != nil && t.Before(*p.StartsAt) {
+		return false
+	}
+	if p.EndsAt != nil && !t.Before(*p.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// Exhausted reports whether the promotion has reached its usage limit
+func (p *Promotion) Exhausted() bool {
+	return p.UsageLimit > 0 && p.UsageCount >= p.UsageLimit
+}
+
+// Redemption records a promotion used by an order
+type Redemption struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	PromotionID uuid.UUID `json:"promotion_id" gorm:"type:uuid;not null"`
+	OrderID     string    `json:"order_id" gorm:"not null"`
+	CustomerID  string    `json:"customer_id,omitempty"`
+	Discount    float64   `json:"discount"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CreatePromotionRequest represents the request to create a promotion
+type CreatePromotionRequest struct {
+	Name        string      `json:"name" validate:"required,min=1,max=255"`
+	Description string      `json:"description"`
+	Type        string      `json:"type" validate:"required,oneof=percentage fixed buy_x_get_y"`
+	Value       float64     `json:"value" validate:"gte=0"`
+	BuyQuantity int         `json:"buy_quantity" validate:"gte=0"`
+	GetQuantity int         `json:"get_quantity" validate:"gte=0"`
+	MinSubtotal float64     `json:"min_subtotal" validate:"gte=0"`
+	Scope       string      `json:"scope" validate:"omitempty,oneof=all products categories"`
+	ProductIDs  []uuid.UUID `json:"product_ids"`
+	CategoryIDs []uuid.UUID `json:"category_ids"`
+	CouponCode  string      `json:"coupon_code" validate:"omitempty,min=3,max=64"`
+	UsageLimit  int         `json:"usage_limit" validate:"gte=0"`
+	Priority    int         `json:"priority"`
+	Stackable   bool        `json:"stackable"`
+	StartsAt    *time.Time  `json:"starts_at"`
+	EndsAt      *time.Time  `json:"ends_at"`
+}
+
+// UpdatePromotionRequest represents the request to update a promotion
+type UpdatePromotionRequest struct {
+	Name        *string     `json:"name,omitempty" validate:"omitempty,min=1,max=255"`
+	Description *string     `json:"description,omitempty"`
+	Value       *float64    `json:"value,omitempty" validate:"omitempty,gte=0"`
+	BuyQuantity *int        `json:"buy_quantity,omitempty" validate:"omitempty,gte=0"`
+	GetQuantity *int        `json:"get_quantity,omitempty" validate:"omitempty,gte=0"`
+	MinSubtotal *float64    `json:"min_subtotal,omitempty" validate:"omitempty,gte=0"`
+	Scope       *string     `json:"scope,omitempty" validate:"omitempty,oneof=all products categories"`
+	ProductIDs  []uuid.UUID `json:"product_ids,omitempty"`
+	CategoryIDs []uuid.UUID `json:"category_ids,omitempty"`
+	UsageLimit  *int        `json:"usage_limit,omitempty" validate:"omitempty,gte=0"`
+	Priority    *int        `json:"priority,omitempty"`
+	Stackable   *bool       `json:"stackable,omitempty"`
+	IsActive    *bool       `json:"is_active,omitempty"`
+	StartsAt    *time.Time  `json:"starts_at,omitempty"`
+	EndsAt      *time.Time  `json:"ends_at,omitempty"`
+}
+
+// PromotionFilters represents filters for promotion queries
+type PromotionFilters struct {
+	IsActive *bool `json:"is_active,omitempty"`
+	Coupon   *bool `json:"coupon,omitempty"` // only coupon (true) or automatic (false) promotions
+	Limit    int   `json:"limit,omitempty"`
+	Offset   int   `json:"offset,omitempty"`
+}
+
+// PromotionList represents a paginated list of promotions
+type PromotionList struct {
+	Promotions []Promotion `json:"promotions"`
+	Total      int64       `json:"total"`
+	Limit      int         `json:"limit"`
+	Offset     int         `json:"offset"`
+	HasMore    bool        `json:"has_more"`
+}
+
+// BasketItem is a line of the basket being priced
+type BasketItem struct {
+	ProductID  uuid.UUID `json:"product_id" validate:"required"`
+	CategoryID uuid.UUID `json:"category_id"`
+	Quantity   int       `json:"quantity" validate:"required,gt=0"`
+	UnitPrice  float64   `json:"unit_price" validate:"gte=0"`
+}
+
+// EvaluateRequest represents a basket to price
+type EvaluateRequest struct {
+	Items       []BasketItem `json:"items" validate:"required,min=1,dive"`
+	CouponCodes []string     `json:"coupon_codes"`
+}
+
+// Evaluation is the priced basket
+type Evaluation struct {
+	Subtotal float64            `json:"subtotal"`
+	Discount float64            `json:"discount"`
+	Total    float64            `json:"total"`
+	Applied  []AppliedPromotion `json:"applied"`
+	Rejected []RejectedCoupon   `json:"rejected,omitempty"`
+}
+
+// AppliedPromotion describes a promotion that discounted the basket
+type AppliedPromotion struct {
+	PromotionID uuid.UUID `json:"promotion_id"`
+	Name        string    `json:"name"`
+	CouponCode  string    `json:"coupon_code,omitempty"`
+	Discount    float64   `json:"discount"`
+}
+
+// RejectedCoupon explains why a submitted coupon code was not applied
+type RejectedCoupon struct {
+	Code   string `json:"code"`
+	Reason string `json:"reason"`
+}
+
+// RedeemRequest records the promotions used by a placed order
+type RedeemRequest struct {
+	OrderID    string             `json:"order_id" validate:"required"`
+	CustomerID string             `json:"customer_id"`
+	Applied    []AppliedPromotion `json:"applied" validate:"required,min=1"`
+}
+
+// TableName returns the table name for Promotion
+func (Promotion) TableName() string {
+	return "promotions"
+}
+
+// TableName returns the table name for Redemption
+func (Redemption) TableName() string {
+	return "promotion_redemptions"
+}
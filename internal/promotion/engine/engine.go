@@ -0,0 +1,199 @@
+package engine
+
+import (
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"ecommerce/internal/promotion/domain"
+)
+
+// Evaluate prices a basket against a set of candidate promotions. Callers are
+// responsible for passing only promotions that are active, within their
+// validity window and not exhausted.
+//
+// Promotions are applied highest priority first. Each one discounts what
+// earlier promotions left of its eligible lines, so stacked discounts can
+// never exceed the basket. A non-stackable promotion only applies to an
+// otherwise undiscounted basket and stops any further promotions.
+func Evaluate(items []domain.BasketItem, promotions []domain.Promotion) *domain.Evaluation {
+	ordered := make([]domain.Promotion, len(promotions))
+	copy(ordered, promotions)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Priority > ordered[j].Priority
+	})
+
+	lines := make([]float64, len(items))
+	var subtotal float64
+	for i, item := range items {
+		lines[i] = float64(item.Quantity) * item.UnitPrice
+		subtotal += lines[i]
+	}
+
+	evaluation := &domain.Evaluation{
+		Subtotal: round(subtotal),
+		Applied:  []domain.AppliedPromotion{},
+	}
+
+	var discount float64
+	for _, promotion := range ordered {
+		if len(evaluation.Applied) > 0 && !promotion.Stackable {
+			continue
+		}
+
+		eligible := eligibleLines(items, promotion)
+		if len(eligible) == 0 || eligibleSubtotal(items, eligible) < promotion.MinSubtotal {
+			continue
+		}
+
+		var amount float64
+		switch promotion.Type {
+		case domain.TypePercentage:
+			amount = applyPercentage(lines, eligible, promotion.Value)
+		case domain.TypeFixed:
+			amount = applyFixed(lines, eligible, promotion.Value)
+		case domain.TypeBuyXGetY:
+			amount = applyBuyXGetY(items, lines, eligible, promotion)
+		}
+
+		amount = round(amount)
+		if amount <= 0 {
+			continue
+		}
+
+		applied := domain.AppliedPromotion{
+			PromotionID: promotion.ID,
+			Name:        promotion.Name,
+			Discount:    amount,
+		}
+		if promotion.CouponCode != nil {
+			applied.CouponCode = *promotion.CouponCode
+		}
+		evaluation.Applied = append(evaluation.Applied, applied)
+		discount += amount
+
+		if !promotion.Stackable {
+			break
+		}
+	}
+
+	evaluation.Discount = round(math.Min(discount, subtotal))
+	evaluation.Total = round(subtotal - evaluation.Discount)
+	return evaluation
+}
+
+// eligibleLines returns the indexes of basket lines within the promotion's scope
+func eligibleLines(items []domain.BasketItem, promotion domain.Promotion) []int {
+	var eligible []int
+	for i, item := range items {
+		switch promotion.Scope {
+		case domain.ScopeProducts:
+			if !containsID(promotion.ProductIDs, item.ProductID) {
+				continue
+			}
+		case domain.ScopeCategories:
+			if !containsID(promotion.CategoryIDs, item.CategoryID) {
+				continue
+			}
+		}
+		eligible = append(eligible, i)
+	}
+	return eligible
+}
+
+// eligibleSubtotal is the undiscounted value of the eligible lines, which is
+// what minimum spend thresholds are measured against
+func eligibleSubtotal(items []domain.BasketItem, eligible []int) float64 {
+	var total float64
+	for _, i := range eligible {
+		total += float64(items[i].Quantity) * items[i].UnitPrice
+	}
+	return total
+}
+
+func applyPercentage(lines []float64, eligible []int, percent float64) float64 {
+	rate := math.Min(percent, 100) / 100
+	var amount float64
+	for _, i := range eligible {
+		cut := lines[i] * rate
+		lines[i] -= cut
+		amount += cut
+	}
+	return amount
+}
+
+// applyFixed takes a fixed amount off the eligible lines, spread in
+// proportion to what remains on each so no line goes negative
+func applyFixed(lines []float64, eligible []int, value float64) float64 {
+	var remaining float64
+	for _, i := range eligible {
+		remaining += lines[i]
+	}
+	if remaining <= 0 {
+		return 0
+	}
+
+	amount := math.Min(value, remaining)
+	for _, i := range eligible {
+		lines[i] -= amount * lines[i] / remaining
+	}
+	return amount
+}
+
+// applyBuyXGetY discounts the cheapest units of every group of buy+get
+// eligible units, so the customer always pays for the most expensive ones
+func applyBuyXGetY(items []domain.BasketItem, lines []float64, eligible []int, promotion domain.Promotion) float64 {
+	if promotion.BuyQuantity < 1 || promotion.GetQuantity < 1 {
+		return 0
+	}
+	groupSize := promotion.BuyQuantity + promotion.GetQuantity
+
+	type unit struct {
+		line  int
+		price float64
+	}
+	var units []unit
+	for _, i := range eligible {
+		price := lines[i] / float64(items[i].Quantity)
+		for n := 0; n < items[i].Quantity; n++ {
+			units = append(units, unit{line: i, price: price})
+		}
+	}
+
+	free := (len(units) / groupSize) * promotion.GetQuantity
+	if free == 0 {
+		return 0
+	}
+
+	sort.SliceStable(units, func(a, b int) bool {
+		return units[a].price > units[b].price
+	})
+
+	rate := 1.0
+	if promotion.Value > 0 {
+		rate = math.Min(promotion.Value, 100) / 100
+	}
+
+	var amount float64
+	for _, u := range units[len(units)-free:] {
+		cut := u.price * rate
+		lines[u.line] -= cut
+		amount += cut
+	}
+	return amount
+}
+
+func containsID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+// round rounds an amount to whole cents
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
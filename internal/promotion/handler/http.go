@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/promotion/domain"
+	"ecommerce/internal/promotion/service"
+	"ecommerce/pkg/database"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/response"
+)
+
+// HTTPHandler handles HTTP requests for promotion service
+type HTTPHandler struct {
+	service service.PromotionService
+	logger  *logrus.Logger
+}
+
+// NewHTTPHandler creates a new HTTP handler
+func NewHTTPHandler(service service.PromotionService, logger *logrus.Logger) *HTTPHandler {
+	return &HTTPHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers all HTTP routes
+func (h *HTTPHandler) RegisterRoutes(router *gin.Engine) {
+	api := router.Group("/api/v1")
+
+	// Promotion routes
+	promotions := api.Group("/promotions")
+	{
+		promotions.POST("", h.CreatePromotion)
+		promotions.GET("", h.ListPromotions)
+		promotions.POST("/evaluate", h.Evaluate)
+		promotions.POST("/redeem", h.Redeem)
+		promotions.GET("/:id", h.GetPromotion)
+		promotions.PUT("/:id", h.UpdatePromotion)
+		promotions.DELETE("/:id", h.DeletePromotion)
+	}
+
+	// Health check
+	router.GET("/health", h.HealthCheck)
+	router.GET("/ready", h.ReadinessCheck)
+}
+
+// CreatePromotion handles promotion creation
+func (h *HTTPHandler) CreatePromotion(c *gin.Context) {
+	var req domain.CreatePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	promotion, err := h.service.CreatePromotion(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Promotion created successfully", promotion)
+}
+
+// GetPromotion handles getting a single promotion
+func (h *HTTPHandler) GetPromotion(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid promotion ID", err)
+		return
+	}
+
+	promotion, err := h.service.GetPromotion(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Promotion retrieved successfully", promotion)
+}
+
+// UpdatePromotion handles promotion updates
+func (h *HTTPHandler) UpdatePromotion(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid promotion ID", err)
+		return
+	}
+
+	var req domain.UpdatePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	promotion, err := h.service.UpdatePromotion(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Promotion updated successfully", promotion)
+}
+
+// DeletePromotion handles promotion deletion
+func (h *HTTPHandler) DeletePromotion(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid promotion ID", err)
+		return
+	}
+
+	if err := h.service.DeletePromotion(c.Request.Context(), id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Promotion deleted successfully", nil)
+}
+
+// ListPromotions handles promotion listing with filters
+func (h *HTTPHandler) ListPromotions(c *gin.Context) {
+	filters := &domain.PromotionFilters{}
+
+	if isActive := c.Query("is_active"); isActive != "" {
+		if active, err := strconv.ParseBool(isActive); err == nil {
+			filters.IsActive = &active
+		}
+	}
+
+	if coupon := c.Query("coupon"); coupon != "" {
+		if isCoupon, err := strconv.ParseBool(coupon); err == nil {
+			filters.Coupon = &isCoupon
+		}
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			filters.Limit = l
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if o, err := strconv.Atoi(offset); err == nil {
+			filters.Offset = o
+		}
+	}
+
+	promotions, err := h.service.ListPromotions(c.Request.Context(), filters)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Promotions retrieved successfully", promotions)
+}
+
+// Evaluate handles pricing a basket against the current promotions
+func (h *HTTPHandler) Evaluate(c *gin.Context) {
+	var req domain.EvaluateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	evaluation, err := h.service.Evaluate(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Basket evaluated successfully", evaluation)
+}
+
+// Redeem handles recording the promotions used by an order
+func (h *HTTPHandler) Redeem(c *gin.Context) {
+	var req domain.RedeemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := h.service.Redeem(c.Request.Context(), &req); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Promotions redeemed successfully", nil)
+}
+
+// HealthCheck handles health check requests
+func (h *HTTPHandler) HealthCheck(c *gin.Context) {
+	response.Success(c, http.StatusOK, "Service is healthy", gin.H{
+		"service": "promotion-service",
+		"status":  "healthy",
+	})
+}
+
+// ReadinessCheck handles readiness check requests
+func (h *HTTPHandler) ReadinessCheck(c *gin.Context) {
+	response.Success(c, http.StatusOK, "Service is ready", gin.H{
+		"service": "promotion-service",
+		"status":  "ready",
+	})
+}
+
+// handleError handles service errors and converts them to appropriate HTTP responses
+func (h *HTTPHandler) handleError(c *gin.Context, err error) {
+	err = database.TranslateError(err)
+	switch {
+	case errors.IsNotFound(err):
+		response.Error(c, http.StatusNotFound, "Resource not found", err)
+	case errors.IsValidation(err):
+		response.Error(c, http.StatusBadRequest, "Validation failed", err)
+	case errors.IsConflict(err):
+		response.Error(c, http.StatusConflict, "Resource conflict", err)
+	case errors.IsUnauthorized(err):
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", err)
+	case errors.IsForbidden(err):
+		response.Error(c, http.StatusForbidden, "Forbidden", err)
+	case errors.IsUnavailable(err):
+		response.Error(c, http.StatusServiceUnavailable, "Service unavailable", err)
+	default:
+		h.logger.WithError(err).Error("Internal server error")
+		response.Error(c, http.StatusInternalServerError, "Internal server error", nil)
+	}
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"ecommerce/internal/promotion/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+// PromotionRepository defines the interface for promotion data operations
+type PromotionRepository interface {
+	Create(ctx context.Context, promotion *domain.Promotion) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Promotion, error)
+	GetByCode(ctx context.Context, code string) (*domain.Promotion, error)
+	Update(ctx context.Context, promotion *domain.Promotion) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	List(ctx context.Context, filters *domain.PromotionFilters) ([]domain.Promotion, int64, error)
+	ListAutomatic(ctx context.Context, now time.Time) ([]domain.Promotion, error)
+
+	Redeem(ctx context.Context, redemptions []domain.Redemption) error
+	HasRedemptions(ctx context.Context, promotionID uuid.UUID) (bool, error)
+}
+
+type promotionRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+// NewPromotionRepository creates a new promotion repository
+func NewPromotionRepository(db *gorm.DB, logger *logrus.Logger) PromotionRepository {
+	return &promotionRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *promotionRepository) Create(ctx context.Context, promotion *domain.Promotion) error {
+	if err := r.db.WithContext(ctx).Create(promotion).Error; err != nil {
+		return fmt.Errorf("failed to create promotion: %w", err)
+	}
+	return nil
+}
+
+func (r *promotionRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Promotion, error) {
+	var promotion domain.Promotion
+	err := r.db.WithContext(ctx).First(&promotion, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Promotion not found", err).WithCode(customErrors.CodePromotionNotFound)
+		}
+		return nil, fmt.Errorf("failed to get promotion: %w", err)
+	}
+
+	return &promotion, nil
+}
+
+func (r *promotionRepository) GetByCode(ctx context.Context, code string) (*domain.Promotion, error) {
+	var promotion domain.Promotion
+	err := r.db.WithContext(ctx).First(&promotion, "coupon_code = ?", code).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Coupon not found", err).WithCode(customErrors.CodeCouponNotFound)
+		}
+		return nil, fmt.Errorf("failed to get promotion by coupon code: %w", err)
+	}
+
+	return &promotion, nil
+}
+
+func (r *promotionRepository) Update(ctx context.Context, promotion *domain.Promotion) error {
+	// usage_count is owned by Redeem; never overwrite it from a stale copy
+	if err := r.db.WithContext(ctx).Omit("usage_count").Save(promotion).Error; err != nil {
+		return fmt.Errorf("failed to update promotion: %w", err)
+	}
+	return nil
+}
+
+func (r *promotionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Delete(&domain.Promotion{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete promotion: %w", err)
+	}
+	return nil
+}
+
+func (r *promotionRepository) List(ctx context.Context, filters *domain.PromotionFilters) ([]domain.Promotion, int64, error) {
+	query := r.db.WithContext(ctx).Model(&domain.Promotion{})
+
+	if filters.IsActive != nil {
+		query = query.Where("is_active = ?", *filters.IsActive)
+	}
+	if filters.Coupon != nil {
+		if *filters.Coupon {
+			query = query.Where("coupon_code IS NOT NULL")
+		} else {
+			query = query.Where("coupon_code IS NULL")
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count promotions: %w", err)
+	}
+
+	var promotions []domain.Promotion
+	err := query.
+		Order("priority DESC, created_at DESC").
+		Limit(filters.Limit).
+		Offset(filters.Offset).
+		Find(&promotions).Error
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list promotions: %w", err)
+	}
+
+	return promotions, total, nil
+}
+
+func (r *promotionRepository) ListAutomatic(ctx context.Context, now time.Time) ([]domain.Promotion, error) {
+	var promotions []domain.Promotion
+	err := r.db.WithContext(ctx).
+		Where("is_active = ? AND coupon_code IS NULL", true).
+		Where("starts_at IS NULL OR starts_at <= ?", now).
+		Where("ends_at IS NULL OR ends_at > ?", now).
+		Where("usage_limit = 0 OR usage_count < usage_limit").
+		Order("priority DESC, created_at ASC").
+		Find(&promotions).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list automatic promotions: %w", err)
+	}
+
+	return promotions, nil
+}
+
+// Redeem records the redemptions of an order and counts them against each
+// promotion's usage limit. The increment is guarded in SQL so concurrent
+// checkouts cannot push a promotion past its limit; if any promotion is
+// exhausted nothing is recorded.
+func (r *promotionRepository) Redeem(ctx context.Context, redemptions []domain.Redemption) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range redemptions {
+			result := tx.Model(&domain.Promotion{}).
+				Where("id = ? AND (usage_limit = 0 OR usage_count < usage_limit)", redemptions[i].PromotionID).
+				Update("usage_count", gorm.Expr("usage_count + 1"))
+			if result.Error != nil {
+				return fmt.Errorf("failed to count promotion usage: %w", result.Error)
+			}
+			if result.RowsAffected == 0 {
+				return customErrors.NewConflictError("Promotion usage limit reached", nil).WithCode(customErrors.CodePromotionUsageExhausted)
+			}
+		}
+
+		if err := tx.Create(&redemptions).Error; err != nil {
+			return fmt.Errorf("failed to record redemptions: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *promotionRepository) HasRedemptions(ctx context.Context, promotionID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&domain.Redemption{}).
+		Where("promotion_id = ?", promotionID).
+		Limit(1).
+		Count(&count).Error
+
+	if err != nil {
+		return false, fmt.Errorf("failed to check promotion redemptions: %w", err)
+	}
+
+	return count > 0, nil
+}
@@ -0,0 +1,428 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/promotion/domain"
+	"ecommerce/internal/promotion/engine"
+	"ecommerce/internal/promotion/repository"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/logger"
+	"ecommerce/pkg/validator"
+)
+
+// PromotionService defines the promotion service interface
+type PromotionService interface {
+	CreatePromotion(ctx context.Context, req *domain.CreatePromotionRequest) (*domain.Promotion, error)
+	GetPromotion(ctx context.Context, id uuid.UUID) (*domain.Promotion, error)
+	UpdatePromotion(ctx context.Context, id uuid.UUID, req *domain.UpdatePromotionRequest) (*domain.Promotion, error)
+	DeletePromotion(ctx context.Context, id uuid.UUID) error
+	ListPromotions(ctx context.Context, filters *domain.PromotionFilters) (*domain.PromotionList, error)
+
+	Evaluate(ctx context.Context, req *domain.EvaluateRequest) (*domain.Evaluation, error)
+	Redeem(ctx context.Context, req *domain.RedeemRequest) error
+}
+
+type promotionService struct {
+	repo      repository.PromotionRepository
+	logger    *logrus.Logger
+	validator *validator.Validator
+}
+
+// NewPromotionService creates a new promotion service
+func NewPromotionService(repo repository.PromotionRepository, logger *logrus.Logger) PromotionService {
+	return &promotionService{
+		repo:      repo,
+		logger:    logger,
+		validator: validator.New(),
+	}
+}
+
+// log returns the logger of the request ctx belongs to
+func (s *promotionService) log(ctx context.Context) *logrus.Entry {
+	return logger.FromContext(ctx, s.logger)
+}
+
+func (s *promotionService) CreatePromotion(ctx context.Context, req *domain.CreatePromotionRequest) (*domain.Promotion, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing promotions requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid create promotion request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	promotion := &domain.Promotion{
+		Name:        req.Name,
+		Description: req.Description,
+		Type:        req.Type,
+		Value:       req.Value,
+		BuyQuantity: req.BuyQuantity,
+		GetQuantity: req.GetQuantity,
+		MinSubtotal: req.MinSubtotal,
+		Scope:       req.Scope,
+		ProductIDs:  req.ProductIDs,
+		CategoryIDs: req.CategoryIDs,
+		UsageLimit:  req.UsageLimit,
+		Priority:    req.Priority,
+		Stackable:   req.Stackable,
+		IsActive:    true,
+		StartsAt:    req.StartsAt,
+		EndsAt:      req.EndsAt,
+	}
+	if promotion.Scope == "" {
+		promotion.Scope = domain.ScopeAll
+	}
+	if err := validateRules(promotion); err != nil {
+		return nil, err
+	}
+
+	// Check if coupon code already exists
+	if code := normalizeCode(req.CouponCode); code != "" {
+		existing, err := s.repo.GetByCode(ctx, code)
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, errors.NewInternalError("Failed to validate coupon code", err)
+		}
+		if existing != nil {
+			return nil, errors.NewConflictError("Coupon code already exists", nil).WithCode(errors.CodeCouponCodeConflict)
+		}
+		promotion.CouponCode = &code
+	}
+
+	if err := s.repo.Create(ctx, promotion); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to create promotion")
+		return nil, errors.NewInternalError("Failed to create promotion", err)
+	}
+
+	s.log(ctx).WithField("promotion_id", promotion.ID).Info("Promotion created successfully")
+	return promotion, nil
+}
+
+func (s *promotionService) GetPromotion(ctx context.Context, id uuid.UUID) (*domain.Promotion, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing promotions requires the admin role", nil)
+	}
+
+	promotion, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Promotion not found", err).WithCode(errors.CodePromotionNotFound)
+		}
+		s.log(ctx).WithError(err).Error("Failed to get promotion")
+		return nil, errors.NewInternalError("Failed to get promotion", err)
+	}
+
+	return promotion, nil
+}
+
+func (s *promotionService) UpdatePromotion(ctx context.Context, id uuid.UUID, req *domain.UpdatePromotionRequest) (*domain.Promotion, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing promotions requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid update promotion request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	// Get existing promotion
+	promotion, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Promotion not found", err).WithCode(errors.CodePromotionNotFound)
+		}
+		return nil, errors.NewInternalError("Failed to get promotion", err)
+	}
+
+	// Update fields
+	if req.Name != nil {
+		promotion.Name = *req.Name
+	}
+	if req.Description != nil {
+		promotion.Description = *req.Description
+	}
+	if req.Value != nil {
+		promotion.Value = *req.Value
+	}
+	if req.BuyQuantity != nil {
+		promotion.BuyQuantity = *req.BuyQuantity
+	}
+	if req.GetQuantity != nil {
+		promotion.GetQuantity = *req.GetQuantity
+	}
+	if req.MinSubtotal != nil {
+		promotion.MinSubtotal = *req.MinSubtotal
+	}
+	if req.Scope != nil {
+		promotion.Scope = *req.Scope
+	}
+	if req.ProductIDs != nil {
+		promotion.ProductIDs = req.ProductIDs
+	}
+	if req.CategoryIDs != nil {
+		promotion.CategoryIDs = req.CategoryIDs
+	}
+	if req.UsageLimit != nil {
+		promotion.UsageLimit = *req.UsageLimit
+	}
+	if req.Priority != nil {
+		promotion.Priority = *req.Priority
+	}
+	if req.Stackable != nil {
+		promotion.Stackable = *req.Stackable
+	}
+	if req.IsActive != nil {
+		promotion.IsActive = *req.IsActive
+	}
+	if req.StartsAt != nil {
+		promotion.StartsAt = req.StartsAt
+	}
+	if req.EndsAt != nil {
+		promotion.EndsAt = req.EndsAt
+	}
+
+	if err := validateRules(promotion); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(ctx, promotion); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to update promotion")
+		return nil, errors.NewInternalError("Failed to update promotion", err)
+	}
+
+	s.log(ctx).WithField("promotion_id", promotion.ID).Info("Promotion updated successfully")
+	return promotion, nil
+}
+
+func (s *promotionService) DeletePromotion(ctx context.Context, id uuid.UUID) error {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return errors.NewForbiddenError("Managing promotions requires the admin role", nil)
+	}
+
+	// Check if promotion exists
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		if errors.IsNotFound(err) {
+			return errors.NewNotFoundError("Promotion not found", err).WithCode(errors.CodePromotionNotFound)
+		}
+		return errors.NewInternalError("Failed to get promotion", err)
+	}
+
+	// Redeemed promotions are kept so order discounts stay explainable
+	redeemed, err := s.repo.HasRedemptions(ctx, id)
+	if err != nil {
+		return errors.NewInternalError("Failed to check promotion redemptions", err)
+	}
+	if redeemed {
+		return errors.NewConflictError("Promotion has been redeemed; deactivate it instead", nil).WithCode(errors.CodePromotionRedeemed)
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to delete promotion")
+		return errors.NewInternalError("Failed to delete promotion", err)
+	}
+
+	s.log(ctx).WithField("promotion_id", id).Info("Promotion deleted successfully")
+	return nil
+}
+
+func (s *promotionService) ListPromotions(ctx context.Context, filters *domain.PromotionFilters) (*domain.PromotionList, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing promotions requires the admin role", nil)
+	}
+
+	// Set default values
+	if filters.Limit <= 0 {
+		filters.Limit = 20
+	}
+	if filters.Limit > 100 {
+		filters.Limit = 100
+	}
+
+	promotions, total, err := s.repo.List(ctx, filters)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list promotions")
+		return nil, errors.NewInternalError("Failed to list promotions", err)
+	}
+
+	return &domain.PromotionList{
+		Promotions: promotions,
+		Total:      total,
+		Limit:      filters.Limit,
+		Offset:     filters.Offset,
+		HasMore:    int64(filters.Offset+filters.Limit) < total,
+	}, nil
+}
+
+func (s *promotionService) Evaluate(ctx context.Context, req *domain.EvaluateRequest) (*domain.Evaluation, error) {
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid evaluate request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	now := time.Now()
+	candidates, err := s.repo.ListAutomatic(ctx, now)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list automatic promotions")
+		return nil, errors.NewInternalError("Failed to evaluate basket", err)
+	}
+
+	var rejected []domain.RejectedCoupon
+	var coupons []domain.Promotion
+	seen := make(map[string]bool)
+	for _, raw := range req.CouponCodes {
+		code := normalizeCode(raw)
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+
+		promotion, reason, err := s.resolveCoupon(ctx, code, now)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			rejected = append(rejected, domain.RejectedCoupon{Code: code, Reason: reason})
+			continue
+		}
+		coupons = append(coupons, *promotion)
+	}
+
+	evaluation := engine.Evaluate(req.Items, append(candidates, coupons...))
+
+	// Explain valid coupons the engine left out, so the basket can tell the
+	// customer why their code did nothing
+	for _, coupon := range coupons {
+		if !isApplied(evaluation.Applied, coupon.ID) {
+			rejected = append(rejected, domain.RejectedCoupon{Code: *coupon.CouponCode, Reason: "coupon does not apply to this basket"})
+		}
+	}
+	evaluation.Rejected = rejected
+
+	return evaluation, nil
+}
+
+// Redeem records the promotions an order used. Only checkout, whose calls
+// carry no actor, and admins may record them.
+func (s *promotionService) Redeem(ctx context.Context, req *domain.RedeemRequest) error {
+	if actor := auth.ActorFromContext(ctx); actor != nil && actor.Role != auth.RoleAdmin {
+		return errors.NewForbiddenError("Redeeming promotions requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid redeem request")
+		return errors.NewValidationError("Invalid request", err)
+	}
+
+	redemptions := make([]domain.Redemption, 0, len(req.Applied))
+	for _, applied := range req.Applied {
+		if _, err := s.repo.GetByID(ctx, applied.PromotionID); err != nil {
+			if errors.IsNotFound(err) {
+				return errors.NewNotFoundError("Promotion not found", err).WithCode(errors.CodePromotionNotFound)
+			}
+			return errors.NewInternalError("Failed to get promotion", err)
+		}
+		redemptions = append(redemptions, domain.Redemption{
+			PromotionID: applied.PromotionID,
+			OrderID:     req.OrderID,
+			CustomerID:  req.CustomerID,
+			Discount:    applied.Discount,
+		})
+	}
+
+	if err := s.repo.Redeem(ctx, redemptions); err != nil {
+		if errors.IsConflict(err) {
+			return err
+		}
+		s.log(ctx).WithError(err).Error("Failed to redeem promotions")
+		return errors.NewInternalError("Failed to redeem promotions", err)
+	}
+
+	s.log(ctx).WithField("order_id", req.OrderID).Info("Promotions redeemed successfully")
+	return nil
+}
+
+// resolveCoupon looks up a coupon code and returns the promotion when it can
+// be used now, or the reason it was rejected
+func (s *promotionService) resolveCoupon(ctx context.Context, code string, now time.Time) (*domain.Promotion, string, error) {
+	promotion, err := s.repo.GetByCode(ctx, code)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, "unknown coupon code", nil
+		}
+		s.log(ctx).WithError(err).Error("Failed to get coupon")
+		return nil, "", errors.NewInternalError("Failed to evaluate basket", err)
+	}
+
+	switch {
+	case !promotion.IsActive:
+		return nil, "coupon is no longer active", nil
+	case !promotion.InWindow(now):
+		return nil, "coupon is not valid at this time", nil
+	case promotion.Exhausted():
+		return nil, "coupon usage limit reached", nil
+	}
+	return promotion, "", nil
+}
+
+// validateRules checks the fields that depend on the promotion type and scope
+func validateRules(promotion *domain.Promotion) error {
+	switch promotion.Type {
+	case domain.TypePercentage:
+		if promotion.Value <= 0 || promotion.Value > 100 {
+			return errors.NewValidationError("Percentage promotions need a value between 0 and 100", nil)
+		}
+	case domain.TypeFixed:
+		if promotion.Value <= 0 {
+			return errors.NewValidationError("Fixed promotions need a positive value", nil)
+		}
+	case domain.TypeBuyXGetY:
+		if promotion.BuyQuantity < 1 || promotion.GetQuantity < 1 {
+			return errors.NewValidationError("Buy X get Y promotions need buy and get quantities of at least 1", nil)
+		}
+		if promotion.Value > 100 {
+			return errors.NewValidationError("Buy X get Y discount cannot exceed 100 percent", nil)
+		}
+	}
+
+	switch promotion.Scope {
+	case domain.ScopeProducts:
+		if len(promotion.ProductIDs) == 0 {
+			return errors.NewValidationError("Product-scoped promotions need at least one product", nil)
+		}
+	case domain.ScopeCategories:
+		if len(promotion.CategoryIDs) == 0 {
+			return errors.NewValidationError("Category-scoped promotions need at least one category", nil)
+		}
+	}
+
+	if promotion.StartsAt != nil && promotion.EndsAt != nil && !promotion.EndsAt.After(*promotion.StartsAt) {
+		return errors.NewValidationError("Promotion must end after it starts", nil)
+	}
+
+	return nil
+}
+
+// normalizeCode makes coupon codes case-insensitive
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func isApplied(applied []domain.AppliedPromotion, id uuid.UUID) bool {
+	for _, promotion := range applied {
+		if promotion.PromotionID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package service_test
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/promotion/domain"
+	"ecommerce/internal/promotion/service"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+)
+
+// TestRedeemRequiresAdmin checks that signed-in callers who are not admins
+// cannot record redemptions, which would use up a promotion's allowance
+func TestRedeemRequiresAdmin(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	// The caller is refused before the repository is used
+	s := service.NewPromotionService(nil, logger)
+
+	ctx := auth.WithActor(context.Background(), &auth.Actor{ID: "customer-1", Role: "customer"})
+	err := s.Redeem(ctx, &domain.RedeemRequest{OrderID: "order-1", CustomerID: "customer-1"})
+	if !errors.IsForbidden(err) {
+		t.Fatalf("expected forbidden, got %v", err)
+	}
+}
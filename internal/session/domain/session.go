@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// Session is a device a user is signed in on. Sessions are those of the
+// identity provider, whose refresh tokens keep them going and whose access
+// tokens name them in the sid claim. The gateway only keeps a hash of that
+// claim, which is the session's ID here.
+type Session struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	Device     string     `json:"device,omitempty"` // browser and platform, as far as the user agent tells
+	UserAgent  string     `json:"user_agent,omitempty"`
+	IP         string     `json:"ip,omitempty"` // of the last request
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"` // unless it is used again before then
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	Current    bool       `json:"current"` // made the request listing it
+
+	// TwoFactorAt is when the user passed a second factor in the session
+	TwoFactorAt *time.Time `json:"two_factor_at,omitempty"`
+}
+
+// IsActive reports whether the session may be used at the given time
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// devices are checked in order, since user agents name the engines they
+// are compatible with as well as their own
+var (
+	browsers = []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+	}
+	platforms = []struct{ token, name string }{
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	}
+)
+
+// DeviceName describes the device a user agent belongs to, such as
+// "Firefox on Windows", or returns "" when it cannot tell
+func DeviceName(userAgent string) string {
+	var browser, platform string
+	for _, b := range browsers {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	for _, p := range platforms {
+		if strings.Contains(userAgent, p.token) {
+			platform = p.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	}
+	return platform
+}
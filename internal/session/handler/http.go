@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/session/service"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/response"
+)
+
+// HTTPHandler handles HTTP requests for the caller's sessions
+type HTTPHandler struct {
+	service service.SessionService
+	logger  *logrus.Logger
+}
+
+// NewHTTPHandler creates a new HTTP handler
+func NewHTTPHandler(service service.SessionService, logger *logrus.Logger) *HTTPHandler {
+	return &HTTPHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers the session routes under api. The caller must
+// put the routes behind middleware that authenticates the actor.
+func (h *HTTPHandler) RegisterRoutes(api *gin.RouterGroup) {
+	sessions := api.Group("/users/me/sessions")
+	{
+		sessions.GET("", h.ListSessions)
+		sessions.DELETE("", h.RevokeAllSessions)
+		sessions.DELETE("/:id", h.RevokeSession)
+	}
+}
+
+// ListSessions handles listing the devices the caller is signed in on
+func (h *HTTPHandler) ListSessions(c *gin.Context) {
+	sessions, err := h.service.ListSessions(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Sessions retrieved successfully", sessions)
+}
+
+// RevokeSession handles signing the caller out of one device
+func (h *HTTPHandler) RevokeSession(c *gin.Context) {
+	if err := h.service.RevokeSession(c.Request.Context(), c.Param("id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Session revoked successfully", nil)
+}
+
+// RevokeAllSessions handles signing the caller out everywhere
+func (h *HTTPHandler) RevokeAllSessions(c *gin.Context) {
+	count, err := h.service.RevokeAllSessions(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Sessions revoked successfully", gin.H{"revoked": count})
+}
+
+// handleError handles service errors and converts them to appropriate HTTP responses
+func (h *HTTPHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.IsNotFound(err):
+		response.Error(c, http.StatusNotFound, "Resource not found", err)
+	case errors.IsValidation(err):
+		response.Error(c, http.StatusBadRequest, "Validation failed", err)
+	case errors.IsUnauthorized(err):
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", err)
+	case errors.IsForbidden(err):
+		response.Error(c, http.StatusForbidden, "Forbidden", err)
+	case errors.IsUnavailable(err):
+		response.Error(c, http.StatusServiceUnavailable, "Service unavailable", err)
+	default:
+		h.logger.WithError(err).Error("Internal server error")
+		response.Error(c, http.StatusInternalServerError, "Internal server error", nil)
+	}
+}
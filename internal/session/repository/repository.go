@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"ecommerce/internal/session/domain"
+)
+
+// keyPrefix namespaces sessions in Redis. Each session is a hash under
+// session:<id>, each user's session IDs a set under session:user:<id>, and
+// the time a user last signed out everywhere a string under
+// session:user:<id>:ended.
+const keyPrefix = "session:"
+
+// SessionRepository defines the interface for session data operations
+type SessionRepository interface {
+	// Lookup returns a session, or nil when it is unknown, together with
+	// the time its user last signed out everywhere, which is zero if never
+	Lookup(ctx context.Context, id, userID string) (*domain.Session, time.Time, error)
+	Create(ctx context.Context, session *domain.Session, ttl time.Duration) error
+	Touch(ctx context.Context, session *domain.Session, ttl time.Duration) error
+	Revoke(ctx context.Context, session *domain.Session, ttl time.Duration) error
+	ListByUser(ctx context.Context, userID string) ([]domain.Session, error)
+	EndAll(ctx context.Context, userID string, at time.Time, ttl time.Duration) error
+	MarkTwoFactor(ctx context.Context, id string, at time.Time) (bool, error)
+}
+
+type sessionRepository struct {
+	client redis.UniversalClient
+}
+
+// NewSessionRepository creates a new session repository
+func NewSessionRepository(client redis.UniversalClient) SessionRepository {
+	return &sessionRepository{client: client}
+}
+
+func sessionKey(id string) string {
+	return keyPrefix + id
+}
+
+func userKey(userID string) string {
+	return keyPrefix + "user:" + userID
+}
+
+func endedKey(userID string) string {
+	return userKey(userID) + ":ended"
+}
+
+func (r *sessionRepository) Lookup(ctx context.Context, id, userID string) (*domain.Session, time.Time, error) {
+	pipe := r.client.Pipeline()
+	fields := pipe.HGetAll(ctx, sessionKey(id))
+	ended := pipe.Get(ctx, endedKey(userID))
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, time.Time{}, fmt.Errorf("failed to look up session: %w", err)
+	}
+
+	var endedAt time.Time
+	if value, err := ended.Result(); err == nil {
+		endedAt, _ = time.Parse(time.RFC3339Nano, value)
+	}
+
+	values := fields.Val()
+	if len(values) == 0 {
+		return nil, endedAt, nil
+	}
+	return decode(id, values), endedAt, nil
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *domain.Session, ttl time.Duration) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, sessionKey(session.ID),
+			"user_id", session.UserID,
+			"device", session.Device,
+			"user_agent", session.UserAgent,
+			"ip", session.IP,
+			"created_at", session.CreatedAt.Format(time.RFC3339Nano),
+			"last_seen_at", session.LastSeenAt.Format(time.RFC3339Nano),
+			"expires_at", session.ExpiresAt.Format(time.RFC3339Nano),
+		)
+		pipe.Expire(ctx, sessionKey(session.ID), ttl)
+		pipe.SAdd(ctx, userKey(session.UserID), session.ID)
+		pipe.Expire(ctx, userKey(session.UserID), ttl)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return nil
+}
+
+// Touch records a session's latest use. Only the fields that change with
+// use are written, so a revocation made in between is kept.
+func (r *sessionRepository) Touch(ctx context.Context, session *domain.Session, ttl time.Duration) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, sessionKey(session.ID),
+			"device", session.Device,
+			"user_agent", session.UserAgent,
+			"ip", session.IP,
+			"last_seen_at", session.LastSeenAt.Format(time.RFC3339Nano),
+			"expires_at", session.ExpiresAt.Format(time.RFC3339Nano),
+		)
+		pipe.Expire(ctx, sessionKey(session.ID), ttl)
+		pipe.SAdd(ctx, userKey(session.UserID), session.ID)
+		pipe.Expire(ctx, userKey(session.UserID), ttl)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to touch session: %w", err)
+	}
+	return nil
+}
+
+// Revoke ends a session, which is remembered for ttl so the tokens issued
+// in it keep being refused
+func (r *sessionRepository) Revoke(ctx context.Context, session *domain.Session, ttl time.Duration) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, sessionKey(session.ID), "revoked_at", session.RevokedAt.Format(time.RFC3339Nano))
+		pipe.Expire(ctx, sessionKey(session.ID), ttl)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+// ListByUser returns a user's sessions, forgetting the IDs of those that
+// have expired from Redis
+func (r *sessionRepository) ListByUser(ctx context.Context, userID string) ([]domain.Session, error) {
+	ids, err := r.client.SMembers(ctx, userKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	pipe := r.client.Pipeline()
+	results := make([]*redis.MapStringStringCmd, len(ids))
+	for i, id := range ids {
+		results[i] = pipe.HGetAll(ctx, sessionKey(id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+
+	sessions := make([]domain.Session, 0, len(ids))
+	var gone []interface{}
+	for i, result := range results {
+		values := result.Val()
+		if len(values) == 0 {
+			gone = append(gone, ids[i])
+			continue
+		}
+		sessions = append(sessions, *decode(ids[i], values))
+	}
+	if len(gone) > 0 {
+		if err := r.client.SRem(ctx, userKey(userID), gone...).Err(); err != nil {
+			return nil, fmt.Errorf("failed to forget expired sessions: %w", err)
+		}
+	}
+
+	return sessions, nil
+}
+
+// EndAll records that a user signed out everywhere at the given time
+func (r *sessionRepository) EndAll(ctx context.Context, userID string, at time.Time, ttl time.Duration) error {
+	if err := r.client.Set(ctx, endedKey(userID), at.Format(time.RFC3339Nano), ttl).Err(); err != nil {
+		return fmt.Errorf("failed to end sessions: %w", err)
+	}
+	return nil
+}
+
+// markTwoFactor sets a field of a session's hash only while the session
+// exists, so an expired one is not brought back without its TTL
+var markTwoFactor = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+  return 0
+end
+redis.call('HSET', KEYS[1], 'two_factor_at', ARGV[1])
+return 1
+`)
+
+// MarkTwoFactor records that the user passed a second factor in a session,
+// reporting false when the session no longer exists
+func (r *sessionRepository) MarkTwoFactor(ctx context.Context, id string, at time.Time) (bool, error) {
+	marked, err := markTwoFactor.Run(ctx, r.client, []string{sessionKey(id)}, at.Format(time.RFC3339Nano)).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to mark session: %w", err)
+	}
+	return marked == 1, nil
+}
+
+// decode reads a session from its hash
+func decode(id string, values map[string]string) *domain.Session {
+	session := &domain.Session{
+		ID:        id,
+		UserID:    values["user_id"],
+		Device:    values["device"],
+		UserAgent: values["user_agent"],
+		IP:        values["ip"],
+	}
+	session.CreatedAt, _ = time.Parse(time.RFC3339Nano, values["created_at"])
+	session.LastSeenAt, _ = time.Parse(time.RFC3339Nano, values["last_seen_at"])
+	session.ExpiresAt, _ = time.Parse(time.RFC3339Nano, values["expires_at"])
+	if revoked, err := time.Parse(time.RFC3339Nano, values["revoked_at"]); err == nil {
+		session.RevokedAt = &revoked
+	}
+	if verified, err := time.Parse(time.RFC3339Nano, values["two_factor_at"]); err == nil {
+		session.TwoFactorAt = &verified
+	}
+	return session
+}
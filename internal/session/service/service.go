@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/gateway/config"
+	"ecommerce/internal/session/domain"
+	"ecommerce/internal/session/repository"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/logger"
+)
+
+// SessionService defines the session service interface
+type SessionService interface {
+	Track(ctx context.Context, claims *auth.Claims, userAgent, ip string) (*domain.Session, error)
+	MarkTwoFactor(ctx context.Context) error
+
+	ListSessions(ctx context.Context) ([]domain.Session, error)
+	RevokeSession(ctx context.Context, id string) error
+	RevokeAllSessions(ctx context.Context) (int, error)
+}
+
+type sessionService struct {
+	repo      repository.SessionRepository
+	idle      time.Duration // a session unused for this long ends
+	retention time.Duration // an ended session is remembered for this long
+	touch     time.Duration // between recordings of a session's use
+	logger    *logrus.Logger
+}
+
+// NewSessionService creates a new session service
+func NewSessionService(repo repository.SessionRepository, cfg config.SessionsConfig, logger *logrus.Logger) SessionService {
+	return &sessionService{
+		repo:      repo,
+		idle:      time.Duration(cfg.IdleTimeout) * time.Second,
+		retention: time.Duration(cfg.Retention) * time.Second,
+		touch:     time.Duration(cfg.TouchInterval) * time.Second,
+		logger:    logger,
+	}
+}
+
+type sessionKey struct{}
+
+// WithSessionID returns a copy of ctx carrying the ID of the session the
+// request is made in
+func WithSessionID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, id)
+}
+
+// SessionIDFromContext returns the session ID in ctx, or "" when absent
+func SessionIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(sessionKey{}).(string)
+	return id
+}
+
+// log returns the logger of the request ctx belongs to
+func (s *sessionService) log(ctx context.Context) *logrus.Entry {
+	return logger.FromContext(ctx, s.logger)
+}
+
+// Track checks that the session a token was issued in has not ended and
+// records its use, returning the session. The first token of a session
+// starts it. Tokens that name no session are only refused when they were
+// issued before their user signed out everywhere, which tokens without an
+// issue time are taken to be; nil is returned for them.
+func (s *sessionService) Track(ctx context.Context, claims *auth.Claims, userAgent, ip string) (*domain.Session, error) {
+	var id string
+	if claims.SessionID != "" {
+		id = hashSessionID(claims.SessionID)
+	}
+
+	session, endedAt, err := s.repo.Lookup(ctx, id, claims.Subject)
+	if err != nil {
+		return nil, errors.NewUnavailableError("Failed to look up session", err)
+	}
+	if !endedAt.IsZero() && (claims.IssuedAt == 0 || !time.Unix(claims.IssuedAt, 0).After(endedAt)) {
+		return nil, sessionEnded()
+	}
+	if id == "" {
+		return nil, nil
+	}
+
+	now := time.Now()
+	if session == nil {
+		session = &domain.Session{
+			ID:         id,
+			UserID:     claims.Subject,
+			Device:     domain.DeviceName(userAgent),
+			UserAgent:  userAgent,
+			IP:         ip,
+			CreatedAt:  now,
+			LastSeenAt: now,
+			ExpiresAt:  now.Add(s.idle),
+		}
+		if err := s.repo.Create(ctx, session, s.idle+s.retention); err != nil {
+			return nil, errors.NewUnavailableError("Failed to start session", err)
+		}
+		return session, nil
+	}
+
+	if session.UserID != claims.Subject {
+		return nil, sessionEnded()
+	}
+	if !session.IsActive(now) {
+		if session.RevokedAt == nil {
+			// Idle too long; end it for good, so tokens refreshed from
+			// it later are refused too
+			session.RevokedAt = &session.ExpiresAt
+			if err := s.repo.Revoke(ctx, session, s.retention); err != nil {
+				return nil, errors.NewUnavailableError("Failed to end session", err)
+			}
+		}
+		return nil, sessionEnded()
+	}
+
+	// Record the use, sliding the expiry along, at most once per touch
+	// interval
+	if now.Sub(session.LastSeenAt) >= s.touch || session.IP != ip || session.UserAgent != userAgent {
+		session.Device = domain.DeviceName(userAgent)
+		session.UserAgent = userAgent
+		session.IP = ip
+		session.LastSeenAt = now
+		session.ExpiresAt = now.Add(s.idle)
+		if err := s.repo.Touch(ctx, session, s.idle+s.retention); err != nil {
+			return nil, errors.NewUnavailableError("Failed to record session use", err)
+		}
+	}
+	return session, nil
+}
+
+// ListSessions lists the caller's active sessions, most recently used
+// first
+func (s *sessionService) ListSessions(ctx context.Context) ([]domain.Session, error) {
+	actor := auth.ActorFromContext(ctx)
+	if actor == nil {
+		return nil, errors.NewUnauthorizedError("Authentication required to list sessions", nil).WithCode(errors.CodeAuthenticationRequired)
+	}
+
+	all, err := s.repo.ListByUser(ctx, actor.ID)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list sessions")
+		return nil, errors.NewInternalError("Failed to list sessions", err)
+	}
+
+	now := time.Now()
+	current := SessionIDFromContext(ctx)
+	sessions := make([]domain.Session, 0, len(all))
+	for _, session := range all {
+		if !session.IsActive(now) || session.UserID != actor.ID {
+			continue
+		}
+		session.Current = session.ID == current
+		sessions = append(sessions, session)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+
+	return sessions, nil
+}
+
+// RevokeSession signs the caller out of one of their sessions. The tokens
+// issued in it are refused from then on.
+func (s *sessionService) RevokeSession(ctx context.Context, id string) error {
+	sessions, err := s.ListSessions(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range sessions {
+		if sessions[i].ID != id {
+			continue
+		}
+
+		now := time.Now()
+		sessions[i].RevokedAt = &now
+		if err := s.repo.Revoke(ctx, &sessions[i], s.retention); err != nil {
+			s.log(ctx).WithError(err).Error("Failed to revoke session")
+			return errors.NewInternalError("Failed to revoke session", err)
+		}
+
+		s.log(ctx).WithField("session_id", id).Info("Session revoked successfully")
+		return nil
+	}
+
+	return errors.NewNotFoundError("Session not found", nil).WithCode(errors.CodeSessionNotFound)
+}
+
+// RevokeAllSessions signs the caller out everywhere, the current session
+// included, and reports how many sessions were ended. Tokens issued until
+// now are refused, whether or not they name a session.
+func (s *sessionService) RevokeAllSessions(ctx context.Context) (int, error) {
+	sessions, err := s.ListSessions(ctx)
+	if err != nil {
+		return 0, err
+	}
+	actorID := auth.ActorID(ctx)
+
+	now := time.Now()
+	if err := s.repo.EndAll(ctx, actorID, now, s.idle+s.retention); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to end sessions")
+		return 0, errors.NewInternalError("Failed to end sessions", err)
+	}
+	for i := range sessions {
+		sessions[i].RevokedAt = &now
+		if err := s.repo.Revoke(ctx, &sessions[i], s.retention); err != nil {
+			s.log(ctx).WithError(err).Error("Failed to revoke session")
+			return 0, errors.NewInternalError("Failed to revoke sessions", err)
+		}
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"user_id": actorID,
+		"count":   len(sessions),
+	}).Info("Sessions revoked successfully")
+	return len(sessions), nil
+}
+
+// MarkTwoFactor records that the caller passed a second factor in the
+// session the request is made in
+func (s *sessionService) MarkTwoFactor(ctx context.Context) error {
+	id := SessionIDFromContext(ctx)
+	if id == "" {
+		return errors.NewValidationError("Your token does not name a session to verify", nil)
+	}
+
+	marked, err := s.repo.MarkTwoFactor(ctx, id, time.Now())
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to mark session")
+		return errors.NewInternalError("Failed to verify session", err)
+	}
+	if !marked {
+		return sessionEnded()
+	}
+	return nil
+}
+
+// hashSessionID is the form a session ID is stored and looked up in
+func hashSessionID(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
+
+func sessionEnded() error {
+	return errors.NewUnauthorizedError("Session has ended", nil).WithCode(errors.CodeSessionEnded)
+}
@@ -0,0 +1,70 @@
+package service_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/gateway/config"
+	"ecommerce/internal/session/domain"
+	"ecommerce/internal/session/repository"
+	"ecommerce/internal/session/service"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+)
+
+// signedOut is a repository whose users all signed out everywhere at ended
+// and which knows no sessions
+type signedOut struct {
+	repository.SessionRepository
+	ended time.Time
+}
+
+func (r *signedOut) Lookup(ctx context.Context, id, userID string) (*domain.Session, time.Time, error) {
+	return nil, r.ended, nil
+}
+
+func (r *signedOut) Create(ctx context.Context, session *domain.Session, ttl time.Duration) error {
+	return nil
+}
+
+// TestTrackAfterSigningOutEverywhere checks that tokens issued before their
+// user signed out everywhere, or that carry no issue time, are refused
+func TestTrackAfterSigningOutEverywhere(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	ended := time.Now().Add(-time.Minute)
+	s := service.NewSessionService(&signedOut{ended: ended}, config.SessionsConfig{IdleTimeout: 3600, Retention: 3600}, logger)
+
+	tests := []struct {
+		name    string
+		claims  auth.Claims
+		refused bool
+	}{
+		{name: "issued before", claims: auth.Claims{IssuedAt: ended.Add(-time.Minute).Unix()}, refused: true},
+		{name: "issued at", claims: auth.Claims{IssuedAt: ended.Unix()}, refused: true},
+		{name: "no issue time", claims: auth.Claims{}, refused: true},
+		{name: "no issue time in a session", claims: auth.Claims{SessionID: "sid-1"}, refused: true},
+		{name: "issued after", claims: auth.Claims{IssuedAt: time.Now().Unix()}},
+		{name: "issued after in a session", claims: auth.Claims{IssuedAt: time.Now().Unix(), SessionID: "sid-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := tt.claims
+			claims.Subject = "user-1"
+			_, err := s.Track(context.Background(), &claims, "test", "127.0.0.1")
+			if tt.refused {
+				if errors.Code(err) != errors.CodeSessionEnded {
+					t.Fatalf("expected the token to be refused, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Track: %v", err)
+			}
+		})
+	}
+}
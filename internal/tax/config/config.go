@@ -0,0 +1,122 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	productconfig "ecommerce/internal/product/config"
+)
+
+// Config holds all configuration for the tax service
+type Config struct {
+	Env      string
+	HTTP     productconfig.HTTPConfig
+	Database productconfig.DatabaseConfig
+	Auth     productconfig.AuthConfig
+	Debug    productconfig.DebugConfig
+	MTLS     productconfig.MTLSConfig
+	Logger   productconfig.LoggerConfig
+	Provider string
+	TaxJar   TaxJarConfig
+}
+
+// TaxJarConfig holds TaxJar API configuration
+type TaxJarConfig struct {
+	APIKey  string
+	APIURL  string
+	Timeout int // seconds
+
+	// ClassCodes maps tax classes to TaxJar product tax codes; classes
+	// without one are taxed at the general rate
+	ClassCodes map[string]string
+}
+
+// Load loads configuration from environment variables. The HTTP, database
+// and auth settings use the same variables as the product service.
+func Load() (*Config, error) {
+	shared, err := productconfig.Load()
+	if err != nil {
+		return nil, err
+	}
+	classCodes, err := getEnvAsMap("TAXJAR_CLASS_CODES", "exempt=99999")
+	if err != nil {
+		return nil, err
+	}
+	return &Config{
+		Env:      shared.Env,
+		HTTP:     shared.HTTP,
+		Database: shared.Database,
+		Auth:     shared.Auth,
+		Debug:    shared.Debug,
+		MTLS:     shared.MTLS,
+		Logger:   shared.Logger,
+		Provider: getEnv("TAX_PROVIDER", "rates"),
+		TaxJar: TaxJarConfig{
+			APIKey:     getEnv("TAXJAR_API_KEY", ""),
+			APIURL:     getEnv("TAXJAR_API_URL", "https://api.taxjar.com"),
+			Timeout:    getEnvAsInt("TAXJAR_TIMEOUT", 10),
+			ClassCodes: classCodes,
+		},
+	}, nil
+}
+
+// Validate reports every setting that is missing or invalid, so the service
+// refuses to start rather than run misconfigured
+func (c *Config) Validate() error {
+	errs := []error{
+		productconfig.ValidateEnv(c.Env),
+		c.HTTP.Validate(),
+		c.Database.Validate(c.Env),
+		c.Logger.Validate(),
+		c.MTLS.Validate(),
+		c.Auth.Validate(),
+	}
+	switch c.Provider {
+	case "taxjar":
+		if c.TaxJar.APIKey == "" {
+			errs = append(errs, errors.New("TAXJAR_API_KEY is required for the taxjar provider"))
+		}
+	case "rates":
+	default:
+		errs = append(errs, fmt.Errorf("TAX_PROVIDER must be rates or taxjar, not %q", c.Provider))
+	}
+	return errors.Join(errs...)
+}
+
+// getEnv gets an environment variable with a default value
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvAsInt gets an environment variable as integer with a default value
+func getEnvAsInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsMap gets an environment variable of comma separated key=value
+// pairs with a default value
+func getEnvAsMap(key, defaultValue string) (map[string]string, error) {
+	pairs := make(map[string]string)
+	for _, pair := range strings.Split(getEnv(key, defaultValue), ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || name == "" || value == "" {
+			return nil, fmt.Errorf("%s: %q is not a key=value pair", key, pair)
+		}
+		pairs[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return pairs, nil
+}
@@ -0,0 +1,148 @@
+package domain
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tax classes. Products are charged the rate of their class where they are
+// delivered; exempt products are never taxed.
+const (
+	ClassStandard = "standard"
+	ClassReduced  = "reduced"
+	ClassZero     = "zero"
+	ClassExempt   = "exempt"
+)
+
+// TaxRate is the rate charged on a tax class in a country, or in one region
+// of it. A region's rate takes precedence over its country's.
+type TaxRate struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Country   string    `json:"country" gorm:"not null"` // ISO 3166-1 alpha-2
+	Region    string    `json:"region" gorm:"not null"`  // state or province code; empty for the whole country
+	TaxClass  string    `json:"tax_class" gorm:"not null"`
+	Rate      float64   `json:"rate" gorm:"not null"` // percent
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreateTaxRateRequest represents the request to create a tax rate
+type CreateTaxRateRequest struct {
+	Country  string  `json:"country" validate:"required,iso3166_1_alpha2"`
+	Region   string  `json:"region" validate:"max=10"`
+	TaxClass string  `json:"tax_class" validate:"required,oneof=standard reduced zero"`
+	Rate     float64 `json:"rate" validate:"gte=0,lte=100"`
+	Name     string  `json:"name" validate:"max=100"`
+}
+
+// UpdateTaxRateRequest represents the request to update a tax rate
+type UpdateTaxRateRequest struct {
+	Rate *float64 `json:"rate,omitempty" validate:"omitempty,gte=0,lte=100"`
+	Name *string  `json:"name,omitempty" validate:"omitempty,max=100"`
+}
+
+// TaxRateFilters represents filters for tax rate queries
+type TaxRateFilters struct {
+	Country  string `json:"country,omitempty"`
+	TaxClass string `json:"tax_class,omitempty"`
+	Limit    int    `json:"limit,omitempty"`
+	Offset   int    `json:"offset,omitempty"`
+}
+
+// TaxRateList represents a paginated list of tax rates
+type TaxRateList struct {
+	Rates   []TaxRate `json:"rates"`
+	Total   int64     `json:"total"`
+	Limit   int       `json:"limit"`
+	Offset  int       `json:"offset"`
+	HasMore bool      `json:"has_more"`
+}
+
+// Address is where an order is taxed: where it is delivered, or for orders
+// that ship nothing, the customer's billing address
+type Address struct {
+	Country    string `json:"country" validate:"required,iso3166_1_alpha2"`
+	Region     string `json:"region,omitempty" validate:"max=10"`
+	PostalCode string `json:"postal_code,omitempty" validate:"max=20"`
+	City       string `json:"city,omitempty" validate:"max=100"`
+}
+
+// CalculationItem is a line of the basket being taxed
+type CalculationItem struct {
+	ProductID uuid.UUID `json:"product_id" validate:"required"`
+	TaxClass  string    `json:"tax_class,omitempty" validate:"omitempty,oneof=standard reduced zero exempt"` // standard when omitted
+	Quantity  int       `json:"quantity" validate:"required,gt=0"`
+	UnitPrice float64   `json:"unit_price" validate:"gte=0"`
+}
+
+// CalculateRequest represents a basket to tax. Unit prices exclude tax
+// unless PricesIncludeTax is set.
+type CalculateRequest struct {
+	Address          Address           `json:"address"`
+	Items            []CalculationItem `json:"items" validate:"required,min=1,dive"`
+	PricesIncludeTax bool              `json:"prices_include_tax"`
+}
+
+// Calculation is the taxed basket, with its total both excluding and
+// including tax
+type Calculation struct {
+	Provider         string    `json:"provider"`
+	PricesIncludeTax bool      `json:"prices_include_tax"`
+	TotalExclusive   float64   `json:"total_exclusive"`
+	Tax              float64   `json:"tax"`
+	TotalInclusive   float64   `json:"total_inclusive"`
+	Lines            []TaxLine `json:"lines"`
+}
+
+// TaxLine is the tax on one line of the basket
+type TaxLine struct {
+	ProductID uuid.UUID `json:"product_id"`
+	TaxClass  string    `json:"tax_class"`
+	Rate      float64   `json:"rate"` // percent
+	Exclusive float64   `json:"exclusive"`
+	Tax       float64   `json:"tax"`
+	Inclusive float64   `json:"inclusive"`
+}
+
+// NewTaxLine taxes amount, the price of a whole line, at rate percent.
+// Amounts including tax have the tax backed out of them.
+func NewTaxLine(item CalculationItem, rate, amount float64, inclusive bool) TaxLine {
+	line := TaxLine{ProductID: item.ProductID, TaxClass: item.TaxClass, Rate: rate}
+	amount = Round(amount)
+	if inclusive {
+		line.Inclusive = amount
+		line.Tax = Round(amount * rate / (100 + rate))
+		line.Exclusive = Round(amount - line.Tax)
+	} else {
+		line.Exclusive = amount
+		line.Tax = Round(amount * rate / 100)
+		line.Inclusive = Round(amount + line.Tax)
+	}
+	return line
+}
+
+// Total adds up the lines of a calculation
+func (c *Calculation) Total() {
+	c.TotalExclusive, c.Tax, c.TotalInclusive = 0, 0, 0
+	for _, line := range c.Lines {
+		c.TotalExclusive += line.Exclusive
+		c.Tax += line.Tax
+		c.TotalInclusive += line.Inclusive
+	}
+	c.TotalExclusive = Round(c.TotalExclusive)
+	c.Tax = Round(c.Tax)
+	c.TotalInclusive = Round(c.TotalInclusive)
+}
+
+// Round rounds an amount to whole cents
+func Round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// TableName returns the table name for TaxRate
+func (TaxRate) TableName() string {
+	return "tax_rates"
+}
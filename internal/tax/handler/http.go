@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/tax/domain"
+	"ecommerce/internal/tax/service"
+	"ecommerce/pkg/database"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/response"
+)
+
+// HTTPHandler handles HTTP requests for tax service
+type HTTPHandler struct {
+	service service.TaxService
+	logger  *logrus.Logger
+}
+
+// NewHTTPHandler creates a new HTTP handler
+func NewHTTPHandler(service service.TaxService, logger *logrus.Logger) *HTTPHandler {
+	return &HTTPHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers all HTTP routes
+func (h *HTTPHandler) RegisterRoutes(router *gin.Engine) {
+	api := router.Group("/api/v1")
+
+	// Tax routes
+	tax := api.Group("/tax")
+	{
+		tax.POST("/calculate", h.Calculate)
+		tax.POST("/rates", h.CreateRate)
+		tax.GET("/rates", h.ListRates)
+		tax.GET("/rates/:id", h.GetRate)
+		tax.PUT("/rates/:id", h.UpdateRate)
+		tax.DELETE("/rates/:id", h.DeleteRate)
+	}
+
+	// Health check
+	router.GET("/health", h.HealthCheck)
+	router.GET("/ready", h.ReadinessCheck)
+}
+
+// CreateRate handles tax rate creation
+func (h *HTTPHandler) CreateRate(c *gin.Context) {
+	var req domain.CreateTaxRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	rate, err := h.service.CreateRate(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Tax rate created successfully", rate)
+}
+
+// GetRate handles getting a single tax rate
+func (h *HTTPHandler) GetRate(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid tax rate ID", err)
+		return
+	}
+
+	rate, err := h.service.GetRate(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Tax rate retrieved successfully", rate)
+}
+
+// UpdateRate handles tax rate updates
+func (h *HTTPHandler) UpdateRate(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid tax rate ID", err)
+		return
+	}
+
+	var req domain.UpdateTaxRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	rate, err := h.service.UpdateRate(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Tax rate updated successfully", rate)
+}
+
+// DeleteRate handles tax rate deletion
+func (h *HTTPHandler) DeleteRate(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid tax rate ID", err)
+		return
+	}
+
+	if err := h.service.DeleteRate(c.Request.Context(), id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Tax rate deleted successfully", nil)
+}
+
+// ListRates handles tax rate listing with filters
+func (h *HTTPHandler) ListRates(c *gin.Context) {
+	filters := &domain.TaxRateFilters{
+		Country:  c.Query("country"),
+		TaxClass: c.Query("tax_class"),
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			filters.Limit = l
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if o, err := strconv.Atoi(offset); err == nil {
+			filters.Offset = o
+		}
+	}
+
+	rates, err := h.service.ListRates(c.Request.Context(), filters)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Tax rates retrieved successfully", rates)
+}
+
+// Calculate handles taxing a basket
+func (h *HTTPHandler) Calculate(c *gin.Context) {
+	var req domain.CalculateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	calculation, err := h.service.Calculate(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Tax calculated successfully", calculation)
+}
+
+// HealthCheck handles health check requests
+func (h *HTTPHandler) HealthCheck(c *gin.Context) {
+	response.Success(c, http.StatusOK, "Service is healthy", gin.H{
+		"service": "tax-service",
+		"status":  "healthy",
+	})
+}
+
+// ReadinessCheck handles readiness check requests
+func (h *HTTPHandler) ReadinessCheck(c *gin.Context) {
+	response.Success(c, http.StatusOK, "Service is ready", gin.H{
+		"service": "tax-service",
+		"status":  "ready",
+	})
+}
+
+// handleError handles service errors and converts them to appropriate HTTP responses
+func (h *HTTPHandler) handleError(c *gin.Context, err error) {
+	err = database.TranslateError(err)
+	switch {
+	case errors.IsNotFound(err):
+		response.Error(c, http.StatusNotFound, "Resource not found", err)
+	case errors.IsValidation(err):
+		response.Error(c, http.StatusBadRequest, "Validation failed", err)
+	case errors.IsConflict(err):
+		response.Error(c, http.StatusConflict, "Resource conflict", err)
+	case errors.IsUnauthorized(err):
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", err)
+	case errors.IsForbidden(err):
+		response.Error(c, http.StatusForbidden, "Forbidden", err)
+	case errors.IsUnavailable(err):
+		response.Error(c, http.StatusServiceUnavailable, "Service unavailable", err)
+	default:
+		h.logger.WithError(err).Error("Internal server error")
+		response.Error(c, http.StatusInternalServerError, "Internal server error", nil)
+	}
+}
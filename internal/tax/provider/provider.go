@@ -0,0 +1,20 @@
+package provider
+
+import (
+	"context"
+
+	"ecommerce/internal/tax/domain"
+)
+
+// Supported providers
+const (
+	ProviderRates  = "rates"
+	ProviderTaxJar = "taxjar"
+)
+
+// Provider calculates the tax on a basket. Requests come validated, with
+// the country and region in upper case and every item's tax class set.
+type Provider interface {
+	Name() string
+	Calculate(ctx context.Context, req *domain.CalculateRequest) (*domain.Calculation, error)
+}
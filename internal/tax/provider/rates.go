@@ -0,0 +1,66 @@
+package provider
+
+import (
+	"context"
+
+	"ecommerce/internal/tax/domain"
+	"ecommerce/internal/tax/repository"
+)
+
+// Rates calculates tax from the rates configured in the service. Products
+// of the reduced class pay the standard rate where no reduced rate is set;
+// without any rate for their class they are not taxed.
+type Rates struct {
+	repo repository.TaxRepository
+}
+
+// NewRates creates a provider backed by the configured rates
+func NewRates(repo repository.TaxRepository) *Rates {
+	return &Rates{repo: repo}
+}
+
+func (p *Rates) Name() string {
+	return ProviderRates
+}
+
+func (p *Rates) Calculate(ctx context.Context, req *domain.CalculateRequest) (*domain.Calculation, error) {
+	rates, err := p.repo.ListForRegion(ctx, req.Address.Country, req.Address.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	calculation := &domain.Calculation{
+		Provider:         ProviderRates,
+		PricesIncludeTax: req.PricesIncludeTax,
+		Lines:            make([]domain.TaxLine, 0, len(req.Items)),
+	}
+	for _, item := range req.Items {
+		amount := item.UnitPrice * float64(item.Quantity)
+		calculation.Lines = append(calculation.Lines, domain.NewTaxLine(item, rateFor(rates, item.TaxClass), amount, req.PricesIncludeTax))
+	}
+	calculation.Total()
+	return calculation, nil
+}
+
+// rateFor returns the rate of a tax class, preferring the region's own
+// rate to the country's
+func rateFor(rates []domain.TaxRate, class string) float64 {
+	if class == domain.ClassExempt {
+		return 0
+	}
+
+	var rate *domain.TaxRate
+	for i := range rates {
+		if rates[i].TaxClass == class && (rate == nil || rates[i].Region != "") {
+			rate = &rates[i]
+		}
+	}
+	switch {
+	case rate != nil:
+		return rate.Rate
+	case class == domain.ClassReduced:
+		return rateFor(rates, domain.ClassStandard)
+	default:
+		return 0
+	}
+}
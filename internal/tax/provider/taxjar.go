@@ -0,0 +1,171 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ecommerce/internal/tax/config"
+	"ecommerce/internal/tax/domain"
+	"ecommerce/pkg/errors"
+)
+
+// TaxJar calculates tax with the TaxJar sales tax API. TaxJar works on
+// prices excluding tax, so baskets priced including tax are refused.
+type TaxJar struct {
+	baseURL    string
+	apiKey     string
+	classCodes map[string]string
+	client     *http.Client
+}
+
+// NewTaxJar creates a TaxJar provider
+func NewTaxJar(cfg config.TaxJarConfig) *TaxJar {
+	return &TaxJar{
+		baseURL:    strings.TrimRight(cfg.APIURL, "/"),
+		apiKey:     cfg.APIKey,
+		classCodes: cfg.ClassCodes,
+		client: &http.Client{
+			Timeout: time.Duration(cfg.Timeout) * time.Second,
+		},
+	}
+}
+
+// taxjarLineItem is a line of a TaxJar tax request
+type taxjarLineItem struct {
+	ID             string  `json:"id"`
+	Quantity       int     `json:"quantity"`
+	UnitPrice      float64 `json:"unit_price"`
+	ProductTaxCode string  `json:"product_tax_code,omitempty"`
+}
+
+// taxjarRequest is the body of a TaxJar tax request
+type taxjarRequest struct {
+	ToCountry string           `json:"to_country"`
+	ToState   string           `json:"to_state,omitempty"`
+	ToZip     string           `json:"to_zip,omitempty"`
+	ToCity    string           `json:"to_city,omitempty"`
+	Shipping  float64          `json:"shipping"`
+	LineItems []taxjarLineItem `json:"line_items"`
+}
+
+// taxjarResponse is the subset of a TaxJar tax response the service uses
+type taxjarResponse struct {
+	Tax struct {
+		Breakdown *struct {
+			LineItems []struct {
+				ID              string  `json:"id"`
+				TaxCollectable  float64 `json:"tax_collectable"`
+				CombinedTaxRate float64 `json:"combined_tax_rate"`
+			} `json:"line_items"`
+		} `json:"breakdown"`
+	} `json:"tax"`
+}
+
+// taxjarError is the error body TaxJar returns with non-2xx responses
+type taxjarError struct {
+	Error  string `json:"error"`
+	Detail string `json:"detail"`
+}
+
+func (p *TaxJar) Name() string {
+	return ProviderTaxJar
+}
+
+func (p *TaxJar) Calculate(ctx context.Context, req *domain.CalculateRequest) (*domain.Calculation, error) {
+	if req.PricesIncludeTax {
+		return nil, errors.NewValidationError("The taxjar provider only taxes prices that exclude tax", nil)
+	}
+
+	body := taxjarRequest{
+		ToCountry: req.Address.Country,
+		ToState:   req.Address.Region,
+		ToZip:     req.Address.PostalCode,
+		ToCity:    req.Address.City,
+		LineItems: make([]taxjarLineItem, len(req.Items)),
+	}
+	for i, item := range req.Items {
+		body.LineItems[i] = taxjarLineItem{
+			ID:             strconv.Itoa(i),
+			Quantity:       item.Quantity,
+			UnitPrice:      item.UnitPrice,
+			ProductTaxCode: p.classCodes[item.TaxClass],
+		}
+	}
+
+	var result taxjarResponse
+	if err := p.post(ctx, "/v2/taxes", body, &result); err != nil {
+		return nil, err
+	}
+
+	// Lines TaxJar leaves out of the breakdown are not taxed
+	collectable := make(map[string]float64)
+	rates := make(map[string]float64)
+	if result.Tax.Breakdown != nil {
+		for _, line := range result.Tax.Breakdown.LineItems {
+			collectable[line.ID] = line.TaxCollectable
+			rates[line.ID] = line.CombinedTaxRate * 100
+		}
+	}
+
+	calculation := &domain.Calculation{
+		Provider: ProviderTaxJar,
+		Lines:    make([]domain.TaxLine, 0, len(req.Items)),
+	}
+	for i, item := range req.Items {
+		id := strconv.Itoa(i)
+		line := domain.NewTaxLine(item, rates[id], item.UnitPrice*float64(item.Quantity), false)
+		line.Tax = domain.Round(collectable[id])
+		line.Inclusive = domain.Round(line.Exclusive + line.Tax)
+		calculation.Lines = append(calculation.Lines, line)
+	}
+	calculation.Total()
+	return calculation, nil
+}
+
+// post sends a JSON request to the TaxJar API
+func (p *TaxJar) post(ctx context.Context, path string, body, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode taxjar request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create taxjar request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("taxjar request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read taxjar response: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		var apiErr taxjarError
+		_ = json.Unmarshal(data, &apiErr)
+		cause := fmt.Errorf("taxjar status %d: %s", resp.StatusCode, apiErr.Detail)
+		// TaxJar rejects addresses and baskets it cannot tax with 4xx
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusTooManyRequests {
+			return errors.NewValidationError(fmt.Sprintf("Tax could not be calculated: %s", apiErr.Detail), cause)
+		}
+		return cause
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode taxjar response: %w", err)
+	}
+	return nil
+}
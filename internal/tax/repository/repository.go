@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"ecommerce/internal/tax/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+// TaxRepository defines the interface for tax rate data operations
+type TaxRepository interface {
+	Create(ctx context.Context, rate *domain.TaxRate) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.TaxRate, error)
+	Update(ctx context.Context, rate *domain.TaxRate) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	List(ctx context.Context, filters *domain.TaxRateFilters) ([]domain.TaxRate, int64, error)
+
+	// ListForRegion lists the rates of a country that apply in a region of
+	// it: the region's own and those for the whole country
+	ListForRegion(ctx context.Context, country, region string) ([]domain.TaxRate, error)
+}
+
+type taxRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+// NewTaxRepository creates a new tax repository
+func NewTaxRepository(db *gorm.DB, logger *logrus.Logger) TaxRepository {
+	return &taxRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *taxRepository) Create(ctx context.Context, rate *domain.TaxRate) error {
+	if err := r.db.WithContext(ctx).Create(rate).Error; err != nil {
+		return fmt.Errorf("failed to create tax rate: %w", err)
+	}
+	return nil
+}
+
+func (r *taxRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.TaxRate, error) {
+	var rate domain.TaxRate
+	err := r.db.WithContext(ctx).First(&rate, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Tax rate not found", err).WithCode(customErrors.CodeTaxRateNotFound)
+		}
+		return nil, fmt.Errorf("failed to get tax rate: %w", err)
+	}
+
+	return &rate, nil
+}
+
+func (r *taxRepository) Update(ctx context.Context, rate *domain.TaxRate) error {
+	if err := r.db.WithContext(ctx).Save(rate).Error; err != nil {
+		return fmt.Errorf("failed to update tax rate: %w", err)
+	}
+	return nil
+}
+
+func (r *taxRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Delete(&domain.TaxRate{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete tax rate: %w", err)
+	}
+	return nil
+}
+
+func (r *taxRepository) List(ctx context.Context, filters *domain.TaxRateFilters) ([]domain.TaxRate, int64, error) {
+	query := r.db.WithContext(ctx).Model(&domain.TaxRate{})
+
+	if filters.Country != "" {
+		query = query.Where("country = ?", filters.Country)
+	}
+	if filters.TaxClass != "" {
+		query = query.Where("tax_class = ?", filters.TaxClass)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count tax rates: %w", err)
+	}
+
+	var rates []domain.TaxRate
+	err := query.
+		Order("country, region, tax_class").
+		Limit(filters.Limit).
+		Offset(filters.Offset).
+		Find(&rates).Error
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list tax rates: %w", err)
+	}
+
+	return rates, total, nil
+}
+
+func (r *taxRepository) ListForRegion(ctx context.Context, country, region string) ([]domain.TaxRate, error) {
+	var rates []domain.TaxRate
+	err := r.db.WithContext(ctx).
+		Where("country = ? AND region IN ?", country, []string{"", region}).
+		Order("region, tax_class").
+		Find(&rates).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tax rates: %w", err)
+	}
+
+	return rates, nil
+}
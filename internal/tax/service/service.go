@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/tax/domain"
+	"ecommerce/internal/tax/provider"
+	"ecommerce/internal/tax/repository"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/database"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/logger"
+	"ecommerce/pkg/validator"
+)
+
+// TaxService defines the tax service interface
+type TaxService interface {
+	CreateRate(ctx context.Context, req *domain.CreateTaxRateRequest) (*domain.TaxRate, error)
+	GetRate(ctx context.Context, id uuid.UUID) (*domain.TaxRate, error)
+	UpdateRate(ctx context.Context, id uuid.UUID, req *domain.UpdateTaxRateRequest) (*domain.TaxRate, error)
+	DeleteRate(ctx context.Context, id uuid.UUID) error
+	ListRates(ctx context.Context, filters *domain.TaxRateFilters) (*domain.TaxRateList, error)
+
+	Calculate(ctx context.Context, req *domain.CalculateRequest) (*domain.Calculation, error)
+}
+
+type taxService struct {
+	repo      repository.TaxRepository
+	provider  provider.Provider
+	logger    *logrus.Logger
+	validator *validator.Validator
+}
+
+// NewTaxService creates a new tax service
+func NewTaxService(repo repository.TaxRepository, provider provider.Provider, logger *logrus.Logger) TaxService {
+	return &taxService{
+		repo:      repo,
+		provider:  provider,
+		logger:    logger,
+		validator: validator.New(),
+	}
+}
+
+// log returns the logger of the request ctx belongs to
+func (s *taxService) log(ctx context.Context) *logrus.Entry {
+	return logger.FromContext(ctx, s.logger)
+}
+
+func (s *taxService) CreateRate(ctx context.Context, req *domain.CreateTaxRateRequest) (*domain.TaxRate, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing tax rates requires the admin role", nil)
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid create tax rate request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	rate := &domain.TaxRate{
+		Country:  strings.ToUpper(req.Country),
+		Region:   normalizeRegion(req.Region),
+		TaxClass: req.TaxClass,
+		Rate:     req.Rate,
+		Name:     req.Name,
+	}
+	if err := s.repo.Create(ctx, rate); err != nil {
+		if database.IsUniqueViolation(err, "tax_rates_country_region_tax_class_key") {
+			return nil, errors.NewConflictError("A rate for this tax class and region already exists", err).WithCode(errors.CodeTaxRateConflict)
+		}
+		s.log(ctx).WithError(err).Error("Failed to create tax rate")
+		return nil, errors.NewInternalError("Failed to create tax rate", err)
+	}
+
+	s.log(ctx).WithField("tax_rate_id", rate.ID).Info("Tax rate created successfully")
+	return rate, nil
+}
+
+func (s *taxService) GetRate(ctx context.Context, id uuid.UUID) (*domain.TaxRate, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing tax rates requires the admin role", nil)
+	}
+
+	rate, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, err
+		}
+		s.log(ctx).WithError(err).Error("Failed to get tax rate")
+		return nil, errors.NewInternalError("Failed to get tax rate", err)
+	}
+
+	return rate, nil
+}
+
+func (s *taxService) UpdateRate(ctx context.Context, id uuid.UUID, req *domain.UpdateTaxRateRequest) (*domain.TaxRate, error) {
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid update tax rate request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	rate, err := s.GetRate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update fields
+	if req.Rate != nil {
+		rate.Rate = *req.Rate
+	}
+	if req.Name != nil {
+		rate.Name = *req.Name
+	}
+
+	if err := s.repo.Update(ctx, rate); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to update tax rate")
+		return nil, errors.NewInternalError("Failed to update tax rate", err)
+	}
+
+	s.log(ctx).WithField("tax_rate_id", rate.ID).Info("Tax rate updated successfully")
+	return rate, nil
+}
+
+func (s *taxService) DeleteRate(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.GetRate(ctx, id); err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to delete tax rate")
+		return errors.NewInternalError("Failed to delete tax rate", err)
+	}
+
+	s.log(ctx).WithField("tax_rate_id", id).Info("Tax rate deleted successfully")
+	return nil
+}
+
+func (s *taxService) ListRates(ctx context.Context, filters *domain.TaxRateFilters) (*domain.TaxRateList, error) {
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		return nil, errors.NewForbiddenError("Managing tax rates requires the admin role", nil)
+	}
+
+	// Set default values
+	if filters.Limit <= 0 {
+		filters.Limit = 20
+	}
+	if filters.Limit > 100 {
+		filters.Limit = 100
+	}
+	filters.Country = strings.ToUpper(filters.Country)
+
+	rates, total, err := s.repo.List(ctx, filters)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list tax rates")
+		return nil, errors.NewInternalError("Failed to list tax rates", err)
+	}
+
+	return &domain.TaxRateList{
+		Rates:   rates,
+		Total:   total,
+		Limit:   filters.Limit,
+		Offset:  filters.Offset,
+		HasMore: int64(filters.Offset+filters.Limit) < total,
+	}, nil
+}
+
+// Calculate taxes a basket with the configured provider
+func (s *taxService) Calculate(ctx context.Context, req *domain.CalculateRequest) (*domain.Calculation, error) {
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid tax calculation request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	req.Address.Country = strings.ToUpper(req.Address.Country)
+	req.Address.Region = normalizeRegion(req.Address.Region)
+	for i := range req.Items {
+		if req.Items[i].TaxClass == "" {
+			req.Items[i].TaxClass = domain.ClassStandard
+		}
+	}
+
+	calculation, err := s.provider.Calculate(ctx, req)
+	if err != nil {
+		if errors.IsValidation(err) {
+			return nil, err
+		}
+		s.log(ctx).WithError(err).WithField("provider", s.provider.Name()).Error("Failed to calculate tax")
+		return nil, errors.NewUnavailableError("Tax could not be calculated", err)
+	}
+
+	return calculation, nil
+}
+
+// normalizeRegion makes region codes case-insensitive
+func normalizeRegion(region string) string {
+	return strings.ToUpper(strings.TrimSpace(region))
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TwoFactor is a user's TOTP authenticator. It is enrolled first and only
+// enabled once the user proves their app produces codes for it.
+type TwoFactor struct {
+	UserID    string     `json:"-" gorm:"primary_key"`
+	Secret    string     `json:"-" gorm:"not null"`           // sealed with the gateway's two-factor key
+	LastStep  int64      `json:"-" gorm:"not null;default:0"` // of the last code accepted, so none is used twice
+	EnabledAt *time.Time `json:"enabled_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// IsEnabled reports whether codes are asked for
+func (t *TwoFactor) IsEnabled() bool {
+	return t.EnabledAt != nil
+}
+
+// RecoveryCode stands in for a code once, when the authenticator is lost.
+// Only a hash of the code is stored.
+type RecoveryCode struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    string    `gorm:"not null;index"`
+	Hash      string    `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// Status describes a user's two-factor authentication
+type Status struct {
+	Enabled       bool       `json:"enabled"`
+	EnabledAt     *time.Time `json:"enabled_at,omitempty"`
+	Required      bool       `json:"required"`       // by the policy for the user's role
+	Verified      bool       `json:"verified"`       // in the current session
+	RecoveryCodes int        `json:"recovery_codes"` // left unused
+}
+
+// Enrollment is what an authenticator app is set up from, by scanning the
+// URI as a QR code or typing in the secret
+type Enrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// RecoveryCodes are shown once, when two-factor authentication is enabled
+// or they are regenerated
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+// CodeRequest carries a code from the user's authenticator app, or for
+// the requests that allow one, a recovery code instead
+type CodeRequest struct {
+	Code         string `json:"code,omitempty" validate:"omitempty,len=6,numeric"`
+	RecoveryCode string `json:"recovery_code,omitempty" validate:"omitempty,max=32"`
+}
+
+// TableName returns the table name for TwoFactor
+func (TwoFactor) TableName() string {
+	return "two_factor_auth"
+}
+
+// TableName returns the table name for RecoveryCode
+func (RecoveryCode) TableName() string {
+	return "two_factor_recovery_codes"
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/twofactor/domain"
+	"ecommerce/internal/twofactor/service"
+	"ecommerce/pkg/database"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/response"
+)
+
+// HeaderCaptcha carries the token of a CAPTCHA the client solved
+const HeaderCaptcha = "X-Captcha-Token"
+
+// HTTPHandler handles HTTP requests for two-factor authentication
+type HTTPHandler struct {
+	service service.TwoFactorService
+	logger  *logrus.Logger
+}
+
+// NewHTTPHandler creates a new HTTP handler
+func NewHTTPHandler(service service.TwoFactorService, logger *logrus.Logger) *HTTPHandler {
+	return &HTTPHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers the two-factor routes under api. The caller
+// must put the routes behind middleware that authenticates the actor.
+func (h *HTTPHandler) RegisterRoutes(api *gin.RouterGroup) {
+	twoFactor := api.Group("/users/me/two-factor", h.captcha)
+	{
+		twoFactor.GET("", h.GetStatus)
+		twoFactor.POST("", h.Enroll)
+		twoFactor.DELETE("", h.Disable)
+		twoFactor.POST("/enable", h.Enable)
+		twoFactor.POST("/verify", h.Verify)
+		twoFactor.POST("/recovery-codes", h.RegenerateRecoveryCodes)
+	}
+}
+
+// captcha puts the CAPTCHA the client solved, if any, into the request
+// context
+func (h *HTTPHandler) captcha(c *gin.Context) {
+	if token := c.GetHeader(HeaderCaptcha); token != "" {
+		c.Request = c.Request.WithContext(service.WithCaptcha(c.Request.Context(), token, c.ClientIP()))
+	}
+	c.Next()
+}
+
+// GetStatus handles getting the caller's two-factor status
+func (h *HTTPHandler) GetStatus(c *gin.Context) {
+	status, err := h.service.GetStatus(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Two-factor status retrieved successfully", status)
+}
+
+// Enroll handles setting up an authenticator app
+func (h *HTTPHandler) Enroll(c *gin.Context) {
+	enrollment, err := h.service.Enroll(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Authenticator enrolled successfully", enrollment)
+}
+
+// Enable handles confirming an enrolled authenticator app
+func (h *HTTPHandler) Enable(c *gin.Context) {
+	var req domain.CodeRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	codes, err := h.service.Enable(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Two-factor authentication enabled successfully", codes)
+}
+
+// Verify handles passing the second factor for the current session
+func (h *HTTPHandler) Verify(c *gin.Context) {
+	var req domain.CodeRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	if err := h.service.Verify(c.Request.Context(), &req); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Session verified successfully", nil)
+}
+
+// RegenerateRecoveryCodes handles replacing the caller's recovery codes
+func (h *HTTPHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req domain.CodeRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Recovery codes regenerated successfully", codes)
+}
+
+// Disable handles turning two-factor authentication off
+func (h *HTTPHandler) Disable(c *gin.Context) {
+	var req domain.CodeRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	if err := h.service.Disable(c.Request.Context(), &req); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Two-factor authentication disabled successfully", nil)
+}
+
+// bind reads a code request, responding and returning false when the body
+// is invalid
+func (h *HTTPHandler) bind(c *gin.Context, req *domain.CodeRequest) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return false
+	}
+	return true
+}
+
+// handleError handles service errors and converts them to appropriate HTTP responses
+func (h *HTTPHandler) handleError(c *gin.Context, err error) {
+	err = database.TranslateError(err)
+	switch {
+	case errors.IsNotFound(err):
+		response.Error(c, http.StatusNotFound, "Resource not found", err)
+	case errors.IsValidation(err):
+		response.Error(c, http.StatusBadRequest, "Validation failed", err)
+	case errors.IsConflict(err):
+		response.Error(c, http.StatusConflict, "Resource conflict", err)
+	case errors.IsUnauthorized(err):
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", err)
+	case errors.IsForbidden(err):
+		response.Error(c, http.StatusForbidden, "Forbidden", err)
+	case errors.IsUnavailable(err):
+		response.Error(c, http.StatusServiceUnavailable, "Service unavailable", err)
+	default:
+		h.logger.WithError(err).Error("Internal server error")
+		response.Error(c, http.StatusInternalServerError, "Internal server error", nil)
+	}
+}
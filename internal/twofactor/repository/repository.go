@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"ecommerce/internal/twofactor/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+// TwoFactorRepository defines the interface for two-factor data operations
+type TwoFactorRepository interface {
+	Get(ctx context.Context, userID string) (*domain.TwoFactor, error)
+	Save(ctx context.Context, twoFactor *domain.TwoFactor) error
+	Enable(ctx context.Context, userID string, at time.Time, codes []domain.RecoveryCode) (bool, error)
+	Delete(ctx context.Context, userID string) error
+
+	UseStep(ctx context.Context, userID string, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID, hash string) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID string, codes []domain.RecoveryCode) error
+	CountRecoveryCodes(ctx context.Context, userID string) (int64, error)
+}
+
+type twoFactorRepository struct {
+	db *gorm.DB
+}
+
+// NewTwoFactorRepository creates a new two-factor repository
+func NewTwoFactorRepository(db *gorm.DB) TwoFactorRepository {
+	return &twoFactorRepository{db: db}
+}
+
+func (r *twoFactorRepository) Get(ctx context.Context, userID string) (*domain.TwoFactor, error) {
+	var twoFactor domain.TwoFactor
+	err := r.db.WithContext(ctx).First(&twoFactor, "user_id = ?", userID).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Two-factor authentication not enabled", err).WithCode(customErrors.CodeTwoFactorNotEnabled)
+		}
+		return nil, fmt.Errorf("failed to get two-factor authentication: %w", err)
+	}
+
+	return &twoFactor, nil
+}
+
+// Save stores an enrolment, replacing an earlier one that was never
+// enabled
+func (r *twoFactorRepository) Save(ctx context.Context, twoFactor *domain.TwoFactor) error {
+	if err := r.db.WithContext(ctx).Save(twoFactor).Error; err != nil {
+		return fmt.Errorf("failed to save two-factor authentication: %w", err)
+	}
+	return nil
+}
+
+// Enable turns on an enrolment together with its first recovery codes,
+// reporting false when it was already enabled
+func (r *twoFactorRepository) Enable(ctx context.Context, userID string, at time.Time, codes []domain.RecoveryCode) (bool, error) {
+	var enabled bool
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.TwoFactor{}).
+			Where("user_id = ? AND enabled_at IS NULL", userID).
+			Updates(map[string]interface{}{"enabled_at": at, "updated_at": at})
+		if result.Error != nil {
+			return fmt.Errorf("failed to enable two-factor authentication: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		enabled = true
+		return replaceRecoveryCodes(tx, userID, codes)
+	})
+	return enabled, err
+}
+
+// Delete removes a user's authenticator and recovery codes
+func (r *twoFactorRepository) Delete(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&domain.RecoveryCode{}).Error; err != nil {
+			return fmt.Errorf("failed to delete recovery codes: %w", err)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&domain.TwoFactor{}).Error; err != nil {
+			return fmt.Errorf("failed to delete two-factor authentication: %w", err)
+		}
+		return nil
+	})
+}
+
+// UseStep records the step of an accepted code, reporting false when a
+// code of that step or a later one was accepted before
+func (r *twoFactorRepository) UseStep(ctx context.Context, userID string, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.TwoFactor{}).
+		Where("user_id = ? AND last_step < ?", userID, step).
+		Update("last_step", step)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to record two-factor code: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// UseRecoveryCode spends a recovery code, reporting false when there is no
+// unused one with the hash
+func (r *twoFactorRepository) UseRecoveryCode(ctx context.Context, userID, hash string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.RecoveryCode{}).
+		Where("user_id = ? AND hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *twoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID string, codes []domain.RecoveryCode) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codes)
+	})
+}
+
+func (r *twoFactorRepository) CountRecoveryCodes(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+	return count, nil
+}
+
+// replaceRecoveryCodes swaps a user's recovery codes for new ones within a
+// transaction
+func replaceRecoveryCodes(tx *gorm.DB, userID string, codes []domain.RecoveryCode) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&domain.RecoveryCode{}).Error; err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	if err := tx.Create(&codes).Error; err != nil {
+		return fmt.Errorf("failed to create recovery codes: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/gateway/lockout"
+	"ecommerce/pkg/errors"
+)
+
+// auditKind tells two-factor security events apart from others
+const auditKind = "two_factor"
+
+type captchaKey struct{}
+
+// captchaAnswer is the CAPTCHA a client solved, with its IP for the check
+type captchaAnswer struct {
+	token string
+	ip    string
+}
+
+// WithCaptcha returns a copy of ctx carrying the token of a CAPTCHA the
+// client solved, for when enough wrong codes have been tried to ask for one
+func WithCaptcha(ctx context.Context, token, ip string) context.Context {
+	return context.WithValue(ctx, captchaKey{}, captchaAnswer{token: token, ip: ip})
+}
+
+// guarded runs a check of a code the caller typed under the lockout
+// policy. Callers that are locked out are refused without checking, wrong
+// codes count towards a lockout and a right one clears the count. Redis
+// being unreachable lets checks through, as with rate limiting.
+func (s *twoFactorService) guarded(ctx context.Context, userID string, check func() error) error {
+	if s.guard == nil {
+		return check()
+	}
+	subject := "two-factor:" + userID
+
+	status, err := s.guard.Check(ctx, subject)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to check lockout")
+	}
+	if status.Locked > 0 {
+		lockout.Audit(ctx, s.logger, lockout.EventLockedRefused, auditKind, userID, nil)
+		return lockedOut(status.Locked)
+	}
+	if status.Captcha && s.captcha != nil {
+		if err := s.solvedCaptcha(ctx, userID); err != nil {
+			return err
+		}
+	}
+
+	err = check()
+	switch {
+	case errors.Code(err) == errors.CodeTwoFactorInvalid:
+		status, failErr := s.guard.Fail(ctx, subject)
+		if failErr != nil {
+			s.log(ctx).WithError(failErr).Error("Failed to count two-factor failure")
+			return err
+		}
+		lockout.Audit(ctx, s.logger, lockout.EventAuthFailed, auditKind, userID, logrus.Fields{"failures": status.Failures})
+		if status.Locked > 0 {
+			lockout.Audit(ctx, s.logger, lockout.EventLockedOut, auditKind, userID, logrus.Fields{"locked_for": status.Locked.String()})
+			return lockedOut(status.Locked)
+		}
+	case err == nil:
+		if err := s.guard.Succeed(ctx, subject); err != nil {
+			s.log(ctx).WithError(err).Error("Failed to reset two-factor failures")
+		}
+	}
+	return err
+}
+
+// solvedCaptcha checks the CAPTCHA the caller sent with their code
+func (s *twoFactorService) solvedCaptcha(ctx context.Context, userID string) error {
+	answer, _ := ctx.Value(captchaKey{}).(captchaAnswer)
+
+	ok, err := s.captcha.Verify(ctx, answer.token, answer.ip)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to verify CAPTCHA")
+		return errors.NewUnavailableError("Failed to verify CAPTCHA", err)
+	}
+	if !ok {
+		lockout.Audit(ctx, s.logger, lockout.EventCaptchaFailed, auditKind, userID, nil)
+		return errors.NewForbiddenError("Solve the CAPTCHA to try another code", nil).WithCode(errors.CodeCaptchaRequired)
+	}
+	return nil
+}
+
+func lockedOut(d time.Duration) error {
+	minutes := int(math.Ceil(d.Minutes()))
+	return errors.NewForbiddenError(fmt.Sprintf("Too many wrong codes; try again in %d minute(s)", minutes), nil).WithCode(errors.CodeLockedOut)
+}
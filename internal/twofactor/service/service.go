@@ -0,0 +1,481 @@
+package service
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/gateway/config"
+	"ecommerce/internal/gateway/lockout"
+	sessionservice "ecommerce/internal/session/service"
+	"ecommerce/internal/twofactor/domain"
+	"ecommerce/internal/twofactor/repository"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/logger"
+	"ecommerce/pkg/totp"
+	"ecommerce/pkg/validator"
+)
+
+// TwoFactorService defines the two-factor authentication service interface
+type TwoFactorService interface {
+	GetStatus(ctx context.Context) (*domain.Status, error)
+	Enroll(ctx context.Context) (*domain.Enrollment, error)
+	Enable(ctx context.Context, req *domain.CodeRequest) (*domain.RecoveryCodes, error)
+	Verify(ctx context.Context, req *domain.CodeRequest) error
+	RegenerateRecoveryCodes(ctx context.Context, req *domain.CodeRequest) (*domain.RecoveryCodes, error)
+	Disable(ctx context.Context, req *domain.CodeRequest) error
+
+	Protects(method, path string) bool
+	Required(ctx context.Context) (bool, error)
+}
+
+type twoFactorService struct {
+	repo          repository.TwoFactorRepository
+	sessions      sessionservice.SessionService
+	guard         *lockout.Guard  // nil when lockout is disabled
+	captcha       lockout.Captcha // nil when no CAPTCHA is configured
+	aead          cipher.AEAD     // seals TOTP secrets at rest
+	issuer        string
+	requiredRoles []string
+	routes        []string
+	skew          int
+	recoveryCodes int
+	logger        *logrus.Logger
+	validator     *validator.Validator
+}
+
+// NewTwoFactorService creates a new two-factor authentication service.
+// Second factors are passed per session, so sessions must be tracked.
+// Wrong codes count towards a lockout when guard is set, and once enough
+// have been tried a CAPTCHA is asked for when captcha is set.
+func NewTwoFactorService(repo repository.TwoFactorRepository, sessions sessionservice.SessionService, guard *lockout.Guard, captcha lockout.Captcha, cfg config.TwoFactorConfig, logger *logrus.Logger) (TwoFactorService, error) {
+	// Derive the sealing key, so the secret itself is never used directly
+	mac := hmac.New(sha256.New, []byte(cfg.Secret))
+	mac.Write([]byte("totp-secret"))
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	return &twoFactorService{
+		repo:          repo,
+		sessions:      sessions,
+		guard:         guard,
+		captcha:       captcha,
+		aead:          aead,
+		issuer:        cfg.Issuer,
+		requiredRoles: cfg.RequiredRoles,
+		routes:        cfg.Routes,
+		skew:          cfg.Skew,
+		recoveryCodes: cfg.RecoveryCodes,
+		logger:        logger,
+		validator:     validator.New(),
+	}, nil
+}
+
+// log returns the logger of the request ctx belongs to
+func (s *twoFactorService) log(ctx context.Context) *logrus.Entry {
+	return logger.FromContext(ctx, s.logger)
+}
+
+// GetStatus describes the caller's two-factor authentication
+func (s *twoFactorService) GetStatus(ctx context.Context) (*domain.Status, error) {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &domain.Status{
+		Required: slices.Contains(s.requiredRoles, actor.Role),
+		Verified: Verified(ctx),
+	}
+
+	twoFactor, err := s.repo.Get(ctx, actor.ID)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return status, nil
+		}
+		s.log(ctx).WithError(err).Error("Failed to get two-factor authentication")
+		return nil, errors.NewInternalError("Failed to get two-factor authentication", err)
+	}
+	if !twoFactor.IsEnabled() {
+		return status, nil
+	}
+
+	count, err := s.repo.CountRecoveryCodes(ctx, actor.ID)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to count recovery codes")
+		return nil, errors.NewInternalError("Failed to get two-factor authentication", err)
+	}
+
+	status.Enabled = true
+	status.EnabledAt = twoFactor.EnabledAt
+	status.RecoveryCodes = int(count)
+	return status, nil
+}
+
+// Enroll starts setting up an authenticator app with a new secret. It is
+// not asked for until Enable confirms the app produces its codes;
+// enrolling again before then replaces the secret.
+func (s *twoFactorService) Enroll(ctx context.Context) (*domain.Enrollment, error) {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.Get(ctx, actor.ID)
+	if err != nil && !errors.IsNotFound(err) {
+		s.log(ctx).WithError(err).Error("Failed to get two-factor authentication")
+		return nil, errors.NewInternalError("Failed to enroll two-factor authentication", err)
+	}
+	if existing != nil && existing.IsEnabled() {
+		return nil, errors.NewConflictError("Two-factor authentication is already enabled", nil).WithCode(errors.CodeTwoFactorEnabled)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, errors.NewInternalError("Failed to generate two-factor secret", err)
+	}
+	sealed, err := s.seal(secret)
+	if err != nil {
+		return nil, errors.NewInternalError("Failed to generate two-factor secret", err)
+	}
+
+	twoFactor := &domain.TwoFactor{UserID: actor.ID, Secret: sealed}
+	if existing != nil {
+		twoFactor.CreatedAt = existing.CreatedAt
+	}
+	if err := s.repo.Save(ctx, twoFactor); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to save two-factor authentication")
+		return nil, errors.NewInternalError("Failed to enroll two-factor authentication", err)
+	}
+
+	account := actor.Email
+	if account == "" {
+		account = actor.ID
+	}
+	return &domain.Enrollment{
+		Secret: secret,
+		URI:    totp.ProvisioningURI(s.issuer, account, secret),
+	}, nil
+}
+
+// Enable turns two-factor authentication on with a code from the enrolled
+// app and returns the recovery codes. The current session counts as
+// verified by that code.
+func (s *twoFactorService) Enable(ctx context.Context, req *domain.CodeRequest) (*domain.RecoveryCodes, error) {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validator.Validate(req); err != nil {
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+	if req.Code == "" {
+		return nil, errors.NewValidationError("A code from your authenticator app is required", nil)
+	}
+
+	twoFactor, err := s.repo.Get(ctx, actor.ID)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewConflictError("Enroll an authenticator app first", nil).WithCode(errors.CodeTwoFactorNotEnabled)
+		}
+		s.log(ctx).WithError(err).Error("Failed to get two-factor authentication")
+		return nil, errors.NewInternalError("Failed to enable two-factor authentication", err)
+	}
+	if twoFactor.IsEnabled() {
+		return nil, errors.NewConflictError("Two-factor authentication is already enabled", nil).WithCode(errors.CodeTwoFactorEnabled)
+	}
+	err = s.guarded(ctx, actor.ID, func() error {
+		return s.checkCode(ctx, twoFactor, req.Code)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	codes, records, err := s.newRecoveryCodes(actor.ID)
+	if err != nil {
+		return nil, errors.NewInternalError("Failed to generate recovery codes", err)
+	}
+	enabled, err := s.repo.Enable(ctx, actor.ID, time.Now(), records)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to enable two-factor authentication")
+		return nil, errors.NewInternalError("Failed to enable two-factor authentication", err)
+	}
+	if !enabled {
+		return nil, errors.NewConflictError("Two-factor authentication is already enabled", nil).WithCode(errors.CodeTwoFactorEnabled)
+	}
+	s.markSession(ctx)
+
+	s.log(ctx).WithField("user_id", actor.ID).Info("Two-factor authentication enabled successfully")
+	return &domain.RecoveryCodes{Codes: codes}, nil
+}
+
+// Verify passes the second factor for the session the request is made in,
+// with a code from the app or a recovery code
+func (s *twoFactorService) Verify(ctx context.Context, req *domain.CodeRequest) error {
+	if _, err := s.enabledFor(ctx, req); err != nil {
+		return err
+	}
+
+	if err := s.sessions.MarkTwoFactor(ctx); err != nil {
+		return err
+	}
+
+	s.log(ctx).WithField("session_id", sessionservice.SessionIDFromContext(ctx)).Info("Session verified successfully")
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the caller's recovery codes, used or
+// not, with new ones
+func (s *twoFactorService) RegenerateRecoveryCodes(ctx context.Context, req *domain.CodeRequest) (*domain.RecoveryCodes, error) {
+	twoFactor, err := s.enabledFor(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, records, err := s.newRecoveryCodes(twoFactor.UserID)
+	if err != nil {
+		return nil, errors.NewInternalError("Failed to generate recovery codes", err)
+	}
+	if err := s.repo.ReplaceRecoveryCodes(ctx, twoFactor.UserID, records); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to replace recovery codes")
+		return nil, errors.NewInternalError("Failed to regenerate recovery codes", err)
+	}
+
+	s.log(ctx).WithField("user_id", twoFactor.UserID).Info("Recovery codes regenerated successfully")
+	return &domain.RecoveryCodes{Codes: codes}, nil
+}
+
+// Disable turns two-factor authentication off. Where the caller's role
+// requires it, the protected routes are refused until it is enabled again.
+func (s *twoFactorService) Disable(ctx context.Context, req *domain.CodeRequest) error {
+	twoFactor, err := s.enabledFor(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, twoFactor.UserID); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to delete two-factor authentication")
+		return errors.NewInternalError("Failed to disable two-factor authentication", err)
+	}
+
+	s.log(ctx).WithField("user_id", twoFactor.UserID).Info("Two-factor authentication disabled successfully")
+	return nil
+}
+
+// Protects reports whether a request to a cleaned path changes something
+// that takes a second factor. Prefixes match whole path segments.
+func (s *twoFactorService) Protects(method, path string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	for _, prefix := range s.routes {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Required reports whether the caller must have passed a second factor to
+// make protected requests: their role requires it, or they enabled it
+func (s *twoFactorService) Required(ctx context.Context) (bool, error) {
+	actor := auth.ActorFromContext(ctx)
+	if actor == nil {
+		return false, nil
+	}
+	if slices.Contains(s.requiredRoles, actor.Role) {
+		return true, nil
+	}
+
+	twoFactor, err := s.repo.Get(ctx, actor.ID)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, errors.NewUnavailableError("Failed to get two-factor authentication", err)
+	}
+	return twoFactor.IsEnabled(), nil
+}
+
+type verifiedKey struct{}
+
+// WithVerified returns a copy of ctx recording that the caller has passed
+// a second factor, in their session or with the identity provider
+func WithVerified(ctx context.Context) context.Context {
+	return context.WithValue(ctx, verifiedKey{}, true)
+}
+
+// Verified reports whether ctx records a second factor
+func Verified(ctx context.Context) bool {
+	verified, _ := ctx.Value(verifiedKey{}).(bool)
+	return verified
+}
+
+// enabledFor returns the caller's enabled two-factor authentication once
+// they prove they hold it, with a code or a recovery code
+func (s *twoFactorService) enabledFor(ctx context.Context, req *domain.CodeRequest) (*domain.TwoFactor, error) {
+	actor, err := requireActor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validator.Validate(req); err != nil {
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+	if req.Code == "" && req.RecoveryCode == "" {
+		return nil, errors.NewValidationError("A code or a recovery code is required", nil)
+	}
+
+	twoFactor, err := s.repo.Get(ctx, actor.ID)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, err
+		}
+		s.log(ctx).WithError(err).Error("Failed to get two-factor authentication")
+		return nil, errors.NewInternalError("Failed to get two-factor authentication", err)
+	}
+	if !twoFactor.IsEnabled() {
+		return nil, errors.NewNotFoundError("Two-factor authentication not enabled", nil).WithCode(errors.CodeTwoFactorNotEnabled)
+	}
+
+	err = s.guarded(ctx, actor.ID, func() error {
+		if req.Code != "" {
+			return s.checkCode(ctx, twoFactor, req.Code)
+		}
+
+		used, err := s.repo.UseRecoveryCode(ctx, actor.ID, hashRecoveryCode(req.RecoveryCode))
+		if err != nil {
+			s.log(ctx).WithError(err).Error("Failed to use recovery code")
+			return errors.NewInternalError("Failed to check recovery code", err)
+		}
+		if !used {
+			return invalidCode()
+		}
+		s.log(ctx).WithField("user_id", actor.ID).Warn("Recovery code used")
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return twoFactor, nil
+}
+
+// checkCode accepts a code from the app once. A code that was accepted
+// before, or one from an earlier step, is refused, so an overheard code
+// cannot be replayed.
+func (s *twoFactorService) checkCode(ctx context.Context, twoFactor *domain.TwoFactor, code string) error {
+	secret, err := s.open(twoFactor.Secret)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to open two-factor secret")
+		return errors.NewInternalError("Failed to check code", err)
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now(), s.skew)
+	if !ok {
+		return invalidCode()
+	}
+	used, err := s.repo.UseStep(ctx, twoFactor.UserID, step)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to record two-factor code")
+		return errors.NewInternalError("Failed to check code", err)
+	}
+	if !used {
+		return invalidCode()
+	}
+	return nil
+}
+
+// markSession verifies the current session, if there is one. Enabling
+// two-factor authentication does not depend on it, so failures are only
+// logged.
+func (s *twoFactorService) markSession(ctx context.Context) {
+	if sessionservice.SessionIDFromContext(ctx) == "" {
+		return
+	}
+	if err := s.sessions.MarkTwoFactor(ctx); err != nil {
+		s.log(ctx).WithError(err).Warn("Failed to verify session")
+	}
+}
+
+// newRecoveryCodes generates a set of recovery codes, returning them and
+// the records to store
+func (s *twoFactorService) newRecoveryCodes(userID string) ([]string, []domain.RecoveryCode, error) {
+	codes := make([]string, s.recoveryCodes)
+	records := make([]domain.RecoveryCode, s.recoveryCodes)
+	for i := range codes {
+		buf := make([]byte, 10)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, fmt.Errorf("failed to read random bytes: %w", err)
+		}
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(buf))
+		codes[i] = code[:8] + "-" + code[8:]
+		records[i] = domain.RecoveryCode{UserID: userID, Hash: hashRecoveryCode(codes[i])}
+	}
+	return codes, records, nil
+}
+
+// seal encrypts a TOTP secret for storage
+func (s *twoFactorService) seal(secret string) (string, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	sealed := s.aead.Seal(nonce, nonce, []byte(secret), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open decrypts a stored TOTP secret
+func (s *twoFactorService) open(sealed string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(data) < s.aead.NonceSize() {
+		return "", fmt.Errorf("malformed sealed secret")
+	}
+	nonce, ciphertext := data[:s.aead.NonceSize()], data[s.aead.NonceSize():]
+	secret, err := s.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to open sealed secret: %w", err)
+	}
+	return string(secret), nil
+}
+
+// hashRecoveryCode is the form a recovery code is stored and looked up in.
+// Codes are random and long, so a fast unsalted hash is enough. Case and
+// dashes are ignored, as people type codes back in however they like.
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// requireActor returns the caller, who must be signed in
+func requireActor(ctx context.Context) (*auth.Actor, error) {
+	actor := auth.ActorFromContext(ctx)
+	if actor == nil {
+		return nil, errors.NewUnauthorizedError("Authentication required", nil).WithCode(errors.CodeAuthenticationRequired)
+	}
+	return actor, nil
+}
+
+func invalidCode() error {
+	return errors.NewValidationError("Invalid two-factor code", nil).WithCode(errors.CodeTwoFactorInvalid)
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"strconv"
+
+	productconfig "ecommerce/internal/product/config"
+)
+
+// Config holds all configuration for the webhook service
+type Config struct {
+	Env      string
+	HTTP     productconfig.HTTPConfig
+	Database productconfig.DatabaseConfig
+	Auth     productconfig.AuthConfig
+	Debug    productconfig.DebugConfig
+	MTLS     productconfig.MTLSConfig
+	Logger   productconfig.LoggerConfig
+	Delivery DeliveryConfig
+	Targets  TargetsConfig
+}
+
+// DeliveryConfig holds delivery queue configuration
+type DeliveryConfig struct {
+	PollInterval int // seconds between queue sweeps
+	BatchSize    int
+	MaxAttempts  int
+	RetryBackoff int // seconds before the first retry; doubles per attempt
+	Timeout      int // seconds per callback request
+}
+
+// TargetsConfig restricts the callback URLs subscriptions may use
+type TargetsConfig struct {
+	AllowHTTP    bool // accept plain http URLs, for local development
+	AllowPrivate bool // accept loopback and private network addresses
+}
+
+// Load loads configuration from environment variables. The HTTP, database
+// and auth settings use the same variables as the product service.
+func Load() (*Config, error) {
+	shared, err := productconfig.Load()
+	if err != nil {
+		return nil, err
+	}
+	return &Config{
+		Env:      shared.Env,
+		HTTP:     shared.HTTP,
+		Database: shared.Database,
+		Auth:     shared.Auth,
+		Debug:    shared.Debug,
+		MTLS:     shared.MTLS,
+		Logger:   shared.Logger,
+		Delivery: DeliveryConfig{
+			PollInterval: getEnvAsInt("DELIVERY_POLL_INTERVAL", 5),
+			BatchSize:    getEnvAsInt("DELIVERY_BATCH_SIZE", 50),
+			MaxAttempts:  getEnvAsInt("DELIVERY_MAX_ATTEMPTS", 8),
+			RetryBackoff: getEnvAsInt("DELIVERY_RETRY_BACKOFF", 30),
+			Timeout:      getEnvAsInt("DELIVERY_TIMEOUT", 10),
+		},
+		Targets: TargetsConfig{
+			AllowHTTP:    getEnvAsBool("WEBHOOK_ALLOW_HTTP", false),
+			AllowPrivate: getEnvAsBool("WEBHOOK_ALLOW_PRIVATE_TARGETS", false),
+		},
+	}, nil
+}
+
+// Validate reports every setting that is missing or invalid, so the service
+// refuses to start rather than run misconfigured
+func (c *Config) Validate() error {
+	errs := []error{
+		productconfig.ValidateEnv(c.Env),
+		c.HTTP.Validate(),
+		c.Database.Validate(c.Env),
+		c.Logger.Validate(),
+		c.MTLS.Validate(),
+		c.Auth.Validate(),
+	}
+	if c.Delivery.MaxAttempts < 1 {
+		errs = append(errs, errors.New("DELIVERY_MAX_ATTEMPTS must be at least 1"))
+	}
+	return errors.Join(errs...)
+}
+
+// getEnvAsInt gets an environment variable as integer with a default value
+func getEnvAsInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsBool gets an environment variable as boolean with a default value
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
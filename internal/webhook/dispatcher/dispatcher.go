@@ -0,0 +1,143 @@
+package dispatcher
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Headers sent with every callback
+const (
+	HeaderDeliveryID = "X-Webhook-ID"
+	HeaderEvent      = "X-Webhook-Event"
+	HeaderSignature  = "X-Webhook-Signature"
+)
+
+// maxResponseBody bounds how much of a callback's response is kept for the
+// delivery log
+const maxResponseBody = 1024
+
+// ErrPrivateTarget is returned when a callback URL resolves to an address
+// the service must not call
+var ErrPrivateTarget = errors.New("callback address is not publicly routable")
+
+// Request is one callback to make
+type Request struct {
+	URL        string
+	Secret     string
+	DeliveryID string
+	EventType  string
+	Payload    []byte
+}
+
+// Result describes a callback's response. It is returned alongside the
+// error when the endpoint answered with a non-2xx status.
+type Result struct {
+	StatusCode int
+	Body       string
+	Duration   time.Duration
+}
+
+// Dispatcher POSTs signed event payloads to subscription URLs
+type Dispatcher struct {
+	client *http.Client
+}
+
+// New creates a dispatcher. Unless allowPrivate is set, callbacks to
+// loopback, private and link-local addresses are refused at connect time,
+// so a subscription cannot be pointed at the internal network, including
+// through DNS that changes after the URL was validated.
+func New(timeout time.Duration, allowPrivate bool) *Dispatcher {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !IsPublic(ip) {
+				return ErrPrivateTarget
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Dispatcher{
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			// A redirect is reported as the endpoint's answer rather than
+			// followed, so the signed payload only goes to the registered URL
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Send makes a callback. A response outside 2xx is an error.
+func (d *Dispatcher) Send(ctx context.Context, req *Request) (*Result, error) {
+	timestamp := time.Now().Unix()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "ecommerce-webhooks/1.0")
+	httpReq.Header.Set(HeaderDeliveryID, req.DeliveryID)
+	httpReq.Header.Set(HeaderEvent, req.EventType)
+	httpReq.Header.Set(HeaderSignature, fmt.Sprintf("t=%d,v1=%s", timestamp, Sign(req.Secret, timestamp, req.Payload)))
+
+	start := time.Now()
+	resp, err := d.client.Do(httpReq)
+	if err != nil {
+		return &Result{Duration: time.Since(start)}, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	result := &Result{
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+		Duration:   time.Since(start),
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return result, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return result, nil
+}
+
+// Sign computes the signature of a payload: the hex HMAC-SHA256, keyed with
+// the subscription secret, of the timestamp, a dot and the raw body.
+// Receivers recompute it from the X-Webhook-Signature timestamp to verify a
+// callback, and reject old timestamps to stop replays.
+func Sign(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// IsPublic reports whether ip is a publicly routable unicast address
+func IsPublic(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Delivery statuses
+const (
+	DeliveryStatusPending   = "pending" // queued, or waiting to be retried
+	DeliveryStatusSucceeded = "succeeded"
+	DeliveryStatusFailed    = "failed"
+)
+
+// Delivery is one event sent to one subscription. The payload is captured
+// when the delivery is queued, so retries and replays send the same body.
+type Delivery struct {
+	ID             uuid.UUID         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SubscriptionID uuid.UUID         `json:"subscription_id" gorm:"type:uuid;not null;index"`
+	DedupeKey      string            `json:"-" gorm:"not null;uniqueIndex"`
+	EventID        string            `json:"event_id" gorm:"not null;index"`
+	EventType      string            `json:"event_type" gorm:"not null"`
+	Payload        json.RawMessage   `json:"payload" gorm:"type:jsonb;serializer:json;not null"`
+	Status         string            `json:"status" gorm:"not null"`
+	Attempts       int               `json:"attempts"`
+	NextAttemptAt  *time.Time        `json:"next_attempt_at,omitempty"`
+	LastStatusCode int               `json:"last_status_code,omitempty"`
+	LastError      string            `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time        `json:"delivered_at,omitempty"`
+	ReplayOf       *uuid.UUID        `json:"replay_of,omitempty" gorm:"type:uuid"`
+	Log            []DeliveryAttempt `json:"log,omitempty" gorm:"foreignKey:DeliveryID"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+// DeliveryAttempt records one request made for a delivery
+type DeliveryAttempt struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	DeliveryID   uuid.UUID `json:"delivery_id" gorm:"type:uuid;not null;index"`
+	Attempt      int       `json:"attempt"`
+	StatusCode   int       `json:"status_code,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	ResponseBody string    `json:"response_body,omitempty"`
+	DurationMS   int64     `json:"duration_ms"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// DeliveryFilters represents filters for delivery queries
+type DeliveryFilters struct {
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	Status         string    `json:"status,omitempty"`
+	EventType      string    `json:"event_type,omitempty"`
+	EventID        string    `json:"event_id,omitempty"`
+	Limit          int       `json:"limit,omitempty"`
+	Offset         int       `json:"offset,omitempty"`
+}
+
+// DeliveryList represents a paginated list of deliveries
+type DeliveryList struct {
+	Deliveries []Delivery `json:"deliveries"`
+	Total      int64      `json:"total"`
+	Limit      int        `json:"limit"`
+	Offset     int        `json:"offset"`
+	HasMore    bool       `json:"has_more"`
+}
+
+// TableName returns the table name for Delivery
+func (Delivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// TableName returns the table name for DeliveryAttempt
+func (DeliveryAttempt) TableName() string {
+	return "webhook_delivery_attempts"
+}
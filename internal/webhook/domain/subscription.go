@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event types integrators can subscribe to
+const (
+	EventProductCreated = "product.created"
+	EventProductUpdated = "product.updated"
+	EventProductDeleted = "product.deleted"
+	EventStockLow       = "stock.low"
+	EventOrderCreated   = "order.created"
+
+	EventOrderClaimed    = "order.claimed"
+	EventOrderProcessing = "order.processing"
+	EventOrderShipped    = "order.shipped"
+	EventOrderDelivered  = "order.delivered"
+	EventOrderCancelled  = "order.cancelled"
+
+	EventReturnRequested = "return.requested"
+	EventReturnApproved  = "return.approved"
+	EventReturnRejected  = "return.rejected"
+	EventReturnReceived  = "return.received"
+	EventReturnRefunded  = "return.refunded"
+	EventReturnCancelled = "return.cancelled"
+)
+
+// Subscription is an integrator's callback URL and the events it receives.
+// Deliveries are signed with the subscription's secret.
+type Subscription struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OwnerID     string    `json:"owner_id" gorm:"not null;index"`
+	URL         string    `json:"url" gorm:"not null"`
+	Events      []string  `json:"events" gorm:"type:jsonb;serializer:json;not null"`
+	Description string    `json:"description,omitempty"`
+	Secret      string    `json:"secret,omitempty" gorm:"not null"`
+	IsActive    bool      `json:"is_active" gorm:"default:true"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CreateSubscriptionRequest represents the request to create a subscription
+type CreateSubscriptionRequest struct {
+	URL         string   `json:"url" validate:"required,url,max=2048"`
+	Events      []string `json:"events" validate:"required,min=1,dive,oneof=product.created product.updated product.deleted stock.low order.created order.claimed order.processing order.shipped order.delivered order.cancelled return.requested return.approved return.rejected return.received return.refunded return.cancelled"`
+	Description string   `json:"description" validate:"max=255"`
+}
+
+// UpdateSubscriptionRequest represents the request to update a subscription
+type UpdateSubscriptionRequest struct {
+	URL         *string  `json:"url,omitempty" validate:"omitempty,url,max=2048"`
+	Events      []string `json:"events,omitempty" validate:"omitempty,min=1,dive,oneof=product.created product.updated product.deleted stock.low order.created order.claimed order.processing order.shipped order.delivered order.cancelled return.requested return.approved return.rejected return.received return.refunded return.cancelled"`
+	Description *string  `json:"description,omitempty" validate:"omitempty,max=255"`
+	IsActive    *bool    `json:"is_active,omitempty"`
+}
+
+// SubscriptionFilters represents filters for subscription queries
+type SubscriptionFilters struct {
+	OwnerID string `json:"owner_id,omitempty"`
+	Limit   int    `json:"limit,omitempty"`
+	Offset  int    `json:"offset,omitempty"`
+}
+
+// SubscriptionList represents a paginated list of subscriptions
+type SubscriptionList struct {
+	Subscriptions []Subscription `json:"subscriptions"`
+	Total         int64          `json:"total"`
+	Limit         int            `json:"limit"`
+	Offset        int            `json:"offset"`
+	HasMore       bool           `json:"has_more"`
+}
+
+// TableName returns the table name for Subscription
+func (Subscription) TableName() string {
+	return "webhook_subscriptions"
+}
@@ -0,0 +1,309 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/webhook/domain"
+	"ecommerce/internal/webhook/service"
+	"ecommerce/pkg/database"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/events"
+	"ecommerce/pkg/response"
+)
+
+// HTTPHandler handles HTTP requests for webhook service
+type HTTPHandler struct {
+	service service.WebhookService
+	logger  *logrus.Logger
+}
+
+// NewHTTPHandler creates a new HTTP handler
+func NewHTTPHandler(service service.WebhookService, logger *logrus.Logger) *HTTPHandler {
+	return &HTTPHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers all HTTP routes
+func (h *HTTPHandler) RegisterRoutes(router *gin.Engine) {
+	api := router.Group("/api/v1")
+
+	// Event intake from other services
+	api.POST("/events", h.HandleEvent)
+
+	// Webhook routes
+	webhooks := api.Group("/webhooks")
+	{
+		webhooks.GET("", h.ListSubscriptions)
+		webhooks.POST("", h.CreateSubscription)
+		webhooks.GET("/deliveries/:id", h.GetDelivery)
+		webhooks.POST("/deliveries/:id/replay", h.ReplayDelivery)
+		webhooks.GET("/:id", h.GetSubscription)
+		webhooks.PUT("/:id", h.UpdateSubscription)
+		webhooks.DELETE("/:id", h.DeleteSubscription)
+		webhooks.POST("/:id/rotate-secret", h.RotateSecret)
+		webhooks.GET("/:id/deliveries", h.ListDeliveries)
+	}
+
+	// Health check
+	router.GET("/health", h.HealthCheck)
+	router.GET("/ready", h.ReadinessCheck)
+}
+
+// HandleEvent handles an event forwarded by another service
+func (h *HTTPHandler) HandleEvent(c *gin.Context) {
+	var event events.Event
+	if err := c.ShouldBindJSON(&event); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	queued, err := h.service.HandleEvent(c.Request.Context(), &event)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusAccepted, "Event accepted successfully", gin.H{
+		"queued": queued,
+	})
+}
+
+// CreateSubscription handles subscription creation
+func (h *HTTPHandler) CreateSubscription(c *gin.Context) {
+	var req domain.CreateSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	subscription, err := h.service.CreateSubscription(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Subscription created successfully", subscription)
+}
+
+// ListSubscriptions handles subscription listing
+func (h *HTTPHandler) ListSubscriptions(c *gin.Context) {
+	filters := &domain.SubscriptionFilters{
+		OwnerID: c.Query("owner_id"),
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			filters.Limit = l
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if o, err := strconv.Atoi(offset); err == nil {
+			filters.Offset = o
+		}
+	}
+
+	subscriptions, err := h.service.ListSubscriptions(c.Request.Context(), filters)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Subscriptions retrieved successfully", subscriptions)
+}
+
+// GetSubscription handles getting a single subscription
+func (h *HTTPHandler) GetSubscription(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid subscription ID", err)
+		return
+	}
+
+	subscription, err := h.service.GetSubscription(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Subscription retrieved successfully", subscription)
+}
+
+// UpdateSubscription handles subscription updates
+func (h *HTTPHandler) UpdateSubscription(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid subscription ID", err)
+		return
+	}
+
+	var req domain.UpdateSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Invalid request body")
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	subscription, err := h.service.UpdateSubscription(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Subscription updated successfully", subscription)
+}
+
+// DeleteSubscription handles subscription deletion
+func (h *HTTPHandler) DeleteSubscription(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid subscription ID", err)
+		return
+	}
+
+	if err := h.service.DeleteSubscription(c.Request.Context(), id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Subscription deleted successfully", nil)
+}
+
+// RotateSecret handles replacing a subscription's signing secret
+func (h *HTTPHandler) RotateSecret(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid subscription ID", err)
+		return
+	}
+
+	subscription, err := h.service.RotateSecret(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Subscription secret rotated successfully", subscription)
+}
+
+// ListDeliveries handles listing a subscription's delivery log
+func (h *HTTPHandler) ListDeliveries(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid subscription ID", err)
+		return
+	}
+
+	filters := &domain.DeliveryFilters{
+		SubscriptionID: id,
+		Status:         c.Query("status"),
+		EventType:      c.Query("event_type"),
+		EventID:        c.Query("event_id"),
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			filters.Limit = l
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if o, err := strconv.Atoi(offset); err == nil {
+			filters.Offset = o
+		}
+	}
+
+	deliveries, err := h.service.ListDeliveries(c.Request.Context(), filters)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Deliveries retrieved successfully", deliveries)
+}
+
+// GetDelivery handles getting a delivery with its attempt log
+func (h *HTTPHandler) GetDelivery(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid delivery ID", err)
+		return
+	}
+
+	delivery, err := h.service.GetDelivery(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Delivery retrieved successfully", delivery)
+}
+
+// ReplayDelivery handles sending a delivery again
+func (h *HTTPHandler) ReplayDelivery(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid delivery ID", err)
+		return
+	}
+
+	delivery, err := h.service.ReplayDelivery(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusAccepted, "Delivery queued for replay successfully", delivery)
+}
+
+// HealthCheck handles health check requests
+func (h *HTTPHandler) HealthCheck(c *gin.Context) {
+	response.Success(c, http.StatusOK, "Service is healthy", gin.H{
+		"service": "webhook-service",
+		"status":  "healthy",
+	})
+}
+
+// ReadinessCheck handles readiness check requests
+func (h *HTTPHandler) ReadinessCheck(c *gin.Context) {
+	response.Success(c, http.StatusOK, "Service is ready", gin.H{
+		"service": "webhook-service",
+		"status":  "ready",
+	})
+}
+
+// handleError handles service errors and converts them to appropriate HTTP responses
+func (h *HTTPHandler) handleError(c *gin.Context, err error) {
+	err = database.TranslateError(err)
+	switch {
+	case errors.IsNotFound(err):
+		response.Error(c, http.StatusNotFound, "Resource not found", err)
+	case errors.IsValidation(err):
+		response.Error(c, http.StatusBadRequest, "Validation failed", err)
+	case errors.IsConflict(err):
+		response.Error(c, http.StatusConflict, "Resource conflict", err)
+	case errors.IsUnauthorized(err):
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", err)
+	case errors.IsForbidden(err):
+		response.Error(c, http.StatusForbidden, "Forbidden", err)
+	default:
+		h.logger.WithError(err).Error("Internal server error")
+		response.Error(c, http.StatusInternalServerError, "Internal server error", nil)
+	}
+}
@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"ecommerce/internal/webhook/domain"
+	customErrors "ecommerce/pkg/errors"
+)
+
+// WebhookRepository defines the interface for webhook data operations
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription *domain.Subscription) error
+	GetSubscription(ctx context.Context, id uuid.UUID) (*domain.Subscription, error)
+	UpdateSubscription(ctx context.Context, subscription *domain.Subscription) error
+	DeleteSubscription(ctx context.Context, id uuid.UUID) error
+	ListSubscriptions(ctx context.Context, filters *domain.SubscriptionFilters) ([]domain.Subscription, int64, error)
+	ListSubscribers(ctx context.Context, eventType string) ([]domain.Subscription, error)
+
+	Enqueue(ctx context.Context, deliveries []domain.Delivery) (int64, error)
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]domain.Delivery, error)
+	GetDelivery(ctx context.Context, id uuid.UUID) (*domain.Delivery, error)
+	RecordAttempt(ctx context.Context, delivery *domain.Delivery, attempt *domain.DeliveryAttempt) error
+	ListDeliveries(ctx context.Context, filters *domain.DeliveryFilters) ([]domain.Delivery, int64, error)
+}
+
+type webhookRepository struct {
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(db *gorm.DB, logger *logrus.Logger) WebhookRepository {
+	return &webhookRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *webhookRepository) CreateSubscription(ctx context.Context, subscription *domain.Subscription) error {
+	if err := r.db.WithContext(ctx).Create(subscription).Error; err != nil {
+		return fmt.Errorf("failed to create subscription: %w", err)
+	}
+	return nil
+}
+
+func (r *webhookRepository) GetSubscription(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
+	var subscription domain.Subscription
+	err := r.db.WithContext(ctx).First(&subscription, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Subscription not found", err).WithCode(customErrors.CodeSubscriptionNotFound)
+		}
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+
+	return &subscription, nil
+}
+
+func (r *webhookRepository) UpdateSubscription(ctx context.Context, subscription *domain.Subscription) error {
+	if err := r.db.WithContext(ctx).Save(subscription).Error; err != nil {
+		return fmt.Errorf("failed to update subscription: %w", err)
+	}
+	return nil
+}
+
+// DeleteSubscription deletes a subscription along with its delivery history
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Delete(&domain.Subscription{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete subscription: %w", err)
+	}
+	return nil
+}
+
+func (r *webhookRepository) ListSubscriptions(ctx context.Context, filters *domain.SubscriptionFilters) ([]domain.Subscription, int64, error) {
+	query := r.db.WithContext(ctx).Model(&domain.Subscription{})
+
+	if filters.OwnerID != "" {
+		query = query.Where("owner_id = ?", filters.OwnerID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count subscriptions: %w", err)
+	}
+
+	var subscriptions []domain.Subscription
+	err := query.
+		Order("created_at DESC").
+		Limit(filters.Limit).
+		Offset(filters.Offset).
+		Find(&subscriptions).Error
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+
+	return subscriptions, total, nil
+}
+
+// ListSubscribers returns the active subscriptions to an event type
+func (r *webhookRepository) ListSubscribers(ctx context.Context, eventType string) ([]domain.Subscription, error) {
+	match, err := json.Marshal([]string{eventType})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event type: %w", err)
+	}
+
+	var subscriptions []domain.Subscription
+	err = r.db.WithContext(ctx).
+		Where("is_active = ? AND events @> ?::jsonb", true, string(match)).
+		Find(&subscriptions).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscribers: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+// Enqueue queues deliveries, skipping any whose dedupe key is already
+// queued, and reports how many were queued
+func (r *webhookRepository) Enqueue(ctx context.Context, deliveries []domain.Delivery) (int64, error) {
+	if len(deliveries) == 0 {
+		return 0, nil
+	}
+
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "dedupe_key"}},
+			DoNothing: true,
+		}).
+		Create(&deliveries)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to enqueue deliveries: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// ClaimDue leases pending deliveries that are due by pushing their next
+// attempt out to leaseUntil. Rows locked by another worker are skipped, so
+// several service instances can drain the queue without calling twice, and
+// a worker that dies mid-call releases its claim when the lease runs out.
+func (r *webhookRepository) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]domain.Delivery, error) {
+	var deliveries []domain.Delivery
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE webhook_deliveries SET next_attempt_at = ?, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		leaseUntil, now, domain.DeliveryStatusPending, now, limit,
+	).Scan(&deliveries).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// GetDelivery returns a delivery with its attempts, oldest first
+func (r *webhookRepository) GetDelivery(ctx context.Context, id uuid.UUID) (*domain.Delivery, error) {
+	var delivery domain.Delivery
+	err := r.db.WithContext(ctx).
+		Preload("Log", func(db *gorm.DB) *gorm.DB {
+			return db.Order("attempt ASC")
+		}).
+		First(&delivery, "id = ?", id).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, customErrors.NewNotFoundError("Delivery not found", err).WithCode(customErrors.CodeDeliveryNotFound)
+		}
+		return nil, fmt.Errorf("failed to get delivery: %w", err)
+	}
+
+	return &delivery, nil
+}
+
+// RecordAttempt logs an attempt and saves the delivery's resulting state
+// in one transaction
+func (r *webhookRepository) RecordAttempt(ctx context.Context, delivery *domain.Delivery, attempt *domain.DeliveryAttempt) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if attempt != nil {
+			if err := tx.Create(attempt).Error; err != nil {
+				return fmt.Errorf("failed to record delivery attempt: %w", err)
+			}
+		}
+		if err := tx.Omit(clause.Associations).Save(delivery).Error; err != nil {
+			return fmt.Errorf("failed to update delivery: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *webhookRepository) ListDeliveries(ctx context.Context, filters *domain.DeliveryFilters) ([]domain.Delivery, int64, error) {
+	query := r.db.WithContext(ctx).Model(&domain.Delivery{}).
+		Where("subscription_id = ?", filters.SubscriptionID)
+
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.EventType != "" {
+		query = query.Where("event_type = ?", filters.EventType)
+	}
+	if filters.EventID != "" {
+		query = query.Where("event_id = ?", filters.EventID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count deliveries: %w", err)
+	}
+
+	var deliveries []domain.Delivery
+	err := query.
+		Order("created_at DESC").
+		Limit(filters.Limit).
+		Offset(filters.Offset).
+		Find(&deliveries).Error
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list deliveries: %w", err)
+	}
+
+	return deliveries, total, nil
+}
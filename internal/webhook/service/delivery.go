@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/webhook/dispatcher"
+	"ecommerce/internal/webhook/domain"
+	"ecommerce/pkg/errors"
+)
+
+// maxRetryBackoff caps the delay between delivery attempts
+const maxRetryBackoff = 6 * time.Hour
+
+// DeliverDue makes the callbacks that are due and reports how many
+// succeeded. Failed callbacks are retried with exponential backoff until the
+// attempts run out.
+func (s *webhookService) DeliverDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	// The lease outlasts a callback, so a claim is only picked up again if
+	// this worker dies before recording the outcome
+	lease := now.Add(2 * time.Duration(s.delivery.Timeout) * time.Second)
+
+	deliveries, err := s.repo.ClaimDue(ctx, now, lease, s.delivery.BatchSize)
+	if err != nil {
+		return 0, errors.NewInternalError("Failed to claim deliveries", err)
+	}
+
+	delivered := 0
+	for i := range deliveries {
+		if s.deliver(ctx, &deliveries[i]) {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// deliver makes one callback for a delivery and records the outcome
+func (s *webhookService) deliver(ctx context.Context, delivery *domain.Delivery) bool {
+	logger := s.log(ctx).WithFields(logrus.Fields{
+		"delivery_id":     delivery.ID,
+		"subscription_id": delivery.SubscriptionID,
+	})
+
+	subscription, err := s.repo.GetSubscription(ctx, delivery.SubscriptionID)
+	if err != nil && !errors.IsNotFound(err) {
+		// Left claimed; the lease runs out and the delivery is tried again
+		logger.WithError(err).Error("Failed to get subscription")
+		return false
+	}
+	if subscription == nil || !subscription.IsActive {
+		delivery.Status = domain.DeliveryStatusFailed
+		delivery.NextAttemptAt = nil
+		delivery.LastError = "Subscription is inactive"
+		s.record(ctx, delivery, nil, logger)
+		return false
+	}
+
+	delivery.Attempts++
+	result, err := s.dispatcher.Send(ctx, &dispatcher.Request{
+		URL:        subscription.URL,
+		Secret:     subscription.Secret,
+		DeliveryID: delivery.ID.String(),
+		EventType:  delivery.EventType,
+		Payload:    delivery.Payload,
+	})
+
+	attempt := &domain.DeliveryAttempt{
+		DeliveryID: delivery.ID,
+		Attempt:    delivery.Attempts,
+	}
+	if result != nil {
+		attempt.StatusCode = result.StatusCode
+		attempt.ResponseBody = result.Body
+		attempt.DurationMS = result.Duration.Milliseconds()
+	}
+	delivery.LastStatusCode = attempt.StatusCode
+
+	if err != nil {
+		attempt.Error = err.Error()
+		delivery.LastError = err.Error()
+		if delivery.Attempts >= s.delivery.MaxAttempts {
+			delivery.Status = domain.DeliveryStatusFailed
+			delivery.NextAttemptAt = nil
+			logger.WithError(err).Error("Webhook delivery failed")
+		} else {
+			next := time.Now().Add(retryBackoff(s.delivery.RetryBackoff, delivery.Attempts))
+			delivery.NextAttemptAt = &next
+			logger.WithError(err).WithField("attempt", delivery.Attempts).Warn("Webhook delivery will be retried")
+		}
+		s.record(ctx, delivery, attempt, logger)
+		return false
+	}
+
+	now := time.Now()
+	delivery.Status = domain.DeliveryStatusSucceeded
+	delivery.DeliveredAt = &now
+	delivery.NextAttemptAt = nil
+	delivery.LastError = ""
+	s.record(ctx, delivery, attempt, logger)
+
+	logger.Info("Webhook delivered successfully")
+	return true
+}
+
+// record saves a delivery outcome. If it cannot be saved the claim's lease
+// expires and the callback is made again, which receivers tolerate since
+// deliveries are at least once.
+func (s *webhookService) record(ctx context.Context, delivery *domain.Delivery, attempt *domain.DeliveryAttempt, logger *logrus.Entry) {
+	if err := s.repo.RecordAttempt(context.WithoutCancel(ctx), delivery, attempt); err != nil {
+		logger.WithError(err).Error("Failed to record webhook delivery")
+	}
+}
+
+// GetDelivery returns a delivery with its attempt log
+func (s *webhookService) GetDelivery(ctx context.Context, id uuid.UUID) (*domain.Delivery, error) {
+	if err := requireIntegrator(ctx); err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.repo.GetDelivery(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Delivery not found", err).WithCode(errors.CodeDeliveryNotFound)
+		}
+		s.log(ctx).WithError(err).Error("Failed to get delivery")
+		return nil, errors.NewInternalError("Failed to get delivery", err)
+	}
+
+	// Deliveries are visible to whoever may see their subscription
+	if _, err := s.subscriptionFor(ctx, delivery.SubscriptionID); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Delivery not found", nil).WithCode(errors.CodeDeliveryNotFound)
+		}
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+func (s *webhookService) ListDeliveries(ctx context.Context, filters *domain.DeliveryFilters) (*domain.DeliveryList, error) {
+	if _, err := s.subscriptionFor(ctx, filters.SubscriptionID); err != nil {
+		return nil, err
+	}
+
+	// Set default values
+	if filters.Limit <= 0 {
+		filters.Limit = 20
+	}
+	if filters.Limit > 100 {
+		filters.Limit = 100
+	}
+
+	deliveries, total, err := s.repo.ListDeliveries(ctx, filters)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list deliveries")
+		return nil, errors.NewInternalError("Failed to list deliveries", err)
+	}
+
+	return &domain.DeliveryList{
+		Deliveries: deliveries,
+		Total:      total,
+		Limit:      filters.Limit,
+		Offset:     filters.Offset,
+		HasMore:    int64(filters.Offset+filters.Limit) < total,
+	}, nil
+}
+
+// ReplayDelivery queues a delivery's payload to be sent again to its
+// subscription's current URL. The replay is a new delivery, so the original
+// keeps its log; receivers see the same event ID under a new X-Webhook-ID.
+func (s *webhookService) ReplayDelivery(ctx context.Context, id uuid.UUID) (*domain.Delivery, error) {
+	original, err := s.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription, err := s.subscriptionFor(ctx, original.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if !subscription.IsActive {
+		return nil, errors.NewConflictError("Deliveries cannot be replayed to an inactive subscription", nil).WithCode(errors.CodeSubscriptionInactive)
+	}
+
+	now := time.Now()
+	deliveries := []domain.Delivery{{
+		SubscriptionID: original.SubscriptionID,
+		DedupeKey:      "replay:" + uuid.New().String(),
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		Status:         domain.DeliveryStatusPending,
+		NextAttemptAt:  &now,
+		ReplayOf:       &original.ID,
+	}}
+
+	if _, err := s.repo.Enqueue(ctx, deliveries); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to replay delivery")
+		return nil, errors.NewInternalError("Failed to replay delivery", err)
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"delivery_id": deliveries[0].ID,
+		"replay_of":   original.ID,
+	}).Info("Delivery replayed successfully")
+	return &deliveries[0], nil
+}
+
+// retryBackoff is the delay before the next attempt: the base delay doubled
+// for each attempt already made, capped at maxRetryBackoff
+func retryBackoff(baseSeconds, attempts int) time.Duration {
+	backoff := time.Duration(baseSeconds) * time.Second
+	for i := 1; i < attempts && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/webhook/domain"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/events"
+)
+
+// HandleEvent queues a delivery of an event to every active subscription
+// to its type and reports how many were queued. Events are forwarded at
+// least once, so deliveries are deduplicated per subscription and event.
+func (s *webhookService) HandleEvent(ctx context.Context, event *events.Event) (int64, error) {
+	if event.ID == "" || event.Type == "" {
+		return 0, errors.NewValidationError("Event ID and type are required", nil)
+	}
+
+	logger := s.log(ctx).WithFields(logrus.Fields{
+		"event_id":   event.ID,
+		"event_type": event.Type,
+	})
+
+	subscriptions, err := s.repo.ListSubscribers(ctx, event.Type)
+	if err != nil {
+		logger.WithError(err).Error("Failed to list subscribers")
+		return 0, errors.NewInternalError("Failed to list subscribers", err)
+	}
+	if len(subscriptions) == 0 {
+		return 0, nil
+	}
+
+	// Integrators receive the event envelope as published
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return 0, errors.NewValidationError("Invalid event payload", err)
+	}
+
+	now := time.Now()
+	deliveries := make([]domain.Delivery, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		deliveries = append(deliveries, domain.Delivery{
+			SubscriptionID: subscription.ID,
+			DedupeKey:      fmt.Sprintf("%s:%s", subscription.ID, event.ID),
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        payload,
+			Status:         domain.DeliveryStatusPending,
+			NextAttemptAt:  &now,
+		})
+	}
+
+	queued, err := s.repo.Enqueue(ctx, deliveries)
+	if err != nil {
+		logger.WithError(err).Error("Failed to queue deliveries")
+		return 0, errors.NewInternalError("Failed to queue deliveries", err)
+	}
+
+	if queued > 0 {
+		logger.WithField("count", queued).Info("Webhook deliveries queued successfully")
+	}
+	return queued, nil
+}
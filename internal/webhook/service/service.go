@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/webhook/config"
+	"ecommerce/internal/webhook/dispatcher"
+	"ecommerce/internal/webhook/domain"
+	"ecommerce/internal/webhook/repository"
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/events"
+	"ecommerce/pkg/logger"
+	"ecommerce/pkg/validator"
+)
+
+// WebhookService defines the webhook service interface
+type WebhookService interface {
+	CreateSubscription(ctx context.Context, req *domain.CreateSubscriptionRequest) (*domain.Subscription, error)
+	GetSubscription(ctx context.Context, id uuid.UUID) (*domain.Subscription, error)
+	UpdateSubscription(ctx context.Context, id uuid.UUID, req *domain.UpdateSubscriptionRequest) (*domain.Subscription, error)
+	DeleteSubscription(ctx context.Context, id uuid.UUID) error
+	ListSubscriptions(ctx context.Context, filters *domain.SubscriptionFilters) (*domain.SubscriptionList, error)
+	RotateSecret(ctx context.Context, id uuid.UUID) (*domain.Subscription, error)
+
+	HandleEvent(ctx context.Context, event *events.Event) (int64, error)
+	DeliverDue(ctx context.Context) (int, error)
+	GetDelivery(ctx context.Context, id uuid.UUID) (*domain.Delivery, error)
+	ListDeliveries(ctx context.Context, filters *domain.DeliveryFilters) (*domain.DeliveryList, error)
+	ReplayDelivery(ctx context.Context, id uuid.UUID) (*domain.Delivery, error)
+}
+
+type webhookService struct {
+	repo       repository.WebhookRepository
+	dispatcher *dispatcher.Dispatcher
+	delivery   config.DeliveryConfig
+	targets    config.TargetsConfig
+	logger     *logrus.Logger
+	validator  *validator.Validator
+}
+
+// NewWebhookService creates a new webhook service
+func NewWebhookService(repo repository.WebhookRepository, dispatcher *dispatcher.Dispatcher, delivery config.DeliveryConfig, targets config.TargetsConfig, logger *logrus.Logger) WebhookService {
+	return &webhookService{
+		repo:       repo,
+		dispatcher: dispatcher,
+		delivery:   delivery,
+		targets:    targets,
+		logger:     logger,
+		validator:  validator.New(),
+	}
+}
+
+// log returns the logger of the request ctx belongs to
+func (s *webhookService) log(ctx context.Context) *logrus.Entry {
+	return logger.FromContext(ctx, s.logger)
+}
+
+// CreateSubscription registers a callback URL. The response is the only
+// time the signing secret is returned, apart from rotating it.
+func (s *webhookService) CreateSubscription(ctx context.Context, req *domain.CreateSubscriptionRequest) (*domain.Subscription, error) {
+	if err := requireIntegrator(ctx); err != nil {
+		return nil, err
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid create subscription request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+	if err := s.validateURL(req.URL); err != nil {
+		return nil, err
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return nil, errors.NewInternalError("Failed to generate signing secret", err)
+	}
+
+	subscription := &domain.Subscription{
+		OwnerID:     auth.ActorID(ctx),
+		URL:         req.URL,
+		Events:      uniqueEvents(req.Events),
+		Description: req.Description,
+		Secret:      secret,
+		IsActive:    true,
+	}
+
+	if err := s.repo.CreateSubscription(ctx, subscription); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to create subscription")
+		return nil, errors.NewInternalError("Failed to create subscription", err)
+	}
+
+	s.log(ctx).WithField("subscription_id", subscription.ID).Info("Subscription created successfully")
+	return subscription, nil
+}
+
+func (s *webhookService) GetSubscription(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
+	subscription, err := s.subscriptionFor(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return redact(subscription), nil
+}
+
+func (s *webhookService) UpdateSubscription(ctx context.Context, id uuid.UUID, req *domain.UpdateSubscriptionRequest) (*domain.Subscription, error) {
+	subscription, err := s.subscriptionFor(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		s.log(ctx).WithError(err).Error("Invalid update subscription request")
+		return nil, errors.NewValidationError("Invalid request", err)
+	}
+
+	if req.URL != nil {
+		if err := s.validateURL(*req.URL); err != nil {
+			return nil, err
+		}
+		subscription.URL = *req.URL
+	}
+	if req.Events != nil {
+		subscription.Events = uniqueEvents(req.Events)
+	}
+	if req.Description != nil {
+		subscription.Description = *req.Description
+	}
+	if req.IsActive != nil {
+		subscription.IsActive = *req.IsActive
+	}
+
+	if err := s.repo.UpdateSubscription(ctx, subscription); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to update subscription")
+		return nil, errors.NewInternalError("Failed to update subscription", err)
+	}
+
+	s.log(ctx).WithField("subscription_id", subscription.ID).Info("Subscription updated successfully")
+	return redact(subscription), nil
+}
+
+func (s *webhookService) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.subscriptionFor(ctx, id); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteSubscription(ctx, id); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to delete subscription")
+		return errors.NewInternalError("Failed to delete subscription", err)
+	}
+
+	s.log(ctx).WithField("subscription_id", id).Info("Subscription deleted successfully")
+	return nil
+}
+
+// ListSubscriptions lists the caller's subscriptions. Admins see every
+// subscription unless they filter by owner.
+func (s *webhookService) ListSubscriptions(ctx context.Context, filters *domain.SubscriptionFilters) (*domain.SubscriptionList, error) {
+	if err := requireIntegrator(ctx); err != nil {
+		return nil, err
+	}
+	if !auth.HasRole(ctx, auth.RoleAdmin) {
+		filters.OwnerID = auth.ActorID(ctx)
+	}
+
+	// Set default values
+	if filters.Limit <= 0 {
+		filters.Limit = 20
+	}
+	if filters.Limit > 100 {
+		filters.Limit = 100
+	}
+
+	subscriptions, total, err := s.repo.ListSubscriptions(ctx, filters)
+	if err != nil {
+		s.log(ctx).WithError(err).Error("Failed to list subscriptions")
+		return nil, errors.NewInternalError("Failed to list subscriptions", err)
+	}
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+
+	return &domain.SubscriptionList{
+		Subscriptions: subscriptions,
+		Total:         total,
+		Limit:         filters.Limit,
+		Offset:        filters.Offset,
+		HasMore:       int64(filters.Offset+filters.Limit) < total,
+	}, nil
+}
+
+// RotateSecret replaces a subscription's signing secret. Callbacks already
+// in flight may still carry the old signature.
+func (s *webhookService) RotateSecret(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
+	subscription, err := s.subscriptionFor(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return nil, errors.NewInternalError("Failed to generate signing secret", err)
+	}
+	subscription.Secret = secret
+
+	if err := s.repo.UpdateSubscription(ctx, subscription); err != nil {
+		s.log(ctx).WithError(err).Error("Failed to rotate subscription secret")
+		return nil, errors.NewInternalError("Failed to rotate subscription secret", err)
+	}
+
+	s.log(ctx).WithField("subscription_id", subscription.ID).Info("Subscription secret rotated successfully")
+	return subscription, nil
+}
+
+// subscriptionFor returns a subscription the caller may manage. Other
+// integrators' subscriptions are reported as not found.
+func (s *webhookService) subscriptionFor(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
+	if err := requireIntegrator(ctx); err != nil {
+		return nil, err
+	}
+
+	subscription, err := s.repo.GetSubscription(ctx, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, errors.NewNotFoundError("Subscription not found", err).WithCode(errors.CodeSubscriptionNotFound)
+		}
+		s.log(ctx).WithError(err).Error("Failed to get subscription")
+		return nil, errors.NewInternalError("Failed to get subscription", err)
+	}
+
+	if !auth.HasRole(ctx, auth.RoleAdmin) && subscription.OwnerID != auth.ActorID(ctx) {
+		return nil, errors.NewNotFoundError("Subscription not found", nil).WithCode(errors.CodeSubscriptionNotFound)
+	}
+
+	return subscription, nil
+}
+
+// validateURL checks that a callback URL is one the service may call
+func (s *webhookService) validateURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return errors.NewValidationError("Invalid callback URL", err)
+	}
+	if u.User != nil {
+		return errors.NewValidationError("Callback URL must not contain credentials", nil)
+	}
+
+	switch u.Scheme {
+	case "https":
+	case "http":
+		if !s.targets.AllowHTTP {
+			return errors.NewValidationError("Callback URL must use https", nil)
+		}
+	default:
+		return errors.NewValidationError("Callback URL must use https", nil)
+	}
+
+	// Hostnames are checked again when the dispatcher connects
+	if !s.targets.AllowPrivate {
+		host := strings.ToLower(u.Hostname())
+		ip := net.ParseIP(host)
+		if host == "localhost" || strings.HasSuffix(host, ".localhost") || (ip != nil && !dispatcher.IsPublic(ip)) {
+			return errors.NewValidationError("Callback URL must be publicly routable", nil)
+		}
+	}
+
+	return nil
+}
+
+// requireIntegrator checks that the caller may manage webhooks
+func requireIntegrator(ctx context.Context) error {
+	if auth.HasRole(ctx, auth.RoleAdmin) || auth.HasRole(ctx, auth.RoleIntegrator) {
+		return nil
+	}
+	return errors.NewForbiddenError("Managing webhooks requires the integrator role", nil)
+}
+
+// redact returns a copy of a subscription without its signing secret
+func redact(subscription *domain.Subscription) *domain.Subscription {
+	redacted := *subscription
+	redacted.Secret = ""
+	return &redacted
+}
+
+// newSecret generates a random signing secret
+func newSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// uniqueEvents drops repeated event types, keeping the first occurrence
+func uniqueEvents(eventTypes []string) []string {
+	seen := make(map[string]bool, len(eventTypes))
+	unique := make([]string, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		if !seen[eventType] {
+			seen[eventType] = true
+			unique = append(unique, eventType)
+		}
+	}
+	return unique
+}
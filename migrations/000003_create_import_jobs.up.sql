@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS import_jobs (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    filename       TEXT,
+    status         TEXT NOT NULL DEFAULT 'pending',
+    total_rows     INTEGER NOT NULL DEFAULT 0,
+    processed_rows INTEGER NOT NULL DEFAULT 0,
+    succeeded_rows INTEGER NOT NULL DEFAULT 0,
+    failed_rows    INTEGER NOT NULL DEFAULT 0,
+    errors         JSONB,
+    message        TEXT,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at   TIMESTAMPTZ
+);
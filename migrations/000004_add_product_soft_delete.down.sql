@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_products_sku_live;
+ALTER TABLE products ADD CONSTRAINT products_sku_key UNIQUE (sku);
+
+DROP INDEX IF EXISTS idx_products_deleted_at;
+ALTER TABLE products DROP COLUMN IF EXISTS deleted_at;
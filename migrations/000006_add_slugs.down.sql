@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS slug_redirects;
+
+DROP INDEX IF EXISTS idx_categories_slug;
+DROP INDEX IF EXISTS idx_products_slug;
+
+ALTER TABLE categories DROP COLUMN IF EXISTS slug;
+ALTER TABLE products DROP COLUMN IF EXISTS slug;
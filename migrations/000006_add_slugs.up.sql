@@ -0,0 +1,53 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS slug VARCHAR(255);
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS slug VARCHAR(255);
+
+-- Backfill existing rows; duplicates after the first get an id-derived suffix
+UPDATE products p
+SET slug = s.slug
+FROM (
+    SELECT id,
+           CASE WHEN rn = 1 THEN base ELSE base || '-' || left(id::text, 8) END AS slug
+    FROM (
+        SELECT id, base, ROW_NUMBER() OVER (PARTITION BY base ORDER BY created_at, id) AS rn
+        FROM (
+            SELECT id, created_at,
+                   COALESCE(NULLIF(left(trim(BOTH '-' FROM regexp_replace(lower(name), '[^a-z0-9]+', '-', 'g')), 200), ''), 'product') AS base
+            FROM products
+        ) b
+    ) r
+) s
+WHERE p.id = s.id AND p.slug IS NULL;
+
+UPDATE categories c
+SET slug = s.slug
+FROM (
+    SELECT id,
+           CASE WHEN rn = 1 THEN base ELSE base || '-' || left(id::text, 8) END AS slug
+    FROM (
+        SELECT id, base, ROW_NUMBER() OVER (PARTITION BY base ORDER BY created_at, id) AS rn
+        FROM (
+            SELECT id, created_at,
+                   COALESCE(NULLIF(left(trim(BOTH '-' FROM regexp_replace(lower(name), '[^a-z0-9]+', '-', 'g')), 200), ''), 'category') AS base
+            FROM categories
+        ) b
+    ) r
+) s
+WHERE c.id = s.id AND c.slug IS NULL;
+
+ALTER TABLE products ALTER COLUMN slug SET NOT NULL;
+ALTER TABLE categories ALTER COLUMN slug SET NOT NULL;
+
+-- Slugs stay reserved by soft-deleted products so restores never collide
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_slug ON products (slug);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_slug ON categories (slug);
+
+CREATE TABLE IF NOT EXISTS slug_redirects (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    entity_type TEXT NOT NULL,
+    entity_id   UUID NOT NULL,
+    slug        VARCHAR(255) NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (entity_type, slug)
+);
+
+CREATE INDEX IF NOT EXISTS idx_slug_redirects_entity ON slug_redirects (entity_type, entity_id);
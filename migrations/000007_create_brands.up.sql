@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS brands (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name        TEXT NOT NULL UNIQUE,
+    description TEXT,
+    logo_url    TEXT,
+    is_active   BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS brand_id UUID REFERENCES brands (id);
+
+CREATE INDEX IF NOT EXISTS idx_products_brand_id ON products (brand_id);
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS attribute_definitions (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    category_id UUID NOT NULL REFERENCES categories (id) ON DELETE CASCADE,
+    key         TEXT NOT NULL,
+    name        TEXT NOT NULL,
+    type        TEXT NOT NULL,
+    unit        TEXT,
+    required    BOOLEAN NOT NULL DEFAULT FALSE,
+    options     JSONB,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (category_id, key)
+);
+
+CREATE TABLE IF NOT EXISTS product_attributes (
+    product_id UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    key        TEXT NOT NULL,
+    value      TEXT NOT NULL,
+    unit       TEXT,
+    type       TEXT NOT NULL,
+    PRIMARY KEY (product_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_attributes_key_value ON product_attributes (key, value);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS product_relations (
+    product_id UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    related_id UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    type       TEXT NOT NULL,
+    position   INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (product_id, related_id, type),
+    CHECK (product_id <> related_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_relations_lookup ON product_relations (product_id, type, position);
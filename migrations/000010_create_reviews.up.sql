@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS reviews (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id      UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    user_id         TEXT NOT NULL,
+    rating          SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    title           TEXT,
+    body            TEXT,
+    status          TEXT NOT NULL DEFAULT 'pending',
+    moderation_note TEXT,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (product_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reviews_product_status ON reviews (product_id, status, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_reviews_status ON reviews (status, created_at);
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS rating_average NUMERIC(3, 2) NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS review_count INTEGER NOT NULL DEFAULT 0;
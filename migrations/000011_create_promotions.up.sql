@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS promotions (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name         TEXT NOT NULL,
+    description  TEXT,
+    type         TEXT NOT NULL CHECK (type IN ('percentage', 'fixed', 'buy_x_get_y')),
+    value        NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    buy_quantity INTEGER NOT NULL DEFAULT 0,
+    get_quantity INTEGER NOT NULL DEFAULT 0,
+    min_subtotal NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    scope        TEXT NOT NULL DEFAULT 'all' CHECK (scope IN ('all', 'products', 'categories')),
+    product_ids  JSONB,
+    category_ids JSONB,
+    coupon_code  TEXT UNIQUE,
+    usage_limit  INTEGER NOT NULL DEFAULT 0,
+    usage_count  INTEGER NOT NULL DEFAULT 0,
+    priority     INTEGER NOT NULL DEFAULT 0,
+    stackable    BOOLEAN NOT NULL DEFAULT FALSE,
+    is_active    BOOLEAN NOT NULL DEFAULT TRUE,
+    starts_at    TIMESTAMPTZ,
+    ends_at      TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (usage_limit = 0 OR usage_count <= usage_limit)
+);
+
+CREATE INDEX IF NOT EXISTS idx_promotions_automatic ON promotions (priority DESC) WHERE is_active AND coupon_code IS NULL;
+
+CREATE TABLE IF NOT EXISTS promotion_redemptions (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    promotion_id UUID NOT NULL REFERENCES promotions (id),
+    order_id     TEXT NOT NULL,
+    customer_id  TEXT,
+    discount     NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (promotion_id, order_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_promotion_redemptions_order ON promotion_redemptions (order_id);
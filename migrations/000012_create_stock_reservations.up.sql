@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    reference  TEXT NOT NULL,
+    product_id UUID NOT NULL REFERENCES products (id),
+    quantity   INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price NUMERIC(10, 2) NOT NULL,
+    status     TEXT NOT NULL DEFAULT 'reserved',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (reference, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_reserved ON stock_reservations (created_at) WHERE status = 'reserved';
@@ -0,0 +1,47 @@
+CREATE TABLE IF NOT EXISTS orders (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    customer_id TEXT NOT NULL,
+    status      TEXT NOT NULL,
+    currency    TEXT NOT NULL,
+    subtotal    NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    total       NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    payment_id  TEXT,
+    checkout_id UUID,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_customer ON orders (customer_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS order_items (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id   UUID NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    product_id UUID NOT NULL,
+    sku        TEXT,
+    name       TEXT,
+    quantity   INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price NUMERIC(10, 2) NOT NULL,
+    total      NUMERIC(12, 2) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_items_order ON order_items (order_id);
+
+CREATE TABLE IF NOT EXISTS checkouts (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    idempotency_key TEXT NOT NULL UNIQUE,
+    request_hash    TEXT NOT NULL,
+    customer_id     TEXT NOT NULL,
+    status          TEXT NOT NULL,
+    step            TEXT,
+    attempt         INTEGER NOT NULL DEFAULT 1,
+    reference       TEXT,
+    payment_id      TEXT,
+    order_id        UUID REFERENCES orders (id),
+    error           TEXT,
+    compensated     BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_checkouts_unsettled ON checkouts (updated_at)
+    WHERE status = 'pending' OR (status = 'failed' AND NOT compensated);
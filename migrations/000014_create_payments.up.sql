@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS payments (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    reference       TEXT NOT NULL UNIQUE,
+    customer_id     TEXT,
+    provider        TEXT NOT NULL,
+    provider_ref    TEXT,
+    amount          NUMERIC(10, 2) NOT NULL CHECK (amount > 0),
+    currency        TEXT NOT NULL,
+    status          TEXT NOT NULL CHECK (status IN ('pending', 'authorized', 'captured', 'refunded', 'voided', 'failed')),
+    amount_captured NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    amount_refunded NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    failure_reason  TEXT,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (amount_captured <= amount),
+    CHECK (amount_refunded >= 0 AND amount_refunded <= amount_captured)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_provider_ref ON payments (provider_ref) WHERE provider_ref IS NOT NULL AND provider_ref <> '';
+CREATE INDEX IF NOT EXISTS idx_payments_customer ON payments (customer_id);
+
+CREATE TABLE IF NOT EXISTS payment_refunds (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id   UUID NOT NULL REFERENCES payments (id),
+    provider_ref TEXT,
+    amount       NUMERIC(10, 2) NOT NULL CHECK (amount > 0),
+    reason       TEXT,
+    status       TEXT NOT NULL CHECK (status IN ('pending', 'succeeded', 'failed')),
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_refunds_payment ON payment_refunds (payment_id);
+CREATE INDEX IF NOT EXISTS idx_payment_refunds_provider_ref ON payment_refunds (provider_ref);
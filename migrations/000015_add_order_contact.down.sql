@@ -0,0 +1,2 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS phone;
+ALTER TABLE orders DROP COLUMN IF EXISTS email;
@@ -0,0 +1,63 @@
+CREATE TABLE IF NOT EXISTS notification_templates (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name       TEXT NOT NULL,
+    channel    TEXT NOT NULL CHECK (channel IN ('email', 'sms')),
+    subject    TEXT,
+    body       TEXT NOT NULL,
+    is_active  BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT idx_notification_templates_name_channel UNIQUE (name, channel)
+);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    dedupe_key      TEXT NOT NULL UNIQUE,
+    event_id        TEXT,
+    event_type      TEXT,
+    channel         TEXT NOT NULL CHECK (channel IN ('email', 'sms')),
+    template        TEXT NOT NULL,
+    recipient       TEXT NOT NULL,
+    subject         TEXT,
+    body            TEXT NOT NULL,
+    status          TEXT NOT NULL CHECK (status IN ('pending', 'sent', 'delivered', 'failed')),
+    provider        TEXT,
+    provider_ref    TEXT,
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ,
+    last_error      TEXT,
+    sent_at         TIMESTAMPTZ,
+    delivered_at    TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_due ON notifications (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_notifications_event ON notifications (event_id);
+CREATE INDEX IF NOT EXISTS idx_notifications_recipient ON notifications (recipient, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_notifications_provider_ref ON notifications (provider, provider_ref);
+
+INSERT INTO notification_templates (name, channel, subject, body) VALUES
+(
+    'order_confirmation',
+    'email',
+    'Your order {{.Order.ID}} is confirmed',
+    '<p>Thank you for your order!</p>
+<table>
+{{range .Order.Items}}<tr><td>{{.Quantity}} &times; {{.Name}}</td><td>{{printf "%.2f" .Total}}</td></tr>
+{{end}}</table>
+<p>Total: {{printf "%.2f" .Order.Total}} {{.Order.Currency}}</p>'
+),
+(
+    'order_confirmation',
+    'sms',
+    NULL,
+    'Your order {{.Order.ID}} for {{printf "%.2f" .Order.Total}} {{.Order.Currency}} is confirmed. Thank you!'
+),
+(
+    'low_stock',
+    'email',
+    'Low stock: {{.Product.Name}} ({{.Product.SKU}})',
+    '<p>{{.Product.Name}} ({{.Product.SKU}}) has {{.Product.Stock}} left in stock, at or below the alert threshold of {{.Threshold}}.</p>'
+)
+ON CONFLICT (name, channel) DO NOTHING;
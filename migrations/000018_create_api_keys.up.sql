@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name       TEXT NOT NULL,
+    owner_id   TEXT NOT NULL,
+    role       TEXT NOT NULL,
+    scope      TEXT NOT NULL CHECK (scope IN ('read', 'read_write')),
+    prefix     TEXT NOT NULL,
+    hash       TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_owner_id ON api_keys (owner_id, created_at DESC);
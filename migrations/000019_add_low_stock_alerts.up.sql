@@ -0,0 +1,7 @@
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS low_stock_threshold INTEGER CHECK (low_stock_threshold >= 0),
+    ADD COLUMN IF NOT EXISTS low_stock_alerted_at TIMESTAMPTZ;
+
+-- Backs the low stock listing and the checker's scan for unreported shortages
+CREATE INDEX IF NOT EXISTS idx_products_low_stock ON products (stock)
+    WHERE deleted_at IS NULL AND is_active;
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS inventory_movements (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    delta      INTEGER NOT NULL,
+    balance    INTEGER NOT NULL CHECK (balance >= 0),
+    reason     TEXT NOT NULL CHECK (reason IN ('initial', 'restock', 'adjustment', 'reservation', 'release', 'import')),
+    reference  TEXT,
+    actor_id   TEXT,
+    note       TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_inventory_movements_product_id ON inventory_movements (product_id, created_at DESC);
+
+-- Open the ledger with each product's current stock, so stock equals the
+-- sum of its movements from here on
+INSERT INTO inventory_movements (product_id, delta, balance, reason, note)
+SELECT id, stock, stock, 'initial', 'Opening balance'
+FROM products
+WHERE stock <> 0;
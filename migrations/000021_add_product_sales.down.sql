@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_products_sales;
+ALTER TABLE products
+    DROP CONSTRAINT IF EXISTS products_sale_window_check,
+    DROP COLUMN IF EXISTS sale_active,
+    DROP COLUMN IF EXISTS sale_ends_at,
+    DROP COLUMN IF EXISTS sale_starts_at,
+    DROP COLUMN IF EXISTS sale_price;
@@ -0,0 +1,10 @@
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS sale_price NUMERIC(12, 2) CHECK (sale_price > 0),
+    ADD COLUMN IF NOT EXISTS sale_starts_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS sale_ends_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS sale_active BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD CONSTRAINT products_sale_window_check CHECK (sale_ends_at IS NULL OR sale_starts_at IS NULL OR sale_ends_at > sale_starts_at);
+
+-- Backs the sale check's scan for windows that opened or closed
+CREATE INDEX IF NOT EXISTS idx_products_sales ON products (sale_starts_at, sale_ends_at)
+    WHERE deleted_at IS NULL AND (sale_price IS NOT NULL OR sale_active);
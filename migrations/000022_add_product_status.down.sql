@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_products_publish_at;
+DROP INDEX IF EXISTS idx_products_status;
+ALTER TABLE products
+    DROP COLUMN IF EXISTS published_at,
+    DROP COLUMN IF EXISTS publish_at,
+    DROP COLUMN IF EXISTS status;
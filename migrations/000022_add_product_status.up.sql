@@ -0,0 +1,13 @@
+-- Existing products are already on the storefront, so they start published
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'published' CHECK (status IN ('draft', 'published', 'archived')),
+    ADD COLUMN IF NOT EXISTS publish_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS published_at TIMESTAMPTZ;
+
+UPDATE products SET published_at = created_at WHERE published_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_products_status ON products (status) WHERE deleted_at IS NULL;
+
+-- Backs the scheduled publishing check
+CREATE INDEX IF NOT EXISTS idx_products_publish_at ON products (publish_at)
+    WHERE deleted_at IS NULL AND status = 'draft' AND publish_at IS NOT NULL;
@@ -0,0 +1,21 @@
+-- Content in the default locale stays on products and categories; these
+-- hold the other locales
+CREATE TABLE IF NOT EXISTS product_translations (
+    product_id  UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    locale      TEXT NOT NULL,
+    name        VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (product_id, locale)
+);
+
+CREATE TABLE IF NOT EXISTS category_translations (
+    category_id UUID NOT NULL REFERENCES categories (id) ON DELETE CASCADE,
+    locale      TEXT NOT NULL,
+    name        VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (category_id, locale)
+);
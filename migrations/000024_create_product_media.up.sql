@@ -0,0 +1,21 @@
+-- Images live in object storage; this is their metadata. Thumbnails are
+-- stored next to the original and listed in thumbnails.
+CREATE TABLE IF NOT EXISTS product_media (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id   UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    storage_key  TEXT NOT NULL UNIQUE,
+    filename     VARCHAR(255) NOT NULL,
+    content_type TEXT NOT NULL,
+    size         BIGINT NOT NULL DEFAULT 0,
+    width        INTEGER NOT NULL DEFAULT 0,
+    height       INTEGER NOT NULL DEFAULT 0,
+    status       TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'ready')),
+    thumbnails   JSONB NOT NULL DEFAULT '[]',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_media_product_id ON product_media (product_id, created_at);
+
+-- Backs the purge of uploads that were never completed
+CREATE INDEX IF NOT EXISTS idx_product_media_pending ON product_media (created_at) WHERE status = 'pending';
@@ -0,0 +1,3 @@
+-- Global Trade Item Number (UPC, EAN or ISBN); empty when the product has none
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS gtin VARCHAR(14) NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS zero_result_searches;
+DROP TABLE IF EXISTS search_synonyms;
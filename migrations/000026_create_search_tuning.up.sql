@@ -0,0 +1,19 @@
+-- Words searched for alongside a term, e.g. t-shirt for tee
+CREATE TABLE IF NOT EXISTS search_synonyms (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    term       TEXT NOT NULL UNIQUE,
+    synonyms   JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Normalized storefront queries that found no products
+CREATE TABLE IF NOT EXISTS zero_result_searches (
+    id                UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    query             TEXT NOT NULL UNIQUE,
+    count             BIGINT NOT NULL DEFAULT 1,
+    first_searched_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_searched_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_zero_result_searches_count ON zero_result_searches (count DESC, last_searched_at DESC);
@@ -0,0 +1,2 @@
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku_live ON products (sku) WHERE deleted_at IS NULL;
+DROP INDEX IF EXISTS idx_products_sku_live_lower;
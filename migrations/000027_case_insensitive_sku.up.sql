@@ -0,0 +1,5 @@
+-- SKUs are unique among live products regardless of case. Creating the
+-- index fails while live products have SKUs differing only in case; those
+-- need renaming first.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku_live_lower ON products (LOWER(sku)) WHERE deleted_at IS NULL;
+DROP INDEX IF EXISTS idx_products_sku_live;
@@ -0,0 +1,30 @@
+-- Background jobs, claimed by the services' worker pools. A job is pending
+-- until it completes, or runs out of attempts and is dead-lettered; run_at
+-- is when it is next due, pushed out while a worker holds it.
+CREATE TABLE IF NOT EXISTS jobs (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    type         TEXT NOT NULL,
+    payload      JSONB NOT NULL DEFAULT '{}',
+    dedupe_key   TEXT UNIQUE,
+    status       TEXT NOT NULL CHECK (status IN ('pending', 'completed', 'dead')),
+    attempts     INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL CHECK (max_attempts > 0),
+    backoff      INTEGER NOT NULL CHECK (backoff >= 0),
+    run_at       TIMESTAMPTZ NOT NULL,
+    last_error   TEXT NOT NULL DEFAULT '',
+    completed_at TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_due ON jobs (type, run_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_jobs_dead ON jobs (type, updated_at DESC) WHERE status = 'dead';
+CREATE INDEX IF NOT EXISTS idx_jobs_completed ON jobs (completed_at) WHERE status = 'completed';
+
+-- Notifications are now delivered by jobs; queue those still waiting, with
+-- the attempts they have left
+INSERT INTO jobs (type, payload, status, max_attempts, backoff, run_at)
+SELECT 'notification.deliver', jsonb_build_object('notification_id', id), 'pending',
+       GREATEST(5 - attempts, 1), 30, COALESCE(next_attempt_at, NOW())
+FROM notifications
+WHERE status = 'pending';
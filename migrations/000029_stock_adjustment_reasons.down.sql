@@ -0,0 +1,7 @@
+-- The finer reasons were all corrections, which the older codes record as
+-- adjustments
+UPDATE inventory_movements SET reason = 'adjustment' WHERE reason IN ('count', 'damaged', 'shrinkage', 'return');
+
+ALTER TABLE inventory_movements DROP CONSTRAINT IF EXISTS inventory_movements_reason_check;
+ALTER TABLE inventory_movements ADD CONSTRAINT inventory_movements_reason_check
+    CHECK (reason IN ('initial', 'restock', 'adjustment', 'reservation', 'release', 'import'));
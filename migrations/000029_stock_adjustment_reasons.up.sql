@@ -0,0 +1,4 @@
+-- Reason codes for warehouse stock corrections
+ALTER TABLE inventory_movements DROP CONSTRAINT IF EXISTS inventory_movements_reason_check;
+ALTER TABLE inventory_movements ADD CONSTRAINT inventory_movements_reason_check
+    CHECK (reason IN ('initial', 'restock', 'adjustment', 'reservation', 'release', 'import', 'count', 'damaged', 'shrinkage', 'return'));
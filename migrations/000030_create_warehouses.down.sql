@@ -0,0 +1,24 @@
+-- Reservations split over warehouses are merged back into one row per
+-- product
+WITH totals AS (
+    SELECT reference, product_id, SUM(quantity) AS quantity
+    FROM stock_reservations
+    GROUP BY reference, product_id
+    HAVING COUNT(*) > 1
+)
+UPDATE stock_reservations r SET quantity = totals.quantity
+FROM totals
+WHERE r.reference = totals.reference AND r.product_id = totals.product_id;
+
+DELETE FROM stock_reservations r
+USING stock_reservations other
+WHERE r.reference = other.reference AND r.product_id = other.product_id AND r.id > other.id;
+
+ALTER TABLE stock_reservations DROP CONSTRAINT IF EXISTS stock_reservations_reference_product_id_warehouse_id_key;
+ALTER TABLE stock_reservations ADD CONSTRAINT stock_reservations_reference_product_id_key UNIQUE (reference, product_id);
+ALTER TABLE stock_reservations DROP COLUMN IF EXISTS warehouse_id;
+
+ALTER TABLE inventory_movements DROP COLUMN IF EXISTS warehouse_id;
+
+DROP TABLE IF EXISTS stock_levels;
+DROP TABLE IF EXISTS warehouses;
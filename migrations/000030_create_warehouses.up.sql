@@ -0,0 +1,46 @@
+-- Warehouses hold a product's stock between them. products.stock stays the
+-- total across warehouses; stock changes that name no warehouse go to the
+-- default one.
+CREATE TABLE IF NOT EXISTS warehouses (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code       TEXT NOT NULL UNIQUE,
+    name       TEXT NOT NULL,
+    priority   INTEGER NOT NULL DEFAULT 0,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_warehouses_default ON warehouses (is_default) WHERE is_default;
+
+INSERT INTO warehouses (code, name, is_default)
+VALUES ('default', 'Default warehouse', TRUE)
+ON CONFLICT (code) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS stock_levels (
+    product_id   UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    warehouse_id UUID NOT NULL REFERENCES warehouses (id),
+    quantity     INTEGER NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (product_id, warehouse_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_levels_warehouse_id ON stock_levels (warehouse_id);
+
+-- All stock so far was held in the one, default warehouse
+INSERT INTO stock_levels (product_id, warehouse_id, quantity)
+SELECT p.id, w.id, p.stock
+FROM products p, warehouses w
+WHERE w.is_default AND p.stock > 0;
+
+ALTER TABLE inventory_movements ADD COLUMN IF NOT EXISTS warehouse_id UUID REFERENCES warehouses (id);
+UPDATE inventory_movements SET warehouse_id = (SELECT id FROM warehouses WHERE is_default);
+
+-- A reservation may take a product's stock from several warehouses, with
+-- a row for each
+ALTER TABLE stock_reservations ADD COLUMN IF NOT EXISTS warehouse_id UUID REFERENCES warehouses (id);
+UPDATE stock_reservations SET warehouse_id = (SELECT id FROM warehouses WHERE is_default);
+ALTER TABLE stock_reservations ALTER COLUMN warehouse_id SET NOT NULL;
+ALTER TABLE stock_reservations DROP CONSTRAINT IF EXISTS stock_reservations_reference_product_id_key;
+ALTER TABLE stock_reservations ADD CONSTRAINT stock_reservations_reference_product_id_warehouse_id_key
+    UNIQUE (reference, product_id, warehouse_id);
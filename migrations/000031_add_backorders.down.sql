@@ -0,0 +1,29 @@
+-- Oversold stock is written back up to zero, with a ledger entry for each
+-- warehouse, so the levels still add up to each product's stock
+WITH oversold AS (
+    SELECT product_id, warehouse_id, -quantity AS delta
+    FROM stock_levels
+    WHERE quantity < 0
+), totals AS (
+    UPDATE products p SET stock = p.stock + t.delta, updated_at = NOW()
+    FROM (SELECT product_id, SUM(delta) AS delta FROM oversold GROUP BY product_id) t
+    WHERE p.id = t.product_id
+    RETURNING p.id, p.stock
+)
+INSERT INTO inventory_movements (product_id, warehouse_id, delta, balance, reason, note)
+SELECT o.product_id, o.warehouse_id, o.delta, totals.stock, 'adjustment', 'Backorders written off'
+FROM oversold o
+JOIN totals ON totals.id = o.product_id;
+
+UPDATE stock_levels SET quantity = 0, updated_at = NOW() WHERE quantity < 0;
+
+-- Earlier ledger entries may still show negative balances, so the check
+-- only applies to new ones
+ALTER TABLE inventory_movements ADD CONSTRAINT inventory_movements_balance_check
+    CHECK (balance >= 0) NOT VALID;
+ALTER TABLE stock_levels ADD CONSTRAINT stock_levels_quantity_check CHECK (quantity >= 0);
+
+ALTER TABLE products
+    DROP COLUMN IF EXISTS release_date,
+    DROP COLUMN IF EXISTS preorder,
+    DROP COLUMN IF EXISTS allow_backorder;
@@ -0,0 +1,10 @@
+-- Backordered and preordered products can be reserved beyond their stock,
+-- so their stock, the ledger balance and the level of the warehouse
+-- charged with the shortfall may all go below zero
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS allow_backorder BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS preorder BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS release_date TIMESTAMPTZ;
+
+ALTER TABLE stock_levels DROP CONSTRAINT IF EXISTS stock_levels_quantity_check;
+ALTER TABLE inventory_movements DROP CONSTRAINT IF EXISTS inventory_movements_balance_check;
@@ -0,0 +1,13 @@
+DELETE FROM notification_templates WHERE name = 'downloads_ready';
+
+ALTER TABLE orders DROP COLUMN IF EXISTS requires_shipping;
+ALTER TABLE order_items DROP COLUMN IF EXISTS type;
+
+DROP TABLE IF EXISTS entitlements;
+DROP TABLE IF EXISTS digital_assets;
+
+-- Reservations of products that held no stock have nothing to return
+DELETE FROM stock_reservations WHERE warehouse_id IS NULL;
+ALTER TABLE stock_reservations ALTER COLUMN warehouse_id SET NOT NULL;
+
+ALTER TABLE products DROP COLUMN IF EXISTS type;
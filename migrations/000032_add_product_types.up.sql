@@ -0,0 +1,51 @@
+-- Only physical products hold stock and are shipped; digital products are
+-- delivered as downloads, and services need neither
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS type TEXT NOT NULL DEFAULT 'physical'
+        CHECK (type IN ('physical', 'digital', 'service'));
+
+-- Products that hold no stock are reserved without a warehouse
+ALTER TABLE stock_reservations ALTER COLUMN warehouse_id DROP NOT NULL;
+
+CREATE TABLE IF NOT EXISTS digital_assets (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id   UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    storage_key  TEXT NOT NULL,
+    filename     TEXT NOT NULL,
+    content_type TEXT,
+    size         BIGINT NOT NULL DEFAULT 0,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_digital_assets_product_id ON digital_assets (product_id, created_at);
+
+-- An order is fulfilled at most once per digital product in it, however
+-- often its event is delivered
+CREATE TABLE IF NOT EXISTS entitlements (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id     UUID NOT NULL,
+    customer_id  TEXT NOT NULL,
+    product_id   UUID NOT NULL REFERENCES products (id),
+    product_name TEXT NOT NULL,
+    quantity     INTEGER NOT NULL CHECK (quantity > 0),
+    license_key  TEXT NOT NULL UNIQUE,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (order_id, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_entitlements_customer ON entitlements (customer_id, created_at DESC);
+
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS type TEXT NOT NULL DEFAULT 'physical';
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS requires_shipping BOOLEAN NOT NULL DEFAULT TRUE;
+
+INSERT INTO notification_templates (name, channel, subject, body) VALUES
+(
+    'downloads_ready',
+    'email',
+    'Your downloads for order {{.Downloads.OrderID}} are ready',
+    '<p>Your digital products are ready to download from your account.</p>
+<table>
+{{range .Downloads.Entitlements}}<tr><td>{{.Quantity}} &times; {{.ProductName}}</td><td>License key: {{.LicenseKey}}</td></tr>
+{{end}}</table>'
+)
+ON CONFLICT (name, channel) DO NOTHING;
@@ -0,0 +1,17 @@
+-- Reservations of components for bundles go with the bundles
+DELETE FROM stock_reservations WHERE bundle_id IS NOT NULL;
+ALTER TABLE stock_reservations DROP CONSTRAINT IF EXISTS stock_reservations_reference_product_id_warehouse_id_bundle_id_key;
+ALTER TABLE stock_reservations DROP COLUMN IF EXISTS bundle_id;
+ALTER TABLE stock_reservations ADD CONSTRAINT stock_reservations_reference_product_id_warehouse_id_key
+    UNIQUE (reference, product_id, warehouse_id);
+
+DROP TABLE IF EXISTS bundle_components;
+
+ALTER TABLE products DROP COLUMN IF EXISTS bundle_discount;
+ALTER TABLE products DROP COLUMN IF EXISTS bundle_pricing;
+
+-- Bundles fall back to products selling their own stock
+UPDATE products SET type = 'physical' WHERE type = 'bundle';
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_type_check;
+ALTER TABLE products ADD CONSTRAINT products_type_check
+    CHECK (type IN ('physical', 'digital', 'service'));
@@ -0,0 +1,29 @@
+-- Bundles are sold as one product but hold no stock of their own; reserving
+-- one reserves its components
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_type_check;
+ALTER TABLE products ADD CONSTRAINT products_type_check
+    CHECK (type IN ('physical', 'digital', 'service', 'bundle'));
+
+-- A fixed bundle is sold at its own price; a computed one at its
+-- components' regular prices less its discount, in percent
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS bundle_pricing TEXT NOT NULL DEFAULT ''
+        CHECK (bundle_pricing IN ('', 'fixed', 'computed')),
+    ADD COLUMN IF NOT EXISTS bundle_discount NUMERIC(5, 2) NOT NULL DEFAULT 0
+        CHECK (bundle_discount >= 0 AND bundle_discount < 100);
+
+CREATE TABLE IF NOT EXISTS bundle_components (
+    bundle_id    UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    component_id UUID NOT NULL REFERENCES products (id),
+    quantity     INTEGER NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (bundle_id, component_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bundle_components_component_id ON bundle_components (component_id);
+
+-- Components reserved for a bundle are kept apart from the same products
+-- ordered on their own, so each is released to where it came from
+ALTER TABLE stock_reservations ADD COLUMN IF NOT EXISTS bundle_id UUID REFERENCES products (id);
+ALTER TABLE stock_reservations DROP CONSTRAINT IF EXISTS stock_reservations_reference_product_id_warehouse_id_key;
+ALTER TABLE stock_reservations ADD CONSTRAINT stock_reservations_reference_product_id_warehouse_id_bundle_id_key
+    UNIQUE NULLS NOT DISTINCT (reference, product_id, warehouse_id, bundle_id);
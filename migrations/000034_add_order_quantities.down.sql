@@ -0,0 +1,5 @@
+ALTER TABLE products
+    DROP COLUMN IF EXISTS max_order_quantity,
+    DROP COLUMN IF EXISTS min_order_quantity,
+    DROP COLUMN IF EXISTS quantity_increment,
+    DROP COLUMN IF EXISTS unit_of_measure;
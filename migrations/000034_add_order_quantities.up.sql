@@ -0,0 +1,8 @@
+-- Products are sold in a unit of measure, in multiples of an increment
+-- (packs of six take 6) and within optional order quantity limits, where
+-- zero is no limit
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS unit_of_measure TEXT NOT NULL DEFAULT 'each',
+    ADD COLUMN IF NOT EXISTS quantity_increment INTEGER NOT NULL DEFAULT 1 CHECK (quantity_increment >= 1),
+    ADD COLUMN IF NOT EXISTS min_order_quantity INTEGER NOT NULL DEFAULT 0 CHECK (min_order_quantity >= 0),
+    ADD COLUMN IF NOT EXISTS max_order_quantity INTEGER NOT NULL DEFAULT 0 CHECK (max_order_quantity >= 0);
@@ -0,0 +1,14 @@
+ALTER TABLE order_items
+    DROP COLUMN IF EXISTS tax,
+    DROP COLUMN IF EXISTS tax_rate,
+    DROP COLUMN IF EXISTS tax_class;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS postal_code,
+    DROP COLUMN IF EXISTS region,
+    DROP COLUMN IF EXISTS country,
+    DROP COLUMN IF EXISTS tax;
+
+DROP TABLE IF EXISTS tax_rates;
+
+ALTER TABLE products DROP COLUMN IF EXISTS tax_class;
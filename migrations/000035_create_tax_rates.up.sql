@@ -0,0 +1,30 @@
+-- Products are taxed at the rate of their tax class where they are
+-- delivered; exempt products are never taxed
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS tax_class TEXT NOT NULL DEFAULT 'standard'
+        CHECK (tax_class IN ('standard', 'reduced', 'zero', 'exempt'));
+
+-- A rate with an empty region applies to the whole country, unless the
+-- region has its own
+CREATE TABLE IF NOT EXISTS tax_rates (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    country    TEXT NOT NULL,
+    region     TEXT NOT NULL DEFAULT '',
+    tax_class  TEXT NOT NULL CHECK (tax_class IN ('standard', 'reduced', 'zero')),
+    rate       NUMERIC(5, 2) NOT NULL CHECK (rate >= 0 AND rate <= 100),
+    name       TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT tax_rates_country_region_tax_class_key UNIQUE (country, region, tax_class)
+);
+
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS tax NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS country TEXT,
+    ADD COLUMN IF NOT EXISTS region TEXT,
+    ADD COLUMN IF NOT EXISTS postal_code TEXT;
+
+ALTER TABLE order_items
+    ADD COLUMN IF NOT EXISTS tax_class TEXT,
+    ADD COLUMN IF NOT EXISTS tax_rate NUMERIC(5, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS tax NUMERIC(12, 2) NOT NULL DEFAULT 0;
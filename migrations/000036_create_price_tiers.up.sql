@@ -0,0 +1,12 @@
+-- Price tiers price products for a customer group, or for every group when
+-- it is empty, from a minimum quantity up. Customers are charged the lowest
+-- price open to them.
+CREATE TABLE IF NOT EXISTS product_price_tiers (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id     UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    customer_group TEXT NOT NULL DEFAULT '' CHECK (customer_group IN ('', 'retail', 'wholesale', 'vip')),
+    min_quantity   INTEGER NOT NULL CHECK (min_quantity >= 1),
+    price          NUMERIC(12, 2) NOT NULL CHECK (price > 0),
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT product_price_tiers_product_id_customer_group_min_quantity_key UNIQUE (product_id, customer_group, min_quantity)
+);
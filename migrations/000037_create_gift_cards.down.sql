@@ -0,0 +1,10 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS gift_card_amount;
+
+DROP TABLE IF EXISTS gift_card_transactions;
+DROP TABLE IF EXISTS gift_cards;
+
+-- Gift card products fall back to products selling their own stock
+UPDATE products SET type = 'physical' WHERE type = 'gift_card';
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_type_check;
+ALTER TABLE products ADD CONSTRAINT products_type_check
+    CHECK (type IN ('physical', 'digital', 'service', 'bundle'));
@@ -0,0 +1,47 @@
+-- Gift cards are sold as products holding no stock; buying one issues a
+-- card worth its price
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_type_check;
+ALTER TABLE products ADD CONSTRAINT products_type_check
+    CHECK (type IN ('physical', 'digital', 'service', 'bundle', 'gift_card'));
+
+CREATE TABLE IF NOT EXISTS gift_cards (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code            TEXT NOT NULL UNIQUE,
+    currency        TEXT NOT NULL,
+    initial_balance NUMERIC(10, 2) NOT NULL CHECK (initial_balance > 0),
+    balance         NUMERIC(10, 2) NOT NULL CHECK (balance >= 0),
+    status          TEXT NOT NULL CHECK (status IN ('active', 'disabled')),
+    purchaser_id    TEXT,
+    order_id        UUID,
+    product_id      UUID,
+    serial          INTEGER NOT NULL DEFAULT 0,
+    expires_at      TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (balance <= initial_balance),
+    -- Cards issued for an order line are numbered, so issuing for the same
+    -- order again creates nothing
+    CONSTRAINT gift_cards_order_id_product_id_serial_key UNIQUE (order_id, product_id, serial)
+);
+
+CREATE INDEX IF NOT EXISTS idx_gift_cards_purchaser ON gift_cards (purchaser_id);
+
+-- Every change to a balance is logged. A card is redeemed and voided at most
+-- once per reference, which makes both safe to retry.
+CREATE TABLE IF NOT EXISTS gift_card_transactions (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    gift_card_id  UUID NOT NULL REFERENCES gift_cards (id),
+    type          TEXT NOT NULL CHECK (type IN ('issue', 'redeem', 'void')),
+    amount        NUMERIC(10, 2) NOT NULL,
+    balance_after NUMERIC(10, 2) NOT NULL,
+    reference     TEXT NOT NULL DEFAULT '',
+    actor_id      TEXT,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT gift_card_transactions_gift_card_id_type_reference_key UNIQUE (gift_card_id, type, reference)
+);
+
+CREATE INDEX IF NOT EXISTS idx_gift_card_transactions_reference ON gift_card_transactions (reference);
+
+-- Gift card amounts paid at checkout come off what is charged to the payment
+-- method
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS gift_card_amount NUMERIC(10, 2) NOT NULL DEFAULT 0;
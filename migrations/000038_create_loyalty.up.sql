@@ -0,0 +1,44 @@
+-- Earn rules award points per unit of currency spent on the products of a
+-- type, or on every product when the type is empty
+CREATE TABLE IF NOT EXISTS loyalty_earn_rules (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name            TEXT NOT NULL,
+    product_type    TEXT NOT NULL DEFAULT '',
+    points_per_unit NUMERIC(10, 4) NOT NULL CHECK (points_per_unit > 0),
+    min_order_total NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (min_order_total >= 0),
+    active          BOOLEAN NOT NULL DEFAULT TRUE,
+    starts_at       TIMESTAMPTZ,
+    ends_at         TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (ends_at IS NULL OR starts_at IS NULL OR ends_at > starts_at)
+);
+
+CREATE TABLE IF NOT EXISTS loyalty_accounts (
+    customer_id     TEXT PRIMARY KEY,
+    balance         BIGINT NOT NULL DEFAULT 0 CHECK (balance >= 0),
+    lifetime_earned BIGINT NOT NULL DEFAULT 0,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- A customer earns, redeems and voids at most once per reference, which
+-- makes all three safe to retry
+CREATE TABLE IF NOT EXISTS loyalty_transactions (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    customer_id   TEXT NOT NULL REFERENCES loyalty_accounts (customer_id),
+    type          TEXT NOT NULL CHECK (type IN ('earn', 'redeem', 'void')),
+    points        BIGINT NOT NULL,
+    balance_after BIGINT NOT NULL,
+    reference     TEXT NOT NULL,
+    description   TEXT,
+    actor_id      TEXT,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT loyalty_transactions_customer_id_type_reference_key UNIQUE (customer_id, type, reference)
+);
+
+CREATE INDEX IF NOT EXISTS idx_loyalty_transactions_customer_created ON loyalty_transactions (customer_id, created_at DESC);
+
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS points_redeemed BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS points_amount NUMERIC(10, 2) NOT NULL DEFAULT 0;
@@ -0,0 +1,39 @@
+-- Returns send back some of the items of an order for a refund. Amount is
+-- what the returned items cost; refunded is what went back to the payment
+-- method.
+CREATE TABLE IF NOT EXISTS returns (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id    UUID NOT NULL REFERENCES orders (id),
+    customer_id TEXT NOT NULL,
+    status      TEXT NOT NULL CHECK (status IN ('requested', 'approved', 'rejected', 'received', 'refunded', 'cancelled')),
+    reason      TEXT NOT NULL,
+    note        TEXT,
+    resolution  TEXT,
+    amount      NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    refunded    NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (refunded >= 0),
+    refund_id   TEXT,
+    restocked   BOOLEAN NOT NULL DEFAULT FALSE,
+    reviewed_at TIMESTAMPTZ,
+    received_at TIMESTAMPTZ,
+    refunded_at TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_returns_order_id ON returns (order_id);
+CREATE INDEX IF NOT EXISTS idx_returns_customer_created ON returns (customer_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_returns_status ON returns (status);
+
+CREATE TABLE IF NOT EXISTS return_items (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    return_id     UUID NOT NULL REFERENCES returns (id) ON DELETE CASCADE,
+    order_item_id UUID NOT NULL REFERENCES order_items (id),
+    product_id    UUID NOT NULL,
+    sku           TEXT,
+    name          TEXT,
+    quantity      INTEGER NOT NULL CHECK (quantity > 0),
+    amount        NUMERIC(10, 2) NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_return_items_return_id ON return_items (return_id);
+CREATE INDEX IF NOT EXISTS idx_return_items_order_item_id ON return_items (order_item_id);
@@ -0,0 +1,19 @@
+-- Order events are the timeline of an order's status changes, from its
+-- creation on.
+CREATE TABLE IF NOT EXISTS order_events (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id    UUID NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    type        TEXT NOT NULL,
+    from_status TEXT,
+    status      TEXT NOT NULL,
+    note        TEXT,
+    actor_id    TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_events_order_created ON order_events (order_id, created_at);
+
+-- Existing orders start their timeline at their creation
+INSERT INTO order_events (order_id, type, status, actor_id, created_at)
+SELECT id, 'order.created', status, customer_id, created_at
+FROM orders;
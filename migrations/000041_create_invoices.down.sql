@@ -0,0 +1,2 @@
+DELETE FROM notification_templates WHERE name = 'invoice';
+DROP TABLE IF EXISTS invoices;
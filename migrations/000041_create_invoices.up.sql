@@ -0,0 +1,25 @@
+-- Invoices are the numbered bills of orders. Numbers follow sequence, which
+-- runs on without gaps; stored_at is set once the PDF is in storage.
+CREATE TABLE IF NOT EXISTS invoices (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id   UUID NOT NULL UNIQUE REFERENCES orders (id),
+    sequence   BIGINT NOT NULL UNIQUE,
+    number     TEXT NOT NULL UNIQUE,
+    currency   TEXT NOT NULL,
+    subtotal   NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    tax        NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    total      NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    issued_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    stored_at  TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO notification_templates (name, channel, subject, body) VALUES
+(
+    'invoice',
+    'email',
+    'Your invoice {{.Invoice.Number}}',
+    '<p>Thank you for your order {{.Invoice.OrderID}}.</p>
+<p>Your invoice {{.Invoice.Number}} for {{printf "%.2f" .Invoice.Total}} {{.Invoice.Currency}} is ready to <a href="{{.Invoice.URL}}">download</a>.</p>'
+)
+ON CONFLICT (name, channel) DO NOTHING;
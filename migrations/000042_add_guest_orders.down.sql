@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_orders_guest_email;
+ALTER TABLE orders DROP COLUMN IF EXISTS guest;
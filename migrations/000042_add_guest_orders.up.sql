@@ -0,0 +1,5 @@
+-- Guest orders are placed with an email instead of an account, until an
+-- account with the same email claims them
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS guest BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_orders_guest_email ON orders (LOWER(email)) WHERE guest;
@@ -0,0 +1,21 @@
+-- Accounts of shoppers signing in through an external identity provider,
+-- such as Google or Apple. An account is linked to each provider identity
+-- it signed in with, and found again by the provider's verified email when
+-- it signs in with another.
+CREATE TABLE IF NOT EXISTS accounts (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    email      TEXT NOT NULL UNIQUE CHECK (email = LOWER(email)),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS account_identities (
+    provider   TEXT NOT NULL,
+    subject    TEXT NOT NULL,
+    account_id UUID NOT NULL REFERENCES accounts (id) ON DELETE CASCADE,
+    email      TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (provider, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_identities_account_id ON account_identities (account_id);
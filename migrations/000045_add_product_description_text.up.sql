@@ -0,0 +1,18 @@
+-- Descriptions may hold HTML; search indexes their text instead. Existing
+-- rows get their tags stripped here, and the text is kept by the service
+-- from then on.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS description_text TEXT;
+
+UPDATE products
+SET description_text = btrim(regexp_replace(regexp_replace(coalesce(description, ''), '<[^>]*>', ' ', 'g'), '\s+', ' ', 'g'));
+
+DROP INDEX IF EXISTS idx_products_search_vector;
+ALTER TABLE products DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE products
+    ADD COLUMN search_vector TSVECTOR
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(description_text, '')), 'B')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING GIN (search_vector);
@@ -0,0 +1,36 @@
+ALTER TABLE checkouts DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE jobs DROP COLUMN IF EXISTS tenant;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS tenant_id;
+
+DROP INDEX IF EXISTS idx_audit_events_tenant_id;
+DROP INDEX IF EXISTS idx_import_jobs_tenant_id;
+DROP INDEX IF EXISTS idx_products_tenant_id;
+
+-- Restoring global uniqueness fails while tenants share values; those need
+-- renaming first
+ALTER TABLE slug_redirects DROP CONSTRAINT IF EXISTS slug_redirects_entity_type_slug_key;
+ALTER TABLE slug_redirects ADD CONSTRAINT slug_redirects_entity_type_slug_key UNIQUE (entity_type, slug);
+ALTER TABLE zero_result_searches DROP CONSTRAINT IF EXISTS zero_result_searches_query_key;
+ALTER TABLE zero_result_searches ADD CONSTRAINT zero_result_searches_query_key UNIQUE (query);
+ALTER TABLE search_synonyms DROP CONSTRAINT IF EXISTS search_synonyms_term_key;
+ALTER TABLE search_synonyms ADD CONSTRAINT search_synonyms_term_key UNIQUE (term);
+ALTER TABLE brands DROP CONSTRAINT IF EXISTS brands_name_key;
+ALTER TABLE brands ADD CONSTRAINT brands_name_key UNIQUE (name);
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_name_key;
+ALTER TABLE categories ADD CONSTRAINT categories_name_key UNIQUE (name);
+
+DROP INDEX IF EXISTS idx_categories_slug;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_slug ON categories (slug);
+DROP INDEX IF EXISTS idx_products_slug;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_slug ON products (slug);
+DROP INDEX IF EXISTS idx_products_sku_live_lower;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku_live_lower ON products (LOWER(sku)) WHERE deleted_at IS NULL;
+
+ALTER TABLE audit_events DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE import_jobs DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE slug_redirects DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE zero_result_searches DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE search_synonyms DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE brands DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE categories DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE products DROP COLUMN IF EXISTS tenant_id;
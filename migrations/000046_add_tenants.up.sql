@@ -0,0 +1,41 @@
+-- Every catalog row belongs to a tenant. Rows written before tenancy belong
+-- to the default tenant. Rows of child tables, such as stock movements,
+-- belong to the tenant of their product or category.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE brands ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE search_synonyms ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE zero_result_searches ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE slug_redirects ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE import_jobs ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE audit_events ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+
+-- Unique values are unique per tenant. The indexes keep their names, which
+-- the services match violations by.
+DROP INDEX IF EXISTS idx_products_sku_live_lower;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku_live_lower ON products (tenant_id, LOWER(sku)) WHERE deleted_at IS NULL;
+DROP INDEX IF EXISTS idx_products_slug;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_slug ON products (tenant_id, slug);
+DROP INDEX IF EXISTS idx_categories_slug;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_slug ON categories (tenant_id, slug);
+
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_name_key;
+ALTER TABLE categories ADD CONSTRAINT categories_name_key UNIQUE (tenant_id, name);
+ALTER TABLE brands DROP CONSTRAINT IF EXISTS brands_name_key;
+ALTER TABLE brands ADD CONSTRAINT brands_name_key UNIQUE (tenant_id, name);
+ALTER TABLE search_synonyms DROP CONSTRAINT IF EXISTS search_synonyms_term_key;
+ALTER TABLE search_synonyms ADD CONSTRAINT search_synonyms_term_key UNIQUE (tenant_id, term);
+ALTER TABLE zero_result_searches DROP CONSTRAINT IF EXISTS zero_result_searches_query_key;
+ALTER TABLE zero_result_searches ADD CONSTRAINT zero_result_searches_query_key UNIQUE (tenant_id, query);
+ALTER TABLE slug_redirects DROP CONSTRAINT IF EXISTS slug_redirects_entity_type_slug_key;
+ALTER TABLE slug_redirects ADD CONSTRAINT slug_redirects_entity_type_slug_key UNIQUE (tenant_id, entity_type, slug);
+
+CREATE INDEX IF NOT EXISTS idx_products_tenant_id ON products (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_import_jobs_tenant_id ON import_jobs (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_tenant_id ON audit_events (tenant_id);
+
+-- The tenant API keys are issued for, jobs are queued for and checkouts
+-- are placed in; empty for those of the time before tenancy
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS tenant TEXT NOT NULL DEFAULT '';
+ALTER TABLE checkouts ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT '';
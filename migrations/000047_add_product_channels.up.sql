@@ -0,0 +1,5 @@
+-- How each product is sold through each sales channel, keyed by channel:
+-- whether it is hidden there and the price replacing its regular price.
+-- Products without settings for a channel are visible there at their
+-- regular price.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS channels JSONB;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_products_approval_status;
+DROP INDEX IF EXISTS idx_products_vendor_id;
+
+ALTER TABLE products DROP COLUMN IF EXISTS approval_status;
+ALTER TABLE products DROP COLUMN IF EXISTS vendor_id;
+
+DROP TABLE IF EXISTS vendors;
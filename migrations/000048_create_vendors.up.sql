@@ -0,0 +1,23 @@
+-- Vendors are marketplace sellers. Each is run by one user, who manages
+-- the vendor's products and no others; commission_rate is the percentage
+-- of its sales the marketplace keeps.
+CREATE TABLE IF NOT EXISTS vendors (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id       TEXT NOT NULL DEFAULT 'default',
+    name            TEXT NOT NULL,
+    email           TEXT NOT NULL DEFAULT '',
+    owner_id        TEXT NOT NULL,
+    status          TEXT NOT NULL DEFAULT 'active',
+    commission_rate NUMERIC(5, 2) NOT NULL DEFAULT 0 CHECK (commission_rate BETWEEN 0 AND 100),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT vendors_owner_id_key UNIQUE (tenant_id, owner_id)
+);
+
+-- Products vendors list belong to them and wait for approval before they
+-- can be published; approval_status stays empty for the shop's own
+ALTER TABLE products ADD COLUMN IF NOT EXISTS vendor_id UUID REFERENCES vendors (id);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS approval_status TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_products_vendor_id ON products (vendor_id);
+CREATE INDEX IF NOT EXISTS idx_products_approval_status ON products (approval_status) WHERE approval_status <> '';
@@ -0,0 +1,5 @@
+DELETE FROM notification_templates WHERE name = 'product_reviewed';
+
+ALTER TABLE products DROP COLUMN IF EXISTS review_comment;
+
+DROP TABLE IF EXISTS product_revisions;
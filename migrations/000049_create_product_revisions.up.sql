@@ -0,0 +1,32 @@
+-- Product revisions are changes to live products proposed for review. patch
+-- is a JSON Merge Patch of the product, applied once a reviewer approves it.
+CREATE TABLE IF NOT EXISTS product_revisions (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id      UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    status          TEXT NOT NULL DEFAULT 'pending',
+    patch           JSONB NOT NULL,
+    note            TEXT NOT NULL DEFAULT '',
+    submitted_by    TEXT NOT NULL,
+    submitter_email TEXT NOT NULL DEFAULT '',
+    reviewed_by     TEXT NOT NULL DEFAULT '',
+    review_comment  TEXT NOT NULL DEFAULT '',
+    reviewed_at     TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_revisions_product_id ON product_revisions (product_id);
+CREATE INDEX IF NOT EXISTS idx_product_revisions_status ON product_revisions (status);
+
+-- The reviewer's comment on the last review of a vendor product
+ALTER TABLE products ADD COLUMN IF NOT EXISTS review_comment TEXT NOT NULL DEFAULT '';
+
+INSERT INTO notification_templates (name, channel, subject, body) VALUES
+(
+    'product_reviewed',
+    'email',
+    '{{if .Review.RevisionID}}Your changes to {{.Review.ProductName}} were {{.Review.Status}}{{else}}{{.Review.ProductName}} was {{.Review.Status}}{{end}}',
+    '<p>{{if .Review.RevisionID}}Your proposed changes to {{.Review.ProductName}} were {{.Review.Status}}.{{else}}Your product {{.Review.ProductName}} was {{.Review.Status}}.{{end}}</p>
+{{if .Review.Comment}}<p>The reviewer said: {{.Review.Comment}}</p>{{end}}'
+)
+ON CONFLICT (name, channel) DO NOTHING;
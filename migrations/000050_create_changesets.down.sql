@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS changeset_items;
+DROP TABLE IF EXISTS changesets;
@@ -0,0 +1,34 @@
+-- Changesets are named sets of product edits staged to go live together,
+-- now or at apply_at, and to be rolled back together. Each item is a JSON
+-- Merge Patch of one product; revert is the patch undoing it, recorded as
+-- the changeset is applied.
+CREATE TABLE IF NOT EXISTS changesets (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id      TEXT NOT NULL DEFAULT 'default',
+    name           TEXT NOT NULL,
+    description    TEXT NOT NULL DEFAULT '',
+    status         TEXT NOT NULL DEFAULT 'draft',
+    apply_at       TIMESTAMPTZ,
+    applied_at     TIMESTAMPTZ,
+    rolled_back_at TIMESTAMPTZ,
+    error          TEXT NOT NULL DEFAULT '',
+    created_by     TEXT NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_changesets_tenant_id ON changesets (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_changesets_apply_at ON changesets (apply_at) WHERE status = 'scheduled';
+
+CREATE TABLE IF NOT EXISTS changeset_items (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    changeset_id UUID NOT NULL REFERENCES changesets (id) ON DELETE CASCADE,
+    product_id   UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    patch        JSONB NOT NULL,
+    revert       JSONB,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT changeset_items_product_key UNIQUE (changeset_id, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_changeset_items_product_id ON changeset_items (product_id);
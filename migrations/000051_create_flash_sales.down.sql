@@ -0,0 +1,10 @@
+-- Flash sale movements moved stock in and out of the sale; the older codes
+-- record them as adjustments
+UPDATE inventory_movements SET reason = 'adjustment' WHERE reason = 'flash_sale';
+
+ALTER TABLE inventory_movements DROP CONSTRAINT IF EXISTS inventory_movements_reason_check;
+ALTER TABLE inventory_movements ADD CONSTRAINT inventory_movements_reason_check
+    CHECK (reason IN ('initial', 'restock', 'adjustment', 'reservation', 'release', 'import', 'count', 'damaged', 'shrinkage', 'return'));
+
+DROP TABLE IF EXISTS flash_sale_purchases;
+DROP TABLE IF EXISTS flash_sales;
@@ -0,0 +1,44 @@
+-- Flash sales sell a limited quantity of a product at a sale price for a
+-- short window. Purchases are taken in Redis while the sale runs and
+-- written to flash_sale_purchases in the background, under the ids they
+-- were taken with. held is the stock set aside for the sale, put back less
+-- what sold once it closes.
+CREATE TABLE IF NOT EXISTS flash_sales (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id      TEXT NOT NULL DEFAULT 'default',
+    product_id     UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    starts_at      TIMESTAMPTZ NOT NULL,
+    ends_at        TIMESTAMPTZ NOT NULL,
+    price          NUMERIC(12, 2) NOT NULL CHECK (price > 0),
+    quantity       INTEGER NOT NULL CHECK (quantity > 0),
+    sold           INTEGER NOT NULL DEFAULT 0 CHECK (sold >= 0),
+    per_user_limit INTEGER NOT NULL DEFAULT 0 CHECK (per_user_limit >= 0),
+    held           INTEGER NOT NULL DEFAULT 0 CHECK (held >= 0),
+    closed_at      TIMESTAMPTZ,
+    created_by     TEXT NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_flash_sales_tenant_id ON flash_sales (tenant_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_flash_sales_open_product ON flash_sales (product_id) WHERE closed_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_flash_sales_starts_at ON flash_sales (starts_at) WHERE closed_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS flash_sale_purchases (
+    id            UUID PRIMARY KEY,
+    flash_sale_id UUID NOT NULL REFERENCES flash_sales (id) ON DELETE CASCADE,
+    product_id    UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    user_id       TEXT NOT NULL,
+    quantity      INTEGER NOT NULL CHECK (quantity > 0),
+    price         NUMERIC(12, 2) NOT NULL,
+    purchased_at  TIMESTAMPTZ NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_flash_sale_purchases_sale_user ON flash_sale_purchases (flash_sale_id, user_id);
+
+-- Stock set aside for a flash sale, and its unsold units put back
+ALTER TABLE inventory_movements DROP CONSTRAINT IF EXISTS inventory_movements_reason_check;
+ALTER TABLE inventory_movements ADD CONSTRAINT inventory_movements_reason_check
+    CHECK (reason IN ('initial', 'restock', 'adjustment', 'reservation', 'release', 'import', 'count', 'damaged', 'shrinkage', 'return', 'flash_sale'));
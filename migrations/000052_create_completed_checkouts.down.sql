@@ -0,0 +1 @@
+DROP TABLE IF EXISTS completed_checkouts;
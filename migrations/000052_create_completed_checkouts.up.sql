@@ -0,0 +1,8 @@
+-- Checkouts that became orders, recorded by the payment service from the
+-- order.created event. What a completed checkout redeemed from gift cards
+-- and loyalty points paid for its order and can no longer be voided.
+CREATE TABLE IF NOT EXISTS completed_checkouts (
+    checkout_id  UUID PRIMARY KEY,
+    order_id     UUID NOT NULL,
+    completed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,10 @@
+-- Restoring global uniqueness fails while tenants share codes or each have
+-- a default warehouse; stock in other tenants' warehouses needs moving out
+-- first
+DROP INDEX IF EXISTS idx_warehouses_default;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_warehouses_default ON warehouses (is_default) WHERE is_default;
+
+ALTER TABLE warehouses DROP CONSTRAINT IF EXISTS warehouses_code_key;
+ALTER TABLE warehouses ADD CONSTRAINT warehouses_code_key UNIQUE (code);
+
+ALTER TABLE warehouses DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1,11 @@
+-- Warehouses belong to a tenant. Codes are unique per tenant, under the
+-- constraint name the services match violations by.
+ALTER TABLE warehouses ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+
+ALTER TABLE warehouses DROP CONSTRAINT IF EXISTS warehouses_code_key;
+ALTER TABLE warehouses ADD CONSTRAINT warehouses_code_key UNIQUE (tenant_id, code);
+
+-- Each tenant has a default warehouse of its own, created with the first
+-- stock change that names none
+DROP INDEX IF EXISTS idx_warehouses_default;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_warehouses_default ON warehouses (tenant_id) WHERE is_default;
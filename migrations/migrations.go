@@ -0,0 +1,10 @@
+// Package migrations embeds the versioned SQL migrations so services can
+// apply them without the files being shipped alongside the binary.
+package migrations
+
+import "embed"
+
+// FS holds the migration files
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,298 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	_ "crypto/sha512" // registers SHA-384 and SHA-512
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Token validation errors
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+)
+
+// clockSkew is the leeway allowed when checking token times against an
+// identity provider's clock
+const clockSkew = time.Minute
+
+// Claims holds the JWT claims used by the services
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Email     string   `json:"email,omitempty"`
+	Role      string   `json:"role,omitempty"`
+	Group     string   `json:"customer_group,omitempty"`
+	TenantID  string   `json:"tenant_id,omitempty"`
+	SessionID string   `json:"sid,omitempty"` // the identity provider's session the token was issued in
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	AMR       []string `json:"amr,omitempty"` // how the user authenticated, such as pwd, otp or mfa
+}
+
+// Actor returns the user the claims identify
+func (c *Claims) Actor() *Actor {
+	return &Actor{ID: c.Subject, Email: c.Email, Role: c.Role, Group: c.Group, Tenant: c.TenantID}
+}
+
+// Audience is the aud claim, which is either a single string or a list
+type Audience []string
+
+// UnmarshalJSON accepts both forms of the aud claim
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// Contains reports whether the audience includes aud
+func (a Audience) Contains(aud string) bool {
+	for _, value := range a {
+		if value == aud {
+			return true
+		}
+	}
+	return false
+}
+
+// Verifier verifies a bearer token and returns its claims
+type Verifier interface {
+	Verify(ctx context.Context, token string) (*Claims, error)
+}
+
+// KeySet resolves the public key that signed a token
+type KeySet interface {
+	Key(ctx context.Context, kid string) (crypto.PublicKey, error)
+}
+
+// NewHMACVerifier creates a verifier for HS256 tokens signed with a shared
+// secret
+func NewHMACVerifier(secret string) Verifier {
+	return hmacVerifier{secret: []byte(secret)}
+}
+
+type hmacVerifier struct {
+	secret []byte
+}
+
+func (v hmacVerifier) Verify(_ context.Context, token string) (*Claims, error) {
+	return ParseToken(token, v.secret)
+}
+
+// NewKeySetVerifier creates a verifier for RSA and ECDSA signed tokens, such
+// as those an OpenID Connect provider issues. Tokens must carry an expiry,
+// and the issuer and audience when those are set.
+func NewKeySetVerifier(keys KeySet, issuer, audience string) Verifier {
+	return &keySetVerifier{
+		keys:     keys,
+		issuer:   issuer,
+		audience: audience,
+	}
+}
+
+type keySetVerifier struct {
+	keys     KeySet
+	issuer   string
+	audience string
+}
+
+// Verify verifies a token. Errors other than ErrInvalidToken and
+// ErrTokenExpired mean the signing keys could not be fetched.
+func (v *keySetVerifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	key, err := v.keys.Key(ctx, header.Kid)
+	if err != nil {
+		if errors.Is(err, ErrUnknownKey) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+	if !verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if err := checkTimes(&claims, time.Now()); err != nil {
+		return nil, err
+	}
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return nil, ErrInvalidToken
+	}
+	if v.audience != "" && !claims.Audience.Contains(v.audience) {
+		return nil, ErrInvalidToken
+	}
+
+	return &claims, nil
+}
+
+// signingHashes maps the asymmetric algorithms accepted to their digests
+var signingHashes = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+}
+
+// ecdsaCurveBits maps the ECDSA algorithms to the curve each is defined on
+var ecdsaCurveBits = map[string]int{
+	"ES256": 256,
+	"ES384": 384,
+	"ES512": 521,
+}
+
+// verifySignature checks a JWS signature. The algorithm must match the key
+// type, so a token cannot pick a weaker check than the key was issued for.
+func verifySignature(alg string, key crypto.PublicKey, signed string, signature []byte) bool {
+	hash, ok := signingHashes[alg]
+	if !ok {
+		return false
+	}
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		return strings.HasPrefix(alg, "RS") && rsa.VerifyPKCS1v15(pub, hash, digest, signature) == nil
+
+	case *ecdsa.PublicKey:
+		bits := pub.Curve.Params().BitSize
+		if ecdsaCurveBits[alg] != bits {
+			return false
+		}
+		size := (bits + 7) / 8
+		if len(signature) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(pub, digest, r, s)
+	}
+
+	return false
+}
+
+// ParseToken verifies an HS256-signed JWT and returns its claims. Tokens
+// must carry an expiry.
+func ParseToken(token string, secret []byte) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil || header.Alg != "HS256" {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if err := checkTimes(&claims, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return &claims, nil
+}
+
+// SignToken issues an HS256-signed JWT carrying claims, which ParseToken
+// verifies with the same secret
+func SignToken(claims *Claims, secret []byte) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// checkTimes checks a token is in force at now, allowing for clock skew.
+// Tokens without an expiry are refused, as they would never stop working.
+func checkTimes(claims *Claims, now time.Time) error {
+	if claims.ExpiresAt == 0 || claims.NotBefore > now.Add(clockSkew).Unix() {
+		return ErrInvalidToken
+	}
+	if now.Add(-clockSkew).Unix() > claims.ExpiresAt {
+		return ErrTokenExpired
+	}
+	return nil
+}
@@ -0,0 +1,334 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/pkg/localcache"
+	"ecommerce/pkg/metrics"
+)
+
+// Cache layers lookups are counted against
+const (
+	layerLocal = "local"
+	layerRedis = "redis"
+)
+
+// maxJitter bounds the jitter so an entry always lives at least half its TTL
+const maxJitter = 50
+
+var lookups = metrics.NewCounterVec(
+	"cache_lookups_total",
+	"Cache lookups by namespace, cache layer and result (hit or miss).",
+	"namespace", "layer", "result",
+)
+
+// Options configure a cache
+type Options struct {
+	Codec  Codec // how values are stored; JSON when nil
+	Jitter int   // percent of its TTL an entry may expire early by, so entries written together don't all expire together
+}
+
+// Cache reads and writes typed values in Redis, read-through: a miss loads
+// the value from its source and caches it. Values are grouped in
+// namespaces, each with its own key prefix and TTL.
+type Cache struct {
+	client redis.UniversalClient
+	codec  Codec
+	jitter int
+	logger *logrus.Logger
+}
+
+// New creates a cache storing values in Redis
+func New(client redis.UniversalClient, opts Options, logger *logrus.Logger) *Cache {
+	if opts.Codec == nil {
+		opts.Codec = JSON
+	}
+	if opts.Jitter < 0 {
+		opts.Jitter = 0
+	}
+	if opts.Jitter > maxJitter {
+		opts.Jitter = maxJitter
+	}
+
+	return &Cache{
+		client: client,
+		codec:  opts.Codec,
+		jitter: opts.Jitter,
+		logger: logger,
+	}
+}
+
+// Client returns the Redis client the cache stores values in
+func (c *Cache) Client() redis.UniversalClient {
+	return c.client
+}
+
+// Codec returns how the cache stores values
+func (c *Cache) Codec() Codec {
+	return c.codec
+}
+
+// Inspect renders a stored value as JSON, for debugging. Values the codec
+// can't decode are rendered as a JSON string.
+func (c *Cache) Inspect(data []byte) json.RawMessage {
+	if c.codec == JSON && json.Valid(data) {
+		return data
+	}
+	if c.codec != JSON {
+		var value interface{}
+		if err := c.codec.Unmarshal(data, &value); err == nil {
+			if rendered, err := json.Marshal(value); err == nil {
+				return rendered
+			}
+		}
+	}
+	quoted, _ := json.Marshal(string(data))
+	return quoted
+}
+
+// expiry returns the TTL to write an entry with, shortened by up to the
+// configured jitter
+func (c *Cache) expiry(ttl time.Duration) time.Duration {
+	spread := int64(ttl) * int64(c.jitter) / 100
+	if spread <= 0 {
+		return ttl
+	}
+	return ttl - time.Duration(rand.Int64N(spread+1))
+}
+
+// Namespace is a group of keys sharing a prefix and a TTL. A namespace
+// without a TTL is disabled: it caches nothing and every read misses.
+type Namespace struct {
+	cache *Cache
+	name  string
+	ttl   time.Duration
+	local *localcache.Cache
+}
+
+// Namespace returns the namespace with the given name, whose entries live
+// for ttl
+func (c *Cache) Namespace(name string, ttl time.Duration) *Namespace {
+	if ttl < 0 {
+		ttl = 0
+	}
+	return &Namespace{cache: c, name: name, ttl: ttl}
+}
+
+// WithLocal returns a copy of the namespace that also keeps its entries in
+// an in-process cache in front of Redis. The local cache may be nil.
+func (n *Namespace) WithLocal(local *localcache.Cache) *Namespace {
+	copied := *n
+	copied.local = local
+	return &copied
+}
+
+// Name returns the namespace's name, the first part of its keys
+func (n *Namespace) Name() string {
+	return n.name
+}
+
+// Enabled reports whether the namespace caches anything
+func (n *Namespace) Enabled() bool {
+	return n.ttl > 0
+}
+
+// Key returns the key of an entry: the namespace's name followed by the
+// parts, separated by colons
+func (n *Namespace) Key(parts ...string) string {
+	if len(parts) == 0 {
+		return n.name
+	}
+	return n.name + ":" + strings.Join(parts, ":")
+}
+
+// Delete drops entries from Redis and from the local cache of every
+// replica. It runs even when the namespace is disabled, so entries written
+// before it was disabled don't come back when it is enabled again.
+func (n *Namespace) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	n.local.Invalidate(ctx, keys...)
+	if _, err := n.cache.Delete(ctx, keys...); err != nil {
+		return fmt.Errorf("failed to delete cache entries: %w", err)
+	}
+	return nil
+}
+
+// record counts lookups. Local lookups are only counted while the
+// namespace has a local cache.
+func (n *Namespace) record(layer string, hits, misses int) {
+	if layer == layerLocal && n.local == nil {
+		return
+	}
+	if hits > 0 {
+		lookups.Add(uint64(hits), n.name, layer, "hit")
+	}
+	if misses > 0 {
+		lookups.Add(uint64(misses), n.name, layer, "miss")
+	}
+}
+
+// Get reads an entry. An entry that can't be decoded, such as one written
+// by another codec, is a miss; errors are only returned when Redis fails.
+func Get[T any](ctx context.Context, n *Namespace, key string) (T, bool, error) {
+	var value T
+	if !n.Enabled() {
+		return value, false, nil
+	}
+
+	if data, ok := n.local.Get(key); ok {
+		if err := n.cache.codec.Unmarshal(data, &value); err == nil {
+			n.record(layerLocal, 1, 0)
+			return value, true, nil
+		}
+		value = *new(T)
+	}
+	n.record(layerLocal, 0, 1)
+
+	data, err := n.cache.client.Get(ctx, key).Bytes()
+	if err != nil {
+		n.record(layerRedis, 0, 1)
+		if err == redis.Nil {
+			return value, false, nil
+		}
+		return value, false, fmt.Errorf("failed to read cache entry: %w", err)
+	}
+	if err := n.cache.codec.Unmarshal(data, &value); err != nil {
+		n.record(layerRedis, 0, 1)
+		return *new(T), false, nil
+	}
+	n.record(layerRedis, 1, 0)
+	n.local.Set(key, data)
+	return value, true, nil
+}
+
+// GetMany reads several entries in one round trip, returning those found
+// keyed by cache key
+func GetMany[T any](ctx context.Context, n *Namespace, keys []string) (map[string]T, error) {
+	values := make(map[string]T, len(keys))
+	if !n.Enabled() || len(keys) == 0 {
+		return values, nil
+	}
+
+	var pending []string
+	for _, key := range keys {
+		if data, ok := n.local.Get(key); ok {
+			var value T
+			if err := n.cache.codec.Unmarshal(data, &value); err == nil {
+				values[key] = value
+				continue
+			}
+		}
+		pending = append(pending, key)
+	}
+	n.record(layerLocal, len(values), len(pending))
+	if len(pending) == 0 {
+		return values, nil
+	}
+
+	cached, err := n.cache.mget(ctx, pending...)
+	if err != nil {
+		n.record(layerRedis, 0, len(pending))
+		return values, fmt.Errorf("failed to read cache entries: %w", err)
+	}
+	hits := 0
+	for idx, entry := range cached {
+		data, ok := entry.(string)
+		if !ok {
+			continue
+		}
+		var value T
+		if err := n.cache.codec.Unmarshal([]byte(data), &value); err != nil {
+			continue
+		}
+		values[pending[idx]] = value
+		n.local.Set(pending[idx], []byte(data))
+		hits++
+	}
+	n.record(layerRedis, hits, len(pending)-hits)
+	return values, nil
+}
+
+// Set writes an entry for the namespace's TTL
+func Set[T any](ctx context.Context, n *Namespace, key string, value T) error {
+	return SetWithTTL(ctx, n, key, value, n.ttl)
+}
+
+// SetWithTTL writes an entry for the given TTL rather than the
+// namespace's, for entries that carry their own lifetime
+func SetWithTTL[T any](ctx context.Context, n *Namespace, key string, value T, ttl time.Duration) error {
+	if !n.Enabled() || ttl <= 0 {
+		return nil
+	}
+
+	data, err := n.cache.codec.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+	if err := n.cache.client.Set(ctx, key, data, n.cache.expiry(ttl)).Err(); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	n.local.Set(key, data)
+	return nil
+}
+
+// SetMany writes several entries, keyed by cache key, in a single pipeline
+func SetMany[T any](ctx context.Context, n *Namespace, values map[string]T) error {
+	if !n.Enabled() || len(values) == 0 {
+		return nil
+	}
+
+	encoded := make(map[string][]byte, len(values))
+	for key, value := range values {
+		data, err := n.cache.codec.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to encode cache entry: %w", err)
+		}
+		encoded[key] = data
+	}
+
+	_, err := n.cache.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, data := range encoded {
+			pipe.Set(ctx, key, data, n.cache.expiry(n.ttl))
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write cache entries: %w", err)
+	}
+	for key, data := range encoded {
+		n.local.Set(key, data)
+	}
+	return nil
+}
+
+// GetOrLoad reads an entry, or on a miss loads it and caches it. Load
+// errors are returned as they are and nothing is cached; a failing cache
+// only costs the hit, since the value is then loaded from its source.
+func GetOrLoad[T any](ctx context.Context, n *Namespace, key string, load func(ctx context.Context) (T, error)) (T, error) {
+	value, ok, err := Get[T](ctx, n, key)
+	if err != nil {
+		n.cache.logger.WithError(err).WithField("namespace", n.name).Warn("Failed to read cache")
+	}
+	if ok {
+		return value, nil
+	}
+
+	value, err = load(ctx)
+	if err != nil {
+		return value, err
+	}
+	if err := Set(ctx, n, key, value); err != nil {
+		n.cache.logger.WithError(err).WithField("namespace", n.name).Warn("Failed to write cache")
+	}
+	return value, nil
+}
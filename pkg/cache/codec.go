@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/ugorji/go/codec"
+)
+
+// Codec turns values into the bytes stored in the cache and back
+type Codec interface {
+	Name() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSON stores values as JSON, readable with redis-cli
+var JSON Codec = jsonCodec{}
+
+// MsgPack stores values as MessagePack, which is smaller and quicker to
+// decode than JSON, especially for binary data such as response bodies
+var MsgPack Codec = msgpackCodec{}
+
+// CodecByName returns the codec with the given name, json or msgpack
+func CodecByName(name string) (Codec, error) {
+	switch name {
+	case "", JSON.Name():
+		return JSON, nil
+	case MsgPack.Name():
+		return MsgPack, nil
+	}
+	return nil, fmt.Errorf("unknown cache codec %q", name)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string {
+	return "json"
+}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// msgpackHandle reads the json tags of structs, so types cache the same
+// fields with either codec, and decodes untyped maps with string keys so
+// they can be shown as JSON
+var msgpackHandle = func() *codec.MsgpackHandle {
+	handle := &codec.MsgpackHandle{}
+	handle.WriteExt = true
+	handle.RawToString = true
+	handle.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	return handle
+}()
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string {
+	return "msgpack"
+}
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	var data []byte
+	if err := codec.NewEncoderBytes(&data, msgpackHandle).Encode(v); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	return codec.NewDecoderBytes(data, msgpackHandle).Decode(v)
+}
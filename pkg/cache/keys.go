@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// scanBatch is the number of keys asked for per SCAN call
+const scanBatch = 500
+
+// StopScan is returned by a Scan callback to end the scan early; Scan then
+// returns nil
+var StopScan = errors.New("stop scan")
+
+// Delete drops keys and returns how many existed. In a Redis Cluster the
+// keys may live in different slots, so they are deleted one by one in a
+// pipeline rather than with a single DEL.
+func (c *Cache) Delete(ctx context.Context, keys ...string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	if _, ok := c.client.(*redis.ClusterClient); !ok {
+		return c.client.Del(ctx, keys...).Result()
+	}
+
+	cmds := make([]*redis.IntCmd, 0, len(keys))
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			cmds = append(cmds, pipe.Del(ctx, key))
+		}
+		return nil
+	})
+	var deleted int64
+	for _, cmd := range cmds {
+		deleted += cmd.Val()
+	}
+	return deleted, err
+}
+
+// Scan calls fn with every batch of keys matching a pattern, on every
+// master of a Redis Cluster. Calls to fn are never concurrent.
+func (c *Cache) Scan(ctx context.Context, pattern string, fn func(keys []string) error) error {
+	var mu sync.Mutex
+	scan := func(ctx context.Context, client redis.Cmdable) error {
+		var cursor uint64
+		for {
+			batch, next, err := client.Scan(ctx, cursor, pattern, scanBatch).Result()
+			if err != nil {
+				return fmt.Errorf("failed to scan keys: %w", err)
+			}
+			mu.Lock()
+			err = fn(batch)
+			mu.Unlock()
+			if err != nil || next == 0 {
+				return err
+			}
+			cursor = next
+		}
+	}
+
+	var err error
+	if cluster, ok := c.client.(*redis.ClusterClient); ok {
+		err = cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return scan(ctx, node)
+		})
+	} else {
+		err = scan(ctx, c.client)
+	}
+	if errors.Is(err, StopScan) {
+		return nil
+	}
+	return err
+}
+
+// mget reads several keys, with a single MGET on one node or a pipeline of
+// GETs across a cluster's slots. Missing keys read as nil.
+func (c *Cache) mget(ctx context.Context, keys ...string) ([]interface{}, error) {
+	if _, ok := c.client.(*redis.ClusterClient); !ok {
+		return c.client.MGet(ctx, keys...).Result()
+	}
+
+	cmds := make([]*redis.StringCmd, 0, len(keys))
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			cmds = append(cmds, pipe.Get(ctx, key))
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(cmds))
+	for idx, cmd := range cmds {
+		if cmd.Err() == nil {
+			values[idx] = cmd.Val()
+		}
+	}
+	return values, nil
+}
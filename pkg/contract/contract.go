@@ -0,0 +1,122 @@
+// Package contract runs consumer-driven contract tests between services.
+//
+// A contract is written by the consumer of an API and lists the requests it
+// makes and the parts of each response it relies on. The consumer's tests
+// run its client against a mock provider serving the contract, and the
+// provider's tests replay the contract against its real handlers, so a
+// change on either side that breaks the other fails the build.
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+// Contract is the set of interactions a consumer has with a provider
+type Contract struct {
+	Consumer     string        `json:"consumer"`
+	Provider     string        `json:"provider"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one request a consumer makes and the response it expects.
+// Path segments, header values and request body strings may hold
+// {placeholders}, filled in from the provider state when the contract is
+// verified and matching anything in the mock.
+type Interaction struct {
+	Description string   `json:"description"`
+	State       string   `json:"state,omitempty"` // what the provider must hold beforehand
+	Request     Request  `json:"request"`
+	Response    Response `json:"response"`
+}
+
+// Request is the request a consumer makes. Its body is matched by type.
+type Request struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// Response is what the consumer relies on in the reply. The body is matched
+// by type: the provider may send more fields, but not fewer or different
+// kinds. Values at the Exact paths, such as $.code, must match as well.
+// Header values match when the provider's starts with them.
+type Response struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+	Exact   []string          `json:"exact,omitempty"`
+}
+
+// Load reads a contract from a JSON file in fsys
+func Load(fsys fs.FS, name string) (*Contract, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read contract: %w", err)
+	}
+
+	var c Contract
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to decode contract %s: %w", name, err)
+	}
+	for _, interaction := range c.Interactions {
+		if interaction.Description == "" || interaction.Request.Method == "" || interaction.Request.Path == "" || interaction.Response.Status == 0 {
+			return nil, fmt.Errorf("contract %s has an incomplete interaction %q", name, interaction.Description)
+		}
+	}
+	return &c, nil
+}
+
+// Interaction returns the interaction with the given description
+func (c *Contract) Interaction(description string) (*Interaction, bool) {
+	for i := range c.Interactions {
+		if c.Interactions[i].Description == description {
+			return &c.Interactions[i], true
+		}
+	}
+	return nil, false
+}
+
+// matchPath reports whether a request path matches a contract path, where
+// a {placeholder} segment matches any one segment
+func matchPath(pattern, path string) bool {
+	want := strings.Split(pattern, "/")
+	got := strings.Split(path, "/")
+	if len(want) != len(got) {
+		return false
+	}
+	for i := range want {
+		if !isPlaceholder(want[i]) && want[i] != got[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// isPlaceholder reports whether s is a {placeholder}
+func isPlaceholder(s string) bool {
+	return len(s) > 2 && strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}")
+}
+
+// fill replaces the {placeholders} in s with their values
+func fill(s string, values map[string]string) string {
+	for name, value := range values {
+		s = strings.ReplaceAll(s, "{"+name+"}", value)
+	}
+	return s
+}
+
+// matchHeaders checks that a response carries the headers a contract
+// expects
+func matchHeaders(want map[string]string, got http.Header) error {
+	for name, value := range want {
+		if !strings.HasPrefix(got.Get(name), value) {
+			return fmt.Errorf("header %s is %q, want %q", name, got.Get(name), value)
+		}
+	}
+	return nil
+}
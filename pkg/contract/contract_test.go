@@ -0,0 +1,87 @@
+package contract
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	example := `{"success": true, "code": "OK", "data": {"items": [{"id": "a", "quantity": 2}]}, "meta": null}`
+	exact := []string{"$.code", "$.data.items[*].quantity"}
+
+	tests := []struct {
+		name    string
+		actual  string
+		problem string
+	}{
+		{"equal", `{"success": true, "code": "OK", "data": {"items": [{"id": "b", "quantity": 2}]}}`, ""},
+		{"extra fields", `{"success": false, "code": "OK", "extra": 1, "data": {"items": [{"id": "b", "quantity": 2, "sku": "x"}]}}`, ""},
+		{"missing field", `{"success": true, "code": "OK", "data": {"items": [{"quantity": 2}]}}`, "$.data.items[0].id is missing"},
+		{"wrong type", `{"success": "yes", "code": "OK", "data": {"items": [{"id": "b", "quantity": 2}]}}`, "$.success is a string, want a boolean"},
+		{"exact value", `{"success": true, "code": "NOPE", "data": {"items": [{"id": "b", "quantity": 2}]}}`, `$.code is "NOPE", want "OK"`},
+		{"exact element", `{"success": true, "code": "OK", "data": {"items": [{"id": "b", "quantity": 2}, {"id": "c", "quantity": 3}]}}`, "$.data.items[1].quantity is 3, want 2"},
+		{"empty array", `{"success": true, "code": "OK", "data": {"items": []}}`, "$.data.items is empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Match([]byte(example), []byte(tt.actual), exact)
+			switch {
+			case tt.problem == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.problem != "" && (err == nil || !strings.Contains(err.Error(), tt.problem)):
+				t.Fatalf("got %v, want %q", err, tt.problem)
+			}
+		})
+	}
+}
+
+func TestCheckProto(t *testing.T) {
+	published := `syntax = "proto3";
+
+service ProductService {
+    rpc GetProduct(GetProductRequest) returns (GetProductResponse);
+}
+
+message GetProductRequest {
+    string id = 1;
+}
+
+message GetProductResponse {
+    string id = 1;
+    float price = 2;
+    Status status = 3;
+
+    enum Status {
+        DRAFT = 0;
+        PUBLISHED = 1;
+    }
+}`
+
+	tests := []struct {
+		name    string
+		current string
+		problem string
+	}{
+		{"unchanged", published, ""},
+		{"added field", strings.Replace(published, "string id = 1;\n    float", "string id = 1;\n    string sku = 4;\n    float", 1), ""},
+		{"removed field", strings.Replace(published, "float price = 2;", "", 1), "field GetProductResponse.price = 2 was removed"},
+		{"changed type", strings.Replace(published, "float price = 2;", "double price = 2;", 1), "changed from float to double"},
+		{"renumbered", strings.Replace(published, "float price = 2;", "float price = 5;", 1), "field GetProductResponse.price = 2 was removed"},
+		{"removed rpc", strings.Replace(published, "rpc GetProduct(GetProductRequest) returns (GetProductResponse);", "", 1), "rpc ProductService.GetProduct was removed"},
+		{"streamed rpc", strings.Replace(published, "returns (GetProductResponse)", "returns (stream GetProductResponse)", 1), "rpc ProductService.GetProduct changed"},
+		{"removed enum value", strings.Replace(published, "PUBLISHED = 1;", "", 1), "enum value GetProductResponse.Status.PUBLISHED = 1 was removed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckProto([]byte(published), []byte(tt.current))
+			switch {
+			case tt.problem == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.problem != "" && (err == nil || !strings.Contains(err.Error(), tt.problem)):
+				t.Fatalf("got %v, want %q", err, tt.problem)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Match checks a JSON document against the example a contract gives:
+//   - objects must have every field of the example, and may have more
+//   - arrays must match the example's first element in every element, and
+//     may only be empty when the example is
+//   - other values must be of the same JSON type; a null example matches
+//     anything, including a missing field
+//
+// Values at the exact paths must also equal the example's. Paths are
+// written $.field.nested, with [*] standing for every element of an array.
+func Match(example, actual []byte, exact []string) error {
+	if len(example) == 0 {
+		return nil
+	}
+
+	var want, got interface{}
+	if err := json.Unmarshal(example, &want); err != nil {
+		return fmt.Errorf("invalid example: %w", err)
+	}
+	if err := json.Unmarshal(actual, &got); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	m := matcher{exact: exact}
+	m.match("$", "$", want, got)
+	if len(m.problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(m.problems, "; "))
+	}
+	return nil
+}
+
+// matcher collects the differences between an example and a document
+type matcher struct {
+	exact    []string
+	problems []string
+}
+
+// match compares got with want. pattern is the path with [*] for array
+// elements, used to look up exact paths; path names the actual element.
+func (m *matcher) match(pattern, path string, want, got interface{}) {
+	if want == nil {
+		return
+	}
+	if slices.Contains(m.exact, pattern) && !reflect.DeepEqual(want, got) {
+		m.problems = append(m.problems, fmt.Sprintf("%s is %s, want %s", path, describe(got), describe(want)))
+		return
+	}
+
+	switch want := want.(type) {
+	case map[string]interface{}:
+		got, ok := got.(map[string]interface{})
+		if !ok {
+			m.mismatch(path, want, got)
+			return
+		}
+		for key, value := range want {
+			field, ok := got[key]
+			if !ok && value != nil {
+				m.problems = append(m.problems, fmt.Sprintf("%s.%s is missing", path, key))
+				continue
+			}
+			m.match(pattern+"."+key, path+"."+key, value, field)
+		}
+
+	case []interface{}:
+		got, ok := got.([]interface{})
+		if !ok {
+			m.mismatch(path, want, got)
+			return
+		}
+		if len(want) == 0 {
+			return
+		}
+		if len(got) == 0 {
+			m.problems = append(m.problems, fmt.Sprintf("%s is empty", path))
+			return
+		}
+		for i, element := range got {
+			m.match(pattern+"[*]", fmt.Sprintf("%s[%d]", path, i), want[0], element)
+		}
+
+	default:
+		if reflect.TypeOf(want) != reflect.TypeOf(got) {
+			m.mismatch(path, want, got)
+		}
+	}
+}
+
+func (m *matcher) mismatch(path string, want, got interface{}) {
+	m.problems = append(m.problems, fmt.Sprintf("%s is %s, want %s", path, kind(got), kind(want)))
+}
+
+// kind names the JSON type of a decoded value
+func kind(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case string:
+		return "a string"
+	case float64:
+		return "a number"
+	case bool:
+		return "a boolean"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// describe formats a decoded value for an error message
+func describe(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return kind(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,121 @@
+package contract
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// Mock is a provider stand-in for consumer tests. It serves the response of
+// the interaction the test expects, after checking that the consumer sent
+// the request the contract describes.
+type Mock struct {
+	*httptest.Server
+
+	t        *testing.T
+	contract *Contract
+
+	mu       sync.Mutex
+	current  *Interaction
+	problems []string
+	used     map[string]bool
+}
+
+// NewMock starts a mock provider for a contract. When the test ends it
+// fails if any interaction was not exercised, so a contract cannot list
+// requests the consumer no longer makes.
+func NewMock(t *testing.T, c *Contract) *Mock {
+	t.Helper()
+
+	m := &Mock{t: t, contract: c, used: make(map[string]bool)}
+	m.Server = httptest.NewServer(http.HandlerFunc(m.serve))
+	t.Cleanup(func() {
+		m.Close()
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		for _, problem := range m.problems {
+			t.Errorf("contract %s -> %s: %s", c.Consumer, c.Provider, problem)
+		}
+		for _, interaction := range c.Interactions {
+			if !m.used[interaction.Description] {
+				t.Errorf("contract %s -> %s: interaction %q was not exercised", c.Consumer, c.Provider, interaction.Description)
+			}
+		}
+	})
+	return m
+}
+
+// Expect makes the mock answer the next requests with the named interaction
+func (m *Mock) Expect(description string) {
+	m.t.Helper()
+
+	interaction, ok := m.contract.Interaction(description)
+	if !ok {
+		m.t.Fatalf("contract %s -> %s has no interaction %q", m.contract.Consumer, m.contract.Provider, description)
+	}
+	m.mu.Lock()
+	m.current = interaction
+	m.mu.Unlock()
+}
+
+func (m *Mock) serve(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	interaction := m.current
+	if interaction == nil {
+		m.fail(w, "unexpected %s %s with no interaction expected", r.Method, r.URL.Path)
+		return
+	}
+	if err := checkRequest(interaction.Request, r); err != nil {
+		m.fail(w, "interaction %q: %v", interaction.Description, err)
+		return
+	}
+	m.used[interaction.Description] = true
+
+	for name, value := range interaction.Response.Headers {
+		w.Header().Set(name, value)
+	}
+	if len(interaction.Response.Body) > 0 && w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	}
+	w.WriteHeader(interaction.Response.Status)
+	_, _ = w.Write(interaction.Response.Body)
+}
+
+// fail records a problem and answers with a status no consumer expects
+func (m *Mock) fail(w http.ResponseWriter, format string, args ...interface{}) {
+	m.problems = append(m.problems, fmt.Sprintf(format, args...))
+	http.Error(w, "request does not match the contract", http.StatusTeapot)
+}
+
+// checkRequest checks a consumer's request against the contract
+func checkRequest(want Request, r *http.Request) error {
+	if r.Method != want.Method || !matchPath(want.Path, r.URL.Path) {
+		return fmt.Errorf("got %s %s, want %s %s", r.Method, r.URL.Path, want.Method, want.Path)
+	}
+	for name, value := range want.Headers {
+		got := r.Header.Get(name)
+		if got == "" {
+			return fmt.Errorf("header %s is missing", name)
+		}
+		if !isPlaceholder(value) && got != value {
+			return fmt.Errorf("header %s is %q, want %q", name, got, value)
+		}
+	}
+	if len(want.Body) == 0 {
+		return nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read request body: %w", err)
+	}
+	if err := Match(want.Body, body, nil); err != nil {
+		return fmt.Errorf("request body: %w", err)
+	}
+	return nil
+}
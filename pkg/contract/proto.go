@@ -0,0 +1,185 @@
+package contract
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	protoBlock = regexp.MustCompile(`^(message|enum|service|oneof)\s+(\w+)\s*\{$`)
+	protoField = regexp.MustCompile(`^(?:(repeated|optional)\s+)?([\w.]+|map\s*<\s*[\w.]+\s*,\s*[\w.]+\s*>)\s+(\w+)\s*=\s*(\d+)`)
+	protoValue = regexp.MustCompile(`^(\w+)\s*=\s*(-?\d+)`)
+	protoRPC   = regexp.MustCompile(`^rpc\s+(\w+)\s*\(\s*(stream\s+)?([\w.]+)\s*\)\s*returns\s*\(\s*(stream\s+)?([\w.]+)\s*\)`)
+)
+
+// protoSchema is the part of a .proto file that decides wire compatibility
+type protoSchema struct {
+	fields map[string]map[string]string // message -> field number -> label and type
+	names  map[string]map[string]string // message -> field number -> name
+	values map[string]map[string]string // enum -> value number -> name
+	rpcs   map[string]string            // service.rpc -> signature
+}
+
+// CheckProto reports the changes in current that break clients built
+// against published: removed services, RPCs, messages, fields and enum
+// values, changed RPC signatures, and fields that were renumbered or
+// changed type. Adding to the schema is always allowed.
+func CheckProto(published, current []byte) error {
+	before, err := parseProto(published)
+	if err != nil {
+		return fmt.Errorf("failed to parse published schema: %w", err)
+	}
+	after, err := parseProto(current)
+	if err != nil {
+		return fmt.Errorf("failed to parse current schema: %w", err)
+	}
+
+	var problems []string
+	for _, rpc := range slices.Sorted(maps.Keys(before.rpcs)) {
+		signature, ok := after.rpcs[rpc]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("rpc %s was removed", rpc))
+		case signature != before.rpcs[rpc]:
+			problems = append(problems, fmt.Sprintf("rpc %s changed from %s to %s", rpc, before.rpcs[rpc], signature))
+		}
+	}
+	for _, message := range slices.Sorted(maps.Keys(before.fields)) {
+		fields, ok := after.fields[message]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("message %s was removed", message))
+			continue
+		}
+		for _, number := range slices.Sorted(maps.Keys(before.fields[message])) {
+			name := before.names[message][number]
+			typ, ok := fields[number]
+			switch {
+			case !ok:
+				problems = append(problems, fmt.Sprintf("field %s.%s = %s was removed", message, name, number))
+			case typ != before.fields[message][number]:
+				problems = append(problems, fmt.Sprintf("field %s.%s = %s changed from %s to %s", message, name, number, before.fields[message][number], typ))
+			}
+		}
+	}
+	for _, enum := range slices.Sorted(maps.Keys(before.values)) {
+		values, ok := after.values[enum]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("enum %s was removed", enum))
+			continue
+		}
+		for _, number := range slices.Sorted(maps.Keys(before.values[enum])) {
+			if _, ok := values[number]; !ok {
+				problems = append(problems, fmt.Sprintf("enum value %s.%s = %s was removed", enum, before.values[enum][number], number))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("breaking schema changes: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// parseProto reads the messages, enums and services of a proto3 file. It
+// understands the subset of the language the repo's schemas use, with
+// nested declarations named Outer.Inner.
+func parseProto(data []byte) (*protoSchema, error) {
+	schema := &protoSchema{
+		fields: make(map[string]map[string]string),
+		names:  make(map[string]map[string]string),
+		values: make(map[string]map[string]string),
+		rpcs:   make(map[string]string),
+	}
+
+	type block struct{ kind, name string }
+	var stack []block
+	// scope names the innermost message or enum, skipping oneof blocks
+	scope := func() (block, bool) {
+		for i := len(stack) - 1; i >= 0; i-- {
+			if stack[i].kind != "oneof" {
+				return stack[i], true
+			}
+		}
+		return block{}, false
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "":
+		case line == "}":
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: unbalanced }", n)
+			}
+			stack = stack[:len(stack)-1]
+
+		case protoBlock.MatchString(line):
+			m := protoBlock.FindStringSubmatch(line)
+			name := m[2]
+			if outer, ok := scope(); ok && m[1] != "oneof" {
+				name = outer.name + "." + name
+			}
+			stack = append(stack, block{kind: m[1], name: name})
+			switch m[1] {
+			case "message":
+				schema.fields[name] = make(map[string]string)
+				schema.names[name] = make(map[string]string)
+			case "enum":
+				schema.values[name] = make(map[string]string)
+			}
+
+		case strings.HasPrefix(line, "rpc "):
+			outer, ok := scope()
+			m := protoRPC.FindStringSubmatch(line)
+			if !ok || outer.kind != "service" || m == nil {
+				return nil, fmt.Errorf("line %d: unexpected rpc", n)
+			}
+			schema.rpcs[outer.name+"."+m[1]] = fmt.Sprintf("(%s%s) returns (%s%s)", m[2], m[3], m[4], m[5])
+
+		case strings.HasPrefix(line, "syntax"), strings.HasPrefix(line, "package"), strings.HasPrefix(line, "import"),
+			strings.HasPrefix(line, "option"), strings.HasPrefix(line, "reserved"):
+
+		default:
+			outer, ok := scope()
+			if !ok {
+				return nil, fmt.Errorf("line %d: unexpected %q", n, line)
+			}
+			switch outer.kind {
+			case "message":
+				m := protoField.FindStringSubmatch(line)
+				if m == nil {
+					return nil, fmt.Errorf("line %d: unexpected %q in message %s", n, line, outer.name)
+				}
+				typ := strings.Join(strings.Fields(strings.TrimSpace(m[1]+" "+m[2])), " ")
+				schema.fields[outer.name][m[4]] = typ
+				schema.names[outer.name][m[4]] = m[3]
+			case "enum":
+				m := protoValue.FindStringSubmatch(line)
+				if m == nil {
+					return nil, fmt.Errorf("line %d: unexpected %q in enum %s", n, line, outer.name)
+				}
+				schema.values[outer.name][m[2]] = m[1]
+			default:
+				return nil, fmt.Errorf("line %d: unexpected %q in %s %s", n, line, outer.kind, outer.name)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("%s %s is not closed", stack[len(stack)-1].kind, stack[len(stack)-1].name)
+	}
+	return schema, nil
+}
@@ -0,0 +1,56 @@
+package contract
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// State sets up what an interaction needs the provider to hold, such as a
+// product with stock, and returns the values its {placeholders} stand for
+type State func(t *testing.T) map[string]string
+
+// Verify replays every interaction of a contract against a provider's
+// handler, each in its own subtest, and fails when a response no longer
+// gives the consumer what it relies on. Every state the contract names
+// must have a setup.
+func Verify(t *testing.T, c *Contract, handler http.Handler, states map[string]State) {
+	t.Helper()
+
+	for _, interaction := range c.Interactions {
+		t.Run(interaction.Description, func(t *testing.T) {
+			values := map[string]string{}
+			if interaction.State != "" {
+				setup, ok := states[interaction.State]
+				if !ok {
+					t.Fatalf("no setup for provider state %q", interaction.State)
+				}
+				values = setup(t)
+			}
+
+			body := fill(string(interaction.Request.Body), values)
+			req := httptest.NewRequest(interaction.Request.Method, fill(interaction.Request.Path, values), strings.NewReader(body))
+			if len(interaction.Request.Body) > 0 {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			for name, value := range interaction.Request.Headers {
+				req.Header.Set(name, fill(value, values))
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			want := interaction.Response
+			if rec.Code != want.Status {
+				t.Fatalf("%s %s: status %d, want %d: %s", req.Method, req.URL.Path, rec.Code, want.Status, rec.Body.String())
+			}
+			if err := matchHeaders(want.Headers, rec.Header()); err != nil {
+				t.Errorf("%s %s: %v", req.Method, req.URL.Path, err)
+			}
+			if err := Match(want.Body, rec.Body.Bytes(), want.Exact); err != nil {
+				t.Errorf("%s %s: %v\n%s", req.Method, req.URL.Path, err, rec.Body.String())
+			}
+		})
+	}
+}
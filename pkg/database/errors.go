@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	customErrors "ecommerce/pkg/errors"
+)
+
+// SQLSTATEs Postgres rejects a write breaking a constraint with, and
+// cancels a statement past its timeout with
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+	checkViolation      = "23514"
+	queryCanceled       = "57014"
+)
+
+// IsUniqueViolation reports whether err is a write rejected by the named
+// unique index or constraint, such as a concurrent insert of the same key
+// that a check made before the write could not see
+func IsUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation && pgErr.ConstraintName == constraint
+}
+
+// TranslateError turns a failure caused by a violated constraint into the
+// application error it stands for, so the client gets a conflict or a
+// validation error instead of an internal error: a unique violation is a
+// conflict, a foreign key or check violation is invalid input. A query cut
+// short by the statement timeout or the request's deadline makes the
+// service unavailable. Application errors other than internal ones, and
+// errors with other causes, are returned as they are.
+func TranslateError(err error) error {
+	var appErr *customErrors.AppError
+	if errors.As(err, &appErr) && !customErrors.IsInternal(err) {
+		return err
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return customErrors.NewUnavailableError("Request timed out", err)
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	switch pgErr.Code {
+	case uniqueViolation:
+		return customErrors.NewConflictError("Resource already exists", err)
+	case foreignKeyViolation:
+		return customErrors.NewValidationError("Invalid reference to a related resource", err)
+	case checkViolation:
+		return customErrors.NewValidationError("Invalid value", err)
+	case queryCanceled:
+		return customErrors.NewUnavailableError("Request timed out", err)
+	}
+	return err
+}
@@ -1,7 +1,10 @@
 package database
 
 import (
+	"database/sql"
 	"fmt"
+	"net"
+	"strconv"
 	"time"
 
 	"gorm.io/driver/postgres"
@@ -11,18 +14,14 @@ import (
 	"ecommerce/internal/product/config"
 )
 
-// NewPostgresConnection creates a new PostgreSQL database connection
+// NewPostgresConnection creates a new PostgreSQL database connection. With
+// read replicas configured, reads are routed to them; see WithPrimary.
 func NewPostgresConnection(cfg config.DatabaseConfig) (*gorm.DB, error) {
-	dsn := fmt.Sprintf(
-		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s TimeZone=%s",
-		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name, cfg.SSLMode, cfg.TimeZone,
-	)
-
 	gormConfig := &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 	}
 
-	db, err := gorm.Open(postgres.Open(dsn), gormConfig)
+	db, err := gorm.Open(postgres.Open(dsn(cfg, cfg.Host, cfg.Port)), gormConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
@@ -32,24 +31,95 @@ func NewPostgresConnection(cfg config.DatabaseConfig) (*gorm.DB, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get underlying sql.DB: %w", err)
 	}
-
-	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
-	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
-	sqlDB.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime) * time.Minute)
+	configurePool(sqlDB, cfg)
 
 	// Test the connection
 	if err := sqlDB.Ping(); err != nil {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
+	if len(cfg.Replicas) == 0 {
+		return db, nil
+	}
+
+	// Connect to the read replicas
+	replicas := &resolver{}
+	for _, replica := range cfg.Replicas {
+		host, port, err := replicaAddress(replica, cfg.Port)
+		if err != nil {
+			replicas.close()
+			sqlDB.Close()
+			return nil, err
+		}
+
+		replicaDB, err := sql.Open("pgx", dsn(cfg, host, port))
+		if err != nil {
+			replicas.close()
+			sqlDB.Close()
+			return nil, fmt.Errorf("failed to connect to database replica %s: %w", replica, err)
+		}
+		configurePool(replicaDB, cfg)
+		replicas.replicas = append(replicas.replicas, replicaDB)
+
+		if err := replicaDB.Ping(); err != nil {
+			replicas.close()
+			sqlDB.Close()
+			return nil, fmt.Errorf("failed to ping database replica %s: %w", replica, err)
+		}
+	}
+	if err := db.Use(replicas); err != nil {
+		replicas.close()
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to register database replicas: %w", err)
+	}
+
 	return db, nil
 }
 
-// Close closes the database connection
+// Close closes the database connection, and those to its read replicas
 func Close(db *gorm.DB) error {
+	if replicas, ok := db.Config.Plugins[resolverName].(*resolver); ok {
+		if err := replicas.close(); err != nil {
+			return err
+		}
+	}
+
 	sqlDB, err := db.DB()
 	if err != nil {
 		return err
 	}
 	return sqlDB.Close()
 }
+
+// dsn returns the connection string for a server of the database. The
+// statement timeout is set as a run-time parameter of every connection.
+func dsn(cfg config.DatabaseConfig, host string, port int) string {
+	dsn := fmt.Sprintf(
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s TimeZone=%s",
+		host, port, cfg.User, cfg.Password, cfg.Name, cfg.SSLMode, cfg.TimeZone,
+	)
+	if cfg.StatementTimeout > 0 {
+		dsn += fmt.Sprintf(" statement_timeout=%d", cfg.StatementTimeout*1000)
+	}
+	return dsn
+}
+
+func configurePool(sqlDB *sql.DB, cfg config.DatabaseConfig) {
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime) * time.Minute)
+}
+
+// replicaAddress splits a replica given as host or host:port, defaulting
+// to the primary's port
+func replicaAddress(replica string, defaultPort int) (string, int, error) {
+	host, portText, err := net.SplitHostPort(replica)
+	if err != nil {
+		return replica, defaultPort, nil
+	}
+	port, err := strconv.Atoi(portText)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid database replica %q: %w", replica, err)
+	}
+	return host, port, nil
+}
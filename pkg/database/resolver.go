@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+// resolverName is the name the resolver is registered under, as a plugin
+// and as a callback
+const resolverName = "database:resolver"
+
+// writeSQL matches raw SQL that writes or takes row locks, even when it
+// starts with SELECT or WITH
+var writeSQL = regexp.MustCompile(`(?i)\b(INSERT|UPDATE|DELETE|MERGE|SHARE)\b`)
+
+type primaryKey struct{}
+
+// WithPrimary returns a context whose queries read from the primary, for
+// reads that must see a write just made, such as a uniqueness check right
+// before an insert
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// resolver is a GORM plugin sending reads to read replicas in turn. Writes,
+// queries in a transaction, locking reads and reads made with a WithPrimary
+// context stay on the primary.
+type resolver struct {
+	replicas []*sql.DB
+	next     atomic.Uint64
+}
+
+// Name implements gorm.Plugin
+func (r *resolver) Name() string {
+	return resolverName
+}
+
+// Initialize implements gorm.Plugin
+func (r *resolver) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Query().Before("gorm:query").Register(resolverName, r.read); err != nil {
+		return err
+	}
+	if err := db.Callback().Row().Before("gorm:row").Register(resolverName, r.read); err != nil {
+		return err
+	}
+
+	// A chained statement can run a write after a read it shares a
+	// connection with, so writes switch back to the primary
+	if err := db.Callback().Create().Before("gorm:create").Register(resolverName, r.write); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register(resolverName, r.write); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().Before("gorm:delete").Register(resolverName, r.write); err != nil {
+		return err
+	}
+	return db.Callback().Raw().Before("gorm:raw").Register(resolverName, r.write)
+}
+
+// read routes a read to the next replica, when it may go to one
+func (r *resolver) read(db *gorm.DB) {
+	stmt := db.Statement
+	if stmt.Context != nil && stmt.Context.Value(primaryKey{}) != nil {
+		return
+	}
+	if _, ok := stmt.ConnPool.(gorm.TxCommitter); ok {
+		return
+	}
+	if _, ok := stmt.Clauses["FOR"]; ok {
+		return
+	}
+	if raw := strings.TrimSpace(stmt.SQL.String()); raw != "" {
+		upper := strings.ToUpper(raw)
+		if !strings.HasPrefix(upper, "SELECT") && !strings.HasPrefix(upper, "WITH") {
+			return
+		}
+		if writeSQL.MatchString(raw) {
+			return
+		}
+	}
+
+	stmt.ConnPool = r.replicas[r.next.Add(1)%uint64(len(r.replicas))]
+}
+
+// write moves a statement routed to a replica back to the primary
+func (r *resolver) write(db *gorm.DB) {
+	for _, replica := range r.replicas {
+		if db.Statement.ConnPool == gorm.ConnPool(replica) {
+			db.Statement.ConnPool = db.Config.ConnPool
+			return
+		}
+	}
+}
+
+// close closes the connections to every replica
+func (r *resolver) close() error {
+	var firstErr error
+	for _, replica := range r.replicas {
+		if err := replica.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,133 @@
+// Package debug serves runtime diagnostics: pprof profiles, expvar
+// variables and on-demand goroutine and heap dumps. They are served on an
+// internal port of their own, never alongside the public API.
+package debug
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	"runtime"
+	runtimepprof "runtime/pprof"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/product/config"
+)
+
+// Serve starts serving the diagnostics on the configured host and port and
+// returns a function shutting the server down. Without a port nothing is
+// served.
+func Serve(cfg config.DebugConfig, logger *logrus.Logger) func(ctx context.Context) {
+	if cfg.Port == "" {
+		return func(context.Context) {}
+	}
+
+	server := &http.Server{
+		Addr:    net.JoinHostPort(cfg.Host, cfg.Port),
+		Handler: Handler(cfg.DumpDir, logger),
+	}
+	go func() {
+		logger.Info(fmt.Sprintf("Debug server listening on %s", server.Addr))
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.WithError(err).Error("Debug server failed")
+		}
+	}()
+
+	return func(ctx context.Context) {
+		if err := server.Shutdown(ctx); err != nil {
+			logger.WithError(err).Error("Failed to shut down debug server")
+		}
+	}
+}
+
+// Handler serves the diagnostics:
+//
+//   - /debug/pprof/ the pprof profiles, as net/http/pprof serves them
+//   - /debug/vars the expvar variables
+//   - POST /debug/dump writes a goroutine dump and a heap profile to files
+//     in dumpDir, or the temporary directory when empty, and responds with
+//     their paths
+func Handler(dumpDir string, logger *logrus.Logger) http.Handler {
+	if dumpDir == "" {
+		dumpDir = os.TempDir()
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/debug/dump", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		files, err := dump(dumpDir, time.Now())
+		if err != nil {
+			logger.WithError(err).Error("Failed to write runtime dump")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		logger.WithFields(logrus.Fields{
+			"goroutines": files.Goroutines,
+			"heap":       files.Heap,
+		}).Info("Runtime dump written successfully")
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(files)
+	})
+	return mux
+}
+
+// dumpFiles are the files a dump was written to
+type dumpFiles struct {
+	Goroutines string `json:"goroutines"`
+	Heap       string `json:"heap"`
+}
+
+// dump writes the stacks of every goroutine, as text, and a heap profile
+// taken after a garbage collection, for go tool pprof
+func dump(dir string, now time.Time) (dumpFiles, error) {
+	stamp := now.UTC().Format("20060102T150405.000Z")
+	files := dumpFiles{
+		Goroutines: filepath.Join(dir, fmt.Sprintf("goroutines-%s.txt", stamp)),
+		Heap:       filepath.Join(dir, fmt.Sprintf("heap-%s.pb.gz", stamp)),
+	}
+
+	if err := writeProfile(files.Goroutines, "goroutine", 2); err != nil {
+		return dumpFiles{}, err
+	}
+	runtime.GC()
+	if err := writeProfile(files.Heap, "heap", 0); err != nil {
+		return dumpFiles{}, err
+	}
+	return files, nil
+}
+
+// writeProfile writes a runtime profile to a new file
+func writeProfile(path, profile string, debug int) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s dump: %w", profile, err)
+	}
+	if err := runtimepprof.Lookup(profile).WriteTo(file, debug); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s dump: %w", profile, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write %s dump: %w", profile, err)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package errors
+
+// Application error codes are stable identifiers clients can branch on.
+// Messages may be reworded; codes may not.
+
+// Generic codes, used when an error carries no specific code
+const (
+	CodeBadRequest   = "BAD_REQUEST"
+	CodeNotFound     = "NOT_FOUND"
+	CodeValidation   = "VALIDATION_FAILED"
+	CodeConflict     = "CONFLICT"
+	CodeInternal     = "INTERNAL_ERROR"
+	CodeUnauthorized = "UNAUTHORIZED"
+	CodeForbidden    = "FORBIDDEN"
+	CodeUnavailable  = "SERVICE_UNAVAILABLE"
+	CodeRateLimited  = "RATE_LIMITED"
+	CodeTooLarge     = "PAYLOAD_TOO_LARGE"
+)
+
+// Catalog codes
+const (
+	CodeProductNotFound         = "PRODUCT_NOT_FOUND"
+	CodeProductSKUConflict      = "PRODUCT_SKU_CONFLICT"
+	CodeProductNotPublished     = "PRODUCT_NOT_PUBLISHED"
+	CodeProductAlreadyPublished = "PRODUCT_ALREADY_PUBLISHED"
+	CodeProductRelationNotFound = "PRODUCT_RELATION_NOT_FOUND"
+	CodeInsufficientStock       = "INSUFFICIENT_STOCK"
+	CodeInvalidOrderQuantity    = "INVALID_ORDER_QUANTITY"
+	CodeProductNotInChannel     = "PRODUCT_NOT_IN_CHANNEL"
+	CodeProductNotApproved      = "PRODUCT_NOT_APPROVED"
+	CodeProductNotPendingReview = "PRODUCT_NOT_PENDING_REVIEW"
+	CodeProductNotOwned         = "PRODUCT_NOT_OWNED"
+	CodeReservationNotFound     = "RESERVATION_NOT_FOUND"
+	CodeWarehouseNotFound       = "WAREHOUSE_NOT_FOUND"
+	CodeWarehouseCodeConflict   = "WAREHOUSE_CODE_CONFLICT"
+	CodeCategoryNotFound        = "CATEGORY_NOT_FOUND"
+	CodeCategoryNameConflict    = "CATEGORY_NAME_CONFLICT"
+	CodeCategoryHasProducts     = "CATEGORY_HAS_PRODUCTS"
+	CodeCategoryCycle           = "CATEGORY_CYCLE"
+	CodeBrandNotFound           = "BRAND_NOT_FOUND"
+	CodeBrandNameConflict       = "BRAND_NAME_CONFLICT"
+	CodeBrandHasProducts        = "BRAND_HAS_PRODUCTS"
+	CodeAttributeNotFound       = "ATTRIBUTE_NOT_FOUND"
+	CodeAttributeKeyConflict    = "ATTRIBUTE_KEY_CONFLICT"
+	CodeTranslationNotFound     = "TRANSLATION_NOT_FOUND"
+	CodeUnsupportedLocale       = "UNSUPPORTED_LOCALE"
+	CodeMediaNotFound           = "MEDIA_NOT_FOUND"
+	CodeMediaNotUploaded        = "MEDIA_NOT_UPLOADED"
+	CodeMediaRejected           = "MEDIA_REJECTED"
+	CodeDigitalAssetNotFound    = "DIGITAL_ASSET_NOT_FOUND"
+	CodeEntitlementNotFound     = "ENTITLEMENT_NOT_FOUND"
+	CodeProductNotStocked       = "PRODUCT_NOT_STOCKED"
+	CodeSynonymNotFound         = "SYNONYM_NOT_FOUND"
+	CodeSynonymTermConflict     = "SYNONYM_TERM_CONFLICT"
+	CodeReviewNotFound          = "REVIEW_NOT_FOUND"
+	CodeReviewAlreadyExists     = "REVIEW_ALREADY_EXISTS"
+	CodeImportJobNotFound       = "IMPORT_JOB_NOT_FOUND"
+	CodeAuditEventNotFound      = "AUDIT_EVENT_NOT_FOUND"
+	CodeVendorNotFound          = "VENDOR_NOT_FOUND"
+	CodeVendorOwnerConflict     = "VENDOR_OWNER_CONFLICT"
+	CodeVendorSuspended         = "VENDOR_SUSPENDED"
+	CodeRevisionNotFound        = "REVISION_NOT_FOUND"
+	CodeRevisionNotPending      = "REVISION_NOT_PENDING"
+	CodeChangesetNotFound       = "CHANGESET_NOT_FOUND"
+	CodeChangesetItemNotFound   = "CHANGESET_ITEM_NOT_FOUND"
+	CodeChangesetInvalidState   = "CHANGESET_INVALID_STATE"
+	CodeChangesetNotApplicable  = "CHANGESET_NOT_APPLICABLE"
+	CodeFlashSaleNotFound       = "FLASH_SALE_NOT_FOUND"
+	CodeFlashSaleExists         = "FLASH_SALE_EXISTS"
+	CodeFlashSaleStarted        = "FLASH_SALE_STARTED"
+	CodeFlashSaleNotRunning     = "FLASH_SALE_NOT_RUNNING"
+	CodeFlashSaleSoldOut        = "FLASH_SALE_SOLD_OUT"
+	CodeFlashSaleLimitReached   = "FLASH_SALE_LIMIT_REACHED"
+)
+
+// Checkout and payment codes
+const (
+	CodeOrderNotFound           = "ORDER_NOT_FOUND"
+	CodeOrderInvalidState       = "ORDER_INVALID_STATE"
+	CodeCheckoutNotFound        = "CHECKOUT_NOT_FOUND"
+	CodeCheckoutInProgress      = "CHECKOUT_IN_PROGRESS"
+	CodeCheckoutAbandoned       = "CHECKOUT_ABANDONED"
+	CodeCheckoutCompleted       = "CHECKOUT_COMPLETED"
+	CodeIdempotencyKeyRequired  = "IDEMPOTENCY_KEY_REQUIRED"
+	CodeIdempotencyKeyReused    = "IDEMPOTENCY_KEY_REUSED"
+	CodePaymentNotFound         = "PAYMENT_NOT_FOUND"
+	CodePaymentNotAuthorized    = "PAYMENT_NOT_AUTHORIZED"
+	CodePaymentInvalidState     = "PAYMENT_INVALID_STATE"
+	CodePaymentNotCaptured      = "PAYMENT_NOT_CAPTURED"
+	CodePaymentAlreadyCaptured  = "PAYMENT_ALREADY_CAPTURED"
+	CodePaymentAlreadyRefunded  = "PAYMENT_ALREADY_REFUNDED"
+	CodePaymentReferenceReused  = "PAYMENT_REFERENCE_REUSED"
+	CodeCaptureExceedsAmount    = "CAPTURE_EXCEEDS_AMOUNT"
+	CodeRefundNotFound          = "REFUND_NOT_FOUND"
+	CodeRefundExceedsAmount     = "REFUND_EXCEEDS_AMOUNT"
+	CodePromotionNotFound       = "PROMOTION_NOT_FOUND"
+	CodePromotionRedeemed       = "PROMOTION_REDEEMED"
+	CodePromotionUsageExhausted = "PROMOTION_USAGE_EXHAUSTED"
+	CodeCouponNotFound          = "COUPON_NOT_FOUND"
+	CodeCouponCodeConflict      = "COUPON_CODE_CONFLICT"
+	CodeTaxRateNotFound         = "TAX_RATE_NOT_FOUND"
+	CodeTaxRateConflict         = "TAX_RATE_CONFLICT"
+	CodeGiftCardNotFound        = "GIFT_CARD_NOT_FOUND"
+	CodeGiftCardUnusable        = "GIFT_CARD_UNUSABLE"
+	CodeGiftCardEmpty           = "GIFT_CARD_EMPTY"
+	CodeGiftCardCurrency        = "GIFT_CARD_CURRENCY_MISMATCH"
+	CodeEarnRuleNotFound        = "EARN_RULE_NOT_FOUND"
+	CodeInsufficientPoints      = "INSUFFICIENT_LOYALTY_POINTS"
+	CodeReturnNotFound          = "RETURN_NOT_FOUND"
+	CodeReturnNotAllowed        = "RETURN_NOT_ALLOWED"
+	CodeReturnInvalidState      = "RETURN_INVALID_STATE"
+	CodeReturnExceedsOrder      = "RETURN_EXCEEDS_ORDER"
+	CodeInvoiceNotFound         = "INVOICE_NOT_FOUND"
+)
+
+// Integration codes
+const (
+	CodeAuthenticationRequired = "AUTHENTICATION_REQUIRED"
+	CodeAPIKeyNotFound         = "API_KEY_NOT_FOUND"
+	CodeAPIKeyInvalid          = "API_KEY_INVALID"
+	CodeAPIKeyInactive         = "API_KEY_INACTIVE"
+	CodeSessionNotFound        = "SESSION_NOT_FOUND"
+	CodeSessionEnded           = "SESSION_ENDED"
+	CodeTwoFactorRequired      = "TWO_FACTOR_REQUIRED"
+	CodeTwoFactorNotEnabled    = "TWO_FACTOR_NOT_ENABLED"
+	CodeTwoFactorEnabled       = "TWO_FACTOR_ALREADY_ENABLED"
+	CodeTwoFactorInvalid       = "TWO_FACTOR_CODE_INVALID"
+	CodeLoginProviderNotFound  = "LOGIN_PROVIDER_NOT_FOUND"
+	CodeLoginStateInvalid      = "LOGIN_STATE_INVALID"
+	CodeLoginRejected          = "LOGIN_REJECTED"
+	CodeLoginEmailUnverified   = "LOGIN_EMAIL_UNVERIFIED"
+	CodeLockedOut              = "LOCKED_OUT"
+	CodeCaptchaRequired        = "CAPTCHA_REQUIRED"
+	CodeInvalidSignature       = "INVALID_SIGNATURE"
+	CodeInvalidTenant          = "INVALID_TENANT"
+	CodeSubscriptionNotFound   = "SUBSCRIPTION_NOT_FOUND"
+	CodeSubscriptionInactive   = "SUBSCRIPTION_INACTIVE"
+	CodeDeliveryNotFound       = "DELIVERY_NOT_FOUND"
+	CodeNotificationNotFound   = "NOTIFICATION_NOT_FOUND"
+	CodeNotificationNotFailed  = "NOTIFICATION_NOT_FAILED"
+	CodeTemplateNotFound       = "TEMPLATE_NOT_FOUND"
+	CodeTemplateNameConflict   = "TEMPLATE_NAME_CONFLICT"
+)
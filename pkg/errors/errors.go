@@ -1,8 +1,10 @@
 package errors
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
 )
 
 // Error types
@@ -13,11 +15,13 @@ var (
 	ErrInternal     = errors.New("internal error")
 	ErrUnauthorized = errors.New("unauthorized")
 	ErrForbidden    = errors.New("forbidden")
+	ErrUnavailable  = errors.New("service unavailable")
 )
 
 // AppError represents an application error with additional context
 type AppError struct {
 	Type    error
+	Code    string // stable code from the catalogue in codes.go; optional
 	Message string
 	Cause   error
 }
@@ -33,6 +37,25 @@ func (e *AppError) Unwrap() error {
 	return e.Cause
 }
 
+// WithCode sets the error's application code and returns the error
+func (e *AppError) WithCode(code string) *AppError {
+	e.Code = code
+	return e
+}
+
+// FieldError describes why a single request field was rejected, so clients
+// can point at the offending form field
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// fieldErrorer is implemented by errors that know which fields they reject
+type fieldErrorer interface {
+	FieldErrors() []FieldError
+}
+
 // NewNotFoundError creates a new not found error
 func NewNotFoundError(message string, cause error) *AppError {
 	return &AppError{
@@ -87,6 +110,15 @@ func NewForbiddenError(message string, cause error) *AppError {
 	}
 }
 
+// NewUnavailableError creates a new service unavailable error
+func NewUnavailableError(message string, cause error) *AppError {
+	return &AppError{
+		Type:    ErrUnavailable,
+		Message: message,
+		Cause:   cause,
+	}
+}
+
 // Type checking functions
 func IsNotFound(err error) bool {
 	var appErr *AppError
@@ -135,3 +167,107 @@ func IsForbidden(err error) bool {
 	}
 	return false
 }
+
+func IsUnavailable(err error) bool {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return errors.Is(appErr.Type, ErrUnavailable)
+	}
+	return false
+}
+
+// Code returns the application code of err. The outermost specific code in
+// the chain wins, so a service wrapping a repository error keeps the
+// repository's code unless it sets its own. Errors without a specific code
+// get the generic code of their type, and errors that are not application
+// errors get none.
+func Code(err error) string {
+	var outer *AppError
+	if !errors.As(err, &outer) {
+		return ""
+	}
+
+	for appErr := outer; appErr != nil; {
+		if appErr.Code != "" {
+			return appErr.Code
+		}
+		if !errors.As(appErr.Cause, &appErr) {
+			break
+		}
+	}
+
+	switch outer.Type {
+	case ErrNotFound:
+		return CodeNotFound
+	case ErrValidation:
+		return CodeValidation
+	case ErrConflict:
+		return CodeConflict
+	case ErrUnauthorized:
+		return CodeUnauthorized
+	case ErrForbidden:
+		return CodeForbidden
+	case ErrUnavailable:
+		return CodeUnavailable
+	}
+	return CodeInternal
+}
+
+// Message returns the message of the outermost application error in err,
+// which leaves out the underlying causes, or the full error text when err
+// is not an application error
+func Message(err error) string {
+	if err == nil {
+		return ""
+	}
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr.Message
+	}
+	return err.Error()
+}
+
+// FieldErrors returns the per-field details of a validation failure, or nil
+// when err carries none. Struct validation errors and JSON type mismatches
+// anywhere in the chain are understood.
+func FieldErrors(err error) []FieldError {
+	var fieldErr fieldErrorer
+	if errors.As(err, &fieldErr) {
+		return fieldErr.FieldErrors()
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return []FieldError{{
+			Field:   typeErr.Field,
+			Code:    "type",
+			Message: "must be " + jsonTypeName(typeErr.Type),
+		}}
+	}
+	return nil
+}
+
+// jsonTypeName describes a Go type as the JSON value it decodes from
+func jsonTypeName(t reflect.Type) string {
+	if t == nil {
+		return "a valid value"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	case reflect.Ptr:
+		return jsonTypeName(t.Elem())
+	}
+	return "a valid value"
+}
@@ -0,0 +1,154 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrBusClosed is returned when publishing to a closed bus
+var ErrBusClosed = errors.New("event bus closed")
+
+// Event represents a domain event
+type Event struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Source     string          `json:"source"`
+	Data       json.RawMessage `json:"data"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
+// New creates a new event with the given payload encoded as JSON
+func New(eventType, source string, data interface{}) (Event, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to encode event data: %w", err)
+	}
+
+	return Event{
+		ID:         uuid.New().String(),
+		Type:       eventType,
+		Source:     source,
+		Data:       payload,
+		OccurredAt: time.Now().UTC(),
+	}, nil
+}
+
+// Decode decodes the event payload into v
+func (e Event) Decode(v interface{}) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+// Handler handles a single event
+type Handler func(ctx context.Context, event Event) error
+
+// Publisher publishes events
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// Bus is an in-process asynchronous event bus. Events are queued and
+// dispatched in order to the handlers subscribed to their type.
+type Bus struct {
+	logger *logrus.Logger
+	queue  chan Event
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+
+	handlersMu sync.RWMutex
+	handlers   map[string][]Handler
+}
+
+// NewBus creates a new event bus with the given queue size
+func NewBus(logger *logrus.Logger, bufferSize int) *Bus {
+	if bufferSize <= 0 {
+		bufferSize = 1
+	}
+	return &Bus{
+		logger:   logger,
+		queue:    make(chan Event, bufferSize),
+		handlers: make(map[string][]Handler),
+		done:     make(chan struct{}),
+	}
+}
+
+// Subscribe registers a handler for an event type. Use "*" to receive every event.
+func (b *Bus) Subscribe(eventType string, handler Handler) {
+	b.handlersMu.Lock()
+	defer b.handlersMu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+// Publish queues an event for dispatch, blocking while the queue is full
+func (b *Bus) Publish(ctx context.Context, event Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		return ErrBusClosed
+	}
+
+	select {
+	case b.queue <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Start starts dispatching queued events
+func (b *Bus) Start() {
+	go func() {
+		defer close(b.done)
+		for event := range b.queue {
+			b.dispatch(event)
+		}
+	}()
+}
+
+// Close stops accepting events and waits until queued events are dispatched
+func (b *Bus) Close() {
+	b.Stop(context.Background())
+}
+
+// Stop stops accepting events and waits until queued events are dispatched
+// or ctx is done, returning ctx's error if events were left undispatched
+func (b *Bus) Stop(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.queue)
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *Bus) dispatch(event Event) {
+	b.handlersMu.RLock()
+	handlers := make([]Handler, 0, len(b.handlers[event.Type])+len(b.handlers["*"]))
+	handlers = append(handlers, b.handlers[event.Type]...)
+	handlers = append(handlers, b.handlers["*"]...)
+	b.handlersMu.RUnlock()
+
+	for _, handler := range handlers {
+		if err := handler(context.Background(), event); err != nil {
+			b.logger.WithError(err).WithFields(logrus.Fields{
+				"event_id":   event.ID,
+				"event_type": event.Type,
+			}).Error("Event handler failed")
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/pkg/resilience"
+)
+
+// Forwarding limits. Delivery runs on the bus's dispatch goroutine, so
+// retries are bounded and a receiver that keeps failing is cut off for a
+// while rather than holding up every event behind it.
+const (
+	forwardAttempts           = 3
+	forwardBreakerFailures    = 5
+	forwardBreakerOpenTimeout = 30 * time.Second
+)
+
+// Forwarder delivers the events published on a bus to another service by
+// POSTing them as JSON. Receivers must deduplicate on the event ID, since an
+// event whose response is lost is sent again.
+type Forwarder struct {
+	url    string
+	client *http.Client
+	policy resilience.Policy
+	logger *logrus.Logger
+}
+
+// NewForwarder creates a forwarder that posts events to url with client
+func NewForwarder(url string, client *http.Client, timeout time.Duration, logger *logrus.Logger) *Forwarder {
+	return &Forwarder{
+		url:    url,
+		client: client,
+		policy: resilience.Policy{
+			Timeout: timeout,
+			Retry: resilience.RetryPolicy{
+				Attempts:  forwardAttempts,
+				BaseDelay: time.Second,
+				MaxDelay:  4 * time.Second,
+			},
+			Breaker: resilience.NewBreaker(forwardBreakerFailures, forwardBreakerOpenTimeout),
+		},
+		logger: logger,
+	}
+}
+
+// Register subscribes the forwarder to every event type on the bus
+func (f *Forwarder) Register(bus *Bus) {
+	bus.Subscribe("*", f.Forward)
+}
+
+// Forward posts an event, retrying with a short jittered backoff. Events
+// the receiver rejects are not retried.
+func (f *Forwarder) Forward(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	err = f.policy.Do(ctx, func(ctx context.Context) error {
+		err := f.post(ctx, payload)
+		if err != nil && !resilience.IsPermanent(err) {
+			f.logger.WithError(err).WithField("event_id", event.ID).Warn("Event forwarding attempt failed")
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to forward event: %w", err)
+	}
+	return nil
+}
+
+func (f *Forwarder) post(ctx context.Context, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		err := fmt.Errorf("unexpected status %d", resp.StatusCode)
+		if resp.StatusCode < 500 {
+			return resilience.Permanent(err)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package events_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/contracts"
+	"ecommerce/pkg/contract"
+	"ecommerce/pkg/events"
+)
+
+// TestForwarderContract forwards a catalog event to the gateway as the
+// contract describes it
+func TestForwarderContract(t *testing.T) {
+	c, err := contract.Load(contracts.FS, "product-service.api-gateway.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := contract.NewMock(t, c)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	forwarder := events.NewForwarder(mock.URL+"/api/v1/events", http.DefaultClient, time.Second, logger)
+
+	event, err := events.New("product.updated", "product-service", map[string]string{"id": "7d3c1f3e-2f0a-4c7e-9a55-1b2f3c4d5e6f"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.Expect("forward a catalog event")
+	if err := forwarder.Forward(context.Background(), event); err != nil {
+		t.Fatalf("Forward: %v", err)
+	}
+}
@@ -0,0 +1,204 @@
+// Package jobs is a queue of background jobs kept in Postgres. Jobs can be
+// delayed, are retried with exponential backoff when they fail, and are
+// dead-lettered once their attempts run out. Any number of service
+// instances can run workers over the same queue; each job is run by one of
+// them at a time.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"ecommerce/pkg/database"
+	"ecommerce/pkg/tenant"
+)
+
+// Job statuses
+const (
+	StatusPending   = "pending" // waiting to run, running, or waiting to be retried
+	StatusCompleted = "completed"
+	StatusDead      = "dead" // out of attempts, or failed in a way retrying can't fix
+)
+
+// Defaults for jobs enqueued without their own options
+const (
+	DefaultMaxAttempts = 5
+	DefaultBackoff     = 30 * time.Second
+)
+
+// maxBackoff caps the delay between attempts
+const maxBackoff = time.Hour
+
+// Job is a unit of background work of a type a worker has a handler for
+type Job struct {
+	ID          uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Type        string          `json:"type" gorm:"not null"`
+	Payload     json.RawMessage `json:"payload" gorm:"type:jsonb;serializer:json;not null"`
+	DedupeKey   *string         `json:"dedupe_key,omitempty" gorm:"uniqueIndex"`
+	Tenant      string          `json:"tenant,omitempty"` // the tenant the job was queued for; empty for system jobs
+	Status      string          `json:"status" gorm:"not null"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	Backoff     int             `json:"backoff"` // seconds before the first retry; doubles per attempt
+	RunAt       time.Time       `json:"run_at"`
+	LastError   string          `json:"last_error,omitempty"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// Decode decodes the job's payload into v
+func (j *Job) Decode(v interface{}) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+// LastAttempt reports whether the job's current attempt is its last
+func (j *Job) LastAttempt() bool {
+	return j.Attempts >= j.MaxAttempts
+}
+
+// Options tune how a job is run
+type Options struct {
+	Delay       time.Duration // before the first attempt
+	MaxAttempts int           // before the job is dead-lettered; DefaultMaxAttempts when 0
+	Backoff     time.Duration // before the first retry, doubling per attempt; DefaultBackoff when 0
+	DedupeKey   string        // while a job with the key exists, in any status, no other is queued
+}
+
+// Queue enqueues jobs and manages the dead-lettered ones
+type Queue struct {
+	db *gorm.DB
+}
+
+// NewQueue creates a queue over the jobs table
+func NewQueue(db *gorm.DB) *Queue {
+	return &Queue{db: db}
+}
+
+// Enqueue queues a job with its payload encoded as JSON. Inside a
+// transaction the job is only queued if the transaction commits. When a
+// job with the same dedupe key already exists nothing is queued and the
+// job returned is nil. A job queued in a context scoped to a tenant runs
+// scoped to it.
+func (q *Queue) Enqueue(ctx context.Context, jobType string, payload interface{}, opts Options) (*Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job payload: %w", err)
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = DefaultBackoff
+	}
+
+	job := &Job{
+		Type:        jobType,
+		Payload:     data,
+		Status:      StatusPending,
+		MaxAttempts: opts.MaxAttempts,
+		Backoff:     int(opts.Backoff / time.Second),
+		RunAt:       time.Now().Add(opts.Delay),
+	}
+	if opts.DedupeKey != "" {
+		job.DedupeKey = &opts.DedupeKey
+	}
+	if id, ok := tenant.FromContext(ctx); ok {
+		job.Tenant = id
+	}
+
+	result := database.Conn(ctx, q.db).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "dedupe_key"}},
+			DoNothing: true,
+		}).
+		Create(job)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to enqueue job: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return job, nil
+}
+
+// Dead lists the dead-lettered jobs of a type, most recent first; an empty
+// type lists them all
+func (q *Queue) Dead(ctx context.Context, jobType string, limit int) ([]Job, error) {
+	query := database.Conn(ctx, q.db).Where("status = ?", StatusDead)
+	if jobType != "" {
+		query = query.Where("type = ?", jobType)
+	}
+
+	var jobs []Job
+	if err := query.Order("updated_at DESC").Limit(limit).Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list dead jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// ErrNotDead is returned when requeueing a job that isn't dead-lettered
+var ErrNotDead = errors.New("job is not dead-lettered")
+
+// Requeue gives a dead-lettered job a fresh set of attempts, starting now
+func (q *Queue) Requeue(ctx context.Context, id uuid.UUID) error {
+	result := database.Conn(ctx, q.db).Model(&Job{}).
+		Where("id = ? AND status = ?", id, StatusDead).
+		Updates(map[string]interface{}{
+			"status":   StatusPending,
+			"attempts": 0,
+			"run_at":   time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to requeue job: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotDead
+	}
+	return nil
+}
+
+// Backoff is the delay before retrying after the given number of attempts:
+// base doubled for each attempt after the first, capped at an hour
+func Backoff(base time.Duration, attempts int) time.Duration {
+	backoff := base
+	for i := 1; i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
+
+// permanentError marks a failure that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks a handler's error as one retrying cannot fix, such as an
+// invalid payload, so the job is dead-lettered straight away
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// isPermanent reports whether err was marked with Permanent
+func isPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
@@ -0,0 +1,263 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"ecommerce/pkg/tenant"
+)
+
+// Handler runs a job. Returning an error retries the job after a backoff,
+// unless it was its last attempt or the error is marked Permanent.
+type Handler func(ctx context.Context, job *Job) error
+
+// Config tunes a worker pool
+type Config struct {
+	Workers      int           // jobs run at once
+	PollInterval time.Duration // between checks for due jobs while idle
+	Timeout      time.Duration // an attempt may run; the job's claim lasts twice as long
+	Retention    time.Duration // completed jobs are kept this long; 0 keeps them
+}
+
+// cleanupInterval is how often completed jobs past their retention are
+// removed
+const cleanupInterval = time.Hour
+
+// Worker is a pool running the jobs of the types it has handlers for. It
+// is a run.Worker: stopping it lets running jobs finish, and once the stop
+// deadline passes, interrupts them and releases them to be run again.
+type Worker struct {
+	db       *gorm.DB
+	cfg      Config
+	logger   *logrus.Logger
+	handlers map[string]Handler
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+
+	// ctx is cancelled when stopping runs out of time, interrupting the
+	// jobs still running
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewWorker creates a worker pool over the jobs table
+func NewWorker(db *gorm.DB, cfg Config, logger *logrus.Logger) *Worker {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Minute
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Worker{
+		db:       db,
+		cfg:      cfg,
+		logger:   logger,
+		handlers: make(map[string]Handler),
+		stop:     make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Handle registers the handler of a job type. Handlers must be registered
+// before the worker starts.
+func (w *Worker) Handle(jobType string, handler Handler) {
+	w.handlers[jobType] = handler
+}
+
+// Start starts the pool's workers
+func (w *Worker) Start() {
+	if len(w.handlers) == 0 {
+		return
+	}
+	for i := 0; i < w.cfg.Workers; i++ {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			w.loop()
+		}()
+	}
+	if w.cfg.Retention > 0 {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			w.cleanupLoop()
+		}()
+	}
+}
+
+// Stop stops claiming jobs and waits for running ones to finish, or until
+// ctx is done, when they are interrupted and released
+func (w *Worker) Stop(ctx context.Context) error {
+	close(w.stop)
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		w.cancel()
+		return nil
+	case <-ctx.Done():
+		w.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// loop runs due jobs one at a time, waiting a poll interval whenever none
+// are due
+func (w *Worker) loop() {
+	for {
+		select {
+		case <-w.stop:
+			return
+		default:
+		}
+
+		job, err := w.claim()
+		if err != nil {
+			w.logger.WithError(err).Error("Failed to claim job")
+		}
+		if job != nil {
+			w.process(job)
+			continue
+		}
+
+		select {
+		case <-w.stop:
+			return
+		case <-time.After(w.cfg.PollInterval):
+		}
+	}
+}
+
+// claim leases the next due job by pushing its run time out past its
+// timeout, counting the attempt. Rows locked by another worker are
+// skipped, and a worker that dies mid-job releases it when the lease runs
+// out; the attempt still counts, so a job that keeps crashing its worker
+// is dead-lettered in the end.
+func (w *Worker) claim() (*Job, error) {
+	types := make([]string, 0, len(w.handlers))
+	for jobType := range w.handlers {
+		types = append(types, jobType)
+	}
+
+	now := time.Now()
+	var jobs []Job
+	err := w.db.WithContext(w.ctx).Raw(`
+		UPDATE jobs SET run_at = ?, attempts = attempts + 1, updated_at = ?
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE status = ? AND type IN ? AND run_at <= ?
+			ORDER BY run_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		now.Add(2*w.cfg.Timeout), now, StatusPending, types, now,
+	).Scan(&jobs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	return &jobs[0], nil
+}
+
+// process runs a claimed job and records the outcome
+func (w *Worker) process(job *Job) {
+	logger := w.logger.WithFields(logrus.Fields{
+		"job_id":   job.ID,
+		"job_type": job.Type,
+		"attempt":  job.Attempts,
+	})
+
+	ctx, cancel := context.WithTimeout(w.ctx, w.cfg.Timeout)
+	if job.Tenant != "" {
+		ctx = tenant.WithID(ctx, job.Tenant)
+	}
+	err := w.run(ctx, job)
+	cancel()
+
+	// The outcome is recorded even when the worker is being stopped
+	record := context.WithoutCancel(w.ctx)
+	now := time.Now()
+	updates := map[string]interface{}{"updated_at": now}
+	switch {
+	case err == nil:
+		updates["status"] = StatusCompleted
+		updates["completed_at"] = now
+		updates["last_error"] = ""
+	case w.ctx.Err() != nil:
+		// Interrupted by shutdown: release the job for another worker,
+		// without counting the attempt against it
+		updates["run_at"] = now
+		updates["attempts"] = job.Attempts - 1
+		updates["last_error"] = err.Error()
+		logger.WithError(err).Warn("Job interrupted by shutdown; released")
+	case isPermanent(err) || job.LastAttempt():
+		updates["status"] = StatusDead
+		updates["last_error"] = err.Error()
+		logger.WithError(err).Error("Job failed; moved to the dead-letter queue")
+	default:
+		updates["run_at"] = now.Add(Backoff(time.Duration(job.Backoff)*time.Second, job.Attempts))
+		updates["last_error"] = err.Error()
+		logger.WithError(err).Warn("Job failed; will be retried")
+	}
+
+	if err := w.db.WithContext(record).Model(&Job{}).Where("id = ?", job.ID).Updates(updates).Error; err != nil {
+		// The claim's lease runs out and the job is run again, which is
+		// the lesser evil compared to losing it
+		logger.WithError(err).Error("Failed to record job outcome")
+	}
+}
+
+// run runs a job's handler, turning a panic into a failure
+func (w *Worker) run(ctx context.Context, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return w.handlers[job.Type](ctx, job)
+}
+
+// cleanupLoop removes completed jobs past their retention
+func (w *Worker) cleanupLoop() {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			types := make([]string, 0, len(w.handlers))
+			for jobType := range w.handlers {
+				types = append(types, jobType)
+			}
+			result := w.db.WithContext(w.ctx).
+				Where("status = ? AND type IN ? AND completed_at < ?", StatusCompleted, types, time.Now().Add(-w.cfg.Retention)).
+				Delete(&Job{})
+			if result.Error != nil {
+				w.logger.WithError(result.Error).Error("Failed to remove completed jobs")
+			} else if result.RowsAffected > 0 {
+				w.logger.WithField("count", result.RowsAffected).Info("Removed completed jobs")
+			}
+		}
+	}
+}
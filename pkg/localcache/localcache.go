@@ -0,0 +1,205 @@
+package localcache
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+// purgeMessage asks every replica to drop its whole cache
+const purgeMessage = "*"
+
+type entry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// Cache is a small in-process LRU cache for very hot entries, kept in front
+// of Redis to save a round trip. Entries live for a short TTL, so replicas
+// only disagree briefly; invalidations are also broadcast over Redis so
+// other replicas drop changed entries straight away.
+//
+// A nil Cache is valid and caches nothing.
+type Cache struct {
+	size int
+	ttl  time.Duration
+
+	mu    sync.Mutex
+	items map[string]*list.Element
+	order *list.List // most recently used first
+
+	client  redis.UniversalClient
+	channel string
+	logger  *logrus.Logger
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// New creates a cache holding at most size entries for ttl each. It
+// returns nil, a disabled cache, when size or ttl is not positive.
+func New(size int, ttl time.Duration) *Cache {
+	if size <= 0 || ttl <= 0 {
+		return nil
+	}
+	return &Cache{
+		size:  size,
+		ttl:   ttl,
+		items: make(map[string]*list.Element, size),
+		order: list.New(),
+	}
+}
+
+// Get returns a cached value. Callers must not modify it.
+func (c *Cache) Get(key string) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	e := element.Value.(*entry)
+	if time.Now().After(e.expiresAt) {
+		c.remove(element)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return e.value, true
+}
+
+// Set caches a value, evicting the least recently used entry when full
+func (c *Cache) Set(key string, value []byte) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Now().Add(c.ttl)
+	if element, ok := c.items[key]; ok {
+		e := element.Value.(*entry)
+		e.value = value
+		e.expiresAt = expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry{key: key, value: value, expiresAt: expiresAt})
+	if c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+// Invalidate drops entries here and, when broadcasting, on every other
+// replica
+func (c *Cache) Invalidate(ctx context.Context, keys ...string) {
+	if c == nil || len(keys) == 0 {
+		return
+	}
+	c.drop(keys)
+	c.broadcast(ctx, strings.Join(keys, "\n"))
+}
+
+// InvalidateAll empties the cache here and, when broadcasting, on every
+// other replica
+func (c *Cache) InvalidateAll(ctx context.Context) {
+	if c == nil {
+		return
+	}
+	c.purge()
+	c.broadcast(ctx, purgeMessage)
+}
+
+// Broadcast shares invalidations with the other replicas over a Redis
+// channel until Close is called
+func (c *Cache) Broadcast(client redis.UniversalClient, channel string, logger *logrus.Logger) {
+	if c == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.client = client
+	c.channel = channel
+	c.logger = logger
+	c.cancel = cancel
+	c.done = make(chan struct{})
+
+	pubsub := client.Subscribe(ctx, channel)
+	go func() {
+		defer close(c.done)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-messages:
+				if !ok {
+					return
+				}
+				c.apply(message.Payload)
+			}
+		}
+	}()
+}
+
+// Close stops listening for invalidations from other replicas
+func (c *Cache) Close() {
+	if c == nil || c.cancel == nil {
+		return
+	}
+	c.cancel()
+	<-c.done
+}
+
+// apply handles an invalidation broadcast by any replica, this one included
+func (c *Cache) apply(payload string) {
+	if payload == purgeMessage {
+		c.purge()
+		return
+	}
+	c.drop(strings.Split(payload, "\n"))
+}
+
+func (c *Cache) broadcast(ctx context.Context, payload string) {
+	if c.client == nil {
+		return
+	}
+	if err := c.client.Publish(ctx, c.channel, payload).Err(); err != nil {
+		c.logger.WithError(err).Warn("Failed to broadcast cache invalidation")
+	}
+}
+
+func (c *Cache) drop(keys []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if element, ok := c.items[key]; ok {
+			c.remove(element)
+		}
+	}
+}
+
+func (c *Cache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[string]*list.Element, c.size)
+	c.order.Init()
+}
+
+// remove unlinks an element; callers hold mu
+func (c *Cache) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.items, element.Value.(*entry).key)
+}
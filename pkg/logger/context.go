@@ -0,0 +1,31 @@
+package logger
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/pkg/auth"
+)
+
+type entryKey struct{}
+
+// WithContext returns a copy of ctx carrying entry, the logger of the
+// request ctx belongs to, with the fields every line it logs should have
+func WithContext(ctx context.Context, entry *logrus.Entry) context.Context {
+	return context.WithValue(ctx, entryKey{}, entry)
+}
+
+// FromContext returns the logger of the request ctx belongs to, adding the
+// ID of the user making it once authenticated. Outside a request, it
+// returns an entry of base.
+func FromContext(ctx context.Context, base *logrus.Logger) *logrus.Entry {
+	entry, ok := ctx.Value(entryKey{}).(*logrus.Entry)
+	if !ok {
+		entry = logrus.NewEntry(base)
+	}
+	if actor := auth.ActorFromContext(ctx); actor != nil && actor.ID != "" {
+		entry = entry.WithField("user_id", actor.ID)
+	}
+	return entry.WithContext(ctx)
+}
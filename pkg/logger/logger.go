@@ -6,9 +6,11 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-// NewLogger creates a new structured logger
-func NewLogger() *logrus.Logger {
+// NewLogger creates a new structured logger for a service, whose every
+// entry carries the service's name
+func NewLogger(service string) *logrus.Logger {
 	logger := logrus.New()
+	logger.AddHook(serviceHook(service))
 
 	// Set output to stdout
 	logger.SetOutput(os.Stdout)
@@ -40,3 +42,15 @@ func NewLogger() *logrus.Logger {
 func WithFields(logger *logrus.Logger, fields logrus.Fields) *logrus.Entry {
 	return logger.WithFields(fields)
 }
+
+// serviceHook names the service on every entry
+type serviceHook string
+
+func (h serviceHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h serviceHook) Fire(entry *logrus.Entry) error {
+	entry.Data["service"] = string(h)
+	return nil
+}
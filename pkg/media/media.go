@@ -0,0 +1,236 @@
+package media
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// URL rewriting modes
+const (
+	ModeNone     = ""         // images are served from where they are stored
+	ModeCDN      = "cdn"      // the CDN host, with w, h and fm query parameters
+	ModeImgproxy = "imgproxy" // imgproxy processing URLs, signed when a key is set
+)
+
+// Variant names
+const (
+	VariantThumb  = "thumb"
+	VariantMedium = "medium"
+	VariantFull   = "full"
+)
+
+// Options describe how an image should be delivered. Zero values keep the
+// source's dimensions and format.
+type Options struct {
+	Width  int
+	Height int
+	Format string // e.g. webp, avif, jpg
+}
+
+// Variants are the URLs of an image at the sizes clients use
+type Variants struct {
+	Thumb  string `json:"thumb"`
+	Medium string `json:"medium"`
+	Full   string `json:"full"`
+}
+
+// Config holds image URL rewriting configuration
+type Config struct {
+	Mode    string
+	BaseURL string   // CDN or imgproxy URL
+	Origins []string // URL prefixes images are stored under; the CDN only serves these and relative paths
+	Key     string   // imgproxy signing key, hex encoded
+	Salt    string   // imgproxy signing salt, hex encoded
+	Format  string   // format variants are converted to; empty keeps the source format
+
+	ThumbSize  int // pixels; each variant fits a square box of this size, 0 keeps the source size
+	MediumSize int
+	FullSize   int
+}
+
+// Builder rewrites stored image URLs to URLs that deliver them resized and
+// converted, through a CDN or an imgproxy server
+type Builder struct {
+	mode    string
+	baseURL string
+	origins []string
+	key     []byte
+	salt    []byte
+	format  string
+	sizes   map[string]int
+}
+
+// NewBuilder creates a URL builder
+func NewBuilder(cfg Config) (*Builder, error) {
+	b := &Builder{
+		mode:    cfg.Mode,
+		baseURL: strings.TrimRight(cfg.BaseURL, "/"),
+		format:  strings.TrimPrefix(strings.ToLower(cfg.Format), "."),
+		sizes: map[string]int{
+			VariantThumb:  cfg.ThumbSize,
+			VariantMedium: cfg.MediumSize,
+			VariantFull:   cfg.FullSize,
+		},
+	}
+	for _, origin := range cfg.Origins {
+		if origin = strings.TrimRight(origin, "/"); origin != "" {
+			b.origins = append(b.origins, origin)
+		}
+	}
+
+	switch cfg.Mode {
+	case ModeNone:
+		return b, nil
+	case ModeCDN, ModeImgproxy:
+	default:
+		return nil, fmt.Errorf("unknown image URL mode %q", cfg.Mode)
+	}
+
+	if u, err := url.Parse(b.baseURL); err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid image base URL %q", cfg.BaseURL)
+	}
+	if (cfg.Key == "") != (cfg.Salt == "") {
+		return nil, fmt.Errorf("imgproxy key and salt must be set together")
+	}
+	var err error
+	if b.key, err = hex.DecodeString(cfg.Key); err != nil {
+		return nil, fmt.Errorf("invalid imgproxy key: %w", err)
+	}
+	if b.salt, err = hex.DecodeString(cfg.Salt); err != nil {
+		return nil, fmt.Errorf("invalid imgproxy salt: %w", err)
+	}
+	return b, nil
+}
+
+// Enabled reports whether URLs are rewritten at all
+func (b *Builder) Enabled() bool {
+	return b != nil && b.mode != ModeNone
+}
+
+// URL returns the URL that delivers source with the given options. Sources
+// the builder does not handle are returned unchanged.
+func (b *Builder) URL(source string, opts Options) string {
+	if !b.Enabled() || source == "" {
+		return source
+	}
+
+	switch b.mode {
+	case ModeCDN:
+		path, ok := b.originPath(source)
+		if !ok {
+			return source
+		}
+		query := url.Values{}
+		if opts.Width > 0 {
+			query.Set("w", strconv.Itoa(opts.Width))
+		}
+		if opts.Height > 0 {
+			query.Set("h", strconv.Itoa(opts.Height))
+		}
+		if opts.Format != "" {
+			query.Set("fm", opts.Format)
+		}
+		rewritten := b.baseURL + path
+		if len(query) > 0 {
+			separator := "?"
+			if strings.Contains(path, "?") {
+				separator = "&"
+			}
+			rewritten += separator + query.Encode()
+		}
+		return rewritten
+
+	case ModeImgproxy:
+		absolute, ok := b.absoluteSource(source)
+		if !ok {
+			return source
+		}
+		path := fmt.Sprintf("/rs:fit:%d:%d", opts.Width, opts.Height)
+		if opts.Format != "" {
+			path += "/f:" + opts.Format
+		}
+		path += "/plain/" + url.PathEscape(absolute)
+		return b.baseURL + "/" + b.signature(path) + path
+	}
+	return source
+}
+
+// Variants returns the thumb, medium and full URLs of an image, or nil when
+// there is no image or URLs are not rewritten
+func (b *Builder) Variants(source string) *Variants {
+	if !b.Enabled() || source == "" {
+		return nil
+	}
+	variant := func(name string) string {
+		size := b.sizes[name]
+		return b.URL(source, Options{Width: size, Height: size, Format: b.format})
+	}
+	return &Variants{
+		Thumb:  variant(VariantThumb),
+		Medium: variant(VariantMedium),
+		Full:   variant(VariantFull),
+	}
+}
+
+// originPath returns the path a source is served under on the CDN: the
+// rest of a URL under one of the origins, or a relative path as is
+func (b *Builder) originPath(source string) (string, bool) {
+	if isRelative(source) {
+		return source, true
+	}
+	return b.underOrigin(source)
+}
+
+// absoluteSource returns the URL imgproxy fetches a source from. Relative
+// paths are resolved against the first origin; without origins any
+// absolute URL is fetched.
+func (b *Builder) absoluteSource(source string) (string, bool) {
+	if isRelative(source) {
+		if len(b.origins) == 0 {
+			return "", false
+		}
+		return b.origins[0] + source, true
+	}
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return "", false
+	}
+	if len(b.origins) == 0 {
+		return source, true
+	}
+	if _, ok := b.underOrigin(source); !ok {
+		return "", false
+	}
+	return source, true
+}
+
+// underOrigin returns the rest of a URL under one of the origins
+func (b *Builder) underOrigin(source string) (string, bool) {
+	for _, origin := range b.origins {
+		if rest, ok := strings.CutPrefix(source, origin); ok && (rest == "" || strings.HasPrefix(rest, "/")) {
+			return rest, true
+		}
+	}
+	return "", false
+}
+
+// isRelative reports whether a URL is a path on the current host
+func isRelative(source string) bool {
+	return strings.HasPrefix(source, "/") && !strings.HasPrefix(source, "//")
+}
+
+// signature signs an imgproxy path, or marks it insecure when no key is set
+func (b *Builder) signature(path string) string {
+	if len(b.key) == 0 {
+		return "insecure"
+	}
+	mac := hmac.New(sha256.New, b.key)
+	mac.Write(b.salt)
+	mac.Write([]byte(path))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
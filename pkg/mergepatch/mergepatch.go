@@ -0,0 +1,107 @@
+// Package mergepatch applies JSON Merge Patch documents (RFC 7386).
+package mergepatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ContentType is the media type of a merge patch
+const ContentType = "application/merge-patch+json"
+
+// ErrInvalidPatch is returned for a patch that is not valid JSON
+var ErrInvalidPatch = errors.New("invalid merge patch")
+
+// Apply merges patch into doc and returns the result. Members of the patch
+// replace those of the document, nested objects are merged recursively and
+// a null member removes the member from the document. A patch that is not
+// an object replaces the whole document.
+func Apply(doc, patch []byte) ([]byte, error) {
+	var patchValue interface{}
+	if err := json.Unmarshal(patch, &patchValue); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	var docValue interface{}
+	if len(doc) > 0 {
+		if err := json.Unmarshal(doc, &docValue); err != nil {
+			return nil, fmt.Errorf("failed to decode document: %w", err)
+		}
+	}
+
+	merged, err := json.Marshal(merge(docValue, patchValue))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode document: %w", err)
+	}
+	return merged, nil
+}
+
+// merge is the MergePatch function from section 2 of the RFC
+func merge(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = merge(targetObject[name], value)
+	}
+	return targetObject
+}
+
+// Revert returns the merge patch that undoes patch once it has been applied
+// to doc: members the patch sets go back to their values in doc, and those
+// doc lacked are removed again.
+func Revert(doc, patch []byte) ([]byte, error) {
+	var patchValue interface{}
+	if err := json.Unmarshal(patch, &patchValue); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	var docValue interface{}
+	if len(doc) > 0 {
+		if err := json.Unmarshal(doc, &docValue); err != nil {
+			return nil, fmt.Errorf("failed to decode document: %w", err)
+		}
+	}
+
+	reverted, err := json.Marshal(revert(docValue, patchValue))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode patch: %w", err)
+	}
+	return reverted, nil
+}
+
+// revert builds the patch undoing patch for target. Where both are objects
+// it only touches the members the patch does; anywhere else the patch
+// replaced the target, so the target is put back whole.
+func revert(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return target
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		return target
+	}
+
+	reverted := make(map[string]interface{}, len(patchObject))
+	for name, value := range patchObject {
+		member, ok := targetObject[name]
+		if !ok {
+			reverted[name] = nil
+			continue
+		}
+		reverted[name] = revert(member, value)
+	}
+	return reverted
+}
@@ -0,0 +1,150 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Default is the registry metrics are created in and served from
+var Default = NewRegistry()
+
+// Registry holds metrics and renders them in the Prometheus text format
+type Registry struct {
+	mu       sync.Mutex
+	counters []*CounterVec
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// CounterVec is a set of counters sharing a name, told apart by label values
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]*counterValue
+}
+
+type counterValue struct {
+	labels []string
+	value  uint64
+}
+
+// NewCounterVec creates a counter in the default registry
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return Default.NewCounterVec(name, help, labels...)
+}
+
+// NewCounterVec creates a counter in the registry
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	counter := &CounterVec{
+		name:   name,
+		help:   help,
+		labels: labels,
+		values: make(map[string]*counterValue),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.counters = append(r.counters, counter)
+	return counter
+}
+
+// Inc adds one to the counter with the given label values, which must
+// match the counter's labels in number and order
+func (c *CounterVec) Inc(labels ...string) {
+	c.Add(1, labels...)
+}
+
+// Add adds n to the counter with the given label values
+func (c *CounterVec) Add(n uint64, labels ...string) {
+	if len(labels) != len(c.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d labels, got %d", c.name, len(c.labels), len(labels)))
+	}
+	key := strings.Join(labels, "\xff")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.values[key]
+	if !ok {
+		value = &counterValue{labels: append([]string(nil), labels...)}
+		c.values[key] = value
+	}
+	value.value += n
+}
+
+// Value returns the counter with the given label values
+func (c *CounterVec) Value(labels ...string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if value, ok := c.values[strings.Join(labels, "\xff")]; ok {
+		return value.value
+	}
+	return 0
+}
+
+// WriteTo renders every metric in the Prometheus text exposition format
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	counters := append([]*CounterVec(nil), r.counters...)
+	r.mu.Unlock()
+
+	var b strings.Builder
+	for _, counter := range counters {
+		counter.write(&b)
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// Handler serves the registry's metrics for Prometheus to scrape
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteTo(w)
+	})
+}
+
+func (c *CounterVec) write(b *strings.Builder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fmt.Fprintf(b, "# HELP %s %s\n", c.name, escapeHelp(c.help))
+	fmt.Fprintf(b, "# TYPE %s counter\n", c.name)
+
+	keys := make([]string, 0, len(c.values))
+	for key := range c.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := c.values[key]
+		b.WriteString(c.name)
+		if len(c.labels) > 0 {
+			pairs := make([]string, len(c.labels))
+			for idx, label := range c.labels {
+				pairs[idx] = label + `="` + escapeLabel(value.labels[idx]) + `"`
+			}
+			b.WriteString("{" + strings.Join(pairs, ",") + "}")
+		}
+		fmt.Fprintf(b, " %d\n", value.value)
+	}
+}
+
+// escapeHelp escapes a help text as the text format requires
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+// escapeLabel escapes a label value as the text format requires
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
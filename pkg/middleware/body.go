@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/response"
+)
+
+// BodyLimit is middleware refusing request bodies larger than limit bytes
+// with 413 Payload Too Large, before a handler reads them. Routes listed in
+// overrides by request path, such as uploads, get their own limit. A limit
+// of zero or less sets none.
+//
+// Bodies of a declared length are refused up front. Chunked bodies are read
+// up to the limit first, so an oversized one is refused the same way rather
+// than failing halfway through a handler's decoding.
+func BodyLimit(limit int64, overrides map[string]int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		max := limit
+		if override, ok := overrides[c.Request.URL.Path]; ok {
+			max = override
+		}
+		if max <= 0 || c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > max {
+			tooLarge(c, max)
+			return
+		}
+		if c.Request.ContentLength < 0 {
+			body, err := io.ReadAll(io.LimitReader(c.Request.Body, max+1))
+			if err != nil {
+				response.Error(c, http.StatusBadRequest, "Failed to read request body", err)
+				c.Abort()
+				return
+			}
+			if int64(len(body)) > max {
+				tooLarge(c, max)
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, max)
+		c.Next()
+	}
+}
+
+// tooLarge responds 413 and closes the connection, as the rest of the body
+// is left unread
+func tooLarge(c *gin.Context, max int64) {
+	c.Header("Connection", "close")
+	err := errors.NewValidationError(fmt.Sprintf("Request body must not exceed %d bytes", max), nil).WithCode(errors.CodeTooLarge)
+	response.Error(c, http.StatusRequestEntityTooLarge, "Request body too large", err)
+	c.Abort()
+}
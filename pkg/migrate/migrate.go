@@ -0,0 +1,305 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// lockID is the Postgres advisory lock held while migrating, so replicas
+// starting together apply each migration once
+const lockID = 7_206_531_842
+
+// ErrSchemaMismatch is returned by Check when the database is not at the
+// latest migration
+var ErrSchemaMismatch = errors.New("database schema does not match migrations")
+
+// fileName matches migration files in golang-migrate's naming scheme
+var fileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration is a versioned schema change
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Migrator applies SQL migrations and records the schema version in the
+// schema_migrations table that golang-migrate uses, so the migrate CLI and
+// the services agree on the version.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration // ordered by version
+	logger     *logrus.Logger
+}
+
+// New creates a migrator for the migrations in fsys
+func New(db *gorm.DB, fsys fs.FS, logger *logrus.Logger) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations, logger: logger}, nil
+}
+
+// Load reads <version>_<name>.up.sql and .down.sql files from the root of
+// fsys. Every version needs an up migration.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+		body, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[uint(version)]
+		if !ok {
+			migration = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has files with different names", version)
+		}
+		if match[3] == "up" {
+			migration.Up = string(body)
+		} else {
+			migration.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d has no up migration", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Latest returns the version of the newest migration, or 0 if there are none
+func (m *Migrator) Latest() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the schema version recorded in the database, 0 if no
+// migration has been applied. A dirty version is one the migrate CLI failed
+// part way through.
+func (m *Migrator) Version(ctx context.Context) (uint, bool, error) {
+	if err := m.ensureTable(m.db.WithContext(ctx)); err != nil {
+		return 0, false, err
+	}
+	return version(m.db.WithContext(ctx))
+}
+
+// Check returns ErrSchemaMismatch unless the database is cleanly at the
+// latest migration
+func (m *Migrator) Check(ctx context.Context) error {
+	current, dirty, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if dirty || current != m.Latest() {
+		return fmt.Errorf("%w: database is at version %d (dirty: %t), migrations are at %d", ErrSchemaMismatch, current, dirty, m.Latest())
+	}
+	return nil
+}
+
+// Up applies the pending migrations and reports how many were applied.
+// Each migration runs in a transaction with its version update, so a
+// failed migration leaves the schema at the previous version.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.locked(ctx, func(conn *gorm.DB) error {
+		current, err := m.cleanVersion(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version <= current {
+				continue
+			}
+
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.Up).Error; err != nil {
+					return err
+				}
+				return setVersion(tx, migration.Version)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			applied++
+			m.logger.WithField("version", migration.Version).Info(fmt.Sprintf("Applied migration %s", migration.Name))
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the most recently applied migration
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.locked(ctx, func(conn *gorm.DB) error {
+		current, err := m.cleanVersion(conn)
+		if err != nil {
+			return err
+		}
+		if current == 0 {
+			return nil
+		}
+
+		index := m.find(current)
+		if index < 0 {
+			return fmt.Errorf("database is at version %d, which has no migration", current)
+		}
+		migration := m.migrations[index]
+		if migration.Down == "" {
+			return fmt.Errorf("migration %d_%s has no down migration", migration.Version, migration.Name)
+		}
+
+		var previous uint
+		if index > 0 {
+			previous = m.migrations[index-1].Version
+		}
+
+		err = conn.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(migration.Down).Error; err != nil {
+				return err
+			}
+			return setVersion(tx, previous)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to roll back migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		m.logger.WithField("version", migration.Version).Info(fmt.Sprintf("Rolled back migration %s", migration.Name))
+		return nil
+	})
+}
+
+// Force records a version without running any migration and clears the
+// dirty flag. It is for baselining a database whose schema was created
+// some other way, or recovering after a failed migration was fixed by hand.
+func (m *Migrator) Force(ctx context.Context, target uint) error {
+	if target != 0 && m.find(target) < 0 {
+		return fmt.Errorf("no migration has version %d", target)
+	}
+	return m.locked(ctx, func(conn *gorm.DB) error {
+		return conn.Transaction(func(tx *gorm.DB) error {
+			return setVersion(tx, target)
+		})
+	})
+}
+
+// locked runs fn on a single connection holding the migration lock. The
+// statement timeout is lifted meanwhile: waiting for the lock and building
+// indexes may well take longer.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *gorm.DB) error) error {
+	return m.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("SET statement_timeout = 0").Error; err != nil {
+			return fmt.Errorf("failed to lift statement timeout: %w", err)
+		}
+		defer conn.Exec("RESET statement_timeout")
+
+		if err := conn.Exec("SELECT pg_advisory_lock(?)", lockID).Error; err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(?)", lockID)
+
+		if err := m.ensureTable(conn); err != nil {
+			return err
+		}
+		return fn(conn)
+	})
+}
+
+// cleanVersion returns the recorded version, refusing to go on from a
+// dirty one
+func (m *Migrator) cleanVersion(conn *gorm.DB) (uint, error) {
+	current, dirty, err := version(conn)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("database is dirty at version %d; fix the schema and force the version", current)
+	}
+	return current, nil
+}
+
+func (m *Migrator) find(target uint) int {
+	for i, migration := range m.migrations {
+		if migration.Version == target {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *Migrator) ensureTable(conn *gorm.DB) error {
+	err := conn.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT NOT NULL PRIMARY KEY,
+		dirty   BOOLEAN NOT NULL
+	)`).Error
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+func version(conn *gorm.DB) (uint, bool, error) {
+	var rows []struct {
+		Version uint
+		Dirty   bool
+	}
+	if err := conn.Raw("SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&rows).Error; err != nil {
+		return 0, false, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if len(rows) == 0 {
+		return 0, false, nil
+	}
+	return rows[0].Version, rows[0].Dirty, nil
+}
+
+// setVersion records the schema version; version 0 means no migration
+func setVersion(tx *gorm.DB, target uint) error {
+	if err := tx.Exec("DELETE FROM schema_migrations").Error; err != nil {
+		return fmt.Errorf("failed to clear schema version: %w", err)
+	}
+	if target == 0 {
+		return nil
+	}
+	if err := tx.Exec("INSERT INTO schema_migrations (version, dirty) VALUES (?, FALSE)", target).Error; err != nil {
+		return fmt.Errorf("failed to record schema version: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package mtls_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/pkg/mtls"
+)
+
+// authority issues certificates for the tests
+type authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	dir  string
+}
+
+func newAuthority(t *testing.T) *authority {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+
+	dir := t.TempDir()
+	writePEM(t, filepath.Join(dir, "ca.pem"), "CERTIFICATE", der)
+	return &authority{cert: cert, key: key, dir: dir}
+}
+
+// issue writes a certificate for a SPIFFE ID and returns the service's
+// configuration
+func (a *authority) issue(t *testing.T, name, spiffeID string, allowed ...string) mtls.Config {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := url.Parse(spiffeID)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		URIs:         []*url.URL{id},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, a.cert, &key.PublicKey, a.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := mtls.Config{
+		CertFile:   filepath.Join(a.dir, name+".pem"),
+		KeyFile:    filepath.Join(a.dir, name+"-key.pem"),
+		CAFile:     filepath.Join(a.dir, "ca.pem"),
+		AllowedIDs: allowed,
+		Reload:     time.Minute,
+	}
+	writePEM(t, cfg.CertFile, "CERTIFICATE", der)
+	writePEM(t, cfg.KeyFile, "EC PRIVATE KEY", keyDER)
+	return cfg
+}
+
+func writePEM(t *testing.T, path, kind string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func load(t *testing.T, cfg mtls.Config) *mtls.Identity {
+	t.Helper()
+	identity, err := mtls.Load(cfg, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return identity
+}
+
+// TestMutualTLS serves a service to the gateway, and only to peers with an
+// allowed SPIFFE ID and certificate
+func TestMutualTLS(t *testing.T) {
+	ca := newAuthority(t)
+	server := load(t, ca.issue(t, "product", "spiffe://shop.internal/product", "spiffe://shop.internal/gateway"))
+	gateway := load(t, ca.issue(t, "gateway", "spiffe://shop.internal/gateway", "spiffe://shop.internal/*"))
+	order := load(t, ca.issue(t, "order", "spiffe://shop.internal/order", "spiffe://shop.internal/*"))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := &http.Server{Handler: server.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}), "/health")}
+	go mock.Serve(tls.NewListener(listener, server.ServerConfig()))
+	defer mock.Close()
+	serverURL := "https://" + listener.Addr().String()
+
+	resp, err := gateway.HTTPClient().Get(serverURL + "/api/v1/products")
+	if err != nil {
+		t.Fatalf("gateway was refused: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("gateway got status %d, want %d", resp.StatusCode, http.StatusNoContent)
+	}
+
+	if resp, err := order.HTTPClient().Get(serverURL + "/api/v1/products"); err == nil {
+		resp.Body.Close()
+		t.Error("order service was let in though its ID is not allowed")
+	}
+
+	// Without a certificate only the exempt paths are served
+	anonymous := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	for path, want := range map[string]int{"/health": http.StatusNoContent, "/api/v1/products": http.StatusUnauthorized} {
+		resp, err := anonymous.Get(serverURL + path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("%s without a certificate got status %d, want %d", path, resp.StatusCode, want)
+		}
+	}
+}
+
+// TestNilIdentity falls back to plain HTTP
+func TestNilIdentity(t *testing.T) {
+	var identity *mtls.Identity
+	if identity.ClientConfig() != nil {
+		t.Error("a nil identity should have no client configuration")
+	}
+	if identity.HTTPClient() == nil {
+		t.Error("a nil identity should still give a client")
+	}
+}
@@ -0,0 +1,137 @@
+// Package pdf writes plain text documents as PDF: A4 pages of monospaced
+// lines, which is all printouts such as invoices need. Text is set in the
+// standard Courier font, so nothing has to be embedded, and characters it
+// cannot show are replaced with a question mark.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	pageWidth  = 595 // A4, in points
+	pageHeight = 842
+	margin     = 50
+	fontSize   = 10
+	leading    = 12
+
+	// Courier glyphs are 0.6em wide, so this many fit between the margins
+	lineWidth    = (pageWidth - 2*margin) * 10 / (fontSize * 6)
+	linesPerPage = (pageHeight - 2*margin) / leading
+)
+
+// Render lays out text over as many pages as it takes. Lines longer than a
+// page is wide are wrapped, and a form feed starts a new page.
+func Render(title, text string) []byte {
+	var pages [][]string
+	var page []string
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		for strings.HasPrefix(line, "\f") {
+			pages = append(pages, page)
+			page = nil
+			line = line[1:]
+		}
+		for _, wrapped := range wrap(strings.ReplaceAll(line, "\t", "    ")) {
+			if len(page) == linesPerPage {
+				pages = append(pages, page)
+				page = nil
+			}
+			page = append(page, wrapped)
+		}
+	}
+	pages = append(pages, page)
+
+	w := &writer{}
+	w.buf.WriteString("%PDF-1.4\n")
+
+	// Objects 1 to 4 are the catalog, page tree, font and document info;
+	// each page then takes two, itself and its content stream
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	w.object("<< /Type /Catalog /Pages 2 0 R >>")
+	w.object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	w.object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	w.object(fmt.Sprintf("<< /Title (%s) /Producer (ecommerce) >>", escape(title)))
+
+	for i, lines := range pages {
+		var content bytes.Buffer
+		fmt.Fprintf(&content, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", fontSize, leading, margin, pageHeight-margin-fontSize)
+		for j, line := range lines {
+			if j > 0 {
+				content.WriteString("T* ")
+			}
+			fmt.Fprintf(&content, "(%s) Tj\n", escape(line))
+		}
+		content.WriteString("ET\n")
+
+		w.object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", pageWidth, pageHeight, 6+2*i))
+		w.object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", len(w.offsets)+1)
+	for _, offset := range w.offsets {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root 1 0 R /Info 4 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(w.offsets)+1, xref)
+	return w.buf.Bytes()
+}
+
+// writer numbers objects in the order they are written and remembers where
+// each starts, for the cross-reference table
+type writer struct {
+	buf     bytes.Buffer
+	offsets []int
+}
+
+func (w *writer) object(body string) {
+	w.offsets = append(w.offsets, w.buf.Len())
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", len(w.offsets), body)
+}
+
+// wrap breaks a line into pieces that fit the width of a page
+func wrap(line string) []string {
+	if utf8.RuneCountInString(line) <= lineWidth {
+		return []string{line}
+	}
+	var lines []string
+	runes := []rune(line)
+	for len(runes) > lineWidth {
+		lines = append(lines, string(runes[:lineWidth]))
+		runes = runes[lineWidth:]
+	}
+	return append(lines, string(runes))
+}
+
+// winAnsi maps the punctuation WinAnsiEncoding has below 0xA0
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92,
+	'“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// escape encodes text as the body of a PDF string in WinAnsiEncoding,
+// which matches Latin-1 from 0xA0 up
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case winAnsi[r] != 0:
+			fmt.Fprintf(&b, `\%03o`, winAnsi[r])
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, `\%03o`, r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
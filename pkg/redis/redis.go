@@ -10,25 +10,90 @@ import (
 	"ecommerce/internal/product/config"
 )
 
-// NewRedisClient creates a new Redis client
-func NewRedisClient(cfg config.RedisConfig) (*redis.Client, error) {
-	client := redis.NewClient(&redis.Options{
-		Addr:         fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
-		Password:     cfg.Password,
-		DB:           cfg.DB,
-		PoolSize:     cfg.PoolSize,
-		MinIdleConns: cfg.MinIdleConns,
-		MaxRetries:   cfg.MaxRetries,
-		DialTimeout:  time.Duration(cfg.DialTimeout) * time.Second,
-		ReadTimeout:  time.Duration(cfg.ReadTimeout) * time.Second,
-		WriteTimeout: time.Duration(cfg.WriteTimeout) * time.Second,
-	})
+// Redis topologies
+const (
+	ModeStandalone = "standalone"
+	ModeSentinel   = "sentinel"
+	ModeCluster    = "cluster"
+)
+
+// maxRedirects bounds the MOVED and ASK redirects followed per command in
+// cluster mode, which happen while slots migrate or a failover completes
+const maxRedirects = 8
+
+// NewRedisClient creates a new Redis client for the configured topology: a
+// single node, the master of a Sentinel-monitored group, or a Redis
+// Cluster. Commands that fail while a failover is in progress are retried
+// with backoff, and the Sentinel and Cluster clients follow the new master.
+func NewRedisClient(cfg config.RedisConfig) (redis.UniversalClient, error) {
+	minRetryBackoff := time.Duration(cfg.MinRetryBackoff) * time.Millisecond
+	maxRetryBackoff := time.Duration(cfg.MaxRetryBackoff) * time.Millisecond
+
+	var client redis.UniversalClient
+	switch cfg.Mode {
+	case "", ModeStandalone:
+		client = redis.NewClient(&redis.Options{
+			Addr:            fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+			Password:        cfg.Password,
+			DB:              cfg.DB,
+			PoolSize:        cfg.PoolSize,
+			MinIdleConns:    cfg.MinIdleConns,
+			MaxRetries:      cfg.MaxRetries,
+			MinRetryBackoff: minRetryBackoff,
+			MaxRetryBackoff: maxRetryBackoff,
+			DialTimeout:     time.Duration(cfg.DialTimeout) * time.Second,
+			ReadTimeout:     time.Duration(cfg.ReadTimeout) * time.Second,
+			WriteTimeout:    time.Duration(cfg.WriteTimeout) * time.Second,
+		})
+	case ModeSentinel:
+		if cfg.MasterName == "" || len(cfg.Addrs) == 0 {
+			return nil, fmt.Errorf("sentinel mode needs REDIS_MASTER_NAME and REDIS_ADDRS")
+		}
+		client = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       cfg.MasterName,
+			SentinelAddrs:    cfg.Addrs,
+			SentinelPassword: cfg.SentinelPassword,
+			Password:         cfg.Password,
+			DB:               cfg.DB,
+			PoolSize:         cfg.PoolSize,
+			MinIdleConns:     cfg.MinIdleConns,
+			MaxRetries:       cfg.MaxRetries,
+			MinRetryBackoff:  minRetryBackoff,
+			MaxRetryBackoff:  maxRetryBackoff,
+			DialTimeout:      time.Duration(cfg.DialTimeout) * time.Second,
+			ReadTimeout:      time.Duration(cfg.ReadTimeout) * time.Second,
+			WriteTimeout:     time.Duration(cfg.WriteTimeout) * time.Second,
+		})
+	case ModeCluster:
+		if len(cfg.Addrs) == 0 {
+			return nil, fmt.Errorf("cluster mode needs REDIS_ADDRS")
+		}
+		if cfg.DB != 0 {
+			return nil, fmt.Errorf("cluster mode only has database 0, not %d", cfg.DB)
+		}
+		client = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:           cfg.Addrs,
+			Password:        cfg.Password,
+			MaxRedirects:    maxRedirects,
+			PoolSize:        cfg.PoolSize,
+			MinIdleConns:    cfg.MinIdleConns,
+			MaxRetries:      cfg.MaxRetries,
+			MinRetryBackoff: minRetryBackoff,
+			MaxRetryBackoff: maxRetryBackoff,
+			DialTimeout:     time.Duration(cfg.DialTimeout) * time.Second,
+			ReadTimeout:     time.Duration(cfg.ReadTimeout) * time.Second,
+			WriteTimeout:    time.Duration(cfg.WriteTimeout) * time.Second,
+		})
+	default:
+		return nil, fmt.Errorf("unknown Redis mode %q", cfg.Mode)
+	}
 
 	// Test the connection
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
@@ -0,0 +1,106 @@
+package requestlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/url"
+	"strings"
+)
+
+// redacted replaces the value of a sensitive field
+const redacted = "[REDACTED]"
+
+// sensitiveFields are redacted wherever they appear in a field's name,
+// compared in lower case without separators, so api_key and apiKey match
+var sensitiveFields = []string{
+	"password", "secret", "token", "apikey", "authorization", "cookie",
+	"cardnumber", "cvv", "cvc", "iban",
+}
+
+// redactor blanks out sensitive fields in what is logged of a request
+type redactor struct {
+	fields []string
+}
+
+func newRedactor(extra []string) *redactor {
+	fields := append([]string(nil), sensitiveFields...)
+	for _, field := range extra {
+		if field = normalizeField(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return &redactor{fields: fields}
+}
+
+// normalizeField lower-cases a field name and drops its separators
+func normalizeField(name string) string {
+	return strings.NewReplacer("_", "", "-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(name)))
+}
+
+// sensitive reports whether a field must be redacted
+func (r *redactor) sensitive(name string) bool {
+	name = normalizeField(name)
+	for _, field := range r.fields {
+		if strings.Contains(name, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// query returns a query string with sensitive parameters redacted
+func (r *redactor) query(values url.Values) string {
+	if len(values) == 0 {
+		return ""
+	}
+	cleaned := make(url.Values, len(values))
+	for name, list := range values {
+		if r.sensitive(name) {
+			cleaned[name] = []string{redacted}
+			continue
+		}
+		cleaned[name] = list
+	}
+	return cleaned.Encode()
+}
+
+// body returns a JSON body to log with sensitive fields redacted. Bodies
+// that are not JSON, or that are longer than limit and so can't be parsed
+// whole, are described rather than logged.
+func (r *redactor) body(contentType string, data []byte, limit int) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	if len(data) > limit {
+		return fmt.Sprintf("[omitted: longer than %d bytes]", limit)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		return fmt.Sprintf("[omitted: %s]", mediaType)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return "[omitted: invalid JSON]"
+	}
+	return r.redact(value)
+}
+
+// redact blanks out sensitive fields of a decoded JSON value, at any depth
+func (r *redactor) redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, field := range v {
+			if r.sensitive(name) {
+				v[name] = redacted
+				continue
+			}
+			v[name] = r.redact(field)
+		}
+	case []interface{}:
+		for idx, item := range v {
+			v[idx] = r.redact(item)
+		}
+	}
+	return value
+}
@@ -0,0 +1,181 @@
+// Package requestlog logs every HTTP request a service handles as one
+// structured entry, and tags each request with an ID that follows it from
+// the gateway to the services.
+package requestlog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"ecommerce/internal/product/config"
+	"ecommerce/pkg/auth"
+	logging "ecommerce/pkg/logger"
+)
+
+// HeaderRequestID carries the ID of a request, taken from the caller when
+// given and passed on to the services behind the gateway
+const HeaderRequestID = "X-Request-ID"
+
+// maxRequestIDLength bounds request IDs taken from callers
+const maxRequestIDLength = 128
+
+// clientKey is the gin context key of the client a request was made by
+const clientKey = "requestlog.client"
+
+type requestIDKey struct{}
+
+// RequestID returns the ID of the request ctx belongs to, or "" outside a
+// request
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// SetClient records the client a request was made by, such as an API key,
+// for when it is not the actor in the request context
+func SetClient(c *gin.Context, client string) {
+	c.Set(clientKey, client)
+}
+
+// Middleware logs each request once it completes: its method, path,
+// status, latency, caller and ID. It also puts a logger tagged with the
+// request's ID into the request context, for logger.FromContext. Server errors are logged as errors and
+// client errors as warnings. A sample of requests also has its JSON
+// bodies logged, with sensitive fields redacted. Requests to the routes
+// configured to be skipped are not logged but still get an ID.
+func Middleware(cfg config.LoggerConfig, logger *logrus.Logger) gin.HandlerFunc {
+	skip := make(map[string]bool, len(cfg.SkipPaths))
+	for _, path := range cfg.SkipPaths {
+		if path = strings.TrimSpace(path); path != "" {
+			skip[path] = true
+		}
+	}
+	redactor := newRedactor(cfg.RedactFields)
+
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		id := c.GetHeader(HeaderRequestID)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+			c.Request.Header.Set(HeaderRequestID, id)
+		}
+		c.Header(HeaderRequestID, id)
+		ctx := context.WithValue(c.Request.Context(), requestIDKey{}, id)
+		c.Request = c.Request.WithContext(logging.WithContext(ctx, logger.WithField("request_id", id)))
+
+		route := c.FullPath()
+		if !cfg.Requests || skip[route] || skip[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+
+		var requestBody []byte
+		var response *bodyWriter
+		sampled := cfg.BodySamplePercent > 0 && rand.IntN(100) < cfg.BodySamplePercent
+		if sampled {
+			requestBody = peekBody(c, cfg.BodyMaxBytes)
+			response = &bodyWriter{ResponseWriter: c.Writer, limit: cfg.BodyMaxBytes}
+			c.Writer = response
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+		fields := logrus.Fields{
+			"request_id": id,
+			"method":     c.Request.Method,
+			"path":       c.Request.URL.Path,
+			"route":      route,
+			"query":      redactor.query(c.Request.URL.Query()),
+			"status":     status,
+			"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+			"bytes":      c.Writer.Size(),
+			"client_ip":  c.ClientIP(),
+			"user_id":    auth.ActorID(c.Request.Context()),
+		}
+		if client := c.GetString(clientKey); client != "" {
+			fields["client_id"] = client
+		}
+		if sampled {
+			fields["request_body"] = redactor.body(c.ContentType(), requestBody, cfg.BodyMaxBytes)
+			fields["response_body"] = redactor.body(response.Header().Get("Content-Type"), response.body.Bytes(), cfg.BodyMaxBytes)
+		}
+		if len(c.Errors) > 0 {
+			fields["errors"] = c.Errors.String()
+		}
+
+		entry := logger.WithFields(fields)
+		switch {
+		case status >= 500:
+			entry.Error("Request failed")
+		case status >= 400:
+			entry.Warn("Request rejected")
+		default:
+			entry.Info("Request completed")
+		}
+	}
+}
+
+// validRequestID reports whether a caller's request ID can be kept: short
+// and printable, so it can't forge log lines
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// peekBody reads up to limit+1 bytes of the request body, for logging, and
+// puts them back in front of the rest for the handler
+func peekBody(c *gin.Context, limit int) []byte {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody || limit <= 0 {
+		return nil
+	}
+	body := c.Request.Body
+	peeked, _ := io.ReadAll(io.LimitReader(body, int64(limit)+1))
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(peeked), body), body}
+	return peeked
+}
+
+// bodyWriter keeps the first bytes of a response, for logging
+type bodyWriter struct {
+	gin.ResponseWriter
+	body  bytes.Buffer
+	limit int
+}
+
+func (w *bodyWriter) Write(data []byte) (int, error) {
+	w.keep(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyWriter) WriteString(data string) (int, error) {
+	w.keep([]byte(data))
+	return w.ResponseWriter.WriteString(data)
+}
+
+// keep buffers data while the buffer holds at most limit+1 bytes, enough
+// to tell a body was cut short
+func (w *bodyWriter) keep(data []byte) {
+	if room := w.limit + 1 - w.body.Len(); room > 0 {
+		w.body.Write(data[:min(room, len(data))])
+	}
+}
@@ -0,0 +1,116 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of calling a dependency whose circuit
+// breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// State is the state of a circuit breaker
+type State int
+
+// Circuit breaker states
+const (
+	StateClosed   State = iota // calls go through
+	StateOpen                  // calls fail fast
+	StateHalfOpen              // one trial call decides whether to close
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	default:
+		return "half-open"
+	}
+}
+
+// Breaker stops calls to a dependency that keeps failing, so callers fail
+// fast instead of queueing up behind it. After failureThreshold consecutive
+// failures it opens; once openTimeout has passed a single trial call is let
+// through, and its outcome closes the breaker or opens it again.
+type Breaker struct {
+	failureThreshold int
+	openTimeout      time.Duration
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool // a trial call is in flight
+}
+
+// NewBreaker creates a closed circuit breaker
+func NewBreaker(failureThreshold int, openTimeout time.Duration) *Breaker {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+	return &Breaker{
+		failureThreshold: failureThreshold,
+		openTimeout:      openTimeout,
+	}
+}
+
+// State returns the breaker's current state
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Allow reports whether a call may be made, returning ErrCircuitOpen if
+// not. Every allowed call must be followed by Record.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if time.Since(b.openedAt) < b.openTimeout {
+			return ErrCircuitOpen
+		}
+		b.state = StateHalfOpen
+		b.probing = true
+	case StateHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// Record reports the outcome of an allowed call. Permanent errors mean the
+// dependency answered, so they count as successes; calls abandoned by the
+// caller say nothing about the dependency and are not counted.
+func (b *Breaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err != nil && errors.Is(err, context.Canceled) {
+		b.probing = false
+		return
+	}
+
+	if err == nil || IsPermanent(err) {
+		b.state = StateClosed
+		b.failures = 0
+		b.probing = false
+		return
+	}
+
+	b.failures++
+	if b.state == StateHalfOpen || b.failures >= b.failureThreshold {
+		b.state = StateOpen
+		b.openedAt = time.Now()
+		b.failures = 0
+		b.probing = false
+	}
+}
@@ -0,0 +1,31 @@
+package resilience
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deadline is middleware bounding how long a request may run: its context
+// is cancelled once timeout passes, so the queries and calls it makes give
+// up rather than hold on to connections. Routes listed by their full path,
+// such as streaming exports, are exempt. A zero timeout sets no deadline.
+func Deadline(timeout time.Duration, exempt ...string) gin.HandlerFunc {
+	skip := make(map[string]bool, len(exempt))
+	for _, path := range exempt {
+		skip[path] = true
+	}
+
+	return func(c *gin.Context) {
+		if timeout <= 0 || skip[c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
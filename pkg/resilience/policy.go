@@ -0,0 +1,48 @@
+package resilience
+
+import (
+	"context"
+	"time"
+)
+
+// Policy combines a per-attempt timeout, bounded retries and a circuit
+// breaker around calls to one dependency. The zero value makes a single
+// attempt with no timeout and no breaker.
+type Policy struct {
+	Timeout time.Duration // per attempt; zero leaves the caller's deadline alone
+	Retry   RetryPolicy
+	Breaker *Breaker // shared by every call to the dependency; may be nil
+}
+
+// Do calls fn under the policy. fn should mark errors that retrying cannot
+// fix as Permanent. When the breaker is open, Do fails fast with
+// ErrCircuitOpen.
+func (p Policy) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return Retry(ctx, p.Retry, func(ctx context.Context) error {
+		if p.Breaker != nil {
+			if err := p.Breaker.Allow(); err != nil {
+				return Permanent(err)
+			}
+		}
+
+		attemptCtx := ctx
+		if p.Timeout > 0 {
+			var cancel context.CancelFunc
+			attemptCtx, cancel = context.WithTimeout(ctx, p.Timeout)
+			defer cancel()
+		}
+
+		err := fn(attemptCtx)
+		if p.Breaker != nil {
+			p.Breaker.Record(err)
+		}
+		return err
+	})
+}
+
+// WithoutRetry returns a copy of the policy that makes a single attempt,
+// for calls that are not safe to repeat
+func (p Policy) WithoutRetry() Policy {
+	p.Retry.Attempts = 1
+	return p
+}
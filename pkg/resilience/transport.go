@@ -0,0 +1,84 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// errUpstreamUnavailable stands in for a 502, 503 or 504 response
+var errUpstreamUnavailable = errors.New("upstream unavailable")
+
+// maxDrain bounds how much of a discarded response body is read so its
+// connection can be reused
+const maxDrain = 64 << 10
+
+// Transport is an http.RoundTripper that guards a service with a circuit
+// breaker. Connection failures and 502, 503 and 504 responses count against
+// the breaker. Requests without a body using a safe method are retried;
+// anything else is sent once, since the service may already have acted on
+// it. When retries run out the last response is returned as it is.
+type Transport struct {
+	Base    http.RoundTripper
+	Breaker *Breaker
+	Retry   RetryPolicy
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	policy := t.Retry
+	if !replayable(req) {
+		policy.Attempts = 1
+	}
+
+	var resp *http.Response
+	err := Retry(req.Context(), policy, func(context.Context) error {
+		if resp != nil {
+			discard(resp)
+			resp = nil
+		}
+		if err := t.Breaker.Allow(); err != nil {
+			return Permanent(err)
+		}
+
+		r, err := t.Base.RoundTrip(req)
+		if err != nil {
+			t.Breaker.Record(err)
+			return err
+		}
+
+		resp = r
+		if unavailable(r.StatusCode) {
+			t.Breaker.Record(errUpstreamUnavailable)
+			return errUpstreamUnavailable
+		}
+		t.Breaker.Record(nil)
+		return nil
+	})
+
+	if resp != nil {
+		return resp, nil
+	}
+	return nil, err
+}
+
+// replayable reports whether a request can be sent again without side
+// effects
+func replayable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return req.Body == nil || req.Body == http.NoBody
+	}
+	return false
+}
+
+func unavailable(status int) bool {
+	return status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
+}
+
+// discard drains and closes a response that will not be returned
+func discard(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrain))
+	resp.Body.Close()
+}
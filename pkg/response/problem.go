@@ -0,0 +1,74 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"ecommerce/pkg/errors"
+)
+
+// ContentTypeProblem is the media type of RFC 7807 problem details
+const ContentTypeProblem = "application/problem+json"
+
+// Error formats a service can default to
+const (
+	FormatJSON    = "json"
+	FormatProblem = "problem"
+)
+
+// problemTypePrefix namespaces problem types by application error code
+const problemTypePrefix = "urn:ecommerce:problem:"
+
+// problemKey marks requests whose errors default to problem details
+const problemKey = "response.problem"
+
+// ProblemDetails is an RFC 7807 error response. The application code and
+// rejected fields travel as extension members.
+type ProblemDetails struct {
+	Type     string              `json:"type"`
+	Title    string              `json:"title"`
+	Status   int                 `json:"status"`
+	Detail   string              `json:"detail,omitempty"`
+	Instance string              `json:"instance,omitempty"`
+	Code     string              `json:"code,omitempty"`
+	Errors   []errors.FieldError `json:"errors,omitempty"`
+}
+
+// Format returns middleware choosing the default error format. With
+// FormatProblem every error is sent as problem details; otherwise only
+// clients that accept application/problem+json get them.
+func Format(format string) gin.HandlerFunc {
+	problem := strings.EqualFold(format, FormatProblem)
+	return func(c *gin.Context) {
+		if problem {
+			c.Set(problemKey, true)
+		}
+		c.Next()
+	}
+}
+
+// wantsProblem reports whether an error response should be problem details
+func wantsProblem(c *gin.Context) bool {
+	return c.GetBool(problemKey) || strings.Contains(c.GetHeader("Accept"), ContentTypeProblem)
+}
+
+// problem sends an error as problem details
+func problem(c *gin.Context, status int, title, code, detail string, fields []errors.FieldError) {
+	body, err := json.Marshal(ProblemDetails{
+		Type:     problemTypePrefix + code,
+		Title:    title,
+		Status:   status,
+		Detail:   detail,
+		Instance: c.Request.URL.Path,
+		Code:     code,
+		Errors:   fields,
+	})
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	c.Data(status, ContentTypeProblem, body)
+}
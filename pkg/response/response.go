@@ -4,15 +4,21 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"ecommerce/pkg/errors"
 )
 
 // APIResponse represents a standard API response
 type APIResponse struct {
 	Success bool        `json:"success"`
+	Code    string      `json:"code,omitempty"` // application error code; set on errors only
 	Message string      `json:"message"`
 	Data    interface{} `json:"data,omitempty"`
 	Error   interface{} `json:"error,omitempty"`
 	Meta    interface{} `json:"meta,omitempty"`
+
+	// Errors lists the rejected fields of a failed validation
+	Errors []errors.FieldError `json:"errors,omitempty"`
 }
 
 // Success sends a successful response
@@ -34,25 +40,67 @@ func SuccessWithMeta(c *gin.Context, statusCode int, message string, data interf
 	})
 }
 
-// Error sends an error response
+// Error sends an error response, as problem details when the service is
+// configured for them or the client asks for them
 func Error(c *gin.Context, statusCode int, message string, err error) {
 	response := APIResponse{
 		Success: false,
+		Code:    errors.Code(err),
 		Message: message,
 	}
+	if response.Code == "" {
+		response.Code = codeForStatus(statusCode)
+	}
+	if wantsProblem(c) {
+		problem(c, statusCode, message, response.Code, errors.Message(err), errors.FieldErrors(err))
+		return
+	}
 
 	if err != nil {
 		response.Error = err.Error()
+		response.Errors = errors.FieldErrors(err)
 	}
 
 	c.JSON(statusCode, response)
 }
 
 // ValidationError sends a validation error response
-func ValidationError(c *gin.Context, message string, errors interface{}) {
+func ValidationError(c *gin.Context, message string, fieldErrors interface{}) {
+	if wantsProblem(c) {
+		fields, _ := fieldErrors.([]errors.FieldError)
+		problem(c, http.StatusBadRequest, message, errors.CodeValidation, "", fields)
+		return
+	}
 	c.JSON(http.StatusBadRequest, APIResponse{
 		Success: false,
+		Code:    errors.CodeValidation,
 		Message: message,
-		Error:   errors,
+		Error:   fieldErrors,
 	})
 }
+
+// codeForStatus is the generic application code of an HTTP status, for error
+// responses that are not backed by an application error
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return errors.CodeBadRequest
+	case http.StatusUnauthorized:
+		return errors.CodeUnauthorized
+	case http.StatusForbidden:
+		return errors.CodeForbidden
+	case http.StatusNotFound:
+		return errors.CodeNotFound
+	case http.StatusConflict:
+		return errors.CodeConflict
+	case http.StatusUnprocessableEntity:
+		return errors.CodeValidation
+	case http.StatusRequestEntityTooLarge:
+		return errors.CodeTooLarge
+	case http.StatusTooManyRequests:
+		return errors.CodeRateLimited
+	case http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return errors.CodeUnavailable
+	}
+	return errors.CodeInternal
+}
@@ -0,0 +1,191 @@
+// Package richtext cleans up HTML written by merchants, such as product
+// descriptions, so the storefront can render it as is. Only an allowlist of
+// formatting tags and attributes is kept; scripts, styles, event handlers
+// and links to anything but web pages and mail are dropped, so stored
+// content cannot run script in a shopper's browser.
+package richtext
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// allowed lists the tags kept and, for each, the attributes kept on it
+var allowed = map[string]map[string]bool{
+	"p": {}, "br": {}, "hr": {}, "div": {}, "span": {},
+	"strong": {}, "b": {}, "em": {}, "i": {}, "u": {}, "s": {}, "sub": {}, "sup": {}, "small": {}, "mark": {},
+	"h2": {}, "h3": {}, "h4": {}, "h5": {}, "h6": {},
+	"blockquote": {}, "pre": {}, "code": {},
+	"ul": {}, "ol": {"start": true}, "li": {}, "dl": {}, "dt": {}, "dd": {},
+	"table": {}, "thead": {}, "tbody": {}, "tfoot": {}, "tr": {},
+	"th":  {"colspan": true, "rowspan": true, "scope": true},
+	"td":  {"colspan": true, "rowspan": true},
+	"a":   {"href": true, "title": true},
+	"img": {"src": true, "alt": true, "title": true, "width": true, "height": true},
+}
+
+// void tags have no content and no end tag
+var void = map[string]bool{"br": true, "hr": true, "img": true}
+
+// dropped tags are removed together with their content, which is code or
+// markup rather than text
+var dropped = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "embed": true, "template": true,
+	"noscript": true, "svg": true, "math": true, "textarea": true, "select": true, "title": true, "head": true,
+}
+
+// block tags separate words in plain text
+var block = map[string]bool{
+	"p": true, "br": true, "hr": true, "div": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"blockquote": true, "pre": true, "ul": true, "ol": true, "li": true, "dl": true, "dt": true, "dd": true,
+	"table": true, "tr": true, "th": true, "td": true, "h1": true,
+}
+
+// Sanitize returns the allowed HTML of s. Tags that are not allowed are
+// removed but their text is kept; open tags are closed. Text outside any
+// tag is escaped, so plain text comes back as it was, other than < > & and
+// quotes.
+func Sanitize(s string) string {
+	var b strings.Builder
+	var open []string
+	skip := 0
+
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		// The tokenizer stops at the end of s; malformed markup is
+		// tokenized as best it can, as a browser would
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		token := z.Token()
+		name := token.Data
+
+		switch tt {
+		case html.TextToken:
+			if skip == 0 {
+				b.WriteString(html.EscapeString(token.Data))
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if dropped[name] {
+				if tt == html.StartTagToken {
+					skip++
+				}
+				continue
+			}
+			attrs, ok := allowed[name]
+			if skip > 0 || !ok {
+				continue
+			}
+			writeTag(&b, name, token.Attr, attrs)
+			if !void[name] && tt == html.StartTagToken {
+				open = append(open, name)
+			} else if !void[name] {
+				b.WriteString("</" + name + ">")
+			}
+
+		case html.EndTagToken:
+			if dropped[name] {
+				if skip > 0 {
+					skip--
+				}
+				continue
+			}
+			if skip > 0 || void[name] {
+				continue
+			}
+			// Close the tag with any left open inside it; an end tag
+			// without a start is ignored
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != name {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					b.WriteString("</" + open[j] + ">")
+				}
+				open = open[:i]
+				break
+			}
+		}
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i] + ">")
+	}
+	return b.String()
+}
+
+// writeTag writes a start tag with its allowed attributes. Links and
+// images only keep URLs to web pages, or mail for links; links are marked
+// as not endorsed, as their targets are up to whoever wrote the content.
+func writeTag(b *strings.Builder, name string, attrs []html.Attribute, keep map[string]bool) {
+	b.WriteString("<" + name)
+	for _, attr := range attrs {
+		key := strings.ToLower(attr.Key)
+		if attr.Namespace != "" || !keep[key] {
+			continue
+		}
+		if (key == "href" || key == "src") && !safeURL(attr.Val, key == "href") {
+			continue
+		}
+		b.WriteString(" " + key + `="` + html.EscapeString(attr.Val) + `"`)
+	}
+	if name == "a" {
+		b.WriteString(` rel="nofollow noopener noreferrer"`)
+	}
+	b.WriteString(">")
+}
+
+// safeURL reports whether a link or image URL is to a web page, or to mail
+// when mail is set. Relative URLs are resolved against the storefront.
+func safeURL(raw string, mail bool) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https":
+		return true
+	case "mailto":
+		return mail
+	}
+	return false
+}
+
+// PlainText returns the text of HTML s, with the content of scripts and
+// styles left out and whitespace collapsed, for indexing and previews
+func PlainText(s string) string {
+	var b strings.Builder
+	skip := 0
+
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		token := z.Token()
+		switch tt {
+		case html.TextToken:
+			if skip == 0 {
+				b.WriteString(token.Data)
+			}
+		case html.StartTagToken, html.SelfClosingTagToken, html.EndTagToken:
+			if dropped[token.Data] {
+				if tt == html.StartTagToken {
+					skip++
+				} else if tt == html.EndTagToken && skip > 0 {
+					skip--
+				}
+				continue
+			}
+			if block[token.Data] {
+				b.WriteByte(' ')
+			}
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -0,0 +1,44 @@
+package richtext_test
+
+import (
+	"testing"
+
+	"ecommerce/pkg/richtext"
+)
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"formatting kept", `<p>Soft <strong>cotton</strong> &amp; linen</p>`, `<p>Soft <strong>cotton</strong> &amp; linen</p>`},
+		{"script dropped with its content", `<script>alert(1)</script><p>Hi</p>`, `<p>Hi</p>`},
+		{"event handlers and styles dropped", `<p onclick="steal()" style="color:red">Hi</p>`, `<p>Hi</p>`},
+		{"script links dropped", `<a href="javascript:alert(1)">Hi</a>`, `<a rel="nofollow noopener noreferrer">Hi</a>`},
+		{"web links kept", `<a href="https://example.com/size-guide">Sizes</a>`, `<a href="https://example.com/size-guide" rel="nofollow noopener noreferrer">Sizes</a>`},
+		{"data images dropped", `<img src="data:text/html;base64,AAAA" alt="x">`, `<img alt="x">`},
+		{"unknown tags unwrapped", `<font color="red">Sale</font>`, `Sale`},
+		{"open tags closed", `<ul><li><em>One`, `<ul><li><em>One</em></li></ul>`},
+		{"plain text escaped", `Fits 1 < 2 "sizes"`, `Fits 1 &lt; 2 &#34;sizes&#34;`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := richtext.Sanitize(tt.in)
+			if got != tt.want {
+				t.Errorf("Sanitize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+			if again := richtext.Sanitize(got); again != got {
+				t.Errorf("sanitizing again changed %q to %q", got, again)
+			}
+		})
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	in := `<h2>Care</h2><p>Wash at 30&deg;C</p><style>p{}</style><ul><li>Cotton</li><li>Linen</li></ul>`
+	want := "Care Wash at 30°C Cotton Linen"
+	if got := richtext.PlainText(in); got != want {
+		t.Errorf("PlainText() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,131 @@
+// Package run manages the background workers a service runs alongside its
+// servers, such as event dispatch, import jobs and periodic checks, so that
+// they all stop cleanly when the service shuts down.
+package run
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Worker is a background task a service runs until it shuts down
+type Worker interface {
+	// Start starts the worker in the background
+	Start()
+	// Stop stops the worker taking new work and waits for the work in
+	// flight to finish. Once ctx is done, work still running is told to
+	// give up, leaving it to be picked up again, and Stop returns ctx's
+	// error when it has.
+	Stop(ctx context.Context) error
+}
+
+type namedWorker struct {
+	name   string
+	worker Worker
+}
+
+// Group runs a service's workers and stops them together at shutdown
+type Group struct {
+	logger  *logrus.Logger
+	mu      sync.Mutex
+	workers []namedWorker
+}
+
+// NewGroup creates an empty group of workers
+func NewGroup(logger *logrus.Logger) *Group {
+	return &Group{logger: logger}
+}
+
+// Add starts a worker and keeps it to be stopped with the group
+func (g *Group) Add(name string, worker Worker) {
+	g.mu.Lock()
+	g.workers = append(g.workers, namedWorker{name: name, worker: worker})
+	g.mu.Unlock()
+
+	worker.Start()
+}
+
+// Every adds a worker running task every interval. A non-positive interval
+// disables the task.
+func (g *Group) Every(name string, interval time.Duration, task func(ctx context.Context)) {
+	if interval <= 0 {
+		return
+	}
+	g.Add(name, &periodic{interval: interval, task: task})
+}
+
+// Stop stops the workers in the reverse of the order they were added, so a
+// worker stops before those it depends on, such as the event bus it
+// publishes to. Every worker is waited for until ctx is done. Workers that
+// could not finish their work in time are logged and returned as an error.
+func (g *Group) Stop(ctx context.Context) error {
+	g.mu.Lock()
+	workers := g.workers
+	g.workers = nil
+	g.mu.Unlock()
+
+	var unfinished []string
+	for i := len(workers) - 1; i >= 0; i-- {
+		w := workers[i]
+		if err := w.worker.Stop(ctx); err != nil {
+			g.logger.WithError(err).WithField("worker", w.name).Warn("Worker stopped before finishing its work")
+			unfinished = append(unfinished, w.name)
+			continue
+		}
+		g.logger.WithField("worker", w.name).Info("Worker stopped")
+	}
+
+	if len(unfinished) > 0 {
+		return fmt.Errorf("workers stopped before finishing their work: %v", unfinished)
+	}
+	return nil
+}
+
+// periodic runs a task on a ticker. Stopping it lets a run in progress
+// finish, and cancels the run's context only once the stop deadline passes.
+type periodic struct {
+	interval time.Duration
+	task     func(ctx context.Context)
+	stop     chan struct{}
+	done     chan struct{}
+	cancel   context.CancelFunc
+}
+
+func (p *periodic) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+	p.cancel = cancel
+
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+				p.task(ctx)
+			}
+		}
+	}()
+}
+
+func (p *periodic) Stop(ctx context.Context) error {
+	defer p.cancel()
+	close(p.stop)
+
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		<-p.done
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,242 @@
+// Package schedule runs recurring tasks on cron schedules. Every replica of
+// a service runs a scheduler, but only the one holding the leader lock in
+// Redis runs the tasks, so each occurrence runs once however many replicas
+// there are.
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+// renewLock extends the leader lock if this replica still holds it
+var renewLock = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseLock gives up the leader lock if this replica still holds it
+var releaseLock = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// Task is a recurring task. Errors are logged; the task runs again at its
+// next occurrence either way.
+type Task func(ctx context.Context) error
+
+type task struct {
+	name     string
+	schedule *Cron
+	run      Task
+}
+
+// Scheduler runs tasks on cron schedules while its replica is the leader.
+// It is a run.Worker: stopping it lets running tasks finish, cancelling
+// their context once the stop deadline passes, and hands leadership over.
+// Occurrences missed while a task was still running, or while no replica
+// was leader, are skipped rather than made up.
+type Scheduler struct {
+	redis  redis.UniversalClient
+	key    string
+	id     string
+	ttl    time.Duration
+	logger *logrus.Logger
+	tasks  []task
+	leader atomic.Bool
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+
+	// ctx is cancelled when stopping runs out of time, interrupting the
+	// tasks still running
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// New creates a scheduler competing for the leader lock of the named
+// service. The lock lasts ttl without renewal, so a leader that dies
+// without releasing it is replaced within ttl.
+func New(client redis.UniversalClient, service string, ttl time.Duration, logger *logrus.Logger) *Scheduler {
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		redis:  client,
+		key:    fmt.Sprintf("schedule:%s:leader", service),
+		id:     uuid.New().String(),
+		ttl:    ttl,
+		logger: logger,
+		stop:   make(chan struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Add adds a task run on a cron schedule; see Parse. An empty schedule
+// disables the task. Tasks must be added before the scheduler starts.
+func (s *Scheduler) Add(name, spec string, run Task) error {
+	if spec == "" {
+		return nil
+	}
+	schedule, err := Parse(spec)
+	if err != nil {
+		return fmt.Errorf("invalid schedule for %s: %w", name, err)
+	}
+	s.tasks = append(s.tasks, task{name: name, schedule: schedule, run: run})
+	return nil
+}
+
+// Start starts competing for leadership and running the tasks
+func (s *Scheduler) Start() {
+	if len(s.tasks) == 0 {
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.lead()
+	}()
+
+	for _, t := range s.tasks {
+		s.wg.Add(1)
+		go func(t task) {
+			defer s.wg.Done()
+			s.loop(t)
+		}(t)
+	}
+}
+
+// Stop stops scheduling tasks and waits for running ones to finish, or until
+// ctx is done, when they are interrupted. Leadership is released so another
+// replica takes over straight away.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	close(s.stop)
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.cancel()
+		<-done
+		err = ctx.Err()
+	}
+	s.cancel()
+
+	if s.leader.Load() {
+		release := context.WithoutCancel(ctx)
+		if releaseErr := releaseLock.Run(release, s.redis, []string{s.key}, s.id).Err(); releaseErr != nil {
+			s.logger.WithError(releaseErr).Warn("Failed to release scheduler leadership")
+		}
+	}
+	return err
+}
+
+// lead takes the leader lock when it is free and keeps renewing it while
+// held, checking a few times per lock lifetime
+func (s *Scheduler) lead() {
+	ticker := time.NewTicker(s.ttl / 3)
+	defer ticker.Stop()
+	for {
+		s.campaign()
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// campaign renews the leader lock, or tries to take it, and records
+// whether this replica leads
+func (s *Scheduler) campaign() {
+	ctx, cancel := context.WithTimeout(s.ctx, s.ttl/3)
+	defer cancel()
+
+	var leader bool
+	if s.leader.Load() {
+		renewed, err := renewLock.Run(ctx, s.redis, []string{s.key}, s.id, s.ttl.Milliseconds()).Int()
+		if err != nil {
+			s.logger.WithError(err).Warn("Failed to renew scheduler leadership")
+		}
+		leader = err == nil && renewed == 1
+	} else {
+		acquired, err := s.redis.SetNX(ctx, s.key, s.id, s.ttl).Result()
+		if err != nil {
+			s.logger.WithError(err).Warn("Failed to acquire scheduler leadership")
+		}
+		leader = err == nil && acquired
+	}
+
+	if previous := s.leader.Swap(leader); previous != leader {
+		if leader {
+			s.logger.Info("Became scheduler leader")
+		} else {
+			s.logger.Warn("Lost scheduler leadership")
+		}
+	}
+}
+
+// loop runs a task at each occurrence of its schedule while leading
+func (s *Scheduler) loop(t task) {
+	logger := s.logger.WithField("task", t.name)
+	for {
+		next := t.schedule.Next(time.Now())
+		if next.IsZero() {
+			logger.Warn("Schedule never matches; task disabled")
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if !s.leader.Load() {
+			continue
+		}
+		s.run(t, logger)
+	}
+}
+
+// run runs one occurrence of a task, turning a panic into a failure
+func (s *Scheduler) run(t task, logger *logrus.Entry) {
+	start := time.Now()
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("task panicked: %v", r)
+			}
+		}()
+		return t.run(s.ctx)
+	}()
+
+	logger = logger.WithField("duration_ms", time.Since(start).Milliseconds())
+	if err != nil {
+		logger.WithError(err).Error("Scheduled task failed")
+		return
+	}
+	logger.Debug("Scheduled task finished")
+}
@@ -0,0 +1,136 @@
+package tenant
+
+import (
+	"context"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// pluginName is the name the plugin is registered under, as a plugin and
+// as a callback
+const pluginName = "tenant:scope"
+
+// field is the model field holding a row's tenant
+const field = "TenantID"
+
+// Parent is implemented by models without a tenant of their own, which
+// belong to the tenant of the row they hang off, such as the stock
+// movements of a product. TenantParent names the model's column referring
+// to that row, and the table it is in, whose rows have a tenant_id.
+type Parent interface {
+	TenantParent() (column, table string)
+}
+
+// Scope is a GORM plugin limiting the statements of a context scoped to a
+// tenant to that tenant's rows. Models with a TenantID field are scoped by
+// it, and models implementing Parent by their parent's. New rows are
+// written to the context's tenant, and upserts never update another
+// tenant's row. Statements in system contexts are left alone, other than
+// new rows without a tenant going to Default.
+//
+// Raw SQL is not rewritten; it must limit itself with Filter.
+type Scope struct{}
+
+// Name implements gorm.Plugin
+func (Scope) Name() string {
+	return pluginName
+}
+
+// Initialize implements gorm.Plugin
+func (Scope) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Query().Before("gorm:query").Register(pluginName, scope); err != nil {
+		return err
+	}
+	if err := db.Callback().Row().Before("gorm:row").Register(pluginName, scope); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register(pluginName, scope); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().Before("gorm:delete").Register(pluginName, scope); err != nil {
+		return err
+	}
+	return db.Callback().Create().Before("gorm:create").Register(pluginName, assign)
+}
+
+// Filter returns a condition limiting the rows of a table, or of a table's
+// alias, to the tenant ctx is scoped to, for raw SQL the plugin can't scope.
+// Pass it as the argument of a ? placeholder. It is TRUE in system
+// contexts.
+func Filter(ctx context.Context, table string) clause.Expr {
+	id, ok := FromContext(ctx)
+	if !ok {
+		return clause.Expr{SQL: "TRUE"}
+	}
+	return clause.Expr{SQL: "?.tenant_id = ?", Vars: []interface{}{clause.Table{Name: table}, id}}
+}
+
+// scope adds the tenant's condition to a query, update or delete
+func scope(db *gorm.DB) {
+	stmt := db.Statement
+	if stmt.Schema == nil || stmt.SQL.Len() > 0 {
+		return
+	}
+	id, ok := FromContext(stmt.Context)
+	if !ok {
+		return
+	}
+
+	if f := stmt.Schema.LookUpField(field); f != nil && f.DBName != "" {
+		stmt.AddClause(clause.Where{Exprs: []clause.Expression{
+			clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: f.DBName}, Value: id},
+		}})
+		return
+	}
+	if parent, ok := reflect.New(stmt.Schema.ModelType).Interface().(Parent); ok {
+		column, table := parent.TenantParent()
+		stmt.AddClause(clause.Where{Exprs: []clause.Expression{clause.Expr{
+			SQL:  "? IN (SELECT id FROM ? WHERE tenant_id = ?)",
+			Vars: []interface{}{clause.Column{Table: clause.CurrentTable, Name: column}, clause.Table{Name: table}, id},
+		}}})
+	}
+}
+
+// assign writes new rows to the tenant of the context, and stops an upsert
+// from updating a row of another tenant that holds the same key
+func assign(db *gorm.DB) {
+	stmt := db.Statement
+	if stmt.Schema == nil || stmt.SQL.Len() > 0 {
+		return
+	}
+	f := stmt.Schema.LookUpField(field)
+	if f == nil || f.DBName == "" {
+		return
+	}
+	id, scoped := FromContext(stmt.Context)
+
+	set := func(row reflect.Value) {
+		if _, zero := f.ValueOf(stmt.Context, row); scoped || zero {
+			db.AddError(f.Set(stmt.Context, row, ID(stmt.Context)))
+		}
+	}
+	switch value := stmt.ReflectValue; value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			set(reflect.Indirect(value.Index(i)))
+		}
+	case reflect.Struct:
+		set(value)
+	}
+
+	if !scoped {
+		return
+	}
+	if c, ok := stmt.Clauses["ON CONFLICT"]; ok {
+		if onConflict, ok := c.Expression.(clause.OnConflict); ok && !onConflict.DoNothing {
+			onConflict.Where.Exprs = append(onConflict.Where.Exprs, clause.Eq{
+				Column: clause.Column{Table: stmt.Table, Name: f.DBName},
+				Value:  id,
+			})
+			c.Expression = onConflict
+			stmt.Clauses["ON CONFLICT"] = c
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Package tenant scopes the catalog to the tenant a request is made for.
+// Every request is scoped to exactly one tenant; the GORM plugin in this
+// package then limits each query to that tenant's rows. Contexts that carry
+// no tenant, such as those of background workers, are system contexts and
+// see every tenant.
+package tenant
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+
+	"ecommerce/pkg/auth"
+	"ecommerce/pkg/errors"
+	"ecommerce/pkg/response"
+)
+
+// Default is the tenant of rows written before tenancy, and of requests
+// that name no tenant
+const Default = "default"
+
+// Header names the tenant of an anonymous request, such as a storefront
+// page, and of calls between services
+const Header = "X-Tenant-ID"
+
+// pattern is what tenant IDs look like, so they are safe in cache keys
+var pattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// Valid reports whether id is a well-formed tenant ID
+func Valid(id string) bool {
+	return pattern.MatchString(id)
+}
+
+type tenantKey struct{}
+
+// WithID returns a copy of ctx scoped to a tenant
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// FromContext returns the tenant ctx is scoped to, and false for system
+// contexts, which see every tenant
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(tenantKey{}).(string)
+	return id, ok
+}
+
+// ID returns the tenant ctx is scoped to, or Default for system contexts,
+// for rows a system context writes
+func ID(ctx context.Context) string {
+	if id, ok := FromContext(ctx); ok {
+		return id
+	}
+	return Default
+}
+
+// Resolve returns the tenant of a caller: the one their credentials were
+// issued for, or Default when the credentials name none
+func Resolve(actor *auth.Actor) string {
+	if actor == nil || actor.Tenant == "" {
+		return Default
+	}
+	return actor.Tenant
+}
+
+// Middleware scopes every request to a tenant. Authenticated requests are
+// scoped to the actor's tenant whatever they ask for; anonymous ones to the
+// tenant named by the X-Tenant-ID header, or Default. It must run after
+// auth.Middleware.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := Default
+		if actor := auth.ActorFromContext(c.Request.Context()); actor != nil {
+			id = Resolve(actor)
+		} else if requested := c.GetHeader(Header); requested != "" {
+			if !Valid(requested) {
+				err := errors.NewValidationError("Invalid tenant ID", nil).WithCode(errors.CodeInvalidTenant)
+				response.Error(c, http.StatusBadRequest, "Invalid tenant ID", err)
+				c.Abort()
+				return
+			}
+			id = requested
+		}
+
+		c.Request = c.Request.WithContext(WithID(c.Request.Context(), id))
+		c.Next()
+	}
+}
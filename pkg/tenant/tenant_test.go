@@ -0,0 +1,132 @@
+package tenant_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	gormlogger "gorm.io/gorm/logger"
+
+	"ecommerce/pkg/tenant"
+)
+
+type product struct {
+	ID       string
+	TenantID string
+	SKU      string
+}
+
+type movement struct {
+	ID        string
+	ProductID string
+}
+
+func (movement) TenantParent() (string, string) {
+	return "product_id", "products"
+}
+
+// dryRun opens a database that builds statements without running them
+func dryRun(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true, Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(tenant.Scope{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestScope(t *testing.T) {
+	db := dryRun(t)
+	ctx := tenant.WithID(context.Background(), "acme")
+
+	tests := []struct {
+		name string
+		stmt *gorm.DB
+		want string
+		vars []interface{}
+	}{
+		{
+			name: "query",
+			stmt: db.WithContext(ctx).Where("sku = ?", "A-1").Find(&[]product{}),
+			want: `SELECT * FROM "products" WHERE sku = $1 AND "products"."tenant_id" = $2`,
+			vars: []interface{}{"A-1", "acme"},
+		},
+		{
+			name: "child query",
+			stmt: db.WithContext(ctx).Where("product_id = ?", "p1").Find(&[]movement{}),
+			want: `SELECT * FROM "movements" WHERE product_id = $1 AND "movements"."product_id" IN (SELECT id FROM "products" WHERE tenant_id = $2)`,
+			vars: []interface{}{"p1", "acme"},
+		},
+		{
+			name: "delete",
+			stmt: db.WithContext(ctx).Delete(&product{}, "id = ?", "p1"),
+			want: `DELETE FROM "products" WHERE id = $1 AND "products"."tenant_id" = $2`,
+			vars: []interface{}{"p1", "acme"},
+		},
+		{
+			name: "system context",
+			stmt: db.Where("sku = ?", "A-1").Find(&[]product{}),
+			want: `SELECT * FROM "products" WHERE sku = $1`,
+			vars: []interface{}{"A-1"},
+		},
+		{
+			name: "raw filter",
+			stmt: db.Raw("SELECT id FROM products p WHERE p.sku = ? AND ?", "A-1", tenant.Filter(ctx, "p")).Scan(&[]string{}),
+			want: `SELECT id FROM products p WHERE p.sku = $1 AND "p".tenant_id = $2`,
+			vars: []interface{}{"A-1", "acme"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stmt.Statement.SQL.String(); got != tt.want {
+				t.Errorf("SQL = %s\nwant  %s", got, tt.want)
+			}
+			if got := tt.stmt.Statement.Vars; !equal(got, tt.vars) {
+				t.Errorf("vars = %v, want %v", got, tt.vars)
+			}
+		})
+	}
+}
+
+// TestAssign writes new rows to the context's tenant and keeps upserts to
+// rows of the same tenant
+func TestAssign(t *testing.T) {
+	db := dryRun(t)
+	ctx := tenant.WithID(context.Background(), "acme")
+
+	row := product{ID: "p1", TenantID: "other", SKU: "A-1"}
+	stmt := db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "sku"}},
+		DoUpdates: clause.AssignmentColumns([]string{"sku"}),
+	}).Create(&row)
+	if row.TenantID != "acme" {
+		t.Errorf("TenantID = %q, want acme", row.TenantID)
+	}
+	if sql := strings.TrimSpace(stmt.Statement.SQL.String()); !strings.HasSuffix(sql, `DO UPDATE SET "sku"="excluded"."sku" WHERE "products"."tenant_id" = $4`) {
+		t.Errorf("upsert may update another tenant's row: %s", sql)
+	}
+
+	system := product{ID: "p2"}
+	db.Create(&system)
+	if system.TenantID != tenant.Default {
+		t.Errorf("TenantID = %q, want %q", system.TenantID, tenant.Default)
+	}
+}
+
+func equal(a, b []interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
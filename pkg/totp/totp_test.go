@@ -0,0 +1,60 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"ecommerce/pkg/totp"
+)
+
+// TestCode checks the SHA1 test vectors of RFC 6238, appendix B, cut to
+// six digits
+func TestCode(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, v := range vectors {
+		code, err := totp.Code(secret, totp.Step(time.Unix(v.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != v.code {
+			t.Errorf("Code at %d = %s, want %s", v.unix, code, v.code)
+		}
+	}
+}
+
+// TestValidate accepts codes from neighbouring steps within the skew and
+// reports the step that matched
+func TestValidate(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+
+	previous, err := totp.Code(secret, totp.Step(now)-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if step, ok := totp.Validate(secret, previous, now, 1); !ok || step != totp.Step(now)-1 {
+		t.Errorf("Validate(previous step) = %d, %v", step, ok)
+	}
+	if _, ok := totp.Validate(secret, previous, now, 0); ok {
+		t.Error("Validate accepted a code outside the skew")
+	}
+	if _, ok := totp.Validate(secret, "12345", now, 1); ok {
+		t.Error("Validate accepted a short code")
+	}
+}
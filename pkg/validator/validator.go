@@ -1,10 +1,14 @@
 package validator
 
 import (
+	stderrors "errors"
+	"fmt"
 	"reflect"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
+
+	"ecommerce/pkg/errors"
 )
 
 // Validator wraps the go-playground validator
@@ -12,6 +16,12 @@ type Validator struct {
 	validate *validator.Validate
 }
 
+// Errors is returned when validation fails. Besides the usual message it
+// reports each rejected field, keyed by its JSON path.
+type Errors struct {
+	errs validator.ValidationErrors
+}
+
 // New creates a new validator instance
 func New() *Validator {
 	validate := validator.New()
@@ -30,10 +40,94 @@ func New() *Validator {
 
 // Validate validates a struct
 func (v *Validator) Validate(i interface{}) error {
-	return v.validate.Struct(i)
+	return wrap(v.validate.Struct(i))
 }
 
 // ValidateVar validates a single variable
 func (v *Validator) ValidateVar(field interface{}, tag string) error {
-	return v.validate.Var(field, tag)
+	return wrap(v.validate.Var(field, tag))
+}
+
+func (e *Errors) Error() string {
+	return e.errs.Error()
+}
+
+func (e *Errors) Unwrap() error {
+	return e.errs
+}
+
+// FieldErrors lists the rejected fields, each with the failed rule as its
+// code and a message
+func (e *Errors) FieldErrors() []errors.FieldError {
+	fields := make([]errors.FieldError, len(e.errs))
+	for i, fe := range e.errs {
+		fields[i] = errors.FieldError{
+			Field:   fieldPath(fe),
+			Code:    fe.Tag(),
+			Message: message(fe),
+		}
+	}
+	return fields
+}
+
+// wrap turns validation failures into Errors, passing anything else through
+func wrap(err error) error {
+	var errs validator.ValidationErrors
+	if stderrors.As(err, &errs) {
+		return &Errors{errs: errs}
+	}
+	return err
+}
+
+// fieldPath is the JSON path of a field, such as attributes[0].value,
+// without the name of the validated struct
+func fieldPath(fe validator.FieldError) string {
+	namespace := fe.Namespace()
+	if _, path, ok := strings.Cut(namespace, "."); ok {
+		return path
+	}
+	return namespace
+}
+
+// message describes a failed rule in words
+func message(fe validator.FieldError) string {
+	param := fe.Param()
+
+	var size string
+	switch fe.Kind() {
+	case reflect.String:
+		size = " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		size = " items"
+	}
+
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		return fmt.Sprintf("must be at least %s%s", param, size)
+	case "max":
+		return fmt.Sprintf("must be at most %s%s", param, size)
+	case "len":
+		return fmt.Sprintf("must be exactly %s%s", param, size)
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", param)
+	case "gte":
+		return fmt.Sprintf("must be at least %s", param)
+	case "lt":
+		return fmt.Sprintf("must be less than %s", param)
+	case "lte":
+		return fmt.Sprintf("must be at most %s", param)
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", strings.Join(strings.Fields(param), ", "))
+	case "email":
+		return "must be a valid email address"
+	case "url":
+		return "must be a valid URL"
+	case "uuid":
+		return "must be a valid UUID"
+	case "e164":
+		return "must be a phone number in E.164 format"
+	}
+	return fmt.Sprintf("failed the %s rule", fe.Tag())
 }